@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+)
+
+// benchmarkTimeout bounds hashcat's benchmark of one hash mode; slow
+// modes such as bcrypt take a minute or two
+const benchmarkTimeout = 5 * time.Minute
+
+// checkForBenchmarkJob runs the benchmark job the server queued for this
+// agent, if any. Benchmark jobs go before cracking jobs, so the devices
+// are idle while they are measured.
+func (a *Agent) checkForBenchmarkJob() (bool, error) {
+	job, err := a.API.ClaimBenchmarkJob(context.Background(), a.ID)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	infrastructure.AgentLogger.Info("Running benchmark job %s for hash modes %v", job.ID, job.HashTypes)
+	a.updateStatus("busy")
+	defer a.updateStatus("online")
+
+	report := a.runBenchmarkJob(job)
+	if err := a.API.ReportBenchmarkJob(context.Background(), a.ID, job.ID, report); err != nil {
+		return true, fmt.Errorf("failed to report benchmark job %s: %w", job.ID, err)
+	}
+	infrastructure.AgentLogger.Success("Benchmark job %s reported: %d of %d hash modes measured",
+		job.ID, len(report.Speeds), len(job.HashTypes))
+	return true, nil
+}
+
+// runBenchmarkJob benchmarks the job's hash modes one by one, so a mode
+// hashcat doesn't know doesn't cost the others
+func (a *Agent) runBenchmarkJob(job *domain.BenchmarkJob) client.BenchmarkReport {
+	report := client.BenchmarkReport{Speeds: make(map[int]int64)}
+	hashcatPath := a.Settings.Get().HashcatPath
+	if _, err := exec.LookPath(hashcatPath); err != nil {
+		report.Error = fmt.Sprintf("hashcat not found: %v", err)
+		return report
+	}
+
+	var failures []string
+	for _, hashType := range job.HashTypes {
+		speed, err := benchmarkHashMode(hashcatPath, hashType)
+		if err != nil {
+			infrastructure.AgentLogger.Warning("Benchmark of hash mode %d failed: %v", hashType, err)
+			failures = append(failures, fmt.Sprintf("mode %d: %v", hashType, err))
+			continue
+		}
+		infrastructure.AgentLogger.Info("Hash mode %d: %d H/s", hashType, speed)
+		report.Speeds[hashType] = speed
+	}
+	report.Error = strings.Join(failures, "; ")
+	return report
+}
+
+// benchmarkHashMode runs hashcat's benchmark of one hash mode and returns
+// the combined speed of all devices in H/s
+func benchmarkHashMode(hashcatPath string, hashType int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), benchmarkTimeout)
+	defer cancel()
+
+	args := append([]string{"-b", "-m", strconv.Itoa(hashType), "--machine-readable"},
+		domain.HashcatCompatArgs(hashcatVersionOf(hashcatPath), hashType)...)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, hashcatPath, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+
+	if speed := parseBenchmarkOutput(stdout.Bytes(), hashType); speed > 0 {
+		return speed, nil
+	}
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("timed out after %s", benchmarkTimeout)
+	}
+	if runErr != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return 0, fmt.Errorf("%w: %s", runErr, lastLine(message))
+		}
+		return 0, runErr
+	}
+	return 0, fmt.Errorf("no speed in hashcat's output")
+}
+
+// parseBenchmarkOutput adds up the device speeds of a hash mode in
+// hashcat's --machine-readable benchmark output, whose lines read
+// device:mode:core clock:memory clock:run time ms:H/s
+func parseBenchmarkOutput(output []byte, hashType int) int64 {
+	var total int64
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) != 6 {
+			continue
+		}
+		if mode, err := strconv.Atoi(fields[1]); err != nil || mode != hashType {
+			continue
+		}
+		if speed, err := strconv.ParseInt(fields[5], 10, 64); err == nil && speed > 0 {
+			total += speed
+		}
+	}
+	return total
+}
+
+// lastLine is the last line of hashcat's error output, which says what went
+// wrong
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const cacheIndexFile = "index.json"
+
+// downloadCache keeps files downloaded from the server under
+// <upload-dir>/cache/<kind>/<id> and evicts the least recently used ones
+// once the total size exceeds the limit. Files pinned by a running job are
+// never evicted.
+type downloadCache struct {
+	dir   string
+	limit int64 // bytes, 0 = unlimited
+
+	mu      sync.Mutex
+	entries map[string]*domain.AgentCacheEntry // kind/id -> entry
+	pinned  map[string]int
+	version int64 // bumped on every change, used to decide when to report
+}
+
+// cacheKinds are the kinds of files the cache holds, one directory each
+var cacheKinds = []string{"wordlist", "hash_file", "charset"}
+
+func newDownloadCache(dir string, limit int64) (*downloadCache, error) {
+	c := &downloadCache{
+		dir:     dir,
+		limit:   limit,
+		entries: make(map[string]*domain.AgentCacheEntry),
+		pinned:  make(map[string]int),
+	}
+	for _, kind := range cacheKinds {
+		if err := os.MkdirAll(filepath.Join(dir, kind), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func cacheKey(kind string, id uuid.UUID) string {
+	return kind + "/" + id.String()
+}
+
+func (c *downloadCache) path(kind string, id uuid.UUID) string {
+	return filepath.Join(c.dir, kind, id.String())
+}
+
+// load restores the index from disk, dropping entries whose file is gone
+// and deleting files the index doesn't know about (e.g. interrupted
+// downloads)
+func (c *downloadCache) load() error {
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheIndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read cache index: %w", err)
+	}
+
+	var entries []domain.AgentCacheEntry
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			infrastructure.AgentLogger.Warning("Cache index is corrupt, starting empty: %v", err)
+			entries = nil
+		}
+	}
+
+	for i := range entries {
+		entry := entries[i]
+		info, err := os.Stat(c.path(entry.Kind, entry.ID))
+		if err != nil || info.Size() != entry.Size {
+			continue
+		}
+		c.entries[cacheKey(entry.Kind, entry.ID)] = &entry
+	}
+
+	for _, kind := range cacheKinds {
+		files, err := os.ReadDir(filepath.Join(c.dir, kind))
+		if err != nil {
+			return fmt.Errorf("failed to read cache directory: %w", err)
+		}
+		for _, f := range files {
+			id, err := uuid.Parse(f.Name())
+			if err == nil {
+				if _, ok := c.entries[cacheKey(kind, id)]; ok {
+					continue
+				}
+			}
+			os.Remove(filepath.Join(c.dir, kind, f.Name()))
+		}
+	}
+
+	c.evictLocked()
+	return c.saveLocked()
+}
+
+// saveLocked writes the index atomically. Caller holds c.mu.
+func (c *downloadCache) saveLocked() error {
+	entries := make([]domain.AgentCacheEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, *entry)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(c.dir, cacheIndexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(c.dir, cacheIndexFile))
+}
+
+// Get returns the cached file for kind/id. When the server sent a checksum
+// that doesn't match the cached copy, the entry is dropped and Get misses.
+func (c *downloadCache) Get(kind string, id uuid.UUID, sum string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(kind, id)
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	path := c.path(kind, id)
+	if info, err := os.Stat(path); err != nil || info.Size() != entry.Size {
+		c.removeLocked(key)
+		return "", false
+	}
+	if sum != "" && !strings.EqualFold(entry.SHA256, sum) {
+		infrastructure.AgentLogger.Warning("Cached %s %s is out of date, discarding", kind, id)
+		c.removeLocked(key)
+		return "", false
+	}
+
+	entry.LastUsed = time.Now()
+	c.version++
+	c.saveLocked()
+	return path, true
+}
+
+// Store streams r into the cache. The data is written to a temporary file
+// and renamed into place, so a crash mid-download never leaves a truncated
+// file under the final name.
+func (c *downloadCache) Store(kind string, id uuid.UUID, name string, r io.Reader) (string, *domain.AgentCacheEntry, error) {
+	tmp, err := os.CreateTemp(filepath.Join(c.dir, kind), ".download-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(kind, id)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", nil, fmt.Errorf("failed to move download into cache: %w", err)
+	}
+
+	entry := &domain.AgentCacheEntry{
+		Kind:     kind,
+		ID:       id,
+		Name:     name,
+		Size:     size,
+		SHA256:   hex.EncodeToString(hasher.Sum(nil)),
+		LastUsed: time.Now(),
+	}
+	c.entries[cacheKey(kind, id)] = entry
+	c.version++
+
+	c.evictLocked()
+	if err := c.saveLocked(); err != nil {
+		infrastructure.AgentLogger.Warning("Failed to save cache index: %v", err)
+	}
+
+	copied := *entry
+	return path, &copied, nil
+}
+
+// Remove drops a cached file, e.g. after it failed verification
+func (c *downloadCache) Remove(kind string, id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(cacheKey(kind, id))
+	c.saveLocked()
+}
+
+func (c *downloadCache) removeLocked(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	os.Remove(c.path(entry.Kind, entry.ID))
+	delete(c.entries, key)
+	c.version++
+}
+
+// Pin protects kind/id from eviction until the returned func is called
+func (c *downloadCache) Pin(kind string, id uuid.UUID) func() {
+	key := cacheKey(kind, id)
+
+	c.mu.Lock()
+	c.pinned[key]++
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.pinned[key]--; c.pinned[key] <= 0 {
+			delete(c.pinned, key)
+		}
+		c.evictLocked()
+		c.saveLocked()
+	}
+}
+
+// SetLimit changes the size limit, evicting entries when it shrank
+func (c *downloadCache) SetLimit(limit int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit == c.limit {
+		return
+	}
+	c.limit = limit
+	c.version++
+	c.evictLocked()
+	c.saveLocked()
+}
+
+// Free evicts least recently used, unpinned entries until at least need
+// bytes are released, whatever the limit. It returns the bytes released.
+func (c *downloadCache) Free(need int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	candidates := make([]*domain.AgentCacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if c.pinned[key] == 0 {
+			candidates = append(candidates, entry)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+
+	var freed int64
+	for _, entry := range candidates {
+		if freed >= need {
+			break
+		}
+		infrastructure.AgentLogger.Info("Evicting cached %s %s (%s) to make room", entry.Kind, entry.Name, formatFileSize(entry.Size))
+		freed += entry.Size
+		c.removeLocked(cacheKey(entry.Kind, entry.ID))
+	}
+	if freed > 0 {
+		c.saveLocked()
+	}
+	return freed
+}
+
+// Clear removes every unpinned entry. It returns how many files it removed,
+// the bytes released and how many pinned files it kept.
+func (c *downloadCache) Clear() (removed int, freed int64, kept int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if c.pinned[key] > 0 {
+			kept++
+			continue
+		}
+		removed++
+		freed += entry.Size
+		c.removeLocked(key)
+	}
+	if removed > 0 {
+		c.saveLocked()
+	}
+	return removed, freed, kept
+}
+
+// evictLocked removes least recently used, unpinned entries until the
+// cache fits in its limit. Caller holds c.mu.
+func (c *downloadCache) evictLocked() {
+	if c.limit <= 0 {
+		return
+	}
+
+	var used int64
+	candidates := make([]*domain.AgentCacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		used += entry.Size
+		if c.pinned[key] == 0 {
+			candidates = append(candidates, entry)
+		}
+	}
+	if used <= c.limit {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+	for _, entry := range candidates {
+		if used <= c.limit {
+			break
+		}
+		infrastructure.AgentLogger.Info("Evicting cached %s %s (%s)", entry.Kind, entry.Name, formatFileSize(entry.Size))
+		used -= entry.Size
+		c.removeLocked(cacheKey(entry.Kind, entry.ID))
+	}
+}
+
+// Report returns the cache status sent to the server, plus the version it
+// reflects
+func (c *downloadCache) Report() (*domain.AgentCacheReport, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &domain.AgentCacheReport{
+		LimitBytes: c.limit,
+		Entries:    make([]domain.AgentCacheEntry, 0, len(c.entries)),
+		ReportedAt: time.Now(),
+	}
+	for _, entry := range c.entries {
+		report.UsedBytes += entry.Size
+		report.Entries = append(report.Entries, *entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return report.Entries[i].LastUsed.After(report.Entries[j].LastUsed)
+	})
+	return report, c.version
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeAged caches a file of size bytes last used age ago
+func storeAged(t *testing.T, c *downloadCache, name string, size int, age time.Duration) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, _, err := c.Store("wordlist", id, name, strings.NewReader(strings.Repeat("x", size)))
+	require.NoError(t, err)
+	c.mu.Lock()
+	c.entries[cacheKey("wordlist", id)].LastUsed = time.Now().Add(-age)
+	c.mu.Unlock()
+	return id
+}
+
+func cachedNames(c *downloadCache) []string {
+	report, _ := c.Report()
+	names := make([]string, len(report.Entries))
+	for i, entry := range report.Entries {
+		names[i] = entry.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDownloadCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := newDownloadCache(t.TempDir(), 0)
+	require.NoError(t, err)
+	oldest := storeAged(t, c, "oldest", 100, 3*time.Hour)
+	storeAged(t, c, "older", 100, 2*time.Hour)
+	storeAged(t, c, "recent", 100, time.Hour)
+
+	// Using a file makes it the most recent
+	_, ok := c.Get("wordlist", oldest, "")
+	require.True(t, ok)
+
+	c.SetLimit(250)
+	assert.Equal(t, []string{"oldest", "recent"}, cachedNames(c))
+
+	c.SetLimit(100)
+	assert.Equal(t, []string{"oldest"}, cachedNames(c))
+	report, _ := c.Report()
+	assert.Equal(t, int64(100), report.UsedBytes)
+}
+
+func TestDownloadCache_KeepsPinnedFiles(t *testing.T) {
+	c, err := newDownloadCache(t.TempDir(), 0)
+	require.NoError(t, err)
+	pinned := storeAged(t, c, "pinned", 100, 2*time.Hour)
+	storeAged(t, c, "evicted", 100, time.Hour)
+	storeAged(t, c, "newest", 40, 0)
+	unpin := c.Pin("wordlist", pinned)
+
+	// The pinned file is the least recently used, the next one goes instead
+	c.SetLimit(150)
+	assert.Equal(t, []string{"newest", "pinned"}, cachedNames(c))
+
+	// Over the limit while pinned, it goes once the job lets go of it
+	c.SetLimit(50)
+	assert.Equal(t, []string{"pinned"}, cachedNames(c))
+	unpin()
+	assert.Empty(t, cachedNames(c))
+	_, ok := c.Get("wordlist", pinned, "")
+	assert.False(t, ok)
+}
+
+func TestDownloadCache_Reload(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newDownloadCache(dir, 0)
+	require.NoError(t, err)
+	kept := storeAged(t, c, "kept", 100, 2*time.Hour)
+	truncated := storeAged(t, c, "truncated", 100, time.Hour)
+	storeAged(t, c, "evicted", 100, 3*time.Hour)
+	c.mu.Lock()
+	require.NoError(t, c.saveLocked())
+	c.mu.Unlock()
+
+	// A file cut short and one the index doesn't know about are dropped
+	require.NoError(t, os.WriteFile(c.path("wordlist", truncated), []byte("x"), 0644))
+	stray := filepath.Join(dir, "wordlist", ".download-123")
+	require.NoError(t, os.WriteFile(stray, []byte("partial"), 0644))
+
+	// A smaller limit evicts on the way in
+	reloaded, err := newDownloadCache(dir, 150)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept"}, cachedNames(reloaded))
+	assert.NoFileExists(t, stray)
+	path, ok := reloaded.Get("wordlist", kept, "")
+	require.True(t, ok)
+	assert.FileExists(t, path)
+}
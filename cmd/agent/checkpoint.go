@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// jobHandoffTimeout bounds how long shutdown waits for hashcat to write its
+// restore file and exit
+const jobHandoffTimeout = 30 * time.Second
+
+// errJobInterrupted is returned by runJob when the job was stopped for the
+// agent to shut down and handed back to the server
+var errJobInterrupted = errors.New("job interrupted by agent shutdown")
+
+// runningJob is the engine process of the job the agent is running
+type runningJob struct {
+	jobID       uuid.UUID
+	cmd         *exec.Cmd
+	interrupted bool
+	abandoned   bool          // The server took the job back
+	stopReason  string        // Why the watchdog stopped the run, see watchdog.go
+	done        chan struct{} // Closed once runJob has reported the run
+	// Times a restart_watchdog command restarted the watchdog's stall clock
+	watchdogRestarts int
+}
+
+// hashcatSession names the hashcat session of a job
+func hashcatSession(job *domain.Job) string {
+	return "hashcat-" + job.ID.String()
+}
+
+// hashcatRestoreFile is where hashcat keeps the job's restore file, next to
+// the outfile
+func hashcatRestoreFile(outfile string, job *domain.Job) string {
+	return filepath.Join(filepath.Dir(outfile), hashcatSession(job)+".restore")
+}
+
+// trackRun records the engine process of a job so shutdown can interrupt
+// it. The returned func is called once the run is reported.
+func (a *Agent) trackRun(jobID uuid.UUID, cmd *exec.Cmd) func() {
+	run := &runningJob{jobID: jobID, cmd: cmd, done: make(chan struct{})}
+	a.runMu.Lock()
+	a.running = run
+	a.runMu.Unlock()
+
+	return func() {
+		a.runMu.Lock()
+		if a.running == run {
+			a.running = nil
+		}
+		a.runMu.Unlock()
+		close(run.done)
+	}
+}
+
+// wasInterrupted reports whether shutdown stopped the job's run
+func (a *Agent) wasInterrupted(jobID uuid.UUID) bool {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	return a.running != nil && a.running.jobID == jobID && a.running.interrupted
+}
+
+// interruptRunningJob stops the running job, if any, so it can be handed
+// to another agent, and waits until runJob has reported it. hashcat writes
+// its restore file when interrupted; if it doesn't exit in time it is
+// killed and the restore file it last wrote is used.
+func (a *Agent) interruptRunningJob(timeout time.Duration) {
+	a.runMu.Lock()
+	run := a.running
+	if run != nil {
+		run.interrupted = true
+	}
+	a.runMu.Unlock()
+	if run == nil || run.cmd.Process == nil {
+		return
+	}
+
+	logger := infrastructure.AgentLogger.With("job_id", run.jobID)
+	logger.Info("Interrupting job %s to hand it to another agent", run.jobID)
+	if err := interruptProcess(run.cmd.Process); err != nil {
+		logger.Warning("Failed to interrupt engine, killing it: %v", err)
+		run.cmd.Process.Kill()
+	}
+
+	select {
+	case <-run.done:
+	case <-time.After(timeout):
+		logger.Warning("Engine didn't exit within %s, killing it", timeout)
+		run.cmd.Process.Kill()
+		select {
+		case <-run.done:
+		case <-time.After(timeout):
+			logger.Error("Job %s was not handed off", run.jobID)
+		}
+	}
+}
+
+// handOffJob gives an interrupted job back to the server with the restore
+// file hashcat wrote, so another agent resumes it. John jobs start over.
+func (a *Agent) handOffJob(job *domain.Job, engine crackEngine, outfile string) error {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	settings := a.Settings.Get()
+
+	req := domain.InterruptJobRequest{Reason: "agent " + a.Name + " shut down"}
+	if _, ok := engine.(hashcatEngine); ok {
+		data, err := os.ReadFile(hashcatRestoreFile(outfile, job))
+		switch {
+		case err != nil:
+			logger.Warning("No restore file for job %s, it will start over: %v", job.ID, err)
+		case len(data) > domain.MaxCheckpointSize:
+			logger.Warning("Restore file of job %s is too large (%d bytes), it will start over", job.ID, len(data))
+		default:
+			req.Checkpoint = data
+			req.HashcatVersion = hashcatVersionOf(settings.HashcatPath)
+		}
+	}
+
+	path := fmt.Sprintf("/api/v1/jobs/%s/interrupt", job.ID.String())
+	delivered, err := a.report(http.MethodPost, path, req, false)
+	if err != nil {
+		return fmt.Errorf("failed to hand off job: %w", err)
+	}
+	if !delivered {
+		logger.Warning("Server unreachable, handoff of job %s queued for delivery", job.ID)
+		return errJobInterrupted
+	}
+
+	logger.Success("Job %s handed back to the server, checkpoint: %d bytes", job.ID, len(req.Checkpoint))
+	return errJobInterrupted
+}
+
+// resumeArgs returns the hashcat command line that resumes a job from the
+// checkpoint another agent left, or args unchanged when there is none or it
+// can't be used. The restore file holds the previous agent's paths, so its
+// command line and working directory, workDir, are replaced with this
+// agent's before hashcat reads it.
+func (a *Agent) resumeArgs(job *domain.Job, engine crackEngine, binary string, args []string, outfile, workDir string) []string {
+	if _, ok := engine.(hashcatEngine); !ok || job.Checkpoint == nil {
+		return args
+	}
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+
+	restoreFile := hashcatRestoreFile(outfile, job)
+	if err := a.fetchCheckpoint(job, binary, args, restoreFile, workDir); err != nil {
+		logger.Warning("Can't resume job %s from its checkpoint, starting over: %v", job.ID, err)
+		return args
+	}
+
+	logger.Info("Resuming job %s from restore point %d", job.ID, job.Checkpoint.RestorePoint)
+	return []string{"--session", hashcatSession(job), "--restore", "--restore-file-path", restoreFile}
+}
+
+// fetchCheckpoint downloads a job's restore file and rewrites it for the
+// working directory hashcat runs in and this agent's command line
+func (a *Agent) fetchCheckpoint(job *domain.Job, binary string, args []string, restoreFile, workDir string) error {
+	body, err := a.API.DownloadCheckpoint(context.Background(), job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to download checkpoint: %w", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, domain.MaxCheckpointSize+1))
+	if err != nil {
+		return err
+	}
+	restore, err := domain.ParseHashcatRestore(data)
+	if err != nil {
+		return err
+	}
+
+	rewritten, err := restore.Rewrite(workDir, append([]string{binary}, args...))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(restoreFile, rewritten, 0600)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/google/uuid"
+)
+
+// commandQueueSize bounds the commands waiting to run on the agent; more
+// are delivered again with a later heartbeat
+const commandQueueSize = 16
+
+// upgradeTimeout bounds the operator's upgrade command, which may download
+// a release
+const upgradeTimeout = 10 * time.Minute
+
+// upgradeOutputTail is how much of the upgrade command's output is reported
+const upgradeOutputTail = 4 * 1024
+
+// commandRunner queues the commands the server sent for the agent, which
+// runs them one at a time. Heartbeats and polls deliver a command until it
+// is acknowledged, so it remembers the ones it already queued.
+type commandRunner struct {
+	mu    sync.Mutex
+	seen  map[uuid.UUID]bool
+	queue chan domain.AgentCommand
+}
+
+func newCommandRunner() *commandRunner {
+	return &commandRunner{
+		seen:  make(map[uuid.UUID]bool),
+		queue: make(chan domain.AgentCommand, commandQueueSize),
+	}
+}
+
+// deliver queues the commands not seen before
+func (r *commandRunner) deliver(commands []domain.AgentCommand) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, command := range commands {
+		if r.seen[command.ID] {
+			continue
+		}
+		select {
+		case r.queue <- command:
+			r.seen[command.ID] = true
+		default:
+			return // Full, the rest come again
+		}
+	}
+}
+
+// forget lets a command be queued again, after its acknowledgement didn't
+// reach the server
+func (r *commandRunner) forget(id uuid.UUID) {
+	r.mu.Lock()
+	delete(r.seen, id)
+	r.mu.Unlock()
+}
+
+// pollCommands asks the server for commands, for those queued after the
+// last heartbeat reply
+func (a *Agent) pollCommands() {
+	commands, err := a.API.PendingAgentCommands(context.Background(), a.ID)
+	if err != nil {
+		infrastructure.AgentLogger.Debug("Failed to poll for commands: %v", err)
+		return
+	}
+	a.commands.deliver(commands)
+}
+
+// runCommands runs the delivered commands in order until ctx ends
+func (a *Agent) runCommands(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case command := <-a.commands.queue:
+			a.runCommand(command)
+		}
+	}
+}
+
+// runCommand acknowledges a command, runs it and reports the result. A
+// command the server refuses to hand over, as it was cancelled or expired
+// meanwhile, is skipped.
+func (a *Agent) runCommand(command domain.AgentCommand) {
+	logger := infrastructure.AgentLogger.With("command_id", command.ID)
+	if err := a.API.AcknowledgeAgentCommand(context.Background(), a.ID, command.ID); err != nil {
+		if client.IsTemporary(err) {
+			a.commands.forget(command.ID)
+		}
+		logger.Warning("Skipping command %s: %v", command.Type, err)
+		return
+	}
+
+	logger.Info("Running command %s", command.Type)
+	var result domain.AgentCommandResult
+	output, restart, err := a.executeCommand(command)
+	if err != nil {
+		logger.Error("Command %s failed: %v", command.Type, err)
+		result.Error = err.Error()
+	} else {
+		logger.Success("Command %s completed", command.Type)
+	}
+	if output != nil {
+		if result.Result, err = json.Marshal(output); err != nil {
+			result.Error = fmt.Sprintf("failed to encode result: %v", err)
+		}
+	}
+
+	// Results wait in the outbox while the server can't be reached
+	path := fmt.Sprintf("/api/v1/agents/%s/commands/%s/result", a.ID, command.ID)
+	if _, err := a.report(http.MethodPost, path, result, false); err != nil {
+		logger.Error("Failed to report result of command %s: %v", command.Type, err)
+	}
+	if restart {
+		select {
+		case a.restart <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// executeCommand runs one command and returns its result; restart is set
+// when the agent must restart afterwards
+func (a *Agent) executeCommand(command domain.AgentCommand) (result any, restart bool, err error) {
+	switch command.Type {
+	case domain.AgentCommandBenchmark:
+		result, err = a.benchmarkCommand()
+	case domain.AgentCommandClearCache:
+		result, err = a.clearCacheCommand()
+	case domain.AgentCommandUpgrade:
+		result, err = a.upgradeCommand(command.Params)
+		restart = err == nil
+	case domain.AgentCommandCollectDiagnostics:
+		result, err = a.collectDiagnostics(), nil
+	case domain.AgentCommandRestartWatchdog:
+		result, err = a.restartWatchdogCommand()
+	default:
+		err = fmt.Errorf("unknown command %q, the agent may need an upgrade", command.Type)
+	}
+	return result, restart, err
+}
+
+// benchmarkCommand re-runs the startup benchmark and updates the agent's
+// speed. It fails while a job runs, as they would share the devices.
+func (a *Agent) benchmarkCommand() (any, error) {
+	if !a.devicesMu.TryLock() {
+		return nil, fmt.Errorf("agent is busy, try again later")
+	}
+	defer a.devicesMu.Unlock()
+	if job := a.CurrentJob; job != nil {
+		return nil, fmt.Errorf("agent is busy running job %s", job.ID)
+	}
+
+	hashcatPath := a.Settings.Get().HashcatPath
+	if _, err := exec.LookPath(hashcatPath); err != nil {
+		return nil, fmt.Errorf("hashcat not found: %w", err)
+	}
+	a.updateStatus("busy")
+	defer a.updateStatus("online")
+
+	// The startup benchmark measures WPA, see runHashcatBenchmark
+	speed, err := benchmarkHashMode(hashcatPath, 2500)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.updateAgentSpeed(speed); err != nil {
+		return nil, err
+	}
+	return map[string]int64{"hash_type": 2500, "speed": speed}, nil
+}
+
+// clearCacheCommand empties the download cache. Files of the running job
+// are pinned and stay.
+func (a *Agent) clearCacheCommand() (any, error) {
+	if a.Cache == nil {
+		return nil, fmt.Errorf("download cache is not set up")
+	}
+	removed, freed, kept := a.Cache.Clear()
+	infrastructure.AgentLogger.Info("Cleared download cache: %d files removed (%s), %d in use kept", removed, formatFileSize(freed), kept)
+	return map[string]int64{"removed_files": int64(removed), "freed_bytes": freed, "kept_files": int64(kept)}, nil
+}
+
+// upgradeCommand runs the upgrade command configured on the agent with the
+// requested version in HASHCAT_AGENT_UPGRADE_VERSION. The agent restarts
+// once the result is reported, handing a running job back to the server.
+func (a *Agent) upgradeCommand(rawParams json.RawMessage) (any, error) {
+	upgradeCommand := a.Settings.Get().UpgradeCommand
+	if upgradeCommand == "" {
+		return nil, fmt.Errorf("no upgrade command configured on this agent (--upgrade-command)")
+	}
+	var params domain.UpgradeParams
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, fmt.Errorf("invalid upgrade params: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
+	defer cancel()
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", upgradeCommand)
+	cmd.Env = append(os.Environ(), "HASHCAT_AGENT_UPGRADE_VERSION="+params.Version)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	infrastructure.AgentLogger.Info("Upgrading agent to %s", orDefault(params.Version, "the latest version"))
+	runErr := cmd.Run()
+
+	tail := output.String()
+	if len(tail) > upgradeOutputTail {
+		tail = tail[len(tail)-upgradeOutputTail:]
+	}
+	if ctx.Err() != nil {
+		return map[string]string{"output": tail}, fmt.Errorf("upgrade command timed out after %s", upgradeTimeout)
+	}
+	if runErr != nil {
+		return map[string]string{"output": tail}, fmt.Errorf("upgrade command failed: %w", runErr)
+	}
+	return map[string]string{"version": params.Version, "output": tail}, nil
+}
+
+// restartWatchdogCommand restarts the stall clock of the running job's
+// watchdog
+func (a *Agent) restartWatchdogCommand() (any, error) {
+	jobID, ok := a.restartWatchdog()
+	if !ok {
+		return nil, fmt.Errorf("no job is running")
+	}
+	return map[string]string{"job_id": jobID.String()}, nil
+}
+
+// agentDiagnostics is what collect_diagnostics reports
+type agentDiagnostics struct {
+	CollectedAt     time.Time              `json:"collected_at"`
+	Hostname        string                 `json:"hostname"`
+	OS              string                 `json:"os"`
+	Arch            string                 `json:"arch"`
+	GoVersion       string                 `json:"go_version"`
+	Goroutines      int                    `json:"goroutines"`
+	HashcatPath     string                 `json:"hashcat_path"`
+	JohnPath        string                 `json:"john_path"`
+	Heartbeat       *domain.AgentHeartbeat `json:"heartbeat"` // Engines, hashcat version, free disk and job progress
+	UploadDir       string                 `json:"upload_dir"`
+	UploadDirBytes  int64                  `json:"upload_dir_bytes"`
+	CacheUsedBytes  int64                  `json:"cache_used_bytes"`
+	CacheLimitBytes int64                  `json:"cache_limit_bytes"`
+	CacheFiles      int                    `json:"cache_files"`
+	OutboxReports   int                    `json:"outbox_reports"` // Job reports waiting for the server
+	Settings        map[string]string      `json:"settings"`       // In effect, server settings applied
+	ServerSettings  domain.AgentSettings   `json:"server_settings"`
+}
+
+// collectDiagnostics describes the agent's host, setup and state for
+// troubleshooting from the server
+func (a *Agent) collectDiagnostics() *agentDiagnostics {
+	settings := a.Settings.Get()
+	hostname, _ := os.Hostname()
+	diagnostics := &agentDiagnostics{
+		CollectedAt:    time.Now(),
+		Hostname:       hostname,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		GoVersion:      runtime.Version(),
+		Goroutines:     runtime.NumGoroutine(),
+		HashcatPath:    settings.HashcatPath,
+		JohnPath:       settings.JohnPath,
+		Heartbeat:      a.heartbeatSnapshot(),
+		UploadDir:      a.UploadDir,
+		UploadDirBytes: dirSize(a.UploadDir),
+		ServerSettings: a.Settings.Remote(),
+		Settings: map[string]string{
+			"poll_interval":       settings.PollInterval.String(),
+			"status_interval":     settings.StatusInterval.String(),
+			"heartbeat_interval":  a.heartbeatEvery().String(),
+			"file_scan_interval":  settings.FileScanInterval.String(),
+			"log_ship_interval":   settings.LogShipInterval.String(),
+			"workload_profile":    fmt.Sprint(settings.WorkloadProfile),
+			"temp_abort":          fmt.Sprint(settings.TempAbort),
+			"cache_size_mb":       fmt.Sprint(settings.CacheSizeMB),
+			"disk_quota_mb":       fmt.Sprint(settings.DiskQuotaMB),
+			"disk_reserve_mb":     fmt.Sprint(settings.DiskReserveMB),
+			"download_rate_limit": fmt.Sprint(settings.DownloadRateLimitKB),
+			"upgrade_command_set": fmt.Sprint(settings.UpgradeCommand != ""),
+		},
+	}
+	if a.Cache != nil {
+		report, _ := a.Cache.Report()
+		diagnostics.CacheUsedBytes, diagnostics.CacheLimitBytes = report.UsedBytes, report.LimitBytes
+		diagnostics.CacheFiles = len(report.Entries)
+	}
+	if a.Outbox != nil {
+		if entries, err := a.Outbox.list(); err == nil {
+			diagnostics.OutboxReports = len(entries)
+		}
+	}
+	return diagnostics
+}
+
+// orDefault returns s, or fallback when s is empty
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
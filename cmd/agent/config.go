@@ -0,0 +1,386 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/spf13/viper"
+)
+
+// defaultConfigPath is read when present, so images can mount a config
+// file there instead of passing flags to the entrypoint
+const defaultConfigPath = "/etc/hashcat-agent/agent.yaml"
+
+// bindAgentEnv lets every flag be set from the environment as well
+func bindAgentEnv() {
+	viper.BindEnv("server", "HASHCAT_AGENT_SERVER", "HASHCAT_SERVER_URL")
+	viper.BindEnv("name", "HASHCAT_AGENT_NAME")
+	viper.BindEnv("ip", "HASHCAT_AGENT_IP")
+	viper.BindEnv("port", "HASHCAT_AGENT_PORT")
+	viper.BindEnv("capabilities", "HASHCAT_AGENT_CAPABILITIES")
+	viper.BindEnv("agent-key", "HASHCAT_AGENT_KEY")
+	viper.BindEnv("enrollment-token", "HASHCAT_AGENT_ENROLLMENT_TOKEN")
+	viper.BindEnv("upload-dir", "HASHCAT_AGENT_UPLOAD_DIR")
+	viper.BindEnv("cache-size-mb", "HASHCAT_AGENT_CACHE_SIZE_MB")
+	viper.BindEnv("download-rate-limit", "HASHCAT_AGENT_DOWNLOAD_RATE_LIMIT")
+	viper.BindEnv("download-queue-timeout", "HASHCAT_AGENT_DOWNLOAD_QUEUE_TIMEOUT")
+	viper.BindEnv("disk-quota-mb", "HASHCAT_AGENT_DISK_QUOTA_MB")
+	viper.BindEnv("disk-reserve-mb", "HASHCAT_AGENT_DISK_RESERVE_MB")
+	viper.BindEnv("temp-max-age", "HASHCAT_AGENT_TEMP_MAX_AGE")
+	viper.BindEnv("temp-cleanup-interval", "HASHCAT_AGENT_TEMP_CLEANUP_INTERVAL")
+	viper.BindEnv("job-dir-retention", "HASHCAT_AGENT_JOB_DIR_RETENTION")
+	viper.BindEnv("container", "HASHCAT_AGENT_CONTAINER")
+	viper.BindEnv("config", "HASHCAT_AGENT_CONFIG")
+	viper.BindEnv("heartbeat-interval", "HASHCAT_AGENT_HEARTBEAT_INTERVAL")
+	viper.BindEnv("poll-interval", "HASHCAT_AGENT_POLL_INTERVAL")
+	viper.BindEnv("status-interval", "HASHCAT_AGENT_STATUS_INTERVAL")
+	viper.BindEnv("file-scan-interval", "HASHCAT_AGENT_FILE_SCAN_INTERVAL")
+	viper.BindEnv("log-ship-interval", "HASHCAT_AGENT_LOG_SHIP_INTERVAL")
+	viper.BindEnv("output-tail-kb", "HASHCAT_AGENT_OUTPUT_TAIL_KB")
+	viper.BindEnv("hashcat-path", "HASHCAT_AGENT_HASHCAT_PATH")
+	viper.BindEnv("john-path", "HASHCAT_AGENT_JOHN_PATH")
+	viper.BindEnv("upgrade-command", "HASHCAT_AGENT_UPGRADE_COMMAND")
+	viper.BindEnv("workload-profile", "HASHCAT_AGENT_WORKLOAD_PROFILE")
+	viper.BindEnv("temp-abort", "HASHCAT_AGENT_TEMP_ABORT")
+	viper.BindEnv("sandbox-cpus", "HASHCAT_AGENT_SANDBOX_CPUS")
+	viper.BindEnv("sandbox-memory-mb", "HASHCAT_AGENT_SANDBOX_MEMORY_MB")
+	viper.BindEnv("sandbox-cgroup", "HASHCAT_AGENT_SANDBOX_CGROUP")
+	viper.BindEnv("sandbox-nice", "HASHCAT_AGENT_SANDBOX_NICE")
+	viper.BindEnv("sandbox-ionice", "HASHCAT_AGENT_SANDBOX_IONICE")
+	viper.BindEnv("sandbox-user", "HASHCAT_AGENT_SANDBOX_USER")
+	viper.BindEnv("sandbox-jail", "HASHCAT_AGENT_SANDBOX_JAIL")
+	viper.BindEnv("log-format", "HASHCAT_AGENT_LOG_FORMAT")
+	viper.BindEnv("log-level", "HASHCAT_AGENT_LOG_LEVEL")
+	viper.BindEnv("trace-endpoint", "HASHCAT_AGENT_TRACE_ENDPOINT")
+	viper.BindEnv("trace-sample-ratio", "HASHCAT_AGENT_TRACE_SAMPLE_RATIO")
+}
+
+// dotEnvKeys are the variables set from .env, which a reload may overwrite.
+// Variables from the real environment are never touched.
+var dotEnvKeys = map[string]bool{}
+
+// loadDotEnv copies the variables of ./.env into the environment, where
+// bindAgentEnv picks them up
+func loadDotEnv() {
+	if _, err := os.Stat(".env"); err != nil {
+		return
+	}
+
+	env := viper.New()
+	env.SetConfigFile(".env")
+	env.SetConfigType("dotenv")
+	if err := env.ReadInConfig(); err != nil {
+		infrastructure.AgentLogger.Warning("Failed to read .env: %v", err)
+		return
+	}
+
+	for _, key := range env.AllKeys() {
+		name := strings.ToUpper(key)
+		if _, set := os.LookupEnv(name); set && !dotEnvKeys[name] {
+			continue
+		}
+		os.Setenv(name, env.GetString(key))
+		dotEnvKeys[name] = true
+	}
+}
+
+// loadAgentConfigFile reads the config file given with --config, or the
+// first of the default locations that exists. Keys are the flag names;
+// flags and environment variables take precedence.
+func loadAgentConfigFile() {
+	path := viper.GetString("config")
+	if path == "" {
+		for _, candidate := range []string{defaultConfigPath, "agent.yaml", filepath.Join("configs", "agent.yaml")} {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" {
+		return
+	}
+
+	viper.SetConfigFile(path)
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext == "" || ext == "conf" {
+		viper.SetConfigType("yaml")
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		infrastructure.AgentLogger.Fatal("Failed to read config file %s: %v", path, err)
+	}
+	infrastructure.AgentLogger.Info("Using config file: %s", path)
+}
+
+// agentSettings are the values that can change while the agent runs
+type agentSettings struct {
+	HeartbeatInterval time.Duration
+	PollInterval      time.Duration
+	StatusInterval    time.Duration // How often a running job's status is checked
+	FileScanInterval  time.Duration
+	WorkloadProfile   int   // hashcat -w, 1 (low) to 4 (nightmare)
+	TempAbort         int   // hashcat --hwmon-temp-abort in °C, 0 keeps hashcat's default
+	CacheSizeMB       int64 // 0 for unlimited
+	// Downloads from the server, 0 for unlimited
+	DownloadRateLimitKB int64
+	// How long a download waits while the server is busy serving the file
+	DownloadQueueTimeout time.Duration
+	// Size limit of the upload directory, 0 for unlimited. Downloads that
+	// would go over it fail their job before they start.
+	DiskQuotaMB int64
+	// Free space downloads must leave on the upload directory's disk
+	DiskReserveMB int64
+	// Temp files untouched for longer are orphans and get deleted, 0 keeps them
+	TempMaxAge          time.Duration
+	TempCleanupInterval time.Duration
+	// How long a finished job's working directory is kept for debugging,
+	// 0 removes it when the job ends
+	JobDirRetention time.Duration
+	// How often log lines are sent to the server, 0 keeps them local
+	LogShipInterval time.Duration
+	// How much of hashcat's stdout and stderr is kept per job and sent with
+	// the job's completion or failure
+	OutputTailKB int
+	// hashcat binary, a name looked up on PATH or a path such as
+	// /opt/hashcat/hashcat.bin; applies from the next job
+	HashcatPath string
+	// John the Ripper (jumbo) binary for john jobs, looked up like HashcatPath
+	JohnPath string
+	// Shell command an upgrade command from the server runs, empty refuses
+	// upgrades. It gets the version in HASHCAT_AGENT_UPGRADE_VERSION.
+	UpgradeCommand string
+	// Restrictions on the engine process; apply from the next job
+	Sandbox sandboxSettings
+}
+
+// readSettings takes the tunable values from flags, environment and config
+// file, replacing invalid ones with the defaults
+func readSettings() agentSettings {
+	s := agentSettings{
+		HeartbeatInterval: viper.GetDuration("heartbeat-interval"),
+		PollInterval:      viper.GetDuration("poll-interval"),
+		StatusInterval:    viper.GetDuration("status-interval"),
+		FileScanInterval:  viper.GetDuration("file-scan-interval"),
+		LogShipInterval:   viper.GetDuration("log-ship-interval"),
+		WorkloadProfile:   viper.GetInt("workload-profile"),
+		TempAbort:         viper.GetInt("temp-abort"),
+		CacheSizeMB:       viper.GetInt64("cache-size-mb"),
+
+		DownloadRateLimitKB:  viper.GetInt64("download-rate-limit"),
+		DownloadQueueTimeout: viper.GetDuration("download-queue-timeout"),
+		DiskQuotaMB:          viper.GetInt64("disk-quota-mb"),
+		DiskReserveMB:        viper.GetInt64("disk-reserve-mb"),
+		TempMaxAge:           viper.GetDuration("temp-max-age"),
+		TempCleanupInterval:  viper.GetDuration("temp-cleanup-interval"),
+		JobDirRetention:      viper.GetDuration("job-dir-retention"),
+		OutputTailKB:         viper.GetInt("output-tail-kb"),
+		HashcatPath:          strings.TrimSpace(viper.GetString("hashcat-path")),
+		JohnPath:             strings.TrimSpace(viper.GetString("john-path")),
+		UpgradeCommand:       strings.TrimSpace(viper.GetString("upgrade-command")),
+		Sandbox:              readSandboxSettings(),
+	}
+
+	if s.HeartbeatInterval < 0 {
+		s.HeartbeatInterval = 0 // Let the server decide
+	}
+	if s.PollInterval <= 0 {
+		s.PollInterval = 10 * time.Second
+	}
+	if s.StatusInterval <= 0 {
+		s.StatusInterval = 5 * time.Second
+	}
+	if s.FileScanInterval <= 0 {
+		s.FileScanInterval = 5 * time.Minute
+	}
+	if s.LogShipInterval < 0 {
+		s.LogShipInterval = 0
+	}
+	if s.OutputTailKB <= 0 {
+		s.OutputTailKB = 64
+	}
+	if s.HashcatPath == "" {
+		s.HashcatPath = "hashcat"
+	}
+	if s.JohnPath == "" {
+		s.JohnPath = "john"
+	}
+	if s.WorkloadProfile < 1 || s.WorkloadProfile > 4 {
+		infrastructure.AgentLogger.Warning("Invalid workload profile %d, using 4", s.WorkloadProfile)
+		s.WorkloadProfile = 4
+	}
+	if s.TempAbort < 0 {
+		s.TempAbort = 0
+	}
+	if s.CacheSizeMB < 0 {
+		s.CacheSizeMB = 0
+	}
+	if s.DownloadRateLimitKB < 0 {
+		s.DownloadRateLimitKB = 0
+	}
+	if s.DownloadQueueTimeout <= 0 {
+		s.DownloadQueueTimeout = time.Hour
+	}
+	if s.DiskQuotaMB < 0 {
+		s.DiskQuotaMB = 0
+	}
+	if s.DiskReserveMB < 0 {
+		s.DiskReserveMB = 0
+	}
+	if s.TempMaxAge < 0 {
+		s.TempMaxAge = 0
+	}
+	if s.TempCleanupInterval <= 0 {
+		s.TempCleanupInterval = time.Hour
+	}
+	if s.JobDirRetention < 0 {
+		s.JobDirRetention = 0
+	}
+
+	return s
+}
+
+// liveSettings holds the current settings, replaced on reload. Settings
+// managed on the server take precedence over the local ones.
+type liveSettings struct {
+	mu       sync.RWMutex
+	settings agentSettings // From flags, environment and config file
+	remote   domain.AgentSettings
+}
+
+func (l *liveSettings) Get() agentSettings {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return withServerSettings(l.settings, l.remote)
+}
+
+// Set replaces the local settings, returning the settings in effect before
+func (l *liveSettings) Set(s agentSettings) agentSettings {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := withServerSettings(l.settings, l.remote)
+	l.settings = s
+	return old
+}
+
+func (l *liveSettings) Remote() domain.AgentSettings {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.remote
+}
+
+// SetRemote replaces the server-managed settings. It returns false when
+// they didn't change.
+func (l *liveSettings) SetRemote(remote domain.AgentSettings) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if remote == l.remote {
+		return false
+	}
+	l.remote = remote
+	return true
+}
+
+// withServerSettings overrides s with the non-zero server-managed settings
+func withServerSettings(s agentSettings, remote domain.AgentSettings) agentSettings {
+	if remote.PollIntervalSeconds > 0 {
+		s.PollInterval = time.Duration(remote.PollIntervalSeconds) * time.Second
+	}
+	if remote.StatusIntervalSeconds > 0 {
+		s.StatusInterval = time.Duration(remote.StatusIntervalSeconds) * time.Second
+	}
+	if remote.FileScanIntervalSeconds > 0 {
+		s.FileScanInterval = time.Duration(remote.FileScanIntervalSeconds) * time.Second
+	}
+	if remote.WorkloadProfile > 0 {
+		s.WorkloadProfile = remote.WorkloadProfile
+	}
+	if remote.TempAbort > 0 {
+		s.TempAbort = remote.TempAbort
+	}
+	if remote.DiskQuotaMB > 0 {
+		s.DiskQuotaMB = remote.DiskQuotaMB
+	}
+	return s
+}
+
+// applyServerSettings takes the settings the server sent with a heartbeat.
+// Intervals and hashcat options apply as on a reload; a different upload
+// directory only when the agent restarts.
+func (a *Agent) applyServerSettings(remote domain.AgentSettings) {
+	if !a.Settings.SetRemote(remote) {
+		return
+	}
+
+	next := a.Settings.Get()
+	infrastructure.AgentLogger.Info("Server settings changed: poll %v, status %v, file scan %v, workload profile %d, temp abort %d, disk quota %d MB",
+		next.PollInterval, next.StatusInterval, next.FileScanInterval, next.WorkloadProfile, next.TempAbort, next.DiskQuotaMB)
+	if remote.UploadDir != "" && remote.UploadDir != a.UploadDir {
+		infrastructure.AgentLogger.Warning("upload-dir changed to %q on the server, restart the agent to apply it", remote.UploadDir)
+	}
+}
+
+// resetTicker applies a reloaded interval to a running ticker
+func resetTicker(ticker *time.Ticker, current *time.Duration, next time.Duration) {
+	if next != *current {
+		ticker.Reset(next)
+		*current = next
+	}
+}
+
+// reloadConfig re-reads .env and the config file and applies the tunable
+// settings and the log format and level. A running job keeps going; hashcat options apply to the next job.
+func (a *Agent) reloadConfig() {
+	infrastructure.AgentLogger.Info("Reloading configuration...")
+
+	loadDotEnv()
+	if viper.ConfigFileUsed() != "" {
+		if err := viper.ReadInConfig(); err != nil {
+			infrastructure.AgentLogger.Error("Failed to reload config file %s, keeping the current settings: %v", viper.ConfigFileUsed(), err)
+			return
+		}
+	}
+
+	if err := infrastructure.ConfigureLogging(viper.GetString("log-format"), viper.GetString("log-level"), logOutput()); err != nil {
+		infrastructure.AgentLogger.Error("Invalid logging config, keeping the current one: %v", err)
+	}
+
+	old := a.Settings.Set(readSettings())
+	next := a.Settings.Get()
+	if a.Cache != nil && next.CacheSizeMB != old.CacheSizeMB {
+		a.Cache.SetLimit(next.CacheSizeMB * 1024 * 1024)
+	}
+	if next.HashcatPath != old.HashcatPath {
+		infrastructure.AgentLogger.Info("hashcat binary changed to %s, used from the next job", next.HashcatPath)
+	}
+	if next.JohnPath != old.JohnPath {
+		infrastructure.AgentLogger.Info("John the Ripper binary changed to %s, used from the next job", next.JohnPath)
+	}
+	if next.Sandbox != old.Sandbox {
+		infrastructure.AgentLogger.Info("Job sandbox changed to %s, used from the next job", next.Sandbox)
+	}
+
+	infrastructure.AgentLogger.Success("Configuration reloaded: heartbeat %v, poll %v, status %v, file scan %v, workload profile %d, temp abort %d, cache %d MB, download limit %d KB/s, disk quota %d MB, disk reserve %d MB",
+		next.HeartbeatInterval, next.PollInterval, next.StatusInterval, next.FileScanInterval, next.WorkloadProfile, next.TempAbort, next.CacheSizeMB, next.DownloadRateLimitKB, next.DiskQuotaMB, next.DiskReserveMB)
+
+	// Identity and storage are only read at startup
+	for key, current := range map[string]string{
+		"server":     a.ServerURL,
+		"agent-key":  a.AgentKey,
+		"upload-dir": a.UploadDir,
+	} {
+		if key == "agent-key" && viper.GetString(key) == "" {
+			continue // Enrolled agents read their key from the upload directory
+		}
+		if key == "upload-dir" && a.Settings.Remote().UploadDir != "" {
+			continue // Set on the server
+		}
+		if viper.GetString(key) != current {
+			infrastructure.AgentLogger.Warning("%s changed to %q, restart the agent to apply it", key, viper.GetString(key))
+		}
+	}
+}
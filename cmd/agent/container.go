@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// containerMode is set at startup. Inside a container the host tools used for
+// GPU detection are usually missing and /proc and /sys describe the host, so
+// detection relies on what the container runtime injected instead.
+var containerMode bool
+
+// detectContainerMode resolves the --container setting (auto, on or off)
+func detectContainerMode() bool {
+	switch strings.ToLower(viper.GetString("container")) {
+	case "on", "true", "yes", "1":
+		return true
+	case "off", "false", "no", "0":
+		return false
+	}
+	return inContainer()
+}
+
+// inContainer reports whether the agent runs inside a container
+func inContainer() bool {
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	if os.Getenv("container") != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+
+	cgroup, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	for _, runtime := range []string{"docker", "kubepods", "containerd", "libpod"} {
+		if strings.Contains(string(cgroup), runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerGPUs lists the GPUs the container runtime gave this container,
+// either through the NVIDIA runtime or as CDI-injected device nodes
+func containerGPUs() []string {
+	var gpus []string
+
+	// Set by the NVIDIA container runtime: "all", a list of indexes or UUIDs,
+	// or "void"/"none" when no GPU was requested
+	switch visible := strings.TrimSpace(os.Getenv("NVIDIA_VISIBLE_DEVICES")); strings.ToLower(visible) {
+	case "", "void", "none":
+	default:
+		gpus = append(gpus, "NVIDIA_VISIBLE_DEVICES="+visible)
+	}
+
+	// CDI and --device inject the device nodes themselves
+	patterns := []string{
+		"/dev/nvidia[0-9]*", // NVIDIA
+		"/dev/kfd",          // AMD ROCm
+		"/dev/dri/renderD*", // AMD and Intel render nodes
+	}
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		gpus = append(gpus, matches...)
+	}
+
+	return gpus
+}
+
+// localIPs returns the IPv4 addresses of the interfaces that are up,
+// skipping loopback and link-local addresses. Unlike `hostname -I` this
+// works in minimal images.
+func localIPs() ([]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			ips = append(ips, ip.String())
+		}
+	}
+
+	return ips, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// crackPollInterval is how often a run's outfile is checked for new cracks
+const crackPollInterval = time.Second
+
+// crackVerifyTimeout bounds the engine's --show run that confirms the
+// cracks of a finished run
+const crackVerifyTimeout = 5 * time.Minute
+
+// crackParser splits an outfile line into the cracked hash and its password
+type crackParser func(line string) (hash, plaintext string, ok bool)
+
+// outfileTail reads the lines an engine appends to its outfile
+type outfileTail struct {
+	path    string
+	offset  int64
+	partial []byte // A line the engine hasn't finished writing
+}
+
+// lines returns the complete lines appended since the last call
+func (t *outfileTail) lines() ([]string, error) {
+	f, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil // Nothing cracked yet
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, nil
+	}
+	t.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines []string
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// watchCracks tails the outfile of a run and reports each crack to the
+// server as the engine writes it, rather than when the run ends. The
+// returned func stops watching once what the outfile holds is reported.
+func (a *Agent) watchCracks(job *domain.Job, engine crackEngine, in engineInputs) func() {
+	tail := &outfileTail{path: in.outfile}
+	parse := engine.crackParser(in)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(crackPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				a.reportCracks(job.ID, tail, parse)
+				return
+			case <-ticker.C:
+				a.reportCracks(job.ID, tail, parse)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// reportCracks sends the cracks written since the last call. Reports the
+// server doesn't get wait in the outbox.
+func (a *Agent) reportCracks(jobID uuid.UUID, tail *outfileTail, parse crackParser) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
+	lines, err := tail.lines()
+	if err != nil {
+		logger.Warning("Failed to read outfile: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var cracks []domain.JobCrackReport
+	for _, line := range lines {
+		hash, plaintext, ok := parse(line)
+		if !ok {
+			logger.Warning("Skipping unreadable outfile line")
+			continue
+		}
+		cracks = append(cracks, domain.JobCrackReport{Hash: hash, Plaintext: plaintext, CrackedAt: now})
+	}
+
+	path := fmt.Sprintf("/api/v1/jobs/%s/cracks", jobID.String())
+	for len(cracks) > 0 {
+		batch := cracks[:min(len(cracks), domain.MaxJobCracksPerReport)]
+		cracks = cracks[len(batch):]
+
+		req := domain.ReportJobCracksRequest{AgentID: a.ID.String(), Cracks: batch}
+		delivered, err := a.report(http.MethodPost, path, req, false)
+		switch {
+		case err != nil:
+			logger.Error("Failed to report %d cracked hashes: %v", len(batch), err)
+		case delivered:
+			logger.Success("Reported %d cracked hashes", len(batch))
+		default:
+			logger.Warning("Server unreachable, %d cracked hashes queued for delivery", len(batch))
+		}
+	}
+}
+
+// readCracks returns the cracks of every line of an outfile
+func readCracks(path string, parse crackParser) ([]domain.JobCrackReport, error) {
+	lines, err := (&outfileTail{path: path}).lines()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outfile %s: %w", path, err)
+	}
+	cracks := make([]domain.JobCrackReport, 0, len(lines))
+	for _, line := range lines {
+		if hash, plaintext, ok := parse(line); ok {
+			cracks = append(cracks, domain.JobCrackReport{Hash: hash, Plaintext: plaintext})
+		}
+	}
+	return cracks, nil
+}
+
+// showCracks reads the hash:plain lines of --show output, keyed by crackKey
+func showCracks(output []byte, parse crackParser) map[string]bool {
+	shown := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		if hash, plaintext, ok := parse(strings.TrimRight(line, "\r")); ok {
+			shown[crackKey(hash, plaintext)] = true
+		}
+	}
+	return shown
+}
+
+// confirmCracks keeps the cracks --show confirmed, once each
+func confirmCracks(found []domain.JobCrackReport, shown map[string]bool) []domain.JobCrackReport {
+	var cracks []domain.JobCrackReport
+	for _, crack := range found {
+		key := crackKey(crack.Hash, crack.Plaintext)
+		if shown[key] {
+			delete(shown, key)
+			cracks = append(cracks, crack)
+		}
+	}
+	return cracks
+}
+
+// crackKey identifies a crack; hashcat may print a hash in another case
+// than the hash file has it
+func crackKey(hash, plaintext string) string {
+	return strings.ToLower(hash) + ":" + plaintext
+}
+
+// hashcatCrackParser splits the hash:plain lines hashcat writes with
+// --outfile-format 1,2. Salted hashes and passwords may both contain
+// colons, so the longest start of the line that is a line of the hash file
+// is the hash; failing that, the hash ends at the first colon, as unsalted
+// hashes do.
+func hashcatCrackParser(hashFile string) crackParser {
+	var known map[string]bool // Lines of the hash file that have a colon, read on first use
+	return func(line string) (string, string, bool) {
+		first := strings.IndexByte(line, ':')
+		if first < 0 {
+			return "", "", false
+		}
+		if known == nil {
+			known = hashLinesWithColons(hashFile)
+		}
+		for i := strings.LastIndexByte(line, ':'); i > first; i = strings.LastIndexByte(line[:i], ':') {
+			if known[strings.ToLower(line[:i])] {
+				return line[:i], line[i+1:], true
+			}
+		}
+		return line[:first], line[first+1:], true
+	}
+}
+
+// hashLinesWithColons reads the lowercased lines of a hash file that
+// contain a colon. Unsalted hashes have none, so most files give few.
+func hashLinesWithColons(path string) map[string]bool {
+	lines := make(map[string]bool)
+	f, err := os.Open(path)
+	if err != nil {
+		return lines
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); strings.Contains(line, ":") {
+			lines[strings.ToLower(line)] = true
+		}
+	}
+	return lines
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+}
+
+func TestOutfileTail(t *testing.T) {
+	tail := &outfileTail{path: filepath.Join(t.TempDir(), "cracked.txt")}
+
+	lines, err := tail.lines()
+	require.NoError(t, err)
+	assert.Empty(t, lines, "nothing cracked yet")
+
+	// A line the engine hasn't finished waits for its end
+	appendFile(t, tail.path, "aaa:one\r\nbbb:tw")
+	lines, err = tail.lines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaa:one"}, lines)
+
+	appendFile(t, tail.path, "o\n\nccc:three\n")
+	lines, err = tail.lines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bbb:two", "ccc:three"}, lines)
+
+	lines, err = tail.lines()
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+}
+
+func TestHashcatCrackParser(t *testing.T) {
+	hashFile := filepath.Join(t.TempDir(), "hashes.txt")
+	require.NoError(t, os.WriteFile(hashFile, []byte(
+		"5f4dcc3b5aa765d61d8327deb882cf99\n"+
+			"E10ADC3949BA59ABBE56E057F20F883E:pepper:x\n"), 0600))
+	parse := hashcatCrackParser(hashFile)
+
+	for line, want := range map[string][2]string{
+		// Unsalted hashes end at the first colon, the password may have more
+		"5f4dcc3b5aa765d61d8327deb882cf99:pass:word": {"5f4dcc3b5aa765d61d8327deb882cf99", "pass:word"},
+		// Salts with colons are taken from the hash file, in any case
+		"e10adc3949ba59abbe56e057f20f883e:pepper:x:123:456": {"e10adc3949ba59abbe56e057f20f883e:pepper:x", "123:456"},
+		"e10adc3949ba59abbe56e057f20f883e:pepper:x:":        {"e10adc3949ba59abbe56e057f20f883e:pepper:x", ""},
+	} {
+		hash, plaintext, ok := parse(line)
+		require.True(t, ok, line)
+		assert.Equal(t, want, [2]string{hash, plaintext}, line)
+	}
+
+	_, _, ok := parse("no colon")
+	assert.False(t, ok)
+}
+
+func TestConfirmCracks(t *testing.T) {
+	found := []domain.JobCrackReport{
+		{Hash: "AAA", Plaintext: "one"},
+		{Hash: "bbb", Plaintext: "stale"},
+		{Hash: "ccc", Plaintext: "three"},
+		{Hash: "aaa", Plaintext: "one"},
+	}
+	shown := showCracks([]byte("aaa:one\r\nccc:three\nbbb:other\n"), func(line string) (string, string, bool) {
+		return strings.Cut(line, ":")
+	})
+
+	// Hashcat may print hashes in another case; each crack counts once,
+	// in the order found
+	assert.Equal(t, []domain.JobCrackReport{
+		{Hash: "AAA", Plaintext: "one"},
+		{Hash: "ccc", Plaintext: "three"},
+	}, confirmCracks(found, shown))
+}
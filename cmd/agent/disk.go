@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// errDiskSpace is returned when a download wouldn't fit in the free disk
+// space or the upload directory's quota
+var errDiskSpace = errors.New("not enough disk space")
+
+// checkDiskSpace makes sure size more bytes fit on disk before a download
+// starts, evicting unpinned cache entries when that makes room. A size of
+// -1 (unknown) is let through.
+func (a *Agent) checkDiskSpace(name string, size int64) error {
+	if size < 0 {
+		return nil
+	}
+	settings := a.Settings.Get()
+
+	if shortfall := a.diskShortfall(size, settings); shortfall > 0 {
+		a.Cache.Free(shortfall)
+	}
+	reserve := settings.DiskReserveMB * 1024 * 1024
+	if free := freeDiskBytes(a.UploadDir); free >= 0 && free-reserve < size {
+		return fmt.Errorf("%w for %s (%s): %s free, %s kept in reserve",
+			errDiskSpace, name, formatFileSize(size), formatFileSize(free), formatFileSize(reserve))
+	}
+	if quota := settings.DiskQuotaMB * 1024 * 1024; quota > 0 {
+		if used := dirSize(a.UploadDir); used+size > quota {
+			return fmt.Errorf("%w for %s (%s): upload directory uses %s of its %s quota",
+				errDiskSpace, name, formatFileSize(size), formatFileSize(used), formatFileSize(quota))
+		}
+	}
+	return nil
+}
+
+// diskShortfall returns how many bytes must be freed for size more bytes to
+// fit in both the free space left above the reserve and the quota
+func (a *Agent) diskShortfall(size int64, s agentSettings) int64 {
+	var shortfall int64
+	if free := freeDiskBytes(a.UploadDir); free >= 0 {
+		shortfall = size - (free - s.DiskReserveMB*1024*1024)
+	}
+	if quota := s.DiskQuotaMB * 1024 * 1024; quota > 0 {
+		if over := dirSize(a.UploadDir) + size - quota; over > shortfall {
+			shortfall = over
+		}
+	}
+	return shortfall
+}
+
+// dirSize returns the size of the files under root, skipping what can't be
+// read
+func dirSize(root string) int64 {
+	var total int64
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// removeOrphanedFiles deletes files in the temp directory, and downloads
+// the cache never finished, that haven't changed for longer than maxAge.
+// They are left behind by jobs or downloads the agent didn't see through,
+// e.g. after a crash. The running job's files are kept.
+func (a *Agent) removeOrphanedFiles(maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var running string
+	if job := a.CurrentJob; job != nil {
+		running = job.ID.String()
+	}
+
+	var removed int
+	var freed int64
+	remove := func(path string, info fs.FileInfo) {
+		if info.ModTime().After(cutoff) || (running != "" && strings.Contains(info.Name(), running)) {
+			return
+		}
+		if err := os.Remove(path); err != nil {
+			infrastructure.AgentLogger.Warning("Failed to remove orphaned file %s: %v", path, err)
+			return
+		}
+		removed++
+		freed += info.Size()
+	}
+
+	filepath.WalkDir(filepath.Join(a.UploadDir, "temp"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			remove(path, info)
+		}
+		return nil
+	})
+	for _, kind := range cacheKinds {
+		matches, _ := filepath.Glob(filepath.Join(a.UploadDir, "cache", kind, ".download-*"))
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil {
+				remove(path, info)
+			}
+		}
+	}
+
+	if removed > 0 {
+		infrastructure.AgentLogger.Info("Removed %d orphaned temp files (%s) older than %v", removed, formatFileSize(freed), maxAge)
+	}
+}
+
+// cleanupOrphanedFiles runs removeOrphanedFiles and removeExpiredJobDirs
+// periodically
+func (a *Agent) cleanupOrphanedFiles(ctx context.Context) {
+	interval := a.Settings.Get().TempCleanupInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settings := a.Settings.Get()
+			resetTicker(ticker, &interval, settings.TempCleanupInterval)
+			a.removeOrphanedFiles(settings.TempMaxAge)
+			a.removeExpiredJobDirs(settings.jobDirMaxAge())
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeDiskBytes returns the space available to the agent on the volume
+// holding dir, or -1 when it can't be read
+func freeDiskBytes(dir string) int64 {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return -1
+	}
+
+	var available uint64
+	if ok, _, _ := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&available)), 0, 0); ok == 0 {
+		return -1
+	}
+	return int64(available)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/google/uuid"
+)
+
+// defaultDownloadRetryAfter is used when a busy server sends no Retry-After
+const defaultDownloadRetryAfter = 10 * time.Second
+
+// rateLimitedReader throttles reads with a token bucket that refills at
+// rate bytes per second and holds at most one second's worth of tokens
+type rateLimitedReader struct {
+	r      io.Reader
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimitedReader limits r to bytesPerSec, or returns r unchanged
+// when bytesPerSec is 0
+func newRateLimitedReader(r io.Reader, bytesPerSec int64) io.Reader {
+	if bytesPerSec <= 0 {
+		return r
+	}
+	return &rateLimitedReader{r: r, rate: float64(bytesPerSec), last: time.Now()}
+}
+
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	// Small reads keep the transfer smooth instead of bursting a whole buffer
+	if max := int(l.rate); len(p) > max && max > 0 {
+		p = p[:max]
+	}
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.take(n)
+	}
+	return n, err
+}
+
+// take spends n tokens, sleeping until the bucket is out of debt
+func (l *rateLimitedReader) take(n int) {
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens < 0 {
+		time.Sleep(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+}
+
+// downloadQueued starts a download, waiting its turn while the server
+// answers 503 because too many agents are fetching the same file. Each wait
+// follows the server's Retry-After plus random jitter, so queued agents
+// come back staggered instead of all at once.
+func (a *Agent) downloadQueued(kind string, id uuid.UUID) (*client.Download, error) {
+	deadline := time.Now().Add(a.Settings.Get().DownloadQueueTimeout)
+	for {
+		download, err := a.API.DownloadForAgent(context.Background(), a.ID, kind, id)
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			return download, err
+		}
+
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = defaultDownloadRetryAfter
+		}
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		if time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("server is still busy serving the file, giving up")
+		}
+
+		infrastructure.AgentLogger.Info("Server is busy serving this file to other agents, retrying in %v", delay.Round(time.Second))
+		time.Sleep(delay)
+	}
+}
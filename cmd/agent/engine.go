@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// engineInputs are a job's inputs, resolved to local paths
+type engineInputs struct {
+	hashFile  string
+	wordlist  string // File path, or the mask of brute-force attacks
+	wordlist2 string // Right-hand wordlist of combinator attacks
+	outfile   string // Where the engine writes cracked passwords
+}
+
+// runOutcome is what a finished run means for its job
+type runOutcome int
+
+const (
+	runCracked   runOutcome = iota // Read the password from the outfile
+	runExhausted                   // Every candidate was tried
+	runNotFound                    // Reported as a failed search
+	runOutOfTime                   // Stopped at the job's deadline
+	runFailed                      // The engine itself failed
+)
+
+// errNoPassword is returned by engines whose runs end the same way
+// whether or not they cracked anything
+var errNoPassword = errors.New("no password cracked")
+
+// crackEngine runs jobs with one cracking tool: it builds the command
+// line and reads back what the tool prints and leaves behind
+type crackEngine interface {
+	// displayName names the engine in job results and logs
+	displayName() string
+	binary(settings agentSettings) string
+	buildArgs(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]string, error)
+	// parseStatus decodes a progress line from stdout or stderr
+	parseStatus(line []byte) (jobStatus, bool)
+	// outcome maps the exit code of a run that didn't exit with 0
+	outcome(exitCode int) runOutcome
+	// verifiedCracks returns the cracks of a finished run that the engine
+	// confirms against the hash file, in the order they were found
+	verifiedCracks(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]domain.JobCrackReport, error)
+	// crackParser reads the lines the engine writes to its outfile
+	crackParser(in engineInputs) crackParser
+}
+
+// engineFor returns the engine a job runs with
+func engineFor(job *domain.Job) crackEngine {
+	if job.EngineName() == domain.EngineJohn {
+		return johnEngine{}
+	}
+	return hashcatEngine{}
+}
+
+// availableEngines lists the engines whose binaries are found, for the
+// heartbeat
+func availableEngines(settings agentSettings) []string {
+	var engines []string
+	if _, err := exec.LookPath(settings.HashcatPath); err == nil {
+		engines = append(engines, domain.EngineHashcat)
+	}
+	if _, err := exec.LookPath(settings.JohnPath); err == nil {
+		engines = append(engines, domain.EngineJohn)
+	}
+	return engines
+}
+
+type hashcatEngine struct{}
+
+func (hashcatEngine) displayName() string { return "Hashcat" }
+
+func (hashcatEngine) binary(settings agentSettings) string { return settings.HashcatPath }
+
+func (hashcatEngine) buildArgs(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]string, error) {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	args := []string{
+		"-m", strconv.Itoa(job.HashType),
+		"-a", strconv.Itoa(job.AttackMode),
+		in.hashFile,
+		in.wordlist,
+	}
+	if in.wordlist2 != "" {
+		args = append(args, in.wordlist2)
+	}
+	args = append(args,
+		"-w", strconv.Itoa(settings.WorkloadProfile),
+		"--status",
+		"--status-json",
+		"--status-timer=2",
+		// A potfile per job, for --show to confirm the outfile once the run ends
+		"--potfile-path", hashcatPotfile(in.outfile),
+		"--outfile", in.outfile,
+		"--outfile-format", "1,2", // Format: hash:plain
+		// Passwords as they are rather than $HEX[...], for the cracks reported while running
+		"--outfile-autohex-disable",
+		// A restore file per job, handed to another agent on shutdown
+		"--session", hashcatSession(job),
+		"--restore-file-path", hashcatRestoreFile(in.outfile, job),
+	)
+
+	if settings.TempAbort > 0 {
+		args = append(args, "--hwmon-temp-abort", strconv.Itoa(settings.TempAbort))
+	}
+
+	// Stop at the job's deadline; the watchdog stops engines that can't
+	if left, ok := job.TimeLeft(time.Now()); ok {
+		args = append(args, "--runtime", strconv.Itoa(max(int(left.Seconds()), 1)))
+	}
+
+	// Add skip and limit parameters for distributed cracking
+	if job.Skip != nil && *job.Skip >= 0 {
+		args = append(args, "--skip", strconv.FormatInt(*job.Skip, 10))
+		logger.Info("Using --skip parameter: %d", *job.Skip)
+	}
+
+	if job.WordLimit != nil && *job.WordLimit > 0 {
+		args = append(args, "--limit", strconv.FormatInt(*job.WordLimit, 10))
+		logger.Info("Using --limit parameter: %d", *job.WordLimit)
+	}
+
+	if job.Rules != "" {
+		ruleFile, err := a.resolveRuleFile(job.Rules)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-r", ruleFile)
+	}
+
+	// Custom charsets for ?1..?4 in brute-force masks
+	charsetArgs, err := a.customCharsetArgs(job)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, charsetArgs...)
+
+	// Tuning options, the agent's defaults first and then the job's own.
+	// The server whitelists both; they are checked again before use.
+	if err := domain.ValidateHashcatArgs("agent_args", job.AgentArgs); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateHashcatArgs("extra_args", job.ExtraArgs); err != nil {
+		return nil, err
+	}
+	args = append(args, job.AgentArgs...)
+	args = append(args, job.ExtraArgs...)
+
+	// The server only sends jobs this hashcat can run, but the binary may
+	// have changed since the job was assigned
+	version := hashcatVersionOf(settings.HashcatPath)
+	if err := domain.CheckHashcatSupport(version, job.HashType, job.AgentArgs, job.ExtraArgs); err != nil {
+		return nil, err
+	}
+	return append(args, domain.HashcatCompatArgs(version, job.HashType)...), nil
+}
+
+// hashcat runs with --status-json, so every status update is one JSON
+// object per line on stdout
+func (hashcatEngine) parseStatus(line []byte) (jobStatus, bool) {
+	status, ok := parseHashcatStatus(line)
+	if !ok {
+		return nil, false
+	}
+	return status, true
+}
+
+func (hashcatEngine) outcome(exitCode int) runOutcome {
+	switch exitCode {
+	case 1:
+		return runExhausted
+	case 4:
+		return runOutOfTime // Aborted by --runtime
+	case 255:
+		// Exit code 255 usually means invalid arguments or file not found,
+		// but is reported as the password not being found
+		return runNotFound
+	}
+	return runFailed
+}
+
+// verifiedCracks runs hashcat --show on the hash file with the run's
+// potfile and keeps the outfile's cracks it confirms. Lines of an outfile
+// some other run wrote, or whose hash isn't in the hash file, are dropped.
+func (e hashcatEngine) verifiedCracks(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]domain.JobCrackReport, error) {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	parse := e.crackParser(in)
+	found, err := readCracks(in.outfile, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), crackVerifyTimeout)
+	defer cancel()
+	args := []string{
+		"-m", strconv.Itoa(job.HashType),
+		"--show",
+		"--potfile-path", hashcatPotfile(in.outfile),
+		"--outfile-format", "1,2",
+		"--outfile-autohex-disable",
+		in.hashFile,
+	}
+	args = append(args, domain.HashcatCompatArgs(hashcatVersionOf(settings.HashcatPath), job.HashType)...)
+	cmd := exec.CommandContext(ctx, settings.HashcatPath, args...)
+	cmd.Dir = filepath.Dir(in.outfile)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("hashcat --show failed: %w", err)
+	}
+
+	cracks := confirmCracks(found, showCracks(output, parse))
+	if dropped := len(found) - len(cracks); dropped > 0 {
+		logger.Warning("hashcat --show didn't confirm %d of %d cracks in the outfile", dropped, len(found))
+	}
+	if len(cracks) == 0 {
+		return nil, errors.New("hashcat --show confirmed none of the cracks in the outfile")
+	}
+	return cracks, nil
+}
+
+// hashcatPotfile is where hashcat keeps the job's potfile, next to the
+// outfile
+func hashcatPotfile(outfile string) string {
+	return filepath.Join(filepath.Dir(outfile), "hashcat.pot")
+}
+
+func (hashcatEngine) crackParser(in engineInputs) crackParser {
+	return hashcatCrackParser(in.hashFile)
+}
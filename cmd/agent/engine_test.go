@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHashcat writes a script standing in for hashcat that prints show for
+// --show, recording its arguments in args.txt next to it
+func fakeHashcat(t *testing.T, show string, exitCode int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "show.txt"), []byte(show), 0600))
+	script := "#!/bin/sh\n" +
+		"dir=$(dirname \"$0\")\n" +
+		"if [ \"$1\" = \"--version\" ]; then echo v6.2.6; exit 0; fi\n" +
+		"echo \"$@\" > \"$dir/args.txt\"\n" +
+		"cat \"$dir/show.txt\"\n" +
+		"exit " + strconv.Itoa(exitCode) + "\n"
+	path := filepath.Join(dir, "hashcat")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func hashcatRun(t *testing.T, outfile string) engineInputs {
+	t.Helper()
+	dir := t.TempDir()
+	in := engineInputs{hashFile: filepath.Join(dir, "hashes.txt"), outfile: filepath.Join(dir, "cracked.txt")}
+	require.NoError(t, os.WriteFile(in.hashFile, []byte(
+		"5f4dcc3b5aa765d61d8327deb882cf99\n"+
+			"e10adc3949ba59abbe56e057f20f883e:salt\n"), 0600))
+	require.NoError(t, os.WriteFile(in.outfile, []byte(outfile), 0600))
+	return in
+}
+
+func TestHashcatEngine_VerifiedCracks(t *testing.T) {
+	job := &domain.Job{ID: uuid.New(), HashType: 10}
+	in := hashcatRun(t, ""+
+		"5f4dcc3b5aa765d61d8327deb882cf99:password\n"+
+		"d41d8cd98f00b204e9800998ecf8427e:left over from another run\n"+
+		"e10adc3949ba59abbe56e057f20f883e:salt:12:34\n")
+
+	// --show prints the hashes in the hash file's case
+	hashcat := fakeHashcat(t, ""+
+		"E10ADC3949BA59ABBE56E057F20F883E:salt:12:34\n"+
+		"5f4dcc3b5aa765d61d8327deb882cf99:password\n", 0)
+	cracks, err := hashcatEngine{}.verifiedCracks(nil, job, in, agentSettings{HashcatPath: hashcat})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.JobCrackReport{
+		{Hash: "5f4dcc3b5aa765d61d8327deb882cf99", Plaintext: "password"},
+		{Hash: "e10adc3949ba59abbe56e057f20f883e:salt", Plaintext: "12:34"},
+	}, cracks)
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(hashcat), "args.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"-m", "10", "--show", "--potfile-path", hashcatPotfile(in.outfile),
+		"--outfile-format", "1,2", "--outfile-autohex-disable", in.hashFile,
+	}, " "), strings.TrimSpace(string(args)))
+}
+
+func TestHashcatEngine_VerifiedCracksNoneConfirmed(t *testing.T) {
+	job := &domain.Job{ID: uuid.New()}
+	in := hashcatRun(t, "d41d8cd98f00b204e9800998ecf8427e:left over from another run\n")
+
+	_, err := hashcatEngine{}.verifiedCracks(nil, job, in, agentSettings{HashcatPath: fakeHashcat(t, "", 0)})
+	assert.ErrorContains(t, err, "confirmed none")
+
+	_, err = hashcatEngine{}.verifiedCracks(nil, job, in, agentSettings{HashcatPath: fakeHashcat(t, "", 1)})
+	assert.ErrorContains(t, err, "hashcat --show failed")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+)
+
+// enrolledKeyFile in the upload directory keeps the agent key traded for an
+// enrollment token, as the token only works once
+const enrolledKeyFile = "agent-key"
+
+// resolveAgentKey returns the agent key to run with: the configured one,
+// the one saved by an earlier enrollment, or a new one traded for token
+func resolveAgentKey(api *client.Client, configured, token, name, uploadDir string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+
+	path := filepath.Join(uploadDir, enrolledKeyFile)
+	if saved, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(saved)) != "" {
+		return strings.TrimSpace(string(saved)), nil
+	}
+	if token == "" {
+		return "", fmt.Errorf("agent key is required. Please provide --agent-key, HASHCAT_AGENT_KEY or an enrollment token")
+	}
+
+	agent, err := api.Enroll(context.Background(), token, name)
+	if err != nil {
+		return "", fmt.Errorf("enrollment failed: %w", err)
+	}
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to save agent key: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(agent.AgentKey+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to save agent key: %w", err)
+	}
+
+	infrastructure.AgentLogger.Success("Enrolled as %s, agent key saved to %s", agent.Name, path)
+	return agent.AgentKey, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// Where a job's input file came from, reported to the server in the job's
+// file_source field
+const (
+	fileSourceLocal    = "local"
+	fileSourceCache    = "cache"
+	fileSourceDownload = "download"
+)
+
+// verifyFile checks a file against the size and SHA-256 the server expects.
+// Empty expectations are skipped, so jobs for files uploaded before
+// checksums existed still run.
+func verifyFile(path string, size int64, sum string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if size > 0 && info.Size() != size {
+		return fmt.Errorf("size mismatch: have %d bytes, expected %d", info.Size(), size)
+	}
+	if sum == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(actual, sum) {
+		return fmt.Errorf("checksum mismatch: have %s, expected %s", actual, sum)
+	}
+	return nil
+}
+
+// resolveHashFile returns a verified path for the job's hash file: a
+// matching local copy, then the download cache, then a fresh download
+func (a *Agent) resolveHashFile(job *domain.Job) (string, string, error) {
+	if job.HashFileID == nil {
+		// Legacy jobs carry a path only; ensureInUploadDir still applies
+		return job.HashFile, fileSourceLocal, nil
+	}
+
+	if job.HashFile != "" {
+		if path, ok := a.verifiedLocalFile(filepath.Base(job.HashFile), job.HashFileSize, job.HashFileSHA256); ok {
+			infrastructure.AgentLogger.Info("Using local hash file: %s", path)
+			return path, fileSourceLocal, nil
+		}
+	}
+
+	return a.fetchFile("hash_file", *job.HashFileID, job.HashFileSize, job.HashFileSHA256)
+}
+
+// resolveWordlistFile does the same for wordlists referenced by ID. The
+// job's wordlist field holds the original file name, so a pre-staged copy
+// such as rockyou.txt is used when it matches the server's checksum.
+func (a *Agent) resolveWordlistFile(job *domain.Job, wordlistID uuid.UUID) (string, string, error) {
+	if err := domain.ValidateWordlistReference(job.Wordlist); err == nil && job.Wordlist != "" && !strings.Contains(job.Wordlist, "\n") {
+		if path, ok := a.verifiedLocalFile(job.Wordlist, job.WordlistSize, job.WordlistSHA256); ok {
+			infrastructure.AgentLogger.Info("Using local wordlist: %s", path)
+			return path, fileSourceLocal, nil
+		}
+	}
+
+	return a.fetchFile("wordlist", wordlistID, job.WordlistSize, job.WordlistSHA256)
+}
+
+// fetchFile serves kind/id from the download cache, downloading it when
+// missing or stale. Callers pin the entry for the job's lifetime.
+func (a *Agent) fetchFile(kind string, id uuid.UUID, size int64, sum string) (string, string, error) {
+	if path, ok := a.Cache.Get(kind, id, sum); ok {
+		infrastructure.AgentLogger.Info("Using cached %s: %s", kind, path)
+		return path, fileSourceCache, nil
+	}
+
+	entry, path, err := a.downloadToCache(kind, id)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download %s %s: %w", kind, id, err)
+	}
+	if size > 0 && entry.Size != size {
+		a.Cache.Remove(kind, id)
+		return "", "", fmt.Errorf("downloaded %s has %d bytes, expected %d", kind, entry.Size, size)
+	}
+	if sum != "" && !strings.EqualFold(entry.SHA256, sum) {
+		a.Cache.Remove(kind, id)
+		return "", "", fmt.Errorf("downloaded %s checksum %s doesn't match %s", kind, entry.SHA256, sum)
+	}
+	return path, fileSourceDownload, nil
+}
+
+// customCharsets returns the job's custom charsets in -1..-4 order
+func customCharsets(job *domain.Job) []string {
+	return []string{job.CustomCharset1, job.CustomCharset2, job.CustomCharset3, job.CustomCharset4}
+}
+
+// customCharsetArgs builds the hashcat -1..-4 arguments of a job. Inline
+// charsets are passed as they are; charset file UUIDs are fetched through
+// the download cache, which runJob pins for the job's lifetime.
+func (a *Agent) customCharsetArgs(job *domain.Job) ([]string, error) {
+	var args []string
+	for i, charset := range customCharsets(job) {
+		if charset == "" {
+			continue
+		}
+		value := charset
+		if charsetID, err := uuid.Parse(charset); err == nil {
+			path, _, err := a.fetchFile("charset", charsetID, 0, "")
+			if err != nil {
+				return nil, err
+			}
+			if value, err = a.ensureInUploadDir(path); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, fmt.Sprintf("-%d", i+1), value)
+	}
+	return args, nil
+}
+
+// verifiedLocalFile looks a file up by name and only returns it if it
+// matches the expected size and checksum. Without a checksum to compare
+// against, a name match alone is not trusted.
+func (a *Agent) verifiedLocalFile(name string, size int64, sum string) (string, bool) {
+	path, found := a.findLocalFile(name)
+	if !found {
+		return "", false
+	}
+	if sum == "" {
+		infrastructure.AgentLogger.Info("No checksum for %s from server, downloading instead of using %s", name, path)
+		return "", false
+	}
+	if err := verifyFile(path, size, sum); err != nil {
+		infrastructure.AgentLogger.Warning("Local file %s doesn't match server copy (%v), re-downloading", path, err)
+		return "", false
+	}
+	return path, true
+}
+
+// formatFileSource builds the job's file_source value, e.g.
+// "hashfile=local;wordlist=download"
+func formatFileSource(hashFileSource, wordlistSource string) string {
+	parts := make([]string, 0, 2)
+	if hashFileSource != "" {
+		parts = append(parts, "hashfile="+hashFileSource)
+	}
+	if wordlistSource != "" {
+		parts = append(parts, "wordlist="+wordlistSource)
+	}
+	return strings.Join(parts, ";")
+}
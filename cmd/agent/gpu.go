@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// defaultUploadDir keeps the agent's files under the user's home directory,
+// which is /root/uploads for root on Linux
+func defaultUploadDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "uploads"
+	}
+	return filepath.Join(home, "uploads")
+}
+
+// gpuToolOutput runs a GPU tool if it is installed and returns its output,
+// or "" when the tool is missing, fails or prints nothing
+func gpuToolOutput(name string, args ...string) string {
+	if _, err := exec.LookPath(name); err != nil {
+		infrastructure.AgentLogger.Debug("%s command not found", name)
+		return ""
+	}
+
+	infrastructure.AgentLogger.Info("%s command found, checking if GPU is working...", name)
+	output, err := exec.Command(name, args...).Output()
+	if err != nil || len(strings.TrimSpace(string(output))) == 0 {
+		infrastructure.AgentLogger.Warning("%s found but failed to run or no output: %v", name, err)
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// hasNvidiaGPU asks the NVIDIA driver, which ships nvidia-smi on Linux and Windows
+func hasNvidiaGPU() bool {
+	if name := gpuToolOutput("nvidia-smi", "--query-gpu=name", "--format=csv,noheader,nounits"); name != "" {
+		infrastructure.AgentLogger.Success("Detected NVIDIA GPU: %s", name)
+		return true
+	}
+	return false
+}
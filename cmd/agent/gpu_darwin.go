@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// detectHostGPU asks system_profiler for the GPUs hashcat can use through Metal
+func detectHostGPU() bool {
+	output := gpuToolOutput("system_profiler", "SPDisplaysDataType")
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Chipset Model:") {
+			infrastructure.AgentLogger.Success("Detected GPU: %s", strings.TrimSpace(strings.TrimPrefix(line, "Chipset Model:")))
+			return true
+		}
+	}
+	return false
+}
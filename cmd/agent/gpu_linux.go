@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// detectHostGPU checks the vendor tools, then the kernel's view of the devices
+func detectHostGPU() bool {
+	if hasNvidiaGPU() {
+		return true
+	}
+
+	// Check for AMD GPU
+	if output := gpuToolOutput("rocm-smi", "--list-gpus"); output != "" {
+		infrastructure.AgentLogger.Success("Detected AMD GPU (ROCm): %s", output)
+		return true
+	}
+
+	// Check for Intel GPU
+	if output := gpuToolOutput("intel_gpu_top", "-J", "-s", "1"); output != "" {
+		infrastructure.AgentLogger.Success("Detected Intel GPU: %s", output)
+		return true
+	}
+
+	// Additional check: look for GPU devices in /proc
+	if _, err := os.Stat("/proc/driver/nvidia"); err == nil {
+		infrastructure.AgentLogger.Info("Found NVIDIA driver in /proc/driver/nvidia")
+		return true
+	}
+
+	if files, err := os.ReadDir("/sys/class/drm"); err == nil {
+		for _, file := range files {
+			if strings.HasPrefix(file.Name(), "card") && file.Name() != "card0" {
+				infrastructure.AgentLogger.Info("Found GPU device: %s", file.Name())
+				return true
+			}
+		}
+	}
+
+	return false
+}
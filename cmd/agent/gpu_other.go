@@ -0,0 +1,8 @@
+//go:build !linux && !windows && !darwin
+
+package main
+
+// detectHostGPU only knows nvidia-smi on the remaining systems
+func detectHostGPU() bool {
+	return hasNvidiaGPU()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// detectHostGPU checks nvidia-smi, then the display adapters Windows knows about
+func detectHostGPU() bool {
+	if hasNvidiaGPU() {
+		return true
+	}
+
+	output := gpuToolOutput("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Get-CimInstance Win32_VideoController | ForEach-Object { $_.Name }")
+	for _, name := range strings.Split(output, "\n") {
+		name = strings.TrimSpace(name)
+		if isDiscreteGPU(name) {
+			infrastructure.AgentLogger.Success("Detected GPU: %s", name)
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDiscreteGPU skips the adapters Windows lists without a usable GPU
+func isDiscreteGPU(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "" || strings.Contains(lower, "basic display") || strings.Contains(lower, "remote display") {
+		return false
+	}
+	for _, vendor := range []string{"nvidia", "geforce", "quadro", "radeon", "amd", "intel(r) arc"} {
+		if strings.Contains(lower, vendor) {
+			return true
+		}
+	}
+	return false
+}
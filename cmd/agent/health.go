@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// agentHealth is the answer to GET /healthz on the agent's port
+type agentHealth struct {
+	Status          string `json:"status"`
+	AgentID         string `json:"agent_id"`
+	Name            string `json:"name"`
+	ServerReachable bool   `json:"server_reachable"` // Last heartbeat got through
+	CurrentJobID    string `json:"current_job_id,omitempty"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// serveHealth answers liveness probes on port until ctx is done. It only
+// says the agent runs; a server that can't be reached is reported but
+// doesn't fail the probe, restarting the agent wouldn't bring it back.
+func (a *Agent) serveHealth(ctx context.Context, port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		a.link.mu.Lock()
+		reachable := a.link.failures == 0
+		a.link.mu.Unlock()
+
+		health := agentHealth{
+			Status:          "ok",
+			AgentID:         a.ID.String(),
+			Name:            a.Name,
+			ServerReachable: reachable,
+			Timestamp:       time.Now().Unix(),
+		}
+		if job := a.CurrentJob; job != nil {
+			health.CurrentJobID = job.ID.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(health)
+	})
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	infrastructure.AgentLogger.Info("Serving health checks on :%d/healthz", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		infrastructure.AgentLogger.Warning("Health check endpoint stopped: %v", err)
+	}
+}
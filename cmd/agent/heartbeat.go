@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os/exec"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// legacyHeartbeatInterval is used with servers that don't name an interval,
+// which expect a heartbeat every second
+const legacyHeartbeatInterval = time.Second
+
+// heartbeatEvery is the interval the server asked for, else the configured
+// one
+func (a *Agent) heartbeatEvery() time.Duration {
+	if a.heartbeatInterval > 0 {
+		return a.heartbeatInterval
+	}
+	if configured := a.Settings.Get().HeartbeatInterval; configured > 0 {
+		return configured
+	}
+	return legacyHeartbeatInterval
+}
+
+// setHeartbeatInterval applies the interval from a heartbeat response
+func (a *Agent) setHeartbeatInterval(interval time.Duration) {
+	if interval > 0 && interval != a.heartbeatInterval {
+		infrastructure.AgentLogger.Info("Server asked for a heartbeat every %v", interval)
+	}
+	a.heartbeatInterval = interval
+}
+
+// requestedHeartbeatSeconds is the configured interval in whole seconds for
+// the heartbeat request, 0 when the server should decide
+func requestedHeartbeatSeconds(configured time.Duration) int {
+	if configured <= 0 {
+		return 0
+	}
+	return int((configured + time.Second - 1) / time.Second)
+}
+
+// recordJobStatus keeps the latest engine status of a job for the heartbeat
+func (a *Agent) recordJobStatus(jobID uuid.UUID, status jobStatus) {
+	a.statusMu.Lock()
+	a.lastStatus = status
+	a.lastStatusJob = jobID
+	a.statusMu.Unlock()
+}
+
+// heartbeatSnapshot describes what the agent is doing and whether it can
+// take work, for the server's health monitoring and scheduling
+func (a *Agent) heartbeatSnapshot() *domain.AgentHeartbeat {
+	settings := a.Settings.Get()
+	hashcatPath := settings.HashcatPath
+	_, hashcatErr := exec.LookPath(hashcatPath)
+	snapshot := &domain.AgentHeartbeat{
+		GPUUtilization:   -1,
+		FreeDiskBytes:    freeDiskBytes(a.UploadDir),
+		HashcatAvailable: hashcatErr == nil,
+		Engines:          availableEngines(settings),
+	}
+	if hashcatErr == nil {
+		snapshot.HashcatVersion = hashcatVersionOf(hashcatPath)
+	}
+
+	job := a.CurrentJob
+	if job == nil {
+		return snapshot
+	}
+	jobID := job.ID
+	snapshot.CurrentJobID = &jobID
+
+	a.statusMu.Lock()
+	status, statusJob := a.lastStatus, a.lastStatusJob
+	a.statusMu.Unlock()
+
+	// Until the engine prints its first status the job has no progress yet
+	if status != nil && statusJob == jobID {
+		snapshot.JobProgress = status.percent()
+		snapshot.JobSpeed = status.speed()
+		snapshot.GPUUtilization = status.utilization()
+	}
+	return snapshot
+}
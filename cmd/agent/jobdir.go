@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// jobsDirName is the directory under the upload directory holding each
+// job's working directory
+const jobsDirName = "jobs"
+
+// jobDir is the working directory of a job: its outfile, restore files and
+// the wordlists it was sent as content. No other job writes there, so jobs
+// on agents sharing an upload directory can't collide.
+func (a *Agent) jobDir(jobID uuid.UUID) string {
+	return filepath.Join(a.UploadDir, jobsDirName, jobID.String())
+}
+
+// jobOutfile is where the engine writes a job's cracks
+func (a *Agent) jobOutfile(jobID uuid.UUID) string {
+	return filepath.Join(a.jobDir(jobID), "cracked.txt")
+}
+
+// createJobDir gives a job an empty working directory. Whatever an earlier
+// run of the job left there is removed, so its cracks aren't taken for
+// this run's.
+func (a *Agent) createJobDir(jobID uuid.UUID) (string, error) {
+	dir := a.jobDir(jobID)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to clear job directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create job directory: %w", err)
+	}
+	return dir, nil
+}
+
+// cleanupJobFiles removes a finished job's working directory, or keeps it
+// for job-dir-retention to debug the run
+func (a *Agent) cleanupJobFiles(jobID uuid.UUID) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
+	dir := a.jobDir(jobID)
+
+	if retention := a.Settings.Get().JobDirRetention; retention > 0 {
+		// The retention counts from the end of the job
+		now := time.Now()
+		os.Chtimes(dir, now, now)
+		logger.Info("Keeping job directory %s for %v", dir, retention)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warning("Failed to clean up job directory %s: %v", dir, err)
+		return
+	}
+	logger.Info("Cleaned up job directory: %s", dir)
+}
+
+// removeExpiredJobDirs deletes the working directories of jobs that ended
+// more than maxAge ago, kept for debugging or left behind when the agent
+// died. The running job's directory is kept.
+func (a *Agent) removeExpiredJobDirs(maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var running string
+	if job := a.CurrentJob; job != nil {
+		running = job.ID.String()
+	}
+
+	entries, err := os.ReadDir(filepath.Join(a.UploadDir, jobsDirName))
+	if err != nil {
+		return
+	}
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == running {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		path := filepath.Join(a.UploadDir, jobsDirName, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			infrastructure.AgentLogger.Warning("Failed to remove job directory %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		infrastructure.AgentLogger.Info("Removed %d job directories older than %v", removed, maxAge)
+	}
+}
+
+// jobDirMaxAge is how long a job directory outlives its job: the
+// retention when one is set, else the age of orphaned temp files
+func (s agentSettings) jobDirMaxAge() time.Duration {
+	if s.JobDirRetention > 0 {
+		return s.JobDirRetention
+	}
+	return s.TempMaxAge
+}
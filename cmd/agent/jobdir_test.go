@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJobDir(t *testing.T) {
+	a := &Agent{UploadDir: t.TempDir()}
+	jobID := uuid.New()
+
+	dir, err := a.createJobDir(jobID)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(a.UploadDir, "jobs", jobID.String()), dir)
+	assert.Equal(t, dir, filepath.Dir(a.jobOutfile(jobID)))
+
+	// A second run of the job doesn't see the cracks of the first
+	require.NoError(t, os.WriteFile(a.jobOutfile(jobID), []byte("aaa:one\n"), 0600))
+	_, err = a.createJobDir(jobID)
+	require.NoError(t, err)
+	assert.NoFileExists(t, a.jobOutfile(jobID))
+	assert.DirExists(t, dir)
+}
+
+func TestCleanupJobFiles(t *testing.T) {
+	a := &Agent{UploadDir: t.TempDir(), Settings: &liveSettings{}}
+	jobID := uuid.New()
+	dir, err := a.createJobDir(jobID)
+	require.NoError(t, err)
+	a.cleanupJobFiles(jobID)
+	assert.NoDirExists(t, dir)
+
+	// With a retention the directory stays, its age counting from now
+	a.Settings = &liveSettings{settings: agentSettings{JobDirRetention: time.Hour}}
+	dir, err = a.createJobDir(jobID)
+	require.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(dir, old, old))
+	a.cleanupJobFiles(jobID)
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+}
+
+func TestRemoveExpiredJobDirs(t *testing.T) {
+	a := &Agent{UploadDir: t.TempDir()}
+	old := time.Now().Add(-2 * time.Hour)
+	dirAged := func(age time.Time) string {
+		dir, err := a.createJobDir(uuid.New())
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(dir, age, age))
+		return dir
+	}
+	expired := dirAged(old)
+	recent := dirAged(time.Now())
+	running := uuid.New()
+	runningDir, err := a.createJobDir(running)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(runningDir, old, old))
+	a.CurrentJob = &domain.Job{ID: running}
+
+	a.removeExpiredJobDirs(0)
+	assert.DirExists(t, expired, "no maximum age keeps everything")
+
+	a.removeExpiredJobDirs(time.Hour)
+	assert.NoDirExists(t, expired)
+	assert.DirExists(t, recent)
+	assert.DirExists(t, runningDir, "the running job's directory is kept")
+}
+
+func TestJobDirMaxAge(t *testing.T) {
+	assert.Equal(t, 24*time.Hour, agentSettings{TempMaxAge: 24 * time.Hour}.jobDirMaxAge())
+	assert.Equal(t, time.Hour, agentSettings{TempMaxAge: 24 * time.Hour, JobDirRetention: time.Hour}.jobDirMaxAge())
+}
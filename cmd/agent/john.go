@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// johnEngine runs jobs with John the Ripper. It needs a jumbo build for
+// --format names, masks, --no-log and --progress-every.
+type johnEngine struct{}
+
+func (johnEngine) displayName() string { return "John the Ripper" }
+
+func (johnEngine) binary(settings agentSettings) string { return settings.JohnPath }
+
+func (johnEngine) buildArgs(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]string, error) {
+	if (job.Skip != nil && *job.Skip > 0) || (job.WordLimit != nil && *job.WordLimit > 0) {
+		return nil, fmt.Errorf("john jobs can't run a slice of the keyspace")
+	}
+
+	args := []string{
+		"--format=" + job.JohnFormat,
+		// A pot file per job, so hashes cracked earlier don't hide this run's result
+		"--pot=" + in.outfile,
+		"--session=" + johnSession(in.outfile, job),
+		"--no-log",
+		"--progress-every=2",
+	}
+	if job.AttackMode == domain.AttackModeBruteForce {
+		args = append(args, "--mask="+in.wordlist)
+	} else {
+		args = append(args, "--wordlist="+in.wordlist)
+	}
+	return append(args, in.hashFile), nil
+}
+
+// johnSession is where john keeps the job's restore file, next to the outfile
+func johnSession(outfile string, job *domain.Job) string {
+	return filepath.Join(filepath.Dir(outfile), "john-"+job.ID.String())
+}
+
+func (johnEngine) parseStatus(line []byte) (jobStatus, bool) {
+	status, ok := parseJohnStatus(line)
+	if !ok {
+		return nil, false
+	}
+	return status, true
+}
+
+// john exits with 0 whether or not it cracked anything
+func (johnEngine) outcome(exitCode int) runOutcome {
+	return runFailed
+}
+
+// verifiedCracks asks john which hashes of the hash file its pot file
+// cracks and keeps the pot file's cracks with those passwords. john --show
+// prints logins rather than hashes, so the hashes come from the pot file.
+func (e johnEngine) verifiedCracks(a *Agent, job *domain.Job, in engineInputs, settings agentSettings) ([]domain.JobCrackReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crackVerifyTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, settings.JohnPath, "--show", "--format="+job.JohnFormat, "--pot="+in.outfile, in.hashFile).Output()
+	if err != nil {
+		return nil, fmt.Errorf("john --show failed: %w", err)
+	}
+	passwords := parseJohnShow(output)
+	if len(passwords) == 0 {
+		return nil, errNoPassword
+	}
+
+	found, err := readCracks(in.outfile, e.crackParser(in))
+	if err != nil {
+		return nil, err
+	}
+	shown := make(map[string]bool, len(passwords))
+	for _, password := range passwords {
+		shown[password] = true
+	}
+	var cracks []domain.JobCrackReport
+	for _, crack := range found {
+		if shown[crack.Plaintext] {
+			cracks = append(cracks, crack)
+		}
+	}
+	if len(cracks) == 0 {
+		return nil, errors.New("john --show confirmed none of the cracks in the pot file")
+	}
+	return cracks, nil
+}
+
+// crackParser reads john's pot file, hash:plain lines whose hash john
+// writes without colons
+func (johnEngine) crackParser(in engineInputs) crackParser {
+	return func(line string) (string, string, bool) {
+		return strings.Cut(line, ":")
+	}
+}
+
+// johnStatus is one status line john prints with --progress-every, e.g.
+// "0g 0:00:00:04 17.09% (ETA: 12:00:23) 0g/s 2349Kp/s 2349Kc/s 2349KC/s a..b"
+type johnStatus struct {
+	guesses  int64
+	progress float64 // Percent, 0 when john can't tell
+	combos   int64   // Candidate and hash combinations per second
+}
+
+var johnStatusPattern = regexp.MustCompile(`^(\d+)g \d+:\d\d:\d\d:\d\d\s+(?:(DONE)|(\d+(?:\.\d+)?)%)?.*?\s(\d+(?:\.\d+)?)([KMGT]?)C/s`)
+
+// parseJohnStatus decodes a status line. Anything else, such as cracked
+// passwords or warnings, is ignored.
+func parseJohnStatus(line []byte) (*johnStatus, bool) {
+	match := johnStatusPattern.FindSubmatch(bytes.TrimSpace(line))
+	if match == nil {
+		return nil, false
+	}
+
+	status := &johnStatus{}
+	status.guesses, _ = strconv.ParseInt(string(match[1]), 10, 64)
+	switch {
+	case match[2] != nil:
+		status.progress = 100
+	case match[3] != nil:
+		status.progress, _ = strconv.ParseFloat(string(match[3]), 64)
+	}
+
+	speed, _ := strconv.ParseFloat(string(match[4]), 64)
+	switch string(match[5]) {
+	case "K":
+		speed *= 1e3
+	case "M":
+		speed *= 1e6
+	case "G":
+		speed *= 1e9
+	case "T":
+		speed *= 1e12
+	}
+	status.combos = int64(speed)
+	return status, true
+}
+
+func (s *johnStatus) percent() float64 { return s.progress }
+
+func (s *johnStatus) speed() int64 { return s.combos }
+
+// john doesn't report device utilization
+func (s *johnStatus) utilization() int { return -1 }
+
+// john prints its ETA in local time without a date, so it isn't passed on
+func (s *johnStatus) eta() *string { return nil }
+
+func (s *johnStatus) stats() *domain.JobRuntimeStats { return nil }
+
+// parseJohnShow returns the passwords of john --show output. Lines
+// are "login:password[:fields]"; bare hashes get the login "?", and their
+// password is the rest of the line.
+func parseJohnShow(output []byte) []string {
+	var passwords []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimRight(line, "\r")
+		login, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue // Blank line or the "N password hashes cracked" summary
+		}
+		if login != "?" {
+			rest, _, _ = strings.Cut(rest, ":")
+		}
+		passwords = append(passwords, rest)
+	}
+	return passwords
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// Limits of the log buffer shipped to the server
+const (
+	logBufferLines = 2000 // Oldest lines are dropped beyond this
+	logShipBatch   = 500  // Lines sent per request
+)
+
+// agentLogs collects the agent's log output and hashcat's console output
+// until they are shipped to the server. It exists before the agent
+// registers, so startup lines are shipped too.
+var agentLogs = newLogBuffer(logBufferLines)
+
+// logBuffer is a bounded buffer of log lines. As an io.Writer it takes the
+// logger's output, one line per write or split on newlines.
+type logBuffer struct {
+	mu      sync.Mutex
+	lines   []domain.AgentLogLine
+	max     int
+	dropped int    // Lines lost to a full buffer since the last ship
+	partial []byte // Unterminated tail of the last write
+}
+
+func newLogBuffer(max int) *logBuffer {
+	return &logBuffer{max: max}
+}
+
+// logOutput is where the agent's logger writes: the console and the buffer
+func logOutput() io.Writer {
+	return io.MultiWriter(os.Stderr, agentLogs)
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.addLocked(domain.AgentLogSourceAgent, string(data[:i]))
+		data = data[i+1:]
+	}
+	b.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// add buffers one line of output
+func (b *logBuffer) add(source, message string) {
+	b.mu.Lock()
+	b.addLocked(source, message)
+	b.mu.Unlock()
+}
+
+func (b *logBuffer) addLocked(source, message string) {
+	message = strings.TrimRight(message, "\r\n ")
+	if message == "" {
+		return
+	}
+
+	b.lines = append(b.lines, domain.AgentLogLine{Time: time.Now(), Source: source, Message: message})
+	if over := len(b.lines) - b.max; over > 0 {
+		b.lines = append(b.lines[:0], b.lines[over:]...)
+		b.dropped += over
+	}
+}
+
+// take removes up to n of the oldest lines for shipping. A note about lines
+// lost to a full buffer goes first.
+func (b *logBuffer) take(n int) []domain.AgentLogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > len(b.lines) {
+		n = len(b.lines)
+	}
+	batch := make([]domain.AgentLogLine, 0, n+1)
+	if b.dropped > 0 {
+		batch = append(batch, domain.AgentLogLine{
+			Time:    time.Now(),
+			Source:  domain.AgentLogSourceAgent,
+			Message: fmt.Sprintf("%d log lines dropped while the log buffer was full", b.dropped),
+		})
+		b.dropped = 0
+	}
+	batch = append(batch, b.lines[:n]...)
+	b.lines = append(b.lines[:0], b.lines[n:]...)
+	return batch
+}
+
+// putBack returns lines that couldn't be shipped, ahead of newer ones
+func (b *logBuffer) putBack(lines []domain.AgentLogLine) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines = append(append([]domain.AgentLogLine(nil), lines...), b.lines...)
+	if over := len(b.lines) - b.max; over > 0 {
+		b.lines = b.lines[over:]
+		b.dropped += over
+	}
+}
+
+// shipLogs sends the buffered log lines to the server every log ship
+// interval. An interval of 0 turns shipping off; the buffer is then cleared
+// so it doesn't ship stale lines when shipping is turned back on.
+func (a *Agent) shipLogs(ctx context.Context) {
+	interval := logShipTick(a.Settings.Get().LogShipInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			shipInterval := a.Settings.Get().LogShipInterval
+			resetTicker(ticker, &interval, logShipTick(shipInterval))
+			if shipInterval <= 0 {
+				agentLogs.take(logBufferLines)
+				continue
+			}
+			if a.ID == uuid.Nil {
+				continue // Not registered yet
+			}
+
+			for {
+				batch := agentLogs.take(logShipBatch)
+				if len(batch) == 0 {
+					break
+				}
+				if err := a.pushLogs(batch); err != nil {
+					agentLogs.putBack(batch)
+					infrastructure.AgentLogger.Debug("Failed to ship logs: %v", err)
+					break
+				}
+				if len(batch) < logShipBatch {
+					break
+				}
+			}
+		}
+	}
+}
+
+// logShipTick is how often shipLogs wakes up; it keeps checking while
+// shipping is off so turning it back on takes effect
+func logShipTick(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 5 * time.Second
+	}
+	return interval
+}
+
+func (a *Agent) pushLogs(lines []domain.AgentLogLine) error {
+	return a.API.PushAgentLogs(context.Background(), a.ID, lines)
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
 	"context"
 	"crypto/md5"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,11 +15,14 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
 	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/infrastructure/tracing"
+	"go-distributed-hashcat/pkg/client"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -30,7 +33,7 @@ type Agent struct {
 	ID           uuid.UUID
 	Name         string
 	ServerURL    string
-	Client       *http.Client
+	API          *client.Client
 	CurrentJob   *domain.Job
 	UploadDir    string
 	LocalFiles   map[string]LocalFile // filename -> LocalFile
@@ -38,26 +41,35 @@ type Agent struct {
 	OriginalPort int                  // Store original port from database
 	ServerIP     string               // Store server IP for validation
 	Status       string               // Current agent status (online, offline, busy)
-}
+	Cache        *downloadCache       // Downloaded hash files and wordlists
+	Outbox       *outbox              // Job reports waiting for the server
+	Settings     *liveSettings        // Tunable values, reloaded on SIGHUP
 
-type LocalFile struct {
-	Name    string    `json:"name"`
-	Path    string    `json:"path"`
-	Size    int64     `json:"size"`
-	Type    string    `json:"type"` // wordlist, hash_file
-	Hash    string    `json:"hash"` // MD5 hash for integrity
-	ModTime time.Time `json:"mod_time"`
-}
+	cacheReportVersion int64     // Cache version last reported to the server
+	cacheReportedAt    time.Time // When the cache was last reported
+
+	// Heartbeat interval the server asked for, 0 until it answers
+	heartbeatInterval time.Duration
+
+	statusMu      sync.Mutex // Guards lastStatus and lastStatusJob
+	lastStatus    jobStatus  // Latest engine status of the running job
+	lastStatusJob uuid.UUID
 
-type AgentInfo struct {
-	ID           uuid.UUID `json:"id"`
-	Name         string    `json:"name"`
-	IPAddress    string    `json:"ip_address"`
-	Port         int       `json:"port"`
-	Capabilities string    `json:"capabilities"`
-	AgentKey     string    `json:"agent_key"`
+	runMu   sync.Mutex  // Guards running
+	running *runningJob // Engine process of the current job
+
+	// Held while a job is taken and while a benchmark command runs, so
+	// they don't share the devices
+	devicesMu sync.Mutex
+	commands  *commandRunner // Commands the server queued for the agent
+	restart   chan struct{}  // Asks runAgent to shut down, e.g. after an upgrade
+
+	link serverLink // Whether the server can be reached
 }
 
+// LocalFile is a file in the upload directory, reported to the server as is
+type LocalFile = client.LocalFile
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "agent",
@@ -71,9 +83,43 @@ func main() {
 	rootCmd.Flags().Int("port", 8081, "Agent port")
 	rootCmd.Flags().String("capabilities", "auto", "Agent capabilities (auto, CPU, GPU, or custom)")
 	rootCmd.Flags().String("agent-key", "", "Agent key")
-	rootCmd.Flags().String("upload-dir", "/root/uploads", "Local uploads directory")
+	rootCmd.Flags().String("enrollment-token", "", "Single-use token traded for an agent key on first start, when no agent key is set")
+	rootCmd.Flags().String("upload-dir", defaultUploadDir(), "Local uploads directory")
+	rootCmd.Flags().Int64("cache-size-mb", 10240, "Download cache size limit in MB (0 for unlimited)")
+	rootCmd.Flags().Int64("download-rate-limit", 0, "Download bandwidth limit in KB/s (0 for unlimited)")
+	rootCmd.Flags().Duration("download-queue-timeout", time.Hour, "How long to wait for a turn when the server is busy serving a file")
+	rootCmd.Flags().Int64("disk-quota-mb", 0, "Size limit of the upload directory in MB; downloads that would exceed it fail the job (0 for unlimited)")
+	rootCmd.Flags().Int64("disk-reserve-mb", 1024, "Free disk space in MB downloads must leave on the upload directory's disk")
+	rootCmd.Flags().Duration("temp-max-age", 24*time.Hour, "Delete temp files and unfinished downloads untouched for this long (0 to keep them)")
+	rootCmd.Flags().Duration("temp-cleanup-interval", time.Hour, "How often to look for orphaned temp files")
+	rootCmd.Flags().Duration("job-dir-retention", 0, "Keep a finished job's working directory this long for debugging (0 removes it when the job ends)")
+	rootCmd.Flags().String("container", "auto", "Container mode for GPU and IP detection (auto, on, off)")
+	rootCmd.Flags().String("log-format", "text", "Log format (text, json)")
+	rootCmd.Flags().String("log-level", "info", "Minimum log level (debug, info, warning, error)")
+	rootCmd.Flags().String("config", "", "Config file (default "+defaultConfigPath+" or ./agent.yaml if it exists)")
+	rootCmd.Flags().Duration("heartbeat-interval", 0, "How often to send a heartbeat, 0 lets the server decide")
+	rootCmd.Flags().Duration("poll-interval", 10*time.Second, "How often to ask the server for a job")
+	rootCmd.Flags().Duration("status-interval", 5*time.Second, "How often to check the status of the running job")
+	rootCmd.Flags().Duration("file-scan-interval", 5*time.Minute, "How often to rescan local wordlists and hash files")
+	rootCmd.Flags().Duration("log-ship-interval", 5*time.Second, "How often to send recent log lines to the server (0 to keep logs local)")
+	rootCmd.Flags().String("hashcat-path", "hashcat", "hashcat binary, looked up on PATH unless it is a path (e.g. /opt/hashcat/hashcat.bin)")
+	rootCmd.Flags().String("john-path", "john", "John the Ripper (jumbo) binary for john jobs, looked up on PATH unless it is a path")
+	rootCmd.Flags().String("upgrade-command", "", "Shell command run for an upgrade command from the server, with the version in HASHCAT_AGENT_UPGRADE_VERSION; the agent restarts afterwards (empty refuses upgrades)")
+	rootCmd.Flags().Int("output-tail-kb", 64, "KB of hashcat's stdout and stderr kept per job and sent to the server when it ends")
+	rootCmd.Flags().Int("workload-profile", 4, "hashcat workload profile, 1 (low) to 4 (nightmare)")
+	rootCmd.Flags().Int("temp-abort", 0, "Abort hashcat when a GPU reaches this temperature in °C (0 for hashcat's default)")
+	rootCmd.Flags().Float64("sandbox-cpus", 0, "CPU cores a job may use, enforced with a cgroup (0 for unlimited, Linux only)")
+	rootCmd.Flags().Int64("sandbox-memory-mb", 0, "Memory a job may use in MB, enforced with a cgroup (0 for unlimited, Linux only)")
+	rootCmd.Flags().String("sandbox-cgroup", defaultCgroupParent, "cgroup v2 directory the per-job cgroups are created in")
+	rootCmd.Flags().Int("sandbox-nice", 0, "Scheduling priority of jobs, 0 (normal) to 19 (lowest, Linux only)")
+	rootCmd.Flags().String("sandbox-ionice", ioniceNone, "I/O scheduling class of jobs (none, best-effort, idle; Linux only)")
+	rootCmd.Flags().String("sandbox-user", "", "Unprivileged user jobs run as; the agent must run as root (Linux only)")
+	rootCmd.Flags().Bool("sandbox-jail", false, "Run jobs in their job directory with HOME and TMPDIR inside the upload directory")
+	rootCmd.Flags().String("trace-endpoint", "", "OTLP/HTTP collector URL for traces, e.g. http://localhost:4318 (empty disables tracing)")
+	rootCmd.Flags().Float64("trace-sample-ratio", 1, "Share of traces to record, 0 to 1")
 
 	viper.BindPFlags(rootCmd.Flags())
+	bindAgentEnv()
 
 	if err := rootCmd.Execute(); err != nil {
 		infrastructure.AgentLogger.Fatal("%v", err)
@@ -81,6 +127,35 @@ func main() {
 }
 
 func runAgent(cmd *cobra.Command, args []string) {
+	loadDotEnv()
+	loadAgentConfigFile()
+	if err := infrastructure.ConfigureLogging(viper.GetString("log-format"), viper.GetString("log-level"), logOutput()); err != nil {
+		infrastructure.AgentLogger.Fatal("Invalid logging config: %v", err)
+	}
+
+	// Calls to the server are traced and carry the trace context, so the
+	// server's spans join the agent's traces
+	traceEndpoint := viper.GetString("trace-endpoint")
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Enabled:     traceEndpoint != "",
+		Endpoint:    traceEndpoint,
+		ServiceName: "hashcat-agent",
+		SampleRatio: viper.GetFloat64("trace-sample-ratio"),
+	}, "hashcat-agent")
+	if err != nil {
+		infrastructure.AgentLogger.Fatal("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	containerMode = detectContainerMode()
+	if containerMode {
+		infrastructure.AgentLogger.Info("Running in container mode")
+	}
+
 	serverURL := viper.GetString("server")
 	name := viper.GetString("name")
 	ip := viper.GetString("ip")
@@ -88,25 +163,45 @@ func runAgent(cmd *cobra.Command, args []string) {
 	capabilities := viper.GetString("capabilities")
 	agentKey := viper.GetString("agent-key")
 	uploadDir := viper.GetString("upload-dir")
+	settings := readSettings()
 
-	if agentKey == "" {
-		infrastructure.AgentLogger.Fatal("Agent key is required. Please provide --agent-key parameter.")
+	// If name is empty, use hostname
+	if name == "" {
+		hostname, _ := os.Hostname()
+		name = fmt.Sprintf("agent-%s", hostname)
 	}
 
-	// Create temporary agent client to check agent key
-	tempAgent := &Agent{
-		ServerURL: serverURL,
-		Client:    &http.Client{Timeout: 30 * time.Second},
+	api := newServerClient(serverURL)
+
+	agentKey, err = resolveAgentKey(api, agentKey, viper.GetString("enrollment-token"), name, uploadDir)
+	if err != nil {
+		infrastructure.AgentLogger.Fatal("%v", err)
 	}
 
 	// Check if agent key exists in database
-	info, lookupErr := getAgentByKeyOnly(tempAgent, agentKey)
+	info, lookupErr := api.GetAgentByKey(context.Background(), agentKey)
 	if lookupErr != nil {
 		infrastructure.AgentLogger.Fatal("Agent key '%s' not registered in the database. Agent failed to run.", agentKey)
 	}
 
-	// Validate IP address with local IP
-	if ip != "" {
+	// Settings managed on the server apply from the start, so an upload
+	// directory set there is used before anything is stored
+	var remoteSettings domain.AgentSettings
+	if fetched, err := api.GetAgentSettings(context.Background(), info.ID); err != nil {
+		infrastructure.AgentLogger.Warning("Failed to get agent settings from the server: %v", err)
+	} else {
+		remoteSettings = *fetched
+		if remoteSettings.UploadDir != "" && remoteSettings.UploadDir != uploadDir {
+			infrastructure.AgentLogger.Info("Using upload directory %s set on the server", remoteSettings.UploadDir)
+			uploadDir = remoteSettings.UploadDir
+		}
+	}
+
+	// Validate IP address with local IP. A container usually advertises the
+	// host's address, which its own interfaces don't have.
+	if ip != "" && containerMode {
+		infrastructure.AgentLogger.Info("Container mode: advertising %s without checking local interfaces", ip)
+	} else if ip != "" {
 		if err := validateLocalIP(ip); err != nil {
 			infrastructure.AgentLogger.Fatal("%v", err)
 		}
@@ -116,10 +211,13 @@ func runAgent(cmd *cobra.Command, args []string) {
 		infrastructure.AgentLogger.Info("Auto-detected local IP: %s", ip)
 	}
 
+	logHashcatVersion(settings.HashcatPath)
+	infrastructure.AgentLogger.Info("Job sandbox: %s", settings.Sandbox)
+
 	// Auto-detect capabilities using hashcat -I if not specified or empty
 	if capabilities == "" || capabilities == "auto" {
 		infrastructure.AgentLogger.Info("Auto-detection mode: Running hashcat -I to detect capabilities...")
-		capabilities = detectCapabilitiesWithHashcat()
+		capabilities = detectCapabilitiesWithHashcat(settings.HashcatPath)
 		infrastructure.AgentLogger.Success("Auto-detected capabilities using hashcat -I: %s", capabilities)
 	} else {
 		infrastructure.AgentLogger.Info("Using manually specified capabilities: %s", capabilities)
@@ -128,7 +226,8 @@ func runAgent(cmd *cobra.Command, args []string) {
 	// Update capabilities in database if different from detected
 	if info.Capabilities == "" || info.Capabilities != capabilities {
 		infrastructure.AgentLogger.Info("Updating capabilities from '%s' to '%s'", info.Capabilities, capabilities)
-		if err := updateAgentCapabilities(tempAgent, agentKey, capabilities); err != nil {
+		err := api.UpdateAgentData(context.Background(), client.UpdateAgentDataRequest{AgentKey: agentKey, Capabilities: capabilities})
+		if err != nil {
 			infrastructure.AgentLogger.Warning("Failed to update capabilities: %v", err)
 		} else {
 			infrastructure.AgentLogger.Success("Capabilities updated successfully")
@@ -137,12 +236,6 @@ func runAgent(cmd *cobra.Command, args []string) {
 		infrastructure.AgentLogger.Info("Capabilities already up-to-date: %s", capabilities)
 	}
 
-	// If name is empty, use hostname
-	if name == "" {
-		hostname, _ := os.Hostname()
-		name = fmt.Sprintf("agent-%s", hostname)
-	}
-
 	// Save original port from database for restoration
 	originalPort := info.Port
 	if originalPort == 0 {
@@ -154,12 +247,15 @@ func runAgent(cmd *cobra.Command, args []string) {
 		ID:           info.ID,
 		Name:         name,
 		ServerURL:    serverURL,
-		Client:       &http.Client{Timeout: 30 * time.Second},
+		API:          api,
 		UploadDir:    uploadDir,
 		LocalFiles:   make(map[string]LocalFile),
 		AgentKey:     agentKey,
 		OriginalPort: originalPort, // Store original port from database
 		ServerIP:     ip,           // Store server IP for validation
+		Settings:     &liveSettings{settings: settings, remote: remoteSettings},
+		commands:     newCommandRunner(),
+		restart:      make(chan struct{}, 1),
 	}
 
 	// Inisialisasi direktori
@@ -167,6 +263,20 @@ func runAgent(cmd *cobra.Command, args []string) {
 		infrastructure.AgentLogger.Fatal("Failed to initialize directories: %v", err)
 	}
 
+	cache, err := newDownloadCache(filepath.Join(uploadDir, "cache"), settings.CacheSizeMB*1024*1024)
+	if err != nil {
+		infrastructure.AgentLogger.Fatal("Failed to initialize download cache: %v", err)
+	}
+	agent.Cache = cache
+	agent.removeOrphanedFiles(settings.TempMaxAge)
+	agent.removeExpiredJobDirs(settings.jobDirMaxAge())
+
+	outbox, err := newOutbox(filepath.Join(uploadDir, "outbox"))
+	if err != nil {
+		infrastructure.AgentLogger.Fatal("Failed to initialize outbox: %v", err)
+	}
+	agent.Outbox = outbox
+
 	if err := agent.scanLocalFiles(); err != nil {
 		infrastructure.AgentLogger.Warning("Failed to scan local files: %v", err)
 	}
@@ -227,6 +337,9 @@ func runAgent(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// The ID is known now, tag every following line with it
+	infrastructure.AgentLogger = infrastructure.AgentLogger.With("agent_id", agent.ID)
+
 	// Update status to online and port to 8081 when agent starts running
 	infrastructure.AgentLogger.Info("Updating agent status to online and port to 8081...")
 	if err := agent.updateAgentInfo(agent.ID, ip, 8081, capabilities, "online"); err != nil {
@@ -262,14 +375,47 @@ func runAgent(cmd *cobra.Command, args []string) {
 
 	go agent.startHeartbeat(ctx)
 	go agent.pollForJobs(ctx)
+	go agent.runCommands(ctx)
 	go agent.watchLocalFiles(ctx)
+	go agent.shipLogs(ctx)
+	go agent.flushOutbox(ctx)
+	go agent.cleanupOrphanedFiles(ctx)
+	go agent.serveHealth(ctx, port)
+
+	// SIGHUP reloads the tunable settings without touching the running job
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				agent.reloadConfig()
+			}
+		}
+	}()
 
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-quit:
+	case <-agent.restart:
+		// The supervisor (systemd, Docker) starts the upgraded binary
+		infrastructure.AgentLogger.Info("Restarting to finish the upgrade")
+	}
 
 	infrastructure.AgentLogger.Info("Shutting down agent...")
 
+	// Take no new jobs, and hand the running one to another agent
+	cancel()
+	agent.interruptRunningJob(jobHandoffTimeout)
+
+	// Last try for undelivered reports; the rest go out on the next start
+	if !agent.flushOutboxOnce() {
+		infrastructure.AgentLogger.Warning("Some job reports couldn't be delivered, they are kept for the next start")
+	}
+
 	// Update status to offline and restore original port 8080 before shutdown
 	infrastructure.AgentLogger.Info("Updating agent status to offline and restoring port to 8080...")
 	infrastructure.AgentLogger.Info("Preserving capabilities: %s", capabilities)
@@ -297,89 +443,40 @@ func runAgent(cmd *cobra.Command, args []string) {
 	infrastructure.AgentLogger.Info("Agent exited")
 }
 
-func getAgentByKeyOnly(a *Agent, key string) (AgentInfo, error) {
-	var info AgentInfo
-	url := fmt.Sprintf("%s/api/v1/agents/?agent_key=%s", a.ServerURL, key)
-	resp, err := a.Client.Get(url)
-	if err != nil {
-		return info, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return info, fmt.Errorf("failed to get agent: %s", string(body))
-	}
-
-	var res struct {
-		Data []AgentInfo `json:"data"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		return info, err
-	}
-
-	if len(res.Data) == 0 {
-		return info, fmt.Errorf("agent key not found")
-	}
-
-	return res.Data[0], nil
+// newServerClient returns the client for calls to the server. Heartbeats are
+// left out of tracing, one span a second per agent would drown everything else.
+// Requests aren't retried by the client; heartbeats back off on their own
+// and job reports go through the outbox.
+func newServerClient(serverURL string) *client.Client {
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: tracing.Transport(nil, func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/heartbeat")
+		}),
+	}
+	return client.New(serverURL,
+		client.WithHTTPClient(httpClient),
+		client.WithUserAgent("hashcat-agent"),
+		client.WithRetries(0))
 }
 
 func (a *Agent) updateAgentInfo(agentID uuid.UUID, ip string, port int, capabilities string, status string) error {
-	// Use the correct endpoint for updating agent data
-	req := struct {
-		AgentKey     string `json:"agent_key"`
-		IPAddress    string `json:"ip_address"`
-		Port         int    `json:"port"`
-		Capabilities string `json:"capabilities"`
-	}{
+	ctx := context.Background()
+	req := client.UpdateAgentDataRequest{
 		AgentKey:     a.AgentKey,
 		IPAddress:    ip,
 		Port:         port,
 		Capabilities: capabilities,
 	}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/agents/update-data", a.ServerURL)
-
-	httpReq, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
+	if err := a.API.UpdateAgentData(ctx, req); err != nil {
 		return fmt.Errorf("failed to update agent data: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update agent data: %s", string(body))
-	}
 
 	// If status needs to be updated, use the status endpoint
 	if status != "" {
-		statusReq := struct {
-			Status string `json:"status"`
-		}{
-			Status: status,
-		}
-
-		statusData, _ := json.Marshal(statusReq)
-		statusURL := fmt.Sprintf("%s/api/v1/agents/%s/status", a.ServerURL, agentID.String())
-
-		statusHttpReq, _ := http.NewRequest(http.MethodPut, statusURL, bytes.NewBuffer(statusData))
-		statusHttpReq.Header.Set("Content-Type", "application/json")
-
-		statusResp, err := a.Client.Do(statusHttpReq)
-		if err != nil {
+		if err := a.API.UpdateAgentStatus(ctx, agentID, status); err != nil {
 			return fmt.Errorf("failed to update agent status: %w", err)
 		}
-		defer statusResp.Body.Close()
-
-		if statusResp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(statusResp.Body)
-			return fmt.Errorf("failed to update agent status: %s", string(body))
-		}
 	}
 
 	return nil
@@ -391,6 +488,7 @@ func (a *Agent) initializeDirectories() error {
 		filepath.Join(a.UploadDir, "wordlists"),
 		filepath.Join(a.UploadDir, "hash-files"),
 		filepath.Join(a.UploadDir, "temp"),
+		filepath.Join(a.UploadDir, jobsDirName),
 	}
 
 	for _, dir := range dirs {
@@ -442,6 +540,10 @@ func (a *Agent) scanDirectory(dir, fileType string) error {
 		}
 
 		if info.IsDir() {
+			// Cached downloads are tracked by the download cache, not as local files
+			if path == filepath.Join(a.UploadDir, "cache") {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -516,7 +618,8 @@ func (a *Agent) calculateFileHash(path string) (string, error) {
 }
 
 func (a *Agent) watchLocalFiles(ctx context.Context) {
-	ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes
+	interval := a.Settings.Get().FileScanInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -524,6 +627,7 @@ func (a *Agent) watchLocalFiles(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			resetTicker(ticker, &interval, a.Settings.Get().FileScanInterval)
 			oldCount := len(a.LocalFiles)
 			if err := a.scanLocalFiles(); err != nil {
 				infrastructure.AgentLogger.Error("Error rescanning local files: %v", err)
@@ -548,29 +652,8 @@ func (a *Agent) registerLocalFiles() error {
 
 	infrastructure.AgentLogger.Info("Registering local files with server...")
 
-	req := struct {
-		AgentID uuid.UUID            `json:"agent_id"`
-		Files   map[string]LocalFile `json:"files"`
-	}{
-		AgentID: a.ID,
-		Files:   a.LocalFiles,
-	}
-
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return err
-	}
-
-	url := fmt.Sprintf("%s/api/v1/agents/%s/files", a.ServerURL, a.ID.String())
-	resp, err := a.Client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to register local files: %s", string(body))
+	if err := a.API.RegisterAgentFiles(context.Background(), a.ID, a.LocalFiles); err != nil {
+		return fmt.Errorf("failed to register local files: %w", err)
 	}
 
 	infrastructure.AgentLogger.Success("Registered %d local files with server", len(a.LocalFiles))
@@ -611,85 +694,63 @@ func (a *Agent) registerWithServer(name, ip string, port int, capabilities, agen
 		AgentKey:     agentKey, // ← kirim agentKey ke server
 	}
 
-	jsonData, err := json.Marshal(req)
+	agent, err := a.API.RegisterAgent(context.Background(), req)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to register agent: %w", err)
 	}
 
-	resp, err := a.Client.Post(
-		fmt.Sprintf("%s/api/v1/agents", a.ServerURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to register agent: %s", string(body))
-	}
-
-	var response struct {
-		Data domain.Agent `json:"data"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return err
-	}
-
-	a.ID = response.Data.ID
+	a.ID = agent.ID
 	return nil
 }
 
 func (a *Agent) startHeartbeat(ctx context.Context) {
-	// Ultra-fast real-time heartbeat: every 1 second for instant detection
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	// Send initial heartbeat immediately
-	if err := a.sendHeartbeat(); err != nil {
-		infrastructure.AgentLogger.Warning("Initial heartbeat failed: %v", err)
-	}
+	// Send the initial heartbeat immediately. The server answers each one
+	// with the interval it wants; while it can't be reached they back off.
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if err := a.sendHeartbeat(); err != nil {
-				infrastructure.AgentLogger.Error("Failed to send heartbeat: %v", err)
-			}
+		case <-timer.C:
+			timer.Reset(a.heartbeat())
 		}
 	}
 }
 
 func (a *Agent) sendHeartbeat() error {
-	// Use new endpoint with agent key instead of agent ID
-	url := fmt.Sprintf("%s/api/v1/agents/heartbeat", a.ServerURL)
-
-	// Create request body with agent key
-	reqBody := struct {
-		AgentKey string `json:"agent_key"`
-	}{
-		AgentKey: a.AgentKey,
+	reqBody := client.HeartbeatRequest{
+		AgentKey:        a.AgentKey,
+		Snapshot:        a.heartbeatSnapshot(),
+		IntervalSeconds: requestedHeartbeatSeconds(a.Settings.Get().HeartbeatInterval),
+	}
+
+	// Heartbeats are sent every few seconds, so the cache report only rides along
+	// when the cache changed or the last report is getting old
+	var cacheVersion int64
+	if a.Cache != nil {
+		var report *domain.AgentCacheReport
+		report, cacheVersion = a.Cache.Report()
+		if cacheVersion != a.cacheReportVersion || time.Since(a.cacheReportedAt) > time.Minute {
+			reqBody.Cache = report
+		}
 	}
 
-	jsonData, err := json.Marshal(reqBody)
+	resp, err := a.API.Heartbeat(context.Background(), reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to marshal heartbeat request: %v", err)
+		return fmt.Errorf("heartbeat failed: %w", err)
 	}
 
-	resp, err := a.Client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	if reqBody.Cache != nil {
+		a.cacheReportVersion = cacheVersion
+		a.cacheReportedAt = time.Now()
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("heartbeat failed: %s", string(body))
+	a.setHeartbeatInterval(time.Duration(resp.IntervalSeconds) * time.Second)
+	if resp.Settings != nil {
+		a.applyServerSettings(*resp.Settings)
 	}
+	a.commands.deliver(resp.Commands)
 
 	return nil
 }
@@ -700,40 +761,17 @@ func (a *Agent) updateStatus(status string) {
 		return
 	}
 
-	req := struct {
-		Status string `json:"status"`
-	}{
-		Status: status,
-	}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/agents/%s", a.ServerURL, a.ID.String())
-
-	httpReq, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to create status update request: %v", err)
-		return
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
+	if err := a.API.UpdateAgentStatus(context.Background(), a.ID, status); err != nil {
 		infrastructure.AgentLogger.Error("Failed to update agent status: %v", err)
 		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Failed to update agent status: %s", string(body))
-		return
-	}
 
 	infrastructure.AgentLogger.Success("Agent '%s' status successfully updated to '%s'", a.Name, status)
 }
 
 func (a *Agent) pollForJobs(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
+	interval := a.Settings.Get().PollInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -741,196 +779,254 @@ func (a *Agent) pollForJobs(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if a.CurrentJob == nil {
-				if err := a.checkForNewJob(); err != nil {
-					infrastructure.AgentLogger.Error("Error checking for new job: %v", err)
-				}
+			resetTicker(ticker, &interval, a.Settings.Get().PollInterval)
+			if !a.serverReady() {
+				continue
+			}
+			a.pollCommands()
+			// No new work until the server answers and knows what this
+			// agent runs, nor while a benchmark command uses the devices
+			if a.CurrentJob == nil && a.devicesMu.TryLock() {
+				a.checkForWork()
+				a.devicesMu.Unlock()
 			}
 		}
 	}
 }
 
-func (a *Agent) checkForNewJob() error {
-	// Use the specific endpoint for getting available job for this agent
-	url := fmt.Sprintf("%s/api/v1/jobs/agent/%s", a.ServerURL, a.ID.String())
-	resp, err := a.Client.Get(url)
-	if err != nil {
-		return err
+// checkForWork runs a benchmark job the server queued for the agent, or
+// else starts the next cracking job
+func (a *Agent) checkForWork() {
+	if ran, err := a.checkForBenchmarkJob(); err != nil {
+		infrastructure.AgentLogger.Error("Error running benchmark job: %v", err)
+	} else if ran {
+		return
 	}
-	defer resp.Body.Close()
-
-	var response struct {
-		Data *domain.Job `json:"data"`
+	if err := a.checkForNewJob(); err != nil {
+		infrastructure.AgentLogger.Error("Error checking for new job: %v", err)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+}
+
+func (a *Agent) checkForNewJob() error {
+	job, err := a.API.GetAvailableJob(context.Background(), a.ID)
+	if err != nil {
 		return err
 	}
 
 	// Check if we got a job
-	if response.Data != nil {
-		infrastructure.AgentLogger.Info("Found assigned job: %s", response.Data.Name)
-		a.CurrentJob = response.Data
-		go a.executeJob(response.Data)
+	if job != nil {
+		infrastructure.AgentLogger.With("job_id", job.ID).Info("Found assigned job: %s", job.Name)
+		a.CurrentJob = job
+		go a.executeJob(job)
 	}
 
 	return nil
 }
 
 func (a *Agent) executeJob(job *domain.Job) {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	interrupted := false
 	defer func() {
 		a.CurrentJob = nil
-		a.updateStatus("online")
+		// An interrupted job means the agent is shutting down
+		if !interrupted {
+			a.updateStatus("online")
+		}
 	}()
 
-	infrastructure.AgentLogger.Info("Starting job: %s", job.Name)
+	logger.Info("Starting job: %s", job.Name)
 	a.updateStatus("busy")
 
 	// Start the job
 	if err := a.startJob(job.ID); err != nil {
-		infrastructure.AgentLogger.Error("Failed to start job: %v", err)
-		a.failJob(job.ID, fmt.Sprintf("Failed to start job: %v", err))
+		logger.Error("Failed to start job: %v", err)
+		a.failJob(job.ID, fmt.Sprintf("Failed to start job: %v", err), nil)
 		return
 	}
 
-	// Execute hashcat command
-	if err := a.runHashcat(job); err != nil {
-		infrastructure.AgentLogger.Error("Hashcat execution failed: %v", err)
-		a.failJob(job.ID, fmt.Sprintf("Hashcat execution failed: %v", err))
+	// Execute the job's cracking engine
+	if err := a.runJob(job); err != nil {
+		if errors.Is(err, errJobInterrupted) {
+			interrupted = true
+			logger.Warning("Job interrupted: %s", job.Name)
+			return
+		}
+		if errors.Is(err, errJobAbandoned) {
+			logger.Warning("Job abandoned: %s", job.Name)
+			return
+		}
+		if errors.Is(err, errDiskSpace) {
+			logger.Error("Pre-flight check failed: %v", err)
+			a.failJob(job.ID, fmt.Sprintf("Pre-flight check failed: %v", err), nil)
+			return
+		}
+		name := engineFor(job).displayName()
+		logger.Error("%s execution failed: %v", name, err)
+		var output *domain.JobOutput
+		var runErr *hashcatRunError
+		if errors.As(err, &runErr) {
+			output = runErr.output.report()
+		}
+		a.failJob(job.ID, fmt.Sprintf("%s execution failed: %v", name, err), output)
 		return
 	}
 
-	infrastructure.AgentLogger.Success("Job completed: %s", job.Name)
+	logger.Success("Job completed: %s", job.Name)
 }
 
 func (a *Agent) startJob(jobID uuid.UUID) error {
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/start", a.ServerURL, jobID.String())
-	resp, err := a.Client.Post(url, "application/json", nil)
-	if err != nil {
-		return err
+	return a.API.StartJob(context.Background(), jobID)
+}
+
+// runJob runs a job with its engine and reports the result to the server
+func (a *Agent) runJob(job *domain.Job) error {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	if err := a.validateJob(job); err != nil {
+		return fmt.Errorf("rejected job parameters: %w", err)
 	}
-	defer resp.Body.Close()
+	engine := engineFor(job)
 
-	return nil
-}
+	// Keep this job's cached inputs from being evicted while the engine runs
+	if job.HashFileID != nil {
+		defer a.Cache.Pin("hash_file", *job.HashFileID)()
+	}
+	if job.WordlistID != nil {
+		defer a.Cache.Pin("wordlist", *job.WordlistID)()
+	}
+	if job.Wordlist2ID != nil {
+		defer a.Cache.Pin("wordlist", *job.Wordlist2ID)()
+	}
+	for _, charset := range customCharsets(job) {
+		if charsetID, err := uuid.Parse(charset); err == nil {
+			defer a.Cache.Pin("charset", charsetID)()
+		}
+	}
 
-func (a *Agent) runHashcat(job *domain.Job) error {
 	// Send initial job data to server immediately
 	a.sendInitialJobData(job)
 
-	// Resolve hash file (local first, download if needed)
-	var localHashFile string
-	var err error
+	// Everything the job writes stays in its own directory, removed when
+	// the job ends
+	jobDir, err := a.createJobDir(job.ID)
+	if err != nil {
+		return err
+	}
+	defer a.cleanupJobFiles(job.ID)
 
-	if job.HashFileID != nil {
-		// Try to find hash file locally first
-		hashFileName := job.HashFile
-		if hashFileName != "" {
-			if localPath, found := a.findLocalFile(filepath.Base(hashFileName)); found {
-				localHashFile = localPath
-				infrastructure.AgentLogger.Info("Using local hash file: %s", localHashFile)
-			}
-		}
+	// Resolve hash file (verified local copy first, download if needed)
+	localHashFile, hashFileSource, err := a.resolveHashFile(job)
+	if err != nil {
+		return err
+	}
 
-		// Download if not found locally
-		if localHashFile == "" {
-			localHashFile, err = a.downloadHashFile(*job.HashFileID)
-			if err != nil {
-				return fmt.Errorf("failed to download hash file: %w", err)
-			}
-			// defer os.Remove(localHashFile) // Keep file for debugging/--show command
-			infrastructure.AgentLogger.Success("Downloaded hash file: %s", localHashFile)
-		}
-	} else {
-		// Fallback to original path
-		localHashFile = job.HashFile
+	localHashFile, err = a.ensureInUploadDir(localHashFile)
+	if err != nil {
+		return err
 	}
 
 	// Resolve wordlist (local first, download if needed, or create from content)
-	var localWordlist string
+	var localWordlist, wordlistSource string
 
-	// Prioritize WordlistID if available
-	if job.WordlistID != nil {
-		downloadedPath, err := a.downloadWordlist(*job.WordlistID)
+	// Brute-force jobs carry a mask in the wordlist field instead of a file
+	if job.AttackMode == domain.AttackModeBruteForce {
+		if err := domain.ValidateMask(job.Wordlist); err != nil {
+			return err
+		}
+		localWordlist = job.Wordlist
+	} else if job.WordlistID != nil {
+		localWordlist, wordlistSource, err = a.resolveWordlistFile(job, *job.WordlistID)
 		if err != nil {
-			return fmt.Errorf("failed to download wordlist %s: %w", job.WordlistID.String(), err)
+			return err
 		}
-		localWordlist = downloadedPath
-		infrastructure.AgentLogger.Success("Downloaded wordlist from ID: %s", localWordlist)
 	} else if job.Wordlist != "" {
 		// Check if wordlist contains newlines (indicating it's content, not a path)
 		if strings.Contains(job.Wordlist, "\n") {
-			// This is wordlist content, create a temporary file
-			tempDir := filepath.Join(a.UploadDir, "temp")
-			if err := os.MkdirAll(tempDir, 0755); err != nil {
-				return fmt.Errorf("failed to create temp directory: %w", err)
-			}
-
-			wordlistFile := filepath.Join(tempDir, fmt.Sprintf("wordlist-%s.txt", job.ID.String()))
+			// This is wordlist content, write it to the job directory
+			wordlistFile := filepath.Join(jobDir, "wordlist.txt")
 			if err := os.WriteFile(wordlistFile, []byte(job.Wordlist), 0644); err != nil {
 				return fmt.Errorf("failed to create wordlist file: %w", err)
 			}
 
 			localWordlist = wordlistFile
-			infrastructure.AgentLogger.Info("Created wordlist file from content: %s", localWordlist)
-			infrastructure.AgentLogger.Info("Wordlist content preview: %s", strings.Split(job.Wordlist, "\n")[0])
+			wordlistSource = fileSourceLocal
+			logger.Info("Created wordlist file from content: %s", localWordlist)
+			logger.Info("Wordlist content preview: %s", strings.Split(job.Wordlist, "\n")[0])
 		} else {
 			// Fallback to wordlist filename resolution
 			if localPath, found := a.findLocalFile(job.Wordlist); found {
 				localWordlist = localPath
-				infrastructure.AgentLogger.Info("Using local wordlist: %s", localWordlist)
+				wordlistSource = fileSourceLocal
+				logger.Info("Using local wordlist: %s", localWordlist)
 			} else {
 				// Try to parse as UUID and download
 				if wordlistUUID, err := uuid.Parse(job.Wordlist); err == nil {
-					downloadedPath, err := a.downloadWordlist(wordlistUUID)
+					release := a.Cache.Pin("wordlist", wordlistUUID)
+					defer release()
+					localWordlist, wordlistSource, err = a.fetchFile("wordlist", wordlistUUID, 0, "")
 					if err != nil {
-						return fmt.Errorf("failed to download wordlist %s: %w", job.Wordlist, err)
+						return err
 					}
-					localWordlist = downloadedPath
-					infrastructure.AgentLogger.Success("Downloaded wordlist: %s", localWordlist)
 				} else {
-					// If not UUID, use as direct path
-					localWordlist = job.Wordlist
-					infrastructure.AgentLogger.Info("Using wordlist path directly: %s", localWordlist)
+					// Arbitrary paths from the server are never used directly
+					return fmt.Errorf("wordlist %q not found locally", job.Wordlist)
 				}
 			}
 		}
 	}
 
-	// Build hashcat command with UUID-based outfile
-	tempDir := filepath.Join(a.UploadDir, "temp")
-	outfile := filepath.Join(tempDir, fmt.Sprintf("cracked-%s.txt", job.ID.String()))
-	infrastructure.AgentLogger.Info("Outfile will be: %s", outfile)
-	args := []string{
-		"-m", strconv.Itoa(job.HashType),
-		"-a", strconv.Itoa(job.AttackMode),
-		localHashFile,
-		localWordlist,
-		"-w", "4",
-		"--status",
-		"--status-timer=2",
-		"--potfile-disable",
-		"--outfile", outfile,
-		"--outfile-format", "2", // Format: hash:plain
+	if job.AttackMode != domain.AttackModeBruteForce {
+		localWordlist, err = a.ensureInUploadDir(localWordlist)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Add skip and limit parameters for distributed cracking
-	if job.Skip != nil && *job.Skip >= 0 {
-		args = append(args, "--skip", strconv.FormatInt(*job.Skip, 10))
-		infrastructure.AgentLogger.Info("Using --skip parameter: %d", *job.Skip)
+	// Combinator attacks combine the wordlist with a second, right-hand one
+	var localWordlist2 string
+	if job.AttackMode == domain.AttackModeCombinator {
+		localWordlist2, _, err = a.fetchFile("wordlist", *job.Wordlist2ID, 0, "")
+		if err != nil {
+			return err
+		}
+		localWordlist2, err = a.ensureInUploadDir(localWordlist2)
+		if err != nil {
+			return err
+		}
 	}
 
-	if job.WordLimit != nil && *job.WordLimit > 0 {
-		args = append(args, "--limit", strconv.FormatInt(*job.WordLimit, 10))
-		infrastructure.AgentLogger.Info("Using --limit parameter: %d", *job.WordLimit)
-	}
+	// Tell the server where the inputs came from
+	job.FileSource = formatFileSource(hashFileSource, wordlistSource)
+	logger.Info("Job file sources: %s", job.FileSource)
+	a.sendInitialJobData(job)
 
-	if job.Rules != "" {
-		args = append(args, "-r", job.Rules)
+	// Build the engine's command with the outfile in the job directory
+	outfile := a.jobOutfile(job.ID)
+	logger.Info("Outfile will be: %s", outfile)
+	settings := a.Settings.Get()
+	inputs := engineInputs{
+		hashFile:  localHashFile,
+		wordlist:  localWordlist,
+		wordlist2: localWordlist2,
+		outfile:   outfile,
+	}
+	args, err := engine.buildArgs(a, job, inputs, settings)
+	if err != nil {
+		return err
 	}
 
-	infrastructure.AgentLogger.Info("Running hashcat with args: %v", args)
+	binary := engine.binary(settings)
+	workDir, err := a.engineWorkDir(job.ID, settings.Sandbox)
+	if err != nil {
+		return err
+	}
+	args = a.resumeArgs(job, engine, binary, args, outfile, workDir)
+	logger.Info("Running %s with args: %v", binary, args)
 
-	cmd := exec.Command("hashcat", args...)
+	cmd, releaseSandbox, err := a.sandboxCommand(job.ID, settings.Sandbox, binary, args, outfile)
+	if err != nil {
+		return err
+	}
+	defer releaseSandbox()
 
 	// Set up pipes for stdout and stderr
 	stdout, err := cmd.StdoutPipe()
@@ -946,234 +1042,144 @@ func (a *Agent) runHashcat(job *domain.Job) error {
 	if err := cmd.Start(); err != nil {
 		return err
 	}
+	defer a.trackRun(job.ID, cmd)()
 
-	// Monitor output for progress updates
-	go a.monitorHashcatOutput(job, stdout, stderr)
+	// Monitor output for progress updates, keeping its tail for the report
+	output := newHashcatOutput(a.Settings.Get().OutputTailKB)
+	outputDone := a.monitorHashcatOutput(job, engine, stdout, stderr, output)
 
 	// Monitor job status for cancellation/pause
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go a.monitorJobStatus(ctx, job.ID, cmd)
+	go a.monitorJobStatus(ctx, job, cmd)
 
-	// Wait for command to complete
-	if err := cmd.Wait(); err != nil {
-		// Check if hashcat found the password (exit code 0) or exhausted (exit code 1)
+	// Report cracks as the engine writes them
+	stopWatchingCracks := a.watchCracks(job, engine, inputs)
+
+	// Wait for command to complete, after its output is read to the end
+	outputDone()
+	err = cmd.Wait()
+	stopWatchingCracks()
+	if err != nil {
+		// Stopped for the agent to shut down: another agent takes over. Its
+		// exit code says nothing about the search.
+		if a.wasInterrupted(job.ID) {
+			return a.handOffJob(job, engine, outfile)
+		}
+		if a.wasAbandoned(job.ID) {
+			return errJobAbandoned
+		}
+		if reason := a.watchdogStopReason(job.ID); reason != "" {
+			a.failJob(job.ID, reason, output.report())
+			return nil
+		}
+		// Some exit codes tell how the search ended rather than an error
 		if exitError, ok := err.(*exec.ExitError); ok {
 			exitCode := exitError.ExitCode()
-			switch exitCode {
-			case 1:
+			output.setExitCode(exitCode)
+			switch engine.outcome(exitCode) {
+			case runExhausted:
 				// Exhausted - not an error
-				a.completeJob(job.ID, "Password not found - exhausted")
-				a.cleanupJobFiles(job.ID)
+				a.completeJob(job.ID, "Password not found - exhausted", output.report(), nil)
 				return nil
-			case 255:
-				// Exit code 255 usually means invalid arguments or file not found
-				// Check if this is due to password not being found vs other errors
-				// For now, treat exit 255 as password not found scenario
-				a.failJob(job.ID, "Password not found")
-				a.cleanupJobFiles(job.ID)
+			case runNotFound:
+				a.failJob(job.ID, "Password not found", output.report())
+				return nil
+			case runOutOfTime:
+				// The server keeps what was cracked and expires the job
+				a.failJob(job.ID, domain.JobDeadlineReachedReason, output.report())
 				return nil
 			}
 		}
-		// Cleanup on other errors too
-		a.cleanupJobFiles(job.ID)
-		return err
+		return &hashcatRunError{err: err, output: output}
+	}
+	output.setExitCode(0)
+
+	// Success - confirm the cracks against the hash file before reporting
+	cracks, err := engine.verifiedCracks(a, job, inputs, settings)
+	switch {
+	case errors.Is(err, errNoPassword):
+		a.completeJob(job.ID, "Password not found - exhausted", output.report(), nil)
+	case err != nil:
+		// Nothing verified is reported as cracked, the job fails instead
+		logger.Error("Failed to verify cracks: %v", err)
+		a.failJob(job.ID, fmt.Sprintf("Crack verification failed: %v", err), output.report())
+	default:
+		logger.Info("Verified %d cracked hashes", len(cracks))
+		a.completeJob(job.ID, fmt.Sprintf("Password found: %s", cracks[0].Plaintext), output.report(), cracks)
 	}
-
-	// Success - password found, now capture the actual password
-	password, err := a.extractPassword(job.ID)
-	if err != nil {
-		infrastructure.AgentLogger.Warning("Failed to extract password: %v", err)
-		a.completeJob(job.ID, "Password found (extraction failed)")
-	} else {
-		a.completeJob(job.ID, fmt.Sprintf("Password found: %s", password))
-	}
-
-	// Cleanup outfile after job completion
-	a.cleanupJobFiles(job.ID)
 	return nil
 }
 
-func (a *Agent) downloadHashFile(hashFileID uuid.UUID) (string, error) {
-	// Create temp directory for downloaded files
-	tempDir := filepath.Join(a.UploadDir, "temp")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
-	}
-
-	// Download file from server
-	url := fmt.Sprintf("%s/api/v1/hashfiles/%s/download", a.ServerURL, hashFileID.String())
-	resp, err := a.Client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download file: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download file: status %d", resp.StatusCode)
-	}
-
-	// Get filename from Content-Disposition header or use UUID
-	filename := hashFileID.String()
-	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
-		// Extract filename from Content-Disposition header
-		if parts := regexp.MustCompile(`filename=([^;]+)`).FindStringSubmatch(cd); len(parts) > 1 {
-			filename = parts[1]
-		}
-	}
-
-	// Create local file
-	localPath := filepath.Join(tempDir, filename)
-	file, err := os.Create(localPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create local file: %w", err)
-	}
-	defer file.Close()
-
-	// Copy downloaded content to local file
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
-		os.Remove(localPath) // Clean up on error
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	infrastructure.AgentLogger.Success("Downloaded hash file to: %s", localPath)
-	return localPath, nil
-}
-
-func (a *Agent) downloadWordlist(wordlistID uuid.UUID) (string, error) {
-	// Create temp directory for downloaded files
-	tempDir := filepath.Join(a.UploadDir, "temp")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
-	}
-
-	// Download file from server
-	url := fmt.Sprintf("%s/api/v1/wordlists/%s/download", a.ServerURL, wordlistID.String())
-	resp, err := a.Client.Get(url)
+// downloadToCache fetches a file from the server straight into the
+// download cache
+func (a *Agent) downloadToCache(kind string, id uuid.UUID) (*domain.AgentCacheEntry, string, error) {
+	download, err := a.downloadQueued(kind, id)
 	if err != nil {
-		return "", fmt.Errorf("failed to download file: %w", err)
+		return nil, "", fmt.Errorf("failed to download file: %w", err)
 	}
-	defer resp.Body.Close()
+	defer download.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	filename := download.Name
+	if err := a.checkDiskSpace(filename, download.Size); err != nil {
+		return nil, "", err
 	}
-
-	// Get filename from Content-Disposition header or use UUID
-	filename := wordlistID.String()
-	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
-		// Extract filename from Content-Disposition header
-		if parts := regexp.MustCompile(`filename=([^;]+)`).FindStringSubmatch(cd); len(parts) > 1 {
-			filename = parts[1]
-		}
-	}
-
-	// Create local file
-	localPath := filepath.Join(tempDir, filename)
-	file, err := os.Create(localPath)
+	body := newRateLimitedReader(download.Body, a.Settings.Get().DownloadRateLimitKB*1024)
+	localPath, entry, err := a.Cache.Store(kind, id, filename, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create local file: %w", err)
+		return nil, "", err
 	}
-	defer file.Close()
 
-	// Copy downloaded content to local file
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
-		os.Remove(localPath) // Clean up on error
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	infrastructure.AgentLogger.Success("Downloaded wordlist to: %s", localPath)
-	return localPath, nil
+	infrastructure.AgentLogger.Success("Downloaded %s to: %s", filename, localPath)
+	return entry, localPath, nil
 }
 
-func (a *Agent) extractPassword(jobID uuid.UUID) (string, error) {
-	// Read password from outfile created during cracking
-	tempDir := filepath.Join(a.UploadDir, "temp")
-	outfile := filepath.Join(tempDir, fmt.Sprintf("cracked-%s.txt", jobID.String()))
-
-	content, err := os.ReadFile(outfile)
-	if err != nil {
-		return "", fmt.Errorf("failed to read outfile %s: %w", outfile, err)
-	}
+// monitorHashcatOutput reads the engine's stdout and stderr until they
+// close. The returned func waits for that, as cmd.Wait must not run before.
+func (a *Agent) monitorHashcatOutput(job *domain.Job, engine crackEngine, stdout, stderr io.Reader, output *hashcatOutput) func() {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
+	var wg sync.WaitGroup
+	wg.Add(2)
 
-	// Parse output format: plain password (one per line)
-	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			// Return first non-empty password found
-			return line, nil
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			status, ok := engine.parseStatus(scanner.Bytes())
+			if !ok {
+				// Anything but a status line is worth showing to the operator
+				agentLogs.add(domain.AgentLogSourceHashcat, scanner.Text())
+				output.addStdout(scanner.Text())
+				continue
+			}
+			a.recordJobStatus(job.ID, status)
+			a.updateJobDataFromAgent(job.ID, status.percent(), status.speed(), status.eta(), status.stats())
 		}
-	}
-
-	return "", fmt.Errorf("no password found in outfile")
-}
-
-func (a *Agent) cleanupJobFiles(jobID uuid.UUID) {
-	// Clean up job-specific files after completion
-	tempDir := filepath.Join(a.UploadDir, "temp")
-	outfile := filepath.Join(tempDir, fmt.Sprintf("cracked-%s.txt", jobID.String()))
-
-	if err := os.Remove(outfile); err != nil && !os.IsNotExist(err) {
-		infrastructure.AgentLogger.Warning("Failed to cleanup outfile %s: %v", outfile, err)
-	} else {
-		infrastructure.AgentLogger.Info("Cleaned up outfile: %s", outfile)
-	}
-}
-
-func (a *Agent) monitorHashcatOutput(job *domain.Job, stdout, stderr io.Reader) {
-	// Parse hashcat output for progress updates
-	progressRegex := regexp.MustCompile(`Progress\.+:\s*(\d+)/(\d+)\s*\((\d+\.\d+)%\)`)
-	speedRegex := regexp.MustCompile(`Speed\.+:\s*(\d+)\s*H/s`)
-	etaRegex := regexp.MustCompile(`ETA\.+:\s*(\d+):(\d+):(\d+)`)
+	}()
 
-	scanner := func(reader io.Reader) {
-		buf := make([]byte, 1024)
-		for {
-			n, err := reader.Read(buf)
-			if err != nil {
-				return
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			// john prints its status lines to stderr
+			if status, ok := engine.parseStatus(scanner.Bytes()); ok {
+				a.recordJobStatus(job.ID, status)
+				a.updateJobDataFromAgent(job.ID, status.percent(), status.speed(), status.eta(), status.stats())
+				continue
 			}
-
-			output := string(buf[:n])
-
-			// Parse progress
-			if matches := progressRegex.FindStringSubmatch(output); len(matches) > 3 {
-				progress, _ := strconv.ParseFloat(matches[3], 64)
-
-				// Parse speed
-				var speed int64
-				if speedMatches := speedRegex.FindStringSubmatch(output); len(speedMatches) > 1 {
-					speed, _ = strconv.ParseInt(speedMatches[1], 10, 64)
-				}
-
-				// Parse ETA
-				var eta *string
-				if etaMatches := etaRegex.FindStringSubmatch(output); len(etaMatches) > 3 {
-					hours, _ := strconv.Atoi(etaMatches[1])
-					minutes, _ := strconv.Atoi(etaMatches[2])
-					seconds, _ := strconv.Atoi(etaMatches[3])
-
-					etaTime := time.Now().Add(time.Duration(hours)*time.Hour +
-						time.Duration(minutes)*time.Minute +
-						time.Duration(seconds)*time.Second)
-					etaStr := etaTime.Format(time.RFC3339)
-					eta = &etaStr
-				}
-
-				// Send complete data to new endpoint
-				a.updateJobDataFromAgent(job.ID, progress, speed, eta)
+			output.addStderr(scanner.Text())
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				logger.Warning("%s: %s", job.EngineName(), line)
 			}
 		}
-	}
+	}()
 
-	go scanner(stdout)
-	go scanner(stderr)
+	return wg.Wait
 }
 
 func (a *Agent) sendInitialJobData(job *domain.Job) {
+	logger := infrastructure.AgentLogger.With("job_id", job.ID)
 	req := struct {
 		AgentID    string  `json:"agent_id"`
 		AttackMode int     `json:"attack_mode"`
@@ -1181,6 +1187,7 @@ func (a *Agent) sendInitialJobData(job *domain.Job) {
 		Speed      int64   `json:"speed"`
 		ETA        *string `json:"eta,omitempty"`
 		Progress   float64 `json:"progress"`
+		FileSource string  `json:"file_source,omitempty"`
 	}{
 		AgentID:    a.ID.String(),
 		AttackMode: job.AttackMode,
@@ -1188,46 +1195,42 @@ func (a *Agent) sendInitialJobData(job *domain.Job) {
 		Speed:      0,   // Initial speed is 0
 		ETA:        nil, // No ETA initially
 		Progress:   0,   // Initial progress is 0
+		FileSource: job.FileSource,
 	}
 
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/data", a.ServerURL, job.ID.String())
-
-	httpReq, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to send initial job data to server: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Initial job data failed with status %d: %s", resp.StatusCode, string(body))
-	} else {
-		infrastructure.AgentLogger.Success("Initial job data sent successfully to server")
+	path := fmt.Sprintf("/api/v1/jobs/%s/data", job.ID.String())
+	delivered, err := a.report(http.MethodPut, path, req, true)
+	switch {
+	case err != nil:
+		logger.Error("Failed to send initial job data to server: %v", err)
+	case delivered:
+		logger.Success("Initial job data sent successfully to server")
 	}
 }
 
-func (a *Agent) updateJobDataFromAgent(jobID uuid.UUID, progress float64, speed int64, eta *string) {
+func (a *Agent) updateJobDataFromAgent(jobID uuid.UUID, progress float64, speed int64, eta *string, stats *domain.JobRuntimeStats) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
 	// Get current job data to include attack_mode and rules
 	var attackMode int
 	var rules string
+	var fileSource string
 
 	if a.CurrentJob != nil && a.CurrentJob.ID == jobID {
 		attackMode = a.CurrentJob.AttackMode
 		rules = a.CurrentJob.Rules
+		fileSource = a.CurrentJob.FileSource
 	}
 
 	req := struct {
-		AgentID    string  `json:"agent_id"`
-		AttackMode int     `json:"attack_mode"`
-		Rules      string  `json:"rules"`
-		Speed      int64   `json:"speed"`
-		ETA        *string `json:"eta,omitempty"`
-		Progress   float64 `json:"progress"`
+		AgentID    string                  `json:"agent_id"`
+		AttackMode int                     `json:"attack_mode"`
+		Rules      string                  `json:"rules"`
+		Speed      int64                   `json:"speed"`
+		ETA        *string                 `json:"eta,omitempty"`
+		Progress   float64                 `json:"progress"`
+		FileSource string                  `json:"file_source,omitempty"`
+		Stats      *domain.JobRuntimeStats `json:"stats,omitempty"`
+		PowerWatts float64                 `json:"power_watts,omitempty"`
 	}{
 		AgentID:    a.ID.String(),
 		AttackMode: attackMode,
@@ -1235,79 +1238,82 @@ func (a *Agent) updateJobDataFromAgent(jobID uuid.UUID, progress float64, speed
 		Speed:      speed,
 		ETA:        eta,
 		Progress:   progress,
+		FileSource: fileSource,
+		Stats:      stats,
+		PowerWatts: devicePowerDraw(),
 	}
 
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/data", a.ServerURL, jobID.String())
-
-	httpReq, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to send job data update to server: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Job data update failed with status %d: %s", resp.StatusCode, string(body))
-	} else {
-		infrastructure.AgentLogger.Info("Job data update sent successfully (Progress: %.2f%%, Speed: %d H/s)", progress, speed)
+	path := fmt.Sprintf("/api/v1/jobs/%s/data", jobID.String())
+	delivered, err := a.report(http.MethodPut, path, req, true)
+	switch {
+	case err != nil:
+		logger.Error("Failed to send job data update to server: %v", err)
+	case delivered:
+		logger.Info("Job data update sent successfully (Progress: %.2f%%, Speed: %d H/s)", progress, speed)
 	}
 }
 
-func (a *Agent) monitorJobStatus(ctx context.Context, jobID uuid.UUID, cmd *exec.Cmd) {
-	ticker := time.NewTicker(5 * time.Second)
+func (a *Agent) monitorJobStatus(ctx context.Context, job *domain.Job, cmd *exec.Cmd) {
+	jobID := job.ID
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
+	interval := a.Settings.Get().StatusInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	watchdog := newJobWatchdog(job, time.Now())
+	restarts := 0
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			resetTicker(ticker, &interval, a.Settings.Get().StatusInterval)
+			if n := a.watchdogRestarts(jobID); n != restarts {
+				restarts = n
+				watchdog.restart(time.Now())
+				logger.Info("Watchdog of job %s restarted", jobID)
+			}
+			// The limits hold even while the server is unreachable
+			if reason := watchdog.check(a.latestJobStatus(jobID), time.Now()); reason != "" {
+				a.stopRunningJob(jobID, reason)
+				return
+			}
+
 			// Check job status from server
 			status, err := a.checkJobStatus(jobID)
 			if err != nil {
-				infrastructure.AgentLogger.Error("Failed to check job status: %v", err)
+				logger.Error("Failed to check job status: %v", err)
 				continue
 			}
 
 			// Handle status changes
 			switch status {
 			case "paused":
-				infrastructure.AgentLogger.Warning("Job %s status changed to %s, pausing hashcat", jobID, status)
+				logger.Warning("Job %s status changed to %s, pausing hashcat", jobID, status)
 				if cmd.Process != nil {
-					cmd.Process.Signal(syscall.SIGSTOP)
+					if err := suspendProcess(cmd.Process); err != nil {
+						logger.Error("Failed to pause hashcat: %v", err)
+					}
 				}
 				return
-			case "failed":
-				infrastructure.AgentLogger.Warning("Job %s status changed to %s, terminating hashcat", jobID, status)
+			case "failed", "cancelled", "completed", "cracked", "expired":
+				logger.Warning("Job %s status changed to %s, terminating hashcat", jobID, status)
 				if cmd.Process != nil {
 					cmd.Process.Kill()
 				}
 
-				// Check if this is a coordination stop (password found by another agent)
-				if a.isCoordinationStop(jobID) {
-					infrastructure.AgentLogger.Info("Job stopped due to password found by another agent - updating progress to 100%%")
-					// Update progress to 100% and send final status
+				// The server has already finished the job, so only the final
+				// progress is reported for a coordination stop
+				if status == "cancelled" && a.isCoordinationStop(jobID) {
+					logger.Info("Job cancelled due to password found by another agent - updating progress to 100%%")
 					a.updateJobProgress(jobID, 100.0, 0)
-					a.failJob(jobID, "Password found by another agent - stopping")
 				}
 				return
-			case "cancelled":
-				infrastructure.AgentLogger.Warning("Job %s status changed to %s, terminating hashcat", jobID, status)
-				if cmd.Process != nil {
-					cmd.Process.Kill()
-				}
-
-				// Check if this is a coordination stop (password found by another agent)
-				if a.isCoordinationStop(jobID) {
-					infrastructure.AgentLogger.Info("Job cancelled due to password found by another agent - updating progress to 100%%")
-					// Update progress to 100% and send final status
-					a.updateJobProgress(jobID, 100.0, 0)
-					a.failJob(jobID, "Password found by another agent - job cancelled")
+			case "pending", "assigned", "interrupted":
+				// The server took the job back, e.g. its watchdog found it
+				// stalled. A job this agent handed off is already stopping.
+				if !a.wasInterrupted(jobID) {
+					a.abandonRunningJob(jobID, "job is "+status+" on the server")
 				}
 				return
 			}
@@ -1317,125 +1323,77 @@ func (a *Agent) monitorJobStatus(ctx context.Context, jobID uuid.UUID, cmd *exec
 
 // isCoordinationStop checks if the job was stopped due to password being found by another agent
 func (a *Agent) isCoordinationStop(jobID uuid.UUID) bool {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
 	// Get job details to check the failure reason
-	url := fmt.Sprintf("%s/api/v1/jobs/%s", a.ServerURL, jobID.String())
-	resp, err := a.Client.Get(url)
+	job, err := a.API.GetJob(context.Background(), jobID)
 	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to get job details for coordination check: %v", err)
+		logger.Error("Failed to get job details for coordination check: %v", err)
 		return false
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return false
-	}
-
-	var jobResp struct {
-		Data struct {
-			Result string `json:"result"`
-		} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&jobResp); err != nil {
-		infrastructure.AgentLogger.Error("Failed to decode job response: %v", err)
-		return false
-	}
-
-	// Check if the failure reason indicates coordination stop
-	return strings.Contains(jobResp.Data.Result, "Password found by another agent") ||
-		strings.Contains(jobResp.Data.Result, "Password found by another agent - stopping") ||
-		strings.Contains(jobResp.Data.Result, "Password found by another agent - job cancelled")
+	// Check if the cancellation reason indicates coordination stop
+	return strings.HasPrefix(job.Result, "Password found by another agent")
 }
 
 func (a *Agent) checkJobStatus(jobID uuid.UUID) (string, error) {
-	url := fmt.Sprintf("%s/api/v1/jobs/%s", a.ServerURL, jobID.String())
-	resp, err := a.Client.Get(url)
+	job, err := a.API.GetJob(context.Background(), jobID)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to get job status: %d", resp.StatusCode)
-	}
-
-	var jobResp struct {
-		Data struct {
-			Status string `json:"status"`
-		} `json:"data"`
+		return "", fmt.Errorf("failed to get job status: %w", err)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&jobResp); err != nil {
-		return "", err
-	}
-
-	return jobResp.Data.Status, nil
+	return job.Status, nil
 }
 
-func (a *Agent) completeJob(jobID uuid.UUID, result string) {
+// completeJob reports a finished job with the cracks the engine verified
+func (a *Agent) completeJob(jobID uuid.UUID, result string, output *domain.JobOutput, cracks []domain.JobCrackReport) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
 	req := struct {
-		Result string `json:"result"`
-	}{Result: result}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/complete", a.ServerURL, jobID.String())
-
-	resp, err := a.Client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to send job completion to server: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Job completion failed with status %d: %s", resp.StatusCode, string(body))
-	} else {
-		infrastructure.AgentLogger.Success("Job completion sent successfully to server")
+		Result string                  `json:"result"`
+		Output *domain.JobOutput       `json:"output,omitempty"`
+		Cracks []domain.JobCrackReport `json:"cracks,omitempty"`
+	}{Result: result, Output: output, Cracks: cracks}
+
+	path := fmt.Sprintf("/api/v1/jobs/%s/complete", jobID.String())
+	delivered, err := a.report(http.MethodPost, path, req, false)
+	switch {
+	case err != nil:
+		logger.Error("Failed to send job completion to server: %v", err)
+	case delivered:
+		logger.Success("Job completion sent successfully to server")
+	default:
+		logger.Warning("Server unreachable, job completion queued for delivery")
 	}
 }
 
-func (a *Agent) failJob(jobID uuid.UUID, reason string) {
+func (a *Agent) failJob(jobID uuid.UUID, reason string, output *domain.JobOutput) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
 	req := struct {
-		Reason string `json:"reason"`
-	}{Reason: reason}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/fail", a.ServerURL, jobID.String())
-
-	resp, err := a.Client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to send job failure to server: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Job failure notification failed with status %d: %s", resp.StatusCode, string(body))
-	} else {
-		infrastructure.AgentLogger.Success("Job failure notification sent successfully to server")
+		Reason string            `json:"reason"`
+		Output *domain.JobOutput `json:"output,omitempty"`
+	}{Reason: reason, Output: output}
+
+	path := fmt.Sprintf("/api/v1/jobs/%s/fail", jobID.String())
+	delivered, err := a.report(http.MethodPost, path, req, false)
+	switch {
+	case err != nil:
+		logger.Error("Failed to send job failure to server: %v", err)
+	case delivered:
+		logger.Success("Job failure notification sent successfully to server")
+	default:
+		logger.Warning("Server unreachable, job failure queued for delivery")
 	}
 }
 
 func getLocalIP() string {
-	// Use hostname -I to get local IP addresses
-	cmd := exec.Command("hostname", "-I")
-	output, err := cmd.Output()
+	ips, err := localIPs()
 	if err != nil {
-		infrastructure.AgentLogger.Warning("Failed to get local IP using hostname -I: %v", err)
+		infrastructure.AgentLogger.Warning("Failed to list network interfaces: %v", err)
 		return "127.0.0.1" // Fallback to localhost
 	}
 
-	// Parse output and get first non-localhost IP
-	ips := strings.Fields(string(output))
-	for _, ip := range ips {
-		ip = strings.TrimSpace(ip)
-		// Skip localhost and loopback addresses
-		if ip != "127.0.0.1" && ip != "::1" && ip != "" {
-			infrastructure.AgentLogger.Info("Found local IP: %s", ip)
-			return ip
-		}
+	if len(ips) > 0 {
+		infrastructure.AgentLogger.Info("Found local IP: %s", ips[0])
+		return ips[0]
 	}
 
 	infrastructure.AgentLogger.Warning("No valid local IP found, using fallback")
@@ -1444,19 +1402,14 @@ func getLocalIP() string {
 
 // validateLocalIP validates if the provided IP is a valid local IP address
 func validateLocalIP(providedIP string) error {
-	// Get actual local IPs using hostname -I
-	cmd := exec.Command("hostname", "-I")
-	output, err := cmd.Output()
+	ips, err := localIPs()
 	if err != nil {
-		infrastructure.AgentLogger.Warning("Failed to get local IP using hostname -I: %v", err)
+		infrastructure.AgentLogger.Warning("Failed to list network interfaces: %v", err)
 		// If we can't validate, allow the IP to pass
 		return nil
 	}
 
-	// Parse output and check if provided IP exists in local IPs
-	localIPs := strings.Fields(string(output))
-	for _, localIP := range localIPs {
-		localIP = strings.TrimSpace(localIP)
+	for _, localIP := range ips {
 		if localIP == providedIP {
 			infrastructure.AgentLogger.Success("IP address validation passed: %s is a valid local IP", providedIP)
 			return nil
@@ -1464,15 +1417,15 @@ func validateLocalIP(providedIP string) error {
 	}
 
 	// IP not found in local IPs
-	return fmt.Errorf("IP address validation failed: provided IP '%s' is not a valid local IP address. Local IPs: %v", providedIP, localIPs)
+	return fmt.Errorf("IP address validation failed: provided IP '%s' is not a valid local IP address. Local IPs: %v", providedIP, ips)
 }
 
 // detectCapabilitiesWithHashcat detects server capabilities using hashcat -I command
-func detectCapabilitiesWithHashcat() string {
+func detectCapabilitiesWithHashcat(hashcatPath string) string {
 	infrastructure.AgentLogger.Info("Starting hashcat -I capabilities detection...")
 
 	// Check if hashcat is available
-	if _, err := exec.LookPath("hashcat"); err != nil {
+	if _, err := exec.LookPath(hashcatPath); err != nil {
 		infrastructure.AgentLogger.Warning("hashcat not found, falling back to basic detection")
 		infrastructure.AgentLogger.Debug("Error details: %v", err)
 		return detectCapabilitiesBasic()
@@ -1481,7 +1434,7 @@ func detectCapabilitiesWithHashcat() string {
 	infrastructure.AgentLogger.Info("hashcat command found, executing hashcat -I...")
 
 	// Run hashcat -I to get device information
-	cmd := exec.Command("hashcat", "-I")
+	cmd := exec.Command(hashcatPath, "-I")
 	output, err := cmd.Output()
 	if err != nil {
 		infrastructure.AgentLogger.Warning("Failed to run hashcat -I: %v", err)
@@ -1582,102 +1535,25 @@ func detectCapabilitiesBasic() string {
 func hasGPU() bool {
 	infrastructure.AgentLogger.Info("Starting GPU detection...")
 
-	// Check for NVIDIA GPU
-	if _, err := exec.LookPath("nvidia-smi"); err == nil {
-		infrastructure.AgentLogger.Info("nvidia-smi command found, checking if GPU is working...")
-		// Try to run nvidia-smi to verify GPU is working
-		cmd := exec.Command("nvidia-smi", "--query-gpu=name", "--format=csv,noheader,nounits")
-		if output, err := cmd.Output(); err == nil && len(strings.TrimSpace(string(output))) > 0 {
-			gpuName := strings.TrimSpace(string(output))
-			infrastructure.AgentLogger.Success("Detected NVIDIA GPU: %s", gpuName)
-			return true
-		} else {
-			infrastructure.AgentLogger.Warning("nvidia-smi found but failed to run or no output: %v", err)
-		}
-	} else {
-		infrastructure.AgentLogger.Debug("nvidia-smi command not found")
-	}
-
-	// Check for AMD GPU
-	if _, err := exec.LookPath("rocm-smi"); err == nil {
-		infrastructure.AgentLogger.Info("rocm-smi command found, checking if GPU is working...")
-		cmd := exec.Command("rocm-smi", "--list-gpus")
-		if output, err := cmd.Output(); err == nil && len(strings.TrimSpace(string(output))) > 0 {
-			infrastructure.AgentLogger.Success("Detected AMD GPU (ROCm): %s", strings.TrimSpace(string(output)))
+	// Host tools and /proc or /sys don't tell what the container may use
+	if containerMode {
+		if gpus := containerGPUs(); len(gpus) > 0 {
+			infrastructure.AgentLogger.Success("Detected GPU passed to the container: %s", strings.Join(gpus, ", "))
 			return true
-		} else {
-			infrastructure.AgentLogger.Warning("rocm-smi found but failed to run or no output: %v", err)
-		}
-	} else {
-		infrastructure.AgentLogger.Debug("rocm-smi command not found")
-	}
-
-	// Check for Intel GPU
-	if _, err := exec.LookPath("intel_gpu_top"); err == nil {
-		infrastructure.AgentLogger.Info("intel_gpu_top command found, checking if GPU is working...")
-		cmd := exec.Command("intel_gpu_top", "-J", "-s", "1")
-		if output, err := cmd.Output(); err == nil && len(strings.TrimSpace(string(output))) > 0 {
-			infrastructure.AgentLogger.Success("Detected Intel GPU: %s", strings.TrimSpace(string(output)))
-			return true
-		} else {
-			infrastructure.AgentLogger.Warning("intel_gpu_top found but failed to run or no output: %v", err)
 		}
-	} else {
-		infrastructure.AgentLogger.Debug("intel_gpu_top command not found")
+		infrastructure.AgentLogger.Info("No GPU passed to the container, using CPU")
+		return false
 	}
 
-	// Additional check: look for GPU devices in /proc
-	if _, err := os.Stat("/proc/driver/nvidia"); err == nil {
-		infrastructure.AgentLogger.Info("Found NVIDIA driver in /proc/driver/nvidia")
+	// Each OS has its own tools, see gpu_<os>.go
+	if detectHostGPU() {
 		return true
 	}
 
-	if _, err := os.Stat("/sys/class/drm"); err == nil {
-		// Check if there are GPU devices
-		if files, err := os.ReadDir("/sys/class/drm"); err == nil {
-			for _, file := range files {
-				if strings.HasPrefix(file.Name(), "card") && file.Name() != "card0" {
-					infrastructure.AgentLogger.Info("Found GPU device: %s", file.Name())
-					return true
-				}
-			}
-		}
-	}
-
 	infrastructure.AgentLogger.Info("No GPU detected, using CPU")
 	return false
 }
 
-// updateAgentCapabilities updates agent capabilities in the database
-func updateAgentCapabilities(a *Agent, agentKey, capabilities string) error {
-	req := struct {
-		AgentKey     string `json:"agent_key"`
-		Capabilities string `json:"capabilities"`
-	}{
-		AgentKey:     agentKey,
-		Capabilities: capabilities,
-	}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/agents/update-data", a.ServerURL)
-
-	httpReq, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update capabilities: %s", string(body))
-	}
-
-	return nil
-}
-
 func formatFileSize(bytes int64) string {
 	if bytes == 0 {
 		return "0 B"
@@ -1696,6 +1572,7 @@ func formatFileSize(bytes int64) string {
 
 // updateJobProgress updates job progress and speed
 func (a *Agent) updateJobProgress(jobID uuid.UUID, progress float64, speed int64) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
 	req := struct {
 		Progress float64 `json:"progress"`
 		Speed    int64   `json:"speed"`
@@ -1704,24 +1581,13 @@ func (a *Agent) updateJobProgress(jobID uuid.UUID, progress float64, speed int64
 		Speed:    speed,
 	}
 
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/progress", a.ServerURL, jobID.String())
-
-	httpReq, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		infrastructure.AgentLogger.Error("Failed to send job progress update to server: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("Job progress update failed with status %d: %s", resp.StatusCode, string(body))
-	} else {
-		infrastructure.AgentLogger.Info("Job progress update sent successfully (Progress: %.2f%%, Speed: %d H/s)", progress, speed)
+	path := fmt.Sprintf("/api/v1/jobs/%s/progress", jobID.String())
+	delivered, err := a.report(http.MethodPut, path, req, true)
+	switch {
+	case err != nil:
+		logger.Error("Failed to send job progress update to server: %v", err)
+	case delivered:
+		logger.Info("Job progress update sent successfully (Progress: %.2f%%, Speed: %d H/s)", progress, speed)
 	}
 }
 
@@ -1730,7 +1596,8 @@ func (a *Agent) runHashcatBenchmark() error {
 	infrastructure.AgentLogger.Info("Starting hashcat benchmark to detect agent speed...")
 
 	// Check if hashcat is available
-	if _, err := exec.LookPath("hashcat"); err != nil {
+	hashcatPath := a.Settings.Get().HashcatPath
+	if _, err := exec.LookPath(hashcatPath); err != nil {
 		infrastructure.AgentLogger.Warning("hashcat not found in PATH: %v", err)
 		infrastructure.AgentLogger.Info("Skipping automatic speed detection. You can manually set speed via API:")
 		infrastructure.AgentLogger.Info("PUT /api/v1/agents/%s/speed", a.ID.String())
@@ -1738,7 +1605,8 @@ func (a *Agent) runHashcatBenchmark() error {
 	}
 
 	// Run hashcat -b -m 2500 for WPA benchmark
-	cmd := exec.Command("hashcat", "-b", "-m", "2500")
+	args := append([]string{"-b", "-m", "2500"}, domain.HashcatCompatArgs(hashcatVersionOf(hashcatPath), 2500)...)
+	cmd := exec.Command(hashcatPath, args...)
 
 	// Capture stdout and stderr
 	stdout, err := cmd.StdoutPipe()
@@ -1808,84 +1676,29 @@ func (a *Agent) runHashcatBenchmark() error {
 // updateAgentSpeed updates the agent speed in the database
 // This method is called during benchmark detection and real-time monitoring
 func (a *Agent) updateAgentSpeed(speed int64) error {
-	req := struct {
-		Speed int64 `json:"speed"`
-	}{
-		Speed: speed,
+	if err := a.API.UpdateAgentSpeed(context.Background(), a.ID, speed); err != nil {
+		return fmt.Errorf("speed update failed: %w", err)
 	}
-
-	jsonData, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/api/v1/agents/%s/speed", a.ServerURL, a.ID.String())
-
-	httpReq, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to send speed update request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("speed update failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	return nil
 }
 
 // updateAgentStatusOnly updates only the agent status without changing speed
 // This method is used for status consistency during monitoring
 func (a *Agent) updateAgentStatusOnly(status string) error {
-	url := fmt.Sprintf("%s/api/v1/agents/%s/status", a.ServerURL, a.ID.String())
-
-	req := struct {
-		Status string `json:"status"`
-	}{
-		Status: status,
+	if err := a.API.UpdateAgentStatus(context.Background(), a.ID, status); err != nil {
+		return fmt.Errorf("status update failed: %w", err)
 	}
-
-	jsonData, _ := json.Marshal(req)
-	httpReq, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to send status update request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status update failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	return nil
 }
 
 // updateAgentStatusOffline updates agent status to offline without resetting speed
 // This method is used for normal shutdown scenarios to preserve speed data
 func (a *Agent) updateAgentStatusOffline() error {
-	url := fmt.Sprintf("%s/api/v1/agents/%s/status-offline", a.ServerURL, a.ID.String())
-
-	infrastructure.AgentLogger.Info("Sending status offline request to: %s", url)
-
-	httpReq, _ := http.NewRequest(http.MethodPut, url, nil)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.Client.Do(httpReq)
-	if err != nil {
-		infrastructure.AgentLogger.Error("❌ Network error during status offline update: %v", err)
-		return fmt.Errorf("failed to send status offline request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	infrastructure.AgentLogger.Info("📡 Status offline response status: %d", resp.StatusCode)
+	infrastructure.AgentLogger.Info("Sending status offline request to: %s", a.ServerURL)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		infrastructure.AgentLogger.Error("❌ Status offline update failed with status %d: %s", resp.StatusCode, string(body))
-		return fmt.Errorf("status offline update failed with status %d: %s", resp.StatusCode, string(body))
+	if err := a.API.SetAgentOffline(context.Background(), a.ID); err != nil {
+		infrastructure.AgentLogger.Error("❌ Status offline update failed: %v", err)
+		return fmt.Errorf("status offline update failed: %w", err)
 	}
 
 	infrastructure.AgentLogger.Success("✅ Status offline request successful")
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+)
+
+// Backoff between attempts to flush the outbox while the server can't be
+// reached
+const (
+	outboxMinBackoff = 2 * time.Second
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// outboxEntry is a report to the server that couldn't be delivered yet
+type outboxEntry struct {
+	Seq       int64           `json:"seq"`
+	Method    string          `json:"method"`
+	Path      string          `json:"path"` // Relative to the server URL
+	Body      json.RawMessage `json:"body,omitempty"`
+	Replace   bool            `json:"replace,omitempty"` // A newer report to the same path supersedes it
+	CreatedAt time.Time       `json:"created_at"`
+	Attempts  int             `json:"attempts"`
+}
+
+// outbox keeps job reports the server didn't get under
+// <upload-dir>/outbox, one file per report named by its sequence number,
+// so they survive restarts and are delivered in the order they were made.
+// Progress reports replace older ones to the same job instead of piling up.
+type outbox struct {
+	dir  string
+	wake chan struct{}
+
+	mu  sync.Mutex
+	seq int64
+
+	flushMu sync.Mutex // Keeps flushes from sending a report twice
+}
+
+func newOutbox(dir string) (*outbox, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	o := &outbox{dir: dir, wake: make(chan struct{}, 1)}
+
+	entries, err := o.list()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		o.seq = entries[len(entries)-1].Seq
+		infrastructure.AgentLogger.Info("Outbox holds %d undelivered reports from an earlier run", len(entries))
+	}
+	return o, nil
+}
+
+func (o *outbox) path(seq int64) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%020d.json", seq))
+}
+
+// add stores a report, dropping older reports it replaces
+func (o *outbox) add(entry outboxEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if entry.Replace {
+		entries, err := o.pendingLocked()
+		if err != nil {
+			return err
+		}
+		for _, old := range entries {
+			if old.Replace && old.Path == entry.Path {
+				os.Remove(o.path(old.Seq))
+			}
+		}
+	}
+
+	o.seq++
+	entry.Seq = o.seq
+	if err := o.write(entry); err != nil {
+		return err
+	}
+	o.notify()
+	return nil
+}
+
+// write saves an entry atomically
+func (o *outbox) write(entry outboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := o.path(entry.Seq) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outbox entry: %w", err)
+	}
+	return os.Rename(tmp, o.path(entry.Seq))
+}
+
+// list returns the stored reports, oldest first
+func (o *outbox) list() ([]outboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.pendingLocked()
+}
+
+// pendingLocked reads the stored reports, oldest first, removing unreadable
+// ones. Caller holds o.mu.
+func (o *outbox) pendingLocked() ([]outboxEntry, error) {
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var entries []outboxEntry
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if _, err := strconv.ParseInt(strings.TrimSuffix(name, ".json"), 10, 64); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(o.dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		var entry outboxEntry
+		if err == nil {
+			err = json.Unmarshal(data, &entry)
+		}
+		if err != nil {
+			infrastructure.AgentLogger.Warning("Dropping unreadable outbox entry %s: %v", name, err)
+			os.Remove(filepath.Join(o.dir, name))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+	return entries, nil
+}
+
+func (o *outbox) remove(seq int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	os.Remove(o.path(seq))
+}
+
+func (o *outbox) empty() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".json") {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// report delivers a job report to the server. When the server can't be
+// reached, or reports are already waiting, it goes to the outbox and is
+// retried until it gets through. It returns whether the report was
+// delivered now; the error is set when the server rejected it or it
+// couldn't be queued.
+func (a *Agent) report(method, path string, body any, replace bool) (bool, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+	entry := outboxEntry{Method: method, Path: path, Body: data, Replace: replace, CreatedAt: time.Now()}
+
+	// Reports already waiting go first, so this one doesn't overtake them
+	if a.Outbox == nil || a.Outbox.empty() {
+		retry, err := a.sendReport(entry)
+		if err == nil || !retry || a.Outbox == nil {
+			return err == nil, err
+		}
+		infrastructure.AgentLogger.Warning("Server unreachable, queueing %s %s: %v", method, path, err)
+	}
+
+	if err := a.Outbox.add(entry); err != nil {
+		return false, fmt.Errorf("failed to queue report: %w", err)
+	}
+	return false, nil
+}
+
+// sendReport makes one delivery attempt. retry is set for failures that
+// may go away: the server not answering or answering with a 5xx or 429.
+func (a *Agent) sendReport(entry outboxEntry) (retry bool, err error) {
+	err = a.API.Do(context.Background(), entry.Method, entry.Path, json.RawMessage(entry.Body), nil)
+	return client.IsTemporary(err), err
+}
+
+// flushOutbox delivers queued reports in order, backing off exponentially
+// while the server can't be reached
+func (a *Agent) flushOutbox(ctx context.Context) {
+	var backoff time.Duration
+	for {
+		if a.flushOutboxOnce() {
+			backoff = 0
+			// Wait for the next report
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.Outbox.wake:
+			}
+			continue
+		}
+
+		backoff *= 2
+		if backoff < outboxMinBackoff {
+			backoff = outboxMinBackoff
+		}
+		if backoff > outboxMaxBackoff {
+			backoff = outboxMaxBackoff
+		}
+		// Jitter keeps agents that lost the server together from coming
+		// back at the same moment. Reports queued meanwhile wait too.
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// flushOutboxOnce sends the queued reports until one can't be delivered.
+// Reports the server rejects are dropped. It returns whether the outbox
+// was emptied.
+func (a *Agent) flushOutboxOnce() bool {
+	a.Outbox.flushMu.Lock()
+	defer a.Outbox.flushMu.Unlock()
+
+	entries, err := a.Outbox.list()
+	if err != nil {
+		infrastructure.AgentLogger.Error("%v", err)
+		return false
+	}
+
+	for _, entry := range entries {
+		retry, err := a.sendReport(entry)
+		if err != nil && retry {
+			entry.Attempts++
+			a.Outbox.mu.Lock()
+			if _, statErr := os.Stat(a.Outbox.path(entry.Seq)); statErr == nil {
+				a.Outbox.write(entry)
+			}
+			a.Outbox.mu.Unlock()
+			infrastructure.AgentLogger.Debug("Server still unreachable, %d reports queued: %v", len(entries), err)
+			return false
+		}
+		if err != nil {
+			infrastructure.AgentLogger.Warning("Server rejected queued %s %s from %s, dropping it: %v",
+				entry.Method, entry.Path, entry.CreatedAt.Format(time.RFC3339), err)
+		} else {
+			infrastructure.AgentLogger.Info("Delivered queued %s %s from %s", entry.Method, entry.Path, entry.CreatedAt.Format(time.RFC3339))
+		}
+		a.Outbox.remove(entry.Seq)
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outboxPaths(t *testing.T, o *outbox) []string {
+	t.Helper()
+	entries, err := o.list()
+	require.NoError(t, err)
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Method + " " + entry.Path + " " + string(entry.Body)
+	}
+	return paths
+}
+
+func TestOutbox_Replace(t *testing.T) {
+	o, err := newOutbox(t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, o.empty())
+
+	add := func(path, body string, replace bool) {
+		require.NoError(t, o.add(outboxEntry{Method: http.MethodPut, Path: path, Body: json.RawMessage(body), Replace: replace}))
+	}
+	add("/jobs/a/progress", "1", true)
+	add("/jobs/a/status", `"running"`, false)
+	add("/jobs/b/progress", "10", true)
+	add("/jobs/a/progress", "2", true)
+	add("/jobs/a/status", `"completed"`, false)
+
+	// The newer progress of job a supersedes the older one, status reports
+	// all stay, and the order is kept
+	assert.Equal(t, []string{
+		`PUT /jobs/a/status "running"`,
+		"PUT /jobs/b/progress 10",
+		"PUT /jobs/a/progress 2",
+		`PUT /jobs/a/status "completed"`,
+	}, outboxPaths(t, o))
+	assert.False(t, o.empty())
+}
+
+func TestOutbox_Restart(t *testing.T) {
+	dir := t.TempDir()
+	o, err := newOutbox(dir)
+	require.NoError(t, err)
+	require.NoError(t, o.add(outboxEntry{Method: http.MethodPost, Path: "/first"}))
+	require.NoError(t, o.add(outboxEntry{Method: http.MethodPost, Path: "/second"}))
+
+	// Leftovers of a write cut short and foreign files are skipped,
+	// unreadable entries dropped
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000099.json.tmp"), []byte("{"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte("{}"), 0600))
+	corrupt := filepath.Join(dir, "00000000000000000001.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0600))
+
+	restarted, err := newOutbox(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /second "}, outboxPaths(t, restarted))
+	assert.NoFileExists(t, corrupt)
+
+	// New reports are numbered after the ones that survived
+	require.NoError(t, restarted.add(outboxEntry{Method: http.MethodPost, Path: "/third"}))
+	assert.Equal(t, []string{"POST /second ", "POST /third "}, outboxPaths(t, restarted))
+	entries, err := restarted.list()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), entries[1].Seq)
+}
+
+// reportServer records the reports it gets, answering with status
+type reportServer struct {
+	mu       sync.Mutex
+	status   map[string]int // Path -> status, 200 when unset
+	received []string
+}
+
+func (s *reportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status[r.URL.Path]
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusOK {
+		s.received = append(s.received, r.Method+" "+r.URL.Path)
+	}
+	w.WriteHeader(status)
+	w.Write([]byte("{}"))
+}
+
+func (s *reportServer) delivered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+func (s *reportServer) setStatus(path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[path] = status
+}
+
+func TestAgent_FlushOutbox(t *testing.T) {
+	reports := &reportServer{status: map[string]int{"/jobs/a/status": http.StatusServiceUnavailable}}
+	server := httptest.NewServer(reports)
+	defer server.Close()
+	o, err := newOutbox(t.TempDir())
+	require.NoError(t, err)
+	agent := &Agent{API: client.New(server.URL, client.WithRetries(0)), Outbox: o}
+
+	// The server fails, so the report is queued
+	delivered, err := agent.report(http.MethodPut, "/jobs/a/status", "running", false)
+	require.NoError(t, err)
+	assert.False(t, delivered)
+
+	// Later reports queue behind it instead of overtaking it, even when the
+	// server would take them
+	delivered, err = agent.report(http.MethodPut, "/jobs/a/progress", 50, true)
+	require.NoError(t, err)
+	assert.False(t, delivered)
+	_, err = agent.report(http.MethodPost, "/jobs/a/rejected", nil, false)
+	require.NoError(t, err)
+	reports.setStatus("/jobs/a/rejected", http.StatusBadRequest)
+
+	assert.False(t, agent.flushOutboxOnce())
+	entries, err := o.list()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Empty(t, reports.delivered())
+
+	// Once the server is back they go out in order; the one it rejects is
+	// dropped
+	reports.setStatus("/jobs/a/status", http.StatusOK)
+	assert.True(t, agent.flushOutboxOnce())
+	assert.Equal(t, []string{"PUT /jobs/a/status", "PUT /jobs/a/progress"}, reports.delivered())
+	assert.True(t, o.empty())
+
+	delivered, err = agent.report(http.MethodPut, "/jobs/a/progress", 100, true)
+	require.NoError(t, err)
+	assert.True(t, delivered, "an empty outbox sends at once")
+
+	_, err = agent.report(http.MethodPost, "/jobs/a/rejected", nil, false)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr, "rejected reports aren't queued")
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.True(t, o.empty())
+}
+
+func TestAgent_FlushOutboxWakes(t *testing.T) {
+	reports := &reportServer{status: map[string]int{}}
+	server := httptest.NewServer(reports)
+	defer server.Close()
+	o, err := newOutbox(t.TempDir())
+	require.NoError(t, err)
+	agent := &Agent{API: client.New(server.URL, client.WithRetries(0)), Outbox: o}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		agent.flushOutbox(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, o.add(outboxEntry{Method: http.MethodPut, Path: "/jobs/a/status"}))
+	assert.Eventually(t, o.empty, 2*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
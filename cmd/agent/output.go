@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// hashcatOutput keeps the tail of one hashcat run's stdout and stderr, so a
+// failed job can be diagnosed from the server
+type hashcatOutput struct {
+	mu       sync.Mutex
+	stdout   outputTail
+	stderr   outputTail
+	exitCode *int
+}
+
+func newHashcatOutput(tailKB int) *hashcatOutput {
+	max := tailKB * 1024
+	return &hashcatOutput{stdout: outputTail{max: max}, stderr: outputTail{max: max}}
+}
+
+func (o *hashcatOutput) addStdout(line string) {
+	o.mu.Lock()
+	o.stdout.add(line)
+	o.mu.Unlock()
+}
+
+func (o *hashcatOutput) addStderr(line string) {
+	o.mu.Lock()
+	o.stderr.add(line)
+	o.mu.Unlock()
+}
+
+func (o *hashcatOutput) setExitCode(code int) {
+	o.mu.Lock()
+	o.exitCode = &code
+	o.mu.Unlock()
+}
+
+// report returns what is sent with the job's completion or failure. A nil
+// output, for jobs that never ran hashcat, reports nothing.
+func (o *hashcatOutput) report() *domain.JobOutput {
+	if o == nil {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return &domain.JobOutput{
+		ExitCode:  o.exitCode,
+		Stdout:    o.stdout.String(),
+		Stderr:    o.stderr.String(),
+		Truncated: o.stdout.dropped || o.stderr.dropped,
+	}
+}
+
+// outputTail holds the newest lines that fit in max bytes
+type outputTail struct {
+	lines   []string
+	size    int
+	max     int
+	dropped bool // Older lines were dropped to stay within max
+}
+
+func (t *outputTail) add(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) >= t.max {
+		line = line[len(line)-t.max+1:]
+		t.dropped = true
+	}
+
+	t.lines = append(t.lines, line)
+	t.size += len(line) + 1
+	for t.size > t.max {
+		t.size -= len(t.lines[0]) + 1
+		t.lines = t.lines[1:]
+		t.dropped = true
+	}
+}
+
+func (t *outputTail) String() string {
+	if len(t.lines) == 0 {
+		return ""
+	}
+	return strings.Join(t.lines, "\n") + "\n"
+}
+
+// hashcatRunError is a hashcat run that failed, with the output it left
+type hashcatRunError struct {
+	err    error
+	output *hashcatOutput
+}
+
+func (e *hashcatRunError) Error() string { return e.err.Error() }
+
+func (e *hashcatRunError) Unwrap() error { return e.err }
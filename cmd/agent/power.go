@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	powerReadTimeout = 3 * time.Second
+	// powerReadEvery keeps frequent progress updates from each running
+	// nvidia-smi
+	powerReadEvery = 10 * time.Second
+)
+
+var powerCache struct {
+	sync.Mutex
+	watts  float64
+	readAt time.Time
+}
+
+// devicePowerDraw returns the combined power draw of the NVIDIA GPUs in
+// watts, or 0 when nvidia-smi is missing or can't read it. The server then
+// estimates the draw from its configured watts per device.
+func devicePowerDraw() float64 {
+	powerCache.Lock()
+	defer powerCache.Unlock()
+
+	if !powerCache.readAt.IsZero() && time.Since(powerCache.readAt) < powerReadEvery {
+		return powerCache.watts
+	}
+	powerCache.readAt = time.Now()
+	powerCache.watts = 0
+
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), powerReadTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=power.draw", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0
+	}
+	powerCache.watts = parsePowerDraw(string(output))
+	return powerCache.watts
+}
+
+// parsePowerDraw sums the per-GPU lines of nvidia-smi's power.draw query.
+// GPUs that don't support the reading print [N/A] and are skipped.
+func parsePowerDraw(output string) float64 {
+	var total float64
+	for _, line := range strings.Split(output, "\n") {
+		watts, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+		if err == nil && watts > 0 {
+			total += watts
+		}
+	}
+	return total
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// suspendProcess stops a running hashcat process without killing it
+func suspendProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGSTOP)
+}
+
+// interruptProcess asks hashcat to quit; it writes its restore file first
+func interruptProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGINT)
+}
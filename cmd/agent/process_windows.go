@@ -0,0 +1,35 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Windows has no SIGSTOP; ntdll can suspend all threads of a process
+var procNtSuspendProcess = syscall.NewLazyDLL("ntdll.dll").NewProc("NtSuspendProcess")
+
+const processSuspendResume = 0x0800
+
+// suspendProcess stops a running hashcat process without killing it
+func suspendProcess(p *os.Process) error {
+	handle, err := syscall.OpenProcess(processSuspendResume, false, uint32(p.Pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process %d: %w", p.Pid, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	if status, _, _ := procNtSuspendProcess.Call(uintptr(handle)); status != 0 {
+		return fmt.Errorf("NtSuspendProcess failed with status 0x%x", status)
+	}
+	return nil
+}
+
+// interruptProcess stops hashcat. Windows can't send a console process
+// Ctrl-C from outside its console, so it is killed and resumes from the
+// restore file hashcat wrote on its last checkpoint timer.
+func interruptProcess(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// heartbeatMaxBackoff caps the wait between heartbeats while the server
+// can't be reached
+const heartbeatMaxBackoff = time.Minute
+
+// errJobAbandoned is returned by runJob when the server took the job back
+// while the agent was out of touch
+var errJobAbandoned = errors.New("job taken back by the server")
+
+// serverLink tracks whether the server answers heartbeats and whether the
+// agent's jobs were reconciled with it since it last got through
+type serverLink struct {
+	mu       sync.Mutex
+	failures int       // Heartbeats failed in a row
+	lostAt   time.Time // When the first of them failed
+	synced   bool
+}
+
+// serverReady reports whether the server answers and knows what the agent
+// is running, so it may take new jobs
+func (a *Agent) serverReady() bool {
+	a.link.mu.Lock()
+	defer a.link.mu.Unlock()
+	return a.link.failures == 0 && a.link.synced
+}
+
+// heartbeat sends one heartbeat, reconciles jobs with the server after it
+// got through again, and returns when to send the next one
+func (a *Agent) heartbeat() time.Duration {
+	if err := a.sendHeartbeat(); err != nil {
+		return a.heartbeatFailed(err)
+	}
+
+	a.link.mu.Lock()
+	if a.link.failures > 0 {
+		infrastructure.AgentLogger.Success("Reconnected to server after %s", time.Since(a.link.lostAt).Round(time.Second))
+	}
+	a.link.failures = 0
+	synced := a.link.synced
+	a.link.mu.Unlock()
+
+	if !synced {
+		if err := a.resync(); err != nil {
+			infrastructure.AgentLogger.Warning("Failed to sync jobs with server, retrying: %v", err)
+		} else {
+			a.link.mu.Lock()
+			a.link.synced = true
+			a.link.mu.Unlock()
+		}
+	}
+	return a.heartbeatEvery()
+}
+
+// heartbeatFailed records a failed heartbeat and returns how long to wait
+// before the next one. The wait doubles with each failure, with jitter so
+// agents that lost the server together don't all come back at once. Only
+// the first failure is logged as a warning.
+func (a *Agent) heartbeatFailed(err error) time.Duration {
+	a.link.mu.Lock()
+	defer a.link.mu.Unlock()
+
+	if a.link.failures == 0 {
+		a.link.lostAt = time.Now()
+		infrastructure.AgentLogger.Warning("Lost connection to server, retrying with backoff: %v", err)
+	}
+	a.link.failures++
+	a.link.synced = false
+
+	backoff := a.heartbeatEvery()
+	for i := 1; i < a.link.failures && backoff < heartbeatMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > heartbeatMaxBackoff {
+		backoff = heartbeatMaxBackoff
+	}
+	delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	infrastructure.AgentLogger.Debug("Server still unreachable after %d heartbeats, next in %s: %v", a.link.failures, delay.Round(time.Millisecond), err)
+	return delay
+}
+
+// resync tells the server which job the agent is running, so jobs it lost
+// track of are handed to other agents, and stops the running job if the
+// server gave it away meanwhile. Queued reports are delivered first, or the
+// server would requeue jobs the agent already finished.
+func (a *Agent) resync() error {
+	if a.Outbox != nil && !a.Outbox.empty() && !a.flushOutboxOnce() {
+		return fmt.Errorf("job reports still queued")
+	}
+
+	snapshot := a.heartbeatSnapshot()
+	result, err := a.API.SyncAgent(context.Background(), a.AgentKey, snapshot)
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+
+	if n := len(result.RequeuedJobs); n > 0 {
+		infrastructure.AgentLogger.Warning("Server handed %d jobs this agent no longer runs to other agents", n)
+	}
+	if snapshot.CurrentJobID != nil && !result.KeepJob {
+		a.abandonRunningJob(*snapshot.CurrentJobID, result.Reason)
+	}
+	return nil
+}
+
+// abandonRunningJob kills the engine of a job the server took back. Nothing
+// is reported for it; the job is no longer the agent's.
+func (a *Agent) abandonRunningJob(jobID uuid.UUID, reason string) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
+
+	a.runMu.Lock()
+	run := a.running
+	if run != nil && run.jobID == jobID {
+		run.abandoned = true
+	}
+	a.runMu.Unlock()
+
+	if run == nil || run.jobID != jobID || run.cmd.Process == nil {
+		logger.Warning("Server took back job %s (%s) before it started", jobID, reason)
+		return
+	}
+	logger.Warning("Stopping job %s, the server took it back: %s", jobID, reason)
+	run.cmd.Process.Kill()
+}
+
+// wasAbandoned reports whether the job's run was stopped because the server
+// took the job back
+func (a *Agent) wasAbandoned(jobID uuid.UUID) bool {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	return a.running != nil && a.running.jobID == jobID && a.running.abandoned
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// defaultCgroupParent is the cgroup v2 directory per-job cgroups are
+// created in unless sandbox-cgroup names another
+const defaultCgroupParent = "/sys/fs/cgroup/hashcat-agent"
+
+// I/O scheduling classes for sandbox-ionice
+const (
+	ioniceNone       = "none"
+	ioniceBestEffort = "best-effort"
+	ioniceIdle       = "idle"
+)
+
+// sandboxSettings restrict the engine process of a job so it can't starve
+// the host or write outside the upload directory. The zero value runs it
+// unrestricted, as before.
+type sandboxSettings struct {
+	CPUs     float64 // cgroup CPU limit in cores, 0 for unlimited
+	MemoryMB int64   // cgroup memory limit, 0 for unlimited
+	// cgroup v2 directory the per-job cgroups are created in
+	CgroupParent string
+	Nice         int    // 0 (normal) to 19 (lowest priority)
+	IONice       string // none, best-effort or idle
+	User         string // Unprivileged user to run as, empty for the agent's own
+	// Run in <upload-dir>/temp with HOME and TMPDIR inside the upload
+	// directory, so the engine's temp files, sessions and potfiles stay there
+	Jail bool
+}
+
+// readSandboxSettings takes the sandbox settings from flags, environment
+// and config file, replacing invalid ones with the defaults
+func readSandboxSettings() sandboxSettings {
+	s := sandboxSettings{
+		CPUs:         viper.GetFloat64("sandbox-cpus"),
+		MemoryMB:     viper.GetInt64("sandbox-memory-mb"),
+		CgroupParent: strings.TrimSpace(viper.GetString("sandbox-cgroup")),
+		Nice:         viper.GetInt("sandbox-nice"),
+		IONice:       strings.ToLower(strings.TrimSpace(viper.GetString("sandbox-ionice"))),
+		User:         strings.TrimSpace(viper.GetString("sandbox-user")),
+		Jail:         viper.GetBool("sandbox-jail"),
+	}
+
+	if s.CPUs < 0 {
+		s.CPUs = 0
+	}
+	if s.MemoryMB < 0 {
+		s.MemoryMB = 0
+	}
+	if s.CgroupParent == "" {
+		s.CgroupParent = defaultCgroupParent
+	}
+	if s.Nice < 0 || s.Nice > 19 {
+		infrastructure.AgentLogger.Warning("Invalid sandbox nice value %d, using 0", s.Nice)
+		s.Nice = 0
+	}
+	switch s.IONice {
+	case "":
+		s.IONice = ioniceNone
+	case ioniceNone, ioniceBestEffort, ioniceIdle:
+	default:
+		infrastructure.AgentLogger.Warning("Invalid sandbox I/O class %q, using none", s.IONice)
+		s.IONice = ioniceNone
+	}
+
+	return s
+}
+
+// restricted reports whether any setting needs the platform's process
+// controls, which the jail alone doesn't
+func (s sandboxSettings) restricted() bool {
+	return s.CPUs > 0 || s.MemoryMB > 0 || s.Nice > 0 || s.IONice != ioniceNone || s.User != ""
+}
+
+// String describes the restrictions for the log
+func (s sandboxSettings) String() string {
+	var parts []string
+	if s.CPUs > 0 {
+		parts = append(parts, fmt.Sprintf("%g CPUs", s.CPUs))
+	}
+	if s.MemoryMB > 0 {
+		parts = append(parts, fmt.Sprintf("%d MB memory", s.MemoryMB))
+	}
+	if s.Nice > 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", s.Nice))
+	}
+	if s.IONice != ioniceNone {
+		parts = append(parts, "I/O "+s.IONice)
+	}
+	if s.User != "" {
+		parts = append(parts, "user "+s.User)
+	}
+	if s.Jail {
+		parts = append(parts, "jailed to the upload directory")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// engineWorkDir is the directory a job's engine runs in: the job
+// directory when jailed, the agent's own working directory otherwise
+func (a *Agent) engineWorkDir(jobID uuid.UUID, s sandboxSettings) (string, error) {
+	if s.Jail {
+		return filepath.Abs(a.jobDir(jobID))
+	}
+	return os.Getwd()
+}
+
+// sandboxCommand returns the command that runs a job's engine under the
+// sandbox settings. The returned func releases what the sandbox set up
+// and must be called once the process has exited.
+func (a *Agent) sandboxCommand(jobID uuid.UUID, s sandboxSettings, binary string, args []string, outfile string) (*exec.Cmd, func(), error) {
+	if s.Jail {
+		// Everything the engine is told to write goes to the job directory
+		if _, err := a.ensureInUploadDir(outfile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cmd := exec.Command(binary, args...)
+	if s.Jail {
+		workDir, err := a.engineWorkDir(jobID, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		home, err := filepath.Abs(filepath.Join(a.UploadDir, "sandbox"))
+		if err != nil {
+			return nil, nil, err
+		}
+		// The home directory is kept between jobs, so hashcat's kernel
+		// cache survives
+		if err := os.MkdirAll(home, 0700); err != nil {
+			return nil, nil, fmt.Errorf("failed to create sandbox home: %w", err)
+		}
+
+		cmd.Dir = workDir
+		cmd.Env = jailEnv(os.Environ(), home, workDir)
+	}
+
+	if !s.restricted() {
+		return cmd, func() {}, nil
+	}
+	release, err := applySandbox(cmd, jobID, s, a.sandboxPaths(jobID, s))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sandbox job: %w", err)
+	}
+	return cmd, release, nil
+}
+
+// sandboxPaths are the directories a dedicated sandbox user must be able
+// to write to
+func (a *Agent) sandboxPaths(jobID uuid.UUID, s sandboxSettings) []string {
+	paths := []string{a.jobDir(jobID)}
+	if s.Jail {
+		paths = append(paths, filepath.Join(a.UploadDir, "sandbox"))
+	}
+	return paths
+}
+
+// jailEnv points HOME and TMPDIR into the upload directory and drops the
+// XDG directories, which would otherwise lead hashcat out of it
+func jailEnv(environ []string, home, tmp string) []string {
+	env := make([]string, 0, len(environ)+4)
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case "HOME", "TMPDIR", "TMP", "TEMP", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_CONFIG_HOME", "XDG_RUNTIME_DIR":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "HOME="+home, "TMPDIR="+tmp, "TMP="+tmp, "TEMP="+tmp)
+}
@@ -0,0 +1,198 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// cgroupCPUPeriod is the cpu.max period in microseconds
+const cgroupCPUPeriod = 100000
+
+// applySandbox restricts cmd: nice and ionice wrap the engine, which they
+// exec in place so the process stays the same; a cgroup of its own caps
+// CPU and memory; and the credential drops it to the sandbox user, who is
+// given the writable directories. The returned func removes the cgroup.
+func applySandbox(cmd *exec.Cmd, jobID uuid.UUID, s sandboxSettings, writable []string) (func(), error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	if err := wrapPriority(cmd, s); err != nil {
+		return nil, err
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	if s.User != "" {
+		credential, err := sandboxCredential(s.User)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range writable {
+			if err := chownTree(dir, int(credential.Uid), int(credential.Gid)); err != nil {
+				return nil, fmt.Errorf("failed to hand %s to %s: %w", dir, s.User, err)
+			}
+		}
+		cmd.SysProcAttr.Credential = credential
+	}
+
+	if s.CPUs <= 0 && s.MemoryMB <= 0 {
+		return func() {}, nil
+	}
+	cgroup, err := createJobCgroup(s, jobID)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.Open(cgroup)
+	if err != nil {
+		os.Remove(cgroup)
+		return nil, fmt.Errorf("failed to open cgroup %s: %w", cgroup, err)
+	}
+	// The engine starts inside the cgroup, with no moment unrestricted
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+
+	return func() {
+		dir.Close()
+		if err := os.Remove(cgroup); err != nil && !os.IsNotExist(err) {
+			infrastructure.AgentLogger.Warning("Failed to remove cgroup %s: %v", cgroup, err)
+		}
+	}, nil
+}
+
+// wrapPriority runs the engine through ionice and nice
+func wrapPriority(cmd *exec.Cmd, s sandboxSettings) error {
+	var wrappers [][]string
+	switch s.IONice {
+	case ioniceIdle:
+		wrappers = append(wrappers, []string{"ionice", "-c", "3"})
+	case ioniceBestEffort:
+		wrappers = append(wrappers, []string{"ionice", "-c", "2", "-n", "7"})
+	}
+	if s.Nice > 0 {
+		wrappers = append(wrappers, []string{"nice", "-n", strconv.Itoa(s.Nice)})
+	}
+	if len(wrappers) == 0 {
+		return nil
+	}
+
+	var prefix []string
+	for _, wrapper := range wrappers {
+		path, err := exec.LookPath(wrapper[0])
+		if err != nil {
+			return fmt.Errorf("%s is needed for the sandbox priority settings: %w", wrapper[0], err)
+		}
+		prefix = append(append(prefix, path), wrapper[1:]...)
+	}
+	// The wrappers exec the resolved engine binary with its arguments
+	cmd.Args = append(append(prefix, cmd.Path), cmd.Args[1:]...)
+	cmd.Path = prefix[0]
+	return nil
+}
+
+// sandboxCredential looks up the sandbox user with its groups, which
+// usually include the ones that give access to the GPUs
+func sandboxCredential(name string) (*syscall.Credential, error) {
+	account, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox user: %w", err)
+	}
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox user %s has uid %q: %w", name, account.Uid, err)
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox user %s has gid %q: %w", name, account.Gid, err)
+	}
+	if uid == 0 {
+		return nil, fmt.Errorf("sandbox user %s is root", name)
+	}
+	if euid := os.Geteuid(); euid != 0 && uint64(euid) != uid {
+		return nil, errors.New("the agent must run as root to run jobs as another user")
+	}
+
+	credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	groupIDs, err := account.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the groups of sandbox user %s: %w", name, err)
+	}
+	for _, id := range groupIDs {
+		if group, err := strconv.ParseUint(id, 10, 32); err == nil {
+			credential.Groups = append(credential.Groups, uint32(group))
+		}
+	}
+	return credential, nil
+}
+
+// chownTree gives a directory and everything in it to uid and gid,
+// without following symlinks
+func chownTree(root string, uid, gid int) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
+
+// createJobCgroup creates the cgroup v2 group of a job under the parent
+// directory with the CPU and memory limits written to it
+func createJobCgroup(s sandboxSettings, jobID uuid.UUID) (string, error) {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return "", errors.New("CPU and memory limits need cgroup v2 mounted at /sys/fs/cgroup")
+	}
+	if err := os.MkdirAll(s.CgroupParent, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cgroup %s: %w", s.CgroupParent, err)
+	}
+
+	var controllers []string
+	if s.CPUs > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if s.MemoryMB > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if err := os.WriteFile(filepath.Join(s.CgroupParent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0644); err != nil {
+		return "", fmt.Errorf("failed to enable %s in cgroup %s, it must be delegated to the agent: %w", controllers, s.CgroupParent, err)
+	}
+
+	cgroup := filepath.Join(s.CgroupParent, "job-"+jobID.String())
+	// A cgroup left behind by a crash is empty and can go
+	os.Remove(cgroup)
+	if err := os.Mkdir(cgroup, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cgroup %s: %w", cgroup, err)
+	}
+
+	limits := map[string]string{}
+	if s.CPUs > 0 {
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int64(s.CPUs*cgroupCPUPeriod), cgroupCPUPeriod)
+	}
+	if s.MemoryMB > 0 {
+		limits["memory.max"] = strconv.FormatInt(s.MemoryMB<<20, 10)
+	}
+	for file, value := range limits {
+		if err := os.WriteFile(filepath.Join(cgroup, file), []byte(value), 0644); err != nil {
+			os.Remove(cgroup)
+			return "", fmt.Errorf("failed to set %s of cgroup %s: %w", file, cgroup, err)
+		}
+	}
+	if s.MemoryMB > 0 {
+		// Without swap the limit is a limit; kernels without swap
+		// accounting don't have the file
+		os.WriteFile(filepath.Join(cgroup, "memory.swap.max"), []byte("0"), 0644)
+	}
+	return cgroup, nil
+}
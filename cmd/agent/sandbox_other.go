@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/google/uuid"
+)
+
+// applySandbox refuses the process controls, which need Linux; the jail
+// works everywhere
+func applySandbox(cmd *exec.Cmd, jobID uuid.UUID, s sandboxSettings, writable []string) (func(), error) {
+	return nil, fmt.Errorf("CPU and memory limits, nice, ionice and a sandbox user are not supported on %s", runtime.GOOS)
+}
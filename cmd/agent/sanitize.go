@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// validateJob re-checks the job fields the server is supposed to have
+// validated already. The agent doesn't trust the server blindly: a
+// compromised server must not be able to run hashcat with arbitrary modes.
+func (a *Agent) validateJob(job *domain.Job) error {
+	if err := domain.ValidateHashMode(job.HashType); err != nil {
+		return err
+	}
+	if err := domain.ValidateAttackMode(job.AttackMode); err != nil {
+		return err
+	}
+	if err := domain.ValidateCustomCharsets(job.AttackMode, job.Wordlist, customCharsets(job)...); err != nil {
+		return err
+	}
+	if err := domain.ValidateEngineParams(job.EngineName(), job.JohnFormat, job.AttackMode, job.Rules, job.ExtraArgs, customCharsets(job)...); err != nil {
+		return err
+	}
+	if job.AttackMode == domain.AttackModeCombinator && (job.WordlistID == nil || job.Wordlist2ID == nil) {
+		return &domain.ValidationError{Field: "wordlist2_id", Message: "combinator attacks need two wordlist IDs"}
+	}
+	return domain.ValidateRuleReference(job.Rules)
+}
+
+// ensureInUploadDir resolves path and rejects anything outside the agent's
+// upload directory, including symlinks that point out of it
+func (a *Agent) ensureInUploadDir(path string) (string, error) {
+	root, err := filepath.Abs(a.UploadDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve upload directory: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to use %s: outside of %s", path, a.UploadDir)
+	}
+	return abs, nil
+}
+
+// resolveRuleFile finds one of hashcat's built-in rule files next to the
+// agent's hashcat
+func (a *Agent) resolveRuleFile(rules string) (string, error) {
+	return infrastructure.FindHashcatRule(a.Settings.Get().HashcatPath, rules)
+}
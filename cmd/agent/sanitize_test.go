@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJob(t *testing.T) {
+	a := &Agent{}
+	wordlistID, wordlist2ID := uuid.New(), uuid.New()
+	valid := func() *domain.Job {
+		return &domain.Job{HashType: 1000, AttackMode: 0, Wordlist: "rockyou.txt", Rules: "best64.rule"}
+	}
+	require.NoError(t, a.validateJob(valid()))
+
+	combinator := valid()
+	combinator.AttackMode, combinator.Rules = domain.AttackModeCombinator, ""
+	combinator.WordlistID, combinator.Wordlist2ID = &wordlistID, &wordlist2ID
+	require.NoError(t, a.validateJob(combinator))
+
+	// A compromised server can't slip anything else past the agent
+	for name, change := range map[string]func(job *domain.Job){
+		"hash mode":         func(job *domain.Job) { job.HashType = -1 },
+		"attack mode":       func(job *domain.Job) { job.AttackMode = 42 },
+		"rule file path":    func(job *domain.Job) { job.Rules = "../../../etc/shadow" },
+		"charset of mode 0": func(job *domain.Job) { job.CustomCharset1 = "?l?d" },
+		"john format":       func(job *domain.Job) { job.JohnFormat = "raw-md5" },
+		"unknown engine":    func(job *domain.Job) { job.Engine = "sh" },
+		"john with rules":   func(job *domain.Job) { job.Engine, job.JohnFormat = domain.EngineJohn, "raw-md5" },
+		"combinator, one ID": func(job *domain.Job) {
+			job.AttackMode, job.Rules, job.WordlistID = domain.AttackModeCombinator, "", &wordlistID
+		},
+	} {
+		job := valid()
+		change(job)
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, a.validateJob(job), &validationErr, name)
+	}
+}
+
+func TestEnsureInUploadDir(t *testing.T) {
+	base := t.TempDir()
+	a := &Agent{UploadDir: filepath.Join(base, "uploads")}
+	require.NoError(t, os.MkdirAll(filepath.Join(a.UploadDir, "wordlists"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "uploads2"), 0755))
+
+	path, err := a.ensureInUploadDir(filepath.Join(a.UploadDir, "wordlists", "rockyou.txt"))
+	require.NoError(t, err)
+	root, err := filepath.EvalSymlinks(a.UploadDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "wordlists", "rockyou.txt"), path)
+
+	for _, outside := range []string{
+		filepath.Join(a.UploadDir, "..", "secret.txt"),
+		filepath.Join(a.UploadDir, "wordlists", "..", "..", "secret.txt"),
+		filepath.Join(base, "uploads2", "file"), // Shares a prefix only
+		base,
+	} {
+		_, err := a.ensureInUploadDir(outside)
+		assert.Error(t, err, outside)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// Symlinks are followed to where they point
+	secret := filepath.Join(base, "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("x"), 0600))
+	link := filepath.Join(a.UploadDir, "wordlists", "link.txt")
+	require.NoError(t, os.Symlink(secret, link))
+	_, err = a.ensureInUploadDir(link)
+	assert.ErrorContains(t, err, "outside of")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// jobStatus is a progress report parsed from a cracking engine's output
+type jobStatus interface {
+	percent() float64
+	speed() int64                   // H/s
+	utilization() int               // Average device utilization in percent, -1 when unknown
+	eta() *string                   // RFC3339, nil when unknown
+	stats() *domain.JobRuntimeStats // nil when the engine reports none
+}
+
+// hashcatStatus is one line of hashcat's --status-json output
+type hashcatStatus struct {
+	Session         string  `json:"session"`
+	Status          int     `json:"status"`
+	Progress        []int64 `json:"progress"` // [done, total]
+	RestorePoint    int64   `json:"restore_point"`
+	RecoveredHashes []int64 `json:"recovered_hashes"` // [recovered, total]
+	Rejected        int64   `json:"rejected"`
+	Devices         []struct {
+		DeviceID   int    `json:"device_id"`
+		DeviceName string `json:"device_name"`
+		DeviceType string `json:"device_type"`
+		Speed      int64  `json:"speed"`
+		Temp       *int   `json:"temp"`
+		Util       int    `json:"util"`
+	} `json:"devices"`
+	TimeStart     int64 `json:"time_start"`
+	EstimatedStop int64 `json:"estimated_stop"`
+}
+
+// parseHashcatStatus decodes a status line. Anything that isn't a JSON
+// status object (banner, warnings, cracked hashes) is ignored.
+func parseHashcatStatus(line []byte) (*hashcatStatus, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	var status hashcatStatus
+	if err := json.Unmarshal(line, &status); err != nil || len(status.Progress) != 2 {
+		return nil, false
+	}
+	return &status, true
+}
+
+// percent returns overall progress in percent
+func (s *hashcatStatus) percent() float64 {
+	if s.Progress[1] <= 0 {
+		return 0
+	}
+	return float64(s.Progress[0]) / float64(s.Progress[1]) * 100
+}
+
+// speed returns the combined speed of all devices in H/s
+func (s *hashcatStatus) speed() int64 {
+	var total int64
+	for _, d := range s.Devices {
+		total += d.Speed
+	}
+	return total
+}
+
+// utilization returns the average utilization of the devices in percent,
+// or -1 when hashcat reports none
+func (s *hashcatStatus) utilization() int {
+	total, count := 0, 0
+	for _, d := range s.Devices {
+		if d.Util >= 0 {
+			total += d.Util
+			count++
+		}
+	}
+	if count == 0 {
+		return -1
+	}
+	return total / count
+}
+
+// eta returns the estimated stop time in RFC3339, or nil when hashcat
+// doesn't know yet
+func (s *hashcatStatus) eta() *string {
+	if s.EstimatedStop <= 0 {
+		return nil
+	}
+	eta := time.Unix(s.EstimatedStop, 0).Format(time.RFC3339)
+	return &eta
+}
+
+func (s *hashcatStatus) stats() *domain.JobRuntimeStats {
+	stats := &domain.JobRuntimeStats{
+		HashcatStatus: s.Status,
+		ProgressDone:  s.Progress[0],
+		ProgressTotal: s.Progress[1],
+		RestorePoint:  s.RestorePoint,
+		Rejected:      s.Rejected,
+		Devices:       make([]domain.DeviceStatus, 0, len(s.Devices)),
+	}
+	if len(s.RecoveredHashes) == 2 {
+		stats.RecoveredHashes = s.RecoveredHashes[0]
+		stats.TotalHashes = s.RecoveredHashes[1]
+	}
+
+	for _, d := range s.Devices {
+		device := domain.DeviceStatus{
+			ID:          d.DeviceID,
+			Name:        d.DeviceName,
+			Type:        d.DeviceType,
+			Speed:       d.Speed,
+			Utilization: d.Util,
+		}
+		// hashcat reports -1 when the sensor can't be read
+		if d.Temp != nil && *d.Temp >= 0 {
+			device.Temperature = d.Temp
+		}
+		stats.Devices = append(stats.Devices, device)
+	}
+	return stats
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusLine is --status-json output of hashcat 6.2 with two devices, one
+// without a temperature sensor
+const statusLine = `{ "session": "job-1", "guess": { "guess_base": "rockyou.txt" }, "status": 3, "target": "hashes.txt",
+ "progress": [2500, 10000], "restore_point": 2000, "recovered_hashes": [1, 4], "recovered_salts": [1, 1], "rejected": 7,
+ "devices": [ { "device_id": 1, "device_name": "NVIDIA RTX 4090", "device_type": "GPU", "speed": 1200000, "temp": 61, "util": 98 },
+ { "device_id": 2, "device_name": "Intel CPU", "device_type": "CPU", "speed": 3000, "temp": -1, "util": 40 } ],
+ "time_start": 1700000000, "estimated_stop": 1700003600 }`
+
+func TestParseHashcatStatus(t *testing.T) {
+	status, ok := parseHashcatStatus([]byte("  " + statusLine + "\n"))
+	require.True(t, ok)
+	assert.Equal(t, 25.0, status.percent())
+	assert.Equal(t, int64(1203000), status.speed())
+	assert.Equal(t, 69, status.utilization())
+	require.NotNil(t, status.eta())
+	assert.Equal(t, time.Unix(1700003600, 0).Format(time.RFC3339), *status.eta())
+
+	stats := status.stats()
+	assert.Equal(t, 3, stats.HashcatStatus)
+	assert.Equal(t, int64(2500), stats.ProgressDone)
+	assert.Equal(t, int64(10000), stats.ProgressTotal)
+	assert.Equal(t, int64(2000), stats.RestorePoint)
+	assert.Equal(t, int64(1), stats.RecoveredHashes)
+	assert.Equal(t, int64(4), stats.TotalHashes)
+	assert.Equal(t, int64(7), stats.Rejected)
+	require.Len(t, stats.Devices, 2)
+	assert.Equal(t, "NVIDIA RTX 4090", stats.Devices[0].Name)
+	require.NotNil(t, stats.Devices[0].Temperature)
+	assert.Equal(t, 61, *stats.Devices[0].Temperature)
+	assert.Nil(t, stats.Devices[1].Temperature, "hashcat reports -1 without a sensor")
+}
+
+func TestParseHashcatStatus_Ignored(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"hashcat (v6.2.6) starting",
+		"5f4dcc3b5aa765d61d8327deb882cf99:password",
+		`{"session": "job-1", "status": 3}`, // No progress
+		`{"session": "job-1", "progress": [1]}`,
+		`{"session": "job-1", "progress": [1, 2]`,
+	} {
+		_, ok := parseHashcatStatus([]byte(line))
+		assert.False(t, ok, line)
+	}
+}
+
+func TestHashcatStatus_Unknowns(t *testing.T) {
+	status, ok := parseHashcatStatus([]byte(`{"status": 2, "progress": [0, 0]}`))
+	require.True(t, ok)
+	assert.Zero(t, status.percent())
+	assert.Zero(t, status.speed())
+	assert.Equal(t, -1, status.utilization())
+	assert.Nil(t, status.eta())
+
+	stats := status.stats()
+	assert.Zero(t, stats.TotalHashes)
+	assert.Empty(t, stats.Devices)
+}
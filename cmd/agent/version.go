@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// hashcatVersions caches hashcat --version per binary. Heartbeats report it
+// every few seconds, while the binary only changes on a config reload.
+var hashcatVersions sync.Map
+
+// hashcatVersionOf returns what hashcat --version prints, or an empty
+// string when the binary can't be run. Failures are not cached, so a
+// hashcat installed later is picked up by the next heartbeat.
+func hashcatVersionOf(hashcatPath string) string {
+	if version, ok := hashcatVersions.Load(hashcatPath); ok {
+		return version.(string)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, hashcatPath, "--version").Output()
+	if err != nil {
+		return ""
+	}
+
+	version := strings.TrimSpace(string(output))
+	hashcatVersions.Store(hashcatPath, version)
+	return version
+}
+
+// logHashcatVersion reports the hashcat version at startup and warns when
+// the server won't send this agent any work because of it
+func logHashcatVersion(hashcatPath string) {
+	version := hashcatVersionOf(hashcatPath)
+	if version == "" {
+		infrastructure.AgentLogger.Warning("Could not detect the version of %s", hashcatPath)
+		return
+	}
+
+	v, ok := domain.ParseHashcatVersion(version)
+	switch {
+	case !ok:
+		infrastructure.AgentLogger.Warning("Unrecognised hashcat version %q, jobs are not checked against it", version)
+	case !v.AtLeast(domain.MinHashcatVersion):
+		infrastructure.AgentLogger.Warning("hashcat %s is older than %s, the oldest supported release; no jobs will be assigned", version, domain.MinHashcatVersion)
+	default:
+		infrastructure.AgentLogger.Info("Using hashcat %s", version)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// jobWatchdog tells when a run goes over its job's max runtime or past its
+// deadline, or when its progress and speed stop changing, as they do when
+// hashcat hangs in the GPU driver
+type jobWatchdog struct {
+	job       *domain.Job
+	startedAt time.Time
+	progress  float64
+	speed     int64
+	changedAt time.Time
+}
+
+func newJobWatchdog(job *domain.Job, now time.Time) *jobWatchdog {
+	return &jobWatchdog{job: job, startedAt: now, changedAt: now}
+}
+
+// check returns why the run must stop, or "" while it is within its limits.
+// status is the engine's latest status, nil before it printed one.
+func (w *jobWatchdog) check(status jobStatus, now time.Time) string {
+	if status != nil && (status.percent() != w.progress || status.speed() != w.speed) {
+		w.progress, w.speed, w.changedAt = status.percent(), status.speed(), now
+	}
+
+	if left, ok := w.job.TimeLeft(now); ok && left <= 0 {
+		return domain.JobDeadlineReachedReason
+	}
+	if limit := w.job.MaxRuntime(); limit > 0 && now.Sub(w.startedAt) > limit {
+		return domain.JobRuntimeExceededReason(w.job.MaxRuntimeMinutes)
+	}
+	if limit := w.job.StallTimeout(); limit > 0 && now.Sub(w.changedAt) > limit {
+		return domain.JobStalledReason(w.job.StallTimeoutMinutes)
+	}
+	return ""
+}
+
+// restart gives the run a fresh stall timeout from now. The max runtime and
+// deadline are limits of the job and stay as they are.
+func (w *jobWatchdog) restart(now time.Time) {
+	w.changedAt = now
+}
+
+// latestJobStatus is the engine's latest status of a job, nil if it printed
+// none yet
+func (a *Agent) latestJobStatus(jobID uuid.UUID) jobStatus {
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+	if a.lastStatusJob != jobID {
+		return nil
+	}
+	return a.lastStatus
+}
+
+// stopRunningJob kills the engine of a job that went over its limits. runJob
+// then fails the job with reason.
+func (a *Agent) stopRunningJob(jobID uuid.UUID, reason string) {
+	logger := infrastructure.AgentLogger.With("job_id", jobID)
+
+	a.runMu.Lock()
+	run := a.running
+	if run != nil && run.jobID == jobID {
+		run.stopReason = reason
+	}
+	a.runMu.Unlock()
+
+	if run == nil || run.jobID != jobID || run.cmd.Process == nil {
+		return
+	}
+	logger.Warning("Stopping job %s: %s", jobID, reason)
+	run.cmd.Process.Kill()
+}
+
+// watchdogStopReason is why the job's run was stopped for going over its
+// limits, "" if it wasn't
+func (a *Agent) watchdogStopReason(jobID uuid.UUID) string {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	if a.running == nil || a.running.jobID != jobID {
+		return ""
+	}
+	return a.running.stopReason
+}
+
+// restartWatchdog has the running job's watchdog restart its stall clock
+// on its next check. It returns the job, or false when none is running.
+func (a *Agent) restartWatchdog() (uuid.UUID, bool) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	if a.running == nil {
+		return uuid.Nil, false
+	}
+	a.running.watchdogRestarts++
+	return a.running.jobID, true
+}
+
+// watchdogRestarts is how often the job's watchdog was asked to restart
+func (a *Agent) watchdogRestarts(jobID uuid.UUID) int {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	if a.running == nil || a.running.jobID != jobID {
+		return 0
+	}
+	return a.running.watchdogRestarts
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newAgentsCmd() *cobra.Command {
+	agentsCmd := &cobra.Command{
+		Use:   "agents",
+		Short: "Manage agents",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List registered agents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			agents, err := fetchAgents(cmd.Context(), newClient())
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(agents)
+			}
+
+			table := make([][]string, 0, len(agents))
+			for _, a := range agents {
+				table = append(table, []string{
+					a.ID.String(),
+					a.Name,
+					a.Status,
+					fmt.Sprintf("%s:%d", a.IPAddress, a.Port),
+					a.Capabilities,
+					formatSpeed(a.Speed),
+					a.LastSeen.Format("2006-01-02 15:04:05"),
+				})
+			}
+			printTable([]string{"ID", "NAME", "STATUS", "ADDRESS", "CAPABILITIES", "SPEED", "LAST SEEN"}, table)
+			return nil
+		},
+	}
+
+	genKeyCmd := &cobra.Command{
+		Use:   "generate-key <name>",
+		Short: "Generate an agent key for a new agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, _ := cmd.Flags().GetString("key")
+			if key == "" {
+				generated, err := generateAgentKey()
+				if err != nil {
+					return err
+				}
+				key = generated
+			}
+
+			agent, err := newClient().GenerateAgentKey(cmd.Context(), args[0], key)
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(agent)
+			}
+			fmt.Printf("Agent key for %s: %s\n", agent.Name, agent.AgentKey)
+			return nil
+		},
+	}
+	genKeyCmd.Flags().String("key", "", "Use this key instead of generating a random one")
+
+	tokensCmd := &cobra.Command{
+		Use:   "enrollment-tokens",
+		Short: "Generate single-use enrollment tokens agents trade for an agent key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, _ := cmd.Flags().GetInt("count")
+			expires, _ := cmd.Flags().GetDuration("expires")
+			label, _ := cmd.Flags().GetString("label")
+
+			tokens, err := newClient().CreateEnrollmentTokens(cmd.Context(), client.CreateEnrollmentTokensRequest{
+				Count:            count,
+				ExpiresInMinutes: int(expires.Minutes()),
+				Label:            label,
+			})
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(tokens)
+			}
+
+			table := make([][]string, 0, len(tokens))
+			for _, t := range tokens {
+				table = append(table, []string{t.Token, t.ExpiresAt.Format("2006-01-02 15:04:05")})
+			}
+			printTable([]string{"TOKEN", "EXPIRES"}, table)
+			return nil
+		},
+	}
+	tokensCmd.Flags().Int("count", 1, "Number of tokens, e.g. one per machine of a rollout")
+	tokensCmd.Flags().Duration("expires", 24*time.Hour, "How long the tokens can be used")
+	tokensCmd.Flags().String("label", "", "Label shown when listing the tokens")
+
+	agentsCmd.AddCommand(listCmd, genKeyCmd, tokensCmd)
+	return agentsCmd
+}
+
+// fetchAgents returns all agents using the largest page size the API allows
+func fetchAgents(ctx context.Context, api *client.Client) ([]domain.Agent, error) {
+	return api.ListAgents(ctx, 500)
+}
+
+func generateAgentKey() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate agent key: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
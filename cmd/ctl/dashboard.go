@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+const maxRecentCracks = 10
+
+// wsMessage mirrors handler.WebSocketMessage as sent by the server hub
+type wsMessage struct {
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp string          `json:"timestamp"`
+}
+
+type crackEvent struct {
+	JobName string
+	Result  string
+	At      time.Time
+}
+
+// dashboard keeps a live view of the cluster, seeded over REST and kept
+// current by WebSocket events
+type dashboard struct {
+	client *client.Client
+
+	mu        sync.Mutex
+	agents    map[string]*domain.Agent
+	jobs      map[string]*client.JobSummary
+	cracks    []crackEvent
+	connected bool
+	lastError string
+}
+
+func newDashboardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Live terminal dashboard of agents and jobs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			refresh, _ := cmd.Flags().GetDuration("refresh")
+			resync, _ := cmd.Flags().GetDuration("resync")
+
+			d := &dashboard{
+				client: newClient(),
+				agents: make(map[string]*domain.Agent),
+				jobs:   make(map[string]*client.JobSummary),
+			}
+			if err := d.sync(); err != nil {
+				return err
+			}
+			return d.run(refresh, resync)
+		},
+	}
+	cmd.Flags().Duration("refresh", time.Second, "Screen redraw interval")
+	cmd.Flags().Duration("resync", 30*time.Second, "Full REST resync interval")
+	return cmd
+}
+
+// sync reloads agents and jobs over REST so entries created since the
+// last event (which the hub doesn't announce) show up
+func (d *dashboard) sync() error {
+	agents, err := fetchAgents(context.Background(), d.client)
+	if err != nil {
+		return err
+	}
+	jobs, err := d.client.ListJobs(context.Background(), "")
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.agents = make(map[string]*domain.Agent, len(agents))
+	for i := range agents {
+		d.agents[agents[i].ID.String()] = &agents[i]
+	}
+	d.jobs = make(map[string]*client.JobSummary, len(jobs))
+	for i := range jobs {
+		d.jobs[jobs[i].ID] = &jobs[i]
+	}
+	return nil
+}
+
+func (d *dashboard) run(refresh, resync time.Duration) error {
+	done := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	go d.listen(done)
+
+	// Alternate screen buffer + hidden cursor, restored on exit
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	redraw := time.NewTicker(refresh)
+	defer redraw.Stop()
+	resyncTicker := time.NewTicker(resync)
+	defer resyncTicker.Stop()
+
+	d.render()
+	for {
+		select {
+		case <-sigCh:
+			close(done)
+			return nil
+		case <-redraw.C:
+			d.render()
+		case <-resyncTicker.C:
+			if err := d.sync(); err != nil {
+				d.setError(err)
+			}
+		}
+	}
+}
+
+// listen keeps a WebSocket connection to the hub open, reconnecting with
+// a fixed delay until done is closed
+func (d *dashboard) listen(done <-chan struct{}) {
+	wsURL, err := websocketURL(d.client.BaseURL())
+	if err != nil {
+		d.setError(err)
+		return
+	}
+
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			d.setError(fmt.Errorf("websocket: %w", err))
+		} else {
+			d.setConnected(true)
+			go func() {
+				<-done
+				conn.Close()
+			}()
+			for {
+				var msg wsMessage
+				if err := conn.ReadJSON(&msg); err != nil {
+					break
+				}
+				d.apply(msg)
+			}
+			conn.Close()
+			d.setConnected(false)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+func websocketURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/ws"
+	return u.String(), nil
+}
+
+func (d *dashboard) apply(msg wsMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch msg.Type {
+	case "job_progress":
+		var data struct {
+			JobID    string  `json:"job_id"`
+			Progress float64 `json:"progress"`
+			Speed    int64   `json:"speed"`
+			ETA      string  `json:"eta"`
+			Status   string  `json:"status"`
+		}
+		if json.Unmarshal(msg.Data, &data) != nil {
+			return
+		}
+		job := d.job(data.JobID)
+		job.Progress = data.Progress
+		job.Speed = data.Speed
+		job.ETA = data.ETA
+		if data.Status != "" {
+			job.Status = data.Status
+		}
+
+	case "job_status":
+		var data struct {
+			JobID  string `json:"job_id"`
+			Status string `json:"status"`
+			Result string `json:"result"`
+		}
+		if json.Unmarshal(msg.Data, &data) != nil {
+			return
+		}
+		job := d.job(data.JobID)
+		job.Status = data.Status
+		if data.Result != "" {
+			job.Result = data.Result
+		}
+		if data.Status == "cracked" {
+			d.cracks = append([]crackEvent{{JobName: job.Name, Result: data.Result, At: time.Now()}}, d.cracks...)
+			if len(d.cracks) > maxRecentCracks {
+				d.cracks = d.cracks[:maxRecentCracks]
+			}
+		}
+
+	case "agent_status":
+		var data struct {
+			AgentID string `json:"agent_id"`
+			Status  string `json:"status"`
+		}
+		if json.Unmarshal(msg.Data, &data) != nil {
+			return
+		}
+		if agent, ok := d.agents[data.AgentID]; ok {
+			agent.Status = data.Status
+			agent.LastSeen = time.Now()
+		}
+
+	case "agent_speed":
+		var data struct {
+			AgentID string `json:"agent_id"`
+			Speed   int64  `json:"speed"`
+		}
+		if json.Unmarshal(msg.Data, &data) != nil {
+			return
+		}
+		if agent, ok := d.agents[data.AgentID]; ok {
+			agent.Speed = data.Speed
+		}
+	}
+}
+
+// job returns the tracked job, creating a placeholder for jobs not seen yet
+func (d *dashboard) job(id string) *client.JobSummary {
+	job, ok := d.jobs[id]
+	if !ok {
+		job = &client.JobSummary{ID: id, Name: id[:min(8, len(id))]}
+		d.jobs[id] = job
+	}
+	return job
+}
+
+func (d *dashboard) setConnected(connected bool) {
+	d.mu.Lock()
+	d.connected = connected
+	if connected {
+		d.lastError = ""
+	}
+	d.mu.Unlock()
+}
+
+func (d *dashboard) setError(err error) {
+	d.mu.Lock()
+	d.lastError = err.Error()
+	d.mu.Unlock()
+}
+
+func (d *dashboard) render() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+
+	live := "\033[31mdisconnected\033[0m"
+	if d.connected {
+		live = "\033[32mlive\033[0m"
+	}
+	fmt.Fprintf(&b, "\033[1mhashcatctl dashboard\033[0m  %s  [%s]  %s\n", d.client.BaseURL(), live, time.Now().Format("15:04:05"))
+	if d.lastError != "" {
+		fmt.Fprintf(&b, "\033[33m%s\033[0m\n", d.lastError)
+	}
+
+	// Agents
+	agents := make([]*domain.Agent, 0, len(d.agents))
+	var totalSpeed int64
+	for _, a := range d.agents {
+		agents = append(agents, a)
+		if a.Status == "online" || a.Status == "busy" {
+			totalSpeed += a.Speed
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+
+	fmt.Fprintf(&b, "\n\033[1mAGENTS\033[0m (%d)  aggregate %s\n", len(agents), formatSpeed(totalSpeed))
+	for _, a := range agents {
+		fmt.Fprintf(&b, "  %-24s %s %-14s %s\n", truncate(a.Name, 24), statusColor(a.Status), formatSpeed(a.Speed), a.Capabilities)
+	}
+
+	// Active jobs first, then the most recently finished ones
+	jobs := make([]*client.JobSummary, 0, len(d.jobs))
+	for _, j := range d.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		ri, rk := jobRank(jobs[i].Status), jobRank(jobs[k].Status)
+		if ri != rk {
+			return ri < rk
+		}
+		return jobs[i].Name < jobs[k].Name
+	})
+	if len(jobs) > 15 {
+		jobs = jobs[:15]
+	}
+
+	fmt.Fprintf(&b, "\n\033[1mJOBS\033[0m (%d)\n", len(d.jobs))
+	for _, j := range jobs {
+		fmt.Fprintf(&b, "  %-28s %s %s %6.2f%% %s\n",
+			truncate(j.Name, 28), statusColor(j.Status), progressBarString(j.Progress, 25), j.Progress, formatSpeed(j.Speed))
+	}
+
+	fmt.Fprintf(&b, "\n\033[1mRECENT CRACKS\033[0m\n")
+	if len(d.cracks) == 0 {
+		b.WriteString("  none yet\n")
+	}
+	for _, c := range d.cracks {
+		fmt.Fprintf(&b, "  %s  %-28s %s\n", c.At.Format("15:04:05"), truncate(c.JobName, 28), c.Result)
+	}
+
+	b.WriteString("\nCtrl-C to quit\n")
+	os.Stdout.WriteString(b.String())
+}
+
+func jobRank(status string) int {
+	switch status {
+	case "running":
+		return 0
+	case "pending", "assigned", "paused":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func statusColor(status string) string {
+	color := "37"
+	switch status {
+	case "online", "completed", "cracked":
+		color = "32"
+	case "busy", "running":
+		color = "36"
+	case "pending", "assigned", "paused":
+		color = "33"
+	case "offline", "failed", "cancelled", "expired", "error":
+		color = "31"
+	}
+	return fmt.Sprintf("\033[%sm%-10s\033[0m", color, status)
+}
+
+func progressBarString(progress float64, width int) string {
+	filled := int(progress / 100 * float64(width))
+	filled = max(0, min(filled, width))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "~"
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func newJobsCmd() *cobra.Command {
+	jobsCmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Manage cracking jobs",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List jobs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, _ := cmd.Flags().GetString("status")
+			jobs, err := newClient().ListJobs(cmd.Context(), status)
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(jobs)
+			}
+
+			table := make([][]string, 0, len(jobs))
+			for _, j := range jobs {
+				table = append(table, []string{
+					j.ID,
+					j.Name,
+					j.Status,
+					j.AgentName,
+					fmt.Sprintf("%.1f%%", j.Progress),
+					formatSpeed(j.Speed),
+					j.Result,
+				})
+			}
+			printTable([]string{"ID", "NAME", "STATUS", "AGENT", "PROGRESS", "SPEED", "RESULT"}, table)
+			return nil
+		},
+	}
+	listCmd.Flags().String("status", "", "Filter by status (pending, running, completed, failed, paused)")
+
+	var createReq domain.CreateJobRequest
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new job",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job, err := newClient().CreateJob(cmd.Context(), createReq)
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(job)
+			}
+			fmt.Printf("Created job %s (%s)\n", job.ID, job.Name)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&createReq.Name, "name", "", "Job name")
+	createCmd.Flags().IntVar(&createReq.HashType, "hash-type", 0, "Hashcat hash mode (-m)")
+	createCmd.Flags().IntVar(&createReq.AttackMode, "attack-mode", 0, "Hashcat attack mode (-a)")
+	createCmd.Flags().StringVar(&createReq.HashFileID, "hash-file", "", "Hash file ID")
+	createCmd.Flags().StringVar(&createReq.WordlistID, "wordlist", "", "Wordlist ID")
+	createCmd.Flags().StringVar(&createReq.Wordlist2ID, "wordlist2", "", "Right-hand wordlist ID of a combinator attack (-a 1)")
+	createCmd.Flags().StringVar(&createReq.AgentID, "agent", "", "Assign to a specific agent ID")
+	createCmd.Flags().StringVar(&createReq.Rules, "rules", "", "Hashcat rules")
+	createCmd.MarkFlagRequired("name")
+	createCmd.MarkFlagRequired("hash-file")
+	createCmd.MarkFlagRequired("wordlist")
+	createCmd.PreRun = func(cmd *cobra.Command, args []string) {
+		// The API still requires the legacy wordlist field alongside wordlist_id
+		createReq.Wordlist = createReq.WordlistID
+	}
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Queue the attacks of a YAML or JSON playbook",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			project, _ := cmd.Flags().GetString("project")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return applyPlaybook(cmd.Context(), file, project, dryRun)
+		},
+	}
+	applyCmd.Flags().StringP("file", "f", "", "Playbook file, - for stdin")
+	applyCmd.Flags().String("project", "", "Project ID the jobs belong to")
+	applyCmd.Flags().Bool("dry-run", false, "Validate the playbook and show the jobs without queuing them")
+	applyCmd.MarkFlagRequired("file")
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <job-id>",
+		Short: "Stop a running or pending job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := parseJobID(args[0])
+			if err != nil {
+				return err
+			}
+			if err := newClient().StopJob(cmd.Context(), jobID); err != nil {
+				return err
+			}
+			fmt.Printf("Job %s stopped\n", args[0])
+			return nil
+		},
+	}
+
+	revealCmd := &cobra.Command{
+		Use:   "reveal <job-id>",
+		Short: "Show a job's cracked password (logged on the server)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := parseJobID(args[0])
+			if err != nil {
+				return err
+			}
+			revealed, err := newClient().RevealJobResult(cmd.Context(), jobID)
+			if err != nil {
+				return err
+			}
+			if revealed.Password == "" {
+				fmt.Printf("Result: %s\n", revealed.Result)
+				return nil
+			}
+			fmt.Println(revealed.Password)
+			return nil
+		},
+	}
+
+	tailCmd := &cobra.Command{
+		Use:   "tail <job-id>",
+		Short: "Follow job progress until it finishes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobID, err := parseJobID(args[0])
+			if err != nil {
+				return err
+			}
+			interval, _ := cmd.Flags().GetDuration("interval")
+			return tailJob(cmd.Context(), jobID, interval)
+		},
+	}
+	tailCmd.Flags().Duration("interval", 2*time.Second, "Polling interval")
+
+	jobsCmd.AddCommand(listCmd, createCmd, applyCmd, cancelCmd, revealCmd, tailCmd)
+	return jobsCmd
+}
+
+func tailJob(ctx context.Context, jobID uuid.UUID, interval time.Duration) error {
+	api := newClient()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := api.GetJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+
+		if outputJSON() {
+			if err := printJSON(job); err != nil {
+				return err
+			}
+		} else {
+			eta := "-"
+			if job.ETA != nil {
+				eta = time.Until(*job.ETA).Round(time.Second).String()
+			}
+			fmt.Fprintf(os.Stdout, "\r%-10s %6.2f%%  %-14s ETA %-12s", job.Status, job.Progress, formatSpeed(job.Speed), eta)
+		}
+
+		switch job.Status {
+		case "completed", "cracked", "failed", "cancelled", "expired":
+			if !outputJSON() {
+				fmt.Println()
+				if job.Result != "" {
+					fmt.Printf("Result: %s\n", job.Result)
+				}
+			}
+			return nil
+		}
+
+		<-ticker.C
+	}
+}
+
+// applyPlaybook checks a playbook locally, then sends it to the server
+func applyPlaybook(ctx context.Context, path, projectID string, dryRun bool) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read playbook: %w", err)
+	}
+	playbook, err := domain.ParseJobPlaybook(data)
+	if err != nil {
+		return err
+	}
+
+	result, err := newClient().ApplyJobs(ctx, playbook, projectID, dryRun)
+	if err != nil {
+		return err
+	}
+	if outputJSON() {
+		return printJSON(result)
+	}
+
+	headers := []string{"ATTACK", "JOB", "MODE", "WORDLIST/MASK"}
+	if result.Budget != nil {
+		headers = append(headers, "GPU-HOURS")
+	}
+	table := make([][]string, 0, len(result.Attacks))
+	for i, attack := range result.Attacks {
+		jobID := "-"
+		if attack.Job != nil {
+			jobID = attack.Job.ID.String()
+		}
+		row := []string{
+			attack.Attack,
+			jobID,
+			strconv.Itoa(attack.Request.AttackMode),
+			attack.Request.Wordlist,
+		}
+		// Budget stages are in the same pipeline order as the attacks
+		if result.Budget != nil {
+			row = append(row, fmt.Sprintf("%.1f", result.Budget.Stages[i].GPUHours))
+		}
+		table = append(table, row)
+	}
+	printTable(headers, table)
+	if result.Budget != nil {
+		fmt.Printf("Budget: %.1f GPU-hours\n", result.Budget.GPUHours)
+	}
+	if dryRun {
+		fmt.Printf("Playbook %s is valid, %d attacks would be queued\n", result.Name, len(result.Attacks))
+	} else {
+		fmt.Printf("Queued %d attacks of playbook %s in job group %s\n", len(result.Attacks), result.Name, result.GroupID)
+	}
+	return nil
+}
+
+func parseJobID(arg string) (uuid.UUID, error) {
+	jobID, err := uuid.Parse(arg)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid job ID %q", arg)
+	}
+	return jobID, nil
+}
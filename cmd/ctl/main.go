@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:          "hashcatctl",
+		Short:        "Admin CLI for the distributed hashcat server",
+		SilenceUsage: true,
+	}
+
+	rootCmd.PersistentFlags().String("server", "http://localhost:1337", "Server URL")
+	rootCmd.PersistentFlags().String("token", "", "Bearer token for authenticated endpoints")
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json)")
+
+	viper.BindPFlags(rootCmd.PersistentFlags())
+	viper.BindEnv("server", "HASHCAT_SERVER_URL")
+	viper.BindEnv("token", "HASHCAT_TOKEN")
+
+	rootCmd.AddCommand(newJobsCmd())
+	rootCmd.AddCommand(newWordlistsCmd())
+	rootCmd.AddCommand(newAgentsCmd())
+	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newDashboardCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		infrastructure.DefaultLogger.Error("%v", err)
+		os.Exit(1)
+	}
+}
+
+func newClient() *client.Client {
+	return client.New(viper.GetString("server"),
+		client.WithToken(viper.GetString("token")),
+		client.WithUserAgent("hashcatctl"))
+}
+
+func outputJSON() bool {
+	return viper.GetString("output") == "json"
+}
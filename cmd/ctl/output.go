@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// progressBar renders a single-line upload progress indicator on stderr
+type progressBar struct {
+	label   string
+	total   int64
+	current int64
+	lastPct int
+}
+
+func newProgressBar(label string, total int64) *progressBar {
+	return &progressBar{label: label, total: total, lastPct: -1}
+}
+
+func (p *progressBar) Write(b []byte) (int, error) {
+	p.current += int64(len(b))
+	p.render()
+	return len(b), nil
+}
+
+func (p *progressBar) render() {
+	pct := 100
+	if p.total > 0 {
+		pct = int(p.current * 100 / p.total)
+	}
+	if pct == p.lastPct {
+		return
+	}
+	p.lastPct = pct
+
+	const width = 40
+	filled := pct * width / 100
+	fmt.Fprintf(os.Stderr, "\r%s [%s%s] %3d%% %s/%s",
+		p.label,
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", width-filled),
+		pct,
+		formatBytes(p.current),
+		formatBytes(p.total),
+	)
+}
+
+func (p *progressBar) Finish() {
+	p.current = p.total
+	p.render()
+	fmt.Fprintln(os.Stderr)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatSpeed(hps int64) string {
+	switch {
+	case hps >= 1_000_000_000:
+		return fmt.Sprintf("%.2f GH/s", float64(hps)/1_000_000_000)
+	case hps >= 1_000_000:
+		return fmt.Sprintf("%.2f MH/s", float64(hps)/1_000_000)
+	case hps >= 1_000:
+		return fmt.Sprintf("%.2f kH/s", float64(hps)/1_000)
+	default:
+		return fmt.Sprintf("%d H/s", hps)
+	}
+}
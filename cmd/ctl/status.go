@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// clusterStatus summarizes agents and jobs as seen by the server
+type clusterStatus struct {
+	Agents      map[string]int `json:"agents"`
+	Jobs        map[string]int `json:"jobs"`
+	TotalAgents int            `json:"total_agents"`
+	TotalJobs   int            `json:"total_jobs"`
+	TotalSpeed  int64          `json:"total_speed"`
+}
+
+func newStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show cluster status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api := newClient()
+
+			agents, err := fetchAgents(cmd.Context(), api)
+			if err != nil {
+				return err
+			}
+			jobs, err := api.ListJobs(cmd.Context(), "")
+			if err != nil {
+				return err
+			}
+
+			status := clusterStatus{
+				Agents:      make(map[string]int),
+				Jobs:        make(map[string]int),
+				TotalAgents: len(agents),
+				TotalJobs:   len(jobs),
+			}
+			for _, a := range agents {
+				status.Agents[a.Status]++
+				if a.Status == "online" || a.Status == "busy" {
+					status.TotalSpeed += a.Speed
+				}
+			}
+			for _, j := range jobs {
+				status.Jobs[j.Status]++
+			}
+
+			if outputJSON() {
+				return printJSON(status)
+			}
+
+			fmt.Printf("Server: %s\n\n", api.BaseURL())
+			printTable([]string{"AGENTS", "COUNT"}, countRows(status.Agents, "online", "busy", "offline", "error"))
+			fmt.Printf("\nTotal agents: %d, combined speed: %s\n\n", status.TotalAgents, formatSpeed(status.TotalSpeed))
+			printTable([]string{"JOBS", "COUNT"}, countRows(status.Jobs, "pending", "assigned", "running", "paused", "cracked", "completed", "failed", "cancelled", "expired"))
+			fmt.Printf("\nTotal jobs: %d\n", status.TotalJobs)
+			return nil
+		},
+	}
+}
+
+// countRows renders known keys first in a stable order, followed by anything unexpected
+func countRows(counts map[string]int, order ...string) [][]string {
+	rows := make([][]string, 0, len(counts))
+	seen := make(map[string]bool, len(order))
+	for _, key := range order {
+		seen[key] = true
+		rows = append(rows, []string{key, strconv.Itoa(counts[key])})
+	}
+	for key, n := range counts {
+		if !seen[key] {
+			rows = append(rows, []string{key, strconv.Itoa(n)})
+		}
+	}
+	return rows
+}
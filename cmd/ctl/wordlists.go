@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newWordlistsCmd() *cobra.Command {
+	wordlistsCmd := &cobra.Command{
+		Use:   "wordlists",
+		Short: "Manage wordlists",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List uploaded wordlists",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wordlists, err := newClient().ListWordlists(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(wordlists)
+			}
+
+			table := make([][]string, 0, len(wordlists))
+			for _, w := range wordlists {
+				words := "-"
+				if w.WordCount != nil {
+					words = strconv.FormatInt(*w.WordCount, 10)
+				}
+				table = append(table, []string{w.ID.String(), w.OrigName, formatBytes(w.Size), words})
+			}
+			printTable([]string{"ID", "NAME", "SIZE", "WORDS"}, table)
+			return nil
+		},
+	}
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload <file>",
+		Short: "Upload a wordlist",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wordlist, err := uploadWordlist(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if outputJSON() {
+				return printJSON(wordlist)
+			}
+			if wordlist.Duplicate {
+				fmt.Printf("Identical wordlist already on server: %s (%s)\n", wordlist.ID, wordlist.OrigName)
+				return nil
+			}
+			fmt.Printf("Uploaded wordlist %s (%s)\n", wordlist.ID, wordlist.OrigName)
+			return nil
+		},
+	}
+
+	wordlistsCmd.AddCommand(listCmd, uploadCmd)
+	return wordlistsCmd
+}
+
+// uploadWordlist streams a file to the server, reporting progress as bytes are sent
+func uploadWordlist(cmd *cobra.Command, filePath string) (*client.Wordlist, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	name := filepath.Base(filePath)
+	bar := newProgressBar(name, info.Size())
+	wordlist, err := newClient().UploadWordlist(cmd.Context(), name, io.TeeReader(file, bar))
+	if err != nil {
+		return nil, err
+	}
+	bar.Finish()
+	return wordlist, nil
+}
@@ -3,19 +3,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"go-distributed-hashcat/frontend"
 	httpDelivery "go-distributed-hashcat/internal/delivery/http"
 	"go-distributed-hashcat/internal/delivery/http/handler"
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/domain"
 	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/infrastructure/backupstore"
+	"go-distributed-hashcat/internal/infrastructure/cloud"
 	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/mail"
+	"go-distributed-hashcat/internal/infrastructure/oidc"
+	"go-distributed-hashcat/internal/infrastructure/pubsub"
 	"go-distributed-hashcat/internal/infrastructure/repository"
+	"go-distributed-hashcat/internal/infrastructure/tracing"
 	"go-distributed-hashcat/internal/usecase"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -24,6 +39,7 @@ var (
 	migrationsDir = "./internal/infrastructure/database/migrations"
 	serverHost    string
 	serverPort    int
+	demoMode      bool
 )
 
 func main() {
@@ -33,8 +49,9 @@ func main() {
 // Config struct with extended database support
 type Config struct {
 	Server struct {
-		Port int    `mapstructure:"port"`
-		Host string `mapstructure:"host"`
+		Port                   int    `mapstructure:"port"`
+		Host                   string `mapstructure:"host"`
+		ShutdownTimeoutSeconds int    `mapstructure:"shutdown_timeout_seconds"` // How long shutdown waits for connections and writes to drain
 	} `mapstructure:"server"`
 	Database struct {
 		Type     string `mapstructure:"type"`     // sqlite, postgres, mysql
@@ -46,8 +63,137 @@ type Config struct {
 		Password string `mapstructure:"password"` // Password
 	} `mapstructure:"database"`
 	Upload struct {
-		Directory string `mapstructure:"directory"`
+		Directory            string `mapstructure:"directory"`
+		EncryptionKey        string `mapstructure:"encryption_key"`         // Encrypts uploaded hash files at rest when set
+		EncryptionKeyFile    string `mapstructure:"encryption_key_file"`    // Reads the key from a file instead
+		EncryptionKeyCommand string `mapstructure:"encryption_key_command"` // Reads the key from a command's output, e.g. a KMS CLI
+		HashFileMaxSizeMB    int64  `mapstructure:"hashfile_max_size_mb"`   // Largest hash file accepted, 0 for no limit
+		HashFileExtensions   string `mapstructure:"hashfile_extensions"`    // Comma-separated, empty accepts any
+		HashFileMIMETypes    string `mapstructure:"hashfile_mime_types"`    // Comma-separated sniffed content types, empty accepts any
+		WordlistMaxSizeMB    int64  `mapstructure:"wordlist_max_size_mb"`   // Largest wordlist accepted, 0 for no limit
+		WordlistExtensions   string `mapstructure:"wordlist_extensions"`    // Comma-separated, empty accepts any
+		WordlistMIMETypes    string `mapstructure:"wordlist_mime_types"`    // Comma-separated sniffed content types, empty accepts any
+		ClamAVAddress        string `mapstructure:"clamav_address"`         // clamd to scan uploads with, none when empty
+		ClamAVTimeoutSeconds int    `mapstructure:"clamav_timeout_seconds"` // How long one scan may take
 	} `mapstructure:"upload"`
+	Retention struct {
+		ArchiveAfterDays     int    `mapstructure:"archive_after_days"`     // Archive finished jobs after N days, 0 disables
+		PurgeAfterDays       int    `mapstructure:"purge_after_days"`       // Remove deleted jobs after N days, 0 keeps them
+		CheckIntervalMinutes int    `mapstructure:"check_interval_minutes"` // How often the retention workers run
+		AgentStaleAfterDays  int    `mapstructure:"agent_stale_after_days"` // Agents not seen for N days are stale, 0 disables
+		AgentAction          string `mapstructure:"agent_action"`           // flag, archive or delete stale agents
+	} `mapstructure:"retention"`
+	Autoscale struct {
+		Enabled              bool    `mapstructure:"enabled"`
+		CheckIntervalSeconds int     `mapstructure:"check_interval_seconds"` // How often the queue is checked
+		QueueThreshold       int     `mapstructure:"queue_threshold"`        // Start instances while more unassigned jobs than this wait
+		IdleMinutes          int     `mapstructure:"idle_minutes"`           // Delete instances idle for N minutes
+		BootTimeoutMinutes   int     `mapstructure:"boot_timeout_minutes"`   // Delete instances whose agent didn't register in time
+		MaxInstances         int     `mapstructure:"max_instances"`          // Cap on burst instances
+		InstanceHourlyCost   float64 `mapstructure:"instance_hourly_cost"`   // Price of one instance per hour
+		MaxHourlyCost        float64 `mapstructure:"max_hourly_cost"`        // Cap on the hourly price of all burst instances, 0 disables it
+		ServerURL            string  `mapstructure:"server_url"`             // URL burst agents use to reach this server
+		AgentDownloadURL     string  `mapstructure:"agent_download_url"`     // Where burst instances download the agent binary
+		cloud.Config         `mapstructure:",squash"`
+	} `mapstructure:"autoscale"`
+	Logging struct {
+		Format string `mapstructure:"format"` // text or json
+		Level  string `mapstructure:"level"`  // debug, info, warning or error
+	} `mapstructure:"logging"`
+	Tracing tracing.Config `mapstructure:"tracing"`
+	Preview struct {
+		HashcatPath    string `mapstructure:"hashcat_path"`    // hashcat binary used for candidate previews
+		TimeoutSeconds int    `mapstructure:"timeout_seconds"` // Kill a preview run after N seconds
+		Workers        int    `mapstructure:"workers"`         // Previews allowed to run at once
+	} `mapstructure:"preview"`
+	Download struct {
+		MaxPerFile        int `mapstructure:"max_per_file"`        // Downloads of one file served at once, 0 for unlimited
+		RetryAfterSeconds int `mapstructure:"retry_after_seconds"` // How long clients over the limit wait before retrying
+	} `mapstructure:"download"`
+	FaultInjection struct {
+		Enabled           bool    `mapstructure:"enabled"`             // Test and staging only: agent-facing routes misbehave on purpose
+		LatencyRate       float64 `mapstructure:"latency_rate"`        // Share of requests delayed, 0 to 1
+		MaxLatencyMs      int     `mapstructure:"max_latency_ms"`      // Delays are random up to this long
+		ErrorRate         float64 `mapstructure:"error_rate"`          // Share of requests answered with a 5xx error, 0 to 1
+		HeartbeatDropRate float64 `mapstructure:"heartbeat_drop_rate"` // Share of heartbeats acknowledged but ignored, 0 to 1
+		Seed              int64   `mapstructure:"seed"`                // Makes the faults reproducible, 0 for a random seed
+	} `mapstructure:"fault_injection"`
+	Heartbeat struct {
+		IntervalSeconds      int `mapstructure:"interval_seconds"`       // Given to agents that don't ask for an interval
+		MinIntervalSeconds   int `mapstructure:"min_interval_seconds"`   // Shortest interval an agent may ask for
+		MaxIntervalSeconds   int `mapstructure:"max_interval_seconds"`   // Longest interval an agent may ask for
+		FlushIntervalSeconds int `mapstructure:"flush_interval_seconds"` // How often last_seen and job progress are written to the database
+		FlushThreshold       int `mapstructure:"flush_threshold"`        // Buffered heartbeats or progress reports that trigger an early write
+		DegradedAfterMissed  int `mapstructure:"degraded_after_missed"`  // Missed heartbeats before an agent is degraded
+		OfflineAfterMissed   int `mapstructure:"offline_after_missed"`   // Missed heartbeats before an agent is offline
+		JobLeaseSeconds      int `mapstructure:"job_lease_seconds"`      // How long an agent holds a job without a heartbeat or progress report
+	} `mapstructure:"heartbeat"`
+	Jobs struct {
+		MaxRuntimeMinutes   int `mapstructure:"max_runtime_minutes"`   // Stop a run after N minutes unless the job sets its own limit, 0 for none
+		StallTimeoutMinutes int `mapstructure:"stall_timeout_minutes"` // Stop a run without progress for N minutes unless the job sets its own limit, 0 for none
+	} `mapstructure:"jobs"`
+	AgentNetwork struct {
+		Allow          string `mapstructure:"allow"`           // Comma-separated ranges agents may connect from, any when empty
+		Deny           string `mapstructure:"deny"`            // Comma-separated ranges agents may never connect from
+		TrustedProxies string `mapstructure:"trusted_proxies"` // Comma-separated proxies whose X-Forwarded-For is believed
+	} `mapstructure:"agent_network"`
+	AgentLogs struct {
+		RetainLines int `mapstructure:"retain_lines"` // Log lines kept per agent, older ones are dropped
+	} `mapstructure:"agent_logs"`
+	Cache struct {
+		LookupTTLSeconds int `mapstructure:"lookup_ttl_seconds"` // How long agent, wordlist and hash file lookups are cached
+	} `mapstructure:"cache"`
+	Realtime struct {
+		Redis pubsub.RedisConfig `mapstructure:"redis"` // Relays WebSocket events between server instances when addr is set
+	} `mapstructure:"realtime"`
+	Results struct {
+		Redact        bool   `mapstructure:"redact"`         // Mask cracked passwords outside of the reveal endpoint
+		EncryptionKey string `mapstructure:"encryption_key"` // Encrypts results and agent output at rest when set
+	} `mapstructure:"results"`
+	Policy struct {
+		MinLength            int    `mapstructure:"min_length"`             // Shortest compliant password, 0 disables the rule
+		MinClasses           int    `mapstructure:"min_classes"`            // Of lower, upper, digit and special, 0 disables the rule
+		RequiredClasses      string `mapstructure:"required_classes"`       // Comma-separated classes every password must have
+		CheckDictionary      bool   `mapstructure:"check_dictionary"`       // Refuse passwords built on a dictionary word
+		DictionaryWordlistID string `mapstructure:"dictionary_wordlist_id"` // Wordlist of extra dictionary words, none when empty
+		MinWordLength        int    `mapstructure:"min_word_length"`        // Shorter dictionary words are ignored
+		CheckUsername        bool   `mapstructure:"check_username"`         // Refuse passwords containing the username
+	} `mapstructure:"policy"`
+	Backup struct {
+		IntervalHours      int `mapstructure:"interval_hours"` // How often a backup is taken, 0 disables scheduled backups
+		Keep               int `mapstructure:"keep"`           // Most recent backups kept, 0 keeps all
+		backupstore.Config `mapstructure:",squash"`
+	} `mapstructure:"backup"`
+	Accounting struct {
+		Currency       string  `mapstructure:"currency"`
+		DeviceHourRate float64 `mapstructure:"device_hour_rate"` // Price of one device running for an hour
+		KWhRate        float64 `mapstructure:"kwh_rate"`         // Price of one kilowatt-hour
+		DeviceWatts    float64 `mapstructure:"device_watts"`     // Assumed draw of a device whose agent can't read it
+	} `mapstructure:"accounting"`
+	Security struct {
+		CORSAllowedOrigins    string `mapstructure:"cors_allowed_origins"`    // Comma-separated origins browsers may call the API from, * for any
+		CSRFEnabled           bool   `mapstructure:"csrf_enabled"`            // Refuse cross-site requests that carry cookies
+		HSTSMaxAgeSeconds     int    `mapstructure:"hsts_max_age_seconds"`    // Strict-Transport-Security over HTTPS, 0 disables it
+		ContentSecurityPolicy string `mapstructure:"content_security_policy"` // Of the web UI, "off" disables it
+	} `mapstructure:"security"`
+	OIDC struct {
+		oidc.Config  `mapstructure:",squash"`
+		RoleMapping  string `mapstructure:"role_mapping"`   // Comma-separated claim=role pairs, e.g. hashcat-admins=admin
+		DefaultRole  string `mapstructure:"default_role"`   // Role of users without a mapped claim, empty refuses them
+		PostLoginURL string `mapstructure:"post_login_url"` // Frontend page given the token after login, the token is returned as JSON when empty
+	} `mapstructure:"oidc"`
+	SMTP   mail.Config `mapstructure:"smtp"`
+	Digest struct {
+		DashboardURL         string `mapstructure:"dashboard_url"`          // Linked from digests
+		CheckIntervalMinutes int    `mapstructure:"check_interval_minutes"` // How often due digests are looked for
+	} `mapstructure:"digest"`
+	Slack struct {
+		SigningSecret string `mapstructure:"signing_secret"` // Signing secret of the Slack app, commands are off when empty
+		AllowedUsers  string `mapstructure:"allowed_users"`  // Comma-separated Slack user IDs that may queue and stop jobs, everyone when empty
+	} `mapstructure:"slack"`
+	Hashtopolis struct {
+		AgentAPI bool `mapstructure:"agent_api"` // Answer Hashtopolis agents at /api/server.php
+	} `mapstructure:"hashtopolis"`
 }
 
 // Load configuration with .env support
@@ -58,6 +204,7 @@ func loadConfig() *Config {
 	// Map nested config to environment variables (works with all config types)
 	viper.BindEnv("server.port", "HASHCAT_SERVER_PORT", "PORT")
 	viper.BindEnv("server.host", "HASHCAT_SERVER_HOST", "HOST")
+	viper.BindEnv("server.shutdown_timeout_seconds", "HASHCAT_SERVER_SHUTDOWN_TIMEOUT_SECONDS")
 	viper.BindEnv("database.path", "HASHCAT_DATABASE_PATH", "DB_PATH")
 	viper.BindEnv("database.type", "HASHCAT_DATABASE_TYPE", "DB_TYPE")
 	viper.BindEnv("database.host", "HASHCAT_DATABASE_HOST", "DB_HOST")
@@ -66,13 +213,183 @@ func loadConfig() *Config {
 	viper.BindEnv("database.user", "HASHCAT_DATABASE_USER", "DB_USER")
 	viper.BindEnv("database.password", "HASHCAT_DATABASE_PASSWORD", "DB_PASSWORD")
 	viper.BindEnv("upload.directory", "HASHCAT_UPLOAD_DIRECTORY", "UPLOAD_DIR")
+	viper.BindEnv("upload.encryption_key", "HASHCAT_UPLOAD_ENCRYPTION_KEY")
+	viper.BindEnv("upload.encryption_key_file", "HASHCAT_UPLOAD_ENCRYPTION_KEY_FILE")
+	viper.BindEnv("upload.encryption_key_command", "HASHCAT_UPLOAD_ENCRYPTION_KEY_COMMAND")
+	viper.BindEnv("upload.hashfile_max_size_mb", "HASHCAT_UPLOAD_HASHFILE_MAX_SIZE_MB")
+	viper.BindEnv("upload.hashfile_extensions", "HASHCAT_UPLOAD_HASHFILE_EXTENSIONS")
+	viper.BindEnv("upload.hashfile_mime_types", "HASHCAT_UPLOAD_HASHFILE_MIME_TYPES")
+	viper.BindEnv("upload.wordlist_max_size_mb", "HASHCAT_UPLOAD_WORDLIST_MAX_SIZE_MB")
+	viper.BindEnv("upload.wordlist_extensions", "HASHCAT_UPLOAD_WORDLIST_EXTENSIONS")
+	viper.BindEnv("upload.wordlist_mime_types", "HASHCAT_UPLOAD_WORDLIST_MIME_TYPES")
+	viper.BindEnv("upload.clamav_address", "HASHCAT_UPLOAD_CLAMAV_ADDRESS")
+	viper.BindEnv("upload.clamav_timeout_seconds", "HASHCAT_UPLOAD_CLAMAV_TIMEOUT_SECONDS")
+	viper.BindEnv("retention.archive_after_days", "HASHCAT_RETENTION_ARCHIVE_AFTER_DAYS")
+	viper.BindEnv("retention.purge_after_days", "HASHCAT_RETENTION_PURGE_AFTER_DAYS")
+	viper.BindEnv("retention.check_interval_minutes", "HASHCAT_RETENTION_CHECK_INTERVAL_MINUTES")
+	viper.BindEnv("retention.agent_stale_after_days", "HASHCAT_RETENTION_AGENT_STALE_AFTER_DAYS")
+	viper.BindEnv("retention.agent_action", "HASHCAT_RETENTION_AGENT_ACTION")
+	viper.BindEnv("logging.format", "HASHCAT_LOG_FORMAT", "LOG_FORMAT")
+	viper.BindEnv("logging.level", "HASHCAT_LOG_LEVEL", "LOG_LEVEL")
+	viper.BindEnv("tracing.enabled", "HASHCAT_TRACING_ENABLED")
+	viper.BindEnv("tracing.endpoint", "HASHCAT_TRACING_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	viper.BindEnv("tracing.service_name", "HASHCAT_TRACING_SERVICE_NAME", "OTEL_SERVICE_NAME")
+	viper.BindEnv("tracing.sample_ratio", "HASHCAT_TRACING_SAMPLE_RATIO")
+	viper.BindEnv("preview.hashcat_path", "HASHCAT_PREVIEW_HASHCAT_PATH")
+	viper.BindEnv("preview.timeout_seconds", "HASHCAT_PREVIEW_TIMEOUT_SECONDS")
+	viper.BindEnv("preview.workers", "HASHCAT_PREVIEW_WORKERS")
+	viper.BindEnv("download.max_per_file", "HASHCAT_DOWNLOAD_MAX_PER_FILE")
+	viper.BindEnv("download.retry_after_seconds", "HASHCAT_DOWNLOAD_RETRY_AFTER_SECONDS")
+	viper.BindEnv("fault_injection.enabled", "HASHCAT_FAULT_INJECTION_ENABLED")
+	viper.BindEnv("fault_injection.latency_rate", "HASHCAT_FAULT_INJECTION_LATENCY_RATE")
+	viper.BindEnv("fault_injection.max_latency_ms", "HASHCAT_FAULT_INJECTION_MAX_LATENCY_MS")
+	viper.BindEnv("fault_injection.error_rate", "HASHCAT_FAULT_INJECTION_ERROR_RATE")
+	viper.BindEnv("fault_injection.heartbeat_drop_rate", "HASHCAT_FAULT_INJECTION_HEARTBEAT_DROP_RATE")
+	viper.BindEnv("fault_injection.seed", "HASHCAT_FAULT_INJECTION_SEED")
+	viper.BindEnv("heartbeat.interval_seconds", "HASHCAT_HEARTBEAT_INTERVAL_SECONDS")
+	viper.BindEnv("heartbeat.min_interval_seconds", "HASHCAT_HEARTBEAT_MIN_INTERVAL_SECONDS")
+	viper.BindEnv("heartbeat.max_interval_seconds", "HASHCAT_HEARTBEAT_MAX_INTERVAL_SECONDS")
+	viper.BindEnv("heartbeat.flush_interval_seconds", "HASHCAT_HEARTBEAT_FLUSH_INTERVAL_SECONDS")
+	viper.BindEnv("heartbeat.flush_threshold", "HASHCAT_HEARTBEAT_FLUSH_THRESHOLD")
+	viper.BindEnv("heartbeat.degraded_after_missed", "HASHCAT_HEARTBEAT_DEGRADED_AFTER_MISSED")
+	viper.BindEnv("heartbeat.offline_after_missed", "HASHCAT_HEARTBEAT_OFFLINE_AFTER_MISSED")
+	viper.BindEnv("heartbeat.job_lease_seconds", "HASHCAT_HEARTBEAT_JOB_LEASE_SECONDS")
+	viper.BindEnv("jobs.max_runtime_minutes", "HASHCAT_JOBS_MAX_RUNTIME_MINUTES")
+	viper.BindEnv("jobs.stall_timeout_minutes", "HASHCAT_JOBS_STALL_TIMEOUT_MINUTES")
+	viper.BindEnv("agent_logs.retain_lines", "HASHCAT_AGENT_LOGS_RETAIN_LINES")
+	viper.BindEnv("cache.lookup_ttl_seconds", "HASHCAT_CACHE_LOOKUP_TTL_SECONDS")
+	viper.BindEnv("realtime.redis.addr", "HASHCAT_REALTIME_REDIS_ADDR")
+	viper.BindEnv("realtime.redis.password", "HASHCAT_REALTIME_REDIS_PASSWORD")
+	viper.BindEnv("realtime.redis.channel", "HASHCAT_REALTIME_REDIS_CHANNEL")
+	viper.BindEnv("results.redact", "HASHCAT_RESULTS_REDACT")
+	viper.BindEnv("results.encryption_key", "HASHCAT_RESULTS_ENCRYPTION_KEY")
+	viper.BindEnv("policy.min_length", "HASHCAT_POLICY_MIN_LENGTH")
+	viper.BindEnv("policy.min_classes", "HASHCAT_POLICY_MIN_CLASSES")
+	viper.BindEnv("policy.required_classes", "HASHCAT_POLICY_REQUIRED_CLASSES")
+	viper.BindEnv("policy.check_dictionary", "HASHCAT_POLICY_CHECK_DICTIONARY")
+	viper.BindEnv("policy.dictionary_wordlist_id", "HASHCAT_POLICY_DICTIONARY_WORDLIST_ID")
+	viper.BindEnv("policy.min_word_length", "HASHCAT_POLICY_MIN_WORD_LENGTH")
+	viper.BindEnv("policy.check_username", "HASHCAT_POLICY_CHECK_USERNAME")
+	viper.BindEnv("agent_network.allow", "HASHCAT_AGENT_NETWORK_ALLOW")
+	viper.BindEnv("agent_network.deny", "HASHCAT_AGENT_NETWORK_DENY")
+	viper.BindEnv("agent_network.trusted_proxies", "HASHCAT_AGENT_NETWORK_TRUSTED_PROXIES")
+	viper.BindEnv("accounting.currency", "HASHCAT_ACCOUNTING_CURRENCY")
+	viper.BindEnv("accounting.device_hour_rate", "HASHCAT_ACCOUNTING_DEVICE_HOUR_RATE")
+	viper.BindEnv("accounting.kwh_rate", "HASHCAT_ACCOUNTING_KWH_RATE")
+	viper.BindEnv("accounting.device_watts", "HASHCAT_ACCOUNTING_DEVICE_WATTS")
+	viper.BindEnv("backup.interval_hours", "HASHCAT_BACKUP_INTERVAL_HOURS")
+	viper.BindEnv("backup.keep", "HASHCAT_BACKUP_KEEP")
+	viper.BindEnv("backup.target", "HASHCAT_BACKUP_TARGET")
+	viper.BindEnv("backup.path", "HASHCAT_BACKUP_PATH")
+	viper.BindEnv("backup.s3.bucket", "HASHCAT_BACKUP_S3_BUCKET")
+	viper.BindEnv("backup.s3.prefix", "HASHCAT_BACKUP_S3_PREFIX")
+	viper.BindEnv("backup.s3.region", "HASHCAT_BACKUP_S3_REGION")
+	viper.BindEnv("backup.s3.endpoint", "HASHCAT_BACKUP_S3_ENDPOINT")
+	viper.BindEnv("backup.s3.access_key_id", "HASHCAT_BACKUP_S3_ACCESS_KEY_ID")
+	viper.BindEnv("backup.s3.secret_access_key", "HASHCAT_BACKUP_S3_SECRET_ACCESS_KEY")
+	viper.BindEnv("autoscale.enabled", "HASHCAT_AUTOSCALE_ENABLED")
+	viper.BindEnv("autoscale.provider", "HASHCAT_AUTOSCALE_PROVIDER")
+	viper.BindEnv("autoscale.server_url", "HASHCAT_AUTOSCALE_SERVER_URL")
+	viper.BindEnv("autoscale.agent_download_url", "HASHCAT_AUTOSCALE_AGENT_DOWNLOAD_URL")
+	viper.BindEnv("autoscale.hetzner.token", "HASHCAT_AUTOSCALE_HETZNER_TOKEN", "HCLOUD_TOKEN")
+	viper.BindEnv("autoscale.aws.access_key_id", "HASHCAT_AUTOSCALE_AWS_ACCESS_KEY_ID")
+	viper.BindEnv("autoscale.aws.secret_access_key", "HASHCAT_AUTOSCALE_AWS_SECRET_ACCESS_KEY")
+	viper.BindEnv("autoscale.gcp.access_token", "HASHCAT_AUTOSCALE_GCP_ACCESS_TOKEN")
+	viper.BindEnv("security.cors_allowed_origins", "HASHCAT_SECURITY_CORS_ALLOWED_ORIGINS", "HASHCAT_FRONTEND_URL")
+	viper.BindEnv("security.csrf_enabled", "HASHCAT_SECURITY_CSRF_ENABLED")
+	viper.BindEnv("security.hsts_max_age_seconds", "HASHCAT_SECURITY_HSTS_MAX_AGE_SECONDS")
+	viper.BindEnv("security.content_security_policy", "HASHCAT_SECURITY_CONTENT_SECURITY_POLICY")
+	viper.BindEnv("oidc.issuer_url", "HASHCAT_OIDC_ISSUER_URL")
+	viper.BindEnv("oidc.client_id", "HASHCAT_OIDC_CLIENT_ID")
+	viper.BindEnv("oidc.client_secret", "HASHCAT_OIDC_CLIENT_SECRET")
+	viper.BindEnv("oidc.redirect_url", "HASHCAT_OIDC_REDIRECT_URL")
+	viper.BindEnv("oidc.scopes", "HASHCAT_OIDC_SCOPES")
+	viper.BindEnv("oidc.username_claim", "HASHCAT_OIDC_USERNAME_CLAIM")
+	viper.BindEnv("oidc.role_claim", "HASHCAT_OIDC_ROLE_CLAIM")
+	viper.BindEnv("oidc.role_mapping", "HASHCAT_OIDC_ROLE_MAPPING")
+	viper.BindEnv("oidc.default_role", "HASHCAT_OIDC_DEFAULT_ROLE")
+	viper.BindEnv("oidc.post_login_url", "HASHCAT_OIDC_POST_LOGIN_URL")
+	viper.BindEnv("smtp.host", "HASHCAT_SMTP_HOST")
+	viper.BindEnv("smtp.port", "HASHCAT_SMTP_PORT")
+	viper.BindEnv("smtp.username", "HASHCAT_SMTP_USERNAME")
+	viper.BindEnv("smtp.password", "HASHCAT_SMTP_PASSWORD")
+	viper.BindEnv("smtp.from", "HASHCAT_SMTP_FROM")
+	viper.BindEnv("digest.dashboard_url", "HASHCAT_DIGEST_DASHBOARD_URL", "HASHCAT_FRONTEND_URL")
+	viper.BindEnv("digest.check_interval_minutes", "HASHCAT_DIGEST_CHECK_INTERVAL_MINUTES")
+	viper.BindEnv("slack.signing_secret", "HASHCAT_SLACK_SIGNING_SECRET")
+	viper.BindEnv("slack.allowed_users", "HASHCAT_SLACK_ALLOWED_USERS")
+	viper.BindEnv("hashtopolis.agent_api", "HASHCAT_HASHTOPOLIS_AGENT_API")
 
 	// Set defaults
 	viper.SetDefault("server.port", 1337)
 	viper.SetDefault("server.host", "0.0.0.0") // Bind to all interfaces by default
+	viper.SetDefault("server.shutdown_timeout_seconds", 30)
 	viper.SetDefault("database.path", "./data/hashcat.db")
 	viper.SetDefault("database.type", "sqlite")
 	viper.SetDefault("upload.directory", "./uploads")
+	viper.SetDefault("upload.hashfile_max_size_mb", 100)
+	viper.SetDefault("upload.hashfile_extensions", ".txt,.hash,.hashes,.lst,.22000,.hc22000,.16800,.hccapx,.hccap,.cap,.pcap")
+	viper.SetDefault("upload.hashfile_mime_types", strings.Join([]string{handler.ContentTypeText, handler.ContentTypeHccapx, handler.ContentTypePcap, handler.ContentTypePcapng, handler.ContentTypeBinary}, ","))
+	viper.SetDefault("upload.wordlist_max_size_mb", 10240)
+	viper.SetDefault("upload.wordlist_extensions", ".txt,.dic,.dict,.lst,.wordlist")
+	viper.SetDefault("upload.wordlist_mime_types", handler.ContentTypeText)
+	viper.SetDefault("upload.clamav_timeout_seconds", 60)
+	viper.SetDefault("retention.archive_after_days", 30)
+	viper.SetDefault("retention.purge_after_days", 90)
+	viper.SetDefault("retention.check_interval_minutes", 60)
+	viper.SetDefault("smtp.port", 587)
+	viper.SetDefault("digest.check_interval_minutes", 15)
+	viper.SetDefault("retention.agent_stale_after_days", 30)
+	viper.SetDefault("retention.agent_action", domain.AgentRetentionArchive)
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("preview.hashcat_path", "hashcat")
+	viper.SetDefault("preview.timeout_seconds", 10)
+	viper.SetDefault("preview.workers", 2)
+	viper.SetDefault("download.max_per_file", 4)
+	viper.SetDefault("download.retry_after_seconds", 15)
+	viper.SetDefault("fault_injection.enabled", false)
+	viper.SetDefault("fault_injection.max_latency_ms", 2000)
+	viper.SetDefault("heartbeat.interval_seconds", 5)
+	viper.SetDefault("heartbeat.min_interval_seconds", 1)
+	viper.SetDefault("heartbeat.max_interval_seconds", 60)
+	viper.SetDefault("heartbeat.flush_interval_seconds", 5)
+	viper.SetDefault("heartbeat.flush_threshold", 500)
+	viper.SetDefault("heartbeat.degraded_after_missed", 3)
+	viper.SetDefault("heartbeat.offline_after_missed", 6)
+	viper.SetDefault("heartbeat.job_lease_seconds", 180)
+	viper.SetDefault("jobs.max_runtime_minutes", 0)
+	viper.SetDefault("jobs.stall_timeout_minutes", 60)
+	viper.SetDefault("agent_logs.retain_lines", 5000)
+	viper.SetDefault("cache.lookup_ttl_seconds", 300)
+	viper.SetDefault("realtime.redis.channel", pubsub.DefaultRedisChannel)
+	viper.SetDefault("results.redact", true)
+	viper.SetDefault("policy.min_length", 12)
+	viper.SetDefault("policy.min_classes", 3)
+	viper.SetDefault("policy.check_dictionary", true)
+	viper.SetDefault("policy.min_word_length", 4)
+	viper.SetDefault("policy.check_username", true)
+	viper.SetDefault("accounting.currency", "USD")
+	viper.SetDefault("accounting.device_watts", 250)
+	viper.SetDefault("backup.interval_hours", 0)
+	viper.SetDefault("backup.keep", 14)
+	viper.SetDefault("backup.target", "local")
+	viper.SetDefault("backup.path", "./data/backups")
+	viper.SetDefault("autoscale.enabled", false)
+	viper.SetDefault("autoscale.check_interval_seconds", 60)
+	viper.SetDefault("autoscale.queue_threshold", 0)
+	viper.SetDefault("autoscale.idle_minutes", 10)
+	viper.SetDefault("autoscale.boot_timeout_minutes", 15)
+	viper.SetDefault("autoscale.max_instances", 2)
+	viper.SetDefault("security.cors_allowed_origins", "http://localhost:3000,http://localhost:5173,http://127.0.0.1:3000,http://127.0.0.1:5173")
+	viper.SetDefault("security.csrf_enabled", true)
+	viper.SetDefault("security.hsts_max_age_seconds", 31536000)
+	viper.SetDefault("security.content_security_policy", middleware.DefaultContentSecurityPolicy)
+	viper.SetDefault("oidc.scopes", "profile,email")
+	viper.SetDefault("oidc.role_claim", "groups")
+	viper.SetDefault("oidc.default_role", "user")
 
 	// Try to load .env file first
 	viper.SetConfigName(".env")
@@ -109,6 +426,10 @@ func loadConfig() *Config {
 		infrastructure.ServerLogger.Fatal("Unable to decode config: %v", err)
 	}
 
+	if err := infrastructure.ConfigureLogging(config.Logging.Format, config.Logging.Level, nil); err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid logging config: %v", err)
+	}
+
 	// Log config source for debugging with actual values
 	infrastructure.ServerLogger.Info("Configuration loaded - Server: %d, Database: %s (%s), Upload: %s", config.Server.Port, config.Database.Path, config.Database.Type, config.Upload.Directory)
 
@@ -126,6 +447,33 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// readinessChecks are the dependencies /readyz checks besides the WebSocket hub
+func readinessChecks(db *database.SQLiteDB, runner *database.MigrationRunner, uploadDir string) []handler.HealthCheck {
+	return []handler.HealthCheck{
+		{Name: "database", Check: func(ctx context.Context) error {
+			return db.DB().PingContext(ctx)
+		}},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := runner.PendingMigrations()
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d migrations not applied, first %d", len(pending), pending[0])
+			}
+			return nil
+		}},
+		{Name: "upload_dir", Check: func(ctx context.Context) error {
+			probe, err := os.CreateTemp(uploadDir, ".readyz-*")
+			if err != nil {
+				return fmt.Errorf("not writable: %w", err)
+			}
+			probe.Close()
+			return os.Remove(probe.Name())
+		}},
+	}
+}
+
 // Migration commands
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
@@ -246,9 +594,384 @@ Example:
 	},
 }
 
+var encryptHashFilesCmd = &cobra.Command{
+	Use:   "encrypt-hashfiles",
+	Short: "Encrypt hash files stored before encryption was turned on",
+	Long: `Encrypt the uploaded hash files that are still stored in plaintext, using
+the upload encryption key. Files that are already encrypted are left alone,
+so the command can be run again safely.
+
+Example:
+  HASHCAT_UPLOAD_ENCRYPTION_KEY_FILE=/etc/hashcat/upload.key ./server encrypt-hashfiles`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		cipher := loadFileCipher(config)
+		if cipher == nil {
+			infrastructure.ServerLogger.Fatal("No upload encryption key is configured")
+		}
+
+		db, err := database.NewSQLiteDB(config.Database.Path)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+
+		hashFileUsecase := usecase.NewHashFileUsecase(repository.NewHashFileRepository(db), config.Upload.Directory)
+		hashFileUsecase.SetFileEncryptor(cipher)
+		encrypted, err := hashFileUsecase.EncryptStoredHashFiles(context.Background())
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to encrypt hash files after %d: %v", encrypted, err)
+		}
+		infrastructure.ServerLogger.Info("Encrypted %d hash files", encrypted)
+	},
+}
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Fill the database with sample data",
+	Long: `Add simulated agents, sample hash files and wordlists, and a history of
+jobs in a project named Demo, so the system can be explored without GPUs.
+Nothing is added when the Demo project exists.
+
+Start the server with --demo to have the simulated agents take the queued
+jobs; --demo seeds on its own when the database has no demo data yet.
+
+Example:
+  ./server seed
+  ./server --demo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		db, err := database.NewSQLiteDB(config.Database.Path)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+		if config.Results.EncryptionKey != "" {
+			cipher, err := database.NewFieldCipher(config.Results.EncryptionKey)
+			if err != nil {
+				infrastructure.ServerLogger.Fatal("Invalid results encryption key: %v", err)
+			}
+			db.SetFieldCipher(cipher)
+		}
+
+		agentRepo := repository.NewAgentRepository(db)
+		jobRepo := repository.NewJobRepository(db)
+		hashFileRepo := repository.NewHashFileRepository(db)
+		wordlistRepo := repository.NewWordlistRepository(db)
+		hashFileUsecase := usecase.NewHashFileUsecase(hashFileRepo, config.Upload.Directory)
+		if cipher := loadFileCipher(config); cipher != nil {
+			hashFileUsecase.SetFileEncryptor(cipher)
+		}
+		demoUsecase := usecase.NewDemoUsecase(usecase.NewAgentUsecase(agentRepo), usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo),
+			repository.NewProjectRepository(db), repository.NewProjectArchiveRepository(db), hashFileUsecase, usecase.NewWordlistUsecase(wordlistRepo, config.Upload.Directory))
+
+		result, err := demoUsecase.Seed(context.Background())
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to seed demo data: %v", err)
+		}
+		fmt.Printf("Seeded project %s: %d simulated agents, %d hash files, %d wordlists, %d jobs and %d credentials\n",
+			result.Project.Name, result.Agents, result.HashFiles, result.Wordlists, result.Jobs, result.Credentials)
+		fmt.Println("Run the server with --demo to let the simulated agents work through the queued jobs")
+	},
+}
+
+// Backup commands
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Database backup commands",
+	Long: `Back up the database to the configured backup target, a local directory or
+an S3 bucket, list the backups there and restore one.
+
+Backups are consistent copies taken while the server runs. Uploaded files
+aren't copied; each backup lists them so a restore can tell which are missing.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the database now",
+	Long: `Copy the database with SQLite's online backup API, check the copy for
+corruption and store it at the backup target with a listing of the upload
+directory. The server may keep running.
+
+Example:
+  ./server backup create`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		db, err := database.NewSQLiteDB(config.Database.Path)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+
+		backup, err := newBackupUsecase(config, db).CreateBackup(context.Background())
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Backup failed: %v", err)
+		}
+		fmt.Printf("Backup %s written (%d bytes, schema version %d, %d uploads listed)\n",
+			backup.ID, backup.DatabaseSize, backup.SchemaVersion, len(backup.Uploads))
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the backups at the backup target",
+	Long: `List the complete backups at the backup target, newest first.
+
+Example:
+  ./server backup list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		backups, err := newBackupUsecase(config, nil).ListBackups(context.Background())
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to list backups: %v", err)
+		}
+		if len(backups) == 0 {
+			fmt.Println("No backups")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCREATED\tSIZE\tSCHEMA\tUPLOADS")
+		for _, backup := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", backup.ID, backup.CreatedAt.Local().Format(time.RFC3339),
+				backup.DatabaseSize, backup.SchemaVersion, len(backup.Uploads))
+		}
+		w.Flush()
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Replace the database with a backup",
+	Long: `Download a backup, check it against its checksum and for corruption, and put
+it in place of the database. Stop the server first. The replaced database is
+kept next to it with a .pre-restore suffix.
+
+Example:
+  ./server backup restore 20261015T020000Z`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		result, err := newBackupUsecase(config, nil).RestoreBackup(context.Background(), args[0])
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Restore failed: %v", err)
+		}
+
+		fmt.Printf("Restored backup %s to %s\n", result.Backup.ID, config.Database.Path)
+		if result.PreviousDatabase != "" {
+			fmt.Printf("The replaced database was moved to %s\n", result.PreviousDatabase)
+		}
+		for _, path := range result.MissingUploads {
+			fmt.Printf("Missing upload: %s\n", path)
+		}
+		for _, path := range result.ChangedUploads {
+			fmt.Printf("Upload changed since the backup: %s\n", path)
+		}
+	},
+}
+
+// newBackupUsecase returns the backup usecase of the configured backup
+// target. db is only needed to create backups.
+func newBackupUsecase(config *Config, db *database.SQLiteDB) usecase.BackupUsecase {
+	if config.Database.Type != "sqlite" {
+		infrastructure.ServerLogger.Fatal("Backups support SQLite databases only, not %s", config.Database.Type)
+	}
+	store, err := backupstore.NewStore(config.Backup.Config)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Failed to set up the backup target: %v", err)
+	}
+
+	var snapshotter domain.DatabaseSnapshotter
+	if db != nil {
+		snapshotter = db
+	}
+	return usecase.NewBackupUsecase(store, snapshotter, usecase.BackupConfig{
+		DatabaseType: config.Database.Type,
+		DatabasePath: config.Database.Path,
+		UploadDir:    config.Upload.Directory,
+		Keep:         config.Backup.Keep,
+		Verify:       database.VerifySnapshot,
+	})
+}
+
+// loadFileCipher returns the cipher of uploaded hash files, or nil when no
+// upload encryption key is configured
+func loadFileCipher(config *Config) *infrastructure.FileCipher {
+	key, err := infrastructure.LoadEncryptionKey(config.Upload.EncryptionKey, config.Upload.EncryptionKeyFile, config.Upload.EncryptionKeyCommand)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Failed to load upload encryption key: %v", err)
+	}
+	if key == "" {
+		return nil
+	}
+
+	cipher, err := infrastructure.NewFileCipher(key)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid upload encryption key: %v", err)
+	}
+	return cipher
+}
+
+// passwordPolicy returns the policy compliance reports check passwords
+// against unless a request brings its own
+func passwordPolicy(config *Config) domain.PasswordPolicy {
+	policy := domain.PasswordPolicy{
+		MinLength:       config.Policy.MinLength,
+		MinClasses:      config.Policy.MinClasses,
+		RequiredClasses: splitList(config.Policy.RequiredClasses),
+		CheckDictionary: config.Policy.CheckDictionary,
+		MinWordLength:   config.Policy.MinWordLength,
+		CheckUsername:   config.Policy.CheckUsername,
+	}
+	if config.Policy.DictionaryWordlistID != "" {
+		id, err := uuid.Parse(config.Policy.DictionaryWordlistID)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Invalid policy dictionary wordlist ID %q: %v", config.Policy.DictionaryWordlistID, err)
+		}
+		policy.DictionaryWordlistID = &id
+	}
+	return policy
+}
+
+// singleSignOn returns the single sign-on usecase, or nil when no identity
+// provider is configured
+func singleSignOn(config *Config, identityRepo domain.SSOIdentityRepository, userRepo domain.UserRepository, sessions domain.SessionStarter) usecase.SSOUsecase {
+	if config.OIDC.IssuerURL == "" {
+		return nil
+	}
+	provider, err := oidc.NewProvider(config.OIDC.Config)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid single sign-on config: %v", err)
+	}
+	roleMapping, err := usecase.ParseSSORoleMapping(config.OIDC.RoleMapping)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid single sign-on config: %v", err)
+	}
+	infrastructure.ServerLogger.Info("Single sign-on through %s", config.OIDC.IssuerURL)
+	return usecase.NewSSOUsecase(provider, identityRepo, userRepo, sessions, usecase.SSOConfig{
+		RoleMapping: roleMapping,
+		DefaultRole: config.OIDC.DefaultRole,
+	})
+}
+
+// smtpMailer returns the mailer of the configured SMTP server, or nil when
+// there is none
+func smtpMailer(config *Config) domain.Mailer {
+	if config.SMTP.Host == "" {
+		return nil
+	}
+	mailer, err := mail.NewSMTPMailer(config.SMTP)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid SMTP config: %v", err)
+	}
+	return mailer
+}
+
+// chatOps returns the usecase answering Slack commands, or nil when no
+// Slack app is configured
+func chatOps(config *Config, jobUsecase usecase.JobUsecase, statsUsecase usecase.StatsUsecase, hashFileRepo domain.HashFileRepository) usecase.ChatOpsUsecase {
+	if config.Slack.SigningSecret == "" {
+		return nil
+	}
+	allowed := splitList(config.Slack.AllowedUsers)
+	if len(allowed) == 0 {
+		infrastructure.ServerLogger.Warning("Slack commands are on and every Slack user may queue and stop jobs, set HASHCAT_SLACK_ALLOWED_USERS to limit them")
+	} else {
+		infrastructure.ServerLogger.Info("Slack commands are on, %d Slack users may queue and stop jobs", len(allowed))
+	}
+	return usecase.NewChatOpsUsecase(jobUsecase, statsUsecase, hashFileRepo, usecase.ChatOpsConfig{AllowedUsers: allowed})
+}
+
+// webUI returns the web UI built into the binary, or the frontend's build
+// directory when the server was built without it
+func webUI() fs.FS {
+	if files := frontend.Dist(); files != nil {
+		infrastructure.ServerLogger.Info("Serving the embedded web UI")
+		return files
+	}
+	return os.DirFS("./frontend/dist")
+}
+
+// securityConfig returns the CORS, CSRF and security header settings. The
+// CORS origins may also send cookies past the CSRF check.
+func securityConfig(config *Config) middleware.SecurityConfig {
+	origins := splitList(config.Security.CORSAllowedOrigins)
+	csp := config.Security.ContentSecurityPolicy
+	if csp == "off" {
+		csp = ""
+	}
+	return middleware.SecurityConfig{
+		CORS: middleware.CORSConfig{AllowedOrigins: origins},
+		CSRF: middleware.CSRFConfig{Enabled: config.Security.CSRFEnabled, TrustedOrigins: origins},
+		Headers: middleware.SecurityHeadersConfig{
+			HSTSMaxAge:            time.Duration(config.Security.HSTSMaxAgeSeconds) * time.Second,
+			ContentSecurityPolicy: csp,
+		},
+	}
+}
+
+// agentNetworkRules returns the network rules from the configuration and
+// the proxies whose X-Forwarded-For headers are believed
+func agentNetworkRules(config *Config) ([]domain.AgentNetworkRule, []*net.IPNet) {
+	allow, err := usecase.ParseAgentNetworkRules(splitList(config.AgentNetwork.Allow), domain.NetworkRuleAllow)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid agent network allowlist: %v", err)
+	}
+	deny, err := usecase.ParseAgentNetworkRules(splitList(config.AgentNetwork.Deny), domain.NetworkRuleDeny)
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid agent network denylist: %v", err)
+	}
+	proxies, err := usecase.ParseNetworks(splitList(config.AgentNetwork.TrustedProxies))
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid agent network trusted proxies: %v", err)
+	}
+	return append(allow, deny...), proxies
+}
+
+// faultInjection returns the fault injection settings, warning loudly when
+// they are on, since they make the server fail on purpose
+func faultInjection(config *Config) middleware.FaultInjectionConfig {
+	fi := config.FaultInjection
+	if !fi.Enabled {
+		return middleware.FaultInjectionConfig{}
+	}
+	for name, rate := range map[string]float64{"latency_rate": fi.LatencyRate, "error_rate": fi.ErrorRate, "heartbeat_drop_rate": fi.HeartbeatDropRate} {
+		if rate < 0 || rate > 1 {
+			infrastructure.ServerLogger.Fatal("Invalid fault_injection.%s %v: must be between 0 and 1", name, rate)
+		}
+	}
+	infrastructure.ServerLogger.Warning("FAULT INJECTION IS ENABLED: agent-facing routes are delayed %.0f%% (up to %dms), fail %.0f%% and drop %.0f%% of heartbeats. Never run this in production.",
+		fi.LatencyRate*100, fi.MaxLatencyMs, fi.ErrorRate*100, fi.HeartbeatDropRate*100)
+	return middleware.FaultInjectionConfig{
+		Enabled:           true,
+		LatencyRate:       fi.LatencyRate,
+		MaxLatency:        time.Duration(fi.MaxLatencyMs) * time.Millisecond,
+		ErrorRate:         fi.ErrorRate,
+		HeartbeatDropRate: fi.HeartbeatDropRate,
+		Seed:              fi.Seed,
+	}
+}
+
+// splitList splits a comma-separated config value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func init() {
 	// Add migration commands to root
 	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(encryptHashFilesCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(seedCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
 	migrateCmd.AddCommand(migrateGenerateCmd)
 	migrateCmd.AddCommand(migrateUpCmd)
 	migrateCmd.AddCommand(migrateDownCmd)
@@ -257,6 +980,7 @@ func init() {
 	// Server flags
 	rootCmd.Flags().StringVar(&serverHost, "ip", "", "Server IP address to bind to (default: 0.0.0.0)")
 	rootCmd.Flags().IntVar(&serverPort, "port", 0, "Server port (default: 1337)")
+	rootCmd.Flags().BoolVar(&demoMode, "demo", false, "Seed sample data on first start and simulate its agents, no GPUs needed")
 
 	// Migration flags
 	migrateCmd.PersistentFlags().StringVar(&migrationsDir, "migrations-dir", migrationsDir, "Directory containing migration files")
@@ -281,6 +1005,19 @@ func startServer() {
 		config.Server.Port = serverPort
 	}
 
+	// Export request, usecase and query spans to the OTLP collector
+	shutdownTracing, err := tracing.Init(context.Background(), config.Tracing, "hashcat-server")
+	if err != nil {
+		infrastructure.ServerLogger.Fatal("Failed to set up tracing: %v", err)
+	}
+	if config.Tracing.Enabled {
+		endpoint := config.Tracing.Endpoint
+		if endpoint == "" {
+			endpoint = "the default OTLP endpoint"
+		}
+		infrastructure.ServerLogger.Info("Tracing enabled, exporting spans to %s", endpoint)
+	}
+
 	// Initialize database
 	db, err := database.NewSQLiteDB(config.Database.Path)
 	if err != nil {
@@ -288,6 +1025,15 @@ func startServer() {
 	}
 	defer db.Close()
 
+	if config.Results.EncryptionKey != "" {
+		cipher, err := database.NewFieldCipher(config.Results.EncryptionKey)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Invalid results encryption key: %v", err)
+		}
+		db.SetFieldCipher(cipher)
+		infrastructure.ServerLogger.Info("Job results and agent output are encrypted at rest")
+	}
+
 	// Run migrations automatically on server start
 	runner := database.NewMigrationRunner(db.DB(), migrationsDir)
 	if err := runner.MigrateUp(); err != nil {
@@ -299,7 +1045,22 @@ func startServer() {
 	jobRepo := repository.NewJobRepository(db)
 	hashFileRepo := repository.NewHashFileRepository(db)
 	wordlistRepo := repository.NewWordlistRepository(db)
+	charsetRepo := repository.NewCharsetRepository(db)
 	userRepo := repository.NewUserRepository(db.DB())
+	searchRepo := repository.NewSearchRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	effectivenessRepo := repository.NewEffectivenessRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	quotaRepo := repository.NewQuotaRepository(db)
+	tenantRepo := repository.NewTenantRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+	enrollmentRepo := repository.NewEnrollmentRepository(db)
+	resultAccessRepo := repository.NewResultAccessRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	cloudInstanceRepo := repository.NewCloudInstanceRepository(db)
+	hashFileOperationRepo := repository.NewHashFileOperationRepository(db)
+	ntdsRepo := repository.NewNTDSRepository(db)
+	credentialRepo := repository.NewCredentialRepository(db)
 
 	// Initialize JWT service
 	jwtService := infrastructure.NewJWTService()
@@ -308,12 +1069,50 @@ func startServer() {
 	agentUsecase := usecase.NewAgentUsecase(agentRepo)
 	jobUsecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo)
 	hashFileUsecase := usecase.NewHashFileUsecase(hashFileRepo, config.Upload.Directory)
+	if cipher := loadFileCipher(config); cipher != nil {
+		hashFileUsecase.SetFileEncryptor(cipher)
+		infrastructure.ServerLogger.Info("Uploaded hash files are encrypted at rest")
+	}
 	wordlistUsecase := usecase.NewWordlistUsecase(wordlistRepo, config.Upload.Directory)
+	charsetUsecase := usecase.NewCharsetUsecase(charsetRepo, config.Upload.Directory)
 	distributedJobUsecase := usecase.NewDistributedJobUsecase(agentRepo, jobRepo, wordlistRepo, hashFileRepo, config.Upload.Directory)
-	authUsecase := usecase.NewAuthUsecase(userRepo, jwtService)
+	authUsecase := usecase.NewAuthUsecase(userRepo, repository.NewTwoFactorRepository(db), resultAccessRepo, repository.NewSessionRepository(db), jwtService)
+	ssoUsecase := singleSignOn(config, repository.NewSSOIdentityRepository(db), userRepo, authUsecase)
+	if ssoUsecase != nil {
+		authUsecase.SetSessionRefresher(ssoUsecase)
+	}
+	searchUsecase := usecase.NewSearchUsecase(searchRepo)
+	statsUsecase := usecase.NewStatsUsecase(statsRepo, usecase.DefaultStatsCacheTTL)
+	projectUsecase := usecase.NewProjectUsecase(projectRepo, userRepo)
+	suggestionUsecase := usecase.NewSuggestionUsecase(jobRepo, wordlistRepo)
+	recommendationUsecase := usecase.NewRecommendationUsecase(statsRepo, hashFileRepo)
+	analyticsUsecase := usecase.NewAnalyticsUsecase(effectivenessRepo, jobRepo, wordlistRepo)
+	jobUsecase.SetEffectivenessRecorder(analyticsUsecase)
+	quotaUsecase := usecase.NewQuotaUsecase(quotaRepo, userRepo, projectRepo, tenantRepo)
+	tenantUsecase := usecase.NewTenantUsecase(tenantRepo, userRepo, agentRepo, quotaRepo)
+	mailer := smtpMailer(config)
+	digestUsecase := usecase.NewDigestUsecase(repository.NewDigestSubscriptionRepository(db), projectRepo, userRepo, jobRepo, agentRepo, maintenanceRepo, mailer,
+		usecase.DigestConfig{DashboardURL: config.Digest.DashboardURL})
+	maintenanceUsecase := usecase.NewMaintenanceUsecase(maintenanceRepo, agentRepo)
+	enrollmentUsecase := usecase.NewEnrollmentUsecase(enrollmentRepo, agentUsecase)
+	resultAccessUsecase := usecase.NewResultAccessUsecase(resultAccessRepo, jobRepo, userRepo, config.Results.Redact)
+	candidateGenerator := infrastructure.NewHashcatStdoutGenerator(config.Preview.HashcatPath, time.Duration(config.Preview.TimeoutSeconds)*time.Second, config.Preview.Workers)
+	candidatePreviewUsecase := usecase.NewCandidatePreviewUsecase(wordlistRepo, charsetRepo, candidateGenerator)
+
+	// Agents, wordlists and hash files are looked up through one cache,
+	// shared by job enrichment and the scheduler and invalidated by the
+	// usecases that change them
+	lookupCache := usecase.NewLookupCache(agentRepo, wordlistRepo, hashFileRepo, time.Duration(config.Cache.LookupTTLSeconds)*time.Second)
+	agentUsecase.SetLookupCache(lookupCache)
+	wordlistUsecase.SetLookupCache(lookupCache)
+	hashFileUsecase.SetLookupCache(lookupCache)
+	jobUsecase.SetLookupCache(lookupCache)
+	if err := lookupCache.Warm(context.Background()); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to warm the lookup cache: %v", err)
+	}
 
 	// Initialize enrichment service
-	jobEnrichmentService := usecase.NewJobEnrichmentService(agentRepo, wordlistRepo, hashFileRepo)
+	jobEnrichmentService := usecase.NewJobEnrichmentService(lookupCache)
 
 	// Get WebSocket hub early for dependency injection
 	wsHub := handler.GetHub() // Get the singleton hub
@@ -322,8 +1121,125 @@ func startServer() {
 	agentUsecase.SetWebSocketHub(wsHub)
 	infrastructure.ServerLogger.Info("WebSocket hub connected to agent usecase")
 
+	agentUsecase.SetHeartbeatConfig(usecase.HeartbeatConfig{
+		Interval:       time.Duration(config.Heartbeat.IntervalSeconds) * time.Second,
+		MinInterval:    time.Duration(config.Heartbeat.MinIntervalSeconds) * time.Second,
+		MaxInterval:    time.Duration(config.Heartbeat.MaxIntervalSeconds) * time.Second,
+		FlushInterval:  time.Duration(config.Heartbeat.FlushIntervalSeconds) * time.Second,
+		FlushThreshold: config.Heartbeat.FlushThreshold,
+	})
+	agentUsecase.SetAgentLogRetention(config.AgentLogs.RetainLines)
+	agentRetention := domain.AgentRetentionPolicy{
+		StaleAfter: time.Duration(config.Retention.AgentStaleAfterDays) * 24 * time.Hour,
+		Action:     config.Retention.AgentAction,
+	}
+	if err := agentUsecase.SetAgentRetention(agentRetention); err != nil {
+		infrastructure.ServerLogger.Fatal("Invalid agent retention policy: %v", err)
+	}
+
+	// Agents hold their jobs on a lease that heartbeats renew
+	jobUsecase.SetJobLease(time.Duration(config.Heartbeat.JobLeaseSeconds) * time.Second)
+	jobUsecase.SetProgressFlush(time.Duration(config.Heartbeat.FlushIntervalSeconds)*time.Second, config.Heartbeat.FlushThreshold)
+	agentUsecase.SetJobLeaser(jobUsecase)
+
+	// Limits of jobs that don't set their own
+	jobTimeouts := domain.JobTimeouts{
+		MaxRuntimeMinutes:   config.Jobs.MaxRuntimeMinutes,
+		StallTimeoutMinutes: config.Jobs.StallTimeoutMinutes,
+	}
+	if jobTimeouts.MaxRuntimeMinutes < 0 || jobTimeouts.StallTimeoutMinutes < 0 {
+		infrastructure.ServerLogger.Fatal("Job max runtime and stall timeout must not be negative")
+	}
+	jobUsecase.SetJobTimeouts(jobTimeouts)
+	distributedJobUsecase.SetJobTimeouts(jobTimeouts)
+
+	// Cracked passwords accumulate into per-project loopback wordlists
+	jobUsecase.SetCrackedPasswordSink(wordlistUsecase)
+	costRates := domain.CostRates{
+		Currency:    config.Accounting.Currency,
+		DeviceHour:  config.Accounting.DeviceHourRate,
+		KWh:         config.Accounting.KWhRate,
+		DeviceWatts: config.Accounting.DeviceWatts,
+	}
+	jobUsecase.SetCostRates(costRates)
+	statsUsecase.SetCostRates(costRates)
+	statsUsecase.SetDatabaseStats(db)
+
+	// Refuse jobs and uploads of users and projects at their quota
+	jobUsecase.SetQuotaChecker(quotaUsecase)
+	distributedJobUsecase.SetQuotaChecker(quotaUsecase)
+	hashFileUsecase.SetQuotaChecker(quotaUsecase)
+	wordlistUsecase.SetQuotaChecker(quotaUsecase)
+
+	// Merge, split and diff results are stored like uploads, so this comes
+	// after the hash file usecase is fully set up
+	hashFileOperationUsecase := usecase.NewHashFileOperationUsecase(hashFileOperationRepo, hashFileUsecase, 0)
+	if err := hashFileOperationUsecase.FailInterrupted(context.Background()); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to fail interrupted hash file operations: %v", err)
+	}
+	ntdsUsecase := usecase.NewNTDSUsecase(ntdsRepo, hashFileUsecase, jobRepo)
+
+	// Cracked passwords are linked to the accounts of their hash file
+	credentialUsecase := usecase.NewCredentialUsecase(credentialRepo, hashFileUsecase, ntdsRepo)
+	jobUsecase.SetCredentialRecorder(credentialUsecase)
+	complianceUsecase := usecase.NewComplianceUsecase(credentialRepo, wordlistRepo, passwordPolicy(config))
+
+	// Projects move between servers as archives of their jobs, results and files
+	projectArchiveUsecase := usecase.NewProjectArchiveUsecase(repository.NewProjectArchiveRepository(db), projectRepo, hashFileUsecase, wordlistUsecase)
+	// Teams moving over from Hashtopolis import its hashlists, tasks and agents
+	hashtopolisUsecase := usecase.NewHashtopolisUsecase(projectRepo, repository.NewProjectArchiveRepository(db), hashFileUsecase, wordlistUsecase, agentUsecase, enrollmentUsecase)
+	if config.Hashtopolis.AgentAPI {
+		infrastructure.ServerLogger.Info("Hashtopolis agents can register and log in at /api/server.php; they get no tasks")
+	}
+
+	// Standard wordlists are downloaded from their sources instead of
+	// uploaded, stored like uploads so this comes after the quota checker
+	wordlistSourceUsecase := usecase.NewWordlistSourceUsecase(repository.NewWordlistSourceRepository(db), wordlistUsecase, config.Upload.WordlistMaxSizeMB<<20)
+	if err := wordlistSourceUsecase.FailInterrupted(context.Background()); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to fail interrupted wordlist source fetches: %v", err)
+	}
+
+	// Agents are refused outside the configured and stored address ranges
+	staticNetworkRules, trustedProxies := agentNetworkRules(config)
+	agentNetworkUsecase := usecase.NewAgentNetworkUsecase(repository.NewAgentNetworkRepository(db), agentRepo, staticNetworkRules)
+
+	// Agents in a maintenance window of the cluster calendar take no new jobs
+	agentUsecase.SetMaintenanceCalendar(maintenanceUsecase)
+	jobUsecase.SetMaintenanceCalendar(maintenanceUsecase)
+	distributedJobUsecase.SetMaintenanceCalendar(maintenanceUsecase)
+
+	// Agents benchmark hash modes on request; the scheduler uses the speeds
+	// for modes an agent ran no jobs of
+	agentBenchmarkRepo := repository.NewAgentBenchmarkRepository(db)
+	agentBenchmarkUsecase := usecase.NewAgentBenchmarkUsecase(agentBenchmarkRepo, agentRepo)
+	jobUsecase.SetBenchmarks(agentBenchmarkRepo)
+
+	// Commands queued for agents, e.g. to upgrade or collect diagnostics
+	agentCommandUsecase := usecase.NewAgentCommandUsecase(repository.NewAgentCommandRepository(db), agentRepo)
+
 	// Initialize HTTP router
-	router := httpDelivery.NewRouter(agentUsecase, jobUsecase, hashFileUsecase, wordlistUsecase, jobEnrichmentService, distributedJobUsecase, authUsecase)
+	downloadLimitConfig := middleware.DownloadLimitConfig{
+		MaxPerFile: config.Download.MaxPerFile,
+		RetryAfter: time.Duration(config.Download.RetryAfterSeconds) * time.Second,
+	}
+	faultInjectionConfig := faultInjection(config)
+	uploadPolicies := handler.UploadPolicies{
+		HashFiles: handler.UploadPolicy{
+			MaxSize:    config.Upload.HashFileMaxSizeMB << 20,
+			Extensions: splitList(config.Upload.HashFileExtensions),
+			MIMETypes:  splitList(config.Upload.HashFileMIMETypes),
+		},
+		Wordlists: handler.UploadPolicy{
+			MaxSize:    config.Upload.WordlistMaxSizeMB << 20,
+			Extensions: splitList(config.Upload.WordlistExtensions),
+			MIMETypes:  splitList(config.Upload.WordlistMIMETypes),
+		},
+	}
+	if config.Upload.ClamAVAddress != "" {
+		uploadPolicies.Scanner = infrastructure.NewClamAVScanner(config.Upload.ClamAVAddress, time.Duration(config.Upload.ClamAVTimeoutSeconds)*time.Second)
+		infrastructure.ServerLogger.Info("Uploads are scanned with clamd at %s", config.Upload.ClamAVAddress)
+	}
+	router := httpDelivery.NewRouter(agentUsecase, jobUsecase, hashFileUsecase, wordlistUsecase, charsetUsecase, jobEnrichmentService, distributedJobUsecase, authUsecase, searchUsecase, statsUsecase, projectUsecase, suggestionUsecase, recommendationUsecase, analyticsUsecase, quotaUsecase, maintenanceUsecase, enrollmentUsecase, resultAccessUsecase, candidatePreviewUsecase, hashFileOperationUsecase, ntdsUsecase, credentialUsecase, complianceUsecase, projectArchiveUsecase, agentNetworkUsecase, wordlistSourceUsecase, tenantUsecase, digestUsecase, hashtopolisUsecase, config.Hashtopolis.AgentAPI, agentBenchmarkUsecase, agentCommandUsecase, ssoUsecase, config.OIDC.PostLoginURL, chatOps(config, jobUsecase, statsUsecase, hashFileRepo), config.Slack.SigningSecret, idempotencyRepo, downloadLimitConfig, faultInjectionConfig, securityConfig(config), trustedProxies, uploadPolicies, readinessChecks(db, runner, config.Upload.Directory), webUI())
 
 	// Create HTTP server
 	server := &http.Server{
@@ -331,12 +1247,15 @@ func startServer() {
 		Handler: router,
 	}
 
-	// Initialize health monitoring with ultra-fast real-time intervals
+	// Initialize health monitoring. Degraded and offline thresholds scale
+	// with each agent's heartbeat interval; AgentTimeout is the floor.
 	healthConfig := usecase.HealthConfig{
 		CheckInterval:       1 * time.Second, // Ultra-fast: check every 1 second
-		AgentTimeout:        5 * time.Second, // Ultra-fast timeout detection in 5 seconds
+		AgentTimeout:        5 * time.Second, // Never offline in less than 5 seconds
 		HeartbeatGrace:      2 * time.Second, // Very short grace period
 		MaxConcurrentChecks: 20,              // More concurrent checks
+		DegradedAfterMissed: config.Heartbeat.DegradedAfterMissed,
+		OfflineAfterMissed:  config.Heartbeat.OfflineAfterMissed,
 	}
 
 	healthMonitor := usecase.NewAgentHealthMonitor(
@@ -344,6 +1263,7 @@ func startServer() {
 		wsHub,
 		healthConfig,
 	)
+	healthMonitor.SetMaintenanceCalendar(maintenanceUsecase, jobUsecase)
 
 	// Start health monitor
 	ctx, cancel := context.WithCancel(context.Background())
@@ -352,6 +1272,111 @@ func startServer() {
 	healthMonitor.Start(ctx)
 	defer healthMonitor.Stop()
 
+	// Write buffered heartbeats and job progress in batches. The flushers
+	// outlive the HTTP server so the last reports are written after it
+	// stopped taking them.
+	flushCtx, stopFlusher := context.WithCancel(context.Background())
+	defer stopFlusher()
+	var flushers sync.WaitGroup
+	flushers.Add(2)
+	go func() {
+		defer flushers.Done()
+		agentUsecase.RunHeartbeatFlusher(flushCtx)
+	}()
+	go func() {
+		defer flushers.Done()
+		jobUsecase.RunProgressFlusher(flushCtx)
+	}()
+
+	// Fan WebSocket events out to the other server instances
+	if config.Realtime.Redis.Addr != "" {
+		relay, err := pubsub.NewRedisRelay(config.Realtime.Redis)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to set up the realtime relay: %v", err)
+		}
+		wsHub.SetRelay(relay)
+		go wsHub.RunRelay(ctx)
+		infrastructure.ServerLogger.Info("Relaying realtime events through Redis at %s", config.Realtime.Redis.Addr)
+	}
+
+	// Requeue jobs of agents that stopped renewing their lease
+	go jobUsecase.RunLeaseSweeper(ctx)
+	// Stop runs over their max runtime and requeue stalled ones
+	go jobUsecase.RunWatchdog(ctx)
+	// Fetch scheduled wordlist sources when they are due
+	go wordlistSourceUsecase.RunScheduler(ctx)
+
+	// Seed sample data on first start and play the simulated agents
+	demoCtx, stopDemo := context.WithCancel(ctx)
+	defer stopDemo()
+	if demoMode {
+		demoUsecase := usecase.NewDemoUsecase(agentUsecase, jobUsecase, projectRepo, repository.NewProjectArchiveRepository(db), hashFileUsecase, wordlistUsecase)
+		if result, err := demoUsecase.Seed(ctx); err == nil {
+			infrastructure.ServerLogger.Info("Demo mode: seeded %d agents, %d hash files, %d wordlists and %d jobs", result.Agents, result.HashFiles, result.Wordlists, result.Jobs)
+		} else if !domain.IsValidationError(err) {
+			infrastructure.ServerLogger.Fatal("Failed to seed demo data: %v", err)
+		}
+		go demoUsecase.RunSimulator(demoCtx)
+	}
+
+	// Archive old jobs and purge deleted ones in the background
+	retentionWorker := usecase.NewJobRetentionWorker(jobRepo, usecase.RetentionConfig{
+		CheckInterval: time.Duration(config.Retention.CheckIntervalMinutes) * time.Minute,
+		ArchiveAfter:  time.Duration(config.Retention.ArchiveAfterDays) * 24 * time.Hour,
+		PurgeAfter:    time.Duration(config.Retention.PurgeAfterDays) * 24 * time.Hour,
+	})
+	retentionWorker.Start(ctx)
+	defer retentionWorker.Stop()
+
+	// Archive or delete agents that stopped checking in
+	agentRetentionWorker := usecase.NewAgentRetentionWorker(agentUsecase, agentRetention,
+		time.Duration(config.Retention.CheckIntervalMinutes)*time.Minute)
+	agentRetentionWorker.Start(ctx)
+	defer agentRetentionWorker.Stop()
+
+	// Back the database up to the backup target on a schedule
+	var backupWorker usecase.BackupWorker
+	if config.Backup.IntervalHours > 0 {
+		backupWorker = usecase.NewBackupWorker(newBackupUsecase(config, db), time.Duration(config.Backup.IntervalHours)*time.Hour)
+		backupWorker.Start(ctx)
+		defer backupWorker.Stop()
+	}
+
+	// Email the project digests users subscribed to
+	var digestWorker usecase.DigestWorker
+	if mailer != nil {
+		digestWorker = usecase.NewDigestWorker(digestUsecase, time.Duration(config.Digest.CheckIntervalMinutes)*time.Minute)
+		digestWorker.Start(ctx)
+		defer digestWorker.Stop()
+	} else {
+		infrastructure.ServerLogger.Info("Email digests are off, set HASHCAT_SMTP_HOST to send them")
+	}
+
+	// Start burst agents in the cloud when the job queue backs up
+	var autoScaler usecase.AutoScaler
+	if config.Autoscale.Enabled {
+		provider, err := cloud.NewProvider(config.Autoscale.Config)
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to set up auto-scaling: %v", err)
+		}
+		autoScaler, err = usecase.NewAutoScaler(provider, cloudInstanceRepo, agentUsecase, jobUsecase, usecase.AutoScaleConfig{
+			CheckInterval:      time.Duration(config.Autoscale.CheckIntervalSeconds) * time.Second,
+			QueueThreshold:     config.Autoscale.QueueThreshold,
+			IdleTimeout:        time.Duration(config.Autoscale.IdleMinutes) * time.Minute,
+			BootTimeout:        time.Duration(config.Autoscale.BootTimeoutMinutes) * time.Minute,
+			MaxInstances:       config.Autoscale.MaxInstances,
+			InstanceHourlyCost: config.Autoscale.InstanceHourlyCost,
+			MaxHourlyCost:      config.Autoscale.MaxHourlyCost,
+			ServerURL:          config.Autoscale.ServerURL,
+			AgentDownloadURL:   config.Autoscale.AgentDownloadURL,
+		})
+		if err != nil {
+			infrastructure.ServerLogger.Fatal("Failed to set up auto-scaling: %v", err)
+		}
+		autoScaler.Start(ctx)
+		defer autoScaler.Stop()
+	}
+
 	// Start server in a goroutine
 	go func() {
 		infrastructure.ServerLogger.Info("Server starting on %s:%d", config.Server.Host, config.Server.Port)
@@ -367,16 +1392,55 @@ func startServer() {
 
 	infrastructure.ServerLogger.Info("Shutting down server...")
 
-	// Stop health monitor
-	healthMonitor.Stop()
-
-	// Shutdown server with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Everything below shares one drain deadline
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(config.Server.ShutdownTimeoutSeconds)*time.Second)
 	defer shutdownCancel()
 
+	stopDemo()
+	retentionWorker.Stop()
+	agentRetentionWorker.Stop()
+	hashFileOperationUsecase.Stop()
+	wordlistSourceUsecase.Stop()
+	if backupWorker != nil {
+		backupWorker.Stop()
+	}
+	if digestWorker != nil {
+		digestWorker.Stop()
+	}
+	if autoScaler != nil {
+		autoScaler.Stop()
+	}
+
+	// A last health check, so clients and the database see current agent
+	// statuses
+	healthMonitor.Shutdown(shutdownCtx)
+
+	// Realtime clients hold their connections open, so they are closed once
+	// the server stops accepting new ones rather than waited for
+	hubClosed := make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		defer close(hubClosed)
+		if err := wsHub.Shutdown(shutdownCtx); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to close realtime connections: %v", err)
+		}
+	})
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		infrastructure.ServerLogger.Error("Server forced to shutdown: %v", err)
 	}
+	<-hubClosed
+
+	// Write the reports buffered by the last requests
+	stopFlusher()
+	flushers.Wait()
+	if err := db.FlushWrites(shutdownCtx); err != nil {
+		infrastructure.ServerLogger.Error("Failed to write queued database writes: %v", err)
+	}
+
+	// Flush the spans of the last requests
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to flush traces: %v", err)
+	}
 
 	infrastructure.ServerLogger.Info("Server exited")
 }
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/google/uuid"
+)
+
+// capabilities are what virtual agents report. They must not start with
+// domain.SimulatedAgentCapabilities, or a server in demo mode would play
+// these agents itself.
+const capabilities = "virtual GPU (hashcat-simulator)"
+
+// warmup is the share of a job during which the speed climbs to full, as
+// hashcat's does while it autotunes
+const warmup = 0.05
+
+// Outcomes of a simulated job
+const (
+	outcomeExhausted = iota
+	outcomeCracked
+	outcomeFailed
+)
+
+// virtualAgent is one simulated agent
+type virtualAgent struct {
+	api    *client.Client
+	cfg    config
+	stats  *stats
+	logger *infrastructure.Logger
+	rng    *rand.Rand
+
+	name  string
+	key   string
+	ip    string
+	speed int64
+	id    uuid.UUID
+
+	// What the heartbeat reports
+	mu       sync.Mutex
+	jobID    *uuid.UUID
+	progress float64
+	jobSpeed int64
+}
+
+func newVirtualAgent(api *client.Client, cfg config, index int, stats *stats) *virtualAgent {
+	name := fmt.Sprintf("%s-%04d", cfg.NamePrefix, index+1)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(index)))
+	return &virtualAgent{
+		api:    api,
+		cfg:    cfg,
+		stats:  stats,
+		logger: logger.With("agent", name),
+		rng:    rng,
+		name:   name,
+		key:    agentKey(name),
+		// Addresses of the benchmarking range, which nothing answers on
+		ip:    fmt.Sprintf("198.18.%d.%d", index/250, index%250+1),
+		speed: int64(float64(cfg.Speed) * (0.5 + rng.Float64())),
+	}
+}
+
+// agentKey derives the key of a virtual agent from its name, so another run
+// with the same names takes the agents over instead of adding new ones
+func agentKey(name string) string {
+	sum := sha256.Sum256([]byte("hashcat-simulator/" + name))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// run registers the agent and works until ctx is done, then hands back its
+// job and goes offline
+func (a *virtualAgent) run(ctx context.Context) {
+	for {
+		err := a.register(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		a.logger.Warning("Failed to register, retrying: %v", err)
+		if !sleep(ctx, a.cfg.PollInterval) {
+			return
+		}
+	}
+	a.stats.agentOnline(1)
+	defer a.stats.agentOnline(-1)
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(context.Background())
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		a.sendHeartbeats(heartbeatCtx)
+	}()
+
+	for sleep(ctx, a.cfg.PollInterval) {
+		job, err := a.api.GetAvailableJob(ctx, a.id)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Warning("Failed to poll for jobs: %v", err)
+			}
+			continue
+		}
+		if job != nil {
+			a.work(ctx, job)
+		}
+	}
+
+	stopHeartbeat()
+	<-heartbeatDone
+	offlineCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.api.SetAgentOffline(offlineCtx, a.id); err != nil {
+		a.logger.Warning("Failed to go offline: %v", err)
+	}
+}
+
+// register reserves the agent's key, or takes over the agent of an earlier
+// run, and reports its address, capabilities and speed
+func (a *virtualAgent) register(ctx context.Context) error {
+	agent, err := a.api.GenerateAgentKey(ctx, a.name, a.key)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		if agent, err = a.api.GetAgentByKey(ctx, a.key); err != nil {
+			return fmt.Errorf("agent name %s is taken by an agent the simulator didn't create", a.name)
+		}
+	} else if err != nil {
+		return err
+	}
+	a.id = agent.ID
+
+	if err := a.api.UpdateAgentData(ctx, client.UpdateAgentDataRequest{AgentKey: a.key, IPAddress: a.ip, Port: 8080, Capabilities: capabilities}); err != nil {
+		return err
+	}
+	if err := a.api.UpdateAgentSpeed(ctx, a.id, a.speed); err != nil {
+		return err
+	}
+	return a.api.UpdateAgentStatus(ctx, a.id, "online")
+}
+
+// sendHeartbeats sends heartbeats at the interval the server asks for
+func (a *virtualAgent) sendHeartbeats(ctx context.Context) {
+	interval := time.Second
+	for {
+		a.mu.Lock()
+		snapshot := &client.AgentHeartbeat{
+			CurrentJobID:     a.jobID,
+			JobProgress:      a.progress,
+			JobSpeed:         a.jobSpeed,
+			GPUUtilization:   0,
+			FreeDiskBytes:    512 << 30,
+			HashcatAvailable: true,
+			HashcatVersion:   "v6.2.6",
+			Engines:          []string{domain.EngineHashcat},
+		}
+		if a.jobID != nil {
+			snapshot.GPUUtilization = 95 + rand.Intn(5)
+		}
+		a.mu.Unlock()
+
+		resp, err := a.api.Heartbeat(ctx, client.HeartbeatRequest{AgentKey: a.key, Snapshot: snapshot})
+		switch {
+		case err != nil && ctx.Err() == nil:
+			a.logger.Warning("Heartbeat failed: %v", err)
+		case err == nil && resp.IntervalSeconds > 0:
+			interval = time.Duration(resp.IntervalSeconds) * time.Second
+		}
+		if !sleep(ctx, interval) {
+			return
+		}
+	}
+}
+
+// work runs a job: progress follows hashcat's curve, a speed that climbs
+// while it autotunes and then holds with some jitter, and the job cracks,
+// fails or is exhausted at random
+func (a *virtualAgent) work(ctx context.Context, job *client.Job) {
+	logger := a.logger.With("job_id", job.ID)
+	if err := a.api.UpdateAgentStatus(ctx, a.id, "busy"); err != nil {
+		logger.Warning("Failed to update status: %v", err)
+	}
+	defer func() {
+		a.setJob(nil, 0, 0)
+		if ctx.Err() == nil {
+			if err := a.api.UpdateAgentStatus(ctx, a.id, "online"); err != nil {
+				logger.Warning("Failed to update status: %v", err)
+			}
+		}
+	}()
+
+	if err := a.api.StartJob(ctx, job.ID); err != nil {
+		logger.Warning("Failed to start job: %v", err)
+		return
+	}
+	a.stats.jobStarted()
+	logger.Info("Started job %s", job.Name)
+
+	duration := time.Duration(float64(a.cfg.JobDuration) * (0.5 + a.rng.Float64()))
+	outcome, end := outcomeExhausted, 1.0
+	switch roll := a.rng.Float64(); {
+	case roll < a.cfg.CrackProbability:
+		outcome, end = outcomeCracked, 0.05+0.95*a.rng.Float64()
+	case roll < a.cfg.CrackProbability+a.cfg.FailProbability:
+		outcome, end = outcomeFailed, 0.5*a.rng.Float64()
+	}
+	start := job.Progress / 100
+
+	ticker := time.NewTicker(a.cfg.StatusInterval)
+	defer ticker.Stop()
+	var elapsed time.Duration
+	for {
+		select {
+		case <-ctx.Done():
+			a.handOff(job)
+			return
+		case <-ticker.C:
+		}
+
+		current, err := a.api.GetJob(ctx, job.ID)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.Warning("Failed to check job status: %v", err)
+			}
+			continue
+		case current.Status == domain.JobStatusPaused:
+			continue // The clock stops until the job resumes
+		case current.Status != domain.JobStatusRunning:
+			logger.Info("Job %s is %s, dropping it", job.Name, current.Status)
+			return
+		}
+
+		elapsed += a.cfg.StatusInterval
+		x := min(start+elapsed.Seconds()/duration.Seconds(), end)
+		progress := 100 * progressAt(x)
+		speed := int64(float64(a.speed) * (1 - math.Exp(-x/warmup)) * (0.97 + 0.06*a.rng.Float64()))
+		a.setJob(&job.ID, progress, speed)
+
+		if x < end {
+			if err := a.api.UpdateProgress(ctx, job.ID, progress, speed); err != nil && ctx.Err() == nil {
+				logger.Warning("Failed to report progress: %v", err)
+			}
+			continue
+		}
+		a.finish(ctx, job, outcome, speed)
+		return
+	}
+}
+
+// progressAt is the share of the keyspace searched at x, the share of the
+// job's time gone by, with the slow start of the warm-up
+func progressAt(x float64) float64 {
+	searched := func(x float64) float64 { return x - warmup*(1-math.Exp(-x/warmup)) }
+	return searched(x) / searched(1)
+}
+
+// finish reports the outcome of a job
+func (a *virtualAgent) finish(ctx context.Context, job *client.Job, outcome int, speed int64) {
+	logger := a.logger.With("job_id", job.ID)
+	var err error
+	switch outcome {
+	case outcomeCracked:
+		password := fmt.Sprintf("Simulated%04d!", a.rng.Intn(10000))
+		err = a.api.CompleteJob(ctx, job.ID, "Password found: "+password, nil)
+		logger.Success("Job %s cracked: %s", job.Name, password)
+	case outcomeFailed:
+		err = a.api.FailJob(ctx, job.ID, "hashcat exited with code 255: simulated failure", nil)
+		logger.Warning("Job %s failed", job.Name)
+	default:
+		err = a.api.CompleteJob(ctx, job.ID, domain.JobResultExhausted, nil)
+		logger.Info("Job %s exhausted", job.Name)
+	}
+	if err != nil {
+		logger.Warning("Failed to report the end of job %s: %v", job.Name, err)
+		return
+	}
+	a.stats.jobFinished(outcome)
+}
+
+// handOff gives a job back to the server when the simulator stops, as an
+// agent that shuts down does, so another agent takes it over
+func (a *virtualAgent) handOff(job *client.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := domain.InterruptJobRequest{Reason: "agent " + a.name + " shut down"}
+	if err := a.api.Do(ctx, http.MethodPost, "/api/v1/jobs/"+job.ID.String()+"/interrupt", req, nil); err != nil {
+		a.logger.Warning("Failed to hand off job %s: %v", job.Name, err)
+	}
+}
+
+func (a *virtualAgent) setJob(jobID *uuid.UUID, progress float64, speed int64) {
+	a.mu.Lock()
+	a.jobID, a.progress, a.jobSpeed = jobID, progress, speed
+	a.mu.Unlock()
+}
+
+// sleep waits d, reporting false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/pkg/client"
+)
+
+// queueJobs uploads a hash file and a wordlist of made-up passwords and
+// queues count dictionary jobs on them, named after prefix
+func queueJobs(ctx context.Context, api *client.Client, prefix string, count int) error {
+	var hashes, words strings.Builder
+	for i := 0; i < 1000; i++ {
+		password := fmt.Sprintf("Simulated%04d!", i)
+		sum := md5.Sum([]byte(password))
+		hashes.WriteString(hex.EncodeToString(sum[:]) + "\n")
+		words.WriteString(password + "\n")
+	}
+
+	hashFile, err := api.UploadHashFile(ctx, prefix+"-hashes.txt", strings.NewReader(hashes.String()))
+	if err != nil {
+		return fmt.Errorf("failed to upload the hash file: %w", err)
+	}
+	wordlist, err := api.UploadWordlist(ctx, prefix+"-wordlist.txt", strings.NewReader(words.String()))
+	if err != nil {
+		return fmt.Errorf("failed to upload the wordlist: %w", err)
+	}
+
+	for i := 0; i < count; i++ {
+		_, err := api.CreateJob(ctx, client.CreateJobRequest{
+			Name:       fmt.Sprintf("%s-job-%04d", prefix, i+1),
+			HashType:   0,
+			AttackMode: domain.AttackModeStraight,
+			HashFileID: hashFile.ID.String(),
+			Wordlist:   wordlist.OrigName,
+			WordlistID: wordlist.ID.String(),
+			Tags:       []string{"simulator"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create job %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Command hashcat-simulator runs virtual agents against a server. They
+// speak the agents' API, so the server, its WebSocket hub and the scheduler
+// can be load tested without GPUs: every agent registers, sends heartbeats,
+// polls for jobs and reports progress, and cracks or exhausts its jobs at
+// random.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var logger = infrastructure.NewLogger("SIMULATOR")
+
+// config is what the flags set
+type config struct {
+	Server           string
+	Token            string
+	Agents           int
+	NamePrefix       string
+	Ramp             time.Duration
+	Duration         time.Duration
+	Speed            int64
+	JobDuration      time.Duration
+	CrackProbability float64
+	FailProbability  float64
+	PollInterval     time.Duration
+	StatusInterval   time.Duration
+	AssignInterval   time.Duration
+	Jobs             int
+	WebSockets       int
+	ReportInterval   time.Duration
+}
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:          "hashcat-simulator",
+		Short:        "Run virtual agents against a server for load and integration testing",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(config{
+				Server:           viper.GetString("server"),
+				Token:            viper.GetString("token"),
+				Agents:           viper.GetInt("agents"),
+				NamePrefix:       viper.GetString("name-prefix"),
+				Ramp:             viper.GetDuration("ramp"),
+				Duration:         viper.GetDuration("duration"),
+				Speed:            viper.GetInt64("speed"),
+				JobDuration:      viper.GetDuration("job-duration"),
+				CrackProbability: viper.GetFloat64("crack-probability"),
+				FailProbability:  viper.GetFloat64("fail-probability"),
+				PollInterval:     viper.GetDuration("poll-interval"),
+				StatusInterval:   viper.GetDuration("status-interval"),
+				AssignInterval:   viper.GetDuration("assign-interval"),
+				Jobs:             viper.GetInt("jobs"),
+				WebSockets:       viper.GetInt("websockets"),
+				ReportInterval:   viper.GetDuration("report-interval"),
+			})
+		},
+	}
+
+	flags := rootCmd.Flags()
+	flags.String("server", "http://localhost:1337", "Server URL")
+	flags.String("token", "", "Bearer token for authenticated endpoints")
+	flags.IntP("agents", "n", 10, "Number of virtual agents")
+	flags.String("name-prefix", "sim", "Agent names are the prefix and a number, e.g. sim-0001")
+	flags.Duration("ramp", 10*time.Second, "Spread the agents' start over this long")
+	flags.Duration("duration", 0, "Stop after this long (0 runs until interrupted)")
+	flags.Int64("speed", 10_000_000_000, "Average agent speed in H/s; each agent differs by up to 50%")
+	flags.Duration("job-duration", 2*time.Minute, "Average time a job takes; each job differs by up to 50%")
+	flags.Float64("crack-probability", 0.3, "Chance that a job cracks a hash")
+	flags.Float64("fail-probability", 0.02, "Chance that a job fails")
+	flags.Duration("poll-interval", 5*time.Second, "How often idle agents poll for jobs")
+	flags.Duration("status-interval", 5*time.Second, "How often busy agents report progress and check the job's status")
+	flags.Duration("assign-interval", 10*time.Second, "How often to have the scheduler assign pending jobs (0 leaves it to others)")
+	flags.Int("jobs", 0, "Queue this many synthetic jobs at start")
+	flags.Int("websockets", 0, "Number of WebSocket clients subscribed to the hub")
+	flags.Duration("report-interval", 30*time.Second, "How often to print request statistics")
+
+	viper.BindPFlags(flags)
+	viper.BindEnv("server", "HASHCAT_SERVER_URL")
+	viper.BindEnv("token", "HASHCAT_TOKEN")
+
+	if err := rootCmd.Execute(); err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config) error {
+	if cfg.Agents < 0 || cfg.Jobs < 0 || cfg.WebSockets < 0 {
+		return fmt.Errorf("--agents, --jobs and --websockets cannot be negative")
+	}
+	if cfg.CrackProbability < 0 || cfg.FailProbability < 0 || cfg.CrackProbability+cfg.FailProbability > 1 {
+		return fmt.Errorf("--crack-probability and --fail-probability must be between 0 and 1 and add up to at most 1")
+	}
+	if cfg.PollInterval <= 0 || cfg.StatusInterval <= 0 || cfg.JobDuration <= 0 {
+		return fmt.Errorf("--poll-interval, --status-interval and --job-duration must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	stats := newStats()
+	api := client.New(cfg.Server,
+		client.WithToken(cfg.Token),
+		client.WithUserAgent("hashcat-simulator"),
+		client.WithHTTPClient(stats.httpClient(30*time.Second)))
+
+	if cfg.Jobs > 0 {
+		if err := queueJobs(ctx, api, cfg.NamePrefix, cfg.Jobs); err != nil {
+			return err
+		}
+		logger.Success("Queued %d synthetic jobs", cfg.Jobs)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.WebSockets; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			subscribe(ctx, cfg.Server, stats)
+		}()
+	}
+	if cfg.AssignInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assignJobs(ctx, api, cfg.AssignInterval)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stats.reportEvery(ctx, cfg.ReportInterval)
+	}()
+
+	logger.Info("Starting %d virtual agents against %s", cfg.Agents, cfg.Server)
+	var agents sync.WaitGroup
+	for i := 0; i < cfg.Agents; i++ {
+		agent := newVirtualAgent(api, cfg, i, stats)
+		agents.Add(1)
+		go func(delay time.Duration) {
+			defer agents.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			agent.run(ctx)
+		}(time.Duration(i) * cfg.Ramp / time.Duration(max(cfg.Agents, 1)))
+	}
+
+	<-ctx.Done()
+	logger.Info("Stopping virtual agents")
+	agents.Wait()
+	wg.Wait()
+	stats.print(os.Stdout)
+	return nil
+}
+
+// assignJobs has the scheduler hand out pending jobs until ctx is done, as
+// pressing "assign" in the dashboard would
+func assignJobs(ctx context.Context, api *client.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := api.AssignJobs(ctx); err != nil && ctx.Err() == nil {
+				logger.Warning("Failed to assign jobs: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxSamples is how many latencies are kept per route; beyond that a
+// random sample of them is
+const maxSamples = 10000
+
+// routeStats are the requests sent to one route
+type routeStats struct {
+	count     int
+	errors    int
+	latencies []time.Duration
+}
+
+// stats counts what the simulator did and how fast the server answered
+type stats struct {
+	mu      sync.Mutex
+	started time.Time
+	routes  map[string]*routeStats
+
+	agents         int
+	jobs           int
+	cracked        int
+	exhausted      int
+	failed         int
+	websockets     int
+	wsMessages     int
+	wsMessageTypes map[string]int
+}
+
+func newStats() *stats {
+	return &stats{started: time.Now(), routes: make(map[string]*routeStats), wsMessageTypes: make(map[string]int)}
+}
+
+// httpClient returns an HTTP client that times every request
+func (s *stats) httpClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Every virtual agent talks to the same server
+	transport.MaxIdleConnsPerHost = 1000
+	return &http.Client{Timeout: timeout, Transport: &timedTransport{next: transport, stats: s}}
+}
+
+// timedTransport records the latency and outcome of each request
+type timedTransport struct {
+	next  http.RoundTripper
+	stats *stats
+}
+
+func (t *timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+	t.stats.request(req.Method+" "+route(req.URL.Path), time.Since(start), failed)
+	return resp, err
+}
+
+// route replaces the IDs in a path, so requests for different jobs and
+// agents count as one route
+func route(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := uuid.Parse(segment); err == nil {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *stats) request(route string, latency time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.routes[route]
+	if r == nil {
+		r = &routeStats{}
+		s.routes[route] = r
+	}
+	r.count++
+	if failed {
+		r.errors++
+	}
+	if len(r.latencies) < maxSamples {
+		r.latencies = append(r.latencies, latency)
+	} else if i := rand.Intn(r.count); i < maxSamples {
+		r.latencies[i] = latency
+	}
+}
+
+func (s *stats) agentOnline(delta int) {
+	s.mu.Lock()
+	s.agents += delta
+	s.mu.Unlock()
+}
+
+func (s *stats) jobStarted() {
+	s.mu.Lock()
+	s.jobs++
+	s.mu.Unlock()
+}
+
+func (s *stats) jobFinished(outcome int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch outcome {
+	case outcomeCracked:
+		s.cracked++
+	case outcomeFailed:
+		s.failed++
+	default:
+		s.exhausted++
+	}
+}
+
+func (s *stats) websocketConnected(delta int) {
+	s.mu.Lock()
+	s.websockets += delta
+	s.mu.Unlock()
+}
+
+func (s *stats) websocketMessage(messageType string) {
+	s.mu.Lock()
+	s.wsMessages++
+	s.wsMessageTypes[messageType]++
+	s.mu.Unlock()
+}
+
+// reportEvery prints the statistics at interval until ctx is done
+func (s *stats) reportEvery(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var report strings.Builder
+			s.print(&report)
+			logger.Info("Statistics:\n%s", report.String())
+		}
+	}
+}
+
+// print writes the totals and the latency of each route
+func (s *stats) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.started)
+	fmt.Fprintf(w, "After %v: %d agents online, %d jobs started, %d cracked, %d exhausted, %d failed\n",
+		elapsed.Round(time.Second), s.agents, s.jobs, s.cracked, s.exhausted, s.failed)
+	if s.websockets > 0 || s.wsMessages > 0 {
+		var types []string
+		for messageType, count := range s.wsMessageTypes {
+			types = append(types, fmt.Sprintf("%s %d", messageType, count))
+		}
+		sort.Strings(types)
+		fmt.Fprintf(w, "WebSocket: %d clients connected, %d messages (%s)\n", s.websockets, s.wsMessages, strings.Join(types, ", "))
+	}
+
+	names := make([]string, 0, len(s.routes))
+	for name := range s.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUTE\tREQUESTS\tREQ/S\tERRORS\tP50\tP95\tP99\tMAX")
+	for _, name := range names {
+		r := s.routes[name]
+		sorted := append([]time.Duration(nil), r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%v\t%v\t%v\t%v\n", name, r.count, float64(r.count)/elapsed.Seconds(), r.errors,
+			percentile(sorted, 0.50), percentile(sorted, 0.95), percentile(sorted, 0.99), percentile(sorted, 1))
+	}
+	tw.Flush()
+}
+
+// percentile returns the latency below which share p of sorted lies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i].Round(100 * time.Microsecond)
+}
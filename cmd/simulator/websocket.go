@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsMessage mirrors handler.WebSocketMessage as sent by the server hub
+type wsMessage struct {
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp string          `json:"timestamp"`
+}
+
+// subscribe keeps a WebSocket connection to the hub open and counts what it
+// receives, reconnecting until ctx is done
+func subscribe(ctx context.Context, server string, stats *stats) {
+	wsURL, err := websocketURL(server)
+	if err != nil {
+		logger.Error("%v", err)
+		return
+	}
+
+	for {
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warning("WebSocket: %v", err)
+			}
+		} else {
+			stats.websocketConnected(1)
+			closed := make(chan struct{})
+			go func() {
+				select {
+				case <-ctx.Done():
+				case <-closed:
+				}
+				conn.Close()
+			}()
+			for {
+				var msg wsMessage
+				if err := conn.ReadJSON(&msg); err != nil {
+					break
+				}
+				stats.websocketMessage(msg.Type)
+			}
+			close(closed)
+			stats.websocketConnected(-1)
+		}
+
+		if !sleep(ctx, 3*time.Second) {
+			return
+		}
+	}
+}
+
+func websocketURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/ws"
+	return u.String(), nil
+}
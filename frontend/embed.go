@@ -0,0 +1,23 @@
+//go:build embedui
+
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dist is the production build of the web UI, made with npm run build
+// before the server is built with the embedui tag
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Dist returns the web UI built into the binary
+func Dist() fs.FS {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	return files
+}
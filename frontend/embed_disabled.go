@@ -0,0 +1,12 @@
+//go:build !embedui
+
+// Package frontend holds the web UI. Servers built with the embedui tag
+// carry its production build; others serve it from frontend/dist on disk.
+package frontend
+
+import "io/fs"
+
+// Dist returns nil, this binary was built without the web UI
+func Dist() fs.FS {
+	return nil
+}
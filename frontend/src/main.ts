@@ -45,7 +45,7 @@ interface Agent {
 interface Job {
     id: string
     name: string
-    status: 'pending' | 'running' | 'completed' | 'failed' | 'paused' | 'cancelled'
+    status: 'pending' | 'assigned' | 'running' | 'completed' | 'cracked' | 'failed' | 'paused' | 'cancelled' | 'expired'
     progress?: number
     hash_file_name?: string
     hash_file_id?: string
@@ -458,6 +458,7 @@ class DashboardApplication {
             showAgentNameError: false,
             deleteModalConfig: { entityType: '', entityName: '', description: '', warning: '', entityId: '', confirmAction: null as any },
             jobForm: { name: '', hash_file_id: '', wordlist_id: '', agent_ids: [] as string[], hash_type: '', attack_mode: '' },
+            wordlistRecommendations: [] as any[],
             distributedJobForm: { name: '', hash_file_id: '', wordlist_id: '', hash_type: '', attack_mode: '', auto_distribute: true },
             fileForm: { file: null },
             wordlistForm: { file: null as File | null },
@@ -1454,7 +1455,9 @@ class DashboardApplication {
                     // Show notification for important status changes
                     const job = this.jobs.find(j => j.id === update.job_id)
                     if (job) {
-                        if (update.status === 'completed') {
+                        if (update.status === 'cracked') {
+                            this.showNotification(`Job "${job.name}" cracked!`, 'success')
+                        } else if (update.status === 'completed') {
                             this.showNotification(`Job "${job.name}" completed!`, 'success')
                         } else if (update.status === 'failed') {
                             this.showNotification(`Job "${job.name}" failed`, 'error')
@@ -2176,6 +2179,7 @@ class DashboardApplication {
                     hash_type: '2500', 
                     attack_mode: '0' 
                 }
+                this.wordlistRecommendations = []
                 // Clear command template
                 this.commandTemplate = 'hashcat command will appear here...'
                 console.log('🔓 Job modal opened with fresh form')
@@ -2435,6 +2439,29 @@ class DashboardApplication {
                 }
             },
 
+            // Rank wordlists for the selected hash file by what cracked
+            // hash files of the same origin before
+            async loadWordlistRecommendations() {
+                this.wordlistRecommendations = []
+                if (!this.jobForm.hash_file_id) {
+                    return
+                }
+                const hashFileId = this.jobForm.hash_file_id
+                const recommendations = await apiService.getWordlistRecommendations(this.jobForm.hash_type || '2500', hashFileId)
+                // The form runs plain dictionary attacks, so combinations with
+                // rules are left out
+                if (this.jobForm.hash_file_id === hashFileId) {
+                    this.wordlistRecommendations = recommendations
+                        .filter((r: any) => !r.rules && this.wordlists.some((w: any) => w.id === r.wordlist_id))
+                        .slice(0, 3)
+                }
+            },
+
+            applyWordlistRecommendation(recommendation: any) {
+                this.jobForm.wordlist_id = recommendation.wordlist_id
+                this.handleWordlistChange()
+            },
+
             // Handle wordlist change and validate agent selection
             handleWordlistChange() {
                 // Check if wordlist is too small and reset agent selection if needed
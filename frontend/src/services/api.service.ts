@@ -23,7 +23,7 @@ export interface Job {
     wordlist_id?: string     // Changed from wordlist
     hash_type: number
     attack_mode: number
-    status: 'pending' | 'running' | 'completed' | 'failed' | 'cancelled' | 'paused'
+    status: 'pending' | 'assigned' | 'running' | 'completed' | 'cracked' | 'failed' | 'cancelled' | 'expired' | 'paused'
     created_at: string
     started_at?: string
     completed_at?: string
@@ -47,9 +47,24 @@ export interface HashFile {
     path?: string
     size: number
     type: string
+    source?: string          // Where the hashes come from, e.g. wifi or ad
     created_at: string
 }
 
+export interface WordlistRecommendation {
+    wordlist_id: string
+    wordlist: string
+    rules?: string
+    attacks: number
+    cracked: number
+    cracks: number
+    crack_rate: number
+    source_attacks: number
+    source_cracked: number
+    score: number
+    reason: string
+}
+
 export interface Wordlist {
     id: string
     name: string
@@ -402,6 +417,20 @@ class ApiService {
     }
 
     // Wordlist Management
+    // Wordlists that cracked the hash mode before, best first, favouring
+    // those that cracked hash files of the same origin
+    public async getWordlistRecommendations(hashType: string, hashFileId?: string): Promise<WordlistRecommendation[]> {
+        const params = new URLSearchParams({ hash_type: hashType })
+        if (hashFileId) {
+            params.set('hash_file_id', hashFileId)
+        }
+        const response = await this.get<{data: {recommendations: WordlistRecommendation[]}}>(`/api/v1/jobs/recommendations?${params}`)
+        if (response.success && response.data && response.data.data) {
+            return response.data.data.recommendations || []
+        }
+        return []
+    }
+
     public async getWordlists(): Promise<Wordlist[]> {
         const response = await this.get<{data: Wordlist[]}>('/api/v1/wordlists/')
         if (response.success && response.data && response.data.data) {
@@ -6,6 +6,7 @@ class AuthService {
     private baseUrl: string
     private config = getConfig()
     private tokenKey = 'hashcat_auth_token'
+    private refreshTokenKey = 'hashcat_auth_refresh_token'
     private userKey = 'hashcat_auth_user'
 
     constructor() {
@@ -65,17 +66,18 @@ class AuthService {
         })
 
         if (response.success && response.data) {
-            // Store token and user data
+            // Store tokens and user data
             this.setToken(response.data.token)
+            this.setRefreshToken(response.data.refresh_token)
             this.setUser(response.data.user)
         }
 
         return response
     }
 
-    // Logout user
+    // Logout user, revoking the session on the server
     public async logout(): Promise<{ success: boolean; error?: string }> {
-        const token = this.getToken()
+        const token = this.getRefreshToken() || this.getToken()
         if (!token) {
             return { success: true }
         }
@@ -104,21 +106,22 @@ class AuthService {
         })
     }
 
-    // Refresh token
+    // Refresh token, exchanging the refresh token for new tokens
     public async refreshToken(): Promise<{ success: boolean; data?: LoginResponse; error?: string }> {
-        const token = this.getToken()
-        if (!token) {
-            return { success: false, error: 'No token found' }
+        const refreshToken = this.getRefreshToken()
+        if (!refreshToken) {
+            return { success: false, error: 'No refresh token found' }
         }
 
         const response = await this.request<LoginResponse>('/api/v1/auth/refresh', {
             method: 'POST',
-            body: JSON.stringify({ token })
+            body: JSON.stringify({ refresh_token: refreshToken })
         })
 
         if (response.success && response.data) {
-            // Update stored token and user data
+            // Update stored tokens and user data, the old refresh token no longer works
             this.setToken(response.data.token)
+            this.setRefreshToken(response.data.refresh_token)
             this.setUser(response.data.user)
         }
 
@@ -138,6 +141,20 @@ class AuthService {
         localStorage.removeItem(this.tokenKey)
     }
 
+    public getRefreshToken(): string | null {
+        return localStorage.getItem(this.refreshTokenKey)
+    }
+
+    public setRefreshToken(token?: string): void {
+        if (token) {
+            localStorage.setItem(this.refreshTokenKey, token)
+        }
+    }
+
+    public clearRefreshToken(): void {
+        localStorage.removeItem(this.refreshTokenKey)
+    }
+
     // User management
     public getUser(): User | null {
         const userStr = localStorage.getItem(this.userKey)
@@ -161,6 +178,7 @@ class AuthService {
     // Clear all auth data
     public clearAuth(): void {
         this.clearToken()
+        this.clearRefreshToken()
         this.clearUser()
     }
 
@@ -171,8 +189,8 @@ class AuthService {
         return !!(token && user)
     }
 
-    // Check if token is expired (basic check)
-    public isTokenExpired(): boolean {
+    // Check if token is expired, or expires within leewaySeconds (basic check)
+    public isTokenExpired(leewaySeconds = 0): boolean {
         const token = this.getToken()
         if (!token || token.trim() === '') return true
 
@@ -184,7 +202,7 @@ class AuthService {
             // Decode JWT payload (basic implementation)
             const payload = JSON.parse(atob(parts[1]))
             const now = Math.floor(Date.now() / 1000)
-            return payload.exp < now
+            return payload.exp < now + leewaySeconds
         } catch {
             return true
         }
@@ -22,7 +22,9 @@ class AuthStore {
         const user = authService.getUser()
         
         // More strict validation - check if token exists, is valid, and not expired
-        if (token && user && !authService.isTokenExpired() && token.trim() !== '') {
+        // or refreshable
+        const refreshable = !!authService.getRefreshToken()
+        if (token && user && (!authService.isTokenExpired() || refreshable) && token.trim() !== '') {
             this.state = {
                 isAuthenticated: true,
                 user,
@@ -43,6 +45,11 @@ class AuthStore {
         }
         
         this.notifyListeners()
+
+        // Access tokens are short-lived, get a new one for a session that outlived it
+        if (this.state.isAuthenticated && authService.isTokenExpired()) {
+            this.refreshToken()
+        }
     }
 
     // Login user
@@ -186,10 +193,11 @@ class AuthStore {
 
     // Auto-refresh token before expiration
     public startTokenRefresh(): void {
-        const refreshInterval = 5 * 60 * 1000 // 5 minutes
+        const refreshInterval = 60 * 1000 // 1 minute
+        const refreshLeeway = 2 * 60 // Refresh access tokens 2 minutes before they expire
         
         setInterval(async () => {
-            if (this.isAuthenticated() && authService.isTokenExpired()) {
+            if (this.isAuthenticated() && authService.isTokenExpired(refreshLeeway)) {
                 await this.refreshToken()
             }
         }, refreshInterval)
@@ -109,7 +109,7 @@ export class DashboardStore {
         this.state.stats = {
             onlineAgents: this.state.agents.filter(a => a.status === 'online').length,
             runningJobs: this.state.jobs.filter(j => j.status === 'running').length,
-            completedJobs: this.state.jobs.filter(j => j.status === 'completed').length,
+            completedJobs: this.state.jobs.filter(j => j.status === 'completed' || j.status === 'cracked').length,
             hashFiles: this.state.hashFiles.length
         }
     }
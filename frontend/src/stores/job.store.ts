@@ -268,7 +268,7 @@ class JobStore {
             const jobs = this.state.jobs
             const jobIndex = jobs.findIndex(job => job.id === jobId)
             if (jobIndex !== -1) {
-                const validStatuses = ['pending', 'running', 'completed', 'failed', 'cancelled', 'paused']
+                const validStatuses = ['pending', 'assigned', 'running', 'completed', 'cracked', 'failed', 'cancelled', 'expired', 'paused']
                 const updatedJob = { 
                     ...jobs[jobIndex], 
                     progress,
@@ -281,14 +281,14 @@ class JobStore {
                 this.setState({ jobs: newJobs })
 
                 // Auto-fetch job result when progress reaches 100% and job is completed
-                if (progress >= 100 && (status === 'completed' || updatedJob.status === 'completed')) {
+                if (progress >= 100 && ['completed', 'cracked'].includes(status || updatedJob.status)) {
                     try {
                         // Fetch the complete job data including result
                         const completeJob = await apiService.getJob(jobId)
                         if (completeJob && completeJob.result) {
                             // Update the job with the fetched result
                             const updatedJobsWithResult = this.state.jobs.map(job => 
-                                job.id === jobId ? { ...job, result: completeJob.result, status: 'completed' as Job['status'] } : job
+                                job.id === jobId ? { ...job, result: completeJob.result, status: completeJob.status as Job['status'] } : job
                             )
                             this.setState({ jobs: updatedJobsWithResult })
                             
@@ -306,19 +306,19 @@ class JobStore {
             const jobs = this.state.jobs
             const jobIndex = jobs.findIndex(job => job.id === jobId)
             if (jobIndex !== -1) {
-                const validStatuses = ['pending', 'running', 'completed', 'failed', 'cancelled', 'paused']
+                const validStatuses = ['pending', 'assigned', 'running', 'completed', 'cracked', 'failed', 'cancelled', 'expired', 'paused']
                 const updatedJob = { 
                     ...jobs[jobIndex], 
                     status: validStatuses.includes(status) ? status as Job['status'] : jobs[jobIndex].status,
                     result: result || jobs[jobIndex].result,
-                    progress: (status === 'completed' || status === 'failed' || status === 'cancelled') ? 100 : jobs[jobIndex].progress
+                    progress: (status === 'completed' || status === 'cracked' || status === 'failed' || status === 'cancelled') ? 100 : jobs[jobIndex].progress
                 }
                 const newJobs = [...jobs]
                 newJobs[jobIndex] = updatedJob
                 this.setState({ jobs: newJobs })
 
                 // Auto-fetch job result when status is completed but no result is provided
-                if (status === 'completed' && !result) {
+                if ((status === 'completed' || status === 'cracked') && !result) {
                     try {
                         // Fetch the complete job data including result
                         const completeJob = await apiService.getJob(jobId)
@@ -14,7 +14,7 @@ export interface Agent {
 export interface Job {
     id: string
     name: string
-    status: 'pending' | 'running' | 'completed' | 'failed' | 'paused'
+    status: 'pending' | 'assigned' | 'running' | 'completed' | 'cracked' | 'failed' | 'paused' | 'cancelled' | 'expired'
     hash_type: number
     attack_mode: number
     hash_file: string
@@ -39,6 +39,7 @@ export interface HashFile {
     path: string
     size: number
     type: string
+    source?: string
     created_at: string
 }
 
@@ -79,7 +80,8 @@ export interface LoginRequest {
 }
 
 export interface LoginResponse {
-    token: string
+    token: string // Short-lived access token
+    refresh_token?: string // Gets new tokens until the session ends
     user: User
     expires_at: string
 }
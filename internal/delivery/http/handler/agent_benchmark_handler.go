@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AgentBenchmarkHandler struct {
+	benchmarkUsecase usecase.AgentBenchmarkUsecase
+}
+
+func NewAgentBenchmarkHandler(benchmarkUsecase usecase.AgentBenchmarkUsecase) *AgentBenchmarkHandler {
+	return &AgentBenchmarkHandler{
+		benchmarkUsecase: benchmarkUsecase,
+	}
+}
+
+// GetBenchmarks returns the agent's benchmarks by hash mode and its latest
+// benchmark jobs
+func (h *AgentBenchmarkHandler) GetBenchmarks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	benchmarks, err := h.benchmarkUsecase.GetBenchmarks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": benchmarks})
+}
+
+// RequestBenchmark queues a benchmark job of the requested hash modes. The
+// agent runs it before its next cracking job.
+func (h *AgentBenchmarkHandler) RequestBenchmark(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var req domain.BenchmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.benchmarkUsecase.RequestBenchmark(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+// InvalidateBenchmarks drops the agent's benchmarks, e.g. after its
+// hardware changed
+func (h *AgentBenchmarkHandler) InvalidateBenchmarks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	if err := h.benchmarkUsecase.InvalidateBenchmarks(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agent benchmarks invalidated"})
+}
+
+// ClaimBenchmarkJob hands the agent its next benchmark job; data is null
+// when there is none
+func (h *AgentBenchmarkHandler) ClaimBenchmarkJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	job, err := h.benchmarkUsecase.ClaimBenchmarkJob(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// ReportBenchmarkJob takes the speeds the agent measured for a benchmark job
+func (h *AgentBenchmarkHandler) ReportBenchmarkJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+	jobID, err := uuid.Parse(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid benchmark job ID"})
+		return
+	}
+
+	var report domain.BenchmarkReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.benchmarkUsecase.ReportBenchmarkJob(c.Request.Context(), id, jobID, &report)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
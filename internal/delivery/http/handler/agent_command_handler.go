@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AgentCommandHandler struct {
+	commandUsecase usecase.AgentCommandUsecase
+}
+
+func NewAgentCommandHandler(commandUsecase usecase.AgentCommandUsecase) *AgentCommandHandler {
+	return &AgentCommandHandler{
+		commandUsecase: commandUsecase,
+	}
+}
+
+// agentCommandIDs parses the agent and command IDs of the path
+func agentCommandIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	commandID, err := uuid.Parse(c.Param("command_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid command ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, commandID, true
+}
+
+// QueueCommand queues a command for the agent, which picks it up with its
+// next heartbeat or poll
+func (h *AgentCommandHandler) QueueCommand(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var req domain.AgentCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command, err := h.commandUsecase.QueueCommand(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": command})
+}
+
+// GetCommands lists the agent's latest commands with their results
+func (h *AgentCommandHandler) GetCommands(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	commands, err := h.commandUsecase.GetCommands(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": commands})
+}
+
+// GetCommand returns one command, to wait for its result
+func (h *AgentCommandHandler) GetCommand(c *gin.Context) {
+	id, commandID, ok := agentCommandIDs(c)
+	if !ok {
+		return
+	}
+
+	command, err := h.commandUsecase.GetCommand(c.Request.Context(), id, commandID)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": command})
+}
+
+// CancelCommand withdraws a command the agent hasn't acknowledged yet
+func (h *AgentCommandHandler) CancelCommand(c *gin.Context) {
+	id, commandID, ok := agentCommandIDs(c)
+	if !ok {
+		return
+	}
+
+	command, err := h.commandUsecase.CancelCommand(c.Request.Context(), id, commandID)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": command})
+}
+
+// GetPendingCommands hands the agent the commands waiting for it
+func (h *AgentCommandHandler) GetPendingCommands(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	commands, err := h.commandUsecase.PendingCommands(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": commands})
+}
+
+// AcknowledgeCommand marks a command taken by the agent. A command already
+// acknowledged, cancelled or expired is refused with 400, and the agent
+// leaves it alone.
+func (h *AgentCommandHandler) AcknowledgeCommand(c *gin.Context) {
+	id, commandID, ok := agentCommandIDs(c)
+	if !ok {
+		return
+	}
+
+	command, err := h.commandUsecase.AcknowledgeCommand(c.Request.Context(), id, commandID)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": command})
+}
+
+// ReportCommandResult takes the outcome of a command the agent ran
+func (h *AgentCommandHandler) ReportCommandResult(c *gin.Context) {
+	id, commandID, ok := agentCommandIDs(c)
+	if !ok {
+		return
+	}
+
+	var result domain.AgentCommandResult
+	if err := c.ShouldBindJSON(&result); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command, err := h.commandUsecase.ReportCommandResult(c.Request.Context(), id, commandID, &result)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": command})
+}
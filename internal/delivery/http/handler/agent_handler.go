@@ -2,7 +2,7 @@ package handler
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +10,7 @@ import (
 
 	"errors"
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +18,8 @@ import (
 )
 
 type AgentHandler struct {
-	agentUsecase usecase.AgentUsecase
+	agentUsecase   usecase.AgentUsecase
+	commandUsecase usecase.AgentCommandUsecase // Optional, see SetCommands
 }
 
 func NewAgentHandler(agentUsecase usecase.AgentUsecase) *AgentHandler {
@@ -26,6 +28,11 @@ func NewAgentHandler(agentUsecase usecase.AgentUsecase) *AgentHandler {
 	}
 }
 
+// SetCommands has heartbeat replies carry the commands queued for the agent
+func (h *AgentHandler) SetCommands(commandUsecase usecase.AgentCommandUsecase) {
+	h.commandUsecase = commandUsecase
+}
+
 // RegisterAgent hanya membuat agent baru dengan status default "offline"
 func (h *AgentHandler) RegisterAgent(c *gin.Context) {
 	type registerAgentDTO struct {
@@ -391,12 +398,13 @@ func (h *AgentHandler) UpdateAgentSpeedWithStatus(c *gin.Context) {
 	}
 
 	// Log real-time update request
-	log.Printf("[REAL-TIME UPDATE REQUEST] Agent %s: speed=%d H/s, status=%s",
+	logger := agentLogger(c, id)
+	logger.Debug("[REAL-TIME UPDATE REQUEST] Agent %s: speed=%d H/s, status=%s",
 		id.String(), req.Speed, req.Status)
 
 	// Update agent speed and status simultaneously
 	if err := h.agentUsecase.UpdateAgentSpeedWithStatus(c.Request.Context(), id, req.Speed, req.Status); err != nil {
-		log.Printf("❌ [REAL-TIME UPDATE FAILED] Agent %s: error=%v", id.String(), err)
+		logger.Error("[REAL-TIME UPDATE FAILED] Agent %s: error=%v", id.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update agent speed and status",
 			"code":    "UPDATE_SPEED_STATUS_FAILED",
@@ -406,7 +414,7 @@ func (h *AgentHandler) UpdateAgentSpeedWithStatus(c *gin.Context) {
 	}
 
 	// Log successful update
-	log.Printf("✅ [REAL-TIME UPDATE SUCCESS] Agent %s: speed=%d H/s, status=%s",
+	logger.Debug("[REAL-TIME UPDATE SUCCESS] Agent %s: speed=%d H/s, status=%s",
 		id.String(), req.Speed, req.Status)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -434,11 +442,12 @@ func (h *AgentHandler) UpdateAgentStatusOffline(c *gin.Context) {
 	}
 
 	// Log status update request
-	log.Printf("[STATUS UPDATE REQUEST] Agent %s: updating status to offline (preserving speed)", id.String())
+	logger := agentLogger(c, id)
+	logger.Info("[STATUS UPDATE REQUEST] Agent %s: updating status to offline (preserving speed)", id.String())
 
 	// Update agent status to offline without resetting speed
 	if err := h.agentUsecase.UpdateAgentStatusOffline(c.Request.Context(), id); err != nil {
-		log.Printf("❌ [STATUS UPDATE FAILED] Agent %s: error=%v", id.String(), err)
+		logger.Error("[STATUS UPDATE FAILED] Agent %s: error=%v", id.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update agent status",
 			"code":    "UPDATE_STATUS_FAILED",
@@ -448,7 +457,7 @@ func (h *AgentHandler) UpdateAgentStatusOffline(c *gin.Context) {
 	}
 
 	// Log successful update
-	log.Printf("✅ [STATUS UPDATE SUCCESS] Agent %s: status updated to offline", id.String())
+	logger.Success("[STATUS UPDATE SUCCESS] Agent %s: status updated to offline", id.String())
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Agent status updated to offline successfully",
@@ -565,13 +574,194 @@ func (h *AgentHandler) RegisterAgentFiles(c *gin.Context) {
 		return
 	}
 
+	files := make([]domain.AgentFile, 0, len(req.Files))
+	for name, file := range req.Files {
+		if file.Name == "" {
+			file.Name = name
+		}
+		agentFile := domain.AgentFile{
+			Name: file.Name,
+			Path: file.Path,
+			Size: file.Size,
+			Type: file.Type,
+			MD5:  file.Hash,
+		}
+		if modTime, err := time.Parse(time.RFC3339Nano, file.ModTime); err == nil {
+			agentFile.ModTime = modTime
+		}
+		files = append(files, agentFile)
+	}
+
+	if err := h.agentUsecase.ReplaceAgentFiles(c.Request.Context(), id, files); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Agent files registered successfully",
 		"agent_id":   req.AgentID,
-		"file_count": len(req.Files),
+		"file_count": len(files),
 	})
 }
 
+// GetAgentFiles returns the local file inventory last reported by an agent
+func (h *AgentHandler) GetAgentFiles(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	files, err := h.agentUsecase.GetAgentFiles(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": files})
+}
+
+// PushAgentLogs receives log lines an agent buffered since its last push
+func (h *AgentHandler) PushAgentLogs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var req struct {
+		Lines []domain.AgentLogLine `json:"lines" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.agentUsecase.AppendAgentLogs(c.Request.Context(), id, req.Lines); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"stored": len(req.Lines)}})
+}
+
+// GetAgentLogs returns an agent's stored log lines. With ?after=ID it returns
+// the lines following that one, for polling; otherwise the newest lines.
+func (h *AgentHandler) GetAgentLogs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var afterID int64
+	if after := c.Query("after"); after != "" {
+		if afterID, err = strconv.ParseInt(after, 10, 64); err != nil || afterID < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after"})
+			return
+		}
+	}
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			limit = v
+		}
+	}
+
+	lines, err := h.agentUsecase.GetAgentLogs(c.Request.Context(), id, afterID, limit)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lines})
+}
+
+// GetAgentHashcatArgs returns the hashcat options the agent adds to every job
+func (h *AgentHandler) GetAgentHashcatArgs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	args, err := h.agentUsecase.GetAgentHashcatArgs(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"args": args}})
+}
+
+// SetAgentHashcatArgs replaces the agent's default hashcat options. Only
+// whitelisted tuning options are accepted; an empty list clears them.
+func (h *AgentHandler) SetAgentHashcatArgs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var req struct {
+		Args []string `json:"args"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.agentUsecase.SetAgentHashcatArgs(c.Request.Context(), id, req.Args); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Args == nil {
+		req.Args = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"args": req.Args}})
+}
+
+// GetAgentSettings returns the settings managed on the server for an agent
+func (h *AgentHandler) GetAgentSettings(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	settings, err := h.agentUsecase.GetAgentSettings(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// SetAgentSettings replaces the agent's server-managed settings. Fields
+// left out or zero fall back to the agent's own configuration.
+func (h *AgentHandler) SetAgentSettings(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	var settings domain.AgentSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.agentUsecase.SetAgentSettings(c.Request.Context(), id, &settings); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
 // DeleteAgent deletes an agent
 func (h *AgentHandler) DeleteAgent(c *gin.Context) {
 	idStr := c.Param("id")
@@ -611,6 +801,45 @@ func (h *AgentHandler) DeleteAgent(c *gin.Context) {
 	})
 }
 
+// GetStaleAgents lists the agents not seen for ?days, the configured stale
+// period by default, archived ones included
+func (h *AgentHandler) GetStaleAgents(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+	}
+
+	agents, err := h.agentUsecase.GetStaleAgents(c.Request.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": agents, "total": len(agents)})
+}
+
+// PurgeStaleAgents archives or deletes stale agents in bulk, leaving out
+// those that still have jobs
+func (h *AgentHandler) PurgeStaleAgents(c *gin.Context) {
+	var req domain.PurgeStaleAgentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.agentUsecase.PurgeStaleAgents(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 // AgentStartup handles agent startup and validation
 func (h *AgentHandler) AgentStartup(c *gin.Context) {
 	var req struct {
@@ -670,7 +899,7 @@ func (h *AgentHandler) AgentStartup(c *gin.Context) {
 		// Update last seen
 		if err := h.agentUsecase.UpdateAgentLastSeen(c.Request.Context(), existingAgentByKey.ID); err != nil {
 			// Log error but don't fail the request
-			log.Printf("Failed to update agent last seen: %v", err)
+			agentLogger(c, existingAgentByKey.ID).Error("Failed to update agent last seen: %v", err)
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -715,7 +944,7 @@ func (h *AgentHandler) AgentStartup(c *gin.Context) {
 	// Update last seen (this will also update the database)
 	if err := h.agentUsecase.UpdateAgentLastSeen(c.Request.Context(), existingAgentByKey.ID); err != nil {
 		// Log error but don't fail the request
-		log.Printf("Failed to update agent last seen: %v", err)
+		agentLogger(c, existingAgentByKey.ID).Error("Failed to update agent last seen: %v", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -736,7 +965,11 @@ func (h *AgentHandler) AgentStartup(c *gin.Context) {
 // AgentHeartbeat handles agent heartbeat using agent key
 func (h *AgentHandler) AgentHeartbeat(c *gin.Context) {
 	var req struct {
-		AgentKey string `json:"agent_key" binding:"required"`
+		AgentKey string                   `json:"agent_key" binding:"required"`
+		Cache    *domain.AgentCacheReport `json:"cache,omitempty"`
+		Snapshot *domain.AgentHeartbeat   `json:"snapshot,omitempty"`
+		// Interval the agent would like, 0 leaves it to the server
+		IntervalSeconds int `json:"heartbeat_interval_seconds,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -759,27 +992,62 @@ func (h *AgentHandler) AgentHeartbeat(c *gin.Context) {
 		return
 	}
 
-	// Update last seen
-	if err := h.agentUsecase.UpdateAgentLastSeen(c.Request.Context(), agent.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update agent heartbeat",
-			"code":    "UPDATE_HEARTBEAT_FAILED",
-			"message": "Failed to update agent heartbeat.",
-		})
-		return
+	// Agents attach their download cache status when it changed
+	if req.Cache != nil {
+		h.agentUsecase.UpdateAgentCacheReport(c.Request.Context(), agent.ID, req.Cache)
+	}
+
+	// Last seen and the runtime snapshot are written in batches; the reply
+	// tells the agent how often to send heartbeats from now on
+	interval := h.agentUsecase.AcceptAgentHeartbeat(c.Request.Context(), agent.ID,
+		time.Duration(req.IntervalSeconds)*time.Second, req.Snapshot)
+
+	data := gin.H{
+		"id":         agent.ID.String(),
+		"name":       agent.Name,
+		"status":     agent.Status,
+		"updated_at": time.Now().Format(time.RFC3339),
+
+		"heartbeat_interval_seconds": int(interval / time.Second),
+	}
+	// Settings changed on the server reach the agent with the next reply
+	if settings, err := h.agentUsecase.GetAgentSettings(c.Request.Context(), agent.ID); err == nil {
+		data["settings"] = settings
+	}
+	// So do commands queued for it, until it acknowledges them
+	if h.commandUsecase != nil {
+		if commands, err := h.commandUsecase.PendingCommands(c.Request.Context(), agent.ID); err == nil && len(commands) > 0 {
+			data["commands"] = commands
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Agent heartbeat updated successfully",
-		"data": gin.H{
-			"id":         agent.ID.String(),
-			"name":       agent.Name,
-			"status":     agent.Status,
-			"updated_at": time.Now().Format(time.RFC3339),
-		},
+		"data":    data,
 	})
 }
 
+// GetAgentCache returns the download cache status last reported by an agent
+func (h *AgentHandler) GetAgentCache(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	report, err := h.agentUsecase.GetAgentCacheReport(c.Request.Context(), id)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No cache report received from this agent yet"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
 type LocalFile struct {
 	Name    string `json:"name"`
 	Path    string `json:"path"`
@@ -788,3 +1056,130 @@ type LocalFile struct {
 	Hash    string `json:"hash"`
 	ModTime string `json:"mod_time"`
 }
+
+// agentGroupErrorStatus maps agent group errors to HTTP status codes
+func agentGroupErrorStatus(err error) int {
+	switch {
+	case domain.IsNotFoundError(err):
+		return http.StatusNotFound
+	case domain.IsValidationError(err):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *AgentHandler) CreateAgentGroup(c *gin.Context) {
+	var req domain.CreateAgentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.agentUsecase.CreateAgentGroup(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": group})
+}
+
+// GetAllAgentGroups lists agent groups with the health of their agents
+func (h *AgentHandler) GetAllAgentGroups(c *gin.Context) {
+	groups, err := h.agentUsecase.GetAllAgentGroups(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+func (h *AgentHandler) GetAgentGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent group ID"})
+		return
+	}
+
+	group, err := h.agentUsecase.GetAgentGroup(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group})
+}
+
+func (h *AgentHandler) DeleteAgentGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent group ID"})
+		return
+	}
+
+	if err := h.agentUsecase.DeleteAgentGroup(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agent group deleted successfully"})
+}
+
+func (h *AgentHandler) AddAgentToGroup(c *gin.Context) {
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent group ID"})
+		return
+	}
+
+	var req domain.AgentGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	agentID, err := uuid.Parse(req.AgentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	if err := h.agentUsecase.AddAgentToGroup(c.Request.Context(), groupID, agentID); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.agentUsecase.GetAgentGroup(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group})
+}
+
+func (h *AgentHandler) RemoveAgentFromGroup(c *gin.Context) {
+	groupID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent group ID"})
+		return
+	}
+	agentID, err := uuid.Parse(c.Param("agentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	if err := h.agentUsecase.RemoveAgentFromGroup(c.Request.Context(), groupID, agentID); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agent removed from group successfully"})
+}
+
+// agentLogger returns a logger tagged with the request and agent IDs
+func agentLogger(c *gin.Context, agentID uuid.UUID) *infrastructure.Logger {
+	return infrastructure.ServerLogger.WithContext(c.Request.Context()).With("agent_id", agentID)
+}
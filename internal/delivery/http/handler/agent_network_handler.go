@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AgentNetworkHandler struct {
+	agentNetworkUsecase usecase.AgentNetworkUsecase
+}
+
+func NewAgentNetworkHandler(agentNetworkUsecase usecase.AgentNetworkUsecase) *AgentNetworkHandler {
+	return &AgentNetworkHandler{
+		agentNetworkUsecase: agentNetworkUsecase,
+	}
+}
+
+// CreateRule adds a network rule for all agents, or for the one named by
+// agent_id or agent_key
+func (h *AgentNetworkHandler) CreateRule(c *gin.Context) {
+	var req domain.CreateAgentNetworkRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.agentNetworkUsecase.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": rule})
+}
+
+// GetRules lists the rules from the server configuration, marked static,
+// followed by the ones added through the API
+func (h *AgentNetworkHandler) GetRules(c *gin.Context) {
+	rules, err := h.agentNetworkUsecase.GetRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+func (h *AgentNetworkHandler) DeleteRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid network rule ID"})
+		return
+	}
+
+	if err := h.agentNetworkUsecase.DeleteRule(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Network rule deleted"})
+}
+
+// GetDenials lists the newest agent requests the network rules refused
+func (h *AgentNetworkHandler) GetDenials(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	denials, err := h.agentNetworkUsecase.GetDenials(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": denials})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsUsecase usecase.AnalyticsUsecase
+}
+
+func NewAnalyticsHandler(analyticsUsecase usecase.AnalyticsUsecase) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsUsecase: analyticsUsecase}
+}
+
+// GetEffectiveness ranks wordlists, rule files or masks by the cracks per
+// keyspace of completed jobs: group_by is wordlist (default), rules or
+// mask, optionally limited to a hash_type and to jobs completed between
+// from and to (RFC3339)
+func (h *AnalyticsHandler) GetEffectiveness(c *gin.Context) {
+	filter := domain.EffectivenessFilter{GroupBy: c.DefaultQuery("group_by", domain.EffectivenessGroupWordlist)}
+	if v := c.Query("hash_type"); v != "" {
+		hashType, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash_type"})
+			return
+		}
+		filter.HashType = &hashType
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected RFC3339"})
+			return
+		}
+		filter.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected RFC3339"})
+			return
+		}
+		filter.To = &t
+	}
+
+	report, err := h.analyticsUsecase.GetEffectiveness(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -42,7 +42,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authUsecase.Login(c.Request.Context(), &req)
+	response, err := h.authUsecase.Login(clientContext(c), &req)
 	if err != nil {
 		switch err.(type) {
 		case *domain.InvalidCredentialsError:
@@ -50,6 +50,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 				"error": "Invalid username or password",
 				"code":  "INVALID_CREDENTIALS",
 			})
+		case *domain.TwoFactorRequiredError:
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Enter the code from your authenticator app or a recovery code",
+				"code":  "TWO_FACTOR_REQUIRED",
+			})
 		case *domain.AuthenticationError:
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": err.Error(),
@@ -110,17 +115,17 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // RefreshToken handles token refresh
 // @Summary Refresh token
-// @Description Generate new token using existing valid token
+// @Description Exchange a refresh token for a new access token and refresh token
 // @Tags authentication
 // @Accept json
 // @Produce json
-// @Param request body domain.LogoutRequest true "Token to refresh"
+// @Param request body domain.RefreshRequest true "Refresh token"
 // @Success 200 {object} domain.LoginResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /api/v1/auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	var req domain.LogoutRequest // Reusing LogoutRequest struct for token
+	var req domain.RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request format",
@@ -129,7 +134,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authUsecase.RefreshToken(c.Request.Context(), req.Token)
+	response, err := h.authUsecase.RefreshToken(clientContext(c), req.RefreshToken)
 	if err != nil {
 		switch err.(type) {
 		case *domain.AuthenticationError:
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CandidateHandler struct {
+	candidatePreviewUsecase usecase.CandidatePreviewUsecase
+}
+
+func NewCandidateHandler(candidatePreviewUsecase usecase.CandidatePreviewUsecase) *CandidateHandler {
+	return &CandidateHandler{
+		candidatePreviewUsecase: candidatePreviewUsecase,
+	}
+}
+
+// PreviewCandidates runs the attack through hashcat --stdout on the server
+// and returns its first candidates
+func (h *CandidateHandler) PreviewCandidates(c *gin.Context) {
+	var req domain.CandidatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.candidatePreviewUsecase.PreviewCandidates(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preview})
+}
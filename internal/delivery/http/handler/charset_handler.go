@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CharsetHandler struct {
+	charsetUsecase usecase.CharsetUsecase
+}
+
+func NewCharsetHandler(charsetUsecase usecase.CharsetUsecase) *CharsetHandler {
+	return &CharsetHandler{
+		charsetUsecase: charsetUsecase,
+	}
+}
+
+func (h *CharsetHandler) UploadCharset(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		return
+	}
+	defer src.Close()
+
+	charset, err := h.charsetUsecase.UploadCharset(c.Request.Context(), file.Filename, src)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": charset})
+}
+
+func (h *CharsetHandler) GetAllCharsets(c *gin.Context) {
+	charsets, err := h.charsetUsecase.GetAllCharsets(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": charsets})
+}
+
+func (h *CharsetHandler) GetCharset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid charset file ID"})
+		return
+	}
+
+	charset, err := h.charsetUsecase.GetCharset(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": charset})
+}
+
+// DownloadCharset serves the file to agents, which pass it to hashcat as -1..-4
+func (h *CharsetHandler) DownloadCharset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid charset file ID"})
+		return
+	}
+
+	charset, err := h.charsetUsecase.GetCharset(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", charset.Name))
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Transfer-Encoding", "binary")
+
+	c.File(charset.Path)
+}
+
+func (h *CharsetHandler) DeleteCharset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid charset file ID"})
+		return
+	}
+
+	if err := h.charsetUsecase.DeleteCharset(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Charset file deleted successfully"})
+}
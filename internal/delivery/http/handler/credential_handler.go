@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CredentialHandler struct {
+	credentialUsecase usecase.CredentialUsecase
+	maxPotfileSize    int64 // Zero for no limit
+	redactResults     bool  // Mask cracked passwords, see SetResultRedaction
+}
+
+func NewCredentialHandler(credentialUsecase usecase.CredentialUsecase) *CredentialHandler {
+	return &CredentialHandler{
+		credentialUsecase: credentialUsecase,
+	}
+}
+
+// SetMaxPotfileSize limits the potfiles ImportPotfile reads
+func (h *CredentialHandler) SetMaxPotfileSize(maxSize int64) {
+	h.maxPotfileSize = maxSize
+}
+
+// SetResultRedaction masks the plaintexts of credentials, and shows the
+// passwords of statistics as their hashcat mask
+func (h *CredentialHandler) SetResultRedaction(enabled bool) {
+	h.redactResults = enabled
+}
+
+// GetCredentials lists credentials, newest first, optionally only those of
+// ?username (DOMAIN\username narrows the domain too), ?domain,
+// ?hash_file_id or ?project_id, a page at a time with ?limit and ?offset.
+// /credentials/users/:username and /credentials/domains/:domain are the
+// same with the filter in the path.
+func (h *CredentialHandler) GetCredentials(c *gin.Context) {
+	filter, ok := credentialFilter(c)
+	if !ok {
+		return
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	credentials, total, err := h.credentialUsecase.GetCredentials(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if h.redactResults {
+		for i := range credentials {
+			credentials[i].Plaintext = domain.RedactedPassword
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credentials, "total": total})
+}
+
+// GetStats summarises the credentials matching the filters of
+// GetCredentials: password reuse, the ?top most used passwords and the
+// length and character class distributions
+func (h *CredentialHandler) GetStats(c *gin.Context) {
+	filter, ok := credentialFilter(c)
+	if !ok {
+		return
+	}
+	top, _ := strconv.Atoi(c.Query("top"))
+
+	stats, err := h.credentialUsecase.GetStats(c.Request.Context(), filter, top)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if h.redactResults {
+		for i := range stats.Reused {
+			stats.Reused[i].Password = domain.PasswordMask(stats.Reused[i].Password)
+		}
+		for i := range stats.TopPasswords {
+			stats.TopPasswords[i].Password = domain.PasswordMask(stats.TopPasswords[i].Password)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// ImportPotfile reads a hashcat potfile, or --show output, of a hash file
+// from the request body and records the credentials it cracks. ?hash_type
+// is stored with them.
+func (h *CredentialHandler) ImportPotfile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash file ID"})
+		return
+	}
+	hashType := 0
+	if value := c.Query("hash_type"); value != "" {
+		if hashType, err = strconv.Atoi(value); err != nil || hashType < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash_type"})
+			return
+		}
+	}
+
+	if h.maxPotfileSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxPotfileSize)
+	}
+	recorded, err := h.credentialUsecase.ImportPotfile(c.Request.Context(), id, hashType, c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			refusal := tooLarge(h.maxPotfileSize)
+			c.JSON(refusal.status, gin.H{"error": refusal.message, "code": refusal.code})
+			return
+		}
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"recorded": recorded}})
+}
+
+// credentialFilter reads the filters of GetCredentials from the query and
+// the path
+func credentialFilter(c *gin.Context) (domain.CredentialFilter, bool) {
+	var filter domain.CredentialFilter
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	filter.ProjectID = projectID
+	if value := c.Query("hash_file_id"); value != "" {
+		hashFileID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash_file_id"})
+			return filter, false
+		}
+		filter.HashFileID = &hashFileID
+	}
+
+	filter.Domain = c.Query("domain")
+	if value := c.Param("domain"); value != "" {
+		filter.Domain = value
+	}
+	filter.Username = c.Query("username")
+	if value := c.Param("username"); value != "" {
+		filter.Username = value
+	}
+	if i := strings.LastIndex(filter.Username, `\`); i >= 0 {
+		filter.Domain, filter.Username = filter.Username[:i], filter.Username[i+1:]
+	}
+	return filter, true
+}
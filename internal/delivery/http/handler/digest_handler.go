@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DigestHandler struct {
+	digestUsecase usecase.DigestUsecase
+}
+
+// NewDigestHandler manages the logged-in user's project digest subscriptions
+func NewDigestHandler(digestUsecase usecase.DigestUsecase) *DigestHandler {
+	return &DigestHandler{digestUsecase: digestUsecase}
+}
+
+// GetSubscriptions lists the digests the user subscribed to
+func (h *DigestHandler) GetSubscriptions(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	subscriptions, err := h.digestUsecase.GetSubscriptions(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+// Subscribe subscribes the user to a project's digest, or changes how often
+// it comes
+func (h *DigestHandler) Subscribe(c *gin.Context) {
+	userID, role, projectID, ok := h.params(c)
+	if !ok {
+		return
+	}
+	var req domain.DigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.digestUsecase.Subscribe(c.Request.Context(), userID, role, projectID, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	userID, _, projectID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	if err := h.digestUsecase.Unsubscribe(c.Request.Context(), userID, projectID); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed"})
+}
+
+// Preview shows a project's digest as it would be emailed now, as HTML or,
+// with ?format=text, as its plain text alternative
+func (h *DigestHandler) Preview(c *gin.Context) {
+	userID, role, projectID, ok := h.params(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.digestUsecase.PreviewDigest(c.Request.Context(), userID, role, projectID, c.DefaultQuery("frequency", domain.DigestWeekly))
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(message.Text))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(message.HTML))
+}
+
+// params returns the logged-in user and the project of the route
+func (h *DigestHandler) params(c *gin.Context) (uuid.UUID, string, uuid.UUID, bool) {
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, "", uuid.Nil, false
+	}
+	projectID, err := uuid.Parse(c.Param("projectId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return uuid.Nil, "", uuid.Nil, false
+	}
+	return userID, role, projectID, true
+}
@@ -51,6 +51,27 @@ func (h *DistributedJobHandler) CreateDistributedJobs(c *gin.Context) {
 	// Create distributed jobs
 	result, err := h.distributedJobUsecase.CreateDistributedJobs(c.Request.Context(), &req)
 	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create distributed jobs: " + err.Error(),
@@ -135,7 +156,7 @@ func (h *DistributedJobHandler) StartAllSubJobs(c *gin.Context) {
 	// Start all sub-jobs
 	startedCount := 0
 	for _, subJob := range result.SubJobs {
-		if subJob.Status == "pending" {
+		if subJob.Status == domain.JobStatusPending || subJob.Status == domain.JobStatusAssigned {
 			// Update job status to running
 			subJob.Status = "running"
 			// In a real implementation, you would call the job repository to update status
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EnrollmentHandler struct {
+	enrollmentUsecase usecase.EnrollmentUsecase
+}
+
+func NewEnrollmentHandler(enrollmentUsecase usecase.EnrollmentUsecase) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		enrollmentUsecase: enrollmentUsecase,
+	}
+}
+
+// CreateTokens generates a batch of enrollment tokens. The response is the
+// only time the tokens themselves are shown.
+func (h *EnrollmentHandler) CreateTokens(c *gin.Context) {
+	var req domain.CreateEnrollmentTokensRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.enrollmentUsecase.CreateTokens(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": tokens})
+}
+
+func (h *EnrollmentHandler) GetAllTokens(c *gin.Context) {
+	tokens, err := h.enrollmentUsecase.GetAllTokens(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// DeleteToken revokes an unused token, or removes a used one from the list
+func (h *EnrollmentHandler) DeleteToken(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid enrollment token ID"})
+		return
+	}
+
+	if err := h.enrollmentUsecase.DeleteToken(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Enrollment token deleted"})
+}
+
+// Enroll trades an enrollment token for a new agent and its agent key
+func (h *EnrollmentHandler) Enroll(c *gin.Context) {
+	var req domain.EnrollAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	agent, err := h.enrollmentUsecase.Enroll(c.Request.Context(), &req)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, gin.H{"data": agent})
+	case errors.Is(err, domain.ErrEnrollmentTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "ENROLLMENT_TOKEN_INVALID"})
+	case errors.Is(err, domain.ErrEnrollmentTokenExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error(), "code": "ENROLLMENT_TOKEN_EXPIRED"})
+	case errors.Is(err, domain.ErrEnrollmentTokenUsed):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error(), "code": "ENROLLMENT_TOKEN_USED"})
+	case strings.Contains(err.Error(), "already exists"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "AGENT_NAME_EXISTS"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
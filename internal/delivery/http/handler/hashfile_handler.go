@@ -3,7 +3,9 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
+	"go-distributed-hashcat/internal/domain"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +14,8 @@ import (
 
 type HashFileHandler struct {
 	hashFileUsecase usecase.HashFileUsecase
+	uploads         UploadPolicy
+	scanner         domain.UploadScanner // Optional, refuses infected uploads
 }
 
 func NewHashFileHandler(hashFileUsecase usecase.HashFileUsecase) *HashFileHandler {
@@ -20,17 +24,30 @@ func NewHashFileHandler(hashFileUsecase usecase.HashFileUsecase) *HashFileHandle
 	}
 }
 
+// SetUploadPolicy sets what uploads are accepted and the malware scanner
+// they go through, if any
+func (h *HashFileHandler) SetUploadPolicy(policy UploadPolicy, scanner domain.UploadScanner) {
+	h.uploads = policy
+	h.scanner = scanner
+}
+
+// UploadHashFile stores an uploaded hash file. The optional form field
+// source tags where its hashes come from, such as wifi or ad.
 func (h *HashFileHandler) UploadHashFile(c *gin.Context) {
-	file, err := c.FormFile("file")
+	projectID, err := projectIDQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Open the uploaded file
-	src, err := file.Open()
+	source, err := domain.NormalizeHashFileSource(c.PostForm("source"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Open the uploaded file once its size, type and content are accepted
+	file, src, ok := openUpload(c, h.uploads, h.scanner)
+	if !ok {
 		return
 	}
 	defer src.Close()
@@ -41,11 +58,29 @@ func (h *HashFileHandler) UploadHashFile(c *gin.Context) {
 		file.Filename,
 		src,
 		file.Size,
+		projectID,
 	)
 	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if source != "" && source != hashFile.Source {
+		duplicate := hashFile.Duplicate
+		if hashFile, err = h.hashFileUsecase.SetHashFileSource(c.Request.Context(), hashFile.ID, source); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		hashFile.Duplicate = duplicate
+	}
+
+	if hashFile.Duplicate {
+		c.JSON(http.StatusOK, gin.H{"data": hashFile, "message": "Identical file already uploaded, returning existing record"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"data": hashFile})
 }
@@ -68,7 +103,33 @@ func (h *HashFileHandler) GetHashFile(c *gin.Context) {
 }
 
 func (h *HashFileHandler) GetAllHashFiles(c *gin.Context) {
-	hashFiles, err := h.hashFileUsecase.GetAllHashFiles(c.Request.Context())
+	// Content-hash lookup lets agents check a local copy before downloading
+	if sum := c.Query("sha256"); sum != "" {
+		hashFile, err := h.hashFileUsecase.GetHashFileBySHA256(c.Request.Context(), sum)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.JSON(http.StatusOK, gin.H{"data": []domain.HashFile{}})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": []domain.HashFile{*hashFile}})
+		return
+	}
+
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hashFiles []domain.HashFile
+	if projectID != nil {
+		hashFiles, err = h.hashFileUsecase.GetHashFilesByProject(c.Request.Context(), *projectID)
+	} else {
+		hashFiles, err = h.hashFileUsecase.GetAllHashFiles(c.Request.Context())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -77,6 +138,28 @@ func (h *HashFileHandler) GetAllHashFiles(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": hashFiles})
 }
 
+// SetHashFileSource tags a hash file with where its hashes come from
+func (h *HashFileHandler) SetHashFileSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash file ID"})
+		return
+	}
+	var req domain.SetHashFileSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashFile, err := h.hashFileUsecase.SetHashFileSource(c.Request.Context(), id, req.Source)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": hashFile})
+}
+
 func (h *HashFileHandler) DeleteHashFile(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -101,18 +184,18 @@ func (h *HashFileHandler) DownloadHashFile(c *gin.Context) {
 		return
 	}
 
-	hashFile, err := h.hashFileUsecase.GetHashFile(c.Request.Context(), id)
+	// The content is decrypted on the way out when stored encrypted
+	hashFile, content, err := h.hashFileUsecase.OpenHashFile(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Set headers for file download
-	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", hashFile.OrigName))
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Transfer-Encoding", "binary")
+	defer content.Close()
 
 	// Serve the file
-	c.File(hashFile.Path)
+	c.DataFromReader(http.StatusOK, hashFile.Size, "application/octet-stream", content, map[string]string{
+		"Content-Description":       "File Transfer",
+		"Content-Disposition":       fmt.Sprintf("attachment; filename=%s", hashFile.OrigName),
+		"Content-Transfer-Encoding": "binary",
+	})
 }
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type HashFileOperationHandler struct {
+	operationUsecase usecase.HashFileOperationUsecase
+}
+
+func NewHashFileOperationHandler(operationUsecase usecase.HashFileOperationUsecase) *HashFileOperationHandler {
+	return &HashFileOperationHandler{
+		operationUsecase: operationUsecase,
+	}
+}
+
+// MergeHashFiles starts merging hash files into one deduplicated hash file
+func (h *HashFileOperationHandler) MergeHashFiles(c *gin.Context) {
+	var req domain.MergeHashFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operation, err := h.operationUsecase.MergeHashFiles(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": operation})
+}
+
+// SplitHashFile starts splitting a hash file into parts
+func (h *HashFileOperationHandler) SplitHashFile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash file ID"})
+		return
+	}
+
+	var req domain.SplitHashFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operation, err := h.operationUsecase.SplitHashFile(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": operation})
+}
+
+// DiffHashFiles starts comparing two hash files
+func (h *HashFileOperationHandler) DiffHashFiles(c *gin.Context) {
+	var req domain.DiffHashFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operation, err := h.operationUsecase.DiffHashFiles(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": operation})
+}
+
+func (h *HashFileOperationHandler) GetOperations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	operations, err := h.operationUsecase.GetOperations(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": operations})
+}
+
+func (h *HashFileOperationHandler) GetOperation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("operationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation ID"})
+		return
+	}
+
+	operation, err := h.operationUsecase.GetOperation(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": operation})
+}
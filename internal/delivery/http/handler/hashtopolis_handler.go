@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hashtopolisLoginTimeout is how long, in seconds, Hashtopolis agents wait
+// for the server's answers
+const hashtopolisLoginTimeout = 30
+
+type HashtopolisHandler struct {
+	hashtopolisUsecase usecase.HashtopolisUsecase
+	maxExportSize      int64 // Zero for no limit
+}
+
+func NewHashtopolisHandler(hashtopolisUsecase usecase.HashtopolisUsecase) *HashtopolisHandler {
+	return &HashtopolisHandler{hashtopolisUsecase: hashtopolisUsecase}
+}
+
+// SetMaxExportSize limits the exports Import reads
+func (h *HashtopolisHandler) SetMaxExportSize(maxSize int64) {
+	h.maxExportSize = maxSize
+}
+
+// Import creates a project, named by ?name=, from the Hashtopolis export in
+// the request body
+func (h *HashtopolisHandler) Import(c *gin.Context) {
+	body := c.Request.Body
+	if h.maxExportSize > 0 {
+		body = http.MaxBytesReader(c.Writer, body, h.maxExportSize)
+	}
+	var export domain.HashtopolisExport
+	if err := json.NewDecoder(body).Decode(&export); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			refusal := tooLarge(h.maxExportSize)
+			c.JSON(refusal.status, gin.H{"error": refusal.message, "code": refusal.code})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Hashtopolis export: " + err.Error()})
+		return
+	}
+
+	result, err := h.hashtopolisUsecase.Import(c.Request.Context(), c.DefaultQuery("name", "Hashtopolis"), &export)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// hashtopolisQuery is a request of the Hashtopolis agent, whose fields
+// depend on the action
+type hashtopolisQuery struct {
+	Action  string   `json:"action"`
+	Token   string   `json:"token"`
+	Voucher string   `json:"voucher"`
+	Name    string   `json:"name"`
+	Devices []string `json:"devices"`
+}
+
+// AgentAPI answers the Hashtopolis agent's requests to api/server.php, so
+// agents can register, log in and report their devices while they are
+// switched over. No tasks are handed out: getTask always finds none.
+func (h *HashtopolisHandler) AgentAPI(c *gin.Context) {
+	var query hashtopolisQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		hashtopolisError(c, "", "Invalid query!")
+		return
+	}
+	ctx := c.Request.Context()
+
+	switch query.Action {
+	case "testConnection":
+		hashtopolisSuccess(c, query.Action, nil)
+	case "register":
+		agent, err := h.hashtopolisUsecase.RegisterAgent(ctx, query.Voucher, query.Name)
+		if err != nil {
+			hashtopolisError(c, query.Action, hashtopolisMessage(err))
+			return
+		}
+		hashtopolisSuccess(c, query.Action, gin.H{"token": agent.AgentKey})
+	case "login":
+		if _, err := h.hashtopolisUsecase.LoginAgent(ctx, query.Token); err != nil {
+			hashtopolisError(c, query.Action, hashtopolisMessage(err))
+			return
+		}
+		hashtopolisSuccess(c, query.Action, gin.H{"timeout": hashtopolisLoginTimeout, "multicastAvailable": false})
+	case "updateInformation":
+		if err := h.hashtopolisUsecase.UpdateAgentDevices(ctx, query.Token, query.Devices); err != nil {
+			hashtopolisError(c, query.Action, hashtopolisMessage(err))
+			return
+		}
+		hashtopolisSuccess(c, query.Action, nil)
+	case "checkClientVersion":
+		if _, err := h.hashtopolisUsecase.LoginAgent(ctx, query.Token); err != nil {
+			hashtopolisError(c, query.Action, hashtopolisMessage(err))
+			return
+		}
+		hashtopolisSuccess(c, query.Action, gin.H{"version": "OK"})
+	case "getTask":
+		if _, err := h.hashtopolisUsecase.LoginAgent(ctx, query.Token); err != nil {
+			hashtopolisError(c, query.Action, hashtopolisMessage(err))
+			return
+		}
+		hashtopolisSuccess(c, query.Action, gin.H{"taskId": nil, "reason": "Tasks run on this server's agent, install it to get work"})
+	default:
+		hashtopolisError(c, query.Action, "Action is not supported by this server")
+	}
+}
+
+// hashtopolisMessage is the message of an error the agent is told about
+func hashtopolisMessage(err error) string {
+	switch {
+	case domain.IsNotFoundError(err):
+		return "Invalid token!"
+	case errors.Is(err, domain.ErrEnrollmentTokenInvalid), errors.Is(err, domain.ErrEnrollmentTokenExpired), errors.Is(err, domain.ErrEnrollmentTokenUsed):
+		return "Provided voucher does not exist."
+	case domain.IsValidationError(err):
+		return err.Error()
+	default:
+		infrastructure.ServerLogger.Error("Hashtopolis agent request failed: %v", err)
+		return "Internal error"
+	}
+}
+
+// Hashtopolis agents read the outcome from the body, always sent with 200
+func hashtopolisSuccess(c *gin.Context, action string, fields gin.H) {
+	answer := gin.H{"action": action, "response": "SUCCESS"}
+	for key, value := range fields {
+		answer[key] = value
+	}
+	c.JSON(http.StatusOK, answer)
+}
+
+func hashtopolisError(c *gin.Context, action, message string) {
+	c.JSON(http.StatusOK, gin.H{"action": action, "response": "ERROR", "message": message})
+}
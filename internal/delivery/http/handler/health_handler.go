@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each readiness check, so a hung dependency
+// fails the probe instead of stalling it
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is one dependency the server needs to serve traffic
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandler answers infrastructure probes. Liveness only says the
+// process serves HTTP; readiness also runs the checks.
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness is GET /healthz
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ok",
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// Readiness is GET /readyz. It answers 503 when any check fails, listing
+// every check with "ok" or its error.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	results := make(map[string]string, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+			defer cancel()
+
+			result := "ok"
+			if err := check.Check(ctx); err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			if result != "ok" {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":    status,
+		"checks":    results,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// HubCheck reports whether the WebSocket hub's loop is processing messages
+func HubCheck(hub *WebSocketHub) HealthCheck {
+	return HealthCheck{Name: "websocket_hub", Check: hub.Ping}
+}
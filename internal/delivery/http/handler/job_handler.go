@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -23,11 +27,20 @@ func defaultString(s string, fallback string) string {
 	return s
 }
 
+// nonNilStrings returns an empty list instead of nil, so JSON shows []
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 type JobHandler struct {
 	jobUsecase        usecase.JobUsecase
 	enrichmentService usecase.JobEnrichmentService
 	agentUsecase      usecase.AgentUsecase
 	wordlistUsecase   usecase.WordlistUsecase
+	redactResults     bool // Mask cracked passwords, see SetResultRedaction
 }
 
 func NewJobHandler(jobUsecase usecase.JobUsecase, enrichmentService usecase.JobEnrichmentService, agentUsecase usecase.AgentUsecase, wordlistUsecase usecase.WordlistUsecase) *JobHandler {
@@ -39,6 +52,28 @@ func NewJobHandler(jobUsecase usecase.JobUsecase, enrichmentService usecase.JobE
 	}
 }
 
+// SetResultRedaction masks cracked passwords in job lists, job details and
+// status broadcasts. GET /jobs/:id/result reveals them to permitted users.
+func (h *JobHandler) SetResultRedaction(enabled bool) {
+	h.redactResults = enabled
+}
+
+// visibleResult is a job result as the API shows it
+func (h *JobHandler) visibleResult(result string) string {
+	if h.redactResults {
+		return domain.RedactResult(result)
+	}
+	return result
+}
+
+// visiblePlaintext is a cracked password as the API shows it
+func (h *JobHandler) visiblePlaintext(plaintext string) string {
+	if h.redactResults {
+		return domain.RedactedPassword
+	}
+	return plaintext
+}
+
 func (h *JobHandler) CreateJob(c *gin.Context) {
 	var req domain.CreateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,8 +81,28 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
-	job, err := h.jobUsecase.CreateJob(c.Request.Context(), &req)
+	req.ProjectID = c.Query("project_id")
+
+	// A dry run only predicts how long the job would take
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		h.estimateJob(c, &req)
+		return
+	}
+
+	job, err := h.jobUsecase.CreateJob(actorContext(c, domain.ActorAPI), &req)
 	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -55,6 +110,61 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": job})
 }
 
+// maxPlaybookSize caps the playbooks ApplyJobs reads
+const maxPlaybookSize = 1 << 20
+
+// ApplyJobs queues the attacks of a YAML or JSON playbook as one job group.
+// A dry run validates the playbook and shows the jobs it would create.
+func (h *JobHandler) ApplyJobs(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPlaybookSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > maxPlaybookSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("playbook is larger than %d bytes", maxPlaybookSize)})
+		return
+	}
+
+	playbook, err := domain.ParseJobPlaybook(data)
+	if err != nil {
+		playbookErrorResponse(c, err)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	result, err := h.jobUsecase.ApplyJobPlaybook(actorContext(c, domain.ActorAPI), playbook, c.Query("project_id"), dryRun)
+	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		playbookErrorResponse(c, err)
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// playbookErrorResponse lists every problem of an invalid playbook
+func playbookErrorResponse(c *gin.Context, err error) {
+	var pErr *domain.PlaybookError
+	if !errors.As(err, &pErr) {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	problems := make([]gin.H, len(pErr.Problems))
+	for i, problem := range pErr.Problems {
+		problems[i] = gin.H{"field": problem.Field, "message": problem.Message}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "problems": problems})
+}
+
 func (h *JobHandler) GetJob(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -69,21 +179,152 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 		return
 	}
 
+	// Jobs that haven't started show how long they are expected to run
+	if estimate, err := h.jobUsecase.EstimatePendingJob(c.Request.Context(), job); err == nil {
+		job.Estimate = estimate
+	}
+	job.Result = h.visibleResult(job.Result)
+
 	c.JSON(http.StatusOK, gin.H{"data": job})
 }
 
-func (h *JobHandler) GetAllJobs(c *gin.Context) {
-	status := c.Query("status")
+// estimateJob answers a dry run of CreateJob with the predicted run time
+func (h *JobHandler) estimateJob(c *gin.Context, req *domain.CreateJobRequest) {
+	estimate, err := h.jobUsecase.EstimateJob(c.Request.Context(), req)
+	if err != nil {
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	var jobs []domain.Job
-	var err error
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"dry_run": true, "estimate": estimate}})
+}
 
-	if status != "" {
-		jobs, err = h.jobUsecase.GetJobsByStatus(c.Request.Context(), status)
-	} else {
-		jobs, err = h.jobUsecase.GetAllJobs(c.Request.Context())
+// GetJobGroup returns the combined progress, speed and ETA of a job group
+// together with a per-agent breakdown
+func (h *JobHandler) GetJobGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job group ID"})
+		return
 	}
 
+	status, err := h.jobUsecase.GetJobGroupStatus(c.Request.Context(), id)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// parseJobFilter reads pagination, filter and sort query parameters:
+// page, page_size, status, agent_id, project_id, hash_type, created_from,
+// created_to (RFC3339), sort and order (asc/desc)
+func parseJobFilter(c *gin.Context) (domain.JobFilter, int, int, error) {
+	page := 1
+	pageSize := 100
+	if p := c.Query("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			page = v
+		}
+	}
+	if s := c.Query("page_size"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 && v <= 500 {
+			pageSize = v
+		}
+	}
+
+	filter := domain.JobFilter{
+		Status:   c.Query("status"),
+		SortDesc: true,
+		Limit:    pageSize,
+		Offset:   (page - 1) * pageSize,
+	}
+
+	if agentID := c.Query("agent_id"); agentID != "" {
+		id, err := uuid.Parse(agentID)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid agent_id")
+		}
+		filter.AgentID = &id
+	}
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		return filter, 0, 0, err
+	}
+	filter.ProjectID = projectID
+	if hashType := c.Query("hash_type"); hashType != "" {
+		v, err := strconv.Atoi(hashType)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid hash_type")
+		}
+		filter.HashType = &v
+	}
+	if v := c.Query("created_from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid created_from, expected RFC3339")
+		}
+		filter.CreatedFrom = &t
+	}
+	if v := c.Query("created_to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, 0, 0, fmt.Errorf("invalid created_to, expected RFC3339")
+		}
+		filter.CreatedTo = &t
+	}
+
+	// tag=a,b or tag=a&tag=b lists jobs having every tag
+	for _, value := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		valid := false
+		for _, column := range domain.JobSortColumns {
+			valid = valid || sortBy == column
+		}
+		if !valid {
+			return filter, 0, 0, fmt.Errorf("invalid sort column, expected one of %s", strings.Join(domain.JobSortColumns, ", "))
+		}
+		filter.SortBy = sortBy
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		filter.SortDesc = false
+	case "desc":
+	default:
+		return filter, 0, 0, fmt.Errorf("invalid order, expected asc or desc")
+	}
+
+	return filter, page, pageSize, nil
+}
+
+func (h *JobHandler) GetAllJobs(c *gin.Context) {
+	filter, page, pageSize, err := parseJobFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobUsecase.ListJobs(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -107,6 +348,8 @@ func (h *JobHandler) GetAllJobs(c *gin.Context) {
 			hashFileID     string
 			wordlistID     string
 			agentID        string
+			groupID        string
+			projectID      string
 			etaStr         string
 			startedAtStr   string
 			completedAtStr string
@@ -122,6 +365,12 @@ func (h *JobHandler) GetAllJobs(c *gin.Context) {
 		} else {
 			wordlistID = ""
 		}
+		if ej.GroupID != nil {
+			groupID = ej.GroupID.String()
+		}
+		if ej.ProjectID != nil {
+			projectID = ej.ProjectID.String()
+		}
 		if ej.AgentID != nil {
 			agentID = ej.AgentID.String()
 		} else {
@@ -161,15 +410,24 @@ func (h *JobHandler) GetAllJobs(c *gin.Context) {
 			"progress":       ej.Progress,
 			"speed":          ej.Speed,
 			"eta":            etaStr,
-			"result":         defaultString(ej.Result, "-"),
+			"result":         defaultString(h.visibleResult(ej.Result), "-"),
 			"created_at":     ej.CreatedAt.Format(time.RFC3339),
 			"updated_at":     ej.UpdatedAt.Format(time.RFC3339),
 			"started_at":     startedAtStr,
 			"completed_at":   completedAtStr,
+			"file_source":    ej.FileSource,
+			"group_id":       groupID,
+			"project_id":     projectID,
+			"tags":           nonNilStrings(ej.Tags),
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": normalized})
+	c.JSON(http.StatusOK, gin.H{
+		"data":      normalized,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
 }
 
 func (h *JobHandler) StartJob(c *gin.Context) {
@@ -180,10 +438,10 @@ func (h *JobHandler) StartJob(c *gin.Context) {
 		return
 	}
 
-	if err := h.jobUsecase.StartJob(c.Request.Context(), id); err != nil {
+	if err := h.jobUsecase.StartJob(actorContext(c, domain.ActorAPI), id); err != nil {
 		// Add detailed error logging
-		log.Printf("❌ Failed to start job %s: %v", id.String(), err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		jobLogger(c, id).Error("Failed to start job %s: %v", id.String(), err)
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -207,13 +465,13 @@ func (h *JobHandler) UpdateJobProgress(c *gin.Context) {
 		ETA      *string `json:"eta,omitempty"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Invalid request body for job progress update %s: %v", id.String(), err)
+		jobLogger(c, id).Warning("Invalid request body for job progress update %s: %v", id.String(), err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	if err := h.jobUsecase.UpdateJobProgress(c.Request.Context(), id, req.Progress, req.Speed); err != nil {
-		log.Printf("Failed to update job progress %s: %v", id.String(), err)
+		jobLogger(c, id).Error("Failed to update job progress %s: %v", id.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -223,7 +481,7 @@ func (h *JobHandler) UpdateJobProgress(c *gin.Context) {
 	if req.ETA != nil {
 		eta = *req.ETA
 	}
-	Hub.BroadcastJobProgress(id.String(), req.Progress, req.Speed, eta, "running")
+	Hub.BroadcastJobProgress(id.String(), req.Progress, req.Progress, req.Speed, eta, "running", nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job progress updated successfully"})
 }
@@ -237,17 +495,22 @@ func (h *JobHandler) CompleteJob(c *gin.Context) {
 	}
 
 	var req struct {
-		Result string `json:"result"`
+		Result string            `json:"result"`
+		Output *domain.JobOutput `json:"output,omitempty"`
+		// The cracks the agent confirmed with --show when the run ended
+		Cracks []domain.JobCrackReport `json:"cracks,omitempty" binding:"dive"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	logger := jobLogger(c, id)
+
 	// Get job details before completion
 	job, err := h.jobUsecase.GetJob(c.Request.Context(), id)
 	if err != nil {
-		log.Printf("Failed to get job %s for completion logging: %v", id.String(), err)
+		logger.Error("Failed to get job %s for completion logging: %v", id.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -255,9 +518,10 @@ func (h *JobHandler) CompleteJob(c *gin.Context) {
 	// Get agent details
 	var agentName string
 	if job.AgentID != nil {
+		logger = logger.With("agent_id", *job.AgentID)
 		agent, err := h.agentUsecase.GetAgent(c.Request.Context(), *job.AgentID)
 		if err != nil {
-			log.Printf("Failed to get agent details for job %s: %v", id.String(), err)
+			logger.Error("Failed to get agent details for job %s: %v", id.String(), err)
 			agentName = "Unknown Agent"
 		} else {
 			agentName = agent.Name
@@ -267,39 +531,34 @@ func (h *JobHandler) CompleteJob(c *gin.Context) {
 	}
 
 	// Log job completion with agent details
-	if req.Result != "" && req.Result != "Password not found - exhausted" {
-		log.Printf("🎯 PASSWORD FOUND: Agent %s found password for job %s (Status: COMPLETED)", agentName, job.Name)
-		log.Printf("   Result: %s", req.Result)
-		log.Printf("   Job ID: %s", job.ID.String())
-		log.Printf("   ⚡ Speed: %d H/s", job.Speed)
-		log.Printf("   Progress: %.2f%%", job.Progress)
+	if domain.IsPasswordFound(req.Result) {
+		logger.Success("🎯 PASSWORD FOUND: Agent %s found password for job %s (Status: CRACKED), result: %s, speed: %d H/s, progress: %.2f%%",
+			agentName, job.Name, req.Result, job.Speed, job.Progress)
 
 		// Check if this is a distributed job and log coordination info
-		if strings.Contains(job.Name, " (Part ") || strings.Contains(job.Name, " (") {
-			log.Printf("COORDINATION: This is a distributed job - stopping other agents...")
+		if job.GroupID != nil {
+			logger.Info("COORDINATION: This is a distributed job - stopping other agents...")
 		}
 	} else {
-		log.Printf("❌ FAILED: Agent %s failed job %s (no password found)", agentName, job.Name)
-		log.Printf("   🔍 Job ID: %s", job.ID.String())
-		log.Printf("   ⚡ Speed: %d H/s", job.Speed)
-		log.Printf("   📊 Progress: %.2f%%", job.Progress)
-		if req.Result != "" {
-			log.Printf("   📝 Reason: %s", req.Result)
-		}
+		logger.Warning("FAILED: Agent %s failed job %s (no password found), speed: %d H/s, progress: %.2f%%, reason: %s",
+			agentName, job.Name, job.Speed, job.Progress, req.Result)
 	}
 
-	if err := h.jobUsecase.CompleteJob(c.Request.Context(), id, req.Result, job.Speed); err != nil {
-		log.Printf("Failed to complete job %s: %v", id.String(), err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	h.saveJobOutput(c, logger, id, req.Output)
+	h.recordVerifiedCracks(c, logger, job, req.Cracks)
+
+	if err := h.jobUsecase.CompleteJob(actorContext(c, domain.ActorAgent), id, req.Result, job.Speed); err != nil {
+		logger.Error("Failed to complete job %s: %v", id.String(), err)
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Update agent status to online
 	if job.AgentID != nil {
 		if err := h.agentUsecase.UpdateAgentStatus(c.Request.Context(), *job.AgentID, "online"); err != nil {
-			log.Printf("⚠️ Failed to update agent status to online for agent %s: %v", agentName, err)
+			logger.Warning("Failed to update agent status to online for agent %s: %v", agentName, err)
 		} else {
-			log.Printf("✅ Successfully updated agent %s status to online", agentName)
+			logger.Success("Successfully updated agent %s status to online", agentName)
 		}
 	}
 
@@ -307,11 +566,15 @@ func (h *JobHandler) CompleteJob(c *gin.Context) {
 	// Get the updated job to get the correct status
 	updatedJob, err := h.jobUsecase.GetJob(c.Request.Context(), id)
 	if err != nil {
-		log.Printf("Failed to get updated job status for broadcasting: %v", err)
-		// Fallback to completed status
-		Hub.BroadcastJobStatus(id.String(), "completed", req.Result)
+		logger.Error("Failed to get updated job status for broadcasting: %v", err)
+		// Fallback to the status the usecase would have set
+		status := domain.JobStatusFailed
+		if domain.IsPasswordFound(req.Result) {
+			status = domain.JobStatusCracked
+		}
+		Hub.BroadcastJobStatus(id.String(), status, h.visibleResult(req.Result))
 	} else {
-		Hub.BroadcastJobStatus(id.String(), updatedJob.Status, req.Result)
+		Hub.BroadcastJobStatus(id.String(), updatedJob.Status, h.visibleResult(req.Result))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job completed successfully"})
@@ -326,17 +589,20 @@ func (h *JobHandler) FailJob(c *gin.Context) {
 	}
 
 	var req struct {
-		Reason string `json:"reason" binding:"required"`
+		Reason string            `json:"reason" binding:"required"`
+		Output *domain.JobOutput `json:"output,omitempty"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	logger := jobLogger(c, id)
+
 	// Get job details before failure
 	job, err := h.jobUsecase.GetJob(c.Request.Context(), id)
 	if err != nil {
-		log.Printf("❌ Failed to get job %s for failure logging: %v", id.String(), err)
+		logger.Error("Failed to get job %s for failure logging: %v", id.String(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -344,9 +610,10 @@ func (h *JobHandler) FailJob(c *gin.Context) {
 	// Get agent details
 	var agentName string
 	if job.AgentID != nil {
+		logger = logger.With("agent_id", *job.AgentID)
 		agent, err := h.agentUsecase.GetAgent(c.Request.Context(), *job.AgentID)
 		if err != nil {
-			log.Printf("⚠️ Failed to get agent details for job %s: %v", id.String(), err)
+			logger.Warning("Failed to get agent details for job %s: %v", id.String(), err)
 			agentName = "Unknown Agent"
 		} else {
 			agentName = agent.Name
@@ -356,24 +623,23 @@ func (h *JobHandler) FailJob(c *gin.Context) {
 	}
 
 	// Log job failure with agent details
-	log.Printf("💥 FAILED: Agent %s failed job %s", agentName, job.Name)
-	log.Printf("   🔍 Job ID: %s", job.ID.String())
-	log.Printf("   ⚡ Speed: %d H/s", job.Speed)
-	log.Printf("   📊 Progress: %.2f%%", job.Progress)
-	log.Printf("   📝 Reason: %s", req.Reason)
-
-	if err := h.jobUsecase.FailJob(c.Request.Context(), id, req.Reason); err != nil {
-		log.Printf("❌ Failed to mark job %s as failed: %v", id.String(), err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	logger.Error("💥 FAILED: Agent %s failed job %s, speed: %d H/s, progress: %.2f%%, reason: %s",
+		agentName, job.Name, job.Speed, job.Progress, req.Reason)
+
+	h.saveJobOutput(c, logger, id, req.Output)
+
+	if err := h.jobUsecase.FailJob(actorContext(c, domain.ActorAgent), id, req.Reason); err != nil {
+		logger.Error("Failed to mark job %s as failed: %v", id.String(), err)
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Update agent status to online
 	if job.AgentID != nil {
 		if err := h.agentUsecase.UpdateAgentStatus(c.Request.Context(), *job.AgentID, "online"); err != nil {
-			log.Printf("⚠️ Failed to update agent status to online for agent %s: %v", agentName, err)
+			logger.Warning("Failed to update agent status to online for agent %s: %v", agentName, err)
 		} else {
-			log.Printf("✅ Successfully updated agent %s status to online", agentName)
+			logger.Success("Successfully updated agent %s status to online", agentName)
 		}
 	}
 
@@ -388,8 +654,8 @@ func (h *JobHandler) PauseJob(c *gin.Context) {
 		return
 	}
 
-	if err := h.jobUsecase.PauseJob(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.jobUsecase.PauseJob(actorContext(c, domain.ActorAPI), id); err != nil {
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -407,13 +673,17 @@ func (h *JobHandler) ResumeJob(c *gin.Context) {
 		return
 	}
 
-	if err := h.jobUsecase.ResumeJob(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.jobUsecase.ResumeJob(actorContext(c, domain.ActorAPI), id); err != nil {
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Broadcast job status change
-	Hub.BroadcastJobStatus(id.String(), "pending", "")
+	status := domain.JobStatusPending
+	if job, err := h.jobUsecase.GetJob(c.Request.Context(), id); err == nil {
+		status = job.Status
+	}
+	Hub.BroadcastJobStatus(id.String(), status, "")
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job resumed successfully"})
 }
@@ -426,14 +696,13 @@ func (h *JobHandler) StopJob(c *gin.Context) {
 		return
 	}
 
-	// Stop job by setting status to failed with stopped reason
-	if err := h.jobUsecase.FailJob(c.Request.Context(), id, "Job stopped by user"); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.jobUsecase.CancelJob(actorContext(c, domain.ActorAPI), id, "Job stopped by user"); err != nil {
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Broadcast job status change
-	Hub.BroadcastJobStatus(id.String(), "failed", "Job stopped by user")
+	Hub.BroadcastJobStatus(id.String(), domain.JobStatusCancelled, "Job stopped by user")
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job stopped successfully"})
 }
@@ -454,8 +723,427 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Job deleted successfully"})
 }
 
+// GetJobEvents returns the status history of a job for a timeline view
+func (h *JobHandler) GetJobEvents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	events, err := h.jobUsecase.GetJobEvents(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+// SetJobTags replaces a job's tags
+func (h *JobHandler) SetJobTags(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req domain.UpdateJobTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.jobUsecase.SetJobTags(c.Request.Context(), id, req.Tags)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"tags": tags}})
+}
+
+func (h *JobHandler) GetJobComments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	comments, err := h.jobUsecase.GetJobComments(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": comments})
+}
+
+// CreateJobComment adds a comment by the logged-in user to a job
+func (h *JobHandler) CreateJobComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req domain.CreateJobCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := &domain.JobComment{Author: c.GetString("username"), Body: req.Body}
+	if userID, _, ok := currentUser(c); ok {
+		comment.AuthorID = &userID
+	}
+	if err := h.jobUsecase.AddJobComment(c.Request.Context(), id, comment); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": comment})
+}
+
+func (h *JobHandler) DeleteJobComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if err := h.jobUsecase.DeleteJobComment(c.Request.Context(), id, commentID); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
+
+// GetJobOutput returns the tail of hashcat's stdout and stderr the agent
+// reported when the job completed or failed
+func (h *JobHandler) GetJobOutput(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	output, err := h.jobUsecase.GetJobOutput(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": output})
+}
+
+// InterruptJob is called by an agent that stopped a running job because it
+// is shutting down. The job goes back to the dispatcher, resuming from the
+// checkpoint the agent sent, if any.
+func (h *JobHandler) InterruptJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req domain.InterruptJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.jobUsecase.InterruptJob(actorContext(c, domain.ActorAgent), id, &req); err != nil {
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	jobLogger(c, id).Info("Job %s interrupted, checkpoint: %d bytes", id.String(), len(req.Checkpoint))
+
+	status := domain.JobStatusInterrupted
+	if job, err := h.jobUsecase.GetJob(c.Request.Context(), id); err == nil {
+		status = job.Status
+	}
+	Hub.BroadcastJobStatus(id.String(), status, "")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job interrupted successfully"})
+}
+
+// GetJobCheckpoint downloads the hashcat restore file an interrupted job
+// resumes from
+func (h *JobHandler) GetJobCheckpoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	checkpoint, err := h.jobUsecase.GetJobCheckpoint(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=hashcat-"+id.String()+".restore")
+	c.Data(http.StatusOK, "application/octet-stream", checkpoint.Data)
+}
+
+// ReportJobCracks receives the hashes an agent's run cracked since its last
+// report and broadcasts each new one as a job_crack event
+func (h *JobHandler) ReportJobCracks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req domain.ReportJobCracksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	agentID, err := uuid.Parse(req.AgentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return
+	}
+
+	cracks, err := h.jobUsecase.RecordJobCracks(c.Request.Context(), id, agentID, req.Cracks)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	for _, crack := range cracks {
+		Hub.BroadcastJobCrack(crack, h.visiblePlaintext(crack.Plaintext))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recorded": len(cracks)})
+}
+
+// GetJobCracks lists the hashes a job cracked, with its time to first crack
+func (h *JobHandler) GetJobCracks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	cracks, err := h.jobUsecase.GetJobCracks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	for i := range cracks.Cracks {
+		cracks.Cracks[i].Plaintext = h.visiblePlaintext(cracks.Cracks[i].Plaintext)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": cracks})
+}
+
+// CompareJobs sets the jobs named by ?ids=a,b,c side by side: run time,
+// keyspace covered, cracks and cost
+func (h *JobHandler) CompareJobs(c *gin.Context) {
+	var ids []uuid.UUID
+	for _, value := range strings.Split(c.Query("ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID: " + value})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	comparison, err := h.jobUsecase.CompareJobs(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": comparison})
+}
+
+// SyncAgent is called by an agent after it got through to the server
+// again. It reports the job it is running and is told whether to keep it;
+// jobs the server thought it was running are handed to other agents.
+func (h *JobHandler) SyncAgent(c *gin.Context) {
+	var req struct {
+		AgentKey string                 `json:"agent_key" binding:"required"`
+		Snapshot *domain.AgentHeartbeat `json:"snapshot,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	agent, err := h.agentUsecase.GetByAgentKey(c.Request.Context(), req.AgentKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+		return
+	}
+
+	result, err := h.jobUsecase.ReconcileAgentJobs(actorContext(c, domain.ActorAgent), agent.ID, req.Snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, id := range result.RequeuedJobs {
+		Hub.BroadcastJobStatus(id.String(), domain.JobStatusInterrupted, "")
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetArchivedJobs lists archived and soft-deleted jobs
+func (h *JobHandler) GetArchivedJobs(c *gin.Context) {
+	jobs, err := h.jobUsecase.GetArchivedJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range jobs {
+		jobs[i].Result = h.visibleResult(jobs[i].Result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": jobs})
+}
+
+// RestoreJob moves an archived or deleted job back into the job list
+func (h *JobHandler) RestoreJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	if err := h.jobUsecase.RestoreJob(c.Request.Context(), id); err != nil {
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job restored successfully"})
+}
+
+// RetryJob re-runs a failed or cancelled job with the same parameters
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	// The body is optional; without it the job is retried on the same agent
+	var req domain.RetryJobRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var agentID *uuid.UUID
+	if req.AgentID != "" {
+		parsed, err := uuid.Parse(req.AgentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+			return
+		}
+		agentID = &parsed
+	}
+
+	job, err := h.jobUsecase.RetryJob(actorContext(c, domain.ActorAPI), id, agentID)
+	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": job})
+}
+
+// actorContext tags the request context with who is changing a job, for the
+// job event log: the logged-in user if there is one, otherwise fallback
+func actorContext(c *gin.Context, fallback string) context.Context {
+	if username := c.GetString("username"); username != "" {
+		return domain.WithActor(c.Request.Context(), "user:"+username)
+	}
+	return domain.WithActor(c.Request.Context(), fallback)
+}
+
+// jobLogger returns a logger tagged with the request and job IDs
+// saveJobOutput stores the hashcat output an agent sent with its report.
+// Losing it must not lose the report, so errors are only logged.
+func (h *JobHandler) saveJobOutput(c *gin.Context, logger *infrastructure.Logger, id uuid.UUID, output *domain.JobOutput) {
+	if output == nil {
+		return
+	}
+	if err := h.jobUsecase.SaveJobOutput(c.Request.Context(), id, output); err != nil {
+		logger.Warning("Failed to save hashcat output for job %s: %v", id.String(), err)
+	}
+}
+
+// recordVerifiedCracks stores the cracks an agent verified when its job
+// ended. Those it already reported while the job ran are ignored.
+func (h *JobHandler) recordVerifiedCracks(c *gin.Context, logger *infrastructure.Logger, job *domain.Job, reports []domain.JobCrackReport) {
+	if len(reports) == 0 || job.AgentID == nil {
+		return
+	}
+	for len(reports) > 0 {
+		batch := reports[:min(len(reports), domain.MaxJobCracksPerReport)]
+		reports = reports[len(batch):]
+
+		cracks, err := h.jobUsecase.RecordJobCracks(c.Request.Context(), job.ID, *job.AgentID, batch)
+		if err != nil {
+			logger.Warning("Failed to record the verified cracks of job %s: %v", job.ID.String(), err)
+			return
+		}
+		for _, crack := range cracks {
+			Hub.BroadcastJobCrack(crack, h.visiblePlaintext(crack.Plaintext))
+		}
+	}
+}
+
+func jobLogger(c *gin.Context, jobID uuid.UUID) *infrastructure.Logger {
+	return infrastructure.ServerLogger.WithContext(c.Request.Context()).With("job_id", jobID)
+}
+
+// jobErrorStatus maps job state errors to 409 and anything else to 500
+func jobErrorStatus(err error) int {
+	if domain.IsJobTransitionError(err) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *JobHandler) AssignJobs(c *gin.Context) {
-	if err := h.jobUsecase.AssignJobsToAgents(c.Request.Context()); err != nil {
+	if err := h.jobUsecase.AssignJobsToAgents(actorContext(c, domain.ActorAPI)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -477,6 +1165,9 @@ func (h *JobHandler) GetJobsByAgentID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range jobs {
+		jobs[i].Result = h.visibleResult(jobs[i].Result)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": jobs})
 }
@@ -503,26 +1194,40 @@ func (h *JobHandler) GetAvailableJobForAgent(c *gin.Context) {
 func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 	// Ambil hashfile dan wordlist dari request
 	var request struct {
-		HashFileID string `json:"hash_file_id"`
-		WordlistID string `json:"wordlist_id"`
+		HashFileID   string `json:"hash_file_id"`
+		WordlistID   string `json:"wordlist_id"`
+		AgentGroupID string `json:"agent_group_id"` // Optional, only use this agent group
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	// Ambil daftar agent
-	agents, err := h.agentUsecase.GetAllAgents(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch agents"})
-		return
-	}
-
-	// Filter hanya agent yang online
 	var onlineAgents []domain.Agent
-	for _, agent := range agents {
-		if agent.Status == "online" {
-			onlineAgents = append(onlineAgents, agent)
+	if request.AgentGroupID != "" {
+		groupID, err := uuid.Parse(request.AgentGroupID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent group ID"})
+			return
+		}
+		onlineAgents, err = h.agentUsecase.GetOnlineGroupAgents(c.Request.Context(), groupID)
+		if err != nil {
+			c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		// Ambil daftar agent
+		agents, err := h.agentUsecase.GetAllAgents(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch agents"})
+			return
+		}
+
+		// Filter hanya agent yang online, outside maintenance windows
+		for _, agent := range agents {
+			if agent.Status == "online" && agent.Maintenance == nil {
+				onlineAgents = append(onlineAgents, agent)
+			}
 		}
 	}
 
@@ -531,7 +1236,8 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Starting parallel job creation with %d online agents", len(onlineAgents))
+	logger := infrastructure.ServerLogger.WithContext(c.Request.Context())
+	logger.Info("Starting parallel job creation with %d online agents", len(onlineAgents))
 
 	// Ambil detail wordlist
 	wordlistID, err := uuid.Parse(request.WordlistID)
@@ -549,7 +1255,7 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 	var totalWords int64
 	if wordlist.WordCount != nil {
 		totalWords = *wordlist.WordCount
-		log.Printf("📝 Wordlist contains %d words (from repository)", totalWords)
+		logger.Info("📝 Wordlist contains %d words (from repository)", totalWords)
 	} else {
 		// Fallback: read file content if word count not available
 		wordlistLines, err := readWordlistFile(wordlist.Path)
@@ -567,7 +1273,7 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 			}
 		}
 		totalWords = int64(len(validWords))
-		log.Printf("📝 Wordlist contains %d valid words (from file)", totalWords)
+		logger.Info("📝 Wordlist contains %d valid words (from file)", totalWords)
 	}
 
 	// Analisis kecepatan agent berdasarkan capabilities
@@ -621,10 +1327,9 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 	}
 
 	// Log distribusi agent
-	log.Printf("🤖 Agent distribution plan:")
 	for _, agentSpeed := range agentSpeeds {
 		wordCount := int64(float64(totalWords) * agentSpeed.Weight)
-		log.Printf("   - %s (%s): Speed=%d, Weight=%.2f, Words=%d",
+		logger.With("agent_id", agentSpeed.Agent.ID).Info("🤖 Agent distribution plan: %s (%s): Speed=%d, Weight=%.2f, Words=%d",
 			agentSpeed.Agent.Name,
 			agentSpeed.Agent.Capabilities,
 			agentSpeed.Speed,
@@ -632,6 +1337,13 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 			wordCount)
 	}
 
+	// Group the jobs so their combined progress can be tracked
+	group, err := h.jobUsecase.CreateJobGroup(c.Request.Context(), fmt.Sprintf("Parallel Job - %s", wordlist.Name))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Create jobs with skip/limit parameters based on agent performance
 	var createdJobs []domain.Job
 	currentSkip := int64(0)
@@ -661,15 +1373,20 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 			Wordlist:   wordlist.OrigName,                      // Use original wordlist name
 			AgentIDs:   []string{agentSpeed.Agent.ID.String()}, // Use AgentIDs for distributed job
 			Name:       fmt.Sprintf("Parallel Job - %s (%s)", wordlist.Name, agentSpeed.Agent.Name),
+			GroupID:    group.ID.String(),
 		})
 		if err != nil {
-			log.Printf("Failed to create job for agent %s: %v", agentSpeed.Agent.ID.String(), err)
+			logger.With("agent_id", agentSpeed.Agent.ID).Error("Failed to create job for agent %s: %v", agentSpeed.Agent.ID.String(), err)
+			if status, ok := quotaExceededStatus(err); ok {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
 			return
 		}
 
 		createdJobs = append(createdJobs, *job)
-		log.Printf("📦 Created job %s for agent %s with skip=%d, limit=%d words (%.1f%%)",
+		logger.With("job_id", job.ID, "agent_id", agentSpeed.Agent.ID).Info("📦 Created job %s for agent %s with skip=%d, limit=%d words (%.1f%%)",
 			job.ID.String(),
 			agentSpeed.Agent.Name,
 			skip,
@@ -680,11 +1397,12 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 		currentSkip += wordCount
 	}
 
-	log.Printf("Successfully created %d parallel jobs", len(createdJobs))
+	logger.With("group_id", group.ID).Info("Successfully created %d parallel jobs", len(createdJobs))
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Parallel jobs created successfully",
 		"data": gin.H{
+			"group_id":    group.ID,
 			"total_jobs":  len(createdJobs),
 			"total_words": totalWords,
 			"agents_used": len(agentSpeeds),
@@ -693,7 +1411,9 @@ func (h *JobHandler) CreateParallelJobs(c *gin.Context) {
 	})
 }
 
-// UpdateJobDataFromAgent receives complete job data from agent and updates database immediately
+// UpdateJobDataFromAgent receives complete job data from agent. Progress
+// reports of a running job are broadcast immediately and written in batches;
+// other changes are saved right away.
 func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -703,12 +1423,15 @@ func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 	}
 
 	var req struct {
-		AgentID    string  `json:"agent_id" binding:"required"`
-		AttackMode int     `json:"attack_mode"`
-		Rules      string  `json:"rules"`
-		Speed      int64   `json:"speed"`
-		ETA        *string `json:"eta,omitempty"`
-		Progress   float64 `json:"progress"`
+		AgentID    string                  `json:"agent_id" binding:"required"`
+		AttackMode int                     `json:"attack_mode"`
+		Rules      string                  `json:"rules"`
+		Speed      int64                   `json:"speed"`
+		ETA        *string                 `json:"eta,omitempty"`
+		Progress   float64                 `json:"progress"`
+		FileSource string                  `json:"file_source,omitempty"`
+		Stats      *domain.JobRuntimeStats `json:"stats,omitempty"`       // hashcat --status-json details
+		PowerWatts float64                 `json:"power_watts,omitempty"` // Draw of the agent's devices, when it can read it
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -730,11 +1453,18 @@ func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 	}
 
 	// Update job with data from agent (only update if provided)
-	if req.AttackMode > 0 {
+	changed := false
+	if req.AttackMode > 0 && req.AttackMode != job.AttackMode {
 		job.AttackMode = req.AttackMode
+		changed = true
 	}
-	if req.Rules != "" {
+	if req.Rules != "" && req.Rules != job.Rules {
 		job.Rules = req.Rules
+		changed = true
+	}
+	if req.FileSource != "" && req.FileSource != job.FileSource {
+		job.FileSource = req.FileSource
+		changed = true
 	}
 	job.Speed = req.Speed
 	job.Progress = req.Progress
@@ -742,6 +1472,7 @@ func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 	// Update agent ID if not already set
 	if job.AgentID == nil {
 		job.AgentID = &agentID
+		changed = true
 	}
 
 	// Parse ETA if provided
@@ -751,10 +1482,24 @@ func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 		}
 	}
 
-	// Update the job in database immediately
-	if err := h.jobUsecase.UpdateJobData(c.Request.Context(), job); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// Add the compute used since the previous report
+	if job.Status == domain.JobStatusRunning {
+		sample := domain.UsageSample{Devices: 1, PowerWatts: req.PowerWatts}
+		if req.Stats != nil && len(req.Stats.Devices) > 0 {
+			sample.Devices = len(req.Stats.Devices)
+		}
+		h.jobUsecase.AccountJobUsage(job, sample)
+	}
+	h.jobUsecase.ApplyRuntimeStats(c.Request.Context(), job, req.Stats)
+
+	if changed || job.Status != domain.JobStatusRunning {
+		// Update the job in database immediately
+		if err := h.jobUsecase.UpdateJobData(c.Request.Context(), job); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		h.jobUsecase.RecordJobProgress(c.Request.Context(), job)
 	}
 
 	// Broadcast real-time update
@@ -762,7 +1507,7 @@ func (h *JobHandler) UpdateJobDataFromAgent(c *gin.Context) {
 	if req.ETA != nil {
 		eta = *req.ETA
 	}
-	Hub.BroadcastJobProgress(id.String(), req.Progress, req.Speed, eta, job.Status)
+	Hub.BroadcastJobProgress(id.String(), req.Progress, job.NormalizedProgress, req.Speed, eta, job.Status, req.Stats)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job data updated successfully"})
 }
@@ -778,65 +1523,65 @@ func readWordlistFile(path string) ([]string, error) {
 
 // GetParallelJobsSummary returns summary of parallel jobs with agent results
 func (h *JobHandler) GetParallelJobsSummary(c *gin.Context) {
-	// Get all jobs with status completed or failed
-	completedJobs, err := h.jobUsecase.GetJobsByStatus(c.Request.Context(), "completed")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get completed jobs"})
-		return
-	}
-
-	failedJobs, err := h.jobUsecase.GetJobsByStatus(c.Request.Context(), "failed")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get failed jobs"})
-		return
+	// Get all jobs with status cracked, completed or failed
+	var allJobs []domain.Job
+	for _, status := range []string{domain.JobStatusCracked, domain.JobStatusCompleted, domain.JobStatusFailed} {
+		jobs, err := h.jobUsecase.GetJobsByStatus(c.Request.Context(), status)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get %s jobs", status)})
+			return
+		}
+		allJobs = append(allJobs, jobs...)
 	}
 
-	allJobs := append(completedJobs, failedJobs...)
-
-	// Group jobs by name (parallel jobs have similar names)
-	jobGroups := make(map[string][]domain.Job)
+	// Group jobs by their job group
+	jobGroups := make(map[uuid.UUID][]domain.Job)
 	for _, job := range allJobs {
-		if strings.Contains(job.Name, "Parallel Job") {
-			baseName := strings.Replace(job.Name, "Parallel Job - ", "", 1)
-			jobGroups[baseName] = append(jobGroups[baseName], job)
+		if job.GroupID != nil {
+			jobGroups[*job.GroupID] = append(jobGroups[*job.GroupID], job)
 		}
 	}
 
 	var summaries []gin.H
-	for baseName, jobs := range jobGroups {
+	for groupID, jobs := range jobGroups {
 		if len(jobs) > 1 { // Only show parallel jobs
+			baseName := groupID.String()
+			if group, err := h.jobUsecase.GetJobGroup(c.Request.Context(), groupID); err == nil {
+				baseName = strings.TrimPrefix(group.Name, "Parallel Job - ")
+			}
+
 			var agentResults []gin.H
 			var successCount, failureCount int
 			var foundPassword string
 
-			for _, job := range jobs {
-				// Get agent details
-				var agentName string
-				if job.AgentID != nil {
-					agent, err := h.agentUsecase.GetAgent(c.Request.Context(), *job.AgentID)
-					if err != nil {
-						agentName = "Unknown Agent"
-					} else {
-						agentName = agent.Name
-					}
-				} else {
+			// Agent names come from the shared lookup cache
+			enriched, err := h.enrichmentService.EnrichJobs(c.Request.Context(), jobs)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			for i, job := range jobs {
+				agentName := enriched[i].AgentName
+				if job.AgentID == nil {
 					agentName = "Unassigned Agent"
 				}
 
 				// Determine result
 				var result string
 				var status string
-				if job.Status == "completed" && job.Result != "" && job.Result != "Password not found - exhausted" {
-					result = fmt.Sprintf("SUCCESS: Found password (%s)", job.Result)
+				jobResult := h.visibleResult(job.Result)
+				if job.Status == domain.JobStatusCracked {
+					result = fmt.Sprintf("SUCCESS: Found password (%s)", jobResult)
 					status = "success"
 					successCount++
-					foundPassword = job.Result
-				} else if job.Status == "completed" {
+					foundPassword = jobResult
+				} else if job.Status == domain.JobStatusCompleted {
 					result = "FAILED: No password found"
 					status = "failed"
 					failureCount++
 				} else {
-					result = fmt.Sprintf("FAILED: %s", job.Result)
+					result = fmt.Sprintf("FAILED: %s", jobResult)
 					status = "failed"
 					failureCount++
 				}
@@ -863,6 +1608,7 @@ func (h *JobHandler) GetParallelJobsSummary(c *gin.Context) {
 			}
 
 			summaries = append(summaries, gin.H{
+				"group_id":       groupID,
 				"wordlist_name":  baseName,
 				"total_agents":   len(jobs),
 				"success_count":  successCount,
@@ -875,22 +1621,22 @@ func (h *JobHandler) GetParallelJobsSummary(c *gin.Context) {
 	}
 
 	// Log summary
-	log.Printf("📊 Parallel Jobs Summary:")
+	logger := infrastructure.ServerLogger.WithContext(c.Request.Context())
 	for _, summary := range summaries {
-		log.Printf("   📋 Wordlist: %s", summary["wordlist_name"])
-		log.Printf("   🎯 Overall: %s", summary["overall_result"])
-		log.Printf("   🤖 Agents: %d total (%d success, %d failed)",
+		groupLogger := logger.With("group_id", summary["group_id"])
+		groupLogger.Debug("📊 Parallel jobs summary: wordlist %s, overall %s, %d agents (%d success, %d failed)",
+			summary["wordlist_name"],
+			summary["overall_result"],
 			summary["total_agents"],
 			summary["success_count"],
 			summary["failure_count"])
 
 		agentResults := summary["agent_results"].([]gin.H)
 		for _, agentResult := range agentResults {
-			log.Printf("      - %s: %s",
+			groupLogger.Debug("Parallel job result of %s: %s",
 				agentResult["agent_name"],
 				agentResult["result"])
 		}
-		log.Printf("")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MaintenanceHandler struct {
+	maintenanceUsecase usecase.MaintenanceUsecase
+}
+
+func NewMaintenanceHandler(maintenanceUsecase usecase.MaintenanceUsecase) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceUsecase: maintenanceUsecase,
+	}
+}
+
+func (h *MaintenanceHandler) GetAllWindows(c *gin.Context) {
+	windows, err := h.maintenanceUsecase.GetAllWindows(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": windows})
+}
+
+// GetAgentsInMaintenance lists the agents in a maintenance window now
+func (h *MaintenanceHandler) GetAgentsInMaintenance(c *gin.Context) {
+	agents, err := h.maintenanceUsecase.GetAgentsInMaintenance(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": agents})
+}
+
+func (h *MaintenanceHandler) CreateWindow(c *gin.Context) {
+	var req domain.MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := h.maintenanceUsecase.CreateWindow(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": window})
+}
+
+func (h *MaintenanceHandler) GetWindow(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	window, err := h.maintenanceUsecase.GetWindow(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": window})
+}
+
+// UpdateWindow replaces the settings of a maintenance window
+func (h *MaintenanceHandler) UpdateWindow(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	var req domain.MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := h.maintenanceUsecase.UpdateWindow(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": window})
+}
+
+func (h *MaintenanceHandler) DeleteWindow(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	if err := h.maintenanceUsecase.DeleteWindow(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted"})
+}
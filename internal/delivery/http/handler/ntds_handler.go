@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NTDSHandler struct {
+	ntdsUsecase usecase.NTDSUsecase
+	uploads     UploadPolicy
+	scanner     domain.UploadScanner // Optional, refuses infected uploads
+}
+
+func NewNTDSHandler(ntdsUsecase usecase.NTDSUsecase) *NTDSHandler {
+	return &NTDSHandler{
+		ntdsUsecase: ntdsUsecase,
+	}
+}
+
+// SetUploadPolicy sets what dumps are accepted and the malware scanner they
+// go through, if any. Its size limit applies to potfiles too.
+func (h *NTDSHandler) SetUploadPolicy(policy UploadPolicy, scanner domain.UploadScanner) {
+	h.uploads = policy
+	h.scanner = scanner
+}
+
+// ImportNTDS imports secretsdump output uploaded as "file". The optional
+// "privileged" form field lists the usernames of domain admins and other
+// privileged accounts, separated by commas or newlines.
+func (h *NTDSHandler) ImportNTDS(c *gin.Context) {
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, src, ok := openUpload(c, h.uploads, h.scanner)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	req := domain.ImportNTDSRequest{
+		Name:   c.PostForm("name"),
+		Domain: c.PostForm("domain"),
+		Privileged: strings.FieldsFunc(c.PostForm("privileged"), func(r rune) bool {
+			return r == ',' || r == '\n' || r == '\r'
+		}),
+		ProjectID: projectID,
+	}
+	ntdsImport, err := h.ntdsUsecase.ImportNTDS(c.Request.Context(), file.Filename, src, &req)
+	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": ntdsImport})
+}
+
+func (h *NTDSHandler) GetImports(c *gin.Context) {
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	imports, err := h.ntdsUsecase.GetImports(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": imports})
+}
+
+func (h *NTDSHandler) GetImport(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	ntdsImport, err := h.ntdsUsecase.GetImport(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": ntdsImport})
+}
+
+func (h *NTDSHandler) DeleteImport(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ntdsUsecase.DeleteImport(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "NTDS import deleted successfully"})
+}
+
+// GetAccounts lists an import's accounts, optionally only those
+// ?cracked=true|false and ?privileged=true|false, a page at a time with
+// ?limit and ?offset
+func (h *NTDSHandler) GetAccounts(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	var filter domain.NTDSAccountFilter
+	for name, target := range map[string]**bool{"cracked": &filter.Cracked, "privileged": &filter.Privileged} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " filter, expected true or false"})
+			return
+		}
+		*target = &parsed
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	accounts, total, err := h.ntdsUsecase.GetAccounts(c.Request.Context(), id, filter)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts, "total": total})
+}
+
+// SetPrivileged replaces which accounts of an import are privileged
+func (h *NTDSHandler) SetPrivileged(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetNTDSPrivilegedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	privileged, err := h.ntdsUsecase.SetPrivileged(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"privileged": privileged}})
+}
+
+// ImportCracked reads a hashcat potfile, or --show output, from the request
+// body and marks the accounts using its hashes cracked
+func (h *NTDSHandler) ImportCracked(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	if h.uploads.MaxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.uploads.MaxSize)
+	}
+	cracked, err := h.ntdsUsecase.ImportCracked(c.Request.Context(), id, c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			refusal := tooLarge(h.uploads.MaxSize)
+			c.JSON(refusal.status, gin.H{"error": refusal.message, "code": refusal.code})
+			return
+		}
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"cracked": cracked}})
+}
+
+// GetReport joins the cracked results back to the import's accounts
+func (h *NTDSHandler) GetReport(c *gin.Context) {
+	id, ok := ntdsImportID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.ntdsUsecase.GetReport(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+func ntdsImportID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid NTDS import ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ProjectArchiveHandler struct {
+	archiveUsecase usecase.ProjectArchiveUsecase
+	maxArchiveSize int64 // Zero for no limit
+}
+
+func NewProjectArchiveHandler(archiveUsecase usecase.ProjectArchiveUsecase) *ProjectArchiveHandler {
+	return &ProjectArchiveHandler{
+		archiveUsecase: archiveUsecase,
+	}
+}
+
+// SetMaxArchiveSize limits the archives ImportProject reads
+func (h *ProjectArchiveHandler) SetMaxArchiveSize(maxSize int64) {
+	h.maxArchiveSize = maxSize
+}
+
+// ExportProject downloads a project as a zip archive for ImportProject on
+// another server, with the content of its files when ?include_files=true
+func (h *ProjectArchiveHandler) ExportProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	includeFiles, _ := strconv.ParseBool(c.Query("include_files"))
+
+	download := &archiveDownload{c: c, filename: fmt.Sprintf("project-%s.zip", id)}
+	if err := h.archiveUsecase.ExportProject(c.Request.Context(), id, includeFiles, download); err != nil {
+		if !download.started {
+			c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		// The archive is cut short, which the client sees as a broken zip
+		infrastructure.ServerLogger.WithContext(c.Request.Context()).Error("Export of project %s failed: %v", id, err)
+		c.Abort()
+	}
+}
+
+// archiveDownload sends the download headers with the first bytes of the
+// archive, so errors before that can still be answered with JSON
+type archiveDownload struct {
+	c        *gin.Context
+	filename string
+	started  bool
+}
+
+func (d *archiveDownload) Write(p []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.c.Header("Content-Type", "application/zip")
+		d.c.Header("Content-Disposition", "attachment; filename="+d.filename)
+		d.c.Status(http.StatusOK)
+	}
+	return d.c.Writer.Write(p)
+}
+
+// ImportProject creates a project from an archive of ExportProject in the
+// request body
+func (h *ProjectArchiveHandler) ImportProject(c *gin.Context) {
+	body := c.Request.Body
+	if h.maxArchiveSize > 0 {
+		body = http.MaxBytesReader(c.Writer, body, h.maxArchiveSize)
+	}
+
+	// Zip files are read from the end, so the archive is spooled to disk
+	spool, err := os.CreateTemp("", "project-import-*.zip")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			refusal := tooLarge(h.maxArchiveSize)
+			c.JSON(refusal.status, gin.H{"error": refusal.message, "code": refusal.code})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.archiveUsecase.ImportProject(c.Request.Context(), spool, size)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
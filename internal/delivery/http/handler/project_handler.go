@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ProjectHandler struct {
+	projectUsecase    usecase.ProjectUsecase
+	suggestionUsecase usecase.SuggestionUsecase
+	complianceUsecase usecase.ComplianceUsecase
+}
+
+func NewProjectHandler(projectUsecase usecase.ProjectUsecase, suggestionUsecase usecase.SuggestionUsecase, complianceUsecase usecase.ComplianceUsecase) *ProjectHandler {
+	return &ProjectHandler{
+		projectUsecase:    projectUsecase,
+		suggestionUsecase: suggestionUsecase,
+		complianceUsecase: complianceUsecase,
+	}
+}
+
+// projectErrorStatus maps project errors the same way as agent group errors
+func projectErrorStatus(err error) int {
+	return agentGroupErrorStatus(err)
+}
+
+// currentUser returns the ID and role set by the auth middleware
+func currentUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return userID, c.GetString("role"), true
+}
+
+// projectIDQuery parses the optional project_id query parameter. The router
+// runs middleware.ProjectAccess in front of the routes that read it.
+func projectIDQuery(c *gin.Context) (*uuid.UUID, error) {
+	value := c.Query("project_id")
+	if value == "" {
+		return nil, nil
+	}
+	projectID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project_id")
+	}
+	return &projectID, nil
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var req domain.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := h.projectUsecase.CreateProject(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": project})
+}
+
+// GetAllProjects lists the projects the caller can see: all of them for
+// admins, the ones they are a member of for anyone else
+func (h *ProjectHandler) GetAllProjects(c *gin.Context) {
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	projects, err := h.projectUsecase.GetProjectsForUser(c.Request.Context(), userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": projects})
+}
+
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	id, ok := h.accessibleProjectID(c)
+	if !ok {
+		return
+	}
+
+	project, err := h.projectUsecase.GetProject(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": project})
+}
+
+// GetProjectSuggestions suggests the next attacks from what the project
+// cracked so far
+func (h *ProjectHandler) GetProjectSuggestions(c *gin.Context) {
+	id, ok := h.accessibleProjectID(c)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.suggestionUsecase.GetProjectSuggestions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
+
+// GetComplianceReport checks the passwords cracked in the project against
+// the configured password policy. POSTed, the body overrides the fields of
+// the policy it has.
+func (h *ProjectHandler) GetComplianceReport(c *gin.Context) {
+	id, ok := h.accessibleProjectID(c)
+	if !ok {
+		return
+	}
+
+	policy := h.complianceUsecase.Policy()
+	if c.Request.Method == http.MethodPost {
+		if err := c.ShouldBindJSON(&policy); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := h.complianceUsecase.GetComplianceReport(c.Request.Context(), &id, policy)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req domain.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := h.projectUsecase.UpdateProject(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": project})
+}
+
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	if err := h.projectUsecase.DeleteProject(c.Request.Context(), id); err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+func (h *ProjectHandler) GetProjectMembers(c *gin.Context) {
+	id, ok := h.accessibleProjectID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.projectUsecase.GetMembers(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+func (h *ProjectHandler) AddProjectMember(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req domain.ProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.projectUsecase.AddMember(c.Request.Context(), projectID, userID); err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := h.projectUsecase.GetMembers(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+func (h *ProjectHandler) RemoveProjectMember(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.projectUsecase.RemoveMember(c.Request.Context(), projectID, userID); err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed from project successfully"})
+}
+
+// accessibleProjectID parses the project ID of the route and checks the
+// caller may see the project, writing the error response when not
+func (h *ProjectHandler) accessibleProjectID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return uuid.Nil, false
+	}
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, false
+	}
+
+	if err := h.projectUsecase.CheckAccess(c.Request.Context(), id, userID, role); err != nil {
+		c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type QuotaHandler struct {
+	quotaUsecase usecase.QuotaUsecase
+}
+
+func NewQuotaHandler(quotaUsecase usecase.QuotaUsecase) *QuotaHandler {
+	return &QuotaHandler{
+		quotaUsecase: quotaUsecase,
+	}
+}
+
+// quotaExceededStatus is the status of a request refused by a quota: 429
+// while there are too many unfinished jobs, which clears as they finish,
+// and 403 for device-hours and storage, which only an admin can raise
+func quotaExceededStatus(err error) (int, bool) {
+	var qErr *domain.QuotaExceededError
+	if !errors.As(err, &qErr) {
+		return 0, false
+	}
+	if qErr.Resource == domain.QuotaRunningJobs {
+		return http.StatusTooManyRequests, true
+	}
+	return http.StatusForbidden, true
+}
+
+// quotaErrorStatus maps quota errors the same way as agent group errors
+func quotaErrorStatus(err error) int {
+	return agentGroupErrorStatus(err)
+}
+
+// quotaSubject parses the :scope and :id route parameters
+func quotaSubject(c *gin.Context) (string, uuid.UUID, bool) {
+	subjectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + c.Param("scope") + " ID"})
+		return "", uuid.Nil, false
+	}
+	return c.Param("scope"), subjectID, true
+}
+
+// GetAllQuotas lists the users and projects with a quota, with their usage
+func (h *QuotaHandler) GetAllQuotas(c *gin.Context) {
+	quotas, err := h.quotaUsecase.GetAllQuotas(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": quotas})
+}
+
+func (h *QuotaHandler) GetQuota(c *gin.Context) {
+	scope, subjectID, ok := quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	quota, err := h.quotaUsecase.GetQuota(c.Request.Context(), scope, subjectID)
+	if err != nil {
+		c.JSON(quotaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": quota})
+}
+
+func (h *QuotaHandler) SetQuota(c *gin.Context) {
+	scope, subjectID, ok := quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota, err := h.quotaUsecase.SetQuota(c.Request.Context(), scope, subjectID, &req)
+	if err != nil {
+		c.JSON(quotaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": quota})
+}
+
+func (h *QuotaHandler) DeleteQuota(c *gin.Context) {
+	scope, subjectID, ok := quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	if err := h.quotaUsecase.DeleteQuota(c.Request.Context(), scope, subjectID); err != nil {
+		c.JSON(quotaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quota removed"})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RecommendationHandler struct {
+	recommendationUsecase usecase.RecommendationUsecase
+}
+
+func NewRecommendationHandler(recommendationUsecase usecase.RecommendationUsecase) *RecommendationHandler {
+	return &RecommendationHandler{recommendationUsecase: recommendationUsecase}
+}
+
+// GetWordlistRecommendations ranks wordlists and rule files for a new job
+// by their history: hash_type is required, hash_file_id or source name the
+// origin of the hashes
+func (h *RecommendationHandler) GetWordlistRecommendations(c *gin.Context) {
+	hashType, err := strconv.Atoi(c.Query("hash_type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing hash_type"})
+		return
+	}
+	var hashFileID *uuid.UUID
+	if value := c.Query("hash_file_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash file ID"})
+			return
+		}
+		hashFileID = &id
+	}
+
+	recommendations, err := h.recommendationUsecase.RecommendWordlists(c.Request.Context(), hashType, hashFileID, c.Query("source"))
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": recommendations})
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ResultAccessHandler struct {
+	resultAccessUsecase usecase.ResultAccessUsecase
+}
+
+func NewResultAccessHandler(resultAccessUsecase usecase.ResultAccessUsecase) *ResultAccessHandler {
+	return &ResultAccessHandler{
+		resultAccessUsecase: resultAccessUsecase,
+	}
+}
+
+// RevealJobResult returns a job's cracked password in the clear to admins
+// and users with the reveal permission. Every reveal is logged.
+func (h *ResultAccessHandler) RevealJobResult(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	revealed, err := h.resultAccessUsecase.RevealResult(c.Request.Context(), id, domain.ResultViewer{
+		UserID:    userID,
+		Username:  c.GetString("username"),
+		Role:      role,
+		IPAddress: c.ClientIP(),
+	})
+	if errors.Is(err, domain.ErrRevealForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "REVEAL_FORBIDDEN"})
+		return
+	}
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": revealed})
+}
+
+// GetReveals lists the reveal audit log, optionally of one job_id, limited
+// by limit
+func (h *ResultAccessHandler) GetReveals(c *gin.Context) {
+	var jobID *uuid.UUID
+	if value := c.Query("job_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job_id"})
+			return
+		}
+		jobID = &id
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	reveals, err := h.resultAccessUsecase.GetReveals(c.Request.Context(), jobID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reveals})
+}
+
+func (h *ResultAccessHandler) GetRevealGrants(c *gin.Context) {
+	grants, err := h.resultAccessUsecase.GetRevealGrants(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": grants})
+}
+
+// GrantReveal gives a user the permission to reveal cracked passwords
+func (h *ResultAccessHandler) GrantReveal(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	grant, err := h.resultAccessUsecase.GrantReveal(c.Request.Context(), userID, c.GetString("username"))
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": grant})
+}
+
+func (h *ResultAccessHandler) RevokeReveal(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.resultAccessUsecase.RevokeReveal(c.Request.Context(), userID); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reveal permission revoked"})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchUsecase usecase.SearchUsecase
+	redactResults bool // Mask cracked passwords in job matches, and don't match them
+}
+
+func NewSearchHandler(searchUsecase usecase.SearchUsecase) *SearchHandler {
+	return &SearchHandler{
+		searchUsecase: searchUsecase,
+	}
+}
+
+// SetResultRedaction masks cracked passwords in the matched text of jobs,
+// and leaves them out of what the query is matched against
+func (h *SearchHandler) SetResultRedaction(enabled bool) {
+	h.redactResults = enabled
+}
+
+// Search searches jobs (names and cracked results), agents, wordlists and
+// hash files for the global search box
+func (h *SearchHandler) Search(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	results, err := h.searchUsecase.Search(c.Request.Context(), c.Query("q"), limit, !h.redactResults)
+	if err != nil {
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if h.redactResults {
+		for i := range results {
+			if results[i].Type == "job" {
+				results[i].Match = domain.RedactResult(results[i].Match)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// clientContext is the request context with where the request comes from,
+// recorded on the sessions it starts
+func clientContext(c *gin.Context) context.Context {
+	return domain.WithClientInfo(c.Request.Context(), domain.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	})
+}
+
+// currentSession returns the session of the request's access token
+func currentSession(c *gin.Context) (uuid.UUID, bool) {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return uuid.Nil, false
+	}
+	jwtClaims, ok := claims.(*domain.JWTClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(jwtClaims.SessionID)
+	return id, err == nil
+}
+
+// sessionError answers a failed session request
+func sessionError(c *gin.Context, err error) {
+	if _, ok := err.(*domain.UserNotFoundError); ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+}
+
+// listSessions answers the active sessions of a user, marking the one of
+// the request
+func (h *AuthHandler) listSessions(c *gin.Context, userID uuid.UUID) {
+	sessions, err := h.authUsecase.GetSessions(c.Request.Context(), userID)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	if current, ok := currentSession(c); ok {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == current
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// GetMySessions lists the logged-in user's active sessions
+func (h *AuthHandler) GetMySessions(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	h.listSessions(c, userID)
+}
+
+// RevokeMySession logs the logged-in user out of one of their sessions
+func (h *AuthHandler) RevokeMySession(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	if err := h.authUsecase.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		sessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeMyOtherSessions logs the logged-in user out everywhere but the
+// session making the request
+func (h *AuthHandler) RevokeMyOtherSessions(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	var keep *uuid.UUID
+	if current, ok := currentSession(c); ok {
+		keep = &current
+	}
+
+	revoked, err := h.authUsecase.RevokeSessions(c.Request.Context(), userID, keep)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+}
+
+// GetUserSessions lists a user's active sessions for an admin
+func (h *AuthHandler) GetUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	h.listSessions(c, userID)
+}
+
+// RevokeUserSession revokes one of a user's sessions for an admin
+func (h *AuthHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	if err := h.authUsecase.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		sessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeUserSessions logs a user out everywhere for an admin, such as when
+// their password may have leaked
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	revoked, err := h.authUsecase.RevokeSessions(c.Request.Context(), userID, nil)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sessions revoked", "revoked": revoked})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/infrastructure/slack"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSlackRequestSize is well over what Slack sends, interaction payloads
+// repeat the whole message the button was in
+const maxSlackRequestSize = 1 << 20
+
+type SlackHandler struct {
+	chatOpsUsecase usecase.ChatOpsUsecase
+	signingSecret  string
+}
+
+// NewSlackHandler answers the slash command and buttons of a Slack app,
+// refusing requests not signed with its signing secret
+func NewSlackHandler(chatOpsUsecase usecase.ChatOpsUsecase, signingSecret string) *SlackHandler {
+	return &SlackHandler{chatOpsUsecase: chatOpsUsecase, signingSecret: signingSecret}
+}
+
+// Command answers a slash command, e.g. /hashcat jobs running
+func (h *SlackHandler) Command(c *gin.Context) {
+	form, ok := h.verifiedForm(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.chatOpsUsecase.HandleCommand(c.Request.Context(), slack.ParseCommand(form))
+	if err != nil {
+		infrastructure.ServerLogger.WithContext(c.Request.Context()).Error("Failed to answer Slack command %q: %v", form.Get("text"), err)
+		message = &domain.ChatMessage{Text: "Something went wrong on the server, try again later."}
+	}
+	c.JSON(http.StatusOK, slack.Render(message))
+}
+
+// Interaction acknowledges a button press right away, as Slack wants within
+// three seconds, then posts the answer to the response URL of the message
+func (h *SlackHandler) Interaction(c *gin.Context) {
+	form, ok := h.verifiedForm(c)
+	if !ok {
+		return
+	}
+	interaction, err := slack.ParseInteraction(form)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		logger := infrastructure.ServerLogger.WithContext(ctx)
+		for _, action := range interaction.Actions {
+			message, err := h.chatOpsUsecase.HandleAction(ctx, action)
+			if err != nil {
+				logger.Error("Failed to answer Slack action %s on %s: %v", action.ActionID, action.Value, err)
+				message = &domain.ChatMessage{Text: "Something went wrong on the server, try again later."}
+			}
+			if err := slack.Respond(ctx, interaction.ResponseURL, message); err != nil {
+				logger.Warning("Failed to answer Slack action %s: %v", action.ActionID, err)
+			}
+		}
+	}()
+}
+
+// verifiedForm reads the form body of a request signed by Slack, answering
+// unsigned requests with 401
+func (h *SlackHandler) verifiedForm(c *gin.Context) (url.Values, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSlackRequestSize+1))
+	if err != nil || len(body) > maxSlackRequestSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request too large"})
+		return nil, false
+	}
+	if err := slack.Verify(h.signingSecret, c.Request.Header, body, time.Now()); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form body"})
+		return nil, false
+	}
+	return form, true
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ssoCookie keeps the state and nonce of a login in the browser until
+	// the provider sends it back
+	ssoCookie       = "hashcat_sso"
+	ssoCookiePath   = "/api/v1/auth/oidc"
+	ssoCookieMaxAge = 10 * 60
+)
+
+type SSOHandler struct {
+	ssoUsecase   usecase.SSOUsecase
+	postLoginURL string
+}
+
+// NewSSOHandler handles single sign-on logins. With a postLoginURL the
+// callback sends the browser there with the tokens in the URL fragment;
+// without, it answers like POST /auth/login.
+func NewSSOHandler(ssoUsecase usecase.SSOUsecase, postLoginURL string) *SSOHandler {
+	return &SSOHandler{ssoUsecase: ssoUsecase, postLoginURL: postLoginURL}
+}
+
+// BeginLogin sends the browser to the identity provider
+func (h *SSOHandler) BeginLogin(c *gin.Context) {
+	start, err := h.ssoUsecase.BeginLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "SSO_ERROR"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoCookie, start.State+"."+start.Nonce, ssoCookieMaxAge, ssoCookiePath, "", isHTTPS(c), true)
+	c.Redirect(http.StatusFound, start.URL)
+}
+
+// Callback logs in the user the identity provider sent back
+func (h *SSOHandler) Callback(c *gin.Context) {
+	cookie, _ := c.Cookie(ssoCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoCookie, "", -1, ssoCookiePath, "", isHTTPS(c), true)
+
+	if providerError := c.Query("error"); providerError != "" {
+		message := providerError
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		h.fail(c, http.StatusUnauthorized, "identity provider refused the login: "+message)
+		return
+	}
+
+	state, nonce, ok := strings.Cut(cookie, ".")
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		h.fail(c, http.StatusBadRequest, "login expired or was started in another browser, try again")
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		h.fail(c, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	response, err := h.ssoUsecase.CompleteLogin(clientContext(c), code, nonce)
+	if err != nil {
+		if _, ok := err.(*domain.AuthenticationError); ok {
+			h.fail(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		h.fail(c, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if h.postLoginURL == "" {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	fragment := url.Values{
+		"token":         {response.Token},
+		"refresh_token": {response.RefreshToken},
+		"expires_at":    {strconv.FormatInt(response.ExpiresAt.Unix(), 10)},
+	}
+	if response.TwoFactorRequired {
+		fragment.Set("two_factor_required", "true")
+	}
+	if response.TwoFactorSetupRequired {
+		fragment.Set("two_factor_setup_required", "true")
+	}
+	c.Redirect(http.StatusFound, h.postLoginURL+"#"+fragment.Encode())
+}
+
+// fail answers a failed callback, sending the browser back to the frontend
+// with the error when there is one
+func (h *SSOHandler) fail(c *gin.Context, status int, message string) {
+	if h.postLoginURL == "" {
+		c.JSON(status, gin.H{"error": message, "code": "SSO_ERROR"})
+		return
+	}
+	c.Redirect(http.StatusFound, h.postLoginURL+"#"+url.Values{"error": {message}}.Encode())
+}
+
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	statsUsecase usecase.StatsUsecase
+}
+
+func NewStatsHandler(statsUsecase usecase.StatsUsecase) *StatsHandler {
+	return &StatsHandler{
+		statsUsecase: statsUsecase,
+	}
+}
+
+// GetClusterStats returns agent, job and cracking totals for dashboards.
+// The numbers are cached for a short while; generated_at tells their age.
+func (h *StatsHandler) GetClusterStats(c *gin.Context) {
+	stats, err := h.statsUsecase.GetClusterStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// GetCostReport returns the device-hours, energy and cost of jobs grouped
+// by group_by (job, project or agent, default project), optionally limited
+// to jobs started between from and to (RFC3339)
+func (h *StatsHandler) GetCostReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", domain.CostGroupProject)
+
+	var from, to *time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected RFC3339"})
+			return
+		}
+		from = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected RFC3339"})
+			return
+		}
+		to = &t
+	}
+
+	report, err := h.statsUsecase.GetCostReport(c.Request.Context(), groupBy, from, to)
+	if err != nil {
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
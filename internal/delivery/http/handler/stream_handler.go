@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamKeepAlive is how often an idle event stream gets a comment line, so
+// proxies don't close it for inactivity
+const streamKeepAlive = 15 * time.Second
+
+// eventTopics are the event types clients can subscribe to
+var eventTopics = map[string]bool{
+	"job_progress": true,
+	"job_status":   true,
+	"job_crack":    true,
+	"agent_status": true,
+	"agent_speed":  true,
+	"agent_logs":   true,
+}
+
+// optInTopics are only sent to clients that ask for them by name, since
+// they are too chatty for a dashboard that wants everything else
+var optInTopics = map[string]bool{
+	"agent_logs": true,
+}
+
+// subscription narrows the events a realtime client receives. A nil
+// subscription receives everything except opt-in topics.
+type subscription struct {
+	topics  map[string]bool // Event types, empty for all
+	jobID   string          // Only job events of this job
+	agentID string          // Only agent events of this agent
+}
+
+// parseSubscription reads the topics, job_id and agent_id query parameters.
+// topics takes a comma separated list and may be repeated.
+func parseSubscription(c *gin.Context) (*subscription, error) {
+	sub := &subscription{topics: make(map[string]bool)}
+	for _, value := range c.QueryArray("topics") {
+		for _, topic := range strings.Split(value, ",") {
+			topic = strings.TrimSpace(topic)
+			if topic == "" {
+				continue
+			}
+			if !eventTopics[topic] {
+				return nil, fmt.Errorf("unknown topic %q", topic)
+			}
+			sub.topics[topic] = true
+		}
+	}
+
+	if id := c.Query("job_id"); id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid job_id")
+		}
+		sub.jobID = id
+	}
+	if id := c.Query("agent_id"); id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid agent_id")
+		}
+		sub.agentID = id
+	}
+	return sub, nil
+}
+
+// matches reports whether message should be sent to the subscriber. The
+// connection greeting always goes out; an ID filter only applies to events
+// that carry that ID, so job_id doesn't hide agent events.
+func (s *subscription) matches(message WebSocketMessage) bool {
+	if message.Type == "connection" {
+		return true
+	}
+	if optInTopics[message.Type] && (s == nil || !s.topics[message.Type]) {
+		return false
+	}
+	if s == nil {
+		return true
+	}
+	if len(s.topics) > 0 && !s.topics[message.Type] {
+		return false
+	}
+
+	data, ok := message.Data.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if id, ok := data["job_id"].(string); ok && s.jobID != "" && id != s.jobID {
+		return false
+	}
+	if id, ok := data["agent_id"].(string); ok && s.agentID != "" && id != s.agentID {
+		return false
+	}
+	return true
+}
+
+// StreamHandler serves the hub's events as Server-Sent Events, for clients
+// behind proxies that block WebSockets
+type StreamHandler struct {
+	hub *WebSocketHub
+}
+
+func NewStreamHandler() *StreamHandler {
+	return &StreamHandler{hub: Hub}
+}
+
+// Stream sends every hub event as an SSE event named after its type, with
+// the same JSON body as the WebSocket message
+func (h *StreamHandler) Stream(c *gin.Context) {
+	sub, err := parseSubscription(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client := &WebSocketClient{
+		send:   make(chan WebSocketMessage, 256),
+		hub:    h.hub,
+		id:     "sse-" + c.ClientIP() + "-" + time.Now().Format("20060102150405"),
+		filter: sub,
+	}
+	h.hub.register <- client
+	defer func() { h.hub.unregister <- client }()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case message, ok := <-client.send:
+			if !ok {
+				// The hub dropped us for falling behind
+				infrastructure.ServerLogger.Warning("Event stream client %s too slow, closing", client.id)
+				return
+			}
+			c.SSEvent(message.Type, message)
+			c.Writer.Flush()
+			if message.Type == "server_shutdown" {
+				return
+			}
+
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TenantHandler struct {
+	tenantUsecase usecase.TenantUsecase
+}
+
+func NewTenantHandler(tenantUsecase usecase.TenantUsecase) *TenantHandler {
+	return &TenantHandler{tenantUsecase: tenantUsecase}
+}
+
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req domain.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantUsecase.CreateTenant(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": tenant})
+}
+
+func (h *TenantHandler) GetAllTenants(c *gin.Context) {
+	tenants, err := h.tenantUsecase.GetAllTenants(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tenants})
+}
+
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	id, ok := tenantIDParam(c)
+	if !ok {
+		return
+	}
+
+	tenant, err := h.tenantUsecase.GetTenant(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tenant})
+}
+
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	id, ok := tenantIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantUsecase.UpdateTenant(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tenant})
+}
+
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	id, ok := tenantIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tenantUsecase.DeleteTenant(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant deleted successfully"})
+}
+
+// GetTenantStats counts the tenant's users, agents, jobs and files, with
+// what its quota counts
+func (h *TenantHandler) GetTenantStats(c *gin.Context) {
+	id, ok := tenantIDParam(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.tenantUsecase.GetStats(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// AddTenantUser moves a user into the tenant
+func (h *TenantHandler) AddTenantUser(c *gin.Context) {
+	h.member(c, "userId", "Invalid user ID", h.tenantUsecase.AddUser, "User added to tenant successfully")
+}
+
+// RemoveTenantUser takes a user of the tenant out of tenants
+func (h *TenantHandler) RemoveTenantUser(c *gin.Context) {
+	h.member(c, "userId", "Invalid user ID", h.tenantUsecase.RemoveUser, "User removed from tenant successfully")
+}
+
+// AddTenantAgent dedicates an agent to the tenant
+func (h *TenantHandler) AddTenantAgent(c *gin.Context) {
+	h.member(c, "agentId", "Invalid agent ID", h.tenantUsecase.AddAgent, "Agent added to tenant successfully")
+}
+
+// RemoveTenantAgent shares an agent of the tenant with every tenant again
+func (h *TenantHandler) RemoveTenantAgent(c *gin.Context) {
+	h.member(c, "agentId", "Invalid agent ID", h.tenantUsecase.RemoveAgent, "Agent removed from tenant successfully")
+}
+
+// member runs change on the tenant and the user or agent in param
+func (h *TenantHandler) member(c *gin.Context, param, invalid string,
+	change func(ctx context.Context, tenantID, id uuid.UUID) error, message string) {
+	tenantID, ok := tenantIDParam(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid})
+		return
+	}
+
+	if err := change(c.Request.Context(), tenantID, id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func tenantIDParam(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
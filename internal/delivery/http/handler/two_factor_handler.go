@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// twoFactorError answers a failed two-factor request. A wrong code is
+// refused without 401, which would end the caller's session.
+func twoFactorError(c *gin.Context, err error) {
+	switch err.(type) {
+	case *domain.AuthenticationError:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "INVALID_TWO_FACTOR_CODE"})
+	case *domain.UserNotFoundError:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+	}
+}
+
+// GetTwoFactorStatus tells the logged-in user whether they use two-factor
+// authentication and whether the policy requires it
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	status, err := h.authUsecase.GetTwoFactorStatus(c.Request.Context(), userID)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// EnrollTwoFactor starts the enrollment, returning the secret and the
+// otpauth:// URI for the authenticator app
+func (h *AuthHandler) EnrollTwoFactor(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	enrollment, err := h.authUsecase.EnrollTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+// EnableTwoFactor confirms the enrollment with a code, returning the
+// recovery codes and the tokens of a new session
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activation, err := h.authUsecase.EnableTwoFactor(clientContext(c), userID, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": activation})
+}
+
+// VerifyTwoFactor confirms a session that skipped the second factor, such
+// as a single sign-on login, with a current code or a recovery code
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var current *uuid.UUID
+	if id, ok := currentSession(c); ok {
+		current = &id
+	}
+
+	response, err := h.authUsecase.VerifyTwoFactor(clientContext(c), userID, current, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// DisableTwoFactor turns two-factor authentication off with a current code
+// or a recovery code
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authUsecase.DisableTwoFactor(c.Request.Context(), userID, req.Code); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, given a current code
+// or a recovery code
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authUsecase.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"recovery_codes": codes}})
+}
+
+// ResetTwoFactor removes the second factor of a user who lost it (admin only)
+func (h *AuthHandler) ResetTwoFactor(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+
+	if err := h.authUsecase.ResetTwoFactor(c.Request.Context(), id); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+// GetTwoFactorPolicy returns who must use two-factor authentication
+func (h *AuthHandler) GetTwoFactorPolicy(c *gin.Context) {
+	policy, err := h.authUsecase.GetTwoFactorPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdateTwoFactorPolicy changes who must use two-factor authentication
+func (h *AuthHandler) UpdateTwoFactorPolicy(c *gin.Context) {
+	var policy domain.TwoFactorPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.authUsecase.UpdateTwoFactorPolicy(c.Request.Context(), &policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": updated})
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is room for the multipart framing around an upload
+// when the request body is capped at an endpoint's maximum file size
+const multipartOverhead = 1 << 20
+
+// Content types uploads are sniffed as
+const (
+	ContentTypeText   = "text/plain"
+	ContentTypeBinary = "application/octet-stream"
+	ContentTypeHccapx = "application/x-hccapx"
+	ContentTypePcap   = "application/vnd.tcpdump.pcap"
+	ContentTypePcapng = "application/x-pcapng"
+)
+
+// UploadPolicy is what one upload endpoint accepts
+type UploadPolicy struct {
+	MaxSize    int64    // Largest file in bytes, 0 for no limit
+	Extensions []string // Allowed file extensions such as ".txt", any when empty
+	MIMETypes  []string // Allowed sniffed content types, any when empty
+}
+
+// UploadPolicies are the rules hash file and wordlist uploads are checked
+// against before they are stored
+type UploadPolicies struct {
+	HashFiles UploadPolicy
+	Wordlists UploadPolicy
+	Scanner   domain.UploadScanner // Optional, refuses infected files
+}
+
+// fileSignatures are the magic bytes of the binary formats uploads are
+// told apart by
+var fileSignatures = []struct {
+	magic       []byte
+	contentType string
+}{
+	{[]byte("HCPX"), ContentTypeHccapx},
+	{[]byte{0xd4, 0xc3, 0xb2, 0xa1}, ContentTypePcap},
+	{[]byte{0xa1, 0xb2, 0xc3, 0xd4}, ContentTypePcap},
+	{[]byte{0x4d, 0x3c, 0xb2, 0xa1}, ContentTypePcap},
+	{[]byte{0xa1, 0xb2, 0x3c, 0x4d}, ContentTypePcap},
+	{[]byte{0x0a, 0x0d, 0x0d, 0x0a}, ContentTypePcapng},
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xce}, "application/x-mach-binary"},
+	{[]byte{0xfe, 0xed, 0xfa, 0xcf}, "application/x-mach-binary"},
+	{[]byte{0xce, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+	{[]byte{0xcf, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+}
+
+// binaryExtensions are the extensions of the binary formats hashcat reads
+// and what their content must be sniffed as. Files with any other
+// extension must be text, so a renamed executable or archive is refused.
+var binaryExtensions = map[string][]string{
+	".hccapx": {ContentTypeHccapx},
+	".hccap":  {ContentTypeBinary},
+	".pcap":   {ContentTypePcap, ContentTypePcapng},
+	".cap":    {ContentTypePcap, ContentTypePcapng},
+}
+
+// SniffContentType returns the content type of a file from its first
+// bytes. Any text is ContentTypeText.
+func SniffContentType(header []byte) string {
+	detected := http.DetectContentType(header)
+	if strings.HasPrefix(detected, "text/") {
+		return ContentTypeText
+	}
+	for _, signature := range fileSignatures {
+		if bytes.HasPrefix(header, signature.magic) {
+			return signature.contentType
+		}
+	}
+	if i := strings.Index(detected, ";"); i >= 0 {
+		detected = detected[:i]
+	}
+	return detected
+}
+
+// uploadRefusal is why an upload was refused, answered as
+// {"error": message, "code": code}
+type uploadRefusal struct {
+	status  int
+	code    string
+	message string
+}
+
+// check returns why a file named filename starting with header is refused,
+// or nil
+func (p UploadPolicy) check(filename string, size int64, header []byte) *uploadRefusal {
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return tooLarge(p.MaxSize)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(p.Extensions) > 0 && !containsFold(p.Extensions, ext) {
+		return &uploadRefusal{http.StatusUnsupportedMediaType, "FILE_TYPE_NOT_ALLOWED",
+			fmt.Sprintf("Files with extension %q are not accepted here, allowed: %s", ext, strings.Join(p.Extensions, ", "))}
+	}
+
+	contentType := SniffContentType(header)
+	if len(p.MIMETypes) > 0 && !containsFold(p.MIMETypes, contentType) {
+		return &uploadRefusal{http.StatusUnsupportedMediaType, "FILE_TYPE_NOT_ALLOWED",
+			fmt.Sprintf("File content is %s, allowed: %s", contentType, strings.Join(p.MIMETypes, ", "))}
+	}
+
+	expected, ok := binaryExtensions[ext]
+	if !ok {
+		expected = []string{ContentTypeText}
+	}
+	if !containsFold(expected, contentType) {
+		return &uploadRefusal{http.StatusUnsupportedMediaType, "FILE_CONTENT_MISMATCH",
+			fmt.Sprintf("File content is %s, which doesn't match its %q extension", contentType, ext)}
+	}
+	return nil
+}
+
+func tooLarge(maxSize int64) *uploadRefusal {
+	return &uploadRefusal{http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE",
+		fmt.Sprintf("File is larger than the %d byte limit", maxSize)}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// openUpload opens the request's "file" form file once it passes policy
+// and the scanner. When it doesn't, the response is written and ok is
+// false.
+func openUpload(c *gin.Context, policy UploadPolicy, scanner domain.UploadScanner) (file *multipart.FileHeader, src multipart.File, ok bool) {
+	refuse := func(r *uploadRefusal) {
+		c.JSON(r.status, gin.H{"error": r.message, "code": r.code})
+	}
+
+	// Stop reading oversized requests instead of spooling them to disk
+	if policy.MaxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, policy.MaxSize+multipartOverhead)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			refuse(tooLarge(policy.MaxSize))
+			return nil, nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, nil, false
+	}
+
+	src, err = file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+		return nil, nil, false
+	}
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(src, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		src.Close()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+		return nil, nil, false
+	}
+	if refusal := policy.check(file.Filename, file.Size, header[:n]); refusal != nil {
+		src.Close()
+		refuse(refusal)
+		return nil, nil, false
+	}
+
+	if scanner != nil {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			src.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+			return nil, nil, false
+		}
+		if err := scanner.Scan(c.Request.Context(), src); err != nil {
+			src.Close()
+			var infected *domain.InfectedFileError
+			if errors.As(err, &infected) {
+				refuse(&uploadRefusal{http.StatusUnprocessableEntity, "FILE_INFECTED",
+					fmt.Sprintf("File was rejected by the malware scanner: %s", infected.Signature)})
+				return nil, nil, false
+			}
+			refuse(&uploadRefusal{http.StatusServiceUnavailable, "SCAN_UNAVAILABLE",
+				fmt.Sprintf("File could not be scanned for malware: %v", err)})
+			return nil, nil, false
+		}
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		src.Close()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+		return nil, nil, false
+	}
+	return file, src, true
+}
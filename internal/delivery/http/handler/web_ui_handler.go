@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	webUIIndex = "index.html"
+	// webUIAssetsDir holds the build's content-hashed files, which never change
+	webUIAssetsDir = "assets/"
+)
+
+// WebUIHandler serves the dashboard's production build
+type WebUIHandler struct {
+	files fs.FS
+}
+
+// NewWebUIHandler serves the web UI from files, the contents of the
+// frontend's dist directory
+func NewWebUIHandler(files fs.FS) *WebUIHandler {
+	return &WebUIHandler{files: files}
+}
+
+// Serve answers requests no route matched. Files of the build are served
+// as they are; other page paths get index.html, so the client-side router
+// handles them. API paths and missing files answer 404.
+func (h *WebUIHandler) Serve(c *gin.Context) {
+	urlPath := c.Request.URL.Path
+	if strings.HasPrefix(urlPath, "/api/") || urlPath == "/ws" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(urlPath), "/")
+	if name == "" {
+		name = webUIIndex
+	}
+	if h.serveFile(c, name) {
+		return
+	}
+	// Paths with an extension are files that don't exist, not pages
+	if path.Ext(name) != "" || !h.serveFile(c, webUIIndex) {
+		c.String(http.StatusNotFound, "404 page not found")
+	}
+}
+
+// serveFile serves a file of the build with its cache headers, reporting
+// false when there is no such file
+func (h *WebUIHandler) serveFile(c *gin.Context, name string) bool {
+	file, err := h.files.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case name == webUIIndex:
+		// index.html names the current assets, browsers check it every time
+		c.Header("Cache-Control", "no-cache")
+	case strings.HasPrefix(name, webUIAssetsDir):
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	default:
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), content)
+	return true
+}
@@ -1,92 +1,39 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow connections from localhost in development
-		origin := r.Header.Get("Origin")
-		host := r.Host
-		
-		// Allow connections from various localhost origins
-		allowedOrigins := []string{
-			"http://localhost:3000",
-			"http://localhost:5173",
-			"http://127.0.0.1:3000",
-			"http://127.0.0.1:5173",
-			"http://[::1]:3000",
-			"http://[::1]:5173",
-			"", // Empty origin for direct connections
-		}
-		
-		// Add frontend URL from environment variable
-		if frontendURL := os.Getenv("HASHCAT_FRONTEND_URL"); frontendURL != "" {
-			allowedOrigins = append(allowedOrigins, frontendURL)
-		}
-		
-		// Check if origin is in allowed list
-		for _, allowed := range allowedOrigins {
-			if origin == allowed {
-				return true
-			}
-		}
-		
-		// Allow connections from same host (for direct access)
-		serverHost := os.Getenv("HASHCAT_SERVER_HOST")
-		serverPort := os.Getenv("HASHCAT_SERVER_PORT")
-		if serverHost == "" {
-			serverHost = "0.0.0.0"
-		}
-		if serverPort == "" {
-			serverPort = "1337"
-		}
-		
-		allowedHosts := []string{
-			"localhost:1337",
-			"127.0.0.1:1337",
-			"[::1]:1337",
-			serverHost + ":" + serverPort,
-		}
-		
-		if origin == "" {
-			for _, allowedHost := range allowedHosts {
-				if host == allowedHost {
-					return true
-				}
-			}
-		}
-		
-		// In development mode, be more permissive with CORS
-		// Allow any origin that starts with http:// (for development only)
-		if origin != "" && (origin[:7] == "http://" || origin[:8] == "https://") {
-			return true
-		}
-		
-		return false
-	},
-}
-
 type WebSocketMessage struct {
 	Type      string      `json:"type"`
 	Data      interface{} `json:"data"`
 	Timestamp string      `json:"timestamp"`
+
+	relayed bool // Came from another server instance
 }
 
+// WebSocketClient is a realtime subscriber of the hub. Event stream clients
+// have no conn; their handler drains send itself.
 type WebSocketClient struct {
-	conn *websocket.Conn
-	send chan WebSocketMessage
-	hub  *WebSocketHub
-	id   string
+	conn   *websocket.Conn
+	send   chan WebSocketMessage
+	hub    *WebSocketHub
+	id     string
+	filter *subscription
 }
 
 type WebSocketHub struct {
@@ -94,14 +41,22 @@ type WebSocketHub struct {
 	broadcast  chan WebSocketMessage
 	register   chan *WebSocketClient
 	unregister chan *WebSocketClient
+	ping       chan chan struct{} // Answered by Run, see Ping
+	shutdown   chan chan struct{} // See Shutdown
 	mutex      sync.RWMutex
+	relay      atomic.Pointer[hubRelay] // Optional, see SetRelay
+	pumps      sync.WaitGroup           // Running write pumps
 }
 
+// The broadcast buffer absorbs bursts, like a status change right after a
+// progress update, which Broadcast* would otherwise drop
 var Hub = &WebSocketHub{
 	clients:    make(map[*WebSocketClient]bool),
-	broadcast:  make(chan WebSocketMessage),
+	broadcast:  make(chan WebSocketMessage, 256),
 	register:   make(chan *WebSocketClient),
 	unregister: make(chan *WebSocketClient),
+	ping:       make(chan chan struct{}),
+	shutdown:   make(chan chan struct{}),
 }
 
 func (h *WebSocketHub) Run() {
@@ -111,7 +66,7 @@ func (h *WebSocketHub) Run() {
 			h.mutex.Lock()
 			h.clients[client] = true
 			h.mutex.Unlock()
-			log.Printf("WebSocket client connected: %s", client.id)
+			infrastructure.ServerLogger.Info("WebSocket client connected: %s", client.id)
 
 			// Send welcome message
 			welcome := WebSocketMessage{
@@ -131,41 +86,159 @@ func (h *WebSocketHub) Run() {
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
-				log.Printf("WebSocket client disconnected: %s", client.id)
+				infrastructure.ServerLogger.Info("WebSocket client disconnected: %s", client.id)
 			}
 			h.mutex.Unlock()
 
 		case message := <-h.broadcast:
-			h.mutex.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
-			}
-			h.mutex.RUnlock()
+			h.deliver(message)
+
+		case reply := <-h.ping:
+			close(reply)
+
+		case done := <-h.shutdown:
+			h.closeClients()
+			close(done)
 		}
 	}
 }
 
-func (h *WebSocketHub) BroadcastJobProgress(jobID string, progress float64, speed int64, eta string, status string) {
+func (h *WebSocketHub) deliver(message WebSocketMessage) {
+	h.relayOut(message)
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for client := range h.clients {
+		if !client.filter.matches(message) {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(h.clients, client)
+		}
+	}
+}
+
+// closeClients delivers the queued broadcasts, then says goodbye to every
+// client and drops it
+func (h *WebSocketHub) closeClients() {
+	for pending := true; pending; {
+		select {
+		case message := <-h.broadcast:
+			h.deliver(message)
+		default:
+			pending = false
+		}
+	}
+
+	goodbye := WebSocketMessage{
+		Type:      "server_shutdown",
+		Data:      map[string]interface{}{"message": "Server is shutting down"},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for client := range h.clients {
+		select {
+		case client.send <- goodbye:
+		default:
+		}
+		close(client.send)
+		delete(h.clients, client)
+	}
+	infrastructure.ServerLogger.Info("Closed realtime connections for shutdown")
+}
+
+// Ping returns once Run has picked up a ping, or an error when it doesn't
+// before ctx is done
+func (h *WebSocketHub) Ping(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case h.ping <- reply:
+	case <-ctx.Done():
+		return fmt.Errorf("hub is not running: %w", ctx.Err())
+	}
+	<-reply
+	return nil
+}
+
+// Shutdown sends the queued events and a server_shutdown event to every
+// client, closes their connections and waits until the close frames are
+// written or ctx is done. Clients connecting later are served as usual, so
+// call it once the HTTP server stopped accepting connections.
+func (h *WebSocketHub) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+	case <-ctx.Done():
+		return fmt.Errorf("hub is not running: %w", ctx.Err())
+	}
+	<-done
+
+	pumped := make(chan struct{})
+	go func() {
+		h.pumps.Wait()
+		close(pumped)
+	}()
+	select {
+	case <-pumped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("closing WebSocket connections: %w", ctx.Err())
+	}
+}
+
+// BroadcastJobProgress sends a job_progress event with hashcat's progress
+// and the job's normalized keyspace progress. stats carries the structured
+// hashcat status when the agent reported one and may be nil.
+func (h *WebSocketHub) BroadcastJobProgress(jobID string, progress, normalizedProgress float64, speed int64, eta string, status string, stats *domain.JobRuntimeStats) {
+	data := map[string]interface{}{
+		"job_id":              jobID,
+		"progress":            progress,
+		"normalized_progress": normalizedProgress,
+		"speed":               speed,
+		"eta":                 eta,
+		"status":              status,
+	}
+	if stats != nil {
+		data["stats"] = stats
+	}
+
 	message := WebSocketMessage{
-		Type: "job_progress",
-		Data: map[string]interface{}{
-			"job_id":   jobID,
-			"progress": progress,
-			"speed":    speed,
-			"eta":      eta,
-			"status":   status,
-		},
+		Type:      "job_progress",
+		Data:      data,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	select {
+	case h.broadcast <- message:
+	default:
+		infrastructure.ServerLogger.Warning("Failed to broadcast job progress - channel full")
+	}
+}
+
+// BroadcastJobCrack sends a job_crack event for a hash a job just cracked.
+// plaintext is the password as clients may see it.
+func (h *WebSocketHub) BroadcastJobCrack(crack domain.JobCrack, plaintext string) {
+	data := map[string]interface{}{
+		"job_id":     crack.JobID.String(),
+		"hash":       crack.Hash,
+		"plaintext":  plaintext,
+		"cracked_at": crack.CrackedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if crack.AgentID != nil {
+		data["agent_id"] = crack.AgentID.String()
+	}
+
+	message := WebSocketMessage{
+		Type:      "job_crack",
+		Data:      data,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 	select {
 	case h.broadcast <- message:
 	default:
-		log.Println("Failed to broadcast job progress - channel full")
+		infrastructure.ServerLogger.Warning("Failed to broadcast job crack - channel full")
 	}
 }
 
@@ -182,7 +255,7 @@ func (h *WebSocketHub) BroadcastJobStatus(jobID string, status string, result st
 	select {
 	case h.broadcast <- message:
 	default:
-		log.Println("Failed to broadcast job status - channel full")
+		infrastructure.ServerLogger.Warning("Failed to broadcast job status - channel full")
 	}
 }
 
@@ -199,7 +272,7 @@ func (h *WebSocketHub) BroadcastAgentStatus(agentID string, status string, lastS
 	select {
 	case h.broadcast <- message:
 	default:
-		log.Println("Failed to broadcast agent status - channel full")
+		infrastructure.ServerLogger.Warning("Failed to broadcast agent status - channel full")
 	}
 }
 
@@ -215,7 +288,25 @@ func (h *WebSocketHub) BroadcastAgentSpeed(agentID string, speed int64) {
 	select {
 	case h.broadcast <- message:
 	default:
-		log.Println("Failed to broadcast agent speed - channel full")
+		infrastructure.ServerLogger.Warning("Failed to broadcast agent speed - channel full")
+	}
+}
+
+// BroadcastAgentLogs pushes log lines an agent shipped. Only clients that
+// subscribed to the agent_logs topic receive them.
+func (h *WebSocketHub) BroadcastAgentLogs(agentID string, lines []domain.AgentLogLine) {
+	message := WebSocketMessage{
+		Type: "agent_logs",
+		Data: map[string]interface{}{
+			"agent_id": agentID,
+			"lines":    lines,
+		},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	select {
+	case h.broadcast <- message:
+	default:
+		infrastructure.ServerLogger.Warning("Failed to broadcast agent logs - channel full")
 	}
 }
 
@@ -236,7 +327,7 @@ func (c *WebSocketClient) readPump() {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+				infrastructure.ServerLogger.Warning("WebSocket error: %v", err)
 			}
 			break
 		}
@@ -245,7 +336,7 @@ func (c *WebSocketClient) readPump() {
 		var msg map[string]interface{}
 		if err := json.Unmarshal(message, &msg); err == nil {
 			if msgType, ok := msg["type"].(string); ok {
-				log.Printf("Received WebSocket message type: %s", msgType)
+				infrastructure.ServerLogger.Debug("Received WebSocket message type: %s", msgType)
 			}
 		}
 	}
@@ -256,6 +347,7 @@ func (c *WebSocketClient) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.pumps.Done()
 	}()
 
 	for {
@@ -268,7 +360,7 @@ func (c *WebSocketClient) writePump() {
 			}
 
 			if err := c.conn.WriteJSON(message); err != nil {
-				log.Printf("WebSocket write error: %v", err)
+				infrastructure.ServerLogger.Warning("WebSocket write error: %v", err)
 				return
 			}
 
@@ -281,28 +373,54 @@ func (c *WebSocketClient) writePump() {
 	}
 }
 
-type WebSocketHandler struct{}
+type WebSocketHandler struct {
+	upgrader websocket.Upgrader
+}
 
-func NewWebSocketHandler() *WebSocketHandler {
-	return &WebSocketHandler{}
+// NewWebSocketHandler accepts browser connections from the server's own
+// origin and those allowOrigin accepts, usually the CORS origins. Clients
+// that send no Origin, which aren't browsers, are always accepted.
+func NewWebSocketHandler(allowOrigin func(origin string) bool) *WebSocketHandler {
+	return &WebSocketHandler{upgrader: websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+				return true
+			}
+			return allowOrigin != nil && allowOrigin(origin)
+		},
+	}}
 }
 
+// HandleWebSocket upgrades the connection and streams hub events. The
+// topics, job_id and agent_id query parameters filter them like on /api/v1/stream.
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	sub, err := parseSubscription(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
+		infrastructure.ServerLogger.Warning("WebSocket upgrade failed: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to upgrade to WebSocket"})
 		return
 	}
 
 	clientID := c.ClientIP() + "-" + time.Now().Format("20060102150405")
 	client := &WebSocketClient{
-		conn: conn,
-		send: make(chan WebSocketMessage, 256),
-		hub:  Hub,
-		id:   clientID,
+		conn:   conn,
+		send:   make(chan WebSocketMessage, 256),
+		hub:    Hub,
+		id:     clientID,
+		filter: sub,
 	}
 
+	client.hub.pumps.Add(1)
 	client.hub.register <- client
 
 	go client.writePump()
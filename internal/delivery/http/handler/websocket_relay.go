@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// relayBuffer absorbs bursts while the relay is slow, messages beyond it
+// still reach this instance's clients
+const relayBuffer = 1024
+
+// relayEnvelope is a hub message on the relay. Origin tells instances to
+// skip their own messages, which they already delivered.
+type relayEnvelope struct {
+	Origin  string           `json:"origin"`
+	Message WebSocketMessage `json:"message"`
+}
+
+type hubRelay struct {
+	relay    domain.EventRelay
+	instance string
+	outbox   chan WebSocketMessage
+}
+
+// SetRelay fans this hub's messages out to other server instances through
+// relay, and delivers theirs to this hub's clients. Call RunRelay to start
+// it. A nil relay detaches the current one.
+func (h *WebSocketHub) SetRelay(relay domain.EventRelay) {
+	if relay == nil {
+		h.relay.Store(nil)
+		return
+	}
+	h.relay.Store(&hubRelay{
+		relay:    relay,
+		instance: uuid.NewString(),
+		outbox:   make(chan WebSocketMessage, relayBuffer),
+	})
+}
+
+// RunRelay publishes and receives relayed messages until ctx is done
+func (h *WebSocketHub) RunRelay(ctx context.Context) {
+	r := h.relay.Load()
+	if r == nil {
+		return
+	}
+
+	go func() {
+		err := r.relay.Subscribe(ctx, func(payload []byte) {
+			var envelope relayEnvelope
+			if err := json.Unmarshal(payload, &envelope); err != nil {
+				infrastructure.ServerLogger.Warning("Dropped malformed relayed event: %v", err)
+				return
+			}
+			if envelope.Origin == r.instance {
+				return
+			}
+			message := envelope.Message
+			message.relayed = true
+			select {
+			case h.broadcast <- message:
+			default:
+				infrastructure.ServerLogger.Warning("Failed to deliver relayed %s - channel full", message.Type)
+			}
+		})
+		if err != nil {
+			infrastructure.ServerLogger.Error("Realtime relay subscription stopped: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-r.outbox:
+			payload, err := json.Marshal(relayEnvelope{Origin: r.instance, Message: message})
+			if err != nil {
+				infrastructure.ServerLogger.Warning("Failed to encode %s for the relay: %v", message.Type, err)
+				continue
+			}
+			if err := r.relay.Publish(ctx, payload); err != nil && ctx.Err() == nil {
+				infrastructure.ServerLogger.Warning("Failed to relay %s to other instances: %v", message.Type, err)
+			}
+		}
+	}
+}
+
+// relayOut queues a message raised on this instance for the other ones
+func (h *WebSocketHub) relayOut(message WebSocketMessage) {
+	r := h.relay.Load()
+	if r == nil || message.relayed {
+		return
+	}
+	select {
+	case r.outbox <- message:
+	default:
+		infrastructure.ServerLogger.Warning("Failed to relay %s - relay queue full", message.Type)
+	}
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
+	"go-distributed-hashcat/internal/domain"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +16,9 @@ import (
 
 type WordlistHandler struct {
 	wordlistUsecase usecase.WordlistUsecase
+	uploads         UploadPolicy
+	scanner         domain.UploadScanner        // Optional, refuses infected uploads
+	resultAccess    usecase.ResultAccessUsecase // Optional, guards the loopback wordlists
 }
 
 func NewWordlistHandler(wordlistUsecase usecase.WordlistUsecase) *WordlistHandler {
@@ -21,17 +27,62 @@ func NewWordlistHandler(wordlistUsecase usecase.WordlistUsecase) *WordlistHandle
 	}
 }
 
+// SetUploadPolicy sets what uploads are accepted and the malware scanner
+// they go through, if any
+func (h *WordlistHandler) SetUploadPolicy(policy UploadPolicy, scanner domain.UploadScanner) {
+	h.uploads = policy
+	h.scanner = scanner
+}
+
+// SetResultAccess makes reading a loopback wordlist, which holds cracked
+// passwords, take the reveal permission
+func (h *WordlistHandler) SetResultAccess(resultAccess usecase.ResultAccessUsecase) {
+	h.resultAccess = resultAccess
+}
+
+// revealLoopback checks that the request may read a loopback wordlist and
+// logs the read, answering 403 if not. Users need the reveal permission;
+// agents, which don't log in, name themselves with ?agent_id= and may only
+// read the wordlists of their jobs.
+func (h *WordlistHandler) revealLoopback(c *gin.Context, wordlist *domain.Wordlist) bool {
+	if !wordlist.Dynamic || h.resultAccess == nil {
+		return true
+	}
+
+	var err error
+	if userID, role, ok := currentUser(c); ok {
+		err = h.resultAccess.RevealWordlist(c.Request.Context(), wordlist.ID, domain.ResultViewer{
+			UserID:    userID,
+			Username:  c.GetString("username"),
+			Role:      role,
+			IPAddress: c.ClientIP(),
+		})
+	} else if agentID, parseErr := uuid.Parse(c.Query("agent_id")); parseErr == nil {
+		err = h.resultAccess.RevealWordlistToAgent(c.Request.Context(), wordlist.ID, agentID, c.ClientIP())
+	} else {
+		err = domain.ErrRevealForbidden
+	}
+	if errors.Is(err, domain.ErrRevealForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "REVEAL_FORBIDDEN"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 func (h *WordlistHandler) UploadWordlist(c *gin.Context) {
-	file, err := c.FormFile("file")
+	projectID, err := projectIDQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Open the uploaded file
-	src, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+	// Open the uploaded file once its size, type and content are accepted
+	file, src, ok := openUpload(c, h.uploads, h.scanner)
+	if !ok {
 		return
 	}
 	defer src.Close()
@@ -42,12 +93,22 @@ func (h *WordlistHandler) UploadWordlist(c *gin.Context) {
 		file.Filename,
 		src,
 		file.Size,
+		projectID,
 	)
 	if err != nil {
+		if status, ok := quotaExceededStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if wordlist.Duplicate {
+		c.JSON(http.StatusOK, gin.H{"data": wordlist, "message": "Identical file already uploaded, returning existing record"})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"data": wordlist})
 }
 
@@ -69,7 +130,33 @@ func (h *WordlistHandler) GetWordlist(c *gin.Context) {
 }
 
 func (h *WordlistHandler) GetAllWordlists(c *gin.Context) {
-	wordlists, err := h.wordlistUsecase.GetAllWordlists(c.Request.Context())
+	// Content-hash lookup lets agents check a local copy before downloading
+	if sum := c.Query("sha256"); sum != "" {
+		wordlist, err := h.wordlistUsecase.GetWordlistBySHA256(c.Request.Context(), sum)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.JSON(http.StatusOK, gin.H{"data": []domain.Wordlist{}})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": []domain.Wordlist{*wordlist}})
+		return
+	}
+
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var wordlists []domain.Wordlist
+	if projectID != nil {
+		wordlists, err = h.wordlistUsecase.GetWordlistsByProject(c.Request.Context(), *projectID)
+	} else {
+		wordlists, err = h.wordlistUsecase.GetAllWordlists(c.Request.Context())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -78,6 +165,28 @@ func (h *WordlistHandler) GetAllWordlists(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": wordlists})
 }
 
+// GetLoopbackWordlist returns the wordlist of passwords cracked in the
+// project given by ?project_id=, or in jobs without a project
+func (h *WordlistHandler) GetLoopbackWordlist(c *gin.Context) {
+	projectID, err := projectIDQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wordlist, err := h.wordlistUsecase.GetLoopbackWordlist(c.Request.Context(), projectID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": wordlist})
+}
+
 func (h *WordlistHandler) DeleteWordlist(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -107,6 +216,9 @@ func (h *WordlistHandler) GetWordlistContent(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.revealLoopback(c, wordlist) {
+		return
+	}
 
 	// Read file content
 	content, err := os.ReadFile(wordlist.Path)
@@ -133,6 +245,9 @@ func (h *WordlistHandler) DownloadWordlist(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.revealLoopback(c, wordlist) {
+		return
+	}
 
 	// Set headers for file download
 	c.Header("Content-Description", "File Transfer")
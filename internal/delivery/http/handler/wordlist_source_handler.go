@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WordlistSourceHandler struct {
+	wordlistSourceUsecase usecase.WordlistSourceUsecase
+}
+
+func NewWordlistSourceHandler(wordlistSourceUsecase usecase.WordlistSourceUsecase) *WordlistSourceHandler {
+	return &WordlistSourceHandler{
+		wordlistSourceUsecase: wordlistSourceUsecase,
+	}
+}
+
+// CreateSource registers a remote wordlist, fetched right away when the
+// request sets fetch
+func (h *WordlistSourceHandler) CreateSource(c *gin.Context) {
+	var req domain.CreateWordlistSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, err := h.wordlistSourceUsecase.CreateSource(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": source})
+}
+
+func (h *WordlistSourceHandler) GetSources(c *gin.Context) {
+	sources, err := h.wordlistSourceUsecase.GetSources(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sources})
+}
+
+func (h *WordlistSourceHandler) GetSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wordlist source ID"})
+		return
+	}
+
+	source, err := h.wordlistSourceUsecase.GetSource(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": source})
+}
+
+func (h *WordlistSourceHandler) UpdateSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wordlist source ID"})
+		return
+	}
+
+	var req domain.UpdateWordlistSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, err := h.wordlistSourceUsecase.UpdateSource(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": source})
+}
+
+func (h *WordlistSourceHandler) DeleteSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wordlist source ID"})
+		return
+	}
+
+	if err := h.wordlistSourceUsecase.DeleteSource(c.Request.Context(), id); err != nil {
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Wordlist source deleted"})
+}
+
+// FetchSource starts fetching the source; poll it until its status is
+// ready or failed
+func (h *WordlistSourceHandler) FetchSource(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wordlist source ID"})
+		return
+	}
+
+	source, err := h.wordlistSourceUsecase.FetchSource(c.Request.Context(), id)
+	if err != nil {
+		if domain.IsValidationError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(agentGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": source})
+}
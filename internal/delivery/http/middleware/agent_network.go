@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxAgentKeyPeek caps how much of a request body is read to find the agent key
+const maxAgentKeyPeek = 1 << 20
+
+// AgentNetworkChecker decides whether an agent request may come from its address
+type AgentNetworkChecker interface {
+	Check(ctx context.Context, check domain.AgentNetworkCheck) error
+}
+
+// AgentNetworkACL refuses agent requests from addresses the network rules
+// keep out with 403. The agent is named by the route's :id, or by the
+// agent_key field of a JSON body. The client address is the connection's
+// peer; X-Forwarded-For is only believed when the peer is one of
+// trustedProxies, and then only up to the first address that isn't.
+func AgentNetworkACL(checker AgentNetworkChecker, trustedProxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		check := domain.AgentNetworkCheck{
+			IPAddress: agentClientIP(c, trustedProxies),
+			Path:      c.Request.Method + " " + c.Request.URL.Path,
+		}
+		if id, err := uuid.Parse(c.Param("id")); err == nil {
+			check.AgentID = &id
+		}
+		if c.Request.Body != nil && strings.HasPrefix(c.ContentType(), "application/json") {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAgentKeyPeek))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				return
+			}
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+			var peek struct {
+				AgentKey string `json:"agent_key"`
+			}
+			if json.Unmarshal(body, &peek) == nil {
+				check.AgentKey = peek.AgentKey
+			}
+		}
+
+		if err := checker.Check(c.Request.Context(), check); err != nil {
+			if errors.Is(err, domain.ErrAgentNetworkDenied) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": err.Error(),
+					"code":  "AGENT_NETWORK_DENIED",
+				})
+				return
+			}
+			infrastructure.ServerLogger.Error("Failed to check agent network rules: %v", err)
+		}
+
+		c.Next()
+	}
+}
+
+// agentClientIP returns the address a request came from, walking
+// X-Forwarded-For back from the right past trusted proxies only
+func agentClientIP(c *gin.Context, trustedProxies []*net.IPNet) string {
+	remote := c.RemoteIP()
+	if !ipInNetworks(remote, trustedProxies) {
+		return remote
+	}
+
+	hops := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		remote = hop
+		if !ipInNetworks(hop, trustedProxies) {
+			break
+		}
+	}
+	return remote
+}
+
+func ipInNetworks(address string, networks []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,24 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"go-distributed-hashcat/internal/domain"
-	"go-distributed-hashcat/internal/infrastructure"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// TokenValidator validates access tokens, including whether their session
+// was revoked
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*domain.JWTClaims, error)
+}
+
 // AuthMiddleware creates an authentication middleware
-func AuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFunc {
+func AuthMiddleware(validator TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -34,7 +41,7 @@ func AuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFunc {
 		}
 
 		// Validate token
-		claims, err := jwtService.ValidateToken(tokenString)
+		claims, err := validator.ValidateToken(c.Request.Context(), tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or expired token",
@@ -49,6 +56,8 @@ func AuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFunc {
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
 		c.Set("claims", claims)
+		withUserID(c, claims.UserID)
+		withTenantID(c, claims.TenantID)
 
 		c.Next()
 	}
@@ -56,12 +65,13 @@ func AuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFunc {
 
 // OptionalAuthMiddleware creates an optional authentication middleware
 // This allows endpoints to work with or without authentication
-func OptionalAuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFunc {
+func OptionalAuthMiddleware(validator TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			// No token provided, continue without authentication
+			withSharedScope(c)
 			c.Next()
 			return
 		}
@@ -70,14 +80,16 @@ func OptionalAuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFu
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
 			// Invalid format, continue without authentication
+			withSharedScope(c)
 			c.Next()
 			return
 		}
 
 		// Validate token
-		claims, err := jwtService.ValidateToken(tokenString)
+		claims, err := validator.ValidateToken(c.Request.Context(), tokenString)
 		if err != nil {
 			// Invalid token, continue without authentication
+			withSharedScope(c)
 			c.Next()
 			return
 		}
@@ -88,6 +100,8 @@ func OptionalAuthMiddleware(jwtService *infrastructure.JWTService) gin.HandlerFu
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
 		c.Set("claims", claims)
+		withUserID(c, claims.UserID)
+		withTenantID(c, claims.TenantID)
 
 		c.Next()
 	}
@@ -141,6 +155,34 @@ func AdminOnlyMiddleware() gin.HandlerFunc {
 	return RoleMiddleware("admin")
 }
 
+// ClusterAdminOnlyMiddleware only allows admins outside of tenants, for
+// settings that span tenants such as the tenants themselves and quotas.
+// It runs after AdminOnlyMiddleware.
+func ClusterAdminOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, ok := GetCurrentUser(c); !ok || claims.TenantID != "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Only cluster admins can manage this resource",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AgentRoute lifts the shared scope of anonymous requests on the routes
+// agents call. Agents don't log in, and run jobs of every tenant.
+func AgentRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("claims"); !ok {
+			c.Request = c.Request.WithContext(domain.WithoutTenant(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
 // GetCurrentUser extracts current user information from context
 func GetCurrentUser(c *gin.Context) (*domain.JWTClaims, bool) {
 	claims, exists := c.Get("claims")
@@ -185,3 +227,25 @@ func GetCurrentUserRole(c *gin.Context) (string, bool) {
 
 	return roleStr, true
 }
+
+// withUserID passes the logged-in user on to the usecases, which charge
+// the user's quota for new jobs and uploads
+func withUserID(c *gin.Context, userID string) {
+	if id, err := uuid.Parse(userID); err == nil {
+		c.Request = c.Request.WithContext(domain.WithUserID(c.Request.Context(), id))
+	}
+}
+
+// withSharedScope keeps an anonymous request away from the tenants'
+// resources; it only sees those outside of tenants
+func withSharedScope(c *gin.Context) {
+	c.Request = c.Request.WithContext(domain.WithSharedScope(c.Request.Context()))
+}
+
+// withTenantID scopes the request to the tenant of the logged-in user, so
+// the repositories only return and change the tenant's resources
+func withTenantID(c *gin.Context, tenantID string) {
+	if id, err := uuid.Parse(tenantID); err == nil {
+		c.Request = c.Request.WithContext(domain.WithTenantID(c.Request.Context(), id))
+	}
+}
@@ -1,59 +1,96 @@
 package middleware
 
 import (
-	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-// CORS middleware to handle Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Debug: Log the request
-		fmt.Printf("DEBUG: CORS middleware called for %s %s\n", c.Request.Method, c.Request.URL.Path)
-		
-		// Force wildcard CORS for development
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
-
-		// Debug: Log the headers being set
-		fmt.Printf("DEBUG: Setting Access-Control-Allow-Origin to: *\n")
+const (
+	corsAllowHeaders = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key, X-Request-ID"
+	corsAllowMethods = "POST, DELETE, GET, PUT, PATCH, OPTIONS"
+	// defaultCORSMaxAge is how long browsers cache a preflight answer
+	defaultCORSMaxAge = 24 * time.Hour
+)
 
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT, OPTIONS")
-			c.AbortWithStatus(204)
-			return
-		}
+// CORSConfig tells which browser origins may call the API
+type CORSConfig struct {
+	AllowedOrigins []string      // Origins such as https://hashcat.example.com, "*" allows any without credentials
+	MaxAge         time.Duration // How long preflights are cached, default 24 hours
+}
 
-		c.Next()
+// originAllowed reports whether origin is one of the allowed ones, and
+// whether any origin is
+func (config CORSConfig) originAllowed(origin string) (allowed, any bool) {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" {
+			any = true
+		} else if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true, false
+		}
 	}
+	return any, any
 }
 
-// CORSWithSpecificOrigin creates CORS middleware for specific origin
-func CORSWithSpecificOrigin(allowedOrigin string) gin.HandlerFunc {
+// AllowsOrigin reports whether browsers on origin may call the API. Browsers
+// don't apply CORS to WebSocket upgrades, the server checks those itself.
+func (config CORSConfig) AllowsOrigin(origin string) bool {
+	allowed, _ := config.originAllowed(origin)
+	return allowed
+}
+
+// CORS answers cross-origin requests from the allowed origins. Requests of
+// other origins get no CORS headers, so browsers refuse them, and their
+// preflights 403. Same-origin requests and clients that aren't browsers,
+// such as agents, send no Origin and are unaffected.
+func CORS(config CORSConfig) gin.HandlerFunc {
+	maxAge := config.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultCORSMaxAge
+	}
 	return func(c *gin.Context) {
-		// Debug: Log the allowed origin
-		fmt.Printf("DEBUG: CORS middleware using origin: %s\n", allowedOrigin)
-		
-		// Set CORS headers
-		c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		allowed, any := config.originAllowed(origin)
+		if !allowed {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if any {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 
 		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, GET, PUT, OPTIONS")
-			c.AbortWithStatus(204)
+		if preflight {
+			c.Writer.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Writer.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// CORSWithSpecificOrigin creates CORS middleware for specific origin
+func CORSWithSpecificOrigin(allowedOrigin string) gin.HandlerFunc {
+	return CORS(CORSConfig{AllowedOrigins: []string{allowedOrigin}})
+}
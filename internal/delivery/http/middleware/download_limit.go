@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultDownloadRetryAfter = 15 * time.Second
+
+// DownloadLimitConfig caps concurrent downloads of the same file
+type DownloadLimitConfig struct {
+	MaxPerFile int           // Downloads of one file served at once, 0 for unlimited
+	RetryAfter time.Duration // Sent to clients over the limit, default 15 seconds
+}
+
+// DownloadLimit guards file download routes with a semaphore per file, keyed
+// by the route's :id. Downloads over the limit get 503 with a Retry-After
+// header, so a fleet of agents fetching the same large wordlist queues up
+// instead of saturating the uplink all at once.
+func DownloadLimit(config DownloadLimitConfig) gin.HandlerFunc {
+	if config.MaxPerFile <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	retryAfter := config.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = defaultDownloadRetryAfter
+	}
+	retryAfterSeconds := strconv.Itoa(int((retryAfter + time.Second - 1) / time.Second))
+
+	var mu sync.Mutex
+	active := make(map[string]int)
+
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		mu.Lock()
+		if active[id] >= config.MaxPerFile {
+			mu.Unlock()
+			c.Header("Retry-After", retryAfterSeconds)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Too many downloads of this file in progress, retry later"})
+			return
+		}
+		active[id]++
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			if active[id]--; active[id] == 0 {
+				delete(active, id)
+			}
+			mu.Unlock()
+		}()
+
+		c.Next()
+	}
+}
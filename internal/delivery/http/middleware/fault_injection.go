@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FaultInjectedHeader names the fault injected into a response, so tests can
+// tell injected failures from real ones
+const FaultInjectedHeader = "X-Fault-Injected"
+
+// faultStatuses are the errors injected at random
+var faultStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// FaultInjectionConfig sets how often agent-facing routes misbehave. It is
+// meant for integration tests and staging, never for production.
+type FaultInjectionConfig struct {
+	Enabled           bool
+	LatencyRate       float64       // Share of requests delayed, 0 to 1
+	MaxLatency        time.Duration // Delays are random up to this long
+	ErrorRate         float64       // Share of requests answered with a 5xx error, 0 to 1
+	HeartbeatDropRate float64       // Share of heartbeats acknowledged but never processed, 0 to 1
+	Seed              int64         // Seeds the random faults for reproducible runs, 0 for a random seed
+}
+
+// FaultInjection delays requests and fails them with 5xx errors at the
+// configured rates, so the agents' retries and backoff can be exercised
+// against a real server. Injected faults carry the X-Fault-Injected header.
+func FaultInjection(config FaultInjectionConfig) gin.HandlerFunc {
+	return faultInjection(config, false)
+}
+
+// HeartbeatFaultInjection is FaultInjection for heartbeat routes, which
+// additionally drops heartbeats at HeartbeatDropRate: the agent is told the
+// heartbeat arrived, but the server never sees it, so agents go degraded and
+// offline and their job leases expire as if the heartbeats were lost.
+func HeartbeatFaultInjection(config FaultInjectionConfig) gin.HandlerFunc {
+	return faultInjection(config, true)
+}
+
+func faultInjection(config FaultInjectionConfig, heartbeat bool) gin.HandlerFunc {
+	if !config.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(seed))
+	roll := func(rate float64) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return rate > 0 && rng.Float64() < rate
+	}
+	latency := func() time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return time.Duration(rng.Int63n(int64(config.MaxLatency) + 1))
+	}
+	status := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return faultStatuses[rng.Intn(len(faultStatuses))]
+	}
+
+	return func(c *gin.Context) {
+		if config.MaxLatency > 0 && roll(config.LatencyRate) {
+			delay := latency()
+			select {
+			case <-c.Request.Context().Done():
+			case <-time.After(delay):
+			}
+			c.Header(FaultInjectedHeader, "latency")
+		}
+
+		if roll(config.ErrorRate) {
+			code := status()
+			infrastructure.ServerLogger.Debug("Fault injection: %s %s answered with %d", c.Request.Method, c.Request.URL.Path, code)
+			c.Header(FaultInjectedHeader, "error")
+			c.AbortWithStatusJSON(code, gin.H{"error": "Injected fault"})
+			return
+		}
+
+		if heartbeat && roll(config.HeartbeatDropRate) {
+			infrastructure.ServerLogger.Debug("Fault injection: dropped heartbeat %s", c.Request.URL.Path)
+			c.Header(FaultInjectedHeader, "heartbeat-drop")
+			c.AbortWithStatusJSON(http.StatusOK, gin.H{"message": "Heartbeat received"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+)
+
+// responseRecorder keeps a copy of the response body so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a POST endpoint safe to retry. The first request with a
+// given Idempotency-Key runs normally and its successful response is stored;
+// later requests with the same key get that response back instead of
+// creating new work. Failed requests release the key so they can be retried.
+// Keys are forgotten after ttl (default 24 hours).
+func Idempotency(store domain.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.FullPath() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		if _, err := store.DeleteExpired(ctx, time.Now().Add(-ttl)); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to delete expired idempotency keys: %v", err)
+		}
+
+		existing, err := store.Reserve(ctx, &domain.IdempotencyRecord{Key: key, RequestHash: requestHash})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(existing.StatusCode, "application/json; charset=utf-8", existing.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The request context may already be cancelled by the timeout middleware
+		ctx = context.WithoutCancel(ctx)
+		status := recorder.Status()
+		if status >= http.StatusOK && status < http.StatusMultipleChoices {
+			err = store.Complete(ctx, key, status, recorder.body.Bytes())
+		} else {
+			err = store.Release(ctx, key)
+		}
+		if err != nil {
+			infrastructure.ServerLogger.Error("Failed to update idempotency key %s: %v", key, err)
+		}
+	}
+}
@@ -18,18 +18,22 @@ type GzipWriter struct {
 }
 
 func (g *GzipWriter) Write(data []byte) (int, error) {
+	// A length set by the handler is the uncompressed one
+	g.Header().Del("Content-Length")
 	return g.Writer.Write(data)
 }
 
 func (g *GzipWriter) WriteString(s string) (int, error) {
-	return g.Writer.Write([]byte(s))
+	return g.Write([]byte(s))
 }
 
-// Gzip middleware provides gzip compression for responses
-func Gzip() gin.HandlerFunc {
+// Gzip middleware provides gzip compression for responses. Streaming routes
+// in skipRoutes are left alone, the gzip writer would hold back their events.
+func Gzip(skipRoutes ...string) gin.HandlerFunc {
+	skip := routeSet(skipRoutes)
 	return func(c *gin.Context) {
 		// Skip if client doesn't accept gzip
-		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		if skip[c.FullPath()] || !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
 			c.Next()
 			return
 		}
@@ -48,7 +52,10 @@ func Gzip() gin.HandlerFunc {
 			c.Next()
 			return
 		}
-		defer gz.Close()
+		defer func() {
+			c.Writer.Header().Del("Content-Length")
+			gz.Close()
+		}()
 
 		// Set headers
 		c.Header("Content-Encoding", "gzip")
@@ -90,26 +97,16 @@ func Cache() gin.HandlerFunc {
 	}
 }
 
-// SecurityHeaders adds security-related headers
-func SecurityHeaders() gin.HandlerFunc {
+// RequestTimeout middleware sets a timeout for requests. Long-lived routes
+// in skipRoutes, like event streams, run until the client disconnects.
+func RequestTimeout(timeout time.Duration, skipRoutes ...string) gin.HandlerFunc {
+	skip := routeSet(skipRoutes)
 	return func(c *gin.Context) {
-		c.Header("X-Content-Type-Options", "nosniff")
-		c.Header("X-Frame-Options", "DENY")
-		c.Header("X-XSS-Protection", "1; mode=block")
-		c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
-
-		// Only add HSTS for HTTPS
-		if c.Request.TLS != nil {
-			c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		if skip[c.FullPath()] {
+			c.Next()
+			return
 		}
 
-		c.Next()
-	}
-}
-
-// RequestTimeout middleware sets a timeout for requests
-func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
 		// Set a deadline for the request context
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 		defer cancel()
@@ -145,3 +142,12 @@ func Performance() gin.HandlerFunc {
 		c.Header("X-Processing-Time", processingTime.String())
 	})
 }
+
+// routeSet indexes route templates as returned by gin's FullPath
+func routeSet(routes []string) map[string]bool {
+	set := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		set[route] = true
+	}
+	return set
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProjectAccessChecker decides whether a user may use a project
+type ProjectAccessChecker interface {
+	CheckAccess(ctx context.Context, projectID, userID uuid.UUID, role string) error
+}
+
+// ProjectAccess guards requests scoped with the project_id query parameter:
+// they need a logged-in admin or project member. Requests without it pass
+// through untouched, so agents and clients that don't use projects keep
+// working. Run it after OptionalAuthMiddleware.
+func ProjectAccess(checker ProjectAccessChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.Query("project_id")
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		projectID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project_id"})
+			c.Abort()
+			return
+		}
+
+		userIDStr, _ := GetCurrentUserID(c)
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+		role, _ := GetCurrentUserRole(c)
+
+		if err := checker.CheckAccess(c.Request.Context(), projectID, userID, role); err != nil {
+			status := http.StatusInternalServerError
+			if domain.IsNotFoundError(err) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
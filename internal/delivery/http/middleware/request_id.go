@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	maxRequestIDLength  = 128
+	requestIDContextKey = "request_id"
+)
+
+// RequestID gives every request an ID, taken from the X-Request-ID header if
+// the client sent a usable one. The ID is echoed in the response and stored
+// in the request context, where loggers pick it up with WithContext.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set(requestIDContextKey, id)
+		c.Request = c.Request.WithContext(infrastructure.ContextWithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID RequestID assigned to the request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// validRequestID accepts short IDs of printable ASCII without spaces, so a
+// client can't inject fake fields or lines into the log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestLogger logs one line per request with its method, path, status,
+// latency and request ID. It replaces gin.Logger so that requests follow the
+// configured log format.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		logger := infrastructure.ServerLogger.WithContext(c.Request.Context()).With(
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+
+		switch {
+		case status >= 500:
+			logger.Error("%s %s %d", c.Request.Method, path, status)
+		case status >= 400:
+			logger.Warning("%s %s %d", c.Request.Method, path, status)
+		default:
+			logger.Info("%s %s %d", c.Request.Method, path, status)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultContentSecurityPolicy fits the web UI: its inline scripts,
+// Alpine.js from unpkg, Font Awesome from cdnjs and the WebSocket
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' 'unsafe-eval' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://cdnjs.cloudflare.com https://fonts.googleapis.com; " +
+	"font-src 'self' data: https://cdnjs.cloudflare.com https://fonts.gstatic.com; " +
+	"img-src 'self' data:; connect-src 'self' ws: wss:; " +
+	"frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
+
+// apiContentSecurityPolicy is for JSON and files, which load nothing
+const apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// SecurityConfig gathers the browser-facing protections of the router
+type SecurityConfig struct {
+	CORS    CORSConfig
+	CSRF    CSRFConfig
+	Headers SecurityHeadersConfig
+}
+
+// SecurityHeadersConfig configures SecurityHeaders
+type SecurityHeadersConfig struct {
+	HSTSMaxAge            time.Duration // Sent over HTTPS, 0 leaves HSTS out
+	ContentSecurityPolicy string        // Of the web UI, empty leaves it out
+}
+
+// SecurityHeaders adds security-related headers. The web UI gets the
+// configured Content-Security-Policy, the API one that allows nothing.
+func SecurityHeaders(config SecurityHeadersConfig) gin.HandlerFunc {
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(config.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("X-Frame-Options", "DENY")
+		c.Header("X-XSS-Protection", "1; mode=block")
+		c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
+
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Header("Content-Security-Policy", apiContentSecurityPolicy)
+		} else if config.ContentSecurityPolicy != "" {
+			c.Header("Content-Security-Policy", config.ContentSecurityPolicy)
+		}
+
+		// Only add HSTS for HTTPS, directly or through a proxy
+		if hsts != "" && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			c.Header("Strict-Transport-Security", hsts)
+		}
+
+		c.Next()
+	}
+}
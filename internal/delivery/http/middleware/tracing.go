@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-distributed-hashcat/internal/infrastructure/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for each request, continuing the trace of
+// the caller if it sent a traceparent header. Usecase and database spans
+// become its children through the request context. Routes in skipRoutes,
+// like the per-second heartbeats, are not traced.
+func Tracing(skipRoutes ...string) gin.HandlerFunc {
+	tracer := tracing.Tracer("http-server")
+	skip := routeSet(skipRoutes)
+
+	return func(c *gin.Context) {
+		if skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// The route template keeps span names low-cardinality
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched route"
+		}
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.ClientAddress(c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		if id := GetRequestID(c); id != "" {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
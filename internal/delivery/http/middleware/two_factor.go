@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorChecker decides whether a session still owes a second factor
+type TwoFactorChecker interface {
+	TwoFactorPending(ctx context.Context, claims *domain.JWTClaims) (bool, error)
+}
+
+// TwoFactorPolicy refuses logged-in users who have or must use two-factor
+// authentication but logged in without it, except on routes under
+// exemptPrefix, where they set it up or confirm a code. Requests without a login, such as
+// agent traffic, pass through. Run it after OptionalAuthMiddleware.
+func TwoFactorPolicy(checker TwoFactorChecker, exemptPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetCurrentUser(c)
+		if !ok || claims.TwoFactor || strings.HasPrefix(c.FullPath(), exemptPrefix) {
+			c.Next()
+			return
+		}
+
+		pending, err := checker.TwoFactorPending(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if pending {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Two-factor authentication is required for your account, set it up or confirm a code under /api/v1/auth/2fa",
+				"code":  "TWO_FACTOR_SETUP_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
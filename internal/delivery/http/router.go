@@ -1,12 +1,13 @@
 package http
 
 import (
+	"io/fs"
+	"net"
 	"time"
 
 	"go-distributed-hashcat/internal/delivery/http/handler"
 	"go-distributed-hashcat/internal/delivery/http/middleware"
 	"go-distributed-hashcat/internal/domain"
-	"go-distributed-hashcat/internal/infrastructure"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -17,49 +18,132 @@ func NewRouter(
 	jobUsecase usecase.JobUsecase,
 	hashFileUsecase usecase.HashFileUsecase,
 	wordlistUsecase usecase.WordlistUsecase,
+	charsetUsecase usecase.CharsetUsecase,
 	jobEnrichmentService usecase.JobEnrichmentService,
 	distributedJobUsecase domain.DistributedJobUsecase,
 	authUsecase domain.AuthUsecase,
+	searchUsecase usecase.SearchUsecase,
+	statsUsecase usecase.StatsUsecase,
+	projectUsecase usecase.ProjectUsecase,
+	suggestionUsecase usecase.SuggestionUsecase,
+	recommendationUsecase usecase.RecommendationUsecase,
+	analyticsUsecase usecase.AnalyticsUsecase,
+	quotaUsecase usecase.QuotaUsecase,
+	maintenanceUsecase usecase.MaintenanceUsecase,
+	enrollmentUsecase usecase.EnrollmentUsecase,
+	resultAccessUsecase usecase.ResultAccessUsecase,
+	candidatePreviewUsecase usecase.CandidatePreviewUsecase,
+	hashFileOperationUsecase usecase.HashFileOperationUsecase,
+	ntdsUsecase usecase.NTDSUsecase,
+	credentialUsecase usecase.CredentialUsecase,
+	complianceUsecase usecase.ComplianceUsecase,
+	projectArchiveUsecase usecase.ProjectArchiveUsecase,
+	agentNetworkUsecase usecase.AgentNetworkUsecase,
+	wordlistSourceUsecase usecase.WordlistSourceUsecase,
+	tenantUsecase usecase.TenantUsecase,
+	digestUsecase usecase.DigestUsecase,
+	hashtopolisUsecase usecase.HashtopolisUsecase,
+	hashtopolisAgentAPI bool,
+	agentBenchmarkUsecase usecase.AgentBenchmarkUsecase,
+	agentCommandUsecase usecase.AgentCommandUsecase,
+	ssoUsecase usecase.SSOUsecase,
+	ssoPostLoginURL string,
+	chatOpsUsecase usecase.ChatOpsUsecase,
+	slackSigningSecret string,
+	idempotencyRepo domain.IdempotencyRepository,
+	downloadLimitConfig middleware.DownloadLimitConfig,
+	faultInjectionConfig middleware.FaultInjectionConfig,
+	securityConfig middleware.SecurityConfig,
+	trustedProxies []*net.IPNet,
+	uploadPolicies handler.UploadPolicies,
+	healthChecks []handler.HealthCheck,
+	webUI fs.FS,
 ) *gin.Engine {
 	// Set Gin to release mode for production performance
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
 
+	// Tag every request with an ID before anything logs, then trace it
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing("/health", "/healthz", "/readyz", "/ws", "/api/v1/stream", "/api/v1/agents/heartbeat", "/api/v1/agents/:id/heartbeat", "/api/v1/agents/:id/logs"))
+
 	// CORS middleware (must be first to handle preflight requests)
-	// Temporarily use wildcard CORS for development
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(securityConfig.CORS))
+	router.Use(middleware.CSRF(securityConfig.CSRF))
 
 	// Performance middleware
 	router.Use(middleware.Performance())
-	router.Use(middleware.Gzip())
+	// Downloads keep their Content-Length, agents check it against their disk
+	router.Use(middleware.Gzip("/api/v1/stream", "/api/v1/projects/:id/export",
+		"/api/v1/hashfiles/:id/download", "/api/v1/wordlists/:id/download", "/api/v1/charsets/:id/download", "/api/wordlists/:id/download"))
 	router.Use(middleware.Cache())
-	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.RequestTimeout(30 * time.Second))
+	router.Use(middleware.SecurityHeaders(securityConfig.Headers))
+	router.Use(middleware.RequestTimeout(30*time.Second, "/api/v1/stream", "/api/v1/projects/:id/export", "/api/v1/projects/import", "/api/v1/projects/import/hashtopolis"))
 
 	// Standard middleware
-	router.Use(gin.Logger())
+	router.Use(middleware.RequestLogger())
 	router.Use(gin.Recovery())
 
-	// Initialize JWT service
-	jwtService := infrastructure.NewJWTService()
-
 	// Initialize handlers
 	agentHandler := handler.NewAgentHandler(agentUsecase)
 	jobHandler := handler.NewJobHandler(jobUsecase, jobEnrichmentService, agentUsecase, wordlistUsecase)
 	hashFileHandler := handler.NewHashFileHandler(hashFileUsecase)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationUsecase)
 	wordlistHandler := handler.NewWordlistHandler(wordlistUsecase)
+	charsetHandler := handler.NewCharsetHandler(charsetUsecase)
 	cacheHandler := handler.NewCacheHandler(jobEnrichmentService)
-	wsHandler := handler.NewWebSocketHandler()
+	wsHandler := handler.NewWebSocketHandler(securityConfig.CORS.AllowsOrigin)
+	streamHandler := handler.NewStreamHandler()
 	authHandler := handler.NewAuthHandler(authUsecase)
+	searchHandler := handler.NewSearchHandler(searchUsecase)
+	statsHandler := handler.NewStatsHandler(statsUsecase)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsUsecase)
+	projectHandler := handler.NewProjectHandler(projectUsecase, suggestionUsecase, complianceUsecase)
+	projectArchiveHandler := handler.NewProjectArchiveHandler(projectArchiveUsecase)
+	candidateHandler := handler.NewCandidateHandler(candidatePreviewUsecase)
+	hashFileOperationHandler := handler.NewHashFileOperationHandler(hashFileOperationUsecase)
+	ntdsHandler := handler.NewNTDSHandler(ntdsUsecase)
+	credentialHandler := handler.NewCredentialHandler(credentialUsecase)
+	quotaHandler := handler.NewQuotaHandler(quotaUsecase)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceUsecase)
+	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentUsecase)
+	resultAccessHandler := handler.NewResultAccessHandler(resultAccessUsecase)
+	agentNetworkHandler := handler.NewAgentNetworkHandler(agentNetworkUsecase)
+	wordlistSourceHandler := handler.NewWordlistSourceHandler(wordlistSourceUsecase)
+	tenantHandler := handler.NewTenantHandler(tenantUsecase)
+	digestHandler := handler.NewDigestHandler(digestUsecase)
+	hashtopolisHandler := handler.NewHashtopolisHandler(hashtopolisUsecase)
+	agentBenchmarkHandler := handler.NewAgentBenchmarkHandler(agentBenchmarkUsecase)
+	agentCommandHandler := handler.NewAgentCommandHandler(agentCommandUsecase)
+	healthHandler := handler.NewHealthHandler(append(healthChecks, handler.HubCheck(handler.GetHub()))...)
+
+	// Uploads over the size limit, of the wrong type or infected are refused
+	hashFileHandler.SetUploadPolicy(uploadPolicies.HashFiles, uploadPolicies.Scanner)
+	wordlistHandler.SetUploadPolicy(uploadPolicies.Wordlists, uploadPolicies.Scanner)
+	// NTDS dumps are text of any extension, up to the hash file size limit
+	ntdsHandler.SetUploadPolicy(handler.UploadPolicy{MaxSize: uploadPolicies.HashFiles.MaxSize, MIMETypes: []string{handler.ContentTypeText}}, uploadPolicies.Scanner)
+	credentialHandler.SetMaxPotfileSize(uploadPolicies.HashFiles.MaxSize)
+	// Project archives hold wordlists, the largest uploads
+	projectArchiveHandler.SetMaxArchiveSize(uploadPolicies.Wordlists.MaxSize)
+	hashtopolisHandler.SetMaxExportSize(uploadPolicies.Wordlists.MaxSize)
+
+	// Heartbeat replies push the commands queued for the agent
+	agentHandler.SetCommands(agentCommandUsecase)
+
+	// Cracked passwords are masked everywhere but GET /jobs/:id/result
+	jobHandler.SetResultRedaction(resultAccessUsecase.RedactsResults())
+	searchHandler.SetResultRedaction(resultAccessUsecase.RedactsResults())
+	credentialHandler.SetResultRedaction(resultAccessUsecase.RedactsResults())
+	// and the loopback wordlists, which hold them in the clear, take the
+	// reveal permission to read
+	wordlistHandler.SetResultAccess(resultAccessUsecase)
 
 	// Initialize distributed job handler
 	distributedJobHandler := handler.NewDistributedJobHandler(distributedJobUsecase)
 
-	// Serve modern frontend (production build)
-	router.Static("/assets", "./frontend/dist/assets")
-	router.StaticFile("/", "./frontend/dist/index.html")
-	router.StaticFile("/index.html", "./frontend/dist/index.html")
+	// Serve the web UI (production build) on every path no route matches
+	router.NoRoute(handler.NewWebUIHandler(webUI).Serve)
 
 	// WebSocket endpoint
 	router.GET("/ws", wsHandler.HandleWebSocket)
@@ -75,8 +159,41 @@ func NewRouter(
 		})
 	})
 
+	// Probes for orchestrators: /healthz while the process serves HTTP,
+	// /readyz once its dependencies work too
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+
+	// Requests scoped with ?project_id= need a logged-in admin or project
+	// member; anything else, including agent traffic, is unaffected
+	projectScope := []gin.HandlerFunc{
+		middleware.OptionalAuthMiddleware(authUsecase),
+		middleware.ProjectAccess(projectUsecase),
+		// Users the policy requires two-factor authentication of only reach
+		// /auth, where they set it up, until they log in with it
+		middleware.TwoFactorPolicy(authUsecase, "/api/v1/auth/"),
+	}
+
+	// Clients downloading the same file take turns instead of stampeding
+	downloadLimit := middleware.DownloadLimit(downloadLimitConfig)
+
+	// Agents are only served from the addresses the network rules let in
+	agentACL := middleware.AgentNetworkACL(agentNetworkUsecase, trustedProxies)
+
+	// Agent-facing routes misbehave on purpose when fault injection is on
+	faults := middleware.FaultInjection(faultInjectionConfig)
+	heartbeatFaults := middleware.HeartbeatFaultInjection(faultInjectionConfig)
+
+	// Agents call without logging in, but work on every tenant's jobs
+	agentRoute := middleware.AgentRoute()
+
+	// Hashtopolis agents keep their server URL while teams switch over
+	if hashtopolisAgentAPI {
+		router.POST("/api/server.php", agentACL, hashtopolisHandler.AgentAPI)
+	}
+
 	// API v1 routes
-	v1 := router.Group("/api/v1")
+	v1 := router.Group("/api/v1", projectScope...)
 
 	// Handle all OPTIONS requests for API v1
 	v1.OPTIONS("/*any", func(c *gin.Context) {
@@ -92,11 +209,33 @@ func NewRouter(
 			auth.POST("/refresh", authHandler.RefreshToken)
 			auth.POST("/validate", authHandler.ValidateToken)
 			auth.POST("/check-username", authHandler.CheckUsernameExists)
+
+			// Single sign-on, when an identity provider is configured
+			if ssoUsecase != nil {
+				ssoHandler := handler.NewSSOHandler(ssoUsecase, ssoPostLoginURL)
+				auth.GET("/oidc/login", ssoHandler.BeginLogin)
+				auth.GET("/oidc/callback", ssoHandler.Callback)
+			}
+
+			// Two-factor authentication of the logged-in user
+			twoFactor := auth.Group("/2fa", middleware.AuthMiddleware(authUsecase))
+			twoFactor.GET("", authHandler.GetTwoFactorStatus)
+			twoFactor.POST("/enroll", authHandler.EnrollTwoFactor)
+			twoFactor.POST("/enable", authHandler.EnableTwoFactor)
+			twoFactor.POST("/verify", authHandler.VerifyTwoFactor)
+			twoFactor.POST("/disable", authHandler.DisableTwoFactor)
+			twoFactor.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+			// Sessions of the logged-in user
+			sessions := auth.Group("/sessions", middleware.AuthMiddleware(authUsecase))
+			sessions.GET("", authHandler.GetMySessions)
+			sessions.DELETE("", authHandler.RevokeMyOtherSessions)
+			sessions.DELETE("/:id", authHandler.RevokeMySession)
 		}
 
 		// User management routes (admin only)
 		users := v1.Group("/users")
-		users.Use(middleware.AuthMiddleware(jwtService))
+		users.Use(middleware.AuthMiddleware(authUsecase))
 		users.Use(middleware.AdminOnlyMiddleware())
 		{
 			users.POST("/", authHandler.CreateUser)
@@ -104,50 +243,227 @@ func NewRouter(
 			users.GET("/:id", authHandler.GetUser)
 			users.PUT("/:id", authHandler.UpdateUser)
 			users.DELETE("/:id", authHandler.DeleteUser)
+			users.DELETE("/:id/two-factor", authHandler.ResetTwoFactor)
+			users.GET("/:id/sessions", authHandler.GetUserSessions)
+			users.DELETE("/:id/sessions", authHandler.RevokeUserSessions)
+			users.DELETE("/:id/sessions/:sessionId", authHandler.RevokeUserSession)
+			// The policy applies across tenants
+			users.GET("/two-factor-policy", authHandler.GetTwoFactorPolicy)
+			users.PUT("/two-factor-policy", middleware.ClusterAdminOnlyMiddleware(), authHandler.UpdateTwoFactorPolicy)
 		}
+
+		// Email digests of projects the logged-in user subscribed to
+		digests := v1.Group("/digests")
+		digests.Use(middleware.AuthMiddleware(authUsecase))
+		{
+			digests.GET("", digestHandler.GetSubscriptions)
+			digests.PUT("/:projectId", digestHandler.Subscribe)
+			digests.DELETE("/:projectId", digestHandler.Unsubscribe)
+			digests.GET("/:projectId/preview", digestHandler.Preview)
+		}
+
+		// Project routes, listed and read by members, managed by admins
+		projects := v1.Group("/projects")
+		projects.Use(middleware.AuthMiddleware(authUsecase))
+		{
+			adminOnly := middleware.AdminOnlyMiddleware()
+			projects.POST("/", adminOnly, projectHandler.CreateProject)
+			// Archives move projects between servers, cracked passwords included
+			projects.POST("/import", adminOnly, projectArchiveHandler.ImportProject)
+			projects.GET("/:id/export", adminOnly, projectArchiveHandler.ExportProject)
+			projects.POST("/import/hashtopolis", adminOnly, hashtopolisHandler.Import)
+			projects.GET("/", projectHandler.GetAllProjects)
+			projects.GET("/:id", projectHandler.GetProject)
+			projects.PUT("/:id", adminOnly, projectHandler.UpdateProject)
+			projects.DELETE("/:id", adminOnly, projectHandler.DeleteProject)
+			projects.GET("/:id/members", projectHandler.GetProjectMembers)
+			projects.GET("/:id/suggestions", projectHandler.GetProjectSuggestions)
+			projects.GET("/:id/compliance", projectHandler.GetComplianceReport)
+			projects.POST("/:id/compliance", projectHandler.GetComplianceReport)
+			projects.POST("/:id/members", adminOnly, projectHandler.AddProjectMember)
+			projects.DELETE("/:id/members/:userId", adminOnly, projectHandler.RemoveProjectMember)
+		}
+
+		// Quota routes (cluster admins only); scope is user, project or tenant
+		quotas := v1.Group("/quotas")
+		quotas.Use(middleware.AuthMiddleware(authUsecase))
+		quotas.Use(middleware.AdminOnlyMiddleware())
+		quotas.Use(middleware.ClusterAdminOnlyMiddleware())
+		{
+			quotas.GET("/", quotaHandler.GetAllQuotas)
+			quotas.GET("/:scope/:id", quotaHandler.GetQuota)
+			quotas.PUT("/:scope/:id", quotaHandler.SetQuota)
+			quotas.DELETE("/:scope/:id", quotaHandler.DeleteQuota)
+		}
+
+		// Tenant routes (cluster admins only)
+		tenants := v1.Group("/tenants")
+		tenants.Use(middleware.AuthMiddleware(authUsecase))
+		tenants.Use(middleware.AdminOnlyMiddleware())
+		tenants.Use(middleware.ClusterAdminOnlyMiddleware())
+		{
+			tenants.POST("/", tenantHandler.CreateTenant)
+			tenants.GET("/", tenantHandler.GetAllTenants)
+			tenants.GET("/:id", tenantHandler.GetTenant)
+			tenants.PUT("/:id", tenantHandler.UpdateTenant)
+			tenants.DELETE("/:id", tenantHandler.DeleteTenant)
+			tenants.GET("/:id/stats", tenantHandler.GetTenantStats)
+			tenants.PUT("/:id/users/:userId", tenantHandler.AddTenantUser)
+			tenants.DELETE("/:id/users/:userId", tenantHandler.RemoveTenantUser)
+			tenants.PUT("/:id/agents/:agentId", tenantHandler.AddTenantAgent)
+			tenants.DELETE("/:id/agents/:agentId", tenantHandler.RemoveTenantAgent)
+		}
+
 		// Agent routes
 		agents := v1.Group("/agents")
 		{
-			agents.POST("/generate-key", agentHandler.GenerateAgentKey) // New route for generating agent keys
-			agents.POST("/startup", agentHandler.AgentStartup)          // New route for agent startup
-			agents.POST("/heartbeat", agentHandler.AgentHeartbeat)      // New route for agent heartbeat
-			agents.POST("/update-data", agentHandler.UpdateAgentData)   // New route for updating agent data (no status change)
-			agents.POST("/", agentHandler.RegisterAgent)
+			auth, adminOnly := middleware.AuthMiddleware(authUsecase), middleware.AdminOnlyMiddleware()
+			agents.POST("/generate-key", agentHandler.GenerateAgentKey)                                   // New route for generating agent keys
+			agents.POST("/enroll", agentACL, enrollmentHandler.Enroll)                                    // Trade an enrollment token for an agent key
+			agents.POST("/startup", agentACL, agentRoute, faults, agentHandler.AgentStartup)              // New route for agent startup
+			agents.POST("/heartbeat", agentACL, agentRoute, heartbeatFaults, agentHandler.AgentHeartbeat) // New route for agent heartbeat
+			agents.POST("/update-data", agentACL, agentRoute, faults, agentHandler.UpdateAgentData)       // New route for updating agent data (no status change)
+			agents.POST("/sync", agentACL, agentRoute, faults, jobHandler.SyncAgent)
+			agents.POST("/", agentACL, agentRoute, faults, agentHandler.RegisterAgent)
 			agents.GET("/", agentHandler.GetAllAgents)
+			agents.GET("/stale", auth, adminOnly, agentHandler.GetStaleAgents)
+			agents.POST("/stale/purge", auth, adminOnly, agentHandler.PurgeStaleAgents)
 			agents.GET("/:id", agentHandler.GetAgent)
-			agents.PUT("/:id/status", agentHandler.UpdateAgentStatus)
-			agents.PUT("/:id/speed", agentHandler.UpdateAgentSpeed)
-			agents.PUT("/:id/speed-status", agentHandler.UpdateAgentSpeedWithStatus) // Real-time speed and status update
+			agents.PUT("/:id/status", agentRoute, agentHandler.UpdateAgentStatus)
+			agents.PUT("/:id/speed", agentACL, agentRoute, faults, agentHandler.UpdateAgentSpeed)
+			agents.PUT("/:id/speed-status", agentACL, agentRoute, faults, agentHandler.UpdateAgentSpeedWithStatus) // Real-time speed and status update
 
-			agents.PUT("/:id/status-offline", agentHandler.UpdateAgentStatusOffline) // Update status to offline without resetting speed
-			agents.PUT("/:id/heartbeat", agentHandler.UpdateAgentHeartbeat)
-			agents.POST("/:id/files", agentHandler.RegisterAgentFiles)
+			agents.PUT("/:id/status-offline", agentACL, agentRoute, faults, agentHandler.UpdateAgentStatusOffline) // Update status to offline without resetting speed
+			agents.PUT("/:id/heartbeat", agentACL, agentRoute, heartbeatFaults, agentHandler.UpdateAgentHeartbeat)
+			agents.POST("/:id/files", agentACL, agentRoute, faults, agentHandler.RegisterAgentFiles)
+			agents.GET("/:id/files", agentHandler.GetAgentFiles)
+			agents.POST("/:id/logs", agentACL, agentRoute, faults, agentHandler.PushAgentLogs)
+			agents.GET("/:id/logs", agentHandler.GetAgentLogs)
+			agents.GET("/:id/hashcat-args", agentHandler.GetAgentHashcatArgs)
+			agents.PUT("/:id/hashcat-args", agentHandler.SetAgentHashcatArgs)
+			agents.GET("/:id/settings", agentRoute, agentHandler.GetAgentSettings)
+			agents.PUT("/:id/settings", agentHandler.SetAgentSettings)
+			agents.GET("/:id/cache", agentHandler.GetAgentCache)
+			agents.GET("/:id/benchmarks", agentBenchmarkHandler.GetBenchmarks)
+			agents.POST("/:id/benchmarks", agentBenchmarkHandler.RequestBenchmark)
+			agents.DELETE("/:id/benchmarks", agentBenchmarkHandler.InvalidateBenchmarks)
+			agents.GET("/:id/benchmarks/next", agentACL, agentRoute, faults, agentBenchmarkHandler.ClaimBenchmarkJob)
+			agents.POST("/:id/benchmarks/:job_id/report", agentACL, agentRoute, faults, agentBenchmarkHandler.ReportBenchmarkJob)
+			agents.GET("/:id/commands", agentCommandHandler.GetCommands)
+			agents.POST("/:id/commands", agentCommandHandler.QueueCommand)
+			agents.GET("/:id/commands/pending", agentACL, agentRoute, faults, agentCommandHandler.GetPendingCommands)
+			agents.GET("/:id/commands/:command_id", agentCommandHandler.GetCommand)
+			agents.DELETE("/:id/commands/:command_id", agentCommandHandler.CancelCommand)
+			agents.POST("/:id/commands/:command_id/ack", agentACL, agentRoute, faults, agentCommandHandler.AcknowledgeCommand)
+			agents.POST("/:id/commands/:command_id/result", agentACL, agentRoute, faults, agentCommandHandler.ReportCommandResult)
 			agents.GET("/:id/jobs", jobHandler.GetJobsByAgentID)
-			agents.GET("/:id/jobs/next", jobHandler.GetAvailableJobForAgent)
+			agents.GET("/:id/jobs/next", agentACL, agentRoute, faults, jobHandler.GetAvailableJobForAgent)
 			agents.DELETE("/:id", agentHandler.DeleteAgent)
 		}
 
+		// Enrollment token routes (admin only)
+		enrollmentTokens := v1.Group("/enrollment-tokens")
+		enrollmentTokens.Use(middleware.AuthMiddleware(authUsecase))
+		enrollmentTokens.Use(middleware.AdminOnlyMiddleware())
+		{
+			enrollmentTokens.POST("/", enrollmentHandler.CreateTokens)
+			enrollmentTokens.GET("/", enrollmentHandler.GetAllTokens)
+			enrollmentTokens.DELETE("/:id", enrollmentHandler.DeleteToken)
+		}
+
+		// Address ranges agents may connect from, and what they refused (admin only)
+		agentNetwork := v1.Group("/agent-network")
+		agentNetwork.Use(middleware.AuthMiddleware(authUsecase))
+		agentNetwork.Use(middleware.AdminOnlyMiddleware())
+		{
+			agentNetwork.GET("/rules", agentNetworkHandler.GetRules)
+			agentNetwork.POST("/rules", agentNetworkHandler.CreateRule)
+			agentNetwork.DELETE("/rules/:id", agentNetworkHandler.DeleteRule)
+			agentNetwork.GET("/denials", agentNetworkHandler.GetDenials)
+		}
+
+		// Who may reveal cracked passwords, and who did (admin only)
+		results := v1.Group("/results")
+		results.Use(middleware.AuthMiddleware(authUsecase))
+		results.Use(middleware.AdminOnlyMiddleware())
+		{
+			results.GET("/reveals", resultAccessHandler.GetReveals)
+			results.GET("/grants", resultAccessHandler.GetRevealGrants)
+			results.PUT("/grants/:userId", resultAccessHandler.GrantReveal)
+			results.DELETE("/grants/:userId", resultAccessHandler.RevokeReveal)
+		}
+
+		// Maintenance window routes; anyone can read the calendar, admins edit it
+		maintenance := v1.Group("/maintenance-windows")
+		{
+			auth, adminOnly := middleware.AuthMiddleware(authUsecase), middleware.AdminOnlyMiddleware()
+			maintenance.GET("/", maintenanceHandler.GetAllWindows)
+			maintenance.GET("/active", maintenanceHandler.GetAgentsInMaintenance)
+			maintenance.POST("/", auth, adminOnly, maintenanceHandler.CreateWindow)
+			maintenance.GET("/:id", maintenanceHandler.GetWindow)
+			maintenance.PUT("/:id", auth, adminOnly, maintenanceHandler.UpdateWindow)
+			maintenance.DELETE("/:id", auth, adminOnly, maintenanceHandler.DeleteWindow)
+		}
+
+		// Agent group routes
+		agentGroups := v1.Group("/agent-groups")
+		{
+			agentGroups.POST("/", agentHandler.CreateAgentGroup)
+			agentGroups.GET("/", agentHandler.GetAllAgentGroups)
+			agentGroups.GET("/:id", agentHandler.GetAgentGroup)
+			agentGroups.DELETE("/:id", agentHandler.DeleteAgentGroup)
+			agentGroups.POST("/:id/agents", agentHandler.AddAgentToGroup)
+			agentGroups.DELETE("/:id/agents/:agentId", agentHandler.RemoveAgentFromGroup)
+		}
+
 		// Job routes
+		// Job creation honours Idempotency-Key so scripted retries don't create duplicate jobs
+		idempotency := middleware.Idempotency(idempotencyRepo, 24*time.Hour)
 		jobs := v1.Group("/jobs")
 		{
-			jobs.POST("/", jobHandler.CreateJob)
+			// Comments carry their author, so writing them needs a login
+			auth, adminOnly := middleware.AuthMiddleware(authUsecase), middleware.AdminOnlyMiddleware()
+			jobs.POST("/", idempotency, jobHandler.CreateJob)
 			jobs.GET("/", jobHandler.GetAllJobs)
 			jobs.GET("/parallel/summary", jobHandler.GetParallelJobsSummary)
+			jobs.GET("/archived", jobHandler.GetArchivedJobs)
+			jobs.GET("/compare", jobHandler.CompareJobs)
+			jobs.GET("/recommendations", recommendationHandler.GetWordlistRecommendations)
 			jobs.POST("/assign", jobHandler.AssignJobs)
-			jobs.POST("/auto", jobHandler.CreateParallelJobs)
-			jobs.GET("/agent/:id", jobHandler.GetAvailableJobForAgent)
-			jobs.GET("/:id", jobHandler.GetJob)
-			jobs.POST("/:id/start", jobHandler.StartJob)
-			jobs.PUT("/:id/progress", jobHandler.UpdateJobProgress)
-			jobs.PUT("/:id/data", jobHandler.UpdateJobDataFromAgent)
-			jobs.POST("/:id/complete", jobHandler.CompleteJob)
-			jobs.POST("/:id/fail", jobHandler.FailJob)
+			jobs.POST("/auto", idempotency, jobHandler.CreateParallelJobs)
+			jobs.POST("/apply", idempotency, jobHandler.ApplyJobs)
+			jobs.GET("/agent/:id", agentACL, agentRoute, faults, jobHandler.GetAvailableJobForAgent)
+			jobs.GET("/:id", agentRoute, jobHandler.GetJob)
+			jobs.POST("/:id/start", agentRoute, faults, jobHandler.StartJob)
+			jobs.PUT("/:id/progress", agentRoute, faults, jobHandler.UpdateJobProgress)
+			jobs.PUT("/:id/data", agentRoute, faults, jobHandler.UpdateJobDataFromAgent)
+			jobs.POST("/:id/complete", agentRoute, faults, jobHandler.CompleteJob)
+			jobs.POST("/:id/fail", agentRoute, faults, jobHandler.FailJob)
 			jobs.POST("/:id/pause", jobHandler.PauseJob)
 			jobs.POST("/:id/resume", jobHandler.ResumeJob)
 			jobs.POST("/:id/stop", jobHandler.StopJob)
+			jobs.POST("/:id/restore", jobHandler.RestoreJob)
+			jobs.POST("/:id/retry", jobHandler.RetryJob)
+			jobs.GET("/:id/events", jobHandler.GetJobEvents)
+			jobs.PUT("/:id/tags", jobHandler.SetJobTags)
+			jobs.GET("/:id/comments", jobHandler.GetJobComments)
+			jobs.POST("/:id/comments", auth, jobHandler.CreateJobComment)
+			jobs.DELETE("/:id/comments/:commentId", auth, adminOnly, jobHandler.DeleteJobComment)
+			jobs.GET("/:id/result", auth, resultAccessHandler.RevealJobResult) // Unmasked result, logged
+			jobs.GET("/:id/output", jobHandler.GetJobOutput)
+			jobs.POST("/:id/interrupt", agentRoute, faults, jobHandler.InterruptJob)
+			jobs.GET("/:id/checkpoint", agentRoute, faults, jobHandler.GetJobCheckpoint)
+			jobs.POST("/:id/cracks", agentRoute, faults, jobHandler.ReportJobCracks)
+			jobs.GET("/:id/cracks", jobHandler.GetJobCracks)
 			jobs.DELETE("/:id", jobHandler.DeleteJob)
 		}
 
+		// Job group routes
+		jobGroups := v1.Group("/job-groups")
+		{
+			jobGroups.GET("/:id", jobHandler.GetJobGroup)
+		}
+
 		// Distributed Job routes
 		distributedJobs := v1.Group("/distributed-jobs")
 		{
@@ -164,8 +480,41 @@ func NewRouter(
 			hashFiles.POST("/upload", hashFileHandler.UploadHashFile)
 			hashFiles.GET("/", hashFileHandler.GetAllHashFiles)
 			hashFiles.GET("/:id", hashFileHandler.GetHashFile)
-			hashFiles.GET("/:id/download", hashFileHandler.DownloadHashFile)
+			hashFiles.GET("/:id/download", downloadLimit, agentRoute, faults, hashFileHandler.DownloadHashFile)
+			hashFiles.PUT("/:id/source", hashFileHandler.SetHashFileSource)
 			hashFiles.DELETE("/:id", hashFileHandler.DeleteHashFile)
+
+			// Merge, split and diff run in the background, polled by operation ID
+			hashFiles.POST("/merge", hashFileOperationHandler.MergeHashFiles)
+			hashFiles.POST("/diff", hashFileOperationHandler.DiffHashFiles)
+			hashFiles.POST("/:id/split", hashFileOperationHandler.SplitHashFile)
+			hashFiles.GET("/operations", hashFileOperationHandler.GetOperations)
+			hashFiles.GET("/operations/:operationId", hashFileOperationHandler.GetOperation)
+
+			// Potfiles of cracking done elsewhere, recorded as credentials
+			hashFiles.POST("/:id/cracked", credentialHandler.ImportPotfile)
+		}
+
+		// Active Directory NTDS dumps, their accounts and how many are cracked
+		ntds := v1.Group("/ntds")
+		{
+			ntds.POST("/import", ntdsHandler.ImportNTDS)
+			ntds.GET("/", ntdsHandler.GetImports)
+			ntds.GET("/:id", ntdsHandler.GetImport)
+			ntds.DELETE("/:id", ntdsHandler.DeleteImport)
+			ntds.GET("/:id/accounts", ntdsHandler.GetAccounts)
+			ntds.PUT("/:id/privileged", ntdsHandler.SetPrivileged)
+			ntds.POST("/:id/cracked", ntdsHandler.ImportCracked)
+			ntds.GET("/:id/report", ntdsHandler.GetReport)
+		}
+
+		// Cracked passwords linked to their accounts, for findings reports
+		credentials := v1.Group("/credentials")
+		{
+			credentials.GET("/", credentialHandler.GetCredentials)
+			credentials.GET("/stats", credentialHandler.GetStats)
+			credentials.GET("/users/:username", credentialHandler.GetCredentials)
+			credentials.GET("/domains/:domain", credentialHandler.GetCredentials)
 		}
 
 		// Wordlist routes
@@ -173,12 +522,59 @@ func NewRouter(
 		{
 			wordlists.POST("/upload", wordlistHandler.UploadWordlist)
 			wordlists.GET("/", wordlistHandler.GetAllWordlists)
+			wordlists.GET("/loopback", wordlistHandler.GetLoopbackWordlist)
 			wordlists.GET("/:id", wordlistHandler.GetWordlist)
 			wordlists.GET("/:id/content", wordlistHandler.GetWordlistContent)
-			wordlists.GET("/:id/download", wordlistHandler.DownloadWordlist)
+			wordlists.GET("/:id/download", downloadLimit, agentRoute, faults, wordlistHandler.DownloadWordlist)
 			wordlists.DELETE("/:id", wordlistHandler.DeleteWordlist)
 		}
 
+		// Remote wordlists the server downloads itself (admin only)
+		wordlistSources := v1.Group("/wordlist-sources")
+		wordlistSources.Use(middleware.AuthMiddleware(authUsecase))
+		wordlistSources.Use(middleware.AdminOnlyMiddleware())
+		{
+			wordlistSources.POST("/", wordlistSourceHandler.CreateSource)
+			wordlistSources.GET("/", wordlistSourceHandler.GetSources)
+			wordlistSources.GET("/:id", wordlistSourceHandler.GetSource)
+			wordlistSources.PUT("/:id", wordlistSourceHandler.UpdateSource)
+			wordlistSources.DELETE("/:id", wordlistSourceHandler.DeleteSource)
+			wordlistSources.POST("/:id/fetch", wordlistSourceHandler.FetchSource)
+		}
+
+		// Custom charset file routes
+		charsets := v1.Group("/charsets")
+		{
+			charsets.POST("/upload", charsetHandler.UploadCharset)
+			charsets.GET("/", charsetHandler.GetAllCharsets)
+			charsets.GET("/:id", charsetHandler.GetCharset)
+			charsets.GET("/:id/download", downloadLimit, agentRoute, faults, charsetHandler.DownloadCharset)
+			charsets.DELETE("/:id", charsetHandler.DeleteCharset)
+		}
+
+		// Sample the candidates of a wordlist+rule or mask attack before queueing it
+		v1.POST("/candidates/preview", candidateHandler.PreviewCandidates)
+
+		// Global search
+		v1.GET("/search", searchHandler.Search)
+
+		// Cluster statistics for dashboards
+		v1.GET("/stats", statsHandler.GetClusterStats)
+		v1.GET("/reports/cost", statsHandler.GetCostReport)
+		// Cracks per keyspace of wordlists, rule files and masks
+		v1.GET("/analytics/effectiveness", analyticsHandler.GetEffectiveness)
+
+		// Server-Sent Events fallback for clients that can't use /ws
+		v1.GET("/stream", streamHandler.Stream)
+
+		// Slack slash commands and buttons, when a signing secret is configured.
+		// Slack signs its requests instead of logging in.
+		if chatOpsUsecase != nil {
+			slackHandler := handler.NewSlackHandler(chatOpsUsecase, slackSigningSecret)
+			v1.POST("/integrations/slack/commands", slackHandler.Command)
+			v1.POST("/integrations/slack/interactions", slackHandler.Interaction)
+		}
+
 		// Cache management routes
 		cache := v1.Group("/cache")
 		{
@@ -188,7 +584,7 @@ func NewRouter(
 	}
 
 	// Legacy API routes for backward compatibility
-	api := router.Group("/api")
+	api := router.Group("/api", projectScope...)
 	{
 		// Legacy routes (without v1 prefix)
 		api.GET("/agents", agentHandler.GetAllAgents)
@@ -198,7 +594,7 @@ func NewRouter(
 
 		// Legacy upload routes
 		api.POST("/wordlists/upload", wordlistHandler.UploadWordlist)
-		api.GET("/wordlists/:id/download", wordlistHandler.DownloadWordlist)
+		api.GET("/wordlists/:id/download", downloadLimit, agentRoute, faults, wordlistHandler.DownloadWordlist)
 		api.DELETE("/wordlists/:id", wordlistHandler.DeleteWordlist)
 	}
 
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Groupings of cost reports
+const (
+	CostGroupJob     = "job"
+	CostGroupProject = "project"
+	CostGroupAgent   = "agent"
+)
+
+// UsageSample is what an agent reports about the devices of a running job
+type UsageSample struct {
+	Devices    int     // Devices the job runs on
+	PowerWatts float64 // Their combined draw, 0 when the agent can't read it
+}
+
+// CostRates price the compute jobs use, for chargeback
+type CostRates struct {
+	Currency    string  `json:"currency"`
+	DeviceHour  float64 `json:"device_hour"`  // Price of one device running for an hour
+	KWh         float64 `json:"kwh"`          // Price of one kilowatt-hour
+	DeviceWatts float64 `json:"device_watts"` // Assumed draw of a device when its agent doesn't report one
+}
+
+// UsageCost is the compute a set of jobs used and its price
+type UsageCost struct {
+	DeviceHours float64 `json:"device_hours"`
+	EnergyKWh   float64 `json:"energy_kwh"`
+	Cost        float64 `json:"cost"`
+}
+
+// Price converts accumulated usage to device-hours, kWh and their cost
+func (r CostRates) Price(deviceSeconds, energyWh float64) UsageCost {
+	usage := UsageCost{
+		DeviceHours: deviceSeconds / 3600,
+		EnergyKWh:   energyWh / 1000,
+	}
+	usage.Cost = usage.DeviceHours*r.DeviceHour + usage.EnergyKWh*r.KWh
+	return usage
+}
+
+// UsageTotal is the usage of the jobs of one job, project or agent
+type UsageTotal struct {
+	ID            *uuid.UUID // Nil for jobs without a project or agent
+	Name          string
+	Jobs          int
+	DeviceSeconds float64
+	EnergyWh      float64
+}
+
+// CostReport is the usage and cost of jobs grouped by job, project or agent
+type CostReport struct {
+	GroupBy     string           `json:"group_by"`
+	From        *time.Time       `json:"from,omitempty"`
+	To          *time.Time       `json:"to,omitempty"`
+	Rates       CostRates        `json:"rates"`
+	Items       []CostReportItem `json:"items"` // Most expensive first
+	Total       UsageCost        `json:"total"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// CostReportItem is one job, project or agent of a cost report
+type CostReportItem struct {
+	ID   *uuid.UUID `json:"id,omitempty"` // Nil for jobs without a project or agent
+	Name string     `json:"name"`
+	Jobs int        `json:"jobs"`
+	UsageCost
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Agent command types
+const (
+	// AgentCommandBenchmark re-runs the agent's startup benchmark
+	AgentCommandBenchmark = "benchmark"
+	// AgentCommandClearCache empties the agent's download cache, except for
+	// the files of the running job
+	AgentCommandClearCache = "clear_cache"
+	// AgentCommandUpgrade runs the upgrade command configured on the agent,
+	// which then restarts
+	AgentCommandUpgrade = "upgrade"
+	// AgentCommandCollectDiagnostics reports versions, settings, disk and
+	// cache usage and the running job
+	AgentCommandCollectDiagnostics = "collect_diagnostics"
+	// AgentCommandRestartWatchdog restarts the stall clock of the running
+	// job's watchdog, e.g. before a job is stopped for a slow phase
+	AgentCommandRestartWatchdog = "restart_watchdog"
+)
+
+// AgentCommandTypes are the commands agents understand
+var AgentCommandTypes = map[string]bool{
+	AgentCommandBenchmark:          true,
+	AgentCommandClearCache:         true,
+	AgentCommandUpgrade:            true,
+	AgentCommandCollectDiagnostics: true,
+	AgentCommandRestartWatchdog:    true,
+}
+
+// Agent command statuses. A pending command is delivered with every
+// heartbeat and poll until the agent acknowledges it.
+const (
+	AgentCommandPending      = "pending"
+	AgentCommandAcknowledged = "acknowledged"
+	AgentCommandCompleted    = "completed"
+	AgentCommandFailed       = "failed"
+	AgentCommandExpired      = "expired" // Not acknowledged in time
+	AgentCommandCancelled    = "cancelled"
+)
+
+// AgentCommandTTL is how long a command waits for its agent to acknowledge
+// it, so an agent that was offline doesn't upgrade hours later
+const AgentCommandTTL = time.Hour
+
+// maxAgentCommandResult limits the result an agent reports for a command
+const maxAgentCommandResult = 256 * 1024
+
+// upgradeVersionPattern is what an upgrade's version may look like; it is
+// handed to the agent's upgrade command
+var upgradeVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.+-]{0,63}$`)
+
+// AgentCommand is an action queued for an agent. The agent acknowledges it
+// before running it and reports the result back.
+type AgentCommand struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	AgentID uuid.UUID `json:"agent_id" db:"agent_id"`
+	Type    string    `json:"type" db:"type"` // One of the AgentCommand types
+	// Arguments of the command, e.g. {"version": "1.4.0"} for an upgrade
+	Params json.RawMessage `json:"params,omitempty" db:"params"`
+	Status string          `json:"status" db:"status"` // One of the AgentCommand statuses
+	// What the agent reported, e.g. the diagnostics it collected
+	Result         json.RawMessage `json:"result,omitempty" db:"result"`
+	Error          string          `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time       `json:"expires_at" db:"expires_at"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Finished reports whether the command reached a final status
+func (c *AgentCommand) Finished() bool {
+	switch c.Status {
+	case AgentCommandCompleted, AgentCommandFailed, AgentCommandExpired, AgentCommandCancelled:
+		return true
+	}
+	return false
+}
+
+// UpgradeParams are the arguments of an upgrade command
+type UpgradeParams struct {
+	// Version to install, passed to the upgrade command; empty for the latest
+	Version string `json:"version,omitempty"`
+}
+
+// AgentCommandRequest queues a command for an agent
+type AgentCommandRequest struct {
+	Type   string          `json:"type" binding:"required"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Validate checks the command type and its arguments. Only upgrades take
+// arguments.
+func (r *AgentCommandRequest) Validate() error {
+	if !AgentCommandTypes[r.Type] {
+		return &ValidationError{Field: "type", Message: "must be one of benchmark, clear_cache, upgrade, collect_diagnostics, restart_watchdog"}
+	}
+	if len(r.Params) == 0 || string(r.Params) == "null" {
+		r.Params = nil
+		return nil
+	}
+	if r.Type != AgentCommandUpgrade {
+		return &ValidationError{Field: "params", Message: r.Type + " takes no params"}
+	}
+	var params UpgradeParams
+	if err := json.Unmarshal(r.Params, &params); err != nil {
+		return &ValidationError{Field: "params", Message: "invalid upgrade params"}
+	}
+	if params.Version != "" && !upgradeVersionPattern.MatchString(params.Version) {
+		return &ValidationError{Field: "params.version", Message: "must be a version such as 1.4.0"}
+	}
+	return nil
+}
+
+// AgentCommandResult is what an agent reports after running a command
+type AgentCommandResult struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"` // Why the command failed
+}
+
+// Validate checks that the result is JSON of a sane size
+func (r *AgentCommandResult) Validate() error {
+	if len(r.Result) > maxAgentCommandResult {
+		return &ValidationError{Field: "result", Message: "must be at most 256 KB"}
+	}
+	if len(r.Result) > 0 && !json.Valid(r.Result) {
+		return &ValidationError{Field: "result", Message: "must be JSON"}
+	}
+	return nil
+}
+
+// AgentCommandRepository stores the commands queued for agents
+type AgentCommandRepository interface {
+	Create(ctx context.Context, command *AgentCommand) error
+	GetByID(ctx context.Context, id uuid.UUID) (*AgentCommand, error)
+	// GetByAgent returns up to limit of the agent's commands, newest first
+	GetByAgent(ctx context.Context, agentID uuid.UUID, limit int) ([]AgentCommand, error)
+	// GetPending returns the agent's pending commands, oldest first
+	GetPending(ctx context.Context, agentID uuid.UUID) ([]AgentCommand, error)
+	// Expire marks the agent's pending commands that expired by now
+	Expire(ctx context.Context, agentID uuid.UUID, now time.Time) error
+	// Transition moves a command from one status to another, together with
+	// its result fields. It returns false when the command wasn't in from.
+	Transition(ctx context.Context, command *AgentCommand, from string) (bool, error)
+}
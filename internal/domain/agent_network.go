@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Network rule actions
+const (
+	NetworkRuleAllow = "allow"
+	NetworkRuleDeny  = "deny"
+)
+
+// ErrAgentNetworkDenied is returned for agent requests from an address the
+// network rules don't let in
+var ErrAgentNetworkDenied = errors.New("agent requests from this address are not allowed")
+
+// AgentNetworkRule lets an address range in or keeps it out, for one agent
+// or, without AgentID, for all of them. Deny rules win; when allow rules
+// exist, an agent's own allow rules replace the global ones.
+type AgentNetworkRule struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	AgentID     *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"` // Nil for a global rule
+	CIDR        string     `json:"cidr" db:"cidr"`                   // A single address counts as /32 or /128
+	Action      string     `json:"action" db:"action"`               // allow or deny
+	Description string     `json:"description,omitempty" db:"description"`
+	Static      bool       `json:"static,omitempty" db:"-"` // From the server configuration, can't be deleted
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateAgentNetworkRuleRequest adds a network rule. AgentKey names the
+// agent as its configuration does, instead of AgentID.
+type CreateAgentNetworkRuleRequest struct {
+	CIDR        string     `json:"cidr" binding:"required"`
+	Action      string     `json:"action" binding:"required"`
+	AgentID     *uuid.UUID `json:"agent_id,omitempty"`
+	AgentKey    string     `json:"agent_key,omitempty"`
+	Description string     `json:"description,omitempty"`
+}
+
+// AgentNetworkCheck is an agent request to check against the network rules
+type AgentNetworkCheck struct {
+	IPAddress string
+	AgentID   *uuid.UUID // Set for requests that name the agent in the path
+	AgentKey  string     // Set for requests that carry the agent key
+	Path      string
+}
+
+// AgentAccessDenial is an audit record of an agent request the network
+// rules refused
+type AgentAccessDenial struct {
+	ID        int64      `json:"id" db:"id"`
+	IPAddress string     `json:"ip_address" db:"ip_address"`
+	AgentID   *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`
+	AgentName string     `json:"agent_name,omitempty" db:"agent_name"`
+	AgentKey  string     `json:"agent_key,omitempty" db:"agent_key"` // Masked, for keys of no known agent
+	Path      string     `json:"path" db:"path"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
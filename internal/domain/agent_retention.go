@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What happens to agents that haven't been seen for the stale period
+const (
+	AgentRetentionFlag    = "flag"    // Only list them as stale
+	AgentRetentionArchive = "archive" // Hide them and drop their telemetry; they come back when they reconnect
+	AgentRetentionDelete  = "delete"  // Remove them with their telemetry
+)
+
+// AgentRetentionPolicy is how long agents may stay away before they are
+// stale and what is done with them then
+type AgentRetentionPolicy struct {
+	StaleAfter time.Duration `json:"stale_after"` // Zero turns the policy off
+	Action     string        `json:"action"`      // flag, archive or delete
+}
+
+// StaleAgent is an agent that hasn't been seen since before a cutoff
+type StaleAgent struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	IPAddress  string     `json:"ip_address"`
+	Status     string     `json:"status"`
+	LastSeen   time.Time  `json:"last_seen"` // Its registration when it was never seen
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// ActiveJobs counts the assigned, running and paused jobs still on the
+	// agent; agents with any are never purged
+	ActiveJobs int `json:"active_jobs"`
+}
+
+// PurgeStaleAgentsRequest selects the stale agents to archive or delete
+type PurgeStaleAgentsRequest struct {
+	OlderThanDays int         `json:"older_than_days"`     // Defaults to the retention policy
+	Action        string      `json:"action"`              // archive or delete, the default
+	AgentIDs      []uuid.UUID `json:"agent_ids,omitempty"` // Only these of the stale agents
+	DryRun        bool        `json:"dry_run"`             // Report what would be purged without changing anything
+}
+
+// PurgeStaleAgentsResult lists the stale agents purged and those left alone
+// because they still have jobs
+type PurgeStaleAgentsResult struct {
+	Action  string       `json:"action"`
+	Cutoff  time.Time    `json:"cutoff"`
+	DryRun  bool         `json:"dry_run"`
+	Purged  []StaleAgent `json:"purged"`
+	Skipped []StaleAgent `json:"skipped"`
+}
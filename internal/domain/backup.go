@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Names of the files a backup consists of at the backup target, under a
+// directory named after the backup ID. The manifest is written last, so a
+// backup without one is incomplete.
+const (
+	BackupDatabaseFile = "hashcat.db"
+	BackupManifestFile = "manifest.json"
+)
+
+// Backup is the manifest of a backup: a consistent copy of the database
+// and a listing of the upload directory at the time it was taken
+type Backup struct {
+	ID             string         `json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DatabaseType   string         `json:"database_type"`
+	DatabaseSize   int64          `json:"database_size"`
+	DatabaseSHA256 string         `json:"database_sha256"`
+	SchemaVersion  int            `json:"schema_version"` // Last migration applied to the copy
+	Uploads        []BackupUpload `json:"uploads"`
+}
+
+// BackupUpload is a file of the upload directory. Uploads aren't copied,
+// the listing tells what a restored database expects to find.
+type BackupUpload struct {
+	Path    string    `json:"path"` // Relative to the upload directory
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// BackupRestoreResult describes a restored backup
+type BackupRestoreResult struct {
+	Backup           *Backup  `json:"backup"`
+	PreviousDatabase string   `json:"previous_database,omitempty"` // Where the replaced database was moved
+	MissingUploads   []string `json:"missing_uploads"`             // Listed in the backup but not in the upload directory
+	ChangedUploads   []string `json:"changed_uploads"`             // Present with a different size
+}
+
+// BackupStore keeps backup files at a backup target, e.g. a local directory
+// or an S3 bucket
+type BackupStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns a NotFoundError when there is no file under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys of all stored files
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DatabaseSnapshotter copies the live database without stopping writers
+type DatabaseSnapshotter interface {
+	// Snapshot writes a consistent copy of the database to path
+	Snapshot(ctx context.Context, path string) error
+}
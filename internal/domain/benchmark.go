@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBenchmarkHashTypes limits the hash modes one benchmark job measures,
+// as hashcat benchmarks each of them for several seconds
+const MaxBenchmarkHashTypes = 32
+
+// AgentBenchmark is the speed hashcat's benchmark measured for one hash
+// mode on an agent
+type AgentBenchmark struct {
+	AgentID    uuid.UUID `json:"agent_id" db:"agent_id"`
+	HashType   int       `json:"hash_type" db:"hash_type"`
+	Speed      int64     `json:"speed" db:"speed"` // H/s of all the agent's devices
+	MeasuredAt time.Time `json:"measured_at" db:"measured_at"`
+}
+
+// Benchmark job statuses. A running job is handed out again when its agent
+// asks for work, as the agent lost it.
+const (
+	BenchmarkJobPending   = "pending"
+	BenchmarkJobRunning   = "running"
+	BenchmarkJobCompleted = "completed"
+	BenchmarkJobFailed    = "failed"
+)
+
+// BenchmarkJob asks an agent to benchmark hash modes. Agents take it
+// before their next cracking job and report the speeds back.
+type BenchmarkJob struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	AgentID     uuid.UUID  `json:"agent_id" db:"agent_id"`
+	HashTypes   []int      `json:"hash_types" db:"hash_types"`
+	Status      string     `json:"status" db:"status"`         // One of the BenchmarkJob constants
+	Error       string     `json:"error,omitempty" db:"error"` // Why it failed, or the modes without a speed
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// BenchmarkRequest asks for a benchmark of hash modes on an agent
+type BenchmarkRequest struct {
+	HashTypes []int `json:"hash_types" binding:"required"`
+}
+
+// Validate checks the hash modes and drops duplicates
+func (r *BenchmarkRequest) Validate() error {
+	if len(r.HashTypes) == 0 {
+		return &ValidationError{Field: "hash_types", Message: "at least one hash mode is required"}
+	}
+	seen := make(map[int]bool, len(r.HashTypes))
+	hashTypes := make([]int, 0, len(r.HashTypes))
+	for _, hashType := range r.HashTypes {
+		if err := ValidateHashMode(hashType); err != nil {
+			return &ValidationError{Field: "hash_types", Message: fmt.Sprintf("unknown hash mode %d", hashType)}
+		}
+		if !seen[hashType] {
+			seen[hashType] = true
+			hashTypes = append(hashTypes, hashType)
+		}
+	}
+	if len(hashTypes) > MaxBenchmarkHashTypes {
+		return &ValidationError{Field: "hash_types", Message: "at most 32 hash modes per benchmark"}
+	}
+	r.HashTypes = hashTypes
+	return nil
+}
+
+// BenchmarkReport is what an agent measured for a benchmark job
+type BenchmarkReport struct {
+	// Speed in H/s by hash mode; modes hashcat couldn't benchmark are left out
+	Speeds map[int]int64 `json:"speeds"`
+	Error  string        `json:"error,omitempty"` // Why hashcat couldn't run
+}
+
+// AgentBenchmarks is what the server knows about an agent's speed
+type AgentBenchmarks struct {
+	AgentID uuid.UUID `json:"agent_id"`
+	// The agent's startup benchmark, which the scheduler falls back to for
+	// hash modes without data of their own
+	Speed      int64            `json:"speed"`
+	Benchmarks []AgentBenchmark `json:"benchmarks"` // By hash mode
+	// When the benchmarks were last invalidated; job speeds from before
+	// then no longer count
+	ResetAt *time.Time     `json:"reset_at,omitempty"`
+	Jobs    []BenchmarkJob `json:"jobs"` // Newest first
+}
+
+// AgentBenchmarkRepository stores benchmarks of agents and the benchmark
+// jobs that measure them
+type AgentBenchmarkRepository interface {
+	// GetByAgent returns the agent's benchmarks by hash mode
+	GetByAgent(ctx context.Context, agentID uuid.UUID) ([]AgentBenchmark, error)
+	// GetSpeedsByHashType returns each agent's benchmark of a hash mode
+	GetSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error)
+	// Save creates or replaces benchmarks
+	Save(ctx context.Context, benchmarks []AgentBenchmark) error
+	// Invalidate drops the agent's benchmarks and records when, so job
+	// speeds from before then are left out too
+	Invalidate(ctx context.Context, agentID uuid.UUID, at time.Time) error
+	// GetResetAt returns when the agent's benchmarks were last invalidated,
+	// nil if never
+	GetResetAt(ctx context.Context, agentID uuid.UUID) (*time.Time, error)
+
+	CreateJob(ctx context.Context, job *BenchmarkJob) error
+	GetJob(ctx context.Context, id uuid.UUID) (*BenchmarkJob, error)
+	// GetJobs returns up to limit of the agent's benchmark jobs, newest first
+	GetJobs(ctx context.Context, agentID uuid.UUID, limit int) ([]BenchmarkJob, error)
+	// ClaimJob marks the agent's oldest pending or running benchmark job
+	// running and returns it, nil if there is none
+	ClaimJob(ctx context.Context, agentID uuid.UUID, at time.Time) (*BenchmarkJob, error)
+	UpdateJob(ctx context.Context, job *BenchmarkJob) error
+}
@@ -0,0 +1,65 @@
+package domain
+
+// Buttons of chat messages, sent back as the action ID of a ChatAction with
+// the job ID as its value
+const (
+	ChatActionPauseJob  = "pause_job"
+	ChatActionResumeJob = "resume_job"
+	ChatActionCancelJob = "cancel_job"
+)
+
+// ChatUser is who typed a command or pressed a button
+type ChatUser struct {
+	Chat string // e.g. slack
+	ID   string // Checked against the users allowed to change jobs
+	Name string
+}
+
+// Actor is how the user shows in the job event log, e.g. slack:alice
+func (u ChatUser) Actor() string {
+	return u.Chat + ":" + u.Name
+}
+
+// ChatCommand is a command typed in a chat, e.g. the text after /hashcat
+type ChatCommand struct {
+	User ChatUser
+	Text string
+}
+
+// ChatAction is a button of an earlier ChatMessage a chat user pressed
+type ChatAction struct {
+	User     ChatUser
+	ActionID string // One of the ChatAction constants
+	Value    string
+}
+
+// ChatMessage is the answer to a command or action, independent of the
+// chat it is shown in
+type ChatMessage struct {
+	Text     string // Summary, shown in notifications
+	Sections []ChatSection
+	// Shown to the whole channel; only to the user who asked otherwise
+	Public bool
+}
+
+// ChatSection is a block of a ChatMessage: markdown text, label and value
+// fields shown side by side, and buttons
+type ChatSection struct {
+	Text    string
+	Fields  []ChatField
+	Buttons []ChatButton
+}
+
+// ChatField is a labelled value of a ChatSection
+type ChatField struct {
+	Label string
+	Value string
+}
+
+// ChatButton sends a ChatAction when pressed
+type ChatButton struct {
+	Text     string
+	ActionID string
+	Value    string
+	Danger   bool // Asks for confirmation first
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCheckpointSize caps uploaded restore files. Hashcat's is a fixed
+// header plus its command line, well under a kilobyte in practice.
+const MaxCheckpointSize = 1 << 20
+
+// JobCheckpoint is the hashcat restore file an agent saved when it stopped
+// a running job to shut down, so another agent can resume the job where it
+// left off
+type JobCheckpoint struct {
+	JobID          uuid.UUID  `json:"job_id" db:"job_id"`
+	AgentID        *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`               // Agent that wrote it
+	HashcatVersion string     `json:"hashcat_version,omitempty" db:"hashcat_version"` // Empty if unknown
+	RestorePoint   int64      `json:"restore_point" db:"restore_point"`               // Words of the keyspace already tried
+	Size           int64      `json:"size" db:"size"`
+	Data           []byte     `json:"-" db:"data"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CheckResume reports why an agent's hashcat can't resume from the
+// checkpoint: restore files only carry over within a major release, and
+// not to a release older than the one that wrote them. Unknown versions are
+// given the benefit of the doubt.
+func (c *JobCheckpoint) CheckResume(hashcatVersion string) error {
+	written, ok := ParseHashcatVersion(c.HashcatVersion)
+	if !ok {
+		return nil
+	}
+	version, ok := ParseHashcatVersion(hashcatVersion)
+	if !ok {
+		return nil
+	}
+	if version.Major != written.Major || !version.AtLeast(written) {
+		return &HashcatVersionError{Version: hashcatVersion, Reason: "can't resume a checkpoint written by hashcat " + written.String()}
+	}
+	return nil
+}
+
+// InterruptJobRequest is sent by an agent that stopped a running job
+// because it is shutting down
+type InterruptJobRequest struct {
+	Reason         string `json:"reason,omitempty"`
+	Checkpoint     []byte `json:"checkpoint,omitempty"` // hashcat restore file, if one was written
+	HashcatVersion string `json:"hashcat_version,omitempty"`
+}
+
+// hashcatRestoreHeaderSize is the size of hashcat's restore_data_t on 64-bit
+// platforms: version, working directory, dictionary and mask positions,
+// the restore point, argc and the argv pointer. The command line follows,
+// one argument per line.
+const hashcatRestoreHeaderSize = 296
+
+// Offsets of the restore_data_t fields
+const (
+	restoreCwdOffset      = 4
+	restoreCwdSize        = 256
+	restoreWordsCurOffset = 272
+	restoreArgcOffset     = 280
+)
+
+// HashcatRestore is a parsed hashcat .restore file
+type HashcatRestore struct {
+	header [hashcatRestoreHeaderSize]byte
+	Argv   []string
+}
+
+// ParseHashcatRestore parses a restore file written by hashcat 6
+func ParseHashcatRestore(data []byte) (*HashcatRestore, error) {
+	if len(data) < hashcatRestoreHeaderSize {
+		return nil, &ValidationError{Field: "checkpoint", Message: "is not a hashcat restore file"}
+	}
+	r := &HashcatRestore{}
+	copy(r.header[:], data)
+
+	argc := binary.LittleEndian.Uint32(r.header[restoreArgcOffset:])
+	lines := bytes.Split(data[hashcatRestoreHeaderSize:], []byte("\n"))
+	if argc == 0 || uint32(len(lines)) < argc {
+		return nil, &ValidationError{Field: "checkpoint", Message: "is not a hashcat restore file"}
+	}
+	for _, line := range lines[:argc] {
+		r.Argv = append(r.Argv, string(line))
+	}
+	return r, nil
+}
+
+// RestorePoint is how many words of the keyspace hashcat had tried
+func (r *HashcatRestore) RestorePoint() int64 {
+	return int64(binary.LittleEndian.Uint64(r.header[restoreWordsCurOffset:]))
+}
+
+// Rewrite returns the restore file for another machine: hashcat resumes in
+// cwd and runs argv, the command line it would have started the job with,
+// from the saved restore point
+func (r *HashcatRestore) Rewrite(cwd string, argv []string) ([]byte, error) {
+	if len(cwd) >= restoreCwdSize {
+		return nil, fmt.Errorf("working directory %s is too long for a restore file", cwd)
+	}
+	header := r.header
+	cwdField := header[restoreCwdOffset : restoreCwdOffset+restoreCwdSize]
+	clear(cwdField)
+	copy(cwdField, cwd)
+	binary.LittleEndian.PutUint32(header[restoreArgcOffset:], uint32(len(argv)))
+
+	var buf bytes.Buffer
+	buf.Write(header[:])
+	for _, arg := range argv {
+		if strings.Contains(arg, "\n") {
+			return nil, fmt.Errorf("argument %q can't be stored in a restore file", arg)
+		}
+		buf.WriteString(arg)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
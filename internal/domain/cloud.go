@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cloud instance statuses
+const (
+	CloudInstanceProvisioning = "provisioning" // Created at the provider, agent not registered yet
+	CloudInstanceRunning      = "running"      // Agent registered with the server
+	CloudInstanceTerminated   = "terminated"   // Deleted at the provider
+)
+
+// CloudInstance is a burst agent machine started by the auto-scaler
+type CloudInstance struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	Provider     string     `json:"provider" db:"provider"`
+	ProviderID   string     `json:"provider_id" db:"provider_id"` // Instance ID at the provider
+	AgentID      uuid.UUID  `json:"agent_id" db:"agent_id"`       // Agent created for the instance
+	Status       string     `json:"status" db:"status"`           // One of the CloudInstance constants
+	HourlyCost   float64    `json:"hourly_cost" db:"hourly_cost"`
+	IdleSince    *time.Time `json:"idle_since,omitempty" db:"idle_since"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	TerminatedAt *time.Time `json:"terminated_at,omitempty" db:"terminated_at"`
+}
+
+// CloudInstanceSpec describes an instance to start
+type CloudInstanceSpec struct {
+	Name     string
+	UserData string // Script run on first boot to install and start the agent
+}
+
+// CloudProvider starts and deletes instances at a cloud provider
+type CloudProvider interface {
+	Name() string
+	// CreateInstance starts an instance and returns its provider ID
+	CreateInstance(ctx context.Context, spec CloudInstanceSpec) (string, error)
+	DeleteInstance(ctx context.Context, providerID string) error
+}
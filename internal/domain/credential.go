@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a credential's plaintext came from
+const (
+	CredentialSourceJob         = "job"         // A job on the hash file cracked it
+	CredentialSourcePotfile     = "potfile"     // Imported from a hashcat potfile
+	CredentialSourceHashtopolis = "hashtopolis" // Cracked in Hashtopolis, see HashtopolisExport
+)
+
+// Credential is a cracked password linked to the account that uses it: the
+// username, the hash and the hash file it was found in. Credentials of hash
+// files without usernames have none.
+type Credential struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Username   string     `json:"username" db:"username"`
+	Domain     string     `json:"domain,omitempty" db:"domain"`
+	Hash       string     `json:"hash" db:"hash"`
+	Plaintext  string     `json:"plaintext" db:"plaintext"` // Encrypted at rest with the job results
+	HashType   int        `json:"hash_type" db:"hash_type"`
+	HashFileID *uuid.UUID `json:"hash_file_id,omitempty" db:"hash_file_id"`
+	SourceFile string     `json:"source_file" db:"source_file"` // Name of the hash file, kept when it is deleted
+	Source     string     `json:"source" db:"source"`
+	JobID      *uuid.UUID `json:"job_id,omitempty" db:"job_id"` // Job that cracked it, for job credentials
+	ProjectID  *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	CrackedAt  time.Time  `json:"cracked_at" db:"cracked_at"`
+}
+
+// CredentialFilter narrows credentials. Username and domain match
+// case-insensitively.
+type CredentialFilter struct {
+	Username   string
+	Domain     string
+	HashFileID *uuid.UUID
+	ProjectID  *uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+// CredentialStats summarises a set of credentials for findings reports. An
+// account found with the same password in several hash files counts once.
+type CredentialStats struct {
+	Credentials     int             `json:"credentials"` // Distinct account and password pairs
+	Accounts        int             `json:"accounts"`    // Distinct usernames, credentials without one count once each
+	UniquePasswords int             `json:"unique_passwords"`
+	ReusedAccounts  int             `json:"reused_accounts"` // Accounts sharing their password with another account
+	Reused          []PasswordReuse `json:"reused"`          // Most shared first
+	TopPasswords    []PasswordCount `json:"top_passwords"`
+	Lengths         []LengthCount   `json:"lengths"`  // Shortest first
+	Charsets        []CharsetCount  `json:"charsets"` // Most frequent first
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+// PasswordReuse is a password used by more than one account
+type PasswordReuse struct {
+	Password string   `json:"password"`
+	Accounts []string `json:"accounts"` // DOMAIN\username, sorted
+}
+
+// PasswordCount is how many accounts use a password
+type PasswordCount struct {
+	Password string  `json:"password"`
+	Count    int     `json:"count"`
+	Share    float64 `json:"share"` // Fraction of the credentials
+}
+
+// LengthCount is how many credentials have a password of a length
+type LengthCount struct {
+	Length int     `json:"length"`
+	Count  int     `json:"count"`
+	Share  float64 `json:"share"`
+}
+
+// CharsetCount is how many credentials have a password made of a set of
+// character classes, such as "lower+digit"
+type CharsetCount struct {
+	Charset string  `json:"charset"`
+	Count   int     `json:"count"`
+	Share   float64 `json:"share"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "strings"
+
+// DemoProjectName is the project demo data is seeded into; the seed is
+// skipped when a project of that name exists
+const DemoProjectName = "Demo"
+
+// SimulatedAgentCapabilities starts the capabilities of demo agents. The
+// demo simulator plays their part, so they need no GPU.
+const SimulatedAgentCapabilities = "simulated"
+
+// IsSimulatedAgent reports whether the demo simulator runs an agent
+func IsSimulatedAgent(agent *Agent) bool {
+	return strings.HasPrefix(agent.Capabilities, SimulatedAgentCapabilities)
+}
+
+// DemoSeedResult counts what seeding demo data added
+type DemoSeedResult struct {
+	Project     *Project `json:"project"`
+	Agents      int      `json:"agents"`
+	HashFiles   int      `json:"hash_files"`
+	Wordlists   int      `json:"wordlists"`
+	Jobs        int      `json:"jobs"`
+	Credentials int      `json:"credentials"`
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Digest frequencies
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestPeriod is how far back a digest of the frequency looks, zero for
+// frequencies that don't exist
+func DigestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// DigestSubscription is a user's wish to get a project's digest by email
+type DigestSubscription struct {
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	ProjectID   uuid.UUID  `json:"project_id" db:"project_id"`
+	ProjectName string     `json:"project_name" db:"-"` // Joined from projects
+	Frequency   string     `json:"frequency" db:"frequency"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Due reports whether the next digest should go out. The first one goes out
+// right away.
+func (s *DigestSubscription) Due(now time.Time) bool {
+	return s.LastSentAt == nil || !now.Before(s.LastSentAt.Add(DigestPeriod(s.Frequency)))
+}
+
+// DigestSubscriptionRepository stores digest subscriptions
+type DigestSubscriptionRepository interface {
+	// Save creates or replaces the subscription of a user to a project
+	Save(ctx context.Context, subscription *DigestSubscription) error
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]DigestSubscription, error)
+	GetAll(ctx context.Context) ([]DigestSubscription, error)
+	Delete(ctx context.Context, userID, projectID uuid.UUID) error
+	MarkSent(ctx context.Context, userID, projectID uuid.UUID, at time.Time) error
+}
+
+// DigestSubscriptionRequest subscribes to a project's digest, or changes
+// how often it comes
+type DigestSubscriptionRequest struct {
+	Frequency string `json:"frequency" binding:"required"` // daily or weekly
+}
+
+// ProjectDigest is what happened in a project over a digest period, and
+// what is coming up
+type ProjectDigest struct {
+	Project      Project   `json:"project"`
+	Frequency    string    `json:"frequency"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	NewCracks    int       `json:"new_cracks"`
+	JobsFinished int       `json:"jobs_finished"`
+	// Jobs that cracked hashes in the period, most cracks first
+	CrackingJobs []DigestJob        `json:"cracking_jobs"`
+	FinishedJobs []DigestJob        `json:"finished_jobs"`
+	AgentIssues  []DigestAgentIssue `json:"agent_issues"`
+	// Queued jobs, and the maintenance windows starting in the next period
+	QueuedJobs  []DigestJob         `json:"queued_jobs"`
+	Maintenance []MaintenanceWindow `json:"maintenance"`
+}
+
+// Empty reports whether nothing happened and nothing is coming up, so the
+// digest isn't worth an email
+func (d *ProjectDigest) Empty() bool {
+	return d.NewCracks == 0 && d.JobsFinished == 0 && len(d.AgentIssues) == 0 &&
+		len(d.QueuedJobs) == 0 && len(d.Maintenance) == 0
+}
+
+// DigestJob is a job as a digest lists it. Cracked passwords are never
+// part of a digest, only how many there were.
+type DigestJob struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Cracks      int        `json:"cracks,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+}
+
+// DigestAgentIssue is an agent that is offline or can't take work
+type DigestAgentIssue struct {
+	Name     string    `json:"name"`
+	Problems []string  `json:"problems"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// EmailMessage is an email with an HTML body and a plain text alternative
+type EmailMessage struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, message *EmailMessage) error
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Groupings of effectiveness reports
+const (
+	EffectivenessGroupWordlist = "wordlist"
+	EffectivenessGroupRules    = "rules"
+	EffectivenessGroupMask     = "mask"
+)
+
+// EffectivenessRetireJobs is how many jobs a wordlist, rule file or mask
+// runs without cracking anything before a report marks it ineffective
+const EffectivenessRetireJobs = 3
+
+// JobEffectiveness is what one completed job cracked for the keyspace it
+// searched. It is kept apart from the job so the history outlives job
+// retention and deleted wordlists.
+type JobEffectiveness struct {
+	JobID         uuid.UUID  `json:"job_id" db:"job_id"`
+	GroupID       *uuid.UUID `json:"group_id,omitempty" db:"group_id"` // Sub-jobs of a distributed job count as one job
+	HashType      int        `json:"hash_type" db:"hash_type"`
+	AttackMode    int        `json:"attack_mode" db:"attack_mode"`
+	WordlistID    *uuid.UUID `json:"wordlist_id,omitempty" db:"wordlist_id"`
+	Wordlist      string     `json:"wordlist" db:"wordlist"` // Name of the wordlist when the job completed
+	Rules         string     `json:"rules" db:"rules"`       // Rule file UUID
+	Mask          string     `json:"mask" db:"mask"`         // Mask of brute-force attacks
+	Keyspace      int64      `json:"keyspace" db:"keyspace"` // Candidates searched, 0 when the agent didn't report them
+	Cracks        int        `json:"cracks" db:"cracks"`
+	DeviceSeconds float64    `json:"device_seconds" db:"device_seconds"`
+	CompletedAt   time.Time  `json:"completed_at" db:"completed_at"`
+}
+
+// EffectivenessFilter selects the recorded jobs an effectiveness report
+// sums up. HashType, From and To are optional.
+type EffectivenessFilter struct {
+	GroupBy  string
+	HashType *int
+	From     *time.Time
+	To       *time.Time
+}
+
+// EffectivenessTotal sums the recorded jobs of one wordlist, rule file or mask
+type EffectivenessTotal struct {
+	Key            string // Wordlist ID, or its name for jobs that only name the file; rule file UUID; mask
+	Name           string
+	Jobs           int
+	JobsWithCracks int
+	Cracks         int
+	Keyspace       int64
+	DeviceSeconds  float64
+	LastUsedAt     time.Time
+}
+
+// EffectivenessReport ranks wordlists, rule files or masks by how many
+// hashes they cracked for the keyspace they searched
+type EffectivenessReport struct {
+	GroupBy     string              `json:"group_by"`
+	HashType    *int                `json:"hash_type,omitempty"`
+	From        *time.Time          `json:"from,omitempty"`
+	To          *time.Time          `json:"to,omitempty"`
+	Items       []EffectivenessItem `json:"items"` // Most cracks per keyspace first
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// EffectivenessItem is one wordlist, rule file or mask of an effectiveness report
+type EffectivenessItem struct {
+	Key                 string    `json:"key"`
+	Name                string    `json:"name"`
+	Jobs                int       `json:"jobs"`
+	JobsWithCracks      int       `json:"jobs_with_cracks"`
+	Cracks              int       `json:"cracks"`
+	Keyspace            int64     `json:"keyspace"`
+	CracksPerBillion    float64   `json:"cracks_per_billion"` // Cracks per 10^9 candidates searched
+	DeviceHours         float64   `json:"device_hours"`
+	CracksPerDeviceHour float64   `json:"cracks_per_device_hour"`
+	LastUsedAt          time.Time `json:"last_used_at"`
+	// Set after EffectivenessRetireJobs jobs without a single crack
+	Ineffective bool `json:"ineffective"`
+}
+
+// EffectivenessRepository stores the effectiveness of completed jobs
+type EffectivenessRepository interface {
+	// Record stores the effectiveness of a job, replacing an earlier record
+	// of it
+	Record(ctx context.Context, record *JobEffectiveness) error
+	// Aggregate sums the records matching the filter per wordlist, rule
+	// file or mask. Records without one are left out.
+	Aggregate(ctx context.Context, filter EffectivenessFilter) ([]EffectivenessTotal, error)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Cracking engines agents can run jobs with
+const (
+	EngineHashcat = "hashcat"
+	EngineJohn    = "john" // John the Ripper, jumbo build
+)
+
+// johnFormatPattern matches John the Ripper --format names such as
+// "raw-md5", "nt" or "dynamic_0"
+var johnFormatPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// EngineName returns the engine the job runs with. Jobs stored before
+// engines existed ran with hashcat.
+func (j *Job) EngineName() string {
+	if j.Engine == "" {
+		return EngineHashcat
+	}
+	return j.Engine
+}
+
+// HasEngine reports whether the snapshot lists the engine as installed.
+// Agents that predate engine reporting only say whether hashcat is there.
+func (h *AgentHeartbeat) HasEngine(engine string) bool {
+	if engine == EngineHashcat && h.HashcatAvailable {
+		return true
+	}
+	for _, e := range h.Engines {
+		if e == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsEngine reports whether the agent can run jobs with the engine.
+// Until its first heartbeat snapshot an agent is assumed to have hashcat,
+// which every agent needed before other engines were supported.
+func (a *Agent) SupportsEngine(engine string) bool {
+	if a.Heartbeat == nil {
+		return engine == EngineHashcat
+	}
+	return a.Heartbeat.HasEngine(engine)
+}
+
+// ValidateEngineParams checks the job fields that depend on its engine.
+// John the Ripper jobs name a --format instead of relying on hash_type and
+// run straight or mask attacks. Rule files, custom charsets and tuning
+// options are hashcat's, so they are refused for john jobs.
+func ValidateEngineParams(engine, johnFormat string, attackMode int, rules string, extraArgs []string, charsets ...string) error {
+	switch engine {
+	case EngineHashcat:
+		if johnFormat != "" {
+			return &ValidationError{Field: "john_format", Message: "is only used by john jobs"}
+		}
+		return nil
+	case EngineJohn:
+	default:
+		return &ValidationError{Field: "engine", Message: fmt.Sprintf("must be %s or %s", EngineHashcat, EngineJohn)}
+	}
+
+	if !johnFormatPattern.MatchString(johnFormat) {
+		return &ValidationError{Field: "john_format", Message: "must be a John the Ripper format name, e.g. raw-md5"}
+	}
+	if attackMode != AttackModeStraight && attackMode != AttackModeBruteForce {
+		return &ValidationError{Field: "attack_mode", Message: "john jobs support straight and brute-force attacks only"}
+	}
+	if rules != "" {
+		return &ValidationError{Field: "rules", Message: "rule files are not supported by john jobs"}
+	}
+	if len(extraArgs) > 0 {
+		return &ValidationError{Field: "extra_args", Message: "hashcat options are not supported by john jobs"}
+	}
+	for i, charset := range charsets {
+		if charset != "" {
+			return &ValidationError{Field: fmt.Sprintf("custom_charset%d", i+1), Message: "custom charsets are not supported by john jobs"}
+		}
+	}
+	return nil
+}
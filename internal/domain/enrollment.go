@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnrollmentToken lets one agent register itself before the token expires.
+// The agent trades it for an agent key on first contact; the server only
+// keeps a hash of the token.
+type EnrollmentToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Label     string     `json:"label,omitempty" db:"label"`
+	Token     string     `json:"token,omitempty"` // Only returned when the token is created
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	AgentID   *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"` // The agent that enrolled with it
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateEnrollmentTokensRequest generates a batch of enrollment tokens, e.g.
+// one per machine of a fleet rollout
+type CreateEnrollmentTokensRequest struct {
+	Count            int    `json:"count"`              // 1 when zero
+	ExpiresInMinutes int    `json:"expires_in_minutes"` // 24 hours when zero
+	Label            string `json:"label,omitempty"`
+}
+
+// EnrollAgentRequest trades an enrollment token for an agent key
+type EnrollAgentRequest struct {
+	Token string `json:"token" binding:"required"`
+	Name  string `json:"name" binding:"required"`
+}
+
+// Reasons an enrollment token is refused
+var (
+	ErrEnrollmentTokenInvalid = errors.New("enrollment token is not valid")
+	ErrEnrollmentTokenExpired = errors.New("enrollment token has expired")
+	ErrEnrollmentTokenUsed    = errors.New("enrollment token has already been used")
+)
+
+// Usable reports why the token can't enroll an agent at the given time, or
+// nil when it can
+func (t *EnrollmentToken) Usable(at time.Time) error {
+	if t.UsedAt != nil {
+		return ErrEnrollmentTokenUsed
+	}
+	if !at.Before(t.ExpiresAt) {
+		return ErrEnrollmentTokenExpired
+	}
+	return nil
+}
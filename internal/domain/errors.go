@@ -1,6 +1,9 @@
 package domain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // NotFoundError is a custom error for entities that are not found
 type NotFoundError struct {
@@ -22,3 +25,61 @@ var ErrAgentNotFound = &NotFoundError{Entity: "agent"}
 
 // Add other custom errors as needed, for example:
 // var ErrUserNotFound = &NotFoundError{Entity: "user"}
+
+// JobTransitionError is returned when a job can't move to the requested status
+type JobTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *JobTransitionError) Error() string {
+	return fmt.Sprintf("job cannot move from %s to %s", e.From, e.To)
+}
+
+// Helper to check if error is JobTransitionError
+func IsJobTransitionError(err error) bool {
+	var tErr *JobTransitionError
+	return errors.As(err, &tErr)
+}
+
+// ValidationError is returned when request fields fail validation
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// Helper to check if error is ValidationError
+func IsValidationError(err error) bool {
+	var vErr *ValidationError
+	return errors.As(err, &vErr)
+}
+
+// QuotaExceededError is returned when a user, project or tenant is at one
+// of its quota limits
+type QuotaExceededError struct {
+	Scope    string // QuotaScopeUser, QuotaScopeProject or QuotaScopeTenant
+	Resource string // QuotaRunningJobs, QuotaDeviceHours or QuotaStorage
+	Limit    float64
+	Used     float64
+}
+
+func (e *QuotaExceededError) Error() string {
+	switch e.Resource {
+	case QuotaRunningJobs:
+		return fmt.Sprintf("%s quota exceeded: %.0f of %.0f running jobs", e.Scope, e.Used, e.Limit)
+	case QuotaDeviceHours:
+		return fmt.Sprintf("%s quota exceeded: %.1f of %.1f device-hours used this month", e.Scope, e.Used, e.Limit)
+	default:
+		return fmt.Sprintf("%s quota exceeded: the upload would use %.0f of %.0f bytes of storage", e.Scope, e.Used, e.Limit)
+	}
+}
+
+// Helper to check if error is QuotaExceededError
+func IsQuotaExceededError(err error) bool {
+	var qErr *QuotaExceededError
+	return errors.As(err, &qErr)
+}
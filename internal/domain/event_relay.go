@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+// EventRelay carries realtime events between server instances, so clients
+// connected to one replica see events raised on another
+type EventRelay interface {
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe calls handle with every published payload, including this
+	// instance's own, until ctx is done
+	Subscribe(ctx context.Context, handle func(payload []byte)) error
+}
@@ -0,0 +1,100 @@
+package domain
+
+// HashModes are the hashcat -m values jobs may use, with hashcat's name for
+// each. The deprecated WPA modes stay, see HashcatCompatArgs.
+var HashModes = map[int]string{
+	0:     "MD5",
+	10:    "md5($pass.$salt)",
+	20:    "md5($salt.$pass)",
+	30:    "md5(utf16le($pass).$salt)",
+	40:    "md5($salt.utf16le($pass))",
+	50:    "HMAC-MD5 (key = $pass)",
+	60:    "HMAC-MD5 (key = $salt)",
+	100:   "SHA1",
+	110:   "sha1($pass.$salt)",
+	120:   "sha1($salt.$pass)",
+	130:   "sha1(utf16le($pass).$salt)",
+	140:   "sha1($salt.utf16le($pass))",
+	150:   "HMAC-SHA1 (key = $pass)",
+	160:   "HMAC-SHA1 (key = $salt)",
+	300:   "MySQL4.1/MySQL5",
+	400:   "phpass",
+	500:   "md5crypt, MD5 (Unix), Cisco-IOS $1$ (MD5)",
+	900:   "MD4",
+	1000:  "NTLM",
+	1100:  "Domain Cached Credentials (DCC), MS Cache",
+	1300:  "SHA2-224",
+	1400:  "SHA2-256",
+	1410:  "sha256($pass.$salt)",
+	1420:  "sha256($salt.$pass)",
+	1450:  "HMAC-SHA256 (key = $pass)",
+	1460:  "HMAC-SHA256 (key = $salt)",
+	1500:  "descrypt, DES (Unix), Traditional DES",
+	1700:  "SHA2-512",
+	1710:  "sha512($pass.$salt)",
+	1720:  "sha512($salt.$pass)",
+	1750:  "HMAC-SHA512 (key = $pass)",
+	1760:  "HMAC-SHA512 (key = $salt)",
+	1800:  "sha512crypt $6$, SHA512 (Unix)",
+	2100:  "Domain Cached Credentials 2 (DCC2), MS Cache 2",
+	2500:  "WPA-EAPOL-PBKDF2",
+	2501:  "WPA-EAPOL-PMK",
+	2600:  "md5(md5($pass))",
+	3000:  "LM",
+	3200:  "bcrypt $2*$, Blowfish (Unix)",
+	3710:  "md5($salt.md5($pass))",
+	3800:  "md5($salt.$pass.$salt)",
+	4400:  "md5(sha1($pass))",
+	4500:  "sha1(sha1($pass))",
+	4700:  "sha1(md5($pass))",
+	5000:  "SHA3 (Keccak)",
+	5100:  "Half MD5",
+	5500:  "NetNTLMv1 / NetNTLMv1+ESS",
+	5600:  "NetNTLMv2",
+	6000:  "RIPEMD-160",
+	6100:  "Whirlpool",
+	7100:  "macOS v10.8+ (PBKDF2-SHA512)",
+	7300:  "IPMI2 RAKP HMAC-SHA1",
+	7400:  "sha256crypt $5$, SHA256 (Unix)",
+	7500:  "Kerberos 5, etype 23, AS-REQ Pre-Auth",
+	7900:  "Drupal7",
+	8900:  "scrypt",
+	9200:  "Cisco-IOS $8$ (PBKDF2-SHA256)",
+	9300:  "Cisco-IOS $9$ (scrypt)",
+	9400:  "MS Office 2007",
+	9500:  "MS Office 2010",
+	9600:  "MS Office 2013",
+	10000: "Django (PBKDF2-SHA256)",
+	10400: "PDF 1.1 - 1.3 (Acrobat 2 - 4)",
+	10500: "PDF 1.4 - 1.6 (Acrobat 5 - 8)",
+	10700: "PDF 1.7 Level 8 (Acrobat 10 - 11)",
+	10900: "PBKDF2-HMAC-SHA256",
+	11300: "Bitcoin/Litecoin wallet.dat",
+	11600: "7-Zip",
+	12000: "PBKDF2-HMAC-SHA1",
+	12100: "PBKDF2-HMAC-SHA512",
+	12500: "RAR3-hp",
+	13000: "RAR5",
+	13100: "Kerberos 5, etype 23, TGS-REP",
+	13400: "KeePass 1 (AES/Twofish) and KeePass 2 (AES)",
+	13600: "WinZip",
+	15300: "DPAPI masterkey file v1",
+	15900: "DPAPI masterkey file v2",
+	16500: "JWT (JSON Web Token)",
+	16800: "WPA-PMKID-PBKDF2",
+	16801: "WPA-PMKID-PMK",
+	17200: "PKZIP (Compressed)",
+	17210: "PKZIP (Uncompressed)",
+	17400: "SHA3-256",
+	17600: "SHA3-512",
+	18200: "Kerberos 5, etype 23, AS-REP",
+	19600: "Kerberos 5, etype 17, TGS-REP",
+	19700: "Kerberos 5, etype 18, TGS-REP",
+	19800: "Kerberos 5, etype 17, Pre-Auth",
+	19900: "Kerberos 5, etype 18, Pre-Auth",
+	22000: "WPA-PBKDF2-PMKID+EAPOL",
+	22001: "WPA-PMK-PMKID+EAPOL",
+	22100: "BitLocker",
+	22200: "Citrix NetScaler (SHA512)",
+	23100: "Apple Keychain",
+}
@@ -0,0 +1,471 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Hashcat attack modes supported by the agent
+const (
+	AttackModeStraight   = 0
+	AttackModeCombinator = 1
+	AttackModeBruteForce = 3
+)
+
+// AllowedAttackModes lists the attack modes jobs may use. Hybrid modes are
+// rejected until the agent can build command lines for them.
+var AllowedAttackModes = map[int]string{
+	AttackModeStraight:   "straight",
+	AttackModeCombinator: "combinator",
+	AttackModeBruteForce: "brute-force",
+}
+
+const maxMaskLength = 256
+
+// ValidateHashMode checks that a hash mode is one of HashModes
+func ValidateHashMode(mode int) error {
+	if _, ok := HashModes[mode]; !ok {
+		return &ValidationError{Field: "hash_type", Message: fmt.Sprintf("unknown hash mode %d", mode)}
+	}
+	return nil
+}
+
+// ValidateAttackMode checks that an attack mode is whitelisted
+func ValidateAttackMode(mode int) error {
+	if _, ok := AllowedAttackModes[mode]; !ok {
+		return &ValidationError{Field: "attack_mode", Message: "unsupported attack mode"}
+	}
+	return nil
+}
+
+// ValidateMask checks that a brute-force mask only contains literals and
+// hashcat charset placeholders, and can't be mistaken for a flag or a
+// .hcmask file path
+func ValidateMask(mask string) error {
+	if mask == "" {
+		return &ValidationError{Field: "mask", Message: "is required for brute-force attacks"}
+	}
+	if len(mask) > maxMaskLength {
+		return &ValidationError{Field: "mask", Message: "is too long"}
+	}
+	if strings.HasPrefix(mask, "-") {
+		return &ValidationError{Field: "mask", Message: "must not start with '-'"}
+	}
+	if strings.ContainsAny(mask, "/\\") || strings.HasSuffix(mask, ".hcmask") {
+		return &ValidationError{Field: "mask", Message: "must not reference a file"}
+	}
+
+	for i := 0; i < len(mask); i++ {
+		c := mask[i]
+		if c < 0x20 || c == 0x7f {
+			return &ValidationError{Field: "mask", Message: "contains control characters"}
+		}
+		if c != '?' {
+			continue
+		}
+		if i+1 >= len(mask) {
+			return &ValidationError{Field: "mask", Message: "ends with an incomplete placeholder"}
+		}
+		i++
+		if !strings.ContainsRune("ludhHsab1234?", rune(mask[i])) {
+			return &ValidationError{Field: "mask", Message: "unknown placeholder ?" + string(mask[i])}
+		}
+	}
+	return nil
+}
+
+// maskCharsetSizes is the number of characters of each built-in mask
+// placeholder
+var maskCharsetSizes = map[byte]int64{
+	'l': 26, 'u': 26, 'd': 10, 'h': 16, 'H': 16, 's': 33, 'a': 95, 'b': 256, '?': 1,
+}
+
+// PasswordMask returns the brute-force mask that describes the structure
+// of a password, e.g. "?u?l?l?l?d?d" for "Pass12". Bytes outside printable
+// ASCII become ?b.
+func PasswordMask(password string) string {
+	var mask strings.Builder
+	for i := 0; i < len(password); i++ {
+		c := password[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			mask.WriteString("?l")
+		case c >= 'A' && c <= 'Z':
+			mask.WriteString("?u")
+		case c >= '0' && c <= '9':
+			mask.WriteString("?d")
+		case c >= 0x20 && c < 0x7f:
+			mask.WriteString("?s")
+		default:
+			mask.WriteString("?b")
+		}
+	}
+	return mask.String()
+}
+
+// MaskKeyspace returns the number of candidates of a mask, capped at
+// math.MaxInt64. Literals count once; masks using custom charsets ?1..?4
+// have no fixed keyspace and return 0.
+func MaskKeyspace(mask string) int64 {
+	keyspace := int64(1)
+	for i := 0; i < len(mask); i++ {
+		size := int64(1)
+		if mask[i] == '?' && i+1 < len(mask) {
+			i++
+			var ok bool
+			if size, ok = maskCharsetSizes[mask[i]]; !ok {
+				return 0
+			}
+		}
+		if keyspace > math.MaxInt64/size {
+			return math.MaxInt64
+		}
+		keyspace *= size
+	}
+	return keyspace
+}
+
+// BuiltinRuleFiles are the rule files shipped in hashcat's rules
+// directory, which every agent has
+var BuiltinRuleFiles = map[string]bool{
+	"best64.rule":                 true,
+	"combinator.rule":             true,
+	"d3ad0ne.rule":                true,
+	"dive.rule":                   true,
+	"generated.rule":              true,
+	"generated2.rule":             true,
+	"Incisive-leetspeak.rule":     true,
+	"InsidePro-HashManager.rule":  true,
+	"InsidePro-PasswordsPro.rule": true,
+	"leetspeak.rule":              true,
+	"oscommerce.rule":             true,
+	"rockyou-30000.rule":          true,
+	"specific.rule":               true,
+	"T0XlC.rule":                  true,
+	"T0XlCv2.rule":                true,
+	"toggles1.rule":               true,
+	"toggles2.rule":               true,
+	"toggles3.rule":               true,
+	"toggles4.rule":               true,
+	"toggles5.rule":               true,
+	"unix-ninja-leetspeak.rule":   true,
+}
+
+// ValidateRuleReference checks that rules name one of BuiltinRuleFiles, so
+// a job can't point hashcat at an arbitrary path on the agent
+func ValidateRuleReference(rules string) error {
+	if rules == "" {
+		return nil
+	}
+	if !BuiltinRuleFiles[rules] {
+		return &ValidationError{Field: "rules", Message: "must be one of hashcat's built-in rule files, such as best64.rule"}
+	}
+	return nil
+}
+
+// ValidateWordlistReference checks the free-form wordlist field of a job.
+// It may hold inline content, a wordlist UUID, or a bare file name that the
+// agent resolves inside its own wordlist directory.
+func ValidateWordlistReference(wordlist string) error {
+	if wordlist == "" || strings.Contains(wordlist, "\n") {
+		return nil
+	}
+	if _, err := uuid.Parse(wordlist); err == nil {
+		return nil
+	}
+	if strings.HasPrefix(wordlist, "-") {
+		return &ValidationError{Field: "wordlist", Message: "must not start with '-'"}
+	}
+	if strings.ContainsAny(wordlist, "/\\\x00") || wordlist == "." || wordlist == ".." {
+		return &ValidationError{Field: "wordlist", Message: "must be a file name, not a path"}
+	}
+	return nil
+}
+
+// MaxCustomCharsets is the number of hashcat custom charsets, -1 to -4
+const MaxCustomCharsets = 4
+
+// MaxCharsetFileSize caps uploaded charset files. Hashcat charsets hold at
+// most 256 distinct bytes, so anything much larger is not a charset.
+const MaxCharsetFileSize = 4096
+
+// ValidateCustomCharset checks the value of custom charset n (1-4). It is
+// either the UUID of an uploaded charset file or an inline charset of
+// literals and built-in placeholders; like masks, inline charsets can't
+// name a file, since hashcat reads -1 as a path when such a file exists.
+func ValidateCustomCharset(n int, charset string) error {
+	field := fmt.Sprintf("custom_charset%d", n)
+	if charset == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(charset); err == nil {
+		return nil
+	}
+	if len(charset) > maxMaskLength {
+		return &ValidationError{Field: field, Message: "is too long"}
+	}
+	if strings.HasPrefix(charset, "-") {
+		return &ValidationError{Field: field, Message: "must not start with '-'"}
+	}
+	if strings.ContainsAny(charset, "/\\") || strings.HasSuffix(charset, ".hcchr") {
+		return &ValidationError{Field: field, Message: "must be inline or a charset file UUID"}
+	}
+
+	for i := 0; i < len(charset); i++ {
+		c := charset[i]
+		if c < 0x20 || c == 0x7f {
+			return &ValidationError{Field: field, Message: "contains control characters"}
+		}
+		if c != '?' {
+			continue
+		}
+		if i+1 >= len(charset) {
+			return &ValidationError{Field: field, Message: "ends with an incomplete placeholder"}
+		}
+		i++
+		if !strings.ContainsRune("ludhHsab?", rune(charset[i])) {
+			return &ValidationError{Field: field, Message: "unknown placeholder ?" + string(charset[i])}
+		}
+	}
+	return nil
+}
+
+// ValidateCustomCharsets checks a job's custom charsets, given in -1..-4
+// order. They only apply to brute-force attacks, and every ?1..?4 in the
+// mask must have its charset defined.
+func ValidateCustomCharsets(attackMode int, mask string, charsets ...string) error {
+	if len(charsets) > MaxCustomCharsets {
+		return &ValidationError{Field: "custom_charsets", Message: fmt.Sprintf("at most %d are supported", MaxCustomCharsets)}
+	}
+
+	defined := false
+	for i, charset := range charsets {
+		if err := ValidateCustomCharset(i+1, charset); err != nil {
+			return err
+		}
+		defined = defined || charset != ""
+	}
+	if attackMode != AttackModeBruteForce {
+		if defined {
+			return &ValidationError{Field: "custom_charset1", Message: "custom charsets are only used by brute-force attacks"}
+		}
+		return nil
+	}
+
+	for i := 0; i+1 < len(mask); i++ {
+		if mask[i] != '?' {
+			continue
+		}
+		i++
+		if mask[i] < '1' || mask[i] > '4' {
+			continue
+		}
+		n := int(mask[i] - '0')
+		if n > len(charsets) || charsets[n-1] == "" {
+			return &ValidationError{Field: "mask", Message: fmt.Sprintf("uses ?%d but custom_charset%d is not set", n, n)}
+		}
+	}
+	return nil
+}
+
+// ValidateCombinatorWordlists checks the wordlist references of a job.
+// Combinator attacks combine two uploaded wordlists, so both must be given
+// by UUID; other attacks take no second wordlist.
+func ValidateCombinatorWordlists(attackMode int, wordlistID, wordlist2ID string) error {
+	if attackMode != AttackModeCombinator {
+		if wordlist2ID != "" {
+			return &ValidationError{Field: "wordlist2_id", Message: "a second wordlist is only used by combinator attacks"}
+		}
+		return nil
+	}
+	if _, err := uuid.Parse(wordlistID); err != nil {
+		return &ValidationError{Field: "wordlist_id", Message: "combinator attacks need a wordlist UUID"}
+	}
+	if _, err := uuid.Parse(wordlist2ID); err != nil {
+		return &ValidationError{Field: "wordlist2_id", Message: "combinator attacks need a second wordlist UUID"}
+	}
+	return nil
+}
+
+// CombinatorKeyspace splits a combinator attack the way hashcat does: the
+// larger wordlist is the base that --skip and --limit count in, and each of
+// its words is combined with every word of the smaller one. The attack
+// tries base*amplifier candidates.
+func CombinatorKeyspace(left, right int64) (base, amplifier int64) {
+	if left >= right {
+		return left, right
+	}
+	return right, left
+}
+
+// ValidateHashcatParams validates every job field that ends up on the
+// hashcat command line
+func ValidateHashcatParams(hashType, attackMode int, wordlist, rules string) error {
+	if err := ValidateHashMode(hashType); err != nil {
+		return err
+	}
+	if err := ValidateAttackMode(attackMode); err != nil {
+		return err
+	}
+	if err := ValidateRuleReference(rules); err != nil {
+		return err
+	}
+	if attackMode == AttackModeCombinator && rules != "" {
+		return &ValidationError{Field: "rules", Message: "rules are not supported by combinator attacks"}
+	}
+	if attackMode == AttackModeBruteForce {
+		return ValidateMask(wordlist)
+	}
+	return ValidateWordlistReference(wordlist)
+}
+
+// Candidate preview limits
+const (
+	DefaultCandidatePreviewLimit = 100
+	MaxCandidatePreviewLimit     = 10000
+)
+
+// CandidatePreviewRequest describes an attack whose first candidates should
+// be generated with hashcat --stdout before a job is queued
+type CandidatePreviewRequest struct {
+	AttackMode int    `json:"attack_mode"`
+	WordlistID string `json:"wordlist_id,omitempty"` // Straight attacks only
+	Rules      string `json:"rules,omitempty"`       // Built-in rule file, straight attacks only
+	Mask       string `json:"mask,omitempty"`        // Brute-force attacks only
+	Limit      int    `json:"limit,omitempty"`       // Defaults to DefaultCandidatePreviewLimit
+	// Custom charsets for the mask, as on jobs
+	CustomCharset1 string `json:"custom_charset1,omitempty"`
+	CustomCharset2 string `json:"custom_charset2,omitempty"`
+	CustomCharset3 string `json:"custom_charset3,omitempty"`
+	CustomCharset4 string `json:"custom_charset4,omitempty"`
+}
+
+// CandidatePreview holds the first candidates of an attack
+type CandidatePreview struct {
+	Candidates []string `json:"candidates"`
+	Count      int      `json:"count"`
+	Limit      int      `json:"limit"`
+	Truncated  bool     `json:"truncated"` // The attack has more candidates than Limit
+}
+
+// CandidateSpec is a validated attack with its files resolved to local paths
+type CandidateSpec struct {
+	AttackMode   int
+	WordlistPath string
+	Rules        string // One of BuiltinRuleFiles
+	Mask         string
+	// CustomCharsets holds -1..-4 in order, inline or as charset file paths
+	CustomCharsets []string
+}
+
+// CandidateGenerator runs hashcat --stdout for an attack and returns at most
+// limit candidates, reporting whether more were available
+type CandidateGenerator interface {
+	Generate(ctx context.Context, spec CandidateSpec, limit int) ([]string, bool, error)
+}
+
+// MaxHashcatArgs caps the extra arguments of a job or an agent
+const MaxHashcatArgs = 16
+
+// hashcatOption is an extra option jobs and agents may put on hashcat's
+// command line. value checks the option's value; flags have none.
+type hashcatOption struct {
+	value func(string) bool
+}
+
+var (
+	hashcatFlag    = hashcatOption{}
+	hashcatNumber  = hashcatOption{value: isHashcatNumber}
+	hashcatNumbers = hashcatOption{value: isHashcatNumberList}
+)
+
+// allowedHashcatOptions only tune how hashcat runs. Options that change what
+// it cracks, what it reads or where it writes stay under the agent's control.
+var allowedHashcatOptions = map[string]hashcatOption{
+	"-O":                        hashcatFlag,
+	"--optimized-kernel-enable": hashcatFlag,
+	"-S":                        hashcatFlag,
+	"--slow-candidates":         hashcatFlag,
+	"--force":                   hashcatFlag,
+	"--hwmon-disable":           hashcatFlag,
+	"--self-test-disable":       hashcatFlag,
+	"--multiply-accel-disable":  hashcatFlag,
+	"--backend-ignore-cuda":     hashcatFlag,
+	"--backend-ignore-hip":      hashcatFlag,
+	"--backend-ignore-metal":    hashcatFlag,
+	"--backend-ignore-opencl":   hashcatFlag,
+	"-n":                        hashcatNumber,
+	"--kernel-accel":            hashcatNumber,
+	"-u":                        hashcatNumber,
+	"--kernel-loops":            hashcatNumber,
+	"-T":                        hashcatNumber,
+	"--kernel-threads":          hashcatNumber,
+	"--segment-size":            hashcatNumber,
+	"--bitmap-max":              hashcatNumber,
+	"--spin-damp":               hashcatNumber,
+	"--backend-vector-width":    hashcatNumber,
+	"-d":                        hashcatNumbers,
+	"--backend-devices":         hashcatNumbers,
+	"-D":                        hashcatNumbers,
+	"--opencl-device-types":     hashcatNumbers,
+}
+
+// ValidateHashcatArgs checks extra hashcat arguments against the options
+// whitelist. An option's value is given as "--option=value" or as the next
+// argument. field names the request field in errors.
+func ValidateHashcatArgs(field string, args []string) error {
+	if len(args) > MaxHashcatArgs {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("at most %d arguments are allowed", MaxHashcatArgs)}
+	}
+
+	for i := 0; i < len(args); i++ {
+		name, value, inline := strings.Cut(args[i], "=")
+		option, ok := allowedHashcatOptions[name]
+		if !ok {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("option %q is not allowed", name)}
+		}
+
+		if option.value == nil {
+			if inline {
+				return &ValidationError{Field: field, Message: fmt.Sprintf("option %s takes no value", name)}
+			}
+			continue
+		}
+		if !inline {
+			if i+1 >= len(args) {
+				return &ValidationError{Field: field, Message: fmt.Sprintf("option %s needs a value", name)}
+			}
+			i++
+			value = args[i]
+		}
+		if !option.value(value) {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("invalid value %q for %s", value, name)}
+		}
+	}
+	return nil
+}
+
+func isHashcatNumber(s string) bool {
+	if s == "" || len(s) > 9 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHashcatNumberList(s string) bool {
+	for _, part := range strings.Split(s, ",") {
+		if !isHashcatNumber(part) {
+			return false
+		}
+	}
+	return true
+}
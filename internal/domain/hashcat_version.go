@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HashcatVersion is a release number parsed from hashcat --version
+type HashcatVersion struct {
+	Major, Minor, Patch int
+}
+
+// MinHashcatVersion is the oldest hashcat the agent can drive: it reads
+// progress from --status-json, which hashcat 6.0.0 introduced
+var MinHashcatVersion = HashcatVersion{6, 0, 0}
+
+// ParseHashcatVersion parses hashcat --version output such as "v6.2.6" or
+// "v6.2.6-851-g6716447df". It returns false for output it doesn't know.
+func ParseHashcatVersion(s string) (HashcatVersion, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return HashcatVersion{}, false
+	}
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return HashcatVersion{}, false
+		}
+		numbers[i] = n
+	}
+	return HashcatVersion{numbers[0], numbers[1], numbers[2]}, true
+}
+
+// AtLeast reports whether v is the same release as other or a later one
+func (v HashcatVersion) AtLeast(other HashcatVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+func (v HashcatVersion) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// hashModeSince lists hash modes added after MinHashcatVersion with the
+// release that added them
+var hashModeSince = map[int]HashcatVersion{
+	22100: {6, 1, 0}, // BitLocker
+}
+
+// hashcatOptionSince lists whitelisted options added after
+// MinHashcatVersion with the release that added them
+var hashcatOptionSince = map[string]HashcatVersion{
+	"--multiply-accel-disable": {6, 2, 0},
+	"--backend-ignore-hip":     {6, 2, 0},
+	"--backend-ignore-metal":   {6, 2, 6},
+}
+
+// deprecatedHashModes are refused by hashcat 6.2.0 and later unless
+// --deprecated-check-disable is given
+var deprecatedHashModes = map[int]bool{
+	2500:  true, // WPA-EAPOL-PBKDF2, replaced by 22000
+	2501:  true, // WPA-EAPOL-PMK, replaced by 22001
+	16800: true, // WPA-PMKID-PBKDF2, replaced by 22000
+	16801: true, // WPA-PMKID-PMK, replaced by 22001
+}
+
+var deprecatedCheckSince = HashcatVersion{6, 2, 0}
+
+// HashcatVersionError explains why an agent's hashcat can't run a job
+type HashcatVersionError struct {
+	Version string
+	Reason  string
+}
+
+func (e *HashcatVersionError) Error() string {
+	return fmt.Sprintf("hashcat %s %s", e.Version, e.Reason)
+}
+
+// CheckHashcatSupport reports whether a hashcat version can run a job's
+// hash mode with the given extra arguments. An empty or unparseable
+// version is not held against the job, since agents that never reported
+// one would otherwise get no work at all.
+func CheckHashcatSupport(version string, hashType int, args ...[]string) error {
+	v, ok := ParseHashcatVersion(version)
+	if !ok {
+		return nil
+	}
+
+	if !v.AtLeast(MinHashcatVersion) {
+		return &HashcatVersionError{Version: version, Reason: "is older than " + MinHashcatVersion.String() + ", the oldest supported release"}
+	}
+	if since, ok := hashModeSince[hashType]; ok && !v.AtLeast(since) {
+		return &HashcatVersionError{Version: version, Reason: fmt.Sprintf("doesn't support hash mode %d, which needs %s", hashType, since)}
+	}
+	for _, list := range args {
+		for _, arg := range list {
+			name, _, _ := strings.Cut(arg, "=")
+			if since, ok := hashcatOptionSince[name]; ok && !v.AtLeast(since) {
+				return &HashcatVersionError{Version: version, Reason: fmt.Sprintf("doesn't support %s, which needs %s", name, since)}
+			}
+		}
+	}
+	return nil
+}
+
+// HashcatCompatArgs returns the arguments a hashcat version needs to run a
+// hash mode the way older releases did
+func HashcatCompatArgs(version string, hashType int) []string {
+	v, ok := ParseHashcatVersion(version)
+	if ok && deprecatedHashModes[hashType] && v.AtLeast(deprecatedCheckSince) {
+		return []string{"--deprecated-check-disable"}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of hash file operation
+const (
+	HashFileOperationMerge = "merge" // Several lists into one, without duplicates
+	HashFileOperationSplit = "split" // One list into parts of about the same number of lines
+	HashFileOperationDiff  = "diff"  // The lines two lists don't have in common
+)
+
+// Statuses of a hash file operation
+const (
+	HashFileOperationPending   = "pending"
+	HashFileOperationRunning   = "running"
+	HashFileOperationCompleted = "completed"
+	HashFileOperationFailed    = "failed"
+)
+
+// MaxSplitParts caps how many parts a hash file can be split into
+const MaxSplitParts = 100
+
+// HashFileOperation merges, splits or diffs uploaded hash lists in the
+// background. Its results are new hash files.
+type HashFileOperation struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	Type        string           `json:"type" db:"type"`
+	Status      string           `json:"status" db:"status"`
+	Progress    float64          `json:"progress" db:"progress"`               // Percent done
+	InputIDs    []uuid.UUID      `json:"input_ids" db:"input_ids"`             // Merged files in order, the file split, or the base and compared file of a diff
+	ResultIDs   []uuid.UUID      `json:"result_ids" db:"result_ids"`           // Hash files created, in order
+	Counts      map[string]int64 `json:"counts,omitempty" db:"counts"`         // Lines read, written, duplicate, added... depending on the type
+	Parts       int              `json:"parts,omitempty" db:"parts"`           // Parts a split makes
+	Name        string           `json:"name,omitempty" db:"name"`             // Name of a merge's result
+	Error       string           `json:"error,omitempty" db:"error"`           // Why the operation failed
+	ProjectID   *uuid.UUID       `json:"project_id,omitempty" db:"project_id"` // Project the results are put in
+	CreatedBy   *uuid.UUID       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// MergeHashFilesRequest merges hash files into one named Name
+type MergeHashFilesRequest struct {
+	HashFileIDs []uuid.UUID `json:"hash_file_ids" binding:"required"`
+	Name        string      `json:"name"` // merged.txt when empty
+}
+
+// SplitHashFileRequest splits a hash file into Parts files
+type SplitHashFileRequest struct {
+	Parts int `json:"parts" binding:"required"`
+}
+
+// DiffHashFilesRequest compares a list with an earlier one, e.g. this
+// month's AD dump with last month's. The lines only CompareID has are its
+// "added" result and those only BaseID has its "removed" result.
+type DiffHashFilesRequest struct {
+	BaseID    uuid.UUID `json:"base_id" binding:"required"`
+	CompareID uuid.UUID `json:"compare_id" binding:"required"`
+}
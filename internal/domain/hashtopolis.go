@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HashtopolisExport is what the server imports from Hashtopolis: the rows
+// of its Hashlist, Hash, Task and Agent tables as JSON, with the field
+// names of the table columns. Tasks carry the hashlistId of their task
+// wrapper.
+type HashtopolisExport struct {
+	Hashlists []HashtopolisHashlist `json:"hashlists"`
+	Hashes    []HashtopolisHash     `json:"hashes"`
+	Tasks     []HashtopolisTask     `json:"tasks"`
+	Agents    []HashtopolisAgent    `json:"agents"`
+}
+
+// Hashtopolis hashlist formats. Only text hashlists are imported, the
+// hashes of binary ones live in files the export doesn't have.
+const (
+	HashtopolisFormatText   = 0
+	HashtopolisFormatWPA    = 1
+	HashtopolisFormatBinary = 2
+	HashtopolisFormatSuper  = 3
+)
+
+type HashtopolisHashlist struct {
+	HashlistID    int             `json:"hashlistId"`
+	HashlistName  string          `json:"hashlistName"`
+	Format        int             `json:"format"`
+	HashTypeID    int             `json:"hashTypeId"` // hashcat -m
+	IsSalted      HashtopolisFlag `json:"isSalted"`
+	SaltSeparator string          `json:"saltSeparator"`
+	IsArchived    HashtopolisFlag `json:"isArchived"`
+}
+
+type HashtopolisHash struct {
+	HashlistID  int             `json:"hashlistId"`
+	Hash        string          `json:"hash"`
+	Salt        string          `json:"salt"`
+	Plaintext   string          `json:"plaintext"`
+	IsCracked   HashtopolisFlag `json:"isCracked"`
+	TimeCracked int64           `json:"timeCracked"` // Unix seconds, 0 when not cracked
+}
+
+type HashtopolisTask struct {
+	TaskID           int             `json:"taskId"`
+	TaskName         string          `json:"taskName"`
+	AttackCmd        string          `json:"attackCmd"` // e.g. "#HL# -a 0 rockyou.txt -r best64.rule"
+	HashlistID       int             `json:"hashlistId"`
+	Keyspace         int64           `json:"keyspace"`
+	KeyspaceProgress int64           `json:"keyspaceProgress"`
+	IsArchived       HashtopolisFlag `json:"isArchived"`
+}
+
+// Finished reports whether the task went through its whole keyspace
+func (t *HashtopolisTask) Finished() bool {
+	return t.Keyspace > 0 && t.KeyspaceProgress >= t.Keyspace
+}
+
+type HashtopolisAgent struct {
+	AgentID   int    `json:"agentId"`
+	AgentName string `json:"agentName"`
+	// Devices are one per line, as Hashtopolis stores them
+	Devices  string `json:"devices"`
+	Token    string `json:"token"`    // The agent's Hashtopolis access token
+	LastTime int64  `json:"lastTime"` // Unix seconds of the agent's last request
+}
+
+// HashtopolisFlag is a boolean column, which database dumps write as 0 and
+// 1 and the user API as true and false
+type HashtopolisFlag bool
+
+func (f *HashtopolisFlag) UnmarshalJSON(data []byte) error {
+	switch string(bytes.Trim(data, `"`)) {
+	case "1", "true":
+		*f = true
+	case "0", "false", "null", "":
+		*f = false
+	default:
+		return fmt.Errorf("invalid flag %s", data)
+	}
+	return nil
+}
+
+// HashtopolisImportResult is what importing a Hashtopolis export created
+type HashtopolisImportResult struct {
+	Project     Project `json:"project"`
+	HashFiles   int     `json:"hash_files"`
+	Credentials int     `json:"credentials"`
+	Jobs        int     `json:"jobs"`
+	// Unfinished tasks, imported paused
+	PausedJobs int `json:"paused_jobs"`
+	// Unfinished tasks whose attack this server can't run, imported
+	// cancelled with the reason
+	CancelledJobs int `json:"cancelled_jobs"`
+	Agents        int `json:"agents"`
+	// Wordlists and rule files the attack commands name that this server
+	// doesn't have
+	MissingFiles []string `json:"missing_files,omitempty"`
+	// Hashlists, tasks and agents that weren't imported, with why
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// HashtopolisAttack is the attack of a Hashtopolis task's command line
+type HashtopolisAttack struct {
+	AttackMode     int
+	Wordlists      []string // File names, two for combinator attacks
+	Mask           string
+	RuleFiles      []string
+	CustomCharsets []string // -1 to -4, in order
+	// Whitelisted tuning options, see ValidateHashcatArgs
+	ExtraArgs []string
+	// Options left out, as jobs can't set them
+	Ignored []string
+}
+
+// hashtopolisValueOptions take a value as the next argument. Other options
+// are flags, or whitelisted ones whose values allowedHashcatOptions knows.
+var hashtopolisValueOptions = map[string]bool{
+	"-w": true, "--workload-profile": true, "-m": true, "--hash-type": true,
+	"-o": true, "--outfile": true, "--outfile-format": true, "-s": true, "--skip": true,
+	"-l": true, "--limit": true, "-t": true, "--markov-threshold": true,
+	"--increment-min": true, "--increment-max": true, "--status-timer": true,
+	"--session": true, "--runtime": true, "--separator": true, "-p": true,
+}
+
+// ParseHashtopolisAttack reads a Hashtopolis attack command: hashcat's
+// arguments with #HL# in place of the hashlist. Files are referenced by
+// name, as Hashtopolis agents find them in their files directory.
+func ParseHashtopolisAttack(command string) (*HashtopolisAttack, error) {
+	attack := &HashtopolisAttack{}
+	var positional []string
+	args := strings.Fields(command)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "#HL#" {
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") || len(arg) == 1 {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, inline := strings.Cut(arg, "=")
+		// Short options may carry their value, e.g. -a3
+		if !inline && len(name) > 2 && name[1] != '-' {
+			name, value, inline = name[:2], name[2:], true
+		}
+		next := func() (string, error) {
+			if inline {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("option %s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "-a", "--attack-mode":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if attack.AttackMode, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid attack mode %q", value)
+			}
+		case "-r", "--rules-file":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			attack.RuleFiles = append(attack.RuleFiles, value)
+		case "-1", "-2", "-3", "-4", "--custom-charset1", "--custom-charset2", "--custom-charset3", "--custom-charset4":
+			value, err := next()
+			if err != nil {
+				return nil, err
+			}
+			n := int(name[len(name)-1] - '0')
+			for len(attack.CustomCharsets) < n {
+				attack.CustomCharsets = append(attack.CustomCharsets, "")
+			}
+			attack.CustomCharsets[n-1] = value
+		default:
+			if option, ok := allowedHashcatOptions[name]; ok {
+				if option.value == nil {
+					attack.ExtraArgs = append(attack.ExtraArgs, arg)
+					continue
+				}
+				value, err := next()
+				if err != nil {
+					return nil, err
+				}
+				attack.ExtraArgs = append(attack.ExtraArgs, name+"="+value)
+				continue
+			}
+			ignored := arg
+			if hashtopolisValueOptions[name] && !inline && i+1 < len(args) {
+				i++
+				ignored += " " + args[i]
+			}
+			attack.Ignored = append(attack.Ignored, ignored)
+		}
+	}
+
+	switch attack.AttackMode {
+	case AttackModeStraight:
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("straight attacks take one wordlist, got %d", len(positional))
+		}
+		attack.Wordlists = positional
+	case AttackModeCombinator:
+		if len(positional) != 2 {
+			return nil, fmt.Errorf("combinator attacks take two wordlists, got %d", len(positional))
+		}
+		attack.Wordlists = positional
+	case AttackModeBruteForce:
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("brute-force attacks take one mask, got %d", len(positional))
+		}
+		attack.Mask = positional[0]
+	default:
+		return nil, fmt.Errorf("attack mode %d is not supported", attack.AttackMode)
+	}
+	return attack, nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxJobTags          = 20
+	MaxJobTagLength     = 50
+	MaxJobCommentLength = 4000
+)
+
+// JobComment is a note an operator left on a job
+type JobComment struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	JobID     uuid.UUID  `json:"job_id" db:"job_id"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty" db:"author_id"`
+	Author    string     `json:"author" db:"author"` // Username of the author
+	Body      string     `json:"body" db:"body"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateJobCommentRequest adds a comment to a job
+type CreateJobCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// UpdateJobTagsRequest replaces a job's tags; an empty list removes them
+type UpdateJobTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones, comparing
+// case-insensitively and keeping the first spelling. Tags can't contain
+// commas, which separate tags in the job list filter.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		if len(tag) > MaxJobTagLength {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("tag %q is longer than %d characters", tag, MaxJobTagLength)}
+		}
+		if strings.ContainsAny(tag, ",\n\r\t") {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("tag %q contains a comma or control character", tag)}
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxJobTags {
+		return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("at most %d tags are allowed", MaxJobTags)}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,161 @@
+package domain
+
+import "fmt"
+
+// JobBudgetExhaustedReason is the result of a pipeline stage stopped for
+// using up its share of the budget
+const JobBudgetExhaustedReason = "Budget exhausted"
+
+// PipelineBudget is the compute, in GPU-hours, the attacks of a playbook
+// may spend together. Each stage gets a slice, so a long mask can't eat
+// what the later attacks of an engagement need.
+type PipelineBudget struct {
+	GPUHours float64       `json:"gpu_hours"`
+	Stages   []BudgetStage `json:"stages"` // Pipeline order
+}
+
+// BudgetStage is one attack's slice of a pipeline budget
+type BudgetStage struct {
+	Name     string  `json:"name"`
+	GPUHours float64 `json:"gpu_hours"`
+}
+
+// BudgetReport is how much of its budget a job group spent. A stage that
+// finishes under its slice leaves the rest to the next unfinished stage;
+// one that runs over takes it from there.
+type BudgetReport struct {
+	GPUHours          float64             `json:"gpu_hours"`
+	UsedGPUHours      float64             `json:"used_gpu_hours"`
+	RemainingGPUHours float64             `json:"remaining_gpu_hours"`
+	Stages            []BudgetStageReport `json:"stages"`
+}
+
+// BudgetStageReport is one stage's spending against its slice
+type BudgetStageReport struct {
+	Name            string  `json:"name"`
+	Jobs            int     `json:"jobs"` // Sub-jobs and retries included
+	Finished        bool    `json:"finished"`
+	SliceGPUHours   float64 `json:"slice_gpu_hours"`   // Allocated when queued
+	AllowedGPUHours float64 `json:"allowed_gpu_hours"` // The slice plus what earlier stages left
+	UsedGPUHours    float64 `json:"used_gpu_hours"`
+	Exhausted       bool    `json:"exhausted"` // Unfinished and used all it is allowed, so it is stopped
+}
+
+// PlanBudget splits the playbook's budget between its attacks in pipeline
+// order. Attacks that set their own budget_gpu_hours get it, the others
+// share the rest equally. Nil when the playbook has no budget.
+func (p *JobPlaybook) PlanBudget() *PipelineBudget {
+	if p.BudgetGPUHours <= 0 {
+		return nil
+	}
+
+	attacks := p.Ordered()
+	rest, shared := p.BudgetGPUHours, 0
+	for _, attack := range attacks {
+		if attack.BudgetGPUHours > 0 {
+			rest -= attack.BudgetGPUHours
+		} else {
+			shared++
+		}
+	}
+
+	budget := &PipelineBudget{GPUHours: p.BudgetGPUHours, Stages: make([]BudgetStage, 0, len(attacks))}
+	for _, attack := range attacks {
+		hours := attack.BudgetGPUHours
+		if hours <= 0 {
+			hours = rest / float64(shared)
+		}
+		budget.Stages = append(budget.Stages, BudgetStage{Name: attack.Name, GPUHours: hours})
+	}
+	return budget
+}
+
+// validateBudget adds the problems of the playbook's budget fields
+func (p *JobPlaybook) validateBudget(problem func(field, format string, args ...interface{})) {
+	if p.BudgetGPUHours < 0 {
+		problem("budget_gpu_hours", "must not be negative")
+	}
+	if p.Defaults.BudgetGPUHours != 0 {
+		problem("defaults.budget_gpu_hours", "is set on each attack")
+	}
+
+	var allotted float64
+	shared := 0
+	for i, attack := range p.Attacks {
+		field := fmt.Sprintf("attacks[%d].budget_gpu_hours", i)
+		switch {
+		case attack.BudgetGPUHours < 0:
+			problem(field, "must not be negative")
+		case attack.BudgetGPUHours > 0 && p.BudgetGPUHours <= 0:
+			problem(field, "needs a playbook budget_gpu_hours to take it from")
+		}
+		allotted += attack.BudgetGPUHours
+		if attack.BudgetGPUHours == 0 {
+			shared++
+		}
+	}
+	switch {
+	case p.BudgetGPUHours <= 0:
+	case allotted > p.BudgetGPUHours:
+		problem("budget_gpu_hours", "the attacks take %.1f GPU-hours, more than the %.1f of the budget", allotted, p.BudgetGPUHours)
+	case shared > 0 && allotted >= p.BudgetGPUHours:
+		problem("budget_gpu_hours", "nothing is left for the attacks without budget_gpu_hours")
+	}
+}
+
+// Report works out the spending of a job group with this budget from all
+// its jobs, retried ones included. What the stages don't take and what
+// finished stages leave over goes to the first unfinished stage in
+// pipeline order, as do overruns and jobs outside any stage.
+func (b *PipelineBudget) Report(jobs []Job) *BudgetReport {
+	report := &BudgetReport{GPUHours: b.GPUHours, Stages: make([]BudgetStageReport, len(b.Stages))}
+	index := make(map[string]int, len(b.Stages))
+	carry := b.GPUHours
+	for i, stage := range b.Stages {
+		index[stage.Name] = i
+		report.Stages[i] = BudgetStageReport{Name: stage.Name, Finished: true, SliceGPUHours: stage.GPUHours}
+		carry -= stage.GPUHours
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		used := job.DeviceSeconds / 3600
+		report.UsedGPUHours += used
+		s, ok := index[job.BudgetStage]
+		if !ok {
+			carry -= used
+			continue
+		}
+		stage := &report.Stages[s]
+		stage.Jobs++
+		stage.UsedGPUHours += used
+		if !IsTerminalJobStatus(job.Status) {
+			stage.Finished = false
+		}
+	}
+
+	for i := range report.Stages {
+		if stage := &report.Stages[i]; stage.Finished {
+			carry += stage.SliceGPUHours - stage.UsedGPUHours
+		}
+	}
+	carried := false
+	for i := range report.Stages {
+		stage := &report.Stages[i]
+		stage.AllowedGPUHours = stage.SliceGPUHours
+		if stage.Finished {
+			continue
+		}
+		if !carried {
+			stage.AllowedGPUHours += carry
+			carried = true
+		}
+		stage.Exhausted = stage.UsedGPUHours >= stage.AllowedGPUHours
+	}
+
+	report.RemainingGPUHours = b.GPUHours - report.UsedGPUHours
+	if report.RemainingGPUHours < 0 {
+		report.RemainingGPUHours = 0
+	}
+	return report
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxComparedJobs is how many jobs one comparison takes
+const MaxComparedJobs = 10
+
+// JobComparison sets jobs side by side, typically attacks with different
+// wordlists or rules on the same hash file, to show which strategies pay off
+type JobComparison struct {
+	Jobs         []JobComparisonEntry `json:"jobs"`           // In the order they were asked for
+	SameHashFile bool                 `json:"same_hash_file"` // Whether all jobs attacked one hash file
+	Currency     string               `json:"currency"`
+	// Jobs that did best, nil while none cracked anything
+	MostCracks       *uuid.UUID `json:"most_cracks,omitempty"`
+	BestCrackRate    *uuid.UUID `json:"best_crack_rate,omitempty"`    // Most cracks per hour
+	LowestCrackPrice *uuid.UUID `json:"lowest_crack_price,omitempty"` // Lowest cost per crack
+	GeneratedAt      time.Time  `json:"generated_at"`
+}
+
+// JobComparisonEntry is the attack one job ran and what it got out of it
+type JobComparisonEntry struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Engine      string     `json:"engine"`
+	HashType    int        `json:"hash_type"`
+	AttackMode  int        `json:"attack_mode"`
+	HashFileID  *uuid.UUID `json:"hash_file_id"`
+	HashFile    string     `json:"hash_file"`
+	WordlistID  *uuid.UUID `json:"wordlist_id"`
+	Wordlist    string     `json:"wordlist"` // Mask of brute-force attacks
+	Wordlist2ID *uuid.UUID `json:"wordlist2_id,omitempty"`
+	Rules       string     `json:"rules"`
+	// Run time from start to completion, or to now while running
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Keyspace in candidates when the agent reported hashcat's status,
+	// words otherwise
+	KeyspaceTotal     int64   `json:"keyspace_total"`
+	KeyspaceProcessed int64   `json:"keyspace_processed"`
+	KeyspaceCovered   float64 `json:"keyspace_covered"` // Percent, see NormalizeProgress
+	Cracks            int     `json:"cracks"`
+	CracksPerHour     float64 `json:"cracks_per_hour"`
+	// Seconds from the job's creation to its first crack, nil without one
+	TimeToFirstCrack *float64 `json:"time_to_first_crack_seconds"`
+	UsageCost
+	CostPerCrack *float64 `json:"cost_per_crack"` // Nil without cracks
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxJobCracksPerReport is how many cracks an agent may report at once
+const MaxJobCracksPerReport = 1000
+
+// JobCrack is a hash a job cracked, reported by its agent as soon as the
+// engine wrote it to the outfile
+type JobCrack struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	JobID     uuid.UUID  `json:"job_id" db:"job_id"`
+	AgentID   *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`
+	Hash      string     `json:"hash" db:"hash"`
+	Plaintext string     `json:"plaintext" db:"plaintext"` // Encrypted at rest with the job results
+	CrackedAt time.Time  `json:"cracked_at" db:"cracked_at"`
+}
+
+// JobCrackReport is one crack in an agent's report
+type JobCrackReport struct {
+	Hash      string    `json:"hash" binding:"required"`
+	Plaintext string    `json:"plaintext"`
+	CrackedAt time.Time `json:"cracked_at"` // When the agent saw it, now if unset
+}
+
+// ReportJobCracksRequest carries the cracks an agent found since its last
+// report
+type ReportJobCracksRequest struct {
+	AgentID string           `json:"agent_id" binding:"required"`
+	Cracks  []JobCrackReport `json:"cracks" binding:"required,dive"`
+}
+
+// JobCracks are the cracks of a job, oldest first, with how long the job
+// took to crack its first hash
+type JobCracks struct {
+	Cracks       []JobCrack `json:"cracks"`
+	FirstCrackAt *time.Time `json:"first_crack_at"`
+	// Seconds from the job's creation to its first crack, nil until it has one
+	TimeToFirstCrack *float64 `json:"time_to_first_crack_seconds"`
+}
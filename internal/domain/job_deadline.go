@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobStartupAllowance is how long before its deadline a job must still be
+// handed to an agent. Building kernels and the dictionary cache alone can
+// take that long, so a later start would end before cracking anything.
+const JobStartupAllowance = time.Minute
+
+// JobDeadlineReachedReason is what an agent reports when it stopped a job
+// at its deadline
+const JobDeadlineReachedReason = "Deadline reached"
+
+// ValidateJobDeadline checks the deadline a job request sets, if any
+func ValidateJobDeadline(deadline *time.Time, now time.Time) error {
+	if deadline != nil && !deadline.After(now.Add(JobStartupAllowance)) {
+		return &ValidationError{Field: "deadline", Message: fmt.Sprintf("must be more than %s ahead", JobStartupAllowance)}
+	}
+	return nil
+}
+
+// TimeLeft is how long the job may still run before its deadline, negative
+// once it passed. Jobs without a deadline have unlimited time, reported as
+// false.
+func (j *Job) TimeLeft(now time.Time) (time.Duration, bool) {
+	if j.Deadline == nil {
+		return 0, false
+	}
+	return j.Deadline.Sub(now), true
+}
+
+// CanStart reports whether the job may still be started at now, more than
+// JobStartupAllowance before its deadline
+func (j *Job) CanStart(now time.Time) bool {
+	left, ok := j.TimeLeft(now)
+	return !ok || left > JobStartupAllowance
+}
+
+// JobExpiredResult is the result of a job stopped at its deadline or for
+// its budget, telling how far it got
+func JobExpiredResult(reason string, cracks int, progress float64) string {
+	return fmt.Sprintf("%s: %.1f%% of the keyspace searched, %d cracked", reason, progress, cracks)
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultJobLease is how long an agent holds an assigned or running job
+// without renewing the lease. Heartbeats and progress reports renew it.
+const DefaultJobLease = 3 * time.Minute
+
+// JobLeaser renews the job leases of agents that are still in touch
+type JobLeaser interface {
+	RenewJobLeases(ctx context.Context, agentIDs []uuid.UUID) error
+}
@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// JobPlaybookVersion is the playbook format the server understands
+const JobPlaybookVersion = "v1"
+
+// JobPlaybook describes attacks to queue in one request, so attack plans can
+// be kept as files in a repository and applied with hashcatctl. It is read
+// from YAML or JSON.
+type JobPlaybook struct {
+	Version string `json:"version" yaml:"version"`
+	Name    string `json:"name" yaml:"name"` // Also the name of the job group the jobs join
+	// Fields every attack takes unless it sets them itself
+	Defaults PlaybookAttack   `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Attacks  []PlaybookAttack `json:"attacks" yaml:"attacks"`
+	// Attack names in the order their jobs are queued; document order when
+	// empty. Agents take pending jobs oldest first.
+	Pipeline []string `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	// GPU-hours the attacks may spend together, see PlanBudget; no limit
+	// when 0
+	BudgetGPUHours float64 `json:"budget_gpu_hours,omitempty" yaml:"budget_gpu_hours,omitempty"`
+}
+
+// PlaybookAttack is one attack of a playbook. Files are referenced by ID or
+// by name, so a playbook works on any server holding the same files.
+type PlaybookAttack struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Cracking engine, hashcat (default) or john
+	Engine     string `json:"engine,omitempty" yaml:"engine,omitempty"`
+	JohnFormat string `json:"john_format,omitempty" yaml:"john_format,omitempty"`
+	HashType   *int   `json:"hash_type,omitempty" yaml:"hash_type,omitempty"`
+	// 0 (straight), 1 (combinator) or 3 (brute force); follows from
+	// wordlist2 or mask when left out
+	AttackMode     *int            `json:"attack_mode,omitempty" yaml:"attack_mode,omitempty"`
+	HashFile       string          `json:"hash_file,omitempty" yaml:"hash_file,omitempty"`
+	Wordlist       string          `json:"wordlist,omitempty" yaml:"wordlist,omitempty"`
+	Wordlist2      string          `json:"wordlist2,omitempty" yaml:"wordlist2,omitempty"` // Right-hand wordlist of a combinator attack
+	Rules          string          `json:"rules,omitempty" yaml:"rules,omitempty"`         // Built-in rule file
+	Mask           string          `json:"mask,omitempty" yaml:"mask,omitempty"`
+	CustomCharsets []string        `json:"custom_charsets,omitempty" yaml:"custom_charsets,omitempty"` // ?1 to ?4 of the mask
+	ExtraArgs      []string        `json:"extra_args,omitempty" yaml:"extra_args,omitempty"`
+	Tags           []string        `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Agents         *PlaybookAgents `json:"agents,omitempty" yaml:"agents,omitempty"`
+	// Share of the playbook's budget; attacks without one split the rest
+	BudgetGPUHours float64 `json:"budget_gpu_hours,omitempty" yaml:"budget_gpu_hours,omitempty"`
+}
+
+// PlaybookAgents constrains where an attack runs. Without it the scheduler
+// picks an agent.
+type PlaybookAgents struct {
+	Names []string `json:"names,omitempty" yaml:"names,omitempty"` // Agent names or IDs; several split the job
+	Group string   `json:"group,omitempty" yaml:"group,omitempty"` // Agent group name or ID
+}
+
+// JobPlaybookResult is what applying a playbook queued, or would queue on a
+// dry run
+type JobPlaybookResult struct {
+	Name    string                 `json:"name"`
+	DryRun  bool                   `json:"dry_run,omitempty"`
+	GroupID *uuid.UUID             `json:"group_id,omitempty"`
+	Attacks []PlaybookAttackResult `json:"attacks"`
+	Budget  *PipelineBudget        `json:"budget,omitempty"`
+}
+
+// PlaybookAttackResult is an attack with the job request it resolved to
+type PlaybookAttackResult struct {
+	Attack  string           `json:"attack"`
+	Request CreateJobRequest `json:"request"`
+	Job     *Job             `json:"job,omitempty"` // Not set on a dry run
+}
+
+// PlaybookError lists everything wrong with a playbook, so it can be fixed
+// in one go
+type PlaybookError struct {
+	Problems []ValidationError
+}
+
+func (e *PlaybookError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i := range e.Problems {
+		messages[i] = e.Problems[i].Error()
+	}
+	return "invalid playbook: " + strings.Join(messages, "; ")
+}
+
+// Unwrap makes a PlaybookError a validation error for IsValidationError
+func (e *PlaybookError) Unwrap() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return &e.Problems[0]
+}
+
+// ParseJobPlaybook reads a playbook from YAML or JSON, which is YAML too.
+// Unknown fields are errors, so a typo doesn't silently drop a setting.
+func ParseJobPlaybook(data []byte) (*JobPlaybook, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var playbook JobPlaybook
+	if err := decoder.Decode(&playbook); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &ValidationError{Field: "playbook", Message: "is empty"}
+		}
+		return nil, &ValidationError{Field: "playbook", Message: err.Error()}
+	}
+	if err := playbook.Validate(); err != nil {
+		return nil, err
+	}
+	return &playbook, nil
+}
+
+// Validate checks the playbook against its schema. References to files and
+// agents are checked when it is applied.
+func (p *JobPlaybook) Validate() error {
+	var problems []ValidationError
+	problem := func(field, format string, args ...interface{}) {
+		problems = append(problems, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.Version != JobPlaybookVersion {
+		problem("version", "must be %s", JobPlaybookVersion)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		problem("name", "is required")
+	}
+	if len(p.Attacks) == 0 {
+		problem("attacks", "at least one attack is required")
+	}
+
+	names := make(map[string]bool, len(p.Attacks))
+	for i := range p.Attacks {
+		field := fmt.Sprintf("attacks[%d]", i)
+		attack := p.Attacks[i].WithDefaults(p.Defaults)
+
+		switch {
+		case attack.Name == "":
+			problem(field+".name", "is required")
+		case names[attack.Name]:
+			problem(field+".name", "%q is used by another attack", attack.Name)
+		}
+		names[attack.Name] = true
+
+		if attack.HashFile == "" {
+			problem(field+".hash_file", "is required")
+		}
+		if attack.HashType == nil && attack.Engine != EngineJohn {
+			problem(field+".hash_type", "is required")
+		}
+		if attack.Mask != "" && (attack.Wordlist != "" || attack.Wordlist2 != "") {
+			problem(field+".mask", "cannot be combined with a wordlist")
+		}
+		if attack.Mask == "" && attack.Wordlist == "" {
+			problem(field, "needs a wordlist or a mask")
+		}
+		if len(attack.CustomCharsets) > 4 {
+			problem(field+".custom_charsets", "at most 4 are supported")
+		}
+		if attack.Agents != nil && attack.Agents.Group != "" && len(attack.Agents.Names) > 0 {
+			problem(field+".agents", "names cannot be combined with a group")
+		}
+	}
+
+	p.validateBudget(problem)
+
+	if len(p.Pipeline) > 0 {
+		seen := make(map[string]bool, len(p.Pipeline))
+		for i, name := range p.Pipeline {
+			field := fmt.Sprintf("pipeline[%d]", i)
+			switch {
+			case !names[name]:
+				problem(field, "no attack is named %q", name)
+			case seen[name]:
+				problem(field, "%q is listed twice", name)
+			}
+			seen[name] = true
+		}
+		for _, attack := range p.Attacks {
+			if attack.Name != "" && !seen[attack.Name] {
+				problem("pipeline", "attack %q is missing", attack.Name)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PlaybookError{Problems: problems}
+	}
+	return nil
+}
+
+// Ordered returns the attacks in pipeline order, with the defaults applied
+func (p *JobPlaybook) Ordered() []PlaybookAttack {
+	byName := make(map[string]PlaybookAttack, len(p.Attacks))
+	attacks := make([]PlaybookAttack, 0, len(p.Attacks))
+	for _, attack := range p.Attacks {
+		attack = attack.WithDefaults(p.Defaults)
+		byName[attack.Name] = attack
+		attacks = append(attacks, attack)
+	}
+	if len(p.Pipeline) == 0 {
+		return attacks
+	}
+
+	attacks = attacks[:0]
+	for _, name := range p.Pipeline {
+		attacks = append(attacks, byName[name])
+	}
+	return attacks
+}
+
+// WithDefaults fills the fields the attack leaves empty from defaults
+func (a PlaybookAttack) WithDefaults(defaults PlaybookAttack) PlaybookAttack {
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&a.Engine, defaults.Engine)
+	fill(&a.JohnFormat, defaults.JohnFormat)
+	fill(&a.HashFile, defaults.HashFile)
+	fill(&a.Rules, defaults.Rules)
+	// An attack's own mask or wordlist decides what kind of attack it is
+	switch {
+	case a.Mask != "":
+	case a.Wordlist != "" || a.Wordlist2 != "":
+		fill(&a.Wordlist, defaults.Wordlist)
+	default:
+		fill(&a.Wordlist, defaults.Wordlist)
+		fill(&a.Wordlist2, defaults.Wordlist2)
+		fill(&a.Mask, defaults.Mask)
+	}
+	if a.HashType == nil {
+		a.HashType = defaults.HashType
+	}
+	if a.AttackMode == nil {
+		a.AttackMode = defaults.AttackMode
+	}
+	if a.CustomCharsets == nil {
+		a.CustomCharsets = defaults.CustomCharsets
+	}
+	if a.ExtraArgs == nil {
+		a.ExtraArgs = defaults.ExtraArgs
+	}
+	if a.Tags == nil {
+		a.Tags = defaults.Tags
+	}
+	if a.Agents == nil {
+		a.Agents = defaults.Agents
+	}
+	return a
+}
+
+// Mode returns the attack mode, following from the mask or second wordlist
+// when the attack doesn't name one
+func (a PlaybookAttack) Mode() int {
+	switch {
+	case a.AttackMode != nil:
+		return *a.AttackMode
+	case a.Mask != "":
+		return AttackModeBruteForce
+	case a.Wordlist2 != "":
+		return AttackModeCombinator
+	default:
+		return AttackModeStraight
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+// NormalizeProgress returns the share of a job's keyspace that is done, in
+// percent. hashcat's progress counts candidates, words times rules, so with
+// a large rule set it says little about how many words are finished. The
+// restore point counts the words tried with every rule, where the job would
+// resume, so rule-based dictionary attacks are measured by it against the
+// words the job covers. Other attacks, and jobs whose word count is unknown
+// (words 0) or that reported no status, keep hashcat's progress.
+func NormalizeProgress(job *Job, stats *JobRuntimeStats, words int64) float64 {
+	if stats == nil || job.AttackMode != AttackModeStraight || job.Rules == "" || words <= 0 {
+		return job.Progress
+	}
+
+	// The restore point counts from the start of the wordlist, not the
+	// job's part of it
+	done := stats.RestorePoint
+	if job.Skip != nil {
+		done -= *job.Skip
+	}
+	switch {
+	case done <= 0:
+		return 0
+	case done >= words:
+		return 100
+	}
+	return float64(done) / float64(words) * 100
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Job statuses
+const (
+	JobStatusPending     = "pending"     // Created, waiting for an agent
+	JobStatusAssigned    = "assigned"    // Agent chosen, waiting to be picked up
+	JobStatusRunning     = "running"     // hashcat is running on the agent
+	JobStatusPaused      = "paused"      // Temporarily stopped, can be resumed
+	JobStatusInterrupted = "interrupted" // Its agent shut down, waiting for another to resume it
+	JobStatusCompleted   = "completed"   // Finished successfully
+	JobStatusCracked     = "cracked"     // Finished, password found
+	JobStatusFailed      = "failed"      // Finished with an error
+	JobStatusCancelled   = "cancelled"   // Stopped by a user or because another agent found the password
+	JobStatusExpired     = "expired"     // Stopped unfinished at its deadline or over its budget
+)
+
+// JobResultExhausted is the result an agent reports when hashcat went
+// through the whole keyspace without finding the password
+const JobResultExhausted = "Password not found - exhausted"
+
+// IsPasswordFound reports whether a result reported by an agent holds a
+// cracked password
+func IsPasswordFound(result string) bool {
+	return result != "" && result != JobResultExhausted
+}
+
+// jobResultCrackedPrefix starts the result of an agent that cracked the
+// hash and read back the password
+const jobResultCrackedPrefix = "Password found: "
+
+// CrackedPassword returns the password held by a job result. Results that
+// don't carry one, such as a password the agent failed to read back, return
+// false.
+func CrackedPassword(result string) (string, bool) {
+	password, ok := strings.CutPrefix(result, jobResultCrackedPrefix)
+	if !ok || password == "" {
+		return "", false
+	}
+	return password, true
+}
+
+// jobTransitions lists, for each status, the statuses a job may move to next.
+// Terminal statuses have no entry.
+var jobTransitions = map[string][]string{
+	JobStatusPending:     {JobStatusAssigned, JobStatusRunning, JobStatusFailed, JobStatusCancelled, JobStatusExpired},
+	JobStatusAssigned:    {JobStatusPending, JobStatusRunning, JobStatusFailed, JobStatusCancelled, JobStatusExpired},
+	JobStatusRunning:     {JobStatusPaused, JobStatusInterrupted, JobStatusCompleted, JobStatusCracked, JobStatusFailed, JobStatusCancelled, JobStatusExpired},
+	JobStatusPaused:      {JobStatusPending, JobStatusAssigned, JobStatusRunning, JobStatusFailed, JobStatusCancelled, JobStatusExpired},
+	JobStatusInterrupted: {JobStatusAssigned, JobStatusFailed, JobStatusCancelled, JobStatusExpired},
+}
+
+// IsJobStatus reports whether status is one of the job statuses
+func IsJobStatus(status string) bool {
+	_, unfinished := jobTransitions[status]
+	return unfinished || IsTerminalJobStatus(status)
+}
+
+// CanTransitionJob reports whether a job may move from one status to another
+func CanTransitionJob(from, to string) bool {
+	for _, next := range jobTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminalJobStatus reports whether a job in this status is finished for good
+func IsTerminalJobStatus(status string) bool {
+	switch status {
+	case JobStatusCompleted, JobStatusCracked, JobStatusFailed, JobStatusCancelled, JobStatusExpired:
+		return true
+	}
+	return false
+}
+
+// Actors recorded in job events when the request doesn't identify a user
+const (
+	ActorSystem = "system"
+	ActorAgent  = "agent"
+	ActorAPI    = "api"
+)
+
+type actorKey struct{}
+
+// WithActor attaches who is making a change, for the job event log
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobTimeouts are the server's limits for jobs whose request sets none.
+// Zero means no limit.
+type JobTimeouts struct {
+	MaxRuntimeMinutes   int // Stop a run that takes longer
+	StallTimeoutMinutes int // Stop a run whose progress and speed don't change for this long
+}
+
+// Resolve returns the limits of a new job: those the request sets, else
+// the defaults
+func (t JobTimeouts) Resolve(maxRuntime, stallTimeout *int) (int, int) {
+	resolved := t
+	if maxRuntime != nil {
+		resolved.MaxRuntimeMinutes = *maxRuntime
+	}
+	if stallTimeout != nil {
+		resolved.StallTimeoutMinutes = *stallTimeout
+	}
+	return resolved.MaxRuntimeMinutes, resolved.StallTimeoutMinutes
+}
+
+// ValidateJobTimeouts checks the limits a job request sets. Nil takes the
+// server default and 0 turns the limit off.
+func ValidateJobTimeouts(maxRuntime, stallTimeout *int) error {
+	if maxRuntime != nil && *maxRuntime < 0 {
+		return &ValidationError{Field: "max_runtime_minutes", Message: "must not be negative"}
+	}
+	if stallTimeout != nil && *stallTimeout < 0 {
+		return &ValidationError{Field: "stall_timeout_minutes", Message: "must not be negative"}
+	}
+	return nil
+}
+
+// MaxRuntime is how long one run of the job may take, 0 for no limit
+func (j *Job) MaxRuntime() time.Duration {
+	return time.Duration(j.MaxRuntimeMinutes) * time.Minute
+}
+
+// StallTimeout is how long a run may go without a change of progress or
+// speed, 0 for no limit
+func (j *Job) StallTimeout() time.Duration {
+	return time.Duration(j.StallTimeoutMinutes) * time.Minute
+}
+
+// JobRuntimeExceededReason is the result of a job stopped for running
+// longer than its max runtime
+func JobRuntimeExceededReason(minutes int) string {
+	return fmt.Sprintf("Max runtime of %d minutes exceeded", minutes)
+}
+
+// JobStalledReason says why a job whose progress stalled was stopped
+func JobStalledReason(minutes int) string {
+	return fmt.Sprintf("Stalled: no progress for %d minutes", minutes)
+}
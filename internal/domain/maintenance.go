@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceWindow is a period during which no new jobs are dispatched to
+// an agent, the agents of an agent group or, with neither set, every agent.
+// A window is either one-off, from StartsAt to EndsAt, or weekly, from
+// StartTime to EndTime on Days in Timezone. A weekly window that ends at or
+// before its start time runs past midnight into the next day.
+type MaintenanceWindow struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	Description  string     `json:"description,omitempty" db:"description"`
+	AgentID      *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`
+	AgentGroupID *uuid.UUID `json:"agent_group_id,omitempty" db:"agent_group_id"`
+	StartsAt     *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Days         []string   `json:"days,omitempty" db:"days"`             // mon..sun, empty for every day
+	StartTime    string     `json:"start_time,omitempty" db:"start_time"` // HH:MM
+	EndTime      string     `json:"end_time,omitempty" db:"end_time"`     // HH:MM
+	Timezone     string     `json:"timezone,omitempty" db:"timezone"`     // IANA name, UTC when empty
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// MaintenanceWindowRequest creates a maintenance window or replaces one
+type MaintenanceWindowRequest struct {
+	Name         string     `json:"name" binding:"required"`
+	Description  string     `json:"description,omitempty"`
+	AgentID      string     `json:"agent_id,omitempty"`
+	AgentGroupID string     `json:"agent_group_id,omitempty"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	Days         []string   `json:"days,omitempty"`
+	StartTime    string     `json:"start_time,omitempty"`
+	EndTime      string     `json:"end_time,omitempty"`
+	Timezone     string     `json:"timezone,omitempty"`
+}
+
+// AgentMaintenance is the maintenance window an agent is in
+type AgentMaintenance struct {
+	AgentID  uuid.UUID `json:"agent_id"`
+	WindowID uuid.UUID `json:"window_id"`
+	Window   string    `json:"window"`
+	Until    time.Time `json:"until"`
+}
+
+// MaintenanceCalendar tells which agents are in a maintenance window. An
+// agent in several windows maps to the one ending last.
+type MaintenanceCalendar interface {
+	AgentsInMaintenance(ctx context.Context, at time.Time) (map[uuid.UUID]AgentMaintenance, error)
+}
+
+var maintenanceWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate checks that the window is either one-off or weekly and targets
+// at most one of an agent and an agent group
+func (w *MaintenanceWindow) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return &ValidationError{Field: "name", Message: "is required"}
+	}
+	if w.AgentID != nil && w.AgentGroupID != nil {
+		return &ValidationError{Field: "agent_group_id", Message: "cannot be combined with agent_id"}
+	}
+
+	oneOff := w.StartsAt != nil || w.EndsAt != nil
+	weekly := w.StartTime != "" || w.EndTime != "" || len(w.Days) > 0
+	switch {
+	case oneOff && weekly:
+		return &ValidationError{Field: "starts_at", Message: "cannot be combined with a weekly schedule"}
+	case oneOff:
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return &ValidationError{Field: "ends_at", Message: "starts_at and ends_at are both required"}
+		}
+		if !w.EndsAt.After(*w.StartsAt) {
+			return &ValidationError{Field: "ends_at", Message: "must be after starts_at"}
+		}
+	case weekly:
+		if _, _, ok := parseClock(w.StartTime); !ok {
+			return &ValidationError{Field: "start_time", Message: "must be HH:MM"}
+		}
+		if _, _, ok := parseClock(w.EndTime); !ok {
+			return &ValidationError{Field: "end_time", Message: "must be HH:MM"}
+		}
+		for _, day := range w.Days {
+			if _, ok := maintenanceWeekdays[day]; !ok {
+				return &ValidationError{Field: "days", Message: fmt.Sprintf("unknown day %q, use mon, tue, wed, thu, fri, sat or sun", day)}
+			}
+		}
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return &ValidationError{Field: "timezone", Message: fmt.Sprintf("unknown time zone %q", w.Timezone)}
+		}
+	default:
+		return &ValidationError{Field: "starts_at", Message: "set starts_at and ends_at, or start_time and end_time"}
+	}
+	return nil
+}
+
+// ActiveUntil reports whether the window is open at the given time and, if
+// so, when the current occurrence ends
+func (w *MaintenanceWindow) ActiveUntil(at time.Time) (time.Time, bool) {
+	if w.StartsAt != nil && w.EndsAt != nil {
+		if !at.Before(*w.StartsAt) && at.Before(*w.EndsAt) {
+			return *w.EndsAt, true
+		}
+		return time.Time{}, false
+	}
+
+	startHour, startMinute, ok := parseClock(w.StartTime)
+	if !ok {
+		return time.Time{}, false
+	}
+	endHour, endMinute, ok := parseClock(w.EndTime)
+	if !ok {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	overnight := endHour*60+endMinute <= startHour*60+startMinute
+
+	// The occurrence covering the time started today or, past midnight, yesterday
+	local := at.In(loc)
+	for daysAgo := 0; daysAgo <= 1; daysAgo++ {
+		year, month, day := local.AddDate(0, 0, -daysAgo).Date()
+		start := time.Date(year, month, day, startHour, startMinute, 0, 0, loc)
+		if !w.onDay(start.Weekday()) {
+			continue
+		}
+		if overnight {
+			day++
+		}
+		end := time.Date(year, month, day, endHour, endMinute, 0, 0, loc)
+		if !at.Before(start) && at.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// onDay reports whether a weekly window opens on the weekday
+func (w *MaintenanceWindow) onDay(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if maintenanceWeekdays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses a time of day written as HH:MM
+func parseClock(clock string) (hour, minute int, ok bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, false
+	}
+	return t.Hour(), t.Minute(), true
+}
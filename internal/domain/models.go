@@ -14,65 +14,384 @@ type Agent struct {
 	Name         string    `json:"name" db:"name"`
 	IPAddress    string    `json:"ip_address" db:"ip_address"`
 	Port         int       `json:"port" db:"port"`
-	Status       string    `json:"status" db:"status"` // online, offline, busy
+	Status       string    `json:"status" db:"status"` // online, busy, degraded (heartbeats late), offline
 	Capabilities string    `json:"capabilities" db:"capabilities"`
 	AgentKey     string    `json:"agent_key" db:"agent_key"`
 	Speed        int64     `json:"speed" db:"speed"` // Hash rate dalam H/s dari benchmark
 	LastSeen     time.Time `json:"last_seen" db:"last_seen"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// Latest runtime snapshot from the agent's heartbeat, nil until one arrives
+	Heartbeat *AgentHeartbeat `json:"heartbeat,omitempty" db:"heartbeat"`
+	// hashcat version the agent last reported, e.g. "v6.2.6", empty when unknown
+	HashcatVersion string `json:"hashcat_version,omitempty" db:"hashcat_version"`
+	// Maintenance window the agent is in, nil when it can take new jobs
+	Maintenance *AgentMaintenance `json:"maintenance,omitempty" db:"-"`
+	// Tenant the agent belongs to, nil for agents shared by every tenant
+	TenantID *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"`
+}
+
+// AgentHeartbeat is the runtime snapshot an agent sends with its heartbeat
+type AgentHeartbeat struct {
+	CurrentJobID     *uuid.UUID `json:"current_job_id,omitempty"`
+	JobProgress      float64    `json:"job_progress"`      // Percent of the current job
+	JobSpeed         int64      `json:"job_speed"`         // H/s of the current job
+	GPUUtilization   int        `json:"gpu_utilization"`   // Average over devices in percent, -1 when unknown
+	FreeDiskBytes    int64      `json:"free_disk_bytes"`   // Free space in the upload directory, -1 when unknown
+	HashcatAvailable bool       `json:"hashcat_available"` // hashcat found on the agent's PATH
+	ReportedAt       time.Time  `json:"reported_at"`
+	// Output of hashcat --version, empty when the agent couldn't run it
+	HashcatVersion string `json:"hashcat_version,omitempty"`
+	// Cracking engines found on the agent, e.g. ["hashcat", "john"]
+	Engines []string `json:"engines,omitempty"`
+}
+
+// AgentSettings are agent settings managed on the server, so a fleet can be
+// tuned without touching every machine. The agent gets them with its
+// heartbeats; zero values leave the agent's own configuration in place.
+type AgentSettings struct {
+	PollIntervalSeconds     int    `json:"poll_interval_seconds,omitempty"`
+	StatusIntervalSeconds   int    `json:"status_interval_seconds,omitempty"`
+	FileScanIntervalSeconds int    `json:"file_scan_interval_seconds,omitempty"`
+	WorkloadProfile         int    `json:"workload_profile,omitempty"`    // hashcat -w, 1 (low) to 4 (nightmare)
+	TempAbort               int    `json:"temp_abort,omitempty"`          // hashcat --hwmon-temp-abort in °C
+	MaxConcurrentJobs       int    `json:"max_concurrent_jobs,omitempty"` // Jobs queued or running on the agent at once
+	DiskQuotaMB             int64  `json:"disk_quota_mb,omitempty"`       // Size limit of the agent's upload directory
+	UploadDir               string `json:"upload_dir,omitempty"`          // Applies when the agent restarts
+}
+
+// Validate checks the settings are within what the agent accepts
+func (s *AgentSettings) Validate() error {
+	for field, seconds := range map[string]int{
+		"poll_interval_seconds":      s.PollIntervalSeconds,
+		"status_interval_seconds":    s.StatusIntervalSeconds,
+		"file_scan_interval_seconds": s.FileScanIntervalSeconds,
+	} {
+		if seconds < 0 {
+			return &ValidationError{Field: field, Message: "cannot be negative"}
+		}
+	}
+	if s.WorkloadProfile < 0 || s.WorkloadProfile > 4 {
+		return &ValidationError{Field: "workload_profile", Message: "must be between 1 and 4, or 0 for the agent's own setting"}
+	}
+	if s.TempAbort < 0 || s.TempAbort > 120 {
+		return &ValidationError{Field: "temp_abort", Message: "must be between 1 and 120, or 0 for the agent's own setting"}
+	}
+	if s.MaxConcurrentJobs < 0 {
+		return &ValidationError{Field: "max_concurrent_jobs", Message: "cannot be negative"}
+	}
+	if s.DiskQuotaMB < 0 {
+		return &ValidationError{Field: "disk_quota_mb", Message: "cannot be negative"}
+	}
+	return nil
+}
+
+// AgentSyncResult is the server's answer to an agent that reconnected and
+// reported what it is running
+type AgentSyncResult struct {
+	// Whether the agent should carry on with the job it reported
+	KeepJob bool   `json:"keep_job"`
+	Reason  string `json:"reason,omitempty"` // Why it should stop it
+	// Jobs the server thought the agent was running, handed to other agents
+	RequeuedJobs []uuid.UUID `json:"requeued_jobs"`
+}
+
+// Problems lists what keeps an agent from taking work: no cracking engine, a
+// hashcat older than MinHashcatVersion, or less free disk than minFreeDisk
+// bytes. An unknown free disk or hashcat version is not a problem.
+func (h *AgentHeartbeat) Problems(minFreeDisk int64) []string {
+	if h == nil {
+		return nil
+	}
+
+	var problems []string
+	if !h.HashcatAvailable {
+		// A John the Ripper only agent can still take john jobs
+		if len(h.Engines) == 0 {
+			problems = append(problems, "hashcat not available")
+		}
+	} else if v, ok := ParseHashcatVersion(h.HashcatVersion); ok && !v.AtLeast(MinHashcatVersion) {
+		problems = append(problems, fmt.Sprintf("hashcat %s is older than %s", h.HashcatVersion, MinHashcatVersion))
+	}
+	if h.FreeDiskBytes >= 0 && h.FreeDiskBytes < minFreeDisk {
+		problems = append(problems, fmt.Sprintf("low disk space: %d bytes free", h.FreeDiskBytes))
+	}
+	return problems
+}
+
+// AgentSeen is a heartbeat buffered for the next batched write
+type AgentSeen struct {
+	LastSeen  time.Time
+	Heartbeat *AgentHeartbeat // nil keeps the stored snapshot
+}
+
+// JobProgress is a running job's latest progress report, buffered for the
+// next batched write
+type JobProgress struct {
+	Progress           float64
+	NormalizedProgress float64
+	Speed              int64
+	ETA                *time.Time
+	Stats              *JobRuntimeStats
+	DeviceSeconds      float64
+	EnergyWh           float64
+	ReportedAt         time.Time
+}
+
+// Sources of agent log lines
+const (
+	AgentLogSourceAgent   = "agent"   // The agent's own log output
+	AgentLogSourceHashcat = "hashcat" // hashcat's console output
+)
+
+// AgentLogLine is one line of output an agent shipped to the server
+type AgentLogLine struct {
+	ID      int64     `json:"id" db:"id"` // Increases with every stored line, for tailing
+	AgentID uuid.UUID `json:"agent_id" db:"agent_id"`
+	Time    time.Time `json:"time" db:"logged_at"` // When the agent logged it
+	Source  string    `json:"source" db:"source"`
+	Message string    `json:"message" db:"message"`
+}
+
+// AgentGroup is a named pool of agents, e.g. "red-team-lab" or
+// "cloud-burst". Jobs can target a group instead of listing agents.
+type AgentGroup struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// AgentGroupSummary is the health of one agent group
+type AgentGroupSummary struct {
+	AgentGroup
+	TotalAgents    int         `json:"total_agents"`
+	OnlineAgents   int         `json:"online_agents"`
+	BusyAgents     int         `json:"busy_agents"`
+	DegradedAgents int         `json:"degraded_agents"` // Heartbeats late
+	OfflineAgents  int         `json:"offline_agents"`
+	Speed          int64       `json:"speed"` // Combined speed of online and busy agents
+	AgentIDs       []uuid.UUID `json:"agent_ids"`
+}
+
+// CreateAgentGroupRequest represents the request to create an agent group
+type CreateAgentGroupRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	AgentIDs    []string `json:"agent_ids,omitempty"` // Initial members
+}
+
+// AgentGroupMemberRequest adds an agent to an agent group
+type AgentGroupMemberRequest struct {
+	AgentID string `json:"agent_id" binding:"required"`
 }
 
 // Job represents a cracking job
 type Job struct {
-	ID             uuid.UUID   `json:"id" db:"id"`
-	Name           string      `json:"name" db:"name"`
-	Status         string      `json:"status" db:"status"` // pending, running, completed, failed, paused
-	HashType       int         `json:"hash_type" db:"hash_type"`
-	AttackMode     int         `json:"attack_mode" db:"attack_mode"`
-	HashFile       string      `json:"hash_file" db:"hash_file"`
-	HashFileID     *uuid.UUID  `json:"hash_file_id" db:"hash_file_id"`
-	Wordlist       string      `json:"wordlist" db:"wordlist"`
-	WordlistID     *uuid.UUID  `json:"wordlist_id" db:"wordlist_id"`
-	Rules          string      `json:"rules" db:"rules"`                     // Password hasil cracking atau hashcat rules
-	AgentID        *uuid.UUID  `json:"agent_id" db:"agent_id"`               // Single agent (legacy)
-	AgentIDs       []uuid.UUID `json:"agent_ids,omitempty" db:"-"`           // Multiple agents (not stored in DB, computed)
-	Skip           *int64      `json:"skip,omitempty" db:"skip"`             // Hashcat --skip parameter for distributed cracking
-	WordLimit      *int64      `json:"word_limit,omitempty" db:"word_limit"` // Hashcat --limit parameter for distributed cracking
-	Progress       float64     `json:"progress" db:"progress"`
-	Speed          int64       `json:"speed" db:"speed"` // Hash rate dalam H/s
-	ETA            *time.Time  `json:"eta" db:"eta"`     // Estimated time of completion
-	Result         string      `json:"result" db:"result"`
-	TotalWords     int64       `json:"total_words" db:"total_words"`         // Total dictionary words for this job
-	ProcessedWords int64       `json:"processed_words" db:"processed_words"` // Words that have been processed
-	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
-	StartedAt      *time.Time  `json:"started_at" db:"started_at"`
-	CompletedAt    *time.Time  `json:"completed_at" db:"completed_at"`
+	ID             uuid.UUID      `json:"id" db:"id"`
+	Name           string         `json:"name" db:"name"`
+	Status         string         `json:"status" db:"status"` // One of the JobStatus constants
+	HashType       int            `json:"hash_type" db:"hash_type"`
+	AttackMode     int            `json:"attack_mode" db:"attack_mode"`
+	HashFile       string         `json:"hash_file" db:"hash_file"`
+	HashFileID     *uuid.UUID     `json:"hash_file_id" db:"hash_file_id"`
+	Wordlist       string         `json:"wordlist" db:"wordlist"`
+	WordlistID     *uuid.UUID     `json:"wordlist_id" db:"wordlist_id"`
+	Wordlist2ID    *uuid.UUID     `json:"wordlist2_id,omitempty" db:"wordlist2_id"`       // Right-hand wordlist of combinator attacks
+	Rules          string         `json:"rules" db:"rules"`                               // Password hasil cracking atau hashcat rules
+	CustomCharset1 string         `json:"custom_charset1,omitempty" db:"custom_charset1"` // Hashcat -1, inline charset or charset file UUID
+	CustomCharset2 string         `json:"custom_charset2,omitempty" db:"custom_charset2"` // Hashcat -2
+	CustomCharset3 string         `json:"custom_charset3,omitempty" db:"custom_charset3"` // Hashcat -3
+	CustomCharset4 string         `json:"custom_charset4,omitempty" db:"custom_charset4"` // Hashcat -4
+	ExtraArgs      []string       `json:"extra_args,omitempty" db:"extra_args"`           // Whitelisted hashcat tuning options, e.g. -O
+	Engine         string         `json:"engine" db:"engine"`                             // Cracking engine, hashcat or john; see EngineName
+	JohnFormat     string         `json:"john_format,omitempty" db:"john_format"`         // John the Ripper --format of john jobs
+	AgentID        *uuid.UUID     `json:"agent_id" db:"agent_id"`                         // Single agent (legacy)
+	AgentIDs       []uuid.UUID    `json:"agent_ids,omitempty" db:"-"`                     // Multiple agents (not stored in DB, computed)
+	GroupID        *uuid.UUID     `json:"group_id,omitempty" db:"group_id"`               // Job group this sub-job belongs to
+	RetriedFrom    *uuid.UUID     `json:"retried_from,omitempty" db:"retried_from"`       // Job this one re-runs
+	ProjectID      *uuid.UUID     `json:"project_id,omitempty" db:"project_id"`           // Project the job belongs to, if any
+	Skip           *int64         `json:"skip,omitempty" db:"skip"`                       // Hashcat --skip parameter for distributed cracking
+	WordLimit      *int64         `json:"word_limit,omitempty" db:"word_limit"`           // Hashcat --limit parameter for distributed cracking
+	FileSource     string         `json:"file_source,omitempty" db:"file_source"`         // Where the agent got its files, e.g. "hashfile=cache;wordlist=local"
+	HashFileSize   int64          `json:"hash_file_size,omitempty" db:"-"`                // Expected hash file size (computed, sent to agents)
+	HashFileSHA256 string         `json:"hash_file_sha256,omitempty" db:"-"`              // Expected hash file checksum (computed, sent to agents)
+	WordlistSize   int64          `json:"wordlist_size,omitempty" db:"-"`                 // Expected wordlist size (computed, sent to agents)
+	WordlistSHA256 string         `json:"wordlist_sha256,omitempty" db:"-"`               // Expected wordlist checksum (computed, sent to agents)
+	AgentArgs      []string       `json:"agent_args,omitempty" db:"-"`                    // The agent's default hashcat options (computed, sent to agents)
+	Checkpoint     *JobCheckpoint `json:"checkpoint,omitempty" db:"-"`                    // Where an interrupted job resumes (computed, sent to agents)
+	Progress       float64        `json:"progress" db:"progress"`
+	Speed          int64          `json:"speed" db:"speed"` // Hash rate dalam H/s
+	ETA            *time.Time     `json:"eta" db:"eta"`     // Estimated time of completion
+	Result         string         `json:"result" db:"result"`
+	TotalWords     int64          `json:"total_words" db:"total_words"`         // Total dictionary words for this job
+	ProcessedWords int64          `json:"processed_words" db:"processed_words"` // Words that have been processed
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	StartedAt      *time.Time     `json:"started_at" db:"started_at"`
+	CompletedAt    *time.Time     `json:"completed_at" db:"completed_at"`
+	ArchivedAt     *time.Time     `json:"archived_at,omitempty" db:"archived_at"` // Set by the retention worker, hidden from job lists
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`   // Soft delete, purged after the retention period
+	// Compute used, accumulated from the agent's progress reports
+	DeviceSeconds float64 `json:"device_seconds" db:"device_seconds"` // Run time multiplied by the devices it ran on
+	EnergyWh      float64 `json:"energy_wh" db:"energy_wh"`           // Reported or estimated power draw over the run time
+	// Logged-in user who created the job, whose quota it counts against
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	// Until when the agent holds the job; it is requeued unless the agent
+	// renews the lease in time
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty" db:"lease_expires_at"`
+	// Predicted run time, set on the job detail while the job hasn't started
+	Estimate *JobEstimate `json:"estimate,omitempty" db:"-"`
+	// Labels such as "client-x" or "retest", see NormalizeTags
+	Tags []string `json:"tags,omitempty" db:"tags"`
+	// Limits on one run, enforced by the agent and the server's watchdog;
+	// 0 means no limit, see job_timeout.go
+	MaxRuntimeMinutes   int `json:"max_runtime_minutes" db:"max_runtime_minutes"`
+	StallTimeoutMinutes int `json:"stall_timeout_minutes" db:"stall_timeout_minutes"`
+	// Share of the job's keyspace done, see NormalizeProgress
+	NormalizedProgress float64 `json:"normalized_progress" db:"normalized_progress"`
+	// hashcat's latest status: restore point, rejected candidates and the
+	// speed of each device
+	Stats *JobRuntimeStats `json:"stats,omitempty" db:"runtime_stats"`
+	// Tenant the job belongs to, nil outside of tenants
+	TenantID *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"`
+	// When the job expires unfinished, nil for none; see job_deadline.go
+	Deadline *time.Time `json:"deadline,omitempty" db:"deadline"`
+	// Stage of its job group's budget the job spends, see job_budget.go
+	BudgetStage string `json:"budget_stage,omitempty" db:"budget_stage"`
+}
+
+// JobEvent records one status change of a job
+type JobEvent struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	JobID      uuid.UUID `json:"job_id" db:"job_id"`
+	FromStatus string    `json:"from_status,omitempty" db:"from_status"` // Empty for the creation event
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Actor      string    `json:"actor" db:"actor"` // user:<name>, agent, api or system
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
+// JobOutput is the tail of hashcat's console output for one job, reported
+// by the agent so a failed run can be diagnosed without access to the agent
+type JobOutput struct {
+	JobID     uuid.UUID  `json:"job_id" db:"job_id"`
+	AgentID   *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`
+	ExitCode  *int       `json:"exit_code,omitempty" db:"exit_code"` // Nil when hashcat didn't start
+	Stdout    string     `json:"stdout" db:"stdout"`
+	Stderr    string     `json:"stderr" db:"stderr"`
+	Truncated bool       `json:"truncated" db:"truncated"` // Older output was dropped
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// JobEstimate predicts how long a job runs on the agents online now. Time
+// spent waiting in the queue is not included.
+type JobEstimate struct {
+	Keyspace        int64           `json:"keyspace"`                // Candidates the attack tries, 0 if unknown
+	Speed           int64           `json:"speed"`                   // H/s of the agents that would run the job
+	DurationSeconds float64         `json:"duration_seconds"`        // 0 when the keyspace or speed is unknown
+	EstimatedEnd    *time.Time      `json:"estimated_end,omitempty"` // When the job would finish if started now
+	Distributed     bool            `json:"distributed"`             // Split across all agents, else run by the fastest
+	Agents          []AgentEstimate `json:"agents"`                  // Fastest first
+	Note            string          `json:"note,omitempty"`          // Why the duration is missing or a lower bound
+}
+
+// AgentEstimate is the speed one agent is expected to reach on a job
+type AgentEstimate struct {
+	AgentID         uuid.UUID `json:"agent_id"`
+	Name            string    `json:"name"`
+	Speed           int64     `json:"speed"`
+	SpeedSource     string    `json:"speed_source"`     // One of the SpeedSource constants
+	DurationSeconds float64   `json:"duration_seconds"` // Time to run the whole keyspace alone
+}
+
+// Where an agent's expected speed comes from
+const (
+	SpeedSourceHashMode          = "hash_mode"           // Best speed on earlier jobs of the hash mode
+	SpeedSourceHashModeBenchmark = "hash_mode_benchmark" // A benchmark of the hash mode, see AgentBenchmark
+	SpeedSourceBenchmark         = "benchmark"           // The agent's startup benchmark, of another hash mode
+)
+
 // HashFile represents uploaded hash files
 type HashFile struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	OrigName  string    `json:"orig_name" db:"orig_name"`
-	Path      string    `json:"path" db:"path"`
-	Size      int64     `json:"size" db:"size"`
-	Type      string    `json:"type" db:"type"` // hccapx, hccap, hash
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	OrigName  string     `json:"orig_name" db:"orig_name"`
+	Path      string     `json:"path" db:"path"`
+	Size      int64      `json:"size" db:"size"`
+	Type      string     `json:"type" db:"type"`               // hccapx, hccap, hash
+	Source    string     `json:"source,omitempty" db:"source"` // Where the hashes come from, e.g. wifi or ad; see NormalizeHashFileSource
+	SHA256    string     `json:"sha256,omitempty" db:"sha256"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	Duplicate bool       `json:"duplicate,omitempty" db:"-"` // Set when an upload matched an existing file
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	TenantID  *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 }
 
 // Wordlist represents a wordlist file
 type Wordlist struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	OrigName  string     `json:"orig_name" db:"orig_name"`
+	Path      string     `json:"path" db:"path"`
+	Size      int64      `json:"size" db:"size"`
+	WordCount *int64     `json:"word_count,omitempty" db:"word_count"`
+	SHA256    string     `json:"sha256,omitempty" db:"sha256"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	Duplicate bool       `json:"duplicate,omitempty" db:"-"`     // Set when an upload matched an existing file
+	Dynamic   bool       `json:"dynamic,omitempty" db:"dynamic"` // Loopback wordlist of cracked passwords, grows as jobs crack
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	TenantID  *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CharsetFile is an uploaded hashcat custom charset file (.hcchr)
+type CharsetFile struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
 	OrigName  string    `json:"orig_name" db:"orig_name"`
 	Path      string    `json:"path" db:"path"`
 	Size      int64     `json:"size" db:"size"`
-	WordCount *int64    `json:"word_count,omitempty" db:"word_count"`
+	SHA256    string    `json:"sha256" db:"sha256"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// Project groups the jobs, hash files and wordlists of one engagement
+type Project struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProjectMember is a user who can see a project
+type ProjectMember struct {
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"-"` // Joined from users
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateProjectRequest represents the request to create a project
+type CreateProjectRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	MemberIDs   []string `json:"member_ids,omitempty"` // Initial members (user IDs)
+}
+
+// UpdateProjectRequest represents the request to update a project
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ProjectMemberRequest adds a user to a project
+type ProjectMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
 // JobStatus represents different job statuses
 type JobStatus struct {
 	JobID     uuid.UUID `json:"job_id"`
@@ -96,8 +415,64 @@ type CreateJobRequest struct {
 	AgentIDs   []string `json:"agent_ids,omitempty"`   // Multiple agent assignment for distributed jobs
 	Rules      string   `json:"rules,omitempty"`       // Hashcat rules atau password hasil
 	TotalWords int64    `json:"total_words,omitempty"` // Total dictionary words
+	// Right-hand wordlist of combinator attacks (attack_mode 1), which try
+	// every word of wordlist_id joined with every word of this one
+	Wordlist2ID string `json:"wordlist2_id,omitempty"`
+	// Custom charsets for ?1..?4 in brute-force masks: inline, e.g. "?l?d",
+	// or the UUID of an uploaded charset file
+	CustomCharset1 string `json:"custom_charset1,omitempty"`
+	CustomCharset2 string `json:"custom_charset2,omitempty"`
+	CustomCharset3 string `json:"custom_charset3,omitempty"`
+	CustomCharset4 string `json:"custom_charset4,omitempty"`
+	GroupID        string `json:"group_id,omitempty"` // Attach the job to an existing job group
+	// Run on the online agents of this agent group instead of AgentID/AgentIDs
+	AgentGroupID string `json:"agent_group_id,omitempty"`
+	// Hashcat tuning options added to the command line, e.g. ["-O", "-n", "64"]
+	ExtraArgs []string `json:"extra_args,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// Cracking engine, hashcat (default) or john. John the Ripper jobs name
+	// the hash format in john_format, e.g. "raw-md5", instead of hash_type.
+	Engine     string `json:"engine,omitempty"`
+	JohnFormat string `json:"john_format,omitempty"`
+	// Stop a run that takes longer than this, or whose progress and speed
+	// don't change for this long. Unset takes the server default, 0 turns
+	// the limit off.
+	MaxRuntimeMinutes   *int `json:"max_runtime_minutes,omitempty"`
+	StallTimeoutMinutes *int `json:"stall_timeout_minutes,omitempty"`
+	// Stop the job at this time, e.g. the end of the engagement, keeping
+	// what it cracked so far
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Stage of its job group's budget the job spends, the attack name for
+	// jobs queued by a playbook with a budget
+	BudgetStage string `json:"budget_stage,omitempty"`
+	// Taken from the project_id query parameter, which the router checks
+	// against the caller's project memberships
+	ProjectID string `json:"-"`
+}
+
+// RetryJobRequest optionally moves a retried job to a different agent
+type RetryJobRequest struct {
+	AgentID string `json:"agent_id,omitempty"`
 }
 
+// JobFilter selects a page of jobs. Zero values mean "no filter".
+type JobFilter struct {
+	Status      string
+	AgentID     *uuid.UUID
+	ProjectID   *uuid.UUID
+	HashType    *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Tags        []string // Jobs having all of these tags, case-insensitive
+	SortBy      string   // One of JobSortColumns, default created_at
+	SortDesc    bool
+	Limit       int
+	Offset      int
+}
+
+// JobSortColumns are the columns jobs can be sorted by
+var JobSortColumns = []string{"created_at", "updated_at", "name", "status", "progress", "speed", "hash_type"}
+
 // EnrichedJob extends Job with readable names for frontend display
 type EnrichedJob struct {
 	Job
@@ -106,6 +481,47 @@ type EnrichedJob struct {
 	HashFileName string `json:"hash_file_name,omitempty"`
 }
 
+// JobGroup links the sub-jobs created when one job is split across agents
+type JobGroup struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// Compute the group's jobs may spend, set by playbooks
+	Budget *PipelineBudget `json:"budget,omitempty" db:"budget"`
+}
+
+// JobGroupAgentStatus is one sub-job's share of a job group
+type JobGroupAgentStatus struct {
+	JobID     uuid.UUID  `json:"job_id"`
+	JobName   string     `json:"job_name"`
+	AgentID   *uuid.UUID `json:"agent_id"`
+	AgentName string     `json:"agent_name"`
+	Status    string     `json:"status"`
+	Progress  float64    `json:"progress"`
+	Speed     int64      `json:"speed"`
+	ETA       *time.Time `json:"eta"`
+	Keyspace  int64      `json:"keyspace"` // Words assigned to this sub-job, 0 if unknown
+
+	NormalizedProgress float64 `json:"normalized_progress"`
+}
+
+// JobGroupStatus is the combined view of a job group
+type JobGroupStatus struct {
+	JobGroup
+	Status        string                `json:"status"`
+	Progress      float64               `json:"progress"` // Weighted by each sub-job's keyspace
+	Speed         int64                 `json:"speed"`    // Combined speed of running sub-jobs
+	ETA           *time.Time            `json:"eta"`      // ETA of the slowest unfinished sub-job
+	TotalJobs     int                   `json:"total_jobs"`
+	CompletedJobs int                   `json:"completed_jobs"`
+	Agents        []JobGroupAgentStatus `json:"agents"`
+
+	// Weighted like Progress, from each sub-job's normalized progress
+	NormalizedProgress float64 `json:"normalized_progress"`
+	// Spending against the group's budget, if it has one
+	BudgetUsage *BudgetReport `json:"budget_usage,omitempty"`
+}
+
 // AgentPerformance represents agent performance metrics
 type AgentPerformance struct {
 	AgentID      uuid.UUID `json:"agent_id"`
@@ -125,9 +541,14 @@ type DistributedJobRequest struct {
 	HashFileID      string   `json:"hash_file_id" binding:"required"`
 	WordlistID      string   `json:"wordlist_id" binding:"required"`
 	Rules           string   `json:"rules,omitempty"`
-	AutoDistribute  bool     `json:"auto_distribute"`     // Whether to auto-distribute to all agents
-	AgentIDs        []string `json:"agent_ids,omitempty"` // Specific agents to use (if not auto-distribute)
-	CreateMasterJob bool     `json:"create_master_job"`   // Whether to create a master job for coordination
+	AutoDistribute  bool     `json:"auto_distribute"`          // Whether to auto-distribute to all agents
+	AgentIDs        []string `json:"agent_ids,omitempty"`      // Specific agents to use (if not auto-distribute)
+	AgentGroupID    string   `json:"agent_group_id,omitempty"` // Only use agents of this agent group
+	CreateMasterJob bool     `json:"create_master_job"`        // Whether to create a master job for coordination
+	// Limits on each sub-job, as for CreateJobRequest
+	MaxRuntimeMinutes   *int       `json:"max_runtime_minutes,omitempty"`
+	StallTimeoutMinutes *int       `json:"stall_timeout_minutes,omitempty"`
+	Deadline            *time.Time `json:"deadline,omitempty"`
 }
 
 // WordlistSegment represents a segment of wordlist for distribution
@@ -142,6 +563,7 @@ type WordlistSegment struct {
 // DistributedJobResult represents the result of distributed job creation
 type DistributedJobResult struct {
 	MasterJobID      uuid.UUID          `json:"master_job_id"`
+	GroupID          uuid.UUID          `json:"group_id"`
 	SubJobs          []Job              `json:"sub_jobs"`
 	AgentAssignments []AgentPerformance `json:"agent_assignments"`
 	TotalWords       int64              `json:"total_words"`
@@ -159,6 +581,179 @@ type CreateAgentRequest struct {
 	Status       string `json:"status,omitempty"`
 }
 
+// DeviceStatus is one compute device's entry in hashcat's status output
+type DeviceStatus struct {
+	ID          int    `json:"device_id"`
+	Name        string `json:"device_name"`
+	Type        string `json:"device_type"`    // CPU, GPU
+	Speed       int64  `json:"speed"`          // H/s
+	Temperature *int   `json:"temp,omitempty"` // Celsius, nil when hashcat can't read it
+	Utilization int    `json:"util"`           // Percent
+}
+
+// JobRuntimeStats is the structured hashcat status (--status-json) an agent
+// reports while a job runs
+type JobRuntimeStats struct {
+	HashcatStatus   int            `json:"hashcat_status"` // hashcat status code, 3 = running
+	ProgressDone    int64          `json:"progress_done"`
+	ProgressTotal   int64          `json:"progress_total"`
+	RestorePoint    int64          `json:"restore_point"`
+	Rejected        int64          `json:"rejected"`
+	RecoveredHashes int64          `json:"recovered_hashes"`
+	TotalHashes     int64          `json:"total_hashes"`
+	Devices         []DeviceStatus `json:"devices"`
+}
+
+// AgentCacheEntry describes one artifact in an agent's download cache
+type AgentCacheEntry struct {
+	Kind     string    `json:"kind"` // wordlist, hash_file
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// AgentCacheReport is the cache status an agent sends with its heartbeat
+type AgentCacheReport struct {
+	UsedBytes  int64             `json:"used_bytes"`
+	LimitBytes int64             `json:"limit_bytes"` // 0 means unlimited
+	Entries    []AgentCacheEntry `json:"entries"`
+	ReportedAt time.Time         `json:"reported_at"`
+}
+
+// AgentFile is a file an agent holds in its local upload directory, as
+// reported by the agent's periodic file scan
+type AgentFile struct {
+	AgentID    uuid.UUID `json:"agent_id"`
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	Type       string    `json:"type"` // wordlist, hash_file or unknown
+	MD5        string    `json:"md5,omitempty"`
+	ModTime    time.Time `json:"mod_time"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// SearchResult is one hit of the global search
+type SearchResult struct {
+	Type  string    `json:"type"` // job, agent, wordlist or hash_file
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Match string    `json:"match,omitempty"` // Matching text besides the title, e.g. a job's cracked result
+}
+
+// ClusterStats is the aggregate view of the cluster for dashboards and reports
+type ClusterStats struct {
+	Agents         AgentStats          `json:"agents"`
+	JobsByStatus   map[string]int      `json:"jobs_by_status"`
+	CracksLast24h  int                 `json:"cracks_last_24h"`
+	TopWordlists   []WordlistUsage     `json:"top_wordlists"`
+	TimeToCrack    []HashModeCrackTime `json:"avg_time_to_crack"`  // Per hash mode, over all cracked jobs
+	KeyspacePerDay []DailyKeyspace     `json:"keyspace_per_day"`   // Oldest day first
+	GeneratedAt    time.Time           `json:"generated_at"`       // Stats are cached, this tells how fresh they are
+	Database       *DatabaseStats      `json:"database,omitempty"` // Live, not cached
+}
+
+// DatabaseStats shows whether the database keeps up with the writes. Rising
+// busy errors or pool waits mean writers are queuing for the lock.
+type DatabaseStats struct {
+	OpenConnections int   `json:"open_connections"`
+	InUse           int   `json:"in_use"`
+	Idle            int   `json:"idle"`
+	WaitCount       int64 `json:"wait_count"`       // Queries that waited for a free connection
+	WaitDurationMs  int64 `json:"wait_duration_ms"` // Total time spent waiting for one
+	BusyErrors      int64 `json:"busy_errors"`      // Statements that gave up after the busy timeout
+	LockedErrors    int64 `json:"locked_errors"`
+	QueuedWrites    int   `json:"queued_writes"`   // Waiting for the next batch
+	WrittenBatched  int64 `json:"written_batched"` // Queued writes committed so far
+	Coalesced       int64 `json:"coalesced"`       // Queued writes replaced by newer ones before being written
+	Batches         int64 `json:"batches"`
+	FailedBatches   int64 `json:"failed_batches"`
+}
+
+// DatabaseStatsSource reports live database statistics
+type DatabaseStatsSource interface {
+	DatabaseStats() DatabaseStats
+}
+
+// AgentStats counts agents and their combined benchmark speed
+type AgentStats struct {
+	Total      int   `json:"total"`
+	Active     int   `json:"active"`      // online or busy
+	TotalSpeed int64 `json:"total_speed"` // H/s of the active agents
+}
+
+// WordlistUsage is how many jobs used a wordlist. A distributed job counts
+// once, not once per sub-job.
+type WordlistUsage struct {
+	WordlistID *uuid.UUID `json:"wordlist_id,omitempty"` // Nil for jobs that only name the file
+	Name       string     `json:"name"`
+	Jobs       int        `json:"jobs"`
+}
+
+// HashModeCrackTime is the average run time of the cracked jobs of a hash mode
+type HashModeCrackTime struct {
+	HashType   int     `json:"hash_type"`
+	Cracked    int     `json:"cracked"`
+	AvgSeconds float64 `json:"avg_seconds"`
+}
+
+// DailyKeyspace is the number of words processed by jobs finished on a day
+type DailyKeyspace struct {
+	Date      string `json:"date"`      // YYYY-MM-DD in UTC
+	Processed int64  `json:"processed"` // Keyspace (word limit or wordlist word count) times progress
+	Jobs      int    `json:"jobs"`
+}
+
+// AttackSuggestions are the attacks worth running next in a project, based
+// on the passwords it cracked so far
+type AttackSuggestions struct {
+	ProjectID   uuid.UUID          `json:"project_id"`
+	Cracked     int                `json:"cracked"` // Passwords the mask statistics are based on
+	Masks       []MaskSuggestion   `json:"masks"`   // Most frequent first
+	Rules       []RuleSuggestion   `json:"rules"`   // Highest success rate first
+	Attacks     []AttackSuggestion `json:"attacks"` // Job parameters, best first
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// MaskSuggestion is the structure shared by some of the cracked passwords
+type MaskSuggestion struct {
+	Mask     string  `json:"mask"`
+	Count    int     `json:"count"`
+	Share    float64 `json:"share"`    // Fraction of the cracked passwords
+	Keyspace int64   `json:"keyspace"` // Candidates a brute-force attack on the mask tries
+}
+
+// RuleSuggestion is how often the finished jobs of a rule file cracked
+// their hash. A distributed job counts once, not once per sub-job.
+type RuleSuggestion struct {
+	Rules       string  `json:"rules"` // Rule file UUID
+	Jobs        int     `json:"jobs"`
+	Cracked     int     `json:"cracked"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// AttackSuggestion holds the attack fields of a CreateJobRequest, so a
+// suggestion can be queued by adding a name and a hash file
+type AttackSuggestion struct {
+	AttackMode int        `json:"attack_mode"`
+	Wordlist   string     `json:"wordlist"` // Mask of brute-force attacks
+	WordlistID *uuid.UUID `json:"wordlist_id,omitempty"`
+	Rules      string     `json:"rules,omitempty"`
+	Reason     string     `json:"reason"`
+}
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key header so that retries of it can be answered the same way
+type IdempotencyRecord struct {
+	Key         string    `json:"key" db:"key"`
+	RequestHash string    `json:"request_hash" db:"request_hash"` // Detects a key reused for a different request
+	StatusCode  int       `json:"status_code" db:"status_code"`   // 0 while the first request is still in progress
+	Response    []byte    `json:"response" db:"response"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
 // DuplicateAgentError represents an error when trying to create an agent that already exists
 type DuplicateAgentError struct {
 	Name      string
@@ -194,22 +789,39 @@ type User struct {
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 	LastLogin *time.Time `json:"last_login,omitempty" db:"last_login"`
+	TenantID  *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"` // Nil for cluster users
 }
 
 // LoginRequest represents the request to login
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Users with two-factor authentication send a current TOTP code or one
+	// of their recovery codes
+	TOTPCode     string `json:"totp_code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
 }
 
 // LoginResponse represents the response after successful login
 type LoginResponse struct {
-	Token     string    `json:"token"`
+	Token     string    `json:"token"` // Short-lived access token
 	User      User      `json:"user"`
 	ExpiresAt time.Time `json:"expires_at"`
+	// RefreshToken gets new tokens from /auth/refresh until the session
+	// expires or is revoked. Each works once.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// TwoFactorSetupRequired tells a user the policy requires two-factor
+	// authentication of that they haven't set up yet; until they do, the
+	// session only reaches /auth
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+	// TwoFactorRequired tells a user with two-factor authentication who
+	// logged in through single sign-on to confirm a code at /auth/2fa/verify;
+	// until they do, the session only reaches /auth
+	TwoFactorRequired bool `json:"two_factor_required,omitempty"`
 }
 
-// LogoutRequest represents the request to logout
+// LogoutRequest represents the request to logout. Token is the refresh
+// token, or an access token, of the session to end.
 type LogoutRequest struct {
 	Token string `json:"token" binding:"required"`
 }
@@ -233,10 +845,13 @@ type UpdateUserRequest struct {
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TenantID  string `json:"tenant_id,omitempty"` // Empty for cluster users
+	TwoFactor bool   `json:"tfa,omitempty"`       // The login was confirmed with a second factor
+	SessionID string `json:"sid,omitempty"`       // The session the token belongs to
 	jwt.RegisteredClaims
 }
 
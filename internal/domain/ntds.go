@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NTLMHashType is the hashcat mode of NT hashes
+const NTLMHashType = 1000
+
+// EmptyNTHash is the NT hash of the empty password
+const EmptyNTHash = "31d6cfe0d16ae931b73c59d7e0c089c0"
+
+// BuiltinAdministratorRID is the RID of a domain's built-in Administrator,
+// which is always privileged
+const BuiltinAdministratorRID = 500
+
+// NTDSImport is an Active Directory NTDS dump, as printed by secretsdump,
+// imported as the hash file of its unique NT hashes and the accounts
+// using them
+type NTDSImport struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Name            string     `json:"name" db:"name"`
+	Domain          string     `json:"domain,omitempty" db:"domain"`             // Domain of accounts the dump doesn't qualify
+	HashFileID      *uuid.UUID `json:"hash_file_id,omitempty" db:"hash_file_id"` // The NT hashes, to crack as mode 1000
+	Accounts        int        `json:"accounts" db:"accounts"`
+	UniqueHashes    int        `json:"unique_hashes" db:"unique_hashes"`
+	MachineAccounts int        `json:"machine_accounts" db:"machine_accounts"` // Computer accounts left out, their passwords are random
+	HistoryEntries  int        `json:"history_entries" db:"history_entries"`   // Password history entries left out
+	SkippedLines    int        `json:"skipped_lines" db:"skipped_lines"`       // Lines that aren't accounts
+	ProjectID       *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	CreatedBy       *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NTDSAccount is a user account of an NTDS import and its NT hash. The
+// password itself isn't kept, only whether and when it was cracked.
+type NTDSAccount struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	ImportID   uuid.UUID  `json:"import_id" db:"import_id"`
+	Domain     string     `json:"domain,omitempty" db:"domain"`
+	Username   string     `json:"username" db:"username"`
+	RID        int64      `json:"rid" db:"rid"`
+	NTHash     string     `json:"nt_hash" db:"nt_hash"`
+	Enabled    *bool      `json:"enabled,omitempty" db:"enabled"` // Nil when the dump has no account status
+	Privileged bool       `json:"privileged" db:"privileged"`     // Domain admins and the like
+	Cracked    bool       `json:"cracked" db:"cracked"`
+	CrackedAt  *time.Time `json:"cracked_at,omitempty" db:"cracked_at"`
+}
+
+// NTDSAccountFilter narrows the accounts of an import
+type NTDSAccountFilter struct {
+	Cracked    *bool
+	Privileged *bool
+	Limit      int
+	Offset     int
+}
+
+// ImportNTDSRequest describes an uploaded NTDS dump
+type ImportNTDSRequest struct {
+	Name       string     // The uploaded file's name when empty
+	Domain     string     // Domain of accounts the dump doesn't qualify
+	Privileged []string   // Usernames of privileged accounts, such as the domain admins
+	ProjectID  *uuid.UUID // Project the import and its hash file go in
+}
+
+// SetNTDSPrivilegedRequest replaces which accounts of an import are
+// privileged. The built-in Administrator always is.
+type SetNTDSPrivilegedRequest struct {
+	Usernames []string `json:"usernames"`
+}
+
+// NTDSCoverage is how many of a set of accounts are cracked
+type NTDSCoverage struct {
+	Accounts int     `json:"accounts"`
+	Cracked  int     `json:"cracked"`
+	Percent  float64 `json:"percent"`
+}
+
+// NTDSReport joins the cracked results of an import back to its accounts
+type NTDSReport struct {
+	ImportID           uuid.UUID    `json:"import_id"`
+	Name               string       `json:"name"`
+	All                NTDSCoverage `json:"all"`
+	Enabled            NTDSCoverage `json:"enabled"`
+	Privileged         NTDSCoverage `json:"privileged"`
+	UniqueHashes       int          `json:"unique_hashes"`
+	CrackedHashes      int          `json:"cracked_hashes"`
+	SharedHashAccounts int          `json:"shared_hash_accounts"` // Accounts whose hash another account has too, i.e. the same password
+	EmptyPasswords     int          `json:"empty_passwords"`
+	CrackedPrivileged  []string     `json:"cracked_privileged"` // Usernames of the privileged accounts cracked
+	GeneratedAt        time.Time    `json:"generated_at"`
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Character classes of passwords, as password policies and credential
+// statistics name them
+const (
+	PasswordClassLower   = "lower"
+	PasswordClassUpper   = "upper"
+	PasswordClassDigit   = "digit"
+	PasswordClassSpecial = "special" // Printable ASCII that isn't a letter or digit
+	PasswordClassOther   = "other"   // Anything else, such as accented letters
+)
+
+// Rules of a password policy a password can break
+const (
+	PolicyRuleMinLength       = "min_length"
+	PolicyRuleMinClasses      = "min_classes"
+	PolicyRuleRequiredClasses = "required_classes"
+	PolicyRuleDictionaryWord  = "dictionary_word"
+	PolicyRuleUsername        = "username"
+)
+
+// PasswordPolicy is what cracked passwords are checked against. Zero values
+// turn a rule off.
+type PasswordPolicy struct {
+	MinLength       int      `json:"min_length"`
+	MinClasses      int      `json:"min_classes"`      // Of lower, upper, digit and special; other counts as special
+	RequiredClasses []string `json:"required_classes"` // Classes every password must have
+	// CheckDictionary refuses passwords built on a dictionary word, after
+	// undoing leetspeak: a built-in list of common words, the labels of the
+	// account's domain and the words of DictionaryWordlistID
+	CheckDictionary      bool       `json:"check_dictionary"`
+	DictionaryWordlistID *uuid.UUID `json:"dictionary_wordlist_id,omitempty"`
+	MinWordLength        int        `json:"min_word_length"` // Shorter dictionary words are ignored
+	CheckUsername        bool       `json:"check_username"`  // Refuses passwords containing the username or a part of it
+}
+
+// ComplianceReport is how the cracked passwords of a project measure up to
+// a password policy. It names accounts but no passwords, so it can go into
+// deliverables as is.
+type ComplianceReport struct {
+	ProjectID    *uuid.UUID            `json:"project_id,omitempty"`
+	Policy       PasswordPolicy        `json:"policy"`
+	Credentials  int                   `json:"credentials"` // Distinct account and password pairs checked
+	Compliant    int                   `json:"compliant"`
+	Percent      float64               `json:"percent"`    // Compliant share, with one decimal
+	Violations   []PolicyViolation     `json:"violations"` // Per rule, most broken first
+	NonCompliant []NonCompliantAccount `json:"non_compliant"`
+	GeneratedAt  time.Time             `json:"generated_at"`
+}
+
+// PolicyViolation is how many credentials break a rule
+type PolicyViolation struct {
+	Rule    string  `json:"rule"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// NonCompliantAccount is an account whose cracked password breaks rules
+type NonCompliantAccount struct {
+	Account string   `json:"account"` // DOMAIN\username, or the hash when there is no username
+	Source  string   `json:"source"`  // Hash file the password was cracked from
+	Length  int      `json:"length"`
+	Rules   []string `json:"rules"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectArchiveVersion is the archive format the server writes and reads
+const ProjectArchiveVersion = 1
+
+// ProjectArchiveManifest is the name of the archive entry holding the
+// ProjectArchive, the first entry of the zip file
+const ProjectArchiveManifest = "manifest.json"
+
+// ProjectArchive is everything about a project that moves with it to
+// another server: its jobs with their history and results, the cracked
+// credentials and the metadata of its files. The contents of the files
+// are optional further entries of the archive. Members are left out, as
+// the users of the servers differ.
+type ProjectArchive struct {
+	Version     int            `json:"version"`
+	ExportedAt  time.Time      `json:"exported_at"`
+	Project     Project        `json:"project"`
+	HashFiles   []ArchivedFile `json:"hash_files"`
+	Wordlists   []ArchivedFile `json:"wordlists"`
+	JobGroups   []JobGroup     `json:"job_groups"`
+	Jobs        []Job          `json:"jobs"`
+	JobEvents   []JobEvent     `json:"job_events"`
+	JobComments []JobComment   `json:"job_comments"`
+	Credentials []Credential   `json:"credentials"`
+}
+
+// ArchivedFile is a hash file or wordlist of an archive. Files of other
+// projects aren't included, shared files the project's jobs use are.
+type ArchivedFile struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"` // Original file name
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	Shared    bool      `json:"shared,omitempty"` // Belongs to no project
+	Type      string    `json:"type,omitempty"`   // Hash file type
+	Entry     string    `json:"entry,omitempty"`  // Archive entry of the content, empty when not included
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectImportResult is what importing an archive created
+type ProjectImportResult struct {
+	Project     Project `json:"project"`
+	Jobs        int     `json:"jobs"`
+	Credentials int     `json:"credentials"`
+	HashFiles   int     `json:"hash_files"` // Stored from the archive
+	Wordlists   int     `json:"wordlists"`  // Stored from the archive
+	ReusedFiles int     `json:"reused_files"`
+	// Files neither in the archive nor on this server; the jobs and
+	// credentials using them keep their names only
+	MissingFiles []string `json:"missing_files,omitempty"`
+	// Unfinished jobs, which are imported paused
+	PausedJobs int `json:"paused_jobs"`
+}
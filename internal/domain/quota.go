@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Who a quota applies to
+const (
+	QuotaScopeUser    = "user"
+	QuotaScopeProject = "project"
+	QuotaScopeTenant  = "tenant"
+)
+
+// Resources a quota limits
+const (
+	QuotaRunningJobs = "running_jobs"
+	QuotaDeviceHours = "device_hours"
+	QuotaStorage     = "storage"
+)
+
+// Quota limits what the jobs and uploads of one user, project or tenant may use.
+// A zero limit means no limit.
+type Quota struct {
+	Scope                  string    `json:"scope" db:"scope"`
+	SubjectID              uuid.UUID `json:"subject_id" db:"subject_id"`                                 // User, project or tenant ID
+	MaxRunningJobs         int       `json:"max_running_jobs" db:"max_running_jobs"`                     // Unfinished jobs at once, each sub-job of a distributed job counts
+	MaxDeviceHoursPerMonth float64   `json:"max_device_hours_per_month" db:"max_device_hours_per_month"` // Device-hours of the jobs started this calendar month (UTC)
+	MaxStorageBytes        int64     `json:"max_storage_bytes" db:"max_storage_bytes"`                   // Hash files and wordlists uploaded
+	UpdatedAt              time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// QuotaUsage is what a user, project or tenant uses of the resources quotas limit
+type QuotaUsage struct {
+	RunningJobs          int     `json:"running_jobs"`
+	DeviceHoursThisMonth float64 `json:"device_hours_this_month"`
+	StorageBytes         int64   `json:"storage_bytes"`
+}
+
+// QuotaStatus is a quota with the current usage
+type QuotaStatus struct {
+	Quota
+	Usage QuotaUsage `json:"usage"`
+}
+
+// SetQuotaRequest replaces the limits of a quota
+type SetQuotaRequest struct {
+	MaxRunningJobs         int     `json:"max_running_jobs" binding:"min=0"`
+	MaxDeviceHoursPerMonth float64 `json:"max_device_hours_per_month" binding:"min=0"`
+	MaxStorageBytes        int64   `json:"max_storage_bytes" binding:"min=0"`
+}
+
+// QuotaChecker refuses new jobs and uploads of users and projects at their
+// quota, with a QuotaExceededError. The user is taken from the context.
+type QuotaChecker interface {
+	CheckJobQuota(ctx context.Context, projectID *uuid.UUID, jobs int) error
+	CheckStorageQuota(ctx context.Context, projectID *uuid.UUID, size int64) error
+}
+
+// QuotaMonthStart is the start of the calendar month (UTC) device-hour
+// quotas count from
+func QuotaMonthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+type userIDKey struct{}
+
+// WithUserID attaches the logged-in user making a request
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user set by WithUserID, or nil for
+// requests without a login, such as agents and API scripts
+func UserIDFromContext(ctx context.Context) *uuid.UUID {
+	if userID, ok := ctx.Value(userIDKey{}).(uuid.UUID); ok {
+		return &userID
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxHashFileSourceLength caps the origin tag of a hash file
+const MaxHashFileSourceLength = 32
+
+// HashFileSourceActiveDirectory tags the NT hashes of NTDS imports
+const HashFileSourceActiveDirectory = "ad"
+
+// NormalizeHashFileSource lower-cases the tag naming where a hash file's
+// hashes come from, such as "wifi", "ad" or "web-app". It holds letters,
+// digits, '-' and '_'; an empty tag clears it.
+func NormalizeHashFileSource(source string) (string, error) {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if len(source) > MaxHashFileSourceLength {
+		return "", &ValidationError{Field: "source", Message: fmt.Sprintf("must be at most %d characters", MaxHashFileSourceLength)}
+	}
+	for _, r := range source {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return "", &ValidationError{Field: "source", Message: "may only contain letters, digits, '-' and '_'"}
+		}
+	}
+	return source, nil
+}
+
+// SetHashFileSourceRequest tags a hash file with its origin
+type SetHashFileSourceRequest struct {
+	Source string `json:"source"`
+}
+
+// AttackOutcome is how the finished dictionary attacks with one wordlist and
+// rule file fared against the hash files of one origin. A distributed job
+// counts as one attack.
+type AttackOutcome struct {
+	WordlistID uuid.UUID
+	Wordlist   string
+	Rules      string
+	Source     string // Origin of the attacked hash files, empty when untagged
+	Attacks    int
+	Cracked    int // Attacks that cracked their hash
+	Cracks     int // Hashes they cracked in all
+}
+
+// WordlistRecommendations rank wordlist and rule combinations for a hash
+// mode and hash file origin by how well they cracked before
+type WordlistRecommendations struct {
+	HashType        int                      `json:"hash_type"`
+	Source          string                   `json:"source,omitempty"`
+	Recommendations []WordlistRecommendation `json:"recommendations"` // Best first
+	GeneratedAt     time.Time                `json:"generated_at"`
+}
+
+// WordlistRecommendation is one wordlist, with or without rules, and the
+// history it is ranked by
+type WordlistRecommendation struct {
+	WordlistID uuid.UUID `json:"wordlist_id"`
+	Wordlist   string    `json:"wordlist"`
+	Rules      string    `json:"rules,omitempty"`
+	// Finished attacks on the hash mode and the share that cracked
+	Attacks   int     `json:"attacks"`
+	Cracked   int     `json:"cracked"`
+	Cracks    int     `json:"cracks"`
+	CrackRate float64 `json:"crack_rate"`
+	// The same for hash files of the requested origin
+	SourceAttacks int     `json:"source_attacks"`
+	SourceCracked int     `json:"source_cracked"`
+	Score         float64 `json:"score"` // What the ranking goes by, see recommendation_usecase.go
+	Reason        string  `json:"reason"`
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -43,11 +44,43 @@ type AgentRepository interface {
 	CreateAgent(ctx context.Context, agent *Agent) error // bisa panggil Create
 	UpdateAgent(ctx context.Context, agent *Agent) error // bisa panggil Update
 	GetByNameAndIPForStartup(ctx context.Context, name, ip string, port int) (*Agent, error)
+	CreateGroup(ctx context.Context, group *AgentGroup) error
+	GetGroupByID(ctx context.Context, id uuid.UUID) (*AgentGroup, error)
+	GetAllGroups(ctx context.Context) ([]AgentGroup, error)
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+	AddGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error
+	RemoveGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error
+	GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]Agent, error)
+	ReplaceFiles(ctx context.Context, agentID uuid.UUID, files []AgentFile) error
+	GetFiles(ctx context.Context, agentID uuid.UUID) ([]AgentFile, error)
+	UpdateHeartbeat(ctx context.Context, id uuid.UUID, heartbeat *AgentHeartbeat) error
+	// UpdateLastSeenBatch writes buffered heartbeats of many agents in one transaction
+	UpdateLastSeenBatch(ctx context.Context, seen map[uuid.UUID]AgentSeen) error
+	// AppendLogs stores log lines, keeping only the newest keep lines of the agent
+	AppendLogs(ctx context.Context, agentID uuid.UUID, lines []AgentLogLine, keep int) error
+	// GetLogs returns up to limit lines after afterID, or the newest limit lines when afterID is 0
+	GetLogs(ctx context.Context, agentID uuid.UUID, afterID int64, limit int) ([]AgentLogLine, error)
+	// GetHashcatArgs returns the hashcat options the agent adds to every job
+	GetHashcatArgs(ctx context.Context, agentID uuid.UUID) ([]string, error)
+	UpdateHashcatArgs(ctx context.Context, agentID uuid.UUID, args []string) error
+	// GetSettings returns the agent's server-managed settings
+	GetSettings(ctx context.Context, agentID uuid.UUID) (*AgentSettings, error)
+	UpdateSettings(ctx context.Context, agentID uuid.UUID, settings *AgentSettings) error
+	// GetStale returns the agents last seen before the cutoff, archived ones
+	// included, oldest first
+	GetStale(ctx context.Context, seenBefore time.Time) ([]StaleAgent, error)
+	// Archive hides an agent from the agent list and drops its benchmark,
+	// heartbeat, file inventory and logs. Its next contact brings it back.
+	Archive(ctx context.Context, id uuid.UUID) error
+	// SetTenant moves an agent into a tenant, or shares it with every
+	// tenant when tenantID is nil
+	SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error
 }
 
 // JobRepository defines the interface for job data operations
 type JobRepository interface {
 	Create(ctx context.Context, job *Job) error
+	CreateBatch(ctx context.Context, group *JobGroup, jobs []*Job) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Job, error)
 	GetAll(ctx context.Context) ([]Job, error)
 	GetByStatus(ctx context.Context, status string) ([]Job, error)
@@ -57,6 +90,53 @@ type JobRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
 	UpdateProgress(ctx context.Context, id uuid.UUID, progress float64, speed int64) error
+	// UpdateProgressBatch writes buffered progress reports in one
+	// transaction, skipping jobs that are no longer running or paused
+	UpdateProgressBatch(ctx context.Context, progress map[uuid.UUID]JobProgress) error
+	GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]Job, error)
+	CreateGroup(ctx context.Context, group *JobGroup) error
+	GetGroupByID(ctx context.Context, id uuid.UUID) (*JobGroup, error)
+	// GetBudgetedGroups returns the job groups with a budget that still
+	// have unfinished jobs
+	GetBudgetedGroups(ctx context.Context) ([]JobGroup, error)
+	List(ctx context.Context, filter JobFilter) ([]Job, int, error)
+	GetArchived(ctx context.Context) ([]Job, error)
+	Archive(ctx context.Context, completedBefore time.Time) (int64, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	CreateEvent(ctx context.Context, event *JobEvent) error
+	GetEvents(ctx context.Context, jobID uuid.UUID) ([]JobEvent, error)
+	UpdateTags(ctx context.Context, jobID uuid.UUID, tags []string) error
+	CreateComment(ctx context.Context, comment *JobComment) error
+	// GetComments returns a job's comments, oldest first
+	GetComments(ctx context.Context, jobID uuid.UUID) ([]JobComment, error)
+	DeleteComment(ctx context.Context, jobID, commentID uuid.UUID) error
+	// SaveOutput stores a job's hashcat output, replacing any earlier one
+	SaveOutput(ctx context.Context, output *JobOutput) error
+	GetOutput(ctx context.Context, jobID uuid.UUID) (*JobOutput, error)
+	// SaveCheckpoint stores a job's restore file, replacing any earlier one
+	SaveCheckpoint(ctx context.Context, checkpoint *JobCheckpoint) error
+	GetCheckpoint(ctx context.Context, jobID uuid.UUID) (*JobCheckpoint, error)
+	// CreateCracks stores the cracks of a job, skipping hashes it already
+	// cracked, and returns the new ones
+	CreateCracks(ctx context.Context, cracks []JobCrack) ([]JobCrack, error)
+	// GetCracks returns a job's cracks, oldest first
+	GetCracks(ctx context.Context, jobID uuid.UUID) ([]JobCrack, error)
+	// AcquireLease leases a pending or assigned job to the agent it is
+	// assigned to; false if it no longer is
+	AcquireLease(ctx context.Context, jobID, agentID uuid.UUID, until time.Time) (bool, error)
+	// RenewLeases extends the leases on the assigned and running jobs of agents
+	RenewLeases(ctx context.Context, agentIDs []uuid.UUID, until time.Time) error
+	// GetExpiredLeases returns assigned and running jobs whose lease ran out
+	GetExpiredLeases(ctx context.Context, before time.Time) ([]Job, error)
+	// ExpireLease takes a job back from its agent into status to, unless
+	// the job left status from or its lease was renewed past before
+	// meanwhile; false if so
+	ExpireLease(ctx context.Context, jobID uuid.UUID, from, to string, before time.Time) (bool, error)
+	// GetPastDeadline returns unfinished jobs whose deadline is before the given time
+	GetPastDeadline(ctx context.Context, before time.Time) ([]Job, error)
+	// GetAgentSpeedsByHashType returns the best speed each agent reached on jobs of a hash mode
+	GetAgentSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error)
 }
 
 // JobUsecase defines the interface for job business logic operations
@@ -81,7 +161,61 @@ type JobUsecase interface {
 type HashFileRepository interface {
 	Create(ctx context.Context, hashFile *HashFile) error
 	GetByID(ctx context.Context, id uuid.UUID) (*HashFile, error)
+	GetBySHA256(ctx context.Context, sum string) (*HashFile, error)
 	GetAll(ctx context.Context) ([]HashFile, error)
+	GetByProject(ctx context.Context, projectID uuid.UUID) ([]HashFile, error)
+	// SetSource tags a hash file with its origin, see NormalizeHashFileSource
+	SetSource(ctx context.Context, id uuid.UUID, source string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// HashFileOperationRepository stores merge, split and diff operations
+type HashFileOperationRepository interface {
+	Create(ctx context.Context, operation *HashFileOperation) error
+	Update(ctx context.Context, operation *HashFileOperation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*HashFileOperation, error)
+	// GetRecent lists the newest operations first
+	GetRecent(ctx context.Context, limit int) ([]HashFileOperation, error)
+	// FailUnfinished marks pending and running operations failed with
+	// message, returning how many there were
+	FailUnfinished(ctx context.Context, message string) (int, error)
+}
+
+// NTDSRepository stores NTDS imports and the accounts they hold
+type NTDSRepository interface {
+	// Create stores an import together with its accounts
+	Create(ctx context.Context, ntdsImport *NTDSImport, accounts []NTDSAccount) error
+	GetByID(ctx context.Context, id uuid.UUID) (*NTDSImport, error)
+	// GetAll lists the imports of a project, or every import when projectID
+	// is nil, newest first
+	GetAll(ctx context.Context, projectID *uuid.UUID) ([]NTDSImport, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetAccounts returns a page of an import's accounts and how many match
+	// the filter in all
+	GetAccounts(ctx context.Context, importID uuid.UUID, filter NTDSAccountFilter) ([]NTDSAccount, int, error)
+	// MarkCracked marks the accounts using any of ntHashes cracked,
+	// returning how many weren't before
+	MarkCracked(ctx context.Context, importID uuid.UUID, ntHashes []string, at time.Time) (int, error)
+	// SetPrivileged makes the accounts named usernames and the built-in
+	// administrator, and only those, privileged, returning how many there are
+	SetPrivileged(ctx context.Context, importID uuid.UUID, usernames []string) (int, error)
+}
+
+// CredentialRepository stores cracked credentials
+type CredentialRepository interface {
+	// Record stores credentials, skipping those already recorded for the
+	// same hash file, account and hash, and returns how many were new
+	Record(ctx context.Context, credentials []Credential) (int, error)
+	// GetAll returns a page of the credentials matching the filter, newest
+	// first, and how many match in all
+	GetAll(ctx context.Context, filter CredentialFilter) ([]Credential, int, error)
+}
+
+// CharsetRepository defines the interface for custom charset file data operations
+type CharsetRepository interface {
+	Create(ctx context.Context, charset *CharsetFile) error
+	GetByID(ctx context.Context, id uuid.UUID) (*CharsetFile, error)
+	GetAll(ctx context.Context) ([]CharsetFile, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -89,14 +223,193 @@ type HashFileRepository interface {
 type WordlistRepository interface {
 	Create(ctx context.Context, wordlist *Wordlist) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Wordlist, error)
+	GetBySHA256(ctx context.Context, sum string) (*Wordlist, error)
 	GetAll(ctx context.Context) ([]Wordlist, error)
+	GetByProject(ctx context.Context, projectID uuid.UUID) ([]Wordlist, error)
+	// GetLoopback returns the loopback wordlist of a project, or of jobs
+	// without one when projectID is nil. It returns nil if there is none yet.
+	GetLoopback(ctx context.Context, projectID *uuid.UUID) (*Wordlist, error)
+	// UpdateContent saves the size, word count and checksum of a wordlist
+	// whose file changed
+	UpdateContent(ctx context.Context, wordlist *Wordlist) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// WordlistSourceRepository stores the remote wordlists the server fetches
+type WordlistSourceRepository interface {
+	Create(ctx context.Context, source *WordlistSource) error
+	Update(ctx context.Context, source *WordlistSource) error
+	GetByID(ctx context.Context, id uuid.UUID) (*WordlistSource, error)
+	// GetAll lists the sources oldest first
+	GetAll(ctx context.Context) ([]WordlistSource, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// FailFetching marks sources left fetching failed with message,
+	// returning how many there were
+	FailFetching(ctx context.Context, message string) (int, error)
+}
+
+// TenantRepository defines the interface for tenant data operations
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *Tenant) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Tenant, error)
+	GetByName(ctx context.Context, name string) (*Tenant, error)
+	GetAll(ctx context.Context) ([]Tenant, error)
+	Update(ctx context.Context, tenant *Tenant) error
+	// Delete removes a tenant. Rows still in it, such as deleted jobs, keep
+	// its ID and so stay hidden from other tenants.
 	Delete(ctx context.Context, id uuid.UUID) error
+	// CountResources counts the users, agents, jobs, hash files and
+	// wordlists still in a tenant
+	CountResources(ctx context.Context, id uuid.UUID) (int, error)
+	// GetStats counts the tenant's resources; Usage is left to the caller
+	GetStats(ctx context.Context, id uuid.UUID) (*TenantStats, error)
+}
+
+// ProjectRepository defines the interface for project data operations
+type ProjectRepository interface {
+	Create(ctx context.Context, project *Project) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Project, error)
+	GetByName(ctx context.Context, name string) (*Project, error)
+	GetAll(ctx context.Context) ([]Project, error)
+	// GetByMember returns the projects a user is a member of
+	GetByMember(ctx context.Context, userID uuid.UUID) ([]Project, error)
+	Update(ctx context.Context, project *Project) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CountResources counts the jobs, hash files and wordlists still in a project
+	CountResources(ctx context.Context, id uuid.UUID) (int, error)
+	AddMember(ctx context.Context, projectID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error
+	GetMembers(ctx context.Context, projectID uuid.UUID) ([]ProjectMember, error)
+	IsMember(ctx context.Context, projectID, userID uuid.UUID) (bool, error)
+}
+
+// ProjectArchiveRepository reads and writes a project with everything in
+// it, to move it to another server
+type ProjectArchiveRepository interface {
+	// Export returns the project with its jobs, archived ones included, their
+	// groups, events and comments, its credentials and the metadata of the
+	// hash files and wordlists of the project or used by its jobs
+	Export(ctx context.Context, projectID uuid.UUID) (*ProjectArchive, error)
+	// Import stores the project, job groups, jobs, events, comments and
+	// credentials of an archive in one transaction, keeping their IDs and
+	// timestamps, and returns how many credentials were new. Files are
+	// stored by the caller.
+	Import(ctx context.Context, archive *ProjectArchive) (int, error)
 }
 
 // DistributedJobUsecase defines the interface for distributed job operations
 type DistributedJobUsecase interface {
 	CreateDistributedJobs(ctx context.Context, req *DistributedJobRequest) (*DistributedJobResult, error)
 	GetDistributedJobStatus(ctx context.Context, masterJobID uuid.UUID) (*DistributedJobResult, error)
+	// SetQuotaChecker makes job creation refuse jobs over a quota
+	SetQuotaChecker(checker QuotaChecker)
+	// SetMaintenanceCalendar leaves agents in a maintenance window out
+	SetMaintenanceCalendar(calendar MaintenanceCalendar)
+	// SetJobTimeouts sets the limits of sub-jobs whose request sets none
+	SetJobTimeouts(timeouts JobTimeouts)
+}
+
+// SearchRepository defines the interface for full-text search
+type SearchRepository interface {
+	// Search leaves out jobs whose cracked result is all that matches
+	// unless matchResults is set
+	Search(ctx context.Context, query string, limit int, matchResults bool) ([]SearchResult, error)
+}
+
+// StatsRepository computes the aggregates behind the cluster statistics.
+// Soft-deleted jobs are left out; archived jobs still count.
+type StatsRepository interface {
+	GetAgentStats(ctx context.Context) (*AgentStats, error)
+	CountJobsByStatus(ctx context.Context) (map[string]int, error)
+	CountCrackedSince(ctx context.Context, since time.Time) (int, error)
+	GetTopWordlists(ctx context.Context, limit int) ([]WordlistUsage, error)
+	GetAverageTimeToCrack(ctx context.Context) ([]HashModeCrackTime, error)
+	// GetKeyspacePerDay sums the words processed by jobs finished since the
+	// given time, per UTC day. Days without finished jobs are left out.
+	GetKeyspacePerDay(ctx context.Context, since time.Time) ([]DailyKeyspace, error)
+	// GetUsage sums the usage of jobs started between from and to, either
+	// of which may be nil, per job, project or agent (see CostGroupJob)
+	GetUsage(ctx context.Context, groupBy string, from, to *time.Time) ([]UsageTotal, error)
+	// GetAttackOutcomes sums the finished dictionary attacks on a hash mode
+	// per wordlist, rule file and origin of the hash file. Attacks with a
+	// deleted wordlist are left out.
+	GetAttackOutcomes(ctx context.Context, hashType int) ([]AttackOutcome, error)
+}
+
+// QuotaRepository stores quotas and measures what users and projects use
+type QuotaRepository interface {
+	// Get returns nil when the user, project or tenant has no quota
+	Get(ctx context.Context, scope string, subjectID uuid.UUID) (*Quota, error)
+	GetAll(ctx context.Context) ([]Quota, error)
+	Upsert(ctx context.Context, quota *Quota) error
+	Delete(ctx context.Context, scope string, subjectID uuid.UUID) error
+	// GetUsage counts the unfinished jobs, the device-hours of jobs started
+	// since monthStart and the upload storage of a user, project or tenant
+	GetUsage(ctx context.Context, scope string, subjectID uuid.UUID, monthStart time.Time) (*QuotaUsage, error)
+}
+
+// MaintenanceRepository stores the maintenance windows of the cluster calendar
+type MaintenanceRepository interface {
+	Create(ctx context.Context, window *MaintenanceWindow) error
+	GetByID(ctx context.Context, id uuid.UUID) (*MaintenanceWindow, error)
+	GetAll(ctx context.Context) ([]MaintenanceWindow, error)
+	Update(ctx context.Context, window *MaintenanceWindow) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// EnrollmentTokenRepository stores agent enrollment tokens
+type EnrollmentTokenRepository interface {
+	Create(ctx context.Context, token *EnrollmentToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*EnrollmentToken, error)
+	GetAll(ctx context.Context) ([]EnrollmentToken, error)
+	// Redeem marks the token used by the agent, unless it was used or
+	// expired at the given time, which return ErrEnrollmentTokenUsed and
+	// ErrEnrollmentTokenExpired
+	Redeem(ctx context.Context, id, agentID uuid.UUID, at time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// AgentNetworkRepository stores network rules and the audit log of the
+// requests they refused
+type AgentNetworkRepository interface {
+	CreateRule(ctx context.Context, rule *AgentNetworkRule) error
+	GetRules(ctx context.Context) ([]AgentNetworkRule, error)
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	// RecordDenial stores a refused request, keeping only the newest keep records
+	RecordDenial(ctx context.Context, denial *AgentAccessDenial, keep int) error
+	// GetDenials returns up to limit records, newest first
+	GetDenials(ctx context.Context, limit int) ([]AgentAccessDenial, error)
+}
+
+// ResultAccessRepository stores who may reveal cracked passwords and the
+// audit log of reveals
+type ResultAccessRepository interface {
+	// GrantReveal gives a user the reveal permission, replacing an earlier grant
+	GrantReveal(ctx context.Context, grant *ResultRevealGrant) error
+	RevokeReveal(ctx context.Context, userID uuid.UUID) error
+	HasRevealGrant(ctx context.Context, userID uuid.UUID) (bool, error)
+	GetRevealGrants(ctx context.Context) ([]ResultRevealGrant, error)
+	LogReveal(ctx context.Context, reveal *ResultReveal) error
+	// GetReveals lists reveals newest first, of one job when jobID is set
+	GetReveals(ctx context.Context, jobID *uuid.UUID, limit int) ([]ResultReveal, error)
+}
+
+// IdempotencyRepository stores responses of requests made with an Idempotency-Key
+type IdempotencyRepository interface {
+	// Reserve claims record.Key for a new request. When the key is already
+	// taken it returns the existing record instead and stores nothing.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, statusCode int, response []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+// CloudInstanceRepository stores the instances started by the auto-scaler
+type CloudInstanceRepository interface {
+	Create(ctx context.Context, instance *CloudInstance) error
+	Update(ctx context.Context, instance *CloudInstance) error
+	// GetActive returns the instances that haven't been terminated
+	GetActive(ctx context.Context) ([]CloudInstance, error)
 }
 
 // UserRepository defines the interface for user data operations
@@ -109,6 +422,9 @@ type UserRepository interface {
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
+	// SetTenant moves a user into a tenant, or out of tenants when
+	// tenantID is nil
+	SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error
 }
 
 // AuthUsecase defines the interface for authentication business logic operations
@@ -123,4 +439,32 @@ type AuthUsecase interface {
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 	GetAllUsers(ctx context.Context) ([]User, error)
 	CheckUsernameExists(ctx context.Context, username string) (bool, error)
+	// SetSessionRefresher makes RefreshToken check with refresher, such as
+	// the single sign-on provider, before extending a session
+	SetSessionRefresher(refresher SessionRefresher)
+
+	// Sessions of a user, listed and revoked by the user or an admin
+	SessionStarter
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	// RevokeSessions revokes the user's sessions but keep, returning how many
+	RevokeSessions(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error)
+
+	// Two-factor authentication of the logged-in user
+	GetTwoFactorStatus(ctx context.Context, userID uuid.UUID) (*TwoFactorStatus, error)
+	EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactorEnrollment, error)
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*TwoFactorActivation, error)
+	// VerifyTwoFactor confirms a session that skipped the second factor with
+	// a code, replacing it with one that counts as two-factor
+	VerifyTwoFactor(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, code string) (*LoginResponse, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// ResetTwoFactor lets an admin remove the second factor of a user who
+	// lost it
+	ResetTwoFactor(ctx context.Context, userID uuid.UUID) error
+	GetTwoFactorPolicy(ctx context.Context) (*TwoFactorPolicy, error)
+	UpdateTwoFactorPolicy(ctx context.Context, policy *TwoFactorPolicy) (*TwoFactorPolicy, error)
+	// TwoFactorPending reports whether the session owes a second factor,
+	// because the user has one or the policy requires it
+	TwoFactorPending(ctx context.Context, claims *JWTClaims) (bool, error)
 }
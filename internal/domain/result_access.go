@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RedactedPassword stands in for a cracked password in redacted job results
+const RedactedPassword = "********"
+
+// jobResultSummaryPrefix starts the results of distributed jobs, which end
+// with " - " and the password
+const jobResultSummaryPrefix = "SUCCESS: Password found"
+
+// ErrRevealForbidden is returned when a user without the reveal permission
+// asks for a cracked password
+var ErrRevealForbidden = errors.New("not allowed to reveal cracked passwords")
+
+// ResultRevealGrant lets a non-admin user reveal cracked passwords.
+// Admins can always reveal them.
+type ResultRevealGrant struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	GrantedBy string    `json:"granted_by" db:"granted_by"` // Username of the admin who granted it
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ResultReveal is an audit record of a user reading a cracked password, or
+// of a user or agent reading a loopback wordlist. Users reading a loopback
+// wordlist leave JobID the nil UUID; for agents it is the job they read
+// it for.
+type ResultReveal struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	JobID      uuid.UUID  `json:"job_id" db:"job_id"`
+	WordlistID *uuid.UUID `json:"wordlist_id,omitempty" db:"wordlist_id"`
+	UserID     *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	Username   string     `json:"username" db:"username"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ResultViewer is the user asking to reveal a job's result
+type ResultViewer struct {
+	UserID    uuid.UUID
+	Username  string
+	Role      string
+	IPAddress string
+}
+
+// RevealedResult is a job's result with the password in the clear
+type RevealedResult struct {
+	JobID    uuid.UUID `json:"job_id"`
+	Result   string    `json:"result"`
+	Password string    `json:"password,omitempty"` // Empty when the result holds no password
+}
+
+// RedactResult masks the cracked password in a job result. Results without
+// a password, such as failure reasons, are returned unchanged.
+func RedactResult(result string) string {
+	if _, ok := CrackedPassword(result); ok {
+		return jobResultCrackedPrefix + RedactedPassword
+	}
+	if strings.HasPrefix(result, jobResultSummaryPrefix) {
+		if i := strings.Index(result, " - "); i >= 0 {
+			return result[:i+len(" - ")] + RedactedPassword
+		}
+	}
+	return result
+}
+
+// ResultPassword returns the password held by a job result, including the
+// summaries of distributed jobs
+func ResultPassword(result string) (string, bool) {
+	if password, ok := CrackedPassword(result); ok {
+		return password, true
+	}
+	if strings.HasPrefix(result, jobResultSummaryPrefix) {
+		if _, password, ok := strings.Cut(result, " - "); ok && password != "" {
+			return password, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login. Its refresh token gets new short-lived access
+// tokens until the session expires or is revoked; access tokens carry the
+// session's ID so that revoking it locks them out too.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	TokenHash  string     `json:"-"`          // SHA-256 of the current refresh token's secret
+	TwoFactor  bool       `json:"two_factor"` // The login was confirmed with a second factor
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"` // Last refresh
+	ExpiresAt  time.Time  `json:"expires_at"`   // Moves forward on every refresh
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"` // The session of the request listing it
+}
+
+// Active reports whether the session can still be used
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshRequest exchanges a refresh token for new tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionRepository stores sessions
+type SessionRepository interface {
+	// Create stores a new session, dropping the user's sessions that
+	// expired or were revoked
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Session, error)
+	// GetActiveByUser lists the user's sessions that can still be used,
+	// newest first
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	// Rotate replaces the refresh token hash of a session that still has
+	// oldHash, reporting false when it doesn't, e.g. because the token was
+	// already used
+	Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// RevokeByUser revokes the user's sessions but keep, returning how many
+	RevokeByUser(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error)
+}
+
+// SessionStarter starts sessions for users who logged in
+type SessionStarter interface {
+	StartSession(ctx context.Context, user *User, twoFactor bool) (*LoginResponse, error)
+}
+
+type clientKey struct{}
+
+// ClientInfo is where a login comes from, as shown in the session list
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// WithClientInfo attaches where a login request comes from
+func WithClientInfo(ctx context.Context, client ClientInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientInfoFromContext returns the client set by WithClientInfo
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	client, _ := ctx.Value(clientKey{}).(ClientInfo)
+	return client
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SSOClaims is what the identity provider says about a user in a verified
+// ID token
+type SSOClaims struct {
+	Subject       string   // Stable ID of the user at the provider
+	Email         string   // May be empty when the provider doesn't share it
+	EmailVerified bool     // Whether the provider vouches for Email
+	Username      string   // preferred_username, or the configured claim
+	Roles         []string // Values of the configured role claim
+}
+
+// SSOLogin is the outcome of a code exchange or refresh with the provider
+type SSOLogin struct {
+	Claims       *SSOClaims // Nil when a refresh response has no ID token
+	RefreshToken string     // Empty when the provider didn't issue one
+}
+
+// SSOProvider runs the OpenID Connect authorization code flow against one
+// identity provider
+type SSOProvider interface {
+	// AuthCodeURL is where the browser is sent to log in
+	AuthCodeURL(ctx context.Context, state, nonce string) (string, error)
+	// Exchange trades the code of the callback for a verified login. The
+	// ID token must carry nonce.
+	Exchange(ctx context.Context, code, nonce string) (*SSOLogin, error)
+	// Refresh checks with the provider that the session is still valid
+	Refresh(ctx context.Context, refreshToken string) (*SSOLogin, error)
+}
+
+// SSOIdentity links a user to their identity at the provider
+type SSOIdentity struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Subject      string    `json:"subject"`
+	RefreshToken string    `json:"-"` // Sealed like job results at rest
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SSOIdentityRepository stores the users that logged in with single sign-on
+type SSOIdentityRepository interface {
+	GetBySubject(ctx context.Context, subject string) (*SSOIdentity, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*SSOIdentity, error)
+	// Save creates or replaces the identity of identity.UserID
+	Save(ctx context.Context, identity *SSOIdentity) error
+}
+
+// SessionRefresher checks that a user's session may be extended when their
+// token is refreshed, and updates the user with what changed since
+type SessionRefresher interface {
+	RefreshSession(ctx context.Context, user *User) error
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// FileEncryptor encrypts uploaded files at rest. Files written before
+// encryption was turned on stay readable through Decrypt.
+type FileEncryptor interface {
+	// Encrypt returns a writer that encrypts into w; closing it finishes
+	// the file but doesn't close w
+	Encrypt(w io.Writer) (io.WriteCloser, error)
+	// Decrypt returns a reader of the plaintext of a stored file
+	Decrypt(r io.Reader) (io.Reader, error)
+	// EncryptFile encrypts a stored file in place, reporting false when it
+	// was already encrypted
+	EncryptFile(path string) (bool, error)
+}
+
+// UploadScanner scans uploaded files for malware before they are stored
+type UploadScanner interface {
+	// Scan returns an *InfectedFileError when content is infected
+	Scan(ctx context.Context, content io.Reader) error
+}
+
+// InfectedFileError is returned when an upload scanner finds malware
+type InfectedFileError struct {
+	Signature string
+}
+
+func (e *InfectedFileError) Error() string {
+	return fmt.Sprintf("file is infected: %s", e.Signature)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant is an isolated namespace of users, agents, jobs and files. A
+// logged-in user of a tenant only sees the tenant's own resources; users
+// without a tenant administer the whole cluster.
+type Tenant struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTenantRequest represents the request to create a tenant
+type CreateTenantRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateTenantRequest represents the request to update a tenant
+type UpdateTenantRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description *string `json:"description,omitempty"`
+}
+
+// TenantStats counts the resources of a tenant
+type TenantStats struct {
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	Users         int        `json:"users"`
+	Agents        int        `json:"agents"`
+	Jobs          int        `json:"jobs"`
+	RunningJobs   int        `json:"running_jobs"`
+	CompletedJobs int        `json:"completed_jobs"`
+	HashFiles     int        `json:"hash_files"`
+	Wordlists     int        `json:"wordlists"`
+	Usage         QuotaUsage `json:"usage"` // What the tenant's quota counts
+}
+
+type tenantIDKey struct{}
+
+type sharedScopeKey struct{}
+
+// WithTenantID attaches the tenant of the logged-in user making a request.
+// Repositories then only return and change the tenant's resources.
+func WithTenantID(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantIDKey{}, tenantID)
+}
+
+// WithSharedScope limits an anonymous request to the resources outside of
+// tenants, so only a login reveals a tenant's jobs, files and agents
+func WithSharedScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sharedScopeKey{}, true)
+}
+
+// WithoutTenant lifts the tenant scope of a context, for work such as job
+// dispatch that looks across tenants on a tenant user's behalf
+func WithoutTenant(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, sharedScopeKey{}, false)
+	return context.WithValue(ctx, tenantIDKey{}, nil)
+}
+
+// InSharedScope reports whether ctx was limited by WithSharedScope
+func InSharedScope(ctx context.Context) bool {
+	shared, _ := ctx.Value(sharedScopeKey{}).(bool)
+	return shared
+}
+
+// TenantScoped reports whether ctx sees only some of the resources: those
+// of its tenant, or those outside of tenants
+func TenantScoped(ctx context.Context) bool {
+	return TenantIDFromContext(ctx) != nil || InSharedScope(ctx)
+}
+
+// TenantIDFromContext returns the tenant set by WithTenantID, or nil when
+// the request isn't scoped to a tenant
+func TenantIDFromContext(ctx context.Context) *uuid.UUID {
+	if tenantID, ok := ctx.Value(tenantIDKey{}).(uuid.UUID); ok {
+		return &tenantID
+	}
+	return nil
+}
+
+// ServesTenant reports whether the agent may run jobs of a tenant. Agents
+// without a tenant are shared and run jobs of every tenant; a tenant's
+// agents only run its own.
+func (a *Agent) ServesTenant(tenantID *uuid.UUID) bool {
+	if a.TenantID == nil {
+		return true
+	}
+	return tenantID != nil && *tenantID == *a.TenantID
+}
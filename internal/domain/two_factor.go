@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor is a user's TOTP second factor
+type TwoFactor struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Secret       string    `json:"-"`       // Base32 TOTP secret
+	Enabled      bool      `json:"enabled"` // False until a first code confirms the enrollment
+	LastUsedStep int64     `json:"-"`       // Time step of the last accepted code, which can't be used again
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TwoFactorPolicy decides who must log in with a second factor
+type TwoFactorPolicy struct {
+	// RequireForPrivileged requires it of admins and users with the reveal
+	// grant, who can download hash files and reveal cracked passwords
+	RequireForPrivileged bool      `json:"require_for_privileged"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// TwoFactorStatus is what a user sees of their own second factor
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+	Required          bool `json:"required"` // The policy requires it of the user
+}
+
+// TwoFactorEnrollment is what an authenticator app needs to generate codes
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// TwoFactorActivation answers a confirmed enrollment with the recovery
+// codes, shown only once, and a session that counts as two-factor
+type TwoFactorActivation struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorCodeRequest carries a current TOTP code or a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorRepository stores second factors and the policy
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+	// Save replaces the user's second factor
+	Save(ctx context.Context, twoFactor *TwoFactor) error
+	// Delete removes the user's second factor and recovery codes
+	Delete(ctx context.Context, userID uuid.UUID) error
+	// UseStep records that the code of step was used, reporting false when
+	// it or a later one already was
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	// ReplaceRecoveryCodes stores the hashes of new recovery codes,
+	// dropping the old ones
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// UseRecoveryCode removes the recovery code, reporting false when the
+	// user has no such code
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+	// GetPolicy returns the stored policy, or an empty one
+	GetPolicy(ctx context.Context) (*TwoFactorPolicy, error)
+	SavePolicy(ctx context.Context, policy *TwoFactorPolicy) error
+}
+
+// TwoFactorRequiredError is returned by a login that needs a second factor
+type TwoFactorRequiredError struct{}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor code required"
+}
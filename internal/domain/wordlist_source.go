@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a wordlist source
+const (
+	WordlistSourcePending  = "pending"  // Never fetched
+	WordlistSourceFetching = "fetching" // A fetch is running
+	WordlistSourceReady    = "ready"    // Its wordlist holds the last fetch
+	WordlistSourceFailed   = "failed"   // The last fetch failed, see Error
+)
+
+// WordlistSource is a wordlist the server downloads itself, e.g. a SecLists
+// list or a weakpass mirror, instead of an operator uploading it. Each fetch
+// that changed the download adds it as a wordlist; WordlistID is the newest.
+type WordlistSource struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Name          string     `json:"name" db:"name"`                               // Original name of the wordlists it adds
+	URL           string     `json:"url" db:"url"`                                 // http or https, optionally gzip or bzip2 compressed
+	SHA256        string     `json:"sha256,omitempty" db:"sha256"`                 // Expected checksum of the download, unchecked when empty
+	RefreshHours  int        `json:"refresh_hours" db:"refresh_hours"`             // How often it is fetched again, 0 only on demand
+	ProjectID     *uuid.UUID `json:"project_id,omitempty" db:"project_id"`         // Project its wordlists belong to
+	WordlistID    *uuid.UUID `json:"wordlist_id,omitempty" db:"wordlist_id"`       // Wordlist of the last successful fetch
+	Status        string     `json:"status" db:"status"`                           // pending, fetching, ready or failed
+	Error         string     `json:"error,omitempty" db:"error"`                   // Why the last fetch failed
+	FetchedSHA256 string     `json:"fetched_sha256,omitempty" db:"fetched_sha256"` // Checksum of the last download
+	FetchedAt     *time.Time `json:"fetched_at,omitempty" db:"fetched_at"`         // When the last fetch ended, successful or not
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Due reports whether a scheduled source should be fetched again
+func (s *WordlistSource) Due(now time.Time) bool {
+	if s.RefreshHours <= 0 || s.Status == WordlistSourceFetching {
+		return false
+	}
+	return s.FetchedAt == nil || now.Sub(*s.FetchedAt) >= time.Duration(s.RefreshHours)*time.Hour
+}
+
+// CreateWordlistSourceRequest registers a wordlist source. Name defaults to
+// the file name of the URL.
+type CreateWordlistSourceRequest struct {
+	Name         string     `json:"name"`
+	URL          string     `json:"url" binding:"required"`
+	SHA256       string     `json:"sha256"`
+	RefreshHours int        `json:"refresh_hours"`
+	ProjectID    *uuid.UUID `json:"project_id,omitempty"`
+	Fetch        bool       `json:"fetch"` // Fetch it right away
+}
+
+// UpdateWordlistSourceRequest changes the fields that are set, e.g. the
+// checksum after a list was republished
+type UpdateWordlistSourceRequest struct {
+	Name         *string `json:"name,omitempty"`
+	URL          *string `json:"url,omitempty"`
+	SHA256       *string `json:"sha256,omitempty"`
+	RefreshHours *int    `json:"refresh_hours,omitempty"`
+}
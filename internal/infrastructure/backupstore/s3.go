@@ -0,0 +1,270 @@
+package backupstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// unsignedPayload lets uploads stream instead of being hashed up front
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config holds the settings of an S3 bucket or an S3 compatible service
+// such as MinIO. Credentials fall back to the standard AWS_* variables.
+type S3Config struct {
+	Bucket          string `mapstructure:"bucket"`
+	Prefix          string `mapstructure:"prefix"` // Key prefix of the backups, e.g. "hashcat/"
+	Region          string `mapstructure:"region"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"`
+	Endpoint        string `mapstructure:"endpoint"` // Service URL, path-style addressing is used when set
+}
+
+type s3Store struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3Store keeps backups in an S3 bucket. Files are uploaded with a single
+// PUT, which S3 accepts up to 5 GB.
+func NewS3Store(config S3Config) (domain.BackupStore, error) {
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 backup target: bucket is required")
+	}
+	if config.Region == "" {
+		return nil, fmt.Errorf("s3 backup target: region is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 backup target: access_key_id and secret_access_key are required")
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	// No overall timeout, a database upload may take a while
+	return &s3Store{config: config, client: &http.Client{}}, nil
+}
+
+// bucketURL is the URL of the bucket, with a trailing slash
+func (s *s3Store) bucketURL() string {
+	if s.config.Endpoint != "" {
+		return s.config.Endpoint + "/" + s.config.Bucket + "/"
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", s.config.Bucket, s.config.Region)
+}
+
+func (s *s3Store) objectURL(key string) string {
+	return s.bucketURL() + s.config.Prefix + key
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// listBucketResult is the part of a ListObjectsV2 response List reads
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Store) List(ctx context.Context) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		params := url.Values{}
+		params.Set("list-type", "2")
+		params.Set("prefix", s.config.Prefix)
+		if token != "" {
+			params.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bucketURL(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.URL.RawQuery = canonicalQuery(params)
+		resp, err := s.send(req)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode S3 listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			keys = append(keys, strings.TrimPrefix(object.Key, s.config.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// send signs and sends req. Error statuses are returned as errors, a
+// missing object as a NotFoundError.
+func (s *s3Store) send(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 request failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && req.Method != http.MethodPut {
+		return nil, &domain.NotFoundError{Entity: "backup file"}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return nil, fmt.Errorf("S3 returned %d for %s %s: %s", resp.StatusCode, req.Method, req.URL.Path, strings.TrimSpace(string(body)))
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req. The
+// payload isn't part of the signature, TLS protects it in transit.
+func (s *s3Store) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	dateStamp := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if s.config.SessionToken != "" {
+		headers["x-amz-security-token"] = s.config.SessionToken
+		names = append(names, "x-amz-security-token")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes params sorted by name with spaces as %20, the way
+// Signature Version 4 expects the query string
+func canonicalQuery(params url.Values) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, value := range params[name] {
+			parts = append(parts, escape(name)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,29 @@
+// Package backupstore holds the targets backups are written to: a local
+// directory or an S3 bucket.
+package backupstore
+
+import (
+	"fmt"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// Config selects a backup target and holds the settings of every target
+type Config struct {
+	Target string   `mapstructure:"target"` // local or s3
+	Path   string   `mapstructure:"path"`   // Directory of the local target
+	S3     S3Config `mapstructure:"s3"`
+}
+
+// NewStore returns the store selected by cfg.Target
+func NewStore(cfg Config) (domain.BackupStore, error) {
+	switch strings.ToLower(cfg.Target) {
+	case "local", "":
+		return NewLocalStore(cfg.Path)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown backup target %q", cfg.Target)
+	}
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 )
@@ -75,6 +76,19 @@ func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// DeletePrefix removes every key that starts with prefix
+func (c *MemoryCache) DeletePrefix(ctx context.Context, prefix string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
+
 func (c *MemoryCache) Clear(ctx context.Context) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -106,6 +120,7 @@ type Cache interface {
 	Set(ctx context.Context, key string, value interface{}) error
 	Get(ctx context.Context, key string, dest interface{}) (bool, error)
 	Delete(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) error
 	Clear(ctx context.Context) error
 	Close() error
 }
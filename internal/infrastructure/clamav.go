@@ -0,0 +1,105 @@
+package infrastructure
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	defaultClamAVTimeout = 60 * time.Second
+	clamAVChunkSize      = 32 * 1024
+)
+
+// ClamAVScanner scans uploads with a clamd daemon over its INSTREAM
+// command. Address is "host:port", "tcp://host:port" or
+// "unix:///path/to/clamd.ctl".
+type ClamAVScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func NewClamAVScanner(address string, timeout time.Duration) *ClamAVScanner {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	if timeout <= 0 {
+		timeout = defaultClamAVTimeout
+	}
+	return &ClamAVScanner{network: network, address: address, timeout: timeout}
+}
+
+// Scan streams content to clamd. Infected content returns a
+// *domain.InfectedFileError; anything else clamd can't vouch for, such as a
+// file over its StreamMaxLength, is an error too.
+func (s *ClamAVScanner) Scan(ctx context.Context, content io.Reader) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+
+	// Chunks are sent length-prefixed, ending with an empty one
+	buf := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, readErr := content.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd closes the stream early when the file is over its limit;
+				// its reply says so
+				break
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read upload: %w", readErr)
+		}
+	}
+	conn.Write([]byte{0, 0, 0, 0})
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamAVReply turns "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR" into the scan's result
+func parseClamAVReply(reply string) error {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &domain.InfectedFileError{Signature: strings.TrimSuffix(result, " FOUND")}
+	default:
+		return fmt.Errorf("clamd: %s", result)
+	}
+}
@@ -0,0 +1,216 @@
+package cloud
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const ec2APIVersion = "2016-11-15"
+
+// AWSConfig holds the EC2 settings. Instances are spot instances unless
+// OnDemand is set. Credentials fall back to the standard AWS_* variables.
+type AWSConfig struct {
+	Region           string   `mapstructure:"region"`
+	AccessKeyID      string   `mapstructure:"access_key_id"`
+	SecretAccessKey  string   `mapstructure:"secret_access_key"`
+	SessionToken     string   `mapstructure:"session_token"`
+	ImageID          string   `mapstructure:"image_id"`      // AMI with GPU drivers
+	InstanceType     string   `mapstructure:"instance_type"` // e.g. "g4dn.xlarge"
+	KeyName          string   `mapstructure:"key_name"`      // Optional SSH key pair
+	SubnetID         string   `mapstructure:"subnet_id"`     // Optional, default VPC otherwise
+	SecurityGroupIDs []string `mapstructure:"security_group_ids"`
+	OnDemand         bool     `mapstructure:"on_demand"`      // Use on-demand instead of spot instances
+	SpotMaxPrice     string   `mapstructure:"spot_max_price"` // Optional, defaults to the on-demand price
+	Endpoint         string   `mapstructure:"endpoint"`       // API URL, defaults to https://ec2.<region>.amazonaws.com
+}
+
+type awsProvider struct {
+	config AWSConfig
+}
+
+func NewAWSProvider(config AWSConfig) (domain.CloudProvider, error) {
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+
+	if config.Region == "" {
+		return nil, fmt.Errorf("aws: region is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws: access_key_id and secret_access_key are required")
+	}
+	if config.ImageID == "" || config.InstanceType == "" {
+		return nil, fmt.Errorf("aws: image_id and instance_type are required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://ec2.%s.amazonaws.com/", config.Region)
+	}
+
+	return &awsProvider{config: config}, nil
+}
+
+func (p *awsProvider) Name() string {
+	return "aws"
+}
+
+func (p *awsProvider) CreateInstance(ctx context.Context, spec domain.CloudInstanceSpec) (string, error) {
+	params := url.Values{}
+	params.Set("Action", "RunInstances")
+	params.Set("ImageId", p.config.ImageID)
+	params.Set("InstanceType", p.config.InstanceType)
+	params.Set("MinCount", "1")
+	params.Set("MaxCount", "1")
+	params.Set("UserData", base64.StdEncoding.EncodeToString([]byte(spec.UserData)))
+	params.Set("InstanceInitiatedShutdownBehavior", "terminate")
+	params.Set("TagSpecification.1.ResourceType", "instance")
+	params.Set("TagSpecification.1.Tag.1.Key", "Name")
+	params.Set("TagSpecification.1.Tag.1.Value", spec.Name)
+	params.Set("TagSpecification.1.Tag.2.Key", "managed-by")
+	params.Set("TagSpecification.1.Tag.2.Value", "hashcat-autoscaler")
+	if p.config.KeyName != "" {
+		params.Set("KeyName", p.config.KeyName)
+	}
+	if p.config.SubnetID != "" {
+		params.Set("SubnetId", p.config.SubnetID)
+	}
+	for i, id := range p.config.SecurityGroupIDs {
+		params.Set("SecurityGroupId."+strconv.Itoa(i+1), id)
+	}
+	if !p.config.OnDemand {
+		params.Set("InstanceMarketOptions.MarketType", "spot")
+		params.Set("InstanceMarketOptions.SpotOptions.SpotInstanceType", "one-time")
+		params.Set("InstanceMarketOptions.SpotOptions.InstanceInterruptionBehavior", "terminate")
+		if p.config.SpotMaxPrice != "" {
+			params.Set("InstanceMarketOptions.SpotOptions.MaxPrice", p.config.SpotMaxPrice)
+		}
+	}
+
+	body, err := p.call(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Instances []struct {
+			InstanceID string `xml:"instanceId"`
+		} `xml:"instancesSet>item"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode aws API response: %w", err)
+	}
+	if len(resp.Instances) == 0 || resp.Instances[0].InstanceID == "" {
+		return "", fmt.Errorf("aws: response has no instance ID")
+	}
+
+	return resp.Instances[0].InstanceID, nil
+}
+
+func (p *awsProvider) DeleteInstance(ctx context.Context, providerID string) error {
+	params := url.Values{}
+	params.Set("Action", "TerminateInstances")
+	params.Set("InstanceId.1", providerID)
+
+	_, err := p.call(ctx, params)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.Body, "InvalidInstanceID.NotFound") {
+		return nil // Already gone
+	}
+	return err
+}
+
+// call sends a signed EC2 Query API request
+func (p *awsProvider) call(ctx context.Context, params url.Values) ([]byte, error) {
+	params.Set("Version", ec2APIVersion)
+	payload := params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	p.sign(req, payload, time.Now())
+
+	return send(p.Name(), req)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+func (p *awsProvider) sign(req *http.Request, payload string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	dateStamp := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if p.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.config.SessionToken)
+	}
+
+	headers := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+	}
+	names := []string{"content-type", "host", "x-amz-date"}
+	if p.config.SessionToken != "" {
+		headers["x-amz-security-token"] = p.config.SessionToken
+		names = append(names, "x-amz-security-token")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := dateStamp + "/" + p.config.Region + "/ec2/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+p.config.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, p.config.Region)
+	key = hmacSHA256(key, "ec2")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,178 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	gcpEndpoint      = "https://compute.googleapis.com/compute/v1"
+	gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCPConfig holds the Compute Engine settings. Instances are spot VMs unless
+// OnDemand is set. Without an access token the server must run on Compute
+// Engine, where the token of the VM's service account is used.
+type GCPConfig struct {
+	Project          string `mapstructure:"project"`
+	Zone             string `mapstructure:"zone"`         // e.g. "us-central1-a"
+	MachineType      string `mapstructure:"machine_type"` // e.g. "n1-standard-8"
+	Image            string `mapstructure:"image"`        // e.g. "projects/ubuntu-os-cloud/global/images/family/ubuntu-2204-lts"
+	DiskSizeGB       int    `mapstructure:"disk_size_gb"`
+	AcceleratorType  string `mapstructure:"accelerator_type"` // Optional, e.g. "nvidia-tesla-t4"
+	AcceleratorCount int    `mapstructure:"accelerator_count"`
+	OnDemand         bool   `mapstructure:"on_demand"`    // Use standard instead of spot VMs
+	AccessToken      string `mapstructure:"access_token"` // Optional OAuth token
+	Endpoint         string `mapstructure:"endpoint"`     // API base URL, defaults to the public API
+}
+
+type gcpProvider struct {
+	config GCPConfig
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewGCPProvider(config GCPConfig) (domain.CloudProvider, error) {
+	if config.Project == "" || config.Zone == "" {
+		return nil, fmt.Errorf("gcp: project and zone are required")
+	}
+	if config.MachineType == "" || config.Image == "" {
+		return nil, fmt.Errorf("gcp: machine_type and image are required")
+	}
+	if config.DiskSizeGB == 0 {
+		config.DiskSizeGB = 50
+	}
+	if config.AcceleratorType != "" && config.AcceleratorCount == 0 {
+		config.AcceleratorCount = 1
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = gcpEndpoint
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	return &gcpProvider{config: config}, nil
+}
+
+func (p *gcpProvider) Name() string {
+	return "gcp"
+}
+
+func (p *gcpProvider) CreateInstance(ctx context.Context, spec domain.CloudInstanceSpec) (string, error) {
+	zone := "zones/" + p.config.Zone
+	scheduling := map[string]interface{}{
+		// GPU instances can't live-migrate
+		"onHostMaintenance": "TERMINATE",
+		"automaticRestart":  false,
+	}
+	if !p.config.OnDemand {
+		scheduling["provisioningModel"] = "SPOT"
+		scheduling["instanceTerminationAction"] = "DELETE"
+	}
+
+	body := map[string]interface{}{
+		"name":        spec.Name,
+		"machineType": zone + "/machineTypes/" + p.config.MachineType,
+		"labels":      map[string]string{"managed-by": "hashcat-autoscaler"},
+		"disks": []map[string]interface{}{{
+			"boot":       true,
+			"autoDelete": true,
+			"initializeParams": map[string]interface{}{
+				"sourceImage": p.config.Image,
+				"diskSizeGb":  p.config.DiskSizeGB,
+			},
+		}},
+		"networkInterfaces": []map[string]interface{}{{
+			"network":       "global/networks/default",
+			"accessConfigs": []map[string]string{{"type": "ONE_TO_ONE_NAT", "name": "External NAT"}},
+		}},
+		"scheduling": scheduling,
+		"metadata": map[string]interface{}{
+			"items": []map[string]string{{"key": "startup-script", "value": spec.UserData}},
+		},
+	}
+	if p.config.AcceleratorType != "" {
+		body["guestAccelerators"] = []map[string]interface{}{{
+			"acceleratorType":  zone + "/acceleratorTypes/" + p.config.AcceleratorType,
+			"acceleratorCount": p.config.AcceleratorCount,
+		}}
+	}
+
+	req, err := newJSONRequest(ctx, http.MethodPost, p.instancesURL(), body)
+	if err != nil {
+		return "", err
+	}
+	if err := p.authorize(ctx, req); err != nil {
+		return "", err
+	}
+
+	// The API answers with an operation; the instance is known by its name
+	if err := do(p.Name(), req, nil); err != nil {
+		return "", err
+	}
+	return spec.Name, nil
+}
+
+func (p *gcpProvider) DeleteInstance(ctx context.Context, providerID string) error {
+	req, err := newJSONRequest(ctx, http.MethodDelete, p.instancesURL()+"/"+providerID, nil)
+	if err != nil {
+		return err
+	}
+	if err := p.authorize(ctx, req); err != nil {
+		return err
+	}
+
+	err = do(p.Name(), req, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil // Already gone
+	}
+	return err
+}
+
+func (p *gcpProvider) instancesURL() string {
+	return fmt.Sprintf("%s/projects/%s/zones/%s/instances", p.config.Endpoint, p.config.Project, p.config.Zone)
+}
+
+// authorize sets the bearer token, fetching one from the metadata server
+// when none is configured
+func (p *gcpProvider) authorize(ctx context.Context, req *http.Request) error {
+	if p.config.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.AccessToken)
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || time.Now().After(p.tokenExpiry) {
+		tokenReq, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, nil)
+		if err != nil {
+			return err
+		}
+		tokenReq.Header.Set("Metadata-Flavor", "Google")
+
+		var token struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := do("gcp metadata", tokenReq, &token); err != nil {
+			return fmt.Errorf("gcp: no access_token configured and no metadata server token: %w", err)
+		}
+
+		p.token = token.AccessToken
+		// Refresh a minute early so a request never goes out with an expired token
+		p.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return nil
+}
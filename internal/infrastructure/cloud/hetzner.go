@@ -0,0 +1,100 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const hetznerEndpoint = "https://api.hetzner.cloud/v1"
+
+// HetznerConfig holds the Hetzner Cloud settings. Hetzner has no spot
+// market, so instances are billed at the regular hourly price.
+type HetznerConfig struct {
+	Token      string   `mapstructure:"token"`
+	ServerType string   `mapstructure:"server_type"` // e.g. "gex44"
+	Image      string   `mapstructure:"image"`       // e.g. "ubuntu-22.04"
+	Location   string   `mapstructure:"location"`    // Optional, e.g. "fsn1"
+	SSHKeys    []string `mapstructure:"ssh_keys"`    // Optional SSH key names or IDs
+	Endpoint   string   `mapstructure:"endpoint"`    // API base URL, defaults to the public API
+}
+
+type hetznerProvider struct {
+	config HetznerConfig
+}
+
+func NewHetznerProvider(config HetznerConfig) (domain.CloudProvider, error) {
+	if config.Token == "" {
+		return nil, fmt.Errorf("hetzner: token is required")
+	}
+	if config.ServerType == "" || config.Image == "" {
+		return nil, fmt.Errorf("hetzner: server_type and image are required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = hetznerEndpoint
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	return &hetznerProvider{config: config}, nil
+}
+
+func (p *hetznerProvider) Name() string {
+	return "hetzner"
+}
+
+func (p *hetznerProvider) CreateInstance(ctx context.Context, spec domain.CloudInstanceSpec) (string, error) {
+	body := map[string]interface{}{
+		"name":               spec.Name,
+		"server_type":        p.config.ServerType,
+		"image":              p.config.Image,
+		"user_data":          spec.UserData,
+		"start_after_create": true,
+		"labels":             map[string]string{"managed-by": "hashcat-autoscaler"},
+	}
+	if p.config.Location != "" {
+		body["location"] = p.config.Location
+	}
+	if len(p.config.SSHKeys) > 0 {
+		body["ssh_keys"] = p.config.SSHKeys
+	}
+
+	req, err := newJSONRequest(ctx, http.MethodPost, p.config.Endpoint+"/servers", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.Token)
+
+	var resp struct {
+		Server struct {
+			ID int64 `json:"id"`
+		} `json:"server"`
+	}
+	if err := do(p.Name(), req, &resp); err != nil {
+		return "", err
+	}
+	if resp.Server.ID == 0 {
+		return "", fmt.Errorf("hetzner: response has no server ID")
+	}
+
+	return strconv.FormatInt(resp.Server.ID, 10), nil
+}
+
+func (p *hetznerProvider) DeleteInstance(ctx context.Context, providerID string) error {
+	req, err := newJSONRequest(ctx, http.MethodDelete, p.config.Endpoint+"/servers/"+providerID, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.Token)
+
+	err = do(p.Name(), req, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil // Already gone
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+// Package cloud holds the drivers the auto-scaler uses to start and delete
+// burst agent instances. The drivers talk to the provider REST APIs directly.
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// Config selects a provider and holds the settings of every driver
+type Config struct {
+	Provider string        `mapstructure:"provider"` // hetzner, aws or gcp
+	Hetzner  HetznerConfig `mapstructure:"hetzner"`
+	AWS      AWSConfig     `mapstructure:"aws"`
+	GCP      GCPConfig     `mapstructure:"gcp"`
+}
+
+// NewProvider returns the driver selected by cfg.Provider
+func NewProvider(cfg Config) (domain.CloudProvider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "hetzner":
+		return NewHetznerProvider(cfg.Hetzner)
+	case "aws":
+		return NewAWSProvider(cfg.AWS)
+	case "gcp":
+		return NewGCPProvider(cfg.GCP)
+	case "":
+		return nil, fmt.Errorf("no cloud provider configured")
+	default:
+		return nil, fmt.Errorf("unknown cloud provider %q", cfg.Provider)
+	}
+}
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// APIError is returned when a provider API answers with an error status
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API returned %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// send sends req and returns the response body, or an APIError when the
+// provider answers with an error status
+func send(provider string, req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s API request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s API response: %w", provider, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	return body, nil
+}
+
+// do sends req and decodes a JSON response into out when out isn't nil
+func do(provider string, req *http.Request, out interface{}) error {
+	body, err := send(provider, req)
+	if err != nil {
+		return err
+	}
+
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode %s API response: %w", provider, err)
+		}
+	}
+	return nil
+}
+
+// newJSONRequest builds a request with a JSON body
+func newJSONRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(data))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
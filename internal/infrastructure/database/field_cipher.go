@@ -0,0 +1,83 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks a column value encrypted by a FieldCipher. Values
+// without it were written before encryption was turned on and are read
+// as they are.
+const sealedPrefix = "enc:v1:"
+
+// ErrFieldCipherMissing is returned when reading an encrypted value while
+// no encryption key is configured
+var ErrFieldCipherMissing = errors.New("value is encrypted but no encryption key is configured")
+
+// FieldCipher encrypts sensitive columns, such as cracked passwords, with
+// AES-256-GCM under a key derived from the server's encryption key
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+func NewFieldCipher(key string) (*FieldCipher, error) {
+	if key == "" {
+		return nil, fmt.Errorf("encryption key is empty")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldCipher{aead: aead}, nil
+}
+
+// Seal encrypts value. Empty values stay empty so that "no result" can
+// still be told apart in queries.
+func (c *FieldCipher) Seal(value string) (string, error) {
+	if value == "" || IsSealed(value) {
+		return value, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value written by Seal and returns other values unchanged
+func (c *FieldCipher) Open(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value, is the encryption key right? %w", err)
+	}
+	return string(plain), nil
+}
+
+// IsSealed reports whether value was encrypted by a FieldCipher
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
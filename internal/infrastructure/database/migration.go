@@ -227,6 +227,31 @@ func (mr *MigrationRunner) GetAppliedMigrations() ([]int, error) {
 	return versions, nil
 }
 
+// PendingMigrations returns the versions of migration files that were not
+// applied yet
+func (mr *MigrationRunner) PendingMigrations() ([]int, error) {
+	migrations, err := mr.LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	appliedVersions, err := mr.GetAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool, len(appliedVersions))
+	for _, version := range appliedVersions {
+		applied[version] = true
+	}
+	var pending []int
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration.Version)
+		}
+	}
+	return pending, nil
+}
+
 // MigrateUp runs pending migrations
 func (mr *MigrationRunner) MigrateUp() error {
 	// Ensure migrations table exists
@@ -0,0 +1,14 @@
+-- Migration: 007_add_content_sha256.sql
+-- Description: Add SHA-256 content hash to hash files and wordlists for upload deduplication
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: sha256 columns are added by the built-in schema migration on startup
+-- (ALTER TABLE hash_files ADD COLUMN sha256 TEXT; ALTER TABLE wordlists ADD COLUMN sha256 TEXT;)
+CREATE INDEX IF NOT EXISTS idx_hash_files_sha256 ON hash_files(sha256);
+CREATE INDEX IF NOT EXISTS idx_wordlists_sha256 ON wordlists(sha256);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wordlists_sha256;
+DROP INDEX IF EXISTS idx_hash_files_sha256;
@@ -0,0 +1,14 @@
+-- Migration: 008_add_job_file_source.sql
+-- Description: Record where an agent obtained a job's hash file and wordlist (local/cache/download/peer)
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: file_source column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN file_source TEXT;)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the table without file_source
+SELECT 1;
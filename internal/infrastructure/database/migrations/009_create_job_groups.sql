@@ -0,0 +1,19 @@
+-- Migration: 009_create_job_groups.sql
+-- Description: Link sub-jobs of a distributed job through a job group
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_groups (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- Note: jobs.group_id is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN group_id TEXT REFERENCES job_groups(id);)
+CREATE INDEX IF NOT EXISTS idx_jobs_group_id ON jobs(group_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_jobs_group_id;
+DROP TABLE IF EXISTS job_groups;
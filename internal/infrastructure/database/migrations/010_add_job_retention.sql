@@ -0,0 +1,13 @@
+-- Migration: 010_add_job_retention.sql
+-- Description: Soft-delete and archive columns for jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN archived_at DATETIME;
+--  ALTER TABLE jobs ADD COLUMN deleted_at DATETIME;)
+CREATE INDEX IF NOT EXISTS idx_jobs_archived_deleted ON jobs(archived_at, deleted_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_jobs_archived_deleted;
@@ -0,0 +1,12 @@
+-- Migration: 011_add_job_retried_from.sql
+-- Description: Link retried jobs to the job they re-run
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN retried_from TEXT REFERENCES jobs(id);)
+CREATE INDEX IF NOT EXISTS idx_jobs_retried_from ON jobs(retried_from);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_jobs_retried_from;
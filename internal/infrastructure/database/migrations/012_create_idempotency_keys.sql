@@ -0,0 +1,19 @@
+-- Migration: 012_create_idempotency_keys.sql
+-- Description: Stored responses for requests sent with an Idempotency-Key header
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response BLOB,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_idempotency_keys_created_at;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,22 @@
+-- Migration: 013_create_job_events.sql
+-- Description: Log of job status transitions with actor and reason
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_events (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_events_job_id ON job_events(job_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_job_events_job_id;
+DROP TABLE IF EXISTS job_events;
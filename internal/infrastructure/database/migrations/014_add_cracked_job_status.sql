@@ -0,0 +1,14 @@
+-- Migration: 014_add_cracked_job_status.sql
+-- Description: Move jobs that found a password to the new 'cracked' status
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+UPDATE jobs SET status = 'cracked'
+WHERE status IN ('completed', 'failed')
+  AND (result LIKE 'Password found:%'
+       OR result LIKE 'Password found (%'
+       OR result LIKE 'SUCCESS: Password found%');
+
+-- +migrate Down
+UPDATE jobs SET status = 'completed' WHERE status = 'cracked';
@@ -0,0 +1,27 @@
+-- Migration: 015_create_agent_groups.sql
+-- Description: Agent groups (pools) and their members, for targeting jobs at a group
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_groups (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS agent_group_members (
+    group_id TEXT NOT NULL,
+    agent_id TEXT NOT NULL,
+    PRIMARY KEY (group_id, agent_id),
+    FOREIGN KEY (group_id) REFERENCES agent_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_group_members_agent_id ON agent_group_members(agent_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_group_members_agent_id;
+DROP TABLE IF EXISTS agent_group_members;
+DROP TABLE IF EXISTS agent_groups;
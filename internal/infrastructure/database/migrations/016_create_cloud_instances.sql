@@ -0,0 +1,24 @@
+-- Migration: 016_create_cloud_instances.sql
+-- Description: Burst agent instances started by the auto-scaler
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS cloud_instances (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    provider_id TEXT NOT NULL,
+    agent_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    hourly_cost REAL NOT NULL DEFAULT 0,
+    idle_since DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    terminated_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_cloud_instances_status ON cloud_instances(status);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_cloud_instances_status;
+DROP TABLE IF EXISTS cloud_instances;
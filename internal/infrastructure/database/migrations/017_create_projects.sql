@@ -0,0 +1,37 @@
+-- Migration: 017_create_projects.sql
+-- Description: Projects (engagements) grouping jobs, hash files and wordlists, and their members
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS projects (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (project_id, user_id),
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id);
+
+-- Note: the project_id columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs/hash_files/wordlists ADD COLUMN project_id TEXT REFERENCES projects(id);)
+CREATE INDEX IF NOT EXISTS idx_jobs_project_id ON jobs(project_id);
+CREATE INDEX IF NOT EXISTS idx_hash_files_project_id ON hash_files(project_id);
+CREATE INDEX IF NOT EXISTS idx_wordlists_project_id ON wordlists(project_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wordlists_project_id;
+DROP INDEX IF EXISTS idx_hash_files_project_id;
+DROP INDEX IF EXISTS idx_jobs_project_id;
+DROP INDEX IF EXISTS idx_project_members_user_id;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,21 @@
+-- Migration: 018_add_custom_charsets.sql
+-- Description: Custom charsets (-1..-4) on jobs and uploaded charset files
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS charset_files (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    orig_name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN custom_charset1..custom_charset4 TEXT NOT NULL DEFAULT '';)
+
+-- +migrate Down
+DROP TABLE IF EXISTS charset_files;
@@ -0,0 +1,12 @@
+-- Migration: 019_add_combinator_wordlist.sql
+-- Description: Right-hand wordlist of combinator attacks (-a 1) on jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN wordlist2_id TEXT REFERENCES wordlists(id);)
+CREATE INDEX IF NOT EXISTS idx_jobs_wordlist2_id ON jobs(wordlist2_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_jobs_wordlist2_id;
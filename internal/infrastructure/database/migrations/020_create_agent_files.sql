@@ -0,0 +1,24 @@
+-- Migration: 020_create_agent_files.sql
+-- Description: Inventory of the local files each agent reports, used to prefer agents that already hold a job's wordlist
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_files (
+    agent_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    md5 TEXT,
+    mod_time DATETIME,
+    reported_at DATETIME NOT NULL,
+    PRIMARY KEY (agent_id, name),
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_files_name ON agent_files(name);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_files_name;
+DROP TABLE IF EXISTS agent_files;
@@ -0,0 +1,14 @@
+-- Migration: 021_add_agent_heartbeat.sql
+-- Description: Latest heartbeat snapshot (current job, GPU utilization, free disk, hashcat availability) on agents
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: heartbeat column is added by the built-in schema migration on startup
+-- (ALTER TABLE agents ADD COLUMN heartbeat TEXT;)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the table without heartbeat
+SELECT 1;
@@ -0,0 +1,20 @@
+-- Migration: 022_create_agent_logs.sql
+-- Description: Recent log lines shipped by agents, trimmed to the newest lines per agent
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    agent_id TEXT NOT NULL,
+    logged_at DATETIME NOT NULL,
+    source TEXT NOT NULL,
+    message TEXT NOT NULL,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_logs_agent_id ON agent_logs(agent_id, id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_logs_agent_id;
+DROP TABLE IF EXISTS agent_logs;
@@ -0,0 +1,19 @@
+-- Migration: 023_create_job_outputs.sql
+-- Description: Tail of hashcat's stdout/stderr reported by the agent with each job's completion or failure
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_outputs (
+    job_id TEXT PRIMARY KEY,
+    agent_id TEXT,
+    exit_code INTEGER,
+    stdout TEXT NOT NULL DEFAULT '',
+    stderr TEXT NOT NULL DEFAULT '',
+    truncated BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS job_outputs;
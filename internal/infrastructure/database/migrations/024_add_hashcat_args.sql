@@ -0,0 +1,15 @@
+-- Migration: 024_add_hashcat_args.sql
+-- Description: Whitelisted hashcat tuning options per job (extra_args) and per agent (hashcat_args)
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN extra_args TEXT NOT NULL DEFAULT '';)
+-- (ALTER TABLE agents ADD COLUMN hashcat_args TEXT NOT NULL DEFAULT '';)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the tables without extra_args and hashcat_args
+SELECT 1;
@@ -0,0 +1,14 @@
+-- Migration: 025_add_agent_hashcat_version.sql
+-- Description: hashcat version each agent reports with its heartbeat, used to gate jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE agents ADD COLUMN hashcat_version TEXT NOT NULL DEFAULT '';)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the agents table without hashcat_version
+SELECT 1;
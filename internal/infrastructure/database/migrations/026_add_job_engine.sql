@@ -0,0 +1,15 @@
+-- Migration: 026_add_job_engine.sql
+-- Description: Cracking engine per job (hashcat or john) and the John the Ripper format of john jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN engine TEXT NOT NULL DEFAULT 'hashcat';)
+-- (ALTER TABLE jobs ADD COLUMN john_format TEXT NOT NULL DEFAULT '';)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without engine and john_format
+SELECT 1;
@@ -0,0 +1,14 @@
+-- Migration: 027_add_loopback_wordlists.sql
+-- Description: Dynamic (loopback) wordlists that collect the passwords cracked in a project
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE wordlists ADD COLUMN dynamic BOOLEAN NOT NULL DEFAULT 0;)
+CREATE INDEX IF NOT EXISTS idx_wordlists_dynamic ON wordlists(dynamic, project_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wordlists_dynamic;
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the wordlists table without dynamic
@@ -0,0 +1,15 @@
+-- Migration: 028_add_job_usage.sql
+-- Description: Device time and energy used by each job, for cost accounting
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN device_seconds REAL NOT NULL DEFAULT 0;)
+-- (ALTER TABLE jobs ADD COLUMN energy_wh REAL NOT NULL DEFAULT 0;)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without device_seconds and energy_wh
+SELECT 1;
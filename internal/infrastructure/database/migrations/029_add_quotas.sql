@@ -0,0 +1,31 @@
+-- Migration: 029_add_quotas.sql
+-- Description: Per-user and per-project quotas on running jobs, device-hours and upload storage
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS quotas (
+    scope TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    max_running_jobs INTEGER NOT NULL DEFAULT 0,
+    max_device_hours_per_month REAL NOT NULL DEFAULT 0,
+    max_storage_bytes INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (scope, subject_id)
+);
+
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN created_by TEXT;)
+-- (ALTER TABLE hash_files ADD COLUMN created_by TEXT;)
+-- (ALTER TABLE wordlists ADD COLUMN created_by TEXT;)
+CREATE INDEX IF NOT EXISTS idx_jobs_created_by ON jobs(created_by, status);
+CREATE INDEX IF NOT EXISTS idx_hash_files_created_by ON hash_files(created_by);
+CREATE INDEX IF NOT EXISTS idx_wordlists_created_by ON wordlists(created_by);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wordlists_created_by;
+DROP INDEX IF EXISTS idx_hash_files_created_by;
+DROP INDEX IF EXISTS idx_jobs_created_by;
+DROP TABLE IF EXISTS quotas;
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs, hash_files and wordlists tables without created_by
@@ -0,0 +1,26 @@
+-- Migration: 030_add_maintenance_windows.sql
+-- Description: Cluster calendar of maintenance windows during which agents take no new jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    agent_id TEXT,
+    agent_group_id TEXT,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    days TEXT NOT NULL DEFAULT '',
+    start_time TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE,
+    FOREIGN KEY (agent_group_id) REFERENCES agent_groups(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS maintenance_windows;
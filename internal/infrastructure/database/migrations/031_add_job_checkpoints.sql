@@ -0,0 +1,18 @@
+-- Migration: 031_add_job_checkpoints.sql
+-- Description: hashcat restore files of jobs interrupted by an agent shutting down, for handoff to another agent
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_checkpoints (
+    job_id TEXT PRIMARY KEY,
+    agent_id TEXT,
+    hashcat_version TEXT NOT NULL DEFAULT '',
+    restore_point INTEGER NOT NULL DEFAULT 0,
+    data BLOB NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS job_checkpoints;
@@ -0,0 +1,14 @@
+-- Migration: 032_add_job_leases.sql
+-- Description: Expiring agent leases on assigned and running jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN lease_expires_at DATETIME;)
+CREATE INDEX IF NOT EXISTS idx_jobs_lease ON jobs(status, lease_expires_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_jobs_lease;
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without lease_expires_at
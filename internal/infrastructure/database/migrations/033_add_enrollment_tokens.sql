@@ -0,0 +1,19 @@
+-- Migration: 033_add_enrollment_tokens.sql
+-- Description: Single-use, expiring tokens agents trade for an agent key
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS enrollment_tokens (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    agent_id TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE SET NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS enrollment_tokens;
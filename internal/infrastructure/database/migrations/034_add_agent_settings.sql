@@ -0,0 +1,14 @@
+-- Migration: 034_add_agent_settings.sql
+-- Description: Agent settings managed on the server and sent with heartbeat replies
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE agents ADD COLUMN settings TEXT NOT NULL DEFAULT '';)
+SELECT 1;
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the agents table without settings
+SELECT 1;
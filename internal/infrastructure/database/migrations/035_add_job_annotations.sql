@@ -0,0 +1,23 @@
+-- Migration: 035_add_job_annotations.sql
+-- Description: Job tags and operator comments on jobs
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the tags column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN tags TEXT NOT NULL DEFAULT '';)
+CREATE TABLE IF NOT EXISTS job_comments (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    author_id TEXT,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_comments_job_id ON job_comments(job_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_job_comments_job_id;
+DROP TABLE IF EXISTS job_comments;
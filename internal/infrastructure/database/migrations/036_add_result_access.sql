@@ -0,0 +1,28 @@
+-- Migration: 036_add_result_access.sql
+-- Description: Permission to reveal cracked passwords and the audit log of reveals
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS result_reveal_grants (
+    user_id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    granted_by TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS result_reveals (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    user_id TEXT,
+    username TEXT NOT NULL,
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_result_reveals_job_id ON result_reveals(job_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_result_reveals_job_id;
+DROP TABLE IF EXISTS result_reveals;
+DROP TABLE IF EXISTS result_reveal_grants;
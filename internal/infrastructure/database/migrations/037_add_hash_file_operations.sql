@@ -0,0 +1,29 @@
+-- Migration: 037_add_hash_file_operations.sql
+-- Description: Background merge, split and diff operations on hash files
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS hash_file_operations (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    progress REAL NOT NULL DEFAULT 0,
+    input_ids TEXT NOT NULL,
+    result_ids TEXT NOT NULL DEFAULT '[]',
+    counts TEXT NOT NULL DEFAULT '{}',
+    parts INTEGER NOT NULL DEFAULT 0,
+    name TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    project_id TEXT,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    completed_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_hash_file_operations_created_at ON hash_file_operations(created_at DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_hash_file_operations_created_at;
+DROP TABLE IF EXISTS hash_file_operations;
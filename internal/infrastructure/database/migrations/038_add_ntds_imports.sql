@@ -0,0 +1,45 @@
+-- Migration: 038_add_ntds_imports.sql
+-- Description: Active Directory NTDS dumps and the accounts behind their NT hashes
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS ntds_imports (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    domain TEXT NOT NULL DEFAULT '',
+    hash_file_id TEXT,
+    accounts INTEGER NOT NULL DEFAULT 0,
+    unique_hashes INTEGER NOT NULL DEFAULT 0,
+    machine_accounts INTEGER NOT NULL DEFAULT 0,
+    history_entries INTEGER NOT NULL DEFAULT 0,
+    skipped_lines INTEGER NOT NULL DEFAULT 0,
+    project_id TEXT,
+    created_by TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ntds_accounts (
+    id TEXT PRIMARY KEY,
+    import_id TEXT NOT NULL,
+    domain TEXT NOT NULL DEFAULT '',
+    username TEXT NOT NULL,
+    rid INTEGER NOT NULL,
+    nt_hash TEXT NOT NULL,
+    enabled BOOLEAN,
+    privileged BOOLEAN NOT NULL DEFAULT 0,
+    cracked BOOLEAN NOT NULL DEFAULT 0,
+    cracked_at DATETIME,
+    FOREIGN KEY (import_id) REFERENCES ntds_imports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ntds_imports_project_id ON ntds_imports(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ntds_accounts_import_id ON ntds_accounts(import_id, username);
+CREATE INDEX IF NOT EXISTS idx_ntds_accounts_nt_hash ON ntds_accounts(import_id, nt_hash);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_ntds_accounts_nt_hash;
+DROP INDEX IF EXISTS idx_ntds_accounts_import_id;
+DROP INDEX IF EXISTS idx_ntds_imports_project_id;
+DROP TABLE IF EXISTS ntds_accounts;
+DROP TABLE IF EXISTS ntds_imports;
@@ -0,0 +1,32 @@
+-- Migration: 039_add_credentials.sql
+-- Description: Cracked passwords linked to their username, hash and hash file
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS credentials (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL DEFAULT '',
+    domain TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL,
+    plaintext TEXT NOT NULL,
+    hash_type INTEGER NOT NULL DEFAULT 0,
+    hash_file_id TEXT,
+    source_file TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL,
+    job_id TEXT,
+    project_id TEXT,
+    cracked_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credentials_account ON credentials(hash_file_id, domain, username, hash);
+CREATE INDEX IF NOT EXISTS idx_credentials_username ON credentials(username COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_credentials_domain ON credentials(domain COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_credentials_project_id ON credentials(project_id, cracked_at DESC);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_credentials_project_id;
+DROP INDEX IF EXISTS idx_credentials_domain;
+DROP INDEX IF EXISTS idx_credentials_username;
+DROP INDEX IF EXISTS idx_credentials_account;
+DROP TABLE IF EXISTS credentials;
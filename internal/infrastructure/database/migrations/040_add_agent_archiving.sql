@@ -0,0 +1,14 @@
+-- Migration: 040_add_agent_archiving.sql
+-- Description: Stale agents archived by the agent retention policy
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE agents ADD COLUMN archived_at DATETIME;)
+CREATE INDEX IF NOT EXISTS idx_agents_last_seen ON agents(last_seen);
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the agents table without archived_at
+DROP INDEX IF EXISTS idx_agents_last_seen;
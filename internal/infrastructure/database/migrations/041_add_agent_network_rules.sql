@@ -0,0 +1,33 @@
+-- Migration: 041_add_agent_network_rules.sql
+-- Description: Address ranges agents may connect from, and the requests they refused
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_network_rules (
+    id TEXT PRIMARY KEY,
+    agent_id TEXT,
+    cidr TEXT NOT NULL,
+    action TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+-- Denials outlive their agents, so the audit log has no foreign key
+CREATE TABLE IF NOT EXISTS agent_access_denials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ip_address TEXT NOT NULL,
+    agent_id TEXT,
+    agent_name TEXT NOT NULL DEFAULT '',
+    agent_key TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_network_rules_agent_id ON agent_network_rules(agent_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_network_rules_agent_id;
+DROP TABLE IF EXISTS agent_access_denials;
+DROP TABLE IF EXISTS agent_network_rules;
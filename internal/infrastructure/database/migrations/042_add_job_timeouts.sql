@@ -0,0 +1,14 @@
+-- Migration: 042_add_job_timeouts.sql
+-- Description: Max runtime and stall timeout of each job run
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN max_runtime_minutes INTEGER NOT NULL DEFAULT 0;)
+-- (ALTER TABLE jobs ADD COLUMN stall_timeout_minutes INTEGER NOT NULL DEFAULT 0;)
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without max_runtime_minutes
+-- and stall_timeout_minutes
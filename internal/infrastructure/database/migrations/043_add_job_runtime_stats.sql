@@ -0,0 +1,14 @@
+-- Migration: 043_add_job_runtime_stats.sql
+-- Description: Normalized keyspace progress and hashcat's latest status of each job
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN normalized_progress REAL NOT NULL DEFAULT 0;)
+-- (ALTER TABLE jobs ADD COLUMN runtime_stats TEXT NOT NULL DEFAULT '';)
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without normalized_progress
+-- and runtime_stats
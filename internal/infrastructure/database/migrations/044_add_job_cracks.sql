@@ -0,0 +1,21 @@
+-- Migration: 044_add_job_cracks.sql
+-- Description: Hashes cracked by each job, reported by agents as hashcat finds them
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_cracks (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    agent_id TEXT,
+    hash TEXT NOT NULL,
+    plaintext TEXT NOT NULL,
+    cracked_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_cracks_hash ON job_cracks(job_id, hash);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_job_cracks_hash;
+DROP TABLE IF EXISTS job_cracks;
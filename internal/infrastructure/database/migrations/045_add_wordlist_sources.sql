@@ -0,0 +1,28 @@
+-- Migration: 045_add_wordlist_sources.sql
+-- Description: Remote wordlists the server downloads and indexes on demand or on a schedule
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS wordlist_sources (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    sha256 TEXT NOT NULL DEFAULT '',
+    refresh_hours INTEGER NOT NULL DEFAULT 0,
+    project_id TEXT,
+    wordlist_id TEXT,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    fetched_sha256 TEXT NOT NULL DEFAULT '',
+    fetched_at DATETIME,
+    created_by TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_wordlist_sources_project_id ON wordlist_sources(project_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wordlist_sources_project_id;
+DROP TABLE IF EXISTS wordlist_sources;
@@ -0,0 +1,37 @@
+-- Migration: 046_add_tenants.sql
+-- Description: Tenants isolating users, agents, jobs and files from each other
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS tenants (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- The users table only comes from migration 006
+ALTER TABLE users ADD COLUMN tenant_id TEXT;
+
+-- Note: the other columns are added by the built-in schema migration on startup
+-- (ALTER TABLE agents ADD COLUMN tenant_id TEXT;)
+-- (ALTER TABLE jobs ADD COLUMN tenant_id TEXT;)
+-- (ALTER TABLE hash_files ADD COLUMN tenant_id TEXT;)
+-- (ALTER TABLE wordlists ADD COLUMN tenant_id TEXT;)
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_agents_tenant_id ON agents(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_id ON jobs(tenant_id, status);
+CREATE INDEX IF NOT EXISTS idx_hash_files_tenant_id ON hash_files(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_wordlists_tenant_id ON wordlists(tenant_id);
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the tables without tenant_id
+DROP INDEX IF EXISTS idx_wordlists_tenant_id;
+DROP INDEX IF EXISTS idx_hash_files_tenant_id;
+DROP INDEX IF EXISTS idx_jobs_tenant_id;
+DROP INDEX IF EXISTS idx_agents_tenant_id;
+DROP INDEX IF EXISTS idx_users_tenant_id;
+DROP TABLE IF EXISTS tenants;
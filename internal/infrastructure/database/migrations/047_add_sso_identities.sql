@@ -0,0 +1,16 @@
+-- Migration: 047_add_sso_identities.sql
+-- Description: Users that log in with OpenID Connect single sign-on
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS sso_identities (
+    user_id TEXT PRIMARY KEY,
+    subject TEXT NOT NULL UNIQUE,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS sso_identities;
@@ -0,0 +1,31 @@
+-- Migration: 048_add_two_factor.sql
+-- Description: TOTP two-factor authentication, recovery codes and the policy requiring it
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS two_factor (
+    user_id TEXT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    last_used_step INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    user_id TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_policy (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    require_for_privileged BOOLEAN NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS two_factor_policy;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS two_factor;
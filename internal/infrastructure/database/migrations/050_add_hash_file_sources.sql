@@ -0,0 +1,12 @@
+-- Migration: 050_add_hash_file_sources.sql
+-- Description: Origin tag of hash files, such as wifi or ad, for wordlist recommendations
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE hash_files ADD COLUMN source TEXT NOT NULL DEFAULT '';)
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the hash_files table without source
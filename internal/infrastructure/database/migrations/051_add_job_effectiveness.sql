@@ -0,0 +1,26 @@
+-- Migration: 051_add_job_effectiveness.sql
+-- Description: Cracks per keyspace of completed jobs, for wordlist, rule and mask analytics
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS job_effectiveness (
+    job_id TEXT PRIMARY KEY,
+    group_id TEXT,
+    hash_type INTEGER NOT NULL,
+    attack_mode INTEGER NOT NULL,
+    wordlist_id TEXT,
+    wordlist TEXT NOT NULL DEFAULT '',
+    rules TEXT NOT NULL DEFAULT '',
+    mask TEXT NOT NULL DEFAULT '',
+    keyspace INTEGER NOT NULL DEFAULT 0,
+    cracks INTEGER NOT NULL DEFAULT 0,
+    device_seconds REAL NOT NULL DEFAULT 0,
+    completed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_effectiveness_hash_type ON job_effectiveness(hash_type, completed_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_job_effectiveness_hash_type;
+DROP TABLE IF EXISTS job_effectiveness;
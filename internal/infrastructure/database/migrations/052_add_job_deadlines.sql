@@ -0,0 +1,12 @@
+-- Migration: 052_add_job_deadlines.sql
+-- Description: Deadline of time-boxed jobs, which expire unfinished when it passes
+-- Author: System
+-- Date: 2026-10-15
+
+-- +migrate Up
+-- Note: the column is added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN deadline DATETIME;)
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs table without deadline
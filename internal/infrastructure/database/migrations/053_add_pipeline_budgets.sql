@@ -0,0 +1,13 @@
+-- Migration: 053_add_pipeline_budgets.sql
+-- Description: GPU-hour budget of playbook job groups and the stage each job spends
+-- Author: System
+-- Date: 2026-10-16
+
+-- +migrate Up
+-- Note: the columns are added by the built-in schema migration on startup
+-- (ALTER TABLE jobs ADD COLUMN budget_stage TEXT NOT NULL DEFAULT '';)
+-- (ALTER TABLE job_groups ADD COLUMN budget TEXT NOT NULL DEFAULT '';)
+
+-- +migrate Down
+-- Note: SQLite doesn't support DROP COLUMN easily
+-- This would require recreating the jobs and job_groups tables without these columns
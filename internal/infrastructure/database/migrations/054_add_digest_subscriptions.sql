@@ -0,0 +1,21 @@
+-- Migration: 054_add_digest_subscriptions.sql
+-- Description: Users' subscriptions to the daily or weekly email digest of a project
+-- Author: System
+-- Date: 2026-10-16
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id TEXT NOT NULL,
+    project_id TEXT NOT NULL,
+    frequency TEXT NOT NULL,
+    last_sent_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_project_id ON digest_subscriptions(project_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_digest_subscriptions_project_id;
+DROP TABLE IF EXISTS digest_subscriptions;
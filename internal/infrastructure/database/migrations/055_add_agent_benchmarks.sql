@@ -0,0 +1,42 @@
+-- Migration: 055_add_agent_benchmarks.sql
+-- Description: Per hash mode benchmarks of agents and the benchmark jobs that measure them
+-- Author: System
+-- Date: 2026-10-16
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_benchmarks (
+    agent_id TEXT NOT NULL,
+    hash_type INTEGER NOT NULL,
+    speed INTEGER NOT NULL,
+    measured_at DATETIME NOT NULL,
+    PRIMARY KEY (agent_id, hash_type),
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS agent_benchmark_resets (
+    agent_id TEXT PRIMARY KEY,
+    reset_at DATETIME NOT NULL,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS agent_benchmark_jobs (
+    id TEXT PRIMARY KEY,
+    agent_id TEXT NOT NULL,
+    hash_types TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    started_at DATETIME,
+    completed_at DATETIME,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_benchmarks_hash_type ON agent_benchmarks(hash_type);
+CREATE INDEX IF NOT EXISTS idx_agent_benchmark_jobs_agent_id ON agent_benchmark_jobs(agent_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_benchmark_jobs_agent_id;
+DROP INDEX IF EXISTS idx_agent_benchmarks_hash_type;
+DROP TABLE IF EXISTS agent_benchmark_jobs;
+DROP TABLE IF EXISTS agent_benchmark_resets;
+DROP TABLE IF EXISTS agent_benchmarks;
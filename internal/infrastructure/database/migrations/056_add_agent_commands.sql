@@ -0,0 +1,26 @@
+-- Migration: 056_add_agent_commands.sql
+-- Description: Commands queued for agents, acknowledged and reported back by them
+-- Author: System
+-- Date: 2026-10-16
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agent_commands (
+    id TEXT PRIMARY KEY,
+    agent_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    params TEXT,
+    status TEXT NOT NULL,
+    result TEXT,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    acknowledged_at DATETIME,
+    completed_at DATETIME,
+    FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_commands_agent_id ON agent_commands(agent_id, status, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_commands_agent_id;
+DROP TABLE IF EXISTS agent_commands;
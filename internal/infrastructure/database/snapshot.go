@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// snapshotPages is how many pages Snapshot copies at a time. Between steps
+// other connections may write; SQLite then carries the changes over.
+const snapshotPages = 1024
+
+// Snapshot writes a consistent copy of the database to path with SQLite's
+// online backup API, so the server can keep running. An existing file at
+// path is replaced.
+func (s *SQLiteDB) Snapshot(ctx context.Context, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	destConn, err := (&sqlite3.SQLiteDriver{}).Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	dest := destConn.(*sqlite3.SQLiteConn)
+	defer dest.Close()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		src, ok := driverConn.(*tracedConn).Conn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("snapshots need a SQLite connection")
+		}
+
+		backup, err := dest.Backup("main", src, "main")
+		if err != nil {
+			return fmt.Errorf("failed to start snapshot: %w", err)
+		}
+		for {
+			done, err := backup.Step(snapshotPages)
+			if err != nil && !isBusy(err) {
+				backup.Close()
+				return fmt.Errorf("failed to copy database: %w", err)
+			}
+			if done {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				backup.Close()
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return backup.Finish()
+	})
+}
+
+// isBusy reports whether err means another connection held a lock, so the
+// statement can be tried again
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// VerifySnapshot checks a database copy made by Snapshot for corruption and
+// returns the last migration applied to it, 0 when it has none
+func VerifySnapshot(ctx context.Context, path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check database: %w", err)
+	}
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to check database: %w", err)
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("database is corrupt: %s", strings.Join(problems, "; "))
+	}
+
+	var migrations int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&migrations); err != nil {
+		return 0, err
+	}
+	if migrations == 0 {
+		return 0, nil
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
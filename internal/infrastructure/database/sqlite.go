@@ -4,13 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/mattn/go-sqlite3"
 )
 
+// busyTimeout is how long a statement waits for another connection's write
+// lock before failing with "database is locked"
+const busyTimeout = 5 * time.Second
+
 type SQLiteDB struct {
-	db *sql.DB
+	db   *sql.DB
+	fts5 bool // search_index is available
+
+	cipher *FieldCipher // Encrypts cracked passwords at rest, nil when disabled
+
+	contention *contention // Statements refused because the database was locked
+	writes     *writeQueue // Writes committed in batches, see QueueWrite
+	closeOnce  sync.Once
 }
 
 func (db *SQLiteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
@@ -26,13 +41,17 @@ func (db *SQLiteDB) QueryRowContext(ctx context.Context, query string, args ...i
 }
 
 func NewSQLiteDB(dbPath string) (*SQLiteDB, error) {
-	// Enhanced connection string with performance optimizations
-	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory&_mmap_size=268435456", dbPath)
+	// Enhanced connection string with performance optimizations. WAL lets
+	// readers run alongside the writer; writers wait up to the busy timeout
+	// for each other instead of failing with "database is locked", and
+	// transactions take the write lock when they begin, since a read lock
+	// can't be upgraded while another connection writes.
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory&_mmap_size=268435456&_busy_timeout=%d&_txlock=immediate",
+		dbPath, busyTimeout.Milliseconds())
 
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
+	// Wrapped so that queries show up in request traces
+	stats := &contention{}
+	db := sql.OpenDB(tracedConnector{dsn: dsn, driver: &sqlite3.SQLiteDriver{}, contention: stats})
 
 	// Configure connection pool for better performance
 	db.SetMaxOpenConns(25)
@@ -44,21 +63,37 @@ func NewSQLiteDB(dbPath string) (*SQLiteDB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	sqliteDB := &SQLiteDB{db: db}
+	sqliteDB := &SQLiteDB{db: db, contention: stats, writes: newWriteQueue()}
 
 	if err := sqliteDB.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Full-text search needs FTS5, which go-sqlite3 only includes when
+	// built with -tags sqlite_fts5. Without it search falls back to LIKE.
+	if err := sqliteDB.setupSearchIndex(); err != nil {
+		return nil, fmt.Errorf("failed to set up search index: %w", err)
+	}
+
 	// Apply performance optimizations after migration
 	if err := sqliteDB.optimizePerformance(); err != nil {
 		return nil, fmt.Errorf("failed to optimize database: %w", err)
 	}
 
+	go sqliteDB.runWriteQueue()
+
 	return sqliteDB, nil
 }
 
+// Close writes what is left in the write queue and closes the database
 func (s *SQLiteDB) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.writes.stop)
+		<-s.writes.stopped
+		if err := s.FlushWrites(context.Background()); err != nil {
+			infrastructure.ServerLogger.Error("Failed to write queued database writes on close: %v", err)
+		}
+	})
 	return s.db.Close()
 }
 
@@ -66,6 +101,94 @@ func (s *SQLiteDB) DB() *sql.DB {
 	return s.db
 }
 
+// SetFieldCipher turns on encryption at rest for job results and agent
+// output. Rows written earlier stay readable and are encrypted when they
+// are next written.
+func (s *SQLiteDB) SetFieldCipher(cipher *FieldCipher) {
+	s.cipher = cipher
+}
+
+// SealField encrypts a sensitive column value when encryption is enabled
+func (s *SQLiteDB) SealField(value string) (string, error) {
+	if s.cipher == nil {
+		return value, nil
+	}
+	return s.cipher.Seal(value)
+}
+
+// OpenField decrypts a column value written by SealField
+func (s *SQLiteDB) OpenField(value string) (string, error) {
+	if s.cipher == nil {
+		if IsSealed(value) {
+			return "", ErrFieldCipherMissing
+		}
+		return value, nil
+	}
+	return s.cipher.Open(value)
+}
+
+// HasFTS5 reports whether the search_index full-text table is available
+func (s *SQLiteDB) HasFTS5() bool {
+	return s.fts5
+}
+
+// setupSearchIndex creates the search_index FTS5 table and the triggers that
+// keep it in sync with jobs, agents, wordlists and hash files. The index is
+// filled from existing rows the first time it is created.
+func (s *SQLiteDB) setupSearchIndex() error {
+	var existing int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&existing); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		kind UNINDEXED, ref_id UNINDEXED, title, body
+	)`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return nil
+		}
+		return err
+	}
+
+	sources := []struct {
+		kind, table, title, body string
+	}{
+		{"job", "jobs", "name", "result"}, // result holds cracked plaintexts
+		{"agent", "agents", "name", "capabilities"},
+		{"wordlist", "wordlists", "orig_name", "name"},
+		{"hash_file", "hash_files", "orig_name", "name"},
+	}
+
+	queries := make([]string, 0, len(sources)*4)
+	for _, src := range sources {
+		insert := fmt.Sprintf(`INSERT INTO search_index (kind, ref_id, title, body) VALUES ('%s', new.id, new.%s, COALESCE(new.%s, ''));`,
+			src.kind, src.title, src.body)
+		remove := fmt.Sprintf(`DELETE FROM search_index WHERE kind = '%s' AND ref_id = old.id;`, src.kind)
+
+		queries = append(queries,
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s_search_insert AFTER INSERT ON %s BEGIN %s END`, src.table, src.table, insert),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s_search_update AFTER UPDATE OF %[2]s, %[3]s ON %[1]s
+				WHEN old.%[2]s IS NOT new.%[2]s OR old.%[3]s IS NOT new.%[3]s BEGIN %[4]s %[5]s END`,
+				src.table, src.title, src.body, remove, insert),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s_search_delete AFTER DELETE ON %s BEGIN %s END`, src.table, src.table, remove),
+		)
+		if existing == 0 {
+			queries = append(queries, fmt.Sprintf(`INSERT INTO search_index (kind, ref_id, title, body) SELECT '%s', id, %s, COALESCE(%s, '') FROM %s`,
+				src.kind, src.title, src.body, src.table))
+		}
+	}
+
+	for _, query := range queries {
+		if _, err := s.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute search index query: %s, error: %w", query, err)
+		}
+	}
+
+	s.fts5 = true
+	return nil
+}
+
 func (s *SQLiteDB) optimizePerformance() error {
 	optimizations := []string{
 		"PRAGMA synchronous = NORMAL",
@@ -147,6 +270,382 @@ func (s *SQLiteDB) migrate() error {
 			word_count INTEGER,
 			created_at DATETIME NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS job_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_events (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			from_status TEXT,
+			to_status TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			reason TEXT,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_outputs (
+			job_id TEXT PRIMARY KEY,
+			agent_id TEXT,
+			exit_code INTEGER,
+			stdout TEXT NOT NULL DEFAULT '',
+			stderr TEXT NOT NULL DEFAULT '',
+			truncated BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_group_members (
+			group_id TEXT NOT NULL,
+			agent_id TEXT NOT NULL,
+			PRIMARY KEY (group_id, agent_id),
+			FOREIGN KEY (group_id) REFERENCES agent_groups(id) ON DELETE CASCADE,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS projects (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS project_members (
+			project_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (project_id, user_id),
+			FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS charset_files (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			orig_name TEXT NOT NULL,
+			path TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			sha256 TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS cloud_instances (
+			id TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			provider_id TEXT NOT NULL,
+			agent_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			hourly_cost REAL NOT NULL DEFAULT 0,
+			idle_since DATETIME,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			terminated_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_files (
+			agent_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			path TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			md5 TEXT,
+			mod_time DATETIME,
+			reported_at DATETIME NOT NULL,
+			PRIMARY KEY (agent_id, name),
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			agent_id TEXT NOT NULL,
+			logged_at DATETIME NOT NULL,
+			source TEXT NOT NULL,
+			message TEXT NOT NULL,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key TEXT PRIMARY KEY,
+			request_hash TEXT NOT NULL,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			response BLOB,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS quotas (
+			scope TEXT NOT NULL,
+			subject_id TEXT NOT NULL,
+			max_running_jobs INTEGER NOT NULL DEFAULT 0,
+			max_device_hours_per_month REAL NOT NULL DEFAULT 0,
+			max_storage_bytes INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (scope, subject_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS maintenance_windows (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT,
+			agent_id TEXT,
+			agent_group_id TEXT,
+			starts_at DATETIME,
+			ends_at DATETIME,
+			days TEXT NOT NULL DEFAULT '',
+			start_time TEXT NOT NULL DEFAULT '',
+			end_time TEXT NOT NULL DEFAULT '',
+			timezone TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE,
+			FOREIGN KEY (agent_group_id) REFERENCES agent_groups(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_checkpoints (
+			job_id TEXT PRIMARY KEY,
+			agent_id TEXT,
+			hashcat_version TEXT NOT NULL DEFAULT '',
+			restore_point INTEGER NOT NULL DEFAULT 0,
+			data BLOB NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_comments (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			author_id TEXT,
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS enrollment_tokens (
+			id TEXT PRIMARY KEY,
+			label TEXT NOT NULL DEFAULT '',
+			token_hash TEXT NOT NULL UNIQUE,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME,
+			agent_id TEXT,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS result_reveal_grants (
+			user_id TEXT PRIMARY KEY,
+			username TEXT NOT NULL,
+			granted_by TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		// Reveals outlive their jobs, so the audit log has no foreign key
+		`CREATE TABLE IF NOT EXISTS result_reveals (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			user_id TEXT,
+			username TEXT NOT NULL,
+			ip_address TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS hash_file_operations (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			progress REAL NOT NULL DEFAULT 0,
+			input_ids TEXT NOT NULL,
+			result_ids TEXT NOT NULL DEFAULT '[]',
+			counts TEXT NOT NULL DEFAULT '{}',
+			parts INTEGER NOT NULL DEFAULT 0,
+			name TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			project_id TEXT,
+			created_by TEXT,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			completed_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS ntds_imports (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			domain TEXT NOT NULL DEFAULT '',
+			hash_file_id TEXT,
+			accounts INTEGER NOT NULL DEFAULT 0,
+			unique_hashes INTEGER NOT NULL DEFAULT 0,
+			machine_accounts INTEGER NOT NULL DEFAULT 0,
+			history_entries INTEGER NOT NULL DEFAULT 0,
+			skipped_lines INTEGER NOT NULL DEFAULT 0,
+			project_id TEXT,
+			created_by TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS ntds_accounts (
+			id TEXT PRIMARY KEY,
+			import_id TEXT NOT NULL,
+			domain TEXT NOT NULL DEFAULT '',
+			username TEXT NOT NULL,
+			rid INTEGER NOT NULL,
+			nt_hash TEXT NOT NULL,
+			enabled BOOLEAN,
+			privileged BOOLEAN NOT NULL DEFAULT 0,
+			cracked BOOLEAN NOT NULL DEFAULT 0,
+			cracked_at DATETIME,
+			FOREIGN KEY (import_id) REFERENCES ntds_imports(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS credentials (
+			id TEXT PRIMARY KEY,
+			username TEXT NOT NULL DEFAULT '',
+			domain TEXT NOT NULL DEFAULT '',
+			hash TEXT NOT NULL,
+			plaintext TEXT NOT NULL,
+			hash_type INTEGER NOT NULL DEFAULT 0,
+			hash_file_id TEXT,
+			source_file TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL,
+			job_id TEXT,
+			project_id TEXT,
+			cracked_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_network_rules (
+			id TEXT PRIMARY KEY,
+			agent_id TEXT,
+			cidr TEXT NOT NULL,
+			action TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		// Denials outlive their agents, so the audit log has no foreign key
+		`CREATE TABLE IF NOT EXISTS agent_access_denials (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			ip_address TEXT NOT NULL,
+			agent_id TEXT,
+			agent_name TEXT NOT NULL DEFAULT '',
+			agent_key TEXT NOT NULL DEFAULT '',
+			path TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_cracks (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			agent_id TEXT,
+			hash TEXT NOT NULL,
+			plaintext TEXT NOT NULL,
+			cracked_at DATETIME NOT NULL,
+			FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS wordlist_sources (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			sha256 TEXT NOT NULL DEFAULT '',
+			refresh_hours INTEGER NOT NULL DEFAULT 0,
+			project_id TEXT,
+			wordlist_id TEXT,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			fetched_sha256 TEXT NOT NULL DEFAULT '',
+			fetched_at DATETIME,
+			created_by TEXT,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS tenants (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS sso_identities (
+			user_id TEXT PRIMARY KEY,
+			subject TEXT NOT NULL UNIQUE,
+			refresh_token TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS two_factor (
+			user_id TEXT PRIMARY KEY,
+			secret TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT 0,
+			last_used_step INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+			user_id TEXT NOT NULL,
+			code_hash TEXT NOT NULL,
+			PRIMARY KEY (user_id, code_hash)
+		)`,
+		`CREATE TABLE IF NOT EXISTS two_factor_policy (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			require_for_privileged BOOLEAN NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			token_hash TEXT NOT NULL,
+			two_factor BOOLEAN NOT NULL DEFAULT 0,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			last_used_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			revoked_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_effectiveness (
+			job_id TEXT PRIMARY KEY,
+			group_id TEXT,
+			hash_type INTEGER NOT NULL,
+			attack_mode INTEGER NOT NULL,
+			wordlist_id TEXT,
+			wordlist TEXT NOT NULL DEFAULT '',
+			rules TEXT NOT NULL DEFAULT '',
+			mask TEXT NOT NULL DEFAULT '',
+			keyspace INTEGER NOT NULL DEFAULT 0,
+			cracks INTEGER NOT NULL DEFAULT 0,
+			device_seconds REAL NOT NULL DEFAULT 0,
+			completed_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS digest_subscriptions (
+			user_id TEXT NOT NULL,
+			project_id TEXT NOT NULL,
+			frequency TEXT NOT NULL,
+			last_sent_at DATETIME,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, project_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_benchmarks (
+			agent_id TEXT NOT NULL,
+			hash_type INTEGER NOT NULL,
+			speed INTEGER NOT NULL,
+			measured_at DATETIME NOT NULL,
+			PRIMARY KEY (agent_id, hash_type),
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_benchmark_resets (
+			agent_id TEXT PRIMARY KEY,
+			reset_at DATETIME NOT NULL,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_benchmark_jobs (
+			id TEXT PRIMARY KEY,
+			agent_id TEXT NOT NULL,
+			hash_types TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			started_at DATETIME,
+			completed_at DATETIME,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS agent_commands (
+			id TEXT PRIMARY KEY,
+			agent_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			params TEXT,
+			status TEXT NOT NULL,
+			result TEXT,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			acknowledged_at DATETIME,
+			completed_at DATETIME,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+		)`,
 		// Comprehensive indexes for performance
 		`CREATE INDEX IF NOT EXISTS idx_agents_status ON agents(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_agents_last_seen ON agents(last_seen DESC)`,
@@ -168,13 +667,104 @@ func (s *SQLiteDB) migrate() error {
 		`CREATE INDEX IF NOT EXISTS idx_jobs_agent_status ON jobs(agent_id, status)`,
 		`ALTER TABLE jobs ADD COLUMN hash_file_id TEXT REFERENCES hash_files(id)`,
 		`ALTER TABLE jobs ADD COLUMN wordlist_id TEXT REFERENCES wordlists(id)`,
+		`ALTER TABLE hash_files ADD COLUMN sha256 TEXT`,
+		`ALTER TABLE wordlists ADD COLUMN sha256 TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_hash_files_sha256 ON hash_files(sha256)`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlists_sha256 ON wordlists(sha256)`,
+		`ALTER TABLE jobs ADD COLUMN file_source TEXT`,
+		`ALTER TABLE jobs ADD COLUMN group_id TEXT REFERENCES job_groups(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_group_id ON jobs(group_id)`,
+		`ALTER TABLE jobs ADD COLUMN archived_at DATETIME`,
+		`ALTER TABLE jobs ADD COLUMN deleted_at DATETIME`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_archived_deleted ON jobs(archived_at, deleted_at)`,
+		`ALTER TABLE jobs ADD COLUMN retried_from TEXT REFERENCES jobs(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_retried_from ON jobs(retried_from)`,
+		`CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_job_events_job_id ON job_events(job_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_group_members_agent_id ON agent_group_members(agent_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_cloud_instances_status ON cloud_instances(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_files_name ON agent_files(name)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_logs_agent_id ON agent_logs(agent_id, id)`,
+		`ALTER TABLE jobs ADD COLUMN project_id TEXT REFERENCES projects(id)`,
+		`ALTER TABLE hash_files ADD COLUMN project_id TEXT REFERENCES projects(id)`,
+		`ALTER TABLE wordlists ADD COLUMN project_id TEXT REFERENCES projects(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_project_id ON jobs(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_hash_files_project_id ON hash_files(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlists_project_id ON wordlists(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id)`,
+		`ALTER TABLE jobs ADD COLUMN custom_charset1 TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN custom_charset2 TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN custom_charset3 TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN custom_charset4 TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN wordlist2_id TEXT REFERENCES wordlists(id)`,
+		`ALTER TABLE agents ADD COLUMN heartbeat TEXT`,
+		`ALTER TABLE jobs ADD COLUMN extra_args TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE agents ADD COLUMN hashcat_args TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE agents ADD COLUMN hashcat_version TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN engine TEXT NOT NULL DEFAULT 'hashcat'`,
+		`ALTER TABLE jobs ADD COLUMN john_format TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE wordlists ADD COLUMN dynamic BOOLEAN NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN device_seconds REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN energy_wh REAL NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlists_dynamic ON wordlists(dynamic, project_id)`,
+		`ALTER TABLE jobs ADD COLUMN created_by TEXT`,
+		`ALTER TABLE hash_files ADD COLUMN created_by TEXT`,
+		`ALTER TABLE wordlists ADD COLUMN created_by TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_created_by ON jobs(created_by, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_hash_files_created_by ON hash_files(created_by)`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlists_created_by ON wordlists(created_by)`,
+		`ALTER TABLE jobs ADD COLUMN lease_expires_at DATETIME`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_lease ON jobs(status, lease_expires_at)`,
+		`ALTER TABLE agents ADD COLUMN settings TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN tags TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_job_comments_job_id ON job_comments(job_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_result_reveals_job_id ON result_reveals(job_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_hash_file_operations_created_at ON hash_file_operations(created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_ntds_imports_project_id ON ntds_imports(project_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_ntds_accounts_import_id ON ntds_accounts(import_id, username)`,
+		`CREATE INDEX IF NOT EXISTS idx_ntds_accounts_nt_hash ON ntds_accounts(import_id, nt_hash)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_credentials_account ON credentials(hash_file_id, domain, username, hash)`,
+		`CREATE INDEX IF NOT EXISTS idx_credentials_username ON credentials(username COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_credentials_domain ON credentials(domain COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_credentials_project_id ON credentials(project_id, cracked_at DESC)`,
+		`ALTER TABLE agents ADD COLUMN archived_at DATETIME`,
+		`CREATE INDEX IF NOT EXISTS idx_agents_last_seen ON agents(last_seen)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_network_rules_agent_id ON agent_network_rules(agent_id)`,
+		`ALTER TABLE jobs ADD COLUMN max_runtime_minutes INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN stall_timeout_minutes INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN normalized_progress REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE jobs ADD COLUMN runtime_stats TEXT NOT NULL DEFAULT ''`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_job_cracks_hash ON job_cracks(job_id, hash)`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlist_sources_project_id ON wordlist_sources(project_id, created_at)`,
+		`ALTER TABLE agents ADD COLUMN tenant_id TEXT`,
+		`ALTER TABLE jobs ADD COLUMN tenant_id TEXT`,
+		`ALTER TABLE hash_files ADD COLUMN tenant_id TEXT`,
+		`ALTER TABLE wordlists ADD COLUMN tenant_id TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_agents_tenant_id ON agents(tenant_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_tenant_id ON jobs(tenant_id, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_hash_files_tenant_id ON hash_files(tenant_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_wordlists_tenant_id ON wordlists(tenant_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id, created_at DESC)`,
+		`ALTER TABLE hash_files ADD COLUMN source TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_job_effectiveness_hash_type ON job_effectiveness(hash_type, completed_at)`,
+		`ALTER TABLE jobs ADD COLUMN deadline DATETIME`,
+		`ALTER TABLE jobs ADD COLUMN budget_stage TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE job_groups ADD COLUMN budget TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_project_id ON digest_subscriptions(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_benchmarks_hash_type ON agent_benchmarks(hash_type)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_benchmark_jobs_agent_id ON agent_benchmark_jobs(agent_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_commands_agent_id ON agent_commands(agent_id, status, created_at)`,
+		`ALTER TABLE result_reveals ADD COLUMN wordlist_id TEXT`,
+		// Jobs that found a password used to be stored as completed or failed
+		`UPDATE jobs SET status = 'cracked'
+			WHERE status IN ('completed', 'failed')
+			  AND (result LIKE 'Password found:%' OR result LIKE 'Password found (%' OR result LIKE 'SUCCESS: Password found%')`,
 	}
 
 	for _, query := range queries {
 		if _, err := s.db.Exec(query); err != nil {
 			// Ignore "duplicate column" errors for ALTER TABLE
-			errMsg := err.Error()
-			if errMsg != "duplicate column name: hash_file_id" && errMsg != "duplicate column name: wordlist_id" {
+			if !strings.HasPrefix(err.Error(), "duplicate column name") {
 				return fmt.Errorf("failed to execute migration query: %s, error: %w", query, err)
 			}
 		}
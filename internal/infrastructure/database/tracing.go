@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"go-distributed-hashcat/internal/infrastructure/tracing"
+
+	"github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTracedStatementLength keeps huge batch inserts out of span attributes
+const maxTracedStatementLength = 2048
+
+var dbTracer = tracing.Tracer("database")
+
+// tracedConnector opens SQLite connections that add a span for every
+// statement run within a traced request. Statements of background workers
+// without a trace are not recorded, so they don't each start a new trace.
+// Lock errors are counted for every statement.
+type tracedConnector struct {
+	dsn        string
+	driver     driver.Driver
+	contention *contention
+}
+
+func (c tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, contention: c.contention}, nil
+}
+
+func (c tracedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// contention counts statements SQLite refused because another connection
+// held the lock for longer than the busy timeout
+type contention struct {
+	busy   atomic.Int64
+	locked atomic.Int64
+}
+
+func (c *contention) record(err error) {
+	if c == nil || err == nil {
+		return
+	}
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return
+	}
+	switch sqliteErr.Code {
+	case sqlite3.ErrBusy:
+		c.busy.Add(1)
+	case sqlite3.ErrLocked:
+		c.locked.Add(1)
+	}
+}
+
+// startQuerySpan starts a span for query if ctx belongs to a recorded trace
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+
+	statement := strings.TrimSpace(query)
+	operation := strings.ToUpper(strings.SplitN(statement, " ", 2)[0])
+	if len(statement) > maxTracedStatementLength {
+		statement = statement[:maxTracedStatementLength]
+	}
+
+	return dbTracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemSqlite,
+			semconv.DBOperation(operation),
+			semconv.DBStatement(statement),
+		),
+	)
+}
+
+func endQuerySpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if errors.Is(err, driver.ErrSkip) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// tracedConn forwards to the SQLite connection. It implements the context
+// interfaces database/sql looks for, so statements are never re-prepared.
+type tracedConn struct {
+	driver.Conn
+	contention *contention
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query, contention: c.contention}, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	c.contention.record(err)
+	return tx, err
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	c.contention.record(err)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.contention.record(err)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// tracedStmt adds spans to prepared statements, which the repositories use
+// for their hot queries
+type tracedStmt struct {
+	driver.Stmt
+	query      string
+	contention *contention
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args))
+	}
+	s.contention.record(err)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args))
+	}
+	s.contention.record(err)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+// namedValues converts arguments for drivers without the context interfaces
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// writeQueueInterval is how often queued writes are committed
+const writeQueueInterval = time.Second
+
+// QueuedWrite is a statement that may be delayed and superseded, such as a
+// progress update. Queued writes are committed together, in one
+// transaction, by a single writer.
+type QueuedWrite struct {
+	Key   string // A later write with the same key replaces this one
+	Query string
+	Args  []interface{}
+	Done  func() // Called once the write is committed, may be nil
+}
+
+// writeQueue holds the writes waiting for the next batch
+type writeQueue struct {
+	mu      sync.Mutex
+	pending map[string]QueuedWrite
+	order   []string // Keys in the order they were first queued
+
+	flushMu sync.Mutex // One batch at a time
+	stop    chan struct{}
+	stopped chan struct{}
+
+	written   atomic.Int64
+	coalesced atomic.Int64
+	batches   atomic.Int64
+	failed    atomic.Int64
+}
+
+func newWriteQueue() *writeQueue {
+	return &writeQueue{
+		pending: make(map[string]QueuedWrite),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// QueueWrite queues a write for the next batch, replacing a queued write
+// with the same key
+func (s *SQLiteDB) QueueWrite(write QueuedWrite) {
+	q := s.writes
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[write.Key]; ok {
+		q.coalesced.Add(1)
+	} else {
+		q.order = append(q.order, write.Key)
+	}
+	q.pending[write.Key] = write
+}
+
+// FlushWrites commits the queued writes in one transaction. Writes of a
+// failed batch are kept for the next one unless newer ones replaced them.
+func (s *SQLiteDB) FlushWrites(ctx context.Context) error {
+	q := s.writes
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	q.mu.Lock()
+	pending, order := q.pending, q.order
+	q.pending, q.order = make(map[string]QueuedWrite), nil
+	q.mu.Unlock()
+
+	if len(order) == 0 {
+		return nil
+	}
+
+	if err := s.writeBatch(ctx, pending, order); err != nil {
+		q.failed.Add(1)
+		q.mu.Lock()
+		for _, key := range order {
+			if _, newer := q.pending[key]; !newer {
+				q.pending[key] = pending[key]
+				q.order = append(q.order, key)
+			}
+		}
+		q.mu.Unlock()
+		return err
+	}
+
+	q.batches.Add(1)
+	q.written.Add(int64(len(order)))
+	for _, key := range order {
+		if done := pending[key].Done; done != nil {
+			done()
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteDB) writeBatch(ctx context.Context, pending map[string]QueuedWrite, order []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, key := range order {
+		write := pending[key]
+		if _, err := tx.ExecContext(ctx, write.Query, write.Args...); err != nil {
+			return fmt.Errorf("failed to write %s: %w", key, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// runWriteQueue commits queued writes every writeQueueInterval until the
+// database is closed
+func (s *SQLiteDB) runWriteQueue() {
+	defer close(s.writes.stopped)
+
+	ticker := time.NewTicker(writeQueueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.writes.stop:
+			return
+		case <-ticker.C:
+			if err := s.FlushWrites(context.Background()); err != nil {
+				infrastructure.ServerLogger.Warning("Failed to write queued database writes, retrying: %v", err)
+			}
+		}
+	}
+}
+
+// DatabaseStats reports connection pool usage, lock contention and the
+// write queue, for spotting a database that can't keep up
+func (s *SQLiteDB) DatabaseStats() domain.DatabaseStats {
+	pool := s.db.Stats()
+
+	s.writes.mu.Lock()
+	queued := len(s.writes.order)
+	s.writes.mu.Unlock()
+
+	return domain.DatabaseStats{
+		OpenConnections: pool.OpenConnections,
+		InUse:           pool.InUse,
+		Idle:            pool.Idle,
+		WaitCount:       pool.WaitCount,
+		WaitDurationMs:  pool.WaitDuration.Milliseconds(),
+		BusyErrors:      s.contention.busy.Load(),
+		LockedErrors:    s.contention.locked.Load(),
+		QueuedWrites:    queued,
+		WrittenBatched:  s.writes.written.Load(),
+		Coalesced:       s.writes.coalesced.Load(),
+		Batches:         s.writes.batches.Load(),
+		FailedBatches:   s.writes.failed.Load(),
+	}
+}
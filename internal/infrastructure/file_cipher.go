@@ -0,0 +1,298 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// fileCipherChunk is how much plaintext each sealed chunk holds
+	fileCipherChunk = 64 * 1024
+	// keyCommandTimeout bounds the command that fetches the key from a KMS
+	keyCommandTimeout = 30 * time.Second
+)
+
+// fileCipherMagic starts every encrypted file. A random nonce prefix
+// follows, then the chunks.
+var fileCipherMagic = []byte("HCFENC1\x00")
+
+const fileNoncePrefixSize = 8
+
+var errTruncatedFile = errors.New("encrypted file is truncated")
+
+// FileCipher encrypts stored files with AES-256-GCM. Files are sealed in
+// 64 KiB chunks so that large uploads stream through without being held in
+// memory; each chunk's nonce carries its position and the last chunk is
+// marked, so reordered or truncated files fail to decrypt.
+type FileCipher struct {
+	aead cipher.AEAD
+}
+
+func NewFileCipher(key string) (*FileCipher, error) {
+	if key == "" {
+		return nil, fmt.Errorf("encryption key is empty")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileCipher{aead: aead}, nil
+}
+
+// LoadEncryptionKey returns the key set directly, read from keyFile, or
+// printed by keyCommand, e.g. a KMS or secret manager CLI. Trailing
+// whitespace is dropped. An empty result means encryption is off.
+func LoadEncryptionKey(key, keyFile, keyCommand string) (string, error) {
+	switch {
+	case key != "":
+		return key, nil
+	case keyFile != "":
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read encryption key file: %w", err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	case keyCommand != "":
+		ctx, cancel := context.WithTimeout(context.Background(), keyCommandTimeout)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", keyCommand)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("encryption key command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	return "", nil
+}
+
+// Encrypted reports whether header, the start of a stored file, marks the
+// file as encrypted by a FileCipher
+func (c *FileCipher) Encrypted(header []byte) bool {
+	return bytes.HasPrefix(header, fileCipherMagic)
+}
+
+// Encrypt returns a writer that encrypts into w. Close writes the last
+// chunk and must be called; it doesn't close w.
+func (c *FileCipher) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	prefix := make([]byte, fileNoncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if _, err := w.Write(append(append([]byte{}, fileCipherMagic...), prefix...)); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{cipher: c, w: w, prefix: prefix, buf: make([]byte, 0, fileCipherChunk)}, nil
+}
+
+// Decrypt returns a reader of r's plaintext. Files stored before encryption
+// was turned on are read as they are.
+func (c *FileCipher) Decrypt(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, fileCipherChunk+c.aead.Overhead()+1)
+	header, err := br.Peek(len(fileCipherMagic) + fileNoncePrefixSize)
+	if !c.Encrypted(header) {
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return br, nil
+	}
+	if err != nil {
+		return nil, errTruncatedFile
+	}
+
+	prefix := append([]byte{}, header[len(fileCipherMagic):]...)
+	if _, err := br.Discard(len(header)); err != nil {
+		return nil, err
+	}
+	return &decryptReader{cipher: c, r: br, prefix: prefix, chunk: make([]byte, fileCipherChunk+c.aead.Overhead())}, nil
+}
+
+// EncryptFile encrypts a stored file in place, through a temporary file
+// renamed over it. It reports false for files that are already encrypted.
+func (c *FileCipher) EncryptFile(path string) (bool, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+	header := make([]byte, len(fileCipherMagic))
+	n, err := io.ReadFull(src, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	if c.Encrypted(header[:n]) {
+		return false, nil
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".encrypt-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := c.Encrypt(tmp)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp.Name(), path)
+}
+
+// nonce is the nonce of chunk number counter
+func (c *FileCipher) nonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(nonce)-4:], counter)
+	return nonce
+}
+
+// chunkAD is the additional data of a chunk, marking the last one
+func chunkAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type encryptWriter struct {
+	cipher  *FileCipher
+	w       io.Writer
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encrypting writer")
+	}
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data follows, so that the
+		// last chunk is always the one Close seals
+		if len(e.buf) == fileCipherChunk {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):fileCipherChunk], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.counter == ^uint32(0) {
+		return errors.New("file too large to encrypt")
+	}
+	sealed := e.cipher.aead.Seal(nil, e.cipher.nonce(e.prefix, e.counter), e.buf, chunkAD(last))
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+type decryptReader struct {
+	cipher  *FileCipher
+	r       *bufio.Reader
+	prefix  []byte
+	counter uint32
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	last := false
+	switch {
+	case err == io.EOF:
+		return errTruncatedFile
+	case err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		_, peekErr := d.r.Peek(1)
+		last = peekErr == io.EOF
+	}
+
+	plain, err := d.cipher.aead.Open(d.chunk[:0], d.cipher.nonce(d.prefix, d.counter), d.chunk[:n], chunkAD(last))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt file, is the encryption key right? %w", err)
+	}
+	d.counter++
+	d.plain = plain
+	d.done = last
+	return nil
+}
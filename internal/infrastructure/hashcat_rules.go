@@ -0,0 +1,42 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// hashcatRuleDirs are where hashcat packages install the rules directory
+// when it isn't next to the binary
+var hashcatRuleDirs = []string{
+	"/usr/share/hashcat/rules",
+	"/usr/local/share/hashcat/rules",
+	"/opt/homebrew/share/hashcat/rules",
+}
+
+// FindHashcatRule returns the path of one of hashcat's built-in rule files
+// for the hashcat binary given. Release archives keep them in rules next to
+// the binary, packages under share.
+func FindHashcatRule(binary, name string) (string, error) {
+	if !domain.BuiltinRuleFiles[name] {
+		return "", &domain.ValidationError{Field: "rules", Message: fmt.Sprintf("%q is not a built-in rule file", name)}
+	}
+
+	dirs := hashcatRuleDirs
+	if path, err := exec.LookPath(binary); err == nil {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		dirs = append([]string{filepath.Join(filepath.Dir(path), "rules")}, dirs...)
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", &domain.NotFoundError{Entity: "rule file " + name}
+}
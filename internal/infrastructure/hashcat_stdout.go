@@ -0,0 +1,183 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	// DefaultPreviewTimeout bounds one hashcat --stdout run
+	DefaultPreviewTimeout = 10 * time.Second
+	// DefaultPreviewWorkers is how many previews may run at once
+	DefaultPreviewWorkers = 2
+
+	previewStderrLimit = 4096
+	previewMaxLineSize = 64 * 1024
+)
+
+// HashcatStdoutGenerator generates candidates by running hashcat --stdout.
+// Each run is a short-lived worker process in an empty scratch directory
+// with a minimal environment, killed once enough lines were read or its
+// timeout expires. A fixed number of worker slots keeps previews from
+// competing with the server for CPU.
+type HashcatStdoutGenerator struct {
+	binary  string
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+func NewHashcatStdoutGenerator(binary string, timeout time.Duration, workers int) *HashcatStdoutGenerator {
+	if binary == "" {
+		binary = "hashcat"
+	}
+	if timeout <= 0 {
+		timeout = DefaultPreviewTimeout
+	}
+	if workers <= 0 {
+		workers = DefaultPreviewWorkers
+	}
+	return &HashcatStdoutGenerator{
+		binary:  binary,
+		timeout: timeout,
+		slots:   make(chan struct{}, workers),
+	}
+}
+
+func (g *HashcatStdoutGenerator) Generate(ctx context.Context, spec domain.CandidateSpec, limit int) ([]string, bool, error) {
+	select {
+	case g.slots <- struct{}{}:
+		defer func() { <-g.slots }()
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+
+	rulePath := ""
+	if spec.Rules != "" {
+		var err error
+		if rulePath, err = FindHashcatRule(g.binary, spec.Rules); err != nil {
+			return nil, false, err
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	workDir, err := os.MkdirTemp("", "hashcat-preview-")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create preview directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	cmd := exec.CommandContext(runCtx, g.binary, stdoutArgs(spec, rulePath)...)
+	cmd.Dir = workDir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + workDir,
+		"XDG_CACHE_HOME=" + workDir,
+		"XDG_CONFIG_HOME=" + workDir,
+		"XDG_DATA_HOME=" + workDir,
+	}
+	cmd.WaitDelay = time.Second
+	stderr := &limitedBuffer{limit: previewStderrLimit}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, false, fmt.Errorf("failed to start hashcat: %w", err)
+	}
+
+	candidates := make([]string, 0, limit)
+	truncated := false
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 4096), previewMaxLineSize)
+	for scanner.Scan() {
+		if len(candidates) == limit {
+			truncated = true
+			break
+		}
+		candidates = append(candidates, scanner.Text())
+	}
+	// Stop hashcat as soon as we have enough, the rest of the keyspace may be huge
+	cancel()
+	waitErr := cmd.Wait()
+
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+	if truncated {
+		return candidates, true, nil
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		// A slow attack still gives a useful preview of what it got to
+		if len(candidates) > 0 {
+			return candidates, true, nil
+		}
+		return nil, false, fmt.Errorf("hashcat produced no candidates within %s", g.timeout)
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(waitErr, &exitErr) {
+			return nil, false, &domain.ValidationError{Field: "attack", Message: "hashcat rejected the attack: " + stderr.firstLine()}
+		}
+		return nil, false, fmt.Errorf("hashcat failed: %w", waitErr)
+	}
+	return candidates, false, nil
+}
+
+// stdoutArgs builds the hashcat command line for spec, with its rule file
+// at rulePath. Mask and paths were validated by the caller, so none of them
+// can be taken for a flag.
+func stdoutArgs(spec domain.CandidateSpec, rulePath string) []string {
+	args := []string{"--stdout", "--quiet", "-a", strconv.Itoa(spec.AttackMode)}
+	if spec.AttackMode == domain.AttackModeBruteForce {
+		for i, charset := range spec.CustomCharsets {
+			if charset != "" {
+				args = append(args, fmt.Sprintf("-%d", i+1), charset)
+			}
+		}
+		return append(args, spec.Mask)
+	}
+	if rulePath != "" {
+		args = append(args, "-r", rulePath)
+	}
+	return append(args, spec.WordlistPath)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) firstLine() string {
+	for _, line := range strings.Split(b.buf.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return "no error output"
+}
@@ -15,8 +15,9 @@ import (
 
 // JWTService handles JWT token operations
 type JWTService struct {
-	secretKey     []byte
-	tokenDuration time.Duration
+	secretKey       []byte
+	tokenDuration   time.Duration // Lifetime of access tokens
+	sessionDuration time.Duration // Lifetime of sessions between refreshes
 }
 
 // NewJWTService creates a new JWT service
@@ -26,30 +27,58 @@ func NewJWTService() *JWTService {
 		secretKey = "your-secret-key-change-this-in-production" // Default fallback
 	}
 
-	// Get token duration from environment variable (in hours)
-	tokenDurationHours := 24 // Default 24 hours
+	// Access tokens are short-lived, revoking a session locks out its
+	// access tokens within this time at most
+	tokenDurationMinutes := 15 // Default 15 minutes
+	if minutesStr := os.Getenv("JWT_ACCESS_TOKEN_DURATION_MINUTES"); minutesStr != "" {
+		if minutes, err := strconv.Atoi(minutesStr); err == nil && minutes > 0 {
+			tokenDurationMinutes = minutes
+		}
+	}
+
+	// Get session duration from environment variable (in hours)
+	sessionDurationHours := 24 // Default 24 hours
 	if hoursStr := os.Getenv("JWT_TOKEN_DURATION_HOURS"); hoursStr != "" {
 		if hours, err := strconv.Atoi(hoursStr); err == nil {
-			tokenDurationHours = hours
+			sessionDurationHours = hours
 		}
 	}
 
 	return &JWTService{
-		secretKey:     []byte(secretKey),
-		tokenDuration: time.Duration(tokenDurationHours) * time.Hour,
+		secretKey:       []byte(secretKey),
+		tokenDuration:   time.Duration(tokenDurationMinutes) * time.Minute,
+		sessionDuration: time.Duration(sessionDurationHours) * time.Hour,
 	}
 }
 
-// GenerateToken generates a JWT token for a user
+// GenerateToken generates a JWT token for a user, outside of any session
 func (j *JWTService) GenerateToken(user *domain.User) (string, time.Time, error) {
+	return j.GenerateSessionToken(user, nil)
+}
+
+// GenerateSessionToken generates an access token of a session
+func (j *JWTService) GenerateSessionToken(user *domain.User, session *domain.Session) (string, time.Time, error) {
 	now := time.Now()
 	expiresAt := now.Add(j.tokenDuration)
 
+	var tenantID string
+	if user.TenantID != nil {
+		tenantID = user.TenantID.String()
+	}
+	var sessionID string
+	var twoFactor bool
+	if session != nil {
+		sessionID = session.ID.String()
+		twoFactor = session.TwoFactor
+	}
 	claims := &domain.JWTClaims{
-		UserID:   user.ID.String(),
-		Username: user.Username,
-		Email:    user.Email,
-		Role:     user.Role,
+		UserID:    user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		TenantID:  tenantID,
+		TwoFactor: twoFactor,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "go-distributed-hashcat",
 			Subject:   user.ID.String(),
@@ -124,3 +153,8 @@ func (j *JWTService) RefreshToken(tokenString string) (string, time.Time, error)
 func (j *JWTService) GetTokenDuration() time.Duration {
 	return j.tokenDuration
 }
+
+// GetSessionDuration returns how long sessions last without a refresh
+func (j *JWTService) GetSessionDuration() time.Duration {
+	return j.sessionDuration
+}
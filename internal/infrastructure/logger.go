@@ -1,9 +1,16 @@
 package infrastructure
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 // LogLevel represents different log levels
@@ -17,9 +24,97 @@ const (
 	LogLevelDebug   LogLevel = "DEBUG"
 )
 
+// Log output formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// slogLevels maps the log levels to slog levels. SUCCESS sits between INFO
+// and WARNING so that it is kept whenever INFO is.
+var slogLevels = map[LogLevel]slog.Level{
+	LogLevelDebug:   slog.LevelDebug,
+	LogLevelInfo:    slog.LevelInfo,
+	LogLevelSuccess: slog.LevelInfo + 2,
+	LogLevelWarning: slog.LevelWarn,
+	LogLevelError:   slog.LevelError,
+}
+
+// logOutput is the backend shared by all loggers, set by ConfigureLogging
+var logOutput = struct {
+	sync.RWMutex
+	json  *slog.Logger // nil in text mode
+	level slog.LevelVar
+}{}
+
+// ConfigureLogging sets the output format (text or json) and the minimum
+// level (debug, info, warning or error) of all loggers. In JSON mode the
+// standard log package is redirected as well, so every line is parseable.
+func ConfigureLogging(format, level string, w io.Writer) error {
+	lvl, err := ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != "" && format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+	if w == nil {
+		w = os.Stderr
+	}
+
+	logOutput.Lock()
+	defer logOutput.Unlock()
+
+	logOutput.level.Set(slogLevels[lvl])
+
+	if format == LogFormatJSON {
+		handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       &logOutput.level,
+			ReplaceAttr: replaceLevelName,
+		})
+		logOutput.json = slog.New(handler)
+		slog.SetDefault(logOutput.json)
+	} else {
+		logOutput.json = nil
+		log.SetOutput(w)
+	}
+	return nil
+}
+
+// ParseLogLevel parses a level name, case-insensitively
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "", "INFO":
+		return LogLevelInfo, nil
+	case "DEBUG":
+		return LogLevelDebug, nil
+	case "WARN", "WARNING":
+		return LogLevelWarning, nil
+	case "ERROR":
+		return LogLevelError, nil
+	}
+	return "", fmt.Errorf("unknown log level %q, expected debug, info, warning or error", level)
+}
+
+// replaceLevelName writes SUCCESS and WARNING instead of slog's INFO+2 and WARN
+func replaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey || len(groups) > 0 {
+		return a
+	}
+	level, _ := a.Value.Any().(slog.Level)
+	for name, l := range slogLevels {
+		if l == level {
+			return slog.String(slog.LevelKey, string(name))
+		}
+	}
+	return a
+}
+
 // Logger provides structured logging with timestamps and levels
 type Logger struct {
 	prefix string
+	fields []any // Alternating keys and values added to every line
 }
 
 // NewLogger creates a new logger with optional prefix
@@ -27,49 +122,109 @@ func NewLogger(prefix string) *Logger {
 	return &Logger{prefix: prefix}
 }
 
+// With returns a logger that adds the given key-value pairs to every line,
+// e.g. With("job_id", job.ID)
+func (l *Logger) With(args ...any) *Logger {
+	fields := make([]any, 0, len(l.fields)+len(args))
+	fields = append(fields, l.fields...)
+	fields = append(fields, args...)
+	return &Logger{prefix: l.prefix, fields: fields}
+}
+
+// WithContext returns a logger that adds the request ID stored in ctx, if any
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return l.With("request_id", id)
+	}
+	return l
+}
+
 // formatMessage formats a log message with timestamp and level
 func (l *Logger) formatMessage(level LogLevel, message string) string {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	if l.prefix != "" {
-		return fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, level, l.prefix, message)
+		message = fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, level, l.prefix, message)
+	} else {
+		message = fmt.Sprintf("[%s] [%s] %s", timestamp, level, message)
+	}
+
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		message += fmt.Sprintf(" %v=%v", l.fields[i], l.fields[i+1])
 	}
-	return fmt.Sprintf("[%s] [%s] %s", timestamp, level, message)
+	return message
+}
+
+// output writes one line in the configured format, dropping it if it is
+// below the configured level
+func (l *Logger) output(level LogLevel, format string, args []any) {
+	logOutput.RLock()
+	jsonLogger := logOutput.json
+	minLevel := logOutput.level.Level()
+	logOutput.RUnlock()
+
+	if slogLevels[level] < minLevel {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+	if jsonLogger == nil {
+		log.Print(l.formatMessage(level, message))
+		return
+	}
+
+	attrs := l.fields
+	if l.prefix != "" {
+		attrs = append([]any{"component", strings.ToLower(l.prefix)}, l.fields...)
+	}
+	// Emoji markers help on a terminal but only get in the way of queries
+	message = strings.TrimLeftFunc(message, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.Is(unicode.So, r) || r == '\uFE0F'
+	})
+	jsonLogger.Log(context.Background(), slogLevels[level], message, attrs...)
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Print(l.formatMessage(LogLevelInfo, message))
+	l.output(LogLevelInfo, format, args)
 }
 
 // Warning logs a warning message
 func (l *Logger) Warning(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Print(l.formatMessage(LogLevelWarning, message))
+	l.output(LogLevelWarning, format, args)
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Print(l.formatMessage(LogLevelError, message))
+	l.output(LogLevelError, format, args)
 }
 
 // Success logs a success message
 func (l *Logger) Success(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Print(l.formatMessage(LogLevelSuccess, message))
+	l.output(LogLevelSuccess, format, args)
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Print(l.formatMessage(LogLevelDebug, message))
+	l.output(LogLevelDebug, format, args)
 }
 
 // Fatal logs a fatal error and exits
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	log.Fatal(l.formatMessage(LogLevelError, message))
+	l.output(LogLevelError, format, args)
+	os.Exit(1)
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID stores the ID of the HTTP request being served
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
 }
 
 // Global logger instances
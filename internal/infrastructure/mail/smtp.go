@@ -0,0 +1,193 @@
+// Package mail sends emails through an SMTP server, such as the digests of
+// projects.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// sendTimeout bounds one email, from connecting to the server to QUIT
+const sendTimeout = 30 * time.Second
+
+// Config is the SMTP server emails are sent through
+type Config struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"` // 465 is implicit TLS, anything else upgrades with STARTTLS when offered
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"` // e.g. Hashcat <hashcat@example.com>
+}
+
+type smtpMailer struct {
+	config Config
+	from   string // Address part of config.From, for MAIL FROM
+}
+
+// NewSMTPMailer checks the config and returns a mailer for it
+func NewSMTPMailer(config Config) (domain.Mailer, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	from, err := parseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", config.From, err)
+	}
+	return &smtpMailer{config: config, from: from}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message *domain.EmailMessage) error {
+	if len(message.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+	recipients := make([]string, len(message.To))
+	for i, to := range message.To {
+		address, err := parseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+		recipients[i] = address
+	}
+	body, err := Compose(m.config.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	client, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", m.config.Host, err)
+	}
+	defer client.Close()
+
+	if err := m.deliver(client, recipients, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+func (m *smtpMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if m.config.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.config.Host})
+	}
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (m *smtpMailer) deliver(client *smtp.Client, recipients []string, body []byte) error {
+	if m.config.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if m.config.Username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// Compose writes an email with its HTML body and the plain text alternative
+func Compose(from string, message *domain.EmailMessage, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	// Line breaks would start another header
+	oneLine := strings.NewReplacer("\r", "", "\n", "")
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, oneLine.Replace(value))
+	}
+	header("From", from)
+	header("To", strings.Join(message.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", oneLine.Replace(message.Subject)))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+
+	// Clients show the last part they can, so the HTML goes last
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseAddress returns the address of "Name <address>" or a bare address
+func parseAddress(value string) (string, error) {
+	address, err := netmail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
@@ -0,0 +1,458 @@
+// Package oidc logs users in with an OpenID Connect identity provider using
+// the authorization code flow. It speaks just enough of the protocol for
+// that: discovery, the token endpoint and verifying ID tokens against the
+// provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	httpTimeout     = 10 * time.Second
+	maxResponseSize = 1 << 20
+	// discoveryTTL is how long the provider's endpoints and keys are used
+	// before they are fetched again
+	discoveryTTL = time.Hour
+	// keyRefetchInterval limits how often an unknown key ID makes the keys
+	// be fetched again, as providers rotate keys rarely
+	keyRefetchInterval = 30 * time.Second
+)
+
+// signingMethods are the ID token algorithms accepted. Tokens signed with
+// a shared secret or not at all are refused.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Config holds the identity provider and how its claims are read
+type Config struct {
+	IssuerURL     string `mapstructure:"issuer_url"`     // Single sign-on is off when empty
+	ClientID      string `mapstructure:"client_id"`      // This server's client at the provider
+	ClientSecret  string `mapstructure:"client_secret"`  // Empty for public clients
+	RedirectURL   string `mapstructure:"redirect_url"`   // Where the provider sends the browser back: /api/v1/auth/oidc/callback
+	Scopes        string `mapstructure:"scopes"`         // Comma-separated, openid is always asked for
+	UsernameClaim string `mapstructure:"username_claim"` // Defaults to preferred_username
+	RoleClaim     string `mapstructure:"role_claim"`     // Claim holding groups or roles; dots reach nested claims, e.g. realm_access.roles
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex // Guards the fields below
+	meta          *metadata
+	metaFetchedAt time.Time
+	keys          map[string]interface{} // Public keys by key ID
+	keysFetchedAt time.Time
+}
+
+// NewProvider returns the provider at config.IssuerURL. Its endpoints are
+// discovered on first use, so the server starts while it is unreachable.
+func NewProvider(config Config) (domain.SSOProvider, error) {
+	if config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc: issuer_url, client_id and redirect_url are required")
+	}
+	if _, err := url.Parse(config.RedirectURL); err != nil {
+		return nil, fmt.Errorf("oidc: invalid redirect_url: %w", err)
+	}
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	return &provider{config: config, client: &http.Client{Timeout: httpTimeout}}, nil
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.config.ClientID},
+		"redirect_uri":  {p.config.RedirectURL},
+		"scope":         {strings.Join(p.scopes(), " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code, nonce string) (*domain.SSOLogin, error) {
+	response, err := p.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.config.RedirectURL},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.IDToken == "" {
+		return nil, fmt.Errorf("oidc: token response has no ID token")
+	}
+
+	claims, err := p.verify(ctx, response.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SSOLogin{Claims: claims, RefreshToken: response.RefreshToken}, nil
+}
+
+func (p *provider) Refresh(ctx context.Context, refreshToken string) (*domain.SSOLogin, error) {
+	response, err := p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may keep the refresh token and leave out the ID token
+	login := &domain.SSOLogin{RefreshToken: response.RefreshToken}
+	if login.RefreshToken == "" {
+		login.RefreshToken = refreshToken
+	}
+	if response.IDToken != "" {
+		if login.Claims, err = p.verify(ctx, response.IDToken, ""); err != nil {
+			return nil, err
+		}
+	}
+	return login, nil
+}
+
+func (p *provider) scopes() []string {
+	scopes := []string{"openid"}
+	configured := p.config.Scopes
+	if configured == "" {
+		configured = "profile,email"
+	}
+	for _, scope := range strings.Split(configured, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// token posts form to the token endpoint, authenticating as the client
+func (p *provider) token(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var response tokenResponse
+	status, err := p.do(req, &response)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || response.Error != "" {
+		if response.Error == "" {
+			response.Error = http.StatusText(status)
+		}
+		if response.ErrorDescription != "" {
+			return nil, fmt.Errorf("oidc: token endpoint: %s: %s", response.Error, response.ErrorDescription)
+		}
+		return nil, fmt.Errorf("oidc: token endpoint: %s", response.Error)
+	}
+	return &response, nil
+}
+
+// verify checks an ID token's signature, issuer, audience, expiry and, when
+// nonce isn't empty, nonce
+func (p *provider) verify(ctx context.Context, rawToken, nonce string) (*domain.SSOClaims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.key(ctx, meta, keyID)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, fmt.Errorf("oidc: invalid ID token: nonce mismatch")
+		}
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("oidc: invalid ID token: no subject")
+	}
+	return &domain.SSOClaims{
+		Subject:       subject,
+		Email:         stringClaim(claims, "email"),
+		EmailVerified: boolClaim(claims, "email_verified"),
+		Username:      stringClaim(claims, p.config.UsernameClaim),
+		Roles:         listClaim(claims, p.config.RoleClaim),
+	}, nil
+}
+
+// metadata returns the provider's endpoints, discovering them when they
+// weren't yet or are older than discoveryTTL
+func (p *provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil && time.Since(p.metaFetchedAt) < discoveryTTL {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+	var meta metadata
+	if status, err := p.do(req, &meta); err != nil {
+		return nil, err
+	} else if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discovery failed: %s", http.StatusText(status))
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("oidc: provider issuer %q doesn't match %q", meta.Issuer, p.config.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery document is missing endpoints")
+	}
+
+	p.meta = &meta
+	p.metaFetchedAt = time.Now()
+	p.keys = nil
+	return p.meta, nil
+}
+
+// key returns the public key with keyID, fetching the provider's keys when
+// it is unknown. Tokens without a key ID need the provider to have one key.
+func (p *provider) key(ctx context.Context, meta *metadata, keyID string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.findKey(keyID); key != nil {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < keyRefetchInterval {
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if status, err := p.do(req, &set); err != nil {
+		return nil, err
+	} else if status != http.StatusOK {
+		return nil, fmt.Errorf("fetching signing keys failed: %s", http.StatusText(status))
+	}
+
+	p.keys = make(map[string]interface{}, len(set.Keys))
+	p.keysFetchedAt = time.Now()
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of types this server doesn't verify with are skipped
+		if key, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+
+	if key := p.findKey(keyID); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+func (p *provider) findKey(keyID string) interface{} {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[keyID]
+}
+
+// do sends req and decodes the JSON response into v, whatever its status
+func (p *provider) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("oidc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, fmt.Errorf("oidc: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("oidc: invalid response from %s: %w", req.URL.Host, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// jsonWebKey is a public key of the provider's JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA exponent
+	Crv string `json:"crv"` // EC curve
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// lookupClaim finds a claim by name, or by a dotted path into nested
+// claims when no claim has the whole name
+func lookupClaim(claims map[string]interface{}, name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = nested[part]
+	}
+	return value
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := lookupClaim(claims, name).(string)
+	return value
+}
+
+// boolClaim reads a boolean claim, which some providers send as a string
+func boolClaim(claims map[string]interface{}, name string) bool {
+	switch value := lookupClaim(claims, name).(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// listClaim reads a claim holding one string or a list of them
+func listClaim(claims map[string]interface{}, name string) []string {
+	switch value := lookupClaim(claims, name).(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+const (
+	DefaultRedisChannel = "hashcat:events"
+
+	redisDialTimeout   = 5 * time.Second
+	redisIOTimeout     = 5 * time.Second
+	maxResubscribeWait = 30 * time.Second
+)
+
+// RedisConfig holds the Redis server used to relay realtime events
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr"`     // host:port
+	Password string `mapstructure:"password"` // Optional
+	Channel  string `mapstructure:"channel"`  // Defaults to DefaultRedisChannel
+}
+
+// redisRelay publishes and subscribes to one Redis channel. It speaks just
+// enough of the Redis protocol for AUTH, PUBLISH and SUBSCRIBE.
+type redisRelay struct {
+	config RedisConfig
+
+	mu   sync.Mutex // Guards pub, the connection used to publish
+	pub  net.Conn
+	pubR *bufio.Reader
+}
+
+func NewRedisRelay(config RedisConfig) (domain.EventRelay, error) {
+	if config.Addr == "" {
+		return nil, fmt.Errorf("redis: addr is required")
+	}
+	if config.Channel == "" {
+		config.Channel = DefaultRedisChannel
+	}
+	return &redisRelay{config: config}, nil
+}
+
+func (r *redisRelay) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := net.Dialer{Timeout: redisDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.config.Addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+
+	if r.config.Password != "" {
+		if _, err := roundTrip(conn, reader, "AUTH", r.config.Password); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("redis: auth: %w", err)
+		}
+	}
+	return conn, reader, nil
+}
+
+// Publish sends payload to every subscriber of the channel. A broken
+// connection is replaced once before giving up.
+func (r *redisRelay) Publish(ctx context.Context, payload []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if r.pub == nil {
+			if r.pub, r.pubR, err = r.dial(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err = roundTrip(r.pub, r.pubR, "PUBLISH", r.config.Channel, string(payload)); err == nil {
+			return nil
+		}
+		var replyErr redisError
+		if errors.As(err, &replyErr) {
+			return fmt.Errorf("redis: publish: %w", err)
+		}
+		r.pub.Close()
+		r.pub, r.pubR = nil, nil
+	}
+	return fmt.Errorf("redis: publish: %w", err)
+}
+
+// Subscribe delivers the channel's messages to handle until ctx is done,
+// resubscribing with a growing delay when the connection drops
+func (r *redisRelay) Subscribe(ctx context.Context, handle func(payload []byte)) error {
+	wait := time.Second
+	for {
+		err := r.subscribeOnce(ctx, handle, func() { wait = time.Second })
+		if ctx.Err() != nil {
+			return nil
+		}
+		infrastructure.ServerLogger.Warning("Redis subscription to %s lost, retrying in %s: %v", r.config.Channel, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxResubscribeWait {
+			wait = maxResubscribeWait
+		}
+	}
+}
+
+func (r *redisRelay) subscribeOnce(ctx context.Context, handle func(payload []byte), subscribed func()) error {
+	conn, reader, err := r.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock the read below when ctx is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := writeCommand(conn, "SUBSCRIBE", r.config.Channel); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) < 3 {
+			continue
+		}
+		kind, _ := parts[0].(string)
+		switch kind {
+		case "subscribe":
+			infrastructure.ServerLogger.Info("Subscribed to Redis channel %s for realtime events", r.config.Channel)
+			subscribed()
+		case "message":
+			if payload, ok := parts[2].(string); ok {
+				handle([]byte(payload))
+			}
+		}
+	}
+}
+
+// redisError is an error reply from the server, as opposed to a broken connection
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+func roundTrip(conn net.Conn, reader *bufio.Reader, args ...string) (interface{}, error) {
+	if err := writeCommand(conn, args...); err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(redisIOTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	return readReply(reader)
+}
+
+func writeCommand(conn net.Conn, args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(redisIOTimeout))
+	defer conn.SetWriteDeadline(time.Time{})
+	_, err := conn.Write(buf)
+	return err
+}
+
+// readReply reads one reply. Simple and bulk strings are returned as
+// string, integers as int64 and arrays as []interface{}.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// benchmarkJobColumns is the column list every benchmark job SELECT
+// returns, in scanBenchmarkJob order
+const benchmarkJobColumns = `id, agent_id, hash_types, status, error, created_at, started_at, completed_at`
+
+type agentBenchmarkRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewAgentBenchmarkRepository(db *database.SQLiteDB) domain.AgentBenchmarkRepository {
+	return &agentBenchmarkRepository{db: db}
+}
+
+func (r *agentBenchmarkRepository) GetByAgent(ctx context.Context, agentID uuid.UUID) ([]domain.AgentBenchmark, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT hash_type, speed, measured_at FROM agent_benchmarks
+		WHERE agent_id = ?
+		ORDER BY hash_type
+	`, agentID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	benchmarks := []domain.AgentBenchmark{}
+	for rows.Next() {
+		benchmark := domain.AgentBenchmark{AgentID: agentID}
+		if err := rows.Scan(&benchmark.HashType, &benchmark.Speed, &benchmark.MeasuredAt); err != nil {
+			return nil, err
+		}
+		benchmarks = append(benchmarks, benchmark)
+	}
+	return benchmarks, rows.Err()
+}
+
+func (r *agentBenchmarkRepository) GetSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error) {
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT agent_id, speed FROM agent_benchmarks WHERE hash_type = ? AND speed > 0`, hashType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	speeds := make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var agentIDStr string
+		var speed int64
+		if err := rows.Scan(&agentIDStr, &speed); err != nil {
+			return nil, err
+		}
+		if agentID, err := uuid.Parse(agentIDStr); err == nil {
+			speeds[agentID] = speed
+		}
+	}
+	return speeds, rows.Err()
+}
+
+func (r *agentBenchmarkRepository) Save(ctx context.Context, benchmarks []domain.AgentBenchmark) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, benchmark := range benchmarks {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO agent_benchmarks (agent_id, hash_type, speed, measured_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (agent_id, hash_type) DO UPDATE SET speed = excluded.speed, measured_at = excluded.measured_at
+		`, benchmark.AgentID.String(), benchmark.HashType, benchmark.Speed, benchmark.MeasuredAt); err != nil {
+			return fmt.Errorf("failed to save benchmark: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *agentBenchmarkRepository) Invalidate(ctx context.Context, agentID uuid.UUID, at time.Time) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_benchmarks WHERE agent_id = ?`, agentID.String()); err != nil {
+		return fmt.Errorf("failed to remove benchmarks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO agent_benchmark_resets (agent_id, reset_at) VALUES (?, ?)
+		ON CONFLICT (agent_id) DO UPDATE SET reset_at = excluded.reset_at
+	`, agentID.String(), at); err != nil {
+		return fmt.Errorf("failed to record benchmark reset: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *agentBenchmarkRepository) GetResetAt(ctx context.Context, agentID uuid.UUID) (*time.Time, error) {
+	var resetAt time.Time
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT reset_at FROM agent_benchmark_resets WHERE agent_id = ?`, agentID.String()).Scan(&resetAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resetAt, nil
+}
+
+func (r *agentBenchmarkRepository) CreateJob(ctx context.Context, job *domain.BenchmarkJob) error {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	hashTypes, err := json.Marshal(job.HashTypes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, `
+		INSERT INTO agent_benchmark_jobs (`+benchmarkJobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.ID.String(), job.AgentID.String(), string(hashTypes), job.Status, job.Error,
+		job.CreatedAt, job.StartedAt, job.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark job: %w", err)
+	}
+	return nil
+}
+
+func (r *agentBenchmarkRepository) GetJob(ctx context.Context, id uuid.UUID) (*domain.BenchmarkJob, error) {
+	job, err := scanBenchmarkJob(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+benchmarkJobColumns+` FROM agent_benchmark_jobs WHERE id = ?`, id.String()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &domain.NotFoundError{Entity: "benchmark job"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *agentBenchmarkRepository) GetJobs(ctx context.Context, agentID uuid.UUID, limit int) ([]domain.BenchmarkJob, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+benchmarkJobColumns+` FROM agent_benchmark_jobs
+		WHERE agent_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, agentID.String(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []domain.BenchmarkJob{}
+	for rows.Next() {
+		job, err := scanBenchmarkJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *agentBenchmarkRepository) ClaimJob(ctx context.Context, agentID uuid.UUID, at time.Time) (*domain.BenchmarkJob, error) {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	job, err := scanBenchmarkJob(tx.QueryRowContext(ctx, `
+		SELECT `+benchmarkJobColumns+` FROM agent_benchmark_jobs
+		WHERE agent_id = ? AND status IN (?, ?)
+		ORDER BY created_at
+		LIMIT 1
+	`, agentID.String(), domain.BenchmarkJobPending, domain.BenchmarkJobRunning))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE agent_benchmark_jobs SET status = ?, started_at = ? WHERE id = ?`,
+		domain.BenchmarkJobRunning, at, job.ID.String()); err != nil {
+		return nil, fmt.Errorf("failed to claim benchmark job: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	job.Status, job.StartedAt = domain.BenchmarkJobRunning, &at
+	return &job, nil
+}
+
+func (r *agentBenchmarkRepository) UpdateJob(ctx context.Context, job *domain.BenchmarkJob) error {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE agent_benchmark_jobs SET status = ?, error = ?, started_at = ?, completed_at = ? WHERE id = ?
+	`, job.Status, job.Error, job.StartedAt, job.CompletedAt, job.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update benchmark job: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return &domain.NotFoundError{Entity: "benchmark job"}
+	}
+	return nil
+}
+
+// scanBenchmarkJob scans a single row selected with benchmarkJobColumns
+func scanBenchmarkJob(row rowScanner) (domain.BenchmarkJob, error) {
+	var job domain.BenchmarkJob
+	var id, agentID, hashTypes string
+	var startedAt, completedAt sql.NullTime
+	if err := row.Scan(&id, &agentID, &hashTypes, &job.Status, &job.Error, &job.CreatedAt, &startedAt, &completedAt); err != nil {
+		return job, err
+	}
+	var err error
+	if job.ID, err = uuid.Parse(id); err != nil {
+		return job, fmt.Errorf("invalid benchmark job ID %q: %w", id, err)
+	}
+	if job.AgentID, err = uuid.Parse(agentID); err != nil {
+		return job, fmt.Errorf("invalid agent ID %q: %w", agentID, err)
+	}
+	if err := json.Unmarshal([]byte(hashTypes), &job.HashTypes); err != nil {
+		return job, fmt.Errorf("invalid hash modes of benchmark job %s: %w", id, err)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return job, nil
+}
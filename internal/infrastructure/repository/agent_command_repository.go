@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// agentCommandColumns is the column list every agent command SELECT
+// returns, in scanAgentCommand order
+const agentCommandColumns = `id, agent_id, type, params, status, result, error, created_at, expires_at, acknowledged_at, completed_at`
+
+type agentCommandRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewAgentCommandRepository(db *database.SQLiteDB) domain.AgentCommandRepository {
+	return &agentCommandRepository{db: db}
+}
+
+func (r *agentCommandRepository) Create(ctx context.Context, command *domain.AgentCommand) error {
+	if command.ID == uuid.Nil {
+		command.ID = uuid.New()
+	}
+	if command.CreatedAt.IsZero() {
+		command.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO agent_commands (`+agentCommandColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, command.ID.String(), command.AgentID.String(), command.Type, nullableJSON(command.Params), command.Status,
+		nullableJSON(command.Result), command.Error, command.CreatedAt, command.ExpiresAt,
+		command.AcknowledgedAt, command.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create agent command: %w", err)
+	}
+	return nil
+}
+
+func (r *agentCommandRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AgentCommand, error) {
+	command, err := scanAgentCommand(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+agentCommandColumns+` FROM agent_commands WHERE id = ?`, id.String()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &domain.NotFoundError{Entity: "agent command"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
+
+func (r *agentCommandRepository) GetByAgent(ctx context.Context, agentID uuid.UUID, limit int) ([]domain.AgentCommand, error) {
+	return r.query(ctx, `
+		SELECT `+agentCommandColumns+` FROM agent_commands
+		WHERE agent_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, agentID.String(), limit)
+}
+
+func (r *agentCommandRepository) GetPending(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error) {
+	return r.query(ctx, `
+		SELECT `+agentCommandColumns+` FROM agent_commands
+		WHERE agent_id = ? AND status = ?
+		ORDER BY created_at
+	`, agentID.String(), domain.AgentCommandPending)
+}
+
+func (r *agentCommandRepository) Expire(ctx context.Context, agentID uuid.UUID, now time.Time) error {
+	if _, err := r.db.DB().ExecContext(ctx, `
+		UPDATE agent_commands SET status = ?, completed_at = ?
+		WHERE agent_id = ? AND status = ? AND expires_at <= ?
+	`, domain.AgentCommandExpired, now, agentID.String(), domain.AgentCommandPending, now); err != nil {
+		return fmt.Errorf("failed to expire agent commands: %w", err)
+	}
+	return nil
+}
+
+func (r *agentCommandRepository) Transition(ctx context.Context, command *domain.AgentCommand, from string) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE agent_commands SET status = ?, result = ?, error = ?, acknowledged_at = ?, completed_at = ?
+		WHERE id = ? AND status = ?
+	`, command.Status, nullableJSON(command.Result), command.Error, command.AcknowledgedAt, command.CompletedAt,
+		command.ID.String(), from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update agent command: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *agentCommandRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.AgentCommand, error) {
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := []domain.AgentCommand{}
+	for rows.Next() {
+		command, err := scanAgentCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, command)
+	}
+	return commands, rows.Err()
+}
+
+// nullableJSON stores an empty JSON value as NULL
+func nullableJSON(value json.RawMessage) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
+
+// scanAgentCommand scans a single row selected with agentCommandColumns
+func scanAgentCommand(row rowScanner) (domain.AgentCommand, error) {
+	var command domain.AgentCommand
+	var id, agentID string
+	var params, result sql.NullString
+	var acknowledgedAt, completedAt sql.NullTime
+	if err := row.Scan(&id, &agentID, &command.Type, &params, &command.Status, &result, &command.Error,
+		&command.CreatedAt, &command.ExpiresAt, &acknowledgedAt, &completedAt); err != nil {
+		return command, err
+	}
+	var err error
+	if command.ID, err = uuid.Parse(id); err != nil {
+		return command, fmt.Errorf("invalid agent command ID %q: %w", id, err)
+	}
+	if command.AgentID, err = uuid.Parse(agentID); err != nil {
+		return command, fmt.Errorf("invalid agent ID %q: %w", agentID, err)
+	}
+	if params.Valid {
+		command.Params = json.RawMessage(params.String)
+	}
+	if result.Valid {
+		command.Result = json.RawMessage(result.String)
+	}
+	if acknowledgedAt.Valid {
+		command.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if completedAt.Valid {
+		command.CompletedAt = &completedAt.Time
+	}
+	return command, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// networkRuleColumns is the column list every network rule SELECT returns,
+// in scanNetworkRule order
+const networkRuleColumns = `id, agent_id, cidr, action, description, created_at`
+
+// accessDenialColumns is the column list every access denial SELECT
+// returns, in scanAccessDenial order
+const accessDenialColumns = `id, ip_address, agent_id, agent_name, agent_key, path, reason, created_at`
+
+type agentNetworkRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewAgentNetworkRepository(db *database.SQLiteDB) domain.AgentNetworkRepository {
+	return &agentNetworkRepository{db: db}
+}
+
+func (r *agentNetworkRepository) CreateRule(ctx context.Context, rule *domain.AgentNetworkRule) error {
+	if rule.ID == uuid.Nil {
+		rule.ID = uuid.New()
+	}
+	rule.CreatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO agent_network_rules (`+networkRuleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rule.ID.String(), nullableUUID(rule.AgentID), rule.CIDR, rule.Action, rule.Description, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create network rule: %w", err)
+	}
+	return nil
+}
+
+func (r *agentNetworkRepository) GetRules(ctx context.Context) ([]domain.AgentNetworkRule, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+networkRuleColumns+` FROM agent_network_rules ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []domain.AgentNetworkRule{}
+	for rows.Next() {
+		rule, err := scanNetworkRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *agentNetworkRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM agent_network_rules WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "network rule"}
+	}
+	return nil
+}
+
+func (r *agentNetworkRepository) RecordDenial(ctx context.Context, denial *domain.AgentAccessDenial, keep int) error {
+	if denial.CreatedAt.IsZero() {
+		denial.CreatedAt = time.Now()
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO agent_access_denials (ip_address, agent_id, agent_name, agent_key, path, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, denial.IPAddress, nullableUUID(denial.AgentID), denial.AgentName, denial.AgentKey, denial.Path, denial.Reason, denial.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record access denial: %w", err)
+	}
+	if denial.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	if keep > 0 {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM agent_access_denials WHERE id <= (
+				SELECT id FROM agent_access_denials ORDER BY id DESC LIMIT 1 OFFSET ?
+			)`,
+			keep,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to rotate access denials: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *agentNetworkRepository) GetDenials(ctx context.Context, limit int) ([]domain.AgentAccessDenial, error) {
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT `+accessDenialColumns+` FROM agent_access_denials ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	denials := []domain.AgentAccessDenial{}
+	for rows.Next() {
+		denial, err := scanAccessDenial(rows)
+		if err != nil {
+			return nil, err
+		}
+		denials = append(denials, denial)
+	}
+	return denials, rows.Err()
+}
+
+// scanNetworkRule scans a single row selected with networkRuleColumns
+func scanNetworkRule(row rowScanner) (domain.AgentNetworkRule, error) {
+	var rule domain.AgentNetworkRule
+	var id string
+	var agentID sql.NullString
+
+	err := row.Scan(
+		&id,
+		&agentID,
+		&rule.CIDR,
+		&rule.Action,
+		&rule.Description,
+		&rule.CreatedAt,
+	)
+	if err != nil {
+		return rule, err
+	}
+
+	rule.ID = uuid.MustParse(id)
+	rule.AgentID = parseNullableUUID(agentID)
+	return rule, nil
+}
+
+// scanAccessDenial scans a single row selected with accessDenialColumns
+func scanAccessDenial(row rowScanner) (domain.AgentAccessDenial, error) {
+	var denial domain.AgentAccessDenial
+	var agentID sql.NullString
+
+	err := row.Scan(
+		&denial.ID,
+		&denial.IPAddress,
+		&agentID,
+		&denial.AgentName,
+		&denial.AgentKey,
+		&denial.Path,
+		&denial.Reason,
+		&denial.CreatedAt,
+	)
+	if err != nil {
+		return denial, err
+	}
+
+	denial.AgentID = parseNullableUUID(agentID)
+	return denial, nil
+}
@@ -3,11 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
 	"go-distributed-hashcat/internal/infrastructure/cache"
 	"go-distributed-hashcat/internal/infrastructure/database"
 
@@ -41,7 +42,7 @@ func (r *agentRepository) prepareStatements() {
 	var err error
 
 	r.getByIDStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
 		FROM agents WHERE id = ? LIMIT 1
 	`)
 	if err != nil {
@@ -49,7 +50,7 @@ func (r *agentRepository) prepareStatements() {
 	}
 
 	r.getByNameStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
 		FROM agents WHERE name = ? LIMIT 1
 	`)
 	if err != nil {
@@ -57,7 +58,7 @@ func (r *agentRepository) prepareStatements() {
 	}
 
 	r.getByNameIPStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
 		FROM agents WHERE name = ? AND ip_address = ? AND port = ? LIMIT 1
 	`)
 	if err != nil {
@@ -65,7 +66,7 @@ func (r *agentRepository) prepareStatements() {
 	}
 
 	r.getByIPAddressStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
 		FROM agents WHERE ip_address = ? LIMIT 1
 	`)
 	if err != nil {
@@ -73,8 +74,8 @@ func (r *agentRepository) prepareStatements() {
 	}
 
 	r.getAllStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
-		FROM agents ORDER BY created_at DESC, id ASC
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
+		FROM agents WHERE archived_at IS NULL ORDER BY created_at DESC, id ASC
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getAll statement: %v", err))
@@ -97,7 +98,7 @@ func (r *agentRepository) prepareStatements() {
 	}
 
 	r.getByAgentKeyStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at
+		SELECT id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, heartbeat, hashcat_version, tenant_id
 		FROM agents WHERE agent_key = ? LIMIT 1
 	`)
 	if err != nil {
@@ -122,9 +123,10 @@ func (r *agentRepository) Create(ctx context.Context, agent *domain.Agent) error
 	r.cache.Delete(ctx, "agents:all")
 
 	query := `
-        INSERT INTO agents (id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO agents (id, name, ip_address, port, status, capabilities, agent_key, speed, last_seen, created_at, updated_at, tenant_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
+	agent.TenantID = tenantOf(ctx, agent.TenantID)
 
 	_, err := r.db.DB().ExecContext(ctx, query,
 		agent.ID.String(),
@@ -138,6 +140,7 @@ func (r *agentRepository) Create(ctx context.Context, agent *domain.Agent) error
 		agent.LastSeen,
 		agent.CreatedAt,
 		agent.UpdatedAt,
+		nullableUUID(agent.TenantID),
 	)
 
 	if err != nil {
@@ -152,7 +155,7 @@ func (r *agentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Ag
 
 	var agent domain.Agent
 	if found, err := r.cache.Get(ctx, cacheKey, &agent); err == nil && found {
-		return &agent, nil
+		return tenantAgent(ctx, &agent)
 	}
 
 	var idStr string
@@ -168,6 +171,9 @@ func (r *agentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Ag
 		&agent.LastSeen,
 		&agent.CreatedAt,
 		&agent.UpdatedAt,
+		heartbeatColumn{&agent.Heartbeat},
+		&agent.HashcatVersion,
+		uuidColumn{&agent.TenantID},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -179,7 +185,21 @@ func (r *agentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Ag
 	agent.ID = uuid.MustParse(idStr)
 	r.cache.Set(ctx, cacheKey, &agent)
 
-	return &agent, nil
+	return tenantAgent(ctx, &agent)
+}
+
+// agentVisible reports whether an agent is visible to the request's
+// tenant: its own agents and the shared ones, which run its jobs too
+func agentVisible(ctx context.Context, agent *domain.Agent) bool {
+	return agent.TenantID == nil || tenantVisible(ctx, agent.TenantID)
+}
+
+// tenantAgent hides the agents of other tenants as if they didn't exist
+func tenantAgent(ctx context.Context, agent *domain.Agent) (*domain.Agent, error) {
+	if !agentVisible(ctx, agent) {
+		return nil, domain.ErrAgentNotFound
+	}
+	return agent, nil
 }
 
 func (r *agentRepository) GetByName(ctx context.Context, name string) (*domain.Agent, error) {
@@ -203,6 +223,9 @@ func (r *agentRepository) GetByName(ctx context.Context, name string) (*domain.A
 		&agent.LastSeen,
 		&agent.CreatedAt,
 		&agent.UpdatedAt,
+		heartbeatColumn{&agent.Heartbeat},
+		&agent.HashcatVersion,
+		uuidColumn{&agent.TenantID},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -238,6 +261,9 @@ func (r *agentRepository) GetByNameAndIP(ctx context.Context, name, ip string, p
 		&agent.LastSeen,
 		&agent.CreatedAt,
 		&agent.UpdatedAt,
+		heartbeatColumn{&agent.Heartbeat},
+		&agent.HashcatVersion,
+		uuidColumn{&agent.TenantID},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -257,7 +283,7 @@ func (r *agentRepository) GetAll(ctx context.Context) ([]domain.Agent, error) {
 
 	var agents []domain.Agent
 	if found, err := r.cache.Get(ctx, cacheKey, &agents); err == nil && found {
-		return agents, nil
+		return filterTenant(ctx, agents, func(agent *domain.Agent) bool { return agentVisible(ctx, agent) }), nil
 	}
 
 	rows, err := r.getAllStmt.QueryContext(ctx)
@@ -283,6 +309,9 @@ func (r *agentRepository) GetAll(ctx context.Context) ([]domain.Agent, error) {
 			&agent.LastSeen,
 			&agent.CreatedAt,
 			&agent.UpdatedAt,
+			heartbeatColumn{&agent.Heartbeat},
+			&agent.HashcatVersion,
+			uuidColumn{&agent.TenantID},
 		)
 		if err != nil {
 			return nil, err
@@ -294,7 +323,7 @@ func (r *agentRepository) GetAll(ctx context.Context) ([]domain.Agent, error) {
 
 	r.cache.Set(ctx, cacheKey, agents)
 
-	return agents, nil
+	return filterTenant(ctx, agents, func(agent *domain.Agent) bool { return agentVisible(ctx, agent) }), nil
 }
 
 func (r *agentRepository) Update(ctx context.Context, agent *domain.Agent) error {
@@ -339,6 +368,35 @@ func (r *agentRepository) Update(ctx context.Context, agent *domain.Agent) error
 }
 
 func (r *agentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	// A tenant sees the shared agents but may only delete its own
+	if domain.TenantScoped(ctx) {
+		agent, err := r.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil && (agent.TenantID == nil || *agent.TenantID != *tenantID) {
+			return domain.ErrAgentNotFound
+		}
+	}
+	// Foreign keys aren't enforced, so drop the agent's group memberships by hand
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM agent_group_members WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent from its groups: %w", err)
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM agent_files WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent file inventory: %w", err)
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM agent_logs WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent logs: %w", err)
+	}
+	for _, table := range []string{"agent_benchmarks", "agent_benchmark_resets", "agent_benchmark_jobs"} {
+		if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM `+table+` WHERE agent_id = ?`, id.String()); err != nil {
+			return fmt.Errorf("failed to remove agent benchmarks: %w", err)
+		}
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM agent_commands WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent commands: %w", err)
+	}
+
 	_, err := r.deleteStmt.ExecContext(ctx, id.String())
 
 	if err == nil {
@@ -365,8 +423,9 @@ func (r *agentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status
 }
 
 func (r *agentRepository) UpdateLastSeen(ctx context.Context, id uuid.UUID) error {
+	// Hearing from an archived agent brings it back
 	query := `
-		UPDATE agents SET last_seen = ?, updated_at = ? WHERE id = ?
+		UPDATE agents SET last_seen = ?, updated_at = ?, archived_at = NULL WHERE id = ?
 	`
 	now := time.Now()
 	_, err := r.db.DB().ExecContext(ctx, query, now, now, id.String())
@@ -379,6 +438,101 @@ func (r *agentRepository) UpdateLastSeen(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+// UpdateHeartbeat stores the runtime snapshot from an agent's latest heartbeat
+func (r *agentRepository) UpdateHeartbeat(ctx context.Context, id uuid.UUID, heartbeat *domain.AgentHeartbeat) error {
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		return fmt.Errorf("failed to encode agent heartbeat: %w", err)
+	}
+	var version string
+	if heartbeat != nil {
+		version = heartbeat.HashcatVersion
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `UPDATE agents SET heartbeat = ?, hashcat_version = COALESCE(NULLIF(?, ''), hashcat_version) WHERE id = ?`, string(data), version, id.String()); err != nil {
+		return fmt.Errorf("failed to update agent heartbeat: %w", err)
+	}
+
+	r.cache.Delete(ctx, "agent:"+id.String())
+	r.cache.Delete(ctx, "agents:all")
+	return nil
+}
+
+// UpdateLastSeenBatch writes the buffered heartbeats of many agents in one
+// transaction, so a large fleet costs one commit per flush instead of one
+// per heartbeat. Archived agents in it are brought back.
+func (r *agentRepository) UpdateLastSeenBatch(ctx context.Context, seen map[uuid.UUID]domain.AgentSeen) error {
+	if len(seen) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for id, s := range seen {
+		if s.Heartbeat == nil {
+			_, err = tx.ExecContext(ctx, `UPDATE agents SET last_seen = ?, updated_at = ?, archived_at = NULL WHERE id = ?`, s.LastSeen, now, id.String())
+		} else {
+			var data []byte
+			if data, err = json.Marshal(s.Heartbeat); err != nil {
+				return fmt.Errorf("failed to encode agent heartbeat: %w", err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				UPDATE agents SET last_seen = ?, updated_at = ?, archived_at = NULL, heartbeat = ?, hashcat_version = COALESCE(NULLIF(?, ''), hashcat_version)
+				WHERE id = ?`, s.LastSeen, now, string(data), s.Heartbeat.HashcatVersion, id.String())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update last seen of agent %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for id := range seen {
+		r.cache.Delete(ctx, "agent:"+id.String())
+	}
+	r.cache.Delete(ctx, "agents:all")
+	return nil
+}
+
+// heartbeatColumn scans the JSON heartbeat snapshot column, leaving the
+// destination nil for agents that never sent one
+type heartbeatColumn struct {
+	dst **domain.AgentHeartbeat
+}
+
+func (c heartbeatColumn) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c.dst = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unexpected heartbeat column type %T", src)
+	}
+
+	if len(data) == 0 {
+		*c.dst = nil
+		return nil
+	}
+	var heartbeat domain.AgentHeartbeat
+	if err := json.Unmarshal(data, &heartbeat); err != nil {
+		return err
+	}
+	*c.dst = &heartbeat
+	return nil
+}
+
 // UpdateSpeed updates the agent speed with comprehensive logging and cache invalidation
 // This method is called during real-time speed monitoring and benchmark updates
 func (r *agentRepository) UpdateSpeed(ctx context.Context, id uuid.UUID, speed int64) error {
@@ -394,7 +548,7 @@ func (r *agentRepository) UpdateSpeed(ctx context.Context, id uuid.UUID, speed i
 	}
 
 	// Log speed update for real-time monitoring
-	log.Printf("[REAL-TIME SPEED UPDATE] Agent %s speed updated to %d H/s at %s",
+	infrastructure.ServerLogger.With("agent_id", id).Debug("[REAL-TIME SPEED UPDATE] Agent %s speed updated to %d H/s at %s",
 		id.String(), speed, now.Format("2006-01-02 15:04:05"))
 
 	// Invalidate cache to ensure fresh data
@@ -419,7 +573,7 @@ func (r *agentRepository) UpdateSpeedWithStatus(ctx context.Context, id uuid.UUI
 	}
 
 	// Log comprehensive update for real-time monitoring
-	log.Printf("[REAL-TIME AGENT UPDATE] Agent %s: speed=%d H/s, status=%s, time=%s",
+	infrastructure.ServerLogger.With("agent_id", id).Debug("[REAL-TIME AGENT UPDATE] Agent %s: speed=%d H/s, status=%s, time=%s",
 		id.String(), speed, status, now.Format("2006-01-02 15:04:05"))
 
 	// Invalidate cache to ensure fresh data
@@ -454,6 +608,9 @@ func (r *agentRepository) GetByIPAddress(ctx context.Context, ip string) (*domai
 		&agent.LastSeen,
 		&agent.CreatedAt,
 		&agent.UpdatedAt,
+		heartbeatColumn{&agent.Heartbeat},
+		&agent.HashcatVersion,
+		uuidColumn{&agent.TenantID},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -493,6 +650,9 @@ func (r *agentRepository) GetByAgentKey(ctx context.Context, agentKey string) (*
 		&agent.LastSeen,
 		&agent.CreatedAt,
 		&agent.UpdatedAt,
+		heartbeatColumn{&agent.Heartbeat},
+		&agent.HashcatVersion,
+		uuidColumn{&agent.TenantID},
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -525,3 +685,440 @@ func (r *agentRepository) GetByNameAndIPForStartup(ctx context.Context, name, ip
 	}
 	return agent, nil
 }
+
+func (r *agentRepository) CreateGroup(ctx context.Context, group *domain.AgentGroup) error {
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+	group.CreatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT INTO agent_groups (id, name, description, created_at) VALUES (?, ?, ?, ?)`,
+		group.ID.String(), group.Name, group.Description, group.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create agent group: %w", err)
+	}
+	return nil
+}
+
+func (r *agentRepository) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.AgentGroup, error) {
+	var group domain.AgentGroup
+	var idStr string
+	var description sql.NullString
+
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT id, name, description, created_at FROM agent_groups WHERE id = ?`, id.String(),
+	).Scan(&idStr, &group.Name, &description, &group.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "agent group"}
+		}
+		return nil, err
+	}
+
+	group.ID = uuid.MustParse(idStr)
+	group.Description = description.String
+	return &group, nil
+}
+
+func (r *agentRepository) GetAllGroups(ctx context.Context) ([]domain.AgentGroup, error) {
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT id, name, description, created_at FROM agent_groups ORDER BY name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]domain.AgentGroup, 0)
+	for rows.Next() {
+		var group domain.AgentGroup
+		var idStr string
+		var description sql.NullString
+
+		if err := rows.Scan(&idStr, &group.Name, &description, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		group.ID = uuid.MustParse(idStr)
+		group.Description = description.String
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// DeleteGroup removes a group and its memberships; the agents themselves stay
+func (r *agentRepository) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_group_members WHERE group_id = ?`, id.String()); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM agent_groups WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return &domain.NotFoundError{Entity: "agent group"}
+	}
+
+	return tx.Commit()
+}
+
+// AddGroupMember puts an agent in a group. Adding an existing member is a no-op.
+func (r *agentRepository) AddGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error {
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT OR IGNORE INTO agent_group_members (group_id, agent_id) VALUES (?, ?)`,
+		groupID.String(), agentID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add agent to group: %w", err)
+	}
+	return nil
+}
+
+func (r *agentRepository) RemoveGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM agent_group_members WHERE group_id = ? AND agent_id = ?`,
+		groupID.String(), agentID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove agent from group: %w", err)
+	}
+	if removed, err := result.RowsAffected(); err == nil && removed == 0 {
+		return &domain.NotFoundError{Entity: "agent group member"}
+	}
+	return nil
+}
+
+// GetByGroupID returns the agents in a group
+func (r *agentRepository) GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]domain.Agent, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT a.id, a.name, a.ip_address, a.port, a.status, a.capabilities, a.agent_key, a.speed, a.last_seen, a.created_at, a.updated_at, a.heartbeat, a.hashcat_version, a.tenant_id
+		FROM agents a
+		JOIN agent_group_members m ON m.agent_id = a.id
+		WHERE m.group_id = ?
+		ORDER BY a.name
+	`, groupID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agents := make([]domain.Agent, 0)
+	for rows.Next() {
+		var agent domain.Agent
+		var idStr string
+
+		err := rows.Scan(
+			&idStr,
+			&agent.Name,
+			&agent.IPAddress,
+			&agent.Port,
+			&agent.Status,
+			&agent.Capabilities,
+			&agent.AgentKey,
+			&agent.Speed,
+			&agent.LastSeen,
+			&agent.CreatedAt,
+			&agent.UpdatedAt,
+			heartbeatColumn{&agent.Heartbeat},
+			&agent.HashcatVersion,
+			uuidColumn{&agent.TenantID},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		agent.ID = uuid.MustParse(idStr)
+		agents = append(agents, agent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return filterTenant(ctx, agents, func(agent *domain.Agent) bool { return agentVisible(ctx, agent) }), nil
+}
+
+// ReplaceFiles stores the full set of local files an agent reported,
+// dropping files it no longer has
+func (r *agentRepository) ReplaceFiles(ctx context.Context, agentID uuid.UUID, files []domain.AgentFile) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_files WHERE agent_id = ?`, agentID.String()); err != nil {
+		return fmt.Errorf("failed to clear agent files: %w", err)
+	}
+
+	for _, file := range files {
+		var modTime interface{}
+		if !file.ModTime.IsZero() {
+			modTime = file.ModTime
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO agent_files (agent_id, name, path, size, type, md5, mod_time, reported_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			agentID.String(), file.Name, file.Path, file.Size, file.Type, file.MD5, modTime, file.ReportedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store agent file %s: %w", file.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetFiles returns the local files an agent last reported
+func (r *agentRepository) GetFiles(ctx context.Context, agentID uuid.UUID) ([]domain.AgentFile, error) {
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT name, path, size, type, md5, mod_time, reported_at FROM agent_files WHERE agent_id = ? ORDER BY name`,
+		agentID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make([]domain.AgentFile, 0)
+	for rows.Next() {
+		file := domain.AgentFile{AgentID: agentID}
+		var md5 sql.NullString
+		var modTime sql.NullTime
+
+		if err := rows.Scan(&file.Name, &file.Path, &file.Size, &file.Type, &md5, &modTime, &file.ReportedAt); err != nil {
+			return nil, err
+		}
+		file.MD5 = md5.String
+		if modTime.Valid {
+			file.ModTime = modTime.Time
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+// AppendLogs stores log lines an agent shipped and drops its oldest lines
+// beyond keep, so each agent's log rotates at a fixed size. The stored
+// lines get their IDs set.
+func (r *agentRepository) AppendLogs(ctx context.Context, agentID uuid.UUID, lines []domain.AgentLogLine, keep int) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range lines {
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO agent_logs (agent_id, logged_at, source, message) VALUES (?, ?, ?, ?)`,
+			agentID.String(), lines[i].Time, lines[i].Source, lines[i].Message,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store agent log line: %w", err)
+		}
+		if lines[i].ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	if keep > 0 {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM agent_logs WHERE agent_id = ? AND id <= (
+				SELECT id FROM agent_logs WHERE agent_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+			)`,
+			agentID.String(), agentID.String(), keep,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to rotate agent logs: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLogs returns an agent's log lines oldest first: the ones after afterID,
+// or the newest ones when afterID is 0
+func (r *agentRepository) GetLogs(ctx context.Context, agentID uuid.UUID, afterID int64, limit int) ([]domain.AgentLogLine, error) {
+	query := `SELECT id, logged_at, source, message FROM agent_logs WHERE agent_id = ? AND id > ? ORDER BY id LIMIT ?`
+	if afterID <= 0 {
+		query = `SELECT id, logged_at, source, message FROM (
+			SELECT id, logged_at, source, message FROM agent_logs WHERE agent_id = ? AND id > ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, query, agentID.String(), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]domain.AgentLogLine, 0)
+	for rows.Next() {
+		line := domain.AgentLogLine{AgentID: agentID}
+		if err := rows.Scan(&line.ID, &line.Time, &line.Source, &line.Message); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *agentRepository) GetHashcatArgs(ctx context.Context, agentID uuid.UUID) ([]string, error) {
+	var args string
+	err := r.db.DB().QueryRowContext(ctx, `SELECT hashcat_args FROM agents WHERE id = ?`, agentID.String()).Scan(&args)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{Entity: "agent"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeArgs(args), nil
+}
+
+// UpdateHashcatArgs replaces the hashcat options the agent adds to every job
+func (r *agentRepository) UpdateHashcatArgs(ctx context.Context, agentID uuid.UUID, args []string) error {
+	result, err := r.db.DB().ExecContext(ctx, `UPDATE agents SET hashcat_args = ?, updated_at = ? WHERE id = ?`,
+		encodeArgs(args), time.Now(), agentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update agent hashcat args: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &domain.NotFoundError{Entity: "agent"}
+	}
+	return nil
+}
+
+func (r *agentRepository) GetSettings(ctx context.Context, agentID uuid.UUID) (*domain.AgentSettings, error) {
+	var data string
+	err := r.db.DB().QueryRowContext(ctx, `SELECT settings FROM agents WHERE id = ?`, agentID.String()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{Entity: "agent"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &domain.AgentSettings{}
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), settings); err != nil {
+			return nil, fmt.Errorf("failed to decode agent settings: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the agent's server-managed settings
+func (r *agentRepository) UpdateSettings(ctx context.Context, agentID uuid.UUID, settings *domain.AgentSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.DB().ExecContext(ctx, `UPDATE agents SET settings = ?, updated_at = ? WHERE id = ?`,
+		string(data), time.Now(), agentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update agent settings: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &domain.NotFoundError{Entity: "agent"}
+	}
+	return nil
+}
+
+// GetStale returns the agents last seen before the cutoff, or registered
+// before it when never seen, with the jobs still on them
+func (r *agentRepository) GetStale(ctx context.Context, seenBefore time.Time) ([]domain.StaleAgent, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT a.id, a.name, a.ip_address, a.status, a.last_seen, a.created_at, a.archived_at,
+		       (SELECT COUNT(*) FROM jobs j WHERE j.agent_id = a.id AND j.status IN (?, ?, ?))
+		FROM agents a
+		WHERE COALESCE(a.last_seen, a.created_at) < ?
+		ORDER BY COALESCE(a.last_seen, a.created_at), a.name
+	`, domain.JobStatusAssigned, domain.JobStatusRunning, domain.JobStatusPaused, seenBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agents := make([]domain.StaleAgent, 0)
+	for rows.Next() {
+		var agent domain.StaleAgent
+		var idStr string
+		var lastSeen, archivedAt sql.NullTime
+		var createdAt time.Time
+		if err := rows.Scan(&idStr, &agent.Name, &agent.IPAddress, &agent.Status, &lastSeen, &createdAt, &archivedAt, &agent.ActiveJobs); err != nil {
+			return nil, err
+		}
+		agent.ID = uuid.MustParse(idStr)
+		agent.LastSeen = createdAt
+		if lastSeen.Valid {
+			agent.LastSeen = lastSeen.Time
+		}
+		if archivedAt.Valid {
+			agent.ArchivedAt = &archivedAt.Time
+		}
+		agents = append(agents, agent)
+	}
+	return agents, rows.Err()
+}
+
+// Archive hides an agent from the agent list and drops its benchmark speed
+// and benchmarks, heartbeat snapshot, file inventory and logs
+func (r *agentRepository) Archive(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE agents SET archived_at = ?, status = 'offline', speed = 0, heartbeat = NULL, updated_at = ?
+		WHERE id = ?`, now, now, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to archive agent: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return domain.ErrAgentNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_files WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent file inventory: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_logs WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent logs: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM agent_benchmarks WHERE agent_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to remove agent benchmarks: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	r.cache.Delete(ctx, "agent:"+id.String())
+	r.cache.Delete(ctx, "agents:all")
+	return nil
+}
+
+// SetTenant moves an agent into a tenant, or shares it when tenantID is nil
+func (r *agentRepository) SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `UPDATE agents SET tenant_id = ?, updated_at = ? WHERE id = ?`,
+		nullableUUID(tenantID), time.Now(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to set agent tenant: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return domain.ErrAgentNotFound
+	}
+
+	r.cache.Delete(ctx, "agent:"+id.String())
+	r.cache.Delete(ctx, "agents:all")
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// charsetFileColumns is the column list every charset file SELECT returns, in scanCharsetFile order
+const charsetFileColumns = `id, name, orig_name, path, size, sha256, created_at`
+
+type charsetRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewCharsetRepository(db *database.SQLiteDB) domain.CharsetRepository {
+	return &charsetRepository{db: db}
+}
+
+func (r *charsetRepository) Create(ctx context.Context, charset *domain.CharsetFile) error {
+	charset.CreatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO charset_files (id, name, orig_name, path, size, sha256, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, charset.ID.String(), charset.Name, charset.OrigName, charset.Path, charset.Size, charset.SHA256, charset.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create charset file: %w", err)
+	}
+	return nil
+}
+
+func (r *charsetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CharsetFile, error) {
+	charset, err := scanCharsetFile(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+charsetFileColumns+` FROM charset_files WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "charset file"}
+		}
+		return nil, err
+	}
+	return &charset, nil
+}
+
+func (r *charsetRepository) GetAll(ctx context.Context) ([]domain.CharsetFile, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+charsetFileColumns+` FROM charset_files ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	charsets := make([]domain.CharsetFile, 0)
+	for rows.Next() {
+		charset, err := scanCharsetFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		charsets = append(charsets, charset)
+	}
+	return charsets, rows.Err()
+}
+
+func (r *charsetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM charset_files WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return &domain.NotFoundError{Entity: "charset file"}
+	}
+	return nil
+}
+
+func scanCharsetFile(row rowScanner) (domain.CharsetFile, error) {
+	var charset domain.CharsetFile
+	var idStr string
+
+	err := row.Scan(
+		&idStr,
+		&charset.Name,
+		&charset.OrigName,
+		&charset.Path,
+		&charset.Size,
+		&charset.SHA256,
+		&charset.CreatedAt,
+	)
+	if err != nil {
+		return charset, err
+	}
+
+	charset.ID = uuid.MustParse(idStr)
+	return charset, nil
+}
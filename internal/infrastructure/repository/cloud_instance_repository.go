@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+type cloudInstanceRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewCloudInstanceRepository(db *database.SQLiteDB) domain.CloudInstanceRepository {
+	return &cloudInstanceRepository{db: db}
+}
+
+func (r *cloudInstanceRepository) Create(ctx context.Context, instance *domain.CloudInstance) error {
+	if instance.ID == uuid.Nil {
+		instance.ID = uuid.New()
+	}
+	now := time.Now()
+	instance.CreatedAt = now
+	instance.UpdatedAt = now
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO cloud_instances (id, provider, provider_id, agent_id, status, hourly_cost, idle_since, created_at, updated_at, terminated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, instance.ID.String(), instance.Provider, instance.ProviderID, instance.AgentID.String(), instance.Status,
+		instance.HourlyCost, instance.IdleSince, instance.CreatedAt, instance.UpdatedAt, instance.TerminatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create cloud instance: %w", err)
+	}
+	return nil
+}
+
+func (r *cloudInstanceRepository) Update(ctx context.Context, instance *domain.CloudInstance) error {
+	instance.UpdatedAt = time.Now()
+
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE cloud_instances
+		SET status = ?, idle_since = ?, updated_at = ?, terminated_at = ?
+		WHERE id = ?
+	`, instance.Status, instance.IdleSince, instance.UpdatedAt, instance.TerminatedAt, instance.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update cloud instance: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "cloud instance"}
+	}
+	return nil
+}
+
+func (r *cloudInstanceRepository) GetActive(ctx context.Context) ([]domain.CloudInstance, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, provider, provider_id, agent_id, status, hourly_cost, idle_since, created_at, updated_at, terminated_at
+		FROM cloud_instances
+		WHERE status != ?
+		ORDER BY created_at
+	`, domain.CloudInstanceTerminated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instances := []domain.CloudInstance{}
+	for rows.Next() {
+		var instance domain.CloudInstance
+		var idStr, agentIDStr string
+		var idleSince, terminatedAt sql.NullTime
+		if err := rows.Scan(&idStr, &instance.Provider, &instance.ProviderID, &agentIDStr, &instance.Status,
+			&instance.HourlyCost, &idleSince, &instance.CreatedAt, &instance.UpdatedAt, &terminatedAt); err != nil {
+			return nil, err
+		}
+		instance.ID = uuid.MustParse(idStr)
+		instance.AgentID = uuid.MustParse(agentIDStr)
+		if idleSince.Valid {
+			instance.IdleSince = &idleSince.Time
+		}
+		if terminatedAt.Valid {
+			instance.TerminatedAt = &terminatedAt.Time
+		}
+		instances = append(instances, instance)
+	}
+
+	return instances, rows.Err()
+}
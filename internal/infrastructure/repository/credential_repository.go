@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// credentialColumns is the column list every credential SELECT returns, in
+// scanCredential order
+const credentialColumns = `id, username, domain, hash, plaintext, hash_type, hash_file_id, source_file, source, job_id, project_id, cracked_at`
+
+// credentialTenant is the tenant a credential belongs to: that of the job
+// that cracked it, else that of its hash file
+const credentialTenant = `COALESCE(
+	(SELECT tenant_id FROM jobs WHERE jobs.id = credentials.job_id),
+	(SELECT tenant_id FROM hash_files WHERE hash_files.id = credentials.hash_file_id))`
+
+type credentialRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewCredentialRepository(db *database.SQLiteDB) domain.CredentialRepository {
+	return &credentialRepository{db: db}
+}
+
+func (r *credentialRepository) Record(ctx context.Context, credentials []domain.Credential) (int, error) {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR IGNORE INTO credentials (`+credentialColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare credential insert: %w", err)
+	}
+	defer stmt.Close()
+
+	var recorded int64
+	for i := range credentials {
+		credential := &credentials[i]
+		if credential.ID == uuid.Nil {
+			credential.ID = uuid.New()
+		}
+		if credential.CrackedAt.IsZero() {
+			credential.CrackedAt = time.Now()
+		}
+		plaintext, err := r.db.SealField(credential.Plaintext)
+		if err != nil {
+			return 0, err
+		}
+		result, err := stmt.ExecContext(ctx, credential.ID.String(), credential.Username, credential.Domain, credential.Hash,
+			plaintext, credential.HashType, nullableUUID(credential.HashFileID), credential.SourceFile, credential.Source,
+			nullableUUID(credential.JobID), nullableUUID(credential.ProjectID), credential.CrackedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record credential: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		recorded += rows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit credentials: %w", err)
+	}
+	return int(recorded), nil
+}
+
+func (r *credentialRepository) GetAll(ctx context.Context, filter domain.CredentialFilter) ([]domain.Credential, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if filter.Username != "" {
+		where += ` AND username = ? COLLATE NOCASE`
+		args = append(args, filter.Username)
+	}
+	if filter.Domain != "" {
+		where += ` AND domain = ? COLLATE NOCASE`
+		args = append(args, filter.Domain)
+	}
+	if filter.HashFileID != nil {
+		where += ` AND hash_file_id = ?`
+		args = append(args, filter.HashFileID.String())
+	}
+	if filter.ProjectID != nil {
+		where += ` AND project_id = ?`
+		args = append(args, filter.ProjectID.String())
+	}
+	conditions, args := tenantCondition(ctx, credentialTenant, nil, args)
+	for _, condition := range conditions {
+		where += ` AND ` + condition
+	}
+
+	var total int
+	if err := r.db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM credentials`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count credentials: %w", err)
+	}
+
+	query := `SELECT ` + credentialColumns + ` FROM credentials` + where + ` ORDER BY cracked_at DESC, username`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	credentials := []domain.Credential{}
+	for rows.Next() {
+		credential, err := r.scanCredential(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, total, rows.Err()
+}
+
+// scanCredential scans a single row selected with credentialColumns and
+// decrypts its plaintext
+func (r *credentialRepository) scanCredential(row rowScanner) (domain.Credential, error) {
+	var credential domain.Credential
+	var id string
+	var hashFileID, jobID, projectID sql.NullString
+
+	err := row.Scan(
+		&id,
+		&credential.Username,
+		&credential.Domain,
+		&credential.Hash,
+		&credential.Plaintext,
+		&credential.HashType,
+		&hashFileID,
+		&credential.SourceFile,
+		&credential.Source,
+		&jobID,
+		&projectID,
+		&credential.CrackedAt,
+	)
+	if err != nil {
+		return credential, err
+	}
+
+	if credential.Plaintext, err = r.db.OpenField(credential.Plaintext); err != nil {
+		return credential, err
+	}
+	credential.ID = uuid.MustParse(id)
+	credential.HashFileID = parseNullableUUID(hashFileID)
+	credential.JobID = parseNullableUUID(jobID)
+	credential.ProjectID = parseNullableUUID(projectID)
+	return credential, nil
+}
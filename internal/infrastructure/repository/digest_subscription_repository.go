@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// digestSubscriptionColumns is the column list every digest subscription
+// SELECT returns, in scanDigestSubscription order
+const digestSubscriptionColumns = `s.user_id, s.project_id, p.name, s.frequency, s.last_sent_at, s.created_at, s.updated_at`
+
+type digestSubscriptionRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewDigestSubscriptionRepository(db *database.SQLiteDB) domain.DigestSubscriptionRepository {
+	return &digestSubscriptionRepository{db: db}
+}
+
+// Save keeps when the last digest was sent, so changing the frequency
+// doesn't send one right away
+func (r *digestSubscriptionRepository) Save(ctx context.Context, subscription *domain.DigestSubscription) error {
+	now := time.Now()
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = now
+	}
+	subscription.UpdatedAt = now
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO digest_subscriptions (user_id, project_id, frequency, last_sent_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, project_id) DO UPDATE SET frequency = excluded.frequency, updated_at = excluded.updated_at
+	`, subscription.UserID.String(), subscription.ProjectID.String(), subscription.Frequency,
+		subscription.LastSentAt, subscription.CreatedAt, subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *digestSubscriptionRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.DigestSubscription, error) {
+	return r.getMany(ctx, `
+		SELECT `+digestSubscriptionColumns+`
+		FROM digest_subscriptions s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.user_id = ?
+		ORDER BY p.name
+	`, userID.String())
+}
+
+func (r *digestSubscriptionRepository) GetAll(ctx context.Context) ([]domain.DigestSubscription, error) {
+	return r.getMany(ctx, `
+		SELECT `+digestSubscriptionColumns+`
+		FROM digest_subscriptions s
+		JOIN projects p ON p.id = s.project_id
+		ORDER BY s.project_id, s.user_id
+	`)
+}
+
+func (r *digestSubscriptionRepository) getMany(ctx context.Context, query string, args ...interface{}) ([]domain.DigestSubscription, error) {
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []domain.DigestSubscription{}
+	for rows.Next() {
+		subscription, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *digestSubscriptionRepository) Delete(ctx context.Context, userID, projectID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM digest_subscriptions WHERE user_id = ? AND project_id = ?`,
+		userID.String(), projectID.String(),
+	)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return &domain.NotFoundError{Entity: "digest subscription"}
+	}
+	return nil
+}
+
+func (r *digestSubscriptionRepository) MarkSent(ctx context.Context, userID, projectID uuid.UUID, at time.Time) error {
+	_, err := r.db.DB().ExecContext(ctx,
+		`UPDATE digest_subscriptions SET last_sent_at = ? WHERE user_id = ? AND project_id = ?`,
+		at, userID.String(), projectID.String(),
+	)
+	return err
+}
+
+// scanDigestSubscription scans a single row selected with digestSubscriptionColumns
+func scanDigestSubscription(row rowScanner) (domain.DigestSubscription, error) {
+	var subscription domain.DigestSubscription
+	var userID, projectID string
+	var lastSentAt sql.NullTime
+	if err := row.Scan(&userID, &projectID, &subscription.ProjectName, &subscription.Frequency, &lastSentAt,
+		&subscription.CreatedAt, &subscription.UpdatedAt); err != nil {
+		return subscription, err
+	}
+	var err error
+	if subscription.UserID, err = uuid.Parse(userID); err != nil {
+		return subscription, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	if subscription.ProjectID, err = uuid.Parse(projectID); err != nil {
+		return subscription, fmt.Errorf("invalid project ID %q: %w", projectID, err)
+	}
+	if lastSentAt.Valid {
+		subscription.LastSentAt = &lastSentAt.Time
+	}
+	return subscription, nil
+}
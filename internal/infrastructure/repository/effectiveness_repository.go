@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+)
+
+// effectivenessGroups maps each grouping of effectiveness reports to the
+// column it groups by and the one naming it
+var effectivenessGroups = map[string]struct{ key, name string }{
+	domain.EffectivenessGroupWordlist: {"COALESCE(wordlist_id, wordlist)", "wordlist"},
+	domain.EffectivenessGroupRules:    {"rules", "rules"},
+	domain.EffectivenessGroupMask:     {"mask", "mask"},
+}
+
+type effectivenessRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewEffectivenessRepository(db *database.SQLiteDB) domain.EffectivenessRepository {
+	return &effectivenessRepository{db: db}
+}
+
+func (r *effectivenessRepository) Record(ctx context.Context, record *domain.JobEffectiveness) error {
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO job_effectiveness (job_id, group_id, hash_type, attack_mode, wordlist_id, wordlist, rules, mask,
+			keyspace, cracks, device_seconds, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(job_id) DO UPDATE SET
+			keyspace = excluded.keyspace,
+			cracks = excluded.cracks,
+			device_seconds = excluded.device_seconds,
+			completed_at = excluded.completed_at
+	`, record.JobID.String(), nullableUUID(record.GroupID), record.HashType, record.AttackMode,
+		nullableUUID(record.WordlistID), record.Wordlist, record.Rules, record.Mask,
+		record.Keyspace, record.Cracks, record.DeviceSeconds, record.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record job effectiveness: %w", err)
+	}
+	return nil
+}
+
+// Aggregate counts the sub-jobs of a distributed job as one job, which
+// cracked when any of them did
+func (r *effectivenessRepository) Aggregate(ctx context.Context, filter domain.EffectivenessFilter) ([]domain.EffectivenessTotal, error) {
+	group, ok := effectivenessGroups[filter.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown effectiveness grouping %q", filter.GroupBy)
+	}
+
+	where := []string{group.name + " != ''"}
+	var args []interface{}
+	if filter.HashType != nil {
+		where = append(where, "hash_type = ?")
+		args = append(args, *filter.HashType)
+	}
+	if filter.From != nil {
+		where = append(where, "julianday(completed_at) >= julianday(?)")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		where = append(where, "julianday(completed_at) <= julianday(?)")
+		args = append(args, *filter.To)
+	}
+	where, args = tenantCondition(ctx, "(SELECT tenant_id FROM jobs WHERE jobs.id = job_effectiveness.job_id)", where, args)
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+group.key+`, MAX(`+group.name+`),
+		       COUNT(DISTINCT COALESCE(group_id, job_id)),
+		       COUNT(DISTINCT CASE WHEN cracks > 0 THEN COALESCE(group_id, job_id) END),
+		       SUM(cracks), SUM(keyspace), SUM(device_seconds),
+		       MAX(CAST(strftime('%s', completed_at) AS INTEGER))
+		FROM job_effectiveness
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY `+group.key+`
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []domain.EffectivenessTotal{}
+	for rows.Next() {
+		var total domain.EffectivenessTotal
+		var lastUsed int64
+		if err := rows.Scan(&total.Key, &total.Name, &total.Jobs, &total.JobsWithCracks,
+			&total.Cracks, &total.Keyspace, &total.DeviceSeconds, &lastUsed); err != nil {
+			return nil, err
+		}
+		total.LastUsedAt = time.Unix(lastUsed, 0).UTC()
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
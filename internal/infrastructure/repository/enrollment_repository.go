@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// enrollmentColumns is the column list every enrollment token SELECT
+// returns, in scanEnrollmentToken order
+const enrollmentColumns = `id, label, token_hash, expires_at, used_at, agent_id, created_at`
+
+type enrollmentRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewEnrollmentRepository(db *database.SQLiteDB) domain.EnrollmentTokenRepository {
+	return &enrollmentRepository{db: db}
+}
+
+func (r *enrollmentRepository) Create(ctx context.Context, token *domain.EnrollmentToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	token.CreatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO enrollment_tokens (`+enrollmentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, token.ID.String(), token.Label, token.TokenHash, token.ExpiresAt, token.UsedAt, nullableUUID(token.AgentID), token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create enrollment token: %w", err)
+	}
+	return nil
+}
+
+func (r *enrollmentRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.EnrollmentToken, error) {
+	token, err := scanEnrollmentToken(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+enrollmentColumns+` FROM enrollment_tokens WHERE token_hash = ?`, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "enrollment token"}
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *enrollmentRepository) GetAll(ctx context.Context) ([]domain.EnrollmentToken, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+enrollmentColumns+` FROM enrollment_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []domain.EnrollmentToken{}
+	for rows.Next() {
+		token, err := scanEnrollmentToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *enrollmentRepository) Redeem(ctx context.Context, id, agentID uuid.UUID, at time.Time) error {
+	token, err := scanEnrollmentToken(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+enrollmentColumns+` FROM enrollment_tokens WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &domain.NotFoundError{Entity: "enrollment token"}
+		}
+		return err
+	}
+	if err := token.Usable(at); err != nil {
+		return err
+	}
+
+	// used_at IS NULL makes the first of two concurrent redemptions win
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE enrollment_tokens SET used_at = ?, agent_id = ?
+		WHERE id = ? AND used_at IS NULL
+	`, at, agentID.String(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to redeem enrollment token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrEnrollmentTokenUsed
+	}
+	return nil
+}
+
+func (r *enrollmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM enrollment_tokens WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "enrollment token"}
+	}
+	return nil
+}
+
+// scanEnrollmentToken scans a single row selected with enrollmentColumns
+func scanEnrollmentToken(row rowScanner) (domain.EnrollmentToken, error) {
+	var token domain.EnrollmentToken
+	var id string
+	var agentID sql.NullString
+	var usedAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&token.Label,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&agentID,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return token, err
+	}
+
+	token.ID = uuid.MustParse(id)
+	token.AgentID = parseNullableUUID(agentID)
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return token, nil
+}
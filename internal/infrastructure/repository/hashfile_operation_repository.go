@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// hashFileOperationColumns is the column list every hash file operation
+// SELECT returns, in scanHashFileOperation order
+const hashFileOperationColumns = `id, type, status, progress, input_ids, result_ids, counts, parts, name, error, project_id, created_by, created_at, updated_at, completed_at`
+
+type hashFileOperationRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewHashFileOperationRepository(db *database.SQLiteDB) domain.HashFileOperationRepository {
+	return &hashFileOperationRepository{db: db}
+}
+
+func (r *hashFileOperationRepository) Create(ctx context.Context, operation *domain.HashFileOperation) error {
+	if operation.ID == uuid.Nil {
+		operation.ID = uuid.New()
+	}
+	operation.CreatedAt = time.Now()
+	operation.UpdatedAt = operation.CreatedAt
+
+	inputIDs, resultIDs, counts := encodeOperationLists(operation)
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO hash_file_operations (`+hashFileOperationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, operation.ID.String(), operation.Type, operation.Status, operation.Progress, inputIDs, resultIDs, counts,
+		operation.Parts, operation.Name, operation.Error, nullableUUID(operation.ProjectID), nullableUUID(operation.CreatedBy),
+		operation.CreatedAt, operation.UpdatedAt, operation.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create hash file operation: %w", err)
+	}
+	return nil
+}
+
+func (r *hashFileOperationRepository) Update(ctx context.Context, operation *domain.HashFileOperation) error {
+	operation.UpdatedAt = time.Now()
+
+	_, resultIDs, counts := encodeOperationLists(operation)
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE hash_file_operations
+		SET status = ?, progress = ?, result_ids = ?, counts = ?, error = ?, updated_at = ?, completed_at = ?
+		WHERE id = ?
+	`, operation.Status, operation.Progress, resultIDs, counts, operation.Error, operation.UpdatedAt, operation.CompletedAt, operation.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update hash file operation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "hash file operation"}
+	}
+	return nil
+}
+
+func (r *hashFileOperationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.HashFileOperation, error) {
+	operation, err := scanHashFileOperation(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+hashFileOperationColumns+` FROM hash_file_operations WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "hash file operation"}
+		}
+		return nil, err
+	}
+	return &operation, nil
+}
+
+func (r *hashFileOperationRepository) GetRecent(ctx context.Context, limit int) ([]domain.HashFileOperation, error) {
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT `+hashFileOperationColumns+` FROM hash_file_operations ORDER BY created_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	operations := []domain.HashFileOperation{}
+	for rows.Next() {
+		operation, err := scanHashFileOperation(rows)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+	return operations, rows.Err()
+}
+
+func (r *hashFileOperationRepository) FailUnfinished(ctx context.Context, message string) (int, error) {
+	now := time.Now()
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE hash_file_operations SET status = ?, error = ?, updated_at = ?, completed_at = ?
+		WHERE status IN (?, ?)
+	`, domain.HashFileOperationFailed, message, now, now, domain.HashFileOperationPending, domain.HashFileOperationRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished hash file operations: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+// encodeOperationLists stores an operation's ID lists and counts as JSON
+func encodeOperationLists(operation *domain.HashFileOperation) (string, string, string) {
+	inputIDs, _ := json.Marshal(uuidsOrEmpty(operation.InputIDs))
+	resultIDs, _ := json.Marshal(uuidsOrEmpty(operation.ResultIDs))
+	counts := []byte("{}")
+	if len(operation.Counts) > 0 {
+		counts, _ = json.Marshal(operation.Counts)
+	}
+	return string(inputIDs), string(resultIDs), string(counts)
+}
+
+func uuidsOrEmpty(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+// scanHashFileOperation scans a single row selected with
+// hashFileOperationColumns
+func scanHashFileOperation(row rowScanner) (domain.HashFileOperation, error) {
+	var operation domain.HashFileOperation
+	var id, inputIDs, resultIDs, counts string
+	var projectID, createdBy sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&operation.Type,
+		&operation.Status,
+		&operation.Progress,
+		&inputIDs,
+		&resultIDs,
+		&counts,
+		&operation.Parts,
+		&operation.Name,
+		&operation.Error,
+		&projectID,
+		&createdBy,
+		&operation.CreatedAt,
+		&operation.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return operation, err
+	}
+
+	operation.ID = uuid.MustParse(id)
+	operation.ProjectID = parseNullableUUID(projectID)
+	operation.CreatedBy = parseNullableUUID(createdBy)
+	if completedAt.Valid {
+		operation.CompletedAt = &completedAt.Time
+	}
+	if err := json.Unmarshal([]byte(inputIDs), &operation.InputIDs); err != nil {
+		return operation, fmt.Errorf("invalid input_ids of hash file operation %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(resultIDs), &operation.ResultIDs); err != nil {
+		return operation, fmt.Errorf("invalid result_ids of hash file operation %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(counts), &operation.Counts); err != nil {
+		return operation, fmt.Errorf("invalid counts of hash file operation %s: %w", id, err)
+	}
+	return operation, nil
+}
@@ -13,12 +13,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// hashFileColumns is the column list every hash file SELECT returns, in scanHashFile order
+const hashFileColumns = `id, name, orig_name, path, size, type, sha256, project_id, created_by, created_at, tenant_id, source`
+
 type hashFileRepository struct {
-	db          *database.SQLiteDB
-	cache       cache.Cache
-	getByIDStmt *sql.Stmt
-	getAllStmt  *sql.Stmt
-	deleteStmt  *sql.Stmt
+	db           *database.SQLiteDB
+	cache        cache.Cache
+	getByIDStmt  *sql.Stmt
+	getAllStmt   *sql.Stmt
+	getBySHAStmt *sql.Stmt
+	deleteStmt   *sql.Stmt
 }
 
 func NewHashFileRepository(db *database.SQLiteDB) domain.HashFileRepository {
@@ -37,7 +41,7 @@ func (r *hashFileRepository) prepareStatements() {
 	var err error
 
 	r.getByIDStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, orig_name, path, size, type, created_at
+		SELECT ` + hashFileColumns + `
 		FROM hash_files WHERE id = ? LIMIT 1
 	`)
 	if err != nil {
@@ -45,13 +49,21 @@ func (r *hashFileRepository) prepareStatements() {
 	}
 
 	r.getAllStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, orig_name, path, size, type, created_at
+		SELECT ` + hashFileColumns + `
 		FROM hash_files ORDER BY created_at DESC LIMIT 50
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getAll statement: %v", err))
 	}
 
+	r.getBySHAStmt, err = r.db.DB().Prepare(`
+		SELECT ` + hashFileColumns + `
+		FROM hash_files WHERE sha256 = ? ORDER BY created_at ASC LIMIT 1
+	`)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to prepare getBySHA256 statement: %v", err))
+	}
+
 	r.deleteStmt, err = r.db.DB().Prepare(`DELETE FROM hash_files WHERE id = ?`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare delete statement: %v", err))
@@ -60,11 +72,12 @@ func (r *hashFileRepository) prepareStatements() {
 
 func (r *hashFileRepository) Create(ctx context.Context, hashFile *domain.HashFile) error {
 	query := `
-		INSERT INTO hash_files (id, name, orig_name, path, size, type, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO hash_files (id, name, orig_name, path, size, type, sha256, project_id, created_by, created_at, tenant_id, source)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	hashFile.CreatedAt = time.Now()
+	hashFile.TenantID = tenantOf(ctx, hashFile.TenantID)
 
 	_, err := r.db.DB().ExecContext(ctx, query,
 		hashFile.ID.String(),
@@ -73,7 +86,12 @@ func (r *hashFileRepository) Create(ctx context.Context, hashFile *domain.HashFi
 		hashFile.Path,
 		hashFile.Size,
 		hashFile.Type,
+		hashFile.SHA256,
+		nullableUUID(hashFile.ProjectID),
+		nullableUUID(hashFile.CreatedBy),
 		hashFile.CreatedAt,
+		nullableUUID(hashFile.TenantID),
+		hashFile.Source,
 	)
 
 	if err == nil {
@@ -92,22 +110,11 @@ func (r *hashFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	// Try cache first
 	var hashFile domain.HashFile
 	if found, err := r.cache.Get(ctx, cacheKey, &hashFile); err == nil && found {
-		return &hashFile, nil
+		return tenantHashFile(ctx, &hashFile)
 	}
 
 	// Fallback to database with prepared statement
-	var idStr string
-
-	err := r.getByIDStmt.QueryRowContext(ctx, id.String()).Scan(
-		&idStr,
-		&hashFile.Name,
-		&hashFile.OrigName,
-		&hashFile.Path,
-		&hashFile.Size,
-		&hashFile.Type,
-		&hashFile.CreatedAt,
-	)
-
+	hashFile, err := scanHashFile(r.getByIDStmt.QueryRowContext(ctx, id.String()))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("hash file not found")
@@ -115,12 +122,10 @@ func (r *hashFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 		return nil, err
 	}
 
-	hashFile.ID = uuid.MustParse(idStr)
-
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, &hashFile)
 
-	return &hashFile, nil
+	return tenantHashFile(ctx, &hashFile)
 }
 
 func (r *hashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, error) {
@@ -129,7 +134,7 @@ func (r *hashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, err
 	// Try cache first
 	var hashFiles []domain.HashFile
 	if found, err := r.cache.Get(ctx, cacheKey, &hashFiles); err == nil && found {
-		return hashFiles, nil
+		return tenantHashFiles(ctx, hashFiles), nil
 	}
 
 	// Fallback to database with prepared statement
@@ -139,35 +144,23 @@ func (r *hashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, err
 	}
 	defer rows.Close()
 
-	hashFiles = make([]domain.HashFile, 0, 10) // Pre-allocate slice
-	for rows.Next() {
-		var hashFile domain.HashFile
-		var idStr string
-
-		err := rows.Scan(
-			&idStr,
-			&hashFile.Name,
-			&hashFile.OrigName,
-			&hashFile.Path,
-			&hashFile.Size,
-			&hashFile.Type,
-			&hashFile.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		hashFile.ID = uuid.MustParse(idStr)
-		hashFiles = append(hashFiles, hashFile)
+	hashFiles, err = scanHashFiles(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, hashFiles)
 
-	return hashFiles, nil
+	return tenantHashFiles(ctx, hashFiles), nil
 }
 
 func (r *hashFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if domain.TenantScoped(ctx) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
 	_, err := r.deleteStmt.ExecContext(ctx, id.String())
 
 	if err == nil {
@@ -179,3 +172,120 @@ func (r *hashFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return err
 }
+
+func (r *hashFileRepository) GetBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	row := r.getBySHAStmt.QueryRowContext(ctx, sum)
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		// Uploads are only deduplicated against the tenant's own files
+		row = r.db.DB().QueryRowContext(ctx, `
+			SELECT `+hashFileColumns+`
+			FROM hash_files WHERE sha256 = ? AND tenant_id = ? ORDER BY created_at ASC LIMIT 1
+		`, sum, tenantID.String())
+	} else if domain.InSharedScope(ctx) {
+		row = r.db.DB().QueryRowContext(ctx, `
+			SELECT `+hashFileColumns+`
+			FROM hash_files WHERE sha256 = ? AND tenant_id IS NULL ORDER BY created_at ASC LIMIT 1
+		`, sum)
+	}
+	hashFile, err := scanHashFile(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hash file not found")
+		}
+		return nil, err
+	}
+
+	return &hashFile, nil
+}
+
+// GetByProject returns the hash files of a project. It isn't cached, so
+// project lists don't go stale behind the shared list cache.
+func (r *hashFileRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+hashFileColumns+`
+		FROM hash_files WHERE project_id = ? ORDER BY created_at DESC
+	`, projectID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashFiles, err := scanHashFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tenantHashFiles(ctx, hashFiles), nil
+}
+
+func (r *hashFileRepository) SetSource(ctx context.Context, id uuid.UUID, source string) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `UPDATE hash_files SET source = ? WHERE id = ?`, source, id.String()); err != nil {
+		return err
+	}
+
+	r.cache.Delete(ctx, "hashfile:"+id.String())
+	r.cache.Delete(ctx, "hashfiles:all")
+	return nil
+}
+
+// scanHashFile scans a single row selected with hashFileColumns
+func scanHashFile(row rowScanner) (domain.HashFile, error) {
+	var hashFile domain.HashFile
+	var idStr string
+	var sha256Sum sql.NullString
+	var projectID sql.NullString
+	var createdBy sql.NullString
+	var tenantID sql.NullString
+
+	err := row.Scan(
+		&idStr,
+		&hashFile.Name,
+		&hashFile.OrigName,
+		&hashFile.Path,
+		&hashFile.Size,
+		&hashFile.Type,
+		&sha256Sum,
+		&projectID,
+		&createdBy,
+		&hashFile.CreatedAt,
+		&tenantID,
+		&hashFile.Source,
+	)
+	if err != nil {
+		return hashFile, err
+	}
+
+	hashFile.ID = uuid.MustParse(idStr)
+	hashFile.SHA256 = sha256Sum.String
+	hashFile.ProjectID = parseNullableUUID(projectID)
+	hashFile.CreatedBy = parseNullableUUID(createdBy)
+	hashFile.TenantID = parseNullableUUID(tenantID)
+	return hashFile, nil
+}
+
+// tenantHashFile hides the hash files of other tenants as if they didn't exist
+func tenantHashFile(ctx context.Context, hashFile *domain.HashFile) (*domain.HashFile, error) {
+	if !tenantVisible(ctx, hashFile.TenantID) {
+		return nil, fmt.Errorf("hash file not found")
+	}
+	return hashFile, nil
+}
+
+// tenantHashFiles keeps the hash files of the request's tenant
+func tenantHashFiles(ctx context.Context, hashFiles []domain.HashFile) []domain.HashFile {
+	return filterTenant(ctx, hashFiles, func(hashFile *domain.HashFile) bool { return tenantVisible(ctx, hashFile.TenantID) })
+}
+
+func scanHashFiles(rows *sql.Rows) ([]domain.HashFile, error) {
+	hashFiles := make([]domain.HashFile, 0, 10) // Pre-allocate slice
+	for rows.Next() {
+		hashFile, err := scanHashFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		hashFiles = append(hashFiles, hashFile)
+	}
+	return hashFiles, rows.Err()
+}
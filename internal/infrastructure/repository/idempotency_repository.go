@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+)
+
+type idempotencyRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewIdempotencyRepository(db *database.SQLiteDB) domain.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve inserts an in-progress record for the key. INSERT OR IGNORE makes
+// the claim atomic, so two concurrent requests with the same key can't both
+// go on to create work.
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR IGNORE INTO idempotency_keys (key, request_hash, status_code, created_at)
+		VALUES (?, ?, 0, ?)
+	`, record.Key, record.RequestHash, record.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if inserted > 0 {
+		return nil, nil
+	}
+
+	existing := &domain.IdempotencyRecord{}
+	var response []byte
+	err = r.db.DB().QueryRowContext(ctx, `
+		SELECT key, request_hash, status_code, response, created_at
+		FROM idempotency_keys WHERE key = ?
+	`, record.Key).Scan(&existing.Key, &existing.RequestHash, &existing.StatusCode, &response, &existing.CreatedAt)
+	if err == sql.ErrNoRows {
+		// Released between the insert and the lookup; let the caller retry
+		return nil, fmt.Errorf("idempotency key %q was released concurrently", record.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing.Response = response
+
+	return existing, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, statusCode int, response []byte) error {
+	_, err := r.db.DB().ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code = ?, response = ? WHERE key = ?
+	`, statusCode, response, key)
+	return err
+}
+
+// Release forgets a key whose request failed so that it can be retried
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.DB().ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ?`, key)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
@@ -13,17 +15,27 @@ import (
 	"github.com/google/uuid"
 )
 
+// jobColumns is the column list every job SELECT returns, in scanJob order
+const jobColumns = `id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules,
+		       agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit,
+		       file_source, group_id, archived_at, deleted_at, retried_from, project_id,
+		       custom_charset1, custom_charset2, custom_charset3, custom_charset4, wordlist2_id, extra_args, engine, john_format,
+		       device_seconds, energy_wh, created_by, lease_expires_at, tags, max_runtime_minutes, stall_timeout_minutes,
+		       normalized_progress, runtime_stats, tenant_id, deadline, budget_stage`
+
+// activeJobs filters out archived and soft-deleted jobs
+const activeJobs = `archived_at IS NULL AND deleted_at IS NULL`
+
 type jobRepository struct {
-	db                 *database.SQLiteDB
-	cache              cache.Cache
-	getByIDStmt        *sql.Stmt
-	getAllStmt         *sql.Stmt
-	getByStatusStmt    *sql.Stmt
-	getByAgentIDStmt   *sql.Stmt
-	updateStmt         *sql.Stmt
-	deleteStmt         *sql.Stmt
-	updateStatusStmt   *sql.Stmt
-	updateProgressStmt *sql.Stmt
+	db               *database.SQLiteDB
+	cache            cache.Cache
+	getByIDStmt      *sql.Stmt
+	getAllStmt       *sql.Stmt
+	getByStatusStmt  *sql.Stmt
+	getByAgentIDStmt *sql.Stmt
+	updateStmt       *sql.Stmt
+	deleteStmt       *sql.Stmt
+	updateStatusStmt *sql.Stmt
 }
 
 func NewJobRepository(db *database.SQLiteDB) domain.JobRepository {
@@ -42,36 +54,32 @@ func (r *jobRepository) prepareStatements() {
 	var err error
 
 	r.getByIDStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules,
-		       agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit
-		FROM jobs WHERE id = ? LIMIT 1
+		SELECT ` + jobColumns + `
+		FROM jobs WHERE id = ? AND deleted_at IS NULL LIMIT 1
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getByID statement: %v", err))
 	}
 
 	r.getAllStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules,
-		       agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit
-		FROM jobs ORDER BY created_at DESC LIMIT 100
+		SELECT ` + jobColumns + `
+		FROM jobs WHERE ` + activeJobs + ` ORDER BY created_at DESC LIMIT 100
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getAll statement: %v", err))
 	}
 
 	r.getByStatusStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules,
-		       agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit
-		FROM jobs WHERE status = ? ORDER BY created_at DESC LIMIT 50
+		SELECT ` + jobColumns + `
+		FROM jobs WHERE status = ? AND ` + activeJobs + ` ORDER BY created_at DESC LIMIT 50
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getByStatus statement: %v", err))
 	}
 
 	r.getByAgentIDStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules,
-		       agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit
-		FROM jobs WHERE agent_id = ? ORDER BY created_at DESC LIMIT 20
+		SELECT ` + jobColumns + `
+		FROM jobs WHERE agent_id = ? AND ` + activeJobs + ` ORDER BY created_at DESC LIMIT 20
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getByAgentID statement: %v", err))
@@ -80,14 +88,18 @@ func (r *jobRepository) prepareStatements() {
 	r.updateStmt, err = r.db.DB().Prepare(`
 		UPDATE jobs SET 
 		name = ?, status = ?, hash_type = ?, attack_mode = ?, hash_file = ?, hash_file_id = ?, wordlist = ?, wordlist_id = ?, rules = ?,
-		agent_id = ?, progress = ?, speed = ?, eta = ?, result = ?, updated_at = ?, started_at = ?, completed_at = ?, skip = ?, word_limit = ?
+		agent_id = ?, progress = ?, speed = ?, eta = ?, result = ?, updated_at = ?, started_at = ?, completed_at = ?, skip = ?, word_limit = ?,
+		file_source = ?, group_id = ?, retried_from = ?, project_id = ?,
+		custom_charset1 = ?, custom_charset2 = ?, custom_charset3 = ?, custom_charset4 = ?, wordlist2_id = ?, extra_args = ?, engine = ?, john_format = ?,
+		device_seconds = ?, energy_wh = ?, lease_expires_at = ?, max_runtime_minutes = ?, stall_timeout_minutes = ?,
+		normalized_progress = ?, runtime_stats = ?
 		WHERE id = ?
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare update statement: %v", err))
 	}
 
-	r.deleteStmt, err = r.db.DB().Prepare(`DELETE FROM jobs WHERE id = ?`)
+	r.deleteStmt, err = r.db.DB().Prepare(`UPDATE jobs SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare delete statement: %v", err))
 	}
@@ -96,23 +108,73 @@ func (r *jobRepository) prepareStatements() {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare updateStatus statement: %v", err))
 	}
+}
 
-	r.updateProgressStmt, err = r.db.DB().Prepare(`UPDATE jobs SET progress = ?, speed = ?, updated_at = ? WHERE id = ?`)
+func (r *jobRepository) Create(ctx context.Context, job *domain.Job) error {
+	err := r.insertJob(ctx, r.db.DB(), job)
+
+	if err == nil {
+		// Cache the new job
+		r.cache.Set(ctx, "job:"+job.ID.String(), job)
+		// Invalidate list caches
+		r.invalidateListCaches(ctx)
+	}
+
+	return err
+}
+
+// CreateBatch inserts a set of jobs, and the group they belong to when group
+// is not nil, in a single transaction: either all of them are stored or none.
+func (r *jobRepository) CreateBatch(ctx context.Context, group *domain.JobGroup, jobs []*domain.Job) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to prepare updateProgress statement: %v", err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if group != nil {
+		if err := r.insertGroup(ctx, tx, group); err != nil {
+			return fmt.Errorf("failed to create job group: %w", err)
+		}
+	}
+
+	for i, job := range jobs {
+		if err := r.insertJob(ctx, tx, job); err != nil {
+			return fmt.Errorf("failed to create job %d of %d: %w", i+1, len(jobs), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		r.cache.Set(ctx, "job:"+job.ID.String(), job)
 	}
+	r.invalidateListCaches(ctx)
+
+	return nil
 }
 
-func (r *jobRepository) Create(ctx context.Context, job *domain.Job) error {
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *jobRepository) insertJob(ctx context.Context, db execer, job *domain.Job) error {
 	query := `
 		INSERT INTO jobs (id, name, status, hash_type, attack_mode, hash_file, hash_file_id, wordlist, wordlist_id, rules, 
-		                  agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                  agent_id, progress, speed, eta, result, created_at, updated_at, started_at, completed_at, skip, word_limit, file_source, group_id, retried_from, project_id,
+		                  custom_charset1, custom_charset2, custom_charset3, custom_charset4, wordlist2_id, extra_args, engine, john_format, created_by, tags,
+		                  max_runtime_minutes, stall_timeout_minutes, tenant_id, deadline, budget_stage)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
 	job.CreatedAt = now
 	job.UpdatedAt = now
+	job.Engine = job.EngineName()
+	job.TenantID = tenantOf(ctx, job.TenantID)
 
 	var agentID *string
 	if job.AgentID != nil {
@@ -147,7 +209,12 @@ func (r *jobRepository) Create(ctx context.Context, job *domain.Job) error {
 		completedAt = job.CompletedAt
 	}
 
-	_, err := r.db.DB().ExecContext(ctx, query,
+	result, err := r.db.SealField(job.Result)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query,
 		job.ID.String(),
 		job.Name,
 		job.Status,
@@ -162,22 +229,34 @@ func (r *jobRepository) Create(ctx context.Context, job *domain.Job) error {
 		job.Progress,
 		job.Speed,
 		eta,
-		job.Result,
+		result,
 		job.CreatedAt,
 		job.UpdatedAt,
 		startedAt,
 		completedAt,
 		job.Skip,
 		job.WordLimit,
+		job.FileSource,
+		nullableUUID(job.GroupID),
+		nullableUUID(job.RetriedFrom),
+		nullableUUID(job.ProjectID),
+		job.CustomCharset1,
+		job.CustomCharset2,
+		job.CustomCharset3,
+		job.CustomCharset4,
+		nullableUUID(job.Wordlist2ID),
+		encodeArgs(job.ExtraArgs),
+		job.Engine,
+		job.JohnFormat,
+		nullableUUID(job.CreatedBy),
+		encodeArgs(job.Tags),
+		job.MaxRuntimeMinutes,
+		job.StallTimeoutMinutes,
+		nullableUUID(job.TenantID),
+		job.Deadline,
+		job.BudgetStage,
 	)
 
-	if err == nil {
-		// Cache the new job
-		r.cache.Set(ctx, "job:"+job.ID.String(), job)
-		// Invalidate list caches
-		r.invalidateListCaches(ctx)
-	}
-
 	return err
 }
 
@@ -187,7 +266,7 @@ func (r *jobRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Job,
 	// Try cache first
 	var job domain.Job
 	if found, err := r.cache.Get(ctx, cacheKey, &job); err == nil && found {
-		return &job, nil
+		return tenantJob(ctx, &job)
 	}
 
 	// Fallback to database
@@ -199,7 +278,20 @@ func (r *jobRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Job,
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, &job)
 
-	return &job, nil
+	return tenantJob(ctx, &job)
+}
+
+// tenantJob hides the jobs of other tenants as if they didn't exist
+func tenantJob(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	if !tenantVisible(ctx, job.TenantID) {
+		return nil, fmt.Errorf("job not found")
+	}
+	return job, nil
+}
+
+// tenantJobs keeps the jobs of the request's tenant
+func tenantJobs(ctx context.Context, jobs []domain.Job) []domain.Job {
+	return filterTenant(ctx, jobs, func(job *domain.Job) bool { return tenantVisible(ctx, job.TenantID) })
 }
 
 func (r *jobRepository) GetAll(ctx context.Context) ([]domain.Job, error) {
@@ -208,7 +300,7 @@ func (r *jobRepository) GetAll(ctx context.Context) ([]domain.Job, error) {
 	// Try cache first
 	var jobs []domain.Job
 	if found, err := r.cache.Get(ctx, cacheKey, &jobs); err == nil && found {
-		return jobs, nil
+		return tenantJobs(ctx, jobs), nil
 	}
 
 	// Fallback to database
@@ -220,7 +312,7 @@ func (r *jobRepository) GetAll(ctx context.Context) ([]domain.Job, error) {
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, jobs)
 
-	return jobs, nil
+	return tenantJobs(ctx, jobs), nil
 }
 
 func (r *jobRepository) GetByStatus(ctx context.Context, status string) ([]domain.Job, error) {
@@ -229,7 +321,7 @@ func (r *jobRepository) GetByStatus(ctx context.Context, status string) ([]domai
 	// Try cache first
 	var jobs []domain.Job
 	if found, err := r.cache.Get(ctx, cacheKey, &jobs); err == nil && found {
-		return jobs, nil
+		return tenantJobs(ctx, jobs), nil
 	}
 
 	// Fallback to database
@@ -241,7 +333,7 @@ func (r *jobRepository) GetByStatus(ctx context.Context, status string) ([]domai
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, jobs)
 
-	return jobs, nil
+	return tenantJobs(ctx, jobs), nil
 }
 
 func (r *jobRepository) GetByAgentID(ctx context.Context, agentID uuid.UUID) ([]domain.Job, error) {
@@ -250,7 +342,7 @@ func (r *jobRepository) GetByAgentID(ctx context.Context, agentID uuid.UUID) ([]
 	// Try cache first
 	var jobs []domain.Job
 	if found, err := r.cache.Get(ctx, cacheKey, &jobs); err == nil && found {
-		return jobs, nil
+		return tenantJobs(ctx, jobs), nil
 	}
 
 	// Fallback to database
@@ -262,23 +354,23 @@ func (r *jobRepository) GetByAgentID(ctx context.Context, agentID uuid.UUID) ([]
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, jobs)
 
-	return jobs, nil
+	return tenantJobs(ctx, jobs), nil
 }
 
 // GetAvailableJobForAgent gets the next available job assigned to the agent that is ready to run
 func (r *jobRepository) GetAvailableJobForAgent(ctx context.Context, agentID uuid.UUID) (*domain.Job, error) {
 	// Query for pending jobs assigned to this agent
 	query := `
-		SELECT id, name, status, hash_type, attack_mode, hash_file, hash_file_id, 
-		       wordlist, wordlist_id, rules, agent_id, progress, speed, eta, result, 
-		       created_at, updated_at, started_at, completed_at, skip, word_limit
+		SELECT ` + jobColumns + `
 		FROM jobs 
-		WHERE agent_id = ? AND status = 'pending'
+		WHERE agent_id = ? AND status IN ('pending', 'assigned') AND ` + activeJobs + `
+		  AND (deadline IS NULL OR julianday(deadline) > julianday(?))
 		ORDER BY created_at ASC
 		LIMIT 1
 	`
 
-	row := r.db.DB().QueryRowContext(ctx, query, agentID.String())
+	// Jobs too close to their deadline are left for the watchdog to expire
+	row := r.db.DB().QueryRowContext(ctx, query, agentID.String(), time.Now().Add(domain.JobStartupAllowance))
 	job, err := r.scanJob(row)
 	if err != nil {
 		if err.Error() == "job not found" {
@@ -292,6 +384,7 @@ func (r *jobRepository) GetAvailableJobForAgent(ctx context.Context, agentID uui
 
 func (r *jobRepository) Update(ctx context.Context, job *domain.Job) error {
 	job.UpdatedAt = time.Now()
+	job.Engine = job.EngineName()
 
 	var agentID *string
 	if job.AgentID != nil {
@@ -311,7 +404,12 @@ func (r *jobRepository) Update(ctx context.Context, job *domain.Job) error {
 		wordlistID = &wordlistIDStr
 	}
 
-	_, err := r.updateStmt.ExecContext(ctx,
+	result, err := r.db.SealField(job.Result)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.updateStmt.ExecContext(ctx,
 		job.Name,
 		job.Status,
 		job.HashType,
@@ -325,12 +423,31 @@ func (r *jobRepository) Update(ctx context.Context, job *domain.Job) error {
 		job.Progress,
 		job.Speed,
 		job.ETA,
-		job.Result,
+		result,
 		job.UpdatedAt,
 		job.StartedAt,
 		job.CompletedAt,
 		job.Skip,
 		job.WordLimit,
+		job.FileSource,
+		nullableUUID(job.GroupID),
+		nullableUUID(job.RetriedFrom),
+		nullableUUID(job.ProjectID),
+		job.CustomCharset1,
+		job.CustomCharset2,
+		job.CustomCharset3,
+		job.CustomCharset4,
+		nullableUUID(job.Wordlist2ID),
+		encodeArgs(job.ExtraArgs),
+		job.Engine,
+		job.JohnFormat,
+		job.DeviceSeconds,
+		job.EnergyWh,
+		job.LeaseExpiresAt,
+		job.MaxRuntimeMinutes,
+		job.StallTimeoutMinutes,
+		job.NormalizedProgress,
+		encodeStats(job.Stats),
 		job.ID.String(),
 	)
 
@@ -344,8 +461,16 @@ func (r *jobRepository) Update(ctx context.Context, job *domain.Job) error {
 	return err
 }
 
+// Delete soft-deletes a job. The row is kept, so the job can still be
+// restored, until Purge removes it.
 func (r *jobRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.deleteStmt.ExecContext(ctx, id.String())
+	if domain.TenantScoped(ctx) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	_, err := r.deleteStmt.ExecContext(ctx, now, now, id.String())
 
 	if err == nil {
 		// Remove from cache
@@ -369,22 +494,722 @@ func (r *jobRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status s
 	return err
 }
 
+// UpdateProgress queues the progress for the database's next write batch.
+// Agents report progress every few seconds, so only the latest report of a
+// job is written. It applies to running and paused jobs only, so a report
+// written after the job finished doesn't undo its final state.
 func (r *jobRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress float64, speed int64) error {
-	_, err := r.updateProgressStmt.ExecContext(ctx, progress, speed, time.Now(), id.String())
+	r.db.QueueWrite(database.QueuedWrite{
+		Key:   "job-progress:" + id.String(),
+		Query: `UPDATE jobs SET progress = ?, speed = ?, updated_at = ? WHERE id = ? AND status IN ('running', 'paused')`,
+		Args:  []interface{}{progress, speed, time.Now(), id.String()},
+		Done: func() {
+			// Invalidate caches (don't cache individual job for progress updates due to frequency)
+			r.cache.Delete(context.Background(), "job:"+id.String())
+		},
+	})
+	return nil
+}
 
-	if err == nil {
-		// Invalidate caches (don't cache individual job for progress updates due to frequency)
+func (r *jobRepository) UpdateProgressBatch(ctx context.Context, progress map[uuid.UUID]domain.JobProgress) error {
+	if len(progress) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, p := range progress {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE jobs SET progress = ?, normalized_progress = ?, speed = ?, eta = ?, runtime_stats = ?,
+			device_seconds = ?, energy_wh = ?, updated_at = ?
+			WHERE id = ? AND status IN ('running', 'paused')`,
+			p.Progress, p.NormalizedProgress, p.Speed, p.ETA, encodeStats(p.Stats),
+			p.DeviceSeconds, p.EnergyWh, p.ReportedAt, id.String())
+		if err != nil {
+			return fmt.Errorf("failed to update progress of job %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for id := range progress {
 		r.cache.Delete(ctx, "job:"+id.String())
 	}
+	return nil
+}
+
+// AcquireLease gives an agent the lease on a job assigned to it, unless the
+// job was given to another agent or started meanwhile. The check and the
+// write are one statement, so two agents can't both take the same job.
+func (r *jobRepository) AcquireLease(ctx context.Context, jobID, agentID uuid.UUID, until time.Time) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE jobs SET lease_expires_at = ?, updated_at = ?
+		WHERE id = ? AND agent_id = ? AND status IN ('pending', 'assigned') AND `+activeJobs,
+		until, time.Now(), jobID.String(), agentID.String())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if n > 0 {
+		r.cache.Delete(ctx, "job:"+jobID.String())
+	}
+	return n > 0, nil
+}
+
+// RenewLeases extends the leases on the assigned and running jobs of the
+// given agents. Job caches are left alone: this runs with every heartbeat
+// batch and only the lease changes.
+func (r *jobRepository) RenewLeases(ctx context.Context, agentIDs []uuid.UUID, until time.Time) error {
+	if len(agentIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(agentIDs))
+	args := []interface{}{until}
+	for i, id := range agentIDs {
+		placeholders[i] = "?"
+		args = append(args, id.String())
+	}
+	_, err := r.db.DB().ExecContext(ctx, `
+		UPDATE jobs SET lease_expires_at = ?
+		WHERE agent_id IN (`+strings.Join(placeholders, ", ")+`) AND status IN ('assigned', 'running')`,
+		args...)
+	return err
+}
+
+// GetExpiredLeases returns assigned and running jobs whose agent stopped
+// renewing the lease before the given time
+func (r *jobRepository) GetExpiredLeases(ctx context.Context, before time.Time) ([]domain.Job, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE status IN ('assigned', 'running') AND lease_expires_at IS NOT NULL AND lease_expires_at < ? AND `+activeJobs+`
+		ORDER BY lease_expires_at ASC
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanJobs(rows)
+}
+
+// ExpireLease takes a job back from the agent whose lease ran out. The check
+// and the write are one statement, so a lease renewed since the job was
+// read stays with its agent.
+func (r *jobRepository) ExpireLease(ctx context.Context, jobID uuid.UUID, from, to string, before time.Time) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE jobs SET status = ?, agent_id = NULL, lease_expires_at = NULL, updated_at = ?
+		WHERE id = ? AND status = ? AND lease_expires_at IS NOT NULL AND lease_expires_at < ? AND `+activeJobs,
+		to, time.Now(), jobID.String(), from, before)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if n > 0 {
+		r.cache.Delete(ctx, "job:"+jobID.String())
+	}
+	return n > 0, nil
+}
+
+// GetPastDeadline returns the unfinished jobs whose deadline is before the
+// given time, soonest first
+func (r *jobRepository) GetPastDeadline(ctx context.Context, before time.Time) ([]domain.Job, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE status IN ('pending', 'assigned', 'running', 'paused', 'interrupted')
+		  AND deadline IS NOT NULL AND julianday(deadline) < julianday(?) AND deleted_at IS NULL
+		ORDER BY julianday(deadline) ASC
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanJobs(rows)
+}
+
+// GetByGroupID returns the sub-jobs of a job group, oldest first. Not cached:
+// group status is polled while the jobs are running.
+func (r *jobRepository) GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]domain.Job, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE group_id = ? AND deleted_at IS NULL
+		ORDER BY created_at ASC
+	`, groupID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs, err := r.scanJobs(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tenantJobs(ctx, jobs), nil
+}
+
+func (r *jobRepository) CreateGroup(ctx context.Context, group *domain.JobGroup) error {
+	return r.insertGroup(ctx, r.db.DB(), group)
+}
+
+func (r *jobRepository) insertGroup(ctx context.Context, db execer, group *domain.JobGroup) error {
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+	group.CreatedAt = time.Now()
+
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO job_groups (id, name, created_at, budget) VALUES (?, ?, ?, ?)`,
+		group.ID.String(), group.Name, group.CreatedAt, encodeBudget(group.Budget),
+	)
+	return err
+}
+
+func (r *jobRepository) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.JobGroup, error) {
+	group, err := scanJobGroup(r.db.DB().QueryRowContext(ctx,
+		`SELECT id, name, created_at, budget FROM job_groups WHERE id = ?`, id.String(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "job group"}
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetBudgetedGroups returns the job groups with a budget that still have
+// unfinished jobs
+func (r *jobRepository) GetBudgetedGroups(ctx context.Context) ([]domain.JobGroup, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, name, created_at, budget
+		FROM job_groups g
+		WHERE budget != '' AND EXISTS (
+			SELECT 1 FROM jobs
+			WHERE group_id = g.id AND status IN ('pending', 'assigned', 'running', 'paused', 'interrupted') AND deleted_at IS NULL
+		)
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []domain.JobGroup
+	for rows.Next() {
+		group, err := scanJobGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+	return groups, rows.Err()
+}
+
+func scanJobGroup(row rowScanner) (*domain.JobGroup, error) {
+	var group domain.JobGroup
+	var idStr, budget string
+	if err := row.Scan(&idStr, &group.Name, &group.CreatedAt, &budget); err != nil {
+		return nil, err
+	}
+	group.ID = uuid.MustParse(idStr)
+	group.Budget = decodeBudget(budget)
+	return &group, nil
+}
+
+// List returns one page of active jobs matching the filter, plus the total
+// number of matching jobs
+func (r *jobRepository) List(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error) {
+	where := []string{activeJobs}
+	var args []interface{}
+
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.AgentID != nil {
+		where = append(where, "agent_id = ?")
+		args = append(args, filter.AgentID.String())
+	}
+	if filter.ProjectID != nil {
+		where = append(where, "project_id = ?")
+		args = append(args, filter.ProjectID.String())
+	}
+	if filter.HashType != nil {
+		where = append(where, "hash_type = ?")
+		args = append(args, *filter.HashType)
+	}
+	if filter.CreatedFrom != nil {
+		where = append(where, "created_at >= ?")
+		args = append(args, *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where = append(where, "created_at <= ?")
+		args = append(args, *filter.CreatedTo)
+	}
+	for _, tag := range filter.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(NULLIF(jobs.tags, '')) WHERE value = ? COLLATE NOCASE)")
+		args = append(args, tag)
+	}
+	where, args = tenantCondition(ctx, "tenant_id", where, args)
+	whereClause := " WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := r.db.DB().QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Only whitelisted columns reach the ORDER BY clause
+	sortBy := "created_at"
+	for _, column := range domain.JobSortColumns {
+		if filter.SortBy == column {
+			sortBy = column
+		}
+	}
+	order := "ASC"
+	if filter.SortDesc {
+		order = "DESC"
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx,
+		"SELECT "+jobColumns+" FROM jobs"+whereClause+
+			fmt.Sprintf(" ORDER BY %s %s, id LIMIT ? OFFSET ?", sortBy, order),
+		append(args, limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	jobs, err := r.scanJobs(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// GetArchived returns archived and soft-deleted jobs, most recent first
+func (r *jobRepository) GetArchived(ctx context.Context) ([]domain.Job, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE archived_at IS NOT NULL OR deleted_at IS NOT NULL
+		ORDER BY COALESCE(deleted_at, archived_at) DESC
+		LIMIT 500
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs, err := r.scanJobs(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tenantJobs(ctx, jobs), nil
+}
+
+// Archive archives finished jobs that completed before the cutoff and
+// returns how many were archived
+func (r *jobRepository) Archive(ctx context.Context, completedBefore time.Time) (int64, error) {
+	now := time.Now()
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE jobs SET archived_at = ?, updated_at = ?
+		WHERE status IN ('completed', 'cracked', 'failed', 'cancelled', 'expired')
+		  AND completed_at IS NOT NULL AND completed_at < ?
+		  AND `+activeJobs,
+		now, now, completedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	archived, err := result.RowsAffected()
+	if err == nil && archived > 0 {
+		r.cache.Clear(ctx)
+	}
+	return archived, err
+}
+
+// Restore brings an archived or soft-deleted job back into the job lists
+func (r *jobRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE jobs SET archived_at = NULL, deleted_at = NULL, updated_at = ?
+		WHERE id = ? AND (archived_at IS NOT NULL OR deleted_at IS NOT NULL)
+	`, time.Now(), id.String())
+	if err != nil {
+		return err
+	}
 
+	if restored, err := result.RowsAffected(); err != nil {
+		return err
+	} else if restored == 0 {
+		return &domain.NotFoundError{Entity: "archived job"}
+	}
+
+	r.cache.Delete(ctx, "job:"+id.String())
+	r.invalidateListCaches(ctx)
+	return nil
+}
+
+// Purge permanently removes jobs soft-deleted before the cutoff and returns
+// how many were removed
+func (r *jobRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	for _, table := range []string{"job_events", "job_outputs", "job_checkpoints", "job_comments", "job_cracks"} {
+		if _, err := r.db.DB().ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE job_id IN (SELECT id FROM jobs WHERE deleted_at IS NOT NULL AND deleted_at < ?)`,
+			deletedBefore); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM jobs WHERE deleted_at IS NOT NULL AND deleted_at < ?`, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *jobRepository) CreateEvent(ctx context.Context, event *domain.JobEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO job_events (id, job_id, from_status, to_status, actor, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, event.ID.String(), event.JobID.String(), event.FromStatus, event.ToStatus, event.Actor, event.Reason, event.CreatedAt)
+	return err
+}
+
+// GetEvents returns a job's status changes, oldest first
+func (r *jobRepository) GetEvents(ctx context.Context, jobID uuid.UUID) ([]domain.JobEvent, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, job_id, COALESCE(from_status, ''), to_status, actor, COALESCE(reason, ''), created_at
+		FROM job_events
+		WHERE job_id = ?
+		ORDER BY created_at, rowid
+	`, jobID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.JobEvent{}
+	for rows.Next() {
+		var event domain.JobEvent
+		var idStr, jobIDStr string
+		if err := rows.Scan(&idStr, &jobIDStr, &event.FromStatus, &event.ToStatus, &event.Actor, &event.Reason, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.ID = uuid.MustParse(idStr)
+		event.JobID = uuid.MustParse(jobIDStr)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// UpdateTags replaces a job's tags without touching the rest of the row,
+// which agents keep updating while the job runs
+func (r *jobRepository) UpdateTags(ctx context.Context, jobID uuid.UUID, tags []string) error {
+	result, err := r.db.DB().ExecContext(ctx, `UPDATE jobs SET tags = ?, updated_at = ? WHERE id = ?`,
+		encodeArgs(tags), time.Now(), jobID.String())
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return &domain.NotFoundError{Entity: "job"}
+	}
+
+	r.cache.Delete(ctx, "job:"+jobID.String())
+	r.invalidateListCaches(ctx)
+	return nil
+}
+
+func (r *jobRepository) CreateComment(ctx context.Context, comment *domain.JobComment) error {
+	if comment.ID == uuid.Nil {
+		comment.ID = uuid.New()
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO job_comments (id, job_id, author_id, author, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, comment.ID.String(), comment.JobID.String(), nullableUUID(comment.AuthorID), comment.Author, comment.Body, comment.CreatedAt)
 	return err
 }
 
+func (r *jobRepository) GetComments(ctx context.Context, jobID uuid.UUID) ([]domain.JobComment, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, job_id, author_id, author, body, created_at
+		FROM job_comments
+		WHERE job_id = ?
+		ORDER BY created_at, rowid
+	`, jobID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []domain.JobComment{}
+	for rows.Next() {
+		var comment domain.JobComment
+		var idStr, jobIDStr string
+		var authorID sql.NullString
+		if err := rows.Scan(&idStr, &jobIDStr, &authorID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comment.ID = uuid.MustParse(idStr)
+		comment.JobID = uuid.MustParse(jobIDStr)
+		comment.AuthorID = parseNullableUUID(authorID)
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *jobRepository) DeleteComment(ctx context.Context, jobID, commentID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM job_comments WHERE id = ? AND job_id = ?`,
+		commentID.String(), jobID.String())
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return &domain.NotFoundError{Entity: "job comment"}
+	}
+	return nil
+}
+
+func (r *jobRepository) SaveOutput(ctx context.Context, output *domain.JobOutput) error {
+	if output.CreatedAt.IsZero() {
+		output.CreatedAt = time.Now()
+	}
+
+	var exitCode sql.NullInt64
+	if output.ExitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*output.ExitCode), Valid: true}
+	}
+
+	// hashcat prints cracked hashes with their passwords, so the output is
+	// sealed like job results
+	stdout, err := r.db.SealField(output.Stdout)
+	if err != nil {
+		return err
+	}
+	stderr, err := r.db.SealField(output.Stderr)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO job_outputs (job_id, agent_id, exit_code, stdout, stderr, truncated, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, output.JobID.String(), nullableUUID(output.AgentID), exitCode, stdout, stderr, output.Truncated, output.CreatedAt)
+	return err
+}
+
+func (r *jobRepository) GetOutput(ctx context.Context, jobID uuid.UUID) (*domain.JobOutput, error) {
+	var output domain.JobOutput
+	var agentIDStr sql.NullString
+	var exitCode sql.NullInt64
+
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT agent_id, exit_code, stdout, stderr, truncated, created_at
+		FROM job_outputs
+		WHERE job_id = ?
+	`, jobID.String()).Scan(&agentIDStr, &exitCode, &output.Stdout, &output.Stderr, &output.Truncated, &output.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{Entity: "job output"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if output.Stdout, err = r.db.OpenField(output.Stdout); err != nil {
+		return nil, err
+	}
+	if output.Stderr, err = r.db.OpenField(output.Stderr); err != nil {
+		return nil, err
+	}
+
+	output.JobID = jobID
+	output.AgentID = parseNullableUUID(agentIDStr)
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		output.ExitCode = &code
+	}
+	return &output, nil
+}
+
+func (r *jobRepository) SaveCheckpoint(ctx context.Context, checkpoint *domain.JobCheckpoint) error {
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now()
+	}
+	checkpoint.Size = int64(len(checkpoint.Data))
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO job_checkpoints (job_id, agent_id, hashcat_version, restore_point, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, checkpoint.JobID.String(), nullableUUID(checkpoint.AgentID), checkpoint.HashcatVersion, checkpoint.RestorePoint,
+		checkpoint.Data, checkpoint.CreatedAt)
+	return err
+}
+
+func (r *jobRepository) GetCheckpoint(ctx context.Context, jobID uuid.UUID) (*domain.JobCheckpoint, error) {
+	var checkpoint domain.JobCheckpoint
+	var agentIDStr sql.NullString
+
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT agent_id, hashcat_version, restore_point, data, created_at
+		FROM job_checkpoints
+		WHERE job_id = ?
+	`, jobID.String()).Scan(&agentIDStr, &checkpoint.HashcatVersion, &checkpoint.RestorePoint, &checkpoint.Data, &checkpoint.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &domain.NotFoundError{Entity: "job checkpoint"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint.JobID = jobID
+	checkpoint.AgentID = parseNullableUUID(agentIDStr)
+	checkpoint.Size = int64(len(checkpoint.Data))
+	return &checkpoint, nil
+}
+
+func (r *jobRepository) CreateCracks(ctx context.Context, cracks []domain.JobCrack) ([]domain.JobCrack, error) {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := []domain.JobCrack{}
+	for _, crack := range cracks {
+		if crack.ID == uuid.Nil {
+			crack.ID = uuid.New()
+		}
+		plaintext, err := r.db.SealField(crack.Plaintext)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO job_cracks (id, job_id, agent_id, hash, plaintext, cracked_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, crack.ID.String(), crack.JobID.String(), nullableUUID(crack.AgentID), crack.Hash, plaintext, crack.CrackedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store crack: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			created = append(created, crack)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cracks: %w", err)
+	}
+	return created, nil
+}
+
+func (r *jobRepository) GetCracks(ctx context.Context, jobID uuid.UUID) ([]domain.JobCrack, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, agent_id, hash, plaintext, cracked_at
+		FROM job_cracks
+		WHERE job_id = ?
+		ORDER BY cracked_at, rowid
+	`, jobID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cracks := []domain.JobCrack{}
+	for rows.Next() {
+		crack := domain.JobCrack{JobID: jobID}
+		var idStr string
+		var agentID sql.NullString
+		if err := rows.Scan(&idStr, &agentID, &crack.Hash, &crack.Plaintext, &crack.CrackedAt); err != nil {
+			return nil, err
+		}
+		if crack.Plaintext, err = r.db.OpenField(crack.Plaintext); err != nil {
+			return nil, err
+		}
+		crack.ID = uuid.MustParse(idStr)
+		crack.AgentID = parseNullableUUID(agentID)
+		cracks = append(cracks, crack)
+	}
+
+	return cracks, rows.Err()
+}
+
+// GetAgentSpeedsByHashType returns the best speed each agent reached on
+// jobs of one hash mode. Hashcat speeds differ by orders of magnitude
+// between modes, so this history serves as a per-mode benchmark. Jobs
+// last updated before the agent's benchmarks were invalidated don't count.
+func (r *jobRepository) GetAgentSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT j.agent_id, MAX(j.speed)
+		FROM jobs j
+		LEFT JOIN agent_benchmark_resets r ON r.agent_id = j.agent_id
+		WHERE j.hash_type = ? AND j.agent_id IS NOT NULL AND j.agent_id != '' AND j.speed > 0 AND j.deleted_at IS NULL
+		  AND (r.reset_at IS NULL OR j.updated_at > r.reset_at)
+		GROUP BY j.agent_id
+	`, hashType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	speeds := make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var agentIDStr string
+		var speed int64
+		if err := rows.Scan(&agentIDStr, &speed); err != nil {
+			return nil, err
+		}
+		if agentID, err := uuid.Parse(agentIDStr); err == nil {
+			speeds[agentID] = speed
+		}
+	}
+
+	return speeds, rows.Err()
+}
+
 func (r *jobRepository) invalidateListCaches(ctx context.Context) {
-	// Delete all list-related caches
+	// Delete all list-related caches. Stale status lists would have the
+	// dispatcher hand out jobs that are already running.
 	r.cache.Delete(ctx, "jobs:all")
-	// We can't easily invalidate all status-specific caches, so we clear the entire cache periodically
-	// This is acceptable given the 15-second TTL
+	r.cache.DeletePrefix(ctx, "jobs:status:")
+	r.cache.DeletePrefix(ctx, "jobs:agent:")
 }
 
 func (r *jobRepository) queryJobs(ctx context.Context, stmt *sql.Stmt) ([]domain.Job, error) {
@@ -407,8 +1232,13 @@ func (r *jobRepository) queryJobsWithArgs(ctx context.Context, stmt *sql.Stmt, a
 	return r.scanJobs(rows)
 }
 
-// scanJob optimized for single row scanning
-func (r *jobRepository) scanJob(row *sql.Row) (domain.Job, error) {
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a single row selected with jobColumns
+func (r *jobRepository) scanJob(row rowScanner) (domain.Job, error) {
 	var job domain.Job
 	var idStr string
 	var agentIDStr sql.NullString
@@ -420,6 +1250,20 @@ func (r *jobRepository) scanJob(row *sql.Row) (domain.Job, error) {
 
 	var skip sql.NullInt64
 	var wordLimit sql.NullInt64
+	var fileSource sql.NullString
+	var groupIDStr sql.NullString
+	var archivedAt sql.NullTime
+	var deletedAt sql.NullTime
+	var retriedFromStr sql.NullString
+	var projectIDStr sql.NullString
+	var wordlist2IDStr sql.NullString
+	var extraArgs string
+	var createdBy sql.NullString
+	var leaseExpiresAt sql.NullTime
+	var tags string
+	var stats string
+	var tenantID sql.NullString
+	var deadline sql.NullTime
 
 	err := row.Scan(
 		&idStr,
@@ -443,6 +1287,32 @@ func (r *jobRepository) scanJob(row *sql.Row) (domain.Job, error) {
 		&completedAt,
 		&skip,
 		&wordLimit,
+		&fileSource,
+		&groupIDStr,
+		&archivedAt,
+		&deletedAt,
+		&retriedFromStr,
+		&projectIDStr,
+		&job.CustomCharset1,
+		&job.CustomCharset2,
+		&job.CustomCharset3,
+		&job.CustomCharset4,
+		&wordlist2IDStr,
+		&extraArgs,
+		&job.Engine,
+		&job.JohnFormat,
+		&job.DeviceSeconds,
+		&job.EnergyWh,
+		&createdBy,
+		&leaseExpiresAt,
+		&tags,
+		&job.MaxRuntimeMinutes,
+		&job.StallTimeoutMinutes,
+		&job.NormalizedProgress,
+		&stats,
+		&tenantID,
+		&deadline,
+		&job.BudgetStage,
 	)
 
 	if err != nil {
@@ -454,6 +1324,12 @@ func (r *jobRepository) scanJob(row *sql.Row) (domain.Job, error) {
 
 	job.ID = uuid.MustParse(idStr)
 
+	// Without the right key an encrypted result stays as stored rather than
+	// failing every read of the job
+	if result, err := r.db.OpenField(job.Result); err == nil {
+		job.Result = result
+	}
+
 	if agentIDStr.Valid {
 		agentID := uuid.MustParse(agentIDStr.String)
 		job.AgentID = &agentID
@@ -489,90 +1365,137 @@ func (r *jobRepository) scanJob(row *sql.Row) (domain.Job, error) {
 		job.WordLimit = &wordLimit.Int64
 	}
 
+	job.FileSource = fileSource.String
+
+	if groupIDStr.Valid {
+		groupID := uuid.MustParse(groupIDStr.String)
+		job.GroupID = &groupID
+	}
+
+	if archivedAt.Valid {
+		job.ArchivedAt = &archivedAt.Time
+	}
+
+	if deletedAt.Valid {
+		job.DeletedAt = &deletedAt.Time
+	}
+
+	if retriedFromStr.Valid {
+		retriedFrom := uuid.MustParse(retriedFromStr.String)
+		job.RetriedFrom = &retriedFrom
+	}
+
+	job.ProjectID = parseNullableUUID(projectIDStr)
+	job.Wordlist2ID = parseNullableUUID(wordlist2IDStr)
+	job.CreatedBy = parseNullableUUID(createdBy)
+	if leaseExpiresAt.Valid {
+		job.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+	job.ExtraArgs = decodeArgs(extraArgs)
+	job.Tags = decodeArgs(tags)
+	job.Stats = decodeStats(stats)
+	job.TenantID = parseNullableUUID(tenantID)
+	if deadline.Valid {
+		job.Deadline = &deadline.Time
+	}
+
 	return job, nil
 }
 
-func (r *jobRepository) scanJobs(rows *sql.Rows) ([]domain.Job, error) {
-	jobs := make([]domain.Job, 0, 20) // Pre-allocate slice
-
-	for rows.Next() {
-		var job domain.Job
-		var idStr string
-		var agentIDStr sql.NullString
-		var hashFileIDStr sql.NullString
-		var wordlistIDStr sql.NullString
-		var eta sql.NullTime
-		var startedAt sql.NullTime
-		var completedAt sql.NullTime
-		var skip sql.NullInt64
-		var wordLimit sql.NullInt64
-
-		err := rows.Scan(
-			&idStr,
-			&job.Name,
-			&job.Status,
-			&job.HashType,
-			&job.AttackMode,
-			&job.HashFile,
-			&hashFileIDStr,
-			&job.Wordlist,
-			&wordlistIDStr,
-			&job.Rules,
-			&agentIDStr,
-			&job.Progress,
-			&job.Speed,
-			&eta,
-			&job.Result,
-			&job.CreatedAt,
-			&job.UpdatedAt,
-			&startedAt,
-			&completedAt,
-			&skip,
-			&wordLimit,
-		)
-		if err != nil {
-			return nil, err
-		}
+// encodeArgs stores hashcat arguments as a JSON array, or an empty string for none
+func encodeArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(args)
+	return string(data)
+}
 
-		job.ID = uuid.MustParse(idStr)
+// decodeArgs is the reverse of encodeArgs
+func decodeArgs(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(s), &args); err != nil {
+		return nil
+	}
+	return args
+}
 
-		if agentIDStr.Valid {
-			agentID := uuid.MustParse(agentIDStr.String)
-			job.AgentID = &agentID
-		}
+// encodeStats stores a job's runtime stats as JSON, or an empty string for none
+func encodeStats(stats *domain.JobRuntimeStats) string {
+	if stats == nil {
+		return ""
+	}
+	data, _ := json.Marshal(stats)
+	return string(data)
+}
 
-		if hashFileIDStr.Valid {
-			hashFileID := uuid.MustParse(hashFileIDStr.String)
-			job.HashFileID = &hashFileID
-		}
+// decodeStats is the reverse of encodeStats
+func decodeStats(s string) *domain.JobRuntimeStats {
+	if s == "" {
+		return nil
+	}
+	var stats domain.JobRuntimeStats
+	if err := json.Unmarshal([]byte(s), &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
 
-		if wordlistIDStr.Valid {
-			wordlistID := uuid.MustParse(wordlistIDStr.String)
-			job.WordlistID = &wordlistID
-		}
+// encodeBudget stores a job group's budget as JSON, or an empty string for none
+func encodeBudget(budget *domain.PipelineBudget) string {
+	if budget == nil {
+		return ""
+	}
+	data, _ := json.Marshal(budget)
+	return string(data)
+}
 
-		if eta.Valid {
-			job.ETA = &eta.Time
-		}
+// decodeBudget is the reverse of encodeBudget
+func decodeBudget(s string) *domain.PipelineBudget {
+	if s == "" {
+		return nil
+	}
+	var budget domain.PipelineBudget
+	if err := json.Unmarshal([]byte(s), &budget); err != nil {
+		return nil
+	}
+	return &budget
+}
 
-		if startedAt.Valid {
-			job.StartedAt = &startedAt.Time
-		}
+// nullableUUID converts an optional ID to a nullable column value
+func nullableUUID(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
 
-		if completedAt.Valid {
-			job.CompletedAt = &completedAt.Time
-		}
+// parseNullableUUID is the reverse of nullableUUID for scanned columns
+func parseNullableUUID(s sql.NullString) *uuid.UUID {
+	if !s.Valid {
+		return nil
+	}
+	id, err := uuid.Parse(s.String)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
 
-		if skip.Valid {
-			job.Skip = &skip.Int64
-		}
+func (r *jobRepository) scanJobs(rows *sql.Rows) ([]domain.Job, error) {
+	jobs := make([]domain.Job, 0, 20) // Pre-allocate slice
 
-		if wordLimit.Valid {
-			job.WordLimit = &wordLimit.Int64
+	for rows.Next() {
+		job, err := r.scanJob(rows)
+		if err != nil {
+			return nil, err
 		}
-
 		jobs = append(jobs, job)
 	}
 
-	return jobs, nil
+	return jobs, rows.Err()
 }
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// maintenanceColumns is the column list every maintenance window SELECT
+// returns, in scanMaintenanceWindow order
+const maintenanceColumns = `id, name, description, agent_id, agent_group_id, starts_at, ends_at,
+	days, start_time, end_time, timezone, created_at, updated_at`
+
+type maintenanceRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewMaintenanceRepository(db *database.SQLiteDB) domain.MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+func (r *maintenanceRepository) Create(ctx context.Context, window *domain.MaintenanceWindow) error {
+	if window.ID == uuid.Nil {
+		window.ID = uuid.New()
+	}
+	window.CreatedAt = time.Now()
+	window.UpdatedAt = window.CreatedAt
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO maintenance_windows (`+maintenanceColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, window.ID.String(), window.Name, window.Description, nullableUUID(window.AgentID), nullableUUID(window.AgentGroupID),
+		window.StartsAt, window.EndsAt, strings.Join(window.Days, ","), window.StartTime, window.EndTime, window.Timezone,
+		window.CreatedAt, window.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	return nil
+}
+
+func (r *maintenanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	window, err := scanMaintenanceWindow(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+maintenanceColumns+` FROM maintenance_windows WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "maintenance window"}
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (r *maintenanceRepository) GetAll(ctx context.Context) ([]domain.MaintenanceWindow, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+maintenanceColumns+` FROM maintenance_windows ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []domain.MaintenanceWindow{}
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+func (r *maintenanceRepository) Update(ctx context.Context, window *domain.MaintenanceWindow) error {
+	window.UpdatedAt = time.Now()
+
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE maintenance_windows
+		SET name = ?, description = ?, agent_id = ?, agent_group_id = ?, starts_at = ?, ends_at = ?,
+			days = ?, start_time = ?, end_time = ?, timezone = ?, updated_at = ?
+		WHERE id = ?
+	`, window.Name, window.Description, nullableUUID(window.AgentID), nullableUUID(window.AgentGroupID),
+		window.StartsAt, window.EndsAt, strings.Join(window.Days, ","), window.StartTime, window.EndTime, window.Timezone,
+		window.UpdatedAt, window.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "maintenance window"}
+	}
+	return nil
+}
+
+func (r *maintenanceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM maintenance_windows WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "maintenance window"}
+	}
+	return nil
+}
+
+// scanMaintenanceWindow scans a single row selected with maintenanceColumns
+func scanMaintenanceWindow(row rowScanner) (domain.MaintenanceWindow, error) {
+	var window domain.MaintenanceWindow
+	var id, days string
+	var description, agentID, agentGroupID sql.NullString
+	var startsAt, endsAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&window.Name,
+		&description,
+		&agentID,
+		&agentGroupID,
+		&startsAt,
+		&endsAt,
+		&days,
+		&window.StartTime,
+		&window.EndTime,
+		&window.Timezone,
+		&window.CreatedAt,
+		&window.UpdatedAt,
+	)
+	if err != nil {
+		return window, err
+	}
+
+	window.ID = uuid.MustParse(id)
+	window.Description = description.String
+	window.AgentID = parseNullableUUID(agentID)
+	window.AgentGroupID = parseNullableUUID(agentGroupID)
+	if startsAt.Valid {
+		window.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		window.EndsAt = &endsAt.Time
+	}
+	if days != "" {
+		window.Days = strings.Split(days, ",")
+	}
+	return window, nil
+}
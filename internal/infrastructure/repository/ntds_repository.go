@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// ntdsImportColumns is the column list every NTDS import SELECT returns, in
+// scanNTDSImport order
+const ntdsImportColumns = `id, name, domain, hash_file_id, accounts, unique_hashes, machine_accounts, history_entries, skipped_lines, project_id, created_by, created_at`
+
+// ntdsAccountColumns is the column list every NTDS account SELECT returns,
+// in scanNTDSAccount order
+const ntdsAccountColumns = `id, import_id, domain, username, rid, nt_hash, enabled, privileged, cracked, cracked_at`
+
+// ntdsHashBatch is how many hashes or usernames one statement matches, well
+// under SQLite's limit on bound parameters
+const ntdsHashBatch = 500
+
+type ntdsRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewNTDSRepository(db *database.SQLiteDB) domain.NTDSRepository {
+	return &ntdsRepository{db: db}
+}
+
+func (r *ntdsRepository) Create(ctx context.Context, ntdsImport *domain.NTDSImport, accounts []domain.NTDSAccount) error {
+	if ntdsImport.ID == uuid.Nil {
+		ntdsImport.ID = uuid.New()
+	}
+	ntdsImport.CreatedAt = time.Now()
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ntds_imports (`+ntdsImportColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ntdsImport.ID.String(), ntdsImport.Name, ntdsImport.Domain, nullableUUID(ntdsImport.HashFileID), ntdsImport.Accounts,
+		ntdsImport.UniqueHashes, ntdsImport.MachineAccounts, ntdsImport.HistoryEntries, ntdsImport.SkippedLines,
+		nullableUUID(ntdsImport.ProjectID), nullableUUID(ntdsImport.CreatedBy), ntdsImport.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create NTDS import: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ntds_accounts (`+ntdsAccountColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare NTDS account insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range accounts {
+		account := &accounts[i]
+		if account.ID == uuid.Nil {
+			account.ID = uuid.New()
+		}
+		account.ImportID = ntdsImport.ID
+		var enabled interface{}
+		if account.Enabled != nil {
+			enabled = *account.Enabled
+		}
+		if _, err := stmt.ExecContext(ctx, account.ID.String(), account.ImportID.String(), account.Domain, account.Username,
+			account.RID, account.NTHash, enabled, account.Privileged, account.Cracked, account.CrackedAt); err != nil {
+			return fmt.Errorf("failed to create NTDS account %s: %w", account.Username, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit NTDS import: %w", err)
+	}
+	return nil
+}
+
+func (r *ntdsRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.NTDSImport, error) {
+	ntdsImport, err := scanNTDSImport(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+ntdsImportColumns+` FROM ntds_imports WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "NTDS import"}
+		}
+		return nil, err
+	}
+	return &ntdsImport, nil
+}
+
+func (r *ntdsRepository) GetAll(ctx context.Context, projectID *uuid.UUID) ([]domain.NTDSImport, error) {
+	query := `SELECT ` + ntdsImportColumns + ` FROM ntds_imports`
+	var args []interface{}
+	if projectID != nil {
+		query += ` WHERE project_id = ?`
+		args = append(args, projectID.String())
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []domain.NTDSImport{}
+	for rows.Next() {
+		ntdsImport, err := scanNTDSImport(rows)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, ntdsImport)
+	}
+	return imports, rows.Err()
+}
+
+// Delete removes the import and its accounts; foreign keys aren't enforced,
+// so the accounts are deleted here rather than by cascade
+func (r *ntdsRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM ntds_imports WHERE id = ?`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete NTDS import: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "NTDS import"}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ntds_accounts WHERE import_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete NTDS accounts: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *ntdsRepository) GetAccounts(ctx context.Context, importID uuid.UUID, filter domain.NTDSAccountFilter) ([]domain.NTDSAccount, int, error) {
+	where := ` WHERE import_id = ?`
+	args := []interface{}{importID.String()}
+	if filter.Cracked != nil {
+		where += ` AND cracked = ?`
+		args = append(args, *filter.Cracked)
+	}
+	if filter.Privileged != nil {
+		where += ` AND privileged = ?`
+		args = append(args, *filter.Privileged)
+	}
+
+	var total int
+	if err := r.db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM ntds_accounts`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count NTDS accounts: %w", err)
+	}
+
+	query := `SELECT ` + ntdsAccountColumns + ` FROM ntds_accounts` + where + ` ORDER BY username`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	accounts := []domain.NTDSAccount{}
+	for rows.Next() {
+		account, err := scanNTDSAccount(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, total, rows.Err()
+}
+
+func (r *ntdsRepository) MarkCracked(ctx context.Context, importID uuid.UUID, ntHashes []string, at time.Time) (int, error) {
+	var marked int64
+	for start := 0; start < len(ntHashes); start += ntdsHashBatch {
+		batch := ntHashes[start:min(start+ntdsHashBatch, len(ntHashes))]
+		args := []interface{}{at, importID.String()}
+		for _, hash := range batch {
+			args = append(args, strings.ToLower(hash))
+		}
+		result, err := r.db.DB().ExecContext(ctx, `
+			UPDATE ntds_accounts SET cracked = 1, cracked_at = ?
+			WHERE import_id = ? AND cracked = 0 AND nt_hash IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+		`, args...)
+		if err != nil {
+			return int(marked), fmt.Errorf("failed to mark NTDS accounts cracked: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return int(marked), err
+		}
+		marked += rows
+	}
+	return int(marked), nil
+}
+
+func (r *ntdsRepository) SetPrivileged(ctx context.Context, importID uuid.UUID, usernames []string) (int, error) {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE ntds_accounts SET privileged = (rid = ?) WHERE import_id = ?`,
+		domain.BuiltinAdministratorRID, importID.String()); err != nil {
+		return 0, fmt.Errorf("failed to reset privileged NTDS accounts: %w", err)
+	}
+	var privileged int64
+	for start := 0; start < len(usernames); start += ntdsHashBatch {
+		batch := usernames[start:min(start+ntdsHashBatch, len(usernames))]
+		args := []interface{}{importID.String()}
+		for _, username := range batch {
+			args = append(args, strings.ToLower(username))
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE ntds_accounts SET privileged = 1
+			WHERE import_id = ? AND lower(username) IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+		`, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to set privileged NTDS accounts: %w", err)
+		}
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM ntds_accounts WHERE import_id = ? AND privileged = 1`,
+		importID.String()).Scan(&privileged); err != nil {
+		return 0, fmt.Errorf("failed to count privileged NTDS accounts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit privileged NTDS accounts: %w", err)
+	}
+	return int(privileged), nil
+}
+
+// scanNTDSImport scans a single row selected with ntdsImportColumns
+func scanNTDSImport(row rowScanner) (domain.NTDSImport, error) {
+	var ntdsImport domain.NTDSImport
+	var id string
+	var hashFileID, projectID, createdBy sql.NullString
+
+	err := row.Scan(
+		&id,
+		&ntdsImport.Name,
+		&ntdsImport.Domain,
+		&hashFileID,
+		&ntdsImport.Accounts,
+		&ntdsImport.UniqueHashes,
+		&ntdsImport.MachineAccounts,
+		&ntdsImport.HistoryEntries,
+		&ntdsImport.SkippedLines,
+		&projectID,
+		&createdBy,
+		&ntdsImport.CreatedAt,
+	)
+	if err != nil {
+		return ntdsImport, err
+	}
+
+	ntdsImport.ID = uuid.MustParse(id)
+	ntdsImport.HashFileID = parseNullableUUID(hashFileID)
+	ntdsImport.ProjectID = parseNullableUUID(projectID)
+	ntdsImport.CreatedBy = parseNullableUUID(createdBy)
+	return ntdsImport, nil
+}
+
+// scanNTDSAccount scans a single row selected with ntdsAccountColumns
+func scanNTDSAccount(row rowScanner) (domain.NTDSAccount, error) {
+	var account domain.NTDSAccount
+	var id, importID string
+	var enabled sql.NullBool
+	var crackedAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&importID,
+		&account.Domain,
+		&account.Username,
+		&account.RID,
+		&account.NTHash,
+		&enabled,
+		&account.Privileged,
+		&account.Cracked,
+		&crackedAt,
+	)
+	if err != nil {
+		return account, err
+	}
+
+	account.ID = uuid.MustParse(id)
+	account.ImportID = uuid.MustParse(importID)
+	if enabled.Valid {
+		account.Enabled = &enabled.Bool
+	}
+	if crackedAt.Valid {
+		account.CrackedAt = &crackedAt.Time
+	}
+	return account, nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// projectJobs selects the IDs of a project's jobs that go into an archive;
+// soft-deleted jobs are on their way out and are left behind
+const projectJobs = `SELECT id FROM jobs WHERE project_id = ? AND deleted_at IS NULL`
+
+type projectArchiveRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewProjectArchiveRepository(db *database.SQLiteDB) domain.ProjectArchiveRepository {
+	return &projectArchiveRepository{db: db}
+}
+
+func (r *projectArchiveRepository) Export(ctx context.Context, projectID uuid.UUID) (*domain.ProjectArchive, error) {
+	project, err := (&projectRepository{db: r.db}).GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	id := projectID.String()
+	archive := &domain.ProjectArchive{
+		Version:     domain.ProjectArchiveVersion,
+		ExportedAt:  time.Now().UTC(),
+		Project:     *project,
+		HashFiles:   []domain.ArchivedFile{},
+		Wordlists:   []domain.ArchivedFile{},
+		JobGroups:   []domain.JobGroup{},
+		JobEvents:   []domain.JobEvent{},
+		JobComments: []domain.JobComment{},
+	}
+
+	jobs := &jobRepository{db: r.db}
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id IN (`+projectJobs+`) ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	archive.Jobs, err = jobs.scanJobs(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+
+	rows, err = r.db.DB().QueryContext(ctx, `
+		SELECT id, name, created_at, budget FROM job_groups
+		WHERE id IN (SELECT group_id FROM jobs WHERE id IN (`+projectJobs+`))
+		ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job groups: %w", err)
+	}
+	for rows.Next() {
+		group, err := scanJobGroup(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		archive.JobGroups = append(archive.JobGroups, *group)
+	}
+	rows.Close()
+
+	rows, err = r.db.DB().QueryContext(ctx, `
+		SELECT id, job_id, COALESCE(from_status, ''), to_status, actor, COALESCE(reason, ''), created_at
+		FROM job_events
+		WHERE job_id IN (`+projectJobs+`)
+		ORDER BY created_at, rowid
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job events: %w", err)
+	}
+	for rows.Next() {
+		var event domain.JobEvent
+		var eventID, jobID string
+		if err := rows.Scan(&eventID, &jobID, &event.FromStatus, &event.ToStatus, &event.Actor, &event.Reason, &event.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		event.ID = uuid.MustParse(eventID)
+		event.JobID = uuid.MustParse(jobID)
+		archive.JobEvents = append(archive.JobEvents, event)
+	}
+	rows.Close()
+
+	rows, err = r.db.DB().QueryContext(ctx, `
+		SELECT id, job_id, author_id, author, body, created_at
+		FROM job_comments
+		WHERE job_id IN (`+projectJobs+`)
+		ORDER BY created_at, rowid
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job comments: %w", err)
+	}
+	for rows.Next() {
+		var comment domain.JobComment
+		var commentID, jobID string
+		var authorID sql.NullString
+		if err := rows.Scan(&commentID, &jobID, &authorID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		comment.ID = uuid.MustParse(commentID)
+		comment.JobID = uuid.MustParse(jobID)
+		comment.AuthorID = parseNullableUUID(authorID)
+		archive.JobComments = append(archive.JobComments, comment)
+	}
+	rows.Close()
+
+	credentials := &credentialRepository{db: r.db}
+	rows, err = r.db.DB().QueryContext(ctx, `SELECT `+credentialColumns+` FROM credentials WHERE project_id = ? ORDER BY cracked_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	archive.Credentials = []domain.Credential{}
+	for rows.Next() {
+		credential, err := credentials.scanCredential(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		archive.Credentials = append(archive.Credentials, credential)
+	}
+	rows.Close()
+
+	// The project's files, and the shared ones its jobs and credentials use
+	rows, err = r.db.DB().QueryContext(ctx, `
+		SELECT `+hashFileColumns+` FROM hash_files
+		WHERE project_id = ? OR (project_id IS NULL AND (
+			id IN (SELECT hash_file_id FROM jobs WHERE id IN (`+projectJobs+`)) OR
+			id IN (SELECT hash_file_id FROM credentials WHERE project_id = ?)))
+		ORDER BY created_at, id
+	`, id, id, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash files: %w", err)
+	}
+	hashFiles, err := scanHashFiles(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash files: %w", err)
+	}
+	for _, file := range hashFiles {
+		archive.HashFiles = append(archive.HashFiles, domain.ArchivedFile{
+			ID: file.ID, Name: file.OrigName, Size: file.Size, SHA256: file.SHA256,
+			Shared: file.ProjectID == nil, Type: file.Type, CreatedAt: file.CreatedAt,
+		})
+	}
+
+	rows, err = r.db.DB().QueryContext(ctx, `
+		SELECT `+wordlistColumns+` FROM wordlists
+		WHERE project_id = ? OR (project_id IS NULL AND (
+			id IN (SELECT wordlist_id FROM jobs WHERE id IN (`+projectJobs+`)) OR
+			id IN (SELECT wordlist2_id FROM jobs WHERE id IN (`+projectJobs+`))))
+		ORDER BY created_at, id
+	`, id, id, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlists: %w", err)
+	}
+	wordlists, err := scanWordlists(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlists: %w", err)
+	}
+	for _, file := range wordlists {
+		archive.Wordlists = append(archive.Wordlists, domain.ArchivedFile{
+			ID: file.ID, Name: file.OrigName, Size: file.Size, SHA256: file.SHA256,
+			Shared: file.ProjectID == nil, CreatedAt: file.CreatedAt,
+		})
+	}
+
+	return archive, nil
+}
+
+func (r *projectArchiveRepository) Import(ctx context.Context, archive *domain.ProjectArchive) (int, error) {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	project := archive.Project
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO projects (id, name, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		project.ID.String(), project.Name, project.Description, project.CreatedAt, project.UpdatedAt,
+	); err != nil {
+		return 0, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	for _, group := range archive.JobGroups {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO job_groups (id, name, created_at, budget) VALUES (?, ?, ?, ?)`,
+			group.ID.String(), group.Name, group.CreatedAt, encodeBudget(group.Budget)); err != nil {
+			return 0, fmt.Errorf("failed to create job group %s: %w", group.Name, err)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", strings.Count(jobColumns, ",")+1), ", ")
+	jobStmt, err := tx.PrepareContext(ctx, `INSERT INTO jobs (`+jobColumns+`) VALUES (`+placeholders+`)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare job insert: %w", err)
+	}
+	defer jobStmt.Close()
+	for i := range archive.Jobs {
+		job := &archive.Jobs[i]
+		result, err := r.db.SealField(job.Result)
+		if err != nil {
+			return 0, err
+		}
+		// In jobColumns order
+		if _, err := jobStmt.ExecContext(ctx,
+			job.ID.String(), job.Name, job.Status, job.HashType, job.AttackMode, job.HashFile, nullableUUID(job.HashFileID),
+			job.Wordlist, nullableUUID(job.WordlistID), job.Rules, nullableUUID(job.AgentID), job.Progress, job.Speed, job.ETA,
+			result, job.CreatedAt, job.UpdatedAt, job.StartedAt, job.CompletedAt, job.Skip, job.WordLimit, job.FileSource,
+			nullableUUID(job.GroupID), job.ArchivedAt, job.DeletedAt, nullableUUID(job.RetriedFrom), nullableUUID(job.ProjectID),
+			job.CustomCharset1, job.CustomCharset2, job.CustomCharset3, job.CustomCharset4, nullableUUID(job.Wordlist2ID),
+			encodeArgs(job.ExtraArgs), job.EngineName(), job.JohnFormat, job.DeviceSeconds, job.EnergyWh,
+			nullableUUID(job.CreatedBy), job.LeaseExpiresAt, encodeArgs(job.Tags), job.MaxRuntimeMinutes, job.StallTimeoutMinutes,
+			job.NormalizedProgress, encodeStats(job.Stats), nullableUUID(tenantOf(ctx, job.TenantID)), job.Deadline, job.BudgetStage,
+		); err != nil {
+			return 0, fmt.Errorf("failed to create job %s: %w", job.Name, err)
+		}
+	}
+
+	for _, event := range archive.JobEvents {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO job_events (id, job_id, from_status, to_status, actor, reason, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, event.ID.String(), event.JobID.String(), event.FromStatus, event.ToStatus, event.Actor, event.Reason, event.CreatedAt); err != nil {
+			return 0, fmt.Errorf("failed to create job event: %w", err)
+		}
+	}
+	for _, comment := range archive.JobComments {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO job_comments (id, job_id, author_id, author, body, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, comment.ID.String(), comment.JobID.String(), nullableUUID(comment.AuthorID), comment.Author, comment.Body, comment.CreatedAt); err != nil {
+			return 0, fmt.Errorf("failed to create job comment: %w", err)
+		}
+	}
+
+	// Credentials this server already has for a shared hash file are skipped
+	credentialStmt, err := tx.PrepareContext(ctx, `
+		INSERT OR IGNORE INTO credentials (`+credentialColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare credential insert: %w", err)
+	}
+	defer credentialStmt.Close()
+	var recorded int64
+	for _, credential := range archive.Credentials {
+		plaintext, err := r.db.SealField(credential.Plaintext)
+		if err != nil {
+			return 0, err
+		}
+		result, err := credentialStmt.ExecContext(ctx, credential.ID.String(), credential.Username, credential.Domain, credential.Hash,
+			plaintext, credential.HashType, nullableUUID(credential.HashFileID), credential.SourceFile, credential.Source,
+			nullableUUID(credential.JobID), nullableUUID(credential.ProjectID), credential.CrackedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create credential: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		recorded += rows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return int(recorded), nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// projectColumns is the column list every project SELECT returns, in scanProject order
+const projectColumns = `p.id, p.name, p.description, p.created_at, p.updated_at`
+
+type projectRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewProjectRepository(db *database.SQLiteDB) domain.ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *domain.Project) error {
+	if project.ID == uuid.Nil {
+		project.ID = uuid.New()
+	}
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT INTO projects (id, name, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		project.ID.String(), project.Name, project.Description, project.CreatedAt, project.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+	return nil
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	return r.getOne(ctx, `SELECT `+projectColumns+` FROM projects p WHERE p.id = ?`, id.String())
+}
+
+func (r *projectRepository) GetByName(ctx context.Context, name string) (*domain.Project, error) {
+	return r.getOne(ctx, `SELECT `+projectColumns+` FROM projects p WHERE p.name = ? COLLATE NOCASE`, name)
+}
+
+func (r *projectRepository) getOne(ctx context.Context, query string, arg string) (*domain.Project, error) {
+	project, err := scanProject(r.db.DB().QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "project"}
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) GetAll(ctx context.Context) ([]domain.Project, error) {
+	return r.getMany(ctx, `SELECT `+projectColumns+` FROM projects p ORDER BY p.name`)
+}
+
+func (r *projectRepository) GetByMember(ctx context.Context, userID uuid.UUID) ([]domain.Project, error) {
+	return r.getMany(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects p
+		JOIN project_members m ON m.project_id = p.id
+		WHERE m.user_id = ?
+		ORDER BY p.name
+	`, userID.String())
+}
+
+func (r *projectRepository) getMany(ctx context.Context, query string, args ...interface{}) ([]domain.Project, error) {
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := make([]domain.Project, 0)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *domain.Project) error {
+	project.UpdatedAt = time.Now()
+
+	result, err := r.db.DB().ExecContext(ctx,
+		`UPDATE projects SET name = ?, description = ?, updated_at = ? WHERE id = ?`,
+		project.Name, project.Description, project.UpdatedAt, project.ID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return &domain.NotFoundError{Entity: "project"}
+	}
+	return nil
+}
+
+// Delete removes a project and its memberships. Callers check CountResources
+// first, as the jobs and files of a project aren't touched.
+func (r *projectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM project_members WHERE project_id = ?`, id.String()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM digest_subscriptions WHERE project_id = ?`, id.String()); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return &domain.NotFoundError{Entity: "project"}
+	}
+
+	return tx.Commit()
+}
+
+// CountResources leaves out soft-deleted jobs, which are purged on their own
+func (r *projectRepository) CountResources(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM jobs WHERE project_id = ? AND deleted_at IS NULL)
+		     + (SELECT COUNT(*) FROM hash_files WHERE project_id = ?)
+		     + (SELECT COUNT(*) FROM wordlists WHERE project_id = ?)
+	`, id.String(), id.String(), id.String()).Scan(&count)
+	return count, err
+}
+
+// AddMember gives a user access to a project. Adding an existing member is a no-op.
+func (r *projectRepository) AddMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT OR IGNORE INTO project_members (project_id, user_id, created_at) VALUES (?, ?, ?)`,
+		projectID.String(), userID.String(), time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add project member: %w", err)
+	}
+	return nil
+}
+
+func (r *projectRepository) RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM project_members WHERE project_id = ? AND user_id = ?`,
+		projectID.String(), userID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove project member: %w", err)
+	}
+	if removed, err := result.RowsAffected(); err == nil && removed == 0 {
+		return &domain.NotFoundError{Entity: "project member"}
+	}
+	return nil
+}
+
+func (r *projectRepository) GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT m.project_id, m.user_id, COALESCE(u.username, ''), m.created_at
+		FROM project_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = ?
+		ORDER BY u.username
+	`, projectID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]domain.ProjectMember, 0)
+	for rows.Next() {
+		var member domain.ProjectMember
+		var projectIDStr, userIDStr string
+		if err := rows.Scan(&projectIDStr, &userIDStr, &member.Username, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		member.ProjectID = uuid.MustParse(projectIDStr)
+		member.UserID = uuid.MustParse(userIDStr)
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *projectRepository) IsMember(ctx context.Context, projectID, userID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM project_members WHERE project_id = ? AND user_id = ?)`,
+		projectID.String(), userID.String(),
+	).Scan(&exists)
+	return exists, err
+}
+
+// scanProject scans a single row selected with projectColumns
+func scanProject(row rowScanner) (domain.Project, error) {
+	var project domain.Project
+	var idStr string
+	var description sql.NullString
+
+	if err := row.Scan(&idStr, &project.Name, &description, &project.CreatedAt, &project.UpdatedAt); err != nil {
+		return project, err
+	}
+
+	project.ID = uuid.MustParse(idStr)
+	project.Description = description.String
+	return project, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// quotaColumns is the column list every quota SELECT returns, in scanQuota order
+const quotaColumns = `scope, subject_id, max_running_jobs, max_device_hours_per_month, max_storage_bytes, updated_at`
+
+// quotaOwnerColumns maps each quota scope to the column of jobs, hash
+// files and wordlists that holds the owner
+var quotaOwnerColumns = map[string]string{
+	domain.QuotaScopeUser:    "created_by",
+	domain.QuotaScopeProject: "project_id",
+	domain.QuotaScopeTenant:  "tenant_id",
+}
+
+type quotaRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewQuotaRepository(db *database.SQLiteDB) domain.QuotaRepository {
+	return &quotaRepository{db: db}
+}
+
+func (r *quotaRepository) Get(ctx context.Context, scope string, subjectID uuid.UUID) (*domain.Quota, error) {
+	quota, err := scanQuota(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+quotaColumns+` FROM quotas WHERE scope = ? AND subject_id = ?`, scope, subjectID.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *quotaRepository) GetAll(ctx context.Context) ([]domain.Quota, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+quotaColumns+` FROM quotas ORDER BY scope, subject_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotas := []domain.Quota{}
+	for rows.Next() {
+		quota, err := scanQuota(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, rows.Err()
+}
+
+func (r *quotaRepository) Upsert(ctx context.Context, quota *domain.Quota) error {
+	quota.UpdatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO quotas (`+quotaColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, subject_id) DO UPDATE SET
+			max_running_jobs = excluded.max_running_jobs,
+			max_device_hours_per_month = excluded.max_device_hours_per_month,
+			max_storage_bytes = excluded.max_storage_bytes,
+			updated_at = excluded.updated_at
+	`, quota.Scope, quota.SubjectID.String(), quota.MaxRunningJobs, quota.MaxDeviceHoursPerMonth,
+		quota.MaxStorageBytes, quota.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save quota: %w", err)
+	}
+	return nil
+}
+
+func (r *quotaRepository) Delete(ctx context.Context, scope string, subjectID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM quotas WHERE scope = ? AND subject_id = ?`, scope, subjectID.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "quota"}
+	}
+	return nil
+}
+
+// GetUsage counts jobs that haven't finished, each sub-job since it
+// occupies an agent, and the device time of every job started this month,
+// deleted ones included since their compute was used all the same
+func (r *quotaRepository) GetUsage(ctx context.Context, scope string, subjectID uuid.UUID, monthStart time.Time) (*domain.QuotaUsage, error) {
+	owner, ok := quotaOwnerColumns[scope]
+	if !ok {
+		return nil, fmt.Errorf("unknown quota scope %q", scope)
+	}
+	id := subjectID.String()
+
+	var usage domain.QuotaUsage
+	var deviceSeconds float64
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM jobs
+			 WHERE `+owner+` = ? AND deleted_at IS NULL AND status IN (?, ?, ?, ?, ?)),
+			(SELECT COALESCE(SUM(device_seconds), 0) FROM jobs
+			 WHERE `+owner+` = ? AND julianday(COALESCE(started_at, created_at)) >= julianday(?)),
+			(SELECT COALESCE(SUM(size), 0) FROM hash_files WHERE `+owner+` = ?) +
+			(SELECT COALESCE(SUM(size), 0) FROM wordlists WHERE `+owner+` = ?)
+	`, id, domain.JobStatusPending, domain.JobStatusAssigned, domain.JobStatusRunning, domain.JobStatusPaused, domain.JobStatusInterrupted,
+		id, monthStart, id, id).Scan(&usage.RunningJobs, &deviceSeconds, &usage.StorageBytes)
+	if err != nil {
+		return nil, err
+	}
+	usage.DeviceHoursThisMonth = deviceSeconds / 3600
+	return &usage, nil
+}
+
+// scanQuota scans a single row selected with quotaColumns
+func scanQuota(row rowScanner) (domain.Quota, error) {
+	var quota domain.Quota
+	var subjectID string
+
+	err := row.Scan(
+		&quota.Scope,
+		&subjectID,
+		&quota.MaxRunningJobs,
+		&quota.MaxDeviceHoursPerMonth,
+		&quota.MaxStorageBytes,
+		&quota.UpdatedAt,
+	)
+	if err != nil {
+		return quota, err
+	}
+	quota.SubjectID = uuid.MustParse(subjectID)
+	return quota, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+type resultAccessRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewResultAccessRepository(db *database.SQLiteDB) domain.ResultAccessRepository {
+	return &resultAccessRepository{db: db}
+}
+
+func (r *resultAccessRepository) GrantReveal(ctx context.Context, grant *domain.ResultRevealGrant) error {
+	grant.CreatedAt = time.Now()
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO result_reveal_grants (user_id, username, granted_by, created_at)
+		VALUES (?, ?, ?, ?)
+	`, grant.UserID.String(), grant.Username, grant.GrantedBy, grant.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to grant reveal permission: %w", err)
+	}
+	return nil
+}
+
+func (r *resultAccessRepository) RevokeReveal(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM result_reveal_grants WHERE user_id = ?`, userID.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "reveal grant"}
+	}
+	return nil
+}
+
+func (r *resultAccessRepository) HasRevealGrant(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int
+	err := r.db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM result_reveal_grants WHERE user_id = ?`, userID.String()).Scan(&count)
+	return count > 0, err
+}
+
+func (r *resultAccessRepository) GetRevealGrants(ctx context.Context) ([]domain.ResultRevealGrant, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT user_id, username, granted_by, created_at FROM result_reveal_grants ORDER BY username
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []domain.ResultRevealGrant{}
+	for rows.Next() {
+		var grant domain.ResultRevealGrant
+		var userID string
+		if err := rows.Scan(&userID, &grant.Username, &grant.GrantedBy, &grant.CreatedAt); err != nil {
+			return nil, err
+		}
+		grant.UserID = uuid.MustParse(userID)
+		grants = append(grants, grant)
+	}
+	return grants, rows.Err()
+}
+
+func (r *resultAccessRepository) LogReveal(ctx context.Context, reveal *domain.ResultReveal) error {
+	if reveal.ID == uuid.Nil {
+		reveal.ID = uuid.New()
+	}
+	if reveal.CreatedAt.IsZero() {
+		reveal.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO result_reveals (id, job_id, wordlist_id, user_id, username, ip_address, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, reveal.ID.String(), reveal.JobID.String(), nullableUUID(reveal.WordlistID), nullableUUID(reveal.UserID),
+		reveal.Username, reveal.IPAddress, reveal.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log result reveal: %w", err)
+	}
+	return nil
+}
+
+func (r *resultAccessRepository) GetReveals(ctx context.Context, jobID *uuid.UUID, limit int) ([]domain.ResultReveal, error) {
+	query := `SELECT id, job_id, wordlist_id, user_id, username, ip_address, created_at FROM result_reveals`
+	args := []interface{}{}
+	if jobID != nil {
+		query += ` WHERE job_id = ?`
+		args = append(args, jobID.String())
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.DB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reveals := []domain.ResultReveal{}
+	for rows.Next() {
+		var reveal domain.ResultReveal
+		var id, revealJobID string
+		var wordlistID, userID sql.NullString
+		if err := rows.Scan(&id, &revealJobID, &wordlistID, &userID, &reveal.Username, &reveal.IPAddress, &reveal.CreatedAt); err != nil {
+			return nil, err
+		}
+		reveal.ID = uuid.MustParse(id)
+		reveal.JobID = uuid.MustParse(revealJobID)
+		reveal.WordlistID = parseNullableUUID(wordlistID)
+		reveal.UserID = parseNullableUUID(userID)
+		reveals = append(reveals, reveal)
+	}
+	return reveals, rows.Err()
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+type searchRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewSearchRepository(db *database.SQLiteDB) domain.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// Search matches job names, results, tags and comments, agent names and
+// wordlist/hash file names. It uses the FTS5 search_index when SQLite has
+// it and falls back to LIKE otherwise. Deleted jobs are never returned.
+func (r *searchRepository) Search(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error) {
+	var results []domain.SearchResult
+	var err error
+	if r.db.HasFTS5() {
+		results, err = r.searchFTS(ctx, query, limit, matchResults)
+	} else {
+		results, err = r.searchLike(ctx, query, limit, matchResults)
+	}
+	if err != nil || len(results) >= limit {
+		return results, err
+	}
+
+	annotated, err := r.searchJobAnnotations(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uuid.UUID]bool, len(results))
+	for _, result := range results {
+		if result.Type == "job" {
+			found[result.ID] = true
+		}
+	}
+	for _, result := range annotated {
+		if len(results) >= limit {
+			break
+		}
+		if !found[result.ID] {
+			found[result.ID] = true
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// searchJobAnnotations matches job tags and comments, which the search
+// index doesn't cover
+func (r *searchRepository) searchJobAnnotations(ctx context.Context, query string, limit int) ([]domain.SearchResult, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	jobTenant, tenant := tenantFilter(ctx, "jobs.tenant_id", "?3")
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT 'job', id, name, tags FROM jobs
+		WHERE deleted_at IS NULL AND `+jobTenant+` AND tags LIKE ?1 ESCAPE '\'
+		UNION ALL
+		SELECT 'job', jobs.id, jobs.name, job_comments.body FROM job_comments
+		JOIN jobs ON jobs.id = job_comments.job_id
+		WHERE jobs.deleted_at IS NULL AND `+jobTenant+` AND job_comments.body LIKE ?1 ESCAPE '\'
+		LIMIT ?2
+	`, pattern, limit, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSearchResults(rows)
+}
+
+func (r *searchRepository) searchFTS(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error) {
+	filter, tenant := tenantFilter(ctx, "tenant_id", "?3")
+	// The body of jobs is their result, so without it jobs must match by name
+	jobMatch := ftsQuery(query)
+	if !matchResults {
+		jobMatch = "title : (" + jobMatch + ")"
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT kind, ref_id, title, body
+		FROM search_index
+		WHERE search_index MATCH ?1
+		  AND NOT (kind = 'job' AND ref_id IN (SELECT id FROM jobs WHERE deleted_at IS NOT NULL))
+		  AND (kind != 'job' OR ref_id IN (SELECT id FROM jobs WHERE `+filter+`))
+		  AND (kind != 'job' OR rowid IN (SELECT rowid FROM search_index WHERE search_index MATCH ?4))
+		  AND (kind != 'agent' OR ref_id IN (SELECT id FROM agents WHERE tenant_id IS NULL OR `+filter+`))
+		  AND (kind != 'wordlist' OR ref_id IN (SELECT id FROM wordlists WHERE `+filter+`))
+		  AND (kind != 'hash_file' OR ref_id IN (SELECT id FROM hash_files WHERE `+filter+`))
+		ORDER BY rank
+		LIMIT ?2
+	`, ftsQuery(query), limit, tenant, jobMatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSearchResults(rows)
+}
+
+func (r *searchRepository) searchLike(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	// Tenants also see the shared agents
+	filter, tenant := tenantFilter(ctx, "tenant_id", "?3")
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT 'job', id, name, COALESCE(result, '') FROM jobs
+		WHERE deleted_at IS NULL AND `+filter+` AND (name LIKE ?1 ESCAPE '\' OR (?4 AND result LIKE ?1 ESCAPE '\'))
+		UNION ALL
+		SELECT 'agent', id, name, COALESCE(capabilities, '') FROM agents
+		WHERE (tenant_id IS NULL OR `+filter+`) AND (name LIKE ?1 ESCAPE '\' OR capabilities LIKE ?1 ESCAPE '\')
+		UNION ALL
+		SELECT 'wordlist', id, orig_name, name FROM wordlists
+		WHERE `+filter+` AND (orig_name LIKE ?1 ESCAPE '\' OR name LIKE ?1 ESCAPE '\')
+		UNION ALL
+		SELECT 'hash_file', id, orig_name, name FROM hash_files
+		WHERE `+filter+` AND (orig_name LIKE ?1 ESCAPE '\' OR name LIKE ?1 ESCAPE '\')
+		LIMIT ?2
+	`, pattern, limit, tenant, matchResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSearchResults(rows)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ftsQuery turns user input into an FTS5 query: every word becomes a quoted
+// prefix term, so operators and column filters in the input have no effect
+func ftsQuery(query string) string {
+	words := strings.Fields(query)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func scanSearchResults(rows *sql.Rows) ([]domain.SearchResult, error) {
+	results := make([]domain.SearchResult, 0, 20)
+	for rows.Next() {
+		var result domain.SearchResult
+		var idStr string
+		if err := rows.Scan(&result.Type, &idStr, &result.Title, &result.Match); err != nil {
+			return nil, err
+		}
+
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			continue
+		}
+		result.ID = id
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
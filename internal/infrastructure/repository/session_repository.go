@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// sessionColumns is the column list every session SELECT returns, in
+// scanSession order
+const sessionColumns = `id, user_id, token_hash, two_factor, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at`
+
+type sessionRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewSessionRepository(db *database.SQLiteDB) domain.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM sessions WHERE user_id = ? AND (revoked_at IS NOT NULL OR expires_at < ?)`,
+		session.UserID.String(), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete ended sessions: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)
+	`, session.ID.String(), session.UserID.String(), session.TokenHash, session.TwoFactor, session.UserAgent, session.IPAddress,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	session, err := scanSession(r.db.DB().QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "session"}
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC
+	`, userID.String(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Rotate only matches the hash it was given, so of two refreshes racing
+// with the same token one fails
+func (r *sessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE sessions SET token_hash = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND token_hash = ? AND revoked_at IS NULL
+	`, newHash, time.Now(), expiresAt, id.String(), oldHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return &domain.NotFoundError{Entity: "session"}
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeByUser(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error) {
+	keepID := ""
+	if keep != nil {
+		keepID = keep.String()
+	}
+	result, err := r.db.DB().ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL`,
+		time.Now(), userID.String(), keepID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// scanSession scans a single row selected with sessionColumns
+func scanSession(row rowScanner) (domain.Session, error) {
+	var session domain.Session
+	var id, userID string
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&userID,
+		&session.TokenHash,
+		&session.TwoFactor,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return session, err
+	}
+
+	session.ID = uuid.MustParse(id)
+	session.UserID = uuid.MustParse(userID)
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
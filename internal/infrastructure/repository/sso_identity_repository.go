@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// ssoIdentityColumns is the column list every SSO identity SELECT returns,
+// in scanSSOIdentity order
+const ssoIdentityColumns = `user_id, subject, refresh_token, created_at, updated_at`
+
+type ssoIdentityRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewSSOIdentityRepository(db *database.SQLiteDB) domain.SSOIdentityRepository {
+	return &ssoIdentityRepository{db: db}
+}
+
+func (r *ssoIdentityRepository) GetBySubject(ctx context.Context, subject string) (*domain.SSOIdentity, error) {
+	return r.getOne(ctx, `SELECT `+ssoIdentityColumns+` FROM sso_identities WHERE subject = ?`, subject)
+}
+
+func (r *ssoIdentityRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.SSOIdentity, error) {
+	return r.getOne(ctx, `SELECT `+ssoIdentityColumns+` FROM sso_identities WHERE user_id = ?`, userID.String())
+}
+
+func (r *ssoIdentityRepository) getOne(ctx context.Context, query string, arg string) (*domain.SSOIdentity, error) {
+	identity, err := r.scanSSOIdentity(r.db.DB().QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "sso identity"}
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Save replaces the identity of the user, and any other user's identity
+// with the same subject, such as that of a deleted user
+func (r *ssoIdentityRepository) Save(ctx context.Context, identity *domain.SSOIdentity) error {
+	refreshToken, err := r.db.SealField(identity.RefreshToken)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = now
+	}
+	identity.UpdatedAt = now
+
+	_, err = r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO sso_identities (`+ssoIdentityColumns+`)
+		VALUES (?, ?, ?, ?, ?)
+	`, identity.UserID.String(), identity.Subject, refreshToken, identity.CreatedAt, identity.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save sso identity: %w", err)
+	}
+	return nil
+}
+
+// scanSSOIdentity scans a single row selected with ssoIdentityColumns
+func (r *ssoIdentityRepository) scanSSOIdentity(row rowScanner) (domain.SSOIdentity, error) {
+	var identity domain.SSOIdentity
+	var userID, refreshToken string
+	if err := row.Scan(&userID, &identity.Subject, &refreshToken, &identity.CreatedAt, &identity.UpdatedAt); err != nil {
+		return identity, err
+	}
+	parsed, err := uuid.Parse(userID)
+	if err != nil {
+		return identity, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	identity.UserID = parsed
+	if identity.RefreshToken, err = r.db.OpenField(refreshToken); err != nil {
+		return identity, err
+	}
+	return identity, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+type statsRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewStatsRepository(db *database.SQLiteDB) domain.StatsRepository {
+	return &statsRepository{db: db}
+}
+
+func (r *statsRepository) GetAgentStats(ctx context.Context) (*domain.AgentStats, error) {
+	// Tenants count their own agents and the shared ones
+	where := ""
+	conditions, args := tenantCondition(ctx, "tenant_id", nil, nil)
+	if len(conditions) > 0 {
+		where = "WHERE tenant_id IS NULL OR " + conditions[0]
+	}
+
+	var stats domain.AgentStats
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN status IN ('online', 'busy') THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status IN ('online', 'busy') THEN speed ELSE 0 END), 0)
+		FROM agents `+where, args...).Scan(&stats.Total, &stats.Active, &stats.TotalSpeed)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *statsRepository) CountJobsByStatus(ctx context.Context) (map[string]int, error) {
+	where, args := tenantCondition(ctx, "tenant_id", []string{"deleted_at IS NULL"}, nil)
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT status, COUNT(*) FROM jobs
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY status
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// CountCrackedSince compares through julianday rather than as strings, as
+// timestamps are stored with the server's UTC offset
+func (r *statsRepository) CountCrackedSince(ctx context.Context, since time.Time) (int, error) {
+	where, args := tenantCondition(ctx, "tenant_id",
+		[]string{"deleted_at IS NULL", "status = ?", "completed_at IS NOT NULL", "julianday(completed_at) >= julianday(?)"},
+		[]interface{}{domain.JobStatusCracked, since})
+
+	var count int
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM jobs
+		WHERE `+strings.Join(where, " AND "), args...).Scan(&count)
+	return count, err
+}
+
+func (r *statsRepository) GetTopWordlists(ctx context.Context, limit int) ([]domain.WordlistUsage, error) {
+	where, args := tenantCondition(ctx, "j.tenant_id",
+		[]string{"j.deleted_at IS NULL", "COALESCE(w.orig_name, j.wordlist, '') != ''"}, nil)
+
+	// Sub-jobs of a distributed job share a group_id and count as one job
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT j.wordlist_id, COALESCE(w.orig_name, j.wordlist) AS name,
+		       COUNT(DISTINCT COALESCE(j.group_id, j.id)) AS uses
+		FROM jobs j
+		LEFT JOIN wordlists w ON w.id = j.wordlist_id
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY COALESCE(j.wordlist_id, j.wordlist)
+		ORDER BY uses DESC, name
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []domain.WordlistUsage{}
+	for rows.Next() {
+		var wordlistID sql.NullString
+		var u domain.WordlistUsage
+		if err := rows.Scan(&wordlistID, &u.Name, &u.Jobs); err != nil {
+			return nil, err
+		}
+		if wordlistID.Valid {
+			if id, err := uuid.Parse(wordlistID.String); err == nil {
+				u.WordlistID = &id
+			}
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (r *statsRepository) GetAverageTimeToCrack(ctx context.Context) ([]domain.HashModeCrackTime, error) {
+	where, args := tenantCondition(ctx, "tenant_id",
+		[]string{"deleted_at IS NULL", "status = ?", "started_at IS NOT NULL", "completed_at IS NOT NULL"},
+		[]interface{}{domain.JobStatusCracked})
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT hash_type, COUNT(*),
+		       AVG((julianday(completed_at) - julianday(started_at)) * 86400)
+		FROM jobs
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY hash_type
+		ORDER BY hash_type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := []domain.HashModeCrackTime{}
+	for rows.Next() {
+		var t domain.HashModeCrackTime
+		if err := rows.Scan(&t.HashType, &t.Cracked, &t.AvgSeconds); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+func (r *statsRepository) GetKeyspacePerDay(ctx context.Context, since time.Time) ([]domain.DailyKeyspace, error) {
+	// A job's keyspace is its word limit, or for undivided jobs the word
+	// count of its wordlist; progress tells how much of it was covered
+	where, args := tenantCondition(ctx, "j.tenant_id",
+		[]string{"j.deleted_at IS NULL", "j.completed_at IS NOT NULL", "julianday(j.completed_at) >= julianday(?)"},
+		[]interface{}{since})
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT date(j.completed_at) AS day,
+		       CAST(COALESCE(SUM(COALESCE(NULLIF(j.word_limit, 0), w.word_count, 0) * j.progress / 100.0), 0) AS INTEGER),
+		       COUNT(*)
+		FROM jobs j
+		LEFT JOIN wordlists w ON w.id = j.wordlist_id
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY day
+		ORDER BY day
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []domain.DailyKeyspace{}
+	for rows.Next() {
+		var d domain.DailyKeyspace
+		if err := rows.Scan(&d.Date, &d.Processed, &d.Jobs); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// usageGroups maps each cost report grouping to the key and name of a
+// group and the table the name comes from
+var usageGroups = map[string]struct{ key, name, join string }{
+	domain.CostGroupJob:     {"j.id", "j.name", ""},
+	domain.CostGroupProject: {"j.project_id", "COALESCE(p.name, '')", "LEFT JOIN projects p ON p.id = j.project_id"},
+	domain.CostGroupAgent:   {"j.agent_id", "COALESCE(a.name, '')", "LEFT JOIN agents a ON a.id = j.agent_id"},
+}
+
+// GetUsage includes archived and deleted jobs, whose compute was used all
+// the same, and leaves out jobs that never reported any
+func (r *statsRepository) GetUsage(ctx context.Context, groupBy string, from, to *time.Time) ([]domain.UsageTotal, error) {
+	group, ok := usageGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown usage grouping %q", groupBy)
+	}
+
+	where := []string{"(j.device_seconds > 0 OR j.energy_wh > 0)"}
+	var args []interface{}
+	if from != nil {
+		where = append(where, "julianday(COALESCE(j.started_at, j.created_at)) >= julianday(?)")
+		args = append(args, *from)
+	}
+	if to != nil {
+		where = append(where, "julianday(COALESCE(j.started_at, j.created_at)) <= julianday(?)")
+		args = append(args, *to)
+	}
+	where, args = tenantCondition(ctx, "j.tenant_id", where, args)
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+group.key+`, MAX(`+group.name+`), COUNT(*), SUM(j.device_seconds), SUM(j.energy_wh)
+		FROM jobs j `+group.join+`
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY `+group.key+`
+		ORDER BY SUM(j.device_seconds) DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []domain.UsageTotal{}
+	for rows.Next() {
+		var id sql.NullString
+		var total domain.UsageTotal
+		if err := rows.Scan(&id, &total.Name, &total.Jobs, &total.DeviceSeconds, &total.EnergyWh); err != nil {
+			return nil, err
+		}
+		total.ID = parseNullableUUID(id)
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// GetAttackOutcomes counts an attack as cracked when its job was, or when
+// any of its sub-jobs reported cracks
+func (r *statsRepository) GetAttackOutcomes(ctx context.Context, hashType int) ([]domain.AttackOutcome, error) {
+	where, args := tenantCondition(ctx, "j.tenant_id",
+		[]string{"j.deleted_at IS NULL", "j.hash_type = ?", "j.attack_mode = ?", "j.status IN (?, ?, ?)"},
+		[]interface{}{hashType, domain.AttackModeStraight, domain.JobStatusCracked, domain.JobStatusCompleted, domain.JobStatusFailed})
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		WITH attacks AS (
+			SELECT MAX(j.wordlist_id) AS wordlist_id, MAX(w.orig_name) AS wordlist,
+			       MAX(COALESCE(j.rules, '')) AS rules, MAX(COALESCE(h.source, '')) AS source,
+			       SUM((SELECT COUNT(*) FROM job_cracks c WHERE c.job_id = j.id)) AS cracks,
+			       MAX(CASE WHEN j.status = ? THEN 1 ELSE 0 END) AS cracked
+			FROM jobs j
+			JOIN wordlists w ON w.id = j.wordlist_id
+			LEFT JOIN hash_files h ON h.id = j.hash_file_id
+			WHERE `+strings.Join(where, " AND ")+`
+			GROUP BY COALESCE(j.group_id, j.id)
+		)
+		SELECT wordlist_id, MAX(wordlist), rules, source, COUNT(*),
+		       SUM(CASE WHEN cracked = 1 OR cracks > 0 THEN 1 ELSE 0 END), SUM(cracks)
+		FROM attacks
+		GROUP BY wordlist_id, rules, source
+	`, append([]interface{}{domain.JobStatusCracked}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outcomes := []domain.AttackOutcome{}
+	for rows.Next() {
+		var wordlistID string
+		var o domain.AttackOutcome
+		if err := rows.Scan(&wordlistID, &o.Wordlist, &o.Rules, &o.Source, &o.Attacks, &o.Cracked, &o.Cracks); err != nil {
+			return nil, err
+		}
+		id, err := uuid.Parse(wordlistID)
+		if err != nil {
+			continue
+		}
+		o.WordlistID = id
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// tenantColumns is the column list every tenant SELECT returns, in scanTenant order
+const tenantColumns = `id, name, description, created_at, updated_at`
+
+type tenantRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewTenantRepository(db *database.SQLiteDB) domain.TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+func (r *tenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	if tenant.ID == uuid.Nil {
+		tenant.ID = uuid.New()
+	}
+	tenant.CreatedAt = time.Now()
+	tenant.UpdatedAt = tenant.CreatedAt
+
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT INTO tenants (id, name, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		tenant.ID.String(), tenant.Name, tenant.Description, tenant.CreatedAt, tenant.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return nil
+}
+
+func (r *tenantRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tenant, error) {
+	return r.getOne(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id.String())
+}
+
+func (r *tenantRepository) GetByName(ctx context.Context, name string) (*domain.Tenant, error) {
+	return r.getOne(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE name = ? COLLATE NOCASE`, name)
+}
+
+func (r *tenantRepository) getOne(ctx context.Context, query string, arg string) (*domain.Tenant, error) {
+	tenant, err := scanTenant(r.db.DB().QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "tenant"}
+		}
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *tenantRepository) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := make([]domain.Tenant, 0)
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+func (r *tenantRepository) Update(ctx context.Context, tenant *domain.Tenant) error {
+	tenant.UpdatedAt = time.Now()
+
+	result, err := r.db.DB().ExecContext(ctx,
+		`UPDATE tenants SET name = ?, description = ?, updated_at = ? WHERE id = ?`,
+		tenant.Name, tenant.Description, tenant.UpdatedAt, tenant.ID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return &domain.NotFoundError{Entity: "tenant"}
+	}
+	return nil
+}
+
+func (r *tenantRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM tenants WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return &domain.NotFoundError{Entity: "tenant"}
+	}
+	return nil
+}
+
+func (r *tenantRepository) CountResources(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	tenant := id.String()
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM users WHERE tenant_id = ?)
+		     + (SELECT COUNT(*) FROM agents WHERE tenant_id = ?)
+		     + (SELECT COUNT(*) FROM jobs WHERE tenant_id = ? AND deleted_at IS NULL)
+		     + (SELECT COUNT(*) FROM hash_files WHERE tenant_id = ?)
+		     + (SELECT COUNT(*) FROM wordlists WHERE tenant_id = ?)
+	`, tenant, tenant, tenant, tenant, tenant).Scan(&count)
+	return count, err
+}
+
+func (r *tenantRepository) GetStats(ctx context.Context, id uuid.UUID) (*domain.TenantStats, error) {
+	stats := &domain.TenantStats{TenantID: id}
+	tenant := id.String()
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE tenant_id = ?),
+			(SELECT COUNT(*) FROM agents WHERE tenant_id = ? AND archived_at IS NULL),
+			(SELECT COUNT(*) FROM jobs WHERE tenant_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM jobs WHERE tenant_id = ? AND deleted_at IS NULL AND status IN (?, ?, ?, ?, ?)),
+			(SELECT COUNT(*) FROM jobs WHERE tenant_id = ? AND deleted_at IS NULL AND status IN (?, ?)),
+			(SELECT COUNT(*) FROM hash_files WHERE tenant_id = ?),
+			(SELECT COUNT(*) FROM wordlists WHERE tenant_id = ?)
+	`, tenant, tenant, tenant,
+		tenant, domain.JobStatusPending, domain.JobStatusAssigned, domain.JobStatusRunning, domain.JobStatusPaused, domain.JobStatusInterrupted,
+		tenant, domain.JobStatusCompleted, domain.JobStatusCracked,
+		tenant, tenant,
+	).Scan(&stats.Users, &stats.Agents, &stats.Jobs, &stats.RunningJobs, &stats.CompletedJobs, &stats.HashFiles, &stats.Wordlists)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// scanTenant scans a single row selected with tenantColumns
+func scanTenant(row rowScanner) (domain.Tenant, error) {
+	var tenant domain.Tenant
+	var id string
+	if err := row.Scan(&id, &tenant.Name, &tenant.Description, &tenant.CreatedAt, &tenant.UpdatedAt); err != nil {
+		return tenant, err
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return tenant, fmt.Errorf("invalid tenant ID %q: %w", id, err)
+	}
+	tenant.ID = parsed
+	return tenant, nil
+}
+
+// tenantVisible reports whether a row of the owner tenant is visible to
+// the request in ctx. Requests of users without a tenant see every row,
+// anonymous ones only the rows outside of tenants.
+func tenantVisible(ctx context.Context, owner *uuid.UUID) bool {
+	if domain.InSharedScope(ctx) {
+		return owner == nil
+	}
+	return userTenantVisible(ctx, owner)
+}
+
+// userTenantVisible is tenantVisible for users, which anonymous requests
+// look up while logging in
+func userTenantVisible(ctx context.Context, owner *uuid.UUID) bool {
+	tenantID := domain.TenantIDFromContext(ctx)
+	return tenantID == nil || (owner != nil && *owner == *tenantID)
+}
+
+// tenantOf is the tenant a new row is created in: the tenant of the
+// request, else the one the row was given
+func tenantOf(ctx context.Context, own *uuid.UUID) *uuid.UUID {
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		return tenantID
+	}
+	return own
+}
+
+// filterTenant keeps the items visible to the request in ctx. The lists it
+// filters are cached for every tenant, so they are filtered after reading.
+func filterTenant[T any](ctx context.Context, items []T, visible func(*T) bool) []T {
+	if !domain.TenantScoped(ctx) {
+		return items
+	}
+	kept := items[:0:0]
+	for i := range items {
+		if visible(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+	return kept
+}
+
+// tenantCondition appends the condition limiting a query to the tenant of
+// ctx, if any, to the conditions of a WHERE clause. Anonymous requests are
+// limited to the rows outside of tenants.
+func tenantCondition(ctx context.Context, column string, conditions []string, args []interface{}) ([]string, []interface{}) {
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		conditions = append(conditions, column+" = ?")
+		args = append(args, tenantID.String())
+	} else if domain.InSharedScope(ctx) {
+		conditions = append(conditions, column+" IS NULL")
+	}
+	return conditions, args
+}
+
+// tenantFilter is tenantCondition for queries with numbered parameters: the
+// condition limiting column to the rows visible to ctx, comparing it with
+// param, and the argument to bind to param
+func tenantFilter(ctx context.Context, column, param string) (string, interface{}) {
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		return column + " = " + param, tenantID.String()
+	}
+	if domain.InSharedScope(ctx) {
+		return column + " IS NULL", nil
+	}
+	return "1 = 1", nil
+}
+
+// uuidColumn scans a nullable UUID column such as tenant_id
+type uuidColumn struct {
+	dst **uuid.UUID
+}
+
+func (c uuidColumn) Scan(src interface{}) error {
+	*c.dst = nil
+	var s string
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unsupported UUID column type %T", src)
+	}
+	if s == "" {
+		return nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid UUID %q: %w", s, err)
+	}
+	*c.dst = &id
+	return nil
+}
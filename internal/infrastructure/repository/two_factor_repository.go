@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// twoFactorColumns is the column list every two-factor SELECT returns, in
+// scanTwoFactor order
+const twoFactorColumns = `user_id, secret, enabled, last_used_step, created_at, updated_at`
+
+type twoFactorRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewTwoFactorRepository(db *database.SQLiteDB) domain.TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	row := r.db.DB().QueryRowContext(ctx, `SELECT `+twoFactorColumns+` FROM two_factor WHERE user_id = ?`, userID.String())
+	twoFactor, err := r.scanTwoFactor(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "two-factor authentication"}
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) Save(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	secret, err := r.db.SealField(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if twoFactor.CreatedAt.IsZero() {
+		twoFactor.CreatedAt = now
+	}
+	twoFactor.UpdatedAt = now
+
+	_, err = r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO two_factor (`+twoFactorColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, twoFactor.UserID.String(), secret, twoFactor.Enabled, twoFactor.LastUsedStep, twoFactor.CreatedAt, twoFactor.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = ?`, userID.String()); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor WHERE user_id = ?`, userID.String()); err != nil {
+		return fmt.Errorf("failed to delete two-factor authentication: %w", err)
+	}
+	return tx.Commit()
+}
+
+// UseStep only moves last_used_step forward, so of two logins racing with
+// the same code one fails
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx,
+		`UPDATE two_factor SET last_used_step = ?, updated_at = ? WHERE user_id = ? AND last_used_step < ?`,
+		step, time.Now(), userID.String(), step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = ?`, userID.String()); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO two_factor_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID.String(), hash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM two_factor_recovery_codes WHERE user_id = ? AND code_hash = ?`, userID.String(), codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM two_factor_recovery_codes WHERE user_id = ?`, userID.String()).Scan(&count)
+	return count, err
+}
+
+func (r *twoFactorRepository) GetPolicy(ctx context.Context) (*domain.TwoFactorPolicy, error) {
+	var policy domain.TwoFactorPolicy
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT require_for_privileged, updated_at FROM two_factor_policy WHERE id = 1`).
+		Scan(&policy.RequireForPrivileged, &policy.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *twoFactorRepository) SavePolicy(ctx context.Context, policy *domain.TwoFactorPolicy) error {
+	policy.UpdatedAt = time.Now()
+	_, err := r.db.DB().ExecContext(ctx,
+		`INSERT OR REPLACE INTO two_factor_policy (id, require_for_privileged, updated_at) VALUES (1, ?, ?)`,
+		policy.RequireForPrivileged, policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor policy: %w", err)
+	}
+	return nil
+}
+
+// scanTwoFactor scans a single row selected with twoFactorColumns
+func (r *twoFactorRepository) scanTwoFactor(row rowScanner) (domain.TwoFactor, error) {
+	var twoFactor domain.TwoFactor
+	var userID, secret string
+	err := row.Scan(&userID, &secret, &twoFactor.Enabled, &twoFactor.LastUsedStep, &twoFactor.CreatedAt, &twoFactor.UpdatedAt)
+	if err != nil {
+		return twoFactor, err
+	}
+	parsed, err := uuid.Parse(userID)
+	if err != nil {
+		return twoFactor, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	twoFactor.UserID = parsed
+	if twoFactor.Secret, err = r.db.OpenField(secret); err != nil {
+		return twoFactor, err
+	}
+	return twoFactor, nil
+}
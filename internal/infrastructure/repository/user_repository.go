@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
@@ -22,9 +23,10 @@ func NewUserRepository(db *sql.DB) domain.UserRepository {
 // Create creates a new user
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (id, username, email, password, role, is_active, created_at, updated_at, last_login)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (id, username, email, password, role, is_active, created_at, updated_at, last_login, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	user.TenantID = tenantOf(ctx, user.TenantID)
 
 	_, err := r.db.ExecContext(ctx, query,
 		user.ID.String(),
@@ -36,15 +38,16 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 		user.CreatedAt,
 		user.UpdatedAt,
 		user.LastLogin,
+		nullableUUID(user.TenantID),
 	)
 
 	return err
 }
 
-// GetByID retrieves a user by ID
+// GetByID retrieves a user by ID, of the request's tenant if it has one
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login
+		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login, tenant_id
 		FROM users
 		WHERE id = ?
 	`
@@ -64,6 +67,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&lastLogin,
+		uuidColumn{&user.TenantID},
 	)
 
 	if err != nil {
@@ -76,6 +80,9 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	if lastLogin.Valid {
 		user.LastLogin = &lastLogin.Time
 	}
+	if !userTenantVisible(ctx, user.TenantID) {
+		return nil, &domain.UserNotFoundError{Username: id.String()}
+	}
 
 	return user, nil
 }
@@ -83,7 +90,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 // GetByUsername retrieves a user by username
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login
+		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login, tenant_id
 		FROM users
 		WHERE username = ?
 	`
@@ -103,6 +110,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&lastLogin,
+		uuidColumn{&user.TenantID},
 	)
 
 	if err != nil {
@@ -122,7 +130,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 // GetByEmail retrieves a user by email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login
+		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login, tenant_id
 		FROM users
 		WHERE email = ?
 	`
@@ -142,6 +150,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&lastLogin,
+		uuidColumn{&user.TenantID},
 	)
 
 	if err != nil {
@@ -158,15 +167,19 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	return user, nil
 }
 
-// GetAll retrieves all users
+// GetAll retrieves all users of the request's tenant, or all users
+// without one
 func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	conditions, args := tenantCondition(ctx, "tenant_id", nil, nil)
 	query := `
-		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login
-		FROM users
-		ORDER BY created_at DESC
-	`
+		SELECT id, username, email, password, role, is_active, created_at, updated_at, last_login, tenant_id
+		FROM users`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +201,7 @@ func (r *userRepository) GetAll(ctx context.Context) ([]domain.User, error) {
 			&user.CreatedAt,
 			&user.UpdatedAt,
 			&lastLogin,
+			uuidColumn{&user.TenantID},
 		)
 
 		if err != nil {
@@ -226,11 +240,12 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	return err
 }
 
-// Delete deletes a user
+// Delete deletes a user of the request's tenant
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = ?`
+	conditions, args := tenantCondition(ctx, "tenant_id", []string{"id = ?"}, []interface{}{id.String()})
+	query := `DELETE FROM users WHERE ` + strings.Join(conditions, " AND ")
 
-	result, err := r.db.ExecContext(ctx, query, id.String())
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -260,3 +275,23 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) erro
 
 	return err
 }
+
+// SetTenant moves a user into a tenant, or out of tenants when tenantID is nil
+func (r *userRepository) SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET tenant_id = ?, updated_at = ? WHERE id = ?`,
+		nullableUUID(tenantID), time.Now(), id.String())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &domain.UserNotFoundError{Username: id.String()}
+	}
+
+	return nil
+}
@@ -13,12 +13,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// wordlistColumns is the column list every wordlist SELECT returns, in scanWordlist order
+const wordlistColumns = `id, name, orig_name, path, size, word_count, sha256, project_id, dynamic, created_by, created_at, tenant_id`
+
 type wordlistRepository struct {
-	db          *database.SQLiteDB
-	cache       cache.Cache
-	getByIDStmt *sql.Stmt
-	getAllStmt  *sql.Stmt
-	deleteStmt  *sql.Stmt
+	db           *database.SQLiteDB
+	cache        cache.Cache
+	getByIDStmt  *sql.Stmt
+	getAllStmt   *sql.Stmt
+	getBySHAStmt *sql.Stmt
+	deleteStmt   *sql.Stmt
 }
 
 func NewWordlistRepository(db *database.SQLiteDB) domain.WordlistRepository {
@@ -37,7 +41,7 @@ func (r *wordlistRepository) prepareStatements() {
 	var err error
 
 	r.getByIDStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, orig_name, path, size, word_count, created_at
+		SELECT ` + wordlistColumns + `
 		FROM wordlists WHERE id = ? LIMIT 1
 	`)
 	if err != nil {
@@ -45,13 +49,21 @@ func (r *wordlistRepository) prepareStatements() {
 	}
 
 	r.getAllStmt, err = r.db.DB().Prepare(`
-		SELECT id, name, orig_name, path, size, word_count, created_at
+		SELECT ` + wordlistColumns + `
 		FROM wordlists ORDER BY created_at DESC LIMIT 50
 	`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare getAll statement: %v", err))
 	}
 
+	r.getBySHAStmt, err = r.db.DB().Prepare(`
+		SELECT ` + wordlistColumns + `
+		FROM wordlists WHERE sha256 = ? ORDER BY created_at ASC LIMIT 1
+	`)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to prepare getBySHA256 statement: %v", err))
+	}
+
 	r.deleteStmt, err = r.db.DB().Prepare(`DELETE FROM wordlists WHERE id = ?`)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to prepare delete statement: %v", err))
@@ -60,11 +72,12 @@ func (r *wordlistRepository) prepareStatements() {
 
 func (r *wordlistRepository) Create(ctx context.Context, wordlist *domain.Wordlist) error {
 	query := `
-		INSERT INTO wordlists (id, name, orig_name, path, size, word_count, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO wordlists (id, name, orig_name, path, size, word_count, sha256, project_id, dynamic, created_by, created_at, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	wordlist.CreatedAt = time.Now()
+	wordlist.TenantID = tenantOf(ctx, wordlist.TenantID)
 
 	_, err := r.db.DB().ExecContext(ctx, query,
 		wordlist.ID.String(),
@@ -73,7 +86,12 @@ func (r *wordlistRepository) Create(ctx context.Context, wordlist *domain.Wordli
 		wordlist.Path,
 		wordlist.Size,
 		wordlist.WordCount,
+		wordlist.SHA256,
+		nullableUUID(wordlist.ProjectID),
+		wordlist.Dynamic,
+		nullableUUID(wordlist.CreatedBy),
 		wordlist.CreatedAt,
+		nullableUUID(wordlist.TenantID),
 	)
 
 	if err == nil {
@@ -92,23 +110,11 @@ func (r *wordlistRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	// Try cache first
 	var wordlist domain.Wordlist
 	if found, err := r.cache.Get(ctx, cacheKey, &wordlist); err == nil && found {
-		return &wordlist, nil
+		return tenantWordlist(ctx, &wordlist)
 	}
 
 	// Fallback to database with prepared statement
-	var idStr string
-	var wordCount sql.NullInt64
-
-	err := r.getByIDStmt.QueryRowContext(ctx, id.String()).Scan(
-		&idStr,
-		&wordlist.Name,
-		&wordlist.OrigName,
-		&wordlist.Path,
-		&wordlist.Size,
-		&wordCount,
-		&wordlist.CreatedAt,
-	)
-
+	wordlist, err := scanWordlist(r.getByIDStmt.QueryRowContext(ctx, id.String()))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("wordlist not found")
@@ -116,16 +122,10 @@ func (r *wordlistRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 		return nil, err
 	}
 
-	wordlist.ID = uuid.MustParse(idStr)
-
-	if wordCount.Valid {
-		wordlist.WordCount = &wordCount.Int64
-	}
-
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, &wordlist)
 
-	return &wordlist, nil
+	return tenantWordlist(ctx, &wordlist)
 }
 
 func (r *wordlistRepository) GetAll(ctx context.Context) ([]domain.Wordlist, error) {
@@ -134,7 +134,7 @@ func (r *wordlistRepository) GetAll(ctx context.Context) ([]domain.Wordlist, err
 	// Try cache first
 	var wordlists []domain.Wordlist
 	if found, err := r.cache.Get(ctx, cacheKey, &wordlists); err == nil && found {
-		return wordlists, nil
+		return tenantWordlists(ctx, wordlists), nil
 	}
 
 	// Fallback to database with prepared statement
@@ -144,41 +144,23 @@ func (r *wordlistRepository) GetAll(ctx context.Context) ([]domain.Wordlist, err
 	}
 	defer rows.Close()
 
-	wordlists = make([]domain.Wordlist, 0, 10) // Pre-allocate slice
-	for rows.Next() {
-		var wordlist domain.Wordlist
-		var idStr string
-		var wordCount sql.NullInt64
-
-		err := rows.Scan(
-			&idStr,
-			&wordlist.Name,
-			&wordlist.OrigName,
-			&wordlist.Path,
-			&wordlist.Size,
-			&wordCount,
-			&wordlist.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		wordlist.ID = uuid.MustParse(idStr)
-
-		if wordCount.Valid {
-			wordlist.WordCount = &wordCount.Int64
-		}
-
-		wordlists = append(wordlists, wordlist)
+	wordlists, err = scanWordlists(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	// Cache the result
 	r.cache.Set(ctx, cacheKey, wordlists)
 
-	return wordlists, nil
+	return tenantWordlists(ctx, wordlists), nil
 }
 
 func (r *wordlistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if domain.TenantScoped(ctx) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
 	_, err := r.deleteStmt.ExecContext(ctx, id.String())
 
 	if err == nil {
@@ -190,3 +172,145 @@ func (r *wordlistRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return err
 }
+
+func (r *wordlistRepository) GetBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error) {
+	row := r.getBySHAStmt.QueryRowContext(ctx, sum)
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		// Uploads are only deduplicated against the tenant's own files
+		row = r.db.DB().QueryRowContext(ctx, `
+			SELECT `+wordlistColumns+`
+			FROM wordlists WHERE sha256 = ? AND tenant_id = ? ORDER BY created_at ASC LIMIT 1
+		`, sum, tenantID.String())
+	} else if domain.InSharedScope(ctx) {
+		row = r.db.DB().QueryRowContext(ctx, `
+			SELECT `+wordlistColumns+`
+			FROM wordlists WHERE sha256 = ? AND tenant_id IS NULL ORDER BY created_at ASC LIMIT 1
+		`, sum)
+	}
+	wordlist, err := scanWordlist(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("wordlist not found")
+		}
+		return nil, err
+	}
+
+	return &wordlist, nil
+}
+
+// GetByProject returns the wordlists of a project. Like the hash file
+// equivalent it bypasses the list cache.
+func (r *wordlistRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT `+wordlistColumns+`
+		FROM wordlists WHERE project_id = ? ORDER BY created_at DESC
+	`, projectID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wordlists, err := scanWordlists(rows)
+	if err != nil {
+		return nil, err
+	}
+	return tenantWordlists(ctx, wordlists), nil
+}
+
+// GetLoopback looks the loopback wordlist up in the database rather than
+// the cache, since it is read right before its file is rewritten
+func (r *wordlistRepository) GetLoopback(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	query := `SELECT ` + wordlistColumns + ` FROM wordlists WHERE dynamic = 1 AND project_id IS NULL`
+	args := []interface{}{}
+	if projectID != nil {
+		query = `SELECT ` + wordlistColumns + ` FROM wordlists WHERE dynamic = 1 AND project_id = ?`
+		args = append(args, projectID.String())
+	}
+
+	wordlist, err := scanWordlist(r.db.DB().QueryRowContext(ctx, query+` ORDER BY created_at ASC LIMIT 1`, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &wordlist, nil
+}
+
+func (r *wordlistRepository) UpdateContent(ctx context.Context, wordlist *domain.Wordlist) error {
+	_, err := r.db.DB().ExecContext(ctx, `
+		UPDATE wordlists SET size = ?, word_count = ?, sha256 = ? WHERE id = ?
+	`, wordlist.Size, wordlist.WordCount, wordlist.SHA256, wordlist.ID.String())
+
+	if err == nil {
+		r.cache.Delete(ctx, "wordlist:"+wordlist.ID.String())
+		r.cache.Delete(ctx, "wordlists:all")
+	}
+
+	return err
+}
+
+// scanWordlist scans a single row selected with wordlistColumns
+func scanWordlist(row rowScanner) (domain.Wordlist, error) {
+	var wordlist domain.Wordlist
+	var idStr string
+	var wordCount sql.NullInt64
+	var sha256Sum sql.NullString
+	var projectID sql.NullString
+	var createdBy sql.NullString
+	var tenantID sql.NullString
+
+	err := row.Scan(
+		&idStr,
+		&wordlist.Name,
+		&wordlist.OrigName,
+		&wordlist.Path,
+		&wordlist.Size,
+		&wordCount,
+		&sha256Sum,
+		&projectID,
+		&wordlist.Dynamic,
+		&createdBy,
+		&wordlist.CreatedAt,
+		&tenantID,
+	)
+	if err != nil {
+		return wordlist, err
+	}
+
+	wordlist.ID = uuid.MustParse(idStr)
+
+	if wordCount.Valid {
+		wordlist.WordCount = &wordCount.Int64
+	}
+	wordlist.SHA256 = sha256Sum.String
+	wordlist.ProjectID = parseNullableUUID(projectID)
+	wordlist.CreatedBy = parseNullableUUID(createdBy)
+	wordlist.TenantID = parseNullableUUID(tenantID)
+	return wordlist, nil
+}
+
+// tenantWordlist hides the wordlists of other tenants as if they didn't exist
+func tenantWordlist(ctx context.Context, wordlist *domain.Wordlist) (*domain.Wordlist, error) {
+	if !tenantVisible(ctx, wordlist.TenantID) {
+		return nil, fmt.Errorf("wordlist not found")
+	}
+	return wordlist, nil
+}
+
+// tenantWordlists keeps the wordlists of the request's tenant
+func tenantWordlists(ctx context.Context, wordlists []domain.Wordlist) []domain.Wordlist {
+	return filterTenant(ctx, wordlists, func(wordlist *domain.Wordlist) bool { return tenantVisible(ctx, wordlist.TenantID) })
+}
+
+func scanWordlists(rows *sql.Rows) ([]domain.Wordlist, error) {
+	wordlists := make([]domain.Wordlist, 0, 10) // Pre-allocate slice
+	for rows.Next() {
+		wordlist, err := scanWordlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		wordlists = append(wordlists, wordlist)
+	}
+	return wordlists, rows.Err()
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// wordlistSourceColumns is the column list every wordlist source SELECT
+// returns, in scanWordlistSource order
+const wordlistSourceColumns = `id, name, url, sha256, refresh_hours, project_id, wordlist_id, status, error, fetched_sha256, fetched_at, created_by, created_at, updated_at`
+
+type wordlistSourceRepository struct {
+	db *database.SQLiteDB
+}
+
+func NewWordlistSourceRepository(db *database.SQLiteDB) domain.WordlistSourceRepository {
+	return &wordlistSourceRepository{db: db}
+}
+
+func (r *wordlistSourceRepository) Create(ctx context.Context, source *domain.WordlistSource) error {
+	if source.ID == uuid.Nil {
+		source.ID = uuid.New()
+	}
+	source.CreatedAt = time.Now()
+	source.UpdatedAt = source.CreatedAt
+
+	_, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO wordlist_sources (`+wordlistSourceColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, source.ID.String(), source.Name, source.URL, source.SHA256, source.RefreshHours, nullableUUID(source.ProjectID),
+		nullableUUID(source.WordlistID), source.Status, source.Error, source.FetchedSHA256, source.FetchedAt,
+		nullableUUID(source.CreatedBy), source.CreatedAt, source.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create wordlist source: %w", err)
+	}
+	return nil
+}
+
+func (r *wordlistSourceRepository) Update(ctx context.Context, source *domain.WordlistSource) error {
+	source.UpdatedAt = time.Now()
+
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE wordlist_sources
+		SET name = ?, url = ?, sha256 = ?, refresh_hours = ?, wordlist_id = ?, status = ?, error = ?,
+			fetched_sha256 = ?, fetched_at = ?, updated_at = ?
+		WHERE id = ?
+	`, source.Name, source.URL, source.SHA256, source.RefreshHours, nullableUUID(source.WordlistID), source.Status, source.Error,
+		source.FetchedSHA256, source.FetchedAt, source.UpdatedAt, source.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update wordlist source: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "wordlist source"}
+	}
+	return nil
+}
+
+func (r *wordlistSourceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error) {
+	source, err := scanWordlistSource(r.db.DB().QueryRowContext(ctx,
+		`SELECT `+wordlistSourceColumns+` FROM wordlist_sources WHERE id = ?`, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &domain.NotFoundError{Entity: "wordlist source"}
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *wordlistSourceRepository) GetAll(ctx context.Context) ([]domain.WordlistSource, error) {
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+wordlistSourceColumns+` FROM wordlist_sources ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []domain.WordlistSource{}
+	for rows.Next() {
+		source, err := scanWordlistSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+func (r *wordlistSourceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM wordlist_sources WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &domain.NotFoundError{Entity: "wordlist source"}
+	}
+	return nil
+}
+
+func (r *wordlistSourceRepository) FailFetching(ctx context.Context, message string) (int, error) {
+	now := time.Now()
+	result, err := r.db.DB().ExecContext(ctx, `
+		UPDATE wordlist_sources SET status = ?, error = ?, fetched_at = ?, updated_at = ?
+		WHERE status = ?
+	`, domain.WordlistSourceFailed, message, now, now, domain.WordlistSourceFetching)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail fetching wordlist sources: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+// scanWordlistSource scans a single row selected with wordlistSourceColumns
+func scanWordlistSource(row rowScanner) (domain.WordlistSource, error) {
+	var source domain.WordlistSource
+	var id string
+	var projectID, wordlistID, createdBy sql.NullString
+	var fetchedAt sql.NullTime
+
+	err := row.Scan(
+		&id,
+		&source.Name,
+		&source.URL,
+		&source.SHA256,
+		&source.RefreshHours,
+		&projectID,
+		&wordlistID,
+		&source.Status,
+		&source.Error,
+		&source.FetchedSHA256,
+		&fetchedAt,
+		&createdBy,
+		&source.CreatedAt,
+		&source.UpdatedAt,
+	)
+	if err != nil {
+		return source, err
+	}
+
+	source.ID = uuid.MustParse(id)
+	source.ProjectID = parseNullableUUID(projectID)
+	source.WordlistID = parseNullableUUID(wordlistID)
+	source.CreatedBy = parseNullableUUID(createdBy)
+	if fetchedAt.Valid {
+		source.FetchedAt = &fetchedAt.Time
+	}
+	return source, nil
+}
@@ -0,0 +1,233 @@
+// Package slack speaks the Slack side of chat commands: it verifies the
+// signature of slash command and interaction requests, reads them, and
+// renders answers as Block Kit messages.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	// maxRequestAge refuses replayed requests, as Slack recommends
+	maxRequestAge = 5 * time.Minute
+	httpTimeout   = 10 * time.Second
+	// maxFields is the most fields Slack shows in one section
+	maxFields = 10
+)
+
+// ErrBadSignature is returned for requests Slack didn't sign with the
+// app's signing secret, or signed too long ago
+var ErrBadSignature = errors.New("invalid Slack request signature")
+
+// Verify checks the X-Slack-Signature of a request body against the app's
+// signing secret
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return ErrBadSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ParseCommand reads a slash command from its form body
+func ParseCommand(form url.Values) domain.ChatCommand {
+	return domain.ChatCommand{
+		User: domain.ChatUser{Chat: "slack", ID: form.Get("user_id"), Name: form.Get("user_name")},
+		Text: form.Get("text"),
+	}
+}
+
+// Interaction is a button press, with where the answer goes
+type Interaction struct {
+	Actions     []domain.ChatAction
+	ResponseURL string
+}
+
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// ParseInteraction reads the payload field of an interaction request.
+// Interactions other than button presses have no actions.
+func ParseInteraction(form url.Values) (*Interaction, error) {
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return nil, fmt.Errorf("invalid interaction payload: %w", err)
+	}
+
+	interaction := &Interaction{ResponseURL: payload.ResponseURL}
+	if payload.Type != "block_actions" {
+		return interaction, nil
+	}
+	name := payload.User.Username
+	if name == "" {
+		name = payload.User.Name
+	}
+	user := domain.ChatUser{Chat: "slack", ID: payload.User.ID, Name: name}
+	for _, action := range payload.Actions {
+		interaction.Actions = append(interaction.Actions, domain.ChatAction{User: user, ActionID: action.ActionID, Value: action.Value})
+	}
+	return interaction, nil
+}
+
+// Message is a slash command answer or response_url post
+type Message struct {
+	ResponseType string  `json:"response_type"` // in_channel or ephemeral
+	Text         string  `json:"text"`
+	Blocks       []Block `json:"blocks,omitempty"`
+}
+
+// Block is a section or actions block
+type Block struct {
+	Type     string    `json:"type"`
+	Text     *Text     `json:"text,omitempty"`
+	Fields   []Text    `json:"fields,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+}
+
+// Text is a plain_text or mrkdwn text object
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Element is a button
+type Element struct {
+	Type     string   `json:"type"`
+	Text     Text     `json:"text"`
+	ActionID string   `json:"action_id"`
+	Value    string   `json:"value"`
+	Style    string   `json:"style,omitempty"`
+	Confirm  *Confirm `json:"confirm,omitempty"`
+}
+
+// Confirm asks before a button's action is sent
+type Confirm struct {
+	Title   Text `json:"title"`
+	Text    Text `json:"text"`
+	Confirm Text `json:"confirm"`
+	Deny    Text `json:"deny"`
+}
+
+// Render turns an answer into Block Kit: a section per ChatSection, with an
+// actions block after it for its buttons
+func Render(message *domain.ChatMessage) Message {
+	rendered := Message{ResponseType: "ephemeral", Text: message.Text}
+	if message.Public {
+		rendered.ResponseType = "in_channel"
+	}
+	if len(message.Sections) == 0 {
+		rendered.Blocks = []Block{{Type: "section", Text: mrkdwn(message.Text)}}
+		return rendered
+	}
+
+	for _, section := range message.Sections {
+		block := Block{Type: "section"}
+		if section.Text != "" {
+			block.Text = mrkdwn(section.Text)
+		}
+		for i, field := range section.Fields {
+			if i == maxFields {
+				break
+			}
+			block.Fields = append(block.Fields, *mrkdwn("*" + field.Label + "*\n" + field.Value))
+		}
+		rendered.Blocks = append(rendered.Blocks, block)
+
+		if len(section.Buttons) == 0 {
+			continue
+		}
+		actions := Block{Type: "actions"}
+		for _, button := range section.Buttons {
+			element := Element{Type: "button", Text: plain(button.Text), ActionID: button.ActionID, Value: button.Value}
+			if button.Danger {
+				element.Style = "danger"
+				element.Confirm = &Confirm{
+					Title:   plain(button.Text + "?"),
+					Text:    plain("This can't be undone."),
+					Confirm: plain(button.Text),
+					Deny:    plain("Keep"),
+				}
+			}
+			actions.Elements = append(actions.Elements, element)
+		}
+		rendered.Blocks = append(rendered.Blocks, actions)
+	}
+	return rendered
+}
+
+// Respond posts an answer to the response_url of an interaction. Only Slack
+// URLs are posted to.
+func Respond(ctx context.Context, responseURL string, message *domain.ChatMessage) error {
+	target, err := url.Parse(responseURL)
+	if err != nil || target.Scheme != "https" || (target.Host != "slack.com" && !strings.HasSuffix(target.Host, ".slack.com")) {
+		return fmt.Errorf("refusing to answer to %q, not a Slack response URL", responseURL)
+	}
+	body, err := json.Marshal(Render(message))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to answer Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack refused the answer: %s", resp.Status)
+	}
+	return nil
+}
+
+func mrkdwn(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+func plain(text string) Text {
+	return Text{Type: "plain_text", Text: text}
+}
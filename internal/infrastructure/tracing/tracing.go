@@ -0,0 +1,141 @@
+// Package tracing sets up OpenTelemetry tracing for the server and the agent.
+// Spans are exported over OTLP/HTTP; with tracing disabled the global no-op
+// tracer is used and instrumented code costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "go-distributed-hashcat"
+
+// Config selects where spans are sent
+type Config struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	ServiceName string  `mapstructure:"service_name"` // Defaults to the name passed to Init
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Share of new traces to record, 0 or 1 records all
+}
+
+// Init installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes pending spans and must be called
+// on shutdown.
+func Init(ctx context.Context, config Config, defaultServiceName string) (func(context.Context) error, error) {
+	// Propagate incoming trace context even when this process records nothing
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(config.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.SampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for a component, e.g. Tracer("usecase")
+func Tracer(component string) trace.Tracer {
+	return otel.Tracer(instrumentationName + "/" + component)
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// uuidSegment matches the IDs in paths like /api/v1/jobs/<id>/progress, which
+// would otherwise give every job its own span name
+var uuidSegment = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// transport starts a client span for each request and passes the trace
+// context on in the request headers
+type transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+	skip   func(*http.Request) bool
+}
+
+// Transport wraps base (http.DefaultTransport if nil) so that outgoing
+// requests are traced. Requests for which skip returns true are sent as is.
+func Transport(base http.RoundTripper, skip func(*http.Request) bool) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, tracer: Tracer("http-client"), skip: skip}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.skip != nil && t.skip(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+uuidSegment.ReplaceAllString(req.URL.Path, "{id}"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.String()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+
+	// RoundTrippers must not modify the request they were given
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
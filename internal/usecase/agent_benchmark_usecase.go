@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// benchmarkJobHistory is how many of an agent's benchmark jobs
+// GetBenchmarks lists
+const benchmarkJobHistory = 20
+
+// AgentBenchmarkUsecase manages the per hash mode benchmarks of agents:
+// listing them, having agents re-run them and dropping them after the
+// hardware changed
+type AgentBenchmarkUsecase interface {
+	GetBenchmarks(ctx context.Context, agentID uuid.UUID) (*domain.AgentBenchmarks, error)
+	// RequestBenchmark queues a benchmark job, which the agent runs before
+	// its next cracking job
+	RequestBenchmark(ctx context.Context, agentID uuid.UUID, req *domain.BenchmarkRequest) (*domain.BenchmarkJob, error)
+	// InvalidateBenchmarks drops the agent's benchmarks and startup speed,
+	// and leaves the speeds of its earlier jobs out of scheduling
+	InvalidateBenchmarks(ctx context.Context, agentID uuid.UUID) error
+
+	// ClaimBenchmarkJob hands the agent its next benchmark job, nil if
+	// there is none
+	ClaimBenchmarkJob(ctx context.Context, agentID uuid.UUID) (*domain.BenchmarkJob, error)
+	// ReportBenchmarkJob stores the speeds an agent measured and finishes
+	// the benchmark job
+	ReportBenchmarkJob(ctx context.Context, agentID, jobID uuid.UUID, report *domain.BenchmarkReport) (*domain.BenchmarkJob, error)
+}
+
+type agentBenchmarkUsecase struct {
+	benchmarkRepo domain.AgentBenchmarkRepository
+	agentRepo     domain.AgentRepository
+}
+
+func NewAgentBenchmarkUsecase(benchmarkRepo domain.AgentBenchmarkRepository, agentRepo domain.AgentRepository) AgentBenchmarkUsecase {
+	return &agentBenchmarkUsecase{
+		benchmarkRepo: benchmarkRepo,
+		agentRepo:     agentRepo,
+	}
+}
+
+func (u *agentBenchmarkUsecase) GetBenchmarks(ctx context.Context, agentID uuid.UUID) (*domain.AgentBenchmarks, error) {
+	agent, err := u.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	benchmarks := &domain.AgentBenchmarks{AgentID: agent.ID, Speed: agent.Speed}
+	if benchmarks.Benchmarks, err = u.benchmarkRepo.GetByAgent(ctx, agentID); err != nil {
+		return nil, err
+	}
+	if benchmarks.ResetAt, err = u.benchmarkRepo.GetResetAt(ctx, agentID); err != nil {
+		return nil, err
+	}
+	if benchmarks.Jobs, err = u.benchmarkRepo.GetJobs(ctx, agentID, benchmarkJobHistory); err != nil {
+		return nil, err
+	}
+	return benchmarks, nil
+}
+
+func (u *agentBenchmarkUsecase) RequestBenchmark(ctx context.Context, agentID uuid.UUID, req *domain.BenchmarkRequest) (*domain.BenchmarkJob, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	agent, err := u.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.BenchmarkJob{
+		AgentID:   agent.ID,
+		HashTypes: req.HashTypes,
+		Status:    domain.BenchmarkJobPending,
+	}
+	if err := u.benchmarkRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	agentLogger(ctx, agent.ID).Info("Benchmark of hash modes %v requested for agent %s", job.HashTypes, agent.Name)
+	return job, nil
+}
+
+func (u *agentBenchmarkUsecase) InvalidateBenchmarks(ctx context.Context, agentID uuid.UUID) error {
+	if _, err := u.agentRepo.GetByID(ctx, agentID); err != nil {
+		return err
+	}
+	if err := u.benchmarkRepo.Invalidate(ctx, agentID, time.Now()); err != nil {
+		return err
+	}
+	// The startup benchmark is measured again when the agent restarts
+	return u.agentRepo.UpdateSpeed(ctx, agentID, 0)
+}
+
+func (u *agentBenchmarkUsecase) ClaimBenchmarkJob(ctx context.Context, agentID uuid.UUID) (*domain.BenchmarkJob, error) {
+	return u.benchmarkRepo.ClaimJob(ctx, agentID, time.Now())
+}
+
+// ReportBenchmarkJob completes the job when the agent measured any of its
+// hash modes, noting the ones it couldn't; otherwise the job failed
+func (u *agentBenchmarkUsecase) ReportBenchmarkJob(ctx context.Context, agentID, jobID uuid.UUID, report *domain.BenchmarkReport) (*domain.BenchmarkJob, error) {
+	job, err := u.benchmarkRepo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.AgentID != agentID {
+		return nil, &domain.NotFoundError{Entity: "benchmark job"}
+	}
+	if job.Status != domain.BenchmarkJobPending && job.Status != domain.BenchmarkJobRunning {
+		return nil, &domain.ValidationError{Field: "status", Message: fmt.Sprintf("benchmark job is already %s", job.Status)}
+	}
+
+	now := time.Now()
+	var benchmarks []domain.AgentBenchmark
+	var missing []string
+	for _, hashType := range job.HashTypes {
+		if speed := report.Speeds[hashType]; speed > 0 {
+			benchmarks = append(benchmarks, domain.AgentBenchmark{AgentID: agentID, HashType: hashType, Speed: speed, MeasuredAt: now})
+		} else {
+			missing = append(missing, strconv.Itoa(hashType))
+		}
+	}
+	if err := u.benchmarkRepo.Save(ctx, benchmarks); err != nil {
+		return nil, err
+	}
+
+	job.Status, job.CompletedAt = domain.BenchmarkJobCompleted, &now
+	var problems []string
+	if report.Error != "" {
+		problems = append(problems, report.Error)
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "no speed for hash modes "+strings.Join(missing, ", "))
+	}
+	job.Error = strings.Join(problems, "; ")
+	if len(benchmarks) == 0 {
+		job.Status = domain.BenchmarkJobFailed
+	}
+	if err := u.benchmarkRepo.UpdateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// agentCommandHistory is how many of an agent's commands GetCommands lists
+const agentCommandHistory = 50
+
+// AgentCommandUsecase queues commands for agents, such as re-running the
+// benchmark or upgrading, and tracks them until the agent reports back
+type AgentCommandUsecase interface {
+	// QueueCommand queues a command, which reaches the agent with its next
+	// heartbeat or poll
+	QueueCommand(ctx context.Context, agentID uuid.UUID, req *domain.AgentCommandRequest) (*domain.AgentCommand, error)
+	// GetCommands returns the agent's latest commands, newest first
+	GetCommands(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error)
+	GetCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error)
+	// CancelCommand withdraws a command the agent hasn't acknowledged yet
+	CancelCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error)
+
+	// PendingCommands returns the commands waiting for the agent, oldest
+	// first, after expiring the ones it didn't pick up in time
+	PendingCommands(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error)
+	// AcknowledgeCommand tells the server the agent is running the command.
+	// A command is acknowledged once, so it never runs twice.
+	AcknowledgeCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error)
+	// ReportCommandResult finishes an acknowledged command with what the
+	// agent reported
+	ReportCommandResult(ctx context.Context, agentID, commandID uuid.UUID, result *domain.AgentCommandResult) (*domain.AgentCommand, error)
+}
+
+type agentCommandUsecase struct {
+	commandRepo domain.AgentCommandRepository
+	agentRepo   domain.AgentRepository
+}
+
+func NewAgentCommandUsecase(commandRepo domain.AgentCommandRepository, agentRepo domain.AgentRepository) AgentCommandUsecase {
+	return &agentCommandUsecase{
+		commandRepo: commandRepo,
+		agentRepo:   agentRepo,
+	}
+}
+
+func (u *agentCommandUsecase) QueueCommand(ctx context.Context, agentID uuid.UUID, req *domain.AgentCommandRequest) (*domain.AgentCommand, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	agent, err := u.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	command := &domain.AgentCommand{
+		AgentID:   agent.ID,
+		Type:      req.Type,
+		Params:    req.Params,
+		Status:    domain.AgentCommandPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(domain.AgentCommandTTL),
+	}
+	if err := u.commandRepo.Create(ctx, command); err != nil {
+		return nil, err
+	}
+	agentLogger(ctx, agent.ID).Info("Command %s queued for agent %s", command.Type, agent.Name)
+	return command, nil
+}
+
+func (u *agentCommandUsecase) GetCommands(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error) {
+	if _, err := u.agentRepo.GetByID(ctx, agentID); err != nil {
+		return nil, err
+	}
+	if err := u.commandRepo.Expire(ctx, agentID, time.Now()); err != nil {
+		return nil, err
+	}
+	return u.commandRepo.GetByAgent(ctx, agentID, agentCommandHistory)
+}
+
+func (u *agentCommandUsecase) GetCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error) {
+	if err := u.commandRepo.Expire(ctx, agentID, time.Now()); err != nil {
+		return nil, err
+	}
+	return u.agentCommand(ctx, agentID, commandID)
+}
+
+func (u *agentCommandUsecase) CancelCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error) {
+	command, err := u.agentCommand(ctx, agentID, commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	command.Status, command.CompletedAt = domain.AgentCommandCancelled, &now
+	if err := u.transition(ctx, command, domain.AgentCommandPending); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+func (u *agentCommandUsecase) PendingCommands(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error) {
+	if err := u.commandRepo.Expire(ctx, agentID, time.Now()); err != nil {
+		return nil, err
+	}
+	return u.commandRepo.GetPending(ctx, agentID)
+}
+
+func (u *agentCommandUsecase) AcknowledgeCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error) {
+	command, err := u.agentCommand(ctx, agentID, commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if command.Status == domain.AgentCommandPending && !now.Before(command.ExpiresAt) {
+		if err := u.commandRepo.Expire(ctx, agentID, now); err != nil {
+			return nil, err
+		}
+		return nil, &domain.ValidationError{Field: "status", Message: "agent command is already expired"}
+	}
+	command.Status, command.AcknowledgedAt = domain.AgentCommandAcknowledged, &now
+	if err := u.transition(ctx, command, domain.AgentCommandPending); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// ReportCommandResult completes the command, or fails it when the agent
+// reported an error
+func (u *agentCommandUsecase) ReportCommandResult(ctx context.Context, agentID, commandID uuid.UUID, result *domain.AgentCommandResult) (*domain.AgentCommand, error) {
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	command, err := u.agentCommand(ctx, agentID, commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	command.Status, command.CompletedAt = domain.AgentCommandCompleted, &now
+	command.Result, command.Error = result.Result, result.Error
+	if result.Error != "" {
+		command.Status = domain.AgentCommandFailed
+	}
+	if err := u.transition(ctx, command, domain.AgentCommandAcknowledged); err != nil {
+		return nil, err
+	}
+	agentLogger(ctx, agentID).Info("Command %s %s: %s", command.Type, command.Status, command.ID)
+	return command, nil
+}
+
+// agentCommand returns one of the agent's commands; those of other agents
+// are not found
+func (u *agentCommandUsecase) agentCommand(ctx context.Context, agentID, commandID uuid.UUID) (*domain.AgentCommand, error) {
+	command, err := u.commandRepo.GetByID(ctx, commandID)
+	if err != nil {
+		return nil, err
+	}
+	if command.AgentID != agentID {
+		return nil, &domain.NotFoundError{Entity: "agent command"}
+	}
+	return command, nil
+}
+
+// transition stores the command's new status if it is still in from,
+// otherwise says which status it is in
+func (u *agentCommandUsecase) transition(ctx context.Context, command *domain.AgentCommand, from string) error {
+	ok, err := u.commandRepo.Transition(ctx, command, from)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	current, err := u.commandRepo.GetByID(ctx, command.ID)
+	if err != nil {
+		return err
+	}
+	return &domain.ValidationError{Field: "status", Message: fmt.Sprintf("agent command is already %s", current.Status)}
+}
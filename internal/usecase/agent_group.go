@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (u *agentUsecase) CreateAgentGroup(ctx context.Context, req *domain.CreateAgentGroupRequest) (*domain.AgentGroupSummary, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+
+	groups, err := u.agentRepo.GetAllGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent groups: %w", err)
+	}
+	for _, group := range groups {
+		if strings.EqualFold(group.Name, name) {
+			return nil, &domain.ValidationError{Field: "name", Message: fmt.Sprintf("agent group '%s' already exists", group.Name)}
+		}
+	}
+
+	// Check all members before creating anything
+	agentIDs := make([]uuid.UUID, 0, len(req.AgentIDs))
+	for _, agentIDStr := range req.AgentIDs {
+		agentID, err := uuid.Parse(agentIDStr)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: "agent_ids", Message: fmt.Sprintf("invalid agent ID %s", agentIDStr)}
+		}
+		if _, err := u.agentRepo.GetByID(ctx, agentID); err != nil {
+			return nil, err
+		}
+		agentIDs = append(agentIDs, agentID)
+	}
+
+	group := &domain.AgentGroup{Name: name, Description: req.Description}
+	if err := u.agentRepo.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	for _, agentID := range agentIDs {
+		if err := u.agentRepo.AddGroupMember(ctx, group.ID, agentID); err != nil {
+			return nil, err
+		}
+	}
+
+	return u.GetAgentGroup(ctx, group.ID)
+}
+
+func (u *agentUsecase) GetAgentGroup(ctx context.Context, id uuid.UUID) (*domain.AgentGroupSummary, error) {
+	group, err := u.agentRepo.GetGroupByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := u.agentRepo.GetByGroupID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agents of group %s: %w", group.Name, err)
+	}
+
+	summary := summarizeAgentGroup(*group, agents)
+	return &summary, nil
+}
+
+// GetAllAgentGroups returns every agent group with the health of its agents
+func (u *agentUsecase) GetAllAgentGroups(ctx context.Context) ([]domain.AgentGroupSummary, error) {
+	groups, err := u.agentRepo.GetAllGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]domain.AgentGroupSummary, 0, len(groups))
+	for _, group := range groups {
+		agents, err := u.agentRepo.GetByGroupID(ctx, group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get agents of group %s: %w", group.Name, err)
+		}
+		summaries = append(summaries, summarizeAgentGroup(group, agents))
+	}
+
+	return summaries, nil
+}
+
+func (u *agentUsecase) DeleteAgentGroup(ctx context.Context, id uuid.UUID) error {
+	return u.agentRepo.DeleteGroup(ctx, id)
+}
+
+func (u *agentUsecase) AddAgentToGroup(ctx context.Context, groupID, agentID uuid.UUID) error {
+	if _, err := u.agentRepo.GetGroupByID(ctx, groupID); err != nil {
+		return err
+	}
+	if _, err := u.agentRepo.GetByID(ctx, agentID); err != nil {
+		return err
+	}
+	return u.agentRepo.AddGroupMember(ctx, groupID, agentID)
+}
+
+func (u *agentUsecase) RemoveAgentFromGroup(ctx context.Context, groupID, agentID uuid.UUID) error {
+	return u.agentRepo.RemoveGroupMember(ctx, groupID, agentID)
+}
+
+// GetOnlineGroupAgents returns the agents of a group that can take work now
+func (u *agentUsecase) GetOnlineGroupAgents(ctx context.Context, groupID uuid.UUID) ([]domain.Agent, error) {
+	return onlineGroupAgents(ctx, u.agentRepo, u.maintenance, groupID)
+}
+
+// summarizeAgentGroup counts a group's agents by status
+func summarizeAgentGroup(group domain.AgentGroup, agents []domain.Agent) domain.AgentGroupSummary {
+	summary := domain.AgentGroupSummary{
+		AgentGroup:  group,
+		TotalAgents: len(agents),
+		AgentIDs:    make([]uuid.UUID, 0, len(agents)),
+	}
+
+	for _, agent := range agents {
+		summary.AgentIDs = append(summary.AgentIDs, agent.ID)
+		switch agent.Status {
+		case "online":
+			summary.OnlineAgents++
+			summary.Speed += agent.Speed
+		case "busy":
+			summary.BusyAgents++
+			summary.Speed += agent.Speed
+		case "degraded":
+			summary.DegradedAgents++
+		default:
+			summary.OfflineAgents++
+		}
+	}
+
+	return summary
+}
+
+// onlineGroupAgents returns the online agents of a group outside maintenance
+// windows, failing when the group doesn't exist or none of its agents is
+// available. calendar may be nil.
+func onlineGroupAgents(ctx context.Context, agentRepo domain.AgentRepository, calendar domain.MaintenanceCalendar, groupID uuid.UUID) ([]domain.Agent, error) {
+	group, err := agentRepo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := agentRepo.GetByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agents of group %s: %w", group.Name, err)
+	}
+
+	online := make([]domain.Agent, 0, len(agents))
+	for _, agent := range agents {
+		if agent.Status == "online" {
+			online = append(online, agent)
+		}
+	}
+	if len(online) == 0 {
+		return nil, &domain.ValidationError{Field: "agent_group_id", Message: fmt.Sprintf("agent group '%s' has no online agents", group.Name)}
+	}
+
+	available := withoutMaintenance(online, agentsInMaintenance(ctx, calendar))
+	if len(available) == 0 {
+		return nil, &domain.ValidationError{Field: "agent_group_id", Message: fmt.Sprintf("agent group '%s' has no online agents outside maintenance windows", group.Name)}
+	}
+
+	return available, nil
+}
+
+// parseAgentGroupID parses the agent_group_id of a job request
+func parseAgentGroupID(id string) (uuid.UUID, error) {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, &domain.ValidationError{Field: "agent_group_id", Message: "must be a UUID"}
+	}
+	return groupID, nil
+}
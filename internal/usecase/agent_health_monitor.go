@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,9 +17,22 @@ import (
 type AgentHealthMonitor interface {
 	Start(ctx context.Context)
 	Stop()
+	// Shutdown stops the monitor after one last check, so the agent
+	// statuses left behind are current when the server exits
+	Shutdown(ctx context.Context)
 	RegisterAgent(agentID uuid.UUID)
 	UnregisterAgent(agentID uuid.UUID)
 	GetHealthStatus() HealthStatus
+	// SetMaintenanceCalendar makes each check drain agents entering a
+	// maintenance window and hand work to agents leaving one
+	SetMaintenanceCalendar(calendar domain.MaintenanceCalendar, dispatcher JobDispatcher)
+}
+
+// JobDispatcher is what the health monitor needs of the job dispatcher at
+// maintenance window boundaries
+type JobDispatcher interface {
+	DrainAgent(ctx context.Context, agentID uuid.UUID, reason string) (int, error)
+	AssignJobsToAgents(ctx context.Context) error
 }
 
 type HealthStatus struct {
@@ -26,6 +41,19 @@ type HealthStatus struct {
 	RecentlyOffline   int       `json:"recently_offline"`
 	LastHealthCheck   time.Time `json:"last_health_check"`
 	HealthCheckErrors int       `json:"health_check_errors"`
+
+	// Per agent group counts, taken after the check updated agent statuses
+	Groups []domain.AgentGroupSummary `json:"groups,omitempty"`
+
+	// Connected agents whose heartbeats are late or show they can't take work
+	DegradedAgents []DegradedAgent `json:"degraded_agents,omitempty"`
+}
+
+// DegradedAgent is a connected agent that reported a problem
+type DegradedAgent struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Problems []string  `json:"problems"`
 }
 
 type agentHealthMonitor struct {
@@ -37,18 +65,41 @@ type agentHealthMonitor struct {
 	registeredAgents sync.Map // agentID -> registration time
 	mu               sync.RWMutex
 	healthStatus     HealthStatus
+
+	// Agents in a maintenance window as of the last check
+	calendar      domain.MaintenanceCalendar
+	dispatcher    JobDispatcher
+	maintenanceMu sync.Mutex
+	inMaintenance map[uuid.UUID]domain.AgentMaintenance
 }
 
 type HealthConfig struct {
 	CheckInterval       time.Duration `json:"check_interval"`  // How often to check (default: 1 minute)
-	AgentTimeout        time.Duration `json:"agent_timeout"`   // When to mark offline (default: 3 minutes)
+	AgentTimeout        time.Duration `json:"agent_timeout"`   // Never mark offline sooner than this (default: 3 minutes)
 	HeartbeatGrace      time.Duration `json:"heartbeat_grace"` // Grace period for heartbeat (default: 30s)
 	MaxConcurrentChecks int           `json:"max_concurrent"`  // Max concurrent health checks
+	MinFreeDiskBytes    int64         `json:"min_free_disk"`   // Less free disk marks an agent degraded (default: 1 GiB)
+
+	// Thresholds in heartbeat intervals agreed with each agent. An agent is
+	// degraded after DegradedAfterMissed missed heartbeats and offline after
+	// OfflineAfterMissed; it is back online only once a heartbeat arrives on
+	// time, so a link hovering around a threshold doesn't flap.
+	DegradedAfterMissed int `json:"degraded_after_missed"` // default: 3
+	OfflineAfterMissed  int `json:"offline_after_missed"`  // default: 6
+}
+
+// heartbeatThresholds are an agent's status thresholds, measured as time
+// since its last heartbeat
+type heartbeatThresholds struct {
+	degraded time.Duration
+	offline  time.Duration
+	recover  time.Duration
 }
 
 type WebSocketHub interface {
 	BroadcastAgentStatus(agentID string, status string, lastSeen string)
 	BroadcastAgentSpeed(agentID string, speed int64)
+	BroadcastAgentLogs(agentID string, lines []domain.AgentLogLine)
 }
 
 func NewAgentHealthMonitor(
@@ -69,6 +120,15 @@ func NewAgentHealthMonitor(
 	if config.MaxConcurrentChecks == 0 {
 		config.MaxConcurrentChecks = 20 // ✅ More concurrent checks
 	}
+	if config.MinFreeDiskBytes == 0 {
+		config.MinFreeDiskBytes = 1 << 30
+	}
+	if config.DegradedAfterMissed <= 0 {
+		config.DegradedAfterMissed = 3
+	}
+	if config.OfflineAfterMissed <= config.DegradedAfterMissed {
+		config.OfflineAfterMissed = 2 * config.DegradedAfterMissed
+	}
 
 	return &agentHealthMonitor{
 		agentUsecase: agentUsecase,
@@ -103,6 +163,11 @@ func (h *agentHealthMonitor) Stop() {
 	infrastructure.ServerLogger.Info("Agent Health Monitor stopped")
 }
 
+func (h *agentHealthMonitor) Shutdown(ctx context.Context) {
+	h.Stop()
+	h.performHealthCheck(ctx)
+}
+
 func (h *agentHealthMonitor) RegisterAgent(agentID uuid.UUID) {
 	h.registeredAgents.Store(agentID, time.Now())
 	infrastructure.ServerLogger.Info("Registered agent for health monitoring: %s", agentID.String()[:8])
@@ -113,6 +178,14 @@ func (h *agentHealthMonitor) UnregisterAgent(agentID uuid.UUID) {
 	infrastructure.ServerLogger.Info("Unregistered agent from health monitoring: %s", agentID.String()[:8])
 }
 
+func (h *agentHealthMonitor) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar, dispatcher JobDispatcher) {
+	h.maintenanceMu.Lock()
+	defer h.maintenanceMu.Unlock()
+	h.calendar = calendar
+	h.dispatcher = dispatcher
+	h.inMaintenance = make(map[uuid.UUID]domain.AgentMaintenance)
+}
+
 func (h *agentHealthMonitor) GetHealthStatus() HealthStatus {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -139,7 +212,7 @@ func (h *agentHealthMonitor) performHealthCheck(ctx context.Context) {
 	agents, err := h.agentUsecase.GetAllAgents(ctx)
 	if err != nil {
 		infrastructure.ServerLogger.Error("Failed to get agents for health check: %v", err)
-		h.updateHealthStatus(0, 0, 0, 1)
+		h.updateHealthStatus(0, 0, 0, 1, nil, nil)
 		return
 	}
 
@@ -164,7 +237,14 @@ func (h *agentHealthMonitor) performHealthCheck(ctx context.Context) {
 
 	wg.Wait()
 
-	h.updateHealthStatus(onlineCount, offlineCount, recentlyOfflineCount, 0)
+	h.applyMaintenanceWindows(ctx)
+
+	groups, err := h.agentUsecase.GetAllAgentGroups(ctx)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to summarize agent groups: %v", err)
+	}
+
+	h.updateHealthStatus(onlineCount, offlineCount, recentlyOfflineCount, 0, groups, h.degradedAgents(agents))
 
 	duration := time.Since(start)
 	infrastructure.ServerLogger.Debug("Health check completed in %v - Online: %d, Offline: %d, Recently Offline: %d",
@@ -191,12 +271,12 @@ func (h *agentHealthMonitor) checkSingleAgent(ctx context.Context, agent *domain
 		return
 	}
 
-	// Determine if agent should be considered offline
-	shouldBeOffline := timeSinceLastSeen > h.config.AgentTimeout
-	wasRecentlyOnline := timeSinceLastSeen > h.config.AgentTimeout &&
-		timeSinceLastSeen < (h.config.AgentTimeout+5*time.Minute)
+	limits := h.thresholds(agent.ID)
+	connected := agent.Status == "online" || agent.Status == "busy" || agent.Status == "degraded"
 
-	currentlyOnline := agent.Status == "online" || agent.Status == "busy"
+	// Determine if agent should be considered offline
+	shouldBeOffline := timeSinceLastSeen > limits.offline
+	wasRecentlyOnline := shouldBeOffline && timeSinceLastSeen < (limits.offline+5*time.Minute)
 
 	// Update counters (thread-safe with atomic operations would be better)
 	// For simplicity, using direct increment here
@@ -209,8 +289,9 @@ func (h *agentHealthMonitor) checkSingleAgent(ctx context.Context, agent *domain
 		*onlineCount++
 	}
 
-	// Status change needed?
-	if shouldBeOffline && currentlyOnline {
+	// Status change needed? Between the thresholds the status stays as it is.
+	switch {
+	case shouldBeOffline && connected:
 		infrastructure.ServerLogger.Warning("Agent %s (key: %s) timeout detected - last seen %v ago",
 			agent.Name, agent.AgentKey, timeSinceLastSeen)
 
@@ -231,28 +312,33 @@ func (h *agentHealthMonitor) checkSingleAgent(ctx context.Context, agent *domain
 		}
 
 		infrastructure.ServerLogger.Info("Agent %s status updated to offline", agent.Name)
-	} else if !shouldBeOffline && !currentlyOnline {
-		// Handle online status change (agent came back online)
-		infrastructure.ServerLogger.Info("Agent %s (key: %s) came back online - last seen %v ago",
+	case !shouldBeOffline && timeSinceLastSeen > limits.degraded && (agent.Status == "online" || agent.Status == "busy"):
+		infrastructure.ServerLogger.Warning("Agent %s (key: %s) heartbeats are late - last seen %v ago",
 			agent.Name, agent.AgentKey, timeSinceLastSeen)
 
-		// Update status to online
-		if err := h.agentUsecase.UpdateAgentStatus(ctx, agent.ID, "online"); err != nil {
-			infrastructure.ServerLogger.Error("Failed to update agent %s status to online: %v", agent.Name, err)
+		// UpdateAgentStatus broadcasts the change itself
+		if err := h.agentUsecase.UpdateAgentStatus(ctx, agent.ID, "degraded"); err != nil {
+			infrastructure.ServerLogger.Error("Failed to update agent %s status to degraded: %v", agent.Name, err)
 			return
 		}
 
-		// Broadcast status change via WebSocket
-		if h.wsHub != nil {
-			infrastructure.ServerLogger.Debug("Broadcasting agent %s status change to online via WebSocket", agent.Name)
-			h.wsHub.BroadcastAgentStatus(
-				agent.ID.String(),
-				"online",
-				agent.LastSeen.Format(time.RFC3339),
-			)
+		infrastructure.ServerLogger.Info("Agent %s status updated to degraded", agent.Name)
+	case timeSinceLastSeen <= limits.recover && agent.Status != "online" && agent.Status != "busy":
+		// Handle online status change (agent came back online)
+		status := "online"
+		if agent.Heartbeat != nil && agent.Heartbeat.CurrentJobID != nil {
+			status = "busy"
+		}
+		infrastructure.ServerLogger.Info("Agent %s (key: %s) came back %s - last seen %v ago",
+			agent.Name, agent.AgentKey, status, timeSinceLastSeen)
+
+		// UpdateAgentStatus broadcasts the change itself
+		if err := h.agentUsecase.UpdateAgentStatus(ctx, agent.ID, status); err != nil {
+			infrastructure.ServerLogger.Error("Failed to update agent %s status to %s: %v", agent.Name, status, err)
+			return
 		}
 
-		infrastructure.ServerLogger.Info("Agent %s status updated to online", agent.Name)
+		infrastructure.ServerLogger.Info("Agent %s status updated to %s", agent.Name, status)
 	}
 
 	// Optional: Auto-cleanup very old offline agents
@@ -262,7 +348,111 @@ func (h *agentHealthMonitor) checkSingleAgent(ctx context.Context, agent *domain
 	}
 }
 
-func (h *agentHealthMonitor) updateHealthStatus(online, offline, recentlyOffline, errors int) {
+// applyMaintenanceWindows drains the agents that entered a maintenance
+// window since the last check, handing their queued jobs to other agents,
+// and dispatches queued jobs once agents leave one. An agent that fails to
+// drain is tried again on the next check.
+func (h *agentHealthMonitor) applyMaintenanceWindows(ctx context.Context) {
+	h.maintenanceMu.Lock()
+	defer h.maintenanceMu.Unlock()
+	if h.calendar == nil {
+		return
+	}
+
+	current, err := h.calendar.AgentsInMaintenance(ctx, time.Now())
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to read the maintenance calendar: %v", err)
+		return
+	}
+	ctx = domain.WithActor(ctx, domain.ActorSystem)
+
+	changed := false
+	for agentID, maintenance := range current {
+		if _, ok := h.inMaintenance[agentID]; ok {
+			continue
+		}
+		drained, err := h.dispatcher.DrainAgent(ctx, agentID, "agent entered maintenance window "+maintenance.Window)
+		if err != nil {
+			agentLogger(ctx, agentID).Error("Failed to drain agent for maintenance window %s: %v", maintenance.Window, err)
+			continue
+		}
+		agentLogger(ctx, agentID).Info("Agent entered maintenance window %s until %s, %d queued jobs handed back",
+			maintenance.Window, maintenance.Until.Format(time.RFC3339), drained)
+		h.inMaintenance[agentID] = maintenance
+		changed = changed || drained > 0
+	}
+	for agentID, maintenance := range h.inMaintenance {
+		if _, ok := current[agentID]; ok {
+			continue
+		}
+		agentLogger(ctx, agentID).Info("Agent left maintenance window %s, resuming", maintenance.Window)
+		delete(h.inMaintenance, agentID)
+		changed = true
+	}
+
+	if changed {
+		if err := h.dispatcher.AssignJobsToAgents(ctx); err != nil {
+			infrastructure.ServerLogger.Error("Failed to dispatch jobs after maintenance window changes: %v", err)
+		}
+	}
+}
+
+// thresholds scales the status thresholds to the heartbeat interval agreed
+// with the agent. AgentTimeout is the floor for going offline.
+func (h *agentHealthMonitor) thresholds(agentID uuid.UUID) heartbeatThresholds {
+	interval := h.agentUsecase.AgentHeartbeatInterval(agentID)
+
+	limits := heartbeatThresholds{
+		degraded: time.Duration(h.config.DegradedAfterMissed)*interval + h.config.HeartbeatGrace,
+		offline:  time.Duration(h.config.OfflineAfterMissed)*interval + h.config.HeartbeatGrace,
+		recover:  interval + h.config.HeartbeatGrace,
+	}
+	if limits.offline < h.config.AgentTimeout {
+		limits.offline = h.config.AgentTimeout
+	}
+	if limits.degraded > limits.offline {
+		limits.degraded = limits.offline
+	}
+	return limits
+}
+
+// degradedAgents lists the connected agents whose heartbeats are late or
+// whose last heartbeat snapshot reports a problem, warning once when an
+// agent turns degraded
+func (h *agentHealthMonitor) degradedAgents(agents []domain.Agent) []DegradedAgent {
+	h.mu.RLock()
+	previous := make(map[uuid.UUID]bool, len(h.healthStatus.DegradedAgents))
+	for _, agent := range h.healthStatus.DegradedAgents {
+		previous[agent.ID] = true
+	}
+	h.mu.RUnlock()
+
+	var degraded []DegradedAgent
+	for _, agent := range agents {
+		limits := h.thresholds(agent.ID)
+		since := time.Since(agent.LastSeen)
+		if since > limits.offline {
+			continue // Offline agents are counted as such
+		}
+
+		var problems []string
+		if since > limits.degraded || (agent.Status == "degraded" && since > limits.recover) {
+			problems = append(problems, fmt.Sprintf("heartbeats late: last seen %v ago", since.Round(time.Second)))
+		}
+		problems = append(problems, agent.Heartbeat.Problems(h.config.MinFreeDiskBytes)...)
+		if len(problems) == 0 {
+			continue
+		}
+
+		if !previous[agent.ID] {
+			infrastructure.ServerLogger.With("agent_id", agent.ID).Warning("Agent %s is degraded: %s", agent.Name, strings.Join(problems, ", "))
+		}
+		degraded = append(degraded, DegradedAgent{ID: agent.ID, Name: agent.Name, Problems: problems})
+	}
+	return degraded
+}
+
+func (h *agentHealthMonitor) updateHealthStatus(online, offline, recentlyOffline, errors int, groups []domain.AgentGroupSummary, degraded []DegradedAgent) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -272,5 +462,7 @@ func (h *agentHealthMonitor) updateHealthStatus(online, offline, recentlyOffline
 		RecentlyOffline:   recentlyOffline,
 		LastHealthCheck:   time.Now(),
 		HealthCheckErrors: errors,
+		Groups:            groups,
+		DegradedAgents:    degraded,
 	}
 }
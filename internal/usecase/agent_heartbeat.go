@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// HeartbeatConfig controls how often agents send heartbeats and how often
+// the server writes them to the database
+type HeartbeatConfig struct {
+	Interval      time.Duration // Given to agents that don't ask for one (default: 5s)
+	MinInterval   time.Duration // Shortest interval an agent may ask for (default: 1s)
+	MaxInterval   time.Duration // Longest interval an agent may ask for (default: 1 minute)
+	FlushInterval time.Duration // How often buffered last_seen values are written (default: 5s)
+	// Buffered heartbeats that trigger a write before the flush interval is
+	// up (default: 500)
+	FlushThreshold int
+}
+
+func (c HeartbeatConfig) withDefaults() HeartbeatConfig {
+	if c.MinInterval <= 0 {
+		c.MinInterval = time.Second
+	}
+	if c.MaxInterval <= 0 {
+		c.MaxInterval = time.Minute
+	}
+	if c.MaxInterval < c.MinInterval {
+		c.MaxInterval = c.MinInterval
+	}
+	if c.Interval <= 0 {
+		c.Interval = 5 * time.Second
+	}
+	c.Interval = c.clamp(c.Interval)
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 5 * time.Second
+	}
+	if c.FlushThreshold <= 0 {
+		c.FlushThreshold = 500
+	}
+	return c
+}
+
+func (c HeartbeatConfig) clamp(interval time.Duration) time.Duration {
+	if interval < c.MinInterval {
+		return c.MinInterval
+	}
+	if interval > c.MaxInterval {
+		return c.MaxInterval
+	}
+	return interval
+}
+
+func (u *agentUsecase) SetHeartbeatConfig(config HeartbeatConfig) {
+	u.seenMu.Lock()
+	defer u.seenMu.Unlock()
+	u.heartbeatConfig = config.withDefaults()
+}
+
+// AcceptAgentHeartbeat buffers an agent's heartbeat for the next flush,
+// broadcasts its new last seen time and returns the interval the agent
+// should send heartbeats at: the one it asked for within the configured
+// bounds, or the server's default when it asked for none
+func (u *agentUsecase) AcceptAgentHeartbeat(ctx context.Context, id uuid.UUID, requested time.Duration, heartbeat *domain.AgentHeartbeat) time.Duration {
+	now := time.Now()
+	interval := u.bufferHeartbeat(id, now, requested, heartbeat)
+
+	if u.wsHub != nil {
+		if agent, err := u.agentRepo.GetByID(ctx, id); err == nil {
+			u.wsHub.BroadcastAgentStatus(id.String(), agent.Status, now.Format(time.RFC3339))
+		}
+	}
+	return interval
+}
+
+func (u *agentUsecase) bufferHeartbeat(id uuid.UUID, now time.Time, requested time.Duration, heartbeat *domain.AgentHeartbeat) time.Duration {
+	u.seenMu.Lock()
+	defer u.seenMu.Unlock()
+
+	seen := domain.AgentSeen{LastSeen: now, Heartbeat: u.pendingSeen[id].Heartbeat}
+	if heartbeat != nil {
+		stored := *heartbeat
+		stored.ReportedAt = now
+		seen.Heartbeat = &stored
+	}
+	u.pendingSeen[id] = seen
+	if len(u.pendingSeen) >= u.heartbeatConfig.FlushThreshold {
+		select {
+		case u.seenFull <- struct{}{}:
+		default: // A flush is already due
+		}
+	}
+
+	interval := u.heartbeatConfig.Interval
+	if requested > 0 {
+		interval = u.heartbeatConfig.clamp(requested)
+	}
+	u.intervals[id] = interval
+	return interval
+}
+
+// AgentHeartbeatInterval returns the interval agreed with an agent, or the
+// server's default for agents that haven't sent a heartbeat since startup
+func (u *agentUsecase) AgentHeartbeatInterval(id uuid.UUID) time.Duration {
+	u.seenMu.Lock()
+	defer u.seenMu.Unlock()
+
+	if interval, ok := u.intervals[id]; ok {
+		return interval
+	}
+	return u.heartbeatConfig.Interval
+}
+
+// FlushAgentHeartbeats writes the buffered heartbeats in one batch. They
+// were broadcast when they arrived. Heartbeats of a failed batch are kept
+// for the next flush unless newer ones arrived meanwhile.
+func (u *agentUsecase) FlushAgentHeartbeats(ctx context.Context) error {
+	u.seenMu.Lock()
+	seen := u.pendingSeen
+	u.pendingSeen = make(map[uuid.UUID]domain.AgentSeen)
+	u.seenMu.Unlock()
+
+	if len(seen) == 0 {
+		return nil
+	}
+
+	if err := u.agentRepo.UpdateLastSeenBatch(ctx, seen); err != nil {
+		u.seenMu.Lock()
+		for id, s := range seen {
+			if _, newer := u.pendingSeen[id]; !newer {
+				u.pendingSeen[id] = s
+			}
+		}
+		u.seenMu.Unlock()
+		return err
+	}
+
+	// A heartbeat shows the agent still holds its jobs
+	if u.leaser != nil {
+		ids := make([]uuid.UUID, 0, len(seen))
+		for id := range seen {
+			ids = append(ids, id)
+		}
+		if err := u.leaser.RenewJobLeases(ctx, ids); err != nil {
+			infrastructure.ServerLogger.Warning("%v", err)
+		}
+	}
+	return nil
+}
+
+// RunHeartbeatFlusher flushes buffered heartbeats every flush interval, or
+// sooner when the buffer reaches the flush threshold, until ctx is done. It
+// then flushes once more so no heartbeat is lost on shutdown.
+func (u *agentUsecase) RunHeartbeatFlusher(ctx context.Context) {
+	u.seenMu.Lock()
+	interval := u.heartbeatConfig.FlushInterval
+	u.seenMu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := u.FlushAgentHeartbeats(context.Background()); err != nil {
+				infrastructure.ServerLogger.Error("Failed to write agent heartbeats on shutdown: %v", err)
+			}
+			return
+		case <-ticker.C:
+		case <-u.seenFull:
+		}
+		if err := u.FlushAgentHeartbeats(ctx); err != nil {
+			infrastructure.ServerLogger.Error("Failed to write agent heartbeats: %v", err)
+		}
+	}
+}
+
+// withPendingSeen overlays heartbeats that are not written yet, so readers
+// never see a last seen time older than the agent's latest heartbeat
+func (u *agentUsecase) withPendingSeen(agents ...*domain.Agent) {
+	u.seenMu.Lock()
+	defer u.seenMu.Unlock()
+
+	for _, agent := range agents {
+		seen, ok := u.pendingSeen[agent.ID]
+		if !ok {
+			continue
+		}
+		if seen.LastSeen.After(agent.LastSeen) {
+			agent.LastSeen = seen.LastSeen
+		}
+		if seen.Heartbeat != nil {
+			agent.Heartbeat = seen.Heartbeat
+			if seen.Heartbeat.HashcatVersion != "" {
+				agent.HashcatVersion = seen.Heartbeat.HashcatVersion
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Limits on shipped agent logs
+const (
+	defaultAgentLogRetention = 5000 // Lines kept per agent
+	maxAgentLogBatch         = 1000 // Lines accepted in one request
+	maxAgentLogLineLength    = 4096 // Longer lines are cut
+	defaultAgentLogLimit     = 200
+	maxAgentLogLimit         = 1000
+)
+
+// SetAgentLogRetention sets how many log lines are kept per agent, the
+// default for lines <= 0
+func (u *agentUsecase) SetAgentLogRetention(lines int) {
+	if lines <= 0 {
+		lines = defaultAgentLogRetention
+	}
+	u.logMu.Lock()
+	u.logRetention = lines
+	u.logMu.Unlock()
+}
+
+// AppendAgentLogs stores log lines an agent shipped and pushes them to
+// clients tailing the agent's log
+func (u *agentUsecase) AppendAgentLogs(ctx context.Context, id uuid.UUID, lines []domain.AgentLogLine) error {
+	if len(lines) > maxAgentLogBatch {
+		return &domain.ValidationError{Field: "lines", Message: fmt.Sprintf("at most %d lines per request", maxAgentLogBatch)}
+	}
+	if _, err := u.agentRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range lines {
+		line := &lines[i]
+		switch line.Source {
+		case "":
+			line.Source = domain.AgentLogSourceAgent
+		case domain.AgentLogSourceAgent, domain.AgentLogSourceHashcat:
+		default:
+			return &domain.ValidationError{Field: "source", Message: fmt.Sprintf("unknown log source %q", line.Source)}
+		}
+		if len(line.Message) > maxAgentLogLineLength {
+			line.Message = strings.ToValidUTF8(line.Message[:maxAgentLogLineLength], "")
+		}
+		if line.Time.IsZero() {
+			line.Time = now
+		}
+		line.AgentID = id
+	}
+
+	u.logMu.Lock()
+	keep := u.logRetention
+	u.logMu.Unlock()
+
+	if err := u.agentRepo.AppendLogs(ctx, id, lines, keep); err != nil {
+		return err
+	}
+
+	if u.wsHub != nil {
+		u.wsHub.BroadcastAgentLogs(id.String(), lines)
+	}
+	return nil
+}
+
+// GetAgentLogs returns an agent's stored log lines after afterID, or its
+// newest lines when afterID is 0
+func (u *agentUsecase) GetAgentLogs(ctx context.Context, id uuid.UUID, afterID int64, limit int) ([]domain.AgentLogLine, error) {
+	if _, err := u.agentRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultAgentLogLimit
+	}
+	if limit > maxAgentLogLimit {
+		limit = maxAgentLogLimit
+	}
+	return u.agentRepo.GetLogs(ctx, id, afterID, limit)
+}
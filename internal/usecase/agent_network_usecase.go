@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// agentNetworkRulesTTL bounds how long a rule changed straight in the
+	// database takes to apply
+	agentNetworkRulesTTL = 30 * time.Second
+	// maxAccessDenials is how many access denials the audit log keeps
+	maxAccessDenials = 10000
+	// defaultAccessDenials is how many denials GetDenials returns by default
+	defaultAccessDenials = 100
+)
+
+// AgentNetworkUsecase manages the address ranges agents may connect from
+// and checks agent requests against them
+type AgentNetworkUsecase interface {
+	// CreateRule adds a rule. A bare address is stored as a /32 or /128
+	// range, and an agent key is resolved to the agent's ID.
+	CreateRule(ctx context.Context, req *domain.CreateAgentNetworkRuleRequest) (*domain.AgentNetworkRule, error)
+	// GetRules returns the rules from the server configuration followed by
+	// the stored ones
+	GetRules(ctx context.Context) ([]domain.AgentNetworkRule, error)
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	GetDenials(ctx context.Context, limit int) ([]domain.AgentAccessDenial, error)
+	// Check returns domain.ErrAgentNetworkDenied, and records the denial,
+	// when the rules keep the request's address out
+	Check(ctx context.Context, check domain.AgentNetworkCheck) error
+}
+
+// networkRule is a rule with its range parsed
+type networkRule struct {
+	agentID *uuid.UUID
+	network *net.IPNet
+	action  string
+}
+
+type agentNetworkUsecase struct {
+	networkRepo domain.AgentNetworkRepository
+	agentRepo   domain.AgentRepository
+	static      []domain.AgentNetworkRule
+
+	mu       sync.RWMutex
+	rules    []networkRule
+	loadedAt time.Time
+}
+
+// NewAgentNetworkUsecase returns an AgentNetworkUsecase that applies the
+// static rules from the server configuration on top of the stored ones.
+// Static rules must have valid ranges, see ParseAgentNetworkRules.
+func NewAgentNetworkUsecase(networkRepo domain.AgentNetworkRepository, agentRepo domain.AgentRepository, static []domain.AgentNetworkRule) AgentNetworkUsecase {
+	return &agentNetworkUsecase{
+		networkRepo: networkRepo,
+		agentRepo:   agentRepo,
+		static:      static,
+	}
+}
+
+// ParseNetworks parses a list of CIDR ranges or single addresses
+func ParseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		network, err := parseNetwork(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ParseAgentNetworkRules turns a list of ranges from the server
+// configuration into global rules with the given action
+func ParseAgentNetworkRules(cidrs []string, action string) ([]domain.AgentNetworkRule, error) {
+	networks, err := ParseNetworks(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]domain.AgentNetworkRule, 0, len(networks))
+	for _, network := range networks {
+		rules = append(rules, domain.AgentNetworkRule{
+			CIDR:   network.String(),
+			Action: action,
+			Static: true,
+		})
+	}
+	return rules, nil
+}
+
+func (u *agentNetworkUsecase) CreateRule(ctx context.Context, req *domain.CreateAgentNetworkRuleRequest) (*domain.AgentNetworkRule, error) {
+	network, err := parseNetwork(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	if req.Action != domain.NetworkRuleAllow && req.Action != domain.NetworkRuleDeny {
+		return nil, &domain.ValidationError{Field: "action", Message: "must be allow or deny"}
+	}
+
+	rule := &domain.AgentNetworkRule{
+		AgentID:     req.AgentID,
+		CIDR:        network.String(),
+		Action:      req.Action,
+		Description: strings.TrimSpace(req.Description),
+	}
+	switch {
+	case req.AgentID != nil && req.AgentKey != "":
+		return nil, &domain.ValidationError{Field: "agent_key", Message: "give either agent_id or agent_key"}
+	case req.AgentID != nil:
+		if _, err := u.agentRepo.GetByID(ctx, *req.AgentID); err != nil {
+			return nil, err
+		}
+	case req.AgentKey != "":
+		agent, err := u.agentRepo.GetByAgentKey(ctx, req.AgentKey)
+		if err != nil {
+			return nil, err
+		}
+		rule.AgentID = &agent.ID
+	}
+
+	if err := u.networkRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	u.invalidate()
+	return rule, nil
+}
+
+func (u *agentNetworkUsecase) GetRules(ctx context.Context) ([]domain.AgentNetworkRule, error) {
+	stored, err := u.networkRepo.GetRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]domain.AgentNetworkRule{}, u.static...), stored...), nil
+}
+
+func (u *agentNetworkUsecase) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	if err := u.networkRepo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	u.invalidate()
+	return nil
+}
+
+func (u *agentNetworkUsecase) GetDenials(ctx context.Context, limit int) ([]domain.AgentAccessDenial, error) {
+	if limit <= 0 {
+		limit = defaultAccessDenials
+	}
+	if limit > maxAccessDenials {
+		limit = maxAccessDenials
+	}
+	return u.networkRepo.GetDenials(ctx, limit)
+}
+
+func (u *agentNetworkUsecase) Check(ctx context.Context, check domain.AgentNetworkCheck) error {
+	rules, err := u.loadRules(ctx)
+	if err != nil {
+		// A broken rule store must not lock every agent out
+		infrastructure.ServerLogger.Error("Failed to load agent network rules: %v", err)
+		return nil
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	ip := net.ParseIP(check.IPAddress)
+	if ip == nil {
+		return u.deny(ctx, check, nil, "unparseable client address")
+	}
+
+	// The agent only matters when it has rules of its own
+	var agent *domain.Agent
+	agentID := check.AgentID
+	if check.AgentKey != "" && hasAgentRules(rules) {
+		if found, err := u.agentRepo.GetByAgentKey(ctx, check.AgentKey); err == nil {
+			agent = found
+			agentID = &found.ID
+		}
+	}
+
+	var globalAllows, agentAllows int
+	var globalAllowed, agentAllowed bool
+	for _, rule := range rules {
+		if rule.agentID != nil && (agentID == nil || *rule.agentID != *agentID) {
+			continue
+		}
+		matches := rule.network.Contains(ip)
+		if rule.action == domain.NetworkRuleDeny {
+			if matches {
+				return u.deny(ctx, check, agent, "address matches deny rule "+rule.network.String())
+			}
+			continue
+		}
+		if rule.agentID != nil {
+			agentAllows++
+			agentAllowed = agentAllowed || matches
+		} else {
+			globalAllows++
+			globalAllowed = globalAllowed || matches
+		}
+	}
+
+	switch {
+	case agentAllows > 0 && !agentAllowed:
+		return u.deny(ctx, check, agent, "address is not in the agent's allowlist")
+	case agentAllows == 0 && globalAllows > 0 && !globalAllowed:
+		return u.deny(ctx, check, agent, "address is not in the allowlist")
+	}
+	return nil
+}
+
+// deny records a refused request and returns ErrAgentNetworkDenied
+func (u *agentNetworkUsecase) deny(ctx context.Context, check domain.AgentNetworkCheck, agent *domain.Agent, reason string) error {
+	denial := &domain.AgentAccessDenial{
+		IPAddress: check.IPAddress,
+		AgentID:   check.AgentID,
+		Path:      check.Path,
+		Reason:    reason,
+	}
+	if agent == nil && check.AgentKey != "" {
+		agent, _ = u.agentRepo.GetByAgentKey(ctx, check.AgentKey)
+	}
+	if agent == nil && check.AgentID != nil {
+		agent, _ = u.agentRepo.GetByID(ctx, *check.AgentID)
+	}
+	switch {
+	case agent != nil:
+		denial.AgentID = &agent.ID
+		denial.AgentName = agent.Name
+	case check.AgentKey != "":
+		// Don't keep keys that could be someone's real key mistyped
+		denial.AgentKey = maskAgentKey(check.AgentKey)
+	}
+
+	infrastructure.ServerLogger.Warning("Agent request to %s from %s refused: %s", check.Path, check.IPAddress, reason)
+	if err := u.networkRepo.RecordDenial(ctx, denial, maxAccessDenials); err != nil {
+		infrastructure.ServerLogger.Error("Failed to record agent access denial: %v", err)
+	}
+	return domain.ErrAgentNetworkDenied
+}
+
+// loadRules returns the parsed static and stored rules, reloading the
+// stored ones once they are older than agentNetworkRulesTTL
+func (u *agentNetworkUsecase) loadRules(ctx context.Context) ([]networkRule, error) {
+	u.mu.RLock()
+	if !u.loadedAt.IsZero() && time.Since(u.loadedAt) < agentNetworkRulesTTL {
+		rules := u.rules
+		u.mu.RUnlock()
+		return rules, nil
+	}
+	u.mu.RUnlock()
+
+	all, err := u.GetRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]networkRule, 0, len(all))
+	for _, rule := range all {
+		_, network, err := net.ParseCIDR(rule.CIDR)
+		if err != nil {
+			infrastructure.ServerLogger.Warning("Skipping network rule %s with invalid range %q", rule.ID, rule.CIDR)
+			continue
+		}
+		rules = append(rules, networkRule{agentID: rule.AgentID, network: network, action: rule.Action})
+	}
+
+	u.mu.Lock()
+	u.rules = rules
+	u.loadedAt = time.Now()
+	u.mu.Unlock()
+	return rules, nil
+}
+
+func (u *agentNetworkUsecase) invalidate() {
+	u.mu.Lock()
+	u.loadedAt = time.Time{}
+	u.mu.Unlock()
+}
+
+func hasAgentRules(rules []networkRule) bool {
+	for _, rule := range rules {
+		if rule.agentID != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetwork parses a CIDR range, or a single address as a /32 or /128
+func parseNetwork(cidr string) (*net.IPNet, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, &domain.ValidationError{Field: "cidr", Message: fmt.Sprintf("%q is not an address or CIDR range", cidr)}
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "cidr", Message: fmt.Sprintf("%q is not an address or CIDR range", cidr)}
+	}
+	return network, nil
+}
+
+// maskAgentKey keeps the first and last characters of a key
+func maskAgentKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:2] + strings.Repeat("*", len(key)-4) + key[len(key)-2:]
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// SetAgentRetention sets the policy stale agents are judged by when a
+// request doesn't say how long an agent may stay away
+func (u *agentUsecase) SetAgentRetention(policy domain.AgentRetentionPolicy) error {
+	if policy.Action == "" {
+		policy.Action = domain.AgentRetentionFlag
+	}
+	switch policy.Action {
+	case domain.AgentRetentionFlag, domain.AgentRetentionArchive, domain.AgentRetentionDelete:
+	default:
+		return &domain.ValidationError{Field: "action", Message: fmt.Sprintf("unknown agent retention action %q, expected flag, archive or delete", policy.Action)}
+	}
+	if policy.StaleAfter < 0 {
+		return &domain.ValidationError{Field: "stale_after", Message: "must not be negative"}
+	}
+	u.retentionMu.Lock()
+	u.retention = policy
+	u.retentionMu.Unlock()
+	return nil
+}
+
+// GetStaleAgents returns the agents not seen for olderThan, the retention
+// policy's stale period when zero
+func (u *agentUsecase) GetStaleAgents(ctx context.Context, olderThan time.Duration) ([]domain.StaleAgent, error) {
+	cutoff, err := u.staleCutoff(olderThan)
+	if err != nil {
+		return nil, err
+	}
+	return u.agentRepo.GetStale(ctx, cutoff)
+}
+
+// PurgeStaleAgents archives or deletes stale agents. Agents that still have
+// assigned, running or paused jobs are skipped, as are agents already
+// archived when archiving.
+func (u *agentUsecase) PurgeStaleAgents(ctx context.Context, req *domain.PurgeStaleAgentsRequest) (*domain.PurgeStaleAgentsResult, error) {
+	action := req.Action
+	switch action {
+	case "":
+		action = domain.AgentRetentionDelete
+	case domain.AgentRetentionArchive, domain.AgentRetentionDelete:
+	default:
+		return nil, &domain.ValidationError{Field: "action", Message: "must be archive or delete"}
+	}
+	if req.OlderThanDays < 0 {
+		return nil, &domain.ValidationError{Field: "older_than_days", Message: "must not be negative"}
+	}
+	cutoff, err := u.staleCutoff(time.Duration(req.OlderThanDays) * 24 * time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	stale, err := u.agentRepo.GetStale(ctx, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale agents: %w", err)
+	}
+
+	var selected map[uuid.UUID]bool
+	if len(req.AgentIDs) > 0 {
+		selected = make(map[uuid.UUID]bool, len(req.AgentIDs))
+		for _, id := range req.AgentIDs {
+			selected[id] = true
+		}
+	}
+
+	result := &domain.PurgeStaleAgentsResult{
+		Action:  action,
+		Cutoff:  cutoff,
+		DryRun:  req.DryRun,
+		Purged:  []domain.StaleAgent{},
+		Skipped: []domain.StaleAgent{},
+	}
+	for _, agent := range stale {
+		if selected != nil && !selected[agent.ID] {
+			continue
+		}
+		if action == domain.AgentRetentionArchive && agent.ArchivedAt != nil {
+			continue
+		}
+		if agent.ActiveJobs > 0 {
+			result.Skipped = append(result.Skipped, agent)
+			continue
+		}
+		if !req.DryRun {
+			if err := u.purgeAgent(ctx, agent.ID, action); err != nil {
+				return nil, fmt.Errorf("failed to %s agent %s: %w", action, agent.Name, err)
+			}
+			agentLogger(ctx, agent.ID).Info("Agent %s not seen since %s was %sd", agent.Name, agent.LastSeen.Format(time.RFC3339), action)
+		}
+		result.Purged = append(result.Purged, agent)
+	}
+	return result, nil
+}
+
+// staleCutoff returns the time agents must have been seen since, falling
+// back to the retention policy when olderThan is zero
+func (u *agentUsecase) staleCutoff(olderThan time.Duration) (time.Time, error) {
+	if olderThan == 0 {
+		u.retentionMu.Lock()
+		olderThan = u.retention.StaleAfter
+		u.retentionMu.Unlock()
+	}
+	if olderThan <= 0 {
+		return time.Time{}, &domain.ValidationError{Field: "older_than_days", Message: "required when no agent retention period is configured"}
+	}
+	return time.Now().Add(-olderThan), nil
+}
+
+func (u *agentUsecase) purgeAgent(ctx context.Context, id uuid.UUID, action string) error {
+	if action == domain.AgentRetentionDelete {
+		return u.DeleteAgent(ctx, id)
+	}
+	if err := u.agentRepo.Archive(ctx, id); err != nil {
+		return err
+	}
+	u.invalidateLookup(id)
+	u.cacheMu.Lock()
+	delete(u.cacheReports, id)
+	u.cacheMu.Unlock()
+	return nil
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// AgentRetentionWorker periodically applies the agent retention policy:
+// stale agents are logged, archived or deleted
+type AgentRetentionWorker interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context)
+}
+
+type agentRetentionWorker struct {
+	agentUsecase  AgentUsecase
+	policy        domain.AgentRetentionPolicy
+	checkInterval time.Duration
+	ticker        *time.Ticker
+	done          chan struct{}
+}
+
+func NewAgentRetentionWorker(agentUsecase AgentUsecase, policy domain.AgentRetentionPolicy, checkInterval time.Duration) AgentRetentionWorker {
+	if checkInterval == 0 {
+		checkInterval = time.Hour
+	}
+
+	return &agentRetentionWorker{
+		agentUsecase:  agentUsecase,
+		policy:        policy,
+		checkInterval: checkInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (w *agentRetentionWorker) Start(ctx context.Context) {
+	if w.policy.StaleAfter <= 0 {
+		infrastructure.ServerLogger.Info("Agent retention is off")
+		return
+	}
+	infrastructure.ServerLogger.Info("Starting Agent Retention Worker (interval: %v, stale after: %v, action: %s)",
+		w.checkInterval, w.policy.StaleAfter, w.policy.Action)
+
+	w.ticker = time.NewTicker(w.checkInterval)
+
+	go func() {
+		w.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-w.ticker.C:
+				w.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (w *agentRetentionWorker) Stop() {
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	select {
+	case <-w.done:
+		// Channel already closed
+	default:
+		close(w.done)
+	}
+}
+
+// RunOnce applies the agent retention policy a single time
+func (w *agentRetentionWorker) RunOnce(ctx context.Context) {
+	if w.policy.StaleAfter <= 0 {
+		return
+	}
+
+	if w.policy.Action == domain.AgentRetentionArchive || w.policy.Action == domain.AgentRetentionDelete {
+		result, err := w.agentUsecase.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{Action: w.policy.Action})
+		if err != nil {
+			infrastructure.ServerLogger.Error("Failed to purge stale agents: %v", err)
+			return
+		}
+		if len(result.Purged) > 0 {
+			infrastructure.ServerLogger.Info("Agent retention: %sd %d agents not seen for %v", result.Action, len(result.Purged), w.policy.StaleAfter)
+		}
+		if len(result.Skipped) > 0 {
+			infrastructure.ServerLogger.Warning("Agent retention: kept %d stale agents that still have jobs", len(result.Skipped))
+		}
+		return
+	}
+
+	stale, err := w.agentUsecase.GetStaleAgents(ctx, w.policy.StaleAfter)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to get stale agents: %v", err)
+		return
+	}
+	flagged := 0
+	for _, agent := range stale {
+		if agent.ArchivedAt == nil {
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		infrastructure.ServerLogger.Info("Agent retention: %d agents not seen for %v, see GET /api/v1/agents/stale", flagged, w.policy.StaleAfter)
+	}
+}
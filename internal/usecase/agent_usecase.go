@@ -6,12 +6,14 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func generateAgentKey() (string, error) {
@@ -42,16 +44,79 @@ type AgentUsecase interface {
 	UpdateAgent(ctx context.Context, agent *domain.Agent) error
 	UpdateAgentData(ctx context.Context, agentKey string, ipAddress string, port int, capabilities string) error
 	GenerateAgentKey(ctx context.Context, name, agentKey string) (*domain.Agent, error)
+	UpdateAgentCacheReport(ctx context.Context, id uuid.UUID, report *domain.AgentCacheReport) error
+	GetAgentCacheReport(ctx context.Context, id uuid.UUID) (*domain.AgentCacheReport, error)
+	ReplaceAgentFiles(ctx context.Context, id uuid.UUID, files []domain.AgentFile) error
+	GetAgentFiles(ctx context.Context, id uuid.UUID) ([]domain.AgentFile, error)
+	GetAgentHashcatArgs(ctx context.Context, id uuid.UUID) ([]string, error)
+	SetAgentHashcatArgs(ctx context.Context, id uuid.UUID, args []string) error
+	GetAgentSettings(ctx context.Context, id uuid.UUID) (*domain.AgentSettings, error)
+	SetAgentSettings(ctx context.Context, id uuid.UUID, settings *domain.AgentSettings) error
+	SetHeartbeatConfig(config HeartbeatConfig)
+	AcceptAgentHeartbeat(ctx context.Context, id uuid.UUID, requested time.Duration, heartbeat *domain.AgentHeartbeat) time.Duration
+	AgentHeartbeatInterval(id uuid.UUID) time.Duration
+	FlushAgentHeartbeats(ctx context.Context) error
+	RunHeartbeatFlusher(ctx context.Context)
+	SetAgentLogRetention(lines int)
+	AppendAgentLogs(ctx context.Context, id uuid.UUID, lines []domain.AgentLogLine) error
+	GetAgentLogs(ctx context.Context, id uuid.UUID, afterID int64, limit int) ([]domain.AgentLogLine, error)
+	CreateAgentGroup(ctx context.Context, req *domain.CreateAgentGroupRequest) (*domain.AgentGroupSummary, error)
+	GetAgentGroup(ctx context.Context, id uuid.UUID) (*domain.AgentGroupSummary, error)
+	GetAllAgentGroups(ctx context.Context) ([]domain.AgentGroupSummary, error)
+	DeleteAgentGroup(ctx context.Context, id uuid.UUID) error
+	AddAgentToGroup(ctx context.Context, groupID, agentID uuid.UUID) error
+	RemoveAgentFromGroup(ctx context.Context, groupID, agentID uuid.UUID) error
+	GetOnlineGroupAgents(ctx context.Context, groupID uuid.UUID) ([]domain.Agent, error)
+	// SetMaintenanceCalendar marks agents in a maintenance window and
+	// leaves them out of the agents available for new jobs
+	SetMaintenanceCalendar(calendar domain.MaintenanceCalendar)
+	// SetJobLeaser makes each heartbeat batch renew the leases on the jobs
+	// of the agents in it
+	SetJobLeaser(leaser domain.JobLeaser)
+	// SetLookupCache makes changes to agents invalidate their cached lookups
+	SetLookupCache(lookups LookupCache)
+	// SetAgentRetention sets how long agents may stay away before they are stale
+	SetAgentRetention(policy domain.AgentRetentionPolicy) error
+	GetStaleAgents(ctx context.Context, olderThan time.Duration) ([]domain.StaleAgent, error)
+	PurgeStaleAgents(ctx context.Context, req *domain.PurgeStaleAgentsRequest) (*domain.PurgeStaleAgentsResult, error)
 }
 
 type agentUsecase struct {
-	agentRepo domain.AgentRepository
-	wsHub     WebSocketHub
+	agentRepo   domain.AgentRepository
+	wsHub       WebSocketHub
+	maintenance domain.MaintenanceCalendar // Optional, agents in a maintenance window take no new jobs
+	leaser      domain.JobLeaser           // Optional, renews the job leases of agents that are in touch
+	lookups     LookupCache                // Optional, invalidated when agents change
+
+	// Latest download cache report per agent. Kept in memory only: agents
+	// resend it periodically, so it is rebuilt shortly after a restart.
+	cacheMu      sync.RWMutex
+	cacheReports map[uuid.UUID]domain.AgentCacheReport
+
+	// Heartbeats waiting for the next batched write, and the heartbeat
+	// interval agreed with each agent
+	seenMu          sync.Mutex
+	heartbeatConfig HeartbeatConfig
+	pendingSeen     map[uuid.UUID]domain.AgentSeen
+	intervals       map[uuid.UUID]time.Duration
+	seenFull        chan struct{} // Signalled when the flush threshold is reached
+
+	logMu        sync.Mutex
+	logRetention int // Log lines kept per agent
+
+	retentionMu sync.Mutex
+	retention   domain.AgentRetentionPolicy // When agents are stale, off by default
 }
 
 func NewAgentUsecase(agentRepo domain.AgentRepository) AgentUsecase {
 	return &agentUsecase{
-		agentRepo: agentRepo,
+		agentRepo:       agentRepo,
+		cacheReports:    make(map[uuid.UUID]domain.AgentCacheReport),
+		heartbeatConfig: HeartbeatConfig{}.withDefaults(),
+		pendingSeen:     make(map[uuid.UUID]domain.AgentSeen),
+		intervals:       make(map[uuid.UUID]time.Duration),
+		seenFull:        make(chan struct{}, 1),
+		logRetention:    defaultAgentLogRetention,
 	}
 }
 
@@ -59,7 +124,28 @@ func (u *agentUsecase) SetWebSocketHub(wsHub WebSocketHub) {
 	u.wsHub = wsHub
 }
 
+func (u *agentUsecase) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar) {
+	u.maintenance = calendar
+}
+
+func (u *agentUsecase) SetJobLeaser(leaser domain.JobLeaser) {
+	u.leaser = leaser
+}
+
+func (u *agentUsecase) SetLookupCache(lookups LookupCache) {
+	u.lookups = lookups
+}
+
+func (u *agentUsecase) invalidateLookup(id uuid.UUID) {
+	if u.lookups != nil {
+		u.lookups.InvalidateAgent(id)
+	}
+}
+
 func (u *agentUsecase) RegisterAgent(ctx context.Context, req *domain.CreateAgentRequest) (*domain.Agent, error) {
+	ctx, span := startSpan(ctx, "AgentUsecase.RegisterAgent")
+	defer span.End()
+
 	// ✅ Validation 1: Check if agent key exists in database
 	if req.AgentKey == "" {
 		return nil, fmt.Errorf("agent key is required")
@@ -115,7 +201,7 @@ func (u *agentUsecase) RegisterAgent(ctx context.Context, req *domain.CreateAgen
 			// Update last seen to ensure consistency
 			if err := u.agentRepo.UpdateLastSeen(ctx, existingAgentByName.ID); err != nil {
 				// Log error but don't fail the request
-				log.Printf("Failed to update agent last seen: %v", err)
+				agentLogger(ctx, existingAgentByName.ID).Error("Failed to update agent last seen: %v", err)
 			}
 
 			// Don't broadcast status update when creating/updating agent
@@ -162,11 +248,34 @@ func (u *agentUsecase) RegisterAgent(ctx context.Context, req *domain.CreateAgen
 }
 
 func (u *agentUsecase) GetAgent(ctx context.Context, id uuid.UUID) (*domain.Agent, error) {
-	return u.agentRepo.GetByID(ctx, id)
+	agent, err := u.agentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.withPendingSeen(agent)
+	if maintenance, ok := agentsInMaintenance(ctx, u.maintenance)[agent.ID]; ok {
+		agent.Maintenance = &maintenance
+	}
+	return agent, nil
 }
 
 func (u *agentUsecase) GetAllAgents(ctx context.Context) ([]domain.Agent, error) {
-	return u.agentRepo.GetAll(ctx)
+	cached, err := u.agentRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy before the overlay, the repository may hand out its cached slice
+	agents := make([]domain.Agent, len(cached))
+	copy(agents, cached)
+	inMaintenance := agentsInMaintenance(ctx, u.maintenance)
+	for i := range agents {
+		u.withPendingSeen(&agents[i])
+		if maintenance, ok := inMaintenance[agents[i].ID]; ok {
+			agents[i].Maintenance = &maintenance
+		}
+	}
+	return agents, nil
 }
 
 func (u *agentUsecase) UpdateAgentStatus(ctx context.Context, id uuid.UUID, status string) error {
@@ -178,23 +287,36 @@ func (u *agentUsecase) UpdateAgentStatus(ctx context.Context, id uuid.UUID, stat
 	// Get updated agent info for WebSocket broadcast
 	agent, err := u.agentRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("⚠️ Warning: Failed to get agent info for WebSocket broadcast: %v", err)
+		agentLogger(ctx, id).Warning("Failed to get agent info for WebSocket broadcast: %v", err)
 		return nil // Don't fail the status update if broadcast fails
 	}
 
 	// Broadcast real-time status update via WebSocket
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("Real-time agent status broadcast: %s -> %s", agent.Name, status)
+		agentLogger(ctx, id).Debug("Real-time agent status broadcast: %s -> %s", agent.Name, status)
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	return nil
 }
 
 func (u *agentUsecase) DeleteAgent(ctx context.Context, id uuid.UUID) error {
-	return u.agentRepo.Delete(ctx, id)
+	if err := u.agentRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.invalidateLookup(id)
+
+	u.cacheMu.Lock()
+	delete(u.cacheReports, id)
+	u.cacheMu.Unlock()
+
+	u.seenMu.Lock()
+	delete(u.pendingSeen, id)
+	delete(u.intervals, id)
+	u.seenMu.Unlock()
+	return nil
 }
 
 func (u *agentUsecase) GetAvailableAgent(ctx context.Context) (*domain.Agent, error) {
@@ -202,7 +324,7 @@ func (u *agentUsecase) GetAvailableAgent(ctx context.Context) (*domain.Agent, er
 	if err != nil {
 		return nil, err
 	}
-	for _, agent := range agents {
+	for _, agent := range withoutMaintenance(agents, agentsInMaintenance(ctx, u.maintenance)) {
 		if agent.Status == "online" {
 			return &agent, nil
 		}
@@ -230,10 +352,10 @@ func (u *agentUsecase) UpdateAgentHeartbeat(ctx context.Context, id uuid.UUID) e
 
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("Real-time agent heartbeat broadcast: %s -> %s (LastSeen: %s)",
+		agentLogger(ctx, id).Debug("Real-time agent heartbeat broadcast: %s -> %s (LastSeen: %s)",
 			agent.Name, agent.Status, agent.LastSeen.Format(time.RFC3339))
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 	return nil
 }
@@ -247,16 +369,16 @@ func (u *agentUsecase) UpdateAgentLastSeen(ctx context.Context, id uuid.UUID) er
 	// Get updated agent info for WebSocket broadcast
 	agent, err := u.agentRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("⚠️ Warning: Failed to get agent info for WebSocket broadcast: %v", err)
+		agentLogger(ctx, id).Warning("Failed to get agent info for WebSocket broadcast: %v", err)
 		return nil // Don't fail the last seen update if broadcast fails
 	}
 
 	// Broadcast real-time last seen update via WebSocket
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("Real-time agent last seen broadcast: %s -> %s", agent.Name, agent.LastSeen.Format(time.RFC3339))
+		agentLogger(ctx, id).Debug("Real-time agent last seen broadcast: %s -> %s", agent.Name, agent.LastSeen.Format(time.RFC3339))
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	return nil
@@ -271,16 +393,16 @@ func (u *agentUsecase) UpdateAgentSpeed(ctx context.Context, id uuid.UUID, speed
 	// Get updated agent info for WebSocket broadcast
 	agent, err := u.agentRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("⚠️ Warning: Failed to get agent info for WebSocket broadcast: %v", err)
+		agentLogger(ctx, id).Warning("Failed to get agent info for WebSocket broadcast: %v", err)
 		return nil // Don't fail the speed update if broadcast fails
 	}
 
 	// Broadcast real-time speed update via WebSocket
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentSpeed(agent.ID.String(), agent.Speed)
-		log.Printf("Real-time agent speed broadcast: %s -> %d H/s", agent.Name, speed)
+		agentLogger(ctx, id).Debug("Real-time agent speed broadcast: %s -> %d H/s", agent.Name, speed)
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	return nil
@@ -289,9 +411,12 @@ func (u *agentUsecase) UpdateAgentSpeed(ctx context.Context, id uuid.UUID, speed
 // UpdateAgentSpeedWithStatus updates agent speed and status simultaneously with comprehensive logging
 // This method is used for real-time monitoring and comprehensive agent state updates
 func (u *agentUsecase) UpdateAgentSpeedWithStatus(ctx context.Context, id uuid.UUID, speed int64, status string) error {
+	ctx, span := startSpan(ctx, "AgentUsecase.UpdateAgentSpeedWithStatus", attribute.String("agent.id", id.String()))
+	defer span.End()
+
 	// Update speed and status in database using new repository method
 	if err := u.agentRepo.UpdateSpeedWithStatus(ctx, id, speed, status); err != nil {
-		log.Printf("❌ [REAL-TIME UPDATE FAILED] Agent %s: speed=%d H/s, status=%s, error=%v",
+		agentLogger(ctx, id).Error("[REAL-TIME UPDATE FAILED] Agent %s: speed=%d H/s, status=%s, error=%v",
 			id.String(), speed, status, err)
 		return err
 	}
@@ -299,7 +424,7 @@ func (u *agentUsecase) UpdateAgentSpeedWithStatus(ctx context.Context, id uuid.U
 	// Get updated agent info for WebSocket broadcast
 	agent, err := u.agentRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("⚠️ Warning: Failed to get agent info for WebSocket broadcast: %v", err)
+		agentLogger(ctx, id).Warning("Failed to get agent info for WebSocket broadcast: %v", err)
 		return nil // Don't fail the update if broadcast fails
 	}
 
@@ -307,10 +432,10 @@ func (u *agentUsecase) UpdateAgentSpeedWithStatus(ctx context.Context, id uuid.U
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentSpeed(agent.ID.String(), agent.Speed)
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("[REAL-TIME BROADCAST] Agent %s: speed=%d H/s, status=%s",
+		agentLogger(ctx, id).Debug("[REAL-TIME BROADCAST] Agent %s: speed=%d H/s, status=%s",
 			agent.Name, speed, status)
 	} else {
-		log.Printf("⚠️ Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	return nil
@@ -325,16 +450,16 @@ func (u *agentUsecase) UpdateAgentStatusOffline(ctx context.Context, id uuid.UUI
 	// Get updated agent info for WebSocket broadcast
 	agent, err := u.agentRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("⚠️ Warning: Failed to get agent info for WebSocket broadcast: %v", err)
+		agentLogger(ctx, id).Warning("Failed to get agent info for WebSocket broadcast: %v", err)
 		return nil // Don't fail the status update if broadcast fails
 	}
 
 	// Broadcast real-time status update via WebSocket
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("Real-time agent status broadcast: %s -> %s (offline)", agent.Name, agent.Status)
+		agentLogger(ctx, id).Debug("Real-time agent status broadcast: %s -> %s (offline)", agent.Name, agent.Status)
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, id).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	return nil
@@ -377,7 +502,11 @@ func (u *agentUsecase) CreateAgent(ctx context.Context, agent *domain.Agent) err
 }
 
 func (u *agentUsecase) UpdateAgent(ctx context.Context, agent *domain.Agent) error {
-	return u.agentRepo.UpdateAgent(ctx, agent)
+	if err := u.agentRepo.UpdateAgent(ctx, agent); err != nil {
+		return err
+	}
+	u.invalidateLookup(agent.ID)
+	return nil
 }
 
 // UpdateAgentData updates only the data fields (ip_address, port, capabilities) without changing status
@@ -391,7 +520,7 @@ func (u *agentUsecase) UpdateAgentData(ctx context.Context, agentKey string, ipA
 		return fmt.Errorf("failed to get agent by key %s: %w", agentKey, err)
 	}
 
-	log.Printf("Debug: Found agent: %+v", agent)
+	agentLogger(ctx, agent.ID).Debug("Found agent: %+v", agent)
 
 	// Validate IP address uniqueness if provided
 	if ipAddress != "" {
@@ -412,24 +541,24 @@ func (u *agentUsecase) UpdateAgentData(ctx context.Context, agentKey string, ipA
 	agent.UpdatedAt = time.Now()
 	// Note: Status remains unchanged (stays offline until agent binary runs)
 
-	log.Printf("Debug: Updated agent data: IP=%s, Port=%d, Capabilities=%s, UpdatedAt=%v",
+	agentLogger(ctx, agent.ID).Debug("Updated agent data: IP=%s, Port=%d, Capabilities=%s, UpdatedAt=%v",
 		agent.IPAddress, agent.Port, agent.Capabilities, agent.UpdatedAt)
 
 	// Update in database
 	if err := u.agentRepo.Update(ctx, agent); err != nil {
-		log.Printf("Debug: Failed to update agent in database: %v", err)
+		agentLogger(ctx, agent.ID).Debug("Failed to update agent in database: %v", err)
 		return fmt.Errorf("failed to update agent data: %w", err)
 	}
 
-	log.Printf("Debug: Agent updated successfully in database")
+	agentLogger(ctx, agent.ID).Debug("Agent updated successfully in database")
 
 	// Broadcast real-time agent data update via WebSocket
 	if u.wsHub != nil {
 		u.wsHub.BroadcastAgentStatus(agent.ID.String(), agent.Status, agent.LastSeen.Format(time.RFC3339))
-		log.Printf("Real-time agent data broadcast: %s (IP=%s, Port=%d, Capabilities=%s)",
+		agentLogger(ctx, agent.ID).Info("Real-time agent data broadcast: %s (IP=%s, Port=%d, Capabilities=%s)",
 			agent.Name, agent.IPAddress, agent.Port, agent.Capabilities)
 	} else {
-		log.Printf("Warning: WebSocket hub not available for real-time broadcast")
+		agentLogger(ctx, agent.ID).Warning("WebSocket hub not available for real-time broadcast")
 	}
 
 	// Don't broadcast status update - status should remain offline
@@ -478,27 +607,120 @@ func (u *agentUsecase) GenerateAgentKey(ctx context.Context, name, agentKey stri
 	}
 
 	// Debug logging
-	log.Printf("Debug: Creating agent with LastSeen: %v", agent.LastSeen)
-	log.Printf("Debug: LastSeen.IsZero(): %v", agent.LastSeen.IsZero())
-	log.Printf("Debug: LastSeen.Format(time.RFC3339): %v", agent.LastSeen.Format(time.RFC3339))
-	log.Printf("Debug: Agent struct: %+v", agent)
+	agentLogger(ctx, agent.ID).Debug("Creating agent with LastSeen: %v", agent.LastSeen)
+	agentLogger(ctx, agent.ID).Debug("LastSeen.IsZero(): %v", agent.LastSeen.IsZero())
+	agentLogger(ctx, agent.ID).Debug("LastSeen.Format(time.RFC3339): %v", agent.LastSeen.Format(time.RFC3339))
+	agentLogger(ctx, agent.ID).Debug("Agent struct: %+v", agent)
 
 	// Save to database
 	if err := u.agentRepo.Create(ctx, agent); err != nil {
-		log.Printf("Debug: Failed to save agent: %v", err)
+		agentLogger(ctx, agent.ID).Debug("Failed to save agent: %v", err)
 		return nil, fmt.Errorf("failed to save agent key: %w", err)
 	}
 
-	log.Printf("Debug: Agent saved successfully with ID: %s", agent.ID.String())
+	agentLogger(ctx, agent.ID).Debug("Agent saved successfully with ID: %s", agent.ID.String())
 
 	// Verify the saved agent
 	savedAgent, err := u.agentRepo.GetByID(ctx, agent.ID)
 	if err != nil {
-		log.Printf("Debug: Could not retrieve saved agent: %v", err)
+		agentLogger(ctx, agent.ID).Debug("Could not retrieve saved agent: %v", err)
 	} else {
-		log.Printf("Debug: Retrieved saved agent LastSeen: %v", savedAgent.LastSeen)
-		log.Printf("Debug: Retrieved saved agent LastSeen.IsZero(): %v", savedAgent.LastSeen.IsZero())
+		agentLogger(ctx, agent.ID).Debug("Retrieved saved agent LastSeen: %v", savedAgent.LastSeen)
+		agentLogger(ctx, agent.ID).Debug("Retrieved saved agent LastSeen.IsZero(): %v", savedAgent.LastSeen.IsZero())
 	}
 
 	return agent, nil
 }
+
+// UpdateAgentCacheReport stores the download cache status an agent sent
+// with its heartbeat
+func (u *agentUsecase) UpdateAgentCacheReport(ctx context.Context, id uuid.UUID, report *domain.AgentCacheReport) error {
+	if report == nil {
+		return nil
+	}
+
+	stored := *report
+	stored.Entries = append([]domain.AgentCacheEntry(nil), report.Entries...)
+	if stored.ReportedAt.IsZero() {
+		stored.ReportedAt = time.Now()
+	}
+
+	u.cacheMu.Lock()
+	u.cacheReports[id] = stored
+	u.cacheMu.Unlock()
+	return nil
+}
+
+// GetAgentCacheReport returns the last cache report received from an agent
+func (u *agentUsecase) GetAgentCacheReport(ctx context.Context, id uuid.UUID) (*domain.AgentCacheReport, error) {
+	u.cacheMu.RLock()
+	report, ok := u.cacheReports[id]
+	u.cacheMu.RUnlock()
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "cache report"}
+	}
+
+	report.Entries = append([]domain.AgentCacheEntry(nil), report.Entries...)
+	return &report, nil
+}
+
+// ReplaceAgentFiles stores the local file inventory an agent reported. Each
+// report is the agent's complete scan, so it replaces the previous one.
+func (u *agentUsecase) ReplaceAgentFiles(ctx context.Context, id uuid.UUID, files []domain.AgentFile) error {
+	if _, err := u.agentRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range files {
+		files[i].AgentID = id
+		if files[i].ReportedAt.IsZero() {
+			files[i].ReportedAt = now
+		}
+	}
+	return u.agentRepo.ReplaceFiles(ctx, id, files)
+}
+
+// GetAgentFiles returns the local files an agent last reported
+func (u *agentUsecase) GetAgentFiles(ctx context.Context, id uuid.UUID) ([]domain.AgentFile, error) {
+	if _, err := u.agentRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.agentRepo.GetFiles(ctx, id)
+}
+
+// GetAgentHashcatArgs returns the hashcat options the agent adds to every job
+func (u *agentUsecase) GetAgentHashcatArgs(ctx context.Context, id uuid.UUID) ([]string, error) {
+	args, err := u.agentRepo.GetHashcatArgs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = []string{}
+	}
+	return args, nil
+}
+
+// SetAgentHashcatArgs replaces the agent's default hashcat options, e.g. a
+// lower -n for a small GPU. They go before each job's own extra arguments.
+func (u *agentUsecase) SetAgentHashcatArgs(ctx context.Context, id uuid.UUID, args []string) error {
+	if err := domain.ValidateHashcatArgs("args", args); err != nil {
+		return err
+	}
+	return u.agentRepo.UpdateHashcatArgs(ctx, id, args)
+}
+
+// GetAgentSettings returns the settings managed on the server for an agent
+func (u *agentUsecase) GetAgentSettings(ctx context.Context, id uuid.UUID) (*domain.AgentSettings, error) {
+	return u.agentRepo.GetSettings(ctx, id)
+}
+
+// SetAgentSettings replaces the agent's server-managed settings. The agent
+// applies them when its next heartbeat is answered.
+func (u *agentUsecase) SetAgentSettings(ctx context.Context, id uuid.UUID, settings *domain.AgentSettings) error {
+	settings.UploadDir = strings.TrimSpace(settings.UploadDir)
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return u.agentRepo.UpdateSettings(ctx, id, settings)
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EffectivenessRecorder records what a completed job cracked for the
+// keyspace it searched
+type EffectivenessRecorder interface {
+	RecordJobEffectiveness(ctx context.Context, job *domain.Job) error
+}
+
+// AnalyticsUsecase keeps the history of completed jobs that shows which
+// wordlists, rule files and masks are worth running
+type AnalyticsUsecase interface {
+	EffectivenessRecorder
+	// GetEffectiveness ranks the wordlists, rule files or masks of the
+	// recorded jobs matching the filter by cracks per keyspace
+	GetEffectiveness(ctx context.Context, filter domain.EffectivenessFilter) (*domain.EffectivenessReport, error)
+}
+
+type analyticsUsecase struct {
+	effectivenessRepo domain.EffectivenessRepository
+	jobRepo           domain.JobRepository
+	wordlistRepo      domain.WordlistRepository
+}
+
+func NewAnalyticsUsecase(effectivenessRepo domain.EffectivenessRepository, jobRepo domain.JobRepository, wordlistRepo domain.WordlistRepository) AnalyticsUsecase {
+	return &analyticsUsecase{
+		effectivenessRepo: effectivenessRepo,
+		jobRepo:           jobRepo,
+		wordlistRepo:      wordlistRepo,
+	}
+}
+
+func (u *analyticsUsecase) RecordJobEffectiveness(ctx context.Context, job *domain.Job) error {
+	ctx, span := startSpan(ctx, "AnalyticsUsecase.RecordJobEffectiveness", attribute.String("job.id", job.ID.String()))
+	defer span.End()
+
+	cracks, err := u.jobRepo.GetCracks(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get job cracks: %w", err)
+	}
+	record := &domain.JobEffectiveness{
+		JobID:         job.ID,
+		GroupID:       job.GroupID,
+		HashType:      job.HashType,
+		AttackMode:    job.AttackMode,
+		Rules:         job.Rules,
+		Keyspace:      searchedKeyspace(job),
+		Cracks:        len(cracks),
+		DeviceSeconds: job.DeviceSeconds,
+		CompletedAt:   time.Now(),
+	}
+	if job.CompletedAt != nil {
+		record.CompletedAt = *job.CompletedAt
+	}
+	// Agents that predate crack reports only leave the password in the result
+	if record.Cracks == 0 {
+		if _, ok := domain.ResultPassword(job.Result); ok {
+			record.Cracks = 1
+		}
+	}
+
+	if job.AttackMode == domain.AttackModeBruteForce {
+		record.Mask = job.Wordlist
+	} else {
+		record.WordlistID = job.WordlistID
+		record.Wordlist = u.wordlistName(ctx, job)
+	}
+
+	return u.effectivenessRepo.Record(ctx, record)
+}
+
+// wordlistName names the wordlist of a job by its upload, so renamed
+// copies on agents count as one. Inline wordlists have no name.
+func (u *analyticsUsecase) wordlistName(ctx context.Context, job *domain.Job) string {
+	if job.WordlistID != nil {
+		if wordlist, err := u.wordlistRepo.GetByID(ctx, *job.WordlistID); err == nil {
+			return wordlist.OrigName
+		}
+	}
+	if strings.Contains(job.Wordlist, "\n") {
+		return ""
+	}
+	return job.Wordlist
+}
+
+// searchedKeyspace is how many candidates a completed job tried: hashcat's
+// own count when the agent reported it, otherwise the words processed. A
+// job that exhausted its keyspace searched all of it.
+func searchedKeyspace(job *domain.Job) int64 {
+	done, total := job.ProcessedWords, job.TotalWords
+	if job.Stats != nil && job.Stats.ProgressTotal > 0 {
+		done, total = job.Stats.ProgressDone, job.Stats.ProgressTotal
+	}
+	if job.Status != domain.JobStatusCracked || done == 0 {
+		return total
+	}
+	return done
+}
+
+func (u *analyticsUsecase) GetEffectiveness(ctx context.Context, filter domain.EffectivenessFilter) (*domain.EffectivenessReport, error) {
+	ctx, span := startSpan(ctx, "AnalyticsUsecase.GetEffectiveness", attribute.String("group_by", filter.GroupBy))
+	defer span.End()
+
+	switch filter.GroupBy {
+	case domain.EffectivenessGroupWordlist, domain.EffectivenessGroupRules, domain.EffectivenessGroupMask:
+	default:
+		return nil, &domain.ValidationError{Field: "group_by", Message: "must be wordlist, rules or mask"}
+	}
+	if filter.HashType != nil {
+		if err := domain.ValidateHashMode(*filter.HashType); err != nil {
+			return nil, err
+		}
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, &domain.ValidationError{Field: "to", Message: "must not be before from"}
+	}
+
+	totals, err := u.effectivenessRepo.Aggregate(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job effectiveness: %w", err)
+	}
+
+	report := &domain.EffectivenessReport{
+		GroupBy:     filter.GroupBy,
+		HashType:    filter.HashType,
+		From:        filter.From,
+		To:          filter.To,
+		Items:       make([]domain.EffectivenessItem, 0, len(totals)),
+		GeneratedAt: time.Now(),
+	}
+	for _, total := range totals {
+		item := domain.EffectivenessItem{
+			Key:            total.Key,
+			Name:           total.Name,
+			Jobs:           total.Jobs,
+			JobsWithCracks: total.JobsWithCracks,
+			Cracks:         total.Cracks,
+			Keyspace:       total.Keyspace,
+			DeviceHours:    total.DeviceSeconds / 3600,
+			LastUsedAt:     total.LastUsedAt,
+			Ineffective:    total.Cracks == 0 && total.Jobs >= domain.EffectivenessRetireJobs,
+		}
+		if total.Keyspace > 0 {
+			item.CracksPerBillion = float64(total.Cracks) / float64(total.Keyspace) * 1e9
+		}
+		if item.DeviceHours > 0 {
+			item.CracksPerDeviceHour = float64(total.Cracks) / item.DeviceHours
+		}
+		report.Items = append(report.Items, item)
+	}
+	sort.Slice(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if a.CracksPerBillion != b.CracksPerBillion {
+			return a.CracksPerBillion > b.CracksPerBillion
+		}
+		if a.Cracks != b.Cracks {
+			return a.Cracks > b.Cracks
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
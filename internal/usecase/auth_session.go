@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// sessionCheckTTL is how long a session is trusted to be active before it
+// is looked up again. Revocations made on this server apply at once, those
+// made on another server within this time.
+const sessionCheckTTL = 10 * time.Second
+
+// sessionCheck is a cached lookup of an active session
+type sessionCheck struct {
+	userID    uuid.UUID
+	checkedAt time.Time
+}
+
+// sessionCache remembers which sessions were recently found active, so the
+// auth middleware doesn't look one up on every request
+type sessionCache struct {
+	checks sync.Map // uuid.UUID -> sessionCheck
+}
+
+func (c *sessionCache) active(id uuid.UUID) bool {
+	value, ok := c.checks.Load(id)
+	if !ok {
+		return false
+	}
+	if time.Since(value.(sessionCheck).checkedAt) > sessionCheckTTL {
+		c.checks.Delete(id)
+		return false
+	}
+	return true
+}
+
+func (c *sessionCache) store(session *domain.Session) {
+	c.checks.Store(session.ID, sessionCheck{userID: session.UserID, checkedAt: time.Now()})
+}
+
+func (c *sessionCache) forget(id uuid.UUID) {
+	c.checks.Delete(id)
+}
+
+// forgetUser forgets the user's sessions but keep
+func (c *sessionCache) forgetUser(userID uuid.UUID, keep *uuid.UUID) {
+	c.checks.Range(func(key, value interface{}) bool {
+		id := key.(uuid.UUID)
+		if value.(sessionCheck).userID == userID && (keep == nil || id != *keep) {
+			c.checks.Delete(id)
+		}
+		return true
+	})
+}
+
+// StartSession logs the user in with a new session, returning its first
+// access token and its refresh token. A session without two-factor tells
+// the user what second factor it still owes.
+func (u *authUsecase) StartSession(ctx context.Context, user *domain.User, twoFactor bool) (*domain.LoginResponse, error) {
+	secret, err := generateRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	client := domain.ClientInfoFromContext(ctx)
+	now := time.Now()
+	session := &domain.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TokenHash:  hashRefreshSecret(secret),
+		TwoFactor:  twoFactor,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(u.jwtService.GetSessionDuration()),
+	}
+	if err := u.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := u.jwtService.GenerateSessionToken(user, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	u.sessionCache.store(session)
+
+	response := &domain.LoginResponse{
+		Token:        token,
+		User:         *user,
+		ExpiresAt:    expiresAt,
+		RefreshToken: session.ID.String() + "." + secret,
+	}
+	if err := u.markSecondFactorOwed(ctx, response, twoFactor); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// refreshSession checks a refresh token and replaces it with a new one. A
+// token that was already replaced means it leaked, or a client raced
+// itself, and revokes the session.
+func (u *authUsecase) refreshSession(ctx context.Context, refreshToken string) (*domain.Session, string, error) {
+	id, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, "", &domain.AuthenticationError{Message: "invalid refresh token"}
+	}
+	session, err := u.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, "", &domain.AuthenticationError{Message: "session expired, log in again"}
+		}
+		return nil, "", err
+	}
+	if !session.Active(time.Now()) {
+		return nil, "", &domain.AuthenticationError{Message: "session expired, log in again"}
+	}
+
+	newSecret, err := generateRefreshSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	rotated := false
+	if subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(session.TokenHash)) == 1 {
+		rotated, err = u.sessionRepo.Rotate(ctx, id, session.TokenHash, hashRefreshSecret(newSecret), time.Now().Add(u.jwtService.GetSessionDuration()))
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if !rotated {
+		infrastructure.ServerLogger.WithContext(ctx).Warning("Refresh token of session %s was used twice, revoking the session", id)
+		if err := u.revokeSession(ctx, session); err != nil {
+			return nil, "", err
+		}
+		return nil, "", &domain.AuthenticationError{Message: "refresh token was already used, log in again"}
+	}
+
+	u.sessionCache.store(session)
+	return session, session.ID.String() + "." + newSecret, nil
+}
+
+// checkSession reports whether the session of an access token is active
+func (u *authUsecase) checkSession(ctx context.Context, claims *domain.JWTClaims) error {
+	id, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return &domain.AuthenticationError{Message: "token has no session, log in again"}
+	}
+	if u.sessionCache.active(id) {
+		return nil
+	}
+
+	session, err := u.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return &domain.AuthenticationError{Message: "session was revoked"}
+		}
+		return err
+	}
+	if !session.Active(time.Now()) {
+		return &domain.AuthenticationError{Message: "session was revoked"}
+	}
+	u.sessionCache.store(session)
+	return nil
+}
+
+// GetSessions lists the user's active sessions
+func (u *authUsecase) GetSessions(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return u.sessionRepo.GetActiveByUser(ctx, userID)
+}
+
+// RevokeSession revokes one of the user's sessions
+func (u *authUsecase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	session, err := u.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return &domain.NotFoundError{Entity: "session"}
+	}
+	return u.revokeSession(ctx, session)
+}
+
+// RevokeSessions revokes the user's sessions but keep, such as the one
+// making the request
+func (u *authUsecase) RevokeSessions(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error) {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+	return u.revokeUserSessions(ctx, userID, keep)
+}
+
+func (u *authUsecase) revokeSession(ctx context.Context, session *domain.Session) error {
+	u.sessionCache.forget(session.ID)
+	if err := u.sessionRepo.Revoke(ctx, session.ID); err != nil && !domain.IsNotFoundError(err) {
+		return err
+	}
+	return nil
+}
+
+func (u *authUsecase) revokeUserSessions(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error) {
+	u.sessionCache.forgetUser(userID, keep)
+	revoked, err := u.sessionRepo.RevokeByUser(ctx, userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	if revoked > 0 {
+		infrastructure.ServerLogger.WithContext(ctx).Info("Revoked %d sessions of user %s", revoked, userID)
+	}
+	return revoked, nil
+}
+
+// parseRefreshToken splits a refresh token into its session ID and secret
+func parseRefreshToken(token string) (uuid.UUID, string, bool) {
+	value, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return uuid.Nil, "", false
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return id, secret, true
+}
+
+func generateRefreshSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,391 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// twoFactorIssuer names the server in authenticator apps
+	twoFactorIssuer = "Distributed Hashcat"
+	// recoveryCodeCount is how many recovery codes a user gets at a time
+	recoveryCodeCount = 10
+)
+
+// verifySecondFactor checks the code of a login of a user with two-factor
+// authentication, reporting whether the login passed a second factor
+func (u *authUsecase) verifySecondFactor(ctx context.Context, user *domain.User, req *domain.LoginRequest) (bool, error) {
+	twoFactor, err := u.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !twoFactor.Enabled {
+		return false, nil
+	}
+
+	switch {
+	case req.TOTPCode != "":
+		err = u.checkTOTP(ctx, twoFactor, req.TOTPCode)
+	case req.RecoveryCode != "":
+		err = u.checkRecoveryCode(ctx, user, req.RecoveryCode)
+	default:
+		return false, &domain.TwoFactorRequiredError{}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkTOTP accepts a current code that hasn't been used yet
+func (u *authUsecase) checkTOTP(ctx context.Context, twoFactor *domain.TwoFactor, code string) error {
+	step, ok := infrastructure.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return &domain.AuthenticationError{Message: "invalid two-factor code"}
+	}
+	fresh, err := u.twoFactorRepo.UseStep(ctx, twoFactor.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return &domain.AuthenticationError{Message: "two-factor code was already used, wait for the next one"}
+	}
+	return nil
+}
+
+// checkRecoveryCode accepts each recovery code once
+func (u *authUsecase) checkRecoveryCode(ctx context.Context, user *domain.User, code string) error {
+	used, err := u.twoFactorRepo.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return &domain.AuthenticationError{Message: "invalid recovery code"}
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("User %s used a two-factor recovery code", user.Username)
+	return nil
+}
+
+// checkCode accepts a current TOTP code or a recovery code from a user
+// changing their two-factor settings
+func (u *authUsecase) checkCode(ctx context.Context, user *domain.User, twoFactor *domain.TwoFactor, code string) error {
+	if len(strings.TrimSpace(code)) == 6 {
+		return u.checkTOTP(ctx, twoFactor, code)
+	}
+	return u.checkRecoveryCode(ctx, user, code)
+}
+
+func (u *authUsecase) GetTwoFactorStatus(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorStatus, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.TwoFactorStatus{}
+	if status.Enabled, err = u.twoFactorEnabled(ctx, userID); err != nil {
+		return nil, err
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = u.twoFactorRepo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if status.Required, err = u.requiresTwoFactor(ctx, user.ID, user.Role); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// EnrollTwoFactor starts over the enrollment of a user without two-factor
+// authentication. It takes effect once EnableTwoFactor confirms a code.
+func (u *authUsecase) EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorEnrollment, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := u.twoFactorEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, &domain.ValidationError{Field: "two_factor", Message: "two-factor authentication is already enabled, disable it first"}
+	}
+
+	secret, err := infrastructure.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.Save(ctx, &domain.TwoFactor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: infrastructure.TOTPProvisioningURI(twoFactorIssuer, user.Username, secret),
+	}, nil
+}
+
+// EnableTwoFactor confirms the enrollment with a code from the
+// authenticator app, returning the recovery codes and a new session that
+// counts as two-factor. The user's other sessions are revoked.
+func (u *authUsecase) EnableTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*domain.TwoFactorActivation, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, &domain.ValidationError{Field: "two_factor", Message: "start the enrollment first"}
+		}
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, &domain.ValidationError{Field: "two_factor", Message: "two-factor authentication is already enabled"}
+	}
+
+	step, ok := infrastructure.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, &domain.ValidationError{Field: "code", Message: "invalid two-factor code, check the authenticator app's clock"}
+	}
+	twoFactor.Enabled = true
+	twoFactor.LastUsedStep = step
+	if err := u.twoFactorRepo.Save(ctx, twoFactor); err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := u.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sessions that skipped the second factor end, the caller gets a new one
+	if _, err := u.revokeUserSessions(ctx, userID, nil); err != nil {
+		return nil, err
+	}
+	session, err := u.StartSession(ctx, user, true)
+	if err != nil {
+		return nil, err
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("User %s enabled two-factor authentication", user.Username)
+
+	return &domain.TwoFactorActivation{
+		LoginResponse: *session,
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// VerifyTwoFactor confirms a session that didn't pass the second factor,
+// such as a single sign-on login, with a current code or a recovery code.
+// The session is replaced with one that counts as two-factor.
+func (u *authUsecase) VerifyTwoFactor(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, code string) (*domain.LoginResponse, error) {
+	user, twoFactor, err := u.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkCode(ctx, user, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	response, err := u.StartSession(ctx, user, true)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID != nil {
+		if err := u.RevokeSession(ctx, userID, *sessionID); err != nil && !domain.IsNotFoundError(err) {
+			return nil, err
+		}
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("User %s confirmed a session with two-factor authentication", user.Username)
+	return response, nil
+}
+
+// DisableTwoFactor turns off the user's two-factor authentication, unless
+// the policy requires it of them
+func (u *authUsecase) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	user, twoFactor, err := u.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	required, err := u.requiresTwoFactor(ctx, user.ID, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return &domain.ValidationError{Field: "two_factor", Message: "two-factor authentication is required for your account"}
+	}
+	if err := u.checkCode(ctx, user, twoFactor, code); err != nil {
+		return err
+	}
+
+	if err := u.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("User %s disabled two-factor authentication", user.Username)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func (u *authUsecase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, twoFactor, err := u.enabledTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkCode(ctx, user, twoFactor, code); err != nil {
+		return nil, err
+	}
+	return u.replaceRecoveryCodes(ctx, userID)
+}
+
+// ResetTwoFactor removes the second factor of a user who lost it, so they
+// can log in with their password and enroll again
+func (u *authUsecase) ResetTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := u.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("Two-factor authentication of user %s was reset", user.Username)
+	return nil
+}
+
+func (u *authUsecase) GetTwoFactorPolicy(ctx context.Context) (*domain.TwoFactorPolicy, error) {
+	return u.twoFactorRepo.GetPolicy(ctx)
+}
+
+func (u *authUsecase) UpdateTwoFactorPolicy(ctx context.Context, policy *domain.TwoFactorPolicy) (*domain.TwoFactorPolicy, error) {
+	if err := u.twoFactorRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("Two-factor authentication required for privileged users: %t", policy.RequireForPrivileged)
+	return policy, nil
+}
+
+// TwoFactorPending reports whether the session owes a second factor: a
+// code, when the user has two-factor authentication but logged in without
+// it, or its setup, when the policy requires it
+func (u *authUsecase) TwoFactorPending(ctx context.Context, claims *domain.JWTClaims) (bool, error) {
+	if claims.TwoFactor {
+		return false, nil
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return false, &domain.AuthenticationError{Message: "invalid user ID in token"}
+	}
+	code, setup, err := u.secondFactorOwed(ctx, userID, claims.Role)
+	if err != nil {
+		return false, err
+	}
+	return code || setup, nil
+}
+
+// markSecondFactorOwed tells the user of a session without two-factor
+// whether to confirm a code or set up a second factor
+func (u *authUsecase) markSecondFactorOwed(ctx context.Context, response *domain.LoginResponse, twoFactor bool) error {
+	if twoFactor {
+		return nil
+	}
+	code, setup, err := u.secondFactorOwed(ctx, response.User.ID, response.User.Role)
+	if err != nil {
+		return err
+	}
+	response.TwoFactorRequired = code
+	response.TwoFactorSetupRequired = setup
+	return nil
+}
+
+// secondFactorOwed reports what a session without two-factor of the user
+// owes: a code, when they have two-factor authentication, or its setup,
+// when the policy requires it
+func (u *authUsecase) secondFactorOwed(ctx context.Context, userID uuid.UUID, role string) (code, setup bool, err error) {
+	enabled, err := u.twoFactorEnabled(ctx, userID)
+	if err != nil {
+		return false, false, err
+	}
+	if enabled {
+		return true, false, nil
+	}
+	setup, err = u.requiresTwoFactor(ctx, userID, role)
+	return false, setup, err
+}
+
+// requiresTwoFactor applies the policy to admins and users with the reveal
+// grant
+func (u *authUsecase) requiresTwoFactor(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	policy, err := u.twoFactorRepo.GetPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !policy.RequireForPrivileged {
+		return false, nil
+	}
+	if role == "admin" {
+		return true, nil
+	}
+	return u.resultAccessRepo.HasRevealGrant(ctx, userID)
+}
+
+func (u *authUsecase) twoFactorEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	twoFactor, err := u.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+func (u *authUsecase) enabledTwoFactor(ctx context.Context, userID uuid.UUID) (*domain.User, *domain.TwoFactor, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.Get(ctx, userID)
+	if err != nil && !domain.IsNotFoundError(err) {
+		return nil, nil, err
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return nil, nil, &domain.ValidationError{Field: "two_factor", Message: "two-factor authentication is not enabled"}
+	}
+	return user, twoFactor, nil
+}
+
+func (u *authUsecase) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(buf)
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := u.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code as typed, ignoring case, spaces
+// and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"time"
 
@@ -13,19 +14,32 @@ import (
 )
 
 type authUsecase struct {
-	userRepo   domain.UserRepository
-	jwtService *infrastructure.JWTService
+	userRepo         domain.UserRepository
+	twoFactorRepo    domain.TwoFactorRepository
+	resultAccessRepo domain.ResultAccessRepository
+	sessionRepo      domain.SessionRepository
+	jwtService       *infrastructure.JWTService
+	refresher        domain.SessionRefresher
+	sessionCache     sessionCache
 }
 
-// NewAuthUsecase creates a new authentication usecase
-func NewAuthUsecase(userRepo domain.UserRepository, jwtService *infrastructure.JWTService) domain.AuthUsecase {
+// NewAuthUsecase creates a new authentication usecase. resultAccessRepo
+// tells which users the two-factor policy treats as privileged.
+func NewAuthUsecase(userRepo domain.UserRepository, twoFactorRepo domain.TwoFactorRepository, resultAccessRepo domain.ResultAccessRepository, sessionRepo domain.SessionRepository, jwtService *infrastructure.JWTService) domain.AuthUsecase {
 	return &authUsecase{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:         userRepo,
+		twoFactorRepo:    twoFactorRepo,
+		resultAccessRepo: resultAccessRepo,
+		sessionRepo:      sessionRepo,
+		jwtService:       jwtService,
 	}
 }
 
-// Login authenticates a user and returns a JWT token
+func (u *authUsecase) SetSessionRefresher(refresher domain.SessionRefresher) {
+	u.refresher = refresher
+}
+
+// Login authenticates a user and starts a session
 func (u *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*domain.LoginResponse, error) {
 	// Get user by username
 	user, err := u.userRepo.GetByUsername(ctx, req.Username)
@@ -48,10 +62,10 @@ func (u *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		return nil, &domain.InvalidCredentialsError{}
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := u.jwtService.GenerateToken(user)
+	// Users with two-factor authentication confirm the login with a code
+	twoFactor, err := u.verifySecondFactor(ctx, user, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	// Update last login time
@@ -62,58 +76,64 @@ func (u *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 	if err != nil {
 		// Log error but don't fail the login
 		// In production, you might want to use a proper logger
-		fmt.Printf("Warning: failed to update last login time for user %s: %v\n", user.Username, err)
+		infrastructure.ServerLogger.WithContext(ctx).Warning("Failed to update last login time for user %s: %v", user.Username, err)
 	}
 
-	return &domain.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
-	}, nil
+	return u.StartSession(ctx, user, twoFactor)
 }
 
-// Logout invalidates a JWT token (in a simple implementation, we just validate the token)
+// Logout revokes the session of an access token or a refresh token
 func (u *authUsecase) Logout(ctx context.Context, token string) error {
-	// Validate token to ensure it's valid before logout
-	_, err := u.jwtService.ValidateToken(token)
+	if id, secret, ok := parseRefreshToken(token); ok {
+		session, err := u.sessionRepo.GetByID(ctx, id)
+		if err != nil || subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(session.TokenHash)) != 1 {
+			return &domain.AuthenticationError{Message: "invalid token"}
+		}
+		return u.revokeSession(ctx, session)
+	}
+
+	claims, err := u.jwtService.ValidateToken(token)
 	if err != nil {
 		return &domain.AuthenticationError{Message: "invalid token"}
 	}
-
-	// In a more sophisticated implementation, you might:
-	// 1. Add the token to a blacklist
-	// 2. Store logout time in database
-	// 3. Implement token revocation
-
-	// For now, we just validate the token
-	return nil
+	id, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil
+	}
+	session, err := u.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+	return u.revokeSession(ctx, session)
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims, refusing
+// tokens of sessions that were revoked
 func (u *authUsecase) ValidateToken(ctx context.Context, token string) (*domain.JWTClaims, error) {
 	claims, err := u.jwtService.ValidateToken(token)
 	if err != nil {
 		return nil, &domain.AuthenticationError{Message: "invalid token"}
 	}
+	if err := u.checkSession(ctx, claims); err != nil {
+		return nil, err
+	}
 
 	return claims, nil
 }
 
-// RefreshToken generates a new token for the same user
-func (u *authUsecase) RefreshToken(ctx context.Context, token string) (*domain.LoginResponse, error) {
-	// Validate the current token
-	claims, err := u.jwtService.ValidateToken(token)
-	if err != nil {
-		return nil, &domain.AuthenticationError{Message: "invalid token"}
-	}
-
-	// Get user to ensure they still exist and are active
-	userID, err := uuid.Parse(claims.UserID)
+// RefreshToken exchanges a refresh token for a new access token and
+// refresh token of the same session
+func (u *authUsecase) RefreshToken(ctx context.Context, refreshToken string) (*domain.LoginResponse, error) {
+	session, newRefreshToken, err := u.refreshSession(ctx, refreshToken)
 	if err != nil {
-		return nil, &domain.AuthenticationError{Message: "invalid user ID in token"}
+		return nil, err
 	}
 
-	user, err := u.userRepo.GetByID(ctx, userID)
+	// The tenant and role may have changed, the new token gets the user's current ones
+	user, err := u.userRepo.GetByID(domain.WithoutTenant(ctx), session.UserID)
 	if err != nil {
 		return nil, &domain.AuthenticationError{Message: "user not found"}
 	}
@@ -122,17 +142,33 @@ func (u *authUsecase) RefreshToken(ctx context.Context, token string) (*domain.L
 		return nil, &domain.AuthenticationError{Message: "account is deactivated"}
 	}
 
+	// Single sign-on users are only refreshed while the provider agrees
+	if u.refresher != nil {
+		if err := u.refresher.RefreshSession(ctx, user); err != nil {
+			if _, ok := err.(*domain.AuthenticationError); ok {
+				if revokeErr := u.revokeSession(ctx, session); revokeErr != nil {
+					return nil, revokeErr
+				}
+			}
+			return nil, err
+		}
+	}
+
 	// Generate new token
-	newToken, expiresAt, err := u.jwtService.GenerateToken(user)
+	newToken, expiresAt, err := u.jwtService.GenerateSessionToken(user, session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate new token: %w", err)
 	}
-
-	return &domain.LoginResponse{
-		Token:     newToken,
-		User:      *user,
-		ExpiresAt: expiresAt,
-	}, nil
+	response := &domain.LoginResponse{
+		Token:        newToken,
+		User:         *user,
+		ExpiresAt:    expiresAt,
+		RefreshToken: newRefreshToken,
+	}
+	if err := u.markSecondFactorOwed(ctx, response, session.TwoFactor); err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
 // CreateUser creates a new user
@@ -237,12 +273,25 @@ func (u *authUsecase) UpdateUser(ctx context.Context, id uuid.UUID, req *domain.
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
+	// A new password or a deactivation logs the user out everywhere
+	if req.Password != nil || !user.IsActive {
+		if _, err := u.revokeUserSessions(ctx, id, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	return user, nil
 }
 
 // DeleteUser deletes a user
 func (u *authUsecase) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	return u.userRepo.Delete(ctx, id)
+	if err := u.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if _, err := u.revokeUserSessions(ctx, id, nil); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.Delete(ctx, id)
 }
 
 // GetAllUsers retrieves all users
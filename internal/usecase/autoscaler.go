@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// AutoScaler starts burst agents at a cloud provider while too many jobs
+// wait for an agent, and deletes them again once they have been idle
+type AutoScaler interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context)
+}
+
+type AutoScaleConfig struct {
+	CheckInterval      time.Duration `json:"check_interval"`       // How often to check the queue (default: 1 minute)
+	QueueThreshold     int           `json:"queue_threshold"`      // Start instances while more unassigned jobs than this are pending
+	IdleTimeout        time.Duration `json:"idle_timeout"`         // Delete an instance idle for this long (default: 10 minutes)
+	BootTimeout        time.Duration `json:"boot_timeout"`         // Delete an instance whose agent hasn't registered by then (default: 15 minutes)
+	MaxInstances       int           `json:"max_instances"`        // Never run more burst instances than this
+	InstanceHourlyCost float64       `json:"instance_hourly_cost"` // Price of one instance, for the hourly cap
+	MaxHourlyCost      float64       `json:"max_hourly_cost"`      // Cap on the summed price of running instances, 0 disables it
+	ServerURL          string        `json:"server_url"`           // URL the burst agents use to reach this server
+	AgentDownloadURL   string        `json:"agent_download_url"`   // Where instances download the agent binary
+}
+
+type autoScaler struct {
+	provider     domain.CloudProvider
+	instanceRepo domain.CloudInstanceRepository
+	agentUsecase AgentUsecase
+	jobUsecase   JobUsecase
+	config       AutoScaleConfig
+	ticker       *time.Ticker
+	done         chan struct{}
+}
+
+func NewAutoScaler(
+	provider domain.CloudProvider,
+	instanceRepo domain.CloudInstanceRepository,
+	agentUsecase AgentUsecase,
+	jobUsecase JobUsecase,
+	config AutoScaleConfig,
+) (AutoScaler, error) {
+	if config.MaxInstances <= 0 {
+		return nil, fmt.Errorf("max instances must be greater than 0")
+	}
+	if config.MaxHourlyCost > 0 && config.InstanceHourlyCost <= 0 {
+		return nil, fmt.Errorf("instance hourly cost is required when max hourly cost is set")
+	}
+	for name, value := range map[string]string{"server URL": config.ServerURL, "agent download URL": config.AgentDownloadURL} {
+		if value == "" {
+			return nil, fmt.Errorf("%s is required", name)
+		}
+		// Both end up in the boot script
+		if strings.ContainsAny(value, " \t\r\n'\"\\`$") {
+			return nil, fmt.Errorf("%s contains invalid characters", name)
+		}
+	}
+
+	if config.CheckInterval == 0 {
+		config.CheckInterval = time.Minute
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = 10 * time.Minute
+	}
+	if config.BootTimeout == 0 {
+		config.BootTimeout = 15 * time.Minute
+	}
+
+	return &autoScaler{
+		provider:     provider,
+		instanceRepo: instanceRepo,
+		agentUsecase: agentUsecase,
+		jobUsecase:   jobUsecase,
+		config:       config,
+		done:         make(chan struct{}),
+	}, nil
+}
+
+func (s *autoScaler) Start(ctx context.Context) {
+	infrastructure.ServerLogger.Info("Starting Auto-Scaler (provider: %s, interval: %v, queue threshold: %d, max instances: %d)",
+		s.provider.Name(), s.config.CheckInterval, s.config.QueueThreshold, s.config.MaxInstances)
+
+	s.ticker = time.NewTicker(s.config.CheckInterval)
+
+	go func() {
+		s.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			case <-s.ticker.C:
+				s.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (s *autoScaler) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	select {
+	case <-s.done:
+		// Channel already closed
+	default:
+		close(s.done)
+	}
+	infrastructure.ServerLogger.Info("Auto-Scaler stopped")
+}
+
+// RunOnce deletes idle instances, then starts new ones if the queue needs them
+func (s *autoScaler) RunOnce(ctx context.Context) {
+	instances, err := s.instanceRepo.GetActive(ctx)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to get cloud instances: %v", err)
+		return
+	}
+
+	active := make([]domain.CloudInstance, 0, len(instances))
+	for _, instance := range instances {
+		if s.checkInstance(ctx, &instance) {
+			active = append(active, instance)
+		}
+	}
+
+	s.scaleUp(ctx, active)
+}
+
+// checkInstance updates the state of one instance, deleting it when its agent
+// never registered or has been idle too long. It reports whether the instance
+// is still active.
+func (s *autoScaler) checkInstance(ctx context.Context, instance *domain.CloudInstance) bool {
+	agent, err := s.agentUsecase.GetAgent(ctx, instance.AgentID)
+	if err != nil {
+		if domain.IsNotFoundError(err) || errors.Is(err, domain.ErrAgentNotFound) {
+			return !s.terminate(ctx, instance, "its agent was deleted")
+		}
+		infrastructure.ServerLogger.Error("Failed to get agent of cloud instance %s: %v", instance.ProviderID, err)
+		return true
+	}
+
+	now := time.Now()
+	// A degraded agent is still connected, only its heartbeats are late
+	online := agent.Status == "online" || agent.Status == "busy" || agent.Status == "degraded"
+
+	if instance.Status == domain.CloudInstanceProvisioning {
+		if !online {
+			if now.Sub(instance.CreatedAt) > s.config.BootTimeout {
+				return !s.terminate(ctx, instance, fmt.Sprintf("its agent didn't register within %v", s.config.BootTimeout))
+			}
+			return true
+		}
+
+		infrastructure.ServerLogger.Info("Burst agent %s on %s instance %s is online", agent.Name, instance.Provider, instance.ProviderID)
+		instance.Status = domain.CloudInstanceRunning
+		instance.IdleSince = nil
+		s.saveInstance(ctx, instance)
+		return true
+	}
+
+	if online && s.hasWork(ctx, agent) {
+		if instance.IdleSince != nil {
+			instance.IdleSince = nil
+			s.saveInstance(ctx, instance)
+		}
+		return true
+	}
+
+	if instance.IdleSince == nil {
+		instance.IdleSince = &now
+		s.saveInstance(ctx, instance)
+		return true
+	}
+	if now.Sub(*instance.IdleSince) > s.config.IdleTimeout {
+		return !s.terminate(ctx, instance, fmt.Sprintf("it was idle for %v", s.config.IdleTimeout))
+	}
+	return true
+}
+
+// hasWork reports whether the agent is running a job or has one waiting
+func (s *autoScaler) hasWork(ctx context.Context, agent *domain.Agent) bool {
+	if agent.Status == "busy" {
+		return true
+	}
+
+	jobs, err := s.jobUsecase.GetJobsByAgentID(ctx, agent.ID)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to get jobs of burst agent %s: %v", agent.Name, err)
+		return true // Don't delete an instance we can't check
+	}
+	for _, job := range jobs {
+		switch job.Status {
+		case domain.JobStatusPending, domain.JobStatusAssigned, domain.JobStatusRunning:
+			return true
+		}
+	}
+	return false
+}
+
+// terminate deletes the instance at the provider and removes its agent. It
+// reports whether the instance is gone; on failure it is retried next run.
+func (s *autoScaler) terminate(ctx context.Context, instance *domain.CloudInstance, reason string) bool {
+	if err := s.provider.DeleteInstance(ctx, instance.ProviderID); err != nil {
+		infrastructure.ServerLogger.Error("Failed to delete %s instance %s: %v", instance.Provider, instance.ProviderID, err)
+		return false
+	}
+	infrastructure.ServerLogger.Info("Deleted %s instance %s because %s", instance.Provider, instance.ProviderID, reason)
+
+	if err := s.agentUsecase.DeleteAgent(ctx, instance.AgentID); err != nil &&
+		!domain.IsNotFoundError(err) && !errors.Is(err, domain.ErrAgentNotFound) {
+		infrastructure.ServerLogger.Error("Failed to delete burst agent %s: %v", instance.AgentID, err)
+	}
+
+	now := time.Now()
+	instance.Status = domain.CloudInstanceTerminated
+	instance.TerminatedAt = &now
+	s.saveInstance(ctx, instance)
+	return true
+}
+
+func (s *autoScaler) saveInstance(ctx context.Context, instance *domain.CloudInstance) {
+	if err := s.instanceRepo.Update(ctx, instance); err != nil {
+		infrastructure.ServerLogger.Error("Failed to update cloud instance %s: %v", instance.ProviderID, err)
+	}
+}
+
+// scaleUp hands waiting jobs to online burst agents and starts instances for
+// the part of the queue above the threshold, within the instance and cost caps
+func (s *autoScaler) scaleUp(ctx context.Context, active []domain.CloudInstance) {
+	queued, err := s.queueDepth(ctx)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to get pending jobs: %v", err)
+		return
+	}
+
+	booting := 0
+	running := 0
+	for _, instance := range active {
+		if instance.Status == domain.CloudInstanceProvisioning {
+			booting++
+		} else {
+			running++
+		}
+	}
+
+	// Unassigned jobs are only handed out on request, so do it for the
+	// burst agents that have come online
+	if queued > 0 && running > 0 {
+		if err := s.jobUsecase.AssignJobsToAgents(domain.WithActor(ctx, domain.ActorSystem)); err != nil {
+			infrastructure.ServerLogger.Error("Failed to assign pending jobs: %v", err)
+		}
+		if queued, err = s.queueDepth(ctx); err != nil {
+			infrastructure.ServerLogger.Error("Failed to get pending jobs: %v", err)
+			return
+		}
+	}
+
+	// Instances still booting will take jobs soon
+	needed := queued - s.config.QueueThreshold - booting
+	if queued <= s.config.QueueThreshold || needed <= 0 {
+		return
+	}
+
+	allowed := s.config.MaxInstances - len(active)
+	if s.config.MaxHourlyCost > 0 {
+		spent := 0.0
+		for _, instance := range active {
+			spent += instance.HourlyCost
+		}
+		affordable := int(math.Floor((s.config.MaxHourlyCost - spent) / s.config.InstanceHourlyCost))
+		if affordable < allowed {
+			allowed = affordable
+		}
+	}
+
+	launch := needed
+	if launch > allowed {
+		infrastructure.ServerLogger.Warning("%d jobs are waiting but the caps allow only %d more burst instances", queued, max(allowed, 0))
+		launch = allowed
+	}
+
+	for i := 0; i < launch; i++ {
+		if err := s.launch(ctx); err != nil {
+			infrastructure.ServerLogger.Error("Failed to start %s instance: %v", s.provider.Name(), err)
+			return
+		}
+	}
+}
+
+// queueDepth counts the pending jobs that no agent has been chosen for
+func (s *autoScaler) queueDepth(ctx context.Context) (int, error) {
+	jobs, err := s.jobUsecase.GetJobsByStatus(ctx, domain.JobStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, job := range jobs {
+		if job.AgentID == nil {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// launch creates an agent key and starts an instance that runs the agent with it
+func (s *autoScaler) launch(ctx context.Context) error {
+	agentKey, err := generateAgentKey()
+	if err != nil {
+		return err
+	}
+	name := "hashcat-burst-" + agentKey
+
+	agent, err := s.agentUsecase.GenerateAgentKey(ctx, name, agentKey)
+	if err != nil {
+		return fmt.Errorf("failed to create agent key: %w", err)
+	}
+
+	providerID, err := s.provider.CreateInstance(ctx, domain.CloudInstanceSpec{
+		Name:     name,
+		UserData: s.bootScript(name, agentKey),
+	})
+	if err != nil {
+		if delErr := s.agentUsecase.DeleteAgent(ctx, agent.ID); delErr != nil {
+			infrastructure.ServerLogger.Error("Failed to delete unused agent key %s: %v", name, delErr)
+		}
+		return err
+	}
+
+	instance := &domain.CloudInstance{
+		Provider:   s.provider.Name(),
+		ProviderID: providerID,
+		AgentID:    agent.ID,
+		Status:     domain.CloudInstanceProvisioning,
+		HourlyCost: s.config.InstanceHourlyCost,
+	}
+	if err := s.instanceRepo.Create(ctx, instance); err != nil {
+		// An instance we don't track would never be deleted
+		if delErr := s.provider.DeleteInstance(ctx, providerID); delErr != nil {
+			infrastructure.ServerLogger.Error("Untracked %s instance %s left running, delete it manually: %v", s.provider.Name(), providerID, delErr)
+		}
+		if delErr := s.agentUsecase.DeleteAgent(ctx, agent.ID); delErr != nil {
+			infrastructure.ServerLogger.Error("Failed to delete unused agent key %s: %v", name, delErr)
+		}
+		return err
+	}
+
+	infrastructure.ServerLogger.Info("Started %s instance %s for burst agent %s", s.provider.Name(), providerID, name)
+	return nil
+}
+
+// bootScript installs the agent as a systemd service on first boot
+func (s *autoScaler) bootScript(name, agentKey string) string {
+	return fmt.Sprintf(`#!/bin/bash
+set -eu
+if ! command -v hashcat >/dev/null 2>&1; then
+  apt-get update && apt-get install -y hashcat
+fi
+curl -fsSL -o /usr/local/bin/hashcat-agent '%[1]s'
+chmod +x /usr/local/bin/hashcat-agent
+cat > /etc/systemd/system/hashcat-agent.service <<'UNIT'
+[Unit]
+Description=Distributed hashcat burst agent
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+ExecStart=/usr/local/bin/hashcat-agent --server %[2]s --name %[3]s --agent-key %[4]s
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+systemctl daemon-reload
+systemctl enable --now hashcat-agent
+`, s.config.AgentDownloadURL, s.config.ServerURL, name, agentKey)
+}
@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// backupIDFormat names backups after the time they were taken, so they sort
+// oldest first
+const backupIDFormat = "20060102T150405Z"
+
+// BackupConfig describes what is backed up and how many backups are kept
+type BackupConfig struct {
+	DatabaseType string
+	DatabasePath string
+	UploadDir    string
+	Keep         int // Most recent backups kept, 0 keeps all
+	// Verify checks a database copy for corruption and returns the last
+	// migration applied to it
+	Verify func(ctx context.Context, path string) (int, error)
+}
+
+// BackupUsecase writes backups of the database to a backup target and
+// restores them
+type BackupUsecase interface {
+	// CreateBackup copies the live database, checks the copy and stores it
+	// with a listing of the upload directory
+	CreateBackup(ctx context.Context) (*domain.Backup, error)
+	// ListBackups returns the complete backups at the target, newest first
+	ListBackups(ctx context.Context) ([]domain.Backup, error)
+	// RestoreBackup replaces the database file with a backup. The server
+	// must be stopped; the replaced database is kept next to it.
+	RestoreBackup(ctx context.Context, id string) (*domain.BackupRestoreResult, error)
+}
+
+type backupUsecase struct {
+	store    domain.BackupStore
+	database domain.DatabaseSnapshotter // Nil when only restoring
+	config   BackupConfig
+}
+
+func NewBackupUsecase(store domain.BackupStore, database domain.DatabaseSnapshotter, config BackupConfig) BackupUsecase {
+	return &backupUsecase{
+		store:    store,
+		database: database,
+		config:   config,
+	}
+}
+
+func backupKey(id, file string) string {
+	return id + "/" + file
+}
+
+func (u *backupUsecase) CreateBackup(ctx context.Context) (*domain.Backup, error) {
+	if u.database == nil {
+		return nil, fmt.Errorf("no database to back up")
+	}
+	backup := &domain.Backup{
+		ID:           time.Now().UTC().Format(backupIDFormat),
+		DatabaseType: u.config.DatabaseType,
+	}
+	backup.CreatedAt, _ = time.Parse(backupIDFormat, backup.ID)
+
+	tmpDir, err := os.MkdirTemp("", "hashcat-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := filepath.Join(tmpDir, domain.BackupDatabaseFile)
+	if err := u.database.Snapshot(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+	if backup.SchemaVersion, err = u.config.Verify(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("database copy failed the consistency check: %w", err)
+	}
+	if backup.DatabaseSize, backup.DatabaseSHA256, err = fileChecksum(snapshot); err != nil {
+		return nil, err
+	}
+	if backup.Uploads, err = u.listUploads(); err != nil {
+		return nil, fmt.Errorf("failed to list uploads: %w", err)
+	}
+
+	file, err := os.Open(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = u.store.Put(ctx, backupKey(backup.ID, domain.BackupDatabaseFile), file, backup.DatabaseSize)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store database: %w", err)
+	}
+
+	manifest, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := u.store.Put(ctx, backupKey(backup.ID, domain.BackupManifestFile), bytes.NewReader(manifest), int64(len(manifest))); err != nil {
+		u.store.Delete(ctx, backupKey(backup.ID, domain.BackupDatabaseFile))
+		return nil, fmt.Errorf("failed to store manifest: %w", err)
+	}
+
+	if err := u.prune(ctx); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to remove old backups: %v", err)
+	}
+	return backup, nil
+}
+
+// listUploads lists the files of the upload directory
+func (u *backupUsecase) listUploads() ([]domain.BackupUpload, error) {
+	uploads := []domain.BackupUpload{}
+	if u.config.UploadDir == "" {
+		return uploads, nil
+	}
+	err := filepath.WalkDir(u.config.UploadDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == u.config.UploadDir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(u.config.UploadDir, path)
+		if err != nil {
+			return err
+		}
+		uploads = append(uploads, domain.BackupUpload{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime().UTC()})
+		return nil
+	})
+	return uploads, err
+}
+
+// backupIDs returns the IDs of the complete backups, oldest first
+func (u *backupUsecase) backupIDs(ctx context.Context) ([]string, error) {
+	keys, err := u.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	var ids []string
+	for _, key := range keys {
+		if id, file, ok := strings.Cut(key, "/"); ok && file == domain.BackupManifestFile {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (u *backupUsecase) ListBackups(ctx context.Context) ([]domain.Backup, error) {
+	ids, err := u.backupIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]domain.Backup, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		backup, err := u.manifest(ctx, ids[i])
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *backup)
+	}
+	return backups, nil
+}
+
+func (u *backupUsecase) manifest(ctx context.Context, id string) (*domain.Backup, error) {
+	reader, err := u.store.Get(ctx, backupKey(id, domain.BackupManifestFile))
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, &domain.NotFoundError{Entity: "backup"}
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	var backup domain.Backup
+	if err := json.NewDecoder(reader).Decode(&backup); err != nil {
+		return nil, fmt.Errorf("invalid manifest of backup %s: %w", id, err)
+	}
+	return &backup, nil
+}
+
+// prune removes the oldest backups beyond the number kept
+func (u *backupUsecase) prune(ctx context.Context) error {
+	if u.config.Keep <= 0 {
+		return nil
+	}
+	ids, err := u.backupIDs(ctx)
+	if err != nil || len(ids) <= u.config.Keep {
+		return err
+	}
+	for _, id := range ids[:len(ids)-u.config.Keep] {
+		// The manifest goes first, so a half-removed backup isn't listed
+		for _, file := range []string{domain.BackupManifestFile, domain.BackupDatabaseFile} {
+			if err := u.store.Delete(ctx, backupKey(id, file)); err != nil {
+				return err
+			}
+		}
+		infrastructure.ServerLogger.Info("Removed backup %s", id)
+	}
+	return nil
+}
+
+func (u *backupUsecase) RestoreBackup(ctx context.Context, id string) (*domain.BackupRestoreResult, error) {
+	backup, err := u.manifest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if backup.DatabaseType != u.config.DatabaseType {
+		return nil, &domain.ValidationError{Field: "backup", Message: fmt.Sprintf("backup %s is of a %s database, not %s", id, backup.DatabaseType, u.config.DatabaseType)}
+	}
+
+	// Downloaded next to the database, so it can be renamed into place
+	dir := filepath.Dir(u.config.DatabasePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, ".restore-*.db")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	reader, err := u.store.Get(ctx, backupKey(id, domain.BackupDatabaseFile))
+	if err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to read database of backup %s: %w", id, err)
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), reader)
+	reader.Close()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download database of backup %s: %w", id, err)
+	}
+	if size != backup.DatabaseSize || hex.EncodeToString(hasher.Sum(nil)) != backup.DatabaseSHA256 {
+		return nil, fmt.Errorf("database of backup %s doesn't match its checksum", id)
+	}
+	if _, err := u.config.Verify(ctx, tmp.Name()); err != nil {
+		return nil, fmt.Errorf("database of backup %s failed the consistency check: %w", id, err)
+	}
+
+	result := &domain.BackupRestoreResult{Backup: backup, MissingUploads: []string{}, ChangedUploads: []string{}}
+	if _, err := os.Stat(u.config.DatabasePath); err == nil {
+		result.PreviousDatabase = u.config.DatabasePath + ".pre-restore-" + time.Now().UTC().Format(backupIDFormat)
+		// The write-ahead log holds committed changes of the replaced
+		// database, so it moves along instead of being applied to the backup
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Rename(u.config.DatabasePath+suffix, result.PreviousDatabase+suffix); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to move the current database aside: %w", err)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), u.config.DatabasePath); err != nil {
+		return nil, fmt.Errorf("failed to put the restored database in place: %w", err)
+	}
+
+	current, err := u.listUploads()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uploads: %w", err)
+	}
+	sizes := make(map[string]int64, len(current))
+	for _, upload := range current {
+		sizes[upload.Path] = upload.Size
+	}
+	for _, upload := range backup.Uploads {
+		size, ok := sizes[upload.Path]
+		switch {
+		case !ok:
+			result.MissingUploads = append(result.MissingUploads, upload.Path)
+		case size != upload.Size:
+			result.ChangedUploads = append(result.ChangedUploads, upload.Path)
+		}
+	}
+	return result, nil
+}
+
+// fileChecksum returns the size and SHA256 of a file
+func fileChecksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
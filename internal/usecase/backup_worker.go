@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// maxBackupCheckInterval bounds how long the backup worker sleeps, so a
+// backup is due soon after a restart rather than a full interval later
+const maxBackupCheckInterval = 10 * time.Minute
+
+// BackupWorker takes a backup whenever the newest one at the backup target
+// is older than the backup interval
+type BackupWorker interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context)
+}
+
+type backupWorker struct {
+	backupUsecase BackupUsecase
+	interval      time.Duration
+	ticker        *time.Ticker
+	done          chan struct{}
+}
+
+func NewBackupWorker(backupUsecase BackupUsecase, interval time.Duration) BackupWorker {
+	return &backupWorker{
+		backupUsecase: backupUsecase,
+		interval:      interval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (w *backupWorker) Start(ctx context.Context) {
+	if w.interval <= 0 {
+		infrastructure.ServerLogger.Info("Scheduled backups are off")
+		return
+	}
+	infrastructure.ServerLogger.Info("Starting Backup Worker (interval: %v)", w.interval)
+
+	w.ticker = time.NewTicker(min(w.interval, maxBackupCheckInterval))
+
+	go func() {
+		w.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-w.ticker.C:
+				w.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (w *backupWorker) Stop() {
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	select {
+	case <-w.done:
+		// Channel already closed
+	default:
+		close(w.done)
+	}
+}
+
+// RunOnce takes a backup if one is due
+func (w *backupWorker) RunOnce(ctx context.Context) {
+	backups, err := w.backupUsecase.ListBackups(ctx)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to list backups: %v", err)
+		return
+	}
+	if len(backups) > 0 && time.Since(backups[0].CreatedAt) < w.interval {
+		return
+	}
+
+	backup, err := w.backupUsecase.CreateBackup(ctx)
+	if err != nil {
+		infrastructure.ServerLogger.Error("Scheduled backup failed: %v", err)
+		return
+	}
+	infrastructure.ServerLogger.Info("Backup %s written (%d bytes, %d uploads listed)", backup.ID, backup.DatabaseSize, len(backup.Uploads))
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type CandidatePreviewUsecase interface {
+	// PreviewCandidates returns the first candidates an attack would try, so
+	// operators can check a wordlist, rule or mask before queueing a job
+	PreviewCandidates(ctx context.Context, req *domain.CandidatePreviewRequest) (*domain.CandidatePreview, error)
+}
+
+type candidatePreviewUsecase struct {
+	wordlistRepo domain.WordlistRepository
+	charsetRepo  domain.CharsetRepository
+	generator    domain.CandidateGenerator
+}
+
+func NewCandidatePreviewUsecase(wordlistRepo domain.WordlistRepository, charsetRepo domain.CharsetRepository, generator domain.CandidateGenerator) CandidatePreviewUsecase {
+	return &candidatePreviewUsecase{
+		wordlistRepo: wordlistRepo,
+		charsetRepo:  charsetRepo,
+		generator:    generator,
+	}
+}
+
+func (u *candidatePreviewUsecase) PreviewCandidates(ctx context.Context, req *domain.CandidatePreviewRequest) (*domain.CandidatePreview, error) {
+	ctx, span := startSpan(ctx, "CandidatePreviewUsecase.PreviewCandidates")
+	defer span.End()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = domain.DefaultCandidatePreviewLimit
+	}
+	if limit < 0 || limit > domain.MaxCandidatePreviewLimit {
+		return nil, &domain.ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", domain.MaxCandidatePreviewLimit)}
+	}
+
+	spec, err := u.resolveSpec(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, truncated, err := u.generator.Generate(ctx, *spec, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CandidatePreview{
+		Candidates: candidates,
+		Count:      len(candidates),
+		Limit:      limit,
+		Truncated:  truncated,
+	}, nil
+}
+
+// resolveSpec validates the request like a job's hashcat parameters and
+// turns its wordlist and rule references into absolute paths, since the
+// generator doesn't run in the server's working directory
+func (u *candidatePreviewUsecase) resolveSpec(ctx context.Context, req *domain.CandidatePreviewRequest) (*domain.CandidateSpec, error) {
+	if err := domain.ValidateAttackMode(req.AttackMode); err != nil {
+		return nil, err
+	}
+
+	spec := &domain.CandidateSpec{AttackMode: req.AttackMode}
+	charsets := []string{req.CustomCharset1, req.CustomCharset2, req.CustomCharset3, req.CustomCharset4}
+	if req.AttackMode == domain.AttackModeBruteForce {
+		if req.WordlistID != "" || req.Rules != "" {
+			return nil, &domain.ValidationError{Field: "attack_mode", Message: "brute-force previews take a mask and charsets only"}
+		}
+		if err := domain.ValidateMask(req.Mask); err != nil {
+			return nil, err
+		}
+		if err := domain.ValidateCustomCharsets(req.AttackMode, req.Mask, charsets...); err != nil {
+			return nil, err
+		}
+		spec.Mask = req.Mask
+		for _, charset := range charsets {
+			value, err := u.resolveCharset(ctx, charset)
+			if err != nil {
+				return nil, err
+			}
+			spec.CustomCharsets = append(spec.CustomCharsets, value)
+		}
+		return spec, nil
+	}
+
+	if err := domain.ValidateCustomCharsets(req.AttackMode, req.Mask, charsets...); err != nil {
+		return nil, err
+	}
+
+	if req.Mask != "" {
+		return nil, &domain.ValidationError{Field: "mask", Message: "is only used by brute-force attacks"}
+	}
+	wordlistID, err := uuid.Parse(req.WordlistID)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "wordlist_id", Message: "must be a wordlist UUID"}
+	}
+	wordlist, err := u.wordlistRepo.GetByID(ctx, wordlistID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, &domain.NotFoundError{Entity: "wordlist"}
+		}
+		return nil, fmt.Errorf("failed to get wordlist: %w", err)
+	}
+	if spec.WordlistPath, err = filepath.Abs(wordlist.Path); err != nil {
+		return nil, err
+	}
+
+	if err := domain.ValidateRuleReference(req.Rules); err != nil {
+		return nil, err
+	}
+	spec.Rules = req.Rules
+	return spec, nil
+}
+
+// resolveCharset turns a charset file UUID into the file's absolute path.
+// Inline charsets are returned unchanged.
+func (u *candidatePreviewUsecase) resolveCharset(ctx context.Context, charset string) (string, error) {
+	charsetID, err := uuid.Parse(charset)
+	if err != nil {
+		return charset, nil
+	}
+	charsetFile, err := u.charsetRepo.GetByID(ctx, charsetID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(charsetFile.Path)
+}
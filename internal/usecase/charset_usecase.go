@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type CharsetUsecase interface {
+	// UploadCharset stores a hashcat .hcchr charset file that jobs can
+	// reference by ID in their custom charsets
+	UploadCharset(ctx context.Context, name string, content io.Reader) (*domain.CharsetFile, error)
+	GetCharset(ctx context.Context, id uuid.UUID) (*domain.CharsetFile, error)
+	GetAllCharsets(ctx context.Context) ([]domain.CharsetFile, error)
+	DeleteCharset(ctx context.Context, id uuid.UUID) error
+}
+
+type charsetUsecase struct {
+	charsetRepo domain.CharsetRepository
+	uploadDir   string
+}
+
+func NewCharsetUsecase(charsetRepo domain.CharsetRepository, uploadDir string) CharsetUsecase {
+	return &charsetUsecase{
+		charsetRepo: charsetRepo,
+		uploadDir:   uploadDir,
+	}
+}
+
+func (u *charsetUsecase) UploadCharset(ctx context.Context, name string, content io.Reader) (*domain.CharsetFile, error) {
+	// Charset files are tiny, so read them whole and check the size up front
+	data, err := io.ReadAll(io.LimitReader(content, domain.MaxCharsetFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read charset file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, &domain.ValidationError{Field: "file", Message: "charset file is empty"}
+	}
+	if len(data) > domain.MaxCharsetFileSize {
+		return nil, &domain.ValidationError{Field: "file", Message: fmt.Sprintf("charset file is larger than %d bytes", domain.MaxCharsetFileSize)}
+	}
+
+	charsetDir := filepath.Join(u.uploadDir, "charsets")
+	if err := os.MkdirAll(charsetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create charset directory: %w", err)
+	}
+
+	fileID := uuid.New()
+	filename := fileID.String() + ".hcchr"
+	filePath := filepath.Join(charsetDir, filename)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	charset := &domain.CharsetFile{
+		ID:       fileID,
+		Name:     filename,
+		OrigName: name,
+		Path:     filePath,
+		Size:     int64(len(data)),
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+
+	if err := u.charsetRepo.Create(ctx, charset); err != nil {
+		// Clean up on error
+		os.Remove(filePath)
+		return nil, err
+	}
+
+	return charset, nil
+}
+
+func (u *charsetUsecase) GetCharset(ctx context.Context, id uuid.UUID) (*domain.CharsetFile, error) {
+	return u.charsetRepo.GetByID(ctx, id)
+}
+
+func (u *charsetUsecase) GetAllCharsets(ctx context.Context) ([]domain.CharsetFile, error) {
+	charsets, err := u.charsetRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get charset files: %w", err)
+	}
+	return charsets, nil
+}
+
+func (u *charsetUsecase) DeleteCharset(ctx context.Context, id uuid.UUID) error {
+	charset, err := u.charsetRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(charset.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete physical file: %w", err)
+	}
+
+	return u.charsetRepo.Delete(ctx, id)
+}
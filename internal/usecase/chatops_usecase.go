@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// chatJobsShown caps the jobs one message lists
+const chatJobsShown = 10
+
+// chatUsage answers help and commands the server doesn't know
+const chatUsage = "*Commands*\n" +
+	"`status` cluster overview\n" +
+	"`jobs [status]` jobs with a status, running by default\n" +
+	"`crack <hash file> <wordlist> [hash mode]` queue a wordlist attack; files by name or ID"
+
+// ChatOpsConfig says who may change jobs from a chat
+type ChatOpsConfig struct {
+	// AllowedUsers are the chat user IDs that may queue, pause and cancel
+	// jobs. Anyone who can use the command may when empty; anyone may read.
+	AllowedUsers []string
+}
+
+// ChatOpsUsecase answers chat commands and the buttons of their answers,
+// so a team can watch and steer jobs without opening the dashboard
+type ChatOpsUsecase interface {
+	HandleCommand(ctx context.Context, cmd domain.ChatCommand) (*domain.ChatMessage, error)
+	HandleAction(ctx context.Context, action domain.ChatAction) (*domain.ChatMessage, error)
+}
+
+type chatOpsUsecase struct {
+	jobUsecase   JobUsecase
+	statsUsecase StatsUsecase
+	hashFileRepo domain.HashFileRepository
+	config       ChatOpsConfig
+}
+
+func NewChatOpsUsecase(jobUsecase JobUsecase, statsUsecase StatsUsecase, hashFileRepo domain.HashFileRepository, config ChatOpsConfig) ChatOpsUsecase {
+	return &chatOpsUsecase{
+		jobUsecase:   jobUsecase,
+		statsUsecase: statsUsecase,
+		hashFileRepo: hashFileRepo,
+		config:       config,
+	}
+}
+
+// HandleCommand runs a command. Mistakes such as an unknown wordlist are
+// answered as messages; errors are left for failures of the server.
+func (u *chatOpsUsecase) HandleCommand(ctx context.Context, cmd domain.ChatCommand) (*domain.ChatMessage, error) {
+	ctx, span := startSpan(ctx, "ChatOpsUsecase.HandleCommand")
+	defer span.End()
+
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 {
+		return &domain.ChatMessage{Text: chatUsage}, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "status":
+		return u.status(ctx)
+	case "jobs":
+		status := domain.JobStatusRunning
+		if len(args) > 1 {
+			status = strings.ToLower(args[1])
+		}
+		return u.jobs(ctx, status)
+	case "crack":
+		if len(args) < 3 || len(args) > 4 {
+			return &domain.ChatMessage{Text: "Usage: `crack <hash file> <wordlist> [hash mode]`"}, nil
+		}
+		mode := ""
+		if len(args) == 4 {
+			mode = args[3]
+		}
+		return u.crack(ctx, cmd.User, args[1], args[2], mode)
+	default:
+		return &domain.ChatMessage{Text: chatUsage}, nil
+	}
+}
+
+// HandleAction pauses, resumes or cancels the job of a pressed button
+func (u *chatOpsUsecase) HandleAction(ctx context.Context, action domain.ChatAction) (*domain.ChatMessage, error) {
+	ctx, span := startSpan(ctx, "ChatOpsUsecase.HandleAction")
+	defer span.End()
+
+	if !u.mayChange(action.User) {
+		return chatRefused(), nil
+	}
+	id, err := uuid.Parse(action.Value)
+	if err != nil {
+		return &domain.ChatMessage{Text: "That button doesn't name a job."}, nil
+	}
+	ctx = domain.WithActor(ctx, action.User.Actor())
+
+	var done string
+	switch action.ActionID {
+	case domain.ChatActionPauseJob:
+		err, done = u.jobUsecase.PauseJob(ctx, id), "paused"
+	case domain.ChatActionResumeJob:
+		err, done = u.jobUsecase.ResumeJob(ctx, id), "resumed"
+	case domain.ChatActionCancelJob:
+		err, done = u.jobUsecase.CancelJob(ctx, id, "Cancelled from "+action.User.Chat+" by "+action.User.Name), "cancelled"
+	default:
+		return &domain.ChatMessage{Text: fmt.Sprintf("Unknown action %q.", action.ActionID)}, nil
+	}
+	if err != nil {
+		return chatFailure("Couldn't change the job", err)
+	}
+
+	job, err := u.jobUsecase.GetJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	text := fmt.Sprintf("%s %s *%s*", action.User.Name, done, job.Name)
+	return &domain.ChatMessage{Text: text, Public: true, Sections: []domain.ChatSection{chatJobSection(job, text)}}, nil
+}
+
+func (u *chatOpsUsecase) status(ctx context.Context) (*domain.ChatMessage, error) {
+	stats, err := u.statsUsecase.GetClusterStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster stats: %w", err)
+	}
+
+	jobs := stats.JobsByStatus
+	text := fmt.Sprintf("%d of %d agents active, %d jobs running", stats.Agents.Active, stats.Agents.Total, jobs[domain.JobStatusRunning])
+	return &domain.ChatMessage{
+		Text: text,
+		Sections: []domain.ChatSection{{
+			Text: "*Cluster status*",
+			Fields: []domain.ChatField{
+				{Label: "Agents", Value: fmt.Sprintf("%d of %d active", stats.Agents.Active, stats.Agents.Total)},
+				{Label: "Speed", Value: chatSpeed(stats.Agents.TotalSpeed)},
+				{Label: "Running", Value: strconv.Itoa(jobs[domain.JobStatusRunning])},
+				{Label: "Queued", Value: strconv.Itoa(jobs[domain.JobStatusPending] + jobs[domain.JobStatusAssigned] + jobs[domain.JobStatusInterrupted])},
+				{Label: "Paused", Value: strconv.Itoa(jobs[domain.JobStatusPaused])},
+				{Label: "Cracked in 24h", Value: strconv.Itoa(stats.CracksLast24h)},
+			},
+		}},
+	}, nil
+}
+
+func (u *chatOpsUsecase) jobs(ctx context.Context, status string) (*domain.ChatMessage, error) {
+	if !domain.IsJobStatus(status) {
+		return &domain.ChatMessage{Text: fmt.Sprintf("%q is not a job status.", status)}, nil
+	}
+	jobs, err := u.jobUsecase.GetJobsByStatus(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s jobs: %w", status, err)
+	}
+	if len(jobs) == 0 {
+		return &domain.ChatMessage{Text: fmt.Sprintf("No %s jobs.", status)}, nil
+	}
+
+	message := &domain.ChatMessage{Text: fmt.Sprintf("%d %s jobs", len(jobs), status)}
+	for i := range jobs {
+		if i == chatJobsShown {
+			message.Sections = append(message.Sections, domain.ChatSection{Text: fmt.Sprintf("…and %d more", len(jobs)-chatJobsShown)})
+			break
+		}
+		message.Sections = append(message.Sections, chatJobSection(&jobs[i], "*"+jobs[i].Name+"*"))
+	}
+	return message, nil
+}
+
+// crack queues a straight attack. The files are resolved like those of a
+// playbook attack, so they may be given by name.
+func (u *chatOpsUsecase) crack(ctx context.Context, user domain.ChatUser, hashFile, wordlist, mode string) (*domain.ChatMessage, error) {
+	if !u.mayChange(user) {
+		return chatRefused(), nil
+	}
+
+	hashType, err := u.hashMode(ctx, hashFile, mode)
+	if err != nil {
+		return chatFailure("Couldn't queue the attack", err)
+	}
+	attack := domain.PlaybookAttack{
+		Name:     fmt.Sprintf("%s with %s", hashFile, wordlist),
+		HashFile: hashFile,
+		Wordlist: wordlist,
+		HashType: &hashType,
+		Tags:     []string{user.Chat},
+	}
+	playbook := &domain.JobPlaybook{Version: domain.JobPlaybookVersion, Name: attack.Name, Attacks: []domain.PlaybookAttack{attack}}
+
+	ctx = domain.WithActor(ctx, user.Actor())
+	resolved, err := u.jobUsecase.ApplyJobPlaybook(ctx, playbook, "", true)
+	if err != nil {
+		return chatFailure("Couldn't queue the attack", err)
+	}
+	job, err := u.jobUsecase.CreateJob(ctx, &resolved.Attacks[0].Request)
+	if err != nil {
+		return chatFailure("Couldn't queue the attack", err)
+	}
+
+	text := fmt.Sprintf("%s queued *%s*, hash mode %d", user.Name, job.Name, job.HashType)
+	return &domain.ChatMessage{Text: text, Public: true, Sections: []domain.ChatSection{chatJobSection(job, text)}}, nil
+}
+
+// hashMode is the mode given, or for WPA captures, which can't be anything
+// else, 2500
+func (u *chatOpsUsecase) hashMode(ctx context.Context, hashFile, mode string) (int, error) {
+	if mode != "" {
+		hashType, err := strconv.Atoi(mode)
+		if err != nil || hashType < 0 {
+			return 0, &domain.ValidationError{Field: "hash mode", Message: fmt.Sprintf("%q is not a hashcat mode", mode)}
+		}
+		return hashType, nil
+	}
+
+	files, err := u.hashFileRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list hash files: %w", err)
+	}
+	for _, file := range files {
+		if file.ID.String() == hashFile || file.Name == hashFile || file.OrigName == hashFile {
+			if file.Type == "hccapx" || file.Type == "hccap" {
+				return 2500, nil
+			}
+			break
+		}
+	}
+	return 0, &domain.ValidationError{Field: "hash mode", Message: "is required, e.g. `crack hashes.txt rockyou.txt 1000`"}
+}
+
+// mayChange reports whether the user may queue and stop jobs
+func (u *chatOpsUsecase) mayChange(user domain.ChatUser) bool {
+	if len(u.config.AllowedUsers) == 0 {
+		return true
+	}
+	for _, id := range u.config.AllowedUsers {
+		if id == user.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func chatRefused() *domain.ChatMessage {
+	return &domain.ChatMessage{Text: "You may look at jobs, but not queue or stop them from here."}
+}
+
+// chatFailure turns the mistakes of a chat user into an answer for them,
+// and passes on other errors
+func chatFailure(what string, err error) (*domain.ChatMessage, error) {
+	var pErr *domain.PlaybookError
+	switch {
+	case errors.As(err, &pErr):
+		problems := make([]string, len(pErr.Problems))
+		for i := range pErr.Problems {
+			problems[i] = pErr.Problems[i].Message
+		}
+		return &domain.ChatMessage{Text: what + ": " + strings.Join(problems, "; ")}, nil
+	case domain.IsValidationError(err), domain.IsNotFoundError(err), domain.IsJobTransitionError(err), domain.IsQuotaExceededError(err):
+		return &domain.ChatMessage{Text: what + ": " + err.Error()}, nil
+	default:
+		return nil, err
+	}
+}
+
+// chatJobSection shows a job with the buttons its status allows
+func chatJobSection(job *domain.Job, text string) domain.ChatSection {
+	progress := job.NormalizedProgress
+	if progress == 0 {
+		progress = job.Progress
+	}
+	eta := "-"
+	if job.ETA != nil && job.Status == domain.JobStatusRunning {
+		eta = time.Until(*job.ETA).Round(time.Minute).String()
+	}
+
+	section := domain.ChatSection{
+		Text: text,
+		Fields: []domain.ChatField{
+			{Label: "Status", Value: job.Status},
+			{Label: "Progress", Value: fmt.Sprintf("%.1f%%", progress)},
+			{Label: "Speed", Value: chatSpeed(job.Speed)},
+			{Label: "ETA", Value: eta},
+		},
+	}
+	value := job.ID.String()
+	if domain.CanTransitionJob(job.Status, domain.JobStatusPaused) {
+		section.Buttons = append(section.Buttons, domain.ChatButton{Text: "Pause", ActionID: domain.ChatActionPauseJob, Value: value})
+	}
+	if job.Status == domain.JobStatusPaused {
+		section.Buttons = append(section.Buttons, domain.ChatButton{Text: "Resume", ActionID: domain.ChatActionResumeJob, Value: value})
+	}
+	if domain.CanTransitionJob(job.Status, domain.JobStatusCancelled) {
+		section.Buttons = append(section.Buttons, domain.ChatButton{Text: "Cancel", ActionID: domain.ChatActionCancelJob, Value: value, Danger: true})
+	}
+	return section
+}
+
+func chatSpeed(hps int64) string {
+	switch {
+	case hps >= 1_000_000_000:
+		return fmt.Sprintf("%.2f GH/s", float64(hps)/1_000_000_000)
+	case hps >= 1_000_000:
+		return fmt.Sprintf("%.2f MH/s", float64(hps)/1_000_000)
+	case hps >= 1_000:
+		return fmt.Sprintf("%.2f kH/s", float64(hps)/1_000)
+	default:
+		return fmt.Sprintf("%d H/s", hps)
+	}
+}
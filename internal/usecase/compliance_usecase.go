@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMinWordLength = 4
+	maxDictionaryWords   = 1000000 // Words of a dictionary wordlist held in memory at most
+	maxDictionaryWordLen = 32      // Longer words are skipped, as are longer substrings of passwords
+)
+
+// commonPasswordWords are the words passwords are most often built on
+var commonPasswordWords = []string{
+	"password", "passwort", "welcome", "letmein", "changeme", "secret", "admin", "administrator", "login", "guest",
+	"qwerty", "asdf", "zxcv", "test", "user", "default", "master", "monkey", "dragon", "shadow", "sunshine",
+	"princess", "football", "baseball", "soccer", "hockey", "iloveyou", "trustno", "superman", "batman", "hello",
+	"spring", "summer", "autumn", "winter", "january", "february", "march", "april", "june", "july", "august",
+	"september", "october", "november", "december", "monday", "tuesday", "wednesday", "thursday", "friday",
+	"saturday", "sunday", "company", "office", "service", "backup", "temp",
+}
+
+// ignoredDomainLabels are domain labels too generic to be a dictionary word
+var ignoredDomainLabels = map[string]bool{"local": true, "lan": true, "internal": true, "intra": true, "com": true, "net": true, "org": true}
+
+// leetspeak undoes the common character substitutions before dictionary
+// words are looked for
+var leetspeak = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+type ComplianceUsecase interface {
+	// Policy returns the configured password policy
+	Policy() domain.PasswordPolicy
+	// GetComplianceReport checks the cracked credentials of a project, or of
+	// every project when projectID is nil, against a password policy
+	GetComplianceReport(ctx context.Context, projectID *uuid.UUID, policy domain.PasswordPolicy) (*domain.ComplianceReport, error)
+}
+
+type complianceUsecase struct {
+	credentialRepo domain.CredentialRepository
+	wordlistRepo   domain.WordlistRepository
+	policy         domain.PasswordPolicy
+}
+
+func NewComplianceUsecase(credentialRepo domain.CredentialRepository, wordlistRepo domain.WordlistRepository, policy domain.PasswordPolicy) ComplianceUsecase {
+	return &complianceUsecase{
+		credentialRepo: credentialRepo,
+		wordlistRepo:   wordlistRepo,
+		policy:         policy,
+	}
+}
+
+func (u *complianceUsecase) Policy() domain.PasswordPolicy {
+	return u.policy
+}
+
+func (u *complianceUsecase) GetComplianceReport(ctx context.Context, projectID *uuid.UUID, policy domain.PasswordPolicy) (*domain.ComplianceReport, error) {
+	if err := validatePolicy(&policy); err != nil {
+		return nil, err
+	}
+	dictionary, err := u.dictionary(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	credentials, _, err := u.credentialRepo.GetAll(ctx, domain.CredentialFilter{ProjectID: projectID})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ComplianceReport{
+		ProjectID:    projectID,
+		Policy:       policy,
+		Violations:   []domain.PolicyViolation{},
+		NonCompliant: []domain.NonCompliantAccount{},
+		GeneratedAt:  time.Now(),
+	}
+	broken := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, credential := range credentials {
+		// An account cracked with the same password in several files counts once
+		key, name := credentialAccount(credential)
+		if seen[key+"\x00"+credential.Plaintext] {
+			continue
+		}
+		seen[key+"\x00"+credential.Plaintext] = true
+		report.Credentials++
+
+		rules := checkPolicy(policy, dictionary, credential)
+		if len(rules) == 0 {
+			report.Compliant++
+			continue
+		}
+		for _, rule := range rules {
+			broken[rule]++
+		}
+		report.NonCompliant = append(report.NonCompliant, domain.NonCompliantAccount{
+			Account: name,
+			Source:  credential.SourceFile,
+			Length:  utf8.RuneCountInString(credential.Plaintext),
+			Rules:   rules,
+		})
+	}
+	report.Percent = percentOf(report.Compliant, report.Credentials)
+
+	for _, rule := range policyRules(policy) {
+		report.Violations = append(report.Violations, domain.PolicyViolation{
+			Rule:    rule,
+			Count:   broken[rule],
+			Percent: percentOf(broken[rule], report.Credentials),
+		})
+	}
+	sort.SliceStable(report.Violations, func(i, j int) bool { return report.Violations[i].Count > report.Violations[j].Count })
+	sort.Slice(report.NonCompliant, func(i, j int) bool {
+		if report.NonCompliant[i].Account != report.NonCompliant[j].Account {
+			return report.NonCompliant[i].Account < report.NonCompliant[j].Account
+		}
+		return report.NonCompliant[i].Source < report.NonCompliant[j].Source
+	})
+	return report, nil
+}
+
+func validatePolicy(policy *domain.PasswordPolicy) error {
+	if policy.MinLength < 0 {
+		return &domain.ValidationError{Field: "min_length", Message: "must not be negative"}
+	}
+	if policy.MinClasses < 0 || policy.MinClasses > 4 {
+		return &domain.ValidationError{Field: "min_classes", Message: "must be between 0 and 4"}
+	}
+	for _, class := range policy.RequiredClasses {
+		switch class {
+		case domain.PasswordClassLower, domain.PasswordClassUpper, domain.PasswordClassDigit, domain.PasswordClassSpecial:
+		default:
+			return &domain.ValidationError{Field: "required_classes", Message: fmt.Sprintf("unknown class %q, expected lower, upper, digit or special", class)}
+		}
+	}
+	if policy.MinWordLength < 0 {
+		return &domain.ValidationError{Field: "min_word_length", Message: "must not be negative"}
+	}
+	if policy.CheckDictionary && policy.MinWordLength == 0 {
+		policy.MinWordLength = defaultMinWordLength
+	}
+	return nil
+}
+
+// policyRules lists the rules a policy turns on
+func policyRules(policy domain.PasswordPolicy) []string {
+	var rules []string
+	if policy.MinLength > 0 {
+		rules = append(rules, domain.PolicyRuleMinLength)
+	}
+	if policy.MinClasses > 0 {
+		rules = append(rules, domain.PolicyRuleMinClasses)
+	}
+	if len(policy.RequiredClasses) > 0 {
+		rules = append(rules, domain.PolicyRuleRequiredClasses)
+	}
+	if policy.CheckDictionary {
+		rules = append(rules, domain.PolicyRuleDictionaryWord)
+	}
+	if policy.CheckUsername {
+		rules = append(rules, domain.PolicyRuleUsername)
+	}
+	return rules
+}
+
+// dictionary returns the words of the dictionary check, lowercased letters
+// only, or nil when the policy doesn't check for words
+func (u *complianceUsecase) dictionary(ctx context.Context, policy domain.PasswordPolicy) (map[string]bool, error) {
+	if !policy.CheckDictionary {
+		return nil, nil
+	}
+	words := make(map[string]bool)
+	add := func(word string) {
+		if word = lettersOnly(strings.ToLower(word)); len(word) >= policy.MinWordLength && len(word) <= maxDictionaryWordLen {
+			words[word] = true
+		}
+	}
+	for _, word := range commonPasswordWords {
+		add(word)
+	}
+	if policy.DictionaryWordlistID == nil {
+		return words, nil
+	}
+
+	wordlist, err := u.wordlistRepo.GetByID(ctx, *policy.DictionaryWordlistID)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "dictionary_wordlist_id", Message: "wordlist not found"}
+	}
+	file, err := os.Open(wordlist.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary wordlist: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && len(words) < maxDictionaryWords {
+		add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dictionary wordlist: %w", err)
+	}
+	return words, nil
+}
+
+// dictionaryForm lowercases s, undoes leetspeak and keeps only the letters
+func dictionaryForm(s string) string {
+	return lettersOnly(leetspeak.Replace(strings.ToLower(s)))
+}
+
+func lettersOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// checkPolicy returns the rules of the policy a credential's password breaks
+func checkPolicy(policy domain.PasswordPolicy, dictionary map[string]bool, credential domain.Credential) []string {
+	password := credential.Plaintext
+	var rules []string
+	if policy.MinLength > 0 && utf8.RuneCountInString(password) < policy.MinLength {
+		rules = append(rules, domain.PolicyRuleMinLength)
+	}
+
+	present := make(map[string]bool)
+	for _, class := range passwordClasses(password) {
+		if class == domain.PasswordClassOther {
+			class = domain.PasswordClassSpecial
+		}
+		present[class] = true
+	}
+	if policy.MinClasses > 0 && len(present) < policy.MinClasses {
+		rules = append(rules, domain.PolicyRuleMinClasses)
+	}
+	for _, class := range policy.RequiredClasses {
+		if !present[class] {
+			rules = append(rules, domain.PolicyRuleRequiredClasses)
+			break
+		}
+	}
+
+	// Passwords are searched as written and with leetspeak undone
+	forms := []string{lettersOnly(strings.ToLower(password)), dictionaryForm(password)}
+	if policy.CheckDictionary && containsDictionaryWord(forms, dictionary, credential.Domain, policy.MinWordLength) {
+		rules = append(rules, domain.PolicyRuleDictionaryWord)
+	}
+	if policy.CheckUsername && containsUsername(password, forms, credential.Username) {
+		rules = append(rules, domain.PolicyRuleUsername)
+	}
+	return rules
+}
+
+// containsDictionaryWord reports whether any of the forms of a password has
+// a dictionary word, or a label of the account's domain, in it
+func containsDictionaryWord(forms []string, dictionary map[string]bool, accountDomain string, minLength int) bool {
+	var labels []string
+	for _, label := range strings.FieldsFunc(strings.ToLower(accountDomain), func(r rune) bool { return r == '.' || r == '\\' }) {
+		if label = lettersOnly(label); len(label) >= minLength && !ignoredDomainLabels[label] {
+			labels = append(labels, label)
+		}
+	}
+	for _, form := range forms {
+		for _, label := range labels {
+			if strings.Contains(form, label) {
+				return true
+			}
+		}
+		for start := 0; start < len(form); start++ {
+			for end := start + minLength; end <= len(form) && end-start <= maxDictionaryWordLen; end++ {
+				if dictionary[form[start:end]] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// containsUsername reports whether a password holds the username, or a part
+// of it of three letters or more such as "smith" of "j.smith"
+func containsUsername(password string, forms []string, username string) bool {
+	username = strings.ToLower(username)
+	if len(username) >= 3 && strings.Contains(strings.ToLower(password), username) {
+		return true
+	}
+	parts := strings.FieldsFunc(username, func(r rune) bool { return r < 'a' || r > 'z' })
+	if joined := lettersOnly(username); len(parts) > 1 {
+		parts = append(parts, joined)
+	}
+	for _, part := range parts {
+		if len(part) < 3 {
+			continue
+		}
+		for _, form := range forms {
+			if strings.Contains(form, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
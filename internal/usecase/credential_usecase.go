@@ -0,0 +1,520 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTopPasswords = 10
+	maxTopPasswords     = 100
+)
+
+// CredentialRecorder records the password of a cracked job as credentials
+type CredentialRecorder interface {
+	RecordCrackedJob(ctx context.Context, job *domain.Job, password string) error
+	// RecordJobCracks records the hashes a running job cracked, as its agent
+	// reported them
+	RecordJobCracks(ctx context.Context, job *domain.Job, cracks []domain.JobCrack) error
+}
+
+// CredentialUsecase links cracked passwords back to the accounts of the hash
+// files they were found in, and summarises them for findings reports
+type CredentialUsecase interface {
+	CredentialRecorder
+	// ImportPotfile records the credentials of a hash file from a hashcat
+	// potfile or --show output, returning how many were new. hashType is
+	// stored with them and may be 0 when unknown.
+	ImportPotfile(ctx context.Context, hashFileID uuid.UUID, hashType int, content io.Reader) (int, error)
+	GetCredentials(ctx context.Context, filter domain.CredentialFilter) ([]domain.Credential, int, error)
+	// GetStats summarises the credentials matching the filter, listing the
+	// top most used passwords
+	GetStats(ctx context.Context, filter domain.CredentialFilter, top int) (*domain.CredentialStats, error)
+}
+
+type credentialUsecase struct {
+	credentialRepo domain.CredentialRepository
+	hashFiles      HashFileUsecase
+	ntdsRepo       domain.NTDSRepository
+}
+
+// NewCredentialUsecase reads hash files through hashFiles, so encrypted ones
+// are decrypted. The hash files of NTDS imports hold bare NT hashes, whose
+// usernames are looked up in ntdsRepo.
+func NewCredentialUsecase(credentialRepo domain.CredentialRepository, hashFiles HashFileUsecase, ntdsRepo domain.NTDSRepository) CredentialUsecase {
+	return &credentialUsecase{
+		credentialRepo: credentialRepo,
+		hashFiles:      hashFiles,
+		ntdsRepo:       ntdsRepo,
+	}
+}
+
+// hashAccount is an account using a hash of a hash file
+type hashAccount struct {
+	username string
+	domain   string
+	hash     string // As written in the hash file
+	hashType int    // Known for NT hashes, 0 otherwise
+}
+
+// hashIndex maps the lowercased hashes of a hash file to their accounts
+type hashIndex struct {
+	hashFile *domain.HashFile
+	accounts map[string][]hashAccount
+	only     []hashAccount // The accounts of the file's only line, if it has one
+	imports  []uuid.UUID   // NTDS imports of the file
+}
+
+// indexHashFile reads the accounts of a hash file. Lines are secretsdump
+// output, user:hash as cracked with --username, or bare hashes; a line
+// with a colon could be either of the last two, so both are indexed.
+// Captures, such as hccapx files, have no lines to index.
+func (u *credentialUsecase) indexHashFile(ctx context.Context, id uuid.UUID) (*hashIndex, error) {
+	hashFile, content, err := u.hashFiles.OpenHashFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	index := &hashIndex{hashFile: hashFile, accounts: make(map[string][]hashAccount)}
+	add := func(key string, account hashAccount) {
+		key = strings.ToLower(key)
+		index.accounts[key] = append(index.accounts[key], account)
+	}
+	if hashFile.Type == "hash" {
+		lines := 0
+		scanner := bufio.NewScanner(content)
+		scanner.Buffer(make([]byte, 64*1024), maxNTDSLineLength)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var accounts []hashAccount
+			if account, ok := parseSecretsdumpLine(line, ""); ok {
+				accounts = append(accounts, hashAccount{username: account.Username, domain: account.Domain, hash: account.NTHash, hashType: domain.NTLMHashType})
+			} else {
+				accounts = append(accounts, hashAccount{hash: line})
+				if user, hash, ok := strings.Cut(line, ":"); ok && user != "" && hash != "" {
+					account := hashAccount{username: user, hash: hash}
+					if i := strings.LastIndex(user, `\`); i >= 0 {
+						account.domain, account.username = user[:i], user[i+1:]
+					}
+					accounts = append(accounts, account)
+				}
+			}
+			for _, account := range accounts {
+				add(account.hash, account)
+			}
+			if lines++; lines == 1 {
+				index.only = accounts
+			} else {
+				index.only = nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read hash file: %w", err)
+		}
+	}
+
+	// The hash files of NTDS imports hold only the hashes
+	imports, err := u.ntdsRepo.GetAll(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NTDS imports: %w", err)
+	}
+	for _, ntdsImport := range imports {
+		if ntdsImport.HashFileID == nil || *ntdsImport.HashFileID != id {
+			continue
+		}
+		index.imports = append(index.imports, ntdsImport.ID)
+		accounts, _, err := u.ntdsRepo.GetAccounts(ctx, ntdsImport.ID, domain.NTDSAccountFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			add(account.NTHash, hashAccount{username: account.Username, domain: account.Domain, hash: account.NTHash, hashType: domain.NTLMHashType})
+		}
+	}
+	return index, nil
+}
+
+// lookup returns the accounts using a hash. When some of them have a
+// username the bare hash entries are left out.
+func (index *hashIndex) lookup(hash string) []hashAccount {
+	return namedAccounts(index.accounts[strings.ToLower(hash)])
+}
+
+func namedAccounts(accounts []hashAccount) []hashAccount {
+	var named []hashAccount
+	for _, account := range accounts {
+		if account.username != "" {
+			named = append(named, account)
+		}
+	}
+	if len(named) == 0 {
+		return accounts
+	}
+	return named
+}
+
+// credentials turns the accounts using a cracked hash into credentials
+func (index *hashIndex) credentials(accounts []hashAccount, plaintext string, hashType int, source string, crackedAt time.Time) []domain.Credential {
+	credentials := make([]domain.Credential, 0, len(accounts))
+	for _, account := range accounts {
+		credential := domain.Credential{
+			Username:   account.username,
+			Domain:     account.domain,
+			Hash:       account.hash,
+			Plaintext:  plaintext,
+			HashType:   hashType,
+			HashFileID: &index.hashFile.ID,
+			SourceFile: index.hashFile.OrigName,
+			Source:     source,
+			ProjectID:  index.hashFile.ProjectID,
+			CrackedAt:  crackedAt,
+		}
+		if account.hashType != 0 {
+			credential.HashType = account.hashType
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+// RecordCrackedJob links the password a job found to the accounts of its
+// hash file. A job result holds no hash, so the hash is computed from the
+// password for the modes computeHash knows; for other modes only the
+// password of a single-hash file can be linked.
+func (u *credentialUsecase) RecordCrackedJob(ctx context.Context, job *domain.Job, password string) error {
+	if job.HashFileID == nil {
+		return nil
+	}
+	index, err := u.indexHashFile(ctx, *job.HashFileID)
+	if err != nil {
+		return err
+	}
+
+	accounts := namedAccounts(index.only)
+	if hash, ok := computeHash(job.HashType, password); ok {
+		accounts = index.lookup(hash)
+	}
+	if len(accounts) == 0 {
+		jobLogger(ctx, job.ID).Debug("Cracked password matches no hash of hash file %s", index.hashFile.ID)
+		return nil
+	}
+
+	crackedAt := time.Now()
+	if job.CompletedAt != nil {
+		crackedAt = *job.CompletedAt
+	}
+	credentials := index.credentials(accounts, password, job.HashType, domain.CredentialSourceJob, crackedAt)
+	for i := range credentials {
+		credentials[i].JobID = &job.ID
+	}
+	_, err = u.credentialRepo.Record(ctx, credentials)
+	return err
+}
+
+// RecordJobCracks finds the accounts of each crack by the hash the engine
+// printed, which works for salted hashes too. Engines print some hashes
+// differently from the hash file; those are matched by the password's hash
+// like RecordCrackedJob does.
+func (u *credentialUsecase) RecordJobCracks(ctx context.Context, job *domain.Job, cracks []domain.JobCrack) error {
+	if job.HashFileID == nil || len(cracks) == 0 {
+		return nil
+	}
+	index, err := u.indexHashFile(ctx, *job.HashFileID)
+	if err != nil {
+		return err
+	}
+
+	var credentials []domain.Credential
+	for _, crack := range cracks {
+		accounts := index.lookup(crack.Hash)
+		if len(accounts) == 0 {
+			if hash, ok := computeHash(job.HashType, crack.Plaintext); ok {
+				accounts = index.lookup(hash)
+			} else {
+				accounts = namedAccounts(index.only)
+			}
+		}
+		if len(accounts) == 0 {
+			jobLogger(ctx, job.ID).Debug("Cracked hash %s matches no hash of hash file %s", crack.Hash, index.hashFile.ID)
+			continue
+		}
+		for _, credential := range index.credentials(accounts, crack.Plaintext, job.HashType, domain.CredentialSourceJob, crack.CrackedAt) {
+			credential.JobID = &job.ID
+			credentials = append(credentials, credential)
+		}
+	}
+	if len(credentials) == 0 {
+		return nil
+	}
+	_, err = u.credentialRepo.Record(ctx, credentials)
+	return err
+}
+
+// computeHash returns the hash of a password in the unsalted hashcat modes
+// whose hash is the password's digest
+func computeHash(hashType int, password string) (string, bool) {
+	switch hashType {
+	case 0:
+		sum := md5.Sum([]byte(password))
+		return hex.EncodeToString(sum[:]), true
+	case 100:
+		sum := sha1.Sum([]byte(password))
+		return hex.EncodeToString(sum[:]), true
+	case 1400:
+		sum := sha256.Sum256([]byte(password))
+		return hex.EncodeToString(sum[:]), true
+	case 1700:
+		sum := sha512.Sum512([]byte(password))
+		return hex.EncodeToString(sum[:]), true
+	case domain.NTLMHashType:
+		return ntHash(password), true
+	}
+	return "", false
+}
+
+func (u *credentialUsecase) ImportPotfile(ctx context.Context, hashFileID uuid.UUID, hashType int, content io.Reader) (int, error) {
+	index, err := u.indexHashFile(ctx, hashFileID)
+	if err != nil {
+		return 0, err
+	}
+
+	var credentials []domain.Credential
+	var ntHashes []string
+	lines := 0
+	now := time.Now()
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 64*1024), maxNTDSLineLength)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		lines++
+		// Salted hashes have colons too, so the longest known hash wins
+		for i := strings.LastIndex(line, ":"); i > 0; i = strings.LastIndex(line[:i], ":") {
+			accounts := index.lookup(line[:i])
+			if len(accounts) == 0 {
+				continue
+			}
+			credentials = append(credentials, index.credentials(accounts, decodeHexPlaintext(line[i+1:]), hashType, domain.CredentialSourcePotfile, now)...)
+			if accounts[0].hashType == domain.NTLMHashType {
+				ntHashes = append(ntHashes, accounts[0].hash)
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return 0, &domain.ValidationError{Field: "body", Message: fmt.Sprintf("has a line longer than %d bytes", maxNTDSLineLength)}
+		}
+		return 0, err
+	}
+	if lines == 0 {
+		return 0, &domain.ValidationError{Field: "body", Message: "no cracked hashes found, expected potfile lines such as <hash>:<password>"}
+	}
+
+	recorded, err := u.credentialRepo.Record(ctx, credentials)
+	if err != nil {
+		return 0, err
+	}
+	// Keep the reports of NTDS imports of the file in step
+	for _, importID := range index.imports {
+		if _, err := u.ntdsRepo.MarkCracked(ctx, importID, ntHashes, now); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to mark accounts of NTDS import %s cracked: %v", importID, err)
+		}
+	}
+	return recorded, nil
+}
+
+// decodeHexPlaintext decodes the $HEX[...] form hashcat writes passwords
+// with colons or non-printable characters in
+func decodeHexPlaintext(plaintext string) string {
+	if encoded, ok := strings.CutPrefix(plaintext, "$HEX["); ok {
+		if encoded, ok = strings.CutSuffix(encoded, "]"); ok {
+			if decoded, err := hex.DecodeString(encoded); err == nil {
+				return string(decoded)
+			}
+		}
+	}
+	return plaintext
+}
+
+func (u *credentialUsecase) GetCredentials(ctx context.Context, filter domain.CredentialFilter) ([]domain.Credential, int, error) {
+	return u.credentialRepo.GetAll(ctx, filter)
+}
+
+func (u *credentialUsecase) GetStats(ctx context.Context, filter domain.CredentialFilter, top int) (*domain.CredentialStats, error) {
+	if top <= 0 {
+		top = defaultTopPasswords
+	}
+	if top > maxTopPasswords {
+		top = maxTopPasswords
+	}
+	filter.Limit, filter.Offset = 0, 0
+	credentials, _, err := u.credentialRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.CredentialStats{
+		Reused:       []domain.PasswordReuse{},
+		TopPasswords: []domain.PasswordCount{},
+		Lengths:      []domain.LengthCount{},
+		Charsets:     []domain.CharsetCount{},
+		GeneratedAt:  time.Now(),
+	}
+	// An account cracked in several hash files counts once per password
+	type pair struct{ account, plaintext string }
+	seen := make(map[pair]bool)
+	accounts := make(map[string]bool)
+	users := make(map[string]map[string]string) // Password to account keys to display names
+	lengths := make(map[int]int)
+	charsets := make(map[string]int)
+	for _, credential := range credentials {
+		key, name := credentialAccount(credential)
+		if seen[pair{key, credential.Plaintext}] {
+			continue
+		}
+		seen[pair{key, credential.Plaintext}] = true
+		accounts[key] = true
+		if users[credential.Plaintext] == nil {
+			users[credential.Plaintext] = make(map[string]string)
+		}
+		users[credential.Plaintext][key] = name
+		lengths[utf8.RuneCountInString(credential.Plaintext)]++
+		charsets[passwordCharset(credential.Plaintext)]++
+	}
+	stats.Credentials = len(seen)
+	stats.Accounts = len(accounts)
+	stats.UniquePasswords = len(users)
+
+	reused := make(map[string]bool)
+	for password, names := range users {
+		stats.TopPasswords = append(stats.TopPasswords, domain.PasswordCount{
+			Password: password,
+			Count:    len(names),
+			Share:    float64(len(names)) / float64(stats.Credentials),
+		})
+		if len(names) < 2 {
+			continue
+		}
+		reuse := domain.PasswordReuse{Password: password}
+		for key, name := range names {
+			reused[key] = true
+			reuse.Accounts = append(reuse.Accounts, name)
+		}
+		sort.Strings(reuse.Accounts)
+		stats.Reused = append(stats.Reused, reuse)
+	}
+	stats.ReusedAccounts = len(reused)
+	sort.Slice(stats.TopPasswords, func(i, j int) bool {
+		if stats.TopPasswords[i].Count != stats.TopPasswords[j].Count {
+			return stats.TopPasswords[i].Count > stats.TopPasswords[j].Count
+		}
+		return stats.TopPasswords[i].Password < stats.TopPasswords[j].Password
+	})
+	if len(stats.TopPasswords) > top {
+		stats.TopPasswords = stats.TopPasswords[:top]
+	}
+	sort.Slice(stats.Reused, func(i, j int) bool {
+		if len(stats.Reused[i].Accounts) != len(stats.Reused[j].Accounts) {
+			return len(stats.Reused[i].Accounts) > len(stats.Reused[j].Accounts)
+		}
+		return stats.Reused[i].Password < stats.Reused[j].Password
+	})
+
+	for length, count := range lengths {
+		stats.Lengths = append(stats.Lengths, domain.LengthCount{Length: length, Count: count, Share: float64(count) / float64(stats.Credentials)})
+	}
+	sort.Slice(stats.Lengths, func(i, j int) bool { return stats.Lengths[i].Length < stats.Lengths[j].Length })
+	for charset, count := range charsets {
+		stats.Charsets = append(stats.Charsets, domain.CharsetCount{Charset: charset, Count: count, Share: float64(count) / float64(stats.Credentials)})
+	}
+	sort.Slice(stats.Charsets, func(i, j int) bool {
+		if stats.Charsets[i].Count != stats.Charsets[j].Count {
+			return stats.Charsets[i].Count > stats.Charsets[j].Count
+		}
+		return stats.Charsets[i].Charset < stats.Charsets[j].Charset
+	})
+	return stats, nil
+}
+
+// credentialAccount returns the key an account is counted under and the
+// name it is listed as. Credentials without a username are an account of
+// their own, named by their hash.
+func credentialAccount(credential domain.Credential) (string, string) {
+	if credential.Username == "" {
+		return "hash:" + credential.SourceFile + ":" + credential.Hash, credential.Hash
+	}
+	name := credential.Username
+	if credential.Domain != "" {
+		name = credential.Domain + `\` + credential.Username
+	}
+	return "user:" + strings.ToLower(name), name
+}
+
+// passwordCharset names the character classes a password is made of, such
+// as "lower+digit"
+func passwordCharset(password string) string {
+	if password == "" {
+		return "empty"
+	}
+	return strings.Join(passwordClasses(password), "+")
+}
+
+// passwordClasses returns the character classes of a password in the order
+// lower, upper, digit, special, other
+func passwordClasses(password string) []string {
+	var lower, upper, digit, special, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 0x20 && r < 0x7f:
+			special = true
+		default:
+			other = true
+		}
+	}
+	var classes []string
+	for _, class := range []struct {
+		name    string
+		present bool
+	}{
+		{domain.PasswordClassLower, lower},
+		{domain.PasswordClassUpper, upper},
+		{domain.PasswordClassDigit, digit},
+		{domain.PasswordClassSpecial, special},
+		{domain.PasswordClassOther, other},
+	} {
+		if class.present {
+			classes = append(classes, class.name)
+		}
+	}
+	return classes
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// demoTick is how often the simulated agents send a heartbeat and report
+	// progress
+	demoTick = 2 * time.Second
+	// demoJobDuration is how long a simulated agent takes for any job
+	demoJobDuration = time.Minute
+)
+
+// simulatedRun is a job a simulated agent is working on
+type simulatedRun struct {
+	job      *domain.Job
+	progress float64
+}
+
+func (u *demoUsecase) RunSimulator(ctx context.Context) {
+	infrastructure.ServerLogger.Info("Demo mode: simulating the agents whose capabilities start with %q", domain.SimulatedAgentCapabilities)
+
+	runs := make(map[uuid.UUID]*simulatedRun)
+	ticker := time.NewTicker(demoTick)
+	defer ticker.Stop()
+	for {
+		// Queued jobs are handed out as POST /jobs/assign would, so the
+		// simulated agents find them assigned
+		if err := u.jobUsecase.AssignJobsToAgents(ctx); err != nil {
+			infrastructure.ServerLogger.Warning("Demo mode: failed to assign jobs: %v", err)
+		}
+		agents, err := u.agentUsecase.GetAllAgents(ctx)
+		if err != nil {
+			infrastructure.ServerLogger.Warning("Demo mode: failed to get agents: %v", err)
+		}
+		for i := range agents {
+			if domain.IsSimulatedAgent(&agents[i]) {
+				u.simulateAgent(ctx, &agents[i], runs)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// simulateAgent does what a real agent does between two heartbeats
+func (u *demoUsecase) simulateAgent(ctx context.Context, agent *domain.Agent, runs map[uuid.UUID]*simulatedRun) {
+	agentCtx := domain.WithActor(ctx, domain.ActorAgent)
+	heartbeat := &domain.AgentHeartbeat{
+		GPUUtilization:   0,
+		FreeDiskBytes:    512 << 30,
+		HashcatAvailable: true,
+		HashcatVersion:   "v6.2.6",
+		Engines:          []string{domain.EngineHashcat},
+	}
+
+	// Jobs cancelled or paused meanwhile are dropped
+	run := runs[agent.ID]
+	if run != nil {
+		if job, err := u.jobUsecase.GetJob(ctx, run.job.ID); err != nil || job.Status != domain.JobStatusRunning {
+			delete(runs, agent.ID)
+			run = nil
+		}
+	}
+
+	if run == nil {
+		if job, err := u.jobUsecase.GetAvailableJobForAgent(ctx, agent.ID); err == nil {
+			if err := u.jobUsecase.StartJob(agentCtx, job.ID); err != nil {
+				agentLogger(ctx, agent.ID).Warning("Demo mode: failed to start job %s: %v", job.ID, err)
+			} else {
+				run = &simulatedRun{job: job}
+				runs[agent.ID] = run
+			}
+		}
+	}
+
+	status := "online"
+	if run != nil {
+		run.progress = min(100, run.progress+100*float64(demoTick)/float64(demoJobDuration))
+		heartbeat.CurrentJobID = &run.job.ID
+		heartbeat.JobProgress = run.progress
+		heartbeat.JobSpeed = agent.Speed
+		heartbeat.GPUUtilization = 98
+		status = "busy"
+
+		if run.progress < 100 {
+			if err := u.jobUsecase.UpdateJobProgress(ctx, run.job.ID, run.progress, agent.Speed); err != nil {
+				agentLogger(ctx, agent.ID).Warning("Demo mode: failed to report progress of job %s: %v", run.job.ID, err)
+			}
+		} else {
+			if err := u.jobUsecase.CompleteJob(agentCtx, run.job.ID, u.simulatedResult(ctx, run.job), agent.Speed); err != nil {
+				agentLogger(ctx, agent.ID).Warning("Demo mode: failed to complete job %s: %v", run.job.ID, err)
+			}
+			delete(runs, agent.ID)
+			heartbeat.CurrentJobID = nil
+			status = "online"
+		}
+	}
+
+	if agent.Status != status {
+		if err := u.agentUsecase.UpdateAgentStatus(ctx, agent.ID, status); err != nil {
+			agentLogger(ctx, agent.ID).Warning("Demo mode: failed to update status: %v", err)
+		}
+	}
+	u.agentUsecase.AcceptAgentHeartbeat(ctx, agent.ID, 0, heartbeat)
+}
+
+// simulatedResult "cracks" a job by looking its hashes up among the hashes
+// of the demo passwords. Any other hash is never found, whatever the attack.
+func (u *demoUsecase) simulatedResult(ctx context.Context, job *domain.Job) string {
+	if job.HashFileID == nil || (job.HashType != 0 && job.HashType != 100) {
+		return domain.JobResultExhausted
+	}
+	_, content, err := u.hashFileUsecase.OpenHashFile(ctx, *job.HashFileID)
+	if err != nil {
+		return domain.JobResultExhausted
+	}
+	defer content.Close()
+
+	known := make(map[string]string, len(demoPasswords))
+	for _, password := range demoPasswords {
+		known[demoHash(job.HashType, password)] = password
+	}
+	scanner := bufio.NewScanner(content)
+	for scanner.Scan() {
+		if password, ok := known[strings.ToLower(strings.TrimSpace(scanner.Text()))]; ok {
+			return "Password found: " + password
+		}
+	}
+	return domain.JobResultExhausted
+}
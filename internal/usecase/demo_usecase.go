@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// demoPasswords are the passwords behind the demo hashes. The simulator
+// "cracks" a hash by finding it among their hashes.
+var demoPasswords = []string{"sunshine1", "Jessica2019", "Summer24", "letmein", "Welcome1!", "dragon", "P@ssw0rd"}
+
+// demoAgent is a simulated agent the seed adds
+type demoAgent struct {
+	name         string
+	capabilities string
+	speed        int64 // H/s
+}
+
+var demoAgents = []demoAgent{
+	{"demo-rtx4090-01", "NVIDIA GeForce RTX 4090 GPU", 164_000_000_000},
+	{"demo-rtx3080-01", "NVIDIA GeForce RTX 3080 GPU", 54_000_000_000},
+	{"demo-gtx1080-01", "NVIDIA GeForce GTX 1080 GPU", 25_000_000_000},
+}
+
+// demoJob is a job of the seeded history. Jobs without a status are queued
+// for the simulator.
+type demoJob struct {
+	name       string
+	hashFile   int // Index into the demo hash files
+	wordlist   int // Index into the demo wordlists, -1 for masks
+	mask       string
+	rules      string
+	status     string
+	daysAgo    int
+	minutes    int // Run time
+	agent      int // Index into the demo agents
+	password   string
+	failReason string
+	tags       []string
+}
+
+var demoJobs = []demoJob{
+	{name: "Web app MD5 - top passwords", hashFile: 0, wordlist: 0, status: domain.JobStatusCracked, daysAgo: 13, minutes: 42, agent: 0, password: "sunshine1", tags: []string{"webapp"}},
+	{name: "Web app MD5 - names with best64", hashFile: 0, wordlist: 1, rules: "best64.rule", status: domain.JobStatusCracked, daysAgo: 12, minutes: 95, agent: 1, password: "Jessica2019", tags: []string{"webapp"}},
+	{name: "Legacy SHA1 - top passwords", hashFile: 1, wordlist: 0, status: domain.JobStatusFailed, daysAgo: 9, minutes: 18, agent: 0, failReason: domain.JobResultExhausted, tags: []string{"legacy"}},
+	{name: "Legacy SHA1 - season and year", hashFile: 1, wordlist: -1, mask: "?u?l?l?l?l?l?d?d", status: domain.JobStatusCracked, daysAgo: 6, minutes: 240, agent: 0, password: "Summer24", tags: []string{"legacy"}},
+	{name: "Legacy SHA1 - 8 character brute force", hashFile: 1, wordlist: -1, mask: "?a?a?a?a?a?a?a?a", status: domain.JobStatusCancelled, daysAgo: 4, minutes: 30, agent: 1, failReason: "too slow, cancelled", tags: []string{"legacy"}},
+	{name: "Web app MD5 - top passwords on the old card", hashFile: 0, wordlist: 0, status: domain.JobStatusFailed, daysAgo: 2, minutes: 1, agent: 2, failReason: "hashcat exited with code 255: No devices found/left", tags: []string{"webapp"}},
+	{name: "Web app MD5 - names", hashFile: 0, wordlist: 1, tags: []string{"webapp"}},
+	{name: "Legacy SHA1 - top passwords with best64", hashFile: 1, wordlist: 0, rules: "best64.rule", tags: []string{"legacy"}},
+	{name: "Web app MD5 - 6 digit PINs", hashFile: 0, wordlist: -1, mask: "?d?d?d?d?d?d", tags: []string{"webapp"}},
+}
+
+// DemoUsecase fills the database with sample data and runs simulated
+// agents, so the system can be explored without GPUs
+type DemoUsecase interface {
+	// Seed adds simulated agents, sample hash files and wordlists, and a
+	// history of jobs in the Demo project. It refuses with a
+	// ValidationError when the Demo project exists.
+	Seed(ctx context.Context) (*domain.DemoSeedResult, error)
+	// RunSimulator plays the simulated agents until ctx is done: they send
+	// heartbeats, take queued jobs and report progress and results
+	RunSimulator(ctx context.Context)
+}
+
+type demoUsecase struct {
+	agentUsecase    AgentUsecase
+	jobUsecase      JobUsecase
+	projectRepo     domain.ProjectRepository
+	archiveRepo     domain.ProjectArchiveRepository
+	hashFileUsecase HashFileUsecase
+	wordlistUsecase WordlistUsecase
+}
+
+func NewDemoUsecase(agentUsecase AgentUsecase, jobUsecase JobUsecase, projectRepo domain.ProjectRepository, archiveRepo domain.ProjectArchiveRepository, hashFileUsecase HashFileUsecase, wordlistUsecase WordlistUsecase) DemoUsecase {
+	return &demoUsecase{
+		agentUsecase:    agentUsecase,
+		jobUsecase:      jobUsecase,
+		projectRepo:     projectRepo,
+		archiveRepo:     archiveRepo,
+		hashFileUsecase: hashFileUsecase,
+		wordlistUsecase: wordlistUsecase,
+	}
+}
+
+// demoHash hashes a password the way the demo hash files do
+func demoHash(hashType int, password string) string {
+	if hashType == 100 {
+		sum := sha1.Sum([]byte(password))
+		return hex.EncodeToString(sum[:])
+	}
+	sum := md5.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (u *demoUsecase) Seed(ctx context.Context) (*domain.DemoSeedResult, error) {
+	if _, err := u.projectRepo.GetByName(ctx, domain.DemoProjectName); err == nil {
+		return nil, &domain.ValidationError{Field: "demo", Message: "demo data was already seeded"}
+	} else if !domain.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	now := time.Now()
+	project := domain.Project{ID: uuid.New(), Name: domain.DemoProjectName, Description: "Sample data, cracked by simulated agents", CreatedAt: now.AddDate(0, 0, -14), UpdatedAt: now}
+	archive := &domain.ProjectArchive{Version: domain.ProjectArchiveVersion, ExportedAt: now, Project: project}
+	result := &domain.DemoSeedResult{Project: &project}
+
+	var agents []*domain.Agent
+	for i, demo := range demoAgents {
+		agent := &domain.Agent{
+			ID:           uuid.New(),
+			Name:         demo.name,
+			IPAddress:    fmt.Sprintf("192.0.2.%d", 11+i), // Documentation range, nothing answers there
+			Port:         8081,
+			Status:       "offline",
+			Capabilities: domain.SimulatedAgentCapabilities + ": " + demo.capabilities,
+			AgentKey:     strings.ReplaceAll(uuid.NewString(), "-", "")[:16],
+			Speed:        demo.speed,
+			LastSeen:     now,
+			CreatedAt:    project.CreatedAt,
+			UpdatedAt:    now,
+		}
+		if err := u.agentUsecase.CreateAgent(ctx, agent); err != nil {
+			return nil, fmt.Errorf("failed to create demo agent %s: %w", demo.name, err)
+		}
+		agents = append(agents, agent)
+	}
+	result.Agents = len(agents)
+
+	// Shared files, so they are there for jobs outside the Demo project too
+	hashTypes := []int{0, 100}
+	var hashFiles []*domain.HashFile
+	for i, name := range []string{"demo-webapp-md5.txt", "demo-legacy-sha1.txt"} {
+		var content strings.Builder
+		for _, password := range demoPasswords[i:] {
+			content.WriteString(demoHash(hashTypes[i], password) + "\n")
+		}
+		file, err := u.hashFileUsecase.UploadHashFile(ctx, name, strings.NewReader(content.String()), int64(content.Len()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload demo hash file: %w", err)
+		}
+		hashFiles = append(hashFiles, file)
+	}
+	result.HashFiles = len(hashFiles)
+
+	var wordlists []*domain.Wordlist
+	for _, list := range []struct {
+		name  string
+		words []string
+	}{
+		{"demo-top-passwords.txt", append([]string{"123456", "password", "qwerty", "111111", "abc123", "iloveyou"}, demoPasswords...)},
+		{"demo-names.txt", []string{"jessica", "michael", "ashley", "daniel", "sarah", "thomas", "Jessica"}},
+	} {
+		content := strings.Join(list.words, "\n") + "\n"
+		wordlist, err := u.wordlistUsecase.UploadWordlist(ctx, list.name, strings.NewReader(content), int64(len(content)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload demo wordlist: %w", err)
+		}
+		wordlists = append(wordlists, wordlist)
+	}
+	result.Wordlists = len(wordlists)
+
+	for _, demo := range demoJobs {
+		job, events := u.demoJob(demo, now, &project, agents, hashTypes, hashFiles, wordlists)
+		archive.Jobs = append(archive.Jobs, *job)
+		archive.JobEvents = append(archive.JobEvents, events...)
+
+		if password := demo.password; password != "" {
+			archive.Credentials = append(archive.Credentials, domain.Credential{
+				ID: uuid.New(), Hash: demoHash(job.HashType, password), Plaintext: password, HashType: job.HashType,
+				HashFileID: job.HashFileID, SourceFile: hashFiles[demo.hashFile].OrigName, Source: domain.CredentialSourceJob,
+				JobID: &job.ID, ProjectID: &project.ID, CrackedAt: *job.CompletedAt,
+			})
+		}
+	}
+	result.Jobs = len(archive.Jobs)
+
+	credentials, err := u.archiveRepo.Import(ctx, archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store demo jobs: %w", err)
+	}
+	result.Credentials = credentials
+	return result, nil
+}
+
+// demoJob builds a job of the seeded history and the events of its run
+func (u *demoUsecase) demoJob(demo demoJob, now time.Time, project *domain.Project, agents []*domain.Agent, hashTypes []int, hashFiles []*domain.HashFile, wordlists []*domain.Wordlist) (*domain.Job, []domain.JobEvent) {
+	hashFile := hashFiles[demo.hashFile]
+	job := &domain.Job{
+		ID: uuid.New(), Name: demo.name, Status: domain.JobStatusPending, HashType: hashTypes[demo.hashFile],
+		AttackMode: domain.AttackModeStraight, HashFile: hashFile.Path, HashFileID: &hashFile.ID, Rules: demo.rules,
+		ProjectID: &project.ID, Tags: demo.tags, Engine: domain.EngineHashcat, CreatedAt: now, UpdatedAt: now,
+	}
+	if demo.wordlist < 0 {
+		job.AttackMode = domain.AttackModeBruteForce
+		job.Wordlist = demo.mask
+	} else {
+		job.Wordlist = wordlists[demo.wordlist].OrigName
+		job.WordlistID = &wordlists[demo.wordlist].ID
+	}
+
+	created := domain.JobEvent{ID: uuid.New(), JobID: job.ID, ToStatus: domain.JobStatusPending, Actor: domain.ActorAPI, CreatedAt: now}
+	if demo.status == "" {
+		return job, []domain.JobEvent{created}
+	}
+
+	agent := agents[demo.agent]
+	started := now.AddDate(0, 0, -demo.daysAgo)
+	completed := started.Add(time.Duration(demo.minutes) * time.Minute)
+	job.CreatedAt, created.CreatedAt = started.Add(-time.Minute), started.Add(-time.Minute)
+	job.UpdatedAt = completed
+	job.StartedAt, job.CompletedAt = &started, &completed
+	job.AgentID = &agent.ID
+	job.Status = demo.status
+	job.Speed = agent.Speed
+	job.DeviceSeconds = completed.Sub(started).Seconds()
+	job.Progress = 100
+	job.NormalizedProgress = 100
+	reason := demo.failReason
+	switch demo.status {
+	case domain.JobStatusCracked:
+		job.Result = "Password found: " + demo.password
+		reason = "password found"
+	case domain.JobStatusFailed:
+		job.Result = demo.failReason
+	case domain.JobStatusCancelled:
+		job.Progress = 8
+	}
+	finishedBy := domain.ActorAgent
+	if demo.status == domain.JobStatusCancelled {
+		finishedBy = domain.ActorAPI
+	}
+
+	return job, []domain.JobEvent{
+		created,
+		{ID: uuid.New(), JobID: job.ID, FromStatus: domain.JobStatusPending, ToStatus: domain.JobStatusRunning, Actor: domain.ActorAgent, CreatedAt: started},
+		{ID: uuid.New(), JobID: job.ID, FromStatus: domain.JobStatusRunning, ToStatus: demo.status, Actor: finishedBy, Reason: reason, CreatedAt: completed},
+	}
+}
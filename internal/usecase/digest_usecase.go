@@ -0,0 +1,469 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// digestPageSize is how many jobs a digest reads at a time
+	digestPageSize = 200
+	// digestJobsListed caps each list of jobs in a digest; counts cover all
+	digestJobsListed = 20
+	// digestMinFreeDisk is the free disk below which an agent has an issue,
+	// the health monitor's default
+	digestMinFreeDisk = 1 << 30
+)
+
+//go:embed templates/digest.html templates/digest.txt
+var digestTemplates embed.FS
+
+var digestFuncs = map[string]any{
+	"date": func(t time.Time) string { return t.UTC().Format("Mon 2 Jan 2006 15:04 UTC") },
+	"dateptr": func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.UTC().Format("Mon 2 Jan 2006 15:04 UTC")
+	},
+}
+
+var (
+	digestHTML = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(digestFuncs).ParseFS(digestTemplates, "templates/digest.html"))
+	digestText = texttemplate.Must(texttemplate.New("digest.txt").Funcs(digestFuncs).ParseFS(digestTemplates, "templates/digest.txt"))
+)
+
+// DigestConfig tunes the email digests
+type DigestConfig struct {
+	// DashboardURL is linked from digests, no link when empty
+	DashboardURL string
+}
+
+// DigestUsecase emails project members a daily or weekly summary of their
+// projects: new cracks, finished jobs, agents with issues and what is
+// coming up
+type DigestUsecase interface {
+	// GetSubscriptions returns the digests a user subscribed to
+	GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]domain.DigestSubscription, error)
+	// Subscribe subscribes a user to the digest of a project they can see,
+	// or changes how often it comes
+	Subscribe(ctx context.Context, userID uuid.UUID, role string, projectID uuid.UUID, req *domain.DigestSubscriptionRequest) (*domain.DigestSubscription, error)
+	Unsubscribe(ctx context.Context, userID, projectID uuid.UUID) error
+	// PreviewDigest renders the digest of a project the user can see as it
+	// would be sent now
+	PreviewDigest(ctx context.Context, userID uuid.UUID, role string, projectID uuid.UUID, frequency string) (*domain.EmailMessage, error)
+	BuildDigest(ctx context.Context, projectID uuid.UUID, frequency string, now time.Time) (*domain.ProjectDigest, error)
+	// SendDueDigests emails the digests that are due, returning how many
+	// were sent
+	SendDueDigests(ctx context.Context, now time.Time) (int, error)
+}
+
+type digestUsecase struct {
+	subscriptionRepo domain.DigestSubscriptionRepository
+	projectRepo      domain.ProjectRepository
+	userRepo         domain.UserRepository
+	jobRepo          domain.JobRepository
+	agentRepo        domain.AgentRepository
+	maintenanceRepo  domain.MaintenanceRepository
+	mailer           domain.Mailer
+	config           DigestConfig
+}
+
+// NewDigestUsecase returns the digest usecase. Without a mailer digests can
+// be subscribed to and previewed, but not sent.
+func NewDigestUsecase(
+	subscriptionRepo domain.DigestSubscriptionRepository,
+	projectRepo domain.ProjectRepository,
+	userRepo domain.UserRepository,
+	jobRepo domain.JobRepository,
+	agentRepo domain.AgentRepository,
+	maintenanceRepo domain.MaintenanceRepository,
+	mailer domain.Mailer,
+	config DigestConfig,
+) DigestUsecase {
+	return &digestUsecase{
+		subscriptionRepo: subscriptionRepo,
+		projectRepo:      projectRepo,
+		userRepo:         userRepo,
+		jobRepo:          jobRepo,
+		agentRepo:        agentRepo,
+		maintenanceRepo:  maintenanceRepo,
+		mailer:           mailer,
+		config:           config,
+	}
+}
+
+func (u *digestUsecase) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]domain.DigestSubscription, error) {
+	return u.subscriptionRepo.GetByUser(ctx, userID)
+}
+
+func (u *digestUsecase) Subscribe(ctx context.Context, userID uuid.UUID, role string, projectID uuid.UUID, req *domain.DigestSubscriptionRequest) (*domain.DigestSubscription, error) {
+	if domain.DigestPeriod(req.Frequency) == 0 {
+		return nil, &domain.ValidationError{Field: "frequency", Message: "must be daily or weekly"}
+	}
+	project, err := u.accessibleProject(ctx, userID, role, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &domain.DigestSubscription{UserID: userID, ProjectID: projectID, ProjectName: project.Name, Frequency: req.Frequency}
+	if err := u.subscriptionRepo.Save(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (u *digestUsecase) Unsubscribe(ctx context.Context, userID, projectID uuid.UUID) error {
+	return u.subscriptionRepo.Delete(ctx, userID, projectID)
+}
+
+func (u *digestUsecase) PreviewDigest(ctx context.Context, userID uuid.UUID, role string, projectID uuid.UUID, frequency string) (*domain.EmailMessage, error) {
+	if _, err := u.accessibleProject(ctx, userID, role, projectID); err != nil {
+		return nil, err
+	}
+	digest, err := u.BuildDigest(ctx, projectID, frequency, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return u.render(digest)
+}
+
+// accessibleProject returns the project when the user is an admin or one of
+// its members, and a NotFoundError otherwise
+func (u *digestUsecase) accessibleProject(ctx context.Context, userID uuid.UUID, role string, projectID uuid.UUID) (*domain.Project, error) {
+	project, err := u.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "admin" {
+		return project, nil
+	}
+	member, err := u.projectRepo.IsMember(ctx, projectID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check project membership: %w", err)
+	}
+	if !member {
+		return nil, &domain.NotFoundError{Entity: "project"}
+	}
+	return project, nil
+}
+
+// BuildDigest collects what happened in a project over the period of the
+// frequency up to now. Jobs and agents are those the context's tenant sees.
+func (u *digestUsecase) BuildDigest(ctx context.Context, projectID uuid.UUID, frequency string, now time.Time) (*domain.ProjectDigest, error) {
+	ctx, span := startSpan(ctx, "DigestUsecase.BuildDigest")
+	defer span.End()
+
+	period := domain.DigestPeriod(frequency)
+	if period == 0 {
+		return nil, &domain.ValidationError{Field: "frequency", Message: "must be daily or weekly"}
+	}
+	project, err := u.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	digest := &domain.ProjectDigest{
+		Project:      *project,
+		Frequency:    frequency,
+		From:         now.Add(-period),
+		To:           now,
+		CrackingJobs: []domain.DigestJob{},
+		FinishedJobs: []domain.DigestJob{},
+		QueuedJobs:   []domain.DigestJob{},
+		Maintenance:  []domain.MaintenanceWindow{},
+	}
+
+	if err := u.addJobActivity(ctx, digest); err != nil {
+		return nil, err
+	}
+	if err := u.addQueuedJobs(ctx, digest); err != nil {
+		return nil, err
+	}
+	if digest.AgentIssues, err = u.agentIssues(ctx); err != nil {
+		return nil, err
+	}
+	if err := u.addMaintenance(ctx, digest, period); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// addJobActivity counts the cracks and finished jobs of the period. Jobs
+// are read most recently updated first, up to the first one untouched in
+// the period.
+func (u *digestUsecase) addJobActivity(ctx context.Context, digest *domain.ProjectDigest) error {
+	projectID := digest.Project.ID
+	for offset := 0; ; offset += digestPageSize {
+		jobs, _, err := u.jobRepo.List(ctx, domain.JobFilter{
+			ProjectID: &projectID,
+			SortBy:    "updated_at",
+			SortDesc:  true,
+			Limit:     digestPageSize,
+			Offset:    offset,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list the jobs of project %s: %w", projectID, err)
+		}
+
+		for i := range jobs {
+			job := &jobs[i]
+			if job.UpdatedAt.Before(digest.From) {
+				sortJobActivity(digest)
+				return nil
+			}
+			if domain.IsTerminalJobStatus(job.Status) && inPeriod(job.CompletedAt, digest) {
+				digest.FinishedJobs = append(digest.FinishedJobs, digestJob(job))
+				digest.JobsFinished++
+			}
+
+			cracks, err := u.jobRepo.GetCracks(ctx, job.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get the cracks of job %s: %w", job.ID, err)
+			}
+			count := 0
+			for j := range cracks {
+				if inPeriod(&cracks[j].CrackedAt, digest) {
+					count++
+				}
+			}
+			if count > 0 {
+				cracking := digestJob(job)
+				cracking.Cracks = count
+				digest.CrackingJobs = append(digest.CrackingJobs, cracking)
+				digest.NewCracks += count
+			}
+		}
+		if len(jobs) < digestPageSize {
+			sortJobActivity(digest)
+			return nil
+		}
+	}
+}
+
+// sortJobActivity puts the most cracks and the latest finished jobs first,
+// and caps the lists
+func sortJobActivity(digest *domain.ProjectDigest) {
+	sort.SliceStable(digest.CrackingJobs, func(i, j int) bool {
+		return digest.CrackingJobs[i].Cracks > digest.CrackingJobs[j].Cracks
+	})
+	sort.SliceStable(digest.FinishedJobs, func(i, j int) bool {
+		return digest.FinishedJobs[i].CompletedAt.After(*digest.FinishedJobs[j].CompletedAt)
+	})
+	digest.CrackingJobs = digest.CrackingJobs[:min(len(digest.CrackingJobs), digestJobsListed)]
+	digest.FinishedJobs = digest.FinishedJobs[:min(len(digest.FinishedJobs), digestJobsListed)]
+}
+
+// addQueuedJobs lists the jobs waiting for an agent, oldest first
+func (u *digestUsecase) addQueuedJobs(ctx context.Context, digest *domain.ProjectDigest) error {
+	projectID := digest.Project.ID
+	for _, status := range []string{domain.JobStatusInterrupted, domain.JobStatusPending} {
+		jobs, _, err := u.jobRepo.List(ctx, domain.JobFilter{
+			ProjectID: &projectID,
+			Status:    status,
+			Limit:     digestJobsListed - len(digest.QueuedJobs),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list the %s jobs of project %s: %w", status, projectID, err)
+		}
+		for i := range jobs {
+			digest.QueuedJobs = append(digest.QueuedJobs, digestJob(&jobs[i]))
+		}
+		if len(digest.QueuedJobs) >= digestJobsListed {
+			break
+		}
+	}
+	return nil
+}
+
+// agentIssues lists the agents that are offline or can't take work
+func (u *digestUsecase) agentIssues(ctx context.Context) ([]domain.DigestAgentIssue, error) {
+	agents, err := u.agentRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agents: %w", err)
+	}
+
+	issues := []domain.DigestAgentIssue{}
+	for i := range agents {
+		agent := &agents[i]
+		var problems []string
+		switch agent.Status {
+		case "offline":
+			problems = append(problems, "offline")
+		case "degraded":
+			problems = append(problems, "heartbeats late")
+		}
+		if agent.Status != "offline" {
+			problems = append(problems, agent.Heartbeat.Problems(digestMinFreeDisk)...)
+		}
+		if len(problems) > 0 {
+			issues = append(issues, domain.DigestAgentIssue{Name: agent.Name, Problems: problems, LastSeen: agent.LastSeen})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Name < issues[j].Name })
+	return issues, nil
+}
+
+// addMaintenance lists the one-off maintenance windows starting before the
+// next digest. Weekly windows are routine and left out.
+func (u *digestUsecase) addMaintenance(ctx context.Context, digest *domain.ProjectDigest, period time.Duration) error {
+	windows, err := u.maintenanceRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get maintenance windows: %w", err)
+	}
+	until := digest.To.Add(period)
+	for _, window := range windows {
+		if window.StartsAt != nil && !window.StartsAt.Before(digest.To) && window.StartsAt.Before(until) {
+			digest.Maintenance = append(digest.Maintenance, window)
+		}
+	}
+	sort.Slice(digest.Maintenance, func(i, j int) bool {
+		return digest.Maintenance[i].StartsAt.Before(*digest.Maintenance[j].StartsAt)
+	})
+	return nil
+}
+
+// SendDueDigests sends each due subscription the digest of the period up to
+// now. Subscribers of the same project, frequency and tenant share one
+// digest. Quiet periods send nothing but still count as sent. Subscriptions
+// of deleted users and of users no longer in the project are dropped.
+func (u *digestUsecase) SendDueDigests(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := startSpan(ctx, "DigestUsecase.SendDueDigests")
+	defer span.End()
+
+	if u.mailer == nil {
+		return 0, fmt.Errorf("no mail server is configured")
+	}
+	subscriptions, err := u.subscriptionRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get digest subscriptions: %w", err)
+	}
+
+	built := make(map[string]*domain.EmailMessage)
+	sent := 0
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if !subscription.Due(now) {
+			continue
+		}
+		logger := infrastructure.ServerLogger.WithContext(ctx).With("user_id", subscription.UserID).With("project_id", subscription.ProjectID)
+
+		user, err := u.subscriber(ctx, subscription)
+		if err != nil {
+			logger.Warning("Skipping digest of project %s: %v", subscription.ProjectName, err)
+			continue
+		}
+		if user == nil {
+			logger.Info("Dropping the digest subscription of a user who left project %s", subscription.ProjectName)
+			if err := u.subscriptionRepo.Delete(ctx, subscription.UserID, subscription.ProjectID); err != nil && !domain.IsNotFoundError(err) {
+				logger.Warning("Failed to drop digest subscription: %v", err)
+			}
+			continue
+		}
+		if !user.IsActive || user.Email == "" {
+			continue
+		}
+
+		userCtx := ctx
+		key := subscription.ProjectID.String() + "/" + subscription.Frequency
+		if user.TenantID != nil {
+			userCtx = domain.WithTenantID(ctx, *user.TenantID)
+			key += "/" + user.TenantID.String()
+		}
+		message, ok := built[key]
+		if !ok {
+			digest, err := u.BuildDigest(userCtx, subscription.ProjectID, subscription.Frequency, now)
+			if err != nil {
+				logger.Error("Failed to build the digest of project %s: %v", subscription.ProjectName, err)
+				continue
+			}
+			if !digest.Empty() {
+				if message, err = u.render(digest); err != nil {
+					logger.Error("Failed to render the digest of project %s: %v", subscription.ProjectName, err)
+					continue
+				}
+			}
+			built[key] = message
+		}
+
+		if message != nil {
+			email := *message
+			email.To = []string{user.Email}
+			if err := u.mailer.Send(ctx, &email); err != nil {
+				// Not marked as sent, so it is tried again
+				logger.Error("Failed to email the digest of project %s: %v", subscription.ProjectName, err)
+				continue
+			}
+			sent++
+		}
+		if err := u.subscriptionRepo.MarkSent(ctx, subscription.UserID, subscription.ProjectID, now); err != nil {
+			logger.Warning("Failed to mark digest as sent: %v", err)
+		}
+	}
+	return sent, nil
+}
+
+// subscriber returns the user of a subscription, or nil when they were
+// deleted or may no longer see the project
+func (u *digestUsecase) subscriber(ctx context.Context, subscription *domain.DigestSubscription) (*domain.User, error) {
+	user, err := u.userRepo.GetByID(ctx, subscription.UserID)
+	if err != nil {
+		if _, ok := err.(*domain.UserNotFoundError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if _, err := u.accessibleProject(ctx, user.ID, user.Role, subscription.ProjectID); err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// digestEmail is what the digest templates are executed with
+type digestEmail struct {
+	*domain.ProjectDigest
+	Period       string
+	DashboardURL string
+}
+
+func (u *digestUsecase) render(digest *domain.ProjectDigest) (*domain.EmailMessage, error) {
+	period := "Daily"
+	if digest.Frequency == domain.DigestWeekly {
+		period = "Weekly"
+	}
+	data := digestEmail{ProjectDigest: digest, Period: period, DashboardURL: u.config.DashboardURL}
+
+	var html, text bytes.Buffer
+	if err := digestHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	if err := digestText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	return &domain.EmailMessage{
+		Subject: fmt.Sprintf("%s digest of %s: %d new cracks, %d jobs finished", period, digest.Project.Name, digest.NewCracks, digest.JobsFinished),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+func digestJob(job *domain.Job) domain.DigestJob {
+	return domain.DigestJob{ID: job.ID, Name: job.Name, Status: job.Status, CompletedAt: job.CompletedAt, Deadline: job.Deadline}
+}
+
+func inPeriod(at *time.Time, digest *domain.ProjectDigest) bool {
+	return at != nil && !at.Before(digest.From) && at.Before(digest.To)
+}
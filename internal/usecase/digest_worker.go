@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// DigestWorker emails the project digests that are due
+type DigestWorker interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context)
+}
+
+type digestWorker struct {
+	digestUsecase DigestUsecase
+	checkInterval time.Duration
+	ticker        *time.Ticker
+	done          chan struct{}
+}
+
+func NewDigestWorker(digestUsecase DigestUsecase, checkInterval time.Duration) DigestWorker {
+	if checkInterval == 0 {
+		checkInterval = 15 * time.Minute
+	}
+
+	return &digestWorker{
+		digestUsecase: digestUsecase,
+		checkInterval: checkInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (w *digestWorker) Start(ctx context.Context) {
+	infrastructure.ServerLogger.Info("Starting Digest Worker (interval: %v)", w.checkInterval)
+
+	w.ticker = time.NewTicker(w.checkInterval)
+
+	go func() {
+		w.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-w.ticker.C:
+				w.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (w *digestWorker) Stop() {
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	select {
+	case <-w.done:
+		// Channel already closed
+	default:
+		close(w.done)
+	}
+}
+
+// RunOnce sends the digests that are due
+func (w *digestWorker) RunOnce(ctx context.Context) {
+	sent, err := w.digestUsecase.SendDueDigests(ctx, time.Now())
+	if err != nil {
+		infrastructure.ServerLogger.Error("Failed to send digests: %v", err)
+		return
+	}
+	if sent > 0 {
+		infrastructure.ServerLogger.Info("Sent %d project digests", sent)
+	}
+}
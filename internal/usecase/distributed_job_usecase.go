@@ -3,7 +3,6 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
 	"math"
 	"sort"
 	"strings"
@@ -12,6 +11,7 @@ import (
 	"go-distributed-hashcat/internal/domain"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type distributedJobUsecase struct {
@@ -20,6 +20,9 @@ type distributedJobUsecase struct {
 	wordlistRepo domain.WordlistRepository
 	hashFileRepo domain.HashFileRepository
 	uploadDir    string
+	quotas       domain.QuotaChecker        // Optional, refuses jobs over a user's quota
+	maintenance  domain.MaintenanceCalendar // Optional, agents in a maintenance window take no new jobs
+	timeouts     domain.JobTimeouts         // Limits of sub-jobs whose request sets none
 }
 
 func NewDistributedJobUsecase(
@@ -38,23 +41,66 @@ func NewDistributedJobUsecase(
 	}
 }
 
+func (u *distributedJobUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	u.quotas = checker
+}
+
+func (u *distributedJobUsecase) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar) {
+	u.maintenance = calendar
+}
+
+func (u *distributedJobUsecase) SetJobTimeouts(timeouts domain.JobTimeouts) {
+	u.timeouts = timeouts
+}
+
 // CreateDistributedJobs creates multiple jobs by dividing wordlist among specified agents
 func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *domain.DistributedJobRequest) (*domain.DistributedJobResult, error) {
+	ctx, span := startSpan(ctx, "DistributedJobUsecase.CreateDistributedJobs")
+	defer span.End()
+
+	if err := domain.ValidateHashMode(req.HashType); err != nil {
+		return nil, err
+	}
+	// Wordlist segments only make sense for straight attacks
+	if req.AttackMode != domain.AttackModeStraight {
+		return nil, &domain.ValidationError{Field: "attack_mode", Message: "distributed jobs only support straight (dictionary) attacks"}
+	}
+	if err := domain.ValidateRuleReference(req.Rules); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateJobTimeouts(req.MaxRuntimeMinutes, req.StallTimeoutMinutes); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateJobDeadline(req.Deadline, time.Now()); err != nil {
+		return nil, err
+	}
+
 	var agents []domain.Agent
 	var err error
 
 	// Determine which agents to use
-	if req.AutoDistribute {
+	if req.AgentGroupID != "" {
+		// Use the online agents of the agent group
+		groupID, err := parseAgentGroupID(req.AgentGroupID)
+		if err != nil {
+			return nil, err
+		}
+		agents, err = onlineGroupAgents(ctx, u.agentRepo, u.maintenance, groupID)
+		if err != nil {
+			return nil, err
+		}
+	} else if req.AutoDistribute {
 		// Use all available online agents
 		agents, err = u.agentRepo.GetAll(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get agents: %w", err)
 		}
-		// Filter only online agents
-		agents = u.filterOnlineAgents(agents)
+		// Filter only online agents outside maintenance windows
+		agents = withoutMaintenance(u.filterOnlineAgents(agents), agentsInMaintenance(ctx, u.maintenance))
 	} else if len(req.AgentIDs) > 0 {
 		// Use specific agents from request
 		agents = make([]domain.Agent, 0, len(req.AgentIDs))
+		inMaintenance := agentsInMaintenance(ctx, u.maintenance)
 		for _, agentIDStr := range req.AgentIDs {
 			agentID, err := uuid.Parse(agentIDStr)
 			if err != nil {
@@ -69,6 +115,9 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 			if agent.Status != "online" {
 				return nil, fmt.Errorf("agent %s is not available (status: %s)", agent.Name, agent.Status)
 			}
+			if maintenance, ok := inMaintenance[agent.ID]; ok {
+				return nil, maintenanceError("agent_ids", agent, maintenance)
+			}
 
 			agents = append(agents, *agent) // Dereference the pointer
 		}
@@ -108,6 +157,14 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 	// Divide wordlist based on agent performance
 	wordlistSegments := u.divideWordlistByPerformance(wordlist, agentPerformances)
 
+	// Every sub-job counts against the quota, since each occupies an agent
+	if u.quotas != nil {
+		if err := u.quotas.CheckJobQuota(ctx, nil, min(len(wordlistSegments), len(agentPerformances))); err != nil {
+			return nil, err
+		}
+	}
+	createdBy := domain.UserIDFromContext(ctx)
+
 	// Create master job record (optional - only for coordination tracking)
 	var masterJobID uuid.UUID
 	var masterJob *domain.Job
@@ -127,11 +184,22 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 			Wordlist:   wordlist.OrigName,
 			WordlistID: &wordlistID,
 			Rules:      req.Rules,
+			CreatedBy:  createdBy,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
 	}
 
+	// Link the sub-jobs through a job group. When a master job exists the
+	// group shares its ID, so the master job ID resolves to its sub-jobs.
+	group := &domain.JobGroup{ID: uuid.New(), Name: req.Name}
+	if masterJob != nil {
+		group.ID = masterJobID
+	}
+	if err := u.jobRepo.CreateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create job group: %w", err)
+	}
+
 	// Create sub-jobs for each agent using skip/limit ranges
 	var subJobs []domain.Job
 	var agentAssignments []domain.AgentPerformance
@@ -152,7 +220,7 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 		subJob := domain.Job{
 			ID:         uuid.New(),
 			Name:       fmt.Sprintf("%s (Part %d - %s)", req.Name, i+1, agent.Name),
-			Status:     "pending",
+			Status:     domain.JobStatusAssigned,
 			HashType:   req.HashType,
 			AttackMode: req.AttackMode,
 			HashFile:   hashFile.OrigName,
@@ -164,9 +232,13 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 			Skip:       &skip,  // Hashcat --skip parameter
 			WordLimit:  &limit, // Hashcat --limit parameter
 			TotalWords: segment.WordCount,
+			GroupID:    &group.ID,
+			CreatedBy:  createdBy,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
+		subJob.MaxRuntimeMinutes, subJob.StallTimeoutMinutes = u.timeouts.Resolve(req.MaxRuntimeMinutes, req.StallTimeoutMinutes)
+		subJob.Deadline = req.Deadline
 
 		// Save sub-job to database - continue even if some fail
 		if err := u.jobRepo.Create(ctx, &subJob); err != nil {
@@ -174,6 +246,7 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 			failedAgents = append(failedAgents, agent.Name)
 			continue
 		}
+		recordJobEvent(ctx, u.jobRepo, subJob.ID, "", subJob.Status, "created")
 
 		subJobs = append(subJobs, subJob)
 
@@ -214,6 +287,7 @@ func (u *distributedJobUsecase) CreateDistributedJobs(ctx context.Context, req *
 
 	return &domain.DistributedJobResult{
 		MasterJobID:      resultMasterJobID,
+		GroupID:          group.ID,
 		SubJobs:          subJobs,
 		AgentAssignments: agentAssignments,
 		TotalWords:       *wordlist.WordCount,
@@ -388,47 +462,42 @@ func (u *distributedJobUsecase) divideWordlistByPerformance(wordlist *domain.Wor
 // parameters for distributed cracking instead of creating physical segment files
 
 // HandleDistributedJobCompletion handles the completion of distributed jobs
-// When one agent finds the password, all other running jobs are marked as failed
+// When one agent finds the password, all other unfinished jobs are cancelled
 func (u *distributedJobUsecase) HandleDistributedJobCompletion(ctx context.Context, masterJobID uuid.UUID, successfulJobID uuid.UUID, password string) error {
+	ctx, span := startSpan(ctx, "DistributedJobUsecase.HandleDistributedJobCompletion", attribute.String("job.id", masterJobID.String()))
+	defer span.End()
+
 	// Get master job
 	masterJob, err := u.jobRepo.GetByID(ctx, masterJobID)
 	if err != nil {
 		return fmt.Errorf("failed to get master job: %w", err)
 	}
 
-	// Get all sub-jobs for this master job
-	allJobs, err := u.jobRepo.GetAll(ctx)
+	// Get all sub-jobs for this master job (the group shares its ID)
+	subJobs, err := u.jobRepo.GetByGroupID(ctx, masterJobID)
 	if err != nil {
-		return fmt.Errorf("failed to get all jobs: %w", err)
-	}
-
-	// Find sub-jobs based on master job name pattern
-	masterJobName := strings.TrimSuffix(masterJob.Name, " (Master)")
-	var subJobs []domain.Job
-	for _, job := range allJobs {
-		if strings.Contains(job.Name, masterJobName) && job.ID != masterJobID {
-			subJobs = append(subJobs, job)
-		}
+		return fmt.Errorf("failed to get sub-jobs: %w", err)
 	}
 
-	// Mark all other running/pending sub-jobs as cancelled
+	// Mark all other unfinished sub-jobs as cancelled
 	for _, subJob := range subJobs {
-		if subJob.ID != successfulJobID && (subJob.Status == "running" || subJob.Status == "pending") {
+		if subJob.ID != successfulJobID && !domain.IsTerminalJobStatus(subJob.Status) {
 			// Update job status to cancelled with 100% progress
-			subJob.Status = "cancelled"
 			subJob.Progress = 100
+			subJob.NormalizedProgress = 100
 			subJob.Result = "Password found by another agent - job cancelled"
 			subJob.CompletedAt = &time.Time{}
 
-			if err := u.jobRepo.Update(ctx, &subJob); err != nil {
-				log.Printf("Warning: failed to update sub-job %s: %v", subJob.ID, err)
+			if err := transitionJob(domain.WithActor(ctx, domain.ActorSystem), u.jobRepo, &subJob, domain.JobStatusCancelled, "password found by job "+successfulJobID.String()); err != nil {
+				jobLogger(ctx, subJob.ID).Warning("Failed to update sub-job %s: %v", subJob.ID, err)
 			}
 		}
 	}
 
 	// Update master job with success result
-	masterJob.Status = "completed"
+	masterJob.Status = domain.JobStatusCracked
 	masterJob.Progress = 100
+	masterJob.NormalizedProgress = 100
 	masterJob.Result = fmt.Sprintf("SUCCESS: Password found by agent - %s", password)
 	masterJob.CompletedAt = &time.Time{}
 
@@ -436,35 +505,25 @@ func (u *distributedJobUsecase) HandleDistributedJobCompletion(ctx context.Conte
 		return fmt.Errorf("failed to update master job: %w", err)
 	}
 
-	log.Printf("✅ Distributed job %s completed successfully. Password: %s", masterJob.Name, password)
+	jobLogger(ctx, masterJobID).Success("Distributed job %s completed successfully. Password: %s", masterJob.Name, password)
 	return nil
 }
 
 // GetDistributedJobStatus gets the status of all sub-jobs for a master job
 func (u *distributedJobUsecase) GetDistributedJobStatus(ctx context.Context, masterJobID uuid.UUID) (*domain.DistributedJobResult, error) {
 	// Get master job
-	masterJob, err := u.jobRepo.GetByID(ctx, masterJobID)
-	if err != nil {
+	if _, err := u.jobRepo.GetByID(ctx, masterJobID); err != nil {
 		return nil, fmt.Errorf("failed to get master job: %w", err)
 	}
 
-	// Get all jobs with similar name pattern (sub-jobs)
-	allJobs, err := u.jobRepo.GetAll(ctx)
+	// Sub-jobs belong to the job group sharing the master job's ID
+	subJobs, err := u.jobRepo.GetByGroupID(ctx, masterJobID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all jobs: %w", err)
+		return nil, fmt.Errorf("failed to get sub-jobs: %w", err)
 	}
 
-	var subJobs []domain.Job
 	var agentAssignments []domain.AgentPerformance
 
-	// Filter sub-jobs based on master job name
-	masterJobName := strings.TrimSuffix(masterJob.Name, " (Master)")
-	for _, job := range allJobs {
-		if strings.Contains(job.Name, masterJobName) && !strings.Contains(job.Name, "(Master)") {
-			subJobs = append(subJobs, job)
-		}
-	}
-
 	// Get agent assignments
 	for _, subJob := range subJobs {
 		if subJob.AgentID != nil {
@@ -485,6 +544,7 @@ func (u *distributedJobUsecase) GetDistributedJobStatus(ctx context.Context, mas
 
 	return &domain.DistributedJobResult{
 		MasterJobID:      masterJobID,
+		GroupID:          masterJobID,
 		SubJobs:          subJobs,
 		AgentAssignments: agentAssignments,
 		TotalWords:       0,
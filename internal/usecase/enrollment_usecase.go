@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEnrollmentTTL = 24 * time.Hour
+	maxEnrollmentTokens  = 1000 // Per request
+)
+
+// EnrollmentUsecase hands out single-use enrollment tokens and trades them
+// for agent keys
+type EnrollmentUsecase interface {
+	// CreateTokens generates a batch of tokens. Only the returned tokens
+	// carry the token itself.
+	CreateTokens(ctx context.Context, req *domain.CreateEnrollmentTokensRequest) ([]domain.EnrollmentToken, error)
+	GetAllTokens(ctx context.Context) ([]domain.EnrollmentToken, error)
+	DeleteToken(ctx context.Context, id uuid.UUID) error
+	// Enroll creates an agent called name with a new agent key, using up
+	// the token. Unknown, expired and used tokens return
+	// domain.ErrEnrollmentTokenInvalid, ErrEnrollmentTokenExpired and
+	// ErrEnrollmentTokenUsed.
+	Enroll(ctx context.Context, req *domain.EnrollAgentRequest) (*domain.Agent, error)
+}
+
+type enrollmentUsecase struct {
+	enrollmentRepo domain.EnrollmentTokenRepository
+	agentUsecase   AgentUsecase
+}
+
+func NewEnrollmentUsecase(enrollmentRepo domain.EnrollmentTokenRepository, agentUsecase AgentUsecase) EnrollmentUsecase {
+	return &enrollmentUsecase{
+		enrollmentRepo: enrollmentRepo,
+		agentUsecase:   agentUsecase,
+	}
+}
+
+func (u *enrollmentUsecase) CreateTokens(ctx context.Context, req *domain.CreateEnrollmentTokensRequest) ([]domain.EnrollmentToken, error) {
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxEnrollmentTokens {
+		return nil, &domain.ValidationError{Field: "count", Message: fmt.Sprintf("must be between 1 and %d", maxEnrollmentTokens)}
+	}
+	if req.ExpiresInMinutes < 0 {
+		return nil, &domain.ValidationError{Field: "expires_in_minutes", Message: "cannot be negative"}
+	}
+	ttl := defaultEnrollmentTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	tokens := make([]domain.EnrollmentToken, 0, count)
+	for i := 0; i < count; i++ {
+		secret, err := generateEnrollmentToken()
+		if err != nil {
+			return nil, err
+		}
+		token := domain.EnrollmentToken{
+			Label:     strings.TrimSpace(req.Label),
+			Token:     secret,
+			TokenHash: hashEnrollmentToken(secret),
+			ExpiresAt: expiresAt,
+		}
+		if err := u.enrollmentRepo.Create(ctx, &token); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func (u *enrollmentUsecase) GetAllTokens(ctx context.Context) ([]domain.EnrollmentToken, error) {
+	return u.enrollmentRepo.GetAll(ctx)
+}
+
+func (u *enrollmentUsecase) DeleteToken(ctx context.Context, id uuid.UUID) error {
+	return u.enrollmentRepo.Delete(ctx, id)
+}
+
+func (u *enrollmentUsecase) Enroll(ctx context.Context, req *domain.EnrollAgentRequest) (*domain.Agent, error) {
+	token, err := u.enrollmentRepo.GetByHash(ctx, hashEnrollmentToken(strings.TrimSpace(req.Token)))
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, domain.ErrEnrollmentTokenInvalid
+		}
+		return nil, err
+	}
+	// Checked before the agent is created, so a refused token leaves nothing behind
+	if err := token.Usable(time.Now()); err != nil {
+		return nil, err
+	}
+
+	agentKey, err := generateAgentKey()
+	if err != nil {
+		return nil, err
+	}
+	agent, err := u.agentUsecase.GenerateAgentKey(ctx, strings.TrimSpace(req.Name), agentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another agent may have redeemed the token meanwhile
+	if err := u.enrollmentRepo.Redeem(ctx, token.ID, agent.ID, time.Now()); err != nil {
+		if delErr := u.agentUsecase.DeleteAgent(ctx, agent.ID); delErr != nil {
+			agentLogger(ctx, agent.ID).Error("Failed to remove agent of a refused enrollment: %v", delErr)
+		}
+		return nil, err
+	}
+
+	agentLogger(ctx, agent.ID).Info("Agent %s enrolled with token %s", agent.Name, token.ID.String()[:8])
+	return agent, nil
+}
+
+// generateEnrollmentToken returns 128 random bits, hex encoded
+func generateEnrollmentToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate enrollment token: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashEnrollmentToken is how tokens are stored and looked up
+func hashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
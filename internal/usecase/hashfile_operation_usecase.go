@@ -0,0 +1,573 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultOperationWorkers   = 2
+	defaultOperationListLimit = 50
+	maxOperationListLimit     = 500
+	maxMergeInputs            = 50
+	// maxHashLineLength fits long hashes such as Kerberos tickets
+	maxHashLineLength = 1 << 20
+	// operationSaveInterval limits how often progress is written
+	operationSaveInterval = time.Second
+)
+
+// errOperationInterrupted is the error of operations cut short by shutdown
+var errOperationInterrupted = errors.New("interrupted by server shutdown")
+
+// HashFileOperationUsecase merges, splits and diffs hash lists in the
+// background. The operation is returned right away and its progress and
+// results are read with GetOperation.
+type HashFileOperationUsecase interface {
+	MergeHashFiles(ctx context.Context, req *domain.MergeHashFilesRequest) (*domain.HashFileOperation, error)
+	SplitHashFile(ctx context.Context, id uuid.UUID, req *domain.SplitHashFileRequest) (*domain.HashFileOperation, error)
+	DiffHashFiles(ctx context.Context, req *domain.DiffHashFilesRequest) (*domain.HashFileOperation, error)
+	GetOperation(ctx context.Context, id uuid.UUID) (*domain.HashFileOperation, error)
+	GetOperations(ctx context.Context, limit int) ([]domain.HashFileOperation, error)
+	// FailInterrupted marks the operations a previous server run left
+	// unfinished as failed
+	FailInterrupted(ctx context.Context) error
+	// Stop cancels the running operations and waits for them to fail
+	Stop()
+}
+
+type hashFileOperationUsecase struct {
+	operationRepo domain.HashFileOperationRepository
+	hashFiles     HashFileUsecase
+	slots         chan struct{} // Limits how many operations run at once
+	ctx           context.Context
+	cancel        context.CancelFunc
+	running       sync.WaitGroup
+}
+
+// NewHashFileOperationUsecase runs at most workers operations at once, 2
+// when workers is zero. Results are stored through hashFiles, so they are
+// deduplicated, encrypted and counted against quotas like uploads.
+func NewHashFileOperationUsecase(operationRepo domain.HashFileOperationRepository, hashFiles HashFileUsecase, workers int) HashFileOperationUsecase {
+	if workers <= 0 {
+		workers = defaultOperationWorkers
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &hashFileOperationUsecase{
+		operationRepo: operationRepo,
+		hashFiles:     hashFiles,
+		slots:         make(chan struct{}, workers),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+func (u *hashFileOperationUsecase) MergeHashFiles(ctx context.Context, req *domain.MergeHashFilesRequest) (*domain.HashFileOperation, error) {
+	if len(req.HashFileIDs) < 2 || len(req.HashFileIDs) > maxMergeInputs {
+		return nil, &domain.ValidationError{Field: "hash_file_ids", Message: fmt.Sprintf("must list between 2 and %d hash files", maxMergeInputs)}
+	}
+	seen := make(map[uuid.UUID]bool, len(req.HashFileIDs))
+	for _, id := range req.HashFileIDs {
+		if seen[id] {
+			return nil, &domain.ValidationError{Field: "hash_file_ids", Message: fmt.Sprintf("lists %s more than once", id)}
+		}
+		seen[id] = true
+	}
+
+	inputs, err := u.loadInputs(ctx, req.HashFileIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs[1:] {
+		if !sameProject(input.ProjectID, inputs[0].ProjectID) {
+			return nil, &domain.ValidationError{Field: "hash_file_ids", Message: "hash files of different projects can't be merged"}
+		}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "merged.txt"
+	}
+	operation := &domain.HashFileOperation{Type: domain.HashFileOperationMerge, InputIDs: req.HashFileIDs, Name: name}
+	return u.start(ctx, operation, inputs, u.merge)
+}
+
+func (u *hashFileOperationUsecase) SplitHashFile(ctx context.Context, id uuid.UUID, req *domain.SplitHashFileRequest) (*domain.HashFileOperation, error) {
+	if req.Parts < 2 || req.Parts > domain.MaxSplitParts {
+		return nil, &domain.ValidationError{Field: "parts", Message: fmt.Sprintf("must be between 2 and %d", domain.MaxSplitParts)}
+	}
+
+	inputs, err := u.loadInputs(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	operation := &domain.HashFileOperation{Type: domain.HashFileOperationSplit, InputIDs: []uuid.UUID{id}, Parts: req.Parts}
+	return u.start(ctx, operation, inputs, u.split)
+}
+
+func (u *hashFileOperationUsecase) DiffHashFiles(ctx context.Context, req *domain.DiffHashFilesRequest) (*domain.HashFileOperation, error) {
+	if req.BaseID == req.CompareID {
+		return nil, &domain.ValidationError{Field: "compare_id", Message: "must be another hash file than base_id"}
+	}
+
+	inputs, err := u.loadInputs(ctx, []uuid.UUID{req.BaseID, req.CompareID})
+	if err != nil {
+		return nil, err
+	}
+	if !sameProject(inputs[0].ProjectID, inputs[1].ProjectID) {
+		return nil, &domain.ValidationError{Field: "compare_id", Message: "hash files of different projects can't be diffed"}
+	}
+	operation := &domain.HashFileOperation{Type: domain.HashFileOperationDiff, InputIDs: []uuid.UUID{req.BaseID, req.CompareID}}
+	return u.start(ctx, operation, inputs, u.diff)
+}
+
+func (u *hashFileOperationUsecase) GetOperation(ctx context.Context, id uuid.UUID) (*domain.HashFileOperation, error) {
+	return u.operationRepo.GetByID(ctx, id)
+}
+
+func (u *hashFileOperationUsecase) GetOperations(ctx context.Context, limit int) ([]domain.HashFileOperation, error) {
+	if limit <= 0 {
+		limit = defaultOperationListLimit
+	}
+	if limit > maxOperationListLimit {
+		limit = maxOperationListLimit
+	}
+	return u.operationRepo.GetRecent(ctx, limit)
+}
+
+func (u *hashFileOperationUsecase) FailInterrupted(ctx context.Context) error {
+	failed, err := u.operationRepo.FailUnfinished(ctx, "interrupted by server restart")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		infrastructure.ServerLogger.Warning("Marked %d unfinished hash file operations as failed", failed)
+	}
+	return nil
+}
+
+func (u *hashFileOperationUsecase) Stop() {
+	u.cancel()
+	u.running.Wait()
+}
+
+// loadInputs returns the hash files of ids, which must all be text lists
+func (u *hashFileOperationUsecase) loadInputs(ctx context.Context, ids []uuid.UUID) ([]*domain.HashFile, error) {
+	inputs := make([]*domain.HashFile, 0, len(ids))
+	for _, id := range ids {
+		hashFile, err := u.hashFiles.GetHashFile(ctx, id)
+		if err != nil {
+			return nil, &domain.NotFoundError{Entity: fmt.Sprintf("hash file %s", id)}
+		}
+		if hashFile.Type != "hash" {
+			return nil, &domain.ValidationError{Field: "hash_file_ids", Message: fmt.Sprintf("%s is a %s capture, only text hash lists can be merged, split or diffed", hashFile.OrigName, hashFile.Type)}
+		}
+		inputs = append(inputs, hashFile)
+	}
+	return inputs, nil
+}
+
+// start stores the operation and runs it in the background once a worker
+// slot is free. Its results go to the project of its inputs.
+func (u *hashFileOperationUsecase) start(ctx context.Context, operation *domain.HashFileOperation, inputs []*domain.HashFile,
+	run func(r *operationRun, inputs []*domain.HashFile) error) (*domain.HashFileOperation, error) {
+	operation.Status = domain.HashFileOperationPending
+	operation.ProjectID = inputs[0].ProjectID
+	operation.CreatedBy = domain.UserIDFromContext(ctx)
+	if err := u.operationRepo.Create(ctx, operation); err != nil {
+		return nil, err
+	}
+
+	// The run outlives the request; it keeps the user, so results count
+	// against their quota
+	runCtx := u.ctx
+	if operation.CreatedBy != nil {
+		runCtx = domain.WithUserID(runCtx, *operation.CreatedBy)
+	}
+	r := &operationRun{usecase: u, ctx: runCtx, operation: *operation}
+
+	u.running.Add(1)
+	go func() {
+		defer u.running.Done()
+		select {
+		case u.slots <- struct{}{}:
+			defer func() { <-u.slots }()
+		case <-runCtx.Done():
+			r.finish(errOperationInterrupted)
+			return
+		}
+
+		r.operation.Status = domain.HashFileOperationRunning
+		r.save()
+		r.finish(run(r, inputs))
+	}()
+
+	return operation, nil
+}
+
+// merge writes the lines of every input once, in the order they are first
+// seen
+func (u *hashFileOperationUsecase) merge(r *operationRun, inputs []*domain.HashFile) error {
+	var total int64
+	for _, input := range inputs {
+		total += input.Size
+	}
+	r.total = total
+
+	seen := make(map[lineKey]struct{})
+	var read, written int64
+	err := r.create([]string{r.operation.Name}, total, func(w *bufio.Writer, next func() error) error {
+		for _, input := range inputs {
+			err := r.eachLine(input, func(line []byte) error {
+				read++
+				key := keyOf(line)
+				if _, ok := seen[key]; ok {
+					return nil
+				}
+				seen[key] = struct{}{}
+				written++
+				return writeLine(w, line)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.operation.Counts = map[string]int64{"lines_read": read, "lines_written": written, "duplicates": read - written}
+	return nil
+}
+
+// split counts the input's lines, then writes them out in order into parts
+// whose sizes differ by at most one line
+func (u *hashFileOperationUsecase) split(r *operationRun, inputs []*domain.HashFile) error {
+	input := inputs[0]
+	parts := r.operation.Parts
+	r.total = 2 * input.Size
+
+	var lines int64
+	if err := r.eachLine(input, func([]byte) error { lines++; return nil }); err != nil {
+		return err
+	}
+	if lines < int64(parts) {
+		return fmt.Errorf("%s has %d lines, fewer than the %d parts asked for", input.OrigName, lines, parts)
+	}
+
+	// One more pass writes the parts one after the other
+	base, ext := splitName(input.OrigName)
+	names := make([]string, parts)
+	for part := range names {
+		names[part] = fmt.Sprintf("%s.part%dof%d%s", base, part+1, parts, ext)
+	}
+	err := r.create(names, input.Size, func(w *bufio.Writer, next func() error) error {
+		var index int64
+		part := int64(0)
+		return r.eachLine(input, func(line []byte) error {
+			if index == lines*(part+1)/int64(parts) {
+				if err := next(); err != nil {
+					return err
+				}
+				part++
+			}
+			index++
+			return writeLine(w, line)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.operation.Counts = map[string]int64{"lines": lines, "parts": int64(parts)}
+	return nil
+}
+
+// diff finds the lines only the compared file has, "added", and those only
+// the base file has, "removed". Each becomes a result when there are any.
+func (u *hashFileOperationUsecase) diff(r *operationRun, inputs []*domain.HashFile) error {
+	baseFile, compareFile := inputs[0], inputs[1]
+	r.total = 2 * (baseFile.Size + compareFile.Size)
+
+	// true once the line was found in the other file too
+	base := make(map[lineKey]bool)
+	if err := r.eachLine(baseFile, func(line []byte) error {
+		base[keyOf(line)] = false
+		return nil
+	}); err != nil {
+		return err
+	}
+	compare := make(map[lineKey]bool)
+	var added int64
+	if err := r.eachLine(compareFile, func(line []byte) error {
+		key := keyOf(line)
+		if _, ok := compare[key]; ok {
+			return nil
+		}
+		if _, ok := base[key]; ok {
+			base[key] = true
+			compare[key] = true
+		} else {
+			compare[key] = false
+			added++
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var removed, unchanged int64
+	for _, inBoth := range base {
+		if inBoth {
+			unchanged++
+		} else {
+			removed++
+		}
+	}
+
+	// Each line only once, in the order of its file
+	onlyIn := func(input *domain.HashFile, lines map[lineKey]bool, name string) error {
+		return r.create([]string{name}, input.Size, func(w *bufio.Writer, next func() error) error {
+			return r.eachLine(input, func(line []byte) error {
+				key := keyOf(line)
+				if inBoth, ok := lines[key]; !ok || inBoth {
+					return nil
+				}
+				lines[key] = true
+				return writeLine(w, line)
+			})
+		})
+	}
+	if added > 0 {
+		name, ext := splitName(compareFile.OrigName)
+		if err := onlyIn(compareFile, compare, name+".added"+ext); err != nil {
+			return err
+		}
+	}
+	if removed > 0 {
+		name, ext := splitName(baseFile.OrigName)
+		if err := onlyIn(baseFile, base, name+".removed"+ext); err != nil {
+			return err
+		}
+	}
+
+	r.operation.Counts = map[string]int64{"added": added, "removed": removed, "unchanged": unchanged}
+	return nil
+}
+
+// errResultAbandoned stops writing results once storing one failed
+var errResultAbandoned = errors.New("result abandoned")
+
+// lineKey identifies a line without keeping it in memory, so lists of
+// millions of long hashes can be deduplicated
+type lineKey [16]byte
+
+func keyOf(line []byte) lineKey {
+	sum := sha256.Sum256(line)
+	var key lineKey
+	copy(key[:], sum[:])
+	return key
+}
+
+func writeLine(w *bufio.Writer, line []byte) error {
+	if _, err := w.Write(line); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// splitName splits a file name into its base and extension, .txt when it
+// has none
+func splitName(name string) (string, string) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if ext == "" {
+		ext = ".txt"
+	}
+	return base, ext
+}
+
+// operationRun is one operation being run: its progress, and the results
+// created so far
+type operationRun struct {
+	usecase   *hashFileOperationUsecase
+	ctx       context.Context
+	mu        sync.Mutex // Guards operation, results are added while progress is saved
+	operation domain.HashFileOperation
+	results   []*domain.HashFile
+	total     int64 // Bytes the run reads in all
+	done      int64
+	lastSave  time.Time
+}
+
+// eachLine calls fn with every non-blank line of the hash file, without its
+// line ending
+func (r *operationRun) eachLine(hashFile *domain.HashFile, fn func(line []byte) error) error {
+	_, content, err := r.usecase.hashFiles.OpenHashFile(r.ctx, hashFile.ID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	scanner := bufio.NewScanner(&progressReader{reader: content, run: r})
+	scanner.Buffer(make([]byte, 64*1024), maxHashLineLength)
+	for scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("%s has a line longer than %d bytes", hashFile.OrigName, maxHashLineLength)
+		}
+		return err
+	}
+	return nil
+}
+
+// create stores what produce writes as new hash files named names, in
+// order: produce writes the first, then calls next to move on to the next
+// one. Each file is streamed into storage as it is written. sizeBound, the
+// most one file can hold, is what the storage quota is checked against,
+// since the sizes are only known at the end.
+func (r *operationRun) create(names []string, sizeBound int64, produce func(w *bufio.Writer, next func() error) error) error {
+	readers := make([]*io.PipeReader, len(names))
+	writers := make([]*io.PipeWriter, len(names))
+	for i := range names {
+		readers[i], writers[i] = io.Pipe()
+	}
+
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		current := 0
+		w := bufio.NewWriterSize(writers[0], 64*1024)
+		next := func() error {
+			if current == len(writers)-1 {
+				return fmt.Errorf("no file after %s", names[current])
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			writers[current].Close()
+			current++
+			w.Reset(writers[current])
+			return nil
+		}
+		err := produce(w, next)
+		if err == nil {
+			err = w.Flush()
+		}
+		for _, writer := range writers[current:] {
+			writer.CloseWithError(err)
+		}
+	}()
+
+	var err error
+	for i, name := range names {
+		var hashFile *domain.HashFile
+		if hashFile, err = r.usecase.hashFiles.UploadHashFile(r.ctx, name, readers[i], sizeBound, r.operation.ProjectID); err != nil {
+			break
+		}
+		r.mu.Lock()
+		r.results = append(r.results, hashFile)
+		r.operation.ResultIDs = append(r.operation.ResultIDs, hashFile.ID)
+		r.mu.Unlock()
+	}
+	// Unblocks produce when storing stopped reading early
+	for _, reader := range readers {
+		reader.CloseWithError(errResultAbandoned)
+	}
+	<-produced
+	return err
+}
+
+// advance counts n more bytes read, saving the progress now and then
+func (r *operationRun) advance(n int) {
+	r.done += int64(n)
+	if r.total <= 0 {
+		return
+	}
+	// Kept under 100 until the operation completes
+	r.mu.Lock()
+	r.operation.Progress = min(99.9, float64(r.done)*100/float64(r.total))
+	due := time.Since(r.lastSave) >= operationSaveInterval
+	r.mu.Unlock()
+	if due {
+		r.save()
+	}
+}
+
+// save writes the operation; it isn't cancelled with the run, so the last
+// state is written on shutdown too
+func (r *operationRun) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSave = time.Now()
+	if err := r.usecase.operationRepo.Update(context.Background(), &r.operation); err != nil {
+		infrastructure.ServerLogger.Warning("Failed to save hash file operation %s: %v", r.operation.ID, err)
+	}
+}
+
+// finish records the run's outcome. The results of a failed run are
+// deleted, unless they are files that existed before.
+func (r *operationRun) finish(err error) {
+	now := time.Now()
+	r.operation.CompletedAt = &now
+	if err == nil {
+		r.operation.Status = domain.HashFileOperationCompleted
+		r.operation.Progress = 100
+		r.save()
+		return
+	}
+
+	if r.ctx.Err() != nil {
+		err = errOperationInterrupted
+	}
+	for _, result := range r.results {
+		if result.Duplicate {
+			continue
+		}
+		if deleteErr := r.usecase.hashFiles.DeleteHashFile(context.Background(), result.ID); deleteErr != nil {
+			infrastructure.ServerLogger.Warning("Failed to delete hash file %s of failed operation %s: %v", result.ID, r.operation.ID, deleteErr)
+		}
+	}
+	r.operation.ResultIDs = nil
+	r.operation.Status = domain.HashFileOperationFailed
+	r.operation.Error = err.Error()
+	r.save()
+}
+
+// progressReader reports what is read to the run, and stops reading when
+// the run is cancelled
+type progressReader struct {
+	reader io.Reader
+	run    *operationRun
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.run.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.reader.Read(b)
+	p.run.advance(n)
+	return n, err
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -14,15 +16,36 @@ import (
 )
 
 type HashFileUsecase interface {
-	UploadHashFile(ctx context.Context, name string, content io.Reader, size int64) (*domain.HashFile, error)
+	// UploadHashFile stores the file in the project, or in none when projectID is nil
+	UploadHashFile(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.HashFile, error)
 	GetHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, error)
+	GetHashFileBySHA256(ctx context.Context, sum string) (*domain.HashFile, error)
 	GetAllHashFiles(ctx context.Context) ([]domain.HashFile, error)
+	GetHashFilesByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error)
 	DeleteHashFile(ctx context.Context, id uuid.UUID) error
+	// SetHashFileSource tags a hash file with where its hashes come from,
+	// which wordlist recommendations go by
+	SetHashFileSource(ctx context.Context, id uuid.UUID, source string) (*domain.HashFile, error)
+	// SetQuotaChecker makes uploads refuse files over a storage quota
+	SetQuotaChecker(checker domain.QuotaChecker)
+	// SetLookupCache makes deleted hash files drop out of the cached lookups
+	SetLookupCache(lookups LookupCache)
+	// SetFileEncryptor makes uploads be stored encrypted
+	SetFileEncryptor(encryptor domain.FileEncryptor)
+	// OpenHashFile returns the hash file and a reader of its content,
+	// decrypted when it is stored encrypted. The reader must be closed.
+	OpenHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, io.ReadCloser, error)
+	// EncryptStoredHashFiles encrypts the hash files stored before
+	// encryption was turned on, returning how many it encrypted
+	EncryptStoredHashFiles(ctx context.Context) (int, error)
 }
 
 type hashFileUsecase struct {
 	hashFileRepo domain.HashFileRepository
 	uploadDir    string
+	quotas       domain.QuotaChecker  // Optional, refuses uploads over a user's or project's quota
+	lookups      LookupCache          // Optional, invalidated when hash files are deleted
+	encryptor    domain.FileEncryptor // Optional, encrypts stored files
 }
 
 func NewHashFileUsecase(hashFileRepo domain.HashFileRepository, uploadDir string) HashFileUsecase {
@@ -32,7 +55,25 @@ func NewHashFileUsecase(hashFileRepo domain.HashFileRepository, uploadDir string
 	}
 }
 
-func (u *hashFileUsecase) UploadHashFile(ctx context.Context, name string, content io.Reader, size int64) (*domain.HashFile, error) {
+func (u *hashFileUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	u.quotas = checker
+}
+
+func (u *hashFileUsecase) SetLookupCache(lookups LookupCache) {
+	u.lookups = lookups
+}
+
+func (u *hashFileUsecase) SetFileEncryptor(encryptor domain.FileEncryptor) {
+	u.encryptor = encryptor
+}
+
+func (u *hashFileUsecase) UploadHashFile(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.HashFile, error) {
+	if u.quotas != nil {
+		if err := u.quotas.CheckStorageQuota(ctx, projectID, size); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create upload directory if it doesn't exist
 	if err := os.MkdirAll(u.uploadDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create upload directory: %w", err)
@@ -51,25 +92,42 @@ func (u *hashFileUsecase) UploadHashFile(ctx context.Context, name string, conte
 	}
 	defer file.Close()
 
-	// Copy content to file
-	written, err := io.Copy(file, content)
+	// Copy content to file, hashing the plaintext so that duplicates are
+	// found whether or not files are encrypted
+	hasher := sha256.New()
+	written, err := u.writeContent(file, io.TeeReader(content, hasher))
 	if err != nil {
 		// Clean up on error
 		os.Remove(filePath)
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+
+	// Reuse the existing hash file if the same content was uploaded before to
+	// the same project; other projects get their own copy
+	if existing, err := u.hashFileRepo.GetBySHA256(ctx, sum); err == nil && sameProject(existing.ProjectID, projectID) {
+		if _, statErr := os.Stat(existing.Path); statErr == nil {
+			file.Close()
+			os.Remove(filePath)
+			existing.Duplicate = true
+			return existing, nil
+		}
+	}
 
 	// Determine file type
 	fileType := u.determineFileType(name)
 
 	// Create hash file record
 	hashFile := &domain.HashFile{
-		ID:       fileID,
-		Name:     filename,
-		OrigName: name,
-		Path:     filePath,
-		Size:     written,
-		Type:     fileType,
+		ID:        fileID,
+		Name:      filename,
+		OrigName:  name,
+		Path:      filePath,
+		Size:      written,
+		Type:      fileType,
+		SHA256:    sum,
+		ProjectID: projectID,
+		CreatedBy: domain.UserIDFromContext(ctx),
 	}
 
 	if err := u.hashFileRepo.Create(ctx, hashFile); err != nil {
@@ -81,6 +139,77 @@ func (u *hashFileUsecase) UploadHashFile(ctx context.Context, name string, conte
 	return hashFile, nil
 }
 
+// writeContent copies content into file, through the encryptor when set
+func (u *hashFileUsecase) writeContent(file *os.File, content io.Reader) (int64, error) {
+	if u.encryptor == nil {
+		return io.Copy(file, content)
+	}
+
+	w, err := u.encryptor.Encrypt(file)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(w, content)
+	if err != nil {
+		return written, err
+	}
+	return written, w.Close()
+}
+
+func (u *hashFileUsecase) OpenHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, io.ReadCloser, error) {
+	hashFile, err := u.GetHashFile(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(hashFile.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open hash file: %w", err)
+	}
+	if u.encryptor == nil {
+		return hashFile, file, nil
+	}
+
+	reader, err := u.encryptor.Decrypt(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to decrypt hash file: %w", err)
+	}
+	return hashFile, struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+func (u *hashFileUsecase) EncryptStoredHashFiles(ctx context.Context) (int, error) {
+	if u.encryptor == nil {
+		return 0, fmt.Errorf("no encryption key is configured")
+	}
+
+	hashFiles, err := u.hashFileRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get hash files: %w", err)
+	}
+
+	encrypted := 0
+	for _, hashFile := range hashFiles {
+		if err := ctx.Err(); err != nil {
+			return encrypted, err
+		}
+		done, err := u.encryptor.EncryptFile(hashFile.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt %s: %w", hashFile.Path, err)
+		}
+		if done {
+			encrypted++
+		}
+	}
+	return encrypted, nil
+}
+
 func (u *hashFileUsecase) GetHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, error) {
 	hashFile, err := u.hashFileRepo.GetByID(ctx, id)
 	if err != nil {
@@ -89,6 +218,28 @@ func (u *hashFileUsecase) GetHashFile(ctx context.Context, id uuid.UUID) (*domai
 	return hashFile, nil
 }
 
+func (u *hashFileUsecase) SetHashFileSource(ctx context.Context, id uuid.UUID, source string) (*domain.HashFile, error) {
+	source, err := domain.NormalizeHashFileSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.hashFileRepo.SetSource(ctx, id, source); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, &domain.NotFoundError{Entity: "hash file"}
+		}
+		return nil, fmt.Errorf("failed to set hash file source: %w", err)
+	}
+	return u.hashFileRepo.GetByID(ctx, id)
+}
+
+func (u *hashFileUsecase) GetHashFileBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	hashFile, err := u.hashFileRepo.GetBySHA256(ctx, strings.ToLower(sum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hash file: %w", err)
+	}
+	return hashFile, nil
+}
+
 func (u *hashFileUsecase) GetAllHashFiles(ctx context.Context) ([]domain.HashFile, error) {
 	hashFiles, err := u.hashFileRepo.GetAll(ctx)
 	if err != nil {
@@ -97,6 +248,14 @@ func (u *hashFileUsecase) GetAllHashFiles(ctx context.Context) ([]domain.HashFil
 	return hashFiles, nil
 }
 
+func (u *hashFileUsecase) GetHashFilesByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	hashFiles, err := u.hashFileRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hash files: %w", err)
+	}
+	return hashFiles, nil
+}
+
 func (u *hashFileUsecase) DeleteHashFile(ctx context.Context, id uuid.UUID) error {
 	hashFile, err := u.hashFileRepo.GetByID(ctx, id)
 	if err != nil {
@@ -112,6 +271,9 @@ func (u *hashFileUsecase) DeleteHashFile(ctx context.Context, id uuid.UUID) erro
 	if err := u.hashFileRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete hash file record: %w", err)
 	}
+	if u.lookups != nil {
+		u.lookups.InvalidateHashFile(id)
+	}
 
 	return nil
 }
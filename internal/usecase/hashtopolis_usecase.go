@@ -0,0 +1,428 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// hashtopolisTag labels the jobs of Hashtopolis imports
+const hashtopolisTag = "hashtopolis"
+
+// HashtopolisUsecase moves teams over from Hashtopolis: it imports an
+// export of its hashlists, tasks and agents, and lets Hashtopolis agents
+// register and log in while they are switched to this server's agent
+type HashtopolisUsecase interface {
+	// Import creates a project of the export's text hashlists, their cracked
+	// hashes as credentials, and its tasks as jobs. Unfinished tasks come
+	// in paused, or cancelled when their attack can't run here. Agents are
+	// created offline, keeping their Hashtopolis token as agent key.
+	Import(ctx context.Context, projectName string, export *domain.HashtopolisExport) (*domain.HashtopolisImportResult, error)
+	// RegisterAgent creates an agent with a Hashtopolis voucher, which is
+	// an enrollment token of this server
+	RegisterAgent(ctx context.Context, voucher, name string) (*domain.Agent, error)
+	// LoginAgent returns the agent of a Hashtopolis token and marks it seen
+	LoginAgent(ctx context.Context, token string) (*domain.Agent, error)
+	// UpdateAgentDevices records the devices a Hashtopolis agent reported
+	UpdateAgentDevices(ctx context.Context, token string, devices []string) error
+}
+
+type hashtopolisUsecase struct {
+	projectRepo       domain.ProjectRepository
+	archiveRepo       domain.ProjectArchiveRepository
+	hashFileUsecase   HashFileUsecase
+	wordlistUsecase   WordlistUsecase
+	agentUsecase      AgentUsecase
+	enrollmentUsecase EnrollmentUsecase
+}
+
+func NewHashtopolisUsecase(projectRepo domain.ProjectRepository, archiveRepo domain.ProjectArchiveRepository, hashFileUsecase HashFileUsecase, wordlistUsecase WordlistUsecase, agentUsecase AgentUsecase, enrollmentUsecase EnrollmentUsecase) HashtopolisUsecase {
+	return &hashtopolisUsecase{
+		projectRepo:       projectRepo,
+		archiveRepo:       archiveRepo,
+		hashFileUsecase:   hashFileUsecase,
+		wordlistUsecase:   wordlistUsecase,
+		agentUsecase:      agentUsecase,
+		enrollmentUsecase: enrollmentUsecase,
+	}
+}
+
+func (u *hashtopolisUsecase) Import(ctx context.Context, projectName string, export *domain.HashtopolisExport) (*domain.HashtopolisImportResult, error) {
+	projectName = strings.TrimSpace(projectName)
+	if projectName == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+	if existing, err := u.projectRepo.GetByName(ctx, projectName); err == nil {
+		return nil, &domain.ValidationError{Field: "name", Message: fmt.Sprintf("project '%s' already exists", existing.Name)}
+	} else if !domain.IsNotFoundError(err) {
+		return nil, fmt.Errorf("failed to check project name: %w", err)
+	}
+
+	now := time.Now()
+	project := domain.Project{ID: uuid.New(), Name: projectName, Description: "Imported from Hashtopolis", CreatedAt: now, UpdatedAt: now}
+	imported := &hashtopolisImport{
+		u:         u,
+		now:       now,
+		archive:   &domain.ProjectArchive{Version: domain.ProjectArchiveVersion, ExportedAt: now, Project: project},
+		result:    &domain.HashtopolisImportResult{},
+		hashFiles: make(map[int]*domain.HashFile),
+		hashTypes: make(map[int]int),
+	}
+
+	if err := imported.storeHashlists(ctx, export); err != nil {
+		imported.removeFiles(ctx)
+		return nil, err
+	}
+	if err := imported.addTasks(ctx, export.Tasks); err != nil {
+		imported.removeFiles(ctx)
+		return nil, err
+	}
+
+	recorded, err := u.archiveRepo.Import(ctx, imported.archive)
+	if err != nil {
+		imported.removeFiles(ctx)
+		return nil, fmt.Errorf("failed to import project %s: %w", projectName, err)
+	}
+
+	result := imported.result
+	result.Project = project
+	result.Credentials = recorded
+	result.Jobs = len(imported.archive.Jobs)
+	// Agents last, so a failed import leaves none behind
+	imported.addAgents(ctx, export.Agents)
+	return result, nil
+}
+
+// hashtopolisImport builds the project archive of one import, tracking the
+// hash files it stored by Hashtopolis hashlist ID
+type hashtopolisImport struct {
+	u       *hashtopolisUsecase
+	now     time.Time
+	archive *domain.ProjectArchive
+	result  *domain.HashtopolisImportResult
+
+	hashFiles map[int]*domain.HashFile
+	hashTypes map[int]int // hashcat -m by hashlist ID
+	wordlists map[string]*domain.Wordlist
+	// Hash files this import created, removed again when it fails
+	created []uuid.UUID
+}
+
+// storeHashlists uploads each text hashlist as a hash file of the project
+// and turns its cracked hashes into credentials
+func (p *hashtopolisImport) storeHashlists(ctx context.Context, export *domain.HashtopolisExport) error {
+	hashes := make(map[int][]domain.HashtopolisHash)
+	for _, hash := range export.Hashes {
+		hashes[hash.HashlistID] = append(hashes[hash.HashlistID], hash)
+	}
+
+	projectID := p.archive.Project.ID
+	for _, hashlist := range export.Hashlists {
+		if hashlist.Format != domain.HashtopolisFormatText {
+			p.skip("hashlist %s: only text hashlists are imported", hashlist.HashlistName)
+			continue
+		}
+		if err := domain.ValidateHashMode(hashlist.HashTypeID); err != nil {
+			p.skip("hashlist %s: invalid hash type %d", hashlist.HashlistName, hashlist.HashTypeID)
+			continue
+		}
+		if len(hashes[hashlist.HashlistID]) == 0 {
+			p.skip("hashlist %s: the export has none of its hashes", hashlist.HashlistName)
+			continue
+		}
+
+		var content strings.Builder
+		lines := make([]string, len(hashes[hashlist.HashlistID]))
+		for i, hash := range hashes[hashlist.HashlistID] {
+			lines[i] = hash.Hash
+			if hashlist.IsSalted && hash.Salt != "" {
+				lines[i] += hashlist.SaltSeparator + hash.Salt
+			}
+			content.WriteString(lines[i] + "\n")
+		}
+		name := hashtopolisFileName(hashlist)
+		hashFile, err := p.u.hashFileUsecase.UploadHashFile(ctx, name, strings.NewReader(content.String()), int64(content.Len()), &projectID)
+		if err != nil {
+			return fmt.Errorf("failed to store hashlist %s: %w", hashlist.HashlistName, err)
+		}
+		if !hashFile.Duplicate {
+			p.created = append(p.created, hashFile.ID)
+		}
+		p.hashFiles[hashlist.HashlistID] = hashFile
+		p.hashTypes[hashlist.HashlistID] = hashlist.HashTypeID
+		p.result.HashFiles++
+
+		for i, hash := range hashes[hashlist.HashlistID] {
+			if !hash.IsCracked {
+				continue
+			}
+			crackedAt := p.now
+			if hash.TimeCracked > 0 {
+				crackedAt = time.Unix(hash.TimeCracked, 0)
+			}
+			p.archive.Credentials = append(p.archive.Credentials, domain.Credential{
+				ID: uuid.New(), Hash: lines[i], Plaintext: hash.Plaintext, HashType: hashlist.HashTypeID,
+				HashFileID: &hashFile.ID, SourceFile: hashFile.OrigName, Source: domain.CredentialSourceHashtopolis,
+				ProjectID: &projectID, CrackedAt: crackedAt,
+			})
+		}
+	}
+	return nil
+}
+
+// hashtopolisFileName names the hash file of a hashlist, whose names are
+// free text
+func hashtopolisFileName(hashlist domain.HashtopolisHashlist) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(hashlist.HashlistName))
+	if name == "" {
+		name = fmt.Sprintf("hashlist-%d", hashlist.HashlistID)
+	}
+	return name + ".txt"
+}
+
+// addTasks turns the tasks into jobs of the project
+func (p *hashtopolisImport) addTasks(ctx context.Context, tasks []domain.HashtopolisTask) error {
+	for _, task := range tasks {
+		hashFile, ok := p.hashFiles[task.HashlistID]
+		if !ok {
+			p.skip("task %s: its hashlist wasn't imported", task.TaskName)
+			continue
+		}
+
+		job := &domain.Job{
+			ID: uuid.New(), Name: task.TaskName, Status: domain.JobStatusPaused, HashType: p.hashTypes[task.HashlistID],
+			HashFile: hashFile.Path, HashFileID: &hashFile.ID, ProjectID: &p.archive.Project.ID,
+			Engine: domain.EngineHashcat, Tags: []string{hashtopolisTag}, CreatedAt: p.now, UpdatedAt: p.now,
+			TotalWords: task.Keyspace, ProcessedWords: min(task.KeyspaceProgress, task.Keyspace),
+		}
+		if job.Name == "" {
+			job.Name = fmt.Sprintf("Hashtopolis task %d", task.TaskID)
+		}
+		if task.Keyspace > 0 {
+			job.Progress = float64(job.ProcessedWords) / float64(task.Keyspace) * 100
+			job.NormalizedProgress = job.Progress
+		}
+		if task.IsArchived {
+			job.ArchivedAt = &p.now
+		}
+
+		reason, err := p.mapAttack(ctx, job, task.AttackCmd)
+		if err != nil {
+			return err
+		}
+		switch {
+		case task.Finished():
+			job.Status = domain.JobStatusCompleted
+			job.CompletedAt = &p.now
+		case reason == "" && bool(task.IsArchived):
+			reason = "archived unfinished in Hashtopolis"
+			fallthrough
+		case reason != "":
+			job.Status = domain.JobStatusCancelled
+			job.CompletedAt = &p.now
+			job.Result = reason
+			p.result.CancelledJobs++
+		default:
+			p.result.PausedJobs++
+		}
+
+		p.archive.Jobs = append(p.archive.Jobs, *job)
+		p.archive.JobEvents = append(p.archive.JobEvents, domain.JobEvent{
+			ID: uuid.New(), JobID: job.ID, ToStatus: job.Status, Actor: domain.ActorSystem,
+			Reason: strings.TrimSpace("imported from Hashtopolis " + reason), CreatedAt: p.now,
+		})
+		p.archive.JobComments = append(p.archive.JobComments, domain.JobComment{
+			ID: uuid.New(), JobID: job.ID, Author: "Hashtopolis import", CreatedAt: p.now,
+			Body: fmt.Sprintf("Hashtopolis task %d ran: %s", task.TaskID, task.AttackCmd),
+		})
+	}
+	return nil
+}
+
+// mapAttack sets the attack of a job from a Hashtopolis attack command. It
+// returns why the attack can't run on this server, empty when it can.
+func (p *hashtopolisImport) mapAttack(ctx context.Context, job *domain.Job, command string) (string, error) {
+	attack, err := domain.ParseHashtopolisAttack(command)
+	if err != nil {
+		return err.Error(), nil
+	}
+	job.AttackMode = attack.AttackMode
+	job.ExtraArgs = attack.ExtraArgs
+
+	reason := ""
+	if attack.AttackMode == domain.AttackModeBruteForce {
+		job.Wordlist = attack.Mask
+		charsets := append(attack.CustomCharsets, make([]string, domain.MaxCustomCharsets)...)[:domain.MaxCustomCharsets]
+		job.CustomCharset1, job.CustomCharset2, job.CustomCharset3, job.CustomCharset4 = charsets[0], charsets[1], charsets[2], charsets[3]
+		if err := domain.ValidateMask(attack.Mask); err != nil {
+			reason = err.Error()
+		} else if err := domain.ValidateCustomCharsets(attack.AttackMode, attack.Mask, attack.CustomCharsets...); err != nil {
+			reason = err.Error()
+		}
+	} else {
+		for i, name := range attack.Wordlists {
+			wordlist, err := p.wordlist(ctx, name)
+			if err != nil {
+				return "", err
+			}
+			if i == 0 {
+				job.Wordlist = name
+			}
+			if wordlist == nil {
+				p.missing(name)
+				if reason == "" {
+					reason = "wordlist " + name + " is not on this server"
+				}
+				continue
+			}
+			if i == 0 {
+				job.WordlistID = &wordlist.ID
+			} else {
+				job.Wordlist2ID = &wordlist.ID
+			}
+		}
+	}
+
+	// Agents only run hashcat's built-in rule files, one per job
+	if len(attack.RuleFiles) == 1 && domain.BuiltinRuleFiles[attack.RuleFiles[0]] {
+		job.Rules = attack.RuleFiles[0]
+	} else if len(attack.RuleFiles) > 0 {
+		p.missing(attack.RuleFiles...)
+		if reason == "" {
+			reason = "rule files " + strings.Join(attack.RuleFiles, ", ") + " are not hashcat's built-in rules"
+		}
+	}
+	if err := domain.ValidateHashcatArgs("extra_args", job.ExtraArgs); err != nil && reason == "" {
+		reason = err.Error()
+	}
+	return reason, nil
+}
+
+// wordlist returns the shared wordlist of the name, nil when there is none
+func (p *hashtopolisImport) wordlist(ctx context.Context, name string) (*domain.Wordlist, error) {
+	if p.wordlists == nil {
+		all, err := p.u.wordlistUsecase.GetAllWordlists(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wordlists: %w", err)
+		}
+		p.wordlists = make(map[string]*domain.Wordlist, len(all))
+		for i := range all {
+			if wordlist := &all[i]; wordlist.ProjectID == nil && !wordlist.Dynamic {
+				p.wordlists[wordlist.OrigName] = wordlist
+			}
+		}
+	}
+	return p.wordlists[name], nil
+}
+
+// addAgents creates the agents offline, under their Hashtopolis token so
+// the same key logs them in through the agent API and this server's agent
+func (p *hashtopolisImport) addAgents(ctx context.Context, agents []domain.HashtopolisAgent) {
+	for _, imported := range agents {
+		name := strings.TrimSpace(imported.AgentName)
+		if name == "" {
+			p.skip("agent %d: has no name", imported.AgentID)
+			continue
+		}
+		key := imported.Token
+		if key == "" {
+			var err error
+			if key, err = generateAgentKey(); err != nil {
+				p.skip("agent %s: %v", name, err)
+				continue
+			}
+		}
+
+		agent, err := p.u.agentUsecase.GenerateAgentKey(ctx, name, key)
+		if err != nil {
+			p.skip("agent %s: %v", name, err)
+			continue
+		}
+		agent.Capabilities = hashtopolisDevices(strings.Split(imported.Devices, "\n"))
+		if imported.LastTime > 0 {
+			agent.LastSeen = time.Unix(imported.LastTime, 0)
+		}
+		if err := p.u.agentUsecase.UpdateAgent(ctx, agent); err != nil {
+			p.skip("agent %s: %v", name, err)
+			continue
+		}
+		p.result.Agents++
+	}
+}
+
+func hashtopolisDevices(devices []string) string {
+	var kept []string
+	for _, device := range devices {
+		if device = strings.TrimSpace(device); device != "" {
+			kept = append(kept, device)
+		}
+	}
+	return strings.Join(kept, "; ")
+}
+
+func (p *hashtopolisImport) skip(format string, args ...any) {
+	p.result.Skipped = append(p.result.Skipped, fmt.Sprintf(format, args...))
+}
+
+func (p *hashtopolisImport) missing(names ...string) {
+	for _, name := range names {
+		if !slices.Contains(p.result.MissingFiles, name) {
+			p.result.MissingFiles = append(p.result.MissingFiles, name)
+		}
+	}
+}
+
+// removeFiles deletes the hash files a failed import stored
+func (p *hashtopolisImport) removeFiles(ctx context.Context) {
+	for _, id := range p.created {
+		p.u.hashFileUsecase.DeleteHashFile(ctx, id)
+	}
+}
+
+func (u *hashtopolisUsecase) RegisterAgent(ctx context.Context, voucher, name string) (*domain.Agent, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+	return u.enrollmentUsecase.Enroll(ctx, &domain.EnrollAgentRequest{Token: voucher, Name: name})
+}
+
+func (u *hashtopolisUsecase) LoginAgent(ctx context.Context, token string) (*domain.Agent, error) {
+	if token == "" {
+		return nil, domain.ErrAgentNotFound
+	}
+	agent, err := u.agentUsecase.GetByAgentKey(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	// Seen, but left offline: the Hashtopolis agent can't run this
+	// server's jobs
+	if err := u.agentUsecase.UpdateAgentLastSeen(ctx, agent.ID); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (u *hashtopolisUsecase) UpdateAgentDevices(ctx context.Context, token string, devices []string) error {
+	agent, err := u.LoginAgent(ctx, token)
+	if err != nil {
+		return err
+	}
+	if capabilities := hashtopolisDevices(devices); capabilities != "" {
+		agent.Capabilities = capabilities
+		if err := u.agentUsecase.UpdateAgent(ctx, agent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
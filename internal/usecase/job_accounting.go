@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxUsageSampleGap is the longest time between two progress reports of a
+// job that is billed. Longer gaps, such as an agent that lost its
+// connection or a server restart, are left out rather than guessed.
+const maxUsageSampleGap = time.Minute
+
+// SetCostRates sets the power draw assumed for devices whose agent doesn't
+// report one
+func (u *jobUsecase) SetCostRates(rates domain.CostRates) {
+	u.usageMu.Lock()
+	defer u.usageMu.Unlock()
+	u.rates = rates
+}
+
+// AccountJobUsage adds the time since the job's previous progress report,
+// times the devices it runs on, to its usage. Energy uses the power the
+// agent reported, or the configured draw per device when it reported none.
+// The caller saves the job.
+func (u *jobUsecase) AccountJobUsage(job *domain.Job, sample domain.UsageSample) {
+	now := time.Now()
+
+	u.usageMu.Lock()
+	last, ok := u.usageSampledAt[job.ID]
+	u.usageSampledAt[job.ID] = now
+	deviceWatts := u.rates.DeviceWatts
+	u.usageMu.Unlock()
+
+	// The first report counts from the job's start
+	if !ok {
+		if job.StartedAt == nil {
+			return
+		}
+		last = *job.StartedAt
+	}
+	elapsed := now.Sub(last)
+	if elapsed <= 0 || elapsed > maxUsageSampleGap {
+		return
+	}
+
+	devices := sample.Devices
+	if devices < 1 {
+		devices = 1
+	}
+	watts := sample.PowerWatts
+	if watts <= 0 {
+		watts = float64(devices) * deviceWatts
+	}
+	job.DeviceSeconds += elapsed.Seconds() * float64(devices)
+	job.EnergyWh += watts * elapsed.Hours()
+}
+
+// forgetUsageSample drops the last report time of a job that stopped
+// running, so a resumed job isn't billed for the pause
+func (u *jobUsecase) forgetUsageSample(id uuid.UUID) {
+	u.usageMu.Lock()
+	defer u.usageMu.Unlock()
+	delete(u.usageSampledAt, id)
+}
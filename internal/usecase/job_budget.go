@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// ExpireJobsOverBudget stops the pipeline stages that used up what their
+// job group's budget allows them, so the rest of the budget is left for the
+// stages after them. Agents don't know about budgets; one running a stopped
+// job finds it expired at its next status check. It returns how many jobs
+// expired.
+func (u *jobUsecase) ExpireJobsOverBudget(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ExpireJobsOverBudget")
+	defer span.End()
+
+	groups, err := u.jobRepo.GetBudgetedGroups(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job groups with a budget: %w", err)
+	}
+
+	expired := 0
+	for _, group := range groups {
+		jobs, err := u.jobRepo.GetByGroupID(ctx, group.ID)
+		if err != nil {
+			infrastructure.ServerLogger.Warning("Failed to get the jobs of job group %s: %v", group.ID, err)
+			continue
+		}
+
+		exhausted := make(map[string]domain.BudgetStageReport)
+		for _, stage := range group.Budget.Report(jobs).Stages {
+			if stage.Exhausted {
+				exhausted[stage.Name] = stage
+			}
+		}
+		for i := range jobs {
+			job := &jobs[i]
+			stage, ok := exhausted[job.BudgetStage]
+			if !ok || domain.IsTerminalJobStatus(job.Status) {
+				continue
+			}
+			if err := u.expireJob(ctx, job, domain.JobBudgetExhaustedReason); err != nil {
+				jobLogger(ctx, job.ID).Warning("Failed to expire job %s over budget: %v", job.ID, err)
+				continue
+			}
+			jobLogger(ctx, job.ID).Warning("Stage %s of job group %s used %.2f of its %.2f GPU-hours, expired job %s",
+				stage.Name, group.ID, stage.UsedGPUHours, stage.AllowedGPUHours, job.ID)
+			expired++
+		}
+	}
+	return expired, nil
+}
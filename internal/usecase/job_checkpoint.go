@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// InterruptJob takes back a running job from an agent that is shutting
+// down. The hashcat restore file the agent saved, if any, is stored so the
+// agent the dispatcher hands the job to next resumes it instead of starting
+// over.
+func (u *jobUsecase) InterruptJob(ctx context.Context, id uuid.UUID, req *domain.InterruptJobRequest) error {
+	ctx, span := startSpan(ctx, "JobUsecase.InterruptJob")
+	defer span.End()
+
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if err := checkTransition(job, domain.JobStatusInterrupted); err != nil {
+		return err
+	}
+
+	if len(req.Checkpoint) > 0 {
+		if len(req.Checkpoint) > domain.MaxCheckpointSize {
+			return &domain.ValidationError{Field: "checkpoint", Message: fmt.Sprintf("must be at most %d bytes", domain.MaxCheckpointSize)}
+		}
+		restore, err := domain.ParseHashcatRestore(req.Checkpoint)
+		if err != nil {
+			return err
+		}
+		checkpoint := &domain.JobCheckpoint{
+			JobID:          job.ID,
+			AgentID:        job.AgentID,
+			HashcatVersion: req.HashcatVersion,
+			RestorePoint:   restore.RestorePoint(),
+			Data:           req.Checkpoint,
+		}
+		if err := u.jobRepo.SaveCheckpoint(ctx, checkpoint); err != nil {
+			return fmt.Errorf("failed to save job checkpoint: %w", err)
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "agent shut down"
+	}
+	job.AgentID = nil
+	if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusInterrupted, reason); err != nil {
+		return err
+	}
+	u.forgetUsageSample(job.ID)
+
+	// Hand the job to another agent right away rather than on the next tick
+	if err := u.AssignJobsToAgents(ctx); err != nil {
+		jobLogger(ctx, job.ID).Warning("Failed to reassign interrupted job %s: %v", job.ID, err)
+	}
+	return nil
+}
+
+// GetJobCheckpoint returns the restore file an interrupted job resumes from
+func (u *jobUsecase) GetJobCheckpoint(ctx context.Context, id uuid.UUID) (*domain.JobCheckpoint, error) {
+	if _, err := u.jobRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return u.jobRepo.GetCheckpoint(ctx, id)
+}
+
+// attachCheckpoint fills in where the job resumes from, if an agent left a
+// checkpoint for it. Only the metadata travels with the job; agents fetch
+// the restore file itself when they start it.
+func (u *jobUsecase) attachCheckpoint(ctx context.Context, job *domain.Job) error {
+	checkpoint, err := u.jobRepo.GetCheckpoint(ctx, job.ID)
+	if domain.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	job.Checkpoint = checkpoint
+	return nil
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// CompareJobs sets the given jobs side by side: how long each ran, how much
+// of its keyspace it covered, what it cracked and what the compute cost.
+// Repeated IDs count once; it takes 2 to MaxComparedJobs jobs.
+func (u *jobUsecase) CompareJobs(ctx context.Context, ids []uuid.UUID) (*domain.JobComparison, error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) < 2 || len(unique) > domain.MaxComparedJobs {
+		return nil, &domain.ValidationError{Field: "ids", Message: fmt.Sprintf("must name 2 to %d jobs", domain.MaxComparedJobs)}
+	}
+
+	u.usageMu.Lock()
+	rates := u.rates
+	u.usageMu.Unlock()
+
+	now := time.Now()
+	comparison := &domain.JobComparison{
+		Jobs:         make([]domain.JobComparisonEntry, 0, len(unique)),
+		SameHashFile: true,
+		Currency:     rates.Currency,
+		GeneratedAt:  now,
+	}
+	for _, id := range unique {
+		job, err := u.jobRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, &domain.NotFoundError{Entity: "job " + id.String()}
+		}
+		cracks, err := u.jobRepo.GetCracks(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job cracks: %w", err)
+		}
+		comparison.Jobs = append(comparison.Jobs, compareJob(job, cracks, rates, now))
+	}
+
+	first := comparison.Jobs[0]
+	var mostCracks, bestRate, lowestPrice *domain.JobComparisonEntry
+	for i := range comparison.Jobs {
+		entry := &comparison.Jobs[i]
+		if first.HashFileID == nil || entry.HashFileID == nil || *entry.HashFileID != *first.HashFileID {
+			comparison.SameHashFile = false
+		}
+		if entry.Cracks == 0 {
+			continue
+		}
+		if mostCracks == nil || entry.Cracks > mostCracks.Cracks {
+			mostCracks = entry
+		}
+		if bestRate == nil || entry.CracksPerHour > bestRate.CracksPerHour {
+			bestRate = entry
+		}
+		if entry.CostPerCrack != nil && (lowestPrice == nil || *entry.CostPerCrack < *lowestPrice.CostPerCrack) {
+			lowestPrice = entry
+		}
+	}
+	if mostCracks != nil {
+		comparison.MostCracks = &mostCracks.ID
+		comparison.BestCrackRate = &bestRate.ID
+	}
+	// Without cost rates every crack is free, which says nothing
+	if lowestPrice != nil && (rates.DeviceHour > 0 || rates.KWh > 0) {
+		comparison.LowestCrackPrice = &lowestPrice.ID
+	}
+	return comparison, nil
+}
+
+// compareJob sums up one job of a comparison
+func compareJob(job *domain.Job, cracks []domain.JobCrack, rates domain.CostRates, now time.Time) domain.JobComparisonEntry {
+	entry := domain.JobComparisonEntry{
+		ID:                job.ID,
+		Name:              job.Name,
+		Status:            job.Status,
+		Engine:            job.EngineName(),
+		HashType:          job.HashType,
+		AttackMode:        job.AttackMode,
+		HashFileID:        job.HashFileID,
+		HashFile:          job.HashFile,
+		WordlistID:        job.WordlistID,
+		Wordlist:          job.Wordlist,
+		Wordlist2ID:       job.Wordlist2ID,
+		Rules:             job.Rules,
+		KeyspaceTotal:     job.TotalWords,
+		KeyspaceProcessed: job.ProcessedWords,
+		KeyspaceCovered:   job.NormalizedProgress,
+		Cracks:            len(cracks),
+		UsageCost:         rates.Price(job.DeviceSeconds, job.EnergyWh),
+	}
+	if job.Stats != nil && job.Stats.ProgressTotal > 0 {
+		entry.KeyspaceTotal = job.Stats.ProgressTotal
+		entry.KeyspaceProcessed = job.Stats.ProgressDone
+	}
+	if entry.KeyspaceCovered == 0 {
+		entry.KeyspaceCovered = job.Progress
+	}
+
+	if job.StartedAt != nil {
+		end := now
+		if job.CompletedAt != nil {
+			end = *job.CompletedAt
+		}
+		if end.After(*job.StartedAt) {
+			entry.DurationSeconds = end.Sub(*job.StartedAt).Seconds()
+		}
+	}
+
+	// Agents that predate crack reports only leave the password in the result
+	if entry.Cracks == 0 {
+		if _, ok := domain.ResultPassword(job.Result); ok {
+			entry.Cracks = 1
+		}
+	}
+	if len(cracks) > 0 {
+		elapsed := cracks[0].CrackedAt.Sub(job.CreatedAt).Seconds()
+		entry.TimeToFirstCrack = &elapsed
+	}
+	if entry.Cracks > 0 {
+		if entry.DurationSeconds > 0 {
+			entry.CracksPerHour = float64(entry.Cracks) / (entry.DurationSeconds / 3600)
+		}
+		perCrack := entry.Cost / float64(entry.Cracks)
+		entry.CostPerCrack = &perCrack
+	}
+	return entry
+}
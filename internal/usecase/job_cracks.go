@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// RecordJobCracks stores the hashes an agent reported its job cracked and
+// returns those that are new, in the order they were reported. They are
+// linked to the accounts of the job's hash file and fed to the project's
+// loopback wordlist right away, rather than when the job completes.
+func (u *jobUsecase) RecordJobCracks(ctx context.Context, id, agentID uuid.UUID, reports []domain.JobCrackReport) ([]domain.JobCrack, error) {
+	if len(reports) == 0 {
+		return nil, &domain.ValidationError{Field: "cracks", Message: "must not be empty"}
+	}
+	if len(reports) > domain.MaxJobCracksPerReport {
+		return nil, &domain.ValidationError{Field: "cracks", Message: fmt.Sprintf("at most %d cracks per report", domain.MaxJobCracksPerReport)}
+	}
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, &domain.NotFoundError{Entity: "job"}
+	}
+
+	// Agents report the time they saw the crack, which a skewed clock may
+	// put in the future
+	now := time.Now()
+	cracks := make([]domain.JobCrack, 0, len(reports))
+	for _, report := range reports {
+		crackedAt := report.CrackedAt
+		if crackedAt.IsZero() || crackedAt.After(now) {
+			crackedAt = now
+		}
+		cracks = append(cracks, domain.JobCrack{
+			JobID:     id,
+			AgentID:   &agentID,
+			Hash:      report.Hash,
+			Plaintext: report.Plaintext,
+			CrackedAt: crackedAt,
+		})
+	}
+
+	created, err := u.jobRepo.CreateCracks(ctx, cracks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save job cracks: %w", err)
+	}
+	if len(created) == 0 {
+		return created, nil
+	}
+	jobLogger(ctx, id).Info("Job %s cracked %d new hashes", id, len(created))
+
+	if u.crackedSink != nil {
+		passwords := make([]string, 0, len(created))
+		for _, crack := range created {
+			passwords = append(passwords, crack.Plaintext)
+		}
+		if err := u.crackedSink.AddCrackedPasswords(ctx, job.ProjectID, passwords...); err != nil {
+			jobLogger(ctx, id).Warning("Failed to add cracked passwords to the loopback wordlist: %v", err)
+		}
+	}
+	if u.credentials != nil {
+		if err := u.credentials.RecordJobCracks(ctx, job, created); err != nil {
+			jobLogger(ctx, id).Warning("Failed to record the cracked credentials: %v", err)
+		}
+	}
+	return created, nil
+}
+
+// GetJobCracks returns the hashes a job cracked and when it cracked the
+// first
+func (u *jobUsecase) GetJobCracks(ctx context.Context, id uuid.UUID) (*domain.JobCracks, error) {
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, &domain.NotFoundError{Entity: "job"}
+	}
+
+	cracks, err := u.jobRepo.GetCracks(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job cracks: %w", err)
+	}
+
+	result := &domain.JobCracks{Cracks: cracks}
+	if len(cracks) > 0 {
+		first := cracks[0].CrackedAt
+		elapsed := first.Sub(job.CreatedAt).Seconds()
+		result.FirstCrackAt = &first
+		result.TimeToFirstCrack = &elapsed
+	}
+	return result, nil
+}
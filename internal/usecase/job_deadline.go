@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// ExpireJobsPastDeadline stops the unfinished jobs whose deadline passed.
+// Agents stop their runs at the deadline themselves and report what they
+// got done, so a running job gets one lease period more; jobs that never
+// started expire at their deadline. It returns how many jobs expired.
+func (u *jobUsecase) ExpireJobsPastDeadline(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ExpireJobsPastDeadline")
+	defer span.End()
+
+	now := time.Now()
+	jobs, err := u.jobRepo.GetPastDeadline(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get jobs past their deadline: %w", err)
+	}
+
+	expired := 0
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Status == domain.JobStatusRunning && now.Before(job.Deadline.Add(u.lease)) {
+			continue
+		}
+		if err := u.expireJob(ctx, job, domain.JobDeadlineReachedReason); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to expire job %s past its deadline: %v", job.ID, err)
+			continue
+		}
+		jobLogger(ctx, job.ID).Warning("Job %s reached its deadline unfinished, expired it", job.ID)
+		expired++
+	}
+	return expired, nil
+}
+
+// expireJob ends a job at its deadline, or when its budget ran out, and
+// frees its agent. Unlike other finished jobs it keeps its progress, which
+// with its cracks makes up the partial result.
+func (u *jobUsecase) expireJob(ctx context.Context, job *domain.Job, reason string) error {
+	if err := checkTransition(job, domain.JobStatusExpired); err != nil {
+		return err
+	}
+	cracks, err := u.jobRepo.GetCracks(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get job cracks: %w", err)
+	}
+
+	held := job.Status == domain.JobStatusAssigned || job.Status == domain.JobStatusRunning
+	now := time.Now()
+	job.CompletedAt = &now
+	job.LeaseExpiresAt = nil
+	progress := job.NormalizedProgress
+	if progress == 0 {
+		progress = job.Progress
+	}
+	job.Result = domain.JobExpiredResult(reason, len(cracks), progress)
+
+	if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusExpired, job.Result); err != nil {
+		return err
+	}
+	u.forgetUsageSample(job.ID)
+
+	// Assigned jobs keep their agent busy as well as running ones
+	if held && job.AgentID != nil {
+		if err := u.agentRepo.UpdateStatus(ctx, *job.AgentID, "online"); err != nil {
+			jobLogger(ctx, job.ID).With("agent_id", *job.AgentID).Warning("Failed to update agent status: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxEstimateSeconds is the longest duration an estimated end time is given
+// for; anything longer doesn't fit a time.Duration
+var maxEstimateSeconds = time.Duration(math.MaxInt64).Seconds()
+
+// EstimateJob predicts how long a job configured like req would run on the
+// agents online now, without creating it. The request is validated like
+// CreateJob does, but quotas are not checked.
+func (u *jobUsecase) EstimateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.JobEstimate, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.EstimateJob")
+	defer span.End()
+
+	engine, err := validateJobRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	hashFileID, err := uuid.Parse(req.HashFileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash file ID: %w", err)
+	}
+	if _, err := u.hashFileRepo.GetByID(ctx, hashFileID); err != nil {
+		return nil, fmt.Errorf("hash file not found: %w", err)
+	}
+
+	job := &domain.Job{
+		HashType:       req.HashType,
+		AttackMode:     req.AttackMode,
+		Wordlist:       req.Wordlist,
+		Rules:          req.Rules,
+		CustomCharset1: req.CustomCharset1,
+		CustomCharset2: req.CustomCharset2,
+		CustomCharset3: req.CustomCharset3,
+		CustomCharset4: req.CustomCharset4,
+		ExtraArgs:      req.ExtraArgs,
+		Engine:         engine,
+		JohnFormat:     req.JohnFormat,
+		TotalWords:     req.TotalWords,
+	}
+	if req.WordlistID != "" {
+		wordlistID, err := uuid.Parse(req.WordlistID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wordlist ID: %w", err)
+		}
+		job.WordlistID = &wordlistID
+	}
+	if req.AttackMode == domain.AttackModeCombinator {
+		wordlist2ID, err := uuid.Parse(req.Wordlist2ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid second wordlist ID: %w", err)
+		}
+		job.Wordlist2ID = &wordlist2ID
+	}
+
+	// The agents the job would run on, as CreateJob picks them
+	var agents []domain.Agent
+	distributed, chosen := false, false
+	switch {
+	case req.AgentGroupID != "":
+		if req.AgentID != "" || len(req.AgentIDs) > 0 {
+			return nil, &domain.ValidationError{Field: "agent_group_id", Message: "cannot be combined with agent_id or agent_ids"}
+		}
+		groupID, err := parseAgentGroupID(req.AgentGroupID)
+		if err != nil {
+			return nil, err
+		}
+		if agents, err = onlineGroupAgents(ctx, u.agentRepo, u.maintenance, groupID); err != nil {
+			return nil, err
+		}
+		distributed = len(agents) > 1
+	case req.AgentID != "" || len(req.AgentIDs) > 0:
+		agentIDs := req.AgentIDs
+		if len(agentIDs) == 0 {
+			agentIDs = []string{req.AgentID}
+		}
+		for _, agentIDStr := range agentIDs {
+			agentID, err := uuid.Parse(agentIDStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid agent ID %s: %w", agentIDStr, err)
+			}
+			agent, err := u.agentRepo.GetByID(ctx, agentID)
+			if err != nil {
+				return nil, fmt.Errorf("agent not found: %w", err)
+			}
+			agents = append(agents, *agent)
+		}
+		distributed, chosen = len(agents) > 1, true
+	default:
+		if agents, err = u.agentRepo.GetAll(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get agents: %w", err)
+		}
+	}
+
+	runnable := u.runnableAgents(ctx, job, agents)
+	var notes []string
+	if chosen && len(runnable) < len(agents) {
+		notes = append(notes, fmt.Sprintf("%d of the chosen agents can't take the job now", len(agents)-len(runnable)))
+	}
+	return u.estimate(ctx, job, runnable, distributed, notes), nil
+}
+
+// EstimatePendingJob predicts the run time of a job that hasn't started: on
+// its agent once assigned, else on the fastest agent that could take it.
+// Jobs that have started get no estimate.
+func (u *jobUsecase) EstimatePendingJob(ctx context.Context, job *domain.Job) (*domain.JobEstimate, error) {
+	if job.Status != domain.JobStatusPending && job.Status != domain.JobStatusAssigned {
+		return nil, nil
+	}
+
+	if job.AgentID != nil {
+		agent, err := u.agentRepo.GetByID(ctx, *job.AgentID)
+		if err != nil {
+			return nil, fmt.Errorf("agent not found: %w", err)
+		}
+		return u.estimate(ctx, job, []domain.Agent{*agent}, false, nil), nil
+	}
+
+	agents, err := u.agentRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agents: %w", err)
+	}
+	return u.estimate(ctx, job, u.runnableAgents(ctx, job, agents), false, nil), nil
+}
+
+// runnableAgents keeps the agents that could take the job now: online,
+// outside maintenance windows and able to run its engine and options
+func (u *jobUsecase) runnableAgents(ctx context.Context, job *domain.Job, agents []domain.Agent) []domain.Agent {
+	inMaintenance := agentsInMaintenance(ctx, u.maintenance)
+	runnable := make([]domain.Agent, 0, len(agents))
+	for _, agent := range agents {
+		if agent.Status != "online" {
+			continue
+		}
+		if _, ok := inMaintenance[agent.ID]; ok {
+			continue
+		}
+		if err := u.checkAgentCanRun(ctx, &agent, job); err != nil {
+			continue
+		}
+		runnable = append(runnable, agent)
+	}
+	return runnable
+}
+
+// hashModeSpeeds returns each agent's speed on a hash mode: the best it
+// reached on earlier jobs of the mode, else its benchmark of the mode.
+// sources maps the agents to the SpeedSource of their speed.
+func (u *jobUsecase) hashModeSpeeds(ctx context.Context, hashType int) (speeds map[uuid.UUID]int64, sources map[uuid.UUID]string) {
+	speeds, err := u.jobRepo.GetAgentSpeedsByHashType(ctx, hashType)
+	if err != nil || speeds == nil {
+		speeds = make(map[uuid.UUID]int64)
+	}
+	sources = make(map[uuid.UUID]string, len(speeds))
+	for agentID := range speeds {
+		sources[agentID] = domain.SpeedSourceHashMode
+	}
+	if u.benchmarks == nil {
+		return speeds, sources
+	}
+
+	benchmarks, err := u.benchmarks.GetSpeedsByHashType(ctx, hashType)
+	if err != nil {
+		return speeds, sources
+	}
+	for agentID, speed := range benchmarks {
+		if speeds[agentID] <= 0 {
+			speeds[agentID], sources[agentID] = speed, domain.SpeedSourceHashModeBenchmark
+		}
+	}
+	return speeds, sources
+}
+
+// estimate divides the job's keyspace by the speed of the agents. Each
+// agent's speed is its speed on the hash mode, see hashModeSpeeds, falling
+// back to its startup benchmark; agents with neither are left out.
+func (u *jobUsecase) estimate(ctx context.Context, job *domain.Job, agents []domain.Agent, distributed bool, notes []string) *domain.JobEstimate {
+	estimate := &domain.JobEstimate{
+		Keyspace:    u.estimateKeyspace(ctx, job),
+		Distributed: distributed,
+		Agents:      []domain.AgentEstimate{},
+	}
+
+	speeds, sources := u.hashModeSpeeds(ctx, job.HashType)
+	unknown := 0
+	for _, agent := range agents {
+		agentEstimate := domain.AgentEstimate{AgentID: agent.ID, Name: agent.Name}
+		switch {
+		case speeds[agent.ID] > 0:
+			agentEstimate.Speed, agentEstimate.SpeedSource = speeds[agent.ID], sources[agent.ID]
+		case agent.Speed > 0:
+			agentEstimate.Speed, agentEstimate.SpeedSource = agent.Speed, domain.SpeedSourceBenchmark
+		default:
+			unknown++
+			continue
+		}
+		agentEstimate.DurationSeconds = float64(estimate.Keyspace) / float64(agentEstimate.Speed)
+		estimate.Agents = append(estimate.Agents, agentEstimate)
+	}
+	sort.Slice(estimate.Agents, func(i, j int) bool {
+		if estimate.Agents[i].Speed != estimate.Agents[j].Speed {
+			return estimate.Agents[i].Speed > estimate.Agents[j].Speed
+		}
+		return estimate.Agents[i].Name < estimate.Agents[j].Name
+	})
+	if unknown > 0 {
+		notes = append(notes, fmt.Sprintf("agents without speed data are left out (%d)", unknown))
+	}
+
+	switch {
+	case len(estimate.Agents) == 0:
+		notes = append(notes, "no online agent with speed data can take the job")
+	case estimate.Keyspace == 0:
+		notes = append(notes, "the keyspace of the attack is unknown")
+	default:
+		if distributed {
+			for _, agentEstimate := range estimate.Agents {
+				estimate.Speed += agentEstimate.Speed
+			}
+		} else {
+			estimate.Speed = estimate.Agents[0].Speed
+		}
+		estimate.DurationSeconds = float64(estimate.Keyspace) / float64(estimate.Speed)
+		if estimate.DurationSeconds < maxEstimateSeconds {
+			end := time.Now().Add(time.Duration(estimate.DurationSeconds * float64(time.Second)))
+			estimate.EstimatedEnd = &end
+		}
+		if job.Rules != "" {
+			notes = append(notes, "rules multiply the keyspace by their rule count, which is not included")
+		}
+	}
+
+	estimate.Note = strings.Join(notes, "; ")
+	return estimate
+}
+
+// estimateKeyspace returns the number of candidates a job tries, 0 if
+// unknown: the mask's keyspace for brute-force attacks, else the job's word
+// count or that of its wordlists
+func (u *jobUsecase) estimateKeyspace(ctx context.Context, job *domain.Job) int64 {
+	if job.AttackMode == domain.AttackModeBruteForce {
+		return domain.MaskKeyspace(job.Wordlist)
+	}
+	if job.TotalWords > 0 {
+		return job.TotalWords
+	}
+
+	if job.WordlistID == nil {
+		// Inline wordlist content
+		words := int64(0)
+		if strings.Contains(job.Wordlist, "\n") {
+			for _, word := range strings.Split(job.Wordlist, "\n") {
+				if strings.TrimSpace(word) != "" {
+					words++
+				}
+			}
+		}
+		return words
+	}
+	left, err := u.wordlistRepo.GetByID(ctx, *job.WordlistID)
+	if err != nil || left.WordCount == nil {
+		return 0
+	}
+	if job.AttackMode != domain.AttackModeCombinator || job.Wordlist2ID == nil {
+		return *left.WordCount
+	}
+	right, err := u.wordlistRepo.GetByID(ctx, *job.Wordlist2ID)
+	if err != nil || right.WordCount == nil {
+		return 0
+	}
+	return *left.WordCount * *right.WordCount
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// SetJobLease sets how long agents hold a job without renewing the lease
+func (u *jobUsecase) SetJobLease(lease time.Duration) {
+	if lease > 0 {
+		u.lease = lease
+	}
+}
+
+// leaseUntil is when a lease taken or renewed now runs out
+func (u *jobUsecase) leaseUntil() time.Time {
+	return time.Now().Add(u.lease)
+}
+
+// RenewJobLeases extends the leases on the jobs of agents that sent a
+// heartbeat
+func (u *jobUsecase) RenewJobLeases(ctx context.Context, agentIDs []uuid.UUID) error {
+	if err := u.jobRepo.RenewLeases(ctx, agentIDs, u.leaseUntil()); err != nil {
+		return fmt.Errorf("failed to renew job leases: %w", err)
+	}
+	return nil
+}
+
+// ExpireJobLeases takes jobs back from agents that stopped renewing their
+// lease. Running jobs are interrupted, so they resume from a checkpoint if
+// the agent left one, and assigned jobs go back to the queue; both are
+// handed to other agents right away. Jobs whose lease a heartbeat renewed
+// after they were read, or that changed status, are left alone. It returns
+// how many jobs it took back.
+func (u *jobUsecase) ExpireJobLeases(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ExpireJobLeases")
+	defer span.End()
+
+	now := time.Now()
+	jobs, err := u.jobRepo.GetExpiredLeases(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get jobs with expired leases: %w", err)
+	}
+
+	expired := 0
+	for _, job := range jobs {
+		to := domain.JobStatusPending
+		if job.Status == domain.JobStatusRunning {
+			to = domain.JobStatusInterrupted
+		}
+		if err := checkTransition(&job, to); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to take back job %s with an expired lease: %v", job.ID, err)
+			continue
+		}
+		taken, err := u.jobRepo.ExpireLease(ctx, job.ID, job.Status, to, now)
+		if err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to take back job %s with an expired lease: %v", job.ID, err)
+			continue
+		}
+		if !taken {
+			jobLogger(ctx, job.ID).Debug("Lease on job %s was renewed or the job moved on, leaving it", job.ID)
+			continue
+		}
+		recordJobEvent(ctx, u.jobRepo, job.ID, job.Status, to, "lease expired")
+		u.forgetUsageSample(job.ID)
+		if job.AgentID != nil {
+			agentLogger(ctx, *job.AgentID).Warning("Lease of agent %s on job %s expired, requeueing the job", job.AgentID, job.ID)
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		if err := u.AssignJobsToAgents(ctx); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to reassign jobs with expired leases: %v", err)
+		}
+	}
+	return expired, nil
+}
+
+// RunLeaseSweeper takes back jobs with expired leases several times per
+// lease period until ctx is done. Leases run out while the server is down,
+// so agents get one lease period after startup to renew them.
+func (u *jobUsecase) RunLeaseSweeper(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(u.lease):
+	}
+
+	ticker := time.NewTicker(u.lease / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.ExpireJobLeases(domain.WithActor(ctx, domain.ActorSystem)); err != nil {
+				infrastructure.ServerLogger.Error("%v", err)
+			}
+		}
+	}
+}
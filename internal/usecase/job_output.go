@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxJobOutputBytes caps each stored output stream; agents send a bounded
+// tail already, this only guards against oversized reports
+const maxJobOutputBytes = 256 * 1024
+
+// SaveJobOutput stores the tail of hashcat's output an agent reported for a
+// job, replacing an earlier report
+func (u *jobUsecase) SaveJobOutput(ctx context.Context, id uuid.UUID, output *domain.JobOutput) error {
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	stored := *output
+	stored.JobID = job.ID
+	stored.AgentID = job.AgentID
+	stored.CreatedAt = time.Now()
+
+	var cut bool
+	stored.Stdout, cut = tailOutput(stored.Stdout)
+	stored.Truncated = stored.Truncated || cut
+	stored.Stderr, cut = tailOutput(stored.Stderr)
+	stored.Truncated = stored.Truncated || cut
+
+	return u.jobRepo.SaveOutput(ctx, &stored)
+}
+
+func (u *jobUsecase) GetJobOutput(ctx context.Context, id uuid.UUID) (*domain.JobOutput, error) {
+	if _, err := u.jobRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return u.jobRepo.GetOutput(ctx, id)
+}
+
+// tailOutput keeps the last maxJobOutputBytes of s, starting at a line
+// boundary, and reports whether anything was cut
+func tailOutput(s string) (string, bool) {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= maxJobOutputBytes {
+		return s, false
+	}
+
+	s = s[len(s)-maxJobOutputBytes:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToValidUTF8(s, ""), true
+}
@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ApplyJobPlaybook queues the attacks of a playbook in pipeline order, as
+// jobs of a job group named after the playbook. Every attack is resolved
+// and validated before the first job is created, so a playbook with a
+// mistake queues nothing; a dry run stops there.
+func (u *jobUsecase) ApplyJobPlaybook(ctx context.Context, playbook *domain.JobPlaybook, projectID string, dryRun bool) (*domain.JobPlaybookResult, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ApplyJobPlaybook", attribute.String("playbook.name", playbook.Name))
+	defer span.End()
+
+	if err := playbook.Validate(); err != nil {
+		return nil, err
+	}
+	refs := &playbookRefs{u: u}
+	if projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: "project_id", Message: "must be a UUID"}
+		}
+		refs.projectID = &id
+	}
+
+	result := &domain.JobPlaybookResult{Name: playbook.Name, DryRun: dryRun, Budget: playbook.PlanBudget()}
+	var problems []domain.ValidationError
+	for _, attack := range playbook.Ordered() {
+		req, err := refs.jobRequest(ctx, attack)
+		if err == nil {
+			req.ProjectID = projectID
+			if result.Budget != nil {
+				req.BudgetStage = attack.Name
+			}
+			_, err = validateJobRequest(req)
+		}
+		if err != nil {
+			var vErr *domain.ValidationError
+			if !errors.As(err, &vErr) {
+				return nil, fmt.Errorf("attack %s: %w", attack.Name, err)
+			}
+			problems = append(problems, domain.ValidationError{Field: "attacks." + attack.Name + "." + vErr.Field, Message: vErr.Message})
+			continue
+		}
+		result.Attacks = append(result.Attacks, domain.PlaybookAttackResult{Attack: attack.Name, Request: *req})
+	}
+	if len(problems) > 0 {
+		return nil, &domain.PlaybookError{Problems: problems}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	group := &domain.JobGroup{ID: uuid.New(), Name: playbook.Name, Budget: result.Budget}
+	if err := u.jobRepo.CreateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create job group: %w", err)
+	}
+	result.GroupID = &group.ID
+	for i := range result.Attacks {
+		attack := &result.Attacks[i]
+		attack.Request.GroupID = group.ID.String()
+		job, err := u.CreateJob(ctx, &attack.Request)
+		if err != nil {
+			// Jobs queued before stay in the group, where they can be stopped
+			return nil, fmt.Errorf("queued %d of %d attacks into job group %s, then attack %s failed: %w",
+				i, len(result.Attacks), group.ID, attack.Attack, err)
+		}
+		attack.Job = job
+	}
+	return result, nil
+}
+
+// playbookRefs resolves the file, agent and agent group references of
+// playbook attacks, which may be IDs or names. Lists are read once per
+// playbook.
+type playbookRefs struct {
+	u         *jobUsecase
+	projectID *uuid.UUID
+
+	hashFiles []domain.HashFile
+	wordlists []domain.Wordlist
+	groups    []domain.AgentGroup
+}
+
+// jobRequest turns an attack into the request CreateJob takes
+func (r *playbookRefs) jobRequest(ctx context.Context, attack domain.PlaybookAttack) (*domain.CreateJobRequest, error) {
+	req := &domain.CreateJobRequest{
+		Name:       attack.Name,
+		AttackMode: attack.Mode(),
+		Rules:      attack.Rules,
+		ExtraArgs:  attack.ExtraArgs,
+		Tags:       attack.Tags,
+		Engine:     attack.Engine,
+		JohnFormat: attack.JohnFormat,
+	}
+	if attack.HashType != nil {
+		req.HashType = *attack.HashType
+	}
+
+	hashFile, err := r.hashFile(ctx, attack.HashFile)
+	if err != nil {
+		return nil, err
+	}
+	req.HashFileID = hashFile.ID.String()
+
+	if attack.Mask != "" {
+		// Brute-force jobs carry the mask in the wordlist field
+		req.Wordlist = attack.Mask
+	} else {
+		wordlist, err := r.wordlist(ctx, "wordlist", attack.Wordlist)
+		if err != nil {
+			return nil, err
+		}
+		req.WordlistID = wordlist.ID.String()
+		// The original name lets agents use a pre-staged copy of the file
+		req.Wordlist = wordlist.OrigName
+		if req.Wordlist == "" {
+			req.Wordlist = wordlist.Name
+		}
+	}
+	if attack.Wordlist2 != "" {
+		wordlist, err := r.wordlist(ctx, "wordlist2", attack.Wordlist2)
+		if err != nil {
+			return nil, err
+		}
+		req.Wordlist2ID = wordlist.ID.String()
+	}
+
+	charsets := []*string{&req.CustomCharset1, &req.CustomCharset2, &req.CustomCharset3, &req.CustomCharset4}
+	for i, charset := range attack.CustomCharsets {
+		*charsets[i] = charset
+	}
+
+	if attack.Agents != nil {
+		for _, name := range attack.Agents.Names {
+			agent, err := r.agent(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			req.AgentIDs = append(req.AgentIDs, agent.ID.String())
+		}
+		if attack.Agents.Group != "" {
+			group, err := r.agentGroup(ctx, attack.Agents.Group)
+			if err != nil {
+				return nil, err
+			}
+			req.AgentGroupID = group.ID.String()
+		}
+	}
+	return req, nil
+}
+
+// visible reports whether a file of owner may be used by the playbook's
+// project: files of no project are shared, those of another project are not
+func (r *playbookRefs) visible(owner *uuid.UUID) bool {
+	return owner == nil || r.projectID == nil || *owner == *r.projectID
+}
+
+func (r *playbookRefs) hashFile(ctx context.Context, ref string) (*domain.HashFile, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		file, err := r.u.lookupHashFile(ctx, id)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: "hash_file", Message: fmt.Sprintf("no hash file has ID %s", id)}
+		}
+		return file, nil
+	}
+	if r.hashFiles == nil {
+		all, err := r.u.hashFileRepo.GetAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hash files: %w", err)
+		}
+		r.hashFiles = all
+	}
+
+	var matches []*domain.HashFile
+	for i := range r.hashFiles {
+		file := &r.hashFiles[i]
+		if (file.Name == ref || file.OrigName == ref) && r.visible(file.ProjectID) {
+			matches = append(matches, file)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, &domain.ValidationError{Field: "hash_file", Message: fmt.Sprintf("no hash file is named %q", ref)}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &domain.ValidationError{Field: "hash_file", Message: fmt.Sprintf("%d hash files are named %q, use the ID", len(matches), ref)}
+	}
+}
+
+func (r *playbookRefs) wordlist(ctx context.Context, field, ref string) (*domain.Wordlist, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		wordlist, err := r.u.lookupWordlist(ctx, id)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: field, Message: fmt.Sprintf("no wordlist has ID %s", id)}
+		}
+		return wordlist, nil
+	}
+	if r.wordlists == nil {
+		all, err := r.u.wordlistRepo.GetAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list wordlists: %w", err)
+		}
+		r.wordlists = all
+	}
+
+	var matches []*domain.Wordlist
+	for i := range r.wordlists {
+		wordlist := &r.wordlists[i]
+		if (wordlist.Name == ref || wordlist.OrigName == ref) && r.visible(wordlist.ProjectID) {
+			matches = append(matches, wordlist)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, &domain.ValidationError{Field: field, Message: fmt.Sprintf("no wordlist is named %q", ref)}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &domain.ValidationError{Field: field, Message: fmt.Sprintf("%d wordlists are named %q, use the ID", len(matches), ref)}
+	}
+}
+
+func (r *playbookRefs) agent(ctx context.Context, ref string) (*domain.Agent, error) {
+	var agent *domain.Agent
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		agent, err = r.u.agentRepo.GetByID(ctx, id)
+	} else {
+		agent, err = r.u.agentRepo.GetByName(ctx, ref)
+	}
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil, &domain.ValidationError{Field: "agents", Message: fmt.Sprintf("no agent is named %q", ref)}
+		}
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (r *playbookRefs) agentGroup(ctx context.Context, ref string) (*domain.AgentGroup, error) {
+	if r.groups == nil {
+		all, err := r.u.agentRepo.GetAllGroups(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list agent groups: %w", err)
+		}
+		r.groups = all
+	}
+	for i := range r.groups {
+		if group := &r.groups[i]; group.ID.String() == ref || group.Name == ref {
+			return group, nil
+		}
+	}
+	return nil, &domain.ValidationError{Field: "agents.group", Message: fmt.Sprintf("no agent group is named %q", ref)}
+}
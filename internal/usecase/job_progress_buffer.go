@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultProgressFlushInterval  = 5 * time.Second
+	defaultProgressFlushThreshold = 500
+)
+
+// progressBuffer holds the latest progress report of each running job until
+// the next batched write. Agents report every few seconds; only the last
+// report before a flush is written.
+type progressBuffer struct {
+	mu        sync.Mutex
+	pending   map[uuid.UUID]domain.JobProgress
+	interval  time.Duration
+	threshold int           // Pending jobs that trigger a flush before the interval is up
+	full      chan struct{} // Signalled when the threshold is reached
+}
+
+func newProgressBuffer() *progressBuffer {
+	return &progressBuffer{
+		pending:   make(map[uuid.UUID]domain.JobProgress),
+		interval:  defaultProgressFlushInterval,
+		threshold: defaultProgressFlushThreshold,
+		full:      make(chan struct{}, 1),
+	}
+}
+
+// overlay applies buffered progress to jobs that still run
+func (b *progressBuffer) overlay(jobs ...*domain.Job) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, job := range jobs {
+		p, ok := b.pending[job.ID]
+		if !ok || (job.Status != domain.JobStatusRunning && job.Status != domain.JobStatusPaused) {
+			continue
+		}
+		job.Progress = p.Progress
+		job.NormalizedProgress = p.NormalizedProgress
+		job.Speed = p.Speed
+		job.ETA = p.ETA
+		job.Stats = p.Stats
+		job.DeviceSeconds = p.DeviceSeconds
+		job.EnergyWh = p.EnergyWh
+		job.UpdatedAt = p.ReportedAt
+	}
+}
+
+// bufferedJobRepository overlays buffered progress on the jobs it reads, so
+// the usecase never shows or saves progress older than the latest report
+type bufferedJobRepository struct {
+	domain.JobRepository
+	buffer *progressBuffer
+}
+
+func (r *bufferedJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
+	job, err := r.JobRepository.GetByID(ctx, id)
+	if err == nil && job != nil {
+		r.buffer.overlay(job)
+	}
+	return job, err
+}
+
+func (r *bufferedJobRepository) GetAll(ctx context.Context) ([]domain.Job, error) {
+	return r.overlayAll(r.JobRepository.GetAll(ctx))
+}
+
+func (r *bufferedJobRepository) GetByStatus(ctx context.Context, status string) ([]domain.Job, error) {
+	return r.overlayAll(r.JobRepository.GetByStatus(ctx, status))
+}
+
+func (r *bufferedJobRepository) GetByAgentID(ctx context.Context, agentID uuid.UUID) ([]domain.Job, error) {
+	return r.overlayAll(r.JobRepository.GetByAgentID(ctx, agentID))
+}
+
+func (r *bufferedJobRepository) List(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error) {
+	jobs, total, err := r.JobRepository.List(ctx, filter)
+	jobs, err = r.overlayAll(jobs, err)
+	return jobs, total, err
+}
+
+func (r *bufferedJobRepository) overlayAll(jobs []domain.Job, err error) ([]domain.Job, error) {
+	if err != nil {
+		return jobs, err
+	}
+	for i := range jobs {
+		r.buffer.overlay(&jobs[i])
+	}
+	return jobs, nil
+}
+
+// SetProgressFlush sets how often buffered progress is written, and how
+// many jobs with buffered progress trigger an early write
+func (u *jobUsecase) SetProgressFlush(interval time.Duration, threshold int) {
+	u.progress.mu.Lock()
+	defer u.progress.mu.Unlock()
+
+	if interval > 0 {
+		u.progress.interval = interval
+	}
+	if threshold > 0 {
+		u.progress.threshold = threshold
+	}
+}
+
+// RecordJobProgress buffers the progress, speed, ETA, stats and usage of a running
+// job for the next batched write. Readers see it right away.
+func (u *jobUsecase) RecordJobProgress(ctx context.Context, job *domain.Job) {
+	b := u.progress
+	b.mu.Lock()
+	b.pending[job.ID] = domain.JobProgress{
+		Progress:           job.Progress,
+		NormalizedProgress: job.NormalizedProgress,
+		Speed:              job.Speed,
+		ETA:                job.ETA,
+		Stats:              job.Stats,
+		DeviceSeconds:      job.DeviceSeconds,
+		EnergyWh:           job.EnergyWh,
+		ReportedAt:         time.Now(),
+	}
+	full := len(b.pending) >= b.threshold
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default: // A flush is already due
+		}
+	}
+}
+
+// FlushJobProgress writes the buffered progress in one batch. Reports of a
+// failed batch are kept for the next flush unless newer ones arrived.
+func (u *jobUsecase) FlushJobProgress(ctx context.Context) error {
+	b := u.progress
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[uuid.UUID]domain.JobProgress)
+	b.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := u.jobRepo.UpdateProgressBatch(ctx, pending); err != nil {
+		b.mu.Lock()
+		for id, p := range pending {
+			if _, newer := b.pending[id]; !newer {
+				b.pending[id] = p
+			}
+		}
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// RunProgressFlusher flushes buffered progress every flush interval, or
+// sooner when the buffer reaches its threshold, until ctx is done. It then
+// flushes once more so no report is lost on shutdown.
+func (u *jobUsecase) RunProgressFlusher(ctx context.Context) {
+	u.progress.mu.Lock()
+	interval := u.progress.interval
+	u.progress.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := u.FlushJobProgress(context.Background()); err != nil {
+				infrastructure.ServerLogger.Error("Failed to write job progress on shutdown: %v", err)
+			}
+			return
+		case <-ticker.C:
+		case <-u.progress.full:
+		}
+		if err := u.FlushJobProgress(ctx); err != nil {
+			infrastructure.ServerLogger.Error("Failed to write job progress: %v", err)
+		}
+	}
+}
@@ -67,8 +67,8 @@ func (s *JobProgressService) UpdateJobResult(ctx context.Context, jobID uuid.UUI
 	// Update status
 	job.Status = status
 
-	// Set completion time if completed
-	if status == "completed" || status == "failed" {
+	// Set completion time if finished
+	if domain.IsTerminalJobStatus(status) {
 		now := time.Now()
 		job.CompletedAt = &now
 		job.ETA = nil // Clear ETA when completed
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ReconcileAgentJobs brings the server's view of an agent's jobs in line
+// with what the agent reports after reconnecting, e.g. when either side
+// restarted. Running jobs the agent no longer has are interrupted and handed
+// to other agents, so they are neither stuck nor run twice. The job the
+// agent is running is kept if it is still the agent's, or taken back if it
+// was interrupted and not handed out yet; otherwise the agent is told to
+// stop it.
+func (u *jobUsecase) ReconcileAgentJobs(ctx context.Context, agentID uuid.UUID, snapshot *domain.AgentHeartbeat) (*domain.AgentSyncResult, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ReconcileAgentJobs")
+	defer span.End()
+
+	var current *uuid.UUID
+	if snapshot != nil {
+		current = snapshot.CurrentJobID
+	}
+
+	jobs, err := u.jobRepo.GetByAgentID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs of agent: %w", err)
+	}
+
+	result := &domain.AgentSyncResult{RequeuedJobs: []uuid.UUID{}}
+	for _, job := range jobs {
+		if job.Status != domain.JobStatusRunning || (current != nil && job.ID == *current) {
+			continue
+		}
+		job.AgentID = nil
+		if err := transitionJob(ctx, u.jobRepo, &job, domain.JobStatusInterrupted, "agent reconnected without the job"); err != nil {
+			return nil, err
+		}
+		u.forgetUsageSample(job.ID)
+		result.RequeuedJobs = append(result.RequeuedJobs, job.ID)
+	}
+
+	if current != nil {
+		if result.KeepJob, result.Reason, err = u.reclaimJob(ctx, agentID, *current, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result.RequeuedJobs) > 0 {
+		agentLogger(ctx, agentID).Warning("Agent reconnected without %d of its running jobs, handing them to other agents", len(result.RequeuedJobs))
+		if err := u.AssignJobsToAgents(ctx); err != nil {
+			agentLogger(ctx, agentID).Warning("Failed to reassign jobs: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// reclaimJob decides whether an agent keeps the job it reports running
+func (u *jobUsecase) reclaimJob(ctx context.Context, agentID, jobID uuid.UUID, snapshot *domain.AgentHeartbeat) (bool, string, error) {
+	job, err := u.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get job: %w", err)
+	}
+
+	owned := job.AgentID != nil && *job.AgentID == agentID
+	switch {
+	case owned && (job.Status == domain.JobStatusRunning || job.Status == domain.JobStatusPaused):
+	case owned && job.Status == domain.JobStatusAssigned:
+		// The start report was lost
+		if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusRunning, "agent reported it running"); err != nil {
+			return false, "", err
+		}
+	case job.Status == domain.JobStatusInterrupted && job.AgentID == nil:
+		// Taken away while the agent was out of touch, but not handed out yet
+		until := u.leaseUntil()
+		job.AgentID = &agentID
+		job.LeaseExpiresAt = &until
+		if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusAssigned, "agent reconnected with the job"); err != nil {
+			return false, "", err
+		}
+		if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusRunning, "agent reconnected with the job"); err != nil {
+			return false, "", err
+		}
+	case job.AgentID != nil && !owned:
+		return false, "the job was given to another agent", nil
+	default:
+		return false, "the job is " + job.Status, nil
+	}
+
+	if snapshot.JobProgress > job.Progress {
+		job.Progress = snapshot.JobProgress
+		job.Speed = snapshot.JobSpeed
+		u.ApplyRuntimeStats(ctx, job, nil)
+		u.RecordJobProgress(ctx, job)
+	}
+	return true, "", nil
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+)
+
+// JobRetentionWorker periodically archives old finished jobs and purges
+// soft-deleted ones
+type JobRetentionWorker interface {
+	Start(ctx context.Context)
+	Stop()
+	RunOnce(ctx context.Context)
+}
+
+type RetentionConfig struct {
+	CheckInterval time.Duration `json:"check_interval"` // How often to apply the policy (default: 1 hour)
+	ArchiveAfter  time.Duration `json:"archive_after"`  // Archive finished jobs older than this, 0 disables archiving
+	PurgeAfter    time.Duration `json:"purge_after"`    // Permanently remove deleted jobs older than this, 0 keeps them forever
+}
+
+type jobRetentionWorker struct {
+	jobRepo domain.JobRepository
+	config  RetentionConfig
+	ticker  *time.Ticker
+	done    chan struct{}
+}
+
+func NewJobRetentionWorker(jobRepo domain.JobRepository, config RetentionConfig) JobRetentionWorker {
+	if config.CheckInterval == 0 {
+		config.CheckInterval = time.Hour
+	}
+
+	return &jobRetentionWorker{
+		jobRepo: jobRepo,
+		config:  config,
+		done:    make(chan struct{}),
+	}
+}
+
+func (w *jobRetentionWorker) Start(ctx context.Context) {
+	infrastructure.ServerLogger.Info("Starting Job Retention Worker (interval: %v, archive after: %v, purge after: %v)",
+		w.config.CheckInterval, w.config.ArchiveAfter, w.config.PurgeAfter)
+
+	w.ticker = time.NewTicker(w.config.CheckInterval)
+
+	go func() {
+		w.RunOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-w.ticker.C:
+				w.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (w *jobRetentionWorker) Stop() {
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	select {
+	case <-w.done:
+		// Channel already closed
+	default:
+		close(w.done)
+	}
+	infrastructure.ServerLogger.Info("Job Retention Worker stopped")
+}
+
+// RunOnce applies the retention policy a single time
+func (w *jobRetentionWorker) RunOnce(ctx context.Context) {
+	now := time.Now()
+
+	if w.config.ArchiveAfter > 0 {
+		archived, err := w.jobRepo.Archive(ctx, now.Add(-w.config.ArchiveAfter))
+		if err != nil {
+			infrastructure.ServerLogger.Error("Failed to archive old jobs: %v", err)
+		} else if archived > 0 {
+			infrastructure.ServerLogger.Info("Archived %d finished jobs older than %v", archived, w.config.ArchiveAfter)
+		}
+	}
+
+	if w.config.PurgeAfter > 0 {
+		purged, err := w.jobRepo.Purge(ctx, now.Add(-w.config.PurgeAfter))
+		if err != nil {
+			infrastructure.ServerLogger.Error("Failed to purge deleted jobs: %v", err)
+		} else if purged > 0 {
+			infrastructure.ServerLogger.Info("Purged %d deleted jobs older than %v", purged, w.config.PurgeAfter)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+// ApplyRuntimeStats keeps the hashcat status an agent reported with the
+// job's progress and works out its normalized progress from it. Reports
+// without a status keep the previous one. The caller saves the job.
+func (u *jobUsecase) ApplyRuntimeStats(ctx context.Context, job *domain.Job, stats *domain.JobRuntimeStats) {
+	if stats != nil {
+		job.Stats = stats
+	}
+	job.NormalizedProgress = domain.NormalizeProgress(job, job.Stats, u.jobWords(ctx, job))
+}
+
+// jobWords is how many wordlist words the job covers, 0 if that isn't known
+func (u *jobUsecase) jobWords(ctx context.Context, job *domain.Job) int64 {
+	if job.WordLimit != nil && *job.WordLimit > 0 {
+		return *job.WordLimit
+	}
+	if job.WordlistID == nil {
+		return 0
+	}
+	wordlist, err := u.lookupWordlist(ctx, *job.WordlistID)
+	if err != nil || wordlist.WordCount == nil {
+		return 0
+	}
+	words := *wordlist.WordCount
+	if job.Skip != nil {
+		words -= *job.Skip
+	}
+	if words < 0 {
+		return 0
+	}
+	return words
+}
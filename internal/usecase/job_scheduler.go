@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Weights of the dispatcher's agent score. The agent's speed for the job's
+// hash mode sets the base. A local copy of the wordlist multiplies it,
+// because fetching a large wordlist usually costs more time than the speed
+// difference between agents. Every queued or running job divides it, and
+// so does GPU utilization the agent reports while not running our job.
+const (
+	localWordlistWeight = 4.0
+	localHashFileWeight = 1.5
+)
+
+// agentScheduler picks agents for pending jobs during one assignment round.
+// Inventories and speed history are loaded once per round and reused.
+type agentScheduler struct {
+	u      *jobUsecase
+	agents []domain.Agent
+	load   map[uuid.UUID]int
+	limits map[uuid.UUID]int // Max concurrent jobs of agents that have one
+	files  map[uuid.UUID][]domain.AgentFile
+	speeds map[int]map[uuid.UUID]int64 // Per hash mode
+}
+
+func (u *jobUsecase) newAgentScheduler(ctx context.Context, agents []domain.Agent, pendingJobs []domain.Job) (*agentScheduler, error) {
+	s := &agentScheduler{
+		u:      u,
+		agents: agents,
+		load:   make(map[uuid.UUID]int),
+		limits: make(map[uuid.UUID]int),
+		files:  make(map[uuid.UUID][]domain.AgentFile),
+		speeds: make(map[int]map[uuid.UUID]int64),
+	}
+
+	// Jobs already waiting on or running on an agent count as its load
+	countLoad := func(jobs []domain.Job) {
+		for _, job := range jobs {
+			if job.AgentID != nil {
+				s.load[*job.AgentID]++
+			}
+		}
+	}
+	countLoad(pendingJobs)
+	for _, status := range []string{domain.JobStatusAssigned, domain.JobStatusRunning} {
+		jobs, err := u.jobRepo.GetByStatus(ctx, status)
+		if err != nil {
+			return nil, err
+		}
+		countLoad(jobs)
+	}
+
+	for _, agent := range agents {
+		if settings, err := u.agentRepo.GetSettings(ctx, agent.ID); err == nil && settings.MaxConcurrentJobs > 0 {
+			s.limits[agent.ID] = settings.MaxConcurrentJobs
+		}
+	}
+
+	return s, nil
+}
+
+// next takes the best scoring free agent for a job. Ties go to the agent
+// listed first. It returns false when no free agent is left that can run
+// the job.
+func (s *agentScheduler) next(ctx context.Context, job *domain.Job) (domain.Agent, bool) {
+	if len(s.agents) == 0 {
+		return domain.Agent{}, false
+	}
+
+	wordlistSize, hashFileSize := s.requiredSizes(ctx, job)
+
+	best, bestScore := -1, 0.0
+	for i, agent := range s.agents {
+		score, ok := s.score(ctx, agent, job, wordlistSize, hashFileSize)
+		if ok && (best < 0 || score > bestScore) {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return domain.Agent{}, false // No free agent can run this job
+	}
+
+	agent := s.agents[best]
+	s.agents = append(s.agents[:best], s.agents[best+1:]...)
+	return agent, true
+}
+
+// score rates how soon an agent is likely to be cracking a job. It returns
+// false when the agent can't run the job at all: the job's engine is
+// missing or too old, the files it would download don't fit on its disk,
+// or it has as many jobs as its settings allow.
+func (s *agentScheduler) score(ctx context.Context, agent domain.Agent, job *domain.Job, wordlistSize, hashFileSize int64) (float64, bool) {
+	if limit, ok := s.limits[agent.ID]; ok && s.load[agent.ID] >= limit {
+		return 0, false
+	}
+	if s.u.checkAgentCanRun(ctx, &agent, job) != nil {
+		return 0, false
+	}
+	heartbeat := agent.Heartbeat
+
+	score := float64(s.speed(ctx, agent, job.HashType))
+	var download int64
+
+	// Mask jobs need no wordlist
+	if job.AttackMode != domain.AttackModeBruteForce && job.Wordlist != "" {
+		if s.holdsFile(ctx, agent.ID, job.Wordlist, wordlistSize) {
+			score *= localWordlistWeight
+		} else {
+			download += wordlistSize
+		}
+	}
+	if job.HashFile != "" {
+		if s.holdsFile(ctx, agent.ID, filepath.Base(job.HashFile), hashFileSize) {
+			score *= localHashFileWeight
+		} else {
+			download += hashFileSize
+		}
+	}
+
+	if heartbeat != nil {
+		if heartbeat.FreeDiskBytes >= 0 && download > heartbeat.FreeDiskBytes {
+			return 0, false
+		}
+		// A busy GPU without one of our jobs is shared with something else
+		if heartbeat.CurrentJobID == nil && heartbeat.GPUUtilization > 0 {
+			score /= 1 + float64(heartbeat.GPUUtilization)/100
+		}
+	}
+
+	return score / float64(1+s.load[agent.ID]), true
+}
+
+// speed is the agent's speed on the hash mode, see hashModeSpeeds, falling
+// back to its startup benchmark. Agents without any speed data still get a
+// small positive speed so locality and load can rank them.
+func (s *agentScheduler) speed(ctx context.Context, agent domain.Agent, hashType int) int64 {
+	speeds, ok := s.speeds[hashType]
+	if !ok {
+		speeds, _ = s.u.hashModeSpeeds(ctx, hashType)
+		s.speeds[hashType] = speeds
+	}
+
+	if speed := speeds[agent.ID]; speed > 0 {
+		return speed
+	}
+	if agent.Speed > 0 {
+		return agent.Speed
+	}
+	return 1
+}
+
+// checkAgentCanRun returns why an agent can't run a job: the agent belongs
+// to another tenant, the job's engine isn't installed, or the hashcat
+// version the agent reported can't run the job with the options configured
+// on the agent and the job's own, or can't resume the job's checkpoint.
+// Agents with an unknown hashcat version are given the benefit of the doubt.
+func (u *jobUsecase) checkAgentCanRun(ctx context.Context, agent *domain.Agent, job *domain.Job) error {
+	if !agent.ServesTenant(job.TenantID) {
+		return fmt.Errorf("agent belongs to another tenant")
+	}
+	engine := job.EngineName()
+	if !agent.SupportsEngine(engine) {
+		return fmt.Errorf("%s is not installed", engine)
+	}
+	if engine != domain.EngineHashcat {
+		return nil
+	}
+	if job.Checkpoint != nil {
+		if err := job.Checkpoint.CheckResume(agent.HashcatVersion); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := domain.ParseHashcatVersion(agent.HashcatVersion); !ok {
+		return nil
+	}
+	agentArgs, _ := u.agentRepo.GetHashcatArgs(ctx, agent.ID)
+	return domain.CheckHashcatSupport(agent.HashcatVersion, job.HashType, job.ExtraArgs, agentArgs)
+}
+
+// checkAgentNotInMaintenance refuses new jobs for an agent in a maintenance window
+func (u *jobUsecase) checkAgentNotInMaintenance(ctx context.Context, field string, agent *domain.Agent) error {
+	if maintenance, ok := agentsInMaintenance(ctx, u.maintenance)[agent.ID]; ok {
+		return maintenanceError(field, agent, maintenance)
+	}
+	return nil
+}
+
+// DrainAgent returns the jobs assigned to an agent that it hasn't started
+// yet to the queue, so the dispatcher can give them to other agents.
+// Running jobs are left to finish. It returns how many jobs went back.
+func (u *jobUsecase) DrainAgent(ctx context.Context, agentID uuid.UUID, reason string) (int, error) {
+	jobs, err := u.jobRepo.GetByAgentID(ctx, agentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get jobs of agent: %w", err)
+	}
+
+	drained, running := 0, false
+	for _, job := range jobs {
+		switch job.Status {
+		case domain.JobStatusAssigned:
+			job.AgentID = nil
+			if err := transitionJob(ctx, u.jobRepo, &job, domain.JobStatusPending, reason); err != nil {
+				return drained, err
+			}
+			drained++
+		case domain.JobStatusRunning:
+			running = true
+		}
+	}
+
+	// The dispatcher marked the agent busy when it assigned the jobs
+	if drained > 0 && !running {
+		agent, err := u.agentRepo.GetByID(ctx, agentID)
+		if err != nil {
+			return drained, err
+		}
+		if agent.Status == "busy" {
+			if err := u.agentRepo.UpdateStatus(ctx, agentID, "online"); err != nil {
+				return drained, fmt.Errorf("failed to update agent status: %w", err)
+			}
+		}
+	}
+	return drained, nil
+}
+
+// holdsFile reports whether the agent's inventory has a file of that name
+// and, when known, size: the same checks the agent makes before using it
+func (s *agentScheduler) holdsFile(ctx context.Context, agentID uuid.UUID, name string, size int64) bool {
+	files, ok := s.files[agentID]
+	if !ok {
+		files, _ = s.u.agentRepo.GetFiles(ctx, agentID)
+		s.files[agentID] = files
+	}
+
+	for _, file := range files {
+		if strings.EqualFold(file.Name, name) && (size == 0 || file.Size == size) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredSizes looks up the sizes of the job's wordlist and hash file, 0
+// when unknown
+func (s *agentScheduler) requiredSizes(ctx context.Context, job *domain.Job) (wordlistSize, hashFileSize int64) {
+	if job.WordlistID != nil {
+		if wordlist, err := s.u.lookupWordlist(ctx, *job.WordlistID); err == nil {
+			wordlistSize = wordlist.Size
+		}
+	}
+	if job.HashFileID != nil {
+		if hashFile, err := s.u.lookupHashFile(ctx, *job.HashFileID); err == nil {
+			hashFileSize = hashFile.Size
+		}
+	}
+	return wordlistSize, hashFileSize
+}
+
+// lookupWordlist reads a wordlist through the lookup cache when there is one
+func (u *jobUsecase) lookupWordlist(ctx context.Context, id uuid.UUID) (*domain.Wordlist, error) {
+	if u.lookups != nil {
+		return u.lookups.GetWordlist(ctx, id)
+	}
+	return u.wordlistRepo.GetByID(ctx, id)
+}
+
+// lookupHashFile reads a hash file through the lookup cache when there is one
+func (u *jobUsecase) lookupHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, error) {
+	if u.lookups != nil {
+		return u.lookups.GetHashFile(ctx, id)
+	}
+	return u.hashFileRepo.GetByID(ctx, id)
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// transitionJob moves a job to a new status if the state machine allows it,
+// saves the job and records the change in its event log. Callers set any
+// other fields (result, timestamps) on the job before calling it.
+func transitionJob(ctx context.Context, jobRepo domain.JobRepository, job *domain.Job, to, reason string) error {
+	from := job.Status
+	if err := checkTransition(job, to); err != nil {
+		return err
+	}
+
+	job.Status = to
+	if err := jobRepo.Update(ctx, job); err != nil {
+		job.Status = from
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	recordJobEvent(ctx, jobRepo, job.ID, from, to, reason)
+	return nil
+}
+
+// checkTransition lets callers validate a transition before they change
+// other fields of the job
+func checkTransition(job *domain.Job, to string) error {
+	if !domain.CanTransitionJob(job.Status, to) {
+		return &domain.JobTransitionError{From: job.Status, To: to}
+	}
+	return nil
+}
+
+// recordJobEvent adds an entry to a job's event log. Failing to record it
+// doesn't undo the status change it describes, so it is only logged.
+func recordJobEvent(ctx context.Context, jobRepo domain.JobRepository, jobID uuid.UUID, from, to, reason string) {
+	event := &domain.JobEvent{
+		JobID:      jobID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      domain.ActorFromContext(ctx),
+		Reason:     reason,
+	}
+	if err := jobRepo.CreateEvent(ctx, event); err != nil {
+		jobLogger(ctx, jobID).Warning("Failed to record event for job %s: %v", jobID, err)
+	}
+}
@@ -4,29 +4,115 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"go-distributed-hashcat/internal/domain"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type JobUsecase interface {
 	CreateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.Job, error)
+	// ApplyJobPlaybook queues the attacks of a playbook as one job group, or
+	// only resolves them on a dry run
+	ApplyJobPlaybook(ctx context.Context, playbook *domain.JobPlaybook, projectID string, dryRun bool) (*domain.JobPlaybookResult, error)
 	GetJob(ctx context.Context, id uuid.UUID) (*domain.Job, error)
 	GetAllJobs(ctx context.Context) ([]domain.Job, error)
 	GetJobsByStatus(ctx context.Context, status string) ([]domain.Job, error)
 	GetJobsByAgentID(ctx context.Context, agentID uuid.UUID) ([]domain.Job, error)
+	ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error)
 	GetAvailableJobForAgent(ctx context.Context, agentID uuid.UUID) (*domain.Job, error)
 	StartJob(ctx context.Context, id uuid.UUID) error
 	UpdateJobProgress(ctx context.Context, id uuid.UUID, progress float64, speed int64) error
 	UpdateJobData(ctx context.Context, job *domain.Job) error
 	CompleteJob(ctx context.Context, id uuid.UUID, result string, speed int64) error
 	FailJob(ctx context.Context, id uuid.UUID, reason string) error
+	CancelJob(ctx context.Context, id uuid.UUID, reason string) error
 	PauseJob(ctx context.Context, id uuid.UUID) error
 	ResumeJob(ctx context.Context, id uuid.UUID) error
 	DeleteJob(ctx context.Context, id uuid.UUID) error
+	GetArchivedJobs(ctx context.Context) ([]domain.Job, error)
+	RestoreJob(ctx context.Context, id uuid.UUID) error
+	RetryJob(ctx context.Context, id uuid.UUID, agentID *uuid.UUID) (*domain.Job, error)
 	AssignJobsToAgents(ctx context.Context) error
+	CreateJobGroup(ctx context.Context, name string) (*domain.JobGroup, error)
+	GetJobGroup(ctx context.Context, id uuid.UUID) (*domain.JobGroup, error)
+	GetJobGroupStatus(ctx context.Context, id uuid.UUID) (*domain.JobGroupStatus, error)
+	GetJobEvents(ctx context.Context, id uuid.UUID) ([]domain.JobEvent, error)
+	// SetJobTags replaces a job's tags and returns them normalized
+	SetJobTags(ctx context.Context, id uuid.UUID, tags []string) ([]string, error)
+	AddJobComment(ctx context.Context, id uuid.UUID, comment *domain.JobComment) error
+	GetJobComments(ctx context.Context, id uuid.UUID) ([]domain.JobComment, error)
+	DeleteJobComment(ctx context.Context, id, commentID uuid.UUID) error
+	SaveJobOutput(ctx context.Context, id uuid.UUID, output *domain.JobOutput) error
+	GetJobOutput(ctx context.Context, id uuid.UUID) (*domain.JobOutput, error)
+	InterruptJob(ctx context.Context, id uuid.UUID, req *domain.InterruptJobRequest) error
+	GetJobCheckpoint(ctx context.Context, id uuid.UUID) (*domain.JobCheckpoint, error)
+	// RecordJobCracks stores the hashes an agent's run cracked and returns
+	// the new ones, see job_cracks.go
+	RecordJobCracks(ctx context.Context, id, agentID uuid.UUID, reports []domain.JobCrackReport) ([]domain.JobCrack, error)
+	GetJobCracks(ctx context.Context, id uuid.UUID) (*domain.JobCracks, error)
+	// CompareJobs sets jobs side by side, see job_comparison.go
+	CompareJobs(ctx context.Context, ids []uuid.UUID) (*domain.JobComparison, error)
+	ReconcileAgentJobs(ctx context.Context, agentID uuid.UUID, snapshot *domain.AgentHeartbeat) (*domain.AgentSyncResult, error)
+	// SetCrackedPasswordSink makes cracked jobs feed their password to sink
+	SetCrackedPasswordSink(sink CrackedPasswordSink)
+	// SetCredentialRecorder makes cracked jobs record their password as
+	// credentials of the accounts of their hash file
+	SetCredentialRecorder(recorder CredentialRecorder)
+	// SetEffectivenessRecorder makes completed jobs record what they cracked
+	// for the keyspace they searched
+	SetEffectivenessRecorder(recorder EffectivenessRecorder)
+	// SetQuotaChecker makes job creation refuse jobs over a quota
+	SetQuotaChecker(checker domain.QuotaChecker)
+	// SetMaintenanceCalendar makes the dispatcher pass over agents in a
+	// maintenance window
+	SetMaintenanceCalendar(calendar domain.MaintenanceCalendar)
+	// SetJobLease sets how long agents hold a job without renewing the lease
+	SetJobLease(lease time.Duration)
+	// SetJobTimeouts sets the limits of new jobs whose request sets none
+	SetJobTimeouts(timeouts domain.JobTimeouts)
+	RenewJobLeases(ctx context.Context, agentIDs []uuid.UUID) error
+	ExpireJobLeases(ctx context.Context) (int, error)
+	// RunLeaseSweeper requeues jobs whose lease expired until ctx is done
+	RunLeaseSweeper(ctx context.Context)
+	ExpireStalledJobs(ctx context.Context) (int, error)
+	// ExpireJobsPastDeadline stops unfinished jobs whose deadline passed,
+	// see job_deadline.go
+	ExpireJobsPastDeadline(ctx context.Context) (int, error)
+	// ExpireJobsOverBudget stops the pipeline stages that used up their
+	// share of their job group's budget, see job_budget.go
+	ExpireJobsOverBudget(ctx context.Context) (int, error)
+	// RunWatchdog stops jobs over their max runtime, past their deadline or
+	// over budget, and requeues stalled jobs until ctx is done
+	RunWatchdog(ctx context.Context)
+	DrainAgent(ctx context.Context, agentID uuid.UUID, reason string) (int, error)
+	// AccountJobUsage adds the compute a running job used since its last
+	// progress report to the job, which the caller then saves
+	AccountJobUsage(job *domain.Job, sample domain.UsageSample)
+	// ApplyRuntimeStats keeps an agent's hashcat status on the job and sets
+	// its normalized progress, which the caller then saves
+	ApplyRuntimeStats(ctx context.Context, job *domain.Job, stats *domain.JobRuntimeStats)
+	// RecordJobProgress buffers a running job's progress, speed, ETA and
+	// usage for the next batched write, see job_progress_buffer.go
+	RecordJobProgress(ctx context.Context, job *domain.Job)
+	FlushJobProgress(ctx context.Context) error
+	RunProgressFlusher(ctx context.Context)
+	SetProgressFlush(interval time.Duration, threshold int)
+	// SetLookupCache makes the scheduler look wordlists and hash files up
+	// through the shared lookup cache
+	SetLookupCache(lookups LookupCache)
+	// SetBenchmarks makes the scheduler and estimates use the agents'
+	// benchmarks of hash modes they ran no jobs of
+	SetBenchmarks(benchmarks domain.AgentBenchmarkRepository)
+	SetCostRates(rates domain.CostRates)
+	// EstimateJob predicts the run time of a job configuration, for dry runs
+	EstimateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.JobEstimate, error)
+	// EstimatePendingJob predicts the run time of a job that hasn't started,
+	// nil for jobs that have
+	EstimatePendingJob(ctx context.Context, job *domain.Job) (*domain.JobEstimate, error)
 }
 
 type jobUsecase struct {
@@ -34,18 +120,88 @@ type jobUsecase struct {
 	agentRepo    domain.AgentRepository
 	hashFileRepo domain.HashFileRepository
 	wordlistRepo domain.WordlistRepository
+	crackedSink  CrackedPasswordSink        // Optional, collects cracked passwords into loopback wordlists
+	credentials  CredentialRecorder         // Optional, links cracked passwords to their accounts
+	analytics    EffectivenessRecorder      // Optional, keeps the crack history of wordlists, rules and masks
+	quotas       domain.QuotaChecker        // Optional, refuses jobs over a user's or project's quota
+	maintenance  domain.MaintenanceCalendar // Optional, agents in a maintenance window take no new jobs
+	lease        time.Duration              // How long agents hold a job without renewing it
+	timeouts     domain.JobTimeouts         // Limits of jobs whose request sets none
+	progress     *progressBuffer            // Progress reports waiting for the next batched write
+	lookups      LookupCache                // Optional, caches the scheduler's wordlist and hash file lookups
+
+	// Optional, per hash mode benchmarks for modes an agent ran no jobs of
+	benchmarks domain.AgentBenchmarkRepository
+
+	// Usage accounting, see job_accounting.go
+	usageMu        sync.Mutex
+	usageSampledAt map[uuid.UUID]time.Time // Last progress report of each running job
+	rates          domain.CostRates
+
+	// Stall detection, see job_watchdog.go
+	watchMu       sync.Mutex
+	progressMarks map[uuid.UUID]progressMark
 }
 
 func NewJobUsecase(jobRepo domain.JobRepository, agentRepo domain.AgentRepository, hashFileRepo domain.HashFileRepository, wordlistRepo domain.WordlistRepository) JobUsecase {
+	progress := newProgressBuffer()
 	return &jobUsecase{
-		jobRepo:      jobRepo,
-		agentRepo:    agentRepo,
-		hashFileRepo: hashFileRepo,
-		wordlistRepo: wordlistRepo,
+		jobRepo:        &bufferedJobRepository{JobRepository: jobRepo, buffer: progress},
+		agentRepo:      agentRepo,
+		hashFileRepo:   hashFileRepo,
+		wordlistRepo:   wordlistRepo,
+		lease:          domain.DefaultJobLease,
+		progress:       progress,
+		usageSampledAt: make(map[uuid.UUID]time.Time),
+		progressMarks:  make(map[uuid.UUID]progressMark),
 	}
 }
 
+func (u *jobUsecase) SetCrackedPasswordSink(sink CrackedPasswordSink) {
+	u.crackedSink = sink
+}
+
+func (u *jobUsecase) SetCredentialRecorder(recorder CredentialRecorder) {
+	u.credentials = recorder
+}
+
+func (u *jobUsecase) SetEffectivenessRecorder(recorder EffectivenessRecorder) {
+	u.analytics = recorder
+}
+
+func (u *jobUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	u.quotas = checker
+}
+
+func (u *jobUsecase) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar) {
+	u.maintenance = calendar
+}
+
+func (u *jobUsecase) SetLookupCache(lookups LookupCache) {
+	u.lookups = lookups
+}
+
+func (u *jobUsecase) SetBenchmarks(benchmarks domain.AgentBenchmarkRepository) {
+	u.benchmarks = benchmarks
+}
+
+// checkJobQuota refuses jobs over a quota, when quotas are enabled
+func (u *jobUsecase) checkJobQuota(ctx context.Context, projectID *uuid.UUID, jobs int) error {
+	if u.quotas == nil {
+		return nil
+	}
+	return u.quotas.CheckJobQuota(ctx, projectID, jobs)
+}
+
 func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.Job, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.CreateJob")
+	defer span.End()
+
+	engine, err := validateJobRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate hash file exists
 	hashFileID, err := uuid.Parse(req.HashFileID)
 	if err != nil {
@@ -67,11 +223,24 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 		HashFileID:     &hashFileID,
 		Wordlist:       req.Wordlist,
 		Rules:          req.Rules,
+		CustomCharset1: req.CustomCharset1,
+		CustomCharset2: req.CustomCharset2,
+		CustomCharset3: req.CustomCharset3,
+		CustomCharset4: req.CustomCharset4,
+		ExtraArgs:      req.ExtraArgs,
+		Tags:           req.Tags,
+		Engine:         engine,
+		JohnFormat:     req.JohnFormat,
 		Progress:       0,
 		Speed:          0,
 		TotalWords:     req.TotalWords,
 		ProcessedWords: 0,
+		CreatedBy:      domain.UserIDFromContext(ctx),
+		TenantID:       domain.TenantIDFromContext(ctx),
 	}
+	job.MaxRuntimeMinutes, job.StallTimeoutMinutes = u.timeouts.Resolve(req.MaxRuntimeMinutes, req.StallTimeoutMinutes)
+	job.Deadline = req.Deadline
+	job.BudgetStage = req.BudgetStage
 
 	// Handle wordlist ID if provided
 	var wordlistID *uuid.UUID
@@ -84,6 +253,91 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 		job.WordlistID = wordlistID
 	}
 
+	// Combinator attacks try every pair of words, so their keyspace is the
+	// product of both word counts
+	var left, right *domain.Wordlist
+	if req.AttackMode == domain.AttackModeCombinator {
+		wordlist2ID, err := uuid.Parse(req.Wordlist2ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid second wordlist ID: %w", err)
+		}
+		if left, right, err = u.combinatorWordlists(ctx, *wordlistID, wordlist2ID); err != nil {
+			return nil, err
+		}
+		job.Wordlist2ID = &wordlist2ID
+		if left.WordCount != nil && right.WordCount != nil {
+			job.TotalWords = *left.WordCount * *right.WordCount
+		}
+	}
+
+	// Project jobs can use the project's files and files in no project, but
+	// not the files of other projects
+	if req.ProjectID != "" {
+		projectID, err := uuid.Parse(req.ProjectID)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: "project_id", Message: "must be a UUID"}
+		}
+		job.ProjectID = &projectID
+
+		if hashFile.ProjectID != nil && *hashFile.ProjectID != projectID {
+			return nil, &domain.ValidationError{Field: "hash_file_id", Message: "hash file belongs to another project"}
+		}
+		if wordlistID != nil {
+			wordlist, err := u.wordlistRepo.GetByID(ctx, *wordlistID)
+			if err != nil {
+				return nil, fmt.Errorf("wordlist not found: %w", err)
+			}
+			if wordlist.ProjectID != nil && *wordlist.ProjectID != projectID {
+				return nil, &domain.ValidationError{Field: "wordlist_id", Message: "wordlist belongs to another project"}
+			}
+		}
+		if right != nil && right.ProjectID != nil && *right.ProjectID != projectID {
+			return nil, &domain.ValidationError{Field: "wordlist2_id", Message: "wordlist belongs to another project"}
+		}
+	}
+
+	// Attach to an existing job group (e.g. sub-jobs created by /jobs/auto)
+	if req.GroupID != "" {
+		groupID, err := uuid.Parse(req.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group ID: %w", err)
+		}
+		if _, err := u.jobRepo.GetGroupByID(ctx, groupID); err != nil {
+			return nil, fmt.Errorf("job group not found: %w", err)
+		}
+		job.GroupID = &groupID
+	}
+
+	// Targeting an agent group runs the job on the group's online agents
+	if req.AgentGroupID != "" {
+		if req.AgentID != "" || len(req.AgentIDs) > 0 {
+			return nil, &domain.ValidationError{Field: "agent_group_id", Message: "cannot be combined with agent_id or agent_ids"}
+		}
+		groupID, err := parseAgentGroupID(req.AgentGroupID)
+		if err != nil {
+			return nil, err
+		}
+		agents, err := onlineGroupAgents(ctx, u.agentRepo, u.maintenance, groupID)
+		if err != nil {
+			return nil, err
+		}
+
+		groupReq := *req
+		for _, agent := range agents {
+			groupReq.AgentIDs = append(groupReq.AgentIDs, agent.ID.String())
+		}
+		req = &groupReq
+	}
+
+	// Every sub-job counts against the quota, since each occupies an agent
+	jobCount := 1
+	if len(req.AgentIDs) > 1 {
+		jobCount = len(req.AgentIDs)
+	}
+	if err := u.checkJobQuota(ctx, job.ProjectID, jobCount); err != nil {
+		return nil, err
+	}
+
 	// Handle agent assignment (single or multiple)
 	if len(req.AgentIDs) > 0 {
 		// Multiple agent assignment for distributed jobs
@@ -104,16 +358,34 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 			if agent.Status != "online" {
 				return nil, fmt.Errorf("agent %s is not available (status: %s)", agent.Name, agent.Status)
 			}
+			if err := u.checkAgentNotInMaintenance(ctx, "agent_ids", agent); err != nil {
+				return nil, err
+			}
+			if err := u.checkAgentCanRun(ctx, agent, job); err != nil {
+				return nil, &domain.ValidationError{Field: "agent_ids", Message: fmt.Sprintf("agent %s can't run this job: %v", agent.Name, err)}
+			}
 
 			agentIDs = append(agentIDs, agentID)
 		}
 
+		// John the Ripper has no --skip/--limit to split the keyspace with
+		if len(agentIDs) > 1 && engine != domain.EngineHashcat {
+			return nil, &domain.ValidationError{Field: "agent_ids", Message: engine + " jobs can't be split across agents"}
+		}
+
 		// Create separate job for each agent (distributed job creation)
 		if len(agentIDs) > 1 {
 			// Get wordlist details for distribution
 			var totalWords int64
+			// Candidates per word of the split wordlist, above 1 for combinator attacks
+			amplifier := int64(1)
 
-			if req.WordlistID != "" {
+			if left != nil {
+				// Hashcat's --skip and --limit count words of the larger wordlist
+				if left.WordCount != nil && right.WordCount != nil {
+					totalWords, amplifier = domain.CombinatorKeyspace(*left.WordCount, *right.WordCount)
+				}
+			} else if req.WordlistID != "" {
 				wordlistID, err := uuid.Parse(req.WordlistID)
 				if err == nil {
 					// Get wordlist from repository to get accurate word count
@@ -195,6 +467,14 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 				agentPerformances[i].Weight = float64(agentPerformances[i].Speed) / float64(totalSpeed)
 			}
 
+			// Link the sub-jobs so their combined progress can be tracked. A new
+			// group is stored together with the sub-jobs.
+			var group *domain.JobGroup
+			if job.GroupID == nil {
+				group = &domain.JobGroup{ID: uuid.New(), Name: req.Name}
+				job.GroupID = &group.ID
+			}
+
 			// Create sub-jobs for each agent with skip/limit parameters
 			var subJobs []*domain.Job
 			currentSkip := int64(0)
@@ -220,47 +500,68 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 				subJob := &domain.Job{
 					ID:             uuid.New(),
 					Name:           fmt.Sprintf("%s (%s)", req.Name, agentPerf.Name),
-					Status:         "pending",
+					Status:         domain.JobStatusAssigned,
 					HashType:       req.HashType,
 					AttackMode:     req.AttackMode,
 					HashFile:       hashFile.Path,
 					HashFileID:     &hashFileID,
 					Wordlist:       req.Wordlist, // Use original wordlist for all agents
 					WordlistID:     wordlistID,   // Reference to original wordlist
+					Wordlist2ID:    job.Wordlist2ID,
 					Rules:          req.Rules,
+					CustomCharset1: req.CustomCharset1,
+					CustomCharset2: req.CustomCharset2,
+					CustomCharset3: req.CustomCharset3,
+					CustomCharset4: req.CustomCharset4,
+					ExtraArgs:      req.ExtraArgs,
+					Tags:           req.Tags,
 					Progress:       0,
 					Speed:          0,
-					TotalWords:     wordCount,
+					TotalWords:     wordCount * amplifier,
 					ProcessedWords: 0,
 					AgentID:        &agentPerf.AgentID,
 					Skip:           &skip,  // Hashcat --skip parameter
 					WordLimit:      &limit, // Hashcat --limit parameter
+					GroupID:        job.GroupID,
+					ProjectID:      job.ProjectID,
+					CreatedBy:      job.CreatedBy,
+					TenantID:       job.TenantID,
 					CreatedAt:      time.Now(),
 					UpdatedAt:      time.Now(),
 				}
-
-				// Save sub-job
-				if err := u.jobRepo.Create(ctx, subJob); err != nil {
-					return nil, fmt.Errorf("failed to create sub-job %d: %w", i, err)
-				}
-
-				// Auto-start the job after creation
-				if err := u.StartJob(ctx, subJob.ID); err != nil {
-					fmt.Printf("Warning: failed to auto-start job %s: %v\n", subJob.Name, err)
-				} else {
-					fmt.Printf("✅ Auto-started job \"%s\" for agent %s\n", subJob.Name, agentPerf.Name)
-				}
+				subJob.MaxRuntimeMinutes, subJob.StallTimeoutMinutes = job.MaxRuntimeMinutes, job.StallTimeoutMinutes
+				subJob.Deadline = job.Deadline
+				subJob.BudgetStage = job.BudgetStage
 
 				subJobs = append(subJobs, subJob)
 
 				// Log distribution info
-				fmt.Printf("✅ Created job \"%s\" for agent %s with skip=%d, limit=%d words (%.1f%%)\n",
+				jobLogger(ctx, subJob.ID).With("agent_id", agentPerf.AgentID).Info("Prepared job \"%s\" for agent %s with skip=%d, limit=%d words (%.1f%%)",
 					subJob.Name, agentPerf.Name, skip, limit, agentPerf.Weight*100)
 
 				// Update currentSkip for next agent
 				currentSkip += wordCount
 			}
 
+			// Save all sub-jobs at once so a failed insert can't leave part of
+			// the keyspace running without the rest
+			if err := u.jobRepo.CreateBatch(ctx, group, subJobs); err != nil {
+				return nil, fmt.Errorf("failed to create sub-jobs: %w", err)
+			}
+
+			for _, subJob := range subJobs {
+				recordJobEvent(ctx, u.jobRepo, subJob.ID, "", subJob.Status, "created")
+			}
+
+			// Auto-start the jobs only once the whole set is stored
+			for _, subJob := range subJobs {
+				if err := u.StartJob(domain.WithActor(ctx, domain.ActorSystem), subJob.ID); err != nil {
+					jobLogger(ctx, subJob.ID).Warning("Failed to auto-start job %s: %v", subJob.Name, err)
+				} else {
+					jobLogger(ctx, subJob.ID).Success("Auto-started job \"%s\"", subJob.Name)
+				}
+			}
+
 			// Return the first sub-job as the primary result
 			// Other sub-jobs are created but not returned
 			return subJobs[0], nil
@@ -285,6 +586,12 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 		if agent.Status != "online" {
 			return nil, fmt.Errorf("agent %s is not available (status: %s)", agent.Name, agent.Status)
 		}
+		if err := u.checkAgentNotInMaintenance(ctx, "agent_id", agent); err != nil {
+			return nil, err
+		}
+		if err := u.checkAgentCanRun(ctx, agent, job); err != nil {
+			return nil, &domain.ValidationError{Field: "agent_id", Message: fmt.Sprintf("agent %s can't run this job: %v", agent.Name, err)}
+		}
 
 		job.AgentID = &agentID
 	} else {
@@ -292,22 +599,65 @@ func (u *jobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest
 		// This is valid for job queuing systems
 	}
 
+	if job.AgentID != nil {
+		job.Status = domain.JobStatusAssigned
+	}
+
 	if err := u.jobRepo.Create(ctx, job); err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
+	recordJobEvent(ctx, u.jobRepo, job.ID, "", job.Status, "created")
 
 	// Auto-start the job if it has an agent assigned
 	if job.AgentID != nil {
-		if err := u.StartJob(ctx, job.ID); err != nil {
-			fmt.Printf("Warning: failed to auto-start job %s: %v\n", job.Name, err)
+		if err := u.StartJob(domain.WithActor(ctx, domain.ActorSystem), job.ID); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to auto-start job %s: %v", job.Name, err)
 		} else {
-			fmt.Printf("✅ Auto-started job \"%s\"\n", job.Name)
+			jobLogger(ctx, job.ID).Success("Auto-started job \"%s\"", job.Name)
 		}
 	}
 
 	return job, nil
 }
 
+// validateJobRequest rejects anything that could smuggle paths or flags
+// onto the agent's hashcat command line and returns the job's engine
+func validateJobRequest(req *domain.CreateJobRequest) (string, error) {
+	if err := domain.ValidateHashcatParams(req.HashType, req.AttackMode, req.Wordlist, req.Rules); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateCustomCharsets(req.AttackMode, req.Wordlist,
+		req.CustomCharset1, req.CustomCharset2, req.CustomCharset3, req.CustomCharset4); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateCombinatorWordlists(req.AttackMode, req.WordlistID, req.Wordlist2ID); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateHashcatArgs("extra_args", req.ExtraArgs); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateJobTimeouts(req.MaxRuntimeMinutes, req.StallTimeoutMinutes); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateJobDeadline(req.Deadline, time.Now()); err != nil {
+		return "", err
+	}
+	tags, err := domain.NormalizeTags(req.Tags)
+	if err != nil {
+		return "", err
+	}
+	req.Tags = tags
+	engine := req.Engine
+	if engine == "" {
+		engine = domain.EngineHashcat
+	}
+	if err := domain.ValidateEngineParams(engine, req.JohnFormat, req.AttackMode, req.Rules, req.ExtraArgs,
+		req.CustomCharset1, req.CustomCharset2, req.CustomCharset3, req.CustomCharset4); err != nil {
+		return "", err
+	}
+	return engine, nil
+}
+
 func (u *jobUsecase) GetJob(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
 	job, err := u.jobRepo.GetByID(ctx, id)
 	if err != nil {
@@ -340,43 +690,126 @@ func (u *jobUsecase) GetJobsByAgentID(ctx context.Context, agentID uuid.UUID) ([
 	return jobs, nil
 }
 
+func (u *jobUsecase) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error) {
+	jobs, total, err := u.jobRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, total, nil
+}
+
 func (u *jobUsecase) GetAvailableJobForAgent(ctx context.Context, agentID uuid.UUID) (*domain.Job, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.GetAvailableJobForAgent", attribute.String("agent.id", agentID.String()))
+	defer span.End()
+
+	// Agents in a maintenance window start no new jobs until it ends
+	if _, ok := agentsInMaintenance(ctx, u.maintenance)[agentID]; ok {
+		return nil, fmt.Errorf("agent is in a maintenance window")
+	}
+
 	job, err := u.jobRepo.GetAvailableJobForAgent(ctx, agentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get available job for agent: %w", err)
 	}
+
+	// The job may have been given to another agent since it was read
+	until := u.leaseUntil()
+	leased, err := u.jobRepo.AcquireLease(ctx, job.ID, agentID, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+	if !leased {
+		return nil, fmt.Errorf("failed to get available job for agent: no available jobs for agent")
+	}
+	job.LeaseExpiresAt = &until
+
+	u.attachFileChecksums(ctx, job)
+	if args, err := u.agentRepo.GetHashcatArgs(ctx, agentID); err == nil {
+		job.AgentArgs = args
+	}
+	if err := u.attachCheckpoint(ctx, job); err != nil {
+		// The agent can still run the job from the start
+		jobLogger(ctx, job.ID).Warning("Failed to get checkpoint of job %s: %v", job.ID, err)
+	}
 	return job, nil
 }
 
+// combinatorWordlists looks up both wordlists of a combinator attack
+func (u *jobUsecase) combinatorWordlists(ctx context.Context, leftID, rightID uuid.UUID) (*domain.Wordlist, *domain.Wordlist, error) {
+	left, err := u.wordlistRepo.GetByID(ctx, leftID)
+	if err != nil {
+		return nil, nil, &domain.NotFoundError{Entity: "wordlist"}
+	}
+	right, err := u.wordlistRepo.GetByID(ctx, rightID)
+	if err != nil {
+		return nil, nil, &domain.NotFoundError{Entity: "second wordlist"}
+	}
+	return left, right, nil
+}
+
+// attachFileChecksums fills in the expected size and SHA-256 of the job's
+// hash file and wordlist so the agent can verify local copies before use.
+// Lookup failures are not fatal: the agent falls back to downloading.
+func (u *jobUsecase) attachFileChecksums(ctx context.Context, job *domain.Job) {
+	if job.HashFileID != nil {
+		if hashFile, err := u.hashFileRepo.GetByID(ctx, *job.HashFileID); err == nil {
+			job.HashFileSize = hashFile.Size
+			job.HashFileSHA256 = hashFile.SHA256
+		}
+	}
+	if job.WordlistID != nil {
+		if wordlist, err := u.wordlistRepo.GetByID(ctx, *job.WordlistID); err == nil {
+			job.WordlistSize = wordlist.Size
+			job.WordlistSHA256 = wordlist.SHA256
+		}
+	}
+}
+
 func (u *jobUsecase) StartJob(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startSpan(ctx, "JobUsecase.StartJob", attribute.String("job.id", id.String()))
+	defer span.End()
+
 	job, err := u.jobRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
-	if job.Status != "pending" && job.Status != "paused" {
-		return fmt.Errorf("job cannot be started from status: %s", job.Status)
+	if err := checkTransition(job, domain.JobStatusRunning); err != nil {
+		return err
 	}
 
 	now := time.Now()
-	job.Status = "running"
 	job.StartedAt = &now
 
-	if err := u.jobRepo.Update(ctx, job); err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
-	}
-
-	return nil
+	return transitionJob(ctx, u.jobRepo, job, domain.JobStatusRunning, "")
 }
 
 func (u *jobUsecase) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress float64, speed int64) error {
-	if err := u.jobRepo.UpdateProgress(ctx, id, progress, speed); err != nil {
+	ctx, span := startSpan(ctx, "JobUsecase.UpdateJobProgress", attribute.String("job.id", id.String()))
+	defer span.End()
+
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
 		return fmt.Errorf("failed to update job progress: %w", err)
 	}
+	job.Progress = progress
+	job.Speed = speed
+	u.ApplyRuntimeStats(ctx, job, nil)
+	u.RecordJobProgress(ctx, job)
+
+	// Progress shows the agent is still at it
+	if job.AgentID != nil {
+		if err := u.RenewJobLeases(ctx, []uuid.UUID{*job.AgentID}); err != nil {
+			jobLogger(ctx, id).Warning("%v", err)
+		}
+	}
 	return nil
 }
 
 func (u *jobUsecase) UpdateJobData(ctx context.Context, job *domain.Job) error {
+	ctx, span := startSpan(ctx, "JobUsecase.UpdateJobData", attribute.String("job.id", job.ID.String()))
+	defer span.End()
+
 	if err := u.jobRepo.Update(ctx, job); err != nil {
 		return fmt.Errorf("failed to update job data: %w", err)
 	}
@@ -384,62 +817,113 @@ func (u *jobUsecase) UpdateJobData(ctx context.Context, job *domain.Job) error {
 }
 
 func (u *jobUsecase) CompleteJob(ctx context.Context, id uuid.UUID, result string, speed int64) error {
+	ctx, span := startSpan(ctx, "JobUsecase.CompleteJob", attribute.String("job.id", id.String()))
+	defer span.End()
+
 	job, err := u.jobRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
+	// Check if password was found. If not, the keyspace is exhausted.
+	passwordFound := domain.IsPasswordFound(result)
+	status, reason := domain.JobStatusFailed, result
+	if passwordFound {
+		status, reason = domain.JobStatusCracked, "password found"
+	}
+	if err := checkTransition(job, status); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	job.Speed = speed
 	job.Progress = 100
+	job.NormalizedProgress = 100
 	job.CompletedAt = &now
+	job.Result = result
 
-	// Check if password was found
-	if result != "" && result != "Password not found - exhausted" {
-		// Password found! Set status to completed and stop other related running jobs
-		job.Status = "completed"
-		job.Result = result
-
-		// Stop other related running jobs
-		if err := u.stopRelatedRunningJobs(ctx, job); err != nil {
-			// Log error but don't fail the job completion
-			fmt.Printf("Warning: failed to stop related running jobs: %v\n", err)
+	if err := transitionJob(ctx, u.jobRepo, job, status, reason); err != nil {
+		return err
+	}
+	u.forgetUsageSample(job.ID)
+	if u.analytics != nil {
+		if err := u.analytics.RecordJobEffectiveness(ctx, job); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to record the job's effectiveness: %v", err)
 		}
-	} else {
-		// Password not found - set status to failed
-		job.Status = "failed"
-		job.Result = result
-		// Progress is already set to 100 above at line 394
+	}
+	if !passwordFound {
+		return nil
 	}
 
-	if err := u.jobRepo.Update(ctx, job); err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
+	// Password found! Stop the other jobs working on the same hash
+	if err := u.stopRelatedRunningJobs(ctx, job); err != nil {
+		// Log error but don't fail the job completion
+		jobLogger(ctx, job.ID).Warning("Failed to stop related running jobs: %v", err)
+	}
+
+	// Feed the password back into the project's loopback wordlist
+	if password, ok := domain.CrackedPassword(result); ok && u.crackedSink != nil {
+		if err := u.crackedSink.AddCrackedPasswords(ctx, job.ProjectID, password); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to add the cracked password to the loopback wordlist: %v", err)
+		}
+	}
+	if password, ok := domain.CrackedPassword(result); ok && u.credentials != nil {
+		if err := u.credentials.RecordCrackedJob(ctx, job, password); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to record the cracked credential: %v", err)
+		}
 	}
 
 	return nil
 }
 
 func (u *jobUsecase) FailJob(ctx context.Context, id uuid.UUID, reason string) error {
+	ctx, span := startSpan(ctx, "JobUsecase.FailJob", attribute.String("job.id", id.String()))
+	defer span.End()
+
 	job, err := u.jobRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
 
+	// The agent stopped the run at the job's deadline
+	if reason == domain.JobDeadlineReachedReason && job.Deadline != nil {
+		return u.expireJob(ctx, job, domain.JobDeadlineReachedReason)
+	}
+	return u.finishJob(ctx, job, domain.JobStatusFailed, reason)
+}
+
+// CancelJob stops a job that hasn't finished yet, e.g. at a user's request
+func (u *jobUsecase) CancelJob(ctx context.Context, id uuid.UUID, reason string) error {
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return u.finishJob(ctx, job, domain.JobStatusCancelled, reason)
+}
+
+// finishJob ends a job without a result and frees its agent
+func (u *jobUsecase) finishJob(ctx context.Context, job *domain.Job, status, reason string) error {
+	if err := checkTransition(job, status); err != nil {
+		return err
+	}
+
 	now := time.Now()
-	job.Status = "failed"
 	job.Result = reason
 	job.CompletedAt = &now
-	job.Progress = 100.0 // All failed jobs should show 100% progress since they are completed
+	job.Progress = 100.0 // Finished jobs show 100% progress
+	job.NormalizedProgress = 100.0
 
-	if err := u.jobRepo.Update(ctx, job); err != nil {
-		return fmt.Errorf("failed to update job: %w", err)
+	if err := transitionJob(ctx, u.jobRepo, job, status, reason); err != nil {
+		return err
 	}
+	u.forgetUsageSample(job.ID)
 
 	// Update agent status to online
 	if job.AgentID != nil {
 		if err := u.agentRepo.UpdateStatus(ctx, *job.AgentID, "online"); err != nil {
-			// Log error but don't fail the job failure
-			fmt.Printf("Warning: failed to update agent status: %v\n", err)
+			// Log error but don't fail the job
+			jobLogger(ctx, job.ID).With("agent_id", *job.AgentID).Warning("Failed to update agent status: %v", err)
 		}
 	}
 
@@ -447,17 +931,33 @@ func (u *jobUsecase) FailJob(ctx context.Context, id uuid.UUID, reason string) e
 }
 
 func (u *jobUsecase) PauseJob(ctx context.Context, id uuid.UUID) error {
-	if err := u.jobRepo.UpdateStatus(ctx, id, "paused"); err != nil {
-		return fmt.Errorf("failed to pause job: %w", err)
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
 	}
+
+	if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusPaused, ""); err != nil {
+		return err
+	}
+	u.forgetUsageSample(job.ID)
 	return nil
 }
 
+// ResumeJob puts a paused job back in the queue of its agent, or in the
+// unassigned queue if it has none
 func (u *jobUsecase) ResumeJob(ctx context.Context, id uuid.UUID) error {
-	if err := u.jobRepo.UpdateStatus(ctx, id, "pending"); err != nil {
-		return fmt.Errorf("failed to resume job: %w", err)
+	job, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
 	}
-	return nil
+
+	to := domain.JobStatusPending
+	if job.AgentID != nil {
+		to = domain.JobStatusAssigned
+		until := u.leaseUntil()
+		job.LeaseExpiresAt = &until
+	}
+	return transitionJob(ctx, u.jobRepo, job, to, "")
 }
 
 func (u *jobUsecase) DeleteJob(ctx context.Context, id uuid.UUID) error {
@@ -467,14 +967,133 @@ func (u *jobUsecase) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (u *jobUsecase) GetArchivedJobs(ctx context.Context) ([]domain.Job, error) {
+	jobs, err := u.jobRepo.GetArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// RestoreJob brings an archived or deleted job back into the job list
+func (u *jobUsecase) RestoreJob(ctx context.Context, id uuid.UUID) error {
+	return u.jobRepo.Restore(ctx, id)
+}
+
+// RetryJob re-runs a failed, cancelled or expired job as a new job with the
+// same parameters. The new job keeps the original's group so it takes its
+// place in the group's aggregate status, and records which job it retries.
+// It keeps the original's deadline only while the job could still start
+// before it.
+func (u *jobUsecase) RetryJob(ctx context.Context, id uuid.UUID, agentID *uuid.UUID) (*domain.Job, error) {
+	original, err := u.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch original.Status {
+	case domain.JobStatusFailed, domain.JobStatusCancelled, domain.JobStatusExpired:
+	default:
+		return nil, &domain.ValidationError{
+			Field:   "status",
+			Message: fmt.Sprintf("only failed, cancelled or expired jobs can be retried (status: %s)", original.Status),
+		}
+	}
+
+	job := &domain.Job{
+		ID:             uuid.New(),
+		Name:           original.Name,
+		Status:         domain.JobStatusPending,
+		HashType:       original.HashType,
+		AttackMode:     original.AttackMode,
+		HashFile:       original.HashFile,
+		HashFileID:     original.HashFileID,
+		Wordlist:       original.Wordlist,
+		WordlistID:     original.WordlistID,
+		Wordlist2ID:    original.Wordlist2ID,
+		Rules:          original.Rules,
+		CustomCharset1: original.CustomCharset1,
+		CustomCharset2: original.CustomCharset2,
+		CustomCharset3: original.CustomCharset3,
+		CustomCharset4: original.CustomCharset4,
+		ExtraArgs:      original.ExtraArgs,
+		Tags:           original.Tags,
+		Engine:         original.Engine,
+		JohnFormat:     original.JohnFormat,
+		TotalWords:     original.TotalWords,
+		AgentID:        original.AgentID,
+		Skip:           original.Skip,
+		WordLimit:      original.WordLimit,
+		GroupID:        original.GroupID,
+		RetriedFrom:    &original.ID,
+		ProjectID:      original.ProjectID,
+		CreatedBy:      original.CreatedBy,
+		TenantID:       original.TenantID,
+		BudgetStage:    original.BudgetStage,
+	}
+	job.MaxRuntimeMinutes, job.StallTimeoutMinutes = original.MaxRuntimeMinutes, original.StallTimeoutMinutes
+	if original.CanStart(time.Now()) {
+		job.Deadline = original.Deadline
+	}
+	if err := u.checkJobQuota(ctx, job.ProjectID, 1); err != nil {
+		return nil, err
+	}
+
+	if agentID != nil {
+		agent, err := u.agentRepo.GetByID(ctx, *agentID)
+		if err != nil {
+			return nil, fmt.Errorf("agent not found: %w", err)
+		}
+		if agent.Status != "online" {
+			return nil, fmt.Errorf("agent %s is not available (status: %s)", agent.Name, agent.Status)
+		}
+		if err := u.checkAgentNotInMaintenance(ctx, "agent_id", agent); err != nil {
+			return nil, err
+		}
+		if err := u.checkAgentCanRun(ctx, agent, job); err != nil {
+			return nil, &domain.ValidationError{Field: "agent_id", Message: fmt.Sprintf("agent %s can't run this job: %v", agent.Name, err)}
+		}
+		job.AgentID = agentID
+	}
+	if job.AgentID != nil {
+		job.Status = domain.JobStatusAssigned
+	}
+
+	if err := u.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	recordJobEvent(ctx, u.jobRepo, job.ID, "", job.Status, "retry of job "+original.ID.String())
+
+	// Auto-start the job if it has an agent assigned, same as CreateJob
+	if job.AgentID != nil {
+		if err := u.StartJob(domain.WithActor(ctx, domain.ActorSystem), job.ID); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to auto-start job %s: %v", job.Name, err)
+		}
+	}
+
+	return job, nil
+}
+
 func (u *jobUsecase) AssignJobsToAgents(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "JobUsecase.AssignJobsToAgents")
+	defer span.End()
+	// Dispatch covers every tenant's jobs, whoever's request triggered it;
+	// checkAgentCanRun keeps each tenant's jobs on agents that serve it
+	ctx = domain.WithoutTenant(ctx)
+
 	// Get pending jobs
 	pendingJobs, err := u.jobRepo.GetByStatus(ctx, "pending")
 	if err != nil {
 		return fmt.Errorf("failed to get pending jobs: %w", err)
 	}
+	// Jobs taken back from agents that shut down already made progress, so
+	// they go first
+	interruptedJobs, err := u.jobRepo.GetByStatus(ctx, domain.JobStatusInterrupted)
+	if err != nil {
+		return fmt.Errorf("failed to get interrupted jobs: %w", err)
+	}
 
-	if len(pendingJobs) == 0 {
+	if len(pendingJobs) == 0 && len(interruptedJobs) == 0 {
 		return nil // Nothing to assign
 	}
 
@@ -485,7 +1104,7 @@ func (u *jobUsecase) AssignJobsToAgents(ctx context.Context) error {
 	}
 
 	var availableAgents []domain.Agent
-	for _, agent := range agents {
+	for _, agent := range withoutMaintenance(agents, agentsInMaintenance(ctx, u.maintenance)) {
 		if agent.Status == "online" {
 			availableAgents = append(availableAgents, agent)
 		}
@@ -497,22 +1116,43 @@ func (u *jobUsecase) AssignJobsToAgents(ctx context.Context) error {
 
 	// Filter jobs that need assignment (don't have AgentID yet)
 	var jobsNeedingAssignment []domain.Job
+	for _, job := range interruptedJobs {
+		// Agents whose hashcat can't read the checkpoint don't get the job
+		if err := u.attachCheckpoint(ctx, &job); err != nil {
+			return fmt.Errorf("failed to get job checkpoint: %w", err)
+		}
+		jobsNeedingAssignment = append(jobsNeedingAssignment, job)
+	}
 	for _, job := range pendingJobs {
 		if job.AgentID == nil {
 			jobsNeedingAssignment = append(jobsNeedingAssignment, job)
 		}
 	}
 
-	// Assign jobs to agents (round-robin)
-	for i, job := range jobsNeedingAssignment {
-		if i >= len(availableAgents) {
-			break // More jobs than agents
-		}
+	// Give each job the free agent likely to start cracking it first, one
+	// job per agent, weighing data locality, hash-mode speed and load
+	scheduler, err := u.newAgentScheduler(ctx, availableAgents, pendingJobs)
+	if err != nil {
+		return fmt.Errorf("failed to get agent load: %w", err)
+	}
 
-		agent := availableAgents[i%len(availableAgents)]
+	now := time.Now()
+	for _, job := range jobsNeedingAssignment {
+		// Too close to its deadline to get anything done; the watchdog
+		// expires it once the deadline passes
+		if !job.CanStart(now) {
+			continue
+		}
+		agent, ok := scheduler.next(ctx, &job)
+		if !ok {
+			continue // Out of agents, or none can run this job
+		}
 		job.AgentID = &agent.ID
+		// The agent has one lease period to pick the job up
+		until := u.leaseUntil()
+		job.LeaseExpiresAt = &until
 
-		if err := u.jobRepo.Update(ctx, &job); err != nil {
+		if err := transitionJob(ctx, u.jobRepo, &job, domain.JobStatusAssigned, "assigned to agent "+agent.Name); err != nil {
 			return fmt.Errorf("failed to assign job to agent: %w", err)
 		}
 
@@ -525,93 +1165,279 @@ func (u *jobUsecase) AssignJobsToAgents(ctx context.Context) error {
 	return nil
 }
 
-// stopRelatedRunningJobs stops all running jobs that are related to the completed job
-// This is used when a password is found to stop other agents from continuing
+// GetJobEvents returns the status history of a job, oldest first
+func (u *jobUsecase) GetJobEvents(ctx context.Context, id uuid.UUID) ([]domain.JobEvent, error) {
+	if _, err := u.jobRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	events, err := u.jobRepo.GetEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job events: %w", err)
+	}
+	return events, nil
+}
+
+func (u *jobUsecase) SetJobTags(ctx context.Context, id uuid.UUID, tags []string) ([]string, error) {
+	tags, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.jobRepo.UpdateTags(ctx, id, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddJobComment saves a comment on a job. The caller sets the author.
+func (u *jobUsecase) AddJobComment(ctx context.Context, id uuid.UUID, comment *domain.JobComment) error {
+	comment.Body = strings.TrimSpace(comment.Body)
+	if comment.Body == "" {
+		return &domain.ValidationError{Field: "body", Message: "comment cannot be empty"}
+	}
+	if len(comment.Body) > domain.MaxJobCommentLength {
+		return &domain.ValidationError{Field: "body", Message: fmt.Sprintf("comment is longer than %d characters", domain.MaxJobCommentLength)}
+	}
+	if _, err := u.jobRepo.GetByID(ctx, id); err != nil {
+		return &domain.NotFoundError{Entity: "job"}
+	}
+
+	comment.JobID = id
+	if err := u.jobRepo.CreateComment(ctx, comment); err != nil {
+		return fmt.Errorf("failed to save job comment: %w", err)
+	}
+	return nil
+}
+
+// GetJobComments returns the comments on a job, oldest first
+func (u *jobUsecase) GetJobComments(ctx context.Context, id uuid.UUID) ([]domain.JobComment, error) {
+	if _, err := u.jobRepo.GetByID(ctx, id); err != nil {
+		return nil, &domain.NotFoundError{Entity: "job"}
+	}
+
+	comments, err := u.jobRepo.GetComments(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job comments: %w", err)
+	}
+	return comments, nil
+}
+
+func (u *jobUsecase) DeleteJobComment(ctx context.Context, id, commentID uuid.UUID) error {
+	return u.jobRepo.DeleteComment(ctx, id, commentID)
+}
+
+// CreateJobGroup creates an empty group for the sub-jobs of a distributed job
+func (u *jobUsecase) CreateJobGroup(ctx context.Context, name string) (*domain.JobGroup, error) {
+	group := &domain.JobGroup{
+		ID:   uuid.New(),
+		Name: name,
+	}
+	if err := u.jobRepo.CreateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create job group: %w", err)
+	}
+	return group, nil
+}
+
+func (u *jobUsecase) GetJobGroup(ctx context.Context, id uuid.UUID) (*domain.JobGroup, error) {
+	return u.jobRepo.GetGroupByID(ctx, id)
+}
+
+// GetJobGroupStatus aggregates the sub-jobs of a group. Progress is weighted
+// by each sub-job's keyspace (its word limit, falling back to equal weights
+// when the split isn't known), speed is the sum of running sub-jobs and the
+// ETA is the latest one among unfinished sub-jobs, since the group is only
+// done when its slowest agent is.
+func (u *jobUsecase) GetJobGroupStatus(ctx context.Context, id uuid.UUID) (*domain.JobGroupStatus, error) {
+	group, err := u.jobRepo.GetGroupByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := u.jobRepo.GetByGroupID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group jobs: %w", err)
+	}
+	status := &domain.JobGroupStatus{JobGroup: *group}
+	// Retried attempts spent budget too
+	if group.Budget != nil {
+		status.BudgetUsage = group.Budget.Report(jobs)
+	}
+	jobs = withoutRetriedJobs(jobs)
+	status.TotalJobs = len(jobs)
+	status.Agents = make([]domain.JobGroupAgentStatus, 0, len(jobs))
+
+	useKeyspace := true
+	for _, job := range jobs {
+		if jobKeyspace(&job) <= 0 {
+			useKeyspace = false
+			break
+		}
+	}
+
+	var weighted, weightedNormalized, totalWeight float64
+	counts := make(map[string]int)
+	for _, job := range jobs {
+		keyspace := jobKeyspace(&job)
+		weight := 1.0
+		if useKeyspace {
+			weight = float64(keyspace)
+		}
+
+		progress, normalized := job.Progress, job.NormalizedProgress
+		if job.Status == domain.JobStatusCompleted || job.Status == domain.JobStatusCracked {
+			progress, normalized = 100, 100
+		}
+		weighted += progress * weight
+		weightedNormalized += normalized * weight
+		totalWeight += weight
+
+		if job.Status == "running" {
+			status.Speed += job.Speed
+		}
+		if !domain.IsTerminalJobStatus(job.Status) && job.ETA != nil {
+			if status.ETA == nil || job.ETA.After(*status.ETA) {
+				status.ETA = job.ETA
+			}
+		}
+		counts[job.Status]++
+
+		agentStatus := domain.JobGroupAgentStatus{
+			JobID:    job.ID,
+			JobName:  job.Name,
+			AgentID:  job.AgentID,
+			Status:   job.Status,
+			Progress: job.Progress,
+			Speed:    job.Speed,
+			ETA:      job.ETA,
+			Keyspace: keyspace,
+
+			NormalizedProgress: job.NormalizedProgress,
+		}
+		if job.AgentID != nil {
+			if agent, err := u.agentRepo.GetByID(ctx, *job.AgentID); err == nil {
+				agentStatus.AgentName = agent.Name
+			}
+		}
+		status.Agents = append(status.Agents, agentStatus)
+	}
+
+	if totalWeight > 0 {
+		status.Progress = weighted / totalWeight
+		status.NormalizedProgress = weightedNormalized / totalWeight
+	}
+	status.CompletedJobs = counts[domain.JobStatusCompleted] + counts[domain.JobStatusCracked]
+	status.Status = groupStatus(counts, len(jobs))
+
+	return status, nil
+}
+
+// withoutRetriedJobs drops jobs that have been superseded by a retry, so a
+// group is judged by the latest attempt of each sub-job
+func withoutRetriedJobs(jobs []domain.Job) []domain.Job {
+	retried := make(map[uuid.UUID]bool)
+	for _, job := range jobs {
+		if job.RetriedFrom != nil {
+			retried[*job.RetriedFrom] = true
+		}
+	}
+
+	latest := make([]domain.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if !retried[job.ID] {
+			latest = append(latest, job)
+		}
+	}
+	return latest
+}
+
+// jobKeyspace returns the number of words assigned to a sub-job, 0 if unknown
+func jobKeyspace(job *domain.Job) int64 {
+	if job.WordLimit != nil && *job.WordLimit > 0 {
+		return *job.WordLimit
+	}
+	return job.TotalWords
+}
+
+// groupStatus derives a group's status from its sub-jobs' statuses
+func groupStatus(counts map[string]int, total int) string {
+	switch {
+	case total == 0:
+		return domain.JobStatusPending
+	case counts[domain.JobStatusRunning] > 0:
+		return domain.JobStatusRunning
+	}
+
+	finished := counts[domain.JobStatusCompleted] + counts[domain.JobStatusCracked] +
+		counts[domain.JobStatusFailed] + counts[domain.JobStatusCancelled] + counts[domain.JobStatusExpired]
+	switch {
+	case finished == total && counts[domain.JobStatusCracked] > 0:
+		// Siblings of the job that found the password are cancelled
+		return domain.JobStatusCracked
+	case finished == total && counts[domain.JobStatusCompleted] > 0:
+		return domain.JobStatusCompleted
+	case finished == total && counts[domain.JobStatusExpired] > 0:
+		return domain.JobStatusExpired
+	case finished == total && counts[domain.JobStatusFailed] > 0:
+		return domain.JobStatusFailed
+	case finished == total:
+		return domain.JobStatusCancelled
+	case counts[domain.JobStatusPaused] > 0:
+		return domain.JobStatusPaused
+	default:
+		return domain.JobStatusPending
+	}
+}
+
+// stopRelatedRunningJobs stops the other sub-jobs of the completed job's
+// group. This is used when a password is found to stop other agents from
+// continuing.
 func (u *jobUsecase) stopRelatedRunningJobs(ctx context.Context, completedJob *domain.Job) error {
-	// Extract base job name (remove agent-specific suffixes)
-	baseJobName := u.extractBaseJobName(completedJob.Name)
-	if baseJobName == "" {
+	if completedJob.GroupID == nil {
 		return nil // Not a distributed job
 	}
 
-	// Find all running jobs with similar names
-	runningJobs, err := u.jobRepo.GetByStatus(ctx, "running")
+	groupJobs, err := u.jobRepo.GetByGroupID(ctx, *completedJob.GroupID)
 	if err != nil {
-		return fmt.Errorf("failed to get running jobs: %w", err)
+		return fmt.Errorf("failed to get group jobs: %w", err)
 	}
 
 	var jobsToStop []*domain.Job
-	for _, job := range runningJobs {
+	for i := range groupJobs {
+		job := &groupJobs[i]
 		// Skip the completed job itself
 		if job.ID == completedJob.ID {
 			continue
 		}
-
-		// Check if this job is related (same base name)
-		if u.isRelatedJob(job.Name, baseJobName) {
-			jobsToStop = append(jobsToStop, &job)
+		if !domain.IsTerminalJobStatus(job.Status) {
+			jobsToStop = append(jobsToStop, job)
 		}
 	}
 
-	// Stop all related running jobs
+	// Stop all related jobs
+	ctx = domain.WithActor(ctx, domain.ActorSystem)
 	for _, job := range jobsToStop {
 		// Set progress to 100% and status to cancelled
 		job.Progress = 100.0
-		job.Status = "cancelled"
+		job.NormalizedProgress = 100.0
 		job.Result = "Password found by another agent - job cancelled"
 		now := time.Now()
 		job.CompletedAt = &now
 
-		if err := u.jobRepo.Update(ctx, job); err != nil {
-			fmt.Printf("Warning: failed to stop related job %s: %v\n", job.Name, err)
+		if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusCancelled, "password found by job "+completedJob.ID.String()); err != nil {
+			jobLogger(ctx, job.ID).Warning("Failed to stop related job %s: %v", job.Name, err)
 			continue
 		}
 
 		// Update agent status to online
 		if job.AgentID != nil {
 			if err := u.agentRepo.UpdateStatus(ctx, *job.AgentID, "online"); err != nil {
-				fmt.Printf("Warning: failed to update agent status for job %s: %v\n", job.Name, err)
+				jobLogger(ctx, job.ID).With("agent_id", *job.AgentID).Warning("Failed to update agent status for job %s: %v", job.Name, err)
 			}
 		}
 
-		fmt.Printf("✅ Stopped related job %s because password was found by %s\n",
+		jobLogger(ctx, job.ID).Success("Stopped related job %s because password was found by %s",
 			job.Name, completedJob.Name)
 	}
 
 	return nil
 }
-
-// extractBaseJobName extracts the base job name from a distributed job name
-// Examples:
-// "test-cracking (Part 1 - test-agent-A)" -> "test-cracking"
-// "test-cracking (Part 2 - test-agent-B)" -> "test-cracking"
-// "test-cracking (test-agent-C)" -> "test-cracking"
-func (u *jobUsecase) extractBaseJobName(jobName string) string {
-	// Look for patterns like "(Part X - Agent)" or "(Agent)"
-	if strings.Contains(jobName, " (Part ") {
-		// Extract everything before " (Part "
-		parts := strings.Split(jobName, " (Part ")
-		if len(parts) > 0 {
-			return strings.TrimSpace(parts[0])
-		}
-	} else if strings.Contains(jobName, " (") && strings.Contains(jobName, ")") {
-		// Extract everything before " ("
-		parts := strings.Split(jobName, " (")
-		if len(parts) > 0 {
-			return strings.TrimSpace(parts[0])
-		}
-	}
-
-	return "" // Not a distributed job
-}
-
-// isRelatedJob checks if a job name is related to a base job name
-func (u *jobUsecase) isRelatedJob(jobName, baseJobName string) bool {
-	if baseJobName == "" {
-		return false
-	}
-
-	// Check if the job name starts with the base name and contains agent-specific info
-	return strings.HasPrefix(jobName, baseJobName) &&
-		(strings.Contains(jobName, " (Part ") || strings.Contains(jobName, " ("))
-}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// watchdogInterval is how often the watchdog looks at running jobs
+const watchdogInterval = 30 * time.Second
+
+// progressMark is the progress and speed a run last changed to, and when
+type progressMark struct {
+	startedAt time.Time
+	progress  float64
+	speed     int64
+	changedAt time.Time
+}
+
+// SetJobTimeouts sets the limits of new jobs whose request sets none
+func (u *jobUsecase) SetJobTimeouts(timeouts domain.JobTimeouts) {
+	u.timeouts = timeouts
+}
+
+// ExpireStalledJobs stops the runs agents didn't stop themselves, e.g.
+// because hashcat hangs in a driver call the agent can't kill. Agents enforce
+// a job's limits; the server gives them one lease period more, then fails
+// jobs over their max runtime and requeues jobs whose progress and speed
+// didn't change within their stall timeout, as it does when a lease expires.
+// It returns how many jobs it stopped.
+func (u *jobUsecase) ExpireStalledJobs(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "JobUsecase.ExpireStalledJobs")
+	defer span.End()
+
+	jobs, err := u.jobRepo.GetByStatus(ctx, domain.JobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get running jobs: %w", err)
+	}
+
+	now := time.Now()
+	running := make(map[uuid.UUID]bool, len(jobs))
+	stopped, requeued := 0, 0
+	for i := range jobs {
+		job := &jobs[i]
+		running[job.ID] = true
+		changedAt := u.markProgress(job, now)
+
+		switch {
+		case job.MaxRuntimeMinutes > 0 && job.StartedAt != nil && now.Sub(*job.StartedAt) > job.MaxRuntime()+u.lease:
+			reason := domain.JobRuntimeExceededReason(job.MaxRuntimeMinutes)
+			if err := u.finishJob(ctx, job, domain.JobStatusFailed, reason); err != nil {
+				jobLogger(ctx, job.ID).Warning("Failed to stop job %s over its max runtime: %v", job.ID, err)
+				continue
+			}
+			jobLogger(ctx, job.ID).Warning("Job %s ran longer than %d minutes, failed it", job.ID, job.MaxRuntimeMinutes)
+			stopped++
+
+		case job.StallTimeoutMinutes > 0 && now.Sub(changedAt) > job.StallTimeout()+u.lease:
+			agentID := job.AgentID
+			job.AgentID = nil
+			job.LeaseExpiresAt = nil
+			if err := transitionJob(ctx, u.jobRepo, job, domain.JobStatusInterrupted, domain.JobStalledReason(job.StallTimeoutMinutes)); err != nil {
+				jobLogger(ctx, job.ID).Warning("Failed to take back stalled job %s: %v", job.ID, err)
+				continue
+			}
+			u.forgetUsageSample(job.ID)
+			if agentID != nil {
+				agentLogger(ctx, *agentID).Warning("Job %s stalled on agent %s for %d minutes, requeueing the job", job.ID, agentID, job.StallTimeoutMinutes)
+			}
+			stopped++
+			requeued++
+		}
+	}
+	u.forgetProgressMarks(running)
+
+	if requeued > 0 {
+		if err := u.AssignJobsToAgents(ctx); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to reassign stalled jobs: %v", err)
+		}
+	}
+	return stopped, nil
+}
+
+// markProgress returns when the run of a job last changed its progress or
+// speed. A run seen for the first time, e.g. after a server restart, counts
+// from now.
+func (u *jobUsecase) markProgress(job *domain.Job, now time.Time) time.Time {
+	var startedAt time.Time
+	if job.StartedAt != nil {
+		startedAt = *job.StartedAt
+	}
+
+	u.watchMu.Lock()
+	defer u.watchMu.Unlock()
+	mark, ok := u.progressMarks[job.ID]
+	if !ok || !mark.startedAt.Equal(startedAt) || mark.progress != job.Progress || mark.speed != job.Speed {
+		mark = progressMark{startedAt: startedAt, progress: job.Progress, speed: job.Speed, changedAt: now}
+		u.progressMarks[job.ID] = mark
+	}
+	return mark.changedAt
+}
+
+// forgetProgressMarks drops the marks of jobs that stopped running
+func (u *jobUsecase) forgetProgressMarks(running map[uuid.UUID]bool) {
+	u.watchMu.Lock()
+	defer u.watchMu.Unlock()
+	for id := range u.progressMarks {
+		if !running[id] {
+			delete(u.progressMarks, id)
+		}
+	}
+}
+
+// RunWatchdog checks running jobs against their limits, and unfinished
+// jobs against their deadline and budget, every watchdogInterval until ctx
+// is done
+func (u *jobUsecase) RunWatchdog(ctx context.Context) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ctx := domain.WithActor(ctx, domain.ActorSystem)
+			if _, err := u.ExpireStalledJobs(ctx); err != nil {
+				infrastructure.ServerLogger.Error("%v", err)
+			}
+			if _, err := u.ExpireJobsPastDeadline(ctx); err != nil {
+				infrastructure.ServerLogger.Error("%v", err)
+			}
+			if _, err := u.ExpireJobsOverBudget(ctx); err != nil {
+				infrastructure.ServerLogger.Error("%v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// jobLogger returns a logger tagged with the job ID and the request ID in ctx
+func jobLogger(ctx context.Context, jobID uuid.UUID) *infrastructure.Logger {
+	return infrastructure.ServerLogger.WithContext(ctx).With("job_id", jobID)
+}
+
+// agentLogger returns a logger tagged with the agent ID and the request ID in ctx
+func agentLogger(ctx context.Context, agentID uuid.UUID) *infrastructure.Logger {
+	return infrastructure.ServerLogger.WithContext(ctx).With("agent_id", agentID)
+}
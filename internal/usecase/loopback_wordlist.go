@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// loopbackWordlistName is the original name of every loopback wordlist
+const loopbackWordlistName = "loopback.txt"
+
+// CrackedPasswordSink collects the passwords of cracked jobs
+type CrackedPasswordSink interface {
+	AddCrackedPasswords(ctx context.Context, projectID *uuid.UUID, passwords ...string) error
+}
+
+// AddCrackedPasswords appends passwords to the loopback wordlist of a
+// project, creating it on first use. Passwords it already holds are
+// skipped, so the list stays a set of unique plaintexts. The file is
+// rewritten and renamed into place, so agents downloading it never see a
+// partial write; its checksum changes, which makes them fetch it again.
+func (u *wordlistUsecase) AddCrackedPasswords(ctx context.Context, projectID *uuid.UUID, passwords ...string) error {
+	u.loopbackMu.Lock()
+	defer u.loopbackMu.Unlock()
+
+	wordlist, err := u.wordlistRepo.GetLoopback(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to get loopback wordlist: %w", err)
+	}
+
+	created := wordlist == nil
+	if created {
+		wordlistDir := filepath.Join(u.uploadDir, "wordlists")
+		if err := os.MkdirAll(wordlistDir, 0755); err != nil {
+			return fmt.Errorf("failed to create wordlist directory: %w", err)
+		}
+		fileID := uuid.New()
+		filename := fileID.String() + ".txt"
+		wordlist = &domain.Wordlist{
+			ID:        fileID,
+			Name:      filename,
+			OrigName:  loopbackWordlistName,
+			Path:      filepath.Join(wordlistDir, filename),
+			ProjectID: projectID,
+			Dynamic:   true,
+		}
+	}
+
+	words, err := readWords(wordlist.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read loopback wordlist: %w", err)
+	}
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		seen[word] = true
+	}
+	added := 0
+	for _, password := range passwords {
+		// One password per line; anything that would split a line is dropped
+		if password == "" || strings.ContainsAny(password, "\r\n") || seen[password] {
+			continue
+		}
+		seen[password] = true
+		words = append(words, password)
+		added++
+	}
+	if added == 0 && !created {
+		return nil
+	}
+
+	size, sum, err := writeWords(wordlist.Path, words)
+	if err != nil {
+		return fmt.Errorf("failed to write loopback wordlist: %w", err)
+	}
+	wordCount := int64(len(words))
+	wordlist.Size = size
+	wordlist.WordCount = &wordCount
+	wordlist.SHA256 = sum
+
+	if created {
+		if err := u.wordlistRepo.Create(ctx, wordlist); err != nil {
+			os.Remove(wordlist.Path)
+			return fmt.Errorf("failed to create loopback wordlist record: %w", err)
+		}
+		return nil
+	}
+	if err := u.wordlistRepo.UpdateContent(ctx, wordlist); err != nil {
+		return fmt.Errorf("failed to update loopback wordlist record: %w", err)
+	}
+	u.invalidateLookup(wordlist.ID)
+	return nil
+}
+
+func (u *wordlistUsecase) GetLoopbackWordlist(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	wordlist, err := u.wordlistRepo.GetLoopback(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loopback wordlist: %w", err)
+	}
+	if wordlist == nil {
+		return nil, &domain.NotFoundError{Entity: "loopback wordlist"}
+	}
+	return wordlist, nil
+}
+
+// readWords returns the lines of a wordlist. A missing file is empty.
+func readWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// writeWords replaces a wordlist with the given lines and returns its new
+// size and SHA-256
+func writeWords(path string, words []string) (int64, string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".loopback-*")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	hasher := sha256.New()
+	writer := bufio.NewWriter(tmp)
+	var size int64
+	for _, word := range words {
+		n, err := writer.WriteString(word + "\n")
+		if err != nil {
+			tmp.Close()
+			return 0, "", err
+		}
+		hasher.Write([]byte(word + "\n"))
+		size += int64(n)
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return 0, "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceUsecase manages the cluster calendar: maintenance windows
+// during which agents take no new jobs
+type MaintenanceUsecase interface {
+	domain.MaintenanceCalendar
+	CreateWindow(ctx context.Context, req *domain.MaintenanceWindowRequest) (*domain.MaintenanceWindow, error)
+	GetWindow(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error)
+	GetAllWindows(ctx context.Context) ([]domain.MaintenanceWindow, error)
+	UpdateWindow(ctx context.Context, id uuid.UUID, req *domain.MaintenanceWindowRequest) (*domain.MaintenanceWindow, error)
+	DeleteWindow(ctx context.Context, id uuid.UUID) error
+	// GetAgentsInMaintenance lists the agents in a maintenance window now,
+	// the ones back soonest first
+	GetAgentsInMaintenance(ctx context.Context) ([]domain.AgentMaintenance, error)
+}
+
+type maintenanceUsecase struct {
+	maintenanceRepo domain.MaintenanceRepository
+	agentRepo       domain.AgentRepository
+}
+
+func NewMaintenanceUsecase(maintenanceRepo domain.MaintenanceRepository, agentRepo domain.AgentRepository) MaintenanceUsecase {
+	return &maintenanceUsecase{
+		maintenanceRepo: maintenanceRepo,
+		agentRepo:       agentRepo,
+	}
+}
+
+func (u *maintenanceUsecase) CreateWindow(ctx context.Context, req *domain.MaintenanceWindowRequest) (*domain.MaintenanceWindow, error) {
+	window := &domain.MaintenanceWindow{}
+	if err := u.applyRequest(ctx, window, req); err != nil {
+		return nil, err
+	}
+	if err := u.maintenanceRepo.Create(ctx, window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+func (u *maintenanceUsecase) GetWindow(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	return u.maintenanceRepo.GetByID(ctx, id)
+}
+
+func (u *maintenanceUsecase) GetAllWindows(ctx context.Context) ([]domain.MaintenanceWindow, error) {
+	return u.maintenanceRepo.GetAll(ctx)
+}
+
+func (u *maintenanceUsecase) UpdateWindow(ctx context.Context, id uuid.UUID, req *domain.MaintenanceWindowRequest) (*domain.MaintenanceWindow, error) {
+	window, err := u.maintenanceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.applyRequest(ctx, window, req); err != nil {
+		return nil, err
+	}
+	if err := u.maintenanceRepo.Update(ctx, window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+func (u *maintenanceUsecase) DeleteWindow(ctx context.Context, id uuid.UUID) error {
+	return u.maintenanceRepo.Delete(ctx, id)
+}
+
+// applyRequest replaces the window's settings with the request's, checking
+// that the agent or agent group it targets exists
+func (u *maintenanceUsecase) applyRequest(ctx context.Context, window *domain.MaintenanceWindow, req *domain.MaintenanceWindowRequest) error {
+	window.Name = strings.TrimSpace(req.Name)
+	window.Description = req.Description
+	window.AgentID, window.AgentGroupID = nil, nil
+	window.StartsAt, window.EndsAt = req.StartsAt, req.EndsAt
+	window.StartTime, window.EndTime = req.StartTime, req.EndTime
+	window.Timezone = req.Timezone
+	window.Days = nil
+	for _, day := range req.Days {
+		window.Days = append(window.Days, strings.ToLower(strings.TrimSpace(day)))
+	}
+
+	if req.AgentID != "" {
+		agentID, err := uuid.Parse(req.AgentID)
+		if err != nil {
+			return &domain.ValidationError{Field: "agent_id", Message: "must be a UUID"}
+		}
+		window.AgentID = &agentID
+	}
+	if req.AgentGroupID != "" {
+		groupID, err := parseAgentGroupID(req.AgentGroupID)
+		if err != nil {
+			return err
+		}
+		window.AgentGroupID = &groupID
+	}
+	if err := window.Validate(); err != nil {
+		return err
+	}
+
+	if window.AgentID != nil {
+		if _, err := u.agentRepo.GetByID(ctx, *window.AgentID); err != nil {
+			return err
+		}
+	}
+	if window.AgentGroupID != nil {
+		if _, err := u.agentRepo.GetGroupByID(ctx, *window.AgentGroupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AgentsInMaintenance resolves the windows open at the given time to the
+// agents they cover
+func (u *maintenanceUsecase) AgentsInMaintenance(ctx context.Context, at time.Time) (map[uuid.UUID]domain.AgentMaintenance, error) {
+	windows, err := u.maintenanceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance windows: %w", err)
+	}
+
+	inMaintenance := make(map[uuid.UUID]domain.AgentMaintenance)
+	add := func(agentID uuid.UUID, window domain.MaintenanceWindow, until time.Time) {
+		if current, ok := inMaintenance[agentID]; ok && !until.After(current.Until) {
+			return
+		}
+		inMaintenance[agentID] = domain.AgentMaintenance{AgentID: agentID, WindowID: window.ID, Window: window.Name, Until: until}
+	}
+
+	for _, window := range windows {
+		until, open := window.ActiveUntil(at)
+		if !open {
+			continue
+		}
+
+		switch {
+		case window.AgentID != nil:
+			add(*window.AgentID, window, until)
+		case window.AgentGroupID != nil:
+			agents, err := u.agentRepo.GetByGroupID(ctx, *window.AgentGroupID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get agents of maintenance window %s: %w", window.Name, err)
+			}
+			for _, agent := range agents {
+				add(agent.ID, window, until)
+			}
+		default:
+			agents, err := u.agentRepo.GetAll(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get agents of maintenance window %s: %w", window.Name, err)
+			}
+			for _, agent := range agents {
+				add(agent.ID, window, until)
+			}
+		}
+	}
+
+	return inMaintenance, nil
+}
+
+func (u *maintenanceUsecase) GetAgentsInMaintenance(ctx context.Context) ([]domain.AgentMaintenance, error) {
+	inMaintenance, err := u.AgentsInMaintenance(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]domain.AgentMaintenance, 0, len(inMaintenance))
+	for _, agent := range inMaintenance {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if !agents[i].Until.Equal(agents[j].Until) {
+			return agents[i].Until.Before(agents[j].Until)
+		}
+		return agents[i].AgentID.String() < agents[j].AgentID.String()
+	})
+	return agents, nil
+}
+
+// agentsInMaintenance asks an optional calendar which agents are in a
+// maintenance window now. Without a calendar, or when it fails, no agent
+// is, so a broken calendar doesn't stop all work.
+func agentsInMaintenance(ctx context.Context, calendar domain.MaintenanceCalendar) map[uuid.UUID]domain.AgentMaintenance {
+	if calendar == nil {
+		return nil
+	}
+	inMaintenance, err := calendar.AgentsInMaintenance(ctx, time.Now())
+	if err != nil {
+		infrastructure.ServerLogger.WithContext(ctx).Error("Failed to read the maintenance calendar: %v", err)
+		return nil
+	}
+	return inMaintenance
+}
+
+// withoutMaintenance drops the agents in a maintenance window
+func withoutMaintenance(agents []domain.Agent, inMaintenance map[uuid.UUID]domain.AgentMaintenance) []domain.Agent {
+	if len(inMaintenance) == 0 {
+		return agents
+	}
+	available := make([]domain.Agent, 0, len(agents))
+	for _, agent := range agents {
+		if _, ok := inMaintenance[agent.ID]; !ok {
+			available = append(available, agent)
+		}
+	}
+	return available
+}
+
+// maintenanceError refuses a job for an agent in a maintenance window
+func maintenanceError(field string, agent *domain.Agent, maintenance domain.AgentMaintenance) error {
+	return &domain.ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf("agent %s is in maintenance window %s until %s", agent.Name, maintenance.Window, maintenance.Until.UTC().Format(time.RFC3339)),
+	}
+}
@@ -0,0 +1,408 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/md4"
+)
+
+// maxNTDSLineLength is the longest line an NTDS dump or potfile may have
+const maxNTDSLineLength = 1 << 20
+
+// ntdsHistoryEntry matches the usernames secretsdump gives password
+// history entries, such as "alice_history0"
+var ntdsHistoryEntry = regexp.MustCompile(`_history\d+$`)
+
+// NTDSUsecase imports Active Directory NTDS dumps and reports how many of
+// their accounts are cracked
+type NTDSUsecase interface {
+	// ImportNTDS parses secretsdump output and stores its accounts, with a
+	// new hash file of their unique NT hashes
+	ImportNTDS(ctx context.Context, filename string, content io.Reader, req *domain.ImportNTDSRequest) (*domain.NTDSImport, error)
+	GetImport(ctx context.Context, id uuid.UUID) (*domain.NTDSImport, error)
+	GetImports(ctx context.Context, projectID *uuid.UUID) ([]domain.NTDSImport, error)
+	// DeleteImport deletes an import and its accounts; its hash file is kept
+	DeleteImport(ctx context.Context, id uuid.UUID) error
+	GetAccounts(ctx context.Context, id uuid.UUID, filter domain.NTDSAccountFilter) ([]domain.NTDSAccount, int, error)
+	SetPrivileged(ctx context.Context, id uuid.UUID, req *domain.SetNTDSPrivilegedRequest) (int, error)
+	// ImportCracked marks cracked the accounts whose hashes are in a hashcat
+	// potfile or --show output, returning how many weren't before
+	ImportCracked(ctx context.Context, id uuid.UUID, content io.Reader) (int, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*domain.NTDSReport, error)
+}
+
+type ntdsUsecase struct {
+	ntdsRepo  domain.NTDSRepository
+	hashFiles HashFileUsecase
+	jobRepo   domain.JobRepository
+}
+
+// NewNTDSUsecase stores the hash files of imports through hashFiles, so
+// they are deduplicated, encrypted and counted against quotas like
+// uploads. Passwords cracked by jobs on them are read from jobRepo.
+func NewNTDSUsecase(ntdsRepo domain.NTDSRepository, hashFiles HashFileUsecase, jobRepo domain.JobRepository) NTDSUsecase {
+	return &ntdsUsecase{
+		ntdsRepo:  ntdsRepo,
+		hashFiles: hashFiles,
+		jobRepo:   jobRepo,
+	}
+}
+
+func (u *ntdsUsecase) ImportNTDS(ctx context.Context, filename string, content io.Reader, req *domain.ImportNTDSRequest) (*domain.NTDSImport, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = filename
+	}
+	ntdsImport := &domain.NTDSImport{
+		Name:      name,
+		Domain:    strings.TrimSpace(req.Domain),
+		ProjectID: req.ProjectID,
+		CreatedBy: domain.UserIDFromContext(ctx),
+	}
+
+	accounts, err := parseSecretsdump(content, ntdsImport)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, &domain.ValidationError{Field: "file", Message: "no accounts found, expected secretsdump lines such as DOMAIN\\user:1104:aad3b435b51404eeaad3b435b51404ee:<nt hash>:::"}
+	}
+
+	privileged := make(map[string]bool, len(req.Privileged))
+	for _, username := range accountNames(req.Privileged) {
+		privileged[strings.ToLower(username)] = true
+	}
+	var hashes bytes.Buffer
+	seen := make(map[string]bool)
+	now := time.Now()
+	for i := range accounts {
+		account := &accounts[i]
+		account.Privileged = account.RID == domain.BuiltinAdministratorRID || privileged[strings.ToLower(account.Username)]
+		// No need to crack what is known
+		if account.NTHash == domain.EmptyNTHash {
+			account.Cracked = true
+			account.CrackedAt = &now
+		}
+		if !seen[account.NTHash] {
+			seen[account.NTHash] = true
+			hashes.WriteString(account.NTHash)
+			hashes.WriteByte('\n')
+		}
+	}
+	ntdsImport.Accounts = len(accounts)
+	ntdsImport.UniqueHashes = len(seen)
+
+	base, _ := splitName(name)
+	hashFile, err := u.hashFiles.UploadHashFile(ctx, base+".nt.txt", &hashes, int64(hashes.Len()), req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	ntdsImport.HashFileID = &hashFile.ID
+	// Wordlist recommendations go by where the hashes come from
+	if hashFile.Source == "" {
+		if _, err := u.hashFiles.SetHashFileSource(ctx, hashFile.ID, domain.HashFileSourceActiveDirectory); err != nil {
+			infrastructure.ServerLogger.Warning("Failed to tag hash file %s of NTDS import: %v", hashFile.ID, err)
+		}
+	}
+
+	if err := u.ntdsRepo.Create(ctx, ntdsImport, accounts); err != nil {
+		if !hashFile.Duplicate {
+			if deleteErr := u.hashFiles.DeleteHashFile(ctx, hashFile.ID); deleteErr != nil {
+				infrastructure.ServerLogger.Warning("Failed to delete hash file %s of failed NTDS import: %v", hashFile.ID, deleteErr)
+			}
+		}
+		return nil, err
+	}
+
+	infrastructure.ServerLogger.Info("Imported NTDS dump %s: %d accounts, %d unique NT hashes in hash file %s",
+		ntdsImport.Name, ntdsImport.Accounts, ntdsImport.UniqueHashes, hashFile.ID)
+	return ntdsImport, nil
+}
+
+// parseSecretsdump reads the accounts of secretsdump output, lines such as
+//
+//	CORP.LOCAL\alice:1104:aad3b435b51404eeaad3b435b51404ee:8846f7eaee8fb117ad06bdd830b7586c::: (status=Enabled)
+//
+// Computer accounts and password history entries are counted but left out,
+// and any other line is counted as skipped.
+func parseSecretsdump(content io.Reader, ntdsImport *domain.NTDSImport) ([]domain.NTDSAccount, error) {
+	var accounts []domain.NTDSAccount
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 64*1024), maxNTDSLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		account, ok := parseSecretsdumpLine(line, ntdsImport.Domain)
+		switch {
+		case !ok:
+			ntdsImport.SkippedLines++
+		case ntdsHistoryEntry.MatchString(account.Username):
+			ntdsImport.HistoryEntries++
+		case strings.HasSuffix(account.Username, "$"):
+			ntdsImport.MachineAccounts++
+		default:
+			accounts = append(accounts, account)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, &domain.ValidationError{Field: "file", Message: fmt.Sprintf("has a line longer than %d bytes", maxNTDSLineLength)}
+		}
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func parseSecretsdumpLine(line, defaultDomain string) (domain.NTDSAccount, bool) {
+	var account domain.NTDSAccount
+	entry, attributes, ok := strings.Cut(line, ":::")
+	if !ok {
+		return account, false
+	}
+	fields := strings.Split(entry, ":")
+	if len(fields) != 4 || fields[0] == "" {
+		return account, false
+	}
+	rid, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || !isHexHash(fields[2]) || !isHexHash(fields[3]) {
+		return account, false
+	}
+
+	account.Domain = defaultDomain
+	account.Username = fields[0]
+	if i := strings.LastIndex(fields[0], `\`); i >= 0 {
+		account.Domain, account.Username = fields[0][:i], fields[0][i+1:]
+	}
+	account.RID = rid
+	account.NTHash = strings.ToLower(fields[3])
+
+	// Dumps made with -user-status end in "(status=Enabled)" or "(status=Disabled)"
+	attributes = strings.ToLower(attributes)
+	if strings.Contains(attributes, "status=enabled") {
+		enabled := true
+		account.Enabled = &enabled
+	} else if strings.Contains(attributes, "status=disabled") {
+		enabled := false
+		account.Enabled = &enabled
+	}
+	return account, account.Username != ""
+}
+
+// isHexHash reports whether s is a 16 byte hash in hex, as LM and NT hashes are
+func isHexHash(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// ntHash returns the NT hash of a password: MD4 of its UTF-16LE encoding
+func ntHash(password string) string {
+	h := md4.New()
+	for _, unit := range utf16.Encode([]rune(password)) {
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], unit)
+		h.Write(b[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (u *ntdsUsecase) GetImport(ctx context.Context, id uuid.UUID) (*domain.NTDSImport, error) {
+	return u.ntdsRepo.GetByID(ctx, id)
+}
+
+func (u *ntdsUsecase) GetImports(ctx context.Context, projectID *uuid.UUID) ([]domain.NTDSImport, error) {
+	return u.ntdsRepo.GetAll(ctx, projectID)
+}
+
+func (u *ntdsUsecase) DeleteImport(ctx context.Context, id uuid.UUID) error {
+	return u.ntdsRepo.Delete(ctx, id)
+}
+
+func (u *ntdsUsecase) GetAccounts(ctx context.Context, id uuid.UUID, filter domain.NTDSAccountFilter) ([]domain.NTDSAccount, int, error) {
+	if _, err := u.syncCracked(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return u.ntdsRepo.GetAccounts(ctx, id, filter)
+}
+
+func (u *ntdsUsecase) SetPrivileged(ctx context.Context, id uuid.UUID, req *domain.SetNTDSPrivilegedRequest) (int, error) {
+	if _, err := u.ntdsRepo.GetByID(ctx, id); err != nil {
+		return 0, err
+	}
+	return u.ntdsRepo.SetPrivileged(ctx, id, accountNames(req.Usernames))
+}
+
+// accountNames returns usernames without their domain, which accounts are
+// matched without
+func accountNames(usernames []string) []string {
+	names := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		if i := strings.LastIndex(username, `\`); i >= 0 {
+			username = username[i+1:]
+		}
+		if username = strings.TrimSpace(username); username != "" {
+			names = append(names, username)
+		}
+	}
+	return names
+}
+
+func (u *ntdsUsecase) ImportCracked(ctx context.Context, id uuid.UUID, content io.Reader) (int, error) {
+	if _, err := u.ntdsRepo.GetByID(ctx, id); err != nil {
+		return 0, err
+	}
+
+	var hashes []string
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 64*1024), maxNTDSLineLength)
+	for scanner.Scan() {
+		// "hash:password", or "user:hash:password" from --show --username
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 3)
+		for _, field := range fields[:len(fields)-1] {
+			if isHexHash(field) {
+				hashes = append(hashes, strings.ToLower(field))
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return 0, &domain.ValidationError{Field: "body", Message: fmt.Sprintf("has a line longer than %d bytes", maxNTDSLineLength)}
+		}
+		return 0, err
+	}
+	if len(hashes) == 0 {
+		return 0, &domain.ValidationError{Field: "body", Message: "no cracked hashes found, expected potfile lines such as <nt hash>:<password>"}
+	}
+	return u.ntdsRepo.MarkCracked(ctx, id, hashes, time.Now())
+}
+
+// syncCracked marks cracked the accounts whose password a job on the
+// import's hash file found. Job results hold the password rather than the
+// hash, so the hash is computed from it.
+func (u *ntdsUsecase) syncCracked(ctx context.Context, id uuid.UUID) (*domain.NTDSImport, error) {
+	ntdsImport, err := u.ntdsRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ntdsImport.HashFileID == nil {
+		return ntdsImport, nil
+	}
+
+	jobs, err := u.jobRepo.GetByStatus(ctx, domain.JobStatusCracked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cracked jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.HashFileID == nil || *job.HashFileID != *ntdsImport.HashFileID || job.HashType != domain.NTLMHashType {
+			continue
+		}
+		password, ok := domain.ResultPassword(job.Result)
+		if !ok {
+			continue
+		}
+		crackedAt := job.UpdatedAt
+		if job.CompletedAt != nil {
+			crackedAt = *job.CompletedAt
+		}
+		if _, err := u.ntdsRepo.MarkCracked(ctx, id, []string{ntHash(password)}, crackedAt); err != nil {
+			return nil, err
+		}
+	}
+	return ntdsImport, nil
+}
+
+func (u *ntdsUsecase) GetReport(ctx context.Context, id uuid.UUID) (*domain.NTDSReport, error) {
+	ntdsImport, err := u.syncCracked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	accounts, _, err := u.ntdsRepo.GetAccounts(ctx, id, domain.NTDSAccountFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.NTDSReport{
+		ImportID:          ntdsImport.ID,
+		Name:              ntdsImport.Name,
+		CrackedPrivileged: []string{},
+		GeneratedAt:       time.Now(),
+	}
+	users := make(map[string]int)
+	crackedHashes := make(map[string]bool)
+	for _, account := range accounts {
+		users[account.NTHash]++
+		if account.Cracked {
+			crackedHashes[account.NTHash] = true
+		}
+	}
+	for _, account := range accounts {
+		countAccount(&report.All, account.Cracked)
+		// Accounts of dumps without statuses count as enabled
+		if account.Enabled == nil || *account.Enabled {
+			countAccount(&report.Enabled, account.Cracked)
+		}
+		if account.Privileged {
+			countAccount(&report.Privileged, account.Cracked)
+			if account.Cracked {
+				report.CrackedPrivileged = append(report.CrackedPrivileged, qualifiedUsername(account))
+			}
+		}
+		if users[account.NTHash] > 1 {
+			report.SharedHashAccounts++
+		}
+		if account.NTHash == domain.EmptyNTHash {
+			report.EmptyPasswords++
+		}
+	}
+	report.UniqueHashes = len(users)
+	report.CrackedHashes = len(crackedHashes)
+	sort.Strings(report.CrackedPrivileged)
+	return report, nil
+}
+
+// countAccount adds an account to a coverage
+func countAccount(coverage *domain.NTDSCoverage, cracked bool) {
+	coverage.Accounts++
+	if cracked {
+		coverage.Cracked++
+	}
+	coverage.Percent = percentOf(coverage.Cracked, coverage.Accounts)
+}
+
+func qualifiedUsername(account domain.NTDSAccount) string {
+	if account.Domain == "" {
+		return account.Username
+	}
+	return account.Domain + `\` + account.Username
+}
+
+// percentOf returns part of whole as a percentage with one decimal
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
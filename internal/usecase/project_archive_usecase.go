@@ -0,0 +1,394 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type ProjectArchiveUsecase interface {
+	// ExportProject writes a project as a zip archive to w: the manifest, and
+	// the content of its files when includeFiles is set
+	ExportProject(ctx context.Context, projectID uuid.UUID, includeFiles bool, w io.Writer) error
+	// ImportProject creates the project of an archive, keeping the IDs of
+	// the project, its jobs and credentials. Shared files this server has
+	// too are reused, the others are stored from the archive; unfinished
+	// jobs come in paused, as agents of another server ran them.
+	ImportProject(ctx context.Context, archive io.ReaderAt, size int64) (*domain.ProjectImportResult, error)
+}
+
+type projectArchiveUsecase struct {
+	archiveRepo     domain.ProjectArchiveRepository
+	projectRepo     domain.ProjectRepository
+	hashFileUsecase HashFileUsecase
+	wordlistUsecase WordlistUsecase
+}
+
+func NewProjectArchiveUsecase(archiveRepo domain.ProjectArchiveRepository, projectRepo domain.ProjectRepository, hashFileUsecase HashFileUsecase, wordlistUsecase WordlistUsecase) ProjectArchiveUsecase {
+	return &projectArchiveUsecase{
+		archiveRepo:     archiveRepo,
+		projectRepo:     projectRepo,
+		hashFileUsecase: hashFileUsecase,
+		wordlistUsecase: wordlistUsecase,
+	}
+}
+
+func (u *projectArchiveUsecase) ExportProject(ctx context.Context, projectID uuid.UUID, includeFiles bool, w io.Writer) error {
+	archive, err := u.archiveRepo.Export(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	// Files are checked before anything is written, so one whose content is
+	// gone goes into the archive as metadata instead of breaking it
+	wordlistPaths := make(map[uuid.UUID]string)
+	if includeFiles {
+		for i := range archive.HashFiles {
+			file := &archive.HashFiles[i]
+			if hashFile, err := u.hashFileUsecase.GetHashFile(ctx, file.ID); err == nil && fileExists(hashFile.Path) {
+				file.Entry = "hash_files/" + file.ID.String()
+			}
+		}
+		for i := range archive.Wordlists {
+			file := &archive.Wordlists[i]
+			if wordlist, err := u.wordlistUsecase.GetWordlist(ctx, file.ID); err == nil && fileExists(wordlist.Path) {
+				file.Entry = "wordlists/" + file.ID.String()
+				wordlistPaths[file.ID] = wordlist.Path
+			}
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := zw.Create(domain.ProjectArchiveManifest)
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, file := range archive.HashFiles {
+		if file.Entry == "" {
+			continue
+		}
+		_, content, err := u.hashFileUsecase.OpenHashFile(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		err = writeArchiveEntry(zw, file, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, file := range archive.Wordlists {
+		if file.Entry == "" {
+			continue
+		}
+		content, err := os.Open(wordlistPaths[file.ID])
+		if err != nil {
+			return fmt.Errorf("failed to open wordlist %s: %w", file.Name, err)
+		}
+		err = writeArchiveEntry(zw, file, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeArchiveEntry copies the content of a file into its archive entry
+func writeArchiveEntry(zw *zip.Writer, file domain.ArchivedFile, content io.Reader) error {
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: file.Entry, Method: zip.Deflate, Modified: file.CreatedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, content); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (u *projectArchiveUsecase) ImportProject(ctx context.Context, r io.ReaderAt, size int64) (*domain.ProjectImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "archive", Message: "is not a zip file"}
+	}
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		entries[file.Name] = file
+	}
+
+	archive, err := readManifest(entries[domain.ProjectArchiveManifest])
+	if err != nil {
+		return nil, err
+	}
+	projectID := archive.Project.ID
+	if _, err := u.projectRepo.GetByID(ctx, projectID); err == nil {
+		return nil, &domain.ValidationError{Field: "project", Message: fmt.Sprintf("project %s already exists", projectID)}
+	} else if !domain.IsNotFoundError(err) {
+		return nil, fmt.Errorf("failed to check project: %w", err)
+	}
+	if existing, err := u.projectRepo.GetByName(ctx, archive.Project.Name); err == nil {
+		return nil, &domain.ValidationError{Field: "name", Message: fmt.Sprintf("project '%s' already exists", existing.Name)}
+	} else if !domain.IsNotFoundError(err) {
+		return nil, fmt.Errorf("failed to check project name: %w", err)
+	}
+
+	imported := &projectImport{u: u, entries: entries, projectID: projectID, result: &domain.ProjectImportResult{}}
+	if err := imported.storeFiles(ctx, archive); err != nil {
+		imported.removeFiles(ctx)
+		return nil, err
+	}
+	imported.remap(archive)
+
+	recorded, err := u.archiveRepo.Import(ctx, archive)
+	if err != nil {
+		imported.removeFiles(ctx)
+		return nil, fmt.Errorf("failed to import project %s: %w", archive.Project.Name, err)
+	}
+
+	result := imported.result
+	result.Jobs = len(archive.Jobs)
+	result.Credentials = recorded
+	if project, err := u.projectRepo.GetByID(ctx, projectID); err == nil {
+		result.Project = *project
+	} else {
+		result.Project = archive.Project
+	}
+	return result, nil
+}
+
+// readManifest reads and checks the manifest entry of an archive
+func readManifest(entry *zip.File) (*domain.ProjectArchive, error) {
+	if entry == nil {
+		return nil, &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("has no %s", domain.ProjectArchiveManifest)}
+	}
+	content, err := entry.Open()
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "archive", Message: err.Error()}
+	}
+	defer content.Close()
+
+	var archive domain.ProjectArchive
+	if err := json.NewDecoder(content).Decode(&archive); err != nil {
+		return nil, &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("invalid %s: %v", domain.ProjectArchiveManifest, err)}
+	}
+	if archive.Version != domain.ProjectArchiveVersion {
+		return nil, &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("version %d is not supported, this server reads version %d", archive.Version, domain.ProjectArchiveVersion)}
+	}
+	if archive.Project.ID == uuid.Nil || archive.Project.Name == "" {
+		return nil, &domain.ValidationError{Field: "archive", Message: "has no project"}
+	}
+	return &archive, nil
+}
+
+// projectImport tracks the files one import stored or reused, by the ID
+// they had on the exporting server
+type projectImport struct {
+	u         *projectArchiveUsecase
+	entries   map[string]*zip.File
+	projectID uuid.UUID
+	result    *domain.ProjectImportResult
+
+	hashFiles map[uuid.UUID]*domain.HashFile
+	wordlists map[uuid.UUID]*domain.Wordlist
+	// Files this import created, removed again when it fails
+	createdHashFiles []uuid.UUID
+	createdWordlists []uuid.UUID
+}
+
+func (p *projectImport) storeFiles(ctx context.Context, archive *domain.ProjectArchive) error {
+	p.hashFiles = make(map[uuid.UUID]*domain.HashFile, len(archive.HashFiles))
+	for _, file := range archive.HashFiles {
+		if existing := p.sharedHashFile(ctx, file.SHA256); existing != nil {
+			p.hashFiles[file.ID] = existing
+			p.result.ReusedFiles++
+			continue
+		}
+		content, size, err := p.open(file)
+		if err != nil {
+			return err
+		}
+		if content == nil {
+			p.result.MissingFiles = append(p.result.MissingFiles, file.Name)
+			continue
+		}
+		hashFile, err := p.u.hashFileUsecase.UploadHashFile(ctx, file.Name, content, size, &p.projectID)
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("failed to store hash file %s: %w", file.Name, err)
+		}
+		if !hashFile.Duplicate {
+			p.createdHashFiles = append(p.createdHashFiles, hashFile.ID)
+		}
+		if err := checkArchivedSum(file, hashFile.SHA256); err != nil {
+			return err
+		}
+		p.hashFiles[file.ID] = hashFile
+		p.result.HashFiles++
+	}
+
+	p.wordlists = make(map[uuid.UUID]*domain.Wordlist, len(archive.Wordlists))
+	for _, file := range archive.Wordlists {
+		if existing := p.sharedWordlist(ctx, file.SHA256); existing != nil {
+			p.wordlists[file.ID] = existing
+			p.result.ReusedFiles++
+			continue
+		}
+		content, size, err := p.open(file)
+		if err != nil {
+			return err
+		}
+		if content == nil {
+			p.result.MissingFiles = append(p.result.MissingFiles, file.Name)
+			continue
+		}
+		wordlist, err := p.u.wordlistUsecase.UploadWordlist(ctx, file.Name, content, size, &p.projectID)
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("failed to store wordlist %s: %w", file.Name, err)
+		}
+		if !wordlist.Duplicate {
+			p.createdWordlists = append(p.createdWordlists, wordlist.ID)
+		}
+		if err := checkArchivedSum(file, wordlist.SHA256); err != nil {
+			return err
+		}
+		p.wordlists[file.ID] = wordlist
+		p.result.Wordlists++
+	}
+	return nil
+}
+
+// sharedHashFile returns the shared hash file with the checksum, if this
+// server has one. Files of other projects aren't shared with the import.
+func (p *projectImport) sharedHashFile(ctx context.Context, sum string) *domain.HashFile {
+	if sum == "" {
+		return nil
+	}
+	if existing, err := p.u.hashFileUsecase.GetHashFileBySHA256(ctx, sum); err == nil && existing.ProjectID == nil {
+		return existing
+	}
+	return nil
+}
+
+func (p *projectImport) sharedWordlist(ctx context.Context, sum string) *domain.Wordlist {
+	if sum == "" {
+		return nil
+	}
+	if existing, err := p.u.wordlistUsecase.GetWordlistBySHA256(ctx, sum); err == nil && existing.ProjectID == nil {
+		return existing
+	}
+	return nil
+}
+
+// open returns the content of a file in the archive, or nil when the
+// archive only has its metadata
+func (p *projectImport) open(file domain.ArchivedFile) (io.ReadCloser, int64, error) {
+	if file.Entry == "" {
+		return nil, 0, nil
+	}
+	entry := p.entries[file.Entry]
+	if entry == nil {
+		return nil, 0, &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("has no entry %s for %s", file.Entry, file.Name)}
+	}
+	content, err := entry.Open()
+	if err != nil {
+		return nil, 0, &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("entry %s: %v", file.Entry, err)}
+	}
+	return content, int64(entry.UncompressedSize64), nil
+}
+
+func checkArchivedSum(file domain.ArchivedFile, sum string) error {
+	if file.SHA256 != "" && sum != file.SHA256 {
+		return &domain.ValidationError{Field: "archive", Message: fmt.Sprintf("content of %s doesn't match its checksum", file.Name)}
+	}
+	return nil
+}
+
+// remap points the jobs and credentials of the archive at the files on this
+// server. Agents and users of the other server don't exist here.
+func (p *projectImport) remap(archive *domain.ProjectArchive) {
+	now := time.Now()
+	for i := range archive.Jobs {
+		job := &archive.Jobs[i]
+		job.ProjectID = &p.projectID
+		if job.HashFileID != nil {
+			if file, ok := p.hashFiles[*job.HashFileID]; ok {
+				job.HashFileID = &file.ID
+				job.HashFile = file.Path
+			} else {
+				job.HashFileID = nil
+			}
+		}
+		job.WordlistID = p.wordlistID(job.WordlistID)
+		job.Wordlist2ID = p.wordlistID(job.Wordlist2ID)
+		job.AgentID = nil
+		job.CreatedBy = nil
+		job.LeaseExpiresAt = nil
+
+		if !domain.IsTerminalJobStatus(job.Status) && job.Status != domain.JobStatusPaused {
+			archive.JobEvents = append(archive.JobEvents, domain.JobEvent{
+				ID: uuid.New(), JobID: job.ID, FromStatus: job.Status, ToStatus: domain.JobStatusPaused,
+				Actor: domain.ActorSystem, Reason: "imported from another server", CreatedAt: now,
+			})
+			job.Status = domain.JobStatusPaused
+			p.result.PausedJobs++
+		}
+	}
+
+	for i := range archive.Credentials {
+		credential := &archive.Credentials[i]
+		credential.ProjectID = &p.projectID
+		if credential.HashFileID != nil {
+			if file, ok := p.hashFiles[*credential.HashFileID]; ok {
+				credential.HashFileID = &file.ID
+			} else {
+				credential.HashFileID = nil
+			}
+		}
+	}
+	for i := range archive.JobComments {
+		archive.JobComments[i].AuthorID = nil
+	}
+}
+
+func (p *projectImport) wordlistID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if wordlist, ok := p.wordlists[*id]; ok {
+		return &wordlist.ID
+	}
+	return nil
+}
+
+// removeFiles deletes the files a failed import stored
+func (p *projectImport) removeFiles(ctx context.Context) {
+	for _, id := range p.createdHashFiles {
+		p.u.hashFileUsecase.DeleteHashFile(ctx, id)
+	}
+	for _, id := range p.createdWordlists {
+		p.u.wordlistUsecase.DeleteWordlist(ctx, id)
+	}
+}
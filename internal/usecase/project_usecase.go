@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type ProjectUsecase interface {
+	CreateProject(ctx context.Context, req *domain.CreateProjectRequest) (*domain.Project, error)
+	GetProject(ctx context.Context, id uuid.UUID) (*domain.Project, error)
+	// GetProjectsForUser returns every project for admins and the projects
+	// the user is a member of for anyone else
+	GetProjectsForUser(ctx context.Context, userID uuid.UUID, role string) ([]domain.Project, error)
+	UpdateProject(ctx context.Context, id uuid.UUID, req *domain.UpdateProjectRequest) (*domain.Project, error)
+	// DeleteProject refuses projects that still have jobs, hash files or wordlists
+	DeleteProject(ctx context.Context, id uuid.UUID) error
+	GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error)
+	AddMember(ctx context.Context, projectID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error
+	// CheckAccess fails with a NotFoundError when the project doesn't exist or
+	// the user isn't an admin or one of its members, so non-members can't tell
+	// which projects exist
+	CheckAccess(ctx context.Context, projectID, userID uuid.UUID, role string) error
+}
+
+type projectUsecase struct {
+	projectRepo domain.ProjectRepository
+	userRepo    domain.UserRepository
+}
+
+func NewProjectUsecase(projectRepo domain.ProjectRepository, userRepo domain.UserRepository) ProjectUsecase {
+	return &projectUsecase{
+		projectRepo: projectRepo,
+		userRepo:    userRepo,
+	}
+}
+
+func (u *projectUsecase) CreateProject(ctx context.Context, req *domain.CreateProjectRequest) (*domain.Project, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+	if err := u.checkNameAvailable(ctx, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	// Check all members before creating anything
+	memberIDs := make([]uuid.UUID, 0, len(req.MemberIDs))
+	for _, memberIDStr := range req.MemberIDs {
+		memberID, err := uuid.Parse(memberIDStr)
+		if err != nil {
+			return nil, &domain.ValidationError{Field: "member_ids", Message: fmt.Sprintf("invalid user ID %s", memberIDStr)}
+		}
+		if err := u.checkUserExists(ctx, memberID); err != nil {
+			return nil, err
+		}
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	project := &domain.Project{Name: name, Description: strings.TrimSpace(req.Description)}
+	if err := u.projectRepo.Create(ctx, project); err != nil {
+		return nil, err
+	}
+	for _, memberID := range memberIDs {
+		if err := u.projectRepo.AddMember(ctx, project.ID, memberID); err != nil {
+			return nil, err
+		}
+	}
+
+	return project, nil
+}
+
+func (u *projectUsecase) GetProject(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	return u.projectRepo.GetByID(ctx, id)
+}
+
+func (u *projectUsecase) GetProjectsForUser(ctx context.Context, userID uuid.UUID, role string) ([]domain.Project, error) {
+	if role == "admin" {
+		return u.projectRepo.GetAll(ctx)
+	}
+	return u.projectRepo.GetByMember(ctx, userID)
+}
+
+func (u *projectUsecase) UpdateProject(ctx context.Context, id uuid.UUID, req *domain.UpdateProjectRequest) (*domain.Project, error) {
+	project, err := u.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, &domain.ValidationError{Field: "name", Message: "must not be empty"}
+		}
+		if err := u.checkNameAvailable(ctx, name, id); err != nil {
+			return nil, err
+		}
+		project.Name = name
+	}
+	if req.Description != nil {
+		project.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := u.projectRepo.Update(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (u *projectUsecase) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	project, err := u.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := u.projectRepo.CountResources(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to count resources of project %s: %w", project.Name, err)
+	}
+	if count > 0 {
+		return &domain.ValidationError{Field: "project", Message: fmt.Sprintf("project '%s' still has %d jobs, hash files or wordlists", project.Name, count)}
+	}
+
+	return u.projectRepo.Delete(ctx, id)
+}
+
+func (u *projectUsecase) GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error) {
+	if _, err := u.projectRepo.GetByID(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return u.projectRepo.GetMembers(ctx, projectID)
+}
+
+func (u *projectUsecase) AddMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	if _, err := u.projectRepo.GetByID(ctx, projectID); err != nil {
+		return err
+	}
+	if err := u.checkUserExists(ctx, userID); err != nil {
+		return err
+	}
+	return u.projectRepo.AddMember(ctx, projectID, userID)
+}
+
+func (u *projectUsecase) RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	return u.projectRepo.RemoveMember(ctx, projectID, userID)
+}
+
+func (u *projectUsecase) CheckAccess(ctx context.Context, projectID, userID uuid.UUID, role string) error {
+	if _, err := u.projectRepo.GetByID(ctx, projectID); err != nil {
+		return err
+	}
+	if role == "admin" {
+		return nil
+	}
+
+	member, err := u.projectRepo.IsMember(ctx, projectID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check project membership: %w", err)
+	}
+	if !member {
+		return &domain.NotFoundError{Entity: "project"}
+	}
+	return nil
+}
+
+// checkNameAvailable fails when another project than exceptID has the name
+func (u *projectUsecase) checkNameAvailable(ctx context.Context, name string, exceptID uuid.UUID) error {
+	existing, err := u.projectRepo.GetByName(ctx, name)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to check project name: %w", err)
+	}
+	if existing.ID != exceptID {
+		return &domain.ValidationError{Field: "name", Message: fmt.Sprintf("project '%s' already exists", existing.Name)}
+	}
+	return nil
+}
+
+// checkUserExists maps the user repository's own not found error to a
+// NotFoundError, which the handlers turn into a 404
+func (u *projectUsecase) checkUserExists(ctx context.Context, userID uuid.UUID) error {
+	_, err := u.userRepo.GetByID(ctx, userID)
+	var notFound *domain.UserNotFoundError
+	if errors.As(err, &notFound) {
+		return &domain.NotFoundError{Entity: "user"}
+	}
+	return err
+}
+
+// sameProject reports whether two resources are in the same project, or both
+// in none
+func sameProject(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
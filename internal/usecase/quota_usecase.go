@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type QuotaUsecase interface {
+	domain.QuotaChecker
+	GetAllQuotas(ctx context.Context) ([]domain.QuotaStatus, error)
+	// GetQuota returns the quota of a user, project or tenant with its usage. One
+	// without a quota gets zero limits, which means no limits.
+	GetQuota(ctx context.Context, scope string, subjectID uuid.UUID) (*domain.QuotaStatus, error)
+	SetQuota(ctx context.Context, scope string, subjectID uuid.UUID, req *domain.SetQuotaRequest) (*domain.QuotaStatus, error)
+	DeleteQuota(ctx context.Context, scope string, subjectID uuid.UUID) error
+}
+
+type quotaUsecase struct {
+	quotaRepo   domain.QuotaRepository
+	userRepo    domain.UserRepository
+	projectRepo domain.ProjectRepository
+	tenantRepo  domain.TenantRepository
+}
+
+func NewQuotaUsecase(quotaRepo domain.QuotaRepository, userRepo domain.UserRepository, projectRepo domain.ProjectRepository, tenantRepo domain.TenantRepository) QuotaUsecase {
+	return &quotaUsecase{
+		quotaRepo:   quotaRepo,
+		userRepo:    userRepo,
+		projectRepo: projectRepo,
+		tenantRepo:  tenantRepo,
+	}
+}
+
+// quotaSubject is a user, project or tenant whose quota applies to a request
+type quotaSubject struct {
+	scope string
+	id    uuid.UUID
+}
+
+// quotaSubjects returns the logged-in user of the request, the project it
+// works in and the user's tenant, whichever there are
+func quotaSubjects(ctx context.Context, projectID *uuid.UUID) []quotaSubject {
+	var subjects []quotaSubject
+	if userID := domain.UserIDFromContext(ctx); userID != nil {
+		subjects = append(subjects, quotaSubject{domain.QuotaScopeUser, *userID})
+	}
+	if projectID != nil {
+		subjects = append(subjects, quotaSubject{domain.QuotaScopeProject, *projectID})
+	}
+	if tenantID := domain.TenantIDFromContext(ctx); tenantID != nil {
+		subjects = append(subjects, quotaSubject{domain.QuotaScopeTenant, *tenantID})
+	}
+	return subjects
+}
+
+// CheckJobQuota refuses new jobs that would take the user, project or
+// tenant over the unfinished jobs it may have, or once it used its
+// device-hours this month
+func (u *quotaUsecase) CheckJobQuota(ctx context.Context, projectID *uuid.UUID, jobs int) error {
+	for _, subject := range quotaSubjects(ctx, projectID) {
+		quota, err := u.quotaRepo.Get(ctx, subject.scope, subject.id)
+		if err != nil {
+			return fmt.Errorf("failed to get quota: %w", err)
+		}
+		if quota == nil || (quota.MaxRunningJobs == 0 && quota.MaxDeviceHoursPerMonth == 0) {
+			continue
+		}
+
+		usage, err := u.quotaRepo.GetUsage(ctx, subject.scope, subject.id, domain.QuotaMonthStart(time.Now()))
+		if err != nil {
+			return fmt.Errorf("failed to get quota usage: %w", err)
+		}
+		if quota.MaxRunningJobs > 0 && usage.RunningJobs+jobs > quota.MaxRunningJobs {
+			return &domain.QuotaExceededError{Scope: subject.scope, Resource: domain.QuotaRunningJobs,
+				Limit: float64(quota.MaxRunningJobs), Used: float64(usage.RunningJobs)}
+		}
+		if quota.MaxDeviceHoursPerMonth > 0 && usage.DeviceHoursThisMonth >= quota.MaxDeviceHoursPerMonth {
+			return &domain.QuotaExceededError{Scope: subject.scope, Resource: domain.QuotaDeviceHours,
+				Limit: quota.MaxDeviceHoursPerMonth, Used: usage.DeviceHoursThisMonth}
+		}
+	}
+	return nil
+}
+
+// CheckStorageQuota refuses an upload of size bytes that would take the
+// user, project or tenant over its storage
+func (u *quotaUsecase) CheckStorageQuota(ctx context.Context, projectID *uuid.UUID, size int64) error {
+	for _, subject := range quotaSubjects(ctx, projectID) {
+		quota, err := u.quotaRepo.Get(ctx, subject.scope, subject.id)
+		if err != nil {
+			return fmt.Errorf("failed to get quota: %w", err)
+		}
+		if quota == nil || quota.MaxStorageBytes == 0 {
+			continue
+		}
+
+		usage, err := u.quotaRepo.GetUsage(ctx, subject.scope, subject.id, domain.QuotaMonthStart(time.Now()))
+		if err != nil {
+			return fmt.Errorf("failed to get quota usage: %w", err)
+		}
+		if usage.StorageBytes+size > quota.MaxStorageBytes {
+			return &domain.QuotaExceededError{Scope: subject.scope, Resource: domain.QuotaStorage,
+				Limit: float64(quota.MaxStorageBytes), Used: float64(usage.StorageBytes + size)}
+		}
+	}
+	return nil
+}
+
+func (u *quotaUsecase) GetAllQuotas(ctx context.Context) ([]domain.QuotaStatus, error) {
+	ctx, span := startSpan(ctx, "QuotaUsecase.GetAllQuotas")
+	defer span.End()
+
+	quotas, err := u.quotaRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quotas: %w", err)
+	}
+
+	statuses := make([]domain.QuotaStatus, 0, len(quotas))
+	for _, quota := range quotas {
+		status, err := u.withUsage(ctx, quota)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (u *quotaUsecase) GetQuota(ctx context.Context, scope string, subjectID uuid.UUID) (*domain.QuotaStatus, error) {
+	ctx, span := startSpan(ctx, "QuotaUsecase.GetQuota", attribute.String("quota.scope", scope))
+	defer span.End()
+
+	if err := u.checkSubject(ctx, scope, subjectID); err != nil {
+		return nil, err
+	}
+	quota, err := u.quotaRepo.Get(ctx, scope, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+	if quota == nil {
+		quota = &domain.Quota{Scope: scope, SubjectID: subjectID}
+	}
+	return u.withUsage(ctx, *quota)
+}
+
+func (u *quotaUsecase) SetQuota(ctx context.Context, scope string, subjectID uuid.UUID, req *domain.SetQuotaRequest) (*domain.QuotaStatus, error) {
+	ctx, span := startSpan(ctx, "QuotaUsecase.SetQuota", attribute.String("quota.scope", scope))
+	defer span.End()
+
+	if err := u.checkSubject(ctx, scope, subjectID); err != nil {
+		return nil, err
+	}
+	if req.MaxRunningJobs < 0 || req.MaxDeviceHoursPerMonth < 0 || req.MaxStorageBytes < 0 {
+		return nil, &domain.ValidationError{Field: "quota", Message: "limits can't be negative"}
+	}
+
+	quota := &domain.Quota{
+		Scope:                  scope,
+		SubjectID:              subjectID,
+		MaxRunningJobs:         req.MaxRunningJobs,
+		MaxDeviceHoursPerMonth: req.MaxDeviceHoursPerMonth,
+		MaxStorageBytes:        req.MaxStorageBytes,
+	}
+	if err := u.quotaRepo.Upsert(ctx, quota); err != nil {
+		return nil, err
+	}
+	return u.withUsage(ctx, *quota)
+}
+
+func (u *quotaUsecase) DeleteQuota(ctx context.Context, scope string, subjectID uuid.UUID) error {
+	if err := validateQuotaScope(scope); err != nil {
+		return err
+	}
+	return u.quotaRepo.Delete(ctx, scope, subjectID)
+}
+
+func (u *quotaUsecase) withUsage(ctx context.Context, quota domain.Quota) (*domain.QuotaStatus, error) {
+	usage, err := u.quotaRepo.GetUsage(ctx, quota.Scope, quota.SubjectID, domain.QuotaMonthStart(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+	return &domain.QuotaStatus{Quota: quota, Usage: *usage}, nil
+}
+
+// checkSubject makes sure the user, project or tenant a quota is for exists
+func (u *quotaUsecase) checkSubject(ctx context.Context, scope string, subjectID uuid.UUID) error {
+	if err := validateQuotaScope(scope); err != nil {
+		return err
+	}
+	if scope == domain.QuotaScopeTenant {
+		if _, err := u.tenantRepo.GetByID(ctx, subjectID); err != nil {
+			if domain.IsNotFoundError(err) {
+				return err
+			}
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
+		return nil
+	}
+	if scope == domain.QuotaScopeUser {
+		if _, err := u.userRepo.GetByID(ctx, subjectID); err != nil {
+			if _, ok := err.(*domain.UserNotFoundError); ok {
+				return &domain.NotFoundError{Entity: "user"}
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return nil
+	}
+	if _, err := u.projectRepo.GetByID(ctx, subjectID); err != nil {
+		if domain.IsNotFoundError(err) {
+			return err
+		}
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	return nil
+}
+
+func validateQuotaScope(scope string) error {
+	if scope != domain.QuotaScopeUser && scope != domain.QuotaScopeProject && scope != domain.QuotaScopeTenant {
+		return &domain.ValidationError{Field: "scope", Message: "must be user, project or tenant"}
+	}
+	return nil
+}
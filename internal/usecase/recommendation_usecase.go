@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	recommendedWordlistsLimit = 10
+	// recommendationPriorWeight is how many attacks' worth of the broader
+	// crack rate a combination starts from, so one lucky attack doesn't put
+	// it on top
+	recommendationPriorWeight = 3
+)
+
+type RecommendationUsecase interface {
+	// RecommendWordlists ranks wordlist and rule combinations for a hash
+	// mode by how often they cracked before, favouring those that cracked
+	// hash files of the same origin. The origin is source, or the hash
+	// file's when source is empty and hashFileID is set.
+	RecommendWordlists(ctx context.Context, hashType int, hashFileID *uuid.UUID, source string) (*domain.WordlistRecommendations, error)
+}
+
+type recommendationUsecase struct {
+	statsRepo    domain.StatsRepository
+	hashFileRepo domain.HashFileRepository
+}
+
+func NewRecommendationUsecase(statsRepo domain.StatsRepository, hashFileRepo domain.HashFileRepository) RecommendationUsecase {
+	return &recommendationUsecase{
+		statsRepo:    statsRepo,
+		hashFileRepo: hashFileRepo,
+	}
+}
+
+// combinationKey identifies a wordlist with a rule file
+type combinationKey struct {
+	wordlistID uuid.UUID
+	rules      string
+}
+
+func (u *recommendationUsecase) RecommendWordlists(ctx context.Context, hashType int, hashFileID *uuid.UUID, source string) (*domain.WordlistRecommendations, error) {
+	ctx, span := startSpan(ctx, "RecommendationUsecase.RecommendWordlists", attribute.Int("hash.type", hashType))
+	defer span.End()
+
+	if hashType < 0 {
+		return nil, &domain.ValidationError{Field: "hash_type", Message: "must not be negative"}
+	}
+	source, err := domain.NormalizeHashFileSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if source == "" && hashFileID != nil {
+		hashFile, err := u.hashFileRepo.GetByID(ctx, *hashFileID)
+		if err != nil {
+			return nil, &domain.NotFoundError{Entity: "hash file"}
+		}
+		source = hashFile.Source
+	}
+
+	outcomes, err := u.statsRepo.GetAttackOutcomes(ctx, hashType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attack outcomes: %w", err)
+	}
+
+	byKey := make(map[combinationKey]*domain.WordlistRecommendation)
+	var attacks, cracked int
+	for _, outcome := range outcomes {
+		key := combinationKey{outcome.WordlistID, outcome.Rules}
+		recommendation, ok := byKey[key]
+		if !ok {
+			recommendation = &domain.WordlistRecommendation{
+				WordlistID: outcome.WordlistID,
+				Wordlist:   outcome.Wordlist,
+				Rules:      outcome.Rules,
+			}
+			byKey[key] = recommendation
+		}
+		recommendation.Attacks += outcome.Attacks
+		recommendation.Cracked += outcome.Cracked
+		recommendation.Cracks += outcome.Cracks
+		if source != "" && outcome.Source == source {
+			recommendation.SourceAttacks += outcome.Attacks
+			recommendation.SourceCracked += outcome.Cracked
+		}
+		attacks += outcome.Attacks
+		cracked += outcome.Cracked
+	}
+
+	// Each crack rate is shrunk towards a broader one: the hash mode's
+	// towards the rate of all its attacks, the origin's towards the hash
+	// mode's. Little history ranks a combination close to the average.
+	baseRate := 0.0
+	if attacks > 0 {
+		baseRate = float64(cracked) / float64(attacks)
+	}
+	recommendations := make([]domain.WordlistRecommendation, 0, len(byKey))
+	for _, recommendation := range byKey {
+		recommendation.CrackRate = float64(recommendation.Cracked) / float64(recommendation.Attacks)
+		recommendation.Score = shrunkRate(recommendation.Cracked, recommendation.Attacks, baseRate)
+		if source != "" {
+			recommendation.Score = shrunkRate(recommendation.SourceCracked, recommendation.SourceAttacks, recommendation.Score)
+		}
+		recommendation.Reason = recommendationReason(recommendation, hashType, source)
+		recommendations = append(recommendations, *recommendation)
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Cracks != b.Cracks {
+			return a.Cracks > b.Cracks
+		}
+		if a.Wordlist != b.Wordlist {
+			return a.Wordlist < b.Wordlist
+		}
+		return a.Rules < b.Rules
+	})
+	if len(recommendations) > recommendedWordlistsLimit {
+		recommendations = recommendations[:recommendedWordlistsLimit]
+	}
+
+	return &domain.WordlistRecommendations{
+		HashType:        hashType,
+		Source:          source,
+		Recommendations: recommendations,
+		GeneratedAt:     time.Now(),
+	}, nil
+}
+
+// shrunkRate is the share of attacks that cracked, weighed together with
+// recommendationPriorWeight attacks at the prior rate
+func shrunkRate(cracked, attacks int, prior float64) float64 {
+	return (float64(cracked) + recommendationPriorWeight*prior) / float64(attacks+recommendationPriorWeight)
+}
+
+// recommendationReason explains a recommendation by its strongest evidence
+func recommendationReason(r *domain.WordlistRecommendation, hashType int, source string) string {
+	attack := r.Wordlist
+	if r.Rules != "" {
+		attack += " with " + r.Rules
+	}
+	if r.SourceAttacks > 0 {
+		return fmt.Sprintf("%s cracked %d of %d attacks on %s hash files", attack, r.SourceCracked, r.SourceAttacks, source)
+	}
+	return fmt.Sprintf("%s cracked %d of %d attacks on hash mode %d", attack, r.Cracked, r.Attacks, hashType)
+}
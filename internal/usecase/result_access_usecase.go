@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRevealLogLimit = 100
+	maxRevealLogLimit     = 1000
+)
+
+// ResultAccessUsecase guards cracked passwords: job lists show them masked
+// and reading one goes through RevealResult, which checks the reveal
+// permission and leaves an audit record
+type ResultAccessUsecase interface {
+	// RedactsResults reports whether job results are masked outside of
+	// RevealResult
+	RedactsResults() bool
+	// RevealResult returns the job's result in the clear to admins and users
+	// granted the reveal permission, and domain.ErrRevealForbidden to others.
+	// Every reveal is logged.
+	RevealResult(ctx context.Context, jobID uuid.UUID, viewer domain.ResultViewer) (*domain.RevealedResult, error)
+	// RevealWordlist lets viewer read a loopback wordlist, which holds the
+	// cracked passwords in the clear, under the rules of RevealResult
+	RevealWordlist(ctx context.Context, wordlistID uuid.UUID, viewer domain.ResultViewer) error
+	// RevealWordlistToAgent lets an agent read a loopback wordlist that one
+	// of its unfinished jobs attacks with, and domain.ErrRevealForbidden
+	// otherwise. Every read is logged.
+	RevealWordlistToAgent(ctx context.Context, wordlistID, agentID uuid.UUID, ipAddress string) error
+	GrantReveal(ctx context.Context, userID uuid.UUID, grantedBy string) (*domain.ResultRevealGrant, error)
+	RevokeReveal(ctx context.Context, userID uuid.UUID) error
+	GetRevealGrants(ctx context.Context) ([]domain.ResultRevealGrant, error)
+	// GetReveals lists the audit log newest first, of one job when jobID is set
+	GetReveals(ctx context.Context, jobID *uuid.UUID, limit int) ([]domain.ResultReveal, error)
+}
+
+type resultAccessUsecase struct {
+	resultAccessRepo domain.ResultAccessRepository
+	jobRepo          domain.JobRepository
+	userRepo         domain.UserRepository
+	redact           bool
+}
+
+func NewResultAccessUsecase(resultAccessRepo domain.ResultAccessRepository, jobRepo domain.JobRepository, userRepo domain.UserRepository, redact bool) ResultAccessUsecase {
+	return &resultAccessUsecase{
+		resultAccessRepo: resultAccessRepo,
+		jobRepo:          jobRepo,
+		userRepo:         userRepo,
+		redact:           redact,
+	}
+}
+
+func (u *resultAccessUsecase) RedactsResults() bool {
+	return u.redact
+}
+
+// canReveal returns domain.ErrRevealForbidden unless viewer is an admin or
+// was granted the reveal permission
+func (u *resultAccessUsecase) canReveal(ctx context.Context, viewer domain.ResultViewer) error {
+	if viewer.Role == "admin" {
+		return nil
+	}
+	granted, err := u.resultAccessRepo.HasRevealGrant(ctx, viewer.UserID)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return domain.ErrRevealForbidden
+	}
+	return nil
+}
+
+func (u *resultAccessUsecase) RevealResult(ctx context.Context, jobID uuid.UUID, viewer domain.ResultViewer) (*domain.RevealedResult, error) {
+	if err := u.canReveal(ctx, viewer); err != nil {
+		return nil, err
+	}
+
+	job, err := u.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, &domain.NotFoundError{Entity: "job"}
+	}
+
+	// The password is only handed out once the reveal is on record
+	userID := viewer.UserID
+	if err := u.resultAccessRepo.LogReveal(ctx, &domain.ResultReveal{
+		JobID:     job.ID,
+		UserID:    &userID,
+		Username:  viewer.Username,
+		IPAddress: viewer.IPAddress,
+	}); err != nil {
+		return nil, err
+	}
+
+	revealed := &domain.RevealedResult{JobID: job.ID, Result: job.Result}
+	revealed.Password, _ = domain.ResultPassword(job.Result)
+	return revealed, nil
+}
+
+func (u *resultAccessUsecase) RevealWordlist(ctx context.Context, wordlistID uuid.UUID, viewer domain.ResultViewer) error {
+	if err := u.canReveal(ctx, viewer); err != nil {
+		return err
+	}
+
+	userID := viewer.UserID
+	return u.resultAccessRepo.LogReveal(ctx, &domain.ResultReveal{
+		WordlistID: &wordlistID,
+		UserID:     &userID,
+		Username:   viewer.Username,
+		IPAddress:  viewer.IPAddress,
+	})
+}
+
+func (u *resultAccessUsecase) RevealWordlistToAgent(ctx context.Context, wordlistID, agentID uuid.UUID, ipAddress string) error {
+	jobs, err := u.jobRepo.GetByAgentID(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.WordlistID == nil || *job.WordlistID != wordlistID || domain.IsTerminalJobStatus(job.Status) {
+			continue
+		}
+		return u.resultAccessRepo.LogReveal(ctx, &domain.ResultReveal{
+			JobID:      job.ID,
+			WordlistID: &wordlistID,
+			Username:   "agent " + agentID.String(),
+			IPAddress:  ipAddress,
+		})
+	}
+	return domain.ErrRevealForbidden
+}
+
+func (u *resultAccessUsecase) GrantReveal(ctx context.Context, userID uuid.UUID, grantedBy string) (*domain.ResultRevealGrant, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		var notFound *domain.UserNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, &domain.NotFoundError{Entity: "user"}
+		}
+		return nil, err
+	}
+
+	grant := &domain.ResultRevealGrant{UserID: user.ID, Username: user.Username, GrantedBy: grantedBy}
+	if err := u.resultAccessRepo.GrantReveal(ctx, grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (u *resultAccessUsecase) RevokeReveal(ctx context.Context, userID uuid.UUID) error {
+	return u.resultAccessRepo.RevokeReveal(ctx, userID)
+}
+
+func (u *resultAccessUsecase) GetRevealGrants(ctx context.Context) ([]domain.ResultRevealGrant, error) {
+	return u.resultAccessRepo.GetRevealGrants(ctx)
+}
+
+func (u *resultAccessUsecase) GetReveals(ctx context.Context, jobID *uuid.UUID, limit int) ([]domain.ResultReveal, error) {
+	if limit <= 0 {
+		limit = defaultRevealLogLimit
+	}
+	if limit > maxRevealLogLimit {
+		limit = maxRevealLogLimit
+	}
+	return u.resultAccessRepo.GetReveals(ctx, jobID, limit)
+}
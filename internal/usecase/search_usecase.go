@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchUsecase interface {
+	// Search only matches cracked results when matchResults is set, so
+	// redacted results can't be confirmed by guessing them
+	Search(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error)
+}
+
+type searchUsecase struct {
+	searchRepo domain.SearchRepository
+}
+
+func NewSearchUsecase(searchRepo domain.SearchRepository) SearchUsecase {
+	return &searchUsecase{searchRepo: searchRepo}
+}
+
+func (u *searchUsecase) Search(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &domain.ValidationError{Field: "q", Message: "search query is required"}
+	}
+
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	results, err := u.searchRepo.Search(ctx, query, limit, matchResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	return results, nil
+}
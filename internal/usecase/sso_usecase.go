@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SSOConfig maps what the identity provider says about users to local roles
+type SSOConfig struct {
+	// RoleMapping maps values of the provider's role claim to local roles.
+	// A user with several mapped values gets admin if any maps to admin.
+	RoleMapping map[string]string
+	// DefaultRole is given to users without a mapped value. Empty refuses
+	// them.
+	DefaultRole string
+}
+
+// SSOStart is where a single sign-on login sends the browser, with what the
+// browser must keep until the provider sends it back
+type SSOStart struct {
+	URL   string
+	State string
+	Nonce string
+}
+
+type SSOUsecase interface {
+	BeginLogin(ctx context.Context) (*SSOStart, error)
+	// CompleteLogin logs in the user the provider sent back with code,
+	// creating them on their first login. Their role follows the
+	// provider's claims on every login.
+	CompleteLogin(ctx context.Context, code, nonce string) (*domain.LoginResponse, error)
+	domain.SessionRefresher
+}
+
+type ssoUsecase struct {
+	provider     domain.SSOProvider
+	identityRepo domain.SSOIdentityRepository
+	userRepo     domain.UserRepository
+	sessions     domain.SessionStarter
+	config       SSOConfig
+}
+
+func NewSSOUsecase(provider domain.SSOProvider, identityRepo domain.SSOIdentityRepository, userRepo domain.UserRepository, sessions domain.SessionStarter, config SSOConfig) SSOUsecase {
+	return &ssoUsecase{
+		provider:     provider,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		sessions:     sessions,
+		config:       config,
+	}
+}
+
+func (u *ssoUsecase) BeginLogin(ctx context.Context) (*SSOStart, error) {
+	state, err := generateSSOToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateSSOToken()
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := u.provider.AuthCodeURL(ctx, state, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the identity provider: %w", err)
+	}
+	return &SSOStart{URL: url, State: state, Nonce: nonce}, nil
+}
+
+func (u *ssoUsecase) CompleteLogin(ctx context.Context, code, nonce string) (*domain.LoginResponse, error) {
+	login, err := u.provider.Exchange(ctx, code, nonce)
+	if err != nil {
+		infrastructure.ServerLogger.WithContext(ctx).Warning("Single sign-on login failed: %v", err)
+		return nil, &domain.AuthenticationError{Message: "single sign-on failed"}
+	}
+	claims := login.Claims
+
+	role, ok := u.mapRole(claims.Roles)
+	if !ok {
+		return nil, &domain.AuthenticationError{Message: "account has no role on this server"}
+	}
+
+	user, identity, err := u.findUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		if user, err = u.provisionUser(ctx, claims, role); err != nil {
+			return nil, err
+		}
+	} else if err := u.applyRole(ctx, user, role); err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, &domain.AuthenticationError{Message: "account is deactivated"}
+	}
+
+	if identity == nil || identity.UserID != user.ID {
+		identity = &domain.SSOIdentity{UserID: user.ID, Subject: claims.Subject}
+	}
+	identity.RefreshToken = login.RefreshToken
+	if err := u.identityRepo.Save(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	if err := u.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		infrastructure.ServerLogger.WithContext(ctx).Warning("Failed to update last login time for user %s: %v", user.Username, err)
+	}
+
+	// The provider's MFA isn't trusted, users with two-factor authentication
+	// confirm the session with a code at /auth/2fa/verify
+	return u.sessions.StartSession(ctx, user, false)
+}
+
+// RefreshSession refreshes the provider session of single sign-on users,
+// refusing the refresh once the provider does. Other users, and those the
+// provider gave no refresh token, are left alone.
+func (u *ssoUsecase) RefreshSession(ctx context.Context, user *domain.User) error {
+	identity, err := u.identityRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+	if identity.RefreshToken == "" {
+		return nil
+	}
+
+	login, err := u.provider.Refresh(ctx, identity.RefreshToken)
+	if err != nil {
+		infrastructure.ServerLogger.WithContext(ctx).Info("Single sign-on session of %s ended: %v", user.Username, err)
+		return &domain.AuthenticationError{Message: "single sign-on session expired, log in again"}
+	}
+	if login.Claims != nil {
+		role, ok := u.mapRole(login.Claims.Roles)
+		if !ok {
+			return &domain.AuthenticationError{Message: "account has no role on this server"}
+		}
+		if err := u.applyRole(ctx, user, role); err != nil {
+			return err
+		}
+	}
+
+	identity.RefreshToken = login.RefreshToken
+	return u.identityRepo.Save(ctx, identity)
+}
+
+// findUser returns the user linked to the provider's subject. Failing that,
+// a user with the provider's verified email is returned to be linked.
+func (u *ssoUsecase) findUser(ctx context.Context, claims *domain.SSOClaims) (*domain.User, *domain.SSOIdentity, error) {
+	identity, err := u.identityRepo.GetBySubject(ctx, claims.Subject)
+	switch {
+	case err == nil:
+		user, err := u.userRepo.GetByID(ctx, identity.UserID)
+		if err == nil {
+			return user, identity, nil
+		}
+		// A deleted user is provisioned again
+		var notFound *domain.UserNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, nil, err
+		}
+	case !domain.IsNotFoundError(err):
+		return nil, nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, nil, nil
+	}
+	user, err := u.userRepo.GetByEmail(ctx, claims.Email)
+	if err != nil {
+		var notFound *domain.UserNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	// The email moved to another account at the provider
+	if linked, err := u.identityRepo.GetByUserID(ctx, user.ID); err == nil && linked.Subject != claims.Subject {
+		return nil, nil, &domain.AuthenticationError{Message: "email belongs to another single sign-on account"}
+	}
+	return user, identity, nil
+}
+
+// provisionUser creates the user of a first single sign-on login. Their
+// password is random, so they can only log in through the provider.
+func (u *ssoUsecase) provisionUser(ctx context.Context, claims *domain.SSOClaims, role string) (*domain.User, error) {
+	if claims.Email == "" {
+		return nil, &domain.AuthenticationError{Message: "identity provider didn't share an email address"}
+	}
+	if _, err := u.userRepo.GetByEmail(ctx, claims.Email); err == nil {
+		return nil, &domain.AuthenticationError{Message: "email belongs to an account the identity provider hasn't verified"}
+	}
+
+	username, err := u.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	password, err := generateSSOToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		ID:        uuid.New(),
+		Username:  username,
+		Email:     claims.Email,
+		Password:  string(hashedPassword),
+		Role:      role,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("Created user %s (%s) on their first single sign-on login", user.Username, user.Role)
+	return user, nil
+}
+
+// availableUsername is the provider's username, else the email's local
+// part, with a number appended while it is taken
+func (u *ssoUsecase) availableUsername(ctx context.Context, claims *domain.SSOClaims) (string, error) {
+	base := strings.TrimSpace(claims.Username)
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	for i := 1; i <= 100; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s-%d", base, i)
+		}
+		_, err := u.userRepo.GetByUsername(ctx, username)
+		var notFound *domain.UserNotFoundError
+		if errors.As(err, &notFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free username for %s", base)
+}
+
+// mapRole returns the local role of the provider's role claim values
+func (u *ssoUsecase) mapRole(values []string) (string, bool) {
+	role := ""
+	for _, value := range values {
+		mapped, ok := u.config.RoleMapping[value]
+		if !ok {
+			continue
+		}
+		if mapped == "admin" {
+			return mapped, true
+		}
+		role = mapped
+	}
+	if role == "" {
+		role = u.config.DefaultRole
+	}
+	return role, role != ""
+}
+
+func (u *ssoUsecase) applyRole(ctx context.Context, user *domain.User, role string) error {
+	if user.Role == role {
+		return nil
+	}
+	infrastructure.ServerLogger.WithContext(ctx).Info("Single sign-on changed the role of %s from %s to %s", user.Username, user.Role, role)
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// ParseSSORoleMapping parses a comma-separated list of claim=role pairs,
+// e.g. "hashcat-admins=admin,pentesters=user"
+func ParseSSORoleMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		claim, role, ok := strings.Cut(pair, "=")
+		claim, role = strings.TrimSpace(claim), strings.TrimSpace(role)
+		if !ok || claim == "" || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected claim=role", pair)
+		}
+		mapping[claim] = role
+	}
+	return mapping, nil
+}
+
+// generateSSOToken returns 256 random bits, hex encoded
+func generateSSOToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+)
+
+const (
+	// DefaultStatsCacheTTL keeps dashboards that poll every few seconds from
+	// re-running the aggregate queries on each request
+	DefaultStatsCacheTTL = 30 * time.Second
+
+	topWordlistsLimit = 10
+	keyspaceDays      = 30
+)
+
+type StatsUsecase interface {
+	// GetClusterStats returns the cluster statistics, at most one cache TTL old
+	GetClusterStats(ctx context.Context) (*domain.ClusterStats, error)
+	// GetCostReport prices the compute of the jobs started between from and
+	// to, either of which may be nil, grouped by job, project or agent
+	GetCostReport(ctx context.Context, groupBy string, from, to *time.Time) (*domain.CostReport, error)
+	SetCostRates(rates domain.CostRates)
+	SetDatabaseStats(source domain.DatabaseStatsSource)
+}
+
+type statsUsecase struct {
+	statsRepo domain.StatsRepository
+	ttl       time.Duration
+
+	// mutex is held while computing, so concurrent requests on an expired
+	// cache wait for one computation instead of each running the queries
+	mutex  sync.Mutex
+	cached *domain.ClusterStats
+
+	rates    domain.CostRates           // Set once at startup
+	database domain.DatabaseStatsSource // Optional, set once at startup
+}
+
+func NewStatsUsecase(statsRepo domain.StatsRepository, ttl time.Duration) StatsUsecase {
+	return &statsUsecase{statsRepo: statsRepo, ttl: ttl}
+}
+
+func (u *statsUsecase) GetClusterStats(ctx context.Context) (*domain.ClusterStats, error) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.cached == nil || time.Since(u.cached.GeneratedAt) >= u.ttl {
+		stats, err := u.computeStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		u.cached = stats
+	}
+
+	if u.database == nil {
+		return u.cached, nil
+	}
+	// Contention is worth watching as it happens, so it isn't cached
+	stats := *u.cached
+	database := u.database.DatabaseStats()
+	stats.Database = &database
+	return &stats, nil
+}
+
+// SetDatabaseStats adds the database's live statistics to the cluster stats
+func (u *statsUsecase) SetDatabaseStats(source domain.DatabaseStatsSource) {
+	u.database = source
+}
+
+func (u *statsUsecase) computeStats(ctx context.Context) (*domain.ClusterStats, error) {
+	ctx, span := startSpan(ctx, "StatsUsecase.GetClusterStats")
+	defer span.End()
+
+	now := time.Now()
+	stats := &domain.ClusterStats{GeneratedAt: now}
+
+	agents, err := u.statsRepo.GetAgentStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent stats: %w", err)
+	}
+	stats.Agents = *agents
+
+	if stats.JobsByStatus, err = u.statsRepo.CountJobsByStatus(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	if stats.CracksLast24h, err = u.statsRepo.CountCrackedSince(ctx, now.Add(-24*time.Hour)); err != nil {
+		return nil, fmt.Errorf("failed to count cracks: %w", err)
+	}
+
+	if stats.TopWordlists, err = u.statsRepo.GetTopWordlists(ctx, topWordlistsLimit); err != nil {
+		return nil, fmt.Errorf("failed to get wordlist usage: %w", err)
+	}
+
+	if stats.TimeToCrack, err = u.statsRepo.GetAverageTimeToCrack(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get time to crack: %w", err)
+	}
+
+	// Whole UTC days, today included
+	since := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(keyspaceDays - 1))
+	if stats.KeyspacePerDay, err = u.statsRepo.GetKeyspacePerDay(ctx, since); err != nil {
+		return nil, fmt.Errorf("failed to get keyspace per day: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (u *statsUsecase) SetCostRates(rates domain.CostRates) {
+	u.rates = rates
+}
+
+func (u *statsUsecase) GetCostReport(ctx context.Context, groupBy string, from, to *time.Time) (*domain.CostReport, error) {
+	ctx, span := startSpan(ctx, "StatsUsecase.GetCostReport")
+	defer span.End()
+
+	switch groupBy {
+	case domain.CostGroupJob, domain.CostGroupProject, domain.CostGroupAgent:
+	default:
+		return nil, &domain.ValidationError{Field: "group_by", Message: "must be job, project or agent"}
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, &domain.ValidationError{Field: "to", Message: "must not be before from"}
+	}
+
+	totals, err := u.statsRepo.GetUsage(ctx, groupBy, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	report := &domain.CostReport{
+		GroupBy:     groupBy,
+		From:        from,
+		To:          to,
+		Rates:       u.rates,
+		Items:       make([]domain.CostReportItem, 0, len(totals)),
+		GeneratedAt: time.Now(),
+	}
+	var deviceSeconds, energyWh float64
+	for _, total := range totals {
+		report.Items = append(report.Items, domain.CostReportItem{
+			ID:        total.ID,
+			Name:      total.Name,
+			Jobs:      total.Jobs,
+			UsageCost: u.rates.Price(total.DeviceSeconds, total.EnergyWh),
+		})
+		deviceSeconds += total.DeviceSeconds
+		energyWh += total.EnergyWh
+	}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		return report.Items[i].Cost > report.Items[j].Cost
+	})
+	report.Total = u.rates.Price(deviceSeconds, energyWh)
+	return report, nil
+}
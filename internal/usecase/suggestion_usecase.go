@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	suggestedMasksLimit = 10
+	suggestedRulesLimit = 10
+	maskAttacksLimit    = 5   // Brute-force attacks among the suggested attacks
+	suggestionJobsPage  = 500 // Jobs read per query while collecting rule statistics
+)
+
+type SuggestionUsecase interface {
+	// GetProjectSuggestions ranks the masks of the passwords cracked in a
+	// project and the rule files of its finished jobs, and turns the best of
+	// them into attacks to run next
+	GetProjectSuggestions(ctx context.Context, projectID uuid.UUID) (*domain.AttackSuggestions, error)
+}
+
+type suggestionUsecase struct {
+	jobRepo      domain.JobRepository
+	wordlistRepo domain.WordlistRepository
+}
+
+func NewSuggestionUsecase(jobRepo domain.JobRepository, wordlistRepo domain.WordlistRepository) SuggestionUsecase {
+	return &suggestionUsecase{
+		jobRepo:      jobRepo,
+		wordlistRepo: wordlistRepo,
+	}
+}
+
+func (u *suggestionUsecase) GetProjectSuggestions(ctx context.Context, projectID uuid.UUID) (*domain.AttackSuggestions, error) {
+	ctx, span := startSpan(ctx, "SuggestionUsecase.GetProjectSuggestions", attribute.String("project.id", projectID.String()))
+	defer span.End()
+
+	// The loopback wordlist holds every password cracked in the project, once
+	loopback, err := u.wordlistRepo.GetLoopback(ctx, &projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loopback wordlist: %w", err)
+	}
+	var passwords []string
+	if loopback != nil {
+		if passwords, err = readWords(loopback.Path); err != nil {
+			return nil, fmt.Errorf("failed to read loopback wordlist: %w", err)
+		}
+	}
+
+	rules, err := u.ruleSuggestions(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := &domain.AttackSuggestions{
+		ProjectID:   projectID,
+		Cracked:     len(passwords),
+		Masks:       maskSuggestions(passwords),
+		Rules:       rules,
+		GeneratedAt: time.Now(),
+	}
+	suggestions.Attacks = suggestAttacks(loopback, suggestions)
+	return suggestions, nil
+}
+
+// maskSuggestions counts the masks of the passwords, most frequent first.
+// Ties go to the smaller keyspace, which is cheaper to run.
+func maskSuggestions(passwords []string) []domain.MaskSuggestion {
+	counts := make(map[string]int)
+	for _, password := range passwords {
+		counts[domain.PasswordMask(password)]++
+	}
+
+	masks := make([]domain.MaskSuggestion, 0, len(counts))
+	for mask, count := range counts {
+		masks = append(masks, domain.MaskSuggestion{
+			Mask:     mask,
+			Count:    count,
+			Share:    float64(count) / float64(len(passwords)),
+			Keyspace: domain.MaskKeyspace(mask),
+		})
+	}
+	sort.Slice(masks, func(i, j int) bool {
+		if masks[i].Count != masks[j].Count {
+			return masks[i].Count > masks[j].Count
+		}
+		if masks[i].Keyspace != masks[j].Keyspace {
+			return masks[i].Keyspace < masks[j].Keyspace
+		}
+		return masks[i].Mask < masks[j].Mask
+	})
+	if len(masks) > suggestedMasksLimit {
+		masks = masks[:suggestedMasksLimit]
+	}
+	return masks
+}
+
+// ruleOutcome is whether a job, or a job group, that used a rule file has
+// finished and whether it cracked its hash
+type ruleOutcome struct {
+	rules    string
+	finished bool
+	cracked  bool
+}
+
+// ruleSuggestions ranks the rule files of the project's finished jobs by
+// how often they cracked the hash
+func (u *suggestionUsecase) ruleSuggestions(ctx context.Context, projectID uuid.UUID) ([]domain.RuleSuggestion, error) {
+	outcomes := make(map[uuid.UUID]*ruleOutcome)
+	filter := domain.JobFilter{ProjectID: &projectID, Limit: suggestionJobsPage}
+	for {
+		jobs, total, err := u.jobRepo.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		for _, job := range jobs {
+			if job.Rules == "" {
+				continue
+			}
+			// Sub-jobs of a distributed job count as one job
+			key := job.ID
+			if job.GroupID != nil {
+				key = *job.GroupID
+			}
+			outcome, ok := outcomes[key]
+			if !ok {
+				outcome = &ruleOutcome{rules: job.Rules}
+				outcomes[key] = outcome
+			}
+			switch job.Status {
+			case domain.JobStatusCracked:
+				outcome.finished, outcome.cracked = true, true
+			case domain.JobStatusCompleted, domain.JobStatusFailed:
+				outcome.finished = true
+			}
+		}
+		filter.Offset += len(jobs)
+		if len(jobs) == 0 || filter.Offset >= total {
+			break
+		}
+	}
+
+	byRules := make(map[string]*domain.RuleSuggestion)
+	for _, outcome := range outcomes {
+		if !outcome.finished {
+			continue
+		}
+		suggestion, ok := byRules[outcome.rules]
+		if !ok {
+			suggestion = &domain.RuleSuggestion{Rules: outcome.rules}
+			byRules[outcome.rules] = suggestion
+		}
+		suggestion.Jobs++
+		if outcome.cracked {
+			suggestion.Cracked++
+		}
+	}
+
+	rules := make([]domain.RuleSuggestion, 0, len(byRules))
+	for _, suggestion := range byRules {
+		suggestion.SuccessRate = float64(suggestion.Cracked) / float64(suggestion.Jobs)
+		rules = append(rules, *suggestion)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].SuccessRate != rules[j].SuccessRate {
+			return rules[i].SuccessRate > rules[j].SuccessRate
+		}
+		if rules[i].Cracked != rules[j].Cracked {
+			return rules[i].Cracked > rules[j].Cracked
+		}
+		return rules[i].Rules < rules[j].Rules
+	})
+	if len(rules) > suggestedRulesLimit {
+		rules = rules[:suggestedRulesLimit]
+	}
+	return rules, nil
+}
+
+// suggestAttacks turns the statistics into job parameters: first the
+// cracked passwords themselves, mangled with the most successful rule
+// file, then brute-force attacks on the most frequent masks
+func suggestAttacks(loopback *domain.Wordlist, suggestions *domain.AttackSuggestions) []domain.AttackSuggestion {
+	attacks := []domain.AttackSuggestion{}
+
+	if loopback != nil && suggestions.Cracked > 0 {
+		attack := domain.AttackSuggestion{
+			AttackMode: domain.AttackModeStraight,
+			Wordlist:   loopback.OrigName,
+			WordlistID: &loopback.ID,
+			Reason:     fmt.Sprintf("the %d passwords cracked so far", suggestions.Cracked),
+		}
+		if len(suggestions.Rules) > 0 && suggestions.Rules[0].Cracked > 0 {
+			best := suggestions.Rules[0]
+			attack.Rules = best.Rules
+			attack.Reason += fmt.Sprintf(", with the rule file that cracked %d of %d jobs", best.Cracked, best.Jobs)
+		}
+		attacks = append(attacks, attack)
+	}
+
+	for i, mask := range suggestions.Masks {
+		if i == maskAttacksLimit {
+			break
+		}
+		attacks = append(attacks, domain.AttackSuggestion{
+			AttackMode: domain.AttackModeBruteForce,
+			Wordlist:   mask.Mask,
+			Reason:     fmt.Sprintf("matches %d of %d cracked passwords", mask.Count, suggestions.Cracked),
+		})
+	}
+	return attacks
+}
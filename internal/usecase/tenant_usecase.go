@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type TenantUsecase interface {
+	CreateTenant(ctx context.Context, req *domain.CreateTenantRequest) (*domain.Tenant, error)
+	GetTenant(ctx context.Context, id uuid.UUID) (*domain.Tenant, error)
+	GetAllTenants(ctx context.Context) ([]domain.Tenant, error)
+	UpdateTenant(ctx context.Context, id uuid.UUID, req *domain.UpdateTenantRequest) (*domain.Tenant, error)
+	// DeleteTenant removes a tenant that no longer has any users, agents,
+	// jobs or files. Left without a tenant they'd be visible to everyone.
+	DeleteTenant(ctx context.Context, id uuid.UUID) error
+	GetStats(ctx context.Context, id uuid.UUID) (*domain.TenantStats, error)
+	// AddUser moves a user into a tenant. The user's next login picks the
+	// change up.
+	AddUser(ctx context.Context, tenantID, userID uuid.UUID) error
+	// RemoveUser takes a user of the tenant out of tenants
+	RemoveUser(ctx context.Context, tenantID, userID uuid.UUID) error
+	// AddAgent dedicates an agent to a tenant
+	AddAgent(ctx context.Context, tenantID, agentID uuid.UUID) error
+	// RemoveAgent shares an agent of the tenant with every tenant again
+	RemoveAgent(ctx context.Context, tenantID, agentID uuid.UUID) error
+}
+
+type tenantUsecase struct {
+	tenantRepo domain.TenantRepository
+	userRepo   domain.UserRepository
+	agentRepo  domain.AgentRepository
+	quotaRepo  domain.QuotaRepository
+}
+
+func NewTenantUsecase(tenantRepo domain.TenantRepository, userRepo domain.UserRepository, agentRepo domain.AgentRepository, quotaRepo domain.QuotaRepository) TenantUsecase {
+	return &tenantUsecase{
+		tenantRepo: tenantRepo,
+		userRepo:   userRepo,
+		agentRepo:  agentRepo,
+		quotaRepo:  quotaRepo,
+	}
+}
+
+func (u *tenantUsecase) CreateTenant(ctx context.Context, req *domain.CreateTenantRequest) (*domain.Tenant, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+	if err := u.checkNameAvailable(ctx, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	tenant := &domain.Tenant{Name: name, Description: strings.TrimSpace(req.Description)}
+	if err := u.tenantRepo.Create(ctx, tenant); err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func (u *tenantUsecase) GetTenant(ctx context.Context, id uuid.UUID) (*domain.Tenant, error) {
+	return u.tenantRepo.GetByID(ctx, id)
+}
+
+func (u *tenantUsecase) GetAllTenants(ctx context.Context) ([]domain.Tenant, error) {
+	return u.tenantRepo.GetAll(ctx)
+}
+
+func (u *tenantUsecase) UpdateTenant(ctx context.Context, id uuid.UUID, req *domain.UpdateTenantRequest) (*domain.Tenant, error) {
+	tenant, err := u.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, &domain.ValidationError{Field: "name", Message: "must not be empty"}
+		}
+		if err := u.checkNameAvailable(ctx, name, id); err != nil {
+			return nil, err
+		}
+		tenant.Name = name
+	}
+	if req.Description != nil {
+		tenant.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := u.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func (u *tenantUsecase) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+	tenant, err := u.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := u.tenantRepo.CountResources(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to count resources of tenant %s: %w", tenant.Name, err)
+	}
+	if count > 0 {
+		return &domain.ValidationError{Field: "tenant", Message: fmt.Sprintf("tenant '%s' still has %d users, agents, jobs or files", tenant.Name, count)}
+	}
+
+	return u.tenantRepo.Delete(ctx, id)
+}
+
+func (u *tenantUsecase) GetStats(ctx context.Context, id uuid.UUID) (*domain.TenantStats, error) {
+	if _, err := u.tenantRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	stats, err := u.tenantRepo.GetStats(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant stats: %w", err)
+	}
+	usage, err := u.quotaRepo.GetUsage(ctx, domain.QuotaScopeTenant, id, domain.QuotaMonthStart(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	stats.Usage = *usage
+	return stats, nil
+}
+
+func (u *tenantUsecase) AddUser(ctx context.Context, tenantID, userID uuid.UUID) error {
+	if _, err := u.tenantRepo.GetByID(ctx, tenantID); err != nil {
+		return err
+	}
+	if _, err := u.getUser(ctx, userID); err != nil {
+		return err
+	}
+	return u.userRepo.SetTenant(ctx, userID, &tenantID)
+}
+
+func (u *tenantUsecase) RemoveUser(ctx context.Context, tenantID, userID uuid.UUID) error {
+	user, err := u.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !inTenant(user.TenantID, tenantID) {
+		return &domain.NotFoundError{Entity: "user"}
+	}
+	return u.userRepo.SetTenant(ctx, userID, nil)
+}
+
+func (u *tenantUsecase) AddAgent(ctx context.Context, tenantID, agentID uuid.UUID) error {
+	if _, err := u.tenantRepo.GetByID(ctx, tenantID); err != nil {
+		return err
+	}
+	if _, err := u.getAgent(ctx, agentID); err != nil {
+		return err
+	}
+	return u.agentRepo.SetTenant(ctx, agentID, &tenantID)
+}
+
+func (u *tenantUsecase) RemoveAgent(ctx context.Context, tenantID, agentID uuid.UUID) error {
+	agent, err := u.getAgent(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	if !inTenant(agent.TenantID, tenantID) {
+		return &domain.NotFoundError{Entity: "agent"}
+	}
+	return u.agentRepo.SetTenant(ctx, agentID, nil)
+}
+
+// getUser maps the user repository's own not found error to a
+// NotFoundError, which the handlers turn into a 404
+func (u *tenantUsecase) getUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	var notFound *domain.UserNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &domain.NotFoundError{Entity: "user"}
+	}
+	return user, err
+}
+
+func (u *tenantUsecase) getAgent(ctx context.Context, agentID uuid.UUID) (*domain.Agent, error) {
+	agent, err := u.agentRepo.GetByID(ctx, agentID)
+	if errors.Is(err, domain.ErrAgentNotFound) {
+		return nil, &domain.NotFoundError{Entity: "agent"}
+	}
+	return agent, err
+}
+
+// inTenant reports whether a resource belongs to the tenant
+func inTenant(owner *uuid.UUID, tenantID uuid.UUID) bool {
+	return owner != nil && *owner == tenantID
+}
+
+// checkNameAvailable fails when another tenant than exceptID has the name
+func (u *tenantUsecase) checkNameAvailable(ctx context.Context, name string, exceptID uuid.UUID) error {
+	existing, err := u.tenantRepo.GetByName(ctx, name)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to check tenant name: %w", err)
+	}
+	if existing.ID != exceptID {
+		return &domain.ValidationError{Field: "name", Message: fmt.Sprintf("tenant '%s' already exists", existing.Name)}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"go-distributed-hashcat/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("usecase")
+
+// startSpan starts a span for a usecase call. Repository queries made with
+// the returned context become its children.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
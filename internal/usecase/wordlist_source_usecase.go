@@ -0,0 +1,432 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSourceFetchWorkers = 2
+	// wordlistSourceCheckInterval is how often the scheduler looks for
+	// sources due to be fetched again
+	wordlistSourceCheckInterval = 5 * time.Minute
+)
+
+// WordlistSourceUsecase keeps a catalog of remote wordlists the server
+// downloads itself. Fetches run in the background; each download is checked
+// against the source's checksum, decompressed and stored like an upload.
+type WordlistSourceUsecase interface {
+	CreateSource(ctx context.Context, req *domain.CreateWordlistSourceRequest) (*domain.WordlistSource, error)
+	GetSource(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error)
+	GetSources(ctx context.Context) ([]domain.WordlistSource, error)
+	UpdateSource(ctx context.Context, id uuid.UUID, req *domain.UpdateWordlistSourceRequest) (*domain.WordlistSource, error)
+	// DeleteSource removes the source; the wordlists it fetched are kept
+	DeleteSource(ctx context.Context, id uuid.UUID) error
+	// FetchSource starts fetching the source and returns it as fetching
+	FetchSource(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error)
+	// FetchDue starts fetching the scheduled sources that are due,
+	// returning how many
+	FetchDue(ctx context.Context) (int, error)
+	// RunScheduler fetches due sources every wordlistSourceCheckInterval
+	// until ctx is done
+	RunScheduler(ctx context.Context)
+	// FailInterrupted marks the fetches a previous server run left
+	// unfinished as failed
+	FailInterrupted(ctx context.Context) error
+	// Stop cancels the running fetches and waits for them to fail
+	Stop()
+}
+
+type wordlistSourceUsecase struct {
+	sourceRepo domain.WordlistSourceRepository
+	wordlists  WordlistUsecase
+	client     *http.Client
+	maxSize    int64         // Largest download accepted, 0 for no limit
+	slots      chan struct{} // Limits how many fetches run at once
+	ctx        context.Context
+	cancel     context.CancelFunc
+	running    sync.WaitGroup
+
+	mu       sync.Mutex
+	fetching map[uuid.UUID]bool
+}
+
+// NewWordlistSourceUsecase stores fetched wordlists through wordlists, so
+// they are deduplicated and counted against quotas like uploads. Downloads
+// larger than maxSize bytes are refused, 0 accepts any size.
+func NewWordlistSourceUsecase(sourceRepo domain.WordlistSourceRepository, wordlists WordlistUsecase, maxSize int64) WordlistSourceUsecase {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &wordlistSourceUsecase{
+		sourceRepo: sourceRepo,
+		wordlists:  wordlists,
+		client:     &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: time.Minute}},
+		maxSize:    maxSize,
+		slots:      make(chan struct{}, defaultSourceFetchWorkers),
+		ctx:        ctx,
+		cancel:     cancel,
+		fetching:   make(map[uuid.UUID]bool),
+	}
+}
+
+func (u *wordlistSourceUsecase) CreateSource(ctx context.Context, req *domain.CreateWordlistSourceRequest) (*domain.WordlistSource, error) {
+	source := &domain.WordlistSource{
+		Name:         strings.TrimSpace(req.Name),
+		URL:          strings.TrimSpace(req.URL),
+		SHA256:       strings.ToLower(strings.TrimSpace(req.SHA256)),
+		RefreshHours: req.RefreshHours,
+		ProjectID:    req.ProjectID,
+		Status:       domain.WordlistSourcePending,
+		CreatedBy:    domain.UserIDFromContext(ctx),
+	}
+	if err := validateWordlistSource(source); err != nil {
+		return nil, err
+	}
+	if source.Name == "" {
+		source.Name = sourceFileName(source.URL)
+	}
+
+	if err := u.sourceRepo.Create(ctx, source); err != nil {
+		return nil, err
+	}
+	if req.Fetch {
+		return u.FetchSource(ctx, source.ID)
+	}
+	return source, nil
+}
+
+func (u *wordlistSourceUsecase) GetSource(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error) {
+	return u.sourceRepo.GetByID(ctx, id)
+}
+
+func (u *wordlistSourceUsecase) GetSources(ctx context.Context) ([]domain.WordlistSource, error) {
+	return u.sourceRepo.GetAll(ctx)
+}
+
+func (u *wordlistSourceUsecase) UpdateSource(ctx context.Context, id uuid.UUID, req *domain.UpdateWordlistSourceRequest) (*domain.WordlistSource, error) {
+	source, err := u.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		source.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		source.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.SHA256 != nil {
+		source.SHA256 = strings.ToLower(strings.TrimSpace(*req.SHA256))
+	}
+	if req.RefreshHours != nil {
+		source.RefreshHours = *req.RefreshHours
+	}
+	if err := validateWordlistSource(source); err != nil {
+		return nil, err
+	}
+	if source.Name == "" {
+		source.Name = sourceFileName(source.URL)
+	}
+
+	if err := u.sourceRepo.Update(ctx, source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (u *wordlistSourceUsecase) DeleteSource(ctx context.Context, id uuid.UUID) error {
+	return u.sourceRepo.Delete(ctx, id)
+}
+
+func (u *wordlistSourceUsecase) FetchSource(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error) {
+	source, err := u.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.claim(id) {
+		return nil, &domain.ValidationError{Field: "status", Message: "the source is already being fetched"}
+	}
+
+	source.Status = domain.WordlistSourceFetching
+	source.Error = ""
+	if err := u.sourceRepo.Update(ctx, source); err != nil {
+		u.release(id)
+		return nil, err
+	}
+	u.start(*source)
+	return source, nil
+}
+
+func (u *wordlistSourceUsecase) FetchDue(ctx context.Context) (int, error) {
+	sources, err := u.sourceRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wordlist sources: %w", err)
+	}
+
+	now := time.Now()
+	started := 0
+	for i := range sources {
+		if !sources[i].Due(now) {
+			continue
+		}
+		if _, err := u.FetchSource(ctx, sources[i].ID); err != nil {
+			if !domain.IsValidationError(err) {
+				infrastructure.ServerLogger.Warning("Failed to start fetching wordlist source %s: %v", sources[i].Name, err)
+			}
+			continue
+		}
+		started++
+	}
+	return started, nil
+}
+
+func (u *wordlistSourceUsecase) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(wordlistSourceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.FetchDue(ctx); err != nil {
+			infrastructure.ServerLogger.Error("%v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *wordlistSourceUsecase) FailInterrupted(ctx context.Context) error {
+	failed, err := u.sourceRepo.FailFetching(ctx, "interrupted by server restart")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		infrastructure.ServerLogger.Warning("Marked %d unfinished wordlist source fetches as failed", failed)
+	}
+	return nil
+}
+
+func (u *wordlistSourceUsecase) Stop() {
+	u.cancel()
+	u.running.Wait()
+}
+
+// claim marks a source as being fetched, false if it already is
+func (u *wordlistSourceUsecase) claim(id uuid.UUID) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fetching[id] {
+		return false
+	}
+	u.fetching[id] = true
+	return true
+}
+
+func (u *wordlistSourceUsecase) release(id uuid.UUID) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.fetching, id)
+}
+
+// start fetches the source in the background once a worker slot is free.
+// The fetch keeps the user who registered the source, so its wordlists
+// count against their quota.
+func (u *wordlistSourceUsecase) start(source domain.WordlistSource) {
+	runCtx := u.ctx
+	if source.CreatedBy != nil {
+		runCtx = domain.WithUserID(runCtx, *source.CreatedBy)
+	}
+
+	u.running.Add(1)
+	go func() {
+		defer u.running.Done()
+		defer u.release(source.ID)
+		select {
+		case u.slots <- struct{}{}:
+			defer func() { <-u.slots }()
+		case <-runCtx.Done():
+			u.finish(runCtx, source.ID, nil, errOperationInterrupted)
+			return
+		}
+
+		result, err := u.fetch(runCtx, &source)
+		u.finish(runCtx, source.ID, result, err)
+	}()
+}
+
+// fetchResult is what a successful fetch changes on its source
+type fetchResult struct {
+	wordlistID uuid.UUID
+	sum        string
+}
+
+// finish saves the outcome of a fetch on the source as it is now, so
+// edits made while it ran are kept
+func (u *wordlistSourceUsecase) finish(ctx context.Context, id uuid.UUID, result *fetchResult, fetchErr error) {
+	ctx = context.WithoutCancel(ctx)
+	source, err := u.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		infrastructure.ServerLogger.Warning("Wordlist source %s is gone, dropping its fetch: %v", id, err)
+		return
+	}
+
+	now := time.Now()
+	source.FetchedAt = &now
+	if fetchErr != nil {
+		if u.ctx.Err() != nil {
+			fetchErr = errOperationInterrupted
+		}
+		source.Status = domain.WordlistSourceFailed
+		source.Error = fetchErr.Error()
+		infrastructure.ServerLogger.Warning("Failed to fetch wordlist source %s from %s: %v", source.Name, source.URL, fetchErr)
+	} else {
+		source.Status = domain.WordlistSourceReady
+		source.Error = ""
+		source.WordlistID = &result.wordlistID
+		source.FetchedSHA256 = result.sum
+	}
+
+	if err := u.sourceRepo.Update(ctx, source); err != nil {
+		infrastructure.ServerLogger.Error("Failed to save fetch of wordlist source %s: %v", source.Name, err)
+	}
+}
+
+// fetch downloads the source to a temporary file, checks its checksum and
+// stores it as a wordlist unless it is the download its wordlist already
+// holds
+func (u *wordlistSourceUsecase) fetch(ctx context.Context, source *domain.WordlistSource) (*fetchResult, error) {
+	download, err := os.CreateTemp("", "wordlist-source-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(download.Name())
+	defer download.Close()
+
+	size, sum, err := u.download(ctx, source.URL, download)
+	if err != nil {
+		return nil, err
+	}
+	if source.SHA256 != "" && sum != source.SHA256 {
+		return nil, fmt.Errorf("checksum mismatch: expected %s, downloaded %s", source.SHA256, sum)
+	}
+
+	if sum == source.FetchedSHA256 && source.WordlistID != nil {
+		if _, err := u.wordlists.GetWordlist(ctx, *source.WordlistID); err == nil {
+			infrastructure.ServerLogger.Info("Wordlist source %s is unchanged", source.Name)
+			return &fetchResult{wordlistID: *source.WordlistID, sum: sum}, nil
+		}
+	}
+
+	if _, err := download.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := decompress(download)
+	if err != nil {
+		return nil, err
+	}
+	wordlist, err := u.wordlists.UploadWordlist(ctx, source.Name, content, size, source.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	infrastructure.ServerLogger.Info("Fetched wordlist source %s into wordlist %s (%d bytes downloaded)", source.Name, wordlist.ID, size)
+	return &fetchResult{wordlistID: wordlist.ID, sum: sum}, nil
+}
+
+// download writes the body of rawURL to dst, returning its size and
+// SHA-256 checksum
+func (u *wordlistSourceUsecase) download(ctx context.Context, rawURL string, dst io.Writer) (int64, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if u.maxSize > 0 {
+		body = io.LimitReader(resp.Body, u.maxSize+1)
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(dst, hasher), body)
+	if err != nil {
+		return 0, "", fmt.Errorf("download failed: %w", err)
+	}
+	if u.maxSize > 0 && size > u.maxSize {
+		return 0, "", fmt.Errorf("download is larger than the %d MB wordlist limit", u.maxSize>>20)
+	}
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// decompress returns the content of a gzip or bzip2 download, and plain
+// text as it is
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(6)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(buffered)
+	case len(magic) == 6 && bytes.HasPrefix(magic, []byte("BZh")) && magic[3] >= '1' && magic[3] <= '9' && magic[4] == 0x31 && magic[5] == 0x41:
+		return bzip2.NewReader(buffered), nil
+	case bytes.HasPrefix(magic, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}), bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return nil, fmt.Errorf("7z and zip archives aren't supported, use a plain, gzip or bzip2 download")
+	}
+	return buffered, nil
+}
+
+func validateWordlistSource(source *domain.WordlistSource) error {
+	parsed, err := url.Parse(source.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return &domain.ValidationError{Field: "url", Message: "must be an http or https URL"}
+	}
+	if source.SHA256 != "" {
+		if _, err := hex.DecodeString(source.SHA256); err != nil || len(source.SHA256) != sha256.Size*2 {
+			return &domain.ValidationError{Field: "sha256", Message: "must be a hex SHA-256 checksum"}
+		}
+	}
+	if source.RefreshHours < 0 {
+		return &domain.ValidationError{Field: "refresh_hours", Message: "must not be negative"}
+	}
+	return nil
+}
+
+// sourceFileName names a source after the file of its URL, without a
+// compression extension
+func sourceFileName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "wordlist.txt"
+	}
+	name := path.Base(parsed.Path)
+	for _, ext := range []string{".gz", ".bz2"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == "" || name == "." || name == "/" {
+		return "wordlist.txt"
+	}
+	return name
+}
@@ -3,11 +3,14 @@ package usecase
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"go-distributed-hashcat/internal/domain"
 
@@ -15,15 +18,29 @@ import (
 )
 
 type WordlistUsecase interface {
-	UploadWordlist(ctx context.Context, name string, content io.Reader, size int64) (*domain.Wordlist, error)
+	// UploadWordlist stores the file in the project, or in none when projectID is nil
+	UploadWordlist(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.Wordlist, error)
 	GetWordlist(ctx context.Context, id uuid.UUID) (*domain.Wordlist, error)
+	GetWordlistBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error)
 	GetAllWordlists(ctx context.Context) ([]domain.Wordlist, error)
+	GetWordlistsByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error)
 	DeleteWordlist(ctx context.Context, id uuid.UUID) error
+	// GetLoopbackWordlist returns the wordlist of passwords cracked in the
+	// project, or in jobs without one when projectID is nil
+	GetLoopbackWordlist(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error)
+	CrackedPasswordSink
+	// SetQuotaChecker makes uploads refuse files over a storage quota
+	SetQuotaChecker(checker domain.QuotaChecker)
+	// SetLookupCache makes changes to wordlists invalidate their cached lookups
+	SetLookupCache(lookups LookupCache)
 }
 
 type wordlistUsecase struct {
 	wordlistRepo domain.WordlistRepository
 	uploadDir    string
+	loopbackMu   sync.Mutex          // Serializes rewrites of loopback wordlist files
+	quotas       domain.QuotaChecker // Optional, refuses uploads over a user's or project's quota
+	lookups      LookupCache         // Optional, invalidated when wordlists change
 }
 
 func NewWordlistUsecase(wordlistRepo domain.WordlistRepository, uploadDir string) WordlistUsecase {
@@ -33,7 +50,27 @@ func NewWordlistUsecase(wordlistRepo domain.WordlistRepository, uploadDir string
 	}
 }
 
-func (u *wordlistUsecase) UploadWordlist(ctx context.Context, name string, content io.Reader, size int64) (*domain.Wordlist, error) {
+func (u *wordlistUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	u.quotas = checker
+}
+
+func (u *wordlistUsecase) SetLookupCache(lookups LookupCache) {
+	u.lookups = lookups
+}
+
+func (u *wordlistUsecase) invalidateLookup(id uuid.UUID) {
+	if u.lookups != nil {
+		u.lookups.InvalidateWordlist(id)
+	}
+}
+
+func (u *wordlistUsecase) UploadWordlist(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	if u.quotas != nil {
+		if err := u.quotas.CheckStorageQuota(ctx, projectID, size); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create upload directory if it doesn't exist
 	wordlistDir := filepath.Join(u.uploadDir, "wordlists")
 	if err := os.MkdirAll(wordlistDir, 0755); err != nil {
@@ -56,13 +93,27 @@ func (u *wordlistUsecase) UploadWordlist(ctx context.Context, name string, conte
 	}
 	defer file.Close()
 
-	// Copy content to file and count words
-	wordCount, written, err := u.copyAndCountWords(file, content)
+	// Copy content to file and count words, hashing what actually lands on disk
+	hasher := sha256.New()
+	wordCount, written, err := u.copyAndCountWords(io.MultiWriter(file, hasher), content)
 	if err != nil {
 		// Clean up on error
 		os.Remove(filePath)
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+
+	// Reuse the existing wordlist if the same content was uploaded before to
+	// the same project; other projects get their own copy. Loopback
+	// wordlists change as jobs crack, so uploads never share them.
+	if existing, err := u.wordlistRepo.GetBySHA256(ctx, sum); err == nil && !existing.Dynamic && sameProject(existing.ProjectID, projectID) {
+		if _, statErr := os.Stat(existing.Path); statErr == nil {
+			file.Close()
+			os.Remove(filePath)
+			existing.Duplicate = true
+			return existing, nil
+		}
+	}
 
 	// Create wordlist record
 	wordlist := &domain.Wordlist{
@@ -72,6 +123,9 @@ func (u *wordlistUsecase) UploadWordlist(ctx context.Context, name string, conte
 		Path:      filePath,
 		Size:      written,
 		WordCount: &wordCount,
+		SHA256:    sum,
+		ProjectID: projectID,
+		CreatedBy: domain.UserIDFromContext(ctx),
 	}
 
 	if err := u.wordlistRepo.Create(ctx, wordlist); err != nil {
@@ -91,6 +145,14 @@ func (u *wordlistUsecase) GetWordlist(ctx context.Context, id uuid.UUID) (*domai
 	return wordlist, nil
 }
 
+func (u *wordlistUsecase) GetWordlistBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error) {
+	wordlist, err := u.wordlistRepo.GetBySHA256(ctx, strings.ToLower(sum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wordlist: %w", err)
+	}
+	return wordlist, nil
+}
+
 func (u *wordlistUsecase) GetAllWordlists(ctx context.Context) ([]domain.Wordlist, error) {
 	wordlists, err := u.wordlistRepo.GetAll(ctx)
 	if err != nil {
@@ -99,6 +161,14 @@ func (u *wordlistUsecase) GetAllWordlists(ctx context.Context) ([]domain.Wordlis
 	return wordlists, nil
 }
 
+func (u *wordlistUsecase) GetWordlistsByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error) {
+	wordlists, err := u.wordlistRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wordlists: %w", err)
+	}
+	return wordlists, nil
+}
+
 func (u *wordlistUsecase) DeleteWordlist(ctx context.Context, id uuid.UUID) error {
 	wordlist, err := u.wordlistRepo.GetByID(ctx, id)
 	if err != nil {
@@ -114,6 +184,7 @@ func (u *wordlistUsecase) DeleteWordlist(ctx context.Context, id uuid.UUID) erro
 	if err := u.wordlistRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete wordlist record: %w", err)
 	}
+	u.invalidateLookup(id)
 
 	return nil
 }
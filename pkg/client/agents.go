@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Agent, CreateAgentRequest and the other aliases let importers outside
+// this module name the server's types
+type (
+	Agent              = domain.Agent
+	CreateAgentRequest = domain.CreateAgentRequest
+	AgentHeartbeat     = domain.AgentHeartbeat
+	AgentCacheReport   = domain.AgentCacheReport
+	AgentSyncResult    = domain.AgentSyncResult
+	AgentLogLine       = domain.AgentLogLine
+	AgentSettings      = domain.AgentSettings
+	AgentBenchmarks    = domain.AgentBenchmarks
+	BenchmarkJob       = domain.BenchmarkJob
+	BenchmarkReport    = domain.BenchmarkReport
+	AgentCommand       = domain.AgentCommand
+	AgentCommandResult = domain.AgentCommandResult
+
+	EnrollmentToken               = domain.EnrollmentToken
+	CreateEnrollmentTokensRequest = domain.CreateEnrollmentTokensRequest
+)
+
+// UpdateAgentDataRequest changes an agent's address and capabilities
+// without touching its status. Empty fields are left as they are.
+type UpdateAgentDataRequest struct {
+	AgentKey     string `json:"agent_key"`
+	IPAddress    string `json:"ip_address,omitempty"`
+	Port         int    `json:"port,omitempty"`
+	Capabilities string `json:"capabilities,omitempty"`
+}
+
+// HeartbeatRequest is an agent's periodic sign of life
+type HeartbeatRequest struct {
+	AgentKey string            `json:"agent_key"`
+	Cache    *AgentCacheReport `json:"cache,omitempty"`
+	Snapshot *AgentHeartbeat   `json:"snapshot"`
+	// Interval the agent asks for in seconds, 0 lets the server decide
+	IntervalSeconds int `json:"heartbeat_interval_seconds,omitempty"`
+}
+
+// HeartbeatResponse is the server's answer to a heartbeat
+type HeartbeatResponse struct {
+	// When to send the next heartbeat
+	IntervalSeconds int `json:"heartbeat_interval_seconds"`
+	// The agent's settings as managed on the server
+	Settings *AgentSettings `json:"settings,omitempty"`
+	// Commands queued for the agent that it hasn't acknowledged yet
+	Commands []AgentCommand `json:"commands,omitempty"`
+}
+
+// LocalFile is a wordlist or hash file in an agent's upload directory
+type LocalFile struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Type    string    `json:"type"` // wordlist, hash_file
+	Hash    string    `json:"hash"` // MD5 hash for integrity
+	ModTime time.Time `json:"mod_time"`
+}
+
+// ListAgents returns up to pageSize agents, 0 for the server's default
+func (c *Client) ListAgents(ctx context.Context, pageSize int) ([]Agent, error) {
+	path := "/api/v1/agents/"
+	if pageSize > 0 {
+		path += fmt.Sprintf("?page_size=%d", pageSize)
+	}
+	var agents []Agent
+	if err := c.Do(ctx, http.MethodGet, path, nil, &agents); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// GetAgentByKey returns the agent registered with agentKey
+func (c *Client) GetAgentByKey(ctx context.Context, agentKey string) (*Agent, error) {
+	var agents []Agent
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/?agent_key="+url.QueryEscape(agentKey), nil, &agents); err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("agent key not found")
+	}
+	return &agents[0], nil
+}
+
+// GenerateAgentKey reserves agentKey for a new agent called name
+func (c *Client) GenerateAgentKey(ctx context.Context, name, agentKey string) (*Agent, error) {
+	var agent Agent
+	body := map[string]string{"name": name, "agent_key": agentKey}
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/generate-key", body, &agent); err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// Enroll trades a single-use enrollment token for a new agent called name.
+// The agent's AgentKey is its credential from then on. Refused tokens
+// return an *APIError with status 401 (unknown) or 410 (expired or used).
+func (c *Client) Enroll(ctx context.Context, token, name string) (*Agent, error) {
+	var agent Agent
+	body := map[string]string{"token": token, "name": name}
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/enroll", body, &agent); err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// CreateEnrollmentTokens generates a batch of enrollment tokens
+func (c *Client) CreateEnrollmentTokens(ctx context.Context, req CreateEnrollmentTokensRequest) ([]EnrollmentToken, error) {
+	var tokens []EnrollmentToken
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/enrollment-tokens/", req, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RegisterAgent registers an agent under a generated agent key
+func (c *Client) RegisterAgent(ctx context.Context, req CreateAgentRequest) (*Agent, error) {
+	var agent Agent
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/", req, &agent); err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// UpdateAgentData changes an agent's address and capabilities
+func (c *Client) UpdateAgentData(ctx context.Context, req UpdateAgentDataRequest) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/update-data", req, nil)
+}
+
+// UpdateAgentStatus sets an agent's status, e.g. online or busy
+func (c *Client) UpdateAgentStatus(ctx context.Context, agentID uuid.UUID, status string) error {
+	body := struct {
+		Status string `json:"status"`
+	}{status}
+	return c.Do(ctx, http.MethodPut, "/api/v1/agents/"+agentID.String()+"/status", body, nil)
+}
+
+// SetAgentOffline marks an agent offline, keeping its last known speed
+func (c *Client) SetAgentOffline(ctx context.Context, agentID uuid.UUID) error {
+	return c.Do(ctx, http.MethodPut, "/api/v1/agents/"+agentID.String()+"/status-offline", nil, nil)
+}
+
+// UpdateAgentSpeed records an agent's benchmark speed in H/s
+func (c *Client) UpdateAgentSpeed(ctx context.Context, agentID uuid.UUID, speed int64) error {
+	body := struct {
+		Speed int64 `json:"speed"`
+	}{speed}
+	return c.Do(ctx, http.MethodPut, "/api/v1/agents/"+agentID.String()+"/speed", body, nil)
+}
+
+// GetAgentSettings returns the settings managed on the server for an agent
+func (c *Client) GetAgentSettings(ctx context.Context, agentID uuid.UUID) (*AgentSettings, error) {
+	var settings AgentSettings
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/settings", nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetAgentSettings replaces an agent's server-managed settings
+func (c *Client) SetAgentSettings(ctx context.Context, agentID uuid.UUID, settings AgentSettings) (*AgentSettings, error) {
+	var saved AgentSettings
+	if err := c.Do(ctx, http.MethodPut, "/api/v1/agents/"+agentID.String()+"/settings", settings, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// GetAgentBenchmarks returns an agent's benchmarks by hash mode and its
+// latest benchmark jobs
+func (c *Client) GetAgentBenchmarks(ctx context.Context, agentID uuid.UUID) (*AgentBenchmarks, error) {
+	var benchmarks AgentBenchmarks
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/benchmarks", nil, &benchmarks); err != nil {
+		return nil, err
+	}
+	return &benchmarks, nil
+}
+
+// RequestBenchmark has an agent benchmark hash modes before its next job
+func (c *Client) RequestBenchmark(ctx context.Context, agentID uuid.UUID, hashTypes []int) (*BenchmarkJob, error) {
+	body := domain.BenchmarkRequest{HashTypes: hashTypes}
+	var job BenchmarkJob
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/benchmarks", body, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// InvalidateBenchmarks drops an agent's benchmarks, e.g. after its hardware
+// changed
+func (c *Client) InvalidateBenchmarks(ctx context.Context, agentID uuid.UUID) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/agents/"+agentID.String()+"/benchmarks", nil, nil)
+}
+
+// ClaimBenchmarkJob returns the benchmark job an agent should run next, or
+// nil when it has none
+func (c *Client) ClaimBenchmarkJob(ctx context.Context, agentID uuid.UUID) (*BenchmarkJob, error) {
+	var job *BenchmarkJob
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/benchmarks/next", nil, &job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ReportBenchmarkJob reports the speeds an agent measured for a benchmark job
+func (c *Client) ReportBenchmarkJob(ctx context.Context, agentID, jobID uuid.UUID, report BenchmarkReport) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/benchmarks/"+jobID.String()+"/report", report, nil)
+}
+
+// QueueAgentCommand queues a command for an agent, e.g.
+// domain.AgentCommandCollectDiagnostics. params may be nil.
+func (c *Client) QueueAgentCommand(ctx context.Context, agentID uuid.UUID, commandType string, params json.RawMessage) (*AgentCommand, error) {
+	body := domain.AgentCommandRequest{Type: commandType, Params: params}
+	var command AgentCommand
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/commands", body, &command); err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
+
+// ListAgentCommands returns an agent's latest commands, newest first
+func (c *Client) ListAgentCommands(ctx context.Context, agentID uuid.UUID) ([]AgentCommand, error) {
+	var commands []AgentCommand
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/commands", nil, &commands); err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// GetAgentCommand returns one of an agent's commands with its result
+func (c *Client) GetAgentCommand(ctx context.Context, agentID, commandID uuid.UUID) (*AgentCommand, error) {
+	var command AgentCommand
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/commands/"+commandID.String(), nil, &command); err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
+
+// CancelAgentCommand withdraws a command the agent hasn't acknowledged yet
+func (c *Client) CancelAgentCommand(ctx context.Context, agentID, commandID uuid.UUID) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/agents/"+agentID.String()+"/commands/"+commandID.String(), nil, nil)
+}
+
+// PendingAgentCommands returns the commands waiting for an agent, oldest
+// first
+func (c *Client) PendingAgentCommands(ctx context.Context, agentID uuid.UUID) ([]AgentCommand, error) {
+	var commands []AgentCommand
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/agents/"+agentID.String()+"/commands/pending", nil, &commands); err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// AcknowledgeAgentCommand tells the server an agent is running a command.
+// It fails when the command was already acknowledged, cancelled or expired.
+func (c *Client) AcknowledgeAgentCommand(ctx context.Context, agentID, commandID uuid.UUID) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/commands/"+commandID.String()+"/ack", nil, nil)
+}
+
+// ReportAgentCommandResult reports the outcome of a command an agent ran
+func (c *Client) ReportAgentCommandResult(ctx context.Context, agentID, commandID uuid.UUID, result AgentCommandResult) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/commands/"+commandID.String()+"/result", result, nil)
+}
+
+// Heartbeat sends an agent's heartbeat
+func (c *Client) Heartbeat(ctx context.Context, req HeartbeatRequest) (*HeartbeatResponse, error) {
+	var resp HeartbeatResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/heartbeat", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SyncAgent tells the server what a reconnected agent is running
+func (c *Client) SyncAgent(ctx context.Context, agentKey string, snapshot *AgentHeartbeat) (*AgentSyncResult, error) {
+	body := struct {
+		AgentKey string          `json:"agent_key"`
+		Snapshot *AgentHeartbeat `json:"snapshot"`
+	}{agentKey, snapshot}
+
+	var result AgentSyncResult
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/agents/sync", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RegisterAgentFiles reports the files in an agent's upload directory,
+// keyed by file name
+func (c *Client) RegisterAgentFiles(ctx context.Context, agentID uuid.UUID, files map[string]LocalFile) error {
+	body := struct {
+		AgentID uuid.UUID            `json:"agent_id"`
+		Files   map[string]LocalFile `json:"files"`
+	}{agentID, files}
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/files", body, nil)
+}
+
+// PushAgentLogs ships log lines of an agent
+func (c *Client) PushAgentLogs(ctx context.Context, agentID uuid.UUID, lines []AgentLogLine) error {
+	body := struct {
+		Lines []AgentLogLine `json:"lines"`
+	}{lines}
+	return c.Do(ctx, http.MethodPost, "/api/v1/agents/"+agentID.String()+"/logs", body, nil)
+}
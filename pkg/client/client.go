@@ -0,0 +1,266 @@
+// Package client is a Go client for the distributed hashcat server's REST
+// API. It is used by the agent and hashcatctl, and can be imported by other
+// tools that drive the server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is sent in the User-Agent header. Release builds set it with
+// -ldflags "-X go-distributed-hashcat/pkg/client.Version=v1.2.3".
+var Version = "dev"
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultRetries   = 2
+	defaultRetryWait = 500 * time.Millisecond
+	maxRetryWait     = 10 * time.Second
+)
+
+// Client calls the server's REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL   string
+	token     string
+	userAgent string
+	http      *http.Client
+	retries   int
+	retryWait time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken sends token as a bearer token with every request
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to add tracing or
+// change the timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.http = httpClient }
+}
+
+// WithUserAgent names the program using the client, e.g. "hashcat-agent".
+// The client version is appended.
+func WithUserAgent(product string) Option {
+	return func(c *Client) { c.userAgent = product + " go-distributed-hashcat-client/" + Version }
+}
+
+// WithRetries sets how often idempotent requests are retried when the
+// server can't be reached or answers 429 or 5xx. 0 disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) { c.retries = retries }
+}
+
+// New returns a client for the server at baseURL, e.g. http://localhost:1337
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: "go-distributed-hashcat-client/" + Version,
+		http:      &http.Client{Timeout: defaultTimeout},
+		retries:   defaultRetries,
+		retryWait: defaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the server URL the client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// APIError is a request the server answered with an error status
+type APIError struct {
+	StatusCode int
+	Message    string        // The server's error message, if it sent one
+	RetryAfter time.Duration // From the Retry-After header, 0 when absent
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("server returned %d", e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed when sent again
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsTemporary reports whether err may go away on retry: the server couldn't
+// be reached or answered 429 or 5xx
+func IsTemporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+// IsNotFound reports whether the server answered 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// envelope mirrors the {"data": ..., "error": ...} responses of the handlers
+type envelope struct {
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+}
+
+// Do sends a JSON request and decodes the response's data into out, which
+// may be nil. body is marshaled unless it is nil; a json.RawMessage is sent
+// as is. Use it for endpoints without a typed method.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.sendWithRetry(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeData(resp.Body, out)
+}
+
+// decodeData decodes the data of a response envelope into out, if not nil
+func decodeData(body io.Reader, out interface{}) error {
+	var data envelope
+	if err := json.NewDecoder(body).Decode(&data); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if out != nil && len(data.Data) > 0 {
+		if err := json.Unmarshal(data.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendWithRetry sends the request, retrying idempotent ones with a growing
+// delay. The response has a 2xx status; anything else is an *APIError.
+func (c *Client) sendWithRetry(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	attempts := 1
+	if idempotent(method) && c.retries > 0 {
+		attempts += c.retries
+	}
+
+	wait := c.retryWait
+	for attempt := 1; ; attempt++ {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		req, err := c.newRequest(ctx, method, path, body)
+		if err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.send(req)
+		if err == nil || attempt == attempts || !IsTemporary(err) {
+			return resp, err
+		}
+
+		delay := wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// send does one round trip with the client's HTTP client
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	return c.sendWith(c.http, req)
+}
+
+// sendWith does one round trip. Error statuses are turned into an *APIError
+// and their body is closed.
+func (c *Client) sendWith(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Path, err)
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var data envelope
+	if json.Unmarshal(raw, &data) == nil && data.Error != "" {
+		apiErr.Message = data.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	return nil, apiErr
+}
+
+// idempotent reports whether a request with method can be sent twice
+// without side effects
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
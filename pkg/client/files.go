@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type (
+	Wordlist = domain.Wordlist
+	HashFile = domain.HashFile
+)
+
+// Kinds of files agents download
+const (
+	FileWordlist = "wordlist"
+	FileHashFile = "hash_file"
+	FileCharset  = "charset"
+)
+
+// Download is a file being downloaded from the server
+type Download struct {
+	Name string // From Content-Disposition, the file's ID when absent
+	Size int64  // -1 when unknown
+	Body io.ReadCloser
+}
+
+// ListWordlists returns the uploaded wordlists
+func (c *Client) ListWordlists(ctx context.Context) ([]Wordlist, error) {
+	var wordlists []Wordlist
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/wordlists/", nil, &wordlists); err != nil {
+		return nil, err
+	}
+	return wordlists, nil
+}
+
+// UploadWordlist streams content to the server as a wordlist called name.
+// It isn't retried, as content can't be read twice.
+func (c *Client) UploadWordlist(ctx context.Context, name string, content io.Reader) (*Wordlist, error) {
+	var wordlist Wordlist
+	if err := c.upload(ctx, "/api/v1/wordlists/upload", name, content, &wordlist); err != nil {
+		return nil, err
+	}
+	return &wordlist, nil
+}
+
+// UploadHashFile streams content to the server as a hash file called name.
+// It isn't retried, as content can't be read twice.
+func (c *Client) UploadHashFile(ctx context.Context, name string, content io.Reader) (*HashFile, error) {
+	var hashFile HashFile
+	if err := c.upload(ctx, "/api/v1/hashfiles/upload", name, content, &hashFile); err != nil {
+		return nil, err
+	}
+	return &hashFile, nil
+}
+
+func (c *Client) upload(ctx context.Context, path, name string, content io.Reader, out interface{}) error {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		part, err := writer.CreateFormFile("file", filepath.Base(name))
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, content); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, pr)
+	if err != nil {
+		pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// Large files take longer than the request timeout
+	resp, err := c.sendWith(c.withoutTimeout(), req)
+	pr.Close()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeData(resp.Body, out)
+}
+
+// Download starts downloading a wordlist, hash file or charset. The body is
+// read without the client's request timeout, as large files outlive it. A
+// server busy serving the file to other agents answers with an *APIError
+// with status 503 and RetryAfter set.
+func (c *Client) Download(ctx context.Context, kind string, id uuid.UUID) (*Download, error) {
+	return c.download(ctx, kind, id, "")
+}
+
+// DownloadForAgent is Download for an agent, which names itself so the
+// server hands it the loopback wordlists its jobs attack with
+func (c *Client) DownloadForAgent(ctx context.Context, agentID uuid.UUID, kind string, id uuid.UUID) (*Download, error) {
+	return c.download(ctx, kind, id, "?agent_id="+agentID.String())
+}
+
+func (c *Client) download(ctx context.Context, kind string, id uuid.UUID, query string) (*Download, error) {
+	endpoint := "wordlists"
+	switch kind {
+	case FileHashFile:
+		endpoint = "hashfiles"
+	case FileCharset:
+		endpoint = "charsets"
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/%s/%s/download%s", endpoint, id, query), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.sendWith(c.withoutTimeout(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	download := &Download{Name: id.String(), Size: resp.ContentLength, Body: resp.Body}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		download.Name = filepath.Base(params["filename"])
+	}
+	return download, nil
+}
+
+// withoutTimeout returns the HTTP client without its request timeout
+func (c *Client) withoutTimeout() *http.Client {
+	httpClient := *c.http
+	httpClient.Timeout = 0
+	return &httpClient
+}
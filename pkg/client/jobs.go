@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type (
+	Job               = domain.Job
+	CreateJobRequest  = domain.CreateJobRequest
+	JobOutput         = domain.JobOutput
+	JobComment        = domain.JobComment
+	RevealedResult    = domain.RevealedResult
+	JobPlaybook       = domain.JobPlaybook
+	JobPlaybookResult = domain.JobPlaybookResult
+)
+
+// JobSummary is a job as listed by GET /api/v1/jobs, with the names of its
+// agent and wordlist resolved
+type JobSummary struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	HashType     int      `json:"hash_type"`
+	AttackMode   int      `json:"attack_mode"`
+	AgentName    string   `json:"agent_name"`
+	WordlistName string   `json:"wordlist_name"`
+	Progress     float64  `json:"progress"`
+	Speed        int64    `json:"speed"`
+	ETA          string   `json:"eta"`
+	Result       string   `json:"result"`
+	Tags         []string `json:"tags"`
+}
+
+// ListJobs returns the jobs with status, or all of them when it is empty
+func (c *Client) ListJobs(ctx context.Context, status string) ([]JobSummary, error) {
+	path := "/api/v1/jobs/"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	var jobs []JobSummary
+	if err := c.Do(ctx, http.MethodGet, path, nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJob returns a job
+func (c *Client) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
+	var job Job
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/jobs/"+jobID.String(), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CreateJob creates a job
+func (c *Client) CreateJob(ctx context.Context, req CreateJobRequest) (*Job, error) {
+	var job Job
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/jobs/", req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ApplyJobs queues the attacks of a playbook, or with dryRun only resolves
+// them. projectID may be empty.
+func (c *Client) ApplyJobs(ctx context.Context, playbook *JobPlaybook, projectID string, dryRun bool) (*JobPlaybookResult, error) {
+	query := url.Values{}
+	if projectID != "" {
+		query.Set("project_id", projectID)
+	}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+	path := "/api/v1/jobs/apply"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var result JobPlaybookResult
+	if err := c.Do(ctx, http.MethodPost, path, playbook, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetJobTags replaces a job's tags and returns them as the server stored them
+func (c *Client) SetJobTags(ctx context.Context, jobID uuid.UUID, tags []string) ([]string, error) {
+	var resp struct {
+		Tags []string `json:"tags"`
+	}
+	body := domain.UpdateJobTagsRequest{Tags: tags}
+	if err := c.Do(ctx, http.MethodPut, "/api/v1/jobs/"+jobID.String()+"/tags", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// GetJobComments returns the comments on a job, oldest first
+func (c *Client) GetJobComments(ctx context.Context, jobID uuid.UUID) ([]JobComment, error) {
+	var comments []JobComment
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/comments", nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// AddJobComment comments on a job as the client's logged-in user
+func (c *Client) AddJobComment(ctx context.Context, jobID uuid.UUID, body string) (*JobComment, error) {
+	var comment JobComment
+	req := domain.CreateJobCommentRequest{Body: body}
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/comments", req, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// RevealJobResult returns a job's result with the cracked password in the
+// clear. The server logs every reveal and refuses users without the
+// permission.
+func (c *Client) RevealJobResult(ctx context.Context, jobID uuid.UUID) (*RevealedResult, error) {
+	var revealed RevealedResult
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/result", nil, &revealed); err != nil {
+		return nil, err
+	}
+	return &revealed, nil
+}
+
+// StopJob stops a running or pending job
+func (c *Client) StopJob(ctx context.Context, jobID uuid.UUID) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/stop", nil, nil)
+}
+
+// AssignJobs has the server's scheduler hand pending jobs to online agents
+func (c *Client) AssignJobs(ctx context.Context) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/jobs/assign", nil, nil)
+}
+
+// GetAvailableJob returns the job assigned to an agent, or nil when it has
+// none
+func (c *Client) GetAvailableJob(ctx context.Context, agentID uuid.UUID) (*Job, error) {
+	var job *Job
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/jobs/agent/"+agentID.String(), nil, &job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// StartJob tells the server an agent started working on a job
+func (c *Client) StartJob(ctx context.Context, jobID uuid.UUID) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/start", nil, nil)
+}
+
+// UpdateProgress reports a running job's progress in percent and speed in H/s
+func (c *Client) UpdateProgress(ctx context.Context, jobID uuid.UUID, progress float64, speed int64) error {
+	body := struct {
+		Progress float64 `json:"progress"`
+		Speed    int64   `json:"speed"`
+	}{progress, speed}
+	return c.Do(ctx, http.MethodPut, "/api/v1/jobs/"+jobID.String()+"/progress", body, nil)
+}
+
+// CompleteJob reports a finished job and what it cracked
+func (c *Client) CompleteJob(ctx context.Context, jobID uuid.UUID, result string, output *JobOutput) error {
+	body := struct {
+		Result string     `json:"result"`
+		Output *JobOutput `json:"output,omitempty"`
+	}{result, output}
+	return c.Do(ctx, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/complete", body, nil)
+}
+
+// FailJob reports a job that couldn't be run
+func (c *Client) FailJob(ctx context.Context, jobID uuid.UUID, reason string, output *JobOutput) error {
+	body := struct {
+		Reason string     `json:"reason"`
+		Output *JobOutput `json:"output,omitempty"`
+	}{reason, output}
+	return c.Do(ctx, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/fail", body, nil)
+}
+
+// DownloadCheckpoint returns a job's hashcat restore file. The caller
+// closes it.
+func (c *Client) DownloadCheckpoint(ctx context.Context, jobID uuid.UUID) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/checkpoint", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
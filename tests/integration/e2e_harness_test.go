@@ -0,0 +1,225 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// e2eHashcatEnv points the end-to-end tests at a hashcat binary other than
+// the one on PATH
+const e2eHashcatEnv = "HASHCAT_E2E_HASHCAT"
+
+// e2eHarness runs the real server and agent binaries against each other.
+// Everything lives in a temporary directory and is stopped when the test
+// ends; the output of both processes is logged if the test fails.
+type e2eHarness struct {
+	t       *testing.T
+	root    string // Module root, where the binaries are built from
+	dir     string
+	hashcat string
+
+	serverURL string
+	api       *client.Client
+}
+
+// newE2EHarness builds the server and agent and starts the server. The test
+// is skipped in -short mode and when hashcat is missing or finds no device
+// to crack on.
+func newE2EHarness(t *testing.T) *e2eHarness {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("end-to-end test skipped in -short mode")
+	}
+	hashcat := requireHashcat(t)
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	require.NoError(t, err)
+	h := &e2eHarness{t: t, root: root, dir: t.TempDir(), hashcat: hashcat}
+	h.build("server", "./cmd/server")
+	h.build("agent", "./cmd/agent")
+	h.startServer()
+	return h
+}
+
+// requireHashcat returns the hashcat binary, skipping the test when there
+// is none or it can't list a compute device
+func requireHashcat(t *testing.T) string {
+	t.Helper()
+	hashcat := os.Getenv(e2eHashcatEnv)
+	if hashcat == "" {
+		hashcat = "hashcat"
+	}
+	path, err := exec.LookPath(hashcat)
+	if err != nil {
+		t.Skipf("hashcat not found (set %s to its path): %v", e2eHashcatEnv, err)
+	}
+	if out, err := exec.Command(path, "-I").CombinedOutput(); err != nil {
+		t.Skipf("hashcat has no usable device: %v\n%s", err, out)
+	}
+	return path
+}
+
+func (h *e2eHarness) build(name, pkg string) {
+	h.t.Helper()
+	cmd := exec.Command("go", "build", "-o", filepath.Join(h.dir, "bin", name), pkg)
+	cmd.Dir = h.root
+	out, err := cmd.CombinedOutput()
+	require.NoError(h.t, err, "building %s failed:\n%s", pkg, out)
+}
+
+// startServer runs the server on a free port with a fresh database and
+// waits until it answers
+func (h *e2eHarness) startServer() {
+	port := freePort(h.t)
+	h.serverURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.NoError(h.t, os.MkdirAll(filepath.Join(h.dir, "server"), 0o755))
+	// The server runs from the module root, where its migrations are
+	h.start("server", "server", h.root, []string{
+		"HASHCAT_SERVER_HOST=127.0.0.1",
+		fmt.Sprintf("HASHCAT_SERVER_PORT=%d", port),
+		"HASHCAT_DATABASE_PATH=" + filepath.Join(h.dir, "server", "hashcat.db"),
+		"HASHCAT_UPLOAD_DIRECTORY=" + filepath.Join(h.dir, "server", "uploads"),
+		// Results are asserted on, so they must not be masked
+		"HASHCAT_RESULTS_REDACT=false",
+		"HASHCAT_HEARTBEAT_INTERVAL_SECONDS=1",
+	})
+
+	h.api = client.New(h.serverURL, client.WithUserAgent("e2e-test"))
+	h.eventually(30*time.Second, "the server to come up", func() bool {
+		resp, err := http.Get(h.serverURL + "/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+}
+
+// startAgent registers a key for an agent and runs the agent binary with it
+func (h *e2eHarness) startAgent(name string) *client.Agent {
+	h.t.Helper()
+	key := fmt.Sprintf("e2e-%d", time.Now().UnixNano())
+	agent, err := h.api.GenerateAgentKey(context.Background(), name, key)
+	require.NoError(h.t, err)
+
+	dir := filepath.Join(h.dir, name)
+	// hashcat keeps its kernel cache and sessions under HOME
+	h.start(name, "agent", dir, []string{"HOME=" + dir},
+		"--server", h.serverURL,
+		"--name", name,
+		"--agent-key", key,
+		"--capabilities", "CPU",
+		"--container", "off",
+		"--port", fmt.Sprint(freePort(h.t)),
+		"--upload-dir", filepath.Join(dir, "uploads"),
+		"--hashcat-path", h.hashcat,
+		"--poll-interval", "1s",
+		"--status-interval", "1s",
+		"--log-ship-interval", "0",
+	)
+	return agent
+}
+
+// start runs binary from the harness directory in workDir until the test
+// ends, then stops it with SIGTERM so it shuts down as it would in service.
+// Its output is logged as name's.
+func (h *e2eHarness) start(name, binary, workDir string, env []string, args ...string) {
+	h.t.Helper()
+	require.NoError(h.t, os.MkdirAll(workDir, 0o755))
+
+	output := &lockedBuffer{}
+	cmd := exec.Command(filepath.Join(h.dir, "bin", binary), args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout, cmd.Stderr = output, output
+	require.NoError(h.t, cmd.Start())
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	h.t.Cleanup(func() {
+		cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(15 * time.Second):
+			cmd.Process.Kill()
+			<-done
+		}
+		if h.t.Failed() {
+			h.t.Logf("output of %s:\n%s", name, output.String())
+		}
+	})
+}
+
+// getAgent returns the agent as the server has it
+func (h *e2eHarness) getAgent(ctx context.Context, id uuid.UUID) *client.Agent {
+	h.t.Helper()
+	var agent client.Agent
+	require.NoError(h.t, h.api.Do(ctx, http.MethodGet, "/api/v1/agents/"+id.String(), nil, &agent))
+	return &agent
+}
+
+// openFixture opens a file of testdata/e2e
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", "e2e", name))
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+// eventually polls condition until it holds, failing the test after timeout
+func (h *e2eHarness) eventually(timeout time.Duration, what string, condition func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// lockedBuffer collects the output of a process while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// e2ePassword is the password of the fixture's hash, word 42 of its
+	// 100-word list
+	e2ePassword      = "correcthorsebattery"
+	e2ePasswordIndex = 41
+)
+
+// TestEndToEndWithHashcat runs the real server and agent with hashcat
+// through a job's whole life: the agent registers, a distributed job gives
+// it the part of the wordlist holding the password and another agent the
+// rest, the agent cracks the hash, the server records the credential and
+// stops the other agent's part.
+func TestEndToEndWithHashcat(t *testing.T) {
+	h := newE2EHarness(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hashFile, err := h.api.UploadHashFile(ctx, "e2e-hashes.txt", openFixture(t, "hashes.txt"))
+	require.NoError(t, err)
+	wordlist, err := h.api.UploadWordlist(ctx, "e2e-wordlist.txt", openFixture(t, "wordlist.txt"))
+	require.NoError(t, err)
+
+	// Register: the agent comes online with the capabilities it was given
+	agent := h.startAgent("e2e-agent")
+	h.eventually(time.Minute, "the agent to come online", func() bool {
+		current := h.getAgent(ctx, agent.ID)
+		return current.Status == "online" && current.Capabilities == "CPU"
+	})
+
+	// The other agent is played by the test. It never runs its part, so
+	// only the coordination stop can end it; at 1 H/s it gets the smallest
+	// part and the real agent the one holding the password.
+	standby, err := h.api.GenerateAgentKey(ctx, "e2e-standby", "e2e-standby-key")
+	require.NoError(t, err)
+	require.NoError(t, h.api.UpdateAgentData(ctx, client.UpdateAgentDataRequest{AgentKey: "e2e-standby-key", IPAddress: "192.0.2.10", Port: 8080, Capabilities: "CPU"}))
+	require.NoError(t, h.api.UpdateAgentStatus(ctx, standby.ID, "online"))
+	require.NoError(t, h.api.UpdateAgentSpeed(ctx, standby.ID, 1))
+	go func() {
+		snapshot := &client.AgentHeartbeat{FreeDiskBytes: 100 << 30, HashcatAvailable: true, Engines: []string{domain.EngineHashcat}}
+		for {
+			h.api.Heartbeat(ctx, client.HeartbeatRequest{AgentKey: "e2e-standby-key", Snapshot: snapshot})
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	// Assign
+	var distributed domain.DistributedJobResult
+	err = h.api.Do(ctx, http.MethodPost, "/api/v1/distributed-jobs/", domain.DistributedJobRequest{
+		Name:       "e2e",
+		HashType:   0,
+		AttackMode: domain.AttackModeStraight,
+		HashFileID: hashFile.ID.String(),
+		WordlistID: wordlist.ID.String(),
+		AgentIDs:   []string{agent.ID.String(), standby.ID.String()},
+	}, &distributed)
+	require.NoError(t, err)
+	require.Len(t, distributed.SubJobs, 2)
+
+	var part, standbyPart domain.Job
+	for _, job := range distributed.SubJobs {
+		require.NotNil(t, job.AgentID)
+		if *job.AgentID == agent.ID {
+			part = job
+		} else {
+			standbyPart = job
+		}
+	}
+	require.NotNil(t, part.Skip)
+	require.NotNil(t, part.WordLimit)
+	require.True(t, *part.Skip <= e2ePasswordIndex && e2ePasswordIndex < *part.Skip+*part.WordLimit,
+		"the password must be in the agent's part, words %d to %d", *part.Skip, *part.Skip+*part.WordLimit)
+
+	// Crack
+	var cracked *client.Job
+	h.eventually(3*time.Minute, "the agent to finish its part", func() bool {
+		cracked, err = h.api.GetJob(ctx, part.ID)
+		return err == nil && domain.IsTerminalJobStatus(cracked.Status)
+	})
+	require.Equal(t, domain.JobStatusCracked, cracked.Status, "result: %s", cracked.Result)
+	assert.Equal(t, "Password found: "+e2ePassword, cracked.Result)
+
+	var events []domain.JobEvent
+	require.NoError(t, h.api.Do(ctx, http.MethodGet, "/api/v1/jobs/"+part.ID.String()+"/events", nil, &events))
+	var statuses []string
+	for _, event := range events {
+		statuses = append(statuses, event.ToStatus)
+	}
+	assert.Subset(t, statuses, []string{domain.JobStatusAssigned, domain.JobStatusRunning, domain.JobStatusCracked})
+
+	// Potfile: the crack is recorded as the hash's credential
+	var credentials []domain.Credential
+	require.NoError(t, h.api.Do(ctx, http.MethodGet, "/api/v1/credentials/?hash_file_id="+hashFile.ID.String(), nil, &credentials))
+	require.Len(t, credentials, 1)
+	sum := md5.Sum([]byte(e2ePassword))
+	assert.Equal(t, hex.EncodeToString(sum[:]), strings.ToLower(credentials[0].Hash))
+	assert.Equal(t, e2ePassword, credentials[0].Plaintext)
+	assert.Equal(t, &part.ID, credentials[0].JobID)
+
+	// Coordination stop: the other part is cancelled, the agent is free
+	stopped, err := h.api.GetJob(ctx, standbyPart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusCancelled, stopped.Status)
+	assert.True(t, strings.HasPrefix(stopped.Result, "Password found by another agent"), "result: %s", stopped.Result)
+
+	h.eventually(30*time.Second, "the agent to be online again", func() bool {
+		return h.getAgent(ctx, agent.ID).Status == "online"
+	})
+}
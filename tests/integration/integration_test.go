@@ -71,7 +71,7 @@ func (suite *APITestSuite) SetupTest() {
 	jobUsecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo)
 
 	// Initialize enrichment service for integration tests
-	jobEnrichmentService := usecase.NewJobEnrichmentService(agentRepo, wordlistRepo, hashFileRepo)
+	jobEnrichmentService := usecase.NewJobEnrichmentService(usecase.NewLookupCache(agentRepo, wordlistRepo, hashFileRepo, usecase.DefaultLookupCacheTTL))
 
 	// Initialize handlers
 	suite.agentHandler = handler.NewAgentHandler(agentUsecase)
@@ -0,0 +1,102 @@
+package backupstore_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/backupstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves one bucket with path-style addressing, listing one object
+// per page so List has to follow continuation tokens
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/backups/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		var keys []string
+		for name := range s.objects {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) && name > r.URL.Query().Get("continuation-token") {
+				keys = append(keys, name)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, "<ListBucketResult>")
+		if len(keys) > 0 {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", keys[0])
+		}
+		if len(keys) > 1 {
+			fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[0])
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[key] = string(body)
+	case r.Method == http.MethodGet:
+		body, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	bucket := &fakeS3{objects: map[string]string{"other/file": "not ours"}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	store, err := backupstore.NewS3Store(backupstore.S3Config{Bucket: "backups", Prefix: "hashcat/", Region: "eu-central-1",
+		AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: server.URL + "/"})
+	require.NoError(t, err)
+
+	for _, key := range []string{"20261015T020000Z/hashcat.db", "20261015T020000Z/manifest.json", "20261016T020000Z/manifest.json"} {
+		require.NoError(t, store.Put(ctx, key, strings.NewReader("content of "+key), int64(len("content of "+key))))
+	}
+	assert.Equal(t, "content of 20261015T020000Z/hashcat.db", bucket.objects["hashcat/20261015T020000Z/hashcat.db"])
+
+	keys, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20261015T020000Z/hashcat.db", "20261015T020000Z/manifest.json", "20261016T020000Z/manifest.json"}, keys)
+
+	reader, err := store.Get(ctx, "20261015T020000Z/manifest.json")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "content of 20261015T020000Z/manifest.json", string(content))
+
+	require.NoError(t, store.Delete(ctx, "20261015T020000Z/manifest.json"))
+	_, err = store.Get(ctx, "20261015T020000Z/manifest.json")
+	assert.True(t, domain.IsNotFoundError(err))
+
+	_, err = backupstore.NewS3Store(backupstore.S3Config{Bucket: "backups", Region: "eu-central-1", AccessKeyID: "AKID"})
+	assert.Error(t, err, "a secret is required")
+}
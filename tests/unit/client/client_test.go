@@ -0,0 +1,142 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-distributed-hashcat/pkg/client"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_TypedCalls(t *testing.T) {
+	jobID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("User-Agent"), "hashcatctl go-distributed-hashcat-client/"))
+
+		switch r.URL.Path {
+		case "/api/v1/jobs/" + jobID.String():
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"id": jobID, "name": "wpa", "status": "running"}})
+		case "/api/v1/jobs/agent/" + jobID.String():
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": nil, "message": "No available jobs"})
+		case "/api/v1/agents/heartbeat":
+			var req client.HeartbeatRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "key-1", req.AgentKey)
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"heartbeat_interval_seconds": 15}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Job not found"})
+		}
+	}))
+	defer server.Close()
+
+	api := client.New(server.URL+"/", client.WithToken("secret"), client.WithUserAgent("hashcatctl"))
+	ctx := context.Background()
+
+	job, err := api.GetJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, "wpa", job.Name)
+	assert.Equal(t, "running", job.Status)
+
+	// An agent without work gets nil, not an error
+	next, err := api.GetAvailableJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+
+	heartbeat, err := api.Heartbeat(ctx, client.HeartbeatRequest{AgentKey: "key-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 15, heartbeat.IntervalSeconds)
+
+	_, err = api.GetJob(ctx, uuid.New())
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "Job not found", apiErr.Message)
+	assert.True(t, client.IsNotFound(err))
+	assert.False(t, client.IsTemporary(err))
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first two attempts fail
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// Idempotent requests are retried
+	_, err := client.New(server.URL).ListJobs(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Others are not, the server may have acted on them
+	calls.Store(0)
+	err = client.New(server.URL).StartJob(ctx, uuid.New())
+	require.Error(t, err)
+	assert.True(t, client.IsTemporary(err))
+	assert.Equal(t, int32(1), calls.Load())
+
+	calls.Store(0)
+	_, err = client.New(server.URL, client.WithRetries(0)).ListJobs(ctx, "")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_Download(t *testing.T) {
+	var busy atomic.Bool
+	busy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/api/v1/hashfiles/"))
+		if busy.Load() {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="capture.hccapx"`)
+		io.WriteString(w, "hash data")
+	}))
+	defer server.Close()
+
+	api := client.New(server.URL)
+	_, err := api.Download(context.Background(), client.FileHashFile, uuid.New())
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 7.0, apiErr.RetryAfter.Seconds())
+
+	busy.Store(false)
+	download, err := api.Download(context.Background(), client.FileHashFile, uuid.New())
+	require.NoError(t, err)
+	defer download.Body.Close()
+	assert.Equal(t, "capture.hccapx", download.Name)
+	data, err := io.ReadAll(download.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hash data", string(data))
+
+	// Agents name themselves
+	agentID := uuid.New()
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/api/v1/wordlists/"))
+		assert.Equal(t, agentID.String(), r.URL.Query().Get("agent_id"))
+		io.WriteString(w, "words")
+	}))
+	defer agentServer.Close()
+	download, err = client.New(agentServer.URL).DownloadForAgent(context.Background(), agentID, client.FileWordlist, uuid.New())
+	require.NoError(t, err)
+	download.Body.Close()
+}
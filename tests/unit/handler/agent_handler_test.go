@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-distributed-hashcat/internal/delivery/http/handler"
 	"go-distributed-hashcat/internal/domain"
@@ -111,14 +112,183 @@ func (m *MockAgentUsecase) UpdateAgent(ctx context.Context, agent *domain.Agent)
 	return args.Error(0)
 }
 
-func (m *MockAgentUsecase) GenerateAgentKey(ctx context.Context, name string) (*domain.Agent, error) {
-	args := m.Called(ctx, name)
+func (m *MockAgentUsecase) GenerateAgentKey(ctx context.Context, name, agentKey string) (*domain.Agent, error) {
+	args := m.Called(ctx, name, agentKey)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Agent), args.Error(1)
 }
 
+func (m *MockAgentUsecase) UpdateAgentCacheReport(ctx context.Context, id uuid.UUID, report *domain.AgentCacheReport) error {
+	args := m.Called(ctx, id, report)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetAgentCacheReport(ctx context.Context, id uuid.UUID) (*domain.AgentCacheReport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentCacheReport), args.Error(1)
+}
+
+func (m *MockAgentUsecase) ReplaceAgentFiles(ctx context.Context, id uuid.UUID, files []domain.AgentFile) error {
+	args := m.Called(ctx, id, files)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetAgentFiles(ctx context.Context, id uuid.UUID) ([]domain.AgentFile, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AgentFile), args.Error(1)
+}
+
+func (m *MockAgentUsecase) GetAgentHashcatArgs(ctx context.Context, id uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAgentUsecase) SetAgentHashcatArgs(ctx context.Context, id uuid.UUID, hashcatArgs []string) error {
+	args := m.Called(ctx, id, hashcatArgs)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetAgentSettings(ctx context.Context, id uuid.UUID) (*domain.AgentSettings, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentSettings), args.Error(1)
+}
+
+func (m *MockAgentUsecase) SetAgentSettings(ctx context.Context, id uuid.UUID, settings *domain.AgentSettings) error {
+	args := m.Called(ctx, id, settings)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) SetHeartbeatConfig(config usecase.HeartbeatConfig) {
+	m.Called(config)
+}
+
+func (m *MockAgentUsecase) AcceptAgentHeartbeat(ctx context.Context, id uuid.UUID, requested time.Duration, heartbeat *domain.AgentHeartbeat) time.Duration {
+	args := m.Called(ctx, id, requested, heartbeat)
+	return args.Get(0).(time.Duration)
+}
+
+func (m *MockAgentUsecase) AgentHeartbeatInterval(id uuid.UUID) time.Duration {
+	args := m.Called(id)
+	return args.Get(0).(time.Duration)
+}
+
+func (m *MockAgentUsecase) FlushAgentHeartbeats(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) RunHeartbeatFlusher(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockAgentUsecase) SetAgentLogRetention(lines int) {
+	m.Called(lines)
+}
+
+func (m *MockAgentUsecase) AppendAgentLogs(ctx context.Context, id uuid.UUID, lines []domain.AgentLogLine) error {
+	args := m.Called(ctx, id, lines)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetAgentLogs(ctx context.Context, id uuid.UUID, afterID int64, limit int) ([]domain.AgentLogLine, error) {
+	args := m.Called(ctx, id, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AgentLogLine), args.Error(1)
+}
+
+func (m *MockAgentUsecase) SetAgentRetention(policy domain.AgentRetentionPolicy) error {
+	args := m.Called(policy)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetStaleAgents(ctx context.Context, olderThan time.Duration) ([]domain.StaleAgent, error) {
+	args := m.Called(ctx, olderThan)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StaleAgent), args.Error(1)
+}
+
+func (m *MockAgentUsecase) PurgeStaleAgents(ctx context.Context, req *domain.PurgeStaleAgentsRequest) (*domain.PurgeStaleAgentsResult, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PurgeStaleAgentsResult), args.Error(1)
+}
+
+func (m *MockAgentUsecase) CreateAgentGroup(ctx context.Context, req *domain.CreateAgentGroupRequest) (*domain.AgentGroupSummary, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentGroupSummary), args.Error(1)
+}
+
+func (m *MockAgentUsecase) GetAgentGroup(ctx context.Context, id uuid.UUID) (*domain.AgentGroupSummary, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentGroupSummary), args.Error(1)
+}
+
+func (m *MockAgentUsecase) GetAllAgentGroups(ctx context.Context) ([]domain.AgentGroupSummary, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.AgentGroupSummary), args.Error(1)
+}
+
+func (m *MockAgentUsecase) DeleteAgentGroup(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) AddAgentToGroup(ctx context.Context, groupID, agentID uuid.UUID) error {
+	args := m.Called(ctx, groupID, agentID)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) RemoveAgentFromGroup(ctx context.Context, groupID, agentID uuid.UUID) error {
+	args := m.Called(ctx, groupID, agentID)
+	return args.Error(0)
+}
+
+func (m *MockAgentUsecase) GetOnlineGroupAgents(ctx context.Context, groupID uuid.UUID) ([]domain.Agent, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Agent), args.Error(1)
+}
+
+func (m *MockAgentUsecase) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar) {
+	m.Called(calendar)
+}
+
+func (m *MockAgentUsecase) SetJobLeaser(leaser domain.JobLeaser) {
+	m.Called(leaser)
+}
+
+func (m *MockAgentUsecase) SetLookupCache(lookups usecase.LookupCache) {
+	m.Called(lookups)
+}
+
 func (m *MockAgentUsecase) UpdateAgentData(ctx context.Context, agentKey string, ipAddress string, port int, capabilities string) error {
 	args := m.Called(ctx, agentKey, ipAddress, port, capabilities)
 	return args.Error(0)
@@ -727,3 +897,185 @@ func TestAgentHandler_HeartbeatAgent(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentHandler_AgentHeartbeat(t *testing.T) {
+	agent := &domain.Agent{ID: uuid.New(), Name: "gpu-1", Status: "online", AgentKey: "abcd1234"}
+
+	mockUsecase := new(MockAgentUsecase)
+	mockUsecase.On("GetByAgentKey", mock.Anything, "abcd1234").Return(agent, nil)
+	mockUsecase.On("AcceptAgentHeartbeat", mock.Anything, agent.ID, 15*time.Second, mock.MatchedBy(func(heartbeat *domain.AgentHeartbeat) bool {
+		return heartbeat != nil && heartbeat.HashcatAvailable
+	})).Return(15 * time.Second)
+	mockUsecase.On("GetAgentSettings", mock.Anything, agent.ID).Return(&domain.AgentSettings{WorkloadProfile: 3}, nil)
+
+	handler := handler.NewAgentHandler(mockUsecase)
+	router := setupTestRouter()
+	router.POST("/agents/heartbeat", handler.AgentHeartbeat)
+
+	body := `{"agent_key":"abcd1234","heartbeat_interval_seconds":15,"snapshot":{"hashcat_available":true}}`
+	req, err := http.NewRequest("POST", "/agents/heartbeat", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			IntervalSeconds int                   `json:"heartbeat_interval_seconds"`
+			Settings        *domain.AgentSettings `json:"settings"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 15, response.Data.IntervalSeconds)
+	// Settings changed on the server ride along
+	assert.Equal(t, &domain.AgentSettings{WorkloadProfile: 3}, response.Data.Settings)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestAgentHandler_AgentLogs(t *testing.T) {
+	agentID := uuid.New()
+
+	mockUsecase := new(MockAgentUsecase)
+	mockUsecase.On("AppendAgentLogs", mock.Anything, agentID, mock.MatchedBy(func(lines []domain.AgentLogLine) bool {
+		return len(lines) == 1 && lines[0].Source == "hashcat" && lines[0].Message == "Session started"
+	})).Return(nil)
+	mockUsecase.On("GetAgentLogs", mock.Anything, agentID, int64(41), 10).Return([]domain.AgentLogLine{{ID: 42, AgentID: agentID, Message: "Session started"}}, nil)
+
+	handler := handler.NewAgentHandler(mockUsecase)
+	router := setupTestRouter()
+	router.POST("/agents/:id/logs", handler.PushAgentLogs)
+	router.GET("/agents/:id/logs", handler.GetAgentLogs)
+
+	body := `{"lines":[{"source":"hashcat","message":"Session started"}]}`
+	req, _ := http.NewRequest("POST", "/agents/"+agentID.String()+"/logs", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/agents/"+agentID.String()+"/logs?after=41&limit=10", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":42`)
+
+	req, _ = http.NewRequest("GET", "/agents/"+agentID.String()+"/logs?after=abc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestAgentHandler_HashcatArgs(t *testing.T) {
+	agentID := uuid.New()
+
+	mockUsecase := new(MockAgentUsecase)
+	mockUsecase.On("SetAgentHashcatArgs", mock.Anything, agentID, []string{"-O", "-n", "32"}).Return(nil)
+	mockUsecase.On("SetAgentHashcatArgs", mock.Anything, agentID, []string{"--outfile", "/tmp/x"}).
+		Return(&domain.ValidationError{Field: "args", Message: `option "--outfile" is not allowed`})
+	mockUsecase.On("GetAgentHashcatArgs", mock.Anything, agentID).Return([]string{"-O", "-n", "32"}, nil)
+
+	handler := handler.NewAgentHandler(mockUsecase)
+	router := setupTestRouter()
+	router.GET("/agents/:id/hashcat-args", handler.GetAgentHashcatArgs)
+	router.PUT("/agents/:id/hashcat-args", handler.SetAgentHashcatArgs)
+
+	req, _ := http.NewRequest("PUT", "/agents/"+agentID.String()+"/hashcat-args", bytes.NewBufferString(`{"args":["-O","-n","32"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("PUT", "/agents/"+agentID.String()+"/hashcat-args", bytes.NewBufferString(`{"args":["--outfile","/tmp/x"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/agents/"+agentID.String()+"/hashcat-args", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"args":["-O","-n","32"]}}`, w.Body.String())
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestAgentHandler_AgentGroups(t *testing.T) {
+	groupID := uuid.New()
+	agentID := uuid.New()
+
+	newRouter := func(mockUsecase *MockAgentUsecase) *gin.Engine {
+		handler := handler.NewAgentHandler(mockUsecase)
+		router := setupTestRouter()
+		router.POST("/agent-groups", handler.CreateAgentGroup)
+		router.GET("/agent-groups/:id", handler.GetAgentGroup)
+		router.POST("/agent-groups/:id/agents", handler.AddAgentToGroup)
+		router.DELETE("/agent-groups/:id/agents/:agentId", handler.RemoveAgentFromGroup)
+		return router
+	}
+
+	t.Run("create", func(t *testing.T) {
+		mockUsecase := new(MockAgentUsecase)
+		mockUsecase.On("CreateAgentGroup", mock.Anything, &domain.CreateAgentGroupRequest{Name: "cloud-burst"}).
+			Return(&domain.AgentGroupSummary{AgentGroup: domain.AgentGroup{ID: groupID, Name: "cloud-burst"}}, nil)
+
+		req, _ := http.NewRequest("POST", "/agent-groups", bytes.NewBufferString(`{"name":"cloud-burst"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newRouter(mockUsecase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), groupID.String())
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("create with duplicate name", func(t *testing.T) {
+		mockUsecase := new(MockAgentUsecase)
+		mockUsecase.On("CreateAgentGroup", mock.Anything, mock.Anything).
+			Return(nil, &domain.ValidationError{Field: "name", Message: "agent group 'cloud-burst' already exists"})
+
+		req, _ := http.NewRequest("POST", "/agent-groups", bytes.NewBufferString(`{"name":"cloud-burst"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		newRouter(mockUsecase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		mockUsecase := new(MockAgentUsecase)
+		mockUsecase.On("GetAgentGroup", mock.Anything, groupID).Return(nil, &domain.NotFoundError{Entity: "agent group"})
+
+		req, _ := http.NewRequest("GET", "/agent-groups/"+groupID.String(), nil)
+		w := httptest.NewRecorder()
+		newRouter(mockUsecase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("add and remove a member", func(t *testing.T) {
+		mockUsecase := new(MockAgentUsecase)
+		mockUsecase.On("AddAgentToGroup", mock.Anything, groupID, agentID).Return(nil)
+		mockUsecase.On("GetAgentGroup", mock.Anything, groupID).
+			Return(&domain.AgentGroupSummary{AgentGroup: domain.AgentGroup{ID: groupID}, TotalAgents: 1, AgentIDs: []uuid.UUID{agentID}}, nil)
+		mockUsecase.On("RemoveAgentFromGroup", mock.Anything, groupID, agentID).Return(nil)
+		router := newRouter(mockUsecase)
+
+		req, _ := http.NewRequest("POST", "/agent-groups/"+groupID.String()+"/agents", bytes.NewBufferString(`{"agent_id":"`+agentID.String()+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), agentID.String())
+
+		req, _ = http.NewRequest("DELETE", "/agent-groups/"+groupID.String()+"/agents/"+agentID.String(), nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
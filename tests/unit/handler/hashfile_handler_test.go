@@ -14,6 +14,7 @@ import (
 
 	"go-distributed-hashcat/internal/delivery/http/handler"
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -25,8 +26,8 @@ type MockHashFileUsecase struct {
 	mock.Mock
 }
 
-func (m *MockHashFileUsecase) UploadHashFile(ctx context.Context, name string, content io.Reader, size int64) (*domain.HashFile, error) {
-	args := m.Called(ctx, name, content, size)
+func (m *MockHashFileUsecase) UploadHashFile(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.HashFile, error) {
+	args := m.Called(ctx, name, content, size, projectID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -41,16 +42,62 @@ func (m *MockHashFileUsecase) GetHashFile(ctx context.Context, id uuid.UUID) (*d
 	return args.Get(0).(*domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileUsecase) GetHashFileBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HashFile), args.Error(1)
+}
+
 func (m *MockHashFileUsecase) GetAllHashFiles(ctx context.Context) ([]domain.HashFile, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileUsecase) GetHashFilesByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.HashFile), args.Error(1)
+}
+
 func (m *MockHashFileUsecase) DeleteHashFile(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockHashFileUsecase) SetHashFileSource(ctx context.Context, id uuid.UUID, source string) (*domain.HashFile, error) {
+	args := m.Called(ctx, id, source)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HashFile), args.Error(1)
+}
+
+func (m *MockHashFileUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	m.Called(checker)
+}
+
+func (m *MockHashFileUsecase) SetLookupCache(lookups usecase.LookupCache) {
+	m.Called(lookups)
+}
+
+func (m *MockHashFileUsecase) SetFileEncryptor(encryptor domain.FileEncryptor) {
+	m.Called(encryptor)
+}
+
+func (m *MockHashFileUsecase) OpenHashFile(ctx context.Context, id uuid.UUID) (*domain.HashFile, io.ReadCloser, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.HashFile), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockHashFileUsecase) EncryptStoredHashFiles(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func TestHashFileHandler_UploadHashFile(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -88,7 +135,7 @@ func TestHashFileHandler_UploadHashFile(t *testing.T) {
 					OrigName: "test.hash",
 					Size:     33,
 				}
-				mockUsecase.On("UploadHashFile", mock.Anything, "test.hash", mock.Anything, mock.AnythingOfType("int64")).Return(expectedHashFile, nil)
+				mockUsecase.On("UploadHashFile", mock.Anything, "test.hash", mock.Anything, mock.AnythingOfType("int64"), (*uuid.UUID)(nil)).Return(expectedHashFile, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -138,7 +185,7 @@ func TestHashFileHandler_UploadHashFile(t *testing.T) {
 				return req, nil
 			},
 			mockSetup: func(mockUsecase *MockHashFileUsecase) {
-				mockUsecase.On("UploadHashFile", mock.Anything, "test.hash", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("failed to save file"))
+				mockUsecase.On("UploadHashFile", mock.Anything, "test.hash", mock.Anything, mock.AnythingOfType("int64"), (*uuid.UUID)(nil)).Return(nil, errors.New("failed to save file"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
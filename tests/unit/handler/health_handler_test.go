@@ -0,0 +1,59 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler_Probes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbErr := errors.New("database is locked")
+	var failing bool
+
+	h := handler.NewHealthHandler(
+		handler.HealthCheck{Name: "database", Check: func(ctx context.Context) error {
+			if failing {
+				return dbErr
+			}
+			return nil
+		}},
+		handler.HubCheck(handler.GetHub()),
+	)
+	router := gin.New()
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+
+	probe := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := probe("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+	assert.Equal(t, map[string]interface{}{"database": "ok", "websocket_hub": "ok"}, body["checks"])
+
+	// A failing dependency fails readiness but not liveness
+	failing = true
+	code, body = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", body["status"])
+	assert.Equal(t, "database is locked", body["checks"].(map[string]interface{})["database"])
+
+	code, body = probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+}
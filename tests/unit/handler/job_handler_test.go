@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-distributed-hashcat/internal/delivery/http/handler"
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
 
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -125,16 +128,42 @@ func (m *MockWordlistRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistRepository) GetBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistRepository) GetAll(ctx context.Context) ([]domain.Wordlist, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockWordlistRepository) GetLoopback(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
+func (m *MockWordlistRepository) UpdateContent(ctx context.Context, wordlist *domain.Wordlist) error {
+	args := m.Called(ctx, wordlist)
+	return args.Error(0)
+}
+
 // MockHashFileRepository for testing
 type MockHashFileRepository struct {
 	mock.Mock
@@ -153,11 +182,29 @@ func (m *MockHashFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileRepository) GetBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HashFile), args.Error(1)
+}
+
 func (m *MockHashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.HashFile), args.Error(1)
+}
+
+func (m *MockHashFileRepository) SetSource(ctx context.Context, id uuid.UUID, source string) error {
+	args := m.Called(ctx, id, source)
+	return args.Error(0)
+}
+
 func (m *MockHashFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -203,6 +250,11 @@ func (m *MockJobUsecase) GetAllJobs(ctx context.Context) ([]domain.Job, error) {
 	return args.Get(0).([]domain.Job), args.Error(1)
 }
 
+func (m *MockJobUsecase) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Job), args.Int(1), args.Error(2)
+}
+
 func (m *MockJobUsecase) GetJobsByStatus(ctx context.Context, status string) ([]domain.Job, error) {
 	args := m.Called(ctx, status)
 	return args.Get(0).([]domain.Job), args.Error(1)
@@ -266,6 +318,269 @@ func (m *MockJobUsecase) UpdateJobData(ctx context.Context, job *domain.Job) err
 	return args.Error(0)
 }
 
+func (m *MockJobUsecase) GetArchivedJobs(ctx context.Context) ([]domain.Job, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobUsecase) RestoreJob(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) CancelJob(ctx context.Context, id uuid.UUID, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) GetJobEvents(ctx context.Context, id uuid.UUID) ([]domain.JobEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.JobEvent), args.Error(1)
+}
+
+func (m *MockJobUsecase) SetJobTags(ctx context.Context, id uuid.UUID, tags []string) ([]string, error) {
+	args := m.Called(ctx, id, tags)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockJobUsecase) AddJobComment(ctx context.Context, id uuid.UUID, comment *domain.JobComment) error {
+	args := m.Called(ctx, id, comment)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) GetJobComments(ctx context.Context, id uuid.UUID) ([]domain.JobComment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.JobComment), args.Error(1)
+}
+
+func (m *MockJobUsecase) DeleteJobComment(ctx context.Context, id, commentID uuid.UUID) error {
+	args := m.Called(ctx, id, commentID)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) SaveJobOutput(ctx context.Context, id uuid.UUID, output *domain.JobOutput) error {
+	args := m.Called(ctx, id, output)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) GetJobOutput(ctx context.Context, id uuid.UUID) (*domain.JobOutput, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobOutput), args.Error(1)
+}
+
+func (m *MockJobUsecase) InterruptJob(ctx context.Context, id uuid.UUID, req *domain.InterruptJobRequest) error {
+	args := m.Called(ctx, id, req)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) ReconcileAgentJobs(ctx context.Context, agentID uuid.UUID, snapshot *domain.AgentHeartbeat) (*domain.AgentSyncResult, error) {
+	args := m.Called(ctx, agentID, snapshot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentSyncResult), args.Error(1)
+}
+
+func (m *MockJobUsecase) SetJobLease(lease time.Duration) {
+	m.Called(lease)
+}
+
+func (m *MockJobUsecase) RenewJobLeases(ctx context.Context, agentIDs []uuid.UUID) error {
+	args := m.Called(ctx, agentIDs)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) ExpireJobLeases(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobUsecase) RunLeaseSweeper(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockJobUsecase) SetJobTimeouts(timeouts domain.JobTimeouts) {
+	m.Called(timeouts)
+}
+
+func (m *MockJobUsecase) ExpireStalledJobs(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobUsecase) ExpireJobsPastDeadline(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobUsecase) ExpireJobsOverBudget(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobUsecase) RunWatchdog(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockJobUsecase) ApplyRuntimeStats(ctx context.Context, job *domain.Job, stats *domain.JobRuntimeStats) {
+	m.Called(ctx, job, stats)
+}
+
+func (m *MockJobUsecase) RecordJobProgress(ctx context.Context, job *domain.Job) {
+	m.Called(ctx, job)
+}
+
+func (m *MockJobUsecase) FlushJobProgress(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockJobUsecase) RunProgressFlusher(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockJobUsecase) SetProgressFlush(interval time.Duration, threshold int) {
+	m.Called(interval, threshold)
+}
+
+func (m *MockJobUsecase) GetJobCheckpoint(ctx context.Context, id uuid.UUID) (*domain.JobCheckpoint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobCheckpoint), args.Error(1)
+}
+
+func (m *MockJobUsecase) RecordJobCracks(ctx context.Context, id, agentID uuid.UUID, reports []domain.JobCrackReport) ([]domain.JobCrack, error) {
+	args := m.Called(ctx, id, agentID, reports)
+	return args.Get(0).([]domain.JobCrack), args.Error(1)
+}
+
+func (m *MockJobUsecase) GetJobCracks(ctx context.Context, id uuid.UUID) (*domain.JobCracks, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobCracks), args.Error(1)
+}
+
+func (m *MockJobUsecase) SetCrackedPasswordSink(sink usecase.CrackedPasswordSink) {
+	m.Called(sink)
+}
+
+func (m *MockJobUsecase) SetCredentialRecorder(recorder usecase.CredentialRecorder) {
+	m.Called(recorder)
+}
+
+func (m *MockJobUsecase) SetEffectivenessRecorder(recorder usecase.EffectivenessRecorder) {
+	m.Called(recorder)
+}
+
+func (m *MockJobUsecase) AccountJobUsage(job *domain.Job, sample domain.UsageSample) {
+	m.Called(job, sample)
+}
+
+func (m *MockJobUsecase) SetCostRates(rates domain.CostRates) {
+	m.Called(rates)
+}
+
+func (m *MockJobUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	m.Called(checker)
+}
+
+func (m *MockJobUsecase) SetMaintenanceCalendar(calendar domain.MaintenanceCalendar) {
+	m.Called(calendar)
+}
+
+func (m *MockJobUsecase) SetLookupCache(lookups usecase.LookupCache) {
+	m.Called(lookups)
+}
+
+func (m *MockJobUsecase) SetBenchmarks(benchmarks domain.AgentBenchmarkRepository) {
+	m.Called(benchmarks)
+}
+
+func (m *MockJobUsecase) DrainAgent(ctx context.Context, agentID uuid.UUID, reason string) (int, error) {
+	args := m.Called(ctx, agentID, reason)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobUsecase) EstimateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.JobEstimate, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobEstimate), args.Error(1)
+}
+
+func (m *MockJobUsecase) CompareJobs(ctx context.Context, ids []uuid.UUID) (*domain.JobComparison, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobComparison), args.Error(1)
+}
+
+func (m *MockJobUsecase) EstimatePendingJob(ctx context.Context, job *domain.Job) (*domain.JobEstimate, error) {
+	args := m.Called(ctx, job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobEstimate), args.Error(1)
+}
+
+func (m *MockJobUsecase) RetryJob(ctx context.Context, id uuid.UUID, agentID *uuid.UUID) (*domain.Job, error) {
+	args := m.Called(ctx, id, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockJobUsecase) ApplyJobPlaybook(ctx context.Context, playbook *domain.JobPlaybook, projectID string, dryRun bool) (*domain.JobPlaybookResult, error) {
+	args := m.Called(ctx, playbook, projectID, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobPlaybookResult), args.Error(1)
+}
+
+func (m *MockJobUsecase) CreateJobGroup(ctx context.Context, name string) (*domain.JobGroup, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobGroup), args.Error(1)
+}
+
+func (m *MockJobUsecase) GetJobGroup(ctx context.Context, id uuid.UUID) (*domain.JobGroup, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobGroup), args.Error(1)
+}
+
+func (m *MockJobUsecase) GetJobGroupStatus(ctx context.Context, id uuid.UUID) (*domain.JobGroupStatus, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobGroupStatus), args.Error(1)
+}
+
 func TestJobHandler_CreateJob(t *testing.T) {
 	hashFileID := uuid.New()
 
@@ -357,6 +672,38 @@ func TestJobHandler_CreateJob(t *testing.T) {
 				assert.Contains(t, response["error"], "hash file not found")
 			},
 		},
+		{
+			name: "too many running jobs",
+			requestBody: map[string]interface{}{
+				"name":         "test-job",
+				"hash_file_id": hashFileID.String(),
+				"wordlist":     "rockyou.txt",
+			},
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("CreateJob", mock.Anything, mock.AnythingOfType("*domain.CreateJobRequest")).Return(nil,
+					&domain.QuotaExceededError{Scope: domain.QuotaScopeUser, Resource: domain.QuotaRunningJobs, Limit: 2, Used: 2})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Contains(t, w.Body.String(), "user quota exceeded: 2 of 2 running jobs")
+			},
+		},
+		{
+			name: "device-hours used up",
+			requestBody: map[string]interface{}{
+				"name":         "test-job",
+				"hash_file_id": hashFileID.String(),
+				"wordlist":     "rockyou.txt",
+			},
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("CreateJob", mock.Anything, mock.AnythingOfType("*domain.CreateJobRequest")).Return(nil,
+					&domain.QuotaExceededError{Scope: domain.QuotaScopeProject, Resource: domain.QuotaDeviceHours, Limit: 100, Used: 100.5})
+			},
+			expectedStatus: http.StatusForbidden,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Contains(t, w.Body.String(), "project quota exceeded")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -391,6 +738,69 @@ func TestJobHandler_CreateJob(t *testing.T) {
 	}
 }
 
+func TestJobHandler_CreateJobDryRun(t *testing.T) {
+	hashFileID := uuid.New()
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":         "test-job",
+		"hash_type":    0,
+		"attack_mode":  3,
+		"hash_file_id": hashFileID.String(),
+		"wordlist":     "?d?d?d?d",
+	})
+
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockJobUsecase)
+		expectedStatus int
+	}{
+		{
+			name: "estimate without creating the job",
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("EstimateJob", mock.Anything, mock.AnythingOfType("*domain.CreateJobRequest")).
+					Return(&domain.JobEstimate{Keyspace: 10000, Speed: 1000, DurationSeconds: 10}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid configuration",
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("EstimateJob", mock.Anything, mock.Anything).
+					Return(nil, &domain.ValidationError{Field: "wordlist", Message: "invalid mask"})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockJobUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+			router := setupTestRouter()
+			router.POST("/jobs", handler.CreateJob)
+
+			req, err := http.NewRequest("POST", "/jobs?dry_run=true", bytes.NewBuffer(body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, true, data["dry_run"])
+				assert.Equal(t, float64(10), data["estimate"].(map[string]interface{})["duration_seconds"])
+			}
+			mockUsecase.AssertExpectations(t)
+			mockUsecase.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestJobHandler_GetJob(t *testing.T) {
 	jobID := uuid.New()
 
@@ -411,6 +821,7 @@ func TestJobHandler_GetJob(t *testing.T) {
 					Status: "running",
 				}
 				mockUsecase.On("GetJob", mock.Anything, jobID).Return(expectedJob, nil)
+				mockUsecase.On("EstimatePendingJob", mock.Anything, expectedJob).Return(nil, nil)
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -420,6 +831,24 @@ func TestJobHandler_GetJob(t *testing.T) {
 				data := response["data"].(map[string]interface{})
 				assert.Equal(t, jobID.String(), data["id"])
 				assert.Equal(t, "test-job", data["name"])
+				assert.NotContains(t, data, "estimate")
+			},
+		},
+		{
+			name:  "pending job with estimate",
+			jobID: jobID.String(),
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				expectedJob := &domain.Job{ID: jobID, Name: "test-job", Status: domain.JobStatusPending}
+				mockUsecase.On("GetJob", mock.Anything, jobID).Return(expectedJob, nil)
+				mockUsecase.On("EstimatePendingJob", mock.Anything, expectedJob).Return(&domain.JobEstimate{Keyspace: 1000, Speed: 100, DurationSeconds: 10}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				estimate := response["data"].(map[string]interface{})["estimate"].(map[string]interface{})
+				assert.Equal(t, float64(10), estimate["duration_seconds"])
 			},
 		},
 		{
@@ -477,6 +906,7 @@ func TestJobHandler_GetJob(t *testing.T) {
 func TestJobHandler_GetAllJobs(t *testing.T) {
 	tests := []struct {
 		name           string
+		query          string
 		mockSetup      func(*MockJobUsecase, *MockJobEnrichmentService)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
@@ -504,7 +934,7 @@ func TestJobHandler_GetAllJobs(t *testing.T) {
 						Job: jobs[1],
 					},
 				}
-				mockUsecase.On("GetAllJobs", mock.Anything).Return(jobs, nil)
+				mockUsecase.On("ListJobs", mock.Anything, mock.AnythingOfType("domain.JobFilter")).Return(jobs, len(jobs), nil)
 				mockEnrichment.On("EnrichJobs", mock.Anything, jobs).Return(enrichedJobs, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -518,6 +948,7 @@ func TestJobHandler_GetAllJobs(t *testing.T) {
 				job2 := data[1].(map[string]interface{})
 				assert.Equal(t, "job-1", job1["name"])
 				assert.Equal(t, "job-2", job2["name"])
+				assert.Equal(t, float64(2), response["total"])
 			},
 		},
 		{
@@ -525,7 +956,7 @@ func TestJobHandler_GetAllJobs(t *testing.T) {
 			mockSetup: func(mockUsecase *MockJobUsecase, mockEnrichment *MockJobEnrichmentService) {
 				jobs := []domain.Job{}
 				enrichedJobs := []domain.EnrichedJob{}
-				mockUsecase.On("GetAllJobs", mock.Anything).Return(jobs, nil)
+				mockUsecase.On("ListJobs", mock.Anything, mock.AnythingOfType("domain.JobFilter")).Return(jobs, len(jobs), nil)
 				mockEnrichment.On("EnrichJobs", mock.Anything, jobs).Return(enrichedJobs, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -537,10 +968,37 @@ func TestJobHandler_GetAllJobs(t *testing.T) {
 				assert.Len(t, data, 0)
 			},
 		},
+		{
+			name:  "filters and pagination are passed to the usecase",
+			query: "?status=running&hash_type=2500&page=3&page_size=20&sort=progress&order=asc",
+			mockSetup: func(mockUsecase *MockJobUsecase, mockEnrichment *MockJobEnrichmentService) {
+				jobs := []domain.Job{}
+				mockUsecase.On("ListJobs", mock.Anything, mock.MatchedBy(func(f domain.JobFilter) bool {
+					return f.Status == "running" && f.HashType != nil && *f.HashType == 2500 &&
+						f.Limit == 20 && f.Offset == 40 && f.SortBy == "progress" && !f.SortDesc
+				})).Return(jobs, 45, nil)
+				mockEnrichment.On("EnrichJobs", mock.Anything, jobs).Return([]domain.EnrichedJob{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, float64(45), response["total"])
+				assert.Equal(t, float64(3), response["page"])
+			},
+		},
+		{
+			name:           "invalid sort column",
+			query:          "?sort=password",
+			mockSetup:      func(mockUsecase *MockJobUsecase, mockEnrichment *MockJobEnrichmentService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse:  func(t *testing.T, w *httptest.ResponseRecorder) {},
+		},
 		{
 			name: "usecase error",
 			mockSetup: func(mockUsecase *MockJobUsecase, mockEnrichment *MockJobEnrichmentService) {
-				mockUsecase.On("GetAllJobs", mock.Anything).Return([]domain.Job{}, errors.New("database error"))
+				mockUsecase.On("ListJobs", mock.Anything, mock.AnythingOfType("domain.JobFilter")).Return([]domain.Job{}, 0, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -562,7 +1020,7 @@ func TestJobHandler_GetAllJobs(t *testing.T) {
 			router := setupTestRouter()
 			router.GET("/jobs", handler.GetAllJobs)
 
-			req, err := http.NewRequest("GET", "/jobs", nil)
+			req, err := http.NewRequest("GET", "/jobs"+tt.query, nil)
 			assert.NoError(t, err)
 
 			w := httptest.NewRecorder()
@@ -727,3 +1185,340 @@ func TestJobHandler_DeleteJob(t *testing.T) {
 		})
 	}
 }
+
+func TestJobHandler_RetryJob(t *testing.T) {
+	jobID := uuid.New()
+	agentID := uuid.New()
+	retryID := uuid.New()
+
+	tests := []struct {
+		name           string
+		jobID          string
+		body           string
+		mockSetup      func(*MockJobUsecase)
+		expectedStatus int
+	}{
+		{
+			name:  "retry on the same agent",
+			jobID: jobID.String(),
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("RetryJob", mock.Anything, jobID, (*uuid.UUID)(nil)).
+					Return(&domain.Job{ID: retryID, Status: "pending", RetriedFrom: &jobID}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:  "retry on a different agent",
+			jobID: jobID.String(),
+			body:  `{"agent_id":"` + agentID.String() + `"}`,
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("RetryJob", mock.Anything, jobID, &agentID).
+					Return(&domain.Job{ID: retryID, Status: "running", AgentID: &agentID, RetriedFrom: &jobID}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid agent ID",
+			jobID:          jobID.String(),
+			body:           `{"agent_id":"not-a-uuid"}`,
+			mockSetup:      func(mockUsecase *MockJobUsecase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "job still running",
+			jobID: jobID.String(),
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("RetryJob", mock.Anything, jobID, (*uuid.UUID)(nil)).
+					Return(nil, &domain.ValidationError{Field: "status", Message: "only failed or cancelled jobs can be retried"})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "job not found",
+			jobID: jobID.String(),
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("RetryJob", mock.Anything, jobID, (*uuid.UUID)(nil)).
+					Return(nil, &domain.NotFoundError{Entity: "job"})
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockJobUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+			router := setupTestRouter()
+			router.POST("/jobs/:id/retry", handler.RetryJob)
+
+			req, err := http.NewRequest("POST", "/jobs/"+tt.jobID+"/retry", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var response map[string]map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, jobID.String(), response["data"]["retried_from"])
+			}
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobHandler_StopJob(t *testing.T) {
+	jobID := uuid.New()
+
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockJobUsecase)
+		expectedStatus int
+	}{
+		{
+			name: "running job is cancelled",
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("CancelJob", mock.Anything, jobID, "Job stopped by user").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "finished job can't be stopped",
+			mockSetup: func(mockUsecase *MockJobUsecase) {
+				mockUsecase.On("CancelJob", mock.Anything, jobID, "Job stopped by user").
+					Return(&domain.JobTransitionError{From: domain.JobStatusCompleted, To: domain.JobStatusCancelled})
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockJobUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+			router := setupTestRouter()
+			router.POST("/jobs/:id/stop", handler.StopJob)
+
+			req, err := http.NewRequest("POST", "/jobs/"+jobID.String()+"/stop", nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobHandler_GetJobEvents(t *testing.T) {
+	jobID := uuid.New()
+	mockUsecase := new(MockJobUsecase)
+	mockUsecase.On("GetJobEvents", mock.Anything, jobID).Return([]domain.JobEvent{
+		{JobID: jobID, ToStatus: domain.JobStatusPending, Actor: domain.ActorAPI, Reason: "created"},
+		{JobID: jobID, FromStatus: domain.JobStatusPending, ToStatus: domain.JobStatusRunning, Actor: domain.ActorAgent},
+	}, nil)
+
+	handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+	router := setupTestRouter()
+	router.GET("/jobs/:id/events", handler.GetJobEvents)
+
+	req, err := http.NewRequest("GET", "/jobs/"+jobID.String()+"/events", nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []domain.JobEvent `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, domain.JobStatusRunning, response.Data[1].ToStatus)
+}
+
+func TestJobHandler_JobComments(t *testing.T) {
+	jobID, userID := uuid.New(), uuid.New()
+	mockUsecase := new(MockJobUsecase)
+	mockUsecase.On("AddJobComment", mock.Anything, jobID, mock.MatchedBy(func(comment *domain.JobComment) bool {
+		return comment.Author == "alice" && comment.AuthorID != nil && *comment.AuthorID == userID && comment.Body == "Retest next week"
+	})).Return(nil)
+	mockUsecase.On("SetJobTags", mock.Anything, jobID, []string{"client-x", " retest "}).Return([]string{"client-x", "retest"}, nil)
+
+	handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+	router := setupTestRouter()
+	// Stands in for the auth middleware
+	router.POST("/jobs/:id/comments", func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Set("username", "alice")
+	}, handler.CreateJobComment)
+	router.PUT("/jobs/:id/tags", handler.SetJobTags)
+
+	req, err := http.NewRequest("POST", "/jobs/"+jobID.String()+"/comments", bytes.NewBufferString(`{"body":"Retest next week"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req, err = http.NewRequest("PUT", "/jobs/"+jobID.String()+"/tags", bytes.NewBufferString(`{"tags":["client-x"," retest "]}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"tags":["client-x","retest"]`)
+
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestJobHandler_ResultRedaction(t *testing.T) {
+	jobID := uuid.New()
+	job := &domain.Job{ID: jobID, Name: "test-job", Status: domain.JobStatusCracked, Result: "Password found: hunter2"}
+	mockUsecase := new(MockJobUsecase)
+	mockUsecase.On("GetJob", mock.Anything, jobID).Return(job, nil)
+	mockUsecase.On("EstimatePendingJob", mock.Anything, job).Return(nil, nil)
+	mockUsecase.On("GetArchivedJobs", mock.Anything).Return([]domain.Job{
+		{ID: uuid.New(), Status: domain.JobStatusCracked, Result: "Password found: hunter2"},
+		{ID: uuid.New(), Status: domain.JobStatusFailed, Result: "hashcat exited with code 255"},
+	}, nil)
+
+	handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+	handler.SetResultRedaction(true)
+	router := setupTestRouter()
+	router.GET("/jobs/archived", handler.GetArchivedJobs)
+	router.GET("/jobs/:id", handler.GetJob)
+
+	req, err := http.NewRequest("GET", "/jobs/"+jobID.String(), nil)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"result":"Password found: ********"`)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+
+	req, err = http.NewRequest("GET", "/jobs/archived", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+	assert.Contains(t, w.Body.String(), "hashcat exited with code 255", "results without a password are shown")
+}
+
+func TestJobHandler_FailJobWithOutput(t *testing.T) {
+	jobID := uuid.New()
+	mockUsecase := new(MockJobUsecase)
+	mockUsecase.On("GetJob", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Name: "Test Job", Status: domain.JobStatusRunning}, nil)
+	mockUsecase.On("SaveJobOutput", mock.Anything, jobID, mock.MatchedBy(func(output *domain.JobOutput) bool {
+		return output.ExitCode != nil && *output.ExitCode == 255 && output.Stderr == "No hashes loaded.\n"
+	})).Return(errors.New("database is locked"))
+	mockUsecase.On("FailJob", mock.Anything, jobID, "Password not found").Return(nil)
+
+	handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+	router := setupTestRouter()
+	router.POST("/jobs/:id/fail", handler.FailJob)
+
+	body := `{"reason":"Password not found","output":{"exit_code":255,"stdout":"","stderr":"No hashes loaded.\n"}}`
+	req, err := http.NewRequest("POST", "/jobs/"+jobID.String()+"/fail", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The failure is recorded even when the output can't be stored
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestJobHandler_GetJobOutput(t *testing.T) {
+	jobID := uuid.New()
+	exitCode := 255
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockJobUsecase)
+		expectedStatus int
+	}{
+		{
+			name: "reported output",
+			setupMock: func(m *MockJobUsecase) {
+				m.On("GetJobOutput", mock.Anything, jobID).Return(&domain.JobOutput{JobID: jobID, ExitCode: &exitCode, Stderr: "No hashes loaded.\n"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "no output reported",
+			setupMock: func(m *MockJobUsecase) {
+				m.On("GetJobOutput", mock.Anything, jobID).Return(nil, &domain.NotFoundError{Entity: "job output"})
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockJobUsecase)
+			tt.setupMock(mockUsecase)
+
+			handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+			router := setupTestRouter()
+			router.GET("/jobs/:id/output", handler.GetJobOutput)
+
+			req, err := http.NewRequest("GET", "/jobs/"+jobID.String()+"/output", nil)
+			assert.NoError(t, err)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if w.Code == http.StatusOK {
+				var response struct {
+					Data domain.JobOutput `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 255, *response.Data.ExitCode)
+				assert.Equal(t, "No hashes loaded.\n", response.Data.Stderr)
+			}
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobHandler_InterruptJob(t *testing.T) {
+	jobID := uuid.New()
+	mockUsecase := new(MockJobUsecase)
+	mockUsecase.On("InterruptJob", mock.Anything, jobID, mock.MatchedBy(func(req *domain.InterruptJobRequest) bool {
+		return string(req.Checkpoint) == "restore" && req.HashcatVersion == "v6.2.6"
+	})).Return(nil)
+	mockUsecase.On("GetJob", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusAssigned}, nil)
+	mockUsecase.On("GetJobCheckpoint", mock.Anything, jobID).Return(&domain.JobCheckpoint{JobID: jobID, Data: []byte("restore")}, nil)
+
+	handler := handler.NewJobHandler(mockUsecase, nil, nil, nil)
+	router := setupTestRouter()
+	router.POST("/jobs/:id/interrupt", handler.InterruptJob)
+	router.GET("/jobs/:id/checkpoint", handler.GetJobCheckpoint)
+
+	// The checkpoint travels base64-encoded
+	body := `{"reason":"agent shut down","checkpoint":"cmVzdG9yZQ==","hashcat_version":"v6.2.6"}`
+	req, err := http.NewRequest("POST", "/jobs/"+jobID.String()+"/interrupt", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, err = http.NewRequest("GET", "/jobs/"+jobID.String()+"/checkpoint", nil)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "restore", w.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
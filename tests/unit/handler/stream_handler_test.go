@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamEvent struct {
+	name    string
+	message handler.WebSocketMessage
+}
+
+// readEvents parses the SSE stream into events until the body closes
+func readEvents(t *testing.T, resp *http.Response) <-chan streamEvent {
+	events := make(chan streamEvent, 16)
+	go func() {
+		defer close(events)
+		var name string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				var message handler.WebSocketMessage
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &message); err != nil {
+					t.Errorf("bad event data %q: %v", line, err)
+					return
+				}
+				events <- streamEvent{name: name, message: message}
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan streamEvent) streamEvent {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+		return streamEvent{}
+	}
+}
+
+func TestStreamHandler_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/stream", handler.NewStreamHandler().Stream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	t.Run("filters by topic and job", func(t *testing.T) {
+		jobID := uuid.New().String()
+		resp, err := http.Get(server.URL + "/api/v1/stream?topics=job_status&job_id=" + jobID)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		events := readEvents(t, resp)
+		assert.Equal(t, "connection", nextEvent(t, events).name)
+
+		// Events for other jobs and other topics are filtered out, so the
+		// first one through is the matching status
+		handler.Hub.BroadcastJobStatus(uuid.New().String(), "running", "")
+		handler.Hub.BroadcastJobProgress(jobID, 50, 50, 1000, "1m", "running", nil)
+		handler.Hub.BroadcastAgentSpeed(uuid.New().String(), 1000)
+		handler.Hub.BroadcastJobStatus(jobID, "completed", "cracked")
+
+		event := nextEvent(t, events)
+		require.Equal(t, "job_status", event.name)
+		assert.Equal(t, "job_status", event.message.Type)
+		assert.NotEmpty(t, event.message.Timestamp)
+		data := event.message.Data.(map[string]interface{})
+		assert.Equal(t, jobID, data["job_id"])
+		assert.Equal(t, "completed", data["status"])
+	})
+
+	t.Run("rejects unknown topics and bad IDs", func(t *testing.T) {
+		for _, query := range []string{"topics=job_status,nope", "job_id=42", "agent_id=x"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stream?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
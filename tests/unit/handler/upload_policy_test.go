@@ -0,0 +1,227 @@
+package handler_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubScanner reports every file infected with signature, or clean when
+// signature is empty
+type stubScanner struct {
+	signature string
+	scanned   string
+}
+
+func (s *stubScanner) Scan(ctx context.Context, content io.Reader) error {
+	data, _ := io.ReadAll(content)
+	s.scanned = string(data)
+	if s.signature != "" {
+		return &domain.InfectedFileError{Signature: s.signature}
+	}
+	return nil
+}
+
+func uploadRequest(t *testing.T, path, filename string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fw, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestSniffContentType(t *testing.T) {
+	tests := map[string]string{
+		"5d41402abc4b2a76b9719d911017c592\n":       handler.ContentTypeText,
+		"p\xe4ssw\xf6rd\n":                         handler.ContentTypeText,
+		"":                                         handler.ContentTypeText,
+		"HCPX\x04\x00\x00\x00\x00":                 handler.ContentTypeHccapx,
+		"\xd4\xc3\xb2\xa1\x02\x00\x04\x00":         handler.ContentTypePcap,
+		"\x0a\x0d\x0d\x0a\x1c\x00\x00\x00":         handler.ContentTypePcapng,
+		"\x7fELF\x02\x01\x01\x00\x00\x00":          "application/x-executable",
+		"MZ\x90\x00\x03\x00\x00\x00\x04\x00":       "application/x-msdownload",
+		"PK\x03\x04\x14\x00\x00\x00\x08\x00":       "application/zip",
+		"\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09": handler.ContentTypeBinary,
+	}
+	for content, want := range tests {
+		assert.Equal(t, want, handler.SniffContentType([]byte(content)), "%q", content)
+	}
+}
+
+func TestHashFileHandler_UploadPolicy(t *testing.T) {
+	policy := handler.UploadPolicy{
+		MaxSize:    64,
+		Extensions: []string{".txt", ".hash", ".hccapx"},
+		MIMETypes:  []string{handler.ContentTypeText, handler.ContentTypeHccapx},
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		status   int
+		code     string
+	}{
+		{"text hash file", "hashes.txt", []byte("5d41402abc4b2a76b9719d911017c592\n"), http.StatusCreated, ""},
+		{"hccapx capture", "wifi.HCCAPX", []byte("HCPX\x04\x00\x00\x00\x02"), http.StatusCreated, ""},
+		{"too large", "hashes.txt", bytes.Repeat([]byte("a"), 65), http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE"},
+		{"extension not allowed", "hashes.docx", []byte("5d41402abc4b2a76b9719d911017c592\n"), http.StatusUnsupportedMediaType, "FILE_TYPE_NOT_ALLOWED"},
+		{"executable renamed to text", "hashes.txt", []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00"), http.StatusUnsupportedMediaType, "FILE_TYPE_NOT_ALLOWED"},
+		{"text renamed to capture", "wifi.hccapx", []byte("5d41402abc4b2a76b9719d911017c592\n"), http.StatusUnsupportedMediaType, "FILE_CONTENT_MISMATCH"},
+		{"capture renamed to text", "hashes.hash", []byte("HCPX\x04\x00\x00\x00\x02"), http.StatusUnsupportedMediaType, "FILE_CONTENT_MISMATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockHashFileUsecase)
+			mockUsecase.On("UploadHashFile", mock.Anything, tt.filename, mock.Anything, int64(len(tt.content)), (*uuid.UUID)(nil)).
+				Return(&domain.HashFile{ID: uuid.New(), OrigName: tt.filename}, nil)
+
+			h := handler.NewHashFileHandler(mockUsecase)
+			h.SetUploadPolicy(policy, nil)
+			router := setupTestRouter()
+			router.POST("/hashfiles", h.UploadHashFile)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, uploadRequest(t, "/hashfiles", tt.filename, tt.content))
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.code != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.code, response["code"])
+				assert.NotEmpty(t, response["error"])
+				mockUsecase.AssertNotCalled(t, "UploadHashFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHashFileHandler_UploadOverBodyLimit(t *testing.T) {
+	mockUsecase := new(MockHashFileUsecase)
+	h := handler.NewHashFileHandler(mockUsecase)
+	h.SetUploadPolicy(handler.UploadPolicy{MaxSize: 16}, nil)
+	router := setupTestRouter()
+	router.POST("/hashfiles", h.UploadHashFile)
+
+	// Large enough that reading stops at the body limit, before the form is parsed
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "/hashfiles", "big.txt", bytes.Repeat([]byte("a"), 2<<20)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "FILE_TOO_LARGE")
+	mockUsecase.AssertNotCalled(t, "UploadHashFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWordlistHandler_UploadScanner(t *testing.T) {
+	content := []byte("password\n123456\n")
+
+	scanner := &stubScanner{}
+	mockUsecase := new(MockWordlistUsecase)
+	mockUsecase.On("UploadWordlist", mock.Anything, "words.txt", mock.Anything, int64(len(content)), (*uuid.UUID)(nil)).
+		Return(&domain.Wordlist{ID: uuid.New(), OrigName: "words.txt"}, nil)
+
+	h := handler.NewWordlistHandler(mockUsecase)
+	h.SetUploadPolicy(handler.UploadPolicy{Extensions: []string{".txt"}}, scanner)
+	router := setupTestRouter()
+	router.POST("/wordlists", h.UploadWordlist)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "/wordlists", "words.txt", content))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, string(content), scanner.scanned, "the scanner sees the whole file")
+
+	scanner.signature = "Eicar-Test-Signature"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "/wordlists", "words.txt", content))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "Eicar-Test-Signature")
+	mockUsecase.AssertNumberOfCalls(t, "UploadWordlist", 1)
+}
+
+// fakeClamd answers INSTREAM scans, finding signature in content that
+// contains marker
+func fakeClamd(t *testing.T, marker, signature string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if command, err := r.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+					return
+				}
+				var content bytes.Buffer
+				for {
+					var size [4]byte
+					if _, err := io.ReadFull(r, size[:]); err != nil {
+						return
+					}
+					n := int(size[0])<<24 | int(size[1])<<16 | int(size[2])<<8 | int(size[3])
+					if n == 0 {
+						break
+					}
+					if _, err := io.CopyN(&content, r, int64(n)); err != nil {
+						return
+					}
+				}
+				if strings.Contains(content.String(), marker) {
+					conn.Write([]byte("stream: " + signature + " FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	ctx := context.Background()
+	scanner := infrastructure.NewClamAVScanner("tcp://"+fakeClamd(t, "EICAR", "Eicar-Signature"), time.Second)
+
+	assert.NoError(t, scanner.Scan(ctx, strings.NewReader(strings.Repeat("clean\n", 20000))))
+
+	err := scanner.Scan(ctx, strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	var infected *domain.InfectedFileError
+	require.ErrorAs(t, err, &infected)
+	assert.Equal(t, "Eicar-Signature", infected.Signature)
+
+	// An unreachable clamd is an error, not a clean file
+	unreachable := infrastructure.NewClamAVScanner("127.0.0.1:1", time.Second)
+	err = unreachable.Scan(ctx, strings.NewReader("clean"))
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &infected))
+}
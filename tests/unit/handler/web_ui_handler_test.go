@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	files := fstest.MapFS{
+		"index.html":           {Data: []byte("<html>dashboard</html>")},
+		"assets/index-3f2a.js": {Data: []byte("console.log('ui')")},
+		"favicon.ico":          {Data: []byte("icon")},
+	}
+	router := gin.New()
+	router.GET("/api/v1/agents/", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	router.NoRoute(handler.NewWebUIHandler(files).Serve)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	for path, want := range map[string]string{
+		"/":           "<html>dashboard</html>",
+		"/index.html": "<html>dashboard</html>",
+		"/jobs/42":    "<html>dashboard</html>",
+	} {
+		w := serve(http.MethodGet, path)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, want, w.Body.String(), path)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"), path)
+	}
+
+	t.Run("assets are cached for good", func(t *testing.T) {
+		w := serve(http.MethodGet, "/assets/index-3f2a.js")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log('ui')", w.Body.String())
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+		w = serve(http.MethodGet, "/favicon.ico")
+		assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("missing files and API paths are not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/assets/gone.js").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/../../etc/passwd.txt").Code)
+
+		w := serve(http.MethodGet, "/api/v1/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"Not found"}`, w.Body.String())
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/jobs").Code)
+	})
+}
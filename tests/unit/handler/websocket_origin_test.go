@@ -0,0 +1,48 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketHandler_CheckOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cors := middleware.CORSConfig{AllowedOrigins: []string{"https://hashcat.example.com"}}
+	router := gin.New()
+	router.GET("/ws", handler.NewWebSocketHandler(cors.AllowsOrigin).HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	for origin, allowed := range map[string]bool{
+		"":                            true, // Not a browser
+		server.URL:                    true,
+		"https://hashcat.example.com": true,
+		"https://evil.example.com":    false,
+		"http://localhost:5173":       false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		if allowed {
+			require.NoError(t, err, origin)
+			conn.Close()
+			continue
+		}
+		assert.ErrorIs(t, err, websocket.ErrBadHandshake, origin)
+		require.NotNil(t, resp, origin)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, origin)
+	}
+}
@@ -0,0 +1,98 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackRelay is an in-memory channel that, like Redis, delivers every
+// published payload to all subscribers including the publisher
+type loopbackRelay struct {
+	published  chan []byte
+	subscribed chan func(payload []byte)
+}
+
+func newLoopbackRelay() *loopbackRelay {
+	return &loopbackRelay{published: make(chan []byte, 16), subscribed: make(chan func(payload []byte), 1)}
+}
+
+func (r *loopbackRelay) Publish(ctx context.Context, payload []byte) error {
+	r.published <- payload
+	return nil
+}
+
+func (r *loopbackRelay) Subscribe(ctx context.Context, handle func(payload []byte)) error {
+	r.subscribed <- handle
+	<-ctx.Done()
+	return nil
+}
+
+func TestWebSocketHub_Relay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/stream", handler.NewStreamHandler().Stream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	relay := newLoopbackRelay()
+	handler.Hub.SetRelay(relay)
+	defer handler.Hub.SetRelay(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.Hub.RunRelay(ctx)
+
+	var deliver func(payload []byte)
+	select {
+	case deliver = <-relay.subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("hub did not subscribe to the relay")
+	}
+
+	jobID := uuid.New().String()
+	resp, err := http.Get(server.URL + "/api/v1/stream?topics=job_status&job_id=" + jobID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	events := readEvents(t, resp)
+	assert.Equal(t, "connection", nextEvent(t, events).name)
+
+	// Events raised on another instance reach this instance's clients
+	deliver([]byte(`{"origin":"other-instance","message":{"type":"job_status","data":{"job_id":"` + jobID + `","status":"running","result":""},"timestamp":"2026-10-15T12:00:00Z"}}`))
+	event := nextEvent(t, events)
+	assert.Equal(t, "running", event.message.Data.(map[string]interface{})["status"])
+
+	// Local events are published once and not delivered twice when they
+	// come back from the relay
+	handler.Hub.BroadcastJobStatus(jobID, "completed", "cracked")
+	event = nextEvent(t, events)
+	assert.Equal(t, "completed", event.message.Data.(map[string]interface{})["status"])
+
+	var payload []byte
+	select {
+	case payload = <-relay.published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("local event was not published")
+	}
+	var envelope struct {
+		Origin  string                   `json:"origin"`
+		Message handler.WebSocketMessage `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &envelope))
+	assert.NotEmpty(t, envelope.Origin)
+	assert.Equal(t, "job_status", envelope.Message.Type)
+	deliver(payload)
+
+	handler.Hub.BroadcastJobStatus(jobID, "failed", "")
+	event = nextEvent(t, events)
+	assert.Equal(t, "failed", event.message.Data.(map[string]interface{})["status"], "the echoed event was delivered again")
+}
@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketHub_Shutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", handler.NewWebSocketHandler(nil).HandleWebSocket)
+	router.GET("/api/v1/stream", handler.NewStreamHandler().Stream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	jobID := uuid.New().String()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?job_id="+jobID, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	resp, err := http.Get(server.URL + "/api/v1/stream?job_id=" + jobID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	events := readEvents(t, resp)
+	assert.Equal(t, "connection", nextEvent(t, events).name)
+
+	var message handler.WebSocketMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, "connection", message.Type)
+
+	// An event still queued when shutdown starts is delivered before the
+	// goodbye
+	handler.Hub.BroadcastJobStatus(jobID, "completed", "cracked")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, handler.Hub.Shutdown(ctx))
+
+	require.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, "job_status", message.Type)
+	require.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, "server_shutdown", message.Type)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNoStatusReceived), "expected a close frame, got %v", err)
+
+	assert.Equal(t, "job_status", nextEvent(t, events).name)
+	assert.Equal(t, "server_shutdown", nextEvent(t, events).name)
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok, "event stream still open")
+	case <-time.After(2 * time.Second):
+		t.Fatal("event stream not closed")
+	}
+}
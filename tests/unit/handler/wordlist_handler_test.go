@@ -14,6 +14,7 @@ import (
 
 	"go-distributed-hashcat/internal/delivery/http/handler"
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -25,8 +26,8 @@ type MockWordlistUsecase struct {
 	mock.Mock
 }
 
-func (m *MockWordlistUsecase) UploadWordlist(ctx context.Context, name string, content io.Reader, size int64) (*domain.Wordlist, error) {
-	args := m.Called(ctx, name, content, size)
+func (m *MockWordlistUsecase) UploadWordlist(ctx context.Context, name string, content io.Reader, size int64, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	args := m.Called(ctx, name, content, size, projectID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -41,16 +42,50 @@ func (m *MockWordlistUsecase) GetWordlist(ctx context.Context, id uuid.UUID) (*d
 	return args.Get(0).(*domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistUsecase) GetWordlistBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistUsecase) GetAllWordlists(ctx context.Context) ([]domain.Wordlist, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistUsecase) GetWordlistsByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistUsecase) DeleteWordlist(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockWordlistUsecase) GetLoopbackWordlist(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
+func (m *MockWordlistUsecase) AddCrackedPasswords(ctx context.Context, projectID *uuid.UUID, passwords ...string) error {
+	args := m.Called(ctx, projectID, passwords)
+	return args.Error(0)
+}
+
+func (m *MockWordlistUsecase) SetQuotaChecker(checker domain.QuotaChecker) {
+	m.Called(checker)
+}
+
+func (m *MockWordlistUsecase) SetLookupCache(lookups usecase.LookupCache) {
+	m.Called(lookups)
+}
+
 func TestWordlistHandler_UploadWordlist(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -88,7 +123,7 @@ func TestWordlistHandler_UploadWordlist(t *testing.T) {
 					OrigName: "rockyou.txt",
 					Size:     26,
 				}
-				mockUsecase.On("UploadWordlist", mock.Anything, "rockyou.txt", mock.Anything, mock.AnythingOfType("int64")).Return(expectedWordlist, nil)
+				mockUsecase.On("UploadWordlist", mock.Anything, "rockyou.txt", mock.Anything, mock.AnythingOfType("int64"), (*uuid.UUID)(nil)).Return(expectedWordlist, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -138,7 +173,7 @@ func TestWordlistHandler_UploadWordlist(t *testing.T) {
 				return req, nil
 			},
 			mockSetup: func(mockUsecase *MockWordlistUsecase) {
-				mockUsecase.On("UploadWordlist", mock.Anything, "test.txt", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("failed to save file"))
+				mockUsecase.On("UploadWordlist", mock.Anything, "test.txt", mock.Anything, mock.AnythingOfType("int64"), (*uuid.UUID)(nil)).Return(nil, errors.New("failed to save file"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -0,0 +1,70 @@
+package infrastructure_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 test key of RFC 6238, base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := infrastructure.TOTPCode(rfcSecret, infrastructure.TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+
+	_, err := infrastructure.TOTPCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := infrastructure.TOTPStep(now)
+
+	got, ok := infrastructure.ValidateTOTP(rfcSecret, "005924", now)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	late, err := infrastructure.TOTPCode(rfcSecret, step-1)
+	require.NoError(t, err)
+	got, ok = infrastructure.ValidateTOTP(rfcSecret, late, now)
+	assert.True(t, ok, "one step of skew is allowed")
+	assert.Equal(t, step-1, got)
+
+	stale, err := infrastructure.TOTPCode(rfcSecret, step-2)
+	require.NoError(t, err)
+	_, ok = infrastructure.ValidateTOTP(rfcSecret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = infrastructure.ValidateTOTP(rfcSecret, "05924", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	secret, err := infrastructure.GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(infrastructure.TOTPProvisioningURI("Distributed Hashcat", "alice", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Distributed Hashcat:alice", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Distributed Hashcat", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,70 @@
+package mail_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	message := &domain.EmailMessage{
+		To:      []string{"alice@example.com"},
+		Subject: "Weekly digest of Red team ✓\r\nBcc: eve@example.com",
+		HTML:    "<p>3 new cracks</p>",
+		Text:    "3 new cracks",
+	}
+	raw, err := mail.Compose("Hashcat <hashcat@example.com>", message, time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", parsed.Header.Get("To"))
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Weekly digest of Red team ✓Bcc: eve@example.com", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+" "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8 3 new cracks",
+		"text/html; charset=utf-8 <p>3 new cracks</p>",
+	}, bodies)
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	_, err := mail.NewSMTPMailer(mail.Config{From: "hashcat@example.com"})
+	assert.Error(t, err)
+
+	_, err = mail.NewSMTPMailer(mail.Config{Host: "smtp.example.com", From: "not an address"})
+	assert.Error(t, err)
+
+	mailer, err := mail.NewSMTPMailer(mail.Config{Host: "smtp.example.com", From: "Hashcat <hashcat@example.com>"})
+	require.NoError(t, err)
+	assert.NotNil(t, mailer)
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// denyingChecker refuses one address and remembers every check
+type denyingChecker struct {
+	denied string
+	checks []domain.AgentNetworkCheck
+}
+
+func (c *denyingChecker) Check(ctx context.Context, check domain.AgentNetworkCheck) error {
+	c.checks = append(c.checks, check)
+	if check.IPAddress == c.denied {
+		return domain.ErrAgentNetworkDenied
+	}
+	return nil
+}
+
+func TestAgentNetworkACL(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/24")
+	require.NoError(t, err)
+	checker := &denyingChecker{denied: "203.0.113.9"}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	acl := middleware.AgentNetworkACL(checker, []*net.IPNet{proxies})
+	router.POST("/agents/heartbeat", acl, func(c *gin.Context) {
+		// The handler still gets the whole body
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	router.GET("/agents/:id/jobs/next", acl, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(req *http.Request, remote string) *httptest.ResponseRecorder {
+		req.RemoteAddr = remote + ":40000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	heartbeat := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/agents/heartbeat", strings.NewReader(`{"agent_key":"k3y"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	w := send(heartbeat(), "198.51.100.4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"agent_key":"k3y"}`, w.Body.String())
+	assert.Equal(t, "k3y", checker.checks[0].AgentKey)
+	assert.Equal(t, "198.51.100.4", checker.checks[0].IPAddress)
+
+	w = send(heartbeat(), "203.0.113.9")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "AGENT_NETWORK_DENIED")
+
+	// X-Forwarded-For is believed from trusted proxies only
+	forwarded := heartbeat()
+	forwarded.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.2")
+	assert.Equal(t, http.StatusForbidden, send(forwarded, "10.0.0.1").Code)
+
+	spoofed := heartbeat()
+	spoofed.Header.Set("X-Forwarded-For", "198.51.100.4")
+	assert.Equal(t, http.StatusForbidden, send(spoofed, "203.0.113.9").Code)
+
+	agentID := "5b7b8d4e-4f3c-4f8e-9c41-2d8d3f1b6a10"
+	w = send(httptest.NewRequest(http.MethodGet, "/agents/"+agentID+"/jobs/next", nil), "198.51.100.4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	last := checker.checks[len(checker.checks)-1]
+	require.NotNil(t, last.AgentID)
+	assert.Equal(t, agentID, last.AgentID.String())
+	assert.Equal(t, "GET /agents/"+agentID+"/jobs/next", last.Path)
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/wordlists/:id/download", middleware.DownloadLimit(middleware.DownloadLimitConfig{MaxPerFile: 2, RetryAfter: 30 * time.Second}), func(c *gin.Context) {
+		// Downloads of "big" stay in progress until released
+		if c.Param("id") == "big" {
+			started <- struct{}{}
+			<-release
+		}
+		c.String(http.StatusOK, "words")
+	})
+
+	download := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wordlists/"+id+"/download", nil))
+		return w
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, download("big").Code)
+		}()
+		<-started
+	}
+
+	// Both slots for "big" are taken, other files are unaffected
+	busy := download("big")
+	assert.Equal(t, http.StatusServiceUnavailable, busy.Code)
+	assert.Equal(t, "30", busy.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, download("small").Code)
+
+	close(release)
+	wg.Wait()
+
+	// Finished downloads free their slots
+	go func() { <-started }()
+	assert.Equal(t, http.StatusOK, download("big").Code)
+}
+
+func TestDownloadLimit_Unlimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/wordlists/:id/download", middleware.DownloadLimit(middleware.DownloadLimitConfig{}), func(c *gin.Context) {
+		c.String(http.StatusOK, "words")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wordlists/big/download", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(config middleware.FaultInjectionConfig) (*gin.Engine, *int) {
+		handled := 0
+		router := gin.New()
+		router.POST("/jobs/:id/progress", middleware.FaultInjection(config), func(c *gin.Context) {
+			handled++
+			c.JSON(http.StatusOK, gin.H{"message": "ok"})
+		})
+		router.POST("/agents/heartbeat", middleware.HeartbeatFaultInjection(config), func(c *gin.Context) {
+			handled++
+			c.JSON(http.StatusOK, gin.H{"data": gin.H{"interval_seconds": 5}})
+		})
+		return router, &handled
+	}
+	send := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w
+	}
+
+	t.Run("disabled passes everything through", func(t *testing.T) {
+		router, handled := newRouter(middleware.FaultInjectionConfig{ErrorRate: 1, HeartbeatDropRate: 1})
+		assert.Equal(t, http.StatusOK, send(router, "/jobs/1/progress").Code)
+		w := send(router, "/agents/heartbeat")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.FaultInjectedHeader))
+		assert.Equal(t, 2, *handled)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		router, handled := newRouter(middleware.FaultInjectionConfig{Enabled: true, ErrorRate: 1, Seed: 1})
+		for i := 0; i < 20; i++ {
+			w := send(router, "/jobs/1/progress")
+			assert.GreaterOrEqual(t, w.Code, http.StatusInternalServerError)
+			assert.Equal(t, "error", w.Header().Get(middleware.FaultInjectedHeader))
+		}
+		assert.Zero(t, *handled)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		router, handled := newRouter(middleware.FaultInjectionConfig{Enabled: true, LatencyRate: 1, MaxLatency: 20 * time.Millisecond})
+		w := send(router, "/jobs/1/progress")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "latency", w.Header().Get(middleware.FaultInjectedHeader))
+		assert.Equal(t, 1, *handled)
+	})
+
+	t.Run("dropped heartbeats are acknowledged but never handled", func(t *testing.T) {
+		router, handled := newRouter(middleware.FaultInjectionConfig{Enabled: true, HeartbeatDropRate: 1})
+		w := send(router, "/agents/heartbeat")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "heartbeat-drop", w.Header().Get(middleware.FaultInjectedHeader))
+		assert.Zero(t, *handled)
+
+		// Only heartbeat routes drop requests
+		assert.Equal(t, http.StatusOK, send(router, "/jobs/1/progress").Code)
+		assert.Equal(t, 1, *handled)
+	})
+
+	t.Run("rates are honoured roughly", func(t *testing.T) {
+		router, handled := newRouter(middleware.FaultInjectionConfig{Enabled: true, ErrorRate: 0.25, Seed: 42})
+		failed := 0
+		for i := 0; i < 400; i++ {
+			if send(router, "/jobs/1/progress").Code != http.StatusOK {
+				failed++
+			}
+		}
+		assert.InDelta(t, 100, failed, 40)
+		assert.Equal(t, 400-failed, *handled)
+	})
+}
@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	content := strings.Repeat("3c077829151f03a4101bf36510d551b1\n", 10)
+	router := gin.New()
+	router.Use(middleware.Gzip("/raw"))
+	serve := func(c *gin.Context) {
+		c.DataFromReader(http.StatusOK, int64(len(content)), "application/octet-stream", strings.NewReader(content), nil)
+	}
+	router.GET("/file", serve)
+	router.GET("/raw", serve)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("compressed responses drop the uncompressed length", func(t *testing.T) {
+		w := get("/file")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Content-Length"))
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(body))
+	})
+
+	t.Run("skipped routes keep their length", func(t *testing.T) {
+		w := get("/raw")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "330", w.Header().Get("Content-Length"))
+		assert.Equal(t, content, w.Body.String())
+	})
+}
@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIdempotentRouter(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/jobs/", middleware.Idempotency(repository.NewIdempotencyRepository(db), time.Hour), handler)
+	return router
+}
+
+func post(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/jobs/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	created := 0
+	router := setupIdempotentRouter(t, func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusCreated, gin.H{"data": gin.H{"job": created}})
+	})
+
+	first := post(router, "abc", `{"name":"crack"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+
+	// The retry gets the original response without creating another job
+	retry := post(router, "abc", `{"name":"crack"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, created)
+
+	t.Run("key reused for a different request", func(t *testing.T) {
+		w := post(router, "abc", `{"name":"other"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("requests without a key are not deduplicated", func(t *testing.T) {
+		post(router, "", `{"name":"crack"}`)
+		post(router, "", `{"name":"crack"}`)
+		assert.Equal(t, 3, created)
+	})
+
+	t.Run("key longer than 255 characters", func(t *testing.T) {
+		w := post(router, string(bytes.Repeat([]byte("k"), 256)), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestIdempotency_FailedRequestReleasesKey(t *testing.T) {
+	attempts := 0
+	router := setupIdempotentRouter(t, func(c *gin.Context) {
+		attempts++
+		if attempts == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database is locked"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": gin.H{"attempt": attempts}})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, post(router, "abc", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, post(router, "abc", `{}`).Code)
+	assert.Equal(t, 2, attempts)
+}
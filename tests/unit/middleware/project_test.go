@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// membershipChecker lets members of its one project in
+type membershipChecker struct {
+	projectID uuid.UUID
+	member    uuid.UUID
+}
+
+func (m membershipChecker) CheckAccess(ctx context.Context, projectID, userID uuid.UUID, role string) error {
+	if projectID != m.projectID || (userID != m.member && role != "admin") {
+		return &domain.NotFoundError{Entity: "project"}
+	}
+	return nil
+}
+
+func TestProjectAccess(t *testing.T) {
+	checker := membershipChecker{projectID: uuid.New(), member: uuid.New()}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stands in for OptionalAuthMiddleware, taking the user from headers
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set("user_id", userID)
+			c.Set("role", c.GetHeader("X-Role"))
+		}
+	})
+	router.Use(middleware.ProjectAccess(checker))
+	router.GET("/jobs", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		projectID string
+		userID    string
+		role      string
+		expected  int
+	}{
+		{name: "unscoped request, e.g. an agent", expected: http.StatusOK},
+		{name: "member", projectID: checker.projectID.String(), userID: checker.member.String(), role: "user", expected: http.StatusOK},
+		{name: "admin", projectID: checker.projectID.String(), userID: uuid.NewString(), role: "admin", expected: http.StatusOK},
+		{name: "non-member", projectID: checker.projectID.String(), userID: uuid.NewString(), role: "user", expected: http.StatusNotFound},
+		{name: "anonymous", projectID: checker.projectID.String(), expected: http.StatusUnauthorized},
+		{name: "invalid project ID", projectID: "acme", userID: checker.member.String(), expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "/jobs"
+			if tt.projectID != "" {
+				url += "?project_id=" + tt.projectID
+			}
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			if tt.userID != "" {
+				req.Header.Set("X-User", tt.userID)
+				req.Header.Set("X-Role", tt.role)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/infrastructure"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	require.NoError(t, infrastructure.ConfigureLogging("json", "debug", &logs))
+	t.Cleanup(func() { infrastructure.ConfigureLogging("text", "info", nil) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.RequestLogger())
+	router.GET("/jobs/:id", func(c *gin.Context) {
+		infrastructure.ServerLogger.WithContext(c.Request.Context()).With("job_id", c.Param("id")).Info("Looking up job")
+		c.JSON(http.StatusOK, gin.H{"request_id": middleware.GetRequestID(c)})
+	})
+
+	get := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/jobs/42", nil)
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("client ID is kept and logged", func(t *testing.T) {
+		logs.Reset()
+		w := get("trace-123")
+		assert.Equal(t, "trace-123", w.Header().Get(middleware.RequestIDHeader))
+		assert.Contains(t, w.Body.String(), "trace-123")
+
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		require.Len(t, lines, 2)
+
+		var handlerLine, requestLine map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &handlerLine))
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &requestLine))
+
+		assert.Equal(t, "Looking up job", handlerLine["msg"])
+		assert.Equal(t, "INFO", handlerLine["level"])
+		assert.Equal(t, "server", handlerLine["component"])
+		assert.Equal(t, "trace-123", handlerLine["request_id"])
+		assert.Equal(t, "42", handlerLine["job_id"])
+
+		assert.Equal(t, "trace-123", requestLine["request_id"])
+		assert.Equal(t, "GET", requestLine["method"])
+		assert.Equal(t, float64(http.StatusOK), requestLine["status"])
+	})
+
+	t.Run("missing or unsafe IDs are replaced", func(t *testing.T) {
+		generated := get("").Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, generated, 36)
+
+		replaced := get("bad id\nlevel=ERROR").Header().Get(middleware.RequestIDHeader)
+		assert.NotContains(t, replaced, " ")
+		assert.Len(t, replaced, 36)
+	})
+}
+
+func TestConfigureLogging(t *testing.T) {
+	var logs bytes.Buffer
+	t.Cleanup(func() { infrastructure.ConfigureLogging("text", "info", nil) })
+
+	require.NoError(t, infrastructure.ConfigureLogging("json", "warning", &logs))
+	logger := infrastructure.NewLogger("AGENT").With("agent_id", "a1")
+	logger.Info("dropped below the level")
+	logger.Success("dropped as well")
+	logger.Warning("⚠️ Disk almost full")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, "WARNING", line["level"])
+	assert.Equal(t, "Disk almost full", line["msg"])
+	assert.Equal(t, "a1", line["agent_id"])
+
+	// Text keeps the classic format with the fields appended
+	logs.Reset()
+	require.NoError(t, infrastructure.ConfigureLogging("text", "info", &logs))
+	logger.Success("Job %s done", "x")
+	assert.Contains(t, logs.String(), "[SUCCESS] [AGENT] Job x done agent_id=a1")
+
+	assert.Error(t, infrastructure.ConfigureLogging("xml", "info", nil))
+	assert.Error(t, infrastructure.ConfigureLogging("json", "loud", nil))
+}
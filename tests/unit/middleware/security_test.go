@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(origins ...string) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.CORS(middleware.CORSConfig{AllowedOrigins: origins}))
+		router.GET("/api/v1/agents/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+	serve := func(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/agents/", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	router := newRouter("https://ui.example.com")
+
+	t.Run("allowed origin", func(t *testing.T) {
+		w := serve(router, http.MethodGet, "https://ui.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://ui.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+		w = serve(router, http.MethodOptions, "https://ui.example.com")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+		assert.Equal(t, "86400", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("other origins get no CORS headers", func(t *testing.T) {
+		w := serve(router, http.MethodGet, "https://evil.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = serve(router, http.MethodOptions, "https://evil.example.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("requests without an origin are untouched", func(t *testing.T) {
+		w := serve(router, http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		w := serve(newRouter("*"), http.MethodGet, "https://anywhere.example.com")
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"), "browsers refuse credentials with a wildcard")
+	})
+}
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CSRF(middleware.CSRFConfig{Enabled: true, TrustedOrigins: []string{"https://ui.example.com"}}))
+	router.POST("/api/v1/jobs/", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	post := func(headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodPost, "http://hashcat.example.com/api/v1/jobs/", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	cookie := "hashcat_session=abc"
+
+	for name, test := range map[string]struct {
+		headers map[string]string
+		want    int
+	}{
+		"cross-site with cookies":    {map[string]string{"Cookie": cookie, "Origin": "https://evil.example.com"}, http.StatusForbidden},
+		"cross-site by referer":      {map[string]string{"Cookie": cookie, "Referer": "https://evil.example.com/page"}, http.StatusForbidden},
+		"opaque origin":              {map[string]string{"Cookie": cookie, "Origin": "null"}, http.StatusForbidden},
+		"same origin":                {map[string]string{"Cookie": cookie, "Origin": "http://hashcat.example.com"}, http.StatusCreated},
+		"trusted origin":             {map[string]string{"Cookie": cookie, "Origin": "https://ui.example.com"}, http.StatusCreated},
+		"fetch metadata same-origin": {map[string]string{"Cookie": cookie, "Sec-Fetch-Site": "same-origin", "Origin": "https://evil.example.com"}, http.StatusCreated},
+		"bearer token":               {map[string]string{"Cookie": cookie, "Authorization": "Bearer token", "Origin": "https://evil.example.com"}, http.StatusCreated},
+		"no cookies":                 {map[string]string{"Origin": "https://evil.example.com"}, http.StatusCreated},
+		"no origin, like agents":     {map[string]string{"Cookie": cookie}, http.StatusCreated},
+	} {
+		assert.Equal(t, test.want, post(test.headers), name)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		router := gin.New()
+		router.Use(middleware.CSRF(middleware.CSRFConfig{}))
+		router.POST("/", func(c *gin.Context) { c.Status(http.StatusCreated) })
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Cookie", cookie)
+		req.Header.Set("Origin", "https://evil.example.com")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.SecurityHeaders(middleware.SecurityHeadersConfig{HSTSMaxAge: time.Hour, ContentSecurityPolicy: middleware.DefaultContentSecurityPolicy}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/", ok)
+	router.GET("/api/v1/agents/", ok)
+
+	get := func(path string, headers map[string]string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	ui := get("/", nil)
+	assert.Equal(t, "nosniff", ui.Get("X-Content-Type-Options"))
+	assert.Equal(t, middleware.DefaultContentSecurityPolicy, ui.Get("Content-Security-Policy"))
+	assert.Empty(t, ui.Get("Strict-Transport-Security"), "not over plain HTTP")
+
+	api := get("/api/v1/agents/", map[string]string{"X-Forwarded-Proto": "https"})
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", api.Get("Content-Security-Policy"))
+	assert.Equal(t, "max-age=3600; includeSubDomains", api.Get("Strict-Transport-Security"))
+}
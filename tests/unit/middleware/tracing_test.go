@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/infrastructure/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// Installs the trace context propagator, exports nothing while disabled
+	_, err := tracing.Init(context.Background(), tracing.Config{}, "test")
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Tracing("/agents/:id/heartbeat"))
+	router.GET("/jobs/:id", func(c *gin.Context) {
+		// Usecase spans hang off the request context
+		_, span := tracing.Tracer("usecase").Start(c.Request.Context(), "JobUsecase.GetJob")
+		span.End()
+		c.Status(http.StatusOK)
+	})
+	router.POST("/jobs/:id/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	router.POST("/agents/:id/heartbeat", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(method, path string, header http.Header) {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("continues the caller's trace", func(t *testing.T) {
+		exporter.Reset()
+		const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		serve(http.MethodGet, "/jobs/42", http.Header{"Traceparent": {"00-" + traceID + "-00f067aa0ba902b7-01"}})
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		usecase, server := spans[0], spans[1]
+
+		assert.Equal(t, "GET /jobs/:id", server.Name)
+		assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+		assert.Equal(t, traceID, server.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+
+		assert.Equal(t, "JobUsecase.GetJob", usecase.Name)
+		assert.Equal(t, server.SpanContext.SpanID(), usecase.Parent.SpanID())
+	})
+
+	t.Run("server errors mark the span", func(t *testing.T) {
+		exporter.Reset()
+		serve(http.MethodPost, "/jobs/42/fail", nil)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+
+	t.Run("skipped routes are not traced", func(t *testing.T) {
+		exporter.Reset()
+		serve(http.MethodPost, "/agents/1/heartbeat", nil)
+		assert.Empty(t, exporter.GetSpans())
+	})
+}
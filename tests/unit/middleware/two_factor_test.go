@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-distributed-hashcat/internal/delivery/http/middleware"
+	"go-distributed-hashcat/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminPolicy requires a second factor of admins
+type adminPolicy struct{}
+
+func (adminPolicy) TwoFactorPending(ctx context.Context, claims *domain.JWTClaims) (bool, error) {
+	return !claims.TwoFactor && claims.Role == "admin", nil
+}
+
+func TestTwoFactorPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stands in for OptionalAuthMiddleware, taking the user from headers
+	router.Use(func(c *gin.Context) {
+		if role := c.GetHeader("X-Role"); role != "" {
+			c.Set("claims", &domain.JWTClaims{Role: role, TwoFactor: c.GetHeader("X-TFA") == "1"})
+		}
+	})
+	router.Use(middleware.TwoFactorPolicy(adminPolicy{}, "/api/v1/auth/"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/jobs/:id/result", ok)
+	router.POST("/api/v1/auth/2fa/enroll", ok)
+
+	tests := []struct {
+		name     string
+		path     string
+		role     string
+		tfa      bool
+		expected int
+	}{
+		{name: "anonymous, e.g. an agent", path: "/api/v1/jobs/1/result", expected: http.StatusOK},
+		{name: "user", path: "/api/v1/jobs/1/result", role: "user", expected: http.StatusOK},
+		{name: "admin without second factor", path: "/api/v1/jobs/1/result", role: "admin", expected: http.StatusForbidden},
+		{name: "admin with second factor", path: "/api/v1/jobs/1/result", role: "admin", tfa: true, expected: http.StatusOK},
+		{name: "admin setting it up", path: "/api/v1/auth/2fa/enroll", role: "admin", expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tt.path == "/api/v1/auth/2fa/enroll" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.role != "" {
+				req.Header.Set("X-Role", tt.role)
+			}
+			if tt.tfa {
+				req.Header.Set("X-TFA", "1")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "TWO_FACTOR_SETUP_REQUIRED")
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdP serves discovery, JWKS and a token endpoint that hands out ID
+// tokens with the claims of the next login
+type fakeIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims
+	forms  []url.Values
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &fakeIdP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "hashcat" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		r.ParseForm()
+		idp.mu.Lock()
+		idp.forms = append(idp.forms, r.PostForm)
+		claims := idp.claims
+		idp.mu.Unlock()
+
+		if r.PostForm.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Session not active"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, claims), "refresh_token": "refresh-2"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(idp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (idp *fakeIdP) login(claims jwt.MapClaims) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.claims = claims
+}
+
+func (idp *fakeIdP) claimsFor(audience, nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": idp.server.URL, "aud": audience, "sub": "user-42", "nonce": nonce,
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+		"email": "alice@example.com", "email_verified": true, "preferred_username": "alice",
+		"realm_access": map[string]interface{}{"roles": []string{"offline_access", "hashcat-admins"}},
+	}
+}
+
+func newProvider(t *testing.T, idp *fakeIdP) domain.SSOProvider {
+	provider, err := oidc.NewProvider(oidc.Config{
+		IssuerURL:    idp.server.URL + "/",
+		ClientID:     "hashcat",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://hashcat.example/api/v1/auth/oidc/callback",
+		RoleClaim:    "realm_access.roles",
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := newProvider(t, idp)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "hashcat", query.Get("client_id"))
+	assert.Equal(t, "openid profile email", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verifies the ID token", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		provider := newProvider(t, idp)
+		idp.login(idp.claimsFor("hashcat", "nonce-1"))
+
+		login, err := provider.Exchange(ctx, "code-1", "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-2", login.RefreshToken)
+		assert.Equal(t, &domain.SSOClaims{
+			Subject: "user-42", Email: "alice@example.com", EmailVerified: true, Username: "alice",
+			Roles: []string{"offline_access", "hashcat-admins"},
+		}, login.Claims)
+		assert.Equal(t, "code-1", idp.forms[0].Get("code"))
+		assert.Equal(t, "authorization_code", idp.forms[0].Get("grant_type"))
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		provider := newProvider(t, idp)
+		idp.login(idp.claimsFor("hashcat", "nonce-1"))
+
+		_, err := provider.Exchange(ctx, "code-1", "nonce-2")
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("token for another client", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		provider := newProvider(t, idp)
+		idp.login(idp.claimsFor("other-app", "nonce-1"))
+
+		_, err := provider.Exchange(ctx, "code-1", "nonce-1")
+		assert.ErrorContains(t, err, "invalid ID token")
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		provider := newProvider(t, idp)
+		claims := idp.claimsFor("hashcat", "nonce-1")
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		idp.login(claims)
+
+		_, err := provider.Exchange(ctx, "code-1", "nonce-1")
+		assert.ErrorContains(t, err, "invalid ID token")
+	})
+}
+
+func TestProvider_Refresh(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdP(t)
+	provider := newProvider(t, idp)
+	claims := idp.claimsFor("hashcat", "")
+	delete(claims, "nonce")
+	idp.login(claims)
+
+	login, err := provider.Refresh(ctx, "refresh-1")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-2", login.RefreshToken)
+	require.NotNil(t, login.Claims)
+	assert.Equal(t, "user-42", login.Claims.Subject)
+
+	_, err = provider.Refresh(ctx, "revoked")
+	assert.ErrorContains(t, err, "Session not active")
+}
+
+func TestNewProvider_RequiresClient(t *testing.T) {
+	_, err := oidc.NewProvider(oidc.Config{IssuerURL: "https://login.example"})
+	assert.Error(t, err)
+}
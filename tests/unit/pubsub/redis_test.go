@@ -0,0 +1,190 @@
+package pubsub_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/infrastructure/pubsub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis understands AUTH, PUBLISH and SUBSCRIBE, enough for the relay
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu          sync.Mutex
+	subscribers map[net.Conn]string // Connection -> channel
+	commands    []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeRedis{listener: listener, password: password, subscribers: make(map[net.Conn]string)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args[0])
+		s.mu.Unlock()
+
+		switch {
+		case args[0] == "AUTH":
+			if args[1] != s.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case !authed:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "SUBSCRIBE":
+			s.mu.Lock()
+			s.subscribers[conn] = args[1]
+			s.mu.Unlock()
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n%s:1\r\n", bulk(args[1]))
+		case args[0] == "PUBLISH":
+			s.mu.Lock()
+			n := 0
+			for sub, channel := range s.subscribers {
+				if channel == args[1] {
+					fmt.Fprintf(sub, "*3\r\n$7\r\nmessage\r\n%s%s", bulk(channel), bulk(args[2]))
+					n++
+				}
+			}
+			s.mu.Unlock()
+			fmt.Fprintf(conn, ":%d\r\n", n)
+		}
+	}
+}
+
+// dropSubscribers closes every subscribed connection, like a Redis restart
+func (s *fakeRedis) dropSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.subscribers {
+		conn.Close()
+		delete(s.subscribers, conn)
+	}
+}
+
+func (s *fakeRedis) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers)
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(reader, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedisRelay(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	relay, err := pubsub.NewRedisRelay(pubsub.RedisConfig{Addr: server.listener.Addr().String(), Password: "secret"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 4)
+	done := make(chan struct{})
+	go func() {
+		relay.Subscribe(ctx, func(payload []byte) { received <- string(payload) })
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return server.subscriberCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// Payloads go through unchanged, line breaks included
+	payload := "{\"type\":\"job_status\"}\r\n{\"split\":true}"
+	require.NoError(t, relay.Publish(ctx, []byte(payload)))
+	select {
+	case got := <-received:
+		assert.Equal(t, payload, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("published payload was not received")
+	}
+
+	// A dropped subscription is restored
+	server.dropSubscribers()
+	require.Eventually(t, func() bool { return server.subscriberCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, relay.Publish(ctx, []byte("after reconnect")))
+	select {
+	case got := <-received:
+		assert.Equal(t, "after reconnect", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no payload after resubscribing")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
+
+func TestRedisRelay_Errors(t *testing.T) {
+	_, err := pubsub.NewRedisRelay(pubsub.RedisConfig{})
+	assert.Error(t, err)
+
+	server := newFakeRedis(t, "secret")
+	relay, err := pubsub.NewRedisRelay(pubsub.RedisConfig{Addr: server.listener.Addr().String(), Password: "wrong"})
+	require.NoError(t, err)
+	err = relay.Publish(context.Background(), []byte("x"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "WRONGPASS")
+}
@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentBenchmarkRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewAgentBenchmarkRepository(db)
+	agentID, otherID := uuid.New(), uuid.New()
+	measuredAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Save(ctx, []domain.AgentBenchmark{
+		{AgentID: agentID, HashType: 22000, Speed: 1000, MeasuredAt: measuredAt},
+		{AgentID: agentID, HashType: 1000, Speed: 5000, MeasuredAt: measuredAt},
+		{AgentID: otherID, HashType: 1000, Speed: 3000, MeasuredAt: measuredAt},
+	}))
+	// Saving a mode again replaces its benchmark
+	require.NoError(t, repo.Save(ctx, []domain.AgentBenchmark{{AgentID: agentID, HashType: 22000, Speed: 1200, MeasuredAt: measuredAt}}))
+
+	benchmarks, err := repo.GetByAgent(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, benchmarks, 2)
+	assert.Equal(t, 1000, benchmarks[0].HashType)
+	assert.Equal(t, int64(1200), benchmarks[1].Speed)
+
+	speeds, err := repo.GetSpeedsByHashType(ctx, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int64{agentID: 5000, otherID: 3000}, speeds)
+
+	resetAt, err := repo.GetResetAt(ctx, agentID)
+	require.NoError(t, err)
+	assert.Nil(t, resetAt)
+	require.NoError(t, repo.Invalidate(ctx, agentID, measuredAt.Add(time.Hour)))
+	benchmarks, err = repo.GetByAgent(ctx, agentID)
+	require.NoError(t, err)
+	assert.Empty(t, benchmarks)
+	resetAt, err = repo.GetResetAt(ctx, agentID)
+	require.NoError(t, err)
+	require.NotNil(t, resetAt)
+	assert.True(t, measuredAt.Add(time.Hour).Equal(*resetAt))
+	speeds, err = repo.GetSpeedsByHashType(ctx, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int64{otherID: 3000}, speeds)
+}
+
+func TestAgentBenchmarkRepository_Jobs(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewAgentBenchmarkRepository(db)
+	agentID := uuid.New()
+
+	job, err := repo.ClaimJob(ctx, agentID, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	first := &domain.BenchmarkJob{AgentID: agentID, HashTypes: []int{1000, 22000}, Status: domain.BenchmarkJobPending, CreatedAt: time.Now().Add(-time.Minute)}
+	second := &domain.BenchmarkJob{AgentID: agentID, HashTypes: []int{0}, Status: domain.BenchmarkJobPending}
+	require.NoError(t, repo.CreateJob(ctx, first))
+	require.NoError(t, repo.CreateJob(ctx, second))
+
+	// The oldest job is handed out, and again until it is reported
+	for range 2 {
+		job, err = repo.ClaimJob(ctx, agentID, time.Now())
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, first.ID, job.ID)
+		assert.Equal(t, []int{1000, 22000}, job.HashTypes)
+		assert.Equal(t, domain.BenchmarkJobRunning, job.Status)
+	}
+
+	now := time.Now()
+	job.Status, job.CompletedAt = domain.BenchmarkJobCompleted, &now
+	require.NoError(t, repo.UpdateJob(ctx, job))
+	job, err = repo.ClaimJob(ctx, agentID, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, second.ID, job.ID)
+
+	jobs, err := repo.GetJobs(ctx, agentID, 20)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, second.ID, jobs[0].ID)
+	assert.Equal(t, domain.BenchmarkJobCompleted, jobs[1].Status)
+	assert.NotNil(t, jobs[1].CompletedAt)
+
+	_, err = repo.GetJob(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+}
@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentCommandRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewAgentCommandRepository(db)
+	agentID := uuid.New()
+	now := time.Now()
+
+	upgrade := &domain.AgentCommand{AgentID: agentID, Type: domain.AgentCommandUpgrade, Params: json.RawMessage(`{"version":"1.4.0"}`),
+		Status: domain.AgentCommandPending, CreatedAt: now.Add(-2 * time.Minute), ExpiresAt: now.Add(time.Hour)}
+	stale := &domain.AgentCommand{AgentID: agentID, Type: domain.AgentCommandClearCache,
+		Status: domain.AgentCommandPending, CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(-time.Second)}
+	other := &domain.AgentCommand{AgentID: uuid.New(), Type: domain.AgentCommandBenchmark,
+		Status: domain.AgentCommandPending, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	for _, command := range []*domain.AgentCommand{upgrade, stale, other} {
+		require.NoError(t, repo.Create(ctx, command))
+	}
+
+	pending, err := repo.GetPending(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, upgrade.ID, pending[0].ID)
+	assert.JSONEq(t, `{"version":"1.4.0"}`, string(pending[0].Params))
+	assert.Nil(t, pending[1].Params)
+
+	// Expired commands are no longer handed out
+	require.NoError(t, repo.Expire(ctx, agentID, now))
+	pending, err = repo.GetPending(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, upgrade.ID, pending[0].ID)
+
+	// A transition only applies to a command in the expected status
+	acknowledgedAt := now
+	upgrade.Status, upgrade.AcknowledgedAt = domain.AgentCommandAcknowledged, &acknowledgedAt
+	ok, err := repo.Transition(ctx, upgrade, domain.AgentCommandPending)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.Transition(ctx, upgrade, domain.AgentCommandPending)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	completedAt := now
+	upgrade.Status, upgrade.CompletedAt = domain.AgentCommandCompleted, &completedAt
+	upgrade.Result = json.RawMessage(`{"version":"1.4.0","output":"ok"}`)
+	ok, err = repo.Transition(ctx, upgrade, domain.AgentCommandAcknowledged)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	got, err := repo.GetByID(ctx, upgrade.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.AgentCommandCompleted, got.Status)
+	assert.JSONEq(t, `{"version":"1.4.0","output":"ok"}`, string(got.Result))
+	assert.NotNil(t, got.AcknowledgedAt)
+	assert.NotNil(t, got.CompletedAt)
+
+	commands, err := repo.GetByAgent(ctx, agentID, 50)
+	require.NoError(t, err)
+	require.Len(t, commands, 2)
+	assert.Equal(t, stale.ID, commands[0].ID)
+	assert.Equal(t, domain.AgentCommandExpired, commands[0].Status)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+}
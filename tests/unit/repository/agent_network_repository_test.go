@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentNetworkRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewAgentNetworkRepository(db)
+	agentID := uuid.New()
+
+	global := &domain.AgentNetworkRule{CIDR: "10.0.0.0/8", Action: domain.NetworkRuleAllow, Description: "lab"}
+	require.NoError(t, repo.CreateRule(ctx, global))
+	own := &domain.AgentNetworkRule{AgentID: &agentID, CIDR: "192.0.2.7/32", Action: domain.NetworkRuleDeny}
+	require.NoError(t, repo.CreateRule(ctx, own))
+
+	rules, err := repo.GetRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, global.ID, rules[0].ID)
+	assert.Nil(t, rules[0].AgentID)
+	assert.Equal(t, "lab", rules[0].Description)
+	assert.Equal(t, agentID, *rules[1].AgentID)
+
+	require.NoError(t, repo.DeleteRule(ctx, own.ID))
+	assert.True(t, domain.IsNotFoundError(repo.DeleteRule(ctx, own.ID)))
+
+	// The audit log keeps the newest denials only
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		denial := &domain.AgentAccessDenial{IPAddress: ip, AgentID: &agentID, AgentName: "gpu-01", Path: "POST /api/v1/agents/heartbeat", Reason: "address is not in the allowlist"}
+		require.NoError(t, repo.RecordDenial(ctx, denial, 2))
+		assert.NotZero(t, denial.ID)
+	}
+
+	denials, err := repo.GetDenials(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, denials, 2)
+	assert.Equal(t, "203.0.113.3", denials[0].IPAddress, "newest first")
+	assert.Equal(t, "203.0.113.2", denials[1].IPAddress)
+	assert.Equal(t, agentID, *denials[0].AgentID)
+	assert.Equal(t, "gpu-01", denials[0].AgentName)
+}
@@ -261,6 +261,422 @@ func (suite *AgentRepositoryTestSuite) TestUpdate() {
 	assert.Equal(suite.T(), "busy", retrievedAgent.Status)
 }
 
+func (suite *AgentRepositoryTestSuite) TestAgentGroups() {
+	ctx := context.Background()
+
+	newAgent := func(name string) *domain.Agent {
+		agent := &domain.Agent{
+			ID:        uuid.New(),
+			Name:      name,
+			IPAddress: "10.0.0.1",
+			Port:      8080,
+			Status:    "online",
+			LastSeen:  time.Now(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, agent))
+		return agent
+	}
+	lab1, lab2, cloud := newAgent("lab-1"), newAgent("lab-2"), newAgent("cloud-1")
+
+	group := &domain.AgentGroup{Name: "red-team-lab", Description: "On-prem GPUs"}
+	suite.Require().NoError(suite.repo.CreateGroup(ctx, group))
+	assert.NotEqual(suite.T(), uuid.Nil, group.ID)
+
+	// Names are unique
+	assert.Error(suite.T(), suite.repo.CreateGroup(ctx, &domain.AgentGroup{Name: "red-team-lab"}))
+
+	suite.Require().NoError(suite.repo.AddGroupMember(ctx, group.ID, lab1.ID))
+	suite.Require().NoError(suite.repo.AddGroupMember(ctx, group.ID, lab2.ID))
+	suite.Require().NoError(suite.repo.AddGroupMember(ctx, group.ID, lab2.ID)) // Already a member
+
+	fetched, err := suite.repo.GetGroupByID(ctx, group.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "On-prem GPUs", fetched.Description)
+
+	members, err := suite.repo.GetByGroupID(ctx, group.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(members, 2)
+	assert.Equal(suite.T(), "lab-1", members[0].Name)
+	assert.Equal(suite.T(), "lab-2", members[1].Name)
+
+	// Deleting an agent drops it from its groups
+	suite.Require().NoError(suite.repo.Delete(ctx, lab2.ID))
+	members, err = suite.repo.GetByGroupID(ctx, group.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), members, 1)
+
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.RemoveGroupMember(ctx, group.ID, cloud.ID)))
+	suite.Require().NoError(suite.repo.RemoveGroupMember(ctx, group.ID, lab1.ID))
+
+	groups, err := suite.repo.GetAllGroups(ctx)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), groups, 1)
+
+	suite.Require().NoError(suite.repo.DeleteGroup(ctx, group.ID))
+	_, err = suite.repo.GetGroupByID(ctx, group.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.DeleteGroup(ctx, group.ID)))
+
+	// The agents themselves are kept
+	_, err = suite.repo.GetByID(ctx, cloud.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AgentRepositoryTestSuite) TestUpdateHeartbeat() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	fetched, err := suite.repo.GetByID(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), fetched.Heartbeat)
+
+	jobID := uuid.New()
+	suite.Require().NoError(suite.repo.UpdateHeartbeat(ctx, agent.ID, &domain.AgentHeartbeat{
+		CurrentJobID:     &jobID,
+		JobProgress:      12.5,
+		JobSpeed:         1000,
+		GPUUtilization:   98,
+		FreeDiskBytes:    1 << 30,
+		HashcatAvailable: true,
+		ReportedAt:       time.Now(),
+	}))
+
+	fetched, err = suite.repo.GetByID(ctx, agent.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(fetched.Heartbeat)
+	assert.Equal(suite.T(), jobID, *fetched.Heartbeat.CurrentJobID)
+	assert.Equal(suite.T(), 98, fetched.Heartbeat.GPUUtilization)
+	assert.True(suite.T(), fetched.Heartbeat.HashcatAvailable)
+
+	agents, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(agents, 1)
+	assert.Equal(suite.T(), int64(1<<30), agents[0].Heartbeat.FreeDiskBytes)
+}
+
+func (suite *AgentRepositoryTestSuite) TestUpdateLastSeenBatch() {
+	ctx := context.Background()
+	stale := time.Now().Add(-time.Hour)
+	newAgent := func(name, ip string) *domain.Agent {
+		agent := &domain.Agent{
+			ID:        uuid.New(),
+			Name:      name,
+			IPAddress: ip,
+			Port:      8080,
+			Status:    "online",
+			LastSeen:  stale,
+			CreatedAt: stale,
+			UpdatedAt: stale,
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, agent))
+		return agent
+	}
+	gpu1 := newAgent("gpu-1", "10.0.0.1")
+	gpu2 := newAgent("gpu-2", "10.0.0.2")
+
+	// Warm the cache, the batch must invalidate it
+	_, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+
+	seen := time.Now()
+	suite.Require().NoError(suite.repo.UpdateLastSeenBatch(ctx, map[uuid.UUID]domain.AgentSeen{
+		gpu1.ID: {LastSeen: seen, Heartbeat: &domain.AgentHeartbeat{GPUUtilization: 55, HashcatAvailable: true}},
+		gpu2.ID: {LastSeen: seen},
+	}))
+
+	agents, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(agents, 2)
+	for _, agent := range agents {
+		assert.WithinDuration(suite.T(), seen, agent.LastSeen, time.Second, agent.Name)
+		if agent.ID == gpu1.ID {
+			suite.Require().NotNil(agent.Heartbeat)
+			assert.Equal(suite.T(), 55, agent.Heartbeat.GPUUtilization)
+		} else {
+			assert.Nil(suite.T(), agent.Heartbeat)
+		}
+	}
+
+	assert.NoError(suite.T(), suite.repo.UpdateLastSeenBatch(ctx, nil))
+}
+
+func (suite *AgentRepositoryTestSuite) TestHashcatVersion() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	stored, err := suite.repo.GetByID(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), stored.HashcatVersion)
+
+	heartbeat := func(version string) {
+		suite.Require().NoError(suite.repo.UpdateLastSeenBatch(ctx, map[uuid.UUID]domain.AgentSeen{
+			agent.ID: {LastSeen: time.Now(), Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: true, HashcatVersion: version}},
+		}))
+	}
+	heartbeat("v6.2.6")
+	stored, err = suite.repo.GetByID(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "v6.2.6", stored.HashcatVersion)
+
+	// A heartbeat that couldn't read the version keeps the known one
+	heartbeat("")
+	agents, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(agents, 1)
+	assert.Equal(suite.T(), "v6.2.6", agents[0].HashcatVersion)
+}
+
+func (suite *AgentRepositoryTestSuite) TestAgentLogs() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	push := func(messages ...string) []domain.AgentLogLine {
+		lines := make([]domain.AgentLogLine, len(messages))
+		for i, message := range messages {
+			lines[i] = domain.AgentLogLine{Time: time.Now(), Source: domain.AgentLogSourceAgent, Message: message}
+		}
+		suite.Require().NoError(suite.repo.AppendLogs(ctx, agent.ID, lines, 4))
+		return lines
+	}
+
+	first := push("one", "two", "three")
+	assert.Greater(suite.T(), first[2].ID, first[0].ID)
+	push("four", "five", "six")
+
+	// Only the newest four lines are kept
+	lines, err := suite.repo.GetLogs(ctx, agent.ID, 0, 100)
+	suite.Require().NoError(err)
+	messages := make([]string, len(lines))
+	for i, line := range lines {
+		messages[i] = line.Message
+	}
+	assert.Equal(suite.T(), []string{"three", "four", "five", "six"}, messages)
+
+	// Without after the newest lines come back, oldest first
+	lines, err = suite.repo.GetLogs(ctx, agent.ID, 0, 2)
+	suite.Require().NoError(err)
+	suite.Require().Len(lines, 2)
+	assert.Equal(suite.T(), "five", lines[0].Message)
+	assert.Equal(suite.T(), "six", lines[1].Message)
+
+	// Tailing picks up after the last line seen
+	lines, err = suite.repo.GetLogs(ctx, agent.ID, first[2].ID, 1)
+	suite.Require().NoError(err)
+	suite.Require().Len(lines, 1)
+	assert.Equal(suite.T(), "four", lines[0].Message)
+	assert.Equal(suite.T(), agent.ID, lines[0].AgentID)
+
+	// Deleting the agent drops its logs
+	suite.Require().NoError(suite.repo.Delete(ctx, agent.ID))
+	lines, err = suite.repo.GetLogs(ctx, agent.ID, 0, 100)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), lines)
+}
+
+func (suite *AgentRepositoryTestSuite) TestHashcatArgs() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	args, err := suite.repo.GetHashcatArgs(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), args)
+
+	suite.Require().NoError(suite.repo.UpdateHashcatArgs(ctx, agent.ID, []string{"-O", "-n", "64"}))
+	args, err = suite.repo.GetHashcatArgs(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{"-O", "-n", "64"}, args)
+
+	// An empty list clears them
+	suite.Require().NoError(suite.repo.UpdateHashcatArgs(ctx, agent.ID, nil))
+	args, err = suite.repo.GetHashcatArgs(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), args)
+
+	unknown := uuid.New()
+	_, err = suite.repo.GetHashcatArgs(ctx, unknown)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.UpdateHashcatArgs(ctx, unknown, []string{"-O"})))
+}
+
+func (suite *AgentRepositoryTestSuite) TestSettings() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	settings, err := suite.repo.GetSettings(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.AgentSettings{}, *settings)
+
+	want := domain.AgentSettings{PollIntervalSeconds: 30, WorkloadProfile: 3, MaxConcurrentJobs: 2, UploadDir: "/data/hashcat"}
+	suite.Require().NoError(suite.repo.UpdateSettings(ctx, agent.ID, &want))
+	settings, err = suite.repo.GetSettings(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), want, *settings)
+
+	unknown := uuid.New()
+	_, err = suite.repo.GetSettings(ctx, unknown)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.UpdateSettings(ctx, unknown, &want)))
+}
+
+func (suite *AgentRepositoryTestSuite) TestAgentFiles() {
+	ctx := context.Background()
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-1",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, agent))
+
+	files, err := suite.repo.GetFiles(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), files)
+
+	reported := time.Now().UTC().Truncate(time.Second)
+	suite.Require().NoError(suite.repo.ReplaceFiles(ctx, agent.ID, []domain.AgentFile{
+		{Name: "rockyou.txt", Path: "/data/wordlists/rockyou.txt", Size: 1024, Type: "wordlist", MD5: "abc", ModTime: reported, ReportedAt: reported},
+		{Name: "hashes.txt", Path: "/data/hash-files/hashes.txt", Size: 64, Type: "hash_file", ReportedAt: reported},
+	}))
+
+	files, err = suite.repo.GetFiles(ctx, agent.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(files, 2)
+	assert.Equal(suite.T(), "hashes.txt", files[0].Name)
+	assert.True(suite.T(), files[0].ModTime.IsZero())
+	assert.Equal(suite.T(), "rockyou.txt", files[1].Name)
+	assert.Equal(suite.T(), int64(1024), files[1].Size)
+	assert.Equal(suite.T(), "abc", files[1].MD5)
+	assert.True(suite.T(), reported.Equal(files[1].ModTime))
+	assert.Equal(suite.T(), agent.ID, files[1].AgentID)
+
+	// A new report replaces the old inventory
+	suite.Require().NoError(suite.repo.ReplaceFiles(ctx, agent.ID, []domain.AgentFile{
+		{Name: "top1000.txt", Path: "/data/wordlists/top1000.txt", Size: 8, Type: "wordlist", ReportedAt: reported},
+	}))
+	files, err = suite.repo.GetFiles(ctx, agent.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(files, 1)
+	assert.Equal(suite.T(), "top1000.txt", files[0].Name)
+
+	// Deleting the agent drops its inventory
+	suite.Require().NoError(suite.repo.Delete(ctx, agent.ID))
+	files, err = suite.repo.GetFiles(ctx, agent.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), files)
+}
+
+func (suite *AgentRepositoryTestSuite) TestStaleAgents() {
+	ctx := context.Background()
+	now := time.Now()
+	newAgent := func(name string, lastSeen time.Time) *domain.Agent {
+		agent := &domain.Agent{ID: uuid.New(), Name: name, IPAddress: "10.0.0." + name[len(name)-1:], Port: 8080, Status: "offline",
+			Speed: 1000, LastSeen: lastSeen, CreatedAt: lastSeen, UpdatedAt: lastSeen}
+		suite.Require().NoError(suite.repo.Create(ctx, agent))
+		return agent
+	}
+	busy := newAgent("gpu-1", now.Add(-40*24*time.Hour))
+	idle := newAgent("gpu-2", now.Add(-35*24*time.Hour))
+	newAgent("gpu-3", now.Add(-time.Hour))
+	_, err := suite.db.DB().Exec(`INSERT INTO jobs (id, name, status, hash_type, attack_mode, hash_file, wordlist, agent_id, created_at, updated_at)
+		VALUES (?, 'job', ?, 0, 0, 'hashes.txt', 'rockyou.txt', ?, ?, ?)`, uuid.New().String(), domain.JobStatusRunning, busy.ID.String(), now, now)
+	suite.Require().NoError(err)
+
+	stale, err := suite.repo.GetStale(ctx, now.Add(-30*24*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().Len(stale, 2)
+	assert.Equal(suite.T(), "gpu-1", stale[0].Name, "oldest first")
+	assert.Equal(suite.T(), 1, stale[0].ActiveJobs)
+	assert.Equal(suite.T(), idle.ID, stale[1].ID)
+	assert.Zero(suite.T(), stale[1].ActiveJobs)
+	assert.Nil(suite.T(), stale[1].ArchivedAt)
+
+	// Archiving hides the agent and drops its telemetry
+	suite.Require().NoError(suite.repo.ReplaceFiles(ctx, idle.ID, []domain.AgentFile{{Name: "rockyou.txt", Path: "/data/rockyou.txt", Type: "wordlist", ReportedAt: now}}))
+	suite.Require().NoError(suite.repo.AppendLogs(ctx, idle.ID, []domain.AgentLogLine{{Time: now, Source: domain.AgentLogSourceAgent, Message: "bye"}}, 10))
+	suite.Require().NoError(suite.repo.Archive(ctx, idle.ID))
+	assert.ErrorIs(suite.T(), suite.repo.Archive(ctx, uuid.New()), domain.ErrAgentNotFound)
+
+	agents, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), agents, 2)
+	archived, err := suite.repo.GetByID(ctx, idle.ID)
+	suite.Require().NoError(err)
+	assert.Zero(suite.T(), archived.Speed)
+	files, err := suite.repo.GetFiles(ctx, idle.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), files)
+	logs, err := suite.repo.GetLogs(ctx, idle.ID, 0, 10)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), logs)
+
+	stale, err = suite.repo.GetStale(ctx, now.Add(-30*24*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().Len(stale, 2)
+	assert.NotNil(suite.T(), stale[1].ArchivedAt)
+
+	// Hearing from the agent again brings it back
+	suite.Require().NoError(suite.repo.UpdateLastSeen(ctx, idle.ID))
+	agents, err = suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), agents, 3)
+}
+
 func TestAgentRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(AgentRepositoryTestSuite))
 }
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharsetRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewCharsetRepository(db)
+	ctx := context.Background()
+
+	charset := &domain.CharsetFile{ID: uuid.New(), Name: "c.hcchr", OrigName: "german.hcchr", Path: "/uploads/charsets/c.hcchr", Size: 8, SHA256: "abc"}
+	require.NoError(t, repo.Create(ctx, charset))
+
+	found, err := repo.GetByID(ctx, charset.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "german.hcchr", found.OrigName)
+	assert.Equal(t, int64(8), found.Size)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	require.NoError(t, repo.Delete(ctx, charset.ID))
+	_, err = repo.GetByID(ctx, charset.ID)
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, charset.ID)))
+}
+
+func TestJobRepository_CustomCharsets(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewJobRepository(db)
+	ctx := context.Background()
+
+	charsetFileID := uuid.NewString()
+	job := &domain.Job{ID: uuid.New(), Name: "mask", Status: domain.JobStatusPending, AttackMode: domain.AttackModeBruteForce,
+		HashFile: "a.hash", Wordlist: "?1?2?d", CustomCharset1: "?l?u", CustomCharset2: charsetFileID}
+	require.NoError(t, repo.Create(ctx, job))
+
+	jobs, _, err := repo.List(ctx, domain.JobFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "?l?u", jobs[0].CustomCharset1)
+	assert.Equal(t, charsetFileID, jobs[0].CustomCharset2)
+	assert.Empty(t, jobs[0].CustomCharset3)
+
+	job.CustomCharset3 = "?d?s"
+	require.NoError(t, repo.Update(ctx, job))
+	jobs, _, err = repo.List(ctx, domain.JobFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "?d?s", jobs[0].CustomCharset3)
+}
+
+func TestJobRepository_ExtraArgs(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewJobRepository(db)
+	ctx := context.Background()
+
+	job := &domain.Job{ID: uuid.New(), Name: "tuned", Status: domain.JobStatusPending,
+		HashFile: "a.hash", Wordlist: "rockyou.txt", ExtraArgs: []string{"-O", "--kernel-accel=64"}}
+	require.NoError(t, repo.Create(ctx, job))
+
+	stored, err := repo.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-O", "--kernel-accel=64"}, stored.ExtraArgs)
+
+	job.ExtraArgs = nil
+	require.NoError(t, repo.Update(ctx, job))
+	jobs, _, err := repo.List(ctx, domain.JobFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Empty(t, jobs[0].ExtraArgs)
+}
+
+func TestJobRepository_Engine(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewJobRepository(db)
+	ctx := context.Background()
+
+	legacy := &domain.Job{ID: uuid.New(), Name: "legacy", Status: domain.JobStatusPending, HashFile: "a.hash", Wordlist: "rockyou.txt"}
+	john := &domain.Job{ID: uuid.New(), Name: "john", Status: domain.JobStatusPending, HashFile: "a.hash", Wordlist: "rockyou.txt",
+		Engine: domain.EngineJohn, JohnFormat: "raw-md5"}
+	require.NoError(t, repo.Create(ctx, legacy))
+	require.NoError(t, repo.Create(ctx, john))
+
+	stored, err := repo.GetByID(ctx, legacy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.EngineHashcat, stored.Engine)
+	assert.Empty(t, stored.JohnFormat)
+
+	stored, err = repo.GetByID(ctx, john.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.EngineJohn, stored.Engine)
+	assert.Equal(t, "raw-md5", stored.JohnFormat)
+}
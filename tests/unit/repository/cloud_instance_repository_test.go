@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudInstanceRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewCloudInstanceRepository(db)
+	ctx := context.Background()
+
+	instance := &domain.CloudInstance{
+		Provider:   "hetzner",
+		ProviderID: "4711",
+		AgentID:    uuid.New(),
+		Status:     domain.CloudInstanceProvisioning,
+		HourlyCost: 0.5,
+	}
+	require.NoError(t, repo.Create(ctx, instance))
+	assert.NotEqual(t, uuid.Nil, instance.ID)
+
+	active, err := repo.GetActive(ctx)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "4711", active[0].ProviderID)
+	assert.Equal(t, instance.AgentID, active[0].AgentID)
+	assert.Equal(t, 0.5, active[0].HourlyCost)
+	assert.Nil(t, active[0].IdleSince)
+
+	idleSince := time.Now().Add(-time.Minute)
+	instance.Status = domain.CloudInstanceRunning
+	instance.IdleSince = &idleSince
+	require.NoError(t, repo.Update(ctx, instance))
+
+	active, err = repo.GetActive(ctx)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, domain.CloudInstanceRunning, active[0].Status)
+	require.NotNil(t, active[0].IdleSince)
+	assert.WithinDuration(t, idleSince, *active[0].IdleSince, time.Second)
+
+	// Terminated instances are no longer active
+	terminatedAt := time.Now()
+	instance.Status = domain.CloudInstanceTerminated
+	instance.TerminatedAt = &terminatedAt
+	require.NoError(t, repo.Update(ctx, instance))
+
+	active, err = repo.GetActive(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+
+	err = repo.Update(ctx, &domain.CloudInstance{ID: uuid.New(), Status: domain.CloudInstanceRunning})
+	assert.True(t, domain.IsNotFoundError(err))
+}
@@ -0,0 +1,82 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	cipher, err := database.NewFieldCipher("credential-test-key")
+	require.NoError(t, err)
+	db.SetFieldCipher(cipher)
+
+	ctx := context.Background()
+	repo := repository.NewCredentialRepository(db)
+
+	hashFileID, projectID, jobID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	credentials := []domain.Credential{
+		{Username: "alice", Domain: "CORP", Hash: "8846f7eaee8fb117ad06bdd830b7586c", Plaintext: "password", HashType: 1000,
+			HashFileID: &hashFileID, SourceFile: "corp.nt.txt", Source: domain.CredentialSourceJob, JobID: &jobID, ProjectID: &projectID, CrackedAt: now.Add(-time.Hour)},
+		{Username: "bob", Domain: "CORP", Hash: "2d20d252a479f485cdf5e171d93985bf", Plaintext: "Summer2026!", HashType: 1000,
+			HashFileID: &hashFileID, SourceFile: "corp.nt.txt", Source: domain.CredentialSourcePotfile, CrackedAt: now},
+		{Username: "bob", Domain: "LAB", Hash: "2d20d252a479f485cdf5e171d93985bf", Plaintext: "Summer2026!", HashType: 1000,
+			HashFileID: &hashFileID, SourceFile: "corp.nt.txt", Source: domain.CredentialSourcePotfile, CrackedAt: now},
+	}
+	recorded, err := repo.Record(ctx, credentials)
+	require.NoError(t, err)
+	assert.Equal(t, 3, recorded)
+
+	// The same account and hash of a file is recorded once
+	recorded, err = repo.Record(ctx, []domain.Credential{{Username: "alice", Domain: "CORP", Hash: "8846f7eaee8fb117ad06bdd830b7586c",
+		Plaintext: "password", HashFileID: &hashFileID, Source: domain.CredentialSourcePotfile}})
+	require.NoError(t, err)
+	assert.Zero(t, recorded)
+
+	// Plaintexts are encrypted at rest
+	var stored string
+	require.NoError(t, db.DB().QueryRow(`SELECT plaintext FROM credentials WHERE username = 'alice'`).Scan(&stored))
+	assert.True(t, database.IsSealed(stored))
+
+	all, total, err := repo.GetAll(ctx, domain.CredentialFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, all, 3)
+	assert.Equal(t, "alice", all[2].Username, "newest first")
+	assert.Equal(t, "password", all[2].Plaintext)
+	assert.Equal(t, &jobID, all[2].JobID)
+	assert.Equal(t, &projectID, all[2].ProjectID)
+	assert.Equal(t, "corp.nt.txt", all[2].SourceFile)
+
+	bobs, total, err := repo.GetAll(ctx, domain.CredentialFilter{Username: "BOB", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, bobs, 1)
+
+	lab, _, err := repo.GetAll(ctx, domain.CredentialFilter{Domain: "lab"})
+	require.NoError(t, err)
+	require.Len(t, lab, 1)
+	assert.Equal(t, "Summer2026!", lab[0].Plaintext)
+
+	inProject, _, err := repo.GetAll(ctx, domain.CredentialFilter{ProjectID: &projectID, HashFileID: &hashFileID})
+	require.NoError(t, err)
+	assert.Len(t, inProject, 1)
+
+	// Tenants only see the credentials of their own jobs and hash files
+	tenantCredentials, total, err := repo.GetAll(domain.WithTenantID(ctx, uuid.New()), domain.CredentialFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, tenantCredentials)
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteDB_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := database.NewSQLiteDB(filepath.Join(dir, "hashcat.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.DB().Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY); INSERT INTO schema_migrations VALUES (7), (41)`)
+	require.NoError(t, err)
+	project := &domain.Project{Name: "acme"}
+	require.NoError(t, repository.NewProjectRepository(db).Create(ctx, project))
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, db.Snapshot(ctx, snapshot))
+	version, err := database.VerifySnapshot(ctx, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, 41, version)
+
+	// The copy is a database of its own, the WAL of the live one included
+	copied, err := database.NewSQLiteDB(snapshot)
+	require.NoError(t, err)
+	defer copied.Close()
+	restored, err := repository.NewProjectRepository(copied).GetByID(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", restored.Name)
+
+	t.Run("damaged copies fail the check", func(t *testing.T) {
+		damaged := filepath.Join(dir, "damaged.db")
+		content, err := os.ReadFile(snapshot)
+		require.NoError(t, err)
+		for i := 100; i < 200; i++ {
+			content[i] = 0xff
+		}
+		require.NoError(t, os.WriteFile(damaged, content, 0600))
+		_, err = database.VerifySnapshot(ctx, damaged)
+		assert.Error(t, err)
+
+		_, err = database.VerifySnapshot(ctx, filepath.Join(dir, "missing.db"))
+		assert.Error(t, err)
+	})
+}
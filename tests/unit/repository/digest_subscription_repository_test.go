@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestSubscriptionRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewDigestSubscriptionRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+
+	project := &domain.Project{Name: "Red team"}
+	require.NoError(t, projectRepo.Create(ctx, project))
+	userID := uuid.New()
+
+	require.NoError(t, repo.Save(ctx, &domain.DigestSubscription{UserID: userID, ProjectID: project.ID, Frequency: domain.DigestDaily}))
+	sentAt := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.MarkSent(ctx, userID, project.ID, sentAt))
+
+	// Saving again changes the frequency but keeps when it was last sent
+	require.NoError(t, repo.Save(ctx, &domain.DigestSubscription{UserID: userID, ProjectID: project.ID, Frequency: domain.DigestWeekly}))
+
+	subscriptions, err := repo.GetByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "Red team", subscriptions[0].ProjectName)
+	assert.Equal(t, domain.DigestWeekly, subscriptions[0].Frequency)
+	require.NotNil(t, subscriptions[0].LastSentAt)
+	assert.True(t, sentAt.Equal(*subscriptions[0].LastSentAt))
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// Deleting the project drops its subscriptions
+	require.NoError(t, projectRepo.Delete(ctx, project.ID))
+	all, err = repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, userID, project.ID)))
+}
@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectivenessRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewEffectivenessRepository(db)
+	rockyou, groupID := uuid.New(), uuid.New()
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+	yesterday := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+	records := []domain.JobEffectiveness{
+		// Two sub-jobs of one distributed job
+		{JobID: uuid.New(), GroupID: &groupID, HashType: 1000, WordlistID: &rockyou, Wordlist: "rockyou.txt",
+			Keyspace: 5_000_000, Cracks: 4, DeviceSeconds: 1800, CompletedAt: lastWeek},
+		{JobID: uuid.New(), GroupID: &groupID, HashType: 1000, WordlistID: &rockyou, Wordlist: "rockyou.txt",
+			Keyspace: 5_000_000, DeviceSeconds: 1800, CompletedAt: lastWeek},
+		{JobID: uuid.New(), HashType: 1000, WordlistID: &rockyou, Wordlist: "rockyou.txt", Rules: "best64",
+			Keyspace: 77_000_000, Cracks: 6, DeviceSeconds: 3600, CompletedAt: yesterday},
+		{JobID: uuid.New(), HashType: 0, AttackMode: domain.AttackModeBruteForce, Mask: "?d?d?d?d",
+			Keyspace: 10_000, CompletedAt: yesterday},
+	}
+	for i := range records {
+		require.NoError(t, repo.Record(ctx, &records[i]))
+	}
+	// Recording a job again replaces its record
+	records[3].Cracks = 2
+	require.NoError(t, repo.Record(ctx, &records[3]))
+
+	totals, err := repo.Aggregate(ctx, domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupWordlist})
+	require.NoError(t, err)
+	require.Len(t, totals, 1, "masks have no wordlist")
+	assert.Equal(t, rockyou.String(), totals[0].Key)
+	assert.Equal(t, "rockyou.txt", totals[0].Name)
+	assert.Equal(t, 2, totals[0].Jobs, "sub-jobs count as one job")
+	assert.Equal(t, 2, totals[0].JobsWithCracks)
+	assert.Equal(t, 10, totals[0].Cracks)
+	assert.Equal(t, int64(87_000_000), totals[0].Keyspace)
+	assert.InDelta(t, 7200, totals[0].DeviceSeconds, 0.001)
+	assert.True(t, totals[0].LastUsedAt.Equal(yesterday))
+
+	totals, err = repo.Aggregate(ctx, domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupMask})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, "?d?d?d?d", totals[0].Key)
+	assert.Equal(t, 2, totals[0].Cracks)
+
+	hashType := 1000
+	since := time.Now().Add(-48 * time.Hour)
+	totals, err = repo.Aggregate(ctx, domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupRules, HashType: &hashType, From: &since})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, "best64", totals[0].Name)
+	assert.Equal(t, 1, totals[0].Jobs)
+
+	totals, err = repo.Aggregate(ctx, domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupWordlist, To: &lastWeek})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, 4, totals[0].Cracks, "only last week's distributed job")
+}
@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollmentRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewEnrollmentRepository(db)
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "gpu-01",
+		Status:    "offline",
+		AgentKey:  "1a2b3c4d",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, repository.NewAgentRepository(db).Create(ctx, agent))
+
+	token := &domain.EnrollmentToken{Label: "rack 3", TokenHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(ctx, token))
+	expired := &domain.EnrollmentToken{TokenHash: "hash-2", ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, repo.Create(ctx, expired))
+
+	got, err := repo.GetByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, got.ID)
+	assert.Equal(t, "rack 3", got.Label)
+	assert.Nil(t, got.UsedAt)
+
+	_, err = repo.GetByHash(ctx, "unknown")
+	assert.True(t, domain.IsNotFoundError(err))
+
+	// Tokens are used once
+	require.NoError(t, repo.Redeem(ctx, token.ID, agent.ID, time.Now()))
+	assert.ErrorIs(t, repo.Redeem(ctx, token.ID, agent.ID, time.Now()), domain.ErrEnrollmentTokenUsed)
+	assert.ErrorIs(t, repo.Redeem(ctx, expired.ID, agent.ID, time.Now()), domain.ErrEnrollmentTokenExpired)
+
+	got, err = repo.GetByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	require.NotNil(t, got.UsedAt)
+	assert.Equal(t, agent.ID, *got.AgentID)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.Delete(ctx, expired.ID))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, expired.ID)))
+}
@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashFileOperationRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewHashFileOperationRepository(db)
+
+	inputs := []uuid.UUID{uuid.New(), uuid.New()}
+	merge := &domain.HashFileOperation{
+		Type:     domain.HashFileOperationMerge,
+		Status:   domain.HashFileOperationPending,
+		InputIDs: inputs,
+		Name:     "merged.txt",
+	}
+	require.NoError(t, repo.Create(ctx, merge))
+	assert.NotEqual(t, uuid.Nil, merge.ID)
+
+	got, err := repo.GetByID(ctx, merge.ID)
+	require.NoError(t, err)
+	assert.Equal(t, inputs, got.InputIDs)
+	assert.Empty(t, got.ResultIDs)
+	assert.Nil(t, got.CompletedAt)
+
+	result := uuid.New()
+	completed := time.Now()
+	merge.Status = domain.HashFileOperationCompleted
+	merge.Progress = 100
+	merge.ResultIDs = []uuid.UUID{result}
+	merge.Counts = map[string]int64{"lines_read": 10, "lines_written": 7, "duplicates": 3}
+	merge.CompletedAt = &completed
+	require.NoError(t, repo.Update(ctx, merge))
+
+	got, err = repo.GetByID(ctx, merge.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HashFileOperationCompleted, got.Status)
+	assert.Equal(t, float64(100), got.Progress)
+	assert.Equal(t, []uuid.UUID{result}, got.ResultIDs)
+	assert.Equal(t, int64(3), got.Counts["duplicates"])
+	require.NotNil(t, got.CompletedAt)
+
+	split := &domain.HashFileOperation{
+		Type:     domain.HashFileOperationSplit,
+		Status:   domain.HashFileOperationRunning,
+		InputIDs: inputs[:1],
+		Parts:    4,
+	}
+	require.NoError(t, repo.Create(ctx, split))
+
+	recent, err := repo.GetRecent(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, split.ID, recent[0].ID, "newest first")
+	assert.Equal(t, 4, recent[0].Parts)
+
+	// A restart fails what was left running, and only that
+	failed, err := repo.FailUnfinished(ctx, "interrupted by server restart")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failed)
+
+	got, err = repo.GetByID(ctx, split.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HashFileOperationFailed, got.Status)
+	assert.Equal(t, "interrupted by server restart", got.Error)
+	got, err = repo.GetByID(ctx, merge.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.HashFileOperationCompleted, got.Status)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.True(t, domain.IsNotFoundError(repo.Update(ctx, &domain.HashFileOperation{ID: uuid.New()})))
+}
@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repository.NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	existing, err := repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-1", RequestHash: "abc"})
+	require.NoError(t, err)
+	assert.Nil(t, existing, "first reservation should claim the key")
+
+	// A second request sees the in-progress record
+	existing, err = repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-1", RequestHash: "abc"})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "abc", existing.RequestHash)
+	assert.Equal(t, 0, existing.StatusCode)
+
+	require.NoError(t, repo.Complete(ctx, "create-job-1", 201, []byte(`{"data":{}}`)))
+	existing, err = repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-1", RequestHash: "abc"})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 201, existing.StatusCode)
+	assert.Equal(t, `{"data":{}}`, string(existing.Response))
+
+	t.Run("released keys can be reserved again", func(t *testing.T) {
+		_, err := repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-2", RequestHash: "def"})
+		require.NoError(t, err)
+		require.NoError(t, repo.Release(ctx, "create-job-2"))
+
+		existing, err := repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-2", RequestHash: "def"})
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("expired keys are deleted", func(t *testing.T) {
+		_, err := repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "old", RequestHash: "x", CreatedAt: time.Now().Add(-48 * time.Hour)})
+		require.NoError(t, err)
+
+		deleted, err := repo.DeleteExpired(ctx, time.Now().Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		existing, err := repo.Reserve(ctx, &domain.IdempotencyRecord{Key: "create-job-1", RequestHash: "abc"})
+		require.NoError(t, err)
+		assert.NotNil(t, existing, "recent keys are kept")
+	})
+}
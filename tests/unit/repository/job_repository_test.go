@@ -2,6 +2,8 @@ package repository_test
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -203,6 +205,19 @@ func (suite *JobRepositoryTestSuite) TestGetByStatus() {
 	completedJobs, err := suite.repo.GetByStatus(context.Background(), "completed")
 	assert.NoError(suite.T(), err)
 	assert.Empty(suite.T(), completedJobs)
+
+	// The cached lists follow status changes, or the dispatcher would hand
+	// out jobs that already run
+	agentID := uuid.New()
+	pendingJob.Status = "running"
+	pendingJob.AgentID = &agentID
+	suite.Require().NoError(suite.repo.Update(context.Background(), pendingJob))
+	pendingJobs, err = suite.repo.GetByStatus(context.Background(), "pending")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), pendingJobs)
+	runningJobs, err = suite.repo.GetByStatus(context.Background(), "running")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), runningJobs, 2)
 }
 
 func (suite *JobRepositoryTestSuite) TestUpdateStatus() {
@@ -260,6 +275,9 @@ func (suite *JobRepositoryTestSuite) TestUpdateProgress() {
 	err = suite.repo.UpdateProgress(context.Background(), job.ID, newProgress, newSpeed)
 	assert.NoError(suite.T(), err)
 
+	// Progress is written with the next batch
+	suite.Require().NoError(suite.db.FlushWrites(context.Background()))
+
 	// Verify progress was updated
 	retrievedJob, err := suite.repo.GetByID(context.Background(), job.ID)
 	assert.NoError(suite.T(), err)
@@ -267,6 +285,72 @@ func (suite *JobRepositoryTestSuite) TestUpdateProgress() {
 	assert.Equal(suite.T(), newSpeed, retrievedJob.Speed)
 }
 
+func (suite *JobRepositoryTestSuite) TestUpdateProgressCoalesces() {
+	ctx := context.Background()
+	running := &domain.Job{ID: uuid.New(), Name: "Running", Status: "running", HashFile: "/tmp/test.hash", Wordlist: "rockyou.txt"}
+	finished := &domain.Job{ID: uuid.New(), Name: "Finished", Status: "completed", Progress: 100, HashFile: "/tmp/test.hash", Wordlist: "rockyou.txt"}
+	suite.Require().NoError(suite.repo.Create(ctx, running))
+	suite.Require().NoError(suite.repo.Create(ctx, finished))
+
+	for _, progress := range []float64{10, 20, 30} {
+		suite.Require().NoError(suite.repo.UpdateProgress(ctx, running.ID, progress, 500))
+	}
+	// A late report must not undo the final state
+	suite.Require().NoError(suite.repo.UpdateProgress(ctx, finished.ID, 90, 500))
+
+	stats := suite.db.DatabaseStats()
+	assert.Equal(suite.T(), 2, stats.QueuedWrites)
+	assert.Equal(suite.T(), int64(2), stats.Coalesced)
+
+	suite.Require().NoError(suite.db.FlushWrites(ctx))
+
+	stats = suite.db.DatabaseStats()
+	assert.Equal(suite.T(), 0, stats.QueuedWrites)
+	assert.Equal(suite.T(), int64(2), stats.WrittenBatched)
+	assert.Equal(suite.T(), int64(1), stats.Batches)
+
+	got, err := suite.repo.GetByID(ctx, running.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 30.0, got.Progress)
+
+	got, err = suite.repo.GetByID(ctx, finished.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 100.0, got.Progress)
+}
+
+func (suite *JobRepositoryTestSuite) TestUpdateProgressBatch() {
+	ctx := context.Background()
+	running := &domain.Job{ID: uuid.New(), Name: "Running", Status: "running", HashFile: "/tmp/test.hash", Wordlist: "rockyou.txt"}
+	finished := &domain.Job{ID: uuid.New(), Name: "Finished", Status: "completed", Progress: 100, HashFile: "/tmp/test.hash", Wordlist: "rockyou.txt"}
+	suite.Require().NoError(suite.repo.Create(ctx, running))
+	suite.Require().NoError(suite.repo.Create(ctx, finished))
+
+	// Cached before the batch, which must invalidate it
+	_, err := suite.repo.GetByID(ctx, running.ID)
+	suite.Require().NoError(err)
+
+	eta := time.Now().Add(time.Hour).Truncate(time.Second)
+	err = suite.repo.UpdateProgressBatch(ctx, map[uuid.UUID]domain.JobProgress{
+		running.ID:  {Progress: 42, Speed: 1000, ETA: &eta, DeviceSeconds: 60, EnergyWh: 5, ReportedAt: time.Now()},
+		finished.ID: {Progress: 90, Speed: 1000, ReportedAt: time.Now()},
+	})
+	suite.Require().NoError(err)
+
+	got, err := suite.repo.GetByID(ctx, running.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 42.0, got.Progress)
+	assert.Equal(suite.T(), int64(1000), got.Speed)
+	assert.Equal(suite.T(), 60.0, got.DeviceSeconds)
+	assert.Equal(suite.T(), 5.0, got.EnergyWh)
+	suite.Require().NotNil(got.ETA)
+	assert.True(suite.T(), eta.Equal(*got.ETA))
+
+	// A late report must not undo the final state
+	got, err = suite.repo.GetByID(ctx, finished.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 100.0, got.Progress)
+}
+
 func (suite *JobRepositoryTestSuite) TestDelete() {
 	// Create a job first
 	job := &domain.Job{
@@ -378,6 +462,766 @@ func (suite *JobRepositoryTestSuite) TestGetByAgentID() {
 	assert.Empty(suite.T(), noJobs)
 }
 
+func (suite *JobRepositoryTestSuite) TestJobGroups() {
+	ctx := context.Background()
+
+	group := &domain.JobGroup{Name: "Distributed Job"}
+	suite.Require().NoError(suite.repo.CreateGroup(ctx, group))
+	assert.NotEqual(suite.T(), uuid.Nil, group.ID)
+
+	retrieved, err := suite.repo.GetGroupByID(ctx, group.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Distributed Job", retrieved.Name)
+
+	_, err = suite.repo.GetGroupByID(ctx, uuid.New())
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+
+	for i, name := range []string{"Part 1", "Part 2"} {
+		job := &domain.Job{
+			ID:         uuid.New(),
+			Name:       name,
+			Status:     "pending",
+			HashType:   2500,
+			AttackMode: 0,
+			HashFile:   "/tmp/test.hash",
+			Wordlist:   "rockyou.txt",
+			GroupID:    &group.ID,
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, job), "job %d", i)
+	}
+	ungrouped := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Ungrouped",
+		Status:   "pending",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, ungrouped))
+
+	jobs, err := suite.repo.GetByGroupID(ctx, group.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(jobs, 2)
+	assert.Equal(suite.T(), "Part 1", jobs[0].Name)
+	suite.Require().NotNil(jobs[0].GroupID)
+	assert.Equal(suite.T(), group.ID, *jobs[0].GroupID)
+
+	fetched, err := suite.repo.GetByID(ctx, ungrouped.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), fetched.GroupID)
+}
+
+func (suite *JobRepositoryTestSuite) TestCreateBatch() {
+	ctx := context.Background()
+
+	newJob := func(name string, groupID *uuid.UUID) *domain.Job {
+		return &domain.Job{
+			ID:       uuid.New(),
+			Name:     name,
+			Status:   "pending",
+			HashFile: "/tmp/test.hash",
+			Wordlist: "rockyou.txt",
+			GroupID:  groupID,
+		}
+	}
+
+	group := &domain.JobGroup{ID: uuid.New(), Name: "Distributed"}
+	jobs := []*domain.Job{newJob("Part 1", &group.ID), newJob("Part 2", &group.ID)}
+	suite.Require().NoError(suite.repo.CreateBatch(ctx, group, jobs))
+
+	stored, err := suite.repo.GetByGroupID(ctx, group.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), stored, 2)
+
+	// A failing insert rolls back the group and the jobs before it
+	failedGroup := &domain.JobGroup{ID: uuid.New(), Name: "Failed"}
+	duplicate := newJob("Duplicate", &failedGroup.ID)
+	duplicate.ID = jobs[0].ID
+	err = suite.repo.CreateBatch(ctx, failedGroup, []*domain.Job{newJob("Part 1", &failedGroup.ID), duplicate})
+	suite.Require().Error(err)
+
+	_, err = suite.repo.GetGroupByID(ctx, failedGroup.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+	all, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), all, 2)
+}
+
+func (suite *JobRepositoryTestSuite) TestEvents() {
+	ctx := context.Background()
+
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Timeline",
+		Status:   "pending",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	start := time.Now()
+	transitions := [][2]string{{"", "pending"}, {"pending", "running"}, {"running", "cancelled"}}
+	for i, transition := range transitions {
+		suite.Require().NoError(suite.repo.CreateEvent(ctx, &domain.JobEvent{
+			JobID:      job.ID,
+			FromStatus: transition[0],
+			ToStatus:   transition[1],
+			Actor:      "system",
+			CreatedAt:  start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	events, err := suite.repo.GetEvents(ctx, job.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(events, 3)
+	assert.Equal(suite.T(), "", events[0].FromStatus)
+	assert.Equal(suite.T(), "cancelled", events[2].ToStatus)
+
+	// Purging a deleted job removes its events too
+	suite.Require().NoError(suite.repo.Delete(ctx, job.ID))
+	_, err = suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+
+	events, err = suite.repo.GetEvents(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), events)
+}
+
+func (suite *JobRepositoryTestSuite) TestTagsAndComments() {
+	ctx := context.Background()
+
+	tagged := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Client X",
+		Status:   "pending",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+		Tags:     []string{"client-x", "phase-2"},
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, tagged))
+	other := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Untagged",
+		Status:   "pending",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, other))
+
+	got, err := suite.repo.GetByID(ctx, tagged.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{"client-x", "phase-2"}, got.Tags)
+
+	// Every tag must match, ignoring case
+	jobs, total, err := suite.repo.List(ctx, domain.JobFilter{Tags: []string{"Client-X", "phase-2"}})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, total)
+	suite.Require().Len(jobs, 1)
+	assert.Equal(suite.T(), tagged.ID, jobs[0].ID)
+	_, total, err = suite.repo.List(ctx, domain.JobFilter{Tags: []string{"client-x", "retest"}})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, total)
+
+	suite.Require().NoError(suite.repo.UpdateTags(ctx, other.ID, []string{"retest"}))
+	_, total, err = suite.repo.List(ctx, domain.JobFilter{Tags: []string{"retest"}})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, total)
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.UpdateTags(ctx, uuid.New(), nil)))
+
+	authorID := uuid.New()
+	first := &domain.JobComment{JobID: tagged.ID, AuthorID: &authorID, Author: "alice", Body: "Scope confirmed", CreatedAt: time.Now().Add(-time.Minute)}
+	suite.Require().NoError(suite.repo.CreateComment(ctx, first))
+	suite.Require().NoError(suite.repo.CreateComment(ctx, &domain.JobComment{JobID: tagged.ID, Author: "bob", Body: "Rerun with rules"}))
+
+	comments, err := suite.repo.GetComments(ctx, tagged.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(comments, 2)
+	assert.Equal(suite.T(), "alice", comments[0].Author)
+	assert.Equal(suite.T(), authorID, *comments[0].AuthorID)
+	assert.Nil(suite.T(), comments[1].AuthorID)
+
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, tagged.ID, first.ID))
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.DeleteComment(ctx, tagged.ID, first.ID)))
+
+	// Purging a deleted job removes its comments too
+	suite.Require().NoError(suite.repo.Delete(ctx, tagged.ID))
+	_, err = suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	comments, err = suite.repo.GetComments(ctx, tagged.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), comments)
+}
+
+func (suite *JobRepositoryTestSuite) TestCrackedStatusMigration() {
+	ctx := context.Background()
+	path := filepath.Join(suite.T().TempDir(), "jobs.db")
+
+	db, err := database.NewSQLiteDB(path)
+	suite.Require().NoError(err)
+	repo := repository.NewJobRepository(db)
+
+	// Rows written before the cracked status existed
+	expected := map[string]string{
+		"Password found: secret":                          "cracked",
+		"Password found (extraction failed)":              "cracked",
+		"Password not found - exhausted":                  "failed",
+		"Password found by another agent - job cancelled": "cancelled",
+	}
+	oldStatus := map[string]string{
+		"Password found: secret":                          "completed",
+		"Password found (extraction failed)":              "failed",
+		"Password not found - exhausted":                  "failed",
+		"Password found by another agent - job cancelled": "cancelled",
+	}
+	ids := make(map[string]uuid.UUID)
+	for result, status := range oldStatus {
+		job := &domain.Job{
+			ID:       uuid.New(),
+			Name:     result,
+			Status:   status,
+			HashFile: "/tmp/test.hash",
+			Wordlist: "rockyou.txt",
+			Result:   result,
+		}
+		suite.Require().NoError(repo.Create(ctx, job))
+		ids[result] = job.ID
+	}
+	suite.Require().NoError(db.Close())
+
+	// Reopening runs the migrations again
+	db, err = database.NewSQLiteDB(path)
+	suite.Require().NoError(err)
+	defer db.Close()
+	repo = repository.NewJobRepository(db)
+
+	for result, status := range expected {
+		job, err := repo.GetByID(ctx, ids[result])
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), status, job.Status, result)
+	}
+}
+
+func (suite *JobRepositoryTestSuite) TestRetriedFrom() {
+	ctx := context.Background()
+
+	original := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Original",
+		Status:   "failed",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	retry := &domain.Job{
+		ID:          uuid.New(),
+		Name:        "Original",
+		Status:      "pending",
+		HashFile:    "/tmp/test.hash",
+		Wordlist:    "rockyou.txt",
+		RetriedFrom: &original.ID,
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, original))
+	suite.Require().NoError(suite.repo.Create(ctx, retry))
+
+	retrieved, err := suite.repo.GetByID(ctx, retry.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(retrieved.RetriedFrom)
+	assert.Equal(suite.T(), original.ID, *retrieved.RetriedFrom)
+
+	retrieved, err = suite.repo.GetByID(ctx, original.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), retrieved.RetriedFrom)
+}
+
+func (suite *JobRepositoryTestSuite) TestCombinatorWordlist() {
+	ctx := context.Background()
+	wordlistRepo := repository.NewWordlistRepository(suite.db)
+	left := &domain.Wordlist{ID: uuid.New(), Name: "words.txt", OrigName: "words.txt", Path: "/tmp/words.txt"}
+	right := &domain.Wordlist{ID: uuid.New(), Name: "years.txt", OrigName: "years.txt", Path: "/tmp/years.txt"}
+	suite.Require().NoError(wordlistRepo.Create(ctx, left))
+	suite.Require().NoError(wordlistRepo.Create(ctx, right))
+
+	job := &domain.Job{
+		ID:          uuid.New(),
+		Name:        "Combinator",
+		Status:      "pending",
+		AttackMode:  domain.AttackModeCombinator,
+		HashFile:    "/tmp/test.hash",
+		Wordlist:    "words.txt",
+		WordlistID:  &left.ID,
+		Wordlist2ID: &right.ID,
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	// List reads from the database, not the job cache
+	jobs, _, err := suite.repo.List(ctx, domain.JobFilter{Limit: 10})
+	suite.Require().NoError(err)
+	suite.Require().Len(jobs, 1)
+	suite.Require().NotNil(jobs[0].Wordlist2ID)
+	assert.Equal(suite.T(), right.ID, *jobs[0].Wordlist2ID)
+
+	job.Wordlist2ID = nil
+	suite.Require().NoError(suite.repo.Update(ctx, job))
+	jobs, _, err = suite.repo.List(ctx, domain.JobFilter{Limit: 10})
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), jobs[0].Wordlist2ID)
+}
+
+func (suite *JobRepositoryTestSuite) TestRetention() {
+	ctx := context.Background()
+
+	completedAt := time.Now().Add(-48 * time.Hour)
+	oldJob := &domain.Job{
+		ID:          uuid.New(),
+		Name:        "Old Job",
+		Status:      "completed",
+		HashFile:    "/tmp/old.hash",
+		Wordlist:    "rockyou.txt",
+		CompletedAt: &completedAt,
+	}
+	runningJob := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Running Job",
+		Status:   "running",
+		HashFile: "/tmp/running.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, oldJob))
+	suite.Require().NoError(suite.repo.Create(ctx, runningJob))
+
+	// Only finished jobs past the cutoff are archived
+	archived, err := suite.repo.Archive(ctx, time.Now().Add(-24*time.Hour))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), archived)
+
+	jobs, err := suite.repo.GetAll(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(jobs, 1)
+	assert.Equal(suite.T(), "Running Job", jobs[0].Name)
+
+	// Soft-deleted jobs are hidden but kept
+	suite.Require().NoError(suite.repo.Delete(ctx, runningJob.ID))
+	_, err = suite.repo.GetByID(ctx, runningJob.ID)
+	assert.Error(suite.T(), err)
+
+	archivedJobs, err := suite.repo.GetArchived(ctx)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), archivedJobs, 2)
+
+	// Restore brings the job back
+	suite.Require().NoError(suite.repo.Restore(ctx, oldJob.ID))
+	restored, err := suite.repo.GetByID(ctx, oldJob.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), restored.ArchivedAt)
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.Restore(ctx, oldJob.ID)))
+
+	// Purge only removes jobs deleted before the cutoff
+	purged, err := suite.repo.Purge(ctx, time.Now().Add(-time.Hour))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(0), purged)
+
+	purged, err = suite.repo.Purge(ctx, time.Now().Add(time.Second))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), purged)
+	assert.True(suite.T(), domain.IsNotFoundError(suite.repo.Restore(ctx, runningJob.ID)))
+}
+
+func (suite *JobRepositoryTestSuite) TestList() {
+	ctx := context.Background()
+	agentID := uuid.New()
+	base := time.Now().Add(-time.Hour)
+
+	for i := 0; i < 5; i++ {
+		job := &domain.Job{
+			ID:       uuid.New(),
+			Name:     fmt.Sprintf("Job %d", i),
+			Status:   "pending",
+			HashType: 0,
+			HashFile: "/tmp/test.hash",
+			Wordlist: "rockyou.txt",
+			Progress: float64(i * 10),
+		}
+		if i%2 == 0 {
+			job.Status = "running"
+			job.HashType = 2500
+			job.AgentID = &agentID
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, job))
+	}
+
+	// Pagination with a total count
+	jobs, total, err := suite.repo.List(ctx, domain.JobFilter{Limit: 2, Offset: 2, SortBy: "progress"})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 5, total)
+	suite.Require().Len(jobs, 2)
+	assert.Equal(suite.T(), "Job 2", jobs[0].Name)
+	assert.Equal(suite.T(), "Job 3", jobs[1].Name)
+
+	// Filters combine
+	hashType := 2500
+	jobs, total, err = suite.repo.List(ctx, domain.JobFilter{
+		Status:   "running",
+		AgentID:  &agentID,
+		HashType: &hashType,
+		SortBy:   "progress",
+		SortDesc: true,
+	})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 3, total)
+	suite.Require().Len(jobs, 3)
+	assert.Equal(suite.T(), "Job 4", jobs[0].Name)
+
+	// Date range
+	future := time.Now().Add(time.Hour)
+	_, total, err = suite.repo.List(ctx, domain.JobFilter{CreatedFrom: &future})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, total)
+	_, total, err = suite.repo.List(ctx, domain.JobFilter{CreatedFrom: &base})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 5, total)
+
+	// Unknown sort columns fall back to created_at instead of reaching SQL
+	_, _, err = suite.repo.List(ctx, domain.JobFilter{SortBy: "name; DROP TABLE jobs"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *JobRepositoryTestSuite) TestGetAgentSpeedsByHashType() {
+	ctx := context.Background()
+	fastID, slowID := uuid.New(), uuid.New()
+
+	newJob := func(agentID *uuid.UUID, hashType int, speed int64) *domain.Job {
+		job := &domain.Job{
+			ID:        uuid.New(),
+			Name:      "speed",
+			Status:    "completed",
+			HashType:  hashType,
+			HashFile:  "/tmp/test.hash",
+			Wordlist:  "rockyou.txt",
+			AgentID:   agentID,
+			Speed:     speed,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, job))
+		return job
+	}
+	newJob(&fastID, 1000, 2000)
+	newJob(&fastID, 1000, 5000)
+	newJob(&slowID, 1000, 300)
+	newJob(&slowID, 2500, 90000) // Other hash mode
+	newJob(nil, 1000, 7000)      // Never assigned
+	deleted := newJob(&slowID, 1000, 99999)
+	suite.Require().NoError(suite.repo.Delete(ctx, deleted.ID))
+
+	speeds, err := suite.repo.GetAgentSpeedsByHashType(ctx, 1000)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), map[uuid.UUID]int64{fastID: 5000, slowID: 300}, speeds)
+
+	speeds, err = suite.repo.GetAgentSpeedsByHashType(ctx, 0)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), speeds)
+
+	// Jobs from before the agent's benchmarks were invalidated don't count
+	benchmarks := repository.NewAgentBenchmarkRepository(suite.db)
+	suite.Require().NoError(benchmarks.Invalidate(ctx, slowID, time.Now()))
+	speeds, err = suite.repo.GetAgentSpeedsByHashType(ctx, 1000)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), map[uuid.UUID]int64{fastID: 5000}, speeds)
+}
+
+func (suite *JobRepositoryTestSuite) TestOutput() {
+	ctx := context.Background()
+	agentID := uuid.New()
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Output",
+		Status:   "failed",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	_, err := suite.repo.GetOutput(ctx, job.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+
+	exitCode := 255
+	suite.Require().NoError(suite.repo.SaveOutput(ctx, &domain.JobOutput{
+		JobID:    job.ID,
+		AgentID:  &agentID,
+		ExitCode: &exitCode,
+		Stdout:   "hashcat (v6.2.6) starting\n",
+		Stderr:   "No hashes loaded.\n",
+	}))
+
+	output, err := suite.repo.GetOutput(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), agentID, *output.AgentID)
+	assert.Equal(suite.T(), 255, *output.ExitCode)
+	assert.Equal(suite.T(), "No hashes loaded.\n", output.Stderr)
+	assert.False(suite.T(), output.Truncated)
+
+	// A later report replaces the earlier one
+	suite.Require().NoError(suite.repo.SaveOutput(ctx, &domain.JobOutput{JobID: job.ID, Stderr: "Out of memory\n", Truncated: true}))
+	output, err = suite.repo.GetOutput(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), output.AgentID)
+	assert.Nil(suite.T(), output.ExitCode)
+	assert.Equal(suite.T(), "Out of memory\n", output.Stderr)
+	assert.True(suite.T(), output.Truncated)
+
+	// Purging a deleted job removes its output too
+	suite.Require().NoError(suite.repo.Delete(ctx, job.ID))
+	_, err = suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+
+	_, err = suite.repo.GetOutput(ctx, job.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+}
+
+func (suite *JobRepositoryTestSuite) TestCheckpoint() {
+	ctx := context.Background()
+	agentID := uuid.New()
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Checkpoint",
+		Status:   domain.JobStatusInterrupted,
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	_, err := suite.repo.GetCheckpoint(ctx, job.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+
+	suite.Require().NoError(suite.repo.SaveCheckpoint(ctx, &domain.JobCheckpoint{
+		JobID:          job.ID,
+		AgentID:        &agentID,
+		HashcatVersion: "v6.2.6",
+		RestorePoint:   4096,
+		Data:           []byte{0x8e, 0x02, 0x00, 0x00},
+	}))
+
+	checkpoint, err := suite.repo.GetCheckpoint(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), agentID, *checkpoint.AgentID)
+	assert.Equal(suite.T(), "v6.2.6", checkpoint.HashcatVersion)
+	assert.Equal(suite.T(), int64(4096), checkpoint.RestorePoint)
+	assert.Equal(suite.T(), int64(4), checkpoint.Size)
+	assert.Equal(suite.T(), []byte{0x8e, 0x02, 0x00, 0x00}, checkpoint.Data)
+
+	// A later checkpoint replaces the earlier one
+	suite.Require().NoError(suite.repo.SaveCheckpoint(ctx, &domain.JobCheckpoint{JobID: job.ID, RestorePoint: 8192, Data: []byte("restore")}))
+	checkpoint, err = suite.repo.GetCheckpoint(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), checkpoint.AgentID)
+	assert.Equal(suite.T(), int64(8192), checkpoint.RestorePoint)
+	assert.Equal(suite.T(), []byte("restore"), checkpoint.Data)
+
+	// Purging a deleted job removes its checkpoint too
+	suite.Require().NoError(suite.repo.Delete(ctx, job.ID))
+	_, err = suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+
+	_, err = suite.repo.GetCheckpoint(ctx, job.ID)
+	assert.True(suite.T(), domain.IsNotFoundError(err))
+}
+
+func (suite *JobRepositoryTestSuite) TestCracks() {
+	ctx := context.Background()
+	agentID := uuid.New()
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Cracks",
+		Status:   domain.JobStatusRunning,
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	crackedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	created, err := suite.repo.CreateCracks(ctx, []domain.JobCrack{
+		{JobID: job.ID, AgentID: &agentID, Hash: "e56f3bb5392369b6468e744ab1da078b:s1", Plaintext: "p:w", CrackedAt: crackedAt.Add(time.Second)},
+		{JobID: job.ID, AgentID: &agentID, Hash: "5f4dcc3b5aa765d61d8327deb882cf99", Plaintext: "password", CrackedAt: crackedAt},
+	})
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), created, 2)
+
+	// A hash the job already cracked isn't stored twice
+	created, err = suite.repo.CreateCracks(ctx, []domain.JobCrack{
+		{JobID: job.ID, Hash: "5f4dcc3b5aa765d61d8327deb882cf99", Plaintext: "password", CrackedAt: time.Now()},
+	})
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), created)
+
+	cracks, err := suite.repo.GetCracks(ctx, job.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(cracks, 2)
+	assert.Equal(suite.T(), "password", cracks[0].Plaintext)
+	assert.True(suite.T(), crackedAt.Equal(cracks[0].CrackedAt))
+	assert.Equal(suite.T(), "p:w", cracks[1].Plaintext)
+	assert.Equal(suite.T(), agentID, *cracks[1].AgentID)
+
+	// Purging a deleted job removes its cracks too
+	suite.Require().NoError(suite.repo.Delete(ctx, job.ID))
+	_, err = suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	cracks, err = suite.repo.GetCracks(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), cracks)
+}
+
+func (suite *JobRepositoryTestSuite) TestLeases() {
+	ctx := context.Background()
+	agentID, otherAgent := uuid.New(), uuid.New()
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     "Leased",
+		Status:   domain.JobStatusAssigned,
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+		AgentID:  &agentID,
+	}
+	suite.Require().NoError(suite.repo.Create(ctx, job))
+
+	// Only the agent the job is assigned to can take the lease
+	leased, err := suite.repo.AcquireLease(ctx, job.ID, otherAgent, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	assert.False(suite.T(), leased)
+
+	leased, err = suite.repo.AcquireLease(ctx, job.ID, agentID, time.Now().Add(-time.Second))
+	suite.Require().NoError(err)
+	assert.True(suite.T(), leased)
+
+	fetched, err := suite.repo.GetByID(ctx, job.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(fetched.LeaseExpiresAt)
+
+	expired, err := suite.repo.GetExpiredLeases(ctx, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Len(expired, 1)
+	assert.Equal(suite.T(), job.ID, expired[0].ID)
+
+	// A heartbeat renews it, after which the sweep that read it leaves it
+	before := time.Now()
+	suite.Require().NoError(suite.repo.RenewLeases(ctx, []uuid.UUID{agentID}, time.Now().Add(time.Minute)))
+	expired, err = suite.repo.GetExpiredLeases(ctx, time.Now())
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), expired)
+	taken, err := suite.repo.ExpireLease(ctx, job.ID, domain.JobStatusAssigned, domain.JobStatusPending, before)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), taken)
+	fetched, err = suite.repo.GetByID(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.JobStatusAssigned, fetched.Status)
+	assert.Equal(suite.T(), &agentID, fetched.AgentID)
+
+	// Running jobs aren't picked up again
+	suite.Require().NoError(suite.repo.UpdateStatus(ctx, job.ID, domain.JobStatusRunning))
+	leased, err = suite.repo.AcquireLease(ctx, job.ID, agentID, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	assert.False(suite.T(), leased)
+
+	// Once the lease runs out the job is taken back, only from the status
+	// it was read in
+	after := time.Now().Add(2 * time.Minute)
+	taken, err = suite.repo.ExpireLease(ctx, job.ID, domain.JobStatusAssigned, domain.JobStatusPending, after)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), taken)
+	taken, err = suite.repo.ExpireLease(ctx, job.ID, domain.JobStatusRunning, domain.JobStatusInterrupted, after)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), taken)
+	fetched, err = suite.repo.GetByID(ctx, job.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), domain.JobStatusInterrupted, fetched.Status)
+	assert.Nil(suite.T(), fetched.AgentID)
+	assert.Nil(suite.T(), fetched.LeaseExpiresAt)
+}
+
+func (suite *JobRepositoryTestSuite) TestDeadlines() {
+	ctx := context.Background()
+	agentID := uuid.New()
+	closing, passed, later := time.Now().Add(30*time.Second), time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	newJob := func(name, status string, deadline *time.Time) *domain.Job {
+		job := &domain.Job{
+			ID:       uuid.New(),
+			Name:     name,
+			Status:   status,
+			HashFile: "/tmp/test.hash",
+			Wordlist: "rockyou.txt",
+			AgentID:  &agentID,
+			Deadline: deadline,
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, job))
+		return job
+	}
+	newJob("Too late", domain.JobStatusPending, &closing)
+	overdue := newJob("Overdue", domain.JobStatusRunning, &passed)
+	newJob("Done", domain.JobStatusCompleted, &passed)
+	onTime := newJob("On time", domain.JobStatusPending, &later)
+
+	fetched, err := suite.repo.GetByID(ctx, onTime.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(fetched.Deadline)
+	assert.True(suite.T(), fetched.Deadline.Equal(later))
+
+	// The older job is too close to its deadline to be handed out
+	available, err := suite.repo.GetAvailableJobForAgent(ctx, agentID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), onTime.ID, available.ID)
+
+	past, err := suite.repo.GetPastDeadline(ctx, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Len(past, 1)
+	assert.Equal(suite.T(), overdue.ID, past[0].ID)
+
+	past, err = suite.repo.GetPastDeadline(ctx, closing.Add(time.Second))
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), past, 2)
+}
+
+func (suite *JobRepositoryTestSuite) TestBudgetedGroups() {
+	ctx := context.Background()
+	budget := &domain.PipelineBudget{GPUHours: 8, Stages: []domain.BudgetStage{{Name: "mask", GPUHours: 8}}}
+	budgeted := &domain.JobGroup{Name: "acme", Budget: budget}
+	plain := &domain.JobGroup{Name: "plain"}
+	suite.Require().NoError(suite.repo.CreateGroup(ctx, budgeted))
+	suite.Require().NoError(suite.repo.CreateGroup(ctx, plain))
+
+	fetched, err := suite.repo.GetGroupByID(ctx, budgeted.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), budget, fetched.Budget)
+
+	newJob := func(group *domain.JobGroup, status string) {
+		job := &domain.Job{
+			ID:          uuid.New(),
+			Name:        group.Name,
+			Status:      status,
+			HashFile:    "/tmp/test.hash",
+			Wordlist:    "?d?d?d?d",
+			GroupID:     &group.ID,
+			BudgetStage: "mask",
+		}
+		suite.Require().NoError(suite.repo.Create(ctx, job))
+	}
+	newJob(plain, domain.JobStatusRunning)
+	newJob(budgeted, domain.JobStatusCompleted)
+
+	// Groups whose jobs all finished have nothing left to stop
+	groups, err := suite.repo.GetBudgetedGroups(ctx)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), groups)
+
+	newJob(budgeted, domain.JobStatusPending)
+	groups, err = suite.repo.GetBudgetedGroups(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(groups, 1)
+	assert.Equal(suite.T(), budgeted.ID, groups[0].ID)
+	assert.Equal(suite.T(), budget, groups[0].Budget)
+
+	jobs, err := suite.repo.GetByGroupID(ctx, budgeted.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(jobs, 2)
+	assert.Equal(suite.T(), "mask", jobs[0].BudgetStage)
+}
+
 func TestJobRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(JobRepositoryTestSuite))
 }
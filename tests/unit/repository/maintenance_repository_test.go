@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewMaintenanceRepository(db)
+	agent := &domain.Agent{
+		ID:        uuid.New(),
+		Name:      "workstation",
+		IPAddress: "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		LastSeen:  time.Now(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, repository.NewAgentRepository(db).Create(ctx, agent))
+
+	weekly := &domain.MaintenanceWindow{
+		Name:      "office hours",
+		AgentID:   &agent.ID,
+		Days:      []string{"mon", "wed", "fri"},
+		StartTime: "09:00",
+		EndTime:   "17:00",
+		Timezone:  "Europe/Berlin",
+	}
+	require.NoError(t, repo.Create(ctx, weekly))
+
+	start := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+	oneOff := &domain.MaintenanceWindow{Name: "datacenter outage", Description: "power work", StartsAt: &start, EndsAt: &end}
+	require.NoError(t, repo.Create(ctx, oneOff))
+
+	got, err := repo.GetByID(ctx, weekly.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.AgentID)
+	assert.Equal(t, agent.ID, *got.AgentID)
+	assert.Nil(t, got.AgentGroupID)
+	assert.Nil(t, got.StartsAt)
+	assert.Equal(t, []string{"mon", "wed", "fri"}, got.Days)
+	assert.Equal(t, "Europe/Berlin", got.Timezone)
+
+	got, err = repo.GetByID(ctx, oneOff.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.AgentID)
+	assert.Empty(t, got.Days)
+	assert.Equal(t, "power work", got.Description)
+	require.NotNil(t, got.StartsAt)
+	require.NotNil(t, got.EndsAt)
+	assert.True(t, start.Equal(*got.StartsAt))
+	assert.True(t, end.Equal(*got.EndsAt))
+
+	weekly.Days = nil
+	weekly.EndTime = "18:00"
+	require.NoError(t, repo.Update(ctx, weekly))
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "datacenter outage", all[0].Name)
+	assert.Equal(t, "18:00", all[1].EndTime)
+	assert.Empty(t, all[1].Days)
+
+	require.NoError(t, repo.Delete(ctx, oneOff.ID))
+	_, err = repo.GetByID(ctx, oneOff.ID)
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, oneOff.ID)))
+	assert.True(t, domain.IsNotFoundError(repo.Update(ctx, oneOff)))
+}
@@ -0,0 +1,94 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNTDSRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewNTDSRepository(db)
+
+	enabled, disabled := true, false
+	projectID := uuid.New()
+	hashFileID := uuid.New()
+	ntdsImport := &domain.NTDSImport{Name: "corp.ntds", Domain: "CORP", HashFileID: &hashFileID, Accounts: 4, UniqueHashes: 3, MachineAccounts: 2, ProjectID: &projectID}
+	accounts := []domain.NTDSAccount{
+		{Domain: "CORP", Username: "Administrator", RID: 500, NTHash: "64f12cddaa88057e06a81b54e73b949b", Enabled: &enabled, Privileged: true},
+		{Domain: "CORP", Username: "alice", RID: 1104, NTHash: "8846f7eaee8fb117ad06bdd830b7586c", Enabled: &enabled},
+		{Domain: "CORP", Username: "bob", RID: 1105, NTHash: "2d20d252a479f485cdf5e171d93985bf", Enabled: &disabled},
+		{Domain: "CORP", Username: "carol", RID: 1106, NTHash: "2d20d252a479f485cdf5e171d93985bf"},
+	}
+	require.NoError(t, repo.Create(ctx, ntdsImport, accounts))
+	assert.NotEqual(t, uuid.Nil, ntdsImport.ID)
+	require.NoError(t, repo.Create(ctx, &domain.NTDSImport{Name: "other.ntds"}, nil))
+
+	got, err := repo.GetByID(ctx, ntdsImport.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "corp.ntds", got.Name)
+	assert.Equal(t, &hashFileID, got.HashFileID)
+	assert.Equal(t, 2, got.MachineAccounts)
+
+	imports, err := repo.GetAll(ctx, &projectID)
+	require.NoError(t, err)
+	require.Len(t, imports, 1)
+	imports, err = repo.GetAll(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, imports, 2)
+
+	all, total, err := repo.GetAccounts(ctx, ntdsImport.ID, domain.NTDSAccountFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, all, 4)
+	assert.Equal(t, "Administrator", all[0].Username)
+	require.NotNil(t, all[2].Enabled)
+	assert.False(t, *all[2].Enabled)
+	assert.Nil(t, all[3].Enabled)
+
+	// Both accounts using the cracked hash are marked, once
+	at := time.Now()
+	marked, err := repo.MarkCracked(ctx, ntdsImport.ID, []string{"2D20D252A479F485CDF5E171D93985BF", "ffffffffffffffffffffffffffffffff"}, at)
+	require.NoError(t, err)
+	assert.Equal(t, 2, marked)
+	marked, err = repo.MarkCracked(ctx, ntdsImport.ID, []string{"2d20d252a479f485cdf5e171d93985bf"}, at)
+	require.NoError(t, err)
+	assert.Equal(t, 0, marked)
+
+	cracked := true
+	page, total, err := repo.GetAccounts(ctx, ntdsImport.ID, domain.NTDSAccountFilter{Cracked: &cracked, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "carol", page[0].Username)
+	require.NotNil(t, page[0].CrackedAt)
+
+	privileged, err := repo.SetPrivileged(ctx, ntdsImport.ID, []string{"ALICE"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, privileged, "alice and the built-in Administrator")
+	isPrivileged := true
+	admins, _, err := repo.GetAccounts(ctx, ntdsImport.ID, domain.NTDSAccountFilter{Privileged: &isPrivileged})
+	require.NoError(t, err)
+	require.Len(t, admins, 2)
+	assert.Equal(t, "alice", admins[1].Username)
+
+	require.NoError(t, repo.Delete(ctx, ntdsImport.ID))
+	_, err = repo.GetByID(ctx, ntdsImport.ID)
+	assert.True(t, domain.IsNotFoundError(err))
+	_, total, err = repo.GetAccounts(ctx, ntdsImport.ID, domain.NTDSAccountFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, ntdsImport.ID)))
+}
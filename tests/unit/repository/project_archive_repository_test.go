@@ -0,0 +1,104 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectArchiveRepository_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := setupProjectDB(t)
+	cipher, err := database.NewFieldCipher("archive-test-key")
+	require.NoError(t, err)
+	source.SetFieldCipher(cipher)
+
+	project := &domain.Project{Name: "acme", Description: "Q3 assessment"}
+	require.NoError(t, repository.NewProjectRepository(source).Create(ctx, project))
+	other := &domain.Project{Name: "other"}
+	require.NoError(t, repository.NewProjectRepository(source).Create(ctx, other))
+
+	hashFiles := repository.NewHashFileRepository(source)
+	hashFile := &domain.HashFile{ID: uuid.New(), Name: "a.txt", OrigName: "corp.nt.txt", Path: "/uploads/a.txt", Size: 33, Type: "hash", SHA256: "aa", ProjectID: &project.ID}
+	otherFile := &domain.HashFile{ID: uuid.New(), Name: "b.txt", OrigName: "other.txt", Path: "/uploads/b.txt", ProjectID: &other.ID}
+	require.NoError(t, hashFiles.Create(ctx, hashFile))
+	require.NoError(t, hashFiles.Create(ctx, otherFile))
+	wordlists := repository.NewWordlistRepository(source)
+	shared := &domain.Wordlist{ID: uuid.New(), Name: "c.txt", OrigName: "rockyou.txt", Path: "/uploads/wordlists/c.txt", Size: 100, SHA256: "cc"}
+	unused := &domain.Wordlist{ID: uuid.New(), Name: "d.txt", OrigName: "unused.txt", Path: "/uploads/wordlists/d.txt"}
+	require.NoError(t, wordlists.Create(ctx, shared))
+	require.NoError(t, wordlists.Create(ctx, unused))
+
+	jobs := repository.NewJobRepository(source)
+	cracked := &domain.Job{ID: uuid.New(), Name: "rockyou", Status: domain.JobStatusCracked, HashType: 1000, HashFileID: &hashFile.ID,
+		HashFile: hashFile.Path, WordlistID: &shared.ID, Wordlist: "rockyou.txt", Result: "password", ProjectID: &project.ID, Tags: []string{"acme"}}
+	deleted := &domain.Job{ID: uuid.New(), Name: "deleted", Status: domain.JobStatusFailed, ProjectID: &project.ID}
+	otherJob := &domain.Job{ID: uuid.New(), Name: "other", Status: domain.JobStatusPending, ProjectID: &other.ID}
+	group := &domain.JobGroup{ID: uuid.New(), Name: "split"}
+	cracked.GroupID = &group.ID
+	require.NoError(t, jobs.CreateBatch(ctx, group, []*domain.Job{cracked}))
+	require.NoError(t, jobs.Create(ctx, deleted))
+	require.NoError(t, jobs.Create(ctx, otherJob))
+	require.NoError(t, jobs.Delete(ctx, deleted.ID))
+	require.NoError(t, jobs.CreateEvent(ctx, &domain.JobEvent{JobID: cracked.ID, ToStatus: domain.JobStatusPending, Actor: domain.ActorAPI}))
+	require.NoError(t, jobs.CreateComment(ctx, &domain.JobComment{JobID: cracked.ID, Author: "alice", Body: "Domain admin cracked"}))
+	_, err = repository.NewCredentialRepository(source).Record(ctx, []domain.Credential{{Username: "administrator", Hash: "8846f7eaee8fb117ad06bdd830b7586c",
+		Plaintext: "password", HashType: 1000, HashFileID: &hashFile.ID, Source: domain.CredentialSourceJob, JobID: &cracked.ID, ProjectID: &project.ID}})
+	require.NoError(t, err)
+
+	archive, err := repository.NewProjectArchiveRepository(source).Export(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProjectArchiveVersion, archive.Version)
+	assert.Equal(t, "acme", archive.Project.Name)
+	require.Len(t, archive.Jobs, 1, "soft-deleted jobs and other projects' jobs are left out")
+	assert.Equal(t, "password", archive.Jobs[0].Result)
+	assert.Equal(t, []string{"acme"}, archive.Jobs[0].Tags)
+	require.Len(t, archive.JobGroups, 1)
+	assert.Equal(t, "split", archive.JobGroups[0].Name)
+	assert.Len(t, archive.JobEvents, 1)
+	assert.Len(t, archive.JobComments, 1)
+	require.Len(t, archive.Credentials, 1)
+	assert.Equal(t, "password", archive.Credentials[0].Plaintext)
+	require.Len(t, archive.HashFiles, 1)
+	assert.Equal(t, domain.ArchivedFile{ID: hashFile.ID, Name: "corp.nt.txt", Size: 33, SHA256: "aa", Type: "hash", CreatedAt: archive.HashFiles[0].CreatedAt}, archive.HashFiles[0])
+	require.Len(t, archive.Wordlists, 1, "shared files are included when the project's jobs use them")
+	assert.True(t, archive.Wordlists[0].Shared)
+
+	_, err = repository.NewProjectArchiveRepository(source).Export(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+
+	// Another server gets the same rows, timestamps included
+	target := setupProjectDB(t)
+	target.SetFieldCipher(cipher)
+	recorded, err := repository.NewProjectArchiveRepository(target).Import(ctx, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recorded)
+
+	imported, err := repository.NewProjectRepository(target).GetByID(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Q3 assessment", imported.Description)
+	job, err := repository.NewJobRepository(target).GetByID(ctx, cracked.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "password", job.Result)
+	assert.Equal(t, &group.ID, job.GroupID)
+	assert.WithinDuration(t, archive.Jobs[0].CreatedAt, job.CreatedAt, time.Millisecond)
+	events, err := repository.NewJobRepository(target).GetEvents(ctx, cracked.ID)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	credentials, total, err := repository.NewCredentialRepository(target).GetAll(ctx, domain.CredentialFilter{ProjectID: &project.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "password", credentials[0].Plaintext)
+
+	// Importing twice fails as a whole
+	_, err = repository.NewProjectArchiveRepository(target).Import(ctx, archive)
+	assert.Error(t, err)
+}
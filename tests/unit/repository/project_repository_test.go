@@ -0,0 +1,205 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProjectDB(t *testing.T) *database.SQLiteDB {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// The users table comes from migration 006 rather than the built-in schema
+	_, err = db.DB().Exec(`CREATE TABLE users (
+		id TEXT PRIMARY KEY, username TEXT NOT NULL UNIQUE, email TEXT NOT NULL UNIQUE, password TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'user', is_active BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL, last_login DATETIME, tenant_id TEXT
+	)`)
+	require.NoError(t, err)
+	return db
+}
+
+func createUser(t *testing.T, db *database.SQLiteDB, username string) *domain.User {
+	user := &domain.User{ID: uuid.New(), Username: username, Email: username + "@example.com", Password: "x",
+		Role: "user", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, repository.NewUserRepository(db.DB()).Create(context.Background(), user))
+	return user
+}
+
+func TestProjectRepository(t *testing.T) {
+	db := setupProjectDB(t)
+	ctx := context.Background()
+	repo := repository.NewProjectRepository(db)
+
+	acme := &domain.Project{Name: "ACME pentest", Description: "Q4 engagement"}
+	require.NoError(t, repo.Create(ctx, acme))
+	other := &domain.Project{Name: "Globex"}
+	require.NoError(t, repo.Create(ctx, other))
+	assert.Error(t, repo.Create(ctx, &domain.Project{Name: "Globex"}), "names are unique")
+
+	found, err := repo.GetByName(ctx, "acme PENTEST")
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, found.ID)
+	assert.Equal(t, "Q4 engagement", found.Description)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+
+	alice := createUser(t, db, "alice")
+	bob := createUser(t, db, "bob")
+	require.NoError(t, repo.AddMember(ctx, acme.ID, alice.ID))
+	require.NoError(t, repo.AddMember(ctx, acme.ID, alice.ID), "adding twice is a no-op")
+	require.NoError(t, repo.AddMember(ctx, acme.ID, bob.ID))
+	require.NoError(t, repo.AddMember(ctx, other.ID, bob.ID))
+
+	members, err := repo.GetMembers(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "alice", members[0].Username)
+	assert.Equal(t, alice.ID, members[0].UserID)
+
+	projects, err := repo.GetByMember(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, acme.ID, projects[0].ID)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	isMember, err := repo.IsMember(ctx, other.ID, alice.ID)
+	require.NoError(t, err)
+	assert.False(t, isMember)
+
+	require.NoError(t, repo.RemoveMember(ctx, acme.ID, bob.ID))
+	assert.True(t, domain.IsNotFoundError(repo.RemoveMember(ctx, acme.ID, bob.ID)))
+
+	acme.Name = "ACME red team"
+	require.NoError(t, repo.Update(ctx, acme))
+	found, err = repo.GetByID(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ACME red team", found.Name)
+
+	require.NoError(t, repo.Delete(ctx, other.ID))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, other.ID)))
+	projects, err = repo.GetByMember(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, projects, "memberships go with the project")
+}
+
+func TestProjectScopedResources(t *testing.T) {
+	db := setupProjectDB(t)
+	ctx := context.Background()
+	projectRepo := repository.NewProjectRepository(db)
+	hashFileRepo := repository.NewHashFileRepository(db)
+	wordlistRepo := repository.NewWordlistRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+
+	project := &domain.Project{Name: "ACME"}
+	require.NoError(t, projectRepo.Create(ctx, project))
+
+	count, err := projectRepo.CountResources(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	scoped := &domain.HashFile{ID: uuid.New(), Name: "a.hash", OrigName: "a.hash", Path: "/tmp/a.hash", Type: "hash", ProjectID: &project.ID}
+	shared := &domain.HashFile{ID: uuid.New(), Name: "b.hash", OrigName: "b.hash", Path: "/tmp/b.hash", Type: "hash"}
+	require.NoError(t, hashFileRepo.Create(ctx, scoped))
+	require.NoError(t, hashFileRepo.Create(ctx, shared))
+
+	hashFiles, err := hashFileRepo.GetByProject(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, hashFiles, 1)
+	assert.Equal(t, scoped.ID, hashFiles[0].ID)
+	require.NotNil(t, hashFiles[0].ProjectID)
+	assert.Equal(t, project.ID, *hashFiles[0].ProjectID)
+
+	sharedFile, err := hashFileRepo.GetByID(ctx, shared.ID)
+	require.NoError(t, err)
+	assert.Nil(t, sharedFile.ProjectID)
+
+	wordlist := &domain.Wordlist{ID: uuid.New(), Name: "w.txt", OrigName: "w.txt", Path: "/tmp/w.txt", ProjectID: &project.ID}
+	require.NoError(t, wordlistRepo.Create(ctx, wordlist))
+	wordlists, err := wordlistRepo.GetByProject(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, wordlists, 1)
+	assert.Equal(t, wordlist.ID, wordlists[0].ID)
+
+	for _, projectID := range []*uuid.UUID{&project.ID, nil} {
+		require.NoError(t, jobRepo.Create(ctx, &domain.Job{ID: uuid.New(), Name: "job", Status: domain.JobStatusPending,
+			HashFile: "a.hash", Wordlist: "w.txt", ProjectID: projectID}))
+	}
+	jobs, total, err := jobRepo.List(ctx, domain.JobFilter{ProjectID: &project.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.NotNil(t, jobs[0].ProjectID)
+	assert.Equal(t, project.ID, *jobs[0].ProjectID)
+
+	count, err = projectRepo.CountResources(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Soft-deleted jobs don't keep a project alive
+	_, err = db.DB().Exec(`UPDATE jobs SET deleted_at = ? WHERE project_id = ?`, time.Now(), project.ID.String())
+	require.NoError(t, err)
+	count, err = projectRepo.CountResources(ctx, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestLoopbackWordlists(t *testing.T) {
+	db := setupProjectDB(t)
+	ctx := context.Background()
+	projectRepo := repository.NewProjectRepository(db)
+	wordlistRepo := repository.NewWordlistRepository(db)
+
+	project := &domain.Project{Name: "ACME"}
+	require.NoError(t, projectRepo.Create(ctx, project))
+
+	none, err := wordlistRepo.GetLoopback(ctx, &project.ID)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	// An uploaded wordlist in the project is not its loopback list
+	uploaded := &domain.Wordlist{ID: uuid.New(), Name: "a.txt", OrigName: "a.txt", Path: "/tmp/a.txt", ProjectID: &project.ID}
+	loopback := &domain.Wordlist{ID: uuid.New(), Name: "b.txt", OrigName: "loopback.txt", Path: "/tmp/b.txt", ProjectID: &project.ID, Dynamic: true}
+	global := &domain.Wordlist{ID: uuid.New(), Name: "c.txt", OrigName: "loopback.txt", Path: "/tmp/c.txt", Dynamic: true}
+	for _, wordlist := range []*domain.Wordlist{uploaded, loopback, global} {
+		require.NoError(t, wordlistRepo.Create(ctx, wordlist))
+	}
+
+	found, err := wordlistRepo.GetLoopback(ctx, &project.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, loopback.ID, found.ID)
+	assert.True(t, found.Dynamic)
+
+	found, err = wordlistRepo.GetLoopback(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, global.ID, found.ID)
+
+	// Reads through the cache see the new content
+	_, err = wordlistRepo.GetByID(ctx, loopback.ID)
+	require.NoError(t, err)
+	words := int64(3)
+	loopback.Size, loopback.WordCount, loopback.SHA256 = 24, &words, "abc123"
+	require.NoError(t, wordlistRepo.UpdateContent(ctx, loopback))
+
+	updated, err := wordlistRepo.GetByID(ctx, loopback.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(24), updated.Size)
+	require.NotNil(t, updated.WordCount)
+	assert.Equal(t, int64(3), *updated.WordCount)
+	assert.Equal(t, "abc123", updated.SHA256)
+}
@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	repo := repository.NewAgentRepository(db)
+
+	// Without a surrounding span queries are not traced
+	exporter.Reset()
+	repo.GetByID(context.Background(), uuid.New())
+	assert.Empty(t, exporter.GetSpans())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	repo.GetByID(ctx, uuid.New())
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "SELECT", query.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+
+	attributes := map[string]string{}
+	for _, kv := range query.Attributes {
+		attributes[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "sqlite", attributes["db.system"])
+	assert.Contains(t, attributes["db.statement"], "FROM agents")
+}
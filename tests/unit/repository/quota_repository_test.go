@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewQuotaRepository(db)
+	userID := uuid.New()
+
+	quota, err := repo.Get(ctx, domain.QuotaScopeUser, userID)
+	require.NoError(t, err)
+	assert.Nil(t, quota, "no quota means no limits")
+
+	require.NoError(t, repo.Upsert(ctx, &domain.Quota{Scope: domain.QuotaScopeUser, SubjectID: userID, MaxRunningJobs: 2}))
+	require.NoError(t, repo.Upsert(ctx, &domain.Quota{Scope: domain.QuotaScopeUser, SubjectID: userID, MaxRunningJobs: 5, MaxDeviceHoursPerMonth: 12.5}))
+	require.NoError(t, repo.Upsert(ctx, &domain.Quota{Scope: domain.QuotaScopeProject, SubjectID: uuid.New(), MaxStorageBytes: 1 << 20}))
+
+	quota, err = repo.Get(ctx, domain.QuotaScopeUser, userID)
+	require.NoError(t, err)
+	require.NotNil(t, quota)
+	assert.Equal(t, 5, quota.MaxRunningJobs)
+	assert.Equal(t, 12.5, quota.MaxDeviceHoursPerMonth)
+	assert.Equal(t, int64(0), quota.MaxStorageBytes)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.Delete(ctx, domain.QuotaScopeUser, userID))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, domain.QuotaScopeUser, userID)))
+}
+
+func TestQuotaRepository_GetUsage(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewQuotaRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	project := &domain.Project{Name: "ACME"}
+	require.NoError(t, repository.NewProjectRepository(db).Create(ctx, project))
+	userID, otherUser := uuid.New(), uuid.New()
+
+	now := time.Now()
+	monthStart := domain.QuotaMonthStart(now)
+	jobs := []struct {
+		user          *uuid.UUID
+		status        string
+		started       time.Time
+		deviceSeconds float64
+	}{
+		{&userID, domain.JobStatusRunning, now, 1800},
+		{&userID, domain.JobStatusPending, now, 0},
+		{&userID, domain.JobStatusCompleted, now, 5400},
+		// Used last month
+		{&userID, domain.JobStatusCompleted, monthStart.Add(-time.Hour), 7200},
+		{&otherUser, domain.JobStatusRunning, now, 3600},
+	}
+	var ids []uuid.UUID
+	for _, j := range jobs {
+		started := j.started
+		job := &domain.Job{ID: uuid.New(), Name: "job", Status: j.status, HashFile: "h", Wordlist: "w",
+			ProjectID: &project.ID, CreatedBy: j.user, StartedAt: &started, CreatedAt: started, UpdatedAt: now}
+		require.NoError(t, jobRepo.Create(ctx, job))
+		job.DeviceSeconds = j.deviceSeconds
+		require.NoError(t, jobRepo.Update(ctx, job))
+		ids = append(ids, job.ID)
+	}
+	// A deleted job no longer runs, but its compute was used all the same
+	_, err = db.DB().Exec(`UPDATE jobs SET deleted_at = ? WHERE id = ?`, now, ids[0].String())
+	require.NoError(t, err)
+
+	require.NoError(t, repository.NewHashFileRepository(db).Create(ctx, &domain.HashFile{ID: uuid.New(), Name: "h", OrigName: "h.txt",
+		Path: "/tmp/h", Size: 100, Type: "hash", ProjectID: &project.ID, CreatedBy: &userID, CreatedAt: now}))
+	require.NoError(t, repository.NewWordlistRepository(db).Create(ctx, &domain.Wordlist{ID: uuid.New(), Name: "w", OrigName: "w.txt",
+		Path: "/tmp/w", Size: 250, CreatedBy: &otherUser, CreatedAt: now}))
+
+	usage, err := repo.GetUsage(ctx, domain.QuotaScopeUser, userID, monthStart)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.RunningJobs)
+	assert.InDelta(t, 2, usage.DeviceHoursThisMonth, 0.001)
+	assert.Equal(t, int64(100), usage.StorageBytes)
+
+	usage, err = repo.GetUsage(ctx, domain.QuotaScopeProject, project.ID, monthStart)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.RunningJobs)
+	assert.InDelta(t, 3, usage.DeviceHoursThisMonth, 0.001)
+	assert.Equal(t, int64(100), usage.StorageBytes)
+}
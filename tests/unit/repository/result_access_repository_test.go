@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultAccessRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewResultAccessRepository(db)
+	alice, bob := uuid.New(), uuid.New()
+
+	granted, err := repo.HasRevealGrant(ctx, alice)
+	require.NoError(t, err)
+	assert.False(t, granted)
+
+	require.NoError(t, repo.GrantReveal(ctx, &domain.ResultRevealGrant{UserID: alice, Username: "alice", GrantedBy: "admin"}))
+	require.NoError(t, repo.GrantReveal(ctx, &domain.ResultRevealGrant{UserID: alice, Username: "alice", GrantedBy: "root"}))
+	granted, err = repo.HasRevealGrant(ctx, alice)
+	require.NoError(t, err)
+	assert.True(t, granted)
+
+	grants, err := repo.GetRevealGrants(ctx)
+	require.NoError(t, err)
+	require.Len(t, grants, 1, "granting again replaces the grant")
+	assert.Equal(t, "root", grants[0].GrantedBy)
+
+	require.NoError(t, repo.RevokeReveal(ctx, alice))
+	assert.True(t, domain.IsNotFoundError(repo.RevokeReveal(ctx, alice)))
+
+	jobA, jobB := uuid.New(), uuid.New()
+	require.NoError(t, repo.LogReveal(ctx, &domain.ResultReveal{JobID: jobA, UserID: &alice, Username: "alice", IPAddress: "10.0.0.5",
+		CreatedAt: time.Now().Add(-time.Minute)}))
+	wordlistID := uuid.New()
+	require.NoError(t, repo.LogReveal(ctx, &domain.ResultReveal{JobID: jobB, WordlistID: &wordlistID, UserID: &bob, Username: "bob"}))
+
+	reveals, err := repo.GetReveals(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, reveals, 2)
+	assert.Equal(t, "bob", reveals[0].Username, "newest first")
+	assert.Equal(t, &wordlistID, reveals[0].WordlistID)
+	assert.Equal(t, "10.0.0.5", reveals[1].IPAddress)
+	assert.Nil(t, reveals[1].WordlistID)
+
+	reveals, err = repo.GetReveals(ctx, &jobA, 10)
+	require.NoError(t, err)
+	require.Len(t, reveals, 1)
+	assert.Equal(t, alice, *reveals[0].UserID)
+}
+
+func TestResultEncryptionAtRest(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	plainJob := &domain.Job{ID: uuid.New(), Name: "before", Status: domain.JobStatusCracked, Result: "Password found: hunter2"}
+	require.NoError(t, repository.NewJobRepository(db).Create(ctx, plainJob))
+
+	cipher, err := database.NewFieldCipher("server-secret")
+	require.NoError(t, err)
+	db.SetFieldCipher(cipher)
+
+	job := &domain.Job{ID: uuid.New(), Name: "after", Status: domain.JobStatusCracked, Result: "Password found: Summer2026!"}
+	repo := repository.NewJobRepository(db)
+	require.NoError(t, repo.Create(ctx, job))
+	require.NoError(t, repo.SaveOutput(ctx, &domain.JobOutput{JobID: job.ID, Stdout: "5f4dcc3b5aa765d61d8327deb882cf99:Summer2026!\n"}))
+
+	var stored, storedOutput string
+	require.NoError(t, db.DB().QueryRow(`SELECT result FROM jobs WHERE id = ?`, job.ID.String()).Scan(&stored))
+	require.NoError(t, db.DB().QueryRow(`SELECT stdout FROM job_outputs WHERE job_id = ?`, job.ID.String()).Scan(&storedOutput))
+	assert.True(t, database.IsSealed(stored))
+	assert.NotContains(t, stored, "Summer2026!")
+	assert.NotContains(t, storedOutput, "Summer2026!")
+
+	// A fresh repository has nothing cached and reads the database
+	reader := repository.NewJobRepository(db)
+	got, err := reader.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Password found: Summer2026!", got.Result)
+	output, err := reader.GetOutput(ctx, job.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(output.Stdout, ":Summer2026!\n"))
+
+	// Rows written before encryption was turned on stay readable
+	got, err = reader.GetByID(ctx, plainJob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Password found: hunter2", got.Result)
+
+	// The wrong key can't read the result, and reads don't fail over it
+	wrong, err := database.NewFieldCipher("another-secret")
+	require.NoError(t, err)
+	db.SetFieldCipher(wrong)
+	got, err = repository.NewJobRepository(db).GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, got.Result, "Summer2026!")
+}
@@ -0,0 +1,176 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SearchRepositoryTestSuite struct {
+	suite.Suite
+	db      *database.SQLiteDB
+	repo    domain.SearchRepository
+	jobRepo domain.JobRepository
+}
+
+func (suite *SearchRepositoryTestSuite) SetupTest() {
+	db, err := database.NewSQLiteDB(":memory:")
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.repo = repository.NewSearchRepository(db)
+	suite.jobRepo = repository.NewJobRepository(db)
+
+	ctx := context.Background()
+	agentRepo := repository.NewAgentRepository(db)
+	wordlistRepo := repository.NewWordlistRepository(db)
+
+	suite.Require().NoError(agentRepo.Create(ctx, &domain.Agent{
+		ID:           uuid.New(),
+		Name:         "gpu-rig-01",
+		IPAddress:    "10.0.0.5",
+		Port:         8080,
+		Status:       "online",
+		Capabilities: "NVIDIA RTX 4090",
+		LastSeen:     time.Now(),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}))
+	suite.Require().NoError(wordlistRepo.Create(ctx, &domain.Wordlist{
+		ID:       uuid.New(),
+		Name:     "stored.txt",
+		OrigName: "rockyou.txt",
+		Path:     "/tmp/stored.txt",
+	}))
+}
+
+func (suite *SearchRepositoryTestSuite) TearDownTest() {
+	if suite.db != nil {
+		suite.db.Close()
+	}
+}
+
+func (suite *SearchRepositoryTestSuite) createJob(name, result string) *domain.Job {
+	job := &domain.Job{
+		ID:       uuid.New(),
+		Name:     name,
+		Status:   "completed",
+		HashFile: "/tmp/test.hash",
+		Wordlist: "rockyou.txt",
+		Result:   result,
+	}
+	suite.Require().NoError(suite.jobRepo.Create(context.Background(), job))
+	return job
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchTypes() {
+	ctx := context.Background()
+	job := suite.createJob("Office WiFi", "")
+
+	results, err := suite.repo.Search(ctx, "gpu-rig", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "agent", results[0].Type)
+
+	results, err = suite.repo.Search(ctx, "rockyou", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "wordlist", results[0].Type)
+
+	results, err = suite.repo.Search(ctx, "office", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "job", results[0].Type)
+	assert.Equal(suite.T(), job.ID, results[0].ID)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchResultsAndUpdates() {
+	ctx := context.Background()
+	job := suite.createJob("Router", "")
+
+	// Cracked plaintexts are searchable once the job is updated...
+	job.Result = "hunter2"
+	suite.Require().NoError(suite.jobRepo.Update(ctx, job))
+
+	results, err := suite.repo.Search(ctx, "hunter2", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "hunter2", results[0].Match)
+
+	// Nor when results are redacted, as a hit would confirm a guessed password
+	results, err = suite.repo.Search(ctx, "hunter2", 10, false)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), results)
+	results, err = suite.repo.Search(ctx, "router", 10, false)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), results, 1)
+
+	// Deleted jobs are not returned
+	suite.Require().NoError(suite.jobRepo.Delete(ctx, job.ID))
+	results, err = suite.repo.Search(ctx, "hunter2", 10, true)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), results)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchJobAnnotations() {
+	ctx := context.Background()
+	tagged := suite.createJob("Tagged", "")
+	suite.Require().NoError(suite.jobRepo.UpdateTags(ctx, tagged.ID, []string{"client-acme"}))
+	commented := suite.createJob("Commented", "")
+	suite.Require().NoError(suite.jobRepo.CreateComment(ctx, &domain.JobComment{JobID: commented.ID, Author: "alice", Body: "Retest after the acme patch"}))
+
+	results, err := suite.repo.Search(ctx, "acme", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	ids := []uuid.UUID{results[0].ID, results[1].ID}
+	assert.ElementsMatch(suite.T(), []uuid.UUID{tagged.ID, commented.ID}, ids)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchSpecialCharacters() {
+	ctx := context.Background()
+	suite.createJob("100% done", "")
+	suite.createJob("1000 done", "")
+
+	for _, query := range []string{`"`, `name:x OR`, `%`, `_`, `*`} {
+		_, err := suite.repo.Search(ctx, query, 10, true)
+		assert.NoError(suite.T(), err, query)
+	}
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchTenants() {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	tenantCtx := domain.WithTenantID(ctx, tenantID)
+	job := &domain.Job{ID: uuid.New(), Name: "Acme domain", Status: "completed", HashFile: "/tmp/test.hash", Wordlist: "rockyou.txt"}
+	suite.Require().NoError(suite.jobRepo.Create(tenantCtx, job))
+	suite.Require().NoError(suite.jobRepo.UpdateTags(tenantCtx, job.ID, []string{"acme-internal"}))
+
+	results, err := suite.repo.Search(tenantCtx, "acme", 10, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), job.ID, results[0].ID)
+
+	// Other tenants and anonymous requests don't find it
+	for _, other := range []context.Context{domain.WithTenantID(ctx, uuid.New()), domain.WithSharedScope(ctx)} {
+		results, err = suite.repo.Search(other, "acme", 10, true)
+		suite.Require().NoError(err)
+		assert.Empty(suite.T(), results)
+	}
+
+	// Shared agents are found by every tenant
+	results, err = suite.repo.Search(tenantCtx, "gpu-rig", 10, true)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), results, 1)
+}
+
+func TestSearchRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchRepositoryTestSuite))
+}
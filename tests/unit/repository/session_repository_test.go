@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSession(userID uuid.UUID, hash string, expiresAt time.Time) *domain.Session {
+	now := time.Now()
+	return &domain.Session{ID: uuid.New(), UserID: userID, TokenHash: hash, UserAgent: "curl/8.0", IPAddress: "10.0.0.7",
+		CreatedAt: now, LastUsedAt: now, ExpiresAt: expiresAt}
+}
+
+func TestSessionRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewSessionRepository(db)
+	userID := uuid.New()
+
+	session := newSession(userID, "hash-1", time.Now().Add(time.Hour))
+	session.TwoFactor = true
+	require.NoError(t, repo.Create(ctx, session))
+	got, err := repo.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hash-1", got.TokenHash)
+	assert.True(t, got.TwoFactor)
+	assert.Equal(t, "10.0.0.7", got.IPAddress)
+	assert.Nil(t, got.RevokedAt)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+
+	t.Run("rotate needs the current hash", func(t *testing.T) {
+		rotated, err := repo.Rotate(ctx, session.ID, "hash-1", "hash-2", time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, rotated)
+		rotated, err = repo.Rotate(ctx, session.ID, "hash-1", "hash-3", time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, rotated)
+
+		got, err := repo.GetByID(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "hash-2", got.TokenHash)
+	})
+
+	t.Run("active sessions", func(t *testing.T) {
+		expired := newSession(userID, "hash-x", time.Now().Add(-time.Minute))
+		require.NoError(t, repo.Create(ctx, expired))
+		other := newSession(uuid.New(), "hash-o", time.Now().Add(time.Hour))
+		require.NoError(t, repo.Create(ctx, other))
+
+		sessions, err := repo.GetActiveByUser(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, session.ID, sessions[0].ID)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		second := newSession(userID, "hash-s", time.Now().Add(time.Hour))
+		require.NoError(t, repo.Create(ctx, second))
+
+		revoked, err := repo.RevokeByUser(ctx, userID, &session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, revoked)
+		got, err := repo.GetByID(ctx, second.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, got.RevokedAt)
+
+		rotated, err := repo.Rotate(ctx, second.ID, "hash-s", "hash-t", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, rotated, "revoked sessions are not refreshed")
+
+		require.NoError(t, repo.Revoke(ctx, session.ID))
+		assert.True(t, domain.IsNotFoundError(repo.Revoke(ctx, session.ID)))
+		sessions, err := repo.GetActiveByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("new sessions drop ended ones", func(t *testing.T) {
+		require.NoError(t, repo.Create(ctx, newSession(userID, "hash-n", time.Now().Add(time.Hour))))
+		_, err := repo.GetByID(ctx, session.ID)
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSOIdentityRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	cipher, err := database.NewFieldCipher("server-secret")
+	require.NoError(t, err)
+	db.SetFieldCipher(cipher)
+
+	ctx := context.Background()
+	repo := repository.NewSSOIdentityRepository(db)
+
+	_, err = repo.GetBySubject(ctx, "user-42")
+	assert.True(t, domain.IsNotFoundError(err))
+
+	userID := uuid.New()
+	require.NoError(t, repo.Save(ctx, &domain.SSOIdentity{UserID: userID, Subject: "user-42", RefreshToken: "refresh-1"}))
+
+	got, err := repo.GetBySubject(ctx, "user-42")
+	require.NoError(t, err)
+	assert.Equal(t, userID, got.UserID)
+	assert.Equal(t, "refresh-1", got.RefreshToken)
+
+	// The refresh token is sealed at rest
+	var stored string
+	require.NoError(t, db.DB().QueryRow(`SELECT refresh_token FROM sso_identities`).Scan(&stored))
+	assert.NotContains(t, stored, "refresh-1")
+
+	// A new user with the subject, after the first was deleted, replaces it
+	newUserID := uuid.New()
+	require.NoError(t, repo.Save(ctx, &domain.SSOIdentity{UserID: newUserID, Subject: "user-42"}))
+	got, err = repo.GetBySubject(ctx, "user-42")
+	require.NoError(t, err)
+	assert.Equal(t, newUserID, got.UserID)
+	assert.Empty(t, got.RefreshToken)
+	_, err = repo.GetByUserID(ctx, userID)
+	assert.True(t, domain.IsNotFoundError(err))
+}
@@ -0,0 +1,293 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewStatsRepository(db)
+	agentRepo := repository.NewAgentRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	wordlistRepo := repository.NewWordlistRepository(db)
+
+	for i, agent := range []struct {
+		status string
+		speed  int64
+	}{{"online", 1000}, {"busy", 500}, {"offline", 9000}} {
+		require.NoError(t, agentRepo.Create(ctx, &domain.Agent{
+			ID:        uuid.New(),
+			Name:      "agent-" + string(rune('a'+i)),
+			IPAddress: "10.0.0.1",
+			Port:      8080 + i,
+			Status:    agent.status,
+			Speed:     agent.speed,
+			LastSeen:  time.Now(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}))
+	}
+
+	words := int64(1000)
+	rockyou := &domain.Wordlist{ID: uuid.New(), Name: "stored.txt", OrigName: "rockyou.txt", Path: "/tmp/stored.txt", WordCount: &words}
+	require.NoError(t, wordlistRepo.Create(ctx, rockyou))
+
+	now := time.Now()
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+	group := uuid.New()
+	jobs := []struct {
+		status    string
+		hashType  int
+		wordlist  *uuid.UUID
+		group     *uuid.UUID
+		started   *time.Time
+		completed *time.Time
+		wordLimit int64
+		progress  float64
+	}{
+		// Two sub-jobs of one distributed job, cracked in 60s and 120s: 40 + 100 words
+		{domain.JobStatusCracked, 2500, &rockyou.ID, &group, at(2 * time.Hour), at(2*time.Hour - time.Minute), 400, 10},
+		{domain.JobStatusCancelled, 2500, &rockyou.ID, &group, at(2 * time.Hour), at(2*time.Hour - 2*time.Minute), 200, 50},
+		// Whole wordlist, 250 words
+		{domain.JobStatusCracked, 2500, &rockyou.ID, nil, at(3 * time.Hour), at(3*time.Hour - 2*time.Minute), 0, 25},
+		// Cracked two days ago, keyspace unknown
+		{domain.JobStatusCracked, 0, nil, nil, at(48 * time.Hour), at(48*time.Hour - 30*time.Second), 0, 100},
+		{domain.JobStatusRunning, 0, nil, nil, at(time.Minute), nil, 500, 50},
+		{domain.JobStatusPending, 0, nil, nil, nil, nil, 0, 0},
+	}
+	for i, j := range jobs {
+		job := &domain.Job{
+			ID:          uuid.New(),
+			Name:        "job",
+			Status:      j.status,
+			HashType:    j.hashType,
+			HashFile:    "hashes.txt",
+			Wordlist:    "custom.txt",
+			WordlistID:  j.wordlist,
+			GroupID:     j.group,
+			StartedAt:   j.started,
+			CompletedAt: j.completed,
+			Progress:    j.progress,
+			CreatedAt:   now.Add(-time.Duration(i) * time.Minute),
+			UpdatedAt:   now,
+		}
+		if j.wordLimit > 0 {
+			job.WordLimit = &j.wordLimit
+		}
+		require.NoError(t, jobRepo.Create(ctx, job))
+	}
+
+	// Deleted jobs don't count anywhere
+	deleted := &domain.Job{ID: uuid.New(), Name: "gone", Status: domain.JobStatusCracked, HashFile: "h", Wordlist: "gone.txt",
+		StartedAt: at(time.Hour), CompletedAt: at(time.Hour), CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, jobRepo.Create(ctx, deleted))
+	_, err = db.DB().Exec(`UPDATE jobs SET deleted_at = ? WHERE id = ?`, now, deleted.ID.String())
+	require.NoError(t, err)
+
+	agents, err := repo.GetAgentStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, domain.AgentStats{Total: 3, Active: 2, TotalSpeed: 1500}, *agents)
+
+	counts, err := repo.CountJobsByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"cracked": 3, "cancelled": 1, "running": 1, "pending": 1}, counts)
+
+	cracked, err := repo.CountCrackedSince(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, cracked)
+
+	wordlists, err := repo.GetTopWordlists(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, wordlists, 2)
+	assert.Equal(t, "custom.txt", wordlists[0].Name)
+	assert.Nil(t, wordlists[0].WordlistID)
+	assert.Equal(t, 3, wordlists[0].Jobs)
+	assert.Equal(t, "rockyou.txt", wordlists[1].Name)
+	require.NotNil(t, wordlists[1].WordlistID)
+	assert.Equal(t, rockyou.ID, *wordlists[1].WordlistID)
+	assert.Equal(t, 2, wordlists[1].Jobs, "sub-jobs of a distributed job count once")
+
+	crackTimes, err := repo.GetAverageTimeToCrack(ctx)
+	require.NoError(t, err)
+	require.Len(t, crackTimes, 2)
+	assert.Equal(t, 0, crackTimes[0].HashType)
+	assert.InDelta(t, 30, crackTimes[0].AvgSeconds, 0.1)
+	assert.Equal(t, 2500, crackTimes[1].HashType)
+	assert.Equal(t, 2, crackTimes[1].Cracked)
+	assert.InDelta(t, 90, crackTimes[1].AvgSeconds, 0.1)
+
+	days, err := repo.GetKeyspacePerDay(ctx, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	var processed int64
+	var finished int
+	for _, day := range days {
+		processed += day.Processed
+		finished += day.Jobs
+	}
+	assert.Equal(t, int64(390), processed, "running and pending jobs haven't finished")
+	assert.Equal(t, 4, finished)
+	assert.Equal(t, now.Add(-48*time.Hour).UTC().Format("2006-01-02"), days[0].Date)
+
+	// A tenant only counts its own jobs, but the shared agents too
+	tenantCtx := domain.WithTenantID(ctx, uuid.New())
+	counts, err = repo.CountJobsByStatus(tenantCtx)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+	wordlists, err = repo.GetTopWordlists(tenantCtx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, wordlists)
+	agents, err = repo.GetAgentStats(tenantCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, agents.Total)
+}
+
+func TestStatsRepository_GetUsage(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewStatsRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	project := &domain.Project{Name: "ACME"}
+	require.NoError(t, repository.NewProjectRepository(db).Create(ctx, project))
+
+	now := time.Now()
+	jobs := []struct {
+		project       *uuid.UUID
+		started       time.Time
+		deviceSeconds float64
+		energyWh      float64
+	}{
+		{&project.ID, now.Add(-time.Hour), 3600, 250},
+		{&project.ID, now.Add(-2 * time.Hour), 1800, 100},
+		{nil, now.Add(-time.Hour), 600, 0},
+		// Started before the report period
+		{&project.ID, now.Add(-72 * time.Hour), 7200, 500},
+		// Never reported any usage
+		{&project.ID, now.Add(-time.Hour), 0, 0},
+	}
+	var ids []uuid.UUID
+	for _, j := range jobs {
+		started := j.started
+		job := &domain.Job{ID: uuid.New(), Name: "job", Status: domain.JobStatusCompleted, HashFile: "h", Wordlist: "w",
+			ProjectID: j.project, StartedAt: &started, CreatedAt: started, UpdatedAt: now}
+		require.NoError(t, jobRepo.Create(ctx, job))
+		job.DeviceSeconds, job.EnergyWh = j.deviceSeconds, j.energyWh
+		require.NoError(t, jobRepo.Update(ctx, job))
+		ids = append(ids, job.ID)
+	}
+	// Deleted jobs used their compute all the same
+	_, err = db.DB().Exec(`UPDATE jobs SET deleted_at = ? WHERE id = ?`, now, ids[1].String())
+	require.NoError(t, err)
+
+	from := now.Add(-24 * time.Hour)
+	byProject, err := repo.GetUsage(ctx, domain.CostGroupProject, &from, nil)
+	require.NoError(t, err)
+	require.Len(t, byProject, 2)
+	assert.Equal(t, &project.ID, byProject[0].ID)
+	assert.Equal(t, "ACME", byProject[0].Name)
+	assert.Equal(t, 2, byProject[0].Jobs)
+	assert.InDelta(t, 5400, byProject[0].DeviceSeconds, 0.01)
+	assert.InDelta(t, 350, byProject[0].EnergyWh, 0.01)
+	assert.Nil(t, byProject[1].ID)
+	assert.Equal(t, "", byProject[1].Name)
+
+	byJob, err := repo.GetUsage(ctx, domain.CostGroupJob, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, byJob, 4)
+	assert.Equal(t, ids[3], *byJob[0].ID)
+
+	_, err = repo.GetUsage(ctx, "hash_type", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestStatsRepository_GetAttackOutcomes(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewStatsRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	wordlistRepo := repository.NewWordlistRepository(db)
+	hashFileRepo := repository.NewHashFileRepository(db)
+
+	rockyou := &domain.Wordlist{ID: uuid.New(), Name: "stored.txt", OrigName: "rockyou.txt", Path: "/tmp/stored.txt"}
+	require.NoError(t, wordlistRepo.Create(ctx, rockyou))
+	capture := &domain.HashFile{ID: uuid.New(), Name: "cap", OrigName: "office.hc22000", Path: "/tmp/cap", Type: "hash"}
+	require.NoError(t, hashFileRepo.Create(ctx, capture))
+	require.NoError(t, hashFileRepo.SetSource(ctx, capture.ID, "wifi"))
+	stored, err := hashFileRepo.GetByID(ctx, capture.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "wifi", stored.Source)
+
+	now := time.Now()
+	group := uuid.New()
+	jobs := []struct {
+		status   string
+		hashType int
+		mode     int
+		group    *uuid.UUID
+		rules    string
+		wordlist *uuid.UUID
+	}{
+		// A distributed job: one sub-job cracked, counted as one attack
+		{domain.JobStatusCracked, 22000, domain.AttackModeStraight, &group, "", &rockyou.ID},
+		{domain.JobStatusCompleted, 22000, domain.AttackModeStraight, &group, "", &rockyou.ID},
+		{domain.JobStatusCompleted, 22000, domain.AttackModeStraight, nil, "", &rockyou.ID},
+		{domain.JobStatusCompleted, 22000, domain.AttackModeStraight, nil, "best64.rule", &rockyou.ID},
+		// Not counted: unfinished, another hash mode, brute force, no wordlist
+		{domain.JobStatusRunning, 22000, domain.AttackModeStraight, nil, "", &rockyou.ID},
+		{domain.JobStatusCracked, 0, domain.AttackModeStraight, nil, "", &rockyou.ID},
+		{domain.JobStatusCracked, 22000, domain.AttackModeBruteForce, nil, "", nil},
+		{domain.JobStatusCracked, 22000, domain.AttackModeStraight, nil, "", nil},
+	}
+	var ids []uuid.UUID
+	for _, j := range jobs {
+		job := &domain.Job{ID: uuid.New(), Name: "job", Status: j.status, HashType: j.hashType, AttackMode: j.mode,
+			HashFile: "office.hc22000", HashFileID: &capture.ID, Wordlist: "rockyou.txt", WordlistID: j.wordlist,
+			Rules: j.rules, GroupID: j.group, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, jobRepo.Create(ctx, job))
+		ids = append(ids, job.ID)
+	}
+	// The rule attack completed with cracks of some of its hashes
+	_, err = jobRepo.CreateCracks(ctx, []domain.JobCrack{
+		{JobID: ids[3], Hash: "a", Plaintext: "summer2024", CrackedAt: now},
+		{JobID: ids[3], Hash: "b", Plaintext: "winter2024", CrackedAt: now},
+	})
+	require.NoError(t, err)
+
+	outcomes, err := repo.GetAttackOutcomes(ctx, 22000)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 2)
+	byRules := map[string]domain.AttackOutcome{}
+	for _, outcome := range outcomes {
+		assert.Equal(t, rockyou.ID, outcome.WordlistID)
+		assert.Equal(t, "rockyou.txt", outcome.Wordlist)
+		assert.Equal(t, "wifi", outcome.Source)
+		byRules[outcome.Rules] = outcome
+	}
+	assert.Equal(t, 2, byRules[""].Attacks)
+	assert.Equal(t, 1, byRules[""].Cracked)
+	assert.Equal(t, 1, byRules["best64.rule"].Attacks)
+	assert.Equal(t, 1, byRules["best64.rule"].Cracked, "cracks count when the job only completed")
+	assert.Equal(t, 2, byRules["best64.rule"].Cracks)
+}
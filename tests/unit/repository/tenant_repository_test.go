@@ -0,0 +1,112 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepository(t *testing.T) {
+	db := setupProjectDB(t)
+	ctx := context.Background()
+	repo := repository.NewTenantRepository(db)
+
+	acme := &domain.Tenant{Name: "Acme", Description: "red team"}
+	require.NoError(t, repo.Create(ctx, acme))
+	other := &domain.Tenant{Name: "Globex"}
+	require.NoError(t, repo.Create(ctx, other))
+
+	got, err := repo.GetByName(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, got.ID)
+	assert.Equal(t, "red team", got.Description)
+
+	tenants, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tenants, 2)
+
+	user := createUser(t, db, "alice")
+	require.NoError(t, repository.NewUserRepository(db.DB()).SetTenant(ctx, user.ID, &acme.ID))
+
+	// Rows created by a tenant user belong to their tenant
+	now := time.Now()
+	acmeCtx, otherCtx := domain.WithTenantID(ctx, acme.ID), domain.WithTenantID(ctx, other.ID)
+	hashFiles := repository.NewHashFileRepository(db)
+	hashFile := &domain.HashFile{ID: uuid.New(), Name: "h", OrigName: "h.txt", Path: "/tmp/h", Size: 100, Type: "hash", CreatedAt: now}
+	require.NoError(t, hashFiles.Create(acmeCtx, hashFile))
+	assert.Equal(t, &acme.ID, hashFile.TenantID)
+
+	jobs := repository.NewJobRepository(db)
+	job := &domain.Job{ID: uuid.New(), Name: "job", Status: domain.JobStatusPending, HashType: 0, HashFile: "/tmp/h",
+		HashFileID: &hashFile.ID, Wordlist: "w", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, jobs.Create(acmeCtx, job))
+
+	agents := repository.NewAgentRepository(db)
+	dedicated := &domain.Agent{ID: uuid.New(), Name: "gpu-acme", Status: "online", AgentKey: "a1", LastSeen: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, agents.Create(acmeCtx, dedicated))
+	shared := &domain.Agent{ID: uuid.New(), Name: "gpu-shared", Status: "online", AgentKey: "s1", LastSeen: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, agents.Create(ctx, shared))
+
+	// Another tenant can't see them
+	_, err = hashFiles.GetByID(otherCtx, hashFile.ID)
+	assert.Error(t, err)
+	_, err = jobs.GetByID(otherCtx, job.ID)
+	assert.Error(t, err)
+	otherAgents, err := agents.GetAll(otherCtx)
+	require.NoError(t, err)
+	require.Len(t, otherAgents, 1)
+	assert.Equal(t, shared.ID, otherAgents[0].ID)
+	assert.Error(t, agents.Delete(otherCtx, shared.ID), "a tenant can only delete its own agents")
+
+	// Nor can anonymous requests, which only see what's outside of tenants
+	anonCtx := domain.WithSharedScope(ctx)
+	_, err = jobs.GetByID(anonCtx, job.ID)
+	assert.Error(t, err)
+	anonFiles, err := hashFiles.GetAll(anonCtx)
+	require.NoError(t, err)
+	assert.Empty(t, anonFiles)
+	anonAgents, err := agents.GetAll(anonCtx)
+	require.NoError(t, err)
+	require.Len(t, anonAgents, 1)
+	assert.Equal(t, shared.ID, anonAgents[0].ID)
+	assert.Error(t, jobs.Delete(anonCtx, job.ID))
+	_, err = jobs.GetByID(domain.WithoutTenant(anonCtx), job.ID)
+	assert.NoError(t, err, "agent routes lift the shared scope")
+
+	// Their own tenant and the cluster can
+	_, err = jobs.GetByID(acmeCtx, job.ID)
+	assert.NoError(t, err)
+	acmeAgents, err := agents.GetAll(acmeCtx)
+	require.NoError(t, err)
+	assert.Len(t, acmeAgents, 2)
+	allFiles, err := hashFiles.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, allFiles, 1)
+
+	stats, err := repo.GetStats(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Users)
+	assert.Equal(t, 1, stats.Agents)
+	assert.Equal(t, 1, stats.Jobs)
+	assert.Equal(t, 1, stats.RunningJobs)
+	assert.Equal(t, 1, stats.HashFiles)
+
+	count, err := repo.CountResources(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	count, err = repo.CountResources(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	require.NoError(t, repo.Delete(ctx, other.ID))
+	_, err = repo.GetByID(ctx, other.ID)
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, other.ID)))
+}
@@ -0,0 +1,90 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	cipher, err := database.NewFieldCipher("server-secret")
+	require.NoError(t, err)
+	db.SetFieldCipher(cipher)
+
+	ctx := context.Background()
+	repo := repository.NewTwoFactorRepository(db)
+	userID := uuid.New()
+
+	_, err = repo.Get(ctx, userID)
+	assert.True(t, domain.IsNotFoundError(err))
+
+	require.NoError(t, repo.Save(ctx, &domain.TwoFactor{UserID: userID, Secret: "GEZDGNBVGY3TQOJQ", Enabled: true, LastUsedStep: 10}))
+	got, err := repo.Get(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQ", got.Secret)
+	assert.True(t, got.Enabled)
+
+	// The secret is sealed at rest
+	var stored string
+	require.NoError(t, db.DB().QueryRow(`SELECT secret FROM two_factor`).Scan(&stored))
+	assert.NotContains(t, stored, "GEZDGNBVGY3TQOJQ")
+
+	t.Run("codes are used once", func(t *testing.T) {
+		fresh, err := repo.UseStep(ctx, userID, 10)
+		require.NoError(t, err)
+		assert.False(t, fresh)
+		fresh, err = repo.UseStep(ctx, userID, 11)
+		require.NoError(t, err)
+		assert.True(t, fresh)
+		fresh, err = repo.UseStep(ctx, userID, 11)
+		require.NoError(t, err)
+		assert.False(t, fresh)
+	})
+
+	t.Run("recovery codes", func(t *testing.T) {
+		require.NoError(t, repo.ReplaceRecoveryCodes(ctx, userID, []string{"a", "b"}))
+		used, err := repo.UseRecoveryCode(ctx, userID, "a")
+		require.NoError(t, err)
+		assert.True(t, used)
+		used, err = repo.UseRecoveryCode(ctx, userID, "a")
+		require.NoError(t, err)
+		assert.False(t, used)
+		used, err = repo.UseRecoveryCode(ctx, uuid.New(), "b")
+		require.NoError(t, err)
+		assert.False(t, used, "codes belong to their user")
+
+		count, err := repo.CountRecoveryCodes(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("delete removes the recovery codes", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, userID))
+		_, err := repo.Get(ctx, userID)
+		assert.True(t, domain.IsNotFoundError(err))
+		count, err := repo.CountRecoveryCodes(ctx, userID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		policy, err := repo.GetPolicy(ctx)
+		require.NoError(t, err)
+		assert.False(t, policy.RequireForPrivileged)
+
+		require.NoError(t, repo.SavePolicy(ctx, &domain.TwoFactorPolicy{RequireForPrivileged: true}))
+		policy, err = repo.GetPolicy(ctx)
+		require.NoError(t, err)
+		assert.True(t, policy.RequireForPrivileged)
+	})
+}
@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/database"
+	"go-distributed-hashcat/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlistSourceRepository(t *testing.T) {
+	db, err := database.NewSQLiteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := repository.NewWordlistSourceRepository(db)
+
+	projectID := uuid.New()
+	source := &domain.WordlistSource{
+		Name:         "rockyou.txt",
+		URL:          "https://mirror.example/rockyou.txt.gz",
+		RefreshHours: 24,
+		ProjectID:    &projectID,
+		Status:       domain.WordlistSourcePending,
+	}
+	require.NoError(t, repo.Create(ctx, source))
+	assert.NotEqual(t, uuid.Nil, source.ID)
+
+	got, err := repo.GetByID(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example/rockyou.txt.gz", got.URL)
+	assert.Equal(t, &projectID, got.ProjectID)
+	assert.Nil(t, got.WordlistID)
+	assert.Nil(t, got.FetchedAt)
+
+	wordlistID := uuid.New()
+	fetchedAt := time.Now()
+	source.Status = domain.WordlistSourceReady
+	source.WordlistID = &wordlistID
+	source.FetchedSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	source.FetchedAt = &fetchedAt
+	require.NoError(t, repo.Update(ctx, source))
+
+	got, err = repo.GetByID(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.WordlistSourceReady, got.Status)
+	assert.Equal(t, &wordlistID, got.WordlistID)
+	assert.Equal(t, source.FetchedSHA256, got.FetchedSHA256)
+	require.NotNil(t, got.FetchedAt)
+
+	fetching := &domain.WordlistSource{Name: "common.txt", URL: "https://mirror.example/common.txt", Status: domain.WordlistSourceFetching}
+	require.NoError(t, repo.Create(ctx, fetching))
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, source.ID, all[0].ID, "oldest first")
+
+	// A restart fails the fetches that were running, and only those
+	failed, err := repo.FailFetching(ctx, "interrupted by server restart")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failed)
+
+	got, err = repo.GetByID(ctx, fetching.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.WordlistSourceFailed, got.Status)
+	assert.Equal(t, "interrupted by server restart", got.Error)
+
+	require.NoError(t, repo.Delete(ctx, source.ID))
+	_, err = repo.GetByID(ctx, source.ID)
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.True(t, domain.IsNotFoundError(repo.Delete(ctx, source.ID)))
+}
@@ -0,0 +1,106 @@
+package slack_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/slack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func signed(body string, at time.Time) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := "command=%2Fhashcat&text=status&user_id=U01ALICE&user_name=alice"
+
+	assert.NoError(t, slack.Verify(secret, signed(body, now.Add(-time.Minute)), []byte(body), now))
+	assert.ErrorIs(t, slack.Verify(secret, signed(body, now), []byte(body+"&text=crack"), now), slack.ErrBadSignature, "changed body")
+	assert.ErrorIs(t, slack.Verify("another-secret", signed(body, now), []byte(body), now), slack.ErrBadSignature)
+	assert.ErrorIs(t, slack.Verify(secret, signed(body, now.Add(-10*time.Minute)), []byte(body), now), slack.ErrBadSignature, "replayed")
+	assert.ErrorIs(t, slack.Verify(secret, http.Header{}, []byte(body), now), slack.ErrBadSignature)
+}
+
+func TestParse(t *testing.T) {
+	form, err := url.ParseQuery("command=%2Fhashcat&text=jobs+paused&user_id=U01ALICE&user_name=alice")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ChatCommand{
+		User: domain.ChatUser{Chat: "slack", ID: "U01ALICE", Name: "alice"},
+		Text: "jobs paused",
+	}, slack.ParseCommand(form))
+
+	interaction, err := slack.ParseInteraction(url.Values{"payload": {`{
+		"type": "block_actions",
+		"user": {"id": "U01ALICE", "username": "alice"},
+		"actions": [{"action_id": "cancel_job", "value": "job-uuid"}],
+		"response_url": "https://hooks.slack.com/actions/T1/1/abc"
+	}`}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/actions/T1/1/abc", interaction.ResponseURL)
+	assert.Equal(t, []domain.ChatAction{{
+		User:     domain.ChatUser{Chat: "slack", ID: "U01ALICE", Name: "alice"},
+		ActionID: domain.ChatActionCancelJob,
+		Value:    "job-uuid",
+	}}, interaction.Actions)
+
+	_, err = slack.ParseInteraction(url.Values{"payload": {"not json"}})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	message := slack.Render(&domain.ChatMessage{Text: "No running jobs."})
+	assert.Equal(t, "ephemeral", message.ResponseType)
+	require.Len(t, message.Blocks, 1)
+	assert.Equal(t, "No running jobs.", message.Blocks[0].Text.Text)
+
+	message = slack.Render(&domain.ChatMessage{
+		Text:   "alice queued *acme*",
+		Public: true,
+		Sections: []domain.ChatSection{{
+			Text:   "alice queued *acme*",
+			Fields: []domain.ChatField{{Label: "Status", Value: "pending"}},
+			Buttons: []domain.ChatButton{
+				{Text: "Pause", ActionID: domain.ChatActionPauseJob, Value: "job-uuid"},
+				{Text: "Cancel", ActionID: domain.ChatActionCancelJob, Value: "job-uuid", Danger: true},
+			},
+		}},
+	})
+	assert.Equal(t, "in_channel", message.ResponseType)
+	require.Len(t, message.Blocks, 2)
+	assert.Equal(t, "section", message.Blocks[0].Type)
+	assert.Equal(t, []slack.Text{{Type: "mrkdwn", Text: "*Status*\npending"}}, message.Blocks[0].Fields)
+
+	actions := message.Blocks[1]
+	assert.Equal(t, "actions", actions.Type)
+	require.Len(t, actions.Elements, 2)
+	assert.Nil(t, actions.Elements[0].Confirm)
+	assert.Equal(t, "danger", actions.Elements[1].Style)
+	assert.NotNil(t, actions.Elements[1].Confirm, "cancelling asks first")
+}
+
+func TestRespondOnlyToSlack(t *testing.T) {
+	for _, target := range []string{"http://hooks.slack.com/actions/1", "https://slack.com.evil.example/actions/1", "https://169.254.169.254/latest"} {
+		err := slack.Respond(context.Background(), target, &domain.ChatMessage{Text: "hi"})
+		assert.Error(t, err, target)
+	}
+}
@@ -0,0 +1,175 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAgentBenchmarkRepository struct {
+	mock.Mock
+}
+
+func (m *MockAgentBenchmarkRepository) GetByAgent(ctx context.Context, agentID uuid.UUID) ([]domain.AgentBenchmark, error) {
+	args := m.Called(ctx, agentID)
+	return args.Get(0).([]domain.AgentBenchmark), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) GetSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, hashType)
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) Save(ctx context.Context, benchmarks []domain.AgentBenchmark) error {
+	return m.Called(ctx, benchmarks).Error(0)
+}
+
+func (m *MockAgentBenchmarkRepository) Invalidate(ctx context.Context, agentID uuid.UUID, at time.Time) error {
+	return m.Called(ctx, agentID, at).Error(0)
+}
+
+func (m *MockAgentBenchmarkRepository) GetResetAt(ctx context.Context, agentID uuid.UUID) (*time.Time, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) CreateJob(ctx context.Context, job *domain.BenchmarkJob) error {
+	return m.Called(ctx, job).Error(0)
+}
+
+func (m *MockAgentBenchmarkRepository) GetJob(ctx context.Context, id uuid.UUID) (*domain.BenchmarkJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BenchmarkJob), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) GetJobs(ctx context.Context, agentID uuid.UUID, limit int) ([]domain.BenchmarkJob, error) {
+	args := m.Called(ctx, agentID, limit)
+	return args.Get(0).([]domain.BenchmarkJob), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) ClaimJob(ctx context.Context, agentID uuid.UUID, at time.Time) (*domain.BenchmarkJob, error) {
+	args := m.Called(ctx, agentID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BenchmarkJob), args.Error(1)
+}
+
+func (m *MockAgentBenchmarkRepository) UpdateJob(ctx context.Context, job *domain.BenchmarkJob) error {
+	return m.Called(ctx, job).Error(0)
+}
+
+func TestBenchmarkRequest_Validate(t *testing.T) {
+	req := &domain.BenchmarkRequest{HashTypes: []int{1000, 22000, 1000}}
+	require.NoError(t, req.Validate())
+	assert.Equal(t, []int{1000, 22000}, req.HashTypes)
+
+	assert.Error(t, (&domain.BenchmarkRequest{}).Validate())
+	assert.Error(t, (&domain.BenchmarkRequest{HashTypes: []int{-1}}).Validate())
+	tooMany := make([]int, domain.MaxBenchmarkHashTypes+1)
+	for i := range tooMany {
+		tooMany[i] = i
+	}
+	assert.Error(t, (&domain.BenchmarkRequest{HashTypes: tooMany}).Validate())
+}
+
+func TestAgentBenchmarkUsecase_RequestBenchmark(t *testing.T) {
+	ctx := context.Background()
+	agent := &domain.Agent{ID: uuid.New(), Name: "gpu-1"}
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByID", ctx, agent.ID).Return(agent, nil)
+	missing := uuid.New()
+	agentRepo.On("GetByID", ctx, missing).Return(nil, domain.ErrAgentNotFound)
+	benchmarkRepo := new(MockAgentBenchmarkRepository)
+	benchmarkRepo.On("CreateJob", ctx, mock.AnythingOfType("*domain.BenchmarkJob")).Return(nil)
+
+	uc := usecase.NewAgentBenchmarkUsecase(benchmarkRepo, agentRepo)
+	job, err := uc.RequestBenchmark(ctx, agent.ID, &domain.BenchmarkRequest{HashTypes: []int{1000, 1000, 22000}})
+	require.NoError(t, err)
+	assert.Equal(t, agent.ID, job.AgentID)
+	assert.Equal(t, []int{1000, 22000}, job.HashTypes)
+	assert.Equal(t, domain.BenchmarkJobPending, job.Status)
+
+	_, err = uc.RequestBenchmark(ctx, missing, &domain.BenchmarkRequest{HashTypes: []int{0}})
+	assert.True(t, domain.IsNotFoundError(err))
+	_, err = uc.RequestBenchmark(ctx, agent.ID, &domain.BenchmarkRequest{HashTypes: []int{100000}})
+	assert.True(t, domain.IsValidationError(err))
+	benchmarkRepo.AssertNumberOfCalls(t, "CreateJob", 1)
+}
+
+func TestAgentBenchmarkUsecase_InvalidateBenchmarks(t *testing.T) {
+	ctx := context.Background()
+	agent := &domain.Agent{ID: uuid.New(), Name: "gpu-1", Speed: 5000}
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByID", ctx, agent.ID).Return(agent, nil)
+	agentRepo.On("UpdateSpeed", ctx, agent.ID, int64(0)).Return(nil)
+	benchmarkRepo := new(MockAgentBenchmarkRepository)
+	benchmarkRepo.On("Invalidate", ctx, agent.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	uc := usecase.NewAgentBenchmarkUsecase(benchmarkRepo, agentRepo)
+	require.NoError(t, uc.InvalidateBenchmarks(ctx, agent.ID))
+	benchmarkRepo.AssertExpectations(t)
+	agentRepo.AssertExpectations(t)
+}
+
+func TestAgentBenchmarkUsecase_ReportBenchmarkJob(t *testing.T) {
+	ctx := context.Background()
+	agentID := uuid.New()
+
+	newJob := func() (usecase.AgentBenchmarkUsecase, *MockAgentBenchmarkRepository, *domain.BenchmarkJob) {
+		job := &domain.BenchmarkJob{ID: uuid.New(), AgentID: agentID, HashTypes: []int{1000, 22000}, Status: domain.BenchmarkJobRunning}
+		benchmarkRepo := new(MockAgentBenchmarkRepository)
+		benchmarkRepo.On("GetJob", ctx, job.ID).Return(job, nil)
+		benchmarkRepo.On("Save", ctx, mock.Anything).Return(nil)
+		benchmarkRepo.On("UpdateJob", ctx, job).Return(nil)
+		return usecase.NewAgentBenchmarkUsecase(benchmarkRepo, new(MockAgentRepository)), benchmarkRepo, job
+	}
+
+	t.Run("stores the measured modes", func(t *testing.T) {
+		uc, benchmarkRepo, job := newJob()
+		got, err := uc.ReportBenchmarkJob(ctx, agentID, job.ID, &domain.BenchmarkReport{
+			Speeds: map[int]int64{1000: 98_000_000_000, 0: 5}, // Modes not asked for are ignored
+			Error:  "mode 22000: no devices support it",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.BenchmarkJobCompleted, got.Status)
+		assert.NotNil(t, got.CompletedAt)
+		assert.Equal(t, "mode 22000: no devices support it; no speed for hash modes 22000", got.Error)
+
+		saved := benchmarkRepo.Calls[1].Arguments.Get(1).([]domain.AgentBenchmark)
+		require.Len(t, saved, 1)
+		assert.Equal(t, domain.AgentBenchmark{AgentID: agentID, HashType: 1000, Speed: 98_000_000_000, MeasuredAt: saved[0].MeasuredAt}, saved[0])
+	})
+
+	t.Run("fails without speeds", func(t *testing.T) {
+		uc, _, job := newJob()
+		got, err := uc.ReportBenchmarkJob(ctx, agentID, job.ID, &domain.BenchmarkReport{Error: "hashcat not found"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.BenchmarkJobFailed, got.Status)
+		assert.Contains(t, got.Error, "hashcat not found")
+	})
+
+	t.Run("refuses other agents and finished jobs", func(t *testing.T) {
+		uc, _, job := newJob()
+		_, err := uc.ReportBenchmarkJob(ctx, uuid.New(), job.ID, &domain.BenchmarkReport{})
+		assert.True(t, domain.IsNotFoundError(err))
+
+		job.Status = domain.BenchmarkJobCompleted
+		_, err = uc.ReportBenchmarkJob(ctx, agentID, job.ID, &domain.BenchmarkReport{})
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
@@ -0,0 +1,154 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAgentCommandRepository struct {
+	mock.Mock
+}
+
+func (m *MockAgentCommandRepository) Create(ctx context.Context, command *domain.AgentCommand) error {
+	return m.Called(ctx, command).Error(0)
+}
+
+func (m *MockAgentCommandRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AgentCommand, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentCommand), args.Error(1)
+}
+
+func (m *MockAgentCommandRepository) GetByAgent(ctx context.Context, agentID uuid.UUID, limit int) ([]domain.AgentCommand, error) {
+	args := m.Called(ctx, agentID, limit)
+	return args.Get(0).([]domain.AgentCommand), args.Error(1)
+}
+
+func (m *MockAgentCommandRepository) GetPending(ctx context.Context, agentID uuid.UUID) ([]domain.AgentCommand, error) {
+	args := m.Called(ctx, agentID)
+	return args.Get(0).([]domain.AgentCommand), args.Error(1)
+}
+
+func (m *MockAgentCommandRepository) Expire(ctx context.Context, agentID uuid.UUID, now time.Time) error {
+	return m.Called(ctx, agentID, now).Error(0)
+}
+
+func (m *MockAgentCommandRepository) Transition(ctx context.Context, command *domain.AgentCommand, from string) (bool, error) {
+	args := m.Called(ctx, command, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestAgentCommandRequest_Validate(t *testing.T) {
+	req := &domain.AgentCommandRequest{Type: domain.AgentCommandClearCache, Params: json.RawMessage("null")}
+	require.NoError(t, req.Validate())
+	assert.Nil(t, req.Params)
+	require.NoError(t, (&domain.AgentCommandRequest{Type: domain.AgentCommandUpgrade, Params: json.RawMessage(`{"version":"1.4.0"}`)}).Validate())
+	require.NoError(t, (&domain.AgentCommandRequest{Type: domain.AgentCommandUpgrade}).Validate())
+
+	assert.Error(t, (&domain.AgentCommandRequest{Type: "reboot"}).Validate())
+	assert.Error(t, (&domain.AgentCommandRequest{Type: domain.AgentCommandBenchmark, Params: json.RawMessage(`{"hash_type":0}`)}).Validate())
+	assert.Error(t, (&domain.AgentCommandRequest{Type: domain.AgentCommandUpgrade, Params: json.RawMessage(`{"version":"1.4; rm -rf /"}`)}).Validate())
+	assert.Error(t, (&domain.AgentCommandResult{Result: json.RawMessage(`{"broken"`)}).Validate())
+}
+
+func TestAgentCommandUsecase_QueueCommand(t *testing.T) {
+	ctx := context.Background()
+	agent := &domain.Agent{ID: uuid.New(), Name: "gpu-1"}
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByID", ctx, agent.ID).Return(agent, nil)
+	missing := uuid.New()
+	agentRepo.On("GetByID", ctx, missing).Return(nil, domain.ErrAgentNotFound)
+	commandRepo := new(MockAgentCommandRepository)
+	commandRepo.On("Create", ctx, mock.AnythingOfType("*domain.AgentCommand")).Return(nil)
+
+	uc := usecase.NewAgentCommandUsecase(commandRepo, agentRepo)
+	command, err := uc.QueueCommand(ctx, agent.ID, &domain.AgentCommandRequest{Type: domain.AgentCommandCollectDiagnostics})
+	require.NoError(t, err)
+	assert.Equal(t, agent.ID, command.AgentID)
+	assert.Equal(t, domain.AgentCommandPending, command.Status)
+	assert.Equal(t, domain.AgentCommandTTL, command.ExpiresAt.Sub(command.CreatedAt))
+
+	_, err = uc.QueueCommand(ctx, missing, &domain.AgentCommandRequest{Type: domain.AgentCommandBenchmark})
+	assert.True(t, domain.IsNotFoundError(err))
+	_, err = uc.QueueCommand(ctx, agent.ID, &domain.AgentCommandRequest{Type: "reboot"})
+	assert.True(t, domain.IsValidationError(err))
+	commandRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestAgentCommandUsecase_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	agentID := uuid.New()
+
+	newCommand := func(status string) (usecase.AgentCommandUsecase, *MockAgentCommandRepository, *domain.AgentCommand) {
+		command := &domain.AgentCommand{ID: uuid.New(), AgentID: agentID, Type: domain.AgentCommandClearCache,
+			Status: status, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+		commandRepo := new(MockAgentCommandRepository)
+		commandRepo.On("GetByID", ctx, command.ID).Return(command, nil)
+		return usecase.NewAgentCommandUsecase(commandRepo, new(MockAgentRepository)), commandRepo, command
+	}
+
+	t.Run("acknowledges a pending command once", func(t *testing.T) {
+		uc, commandRepo, command := newCommand(domain.AgentCommandPending)
+		commandRepo.On("Transition", ctx, command, domain.AgentCommandPending).Return(true, nil).Once()
+		got, err := uc.AcknowledgeCommand(ctx, agentID, command.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.AgentCommandAcknowledged, got.Status)
+		assert.NotNil(t, got.AcknowledgedAt)
+
+		// The stored status has moved on, so a second acknowledgement fails
+		commandRepo.On("Transition", ctx, command, domain.AgentCommandPending).Return(false, nil)
+		_, err = uc.AcknowledgeCommand(ctx, agentID, command.ID)
+		assert.True(t, domain.IsValidationError(err))
+	})
+
+	t.Run("refuses expired commands", func(t *testing.T) {
+		uc, commandRepo, command := newCommand(domain.AgentCommandPending)
+		command.ExpiresAt = time.Now().Add(-time.Second)
+		commandRepo.On("Expire", ctx, agentID, mock.AnythingOfType("time.Time")).Return(nil)
+		_, err := uc.AcknowledgeCommand(ctx, agentID, command.ID)
+		assert.True(t, domain.IsValidationError(err))
+		commandRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports results", func(t *testing.T) {
+		uc, commandRepo, command := newCommand(domain.AgentCommandAcknowledged)
+		commandRepo.On("Transition", ctx, command, domain.AgentCommandAcknowledged).Return(true, nil)
+		got, err := uc.ReportCommandResult(ctx, agentID, command.ID, &domain.AgentCommandResult{Result: json.RawMessage(`{"removed_files":3}`)})
+		require.NoError(t, err)
+		assert.Equal(t, domain.AgentCommandCompleted, got.Status)
+		assert.NotNil(t, got.CompletedAt)
+
+		got, err = uc.ReportCommandResult(ctx, agentID, command.ID, &domain.AgentCommandResult{Error: "download cache is not set up"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.AgentCommandFailed, got.Status)
+		assert.Equal(t, "download cache is not set up", got.Error)
+	})
+
+	t.Run("hides other agents' commands", func(t *testing.T) {
+		uc, _, command := newCommand(domain.AgentCommandPending)
+		_, err := uc.AcknowledgeCommand(ctx, uuid.New(), command.ID)
+		assert.True(t, domain.IsNotFoundError(err))
+		_, err = uc.CancelCommand(ctx, uuid.New(), command.ID)
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+
+	t.Run("cancels pending commands only", func(t *testing.T) {
+		uc, commandRepo, command := newCommand(domain.AgentCommandPending)
+		commandRepo.On("Transition", ctx, command, domain.AgentCommandPending).Return(true, nil)
+		got, err := uc.CancelCommand(ctx, agentID, command.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.AgentCommandCancelled, got.Status)
+	})
+}
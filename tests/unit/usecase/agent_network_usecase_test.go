@@ -0,0 +1,146 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAgentNetworkRepository keeps network rules and denials in slices
+type memoryAgentNetworkRepository struct {
+	rules   []domain.AgentNetworkRule
+	denials []domain.AgentAccessDenial
+}
+
+func (r *memoryAgentNetworkRepository) CreateRule(ctx context.Context, rule *domain.AgentNetworkRule) error {
+	rule.ID = uuid.New()
+	r.rules = append(r.rules, *rule)
+	return nil
+}
+
+func (r *memoryAgentNetworkRepository) GetRules(ctx context.Context) ([]domain.AgentNetworkRule, error) {
+	return append([]domain.AgentNetworkRule{}, r.rules...), nil
+}
+
+func (r *memoryAgentNetworkRepository) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	for i, rule := range r.rules {
+		if rule.ID == id {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return &domain.NotFoundError{Entity: "network rule"}
+}
+
+func (r *memoryAgentNetworkRepository) RecordDenial(ctx context.Context, denial *domain.AgentAccessDenial, keep int) error {
+	r.denials = append(r.denials, *denial)
+	return nil
+}
+
+func (r *memoryAgentNetworkRepository) GetDenials(ctx context.Context, limit int) ([]domain.AgentAccessDenial, error) {
+	return r.denials, nil
+}
+
+func TestAgentNetworkUsecase_Check(t *testing.T) {
+	ctx := context.Background()
+	agent := &domain.Agent{ID: uuid.New(), Name: "gpu-01", AgentKey: "a1b2c3d4e5f6"}
+	check := func(ip string) domain.AgentNetworkCheck {
+		return domain.AgentNetworkCheck{IPAddress: ip, AgentKey: agent.AgentKey, Path: "POST /api/v1/agents/heartbeat"}
+	}
+
+	t.Run("no rules let everyone in", func(t *testing.T) {
+		network := usecase.NewAgentNetworkUsecase(&memoryAgentNetworkRepository{}, new(MockAgentRepository), nil)
+		assert.NoError(t, network.Check(ctx, check("203.0.113.9")))
+	})
+
+	t.Run("global allowlist from the configuration", func(t *testing.T) {
+		static, err := usecase.ParseAgentNetworkRules([]string{"10.0.0.0/8", "192.0.2.1"}, domain.NetworkRuleAllow)
+		require.NoError(t, err)
+		assert.Equal(t, "192.0.2.1/32", static[1].CIDR)
+
+		repo := &memoryAgentNetworkRepository{}
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByAgentKey", mock.Anything, "unknown-key-99").Return(nil, domain.ErrAgentNotFound)
+		network := usecase.NewAgentNetworkUsecase(repo, agentRepo, static)
+
+		assert.NoError(t, network.Check(ctx, domain.AgentNetworkCheck{IPAddress: "10.1.2.3"}))
+		assert.NoError(t, network.Check(ctx, domain.AgentNetworkCheck{IPAddress: "192.0.2.1"}))
+		err = network.Check(ctx, domain.AgentNetworkCheck{IPAddress: "203.0.113.9", AgentKey: "unknown-key-99", Path: "POST /api/v1/agents/startup"})
+		assert.ErrorIs(t, err, domain.ErrAgentNetworkDenied)
+
+		require.Len(t, repo.denials, 1)
+		assert.Equal(t, "203.0.113.9", repo.denials[0].IPAddress)
+		assert.Equal(t, "un**********99", repo.denials[0].AgentKey, "unknown keys are masked")
+		assert.Equal(t, "POST /api/v1/agents/startup", repo.denials[0].Path)
+	})
+
+	t.Run("an agent's allowlist replaces the global one", func(t *testing.T) {
+		static, err := usecase.ParseAgentNetworkRules([]string{"10.0.0.0/8"}, domain.NetworkRuleAllow)
+		require.NoError(t, err)
+		repo := &memoryAgentNetworkRepository{}
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByAgentKey", mock.Anything, agent.AgentKey).Return(agent, nil)
+		agentRepo.On("GetByID", mock.Anything, agent.ID).Return(agent, nil)
+		network := usecase.NewAgentNetworkUsecase(repo, agentRepo, static)
+
+		_, err = network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "198.51.100.0/24", Action: domain.NetworkRuleAllow, AgentKey: agent.AgentKey})
+		require.NoError(t, err)
+		require.Equal(t, agent.ID, *repo.rules[0].AgentID, "the agent key is stored as the agent's ID")
+
+		assert.NoError(t, network.Check(ctx, check("198.51.100.20")))
+		assert.ErrorIs(t, network.Check(ctx, check("10.1.2.3")), domain.ErrAgentNetworkDenied)
+		assert.NoError(t, network.Check(ctx, domain.AgentNetworkCheck{IPAddress: "10.1.2.3"}), "other agents keep the global allowlist")
+
+		require.Len(t, repo.denials, 1)
+		assert.Equal(t, agent.ID, *repo.denials[0].AgentID)
+		assert.Equal(t, "gpu-01", repo.denials[0].AgentName)
+		assert.Empty(t, repo.denials[0].AgentKey)
+	})
+
+	t.Run("deny rules win", func(t *testing.T) {
+		repo := &memoryAgentNetworkRepository{}
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByID", mock.Anything, agent.ID).Return(agent, nil)
+		network := usecase.NewAgentNetworkUsecase(repo, agentRepo, nil)
+
+		_, err := network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "0.0.0.0/0", Action: domain.NetworkRuleAllow})
+		require.NoError(t, err)
+		deny, err := network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "203.0.113.0/24", Action: domain.NetworkRuleDeny, AgentID: &agent.ID})
+		require.NoError(t, err)
+
+		byID := domain.AgentNetworkCheck{IPAddress: "203.0.113.9", AgentID: &agent.ID}
+		assert.ErrorIs(t, network.Check(ctx, byID), domain.ErrAgentNetworkDenied)
+
+		// Changes apply right away
+		require.NoError(t, network.DeleteRule(ctx, deny.ID))
+		assert.NoError(t, network.Check(ctx, byID))
+	})
+}
+
+func TestAgentNetworkUsecase_CreateRule(t *testing.T) {
+	ctx := context.Background()
+	network := usecase.NewAgentNetworkUsecase(&memoryAgentNetworkRepository{}, new(MockAgentRepository), nil)
+
+	rule, err := network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "2001:db8::1", Action: domain.NetworkRuleDeny})
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1/128", rule.CIDR)
+
+	rule, err = network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "10.1.2.3/8", Action: domain.NetworkRuleAllow})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8", rule.CIDR)
+
+	_, err = network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "10.0.0.0/33", Action: domain.NetworkRuleAllow})
+	assert.True(t, domain.IsValidationError(err))
+	_, err = network.CreateRule(ctx, &domain.CreateAgentNetworkRuleRequest{CIDR: "10.0.0.0/8", Action: "maybe"})
+	assert.True(t, domain.IsValidationError(err))
+
+	_, err = usecase.ParseAgentNetworkRules([]string{"not-a-network"}, domain.NetworkRuleDeny)
+	assert.True(t, domain.IsValidationError(err))
+}
@@ -0,0 +1,120 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func staleAgents() (busy, idle, archived domain.StaleAgent) {
+	archivedAt := time.Now().Add(-24 * time.Hour)
+	busy = domain.StaleAgent{ID: uuid.New(), Name: "gpu-1", ActiveJobs: 1}
+	idle = domain.StaleAgent{ID: uuid.New(), Name: "gpu-2"}
+	archived = domain.StaleAgent{ID: uuid.New(), Name: "gpu-3", ArchivedAt: &archivedAt}
+	return busy, idle, archived
+}
+
+func TestAgentUsecase_PurgeStaleAgents(t *testing.T) {
+	ctx := context.Background()
+	busy, idle, archived := staleAgents()
+	olderThan := func(days int) interface{} {
+		return mock.MatchedBy(func(cutoff time.Time) bool {
+			age := time.Since(cutoff)
+			return age >= time.Duration(days)*24*time.Hour && age < time.Duration(days)*24*time.Hour+time.Minute
+		})
+	}
+
+	t.Run("archives idle agents and skips those with jobs", func(t *testing.T) {
+		repo := new(MockAgentRepository)
+		repo.On("GetStale", mock.Anything, olderThan(30)).Return([]domain.StaleAgent{busy, idle, archived}, nil)
+		repo.On("Archive", mock.Anything, idle.ID).Return(nil)
+		agents := usecase.NewAgentUsecase(repo)
+		require.NoError(t, agents.SetAgentRetention(domain.AgentRetentionPolicy{StaleAfter: 30 * 24 * time.Hour, Action: domain.AgentRetentionArchive}))
+
+		result, err := agents.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{Action: domain.AgentRetentionArchive})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.StaleAgent{idle}, result.Purged, "already archived agents are left out")
+		assert.Equal(t, []domain.StaleAgent{busy}, result.Skipped)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("deletes the selected agents", func(t *testing.T) {
+		repo := new(MockAgentRepository)
+		repo.On("GetStale", mock.Anything, olderThan(7)).Return([]domain.StaleAgent{busy, idle, archived}, nil)
+		repo.On("Delete", mock.Anything, archived.ID).Return(nil)
+		agents := usecase.NewAgentUsecase(repo)
+
+		result, err := agents.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{OlderThanDays: 7, AgentIDs: []uuid.UUID{busy.ID, archived.ID}})
+		require.NoError(t, err)
+		assert.Equal(t, domain.AgentRetentionDelete, result.Action)
+		assert.Equal(t, []domain.StaleAgent{archived}, result.Purged)
+		assert.Equal(t, []domain.StaleAgent{busy}, result.Skipped)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, idle.ID)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, busy.ID)
+	})
+
+	t.Run("a dry run changes nothing", func(t *testing.T) {
+		repo := new(MockAgentRepository)
+		repo.On("GetStale", mock.Anything, olderThan(7)).Return([]domain.StaleAgent{busy, idle}, nil)
+		agents := usecase.NewAgentUsecase(repo)
+
+		result, err := agents.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{OlderThanDays: 7, DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, []domain.StaleAgent{idle}, result.Purged)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("needs a stale period", func(t *testing.T) {
+		agents := usecase.NewAgentUsecase(new(MockAgentRepository))
+		_, err := agents.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{})
+		assert.True(t, domain.IsValidationError(err))
+		_, err = agents.PurgeStaleAgents(ctx, &domain.PurgeStaleAgentsRequest{OlderThanDays: 7, Action: domain.AgentRetentionFlag})
+		assert.True(t, domain.IsValidationError(err))
+		assert.True(t, domain.IsValidationError(agents.SetAgentRetention(domain.AgentRetentionPolicy{Action: "shred"})))
+	})
+}
+
+func TestAgentRetentionWorker_RunOnce(t *testing.T) {
+	t.Run("flags stale agents without touching them", func(t *testing.T) {
+		busy, idle, _ := staleAgents()
+		repo := new(MockAgentRepository)
+		repo.On("GetStale", mock.Anything, mock.Anything).Return([]domain.StaleAgent{busy, idle}, nil)
+		agents := usecase.NewAgentUsecase(repo)
+
+		policy := domain.AgentRetentionPolicy{StaleAfter: 30 * 24 * time.Hour, Action: domain.AgentRetentionFlag}
+		usecase.NewAgentRetentionWorker(agents, policy, time.Hour).RunOnce(context.Background())
+
+		repo.AssertCalled(t, "GetStale", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deletes stale agents", func(t *testing.T) {
+		_, idle, _ := staleAgents()
+		repo := new(MockAgentRepository)
+		repo.On("GetStale", mock.Anything, mock.Anything).Return([]domain.StaleAgent{idle}, nil)
+		repo.On("Delete", mock.Anything, idle.ID).Return(nil)
+		agents := usecase.NewAgentUsecase(repo)
+		policy := domain.AgentRetentionPolicy{StaleAfter: 30 * 24 * time.Hour, Action: domain.AgentRetentionDelete}
+		require.NoError(t, agents.SetAgentRetention(policy))
+
+		usecase.NewAgentRetentionWorker(agents, policy, time.Hour).RunOnce(context.Background())
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("a zero stale period disables the policy", func(t *testing.T) {
+		repo := new(MockAgentRepository)
+		usecase.NewAgentRetentionWorker(usecase.NewAgentUsecase(repo), domain.AgentRetentionPolicy{}, time.Hour).RunOnce(context.Background())
+		repo.AssertNotCalled(t, "GetStale", mock.Anything, mock.Anything)
+	})
+}
@@ -3,6 +3,7 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -122,6 +123,127 @@ func (m *MockAgentRepository) ResetSpeedOnOffline(ctx context.Context, id uuid.U
 	return args.Error(0)
 }
 
+func (m *MockAgentRepository) CreateGroup(ctx context.Context, group *domain.AgentGroup) error {
+	args := m.Called(ctx, group)
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.AgentGroup, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentGroup), args.Error(1)
+}
+
+func (m *MockAgentRepository) GetAllGroups(ctx context.Context) ([]domain.AgentGroup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.AgentGroup), args.Error(1)
+}
+
+func (m *MockAgentRepository) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) AddGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error {
+	args := m.Called(ctx, groupID, agentID)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) RemoveGroupMember(ctx context.Context, groupID, agentID uuid.UUID) error {
+	args := m.Called(ctx, groupID, agentID)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]domain.Agent, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).([]domain.Agent), args.Error(1)
+}
+
+func (m *MockAgentRepository) ReplaceFiles(ctx context.Context, agentID uuid.UUID, files []domain.AgentFile) error {
+	args := m.Called(ctx, agentID, files)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetFiles(ctx context.Context, agentID uuid.UUID) ([]domain.AgentFile, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AgentFile), args.Error(1)
+}
+
+func (m *MockAgentRepository) UpdateHeartbeat(ctx context.Context, id uuid.UUID, heartbeat *domain.AgentHeartbeat) error {
+	args := m.Called(ctx, id, heartbeat)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) UpdateLastSeenBatch(ctx context.Context, seen map[uuid.UUID]domain.AgentSeen) error {
+	args := m.Called(ctx, seen)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetHashcatArgs(ctx context.Context, agentID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAgentRepository) UpdateHashcatArgs(ctx context.Context, agentID uuid.UUID, hashcatArgs []string) error {
+	args := m.Called(ctx, agentID, hashcatArgs)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetSettings(ctx context.Context, agentID uuid.UUID) (*domain.AgentSettings, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentSettings), args.Error(1)
+}
+
+func (m *MockAgentRepository) UpdateSettings(ctx context.Context, agentID uuid.UUID, settings *domain.AgentSettings) error {
+	args := m.Called(ctx, agentID, settings)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) AppendLogs(ctx context.Context, agentID uuid.UUID, lines []domain.AgentLogLine, keep int) error {
+	args := m.Called(ctx, agentID, lines, keep)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) GetLogs(ctx context.Context, agentID uuid.UUID, afterID int64, limit int) ([]domain.AgentLogLine, error) {
+	args := m.Called(ctx, agentID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AgentLogLine), args.Error(1)
+}
+
+func (m *MockAgentRepository) GetStale(ctx context.Context, seenBefore time.Time) ([]domain.StaleAgent, error) {
+	args := m.Called(ctx, seenBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StaleAgent), args.Error(1)
+}
+
+func (m *MockAgentRepository) Archive(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAgentRepository) SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error {
+	args := m.Called(ctx, id, tenantID)
+	return args.Error(0)
+}
+
 func TestAgentUsecase_RegisterAgent(t *testing.T) {
 	existingAgentID := uuid.New()
 
@@ -717,3 +839,337 @@ func TestAgentUsecase_UpdateAgentHeartbeat(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentUsecase_AgentCacheReport(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	ctx := context.Background()
+
+	_, err := usecase.GetAgentCacheReport(ctx, agentID)
+	assert.True(t, domain.IsNotFoundError(err))
+
+	report := &domain.AgentCacheReport{
+		UsedBytes:  1024,
+		LimitBytes: 4096,
+		Entries: []domain.AgentCacheEntry{
+			{Kind: "wordlist", ID: uuid.New(), Name: "rockyou.txt", Size: 1024, SHA256: "abc"},
+		},
+	}
+	assert.NoError(t, usecase.UpdateAgentCacheReport(ctx, agentID, report))
+
+	// Later changes to the caller's report must not leak into the stored copy
+	report.Entries[0].Name = "changed"
+
+	stored, err := usecase.GetAgentCacheReport(ctx, agentID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), stored.UsedBytes)
+	assert.Equal(t, "rockyou.txt", stored.Entries[0].Name)
+	assert.False(t, stored.ReportedAt.IsZero())
+
+	// Deleting the agent drops its report
+	mockRepo.On("Delete", mock.Anything, agentID).Return(nil)
+	assert.NoError(t, usecase.DeleteAgent(ctx, agentID))
+	_, err = usecase.GetAgentCacheReport(ctx, agentID)
+	assert.Error(t, err)
+}
+
+func TestAgentUsecase_AgentFiles(t *testing.T) {
+	agentID := uuid.New()
+	unknownID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID}, nil)
+	mockRepo.On("GetByID", mock.Anything, unknownID).Return(nil, domain.ErrAgentNotFound)
+	mockRepo.On("ReplaceFiles", mock.Anything, agentID, mock.MatchedBy(func(files []domain.AgentFile) bool {
+		return len(files) == 1 && files[0].AgentID == agentID && !files[0].ReportedAt.IsZero()
+	})).Return(nil)
+	mockRepo.On("GetFiles", mock.Anything, agentID).Return([]domain.AgentFile{{AgentID: agentID, Name: "rockyou.txt"}}, nil)
+
+	err := usecase.ReplaceAgentFiles(ctx, agentID, []domain.AgentFile{{Name: "rockyou.txt", Size: 1024, Type: "wordlist"}})
+	assert.NoError(t, err)
+
+	files, err := usecase.GetAgentFiles(ctx, agentID)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// Unknown agents can neither report nor be queried
+	assert.True(t, domain.IsNotFoundError(usecase.ReplaceAgentFiles(ctx, unknownID, nil)))
+	_, err = usecase.GetAgentFiles(ctx, unknownID)
+	assert.True(t, domain.IsNotFoundError(err))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAgentUsecase_AcceptAgentHeartbeat(t *testing.T) {
+	agentID := uuid.New()
+	quietID := uuid.New()
+	jobID := uuid.New()
+	config := usecase.HeartbeatConfig{Interval: 5 * time.Second, MinInterval: 2 * time.Second, MaxInterval: 30 * time.Second}
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	usecase.SetHeartbeatConfig(config)
+	ctx := context.Background()
+
+	// Requested intervals are clamped, agents asking for none get the default
+	assert.Equal(t, 2*time.Second, usecase.AcceptAgentHeartbeat(ctx, quietID, time.Second, nil))
+	assert.Equal(t, 30*time.Second, usecase.AcceptAgentHeartbeat(ctx, quietID, time.Hour, nil))
+	assert.Equal(t, 10*time.Second, usecase.AcceptAgentHeartbeat(ctx, quietID, 10*time.Second, nil))
+	assert.Equal(t, 10*time.Second, usecase.AgentHeartbeatInterval(quietID))
+	assert.Equal(t, 5*time.Second, usecase.AcceptAgentHeartbeat(ctx, agentID, 0, nil))
+	assert.Equal(t, 5*time.Second, usecase.AgentHeartbeatInterval(uuid.New()))
+
+	// The agent's clock is not trusted, the server stamps the snapshot
+	sent := &domain.AgentHeartbeat{
+		CurrentJobID:     &jobID,
+		GPUUtilization:   97,
+		HashcatAvailable: true,
+		ReportedAt:       time.Now().Add(-time.Hour),
+	}
+	usecase.AcceptAgentHeartbeat(ctx, agentID, 0, sent)
+	usecase.AcceptAgentHeartbeat(ctx, agentID, 0, nil) // A heartbeat without snapshot keeps it
+
+	// Readers see the buffered heartbeat before it is written
+	stale := time.Now().Add(-time.Hour)
+	mockRepo.On("GetAll", mock.Anything).Return([]domain.Agent{{ID: agentID, LastSeen: stale}}, nil).Once()
+	agents, err := usecase.GetAllAgents(ctx)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), agents[0].LastSeen, time.Minute)
+	assert.Equal(t, 97, agents[0].Heartbeat.GPUUtilization)
+
+	// One batch holds every agent; nothing is written twice
+	mockRepo.On("UpdateLastSeenBatch", mock.Anything, mock.MatchedBy(func(seen map[uuid.UUID]domain.AgentSeen) bool {
+		heartbeat := seen[agentID].Heartbeat
+		return len(seen) == 2 && seen[quietID].Heartbeat == nil &&
+			heartbeat != nil && *heartbeat.CurrentJobID == jobID && time.Since(heartbeat.ReportedAt) < time.Minute
+	})).Return(nil).Once()
+	assert.NoError(t, usecase.FlushAgentHeartbeats(ctx))
+	assert.NoError(t, usecase.FlushAgentHeartbeats(ctx))
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "UpdateLastSeenBatch", 1)
+}
+
+func TestAgentUsecase_FlushAgentHeartbeatsRetries(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	ctx := context.Background()
+
+	usecase.AcceptAgentHeartbeat(ctx, agentID, 0, nil)
+
+	// A failed batch is kept for the next flush
+	mockRepo.On("UpdateLastSeenBatch", mock.Anything, mock.Anything).Return(errors.New("database is locked")).Once()
+	mockRepo.On("UpdateLastSeenBatch", mock.Anything, mock.MatchedBy(func(seen map[uuid.UUID]domain.AgentSeen) bool {
+		_, ok := seen[agentID]
+		return ok
+	})).Return(nil).Once()
+
+	assert.Error(t, usecase.FlushAgentHeartbeats(ctx))
+	assert.NoError(t, usecase.FlushAgentHeartbeats(ctx))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAgentUsecase_AcceptAgentHeartbeatBroadcasts(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	hub := &recordingHub{}
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	usecase.SetWebSocketHub(hub)
+	ctx := context.Background()
+
+	// The dashboard hears of the heartbeat right away, not at the next flush
+	mockRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Status: "busy"}, nil)
+	usecase.AcceptAgentHeartbeat(ctx, agentID, 0, nil)
+
+	assert.Equal(t, []string{agentID.String() + " busy"}, hub.statuses)
+	mockRepo.AssertNotCalled(t, "UpdateLastSeenBatch", mock.Anything, mock.Anything)
+}
+
+// recordingHub records agent status broadcasts
+type recordingHub struct {
+	statuses []string
+}
+
+func (h *recordingHub) BroadcastAgentStatus(agentID string, status string, lastSeen string) {
+	h.statuses = append(h.statuses, agentID+" "+status)
+}
+
+func (h *recordingHub) BroadcastAgentSpeed(agentID string, speed int64) {}
+
+func (h *recordingHub) BroadcastAgentLogs(agentID string, lines []domain.AgentLogLine) {}
+
+func TestAgentUsecase_AgentLogs(t *testing.T) {
+	agentID := uuid.New()
+	unknownID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	usecase.SetAgentLogRetention(100)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Name: "gpu-1"}, nil)
+	mockRepo.On("GetByID", mock.Anything, unknownID).Return(nil, domain.ErrAgentNotFound)
+
+	// Lines are stamped with the agent, defaulted and cut to size
+	long := strings.Repeat("x", 5000)
+	mockRepo.On("AppendLogs", mock.Anything, agentID, mock.MatchedBy(func(lines []domain.AgentLogLine) bool {
+		return len(lines) == 2 &&
+			lines[0].AgentID == agentID && lines[0].Source == domain.AgentLogSourceAgent && !lines[0].Time.IsZero() &&
+			lines[1].Source == domain.AgentLogSourceHashcat && len(lines[1].Message) == 4096
+	}), 100).Return(nil).Once()
+	assert.NoError(t, usecase.AppendAgentLogs(ctx, agentID, []domain.AgentLogLine{
+		{Message: "Starting job"},
+		{Source: domain.AgentLogSourceHashcat, Message: long, Time: time.Now()},
+	}))
+
+	err := usecase.AppendAgentLogs(ctx, agentID, []domain.AgentLogLine{{Source: "kernel", Message: "oops"}})
+	assert.True(t, domain.IsValidationError(err))
+	err = usecase.AppendAgentLogs(ctx, agentID, make([]domain.AgentLogLine, 1001))
+	assert.True(t, domain.IsValidationError(err))
+	assert.True(t, domain.IsNotFoundError(usecase.AppendAgentLogs(ctx, unknownID, nil)))
+
+	// Reads default and cap the number of lines
+	mockRepo.On("GetLogs", mock.Anything, agentID, int64(0), 200).Return([]domain.AgentLogLine{{ID: 1}}, nil).Once()
+	mockRepo.On("GetLogs", mock.Anything, agentID, int64(7), 1000).Return([]domain.AgentLogLine{}, nil).Once()
+	lines, err := usecase.GetAgentLogs(ctx, agentID, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+	_, err = usecase.GetAgentLogs(ctx, agentID, 7, 50000)
+	assert.NoError(t, err)
+	_, err = usecase.GetAgentLogs(ctx, unknownID, 0, 0)
+	assert.True(t, domain.IsNotFoundError(err))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAgentUsecase_HashcatArgs(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetHashcatArgs", mock.Anything, agentID).Return(nil, nil).Once()
+	args, err := usecase.GetAgentHashcatArgs(ctx, agentID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, args)
+
+	mockRepo.On("UpdateHashcatArgs", mock.Anything, agentID, []string{"-O", "-d", "1,2"}).Return(nil).Once()
+	assert.NoError(t, usecase.SetAgentHashcatArgs(ctx, agentID, []string{"-O", "-d", "1,2"}))
+
+	// Only tuning options get through
+	for _, bad := range [][]string{
+		{"--potfile-path", "/tmp/pot"},
+		{"-m", "0"},
+		{"-n"},
+		{"-O=1"},
+		{"--kernel-accel=abc"},
+	} {
+		err := usecase.SetAgentHashcatArgs(ctx, agentID, bad)
+		assert.True(t, domain.IsValidationError(err), "%v", bad)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAgentUsecase_Settings(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	usecase := usecase.NewAgentUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("UpdateSettings", mock.Anything, agentID, &domain.AgentSettings{WorkloadProfile: 3, UploadDir: "/data"}).Return(nil).Once()
+	assert.NoError(t, usecase.SetAgentSettings(ctx, agentID, &domain.AgentSettings{WorkloadProfile: 3, UploadDir: "  /data "}))
+
+	for _, bad := range []domain.AgentSettings{
+		{PollIntervalSeconds: -1},
+		{WorkloadProfile: 5},
+		{TempAbort: 200},
+		{MaxConcurrentJobs: -2},
+		{DiskQuotaMB: -1},
+	} {
+		err := usecase.SetAgentSettings(ctx, agentID, &bad)
+		assert.True(t, domain.IsValidationError(err), "%+v", bad)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAgentUsecase_AgentGroups(t *testing.T) {
+	groupID := uuid.New()
+	gpuID := uuid.New()
+	cpuID := uuid.New()
+
+	t.Run("creates a group with its first members", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetAllGroups", mock.Anything).Return([]domain.AgentGroup{}, nil)
+		agentRepo.On("GetByID", mock.Anything, gpuID).Return(&domain.Agent{ID: gpuID}, nil)
+		var created *domain.AgentGroup
+		agentRepo.On("CreateGroup", mock.Anything, mock.AnythingOfType("*domain.AgentGroup")).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(*domain.AgentGroup)
+				created.ID = groupID
+			}).
+			Return(nil)
+		agentRepo.On("AddGroupMember", mock.Anything, groupID, gpuID).Return(nil)
+		agentRepo.On("GetGroupByID", mock.Anything, groupID).Return(&domain.AgentGroup{ID: groupID, Name: "cloud-burst"}, nil)
+		agentRepo.On("GetByGroupID", mock.Anything, groupID).Return([]domain.Agent{{ID: gpuID, Status: "online", Speed: 3000}}, nil)
+
+		usecase := usecase.NewAgentUsecase(agentRepo)
+		group, err := usecase.CreateAgentGroup(context.Background(), &domain.CreateAgentGroupRequest{
+			Name:     " cloud-burst ",
+			AgentIDs: []string{gpuID.String()},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "cloud-burst", created.Name)
+		assert.Equal(t, 1, group.OnlineAgents)
+		assert.Equal(t, []uuid.UUID{gpuID}, group.AgentIDs)
+		agentRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a duplicate name", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetAllGroups", mock.Anything).Return([]domain.AgentGroup{{ID: groupID, Name: "Cloud-Burst"}}, nil)
+
+		usecase := usecase.NewAgentUsecase(agentRepo)
+		_, err := usecase.CreateAgentGroup(context.Background(), &domain.CreateAgentGroupRequest{Name: "cloud-burst"})
+
+		assert.True(t, domain.IsValidationError(err))
+		agentRepo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("summarizes agents by status", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetAllGroups", mock.Anything).Return([]domain.AgentGroup{{ID: groupID, Name: "red-team-lab"}}, nil)
+		agentRepo.On("GetByGroupID", mock.Anything, groupID).Return([]domain.Agent{
+			{ID: gpuID, Status: "busy", Speed: 3000},
+			{ID: cpuID, Status: "online", Speed: 1000},
+			{ID: uuid.New(), Status: "offline", Speed: 500},
+		}, nil)
+
+		usecase := usecase.NewAgentUsecase(agentRepo)
+		groups, err := usecase.GetAllAgentGroups(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, groups, 1)
+		assert.Equal(t, 3, groups[0].TotalAgents)
+		assert.Equal(t, 1, groups[0].BusyAgents)
+		assert.Equal(t, 1, groups[0].OnlineAgents)
+		assert.Equal(t, 1, groups[0].OfflineAgents)
+		assert.Equal(t, int64(4000), groups[0].Speed)
+	})
+
+	t.Run("adding an unknown agent", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetGroupByID", mock.Anything, groupID).Return(&domain.AgentGroup{ID: groupID}, nil)
+		agentRepo.On("GetByID", mock.Anything, cpuID).Return(nil, domain.ErrAgentNotFound)
+
+		usecase := usecase.NewAgentUsecase(agentRepo)
+		err := usecase.AddAgentToGroup(context.Background(), groupID, cpuID)
+
+		assert.True(t, domain.IsNotFoundError(err))
+		agentRepo.AssertNotCalled(t, "AddGroupMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,110 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEffectivenessRepository struct {
+	mock.Mock
+}
+
+func (m *MockEffectivenessRepository) Record(ctx context.Context, record *domain.JobEffectiveness) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockEffectivenessRepository) Aggregate(ctx context.Context, filter domain.EffectivenessFilter) ([]domain.EffectivenessTotal, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.EffectivenessTotal), args.Error(1)
+}
+
+func TestAnalyticsUsecase_RecordJobEffectiveness(t *testing.T) {
+	wordlistID := uuid.New()
+	completed := time.Now().Add(-time.Minute)
+	cracked := &domain.Job{
+		ID: uuid.New(), Status: domain.JobStatusCracked, HashType: 1000, WordlistID: &wordlistID,
+		Wordlist: wordlistID.String(), Rules: "best64", TotalWords: 1000, ProcessedWords: 400,
+		Stats:         &domain.JobRuntimeStats{ProgressDone: 30800, ProgressTotal: 77000},
+		DeviceSeconds: 600, CompletedAt: &completed,
+	}
+	exhausted := &domain.Job{
+		ID: uuid.New(), Status: domain.JobStatusFailed, AttackMode: domain.AttackModeBruteForce,
+		Wordlist: "?d?d?d?d", TotalWords: 10000, ProcessedWords: 9000, CompletedAt: &completed,
+	}
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetCracks", mock.Anything, cracked.ID).Return([]domain.JobCrack{{Hash: "a"}, {Hash: "b"}}, nil)
+	jobRepo.On("GetCracks", mock.Anything, exhausted.ID).Return([]domain.JobCrack{}, nil)
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, OrigName: "rockyou.txt"}, nil)
+
+	var recorded []*domain.JobEffectiveness
+	effectivenessRepo := new(MockEffectivenessRepository)
+	effectivenessRepo.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(1).(*domain.JobEffectiveness))
+	}).Return(nil)
+
+	uc := usecase.NewAnalyticsUsecase(effectivenessRepo, jobRepo, wordlistRepo)
+	require.NoError(t, uc.RecordJobEffectiveness(context.Background(), cracked))
+	require.NoError(t, uc.RecordJobEffectiveness(context.Background(), exhausted))
+	require.Len(t, recorded, 2)
+
+	assert.Equal(t, "rockyou.txt", recorded[0].Wordlist)
+	assert.Equal(t, "best64", recorded[0].Rules)
+	assert.Equal(t, int64(30800), recorded[0].Keyspace, "hashcat's count of the candidates tried")
+	assert.Equal(t, 2, recorded[0].Cracks)
+	assert.True(t, recorded[0].CompletedAt.Equal(completed))
+
+	assert.Equal(t, "?d?d?d?d", recorded[1].Mask)
+	assert.Empty(t, recorded[1].Wordlist)
+	assert.Equal(t, int64(10000), recorded[1].Keyspace, "an exhausted keyspace was searched in full")
+	assert.Zero(t, recorded[1].Cracks)
+}
+
+func TestAnalyticsUsecase_GetEffectiveness(t *testing.T) {
+	effectivenessRepo := new(MockEffectivenessRepository)
+	effectivenessRepo.On("Aggregate", mock.Anything, mock.Anything).Return([]domain.EffectivenessTotal{
+		{Key: "a", Name: "huge.txt", Jobs: 4, Cracks: 0, Keyspace: 40_000_000_000, DeviceSeconds: 36000},
+		{Key: "b", Name: "rockyou.txt", Jobs: 5, JobsWithCracks: 3, Cracks: 30, Keyspace: 70_000_000, DeviceSeconds: 3600},
+		{Key: "c", Name: "corporate.txt", Jobs: 1, JobsWithCracks: 1, Cracks: 2, Keyspace: 1_000_000, DeviceSeconds: 60},
+		{Key: "d", Name: "new.txt", Jobs: 1},
+	}, nil)
+	uc := usecase.NewAnalyticsUsecase(effectivenessRepo, new(MockJobRepository), new(MockWordlistRepository))
+
+	report, err := uc.GetEffectiveness(context.Background(), domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupWordlist})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 4)
+	assert.Equal(t, "corporate.txt", report.Items[0].Name)
+	assert.InDelta(t, 2000, report.Items[0].CracksPerBillion, 0.001)
+	assert.Equal(t, "rockyou.txt", report.Items[1].Name)
+	assert.InDelta(t, 30, report.Items[1].CracksPerDeviceHour, 0.001)
+	assert.Equal(t, "huge.txt", report.Items[2].Name)
+	assert.True(t, report.Items[2].Ineffective)
+	assert.InDelta(t, 10, report.Items[2].DeviceHours, 0.001)
+	assert.False(t, report.Items[3].Ineffective, "one job is too little to judge")
+
+	t.Run("validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		_, err := uc.GetEffectiveness(context.Background(), domain.EffectivenessFilter{GroupBy: "agent"})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "group_by", validationErr.Field)
+
+		from, to := time.Now(), time.Now().Add(-time.Hour)
+		_, err = uc.GetEffectiveness(context.Background(), domain.EffectivenessFilter{GroupBy: domain.EffectivenessGroupMask, From: &from, To: &to})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "to", validationErr.Field)
+	})
+}
@@ -0,0 +1,214 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySessionRepository keeps sessions in memory
+type memorySessionRepository struct {
+	sessions map[uuid.UUID]domain.Session
+}
+
+func newMemorySessionRepository() *memorySessionRepository {
+	return &memorySessionRepository{sessions: map[uuid.UUID]domain.Session{}}
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "session"}
+	}
+	return &session, nil
+}
+
+func (r *memorySessionRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	sessions := []domain.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.Active(time.Now()) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	session, ok := r.sessions[id]
+	if !ok || session.TokenHash != oldHash || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.TokenHash, session.ExpiresAt, session.LastUsedAt = newHash, expiresAt, time.Now()
+	r.sessions[id] = session
+	return true, nil
+}
+
+func (r *memorySessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	session, ok := r.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return &domain.NotFoundError{Entity: "session"}
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *memorySessionRepository) RevokeByUser(ctx context.Context, userID uuid.UUID, keep *uuid.UUID) (int, error) {
+	revoked := 0
+	for id, session := range r.sessions {
+		if session.UserID != userID || session.RevokedAt != nil || (keep != nil && id == *keep) {
+			continue
+		}
+		now := time.Now()
+		session.RevokedAt = &now
+		r.sessions[id] = session
+		revoked++
+	}
+	return revoked, nil
+}
+
+func newSessionUsecase(t *testing.T) (domain.AuthUsecase, *domain.User, *memorySessionRepository) {
+	user := newTwoFactorUser(t, "user")
+	users := new(MockUserRepository)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("GetByUsername", mock.Anything, "alice").Return(user, nil)
+	users.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+	sessions := newMemorySessionRepository()
+	uc := usecase.NewAuthUsecase(users, newMemoryTwoFactorRepository(), &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{}}, sessions, infrastructure.NewJWTService())
+	return uc, user, sessions
+}
+
+func TestAuthUsecase_Sessions(t *testing.T) {
+	ctx := context.Background()
+	login := &domain.LoginRequest{Username: "alice", Password: "correct horse"}
+	var authErr *domain.AuthenticationError
+
+	t.Run("login records the client", func(t *testing.T) {
+		uc, user, _ := newSessionUsecase(t)
+		client := domain.ClientInfo{UserAgent: "curl/8.0", IPAddress: "10.0.0.7"}
+		response, err := uc.Login(domain.WithClientInfo(ctx, client), login)
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.RefreshToken)
+
+		claims, err := uc.ValidateToken(ctx, response.Token)
+		require.NoError(t, err)
+		sessions, err := uc.GetSessions(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, claims.SessionID, sessions[0].ID.String())
+		assert.Equal(t, "curl/8.0", sessions[0].UserAgent)
+		assert.Equal(t, "10.0.0.7", sessions[0].IPAddress)
+	})
+
+	t.Run("refresh rotates the refresh token", func(t *testing.T) {
+		uc, _, _ := newSessionUsecase(t)
+		response, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+
+		refreshed, err := uc.RefreshToken(ctx, response.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, response.RefreshToken, refreshed.RefreshToken)
+		_, err = uc.ValidateToken(ctx, refreshed.Token)
+		assert.NoError(t, err)
+
+		_, err = uc.RefreshToken(ctx, response.Token)
+		assert.ErrorAs(t, err, &authErr, "access tokens don't refresh")
+	})
+
+	t.Run("a reused refresh token revokes the session", func(t *testing.T) {
+		uc, _, _ := newSessionUsecase(t)
+		response, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+		refreshed, err := uc.RefreshToken(ctx, response.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = uc.RefreshToken(ctx, response.RefreshToken)
+		assert.ErrorContains(t, err, "already used")
+		_, err = uc.RefreshToken(ctx, refreshed.RefreshToken)
+		assert.ErrorAs(t, err, &authErr)
+		_, err = uc.ValidateToken(ctx, refreshed.Token)
+		assert.ErrorAs(t, err, &authErr)
+	})
+
+	t.Run("a tampered refresh token is refused", func(t *testing.T) {
+		uc, _, _ := newSessionUsecase(t)
+		response, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+		id, _, _ := strings.Cut(response.RefreshToken, ".")
+
+		_, err = uc.RefreshToken(ctx, id+".00")
+		assert.ErrorAs(t, err, &authErr)
+		_, err = uc.RefreshToken(ctx, "not-a-token")
+		assert.ErrorAs(t, err, &authErr)
+	})
+
+	t.Run("revoked sessions lock out their access tokens", func(t *testing.T) {
+		uc, user, _ := newSessionUsecase(t)
+		first, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+		second, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+		claims, err := uc.ValidateToken(ctx, second.Token)
+		require.NoError(t, err)
+		current := uuid.MustParse(claims.SessionID)
+
+		revoked, err := uc.RevokeSessions(ctx, user.ID, &current)
+		require.NoError(t, err)
+		assert.Equal(t, 1, revoked)
+		_, err = uc.ValidateToken(ctx, first.Token)
+		assert.ErrorAs(t, err, &authErr)
+		_, err = uc.ValidateToken(ctx, second.Token)
+		assert.NoError(t, err)
+
+		require.NoError(t, uc.RevokeSession(ctx, user.ID, current))
+		_, err = uc.ValidateToken(ctx, second.Token)
+		assert.ErrorAs(t, err, &authErr)
+		assert.True(t, domain.IsNotFoundError(uc.RevokeSession(ctx, user.ID, current)))
+	})
+
+	t.Run("sessions of other users are not found", func(t *testing.T) {
+		uc, user, sessions := newSessionUsecase(t)
+		other := domain.Session{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+		require.NoError(t, sessions.Create(ctx, &other))
+
+		assert.True(t, domain.IsNotFoundError(uc.RevokeSession(ctx, user.ID, other.ID)))
+		assert.Nil(t, sessions.sessions[other.ID].RevokedAt)
+	})
+
+	t.Run("logout revokes the session", func(t *testing.T) {
+		uc, _, _ := newSessionUsecase(t)
+		response, err := uc.Login(ctx, login)
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Logout(ctx, response.RefreshToken))
+		_, err = uc.ValidateToken(ctx, response.Token)
+		assert.ErrorAs(t, err, &authErr)
+		_, err = uc.RefreshToken(ctx, response.RefreshToken)
+		assert.ErrorAs(t, err, &authErr)
+	})
+
+	t.Run("tokens without a session are refused", func(t *testing.T) {
+		uc, user, _ := newSessionUsecase(t)
+		token, _, err := infrastructure.NewJWTService().GenerateToken(user)
+		require.NoError(t, err)
+
+		_, err = uc.ValidateToken(ctx, token)
+		assert.ErrorAs(t, err, &authErr)
+	})
+}
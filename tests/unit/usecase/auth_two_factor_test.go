@@ -0,0 +1,278 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryTwoFactorRepository keeps second factors, recovery codes and the
+// policy in memory
+type memoryTwoFactorRepository struct {
+	factors  map[uuid.UUID]domain.TwoFactor
+	recovery map[uuid.UUID]map[string]bool
+	policy   domain.TwoFactorPolicy
+}
+
+func newMemoryTwoFactorRepository() *memoryTwoFactorRepository {
+	return &memoryTwoFactorRepository{factors: map[uuid.UUID]domain.TwoFactor{}, recovery: map[uuid.UUID]map[string]bool{}}
+}
+
+func (r *memoryTwoFactorRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.TwoFactor, error) {
+	twoFactor, ok := r.factors[userID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "two-factor authentication"}
+	}
+	return &twoFactor, nil
+}
+
+func (r *memoryTwoFactorRepository) Save(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	r.factors[twoFactor.UserID] = *twoFactor
+	return nil
+}
+
+func (r *memoryTwoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	delete(r.factors, userID)
+	delete(r.recovery, userID)
+	return nil
+}
+
+func (r *memoryTwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	twoFactor := r.factors[userID]
+	if twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	r.factors[userID] = twoFactor
+	return true, nil
+}
+
+func (r *memoryTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	r.recovery[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (r *memoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	if !r.recovery[userID][codeHash] {
+		return false, nil
+	}
+	delete(r.recovery[userID], codeHash)
+	return true, nil
+}
+
+func (r *memoryTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	return len(r.recovery[userID]), nil
+}
+
+func (r *memoryTwoFactorRepository) GetPolicy(ctx context.Context) (*domain.TwoFactorPolicy, error) {
+	policy := r.policy
+	return &policy, nil
+}
+
+func (r *memoryTwoFactorRepository) SavePolicy(ctx context.Context, policy *domain.TwoFactorPolicy) error {
+	r.policy = *policy
+	return nil
+}
+
+func newTwoFactorUser(t *testing.T, role string) *domain.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+	return &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Password: string(hash), Role: role, IsActive: true}
+}
+
+func currentTOTP(t *testing.T, secret string, offset int64) string {
+	code, err := infrastructure.TOTPCode(secret, infrastructure.TOTPStep(time.Now())+offset)
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthUsecase_TwoFactorLogin(t *testing.T) {
+	ctx := context.Background()
+	user := newTwoFactorUser(t, "user")
+	users := new(MockUserRepository)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("GetByUsername", mock.Anything, "alice").Return(user, nil)
+	users.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+	twoFactorRepo := newMemoryTwoFactorRepository()
+	jwtService := infrastructure.NewJWTService()
+	uc := usecase.NewAuthUsecase(users, twoFactorRepo, &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{}}, newMemorySessionRepository(), jwtService)
+
+	enrollment, err := uc.EnrollTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+	assert.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+
+	// Until a code confirms the enrollment, logins don't need one
+	response, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+	_, err = uc.EnableTwoFactor(ctx, user.ID, "000000")
+	assert.True(t, domain.IsValidationError(err))
+
+	activation, err := uc.EnableTwoFactor(ctx, user.ID, currentTOTP(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	require.Len(t, activation.RecoveryCodes, 10)
+	claims, err := jwtService.ValidateToken(activation.Token)
+	require.NoError(t, err)
+	assert.True(t, claims.TwoFactor)
+
+	t.Run("sessions from before are revoked", func(t *testing.T) {
+		var authErr *domain.AuthenticationError
+		_, err := uc.ValidateToken(ctx, response.Token)
+		assert.ErrorAs(t, err, &authErr)
+		_, err = uc.RefreshToken(ctx, response.RefreshToken)
+		assert.ErrorAs(t, err, &authErr)
+
+		_, err = uc.ValidateToken(ctx, activation.Token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("password alone", func(t *testing.T) {
+		_, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse"})
+		var required *domain.TwoFactorRequiredError
+		assert.ErrorAs(t, err, &required)
+	})
+
+	t.Run("TOTP code", func(t *testing.T) {
+		_, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse", TOTPCode: currentTOTP(t, enrollment.Secret, 0)})
+		assert.ErrorContains(t, err, "already used", "the code confirmed the enrollment")
+
+		response, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse", TOTPCode: currentTOTP(t, enrollment.Secret, 1)})
+		require.NoError(t, err)
+		claims, err := jwtService.ValidateToken(response.Token)
+		require.NoError(t, err)
+		assert.True(t, claims.TwoFactor)
+
+		_, err = uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "wrong", TOTPCode: currentTOTP(t, enrollment.Secret, 1)})
+		var invalid *domain.InvalidCredentialsError
+		assert.ErrorAs(t, err, &invalid)
+	})
+
+	t.Run("recovery codes work once", func(t *testing.T) {
+		request := &domain.LoginRequest{Username: "alice", Password: "correct horse", RecoveryCode: activation.RecoveryCodes[0]}
+		_, err := uc.Login(ctx, request)
+		require.NoError(t, err)
+		_, err = uc.Login(ctx, request)
+		assert.ErrorContains(t, err, "invalid recovery code")
+
+		status, err := uc.GetTwoFactorStatus(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.TwoFactorStatus{Enabled: true, RecoveryCodesLeft: 9}, status)
+	})
+
+	t.Run("disable with a recovery code", func(t *testing.T) {
+		require.NoError(t, uc.DisableTwoFactor(ctx, user.ID, activation.RecoveryCodes[1]))
+		_, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestAuthUsecase_TwoFactorPolicy(t *testing.T) {
+	ctx := context.Background()
+	admin := newTwoFactorUser(t, "admin")
+	granted, plain := newTwoFactorUser(t, "user"), newTwoFactorUser(t, "user")
+	grants := &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{granted.ID: {UserID: granted.ID}}}
+	users := new(MockUserRepository)
+	users.On("GetByUsername", mock.Anything, "alice").Return(admin, nil)
+	users.On("GetByID", mock.Anything, admin.ID).Return(admin, nil)
+	users.On("UpdateLastLogin", mock.Anything, admin.ID).Return(nil)
+	twoFactorRepo := newMemoryTwoFactorRepository()
+	uc := usecase.NewAuthUsecase(users, twoFactorRepo, grants, newMemorySessionRepository(), infrastructure.NewJWTService())
+
+	claimsOf := func(user *domain.User) *domain.JWTClaims {
+		return &domain.JWTClaims{UserID: user.ID.String(), Role: user.Role}
+	}
+
+	pending, err := uc.TwoFactorPending(ctx, claimsOf(admin))
+	require.NoError(t, err)
+	assert.False(t, pending, "the policy is off by default")
+
+	_, err = uc.UpdateTwoFactorPolicy(ctx, &domain.TwoFactorPolicy{RequireForPrivileged: true})
+	require.NoError(t, err)
+
+	for user, want := range map[*domain.User]bool{admin: true, granted: true, plain: false} {
+		pending, err := uc.TwoFactorPending(ctx, claimsOf(user))
+		require.NoError(t, err)
+		assert.Equal(t, want, pending, user.Role)
+	}
+	verified := claimsOf(admin)
+	verified.TwoFactor = true
+	pending, err = uc.TwoFactorPending(ctx, verified)
+	require.NoError(t, err)
+	assert.False(t, pending)
+
+	response, err := uc.Login(ctx, &domain.LoginRequest{Username: "alice", Password: "correct horse"})
+	require.NoError(t, err)
+	assert.True(t, response.TwoFactorSetupRequired)
+
+	enrollment, err := uc.EnrollTwoFactor(ctx, admin.ID)
+	require.NoError(t, err)
+	_, err = uc.EnableTwoFactor(ctx, admin.ID, currentTOTP(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	err = uc.DisableTwoFactor(ctx, admin.ID, currentTOTP(t, enrollment.Secret, 1))
+	assert.True(t, domain.IsValidationError(err), "the policy requires it")
+
+	require.NoError(t, uc.ResetTwoFactor(ctx, admin.ID))
+	status, err := uc.GetTwoFactorStatus(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.TwoFactorStatus{Required: true}, status)
+}
+
+func TestAuthUsecase_VerifyTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	admin := newTwoFactorUser(t, "admin")
+	users := new(MockUserRepository)
+	users.On("GetByID", mock.Anything, admin.ID).Return(admin, nil)
+	twoFactorRepo := newMemoryTwoFactorRepository()
+	jwtService := infrastructure.NewJWTService()
+	uc := usecase.NewAuthUsecase(users, twoFactorRepo, &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{}}, newMemorySessionRepository(), jwtService)
+	_, err := uc.UpdateTwoFactorPolicy(ctx, &domain.TwoFactorPolicy{RequireForPrivileged: true})
+	require.NoError(t, err)
+
+	enrollment, err := uc.EnrollTwoFactor(ctx, admin.ID)
+	require.NoError(t, err)
+	_, err = uc.EnableTwoFactor(ctx, admin.ID, currentTOTP(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+
+	// Single sign-on logins start without the second factor
+	sso, err := uc.StartSession(ctx, admin, false)
+	require.NoError(t, err)
+	assert.True(t, sso.TwoFactorRequired)
+	assert.False(t, sso.TwoFactorSetupRequired, "the admin has a second factor")
+	claims, err := jwtService.ValidateToken(sso.Token)
+	require.NoError(t, err)
+	pending, err := uc.TwoFactorPending(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, pending)
+	session, err := uuid.Parse(claims.SessionID)
+	require.NoError(t, err)
+
+	_, err = uc.VerifyTwoFactor(ctx, admin.ID, &session, "000000")
+	var authErr *domain.AuthenticationError
+	assert.ErrorAs(t, err, &authErr)
+
+	verified, err := uc.VerifyTwoFactor(ctx, admin.ID, &session, currentTOTP(t, enrollment.Secret, 1))
+	require.NoError(t, err)
+	assert.False(t, verified.TwoFactorRequired)
+	claims, err = jwtService.ValidateToken(verified.Token)
+	require.NoError(t, err)
+	assert.True(t, claims.TwoFactor)
+	pending, err = uc.TwoFactorPending(ctx, claims)
+	require.NoError(t, err)
+	assert.False(t, pending)
+
+	_, err = uc.ValidateToken(ctx, sso.Token)
+	assert.ErrorAs(t, err, &authErr, "the session without two-factor is replaced")
+}
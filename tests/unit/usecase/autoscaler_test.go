@@ -0,0 +1,298 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCloudInstanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockCloudInstanceRepository) Create(ctx context.Context, instance *domain.CloudInstance) error {
+	args := m.Called(ctx, instance)
+	return args.Error(0)
+}
+
+func (m *MockCloudInstanceRepository) Update(ctx context.Context, instance *domain.CloudInstance) error {
+	args := m.Called(ctx, instance)
+	return args.Error(0)
+}
+
+func (m *MockCloudInstanceRepository) GetActive(ctx context.Context) ([]domain.CloudInstance, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.CloudInstance), args.Error(1)
+}
+
+// fakeCloudProvider records the instances it was asked to create and delete
+type fakeCloudProvider struct {
+	created   []domain.CloudInstanceSpec
+	deleted   []string
+	deleteErr error
+}
+
+func (p *fakeCloudProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeCloudProvider) CreateInstance(ctx context.Context, spec domain.CloudInstanceSpec) (string, error) {
+	p.created = append(p.created, spec)
+	return fmt.Sprintf("vm-%d", len(p.created)), nil
+}
+
+func (p *fakeCloudProvider) DeleteInstance(ctx context.Context, providerID string) error {
+	if p.deleteErr != nil {
+		return p.deleteErr
+	}
+	p.deleted = append(p.deleted, providerID)
+	return nil
+}
+
+func unassignedJobs(n int) []domain.Job {
+	jobs := make([]domain.Job, n)
+	for i := range jobs {
+		jobs[i] = domain.Job{ID: uuid.New(), Status: domain.JobStatusPending}
+	}
+	return jobs
+}
+
+func newTestAutoScaler(t *testing.T, provider domain.CloudProvider, instanceRepo domain.CloudInstanceRepository, agentRepo *MockAgentRepository, jobRepo *MockJobRepository, config usecase.AutoScaleConfig) usecase.AutoScaler {
+	config.ServerURL = "http://10.0.0.1:1337"
+	config.AgentDownloadURL = "https://downloads.example.com/agent"
+
+	agentUsecase := usecase.NewAgentUsecase(agentRepo)
+	jobUsecase := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	scaler, err := usecase.NewAutoScaler(provider, instanceRepo, agentUsecase, jobUsecase, config)
+	require.NoError(t, err)
+	return scaler
+}
+
+// expectAgentKeys lets the auto-scaler create agent keys for new instances
+func expectAgentKeys(agentRepo *MockAgentRepository) {
+	agentRepo.On("GetByName", mock.Anything, mock.Anything).Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("GetByAgentKey", mock.Anything, mock.Anything).Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Agent")).Return(nil)
+	agentRepo.On("GetByID", mock.Anything, mock.Anything).Return(&domain.Agent{}, nil)
+}
+
+func TestAutoScaler_RunOnce(t *testing.T) {
+	t.Run("starts instances for the queue above the threshold", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{}, nil)
+		instanceRepo.On("Create", mock.Anything, mock.MatchedBy(func(instance *domain.CloudInstance) bool {
+			return instance.Status == domain.CloudInstanceProvisioning && instance.Provider == "fake" && instance.HourlyCost == 0.5
+		})).Return(nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return(unassignedJobs(3), nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+		expectAgentKeys(agentRepo)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{
+			QueueThreshold:     1,
+			MaxInstances:       5,
+			InstanceHourlyCost: 0.5,
+		})
+		scaler.RunOnce(context.Background())
+
+		require.Len(t, provider.created, 2)
+		instanceRepo.AssertNumberOfCalls(t, "Create", 2)
+		spec := provider.created[0]
+		assert.True(t, strings.HasPrefix(spec.Name, "hashcat-burst-"))
+		assert.Contains(t, spec.UserData, "--server http://10.0.0.1:1337")
+		assert.Contains(t, spec.UserData, "--agent-key "+strings.TrimPrefix(spec.Name, "hashcat-burst-"))
+		assert.Contains(t, spec.UserData, "https://downloads.example.com/agent")
+	})
+
+	t.Run("stays within the hourly cost cap", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		busyAgent := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-1", Status: "busy"}
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{{
+			ID: uuid.New(), Provider: "fake", ProviderID: "vm-0", AgentID: busyAgent.ID,
+			Status: domain.CloudInstanceRunning, HourlyCost: 0.4, CreatedAt: time.Now(),
+		}}, nil)
+		instanceRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, busyAgent.ID).Return(busyAgent, nil)
+		agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{*busyAgent}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return(unassignedJobs(5), nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+		expectAgentKeys(agentRepo)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{
+			MaxInstances:       10,
+			InstanceHourlyCost: 0.4,
+			MaxHourlyCost:      1.0,
+		})
+		scaler.RunOnce(context.Background())
+
+		assert.Len(t, provider.created, 1, "0.4 spent, only one more 0.4 instance fits under 1.0")
+	})
+
+	t.Run("counts booting instances against the queue", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		booting := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-2", Status: "offline"}
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{{
+			ID: uuid.New(), Provider: "fake", ProviderID: "vm-0", AgentID: booting.ID,
+			Status: domain.CloudInstanceProvisioning, CreatedAt: time.Now(),
+		}}, nil)
+		agentRepo.On("GetByID", mock.Anything, booting.ID).Return(booting, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return(unassignedJobs(1), nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{MaxInstances: 5})
+		scaler.RunOnce(context.Background())
+
+		assert.Empty(t, provider.created)
+		assert.Empty(t, provider.deleted)
+	})
+
+	t.Run("deletes instances idle past the timeout", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		idle := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-3", Status: "online"}
+		idleSince := time.Now().Add(-20 * time.Minute)
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{{
+			ID: uuid.New(), Provider: "fake", ProviderID: "vm-7", AgentID: idle.ID,
+			Status: domain.CloudInstanceRunning, IdleSince: &idleSince, CreatedAt: time.Now().Add(-time.Hour),
+		}}, nil)
+		instanceRepo.On("Update", mock.Anything, mock.MatchedBy(func(instance *domain.CloudInstance) bool {
+			return instance.Status == domain.CloudInstanceTerminated && instance.TerminatedAt != nil
+		})).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, idle.ID).Return(idle, nil)
+		agentRepo.On("Delete", mock.Anything, idle.ID).Return(nil)
+		jobRepo.On("GetByAgentID", mock.Anything, idle.ID).Return([]domain.Job{{ID: uuid.New(), Status: domain.JobStatusCompleted}}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{
+			MaxInstances: 5,
+			IdleTimeout:  10 * time.Minute,
+		})
+		scaler.RunOnce(context.Background())
+
+		assert.Equal(t, []string{"vm-7"}, provider.deleted)
+		agentRepo.AssertCalled(t, "Delete", mock.Anything, idle.ID)
+		instanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("keeps instances with work and marks new idle ones", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		working := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-4", Status: "online"}
+		idle := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-5", Status: "online"}
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{
+			{ID: uuid.New(), ProviderID: "vm-1", AgentID: working.ID, Status: domain.CloudInstanceRunning},
+			{ID: uuid.New(), ProviderID: "vm-2", AgentID: idle.ID, Status: domain.CloudInstanceRunning},
+		}, nil)
+		instanceRepo.On("Update", mock.Anything, mock.MatchedBy(func(instance *domain.CloudInstance) bool {
+			return instance.ProviderID == "vm-2" && instance.IdleSince != nil && instance.Status == domain.CloudInstanceRunning
+		})).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, working.ID).Return(working, nil)
+		agentRepo.On("GetByID", mock.Anything, idle.ID).Return(idle, nil)
+		jobRepo.On("GetByAgentID", mock.Anything, working.ID).Return([]domain.Job{{ID: uuid.New(), Status: domain.JobStatusAssigned}}, nil)
+		jobRepo.On("GetByAgentID", mock.Anything, idle.ID).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{MaxInstances: 5})
+		scaler.RunOnce(context.Background())
+
+		assert.Empty(t, provider.deleted)
+		instanceRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("deletes instances whose agent never registered", func(t *testing.T) {
+		provider := &fakeCloudProvider{}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		never := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-6", Status: "offline"}
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{{
+			ID: uuid.New(), ProviderID: "vm-3", AgentID: never.ID,
+			Status: domain.CloudInstanceProvisioning, CreatedAt: time.Now().Add(-30 * time.Minute),
+		}}, nil)
+		instanceRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, never.ID).Return(never, nil)
+		agentRepo.On("Delete", mock.Anything, never.ID).Return(nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{MaxInstances: 5})
+		scaler.RunOnce(context.Background())
+
+		assert.Equal(t, []string{"vm-3"}, provider.deleted)
+	})
+
+	t.Run("keeps tracking instances the provider failed to delete", func(t *testing.T) {
+		provider := &fakeCloudProvider{deleteErr: fmt.Errorf("rate limited")}
+		instanceRepo := new(MockCloudInstanceRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo := new(MockJobRepository)
+
+		never := &domain.Agent{ID: uuid.New(), Name: "hashcat-burst-7", Status: "offline"}
+		instanceRepo.On("GetActive", mock.Anything).Return([]domain.CloudInstance{{
+			ID: uuid.New(), ProviderID: "vm-4", AgentID: never.ID,
+			Status: domain.CloudInstanceProvisioning, CreatedAt: time.Now().Add(-30 * time.Minute),
+		}}, nil)
+		agentRepo.On("GetByID", mock.Anything, never.ID).Return(never, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		scaler := newTestAutoScaler(t, provider, instanceRepo, agentRepo, jobRepo, usecase.AutoScaleConfig{MaxInstances: 5})
+		scaler.RunOnce(context.Background())
+
+		instanceRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		agentRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNewAutoScaler_Validation(t *testing.T) {
+	agentUsecase := usecase.NewAgentUsecase(new(MockAgentRepository))
+	jobUsecase := usecase.NewJobUsecase(new(MockJobRepository), new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	valid := usecase.AutoScaleConfig{
+		MaxInstances:     1,
+		ServerURL:        "http://10.0.0.1:1337",
+		AgentDownloadURL: "https://downloads.example.com/agent",
+	}
+
+	_, err := usecase.NewAutoScaler(&fakeCloudProvider{}, new(MockCloudInstanceRepository), agentUsecase, jobUsecase, valid)
+	assert.NoError(t, err)
+
+	noCap := valid
+	noCap.MaxInstances = 0
+	_, err = usecase.NewAutoScaler(&fakeCloudProvider{}, new(MockCloudInstanceRepository), agentUsecase, jobUsecase, noCap)
+	assert.Error(t, err)
+
+	quoted := valid
+	quoted.ServerURL = "http://x'; rm -rf /"
+	_, err = usecase.NewAutoScaler(&fakeCloudProvider{}, new(MockCloudInstanceRepository), agentUsecase, jobUsecase, quoted)
+	assert.Error(t, err)
+}
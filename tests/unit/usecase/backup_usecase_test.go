@@ -0,0 +1,130 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure/backupstore"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileSnapshotter "copies" a database by writing a fixed content
+type fileSnapshotter struct {
+	content string
+}
+
+func (s *fileSnapshotter) Snapshot(ctx context.Context, path string) error {
+	return os.WriteFile(path, []byte(s.content), 0600)
+}
+
+func verifyContent(ctx context.Context, path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if string(content) == "corrupt" {
+		return 0, fmt.Errorf("database is corrupt")
+	}
+	return 42, nil
+}
+
+func TestBackupUsecase_CreateListRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	uploadDir := filepath.Join(dir, "uploads")
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadDir, "wordlists"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "hashes.txt"), []byte("8846f7eaee8fb117ad06bdd830b7586c\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "wordlists", "rockyou.txt"), []byte("password\n"), 0644))
+
+	store, err := backupstore.NewLocalStore(filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+	dbPath := filepath.Join(dir, "data", "hashcat.db")
+	config := usecase.BackupConfig{DatabaseType: "sqlite", DatabasePath: dbPath, UploadDir: uploadDir, Keep: 1, Verify: verifyContent}
+	snapshotter := &fileSnapshotter{content: "backed up"}
+	backups := usecase.NewBackupUsecase(store, snapshotter, config)
+
+	backup, err := backups.CreateBackup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 42, backup.SchemaVersion)
+	assert.Equal(t, int64(len("backed up")), backup.DatabaseSize)
+	require.Len(t, backup.Uploads, 2)
+	assert.Equal(t, "hashes.txt", backup.Uploads[0].Path)
+	assert.Equal(t, "wordlists/rockyou.txt", backup.Uploads[1].Path)
+
+	listed, err := backups.ListBackups(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, backup.ID, listed[0].ID)
+
+	t.Run("corrupt copies aren't stored", func(t *testing.T) {
+		corrupt := usecase.NewBackupUsecase(store, &fileSnapshotter{content: "corrupt"}, config)
+		_, err := corrupt.CreateBackup(ctx)
+		assert.Error(t, err)
+	})
+
+	// The server's database since then, and an upload that went away
+	require.NoError(t, os.MkdirAll(filepath.Dir(dbPath), 0755))
+	require.NoError(t, os.WriteFile(dbPath, []byte("current"), 0600))
+	require.NoError(t, os.WriteFile(dbPath+"-wal", []byte("current wal"), 0600))
+	require.NoError(t, os.Remove(filepath.Join(uploadDir, "wordlists", "rockyou.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "hashes.txt"), []byte("changed\n"), 0644))
+
+	result, err := usecase.NewBackupUsecase(store, nil, config).RestoreBackup(ctx, backup.ID)
+	require.NoError(t, err)
+	content, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backed up", string(content))
+	assert.NoFileExists(t, dbPath+"-wal", "the old log isn't applied to the backup")
+	previous, err := os.ReadFile(result.PreviousDatabase)
+	require.NoError(t, err)
+	assert.Equal(t, "current", string(previous))
+	assert.FileExists(t, result.PreviousDatabase+"-wal")
+	assert.Equal(t, []string{"wordlists/rockyou.txt"}, result.MissingUploads)
+	assert.Equal(t, []string{"hashes.txt"}, result.ChangedUploads)
+
+	t.Run("unknown backups", func(t *testing.T) {
+		_, err := backups.RestoreBackup(ctx, "20000101T000000Z")
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+
+	t.Run("damaged backups are refused", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "backups", backup.ID, domain.BackupDatabaseFile), []byte("tampered"), 0600))
+		_, err := backups.RestoreBackup(ctx, backup.ID)
+		assert.ErrorContains(t, err, "checksum")
+		content, err := os.ReadFile(dbPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backed up", string(content), "the database is left alone")
+	})
+}
+
+func TestBackupUsecase_KeepsNewest(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := backupstore.NewLocalStore(dir)
+	require.NoError(t, err)
+	backups := usecase.NewBackupUsecase(store, &fileSnapshotter{content: "db"}, usecase.BackupConfig{DatabaseType: "sqlite", Keep: 2, Verify: verifyContent})
+
+	// Backups are named after the second they were taken
+	var ids []string
+	for i := 0; i < 3; i++ {
+		backup, err := backups.CreateBackup(ctx)
+		require.NoError(t, err)
+		ids = append(ids, backup.ID)
+		time.Sleep(time.Until(backup.CreatedAt.Add(time.Second)))
+	}
+
+	listed, err := backups.ListBackups(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, ids[2], listed[0].ID, "newest first")
+	assert.Equal(t, ids[1], listed[1].ID)
+	assert.NoDirExists(t, filepath.Join(dir, ids[0]))
+}
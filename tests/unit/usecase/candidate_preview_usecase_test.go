@@ -0,0 +1,196 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCandidateGenerator is a mock implementation of domain.CandidateGenerator
+type MockCandidateGenerator struct {
+	mock.Mock
+}
+
+func (m *MockCandidateGenerator) Generate(ctx context.Context, spec domain.CandidateSpec, limit int) ([]string, bool, error) {
+	args := m.Called(ctx, spec, limit)
+	candidates, _ := args.Get(0).([]string)
+	return candidates, args.Bool(1), args.Error(2)
+}
+
+func TestCandidatePreviewUsecase_Straight(t *testing.T) {
+	wordlist := &domain.Wordlist{ID: uuid.New(), Path: filepath.Join(t.TempDir(), "wordlists", "w.txt")}
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetByID", mock.Anything, wordlist.ID).Return(wordlist, nil)
+
+	generator := new(MockCandidateGenerator)
+	generator.On("Generate", mock.Anything, domain.CandidateSpec{
+		AttackMode:   domain.AttackModeStraight,
+		WordlistPath: wordlist.Path,
+		Rules:        "best64.rule",
+	}, domain.DefaultCandidatePreviewLimit).Return([]string{"Password", "Letmein"}, true, nil)
+
+	preview, err := usecase.NewCandidatePreviewUsecase(wordlistRepo, new(MockCharsetRepository), generator).PreviewCandidates(context.Background(),
+		&domain.CandidatePreviewRequest{AttackMode: domain.AttackModeStraight, WordlistID: wordlist.ID.String(), Rules: "best64.rule"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Password", "Letmein"}, preview.Candidates)
+	assert.Equal(t, 2, preview.Count)
+	assert.Equal(t, domain.DefaultCandidatePreviewLimit, preview.Limit)
+	assert.True(t, preview.Truncated)
+	generator.AssertExpectations(t)
+}
+
+func TestCandidatePreviewUsecase_CustomCharsets(t *testing.T) {
+	charsetFile := &domain.CharsetFile{ID: uuid.New(), Path: "/uploads/charsets/german.hcchr"}
+	charsetRepo := new(MockCharsetRepository)
+	charsetRepo.On("GetByID", mock.Anything, charsetFile.ID).Return(charsetFile, nil)
+
+	generator := new(MockCandidateGenerator)
+	generator.On("Generate", mock.Anything, domain.CandidateSpec{
+		AttackMode:     domain.AttackModeBruteForce,
+		Mask:           "?1?2?d",
+		CustomCharsets: []string{"?u?l", charsetFile.Path, "", ""},
+	}, 10).Return([]string{"Aä0"}, true, nil)
+
+	preview, err := usecase.NewCandidatePreviewUsecase(new(MockWordlistRepository), charsetRepo, generator).PreviewCandidates(context.Background(),
+		&domain.CandidatePreviewRequest{AttackMode: domain.AttackModeBruteForce, Mask: "?1?2?d", Limit: 10,
+			CustomCharset1: "?u?l", CustomCharset2: charsetFile.ID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Aä0"}, preview.Candidates)
+	generator.AssertExpectations(t)
+}
+
+func TestCandidatePreviewUsecase_Validation(t *testing.T) {
+	wordlistID := uuid.New()
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, Path: "/tmp/w.txt"}, nil)
+	wordlistRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, errors.New("wordlist not found"))
+
+	tests := []struct {
+		name     string
+		req      domain.CandidatePreviewRequest
+		notFound bool
+	}{
+		{name: "unsupported attack mode", req: domain.CandidatePreviewRequest{AttackMode: 6, Mask: "?d"}},
+		{name: "mask with a wordlist", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeBruteForce, Mask: "?d", WordlistID: wordlistID.String()}},
+		{name: "mask that looks like a flag", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeBruteForce, Mask: "--help"}},
+		{name: "wordlist path", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeStraight, WordlistID: "/etc/passwd"}},
+		{name: "rule path", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeStraight, WordlistID: wordlistID.String(), Rules: "../best64.rule"}},
+		{name: "mask using an undefined charset", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeBruteForce, Mask: "?1?d"}},
+		{name: "limit too large", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeBruteForce, Mask: "?d", Limit: domain.MaxCandidatePreviewLimit + 1}},
+		{name: "unknown wordlist", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeStraight, WordlistID: uuid.NewString()}, notFound: true},
+		{name: "rule file that isn't built in", req: domain.CandidatePreviewRequest{AttackMode: domain.AttackModeStraight, WordlistID: wordlistID.String(), Rules: uuid.NewString()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := new(MockCandidateGenerator)
+			_, err := usecase.NewCandidatePreviewUsecase(wordlistRepo, new(MockCharsetRepository), generator).PreviewCandidates(context.Background(), &tt.req)
+			if tt.notFound {
+				assert.True(t, domain.IsNotFoundError(err), "got %v", err)
+			} else {
+				assert.True(t, domain.IsValidationError(err), "got %v", err)
+			}
+			generator.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// fakeHashcat writes a shell script standing in for hashcat --stdout
+func fakeHashcat(t *testing.T, script string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake hashcat is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "hashcat")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestHashcatStdoutGenerator(t *testing.T) {
+	spec := domain.CandidateSpec{AttackMode: domain.AttackModeBruteForce, Mask: "?d?d"}
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		// Endless output, as with a large keyspace
+		binary := fakeHashcat(t, `i=0; while true; do echo "cand$i"; i=$((i+1)); done`)
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 5*time.Second, 1)
+
+		candidates, truncated, err := generator.Generate(context.Background(), spec, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cand0", "cand1", "cand2"}, candidates)
+		assert.True(t, truncated)
+	})
+
+	t.Run("whole keyspace", func(t *testing.T) {
+		binary := fakeHashcat(t, `for arg in "$@"; do echo "$arg"; done; pwd; echo "$HOME"`)
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 5*time.Second, 1)
+
+		candidates, truncated, err := generator.Generate(context.Background(), spec, 100)
+		require.NoError(t, err)
+		assert.False(t, truncated)
+		require.Len(t, candidates, 7)
+		assert.Equal(t, []string{"--stdout", "--quiet", "-a", "3", "?d?d"}, candidates[:5])
+		// Runs in its own scratch directory, which is also its home
+		assert.Equal(t, candidates[5], candidates[6])
+		assert.NoDirExists(t, candidates[5], "scratch directory is removed")
+	})
+
+	t.Run("custom charsets", func(t *testing.T) {
+		binary := fakeHashcat(t, `for arg in "$@"; do echo "$arg"; done`)
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 5*time.Second, 1)
+
+		candidates, _, err := generator.Generate(context.Background(), domain.CandidateSpec{
+			AttackMode:     domain.AttackModeBruteForce,
+			Mask:           "?1?3",
+			CustomCharsets: []string{"?l?d", "", "/uploads/charsets/c.hcchr", ""},
+		}, 100)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--stdout", "--quiet", "-a", "3", "-1", "?l?d", "-3", "/uploads/charsets/c.hcchr", "?1?3"}, candidates)
+	})
+
+	t.Run("built-in rule file", func(t *testing.T) {
+		binary := fakeHashcat(t, `for arg in "$@"; do echo "$arg"; done`)
+		rulePath := filepath.Join(filepath.Dir(binary), "rules", "best64.rule")
+		require.NoError(t, os.MkdirAll(filepath.Dir(rulePath), 0755))
+		require.NoError(t, os.WriteFile(rulePath, []byte(":\n"), 0644))
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 5*time.Second, 1)
+
+		candidates, _, err := generator.Generate(context.Background(), domain.CandidateSpec{
+			AttackMode:   domain.AttackModeStraight,
+			WordlistPath: "/uploads/wordlists/w.txt",
+			Rules:        "best64.rule",
+		}, 100)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--stdout", "--quiet", "-a", "0", "-r", rulePath, "/uploads/wordlists/w.txt"}, candidates)
+	})
+
+	t.Run("hashcat error", func(t *testing.T) {
+		binary := fakeHashcat(t, `echo "Invalid mask." >&2; exit 255`)
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 5*time.Second, 1)
+
+		_, _, err := generator.Generate(context.Background(), spec, 10)
+		require.True(t, domain.IsValidationError(err), "got %v", err)
+		assert.Contains(t, err.Error(), "Invalid mask.")
+	})
+
+	t.Run("timeout keeps partial output", func(t *testing.T) {
+		binary := fakeHashcat(t, `echo first; exec sleep 10`)
+		generator := infrastructure.NewHashcatStdoutGenerator(binary, 200*time.Millisecond, 1)
+
+		candidates, truncated, err := generator.Generate(context.Background(), spec, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first"}, candidates)
+		assert.True(t, truncated)
+	})
+}
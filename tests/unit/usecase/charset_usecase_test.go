@@ -0,0 +1,90 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCharsetRepository is a mock implementation of domain.CharsetRepository
+type MockCharsetRepository struct {
+	mock.Mock
+}
+
+func (m *MockCharsetRepository) Create(ctx context.Context, charset *domain.CharsetFile) error {
+	args := m.Called(ctx, charset)
+	return args.Error(0)
+}
+
+func (m *MockCharsetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CharsetFile, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CharsetFile), args.Error(1)
+}
+
+func (m *MockCharsetRepository) GetAll(ctx context.Context) ([]domain.CharsetFile, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.CharsetFile), args.Error(1)
+}
+
+func (m *MockCharsetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCharsetUsecase_UploadCharset(t *testing.T) {
+	t.Run("stores the file", func(t *testing.T) {
+		uploadDir := t.TempDir()
+		repo := new(MockCharsetRepository)
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.CharsetFile")).Return(nil)
+
+		charset, err := usecase.NewCharsetUsecase(repo, uploadDir).UploadCharset(context.Background(), "german.hcchr", strings.NewReader("äöüß"))
+		require.NoError(t, err)
+		assert.Equal(t, "german.hcchr", charset.OrigName)
+		assert.Equal(t, filepath.Join(uploadDir, "charsets", charset.ID.String()+".hcchr"), charset.Path)
+		assert.Len(t, charset.SHA256, 64)
+
+		content, err := os.ReadFile(charset.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "äöüß", string(content))
+		assert.Equal(t, int64(len(content)), charset.Size)
+	})
+
+	for name, content := range map[string]string{
+		"empty":     "",
+		"too large": strings.Repeat("a", domain.MaxCharsetFileSize+1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockCharsetRepository)
+			_, err := usecase.NewCharsetUsecase(repo, t.TempDir()).UploadCharset(context.Background(), "x.hcchr", strings.NewReader(content))
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCharsetUsecase_DeleteCharset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.hcchr")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0644))
+	charset := &domain.CharsetFile{ID: uuid.New(), Path: path}
+
+	repo := new(MockCharsetRepository)
+	repo.On("GetByID", mock.Anything, charset.ID).Return(charset, nil)
+	repo.On("Delete", mock.Anything, charset.ID).Return(nil)
+
+	require.NoError(t, usecase.NewCharsetUsecase(repo, t.TempDir()).DeleteCharset(context.Background(), charset.ID))
+	assert.NoFileExists(t, path)
+	repo.AssertExpectations(t)
+}
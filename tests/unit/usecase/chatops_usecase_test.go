@@ -0,0 +1,202 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockChatJobUsecase mocks the job usecase methods chat commands use; the
+// embedded interface panics on any other
+type MockChatJobUsecase struct {
+	usecase.JobUsecase
+	mock.Mock
+}
+
+func (m *MockChatJobUsecase) CreateJob(ctx context.Context, req *domain.CreateJobRequest) (*domain.Job, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockChatJobUsecase) ApplyJobPlaybook(ctx context.Context, playbook *domain.JobPlaybook, projectID string, dryRun bool) (*domain.JobPlaybookResult, error) {
+	args := m.Called(ctx, playbook, projectID, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobPlaybookResult), args.Error(1)
+}
+
+func (m *MockChatJobUsecase) GetJob(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Job), args.Error(1)
+}
+
+func (m *MockChatJobUsecase) GetJobsByStatus(ctx context.Context, status string) ([]domain.Job, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockChatJobUsecase) PauseJob(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockChatJobUsecase) CancelJob(ctx context.Context, id uuid.UUID, reason string) error {
+	return m.Called(ctx, id, reason).Error(0)
+}
+
+// MockStatsUsecase is a mock implementation of usecase.StatsUsecase
+type MockStatsUsecase struct {
+	usecase.StatsUsecase
+	mock.Mock
+}
+
+func (m *MockStatsUsecase) GetClusterStats(ctx context.Context) (*domain.ClusterStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.ClusterStats), args.Error(1)
+}
+
+var alice = domain.ChatUser{Chat: "slack", ID: "U01ALICE", Name: "alice"}
+
+func TestChatOpsUsecase_HandleCommand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("status", func(t *testing.T) {
+		stats := new(MockStatsUsecase)
+		stats.On("GetClusterStats", mock.Anything).Return(&domain.ClusterStats{
+			Agents:        domain.AgentStats{Total: 4, Active: 3, TotalSpeed: 2_500_000_000},
+			JobsByStatus:  map[string]int{domain.JobStatusRunning: 2, domain.JobStatusPending: 1, domain.JobStatusInterrupted: 1},
+			CracksLast24h: 17,
+		}, nil)
+		uc := usecase.NewChatOpsUsecase(new(MockChatJobUsecase), stats, new(MockHashFileRepository), usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "status"})
+		require.NoError(t, err)
+		assert.False(t, message.Public)
+		require.Len(t, message.Sections, 1)
+		assert.Contains(t, message.Sections[0].Fields, domain.ChatField{Label: "Speed", Value: "2.50 GH/s"})
+		assert.Contains(t, message.Sections[0].Fields, domain.ChatField{Label: "Queued", Value: "2"})
+		assert.Contains(t, message.Sections[0].Fields, domain.ChatField{Label: "Cracked in 24h", Value: "17"})
+	})
+
+	t.Run("jobs with buttons", func(t *testing.T) {
+		jobs := new(MockChatJobUsecase)
+		running := domain.Job{ID: uuid.New(), Name: "acme-ntlm", Status: domain.JobStatusRunning, Progress: 42.5, Speed: 1_200_000}
+		jobs.On("GetJobsByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{running}, nil)
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), new(MockHashFileRepository), usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "jobs"})
+		require.NoError(t, err)
+		require.Len(t, message.Sections, 1)
+		section := message.Sections[0]
+		assert.Contains(t, section.Fields, domain.ChatField{Label: "Progress", Value: "42.5%"})
+		assert.Contains(t, section.Fields, domain.ChatField{Label: "Speed", Value: "1.20 MH/s"})
+		assert.Equal(t, []domain.ChatButton{
+			{Text: "Pause", ActionID: domain.ChatActionPauseJob, Value: running.ID.String()},
+			{Text: "Cancel", ActionID: domain.ChatActionCancelJob, Value: running.ID.String(), Danger: true},
+		}, section.Buttons)
+
+		message, err = uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "jobs sleeping"})
+		require.NoError(t, err)
+		assert.Equal(t, `"sleeping" is not a job status.`, message.Text)
+	})
+
+	t.Run("crack", func(t *testing.T) {
+		jobs, hashFiles := new(MockChatJobUsecase), new(MockHashFileRepository)
+		hashFiles.On("GetAll", mock.Anything).Return([]domain.HashFile{{ID: uuid.New(), Name: "office.hccapx", Type: "hccapx"}}, nil)
+		request := domain.CreateJobRequest{Name: "office.hccapx with rockyou.txt", HashType: 2500}
+		jobs.On("ApplyJobPlaybook", mock.Anything, mock.MatchedBy(func(playbook *domain.JobPlaybook) bool {
+			attack := playbook.Attacks[0]
+			return playbook.Validate() == nil && *attack.HashType == 2500 && attack.Wordlist == "rockyou.txt"
+		}), "", true).Return(&domain.JobPlaybookResult{Attacks: []domain.PlaybookAttackResult{{Request: request}}}, nil)
+		queued := &domain.Job{ID: uuid.New(), Name: request.Name, HashType: 2500, Status: domain.JobStatusPending}
+		jobs.On("CreateJob", mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.ActorFromContext(ctx) == "slack:alice"
+		}), &request).Return(queued, nil)
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), hashFiles, usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "crack office.hccapx rockyou.txt"})
+		require.NoError(t, err)
+		assert.True(t, message.Public)
+		assert.Equal(t, "alice queued *office.hccapx with rockyou.txt*, hash mode 2500", message.Text)
+		jobs.AssertExpectations(t)
+	})
+
+	t.Run("crack mistakes are answered", func(t *testing.T) {
+		jobs, hashFiles := new(MockChatJobUsecase), new(MockHashFileRepository)
+		hashFiles.On("GetAll", mock.Anything).Return([]domain.HashFile{}, nil)
+		jobs.On("ApplyJobPlaybook", mock.Anything, mock.Anything, "", true).
+			Return(nil, &domain.PlaybookError{Problems: []domain.ValidationError{{Field: "attacks[0].wordlist", Message: `wordlist "nope.txt" not found`}}})
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), hashFiles, usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "crack hashes.txt rockyou.txt"})
+		require.NoError(t, err)
+		assert.Contains(t, message.Text, "hash mode")
+
+		message, err = uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "crack hashes.txt nope.txt 1000"})
+		require.NoError(t, err)
+		assert.Equal(t, `Couldn't queue the attack: wordlist "nope.txt" not found`, message.Text)
+		jobs.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("only allowed users queue", func(t *testing.T) {
+		jobs := new(MockChatJobUsecase)
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), new(MockHashFileRepository), usecase.ChatOpsConfig{AllowedUsers: []string{"U02BOB"}})
+
+		message, err := uc.HandleCommand(ctx, domain.ChatCommand{User: alice, Text: "crack hashes.txt rockyou.txt 1000"})
+		require.NoError(t, err)
+		assert.Contains(t, message.Text, "not queue or stop")
+		jobs.AssertNotCalled(t, "ApplyJobPlaybook", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestChatOpsUsecase_HandleAction(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+
+	t.Run("cancel", func(t *testing.T) {
+		jobs := new(MockChatJobUsecase)
+		jobs.On("CancelJob", mock.Anything, id, "Cancelled from slack by alice").Return(nil)
+		jobs.On("GetJob", mock.Anything, id).Return(&domain.Job{ID: id, Name: "acme-ntlm", Status: domain.JobStatusCancelled}, nil)
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), new(MockHashFileRepository), usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleAction(ctx, domain.ChatAction{User: alice, ActionID: domain.ChatActionCancelJob, Value: id.String()})
+		require.NoError(t, err)
+		assert.True(t, message.Public)
+		assert.Equal(t, "alice cancelled *acme-ntlm*", message.Text)
+		require.Len(t, message.Sections, 1)
+		assert.Empty(t, message.Sections[0].Buttons, "nothing is left to do with a cancelled job")
+	})
+
+	t.Run("a job that finished meanwhile", func(t *testing.T) {
+		jobs := new(MockChatJobUsecase)
+		jobs.On("PauseJob", mock.Anything, id).Return(&domain.JobTransitionError{From: domain.JobStatusCompleted, To: domain.JobStatusPaused})
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), new(MockHashFileRepository), usecase.ChatOpsConfig{})
+
+		message, err := uc.HandleAction(ctx, domain.ChatAction{User: alice, ActionID: domain.ChatActionPauseJob, Value: id.String()})
+		require.NoError(t, err)
+		assert.False(t, message.Public)
+		assert.Contains(t, message.Text, "Couldn't change the job")
+	})
+
+	t.Run("server failures are errors", func(t *testing.T) {
+		jobs := new(MockChatJobUsecase)
+		jobs.On("PauseJob", mock.Anything, id).Return(context.DeadlineExceeded)
+		uc := usecase.NewChatOpsUsecase(jobs, new(MockStatsUsecase), new(MockHashFileRepository), usecase.ChatOpsConfig{})
+
+		_, err := uc.HandleAction(ctx, domain.ChatAction{User: alice, ActionID: domain.ChatActionPauseJob, Value: id.String()})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,102 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplianceUsecase_GetComplianceReport(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryCredentialRepository{}
+	projectID, first, second := uuid.New(), uuid.New(), uuid.New()
+	_, err := repo.Record(ctx, []domain.Credential{
+		{Username: "alice", Domain: "CORP.LOCAL", Hash: "a", Plaintext: "Tr0ub4dor&3xyz!", HashFileID: &first, SourceFile: "corp.txt", ProjectID: &projectID},
+		{Username: "alice", Domain: "CORP.LOCAL", Hash: "a", Plaintext: "Tr0ub4dor&3xyz!", HashFileID: &second, SourceFile: "old.txt", ProjectID: &projectID},
+		{Username: "bob", Domain: "CORP.LOCAL", Hash: "b", Plaintext: "Summer2026!", HashFileID: &first, SourceFile: "corp.txt", ProjectID: &projectID},
+		{Username: "carol.smith", Domain: "CORP.LOCAL", Hash: "c", Plaintext: "Smith#2026abcdef", HashFileID: &first, SourceFile: "corp.txt", ProjectID: &projectID},
+		{Username: "dave", Domain: "CORP.LOCAL", Hash: "d", Plaintext: "alllowercaseletters", HashFileID: &first, SourceFile: "corp.txt", ProjectID: &projectID},
+		{Username: "eve", Domain: "ACME.LOCAL", Hash: "e", Plaintext: "Acme#R0cks2026xx", HashFileID: &first, SourceFile: "corp.txt", ProjectID: &projectID},
+		{Username: "mallory", Hash: "f", Plaintext: "x", HashFileID: &first, SourceFile: "other.txt"},
+	})
+	require.NoError(t, err)
+
+	policy := domain.PasswordPolicy{MinLength: 12, MinClasses: 3, RequiredClasses: []string{"special"}, CheckDictionary: true, CheckUsername: true}
+	compliance := usecase.NewComplianceUsecase(repo, new(MockWordlistRepository), policy)
+	report, err := compliance.GetComplianceReport(ctx, &projectID, compliance.Policy())
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Credentials, "alice counts once for the same password in two files")
+	assert.Equal(t, 1, report.Compliant)
+	assert.Equal(t, 20.0, report.Percent)
+	assert.Equal(t, 4, report.Policy.MinWordLength, "the default word length is filled in")
+	assert.Equal(t, []domain.PolicyViolation{
+		{Rule: domain.PolicyRuleDictionaryWord, Count: 2, Percent: 40},
+		{Rule: domain.PolicyRuleMinLength, Count: 1, Percent: 20},
+		{Rule: domain.PolicyRuleMinClasses, Count: 1, Percent: 20},
+		{Rule: domain.PolicyRuleRequiredClasses, Count: 1, Percent: 20},
+		{Rule: domain.PolicyRuleUsername, Count: 1, Percent: 20},
+	}, report.Violations)
+	assert.Equal(t, []domain.NonCompliantAccount{
+		{Account: "ACME.LOCAL\\eve", Source: "corp.txt", Length: 16, Rules: []string{domain.PolicyRuleDictionaryWord}},
+		{Account: "CORP.LOCAL\\bob", Source: "corp.txt", Length: 11, Rules: []string{domain.PolicyRuleMinLength, domain.PolicyRuleDictionaryWord}},
+		{Account: "CORP.LOCAL\\carol.smith", Source: "corp.txt", Length: 16, Rules: []string{domain.PolicyRuleUsername}},
+		{Account: "CORP.LOCAL\\dave", Source: "corp.txt", Length: 19, Rules: []string{domain.PolicyRuleMinClasses, domain.PolicyRuleRequiredClasses}},
+	}, report.NonCompliant)
+
+	// Every project, with only the length rule
+	report, err = compliance.GetComplianceReport(ctx, nil, domain.PasswordPolicy{MinLength: 12})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Credentials)
+	assert.Equal(t, []domain.PolicyViolation{{Rule: domain.PolicyRuleMinLength, Count: 2, Percent: 33.3}}, report.Violations)
+}
+
+func TestComplianceUsecase_DictionaryWordlist(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryCredentialRepository{}
+	hashFileID := uuid.New()
+	_, err := repo.Record(ctx, []domain.Credential{{Username: "bob", Hash: "b", Plaintext: "Kxq!R0cks77", HashFileID: &hashFileID}})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("rocks\nab\n"), 0644))
+	wordlistID := uuid.New()
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, Path: path}, nil)
+	wordlistRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "wordlist"})
+	compliance := usecase.NewComplianceUsecase(repo, wordlistRepo, domain.PasswordPolicy{})
+
+	report, err := compliance.GetComplianceReport(ctx, nil, domain.PasswordPolicy{CheckDictionary: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Compliant, "rocks isn't a built-in word")
+
+	report, err = compliance.GetComplianceReport(ctx, nil, domain.PasswordPolicy{CheckDictionary: true, DictionaryWordlistID: &wordlistID})
+	require.NoError(t, err)
+	assert.Zero(t, report.Compliant, "R0cks is rocks with leetspeak undone")
+
+	missing := uuid.New()
+	_, err = compliance.GetComplianceReport(ctx, nil, domain.PasswordPolicy{CheckDictionary: true, DictionaryWordlistID: &missing})
+	assert.True(t, domain.IsValidationError(err))
+}
+
+func TestComplianceUsecase_InvalidPolicy(t *testing.T) {
+	compliance := usecase.NewComplianceUsecase(&memoryCredentialRepository{}, new(MockWordlistRepository), domain.PasswordPolicy{})
+	for _, policy := range []domain.PasswordPolicy{
+		{MinLength: -1},
+		{MinClasses: 5},
+		{RequiredClasses: []string{"emoji"}},
+		{CheckDictionary: true, MinWordLength: -2},
+	} {
+		_, err := compliance.GetComplianceReport(context.Background(), nil, policy)
+		assert.True(t, domain.IsValidationError(err), "%+v", policy)
+	}
+}
@@ -0,0 +1,238 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCredentialRepository keeps credentials in memory
+type memoryCredentialRepository struct {
+	credentials []domain.Credential
+}
+
+func (r *memoryCredentialRepository) Record(ctx context.Context, credentials []domain.Credential) (int, error) {
+	recorded := 0
+	for _, credential := range credentials {
+		duplicate := false
+		for _, existing := range r.credentials {
+			if *existing.HashFileID == *credential.HashFileID && existing.Domain == credential.Domain &&
+				existing.Username == credential.Username && existing.Hash == credential.Hash {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			credential.ID = uuid.New()
+			r.credentials = append(r.credentials, credential)
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+func (r *memoryCredentialRepository) GetAll(ctx context.Context, filter domain.CredentialFilter) ([]domain.Credential, int, error) {
+	credentials := []domain.Credential{}
+	for _, credential := range r.credentials {
+		if filter.Username != "" && !strings.EqualFold(credential.Username, filter.Username) {
+			continue
+		}
+		if filter.Domain != "" && !strings.EqualFold(credential.Domain, filter.Domain) {
+			continue
+		}
+		if filter.HashFileID != nil && *credential.HashFileID != *filter.HashFileID {
+			continue
+		}
+		if filter.ProjectID != nil && (credential.ProjectID == nil || *credential.ProjectID != *filter.ProjectID) {
+			continue
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, len(credentials), nil
+}
+
+type credentialFixture struct {
+	credentials usecase.CredentialUsecase
+	repo        *memoryCredentialRepository
+	hashFiles   usecase.HashFileUsecase
+	ntdsRepo    *memoryNTDSRepository
+}
+
+func newCredentialFixture(t *testing.T) *credentialFixture {
+	f := &credentialFixture{
+		repo:     &memoryCredentialRepository{},
+		ntdsRepo: newMemoryNTDSRepository(),
+	}
+	f.hashFiles = usecase.NewHashFileUsecase(&memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}, t.TempDir())
+	f.credentials = usecase.NewCredentialUsecase(f.repo, f.hashFiles, f.ntdsRepo)
+	return f
+}
+
+func (f *credentialFixture) upload(t *testing.T, name, content string) *domain.HashFile {
+	hashFile, err := f.hashFiles.UploadHashFile(context.Background(), name, strings.NewReader(content), int64(len(content)), nil)
+	require.NoError(t, err)
+	return hashFile
+}
+
+func TestCredentialUsecase_RecordCrackedJob(t *testing.T) {
+	f := newCredentialFixture(t)
+	ctx := context.Background()
+
+	// MD5 of "password" for alice and bob, "letmein" for carol
+	users := f.upload(t, "users.txt", "CORP\\alice:5f4dcc3b5aa765d61d8327deb882cf99\n"+
+		"bob:5F4DCC3B5AA765D61D8327DEB882CF99\ncarol:0d107d09f5bbe40cade3de5c71e9e9b7\n")
+	completed := time.Now().Add(-time.Hour)
+	job := &domain.Job{ID: uuid.New(), HashType: 0, HashFileID: &users.ID, CompletedAt: &completed}
+	require.NoError(t, f.credentials.RecordCrackedJob(ctx, job, "password"))
+
+	credentials, total, err := f.credentials.GetCredentials(ctx, domain.CredentialFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "alice", credentials[0].Username)
+	assert.Equal(t, "CORP", credentials[0].Domain)
+	assert.Equal(t, "5f4dcc3b5aa765d61d8327deb882cf99", credentials[0].Hash)
+	assert.Equal(t, "bob", credentials[1].Username)
+	assert.Equal(t, "password", credentials[1].Plaintext)
+	assert.Equal(t, "users.txt", credentials[1].SourceFile)
+	assert.Equal(t, domain.CredentialSourceJob, credentials[1].Source)
+	assert.Equal(t, &job.ID, credentials[1].JobID)
+	assert.WithinDuration(t, completed, credentials[1].CrackedAt, time.Second)
+
+	// The hash of a bcrypt password can't be computed, but a file of one
+	// hash has only one account to link it to
+	single := f.upload(t, "admin.txt", "admin:$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6\n")
+	require.NoError(t, f.credentials.RecordCrackedJob(ctx, &domain.Job{ID: uuid.New(), HashType: 3200, HashFileID: &single.ID}, "hashcat"))
+	credentials, _, err = f.credentials.GetCredentials(ctx, domain.CredentialFilter{Username: "ADMIN"})
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, 3200, credentials[0].HashType)
+
+	// Passwords matching no hash are left out
+	require.NoError(t, f.credentials.RecordCrackedJob(ctx, &domain.Job{ID: uuid.New(), HashType: 0, HashFileID: &users.ID}, "nope"))
+	_, total, _ = f.credentials.GetCredentials(ctx, domain.CredentialFilter{})
+	assert.Equal(t, 3, total)
+}
+
+func TestCredentialUsecase_RecordJobCracks(t *testing.T) {
+	f := newCredentialFixture(t)
+	ctx := context.Background()
+
+	// The hashes hashcat printed find salted accounts whose password can't
+	// be hashed here, even with a colon in the salt
+	salted := f.upload(t, "salted.txt", "dave:e56f3bb5392369b6468e744ab1da078b:s1\neve:0d107d09f5bbe40cade3de5c71e9e9b7:a:b\n")
+	job := &domain.Job{ID: uuid.New(), HashType: 10, HashFileID: &salted.ID}
+	crackedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, f.credentials.RecordJobCracks(ctx, job, []domain.JobCrack{
+		{Hash: "0d107d09f5bbe40cade3de5c71e9e9b7:a:b", Plaintext: "p:w", CrackedAt: crackedAt},
+		{Hash: "ffffffffffffffffffffffffffffffff:zz", Plaintext: "nope", CrackedAt: crackedAt},
+	}))
+
+	credentials, total, err := f.credentials.GetCredentials(ctx, domain.CredentialFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "eve", credentials[0].Username)
+	assert.Equal(t, "p:w", credentials[0].Plaintext)
+	assert.Equal(t, &job.ID, credentials[0].JobID)
+	assert.WithinDuration(t, crackedAt, credentials[0].CrackedAt, time.Second)
+}
+
+func TestCredentialUsecase_ImportPotfile(t *testing.T) {
+	f := newCredentialFixture(t)
+	ctx := context.Background()
+
+	// Salted hashes have colons, and the salt of dave's has one too
+	salted := f.upload(t, "salted.txt", "dave:e56f3bb5392369b6468e744ab1da078b:s1\neve:0d107d09f5bbe40cade3de5c71e9e9b7:a:b\n")
+	recorded, err := f.credentials.ImportPotfile(ctx, salted.ID, 10, strings.NewReader(
+		"e56f3bb5392369b6468e744ab1da078b:s1:Summer2026!\n0d107d09f5bbe40cade3de5c71e9e9b7:a:b:$HEX[70613a7373]\nunknown:hash\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, recorded)
+	credentials, _, err := f.credentials.GetCredentials(ctx, domain.CredentialFilter{Username: "eve"})
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, "pa:ss", credentials[0].Plaintext)
+	assert.Equal(t, "0d107d09f5bbe40cade3de5c71e9e9b7:a:b", credentials[0].Hash)
+	assert.Equal(t, domain.CredentialSourcePotfile, credentials[0].Source)
+	assert.Equal(t, 10, credentials[0].HashType)
+
+	// Importing it again records nothing new
+	recorded, err = f.credentials.ImportPotfile(ctx, salted.ID, 10, strings.NewReader("e56f3bb5392369b6468e744ab1da078b:s1:Summer2026!\n"))
+	require.NoError(t, err)
+	assert.Zero(t, recorded)
+
+	_, err = f.credentials.ImportPotfile(ctx, salted.ID, 0, strings.NewReader(""))
+	assert.True(t, domain.IsValidationError(err))
+}
+
+func TestCredentialUsecase_ImportPotfileOfNTDSImport(t *testing.T) {
+	f := newCredentialFixture(t)
+	ctx := context.Background()
+	ntds := usecase.NewNTDSUsecase(f.ntdsRepo, f.hashFiles, new(MockJobRepository))
+	imported, err := ntds.ImportNTDS(ctx, "corp.ntds", strings.NewReader(ntdsDump), &domain.ImportNTDSRequest{Domain: "CORP.LOCAL"})
+	require.NoError(t, err)
+
+	// The hash file holds bare NT hashes; the usernames come from the import
+	recorded, err := f.credentials.ImportPotfile(ctx, *imported.HashFileID, 0, strings.NewReader("2d20d252a479f485cdf5e171d93985bf:Summer2026!\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, recorded)
+
+	credentials, _, err := f.credentials.GetCredentials(ctx, domain.CredentialFilter{Domain: "corp.local"})
+	require.NoError(t, err)
+	require.Len(t, credentials, 2)
+	assert.Equal(t, "carol", credentials[0].Username)
+	assert.Equal(t, "dave", credentials[1].Username)
+	assert.Equal(t, domain.NTLMHashType, credentials[1].HashType)
+
+	cracked, _, err := f.ntdsRepo.GetAccounts(ctx, imported.ID, domain.NTDSAccountFilter{Cracked: ptrBool(true)})
+	require.NoError(t, err)
+	assert.Len(t, cracked, 3, "carol and dave, and the empty password of guest")
+}
+
+func TestCredentialUsecase_GetStats(t *testing.T) {
+	f := newCredentialFixture(t)
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+	_, err := f.repo.Record(ctx, []domain.Credential{
+		{Username: "alice", Domain: "CORP", Hash: "a", Plaintext: "Summer2026!", HashFileID: &first},
+		{Username: "ALICE", Domain: "corp", Hash: "a", Plaintext: "Summer2026!", HashFileID: &second},
+		{Username: "bob", Hash: "b", Plaintext: "Summer2026!", HashFileID: &first},
+		{Username: "carol", Hash: "c", Plaintext: "letmein", HashFileID: &first},
+		{Hash: "d", Plaintext: "letmein", HashFileID: &first},
+		{Username: "dave", Hash: "e", Plaintext: "hunter2", HashFileID: &first},
+	})
+	require.NoError(t, err)
+
+	stats, err := f.credentials.GetStats(ctx, domain.CredentialFilter{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Credentials, "alice counts once for the same password in two files")
+	assert.Equal(t, 5, stats.Accounts)
+	assert.Equal(t, 3, stats.UniquePasswords)
+	assert.Equal(t, 4, stats.ReusedAccounts)
+	assert.Equal(t, []domain.PasswordReuse{
+		{Password: "Summer2026!", Accounts: []string{"CORP\\alice", "bob"}},
+		{Password: "letmein", Accounts: []string{"carol", "d"}},
+	}, stats.Reused)
+	require.Len(t, stats.TopPasswords, 2)
+	assert.Equal(t, domain.PasswordCount{Password: "Summer2026!", Count: 2, Share: 0.4}, stats.TopPasswords[0])
+	assert.Equal(t, []domain.LengthCount{
+		{Length: 7, Count: 3, Share: 0.6},
+		{Length: 11, Count: 2, Share: 0.4},
+	}, stats.Lengths)
+	assert.Equal(t, []domain.CharsetCount{
+		{Charset: "lower", Count: 2, Share: 0.4},
+		{Charset: "lower+upper+digit+special", Count: 2, Share: 0.4},
+		{Charset: "lower+digit", Count: 1, Share: 0.2},
+	}, stats.Charsets)
+
+	stats, err = f.credentials.GetStats(ctx, domain.CredentialFilter{Username: "carol"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Credentials)
+	assert.Empty(t, stats.Reused)
+}
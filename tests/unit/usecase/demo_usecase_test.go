@@ -0,0 +1,85 @@
+package usecase_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoUsecase_Seed(t *testing.T) {
+	ctx := context.Background()
+	uploadDir := t.TempDir()
+
+	agentRepo := new(MockAgentRepository)
+	var agents []*domain.Agent
+	agentRepo.On("CreateAgent", ctx, mock.AnythingOfType("*domain.Agent")).Run(func(args mock.Arguments) {
+		agents = append(agents, args.Get(1).(*domain.Agent))
+	}).Return(nil)
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetBySHA256", ctx, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "wordlist"})
+	wordlistRepo.On("Create", ctx, mock.AnythingOfType("*domain.Wordlist")).Return(nil)
+	projectRepo := new(MockProjectRepository)
+	projectRepo.On("GetByName", ctx, domain.DemoProjectName).Return(nil, &domain.NotFoundError{Entity: "project"}).Once()
+	archiveRepo := &memoryArchiveRepository{}
+	hashFiles := usecase.NewHashFileUsecase(&memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}, uploadDir)
+
+	demo := usecase.NewDemoUsecase(usecase.NewAgentUsecase(agentRepo), nil, projectRepo, archiveRepo, hashFiles,
+		usecase.NewWordlistUsecase(wordlistRepo, uploadDir))
+	result, err := demo.Seed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DemoProjectName, result.Project.Name)
+	assert.Equal(t, 3, result.Agents)
+	assert.Equal(t, 2, result.HashFiles)
+	assert.Equal(t, 2, result.Wordlists)
+	assert.Equal(t, 9, result.Jobs)
+	assert.Equal(t, 3, result.Credentials)
+
+	require.Len(t, agents, 3)
+	for _, agent := range agents {
+		assert.True(t, domain.IsSimulatedAgent(agent), agent.Name)
+		assert.NotEmpty(t, agent.AgentKey)
+	}
+
+	// The history keeps its timestamps; the rest is queued for the simulator
+	imported := archiveRepo.imported
+	require.NotNil(t, imported)
+	pending := 0
+	for _, job := range imported.Jobs {
+		assert.Equal(t, result.Project.ID, *job.ProjectID)
+		if job.Status == domain.JobStatusPending {
+			pending++
+			assert.Nil(t, job.AgentID)
+			continue
+		}
+		require.NotNil(t, job.CompletedAt, job.Name)
+		assert.True(t, job.CompletedAt.Before(time.Now().Add(-24*time.Hour)), job.Name)
+	}
+	assert.Equal(t, 3, pending)
+
+	// Every credential is a hash of its hash file
+	for _, credential := range imported.Credentials {
+		_, content, err := hashFiles.OpenHashFile(ctx, *credential.HashFileID)
+		require.NoError(t, err)
+		lines, err := io.ReadAll(content)
+		content.Close()
+		require.NoError(t, err)
+		assert.Contains(t, strings.Split(string(lines), "\n"), credential.Hash, credential.Plaintext)
+	}
+
+	t.Run("seeds only once", func(t *testing.T) {
+		projectRepo.On("GetByName", ctx, domain.DemoProjectName).Return(result.Project, nil)
+		_, err := demo.Seed(ctx)
+		assert.True(t, domain.IsValidationError(err))
+		agentRepo.AssertNumberOfCalls(t, "CreateAgent", 3)
+	})
+}
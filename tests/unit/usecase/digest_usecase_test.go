@@ -0,0 +1,235 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDigestSubscriptionRepository is a mock implementation of domain.DigestSubscriptionRepository
+type MockDigestSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockDigestSubscriptionRepository) Save(ctx context.Context, subscription *domain.DigestSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockDigestSubscriptionRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.DigestSubscription, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.DigestSubscription), args.Error(1)
+}
+
+func (m *MockDigestSubscriptionRepository) GetAll(ctx context.Context) ([]domain.DigestSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.DigestSubscription), args.Error(1)
+}
+
+func (m *MockDigestSubscriptionRepository) Delete(ctx context.Context, userID, projectID uuid.UUID) error {
+	args := m.Called(ctx, userID, projectID)
+	return args.Error(0)
+}
+
+func (m *MockDigestSubscriptionRepository) MarkSent(ctx context.Context, userID, projectID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, userID, projectID, at)
+	return args.Error(0)
+}
+
+// recordingMailer keeps the emails it is asked to send
+type recordingMailer struct {
+	sent []domain.EmailMessage
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, message *domain.EmailMessage) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, *message)
+	return nil
+}
+
+type digestFixture struct {
+	subscriptions *MockDigestSubscriptionRepository
+	projects      *MockProjectRepository
+	users         *MockUserRepository
+	jobs          *MockJobRepository
+	agents        *MockAgentRepository
+	maintenance   *MockMaintenanceRepository
+	mailer        *recordingMailer
+	usecase       usecase.DigestUsecase
+}
+
+func newDigestFixture() *digestFixture {
+	f := &digestFixture{
+		subscriptions: new(MockDigestSubscriptionRepository),
+		projects:      new(MockProjectRepository),
+		users:         new(MockUserRepository),
+		jobs:          new(MockJobRepository),
+		agents:        new(MockAgentRepository),
+		maintenance:   new(MockMaintenanceRepository),
+		mailer:        &recordingMailer{},
+	}
+	f.usecase = usecase.NewDigestUsecase(f.subscriptions, f.projects, f.users, f.jobs, f.agents, f.maintenance, f.mailer,
+		usecase.DigestConfig{DashboardURL: "https://hashcat.example.com"})
+	return f
+}
+
+// projectActivity sets up a project with one job that finished and cracked
+// two hashes yesterday, one queued job and an offline agent
+func (f *digestFixture) projectActivity(project *domain.Project, now time.Time) {
+	yesterday := now.Add(-20 * time.Hour)
+	lastMonth := now.Add(-30 * 24 * time.Hour)
+	finished := domain.Job{ID: uuid.New(), Name: "ntlm-dump", Status: domain.JobStatusCompleted, CompletedAt: &yesterday, UpdatedAt: yesterday}
+	stale := domain.Job{ID: uuid.New(), Name: "old-job", Status: domain.JobStatusCompleted, CompletedAt: &lastMonth, UpdatedAt: lastMonth}
+	queued := domain.Job{ID: uuid.New(), Name: "wpa-capture", Status: domain.JobStatusPending, UpdatedAt: yesterday}
+
+	f.projects.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	f.jobs.On("List", mock.Anything, mock.MatchedBy(func(filter domain.JobFilter) bool { return filter.SortBy == "updated_at" })).
+		Return([]domain.Job{finished, stale}, 2, nil)
+	f.jobs.On("List", mock.Anything, mock.MatchedBy(func(filter domain.JobFilter) bool { return filter.Status == domain.JobStatusInterrupted })).
+		Return([]domain.Job{}, 0, nil)
+	f.jobs.On("List", mock.Anything, mock.MatchedBy(func(filter domain.JobFilter) bool { return filter.Status == domain.JobStatusPending })).
+		Return([]domain.Job{queued}, 1, nil)
+	f.jobs.On("GetCracks", mock.Anything, finished.ID).Return([]domain.JobCrack{
+		{JobID: finished.ID, Hash: "a", Plaintext: "secret1", CrackedAt: yesterday},
+		{JobID: finished.ID, Hash: "b", Plaintext: "secret2", CrackedAt: yesterday},
+		{JobID: finished.ID, Hash: "c", Plaintext: "secret3", CrackedAt: lastMonth},
+	}, nil)
+	f.agents.On("GetAll", mock.Anything).Return([]domain.Agent{
+		{Name: "rig-1", Status: "online", LastSeen: now},
+		{Name: "rig-2", Status: "offline", LastSeen: lastMonth},
+	}, nil)
+	f.maintenance.On("GetAll", mock.Anything).Return([]domain.MaintenanceWindow{}, nil)
+}
+
+func TestDigestUsecase_BuildDigest(t *testing.T) {
+	f := newDigestFixture()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	project := &domain.Project{ID: uuid.New(), Name: "Red team"}
+	f.projectActivity(project, now)
+
+	digest, err := f.usecase.BuildDigest(context.Background(), project.ID, domain.DigestDaily, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, now.Add(-24*time.Hour), digest.From)
+	assert.Equal(t, 2, digest.NewCracks)
+	assert.Equal(t, 1, digest.JobsFinished)
+	require.Len(t, digest.CrackingJobs, 1)
+	assert.Equal(t, "ntlm-dump", digest.CrackingJobs[0].Name)
+	require.Len(t, digest.QueuedJobs, 1)
+	assert.Equal(t, "wpa-capture", digest.QueuedJobs[0].Name)
+	require.Len(t, digest.AgentIssues, 1)
+	assert.Equal(t, "rig-2", digest.AgentIssues[0].Name)
+	assert.False(t, digest.Empty())
+
+	_, err = f.usecase.BuildDigest(context.Background(), project.ID, "hourly", now)
+	assert.True(t, domain.IsValidationError(err))
+}
+
+func TestDigestUsecase_SendDueDigests(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	project := &domain.Project{ID: uuid.New(), Name: "Red team"}
+	member := &domain.User{ID: uuid.New(), Email: "alice@example.com", Role: "user", IsActive: true}
+	leaver := &domain.User{ID: uuid.New(), Email: "bob@example.com", Role: "user", IsActive: true}
+	recentlySent := now.Add(-2 * time.Hour)
+
+	t.Run("emails due digests without cracked passwords", func(t *testing.T) {
+		f := newDigestFixture()
+		f.projectActivity(project, now)
+		f.subscriptions.On("GetAll", mock.Anything).Return([]domain.DigestSubscription{
+			{UserID: member.ID, ProjectID: project.ID, ProjectName: project.Name, Frequency: domain.DigestDaily},
+			{UserID: leaver.ID, ProjectID: project.ID, ProjectName: project.Name, Frequency: domain.DigestDaily},
+			{UserID: uuid.New(), ProjectID: project.ID, ProjectName: project.Name, Frequency: domain.DigestDaily, LastSentAt: &recentlySent},
+		}, nil)
+		f.users.On("GetByID", mock.Anything, member.ID).Return(member, nil)
+		f.users.On("GetByID", mock.Anything, leaver.ID).Return(leaver, nil)
+		f.projects.On("IsMember", mock.Anything, project.ID, member.ID).Return(true, nil)
+		f.projects.On("IsMember", mock.Anything, project.ID, leaver.ID).Return(false, nil)
+		f.subscriptions.On("Delete", mock.Anything, leaver.ID, project.ID).Return(nil)
+		f.subscriptions.On("MarkSent", mock.Anything, member.ID, project.ID, now).Return(nil)
+
+		sent, err := f.usecase.SendDueDigests(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, sent)
+		require.Len(t, f.mailer.sent, 1)
+		email := f.mailer.sent[0]
+		assert.Equal(t, []string{"alice@example.com"}, email.To)
+		assert.Equal(t, "Daily digest of Red team: 2 new cracks, 1 jobs finished", email.Subject)
+		assert.Contains(t, email.HTML, "ntlm-dump")
+		assert.Contains(t, email.HTML, "https://hashcat.example.com")
+		assert.Contains(t, email.Text, "rig-2: offline")
+		assert.NotContains(t, email.HTML, "secret1")
+		assert.NotContains(t, email.Text, "secret1")
+		f.subscriptions.AssertExpectations(t)
+	})
+
+	t.Run("failed sends are retried", func(t *testing.T) {
+		f := newDigestFixture()
+		f.mailer.err = errors.New("connection refused")
+		f.projectActivity(project, now)
+		f.subscriptions.On("GetAll", mock.Anything).Return([]domain.DigestSubscription{
+			{UserID: member.ID, ProjectID: project.ID, ProjectName: project.Name, Frequency: domain.DigestDaily},
+		}, nil)
+		f.users.On("GetByID", mock.Anything, member.ID).Return(member, nil)
+		f.projects.On("IsMember", mock.Anything, project.ID, member.ID).Return(true, nil)
+
+		sent, err := f.usecase.SendDueDigests(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, sent)
+		f.subscriptions.AssertNotCalled(t, "MarkSent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("quiet periods are marked sent without an email", func(t *testing.T) {
+		f := newDigestFixture()
+		f.projects.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+		f.jobs.On("List", mock.Anything, mock.Anything).Return([]domain.Job{}, 0, nil)
+		f.agents.On("GetAll", mock.Anything).Return([]domain.Agent{}, nil)
+		f.maintenance.On("GetAll", mock.Anything).Return([]domain.MaintenanceWindow{}, nil)
+		f.subscriptions.On("GetAll", mock.Anything).Return([]domain.DigestSubscription{
+			{UserID: member.ID, ProjectID: project.ID, ProjectName: project.Name, Frequency: domain.DigestWeekly},
+		}, nil)
+		f.users.On("GetByID", mock.Anything, member.ID).Return(member, nil)
+		f.projects.On("IsMember", mock.Anything, project.ID, member.ID).Return(true, nil)
+		f.subscriptions.On("MarkSent", mock.Anything, member.ID, project.ID, now).Return(nil)
+
+		sent, err := f.usecase.SendDueDigests(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, sent)
+		assert.Empty(t, f.mailer.sent)
+		f.subscriptions.AssertExpectations(t)
+	})
+}
+
+func TestDigestUsecase_Subscribe(t *testing.T) {
+	f := newDigestFixture()
+	project := &domain.Project{ID: uuid.New(), Name: "Red team"}
+	member, outsider := uuid.New(), uuid.New()
+	f.projects.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	f.projects.On("IsMember", mock.Anything, project.ID, member).Return(true, nil)
+	f.projects.On("IsMember", mock.Anything, project.ID, outsider).Return(false, nil)
+	f.subscriptions.On("Save", mock.Anything, mock.AnythingOfType("*domain.DigestSubscription")).Return(nil)
+
+	subscription, err := f.usecase.Subscribe(context.Background(), member, "user", project.ID, &domain.DigestSubscriptionRequest{Frequency: domain.DigestWeekly})
+	require.NoError(t, err)
+	assert.Equal(t, "Red team", subscription.ProjectName)
+
+	_, err = f.usecase.Subscribe(context.Background(), outsider, "user", project.ID, &domain.DigestSubscriptionRequest{Frequency: domain.DigestWeekly})
+	assert.True(t, domain.IsNotFoundError(err))
+
+	_, err = f.usecase.Subscribe(context.Background(), member, "user", project.ID, &domain.DigestSubscriptionRequest{Frequency: "monthly"})
+	assert.True(t, domain.IsValidationError(err))
+}
@@ -0,0 +1,105 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryEnrollmentRepository keeps enrollment tokens in a map
+type memoryEnrollmentRepository map[uuid.UUID]*domain.EnrollmentToken
+
+func (r memoryEnrollmentRepository) Create(ctx context.Context, token *domain.EnrollmentToken) error {
+	token.ID = uuid.New()
+	stored := *token
+	stored.Token = ""
+	r[token.ID] = &stored
+	return nil
+}
+
+func (r memoryEnrollmentRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.EnrollmentToken, error) {
+	for _, token := range r {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, &domain.NotFoundError{Entity: "enrollment token"}
+}
+
+func (r memoryEnrollmentRepository) GetAll(ctx context.Context) ([]domain.EnrollmentToken, error) {
+	tokens := []domain.EnrollmentToken{}
+	for _, token := range r {
+		tokens = append(tokens, *token)
+	}
+	return tokens, nil
+}
+
+func (r memoryEnrollmentRepository) Redeem(ctx context.Context, id, agentID uuid.UUID, at time.Time) error {
+	token, ok := r[id]
+	if !ok {
+		return &domain.NotFoundError{Entity: "enrollment token"}
+	}
+	if err := token.Usable(at); err != nil {
+		return err
+	}
+	token.UsedAt, token.AgentID = &at, &agentID
+	return nil
+}
+
+func (r memoryEnrollmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r, id)
+	return nil
+}
+
+func TestEnrollmentUsecase_Enroll(t *testing.T) {
+	ctx := context.Background()
+	agentRepo := new(MockAgentRepository)
+	tokens := memoryEnrollmentRepository{}
+	enrollment := usecase.NewEnrollmentUsecase(tokens, usecase.NewAgentUsecase(agentRepo))
+
+	created, err := enrollment.CreateTokens(ctx, &domain.CreateEnrollmentTokensRequest{Count: 3, ExpiresInMinutes: 60, Label: "rack 3"})
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+	assert.NotEqual(t, created[0].Token, created[1].Token)
+	assert.Len(t, created[0].Token, 32)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), created[0].ExpiresAt, time.Minute)
+	assert.NotContains(t, created[0].TokenHash, created[0].Token, "only the hash is stored")
+
+	_, err = enrollment.CreateTokens(ctx, &domain.CreateEnrollmentTokensRequest{Count: 5000})
+	assert.True(t, domain.IsValidationError(err))
+
+	agentRepo.On("GetByName", ctx, "gpu-01").Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("GetByName", ctx, "gpu-02").Return(&domain.Agent{ID: uuid.New(), Name: "gpu-02"}, nil)
+	agentRepo.On("GetByAgentKey", ctx, mock.Anything).Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("Create", ctx, mock.Anything).Return(nil)
+	agentRepo.On("GetByID", ctx, mock.Anything).Return(nil, domain.ErrAgentNotFound)
+
+	// A taken name fails before the token is used
+	_, err = enrollment.Enroll(ctx, &domain.EnrollAgentRequest{Token: created[0].Token, Name: "gpu-02"})
+	assert.ErrorContains(t, err, "already exists")
+
+	agent, err := enrollment.Enroll(ctx, &domain.EnrollAgentRequest{Token: created[0].Token, Name: "gpu-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "gpu-01", agent.Name)
+	assert.Len(t, agent.AgentKey, 8)
+	assert.Equal(t, agent.ID, *tokens[created[0].ID].AgentID)
+
+	_, err = enrollment.Enroll(ctx, &domain.EnrollAgentRequest{Token: created[0].Token, Name: "gpu-01"})
+	assert.ErrorIs(t, err, domain.ErrEnrollmentTokenUsed)
+
+	tokens[created[1].ID].ExpiresAt = time.Now().Add(-time.Second)
+	_, err = enrollment.Enroll(ctx, &domain.EnrollAgentRequest{Token: created[1].Token, Name: "gpu-01"})
+	assert.ErrorIs(t, err, domain.ErrEnrollmentTokenExpired)
+
+	_, err = enrollment.Enroll(ctx, &domain.EnrollAgentRequest{Token: "not-a-token", Name: "gpu-01"})
+	assert.ErrorIs(t, err, domain.ErrEnrollmentTokenInvalid)
+}
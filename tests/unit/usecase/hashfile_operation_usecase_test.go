@@ -0,0 +1,291 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryHashFileRepository keeps hash files in memory; operations run in
+// the background, so it is safe for concurrent use
+type memoryHashFileRepository struct {
+	mu    sync.Mutex
+	files map[uuid.UUID]domain.HashFile
+}
+
+func (r *memoryHashFileRepository) Create(ctx context.Context, hashFile *domain.HashFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[hashFile.ID] = *hashFile
+	return nil
+}
+
+func (r *memoryHashFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.HashFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hashFile, ok := r.files[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "hash file"}
+	}
+	return &hashFile, nil
+}
+
+func (r *memoryHashFileRepository) GetBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, hashFile := range r.files {
+		if hashFile.SHA256 == sum {
+			return &hashFile, nil
+		}
+	}
+	return nil, &domain.NotFoundError{Entity: "hash file"}
+}
+
+func (r *memoryHashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hashFiles := []domain.HashFile{}
+	for _, hashFile := range r.files {
+		hashFiles = append(hashFiles, hashFile)
+	}
+	return hashFiles, nil
+}
+
+func (r *memoryHashFileRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	return nil, nil
+}
+
+func (r *memoryHashFileRepository) SetSource(ctx context.Context, id uuid.UUID, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hashFile, ok := r.files[id]
+	if !ok {
+		return &domain.NotFoundError{Entity: "hash file"}
+	}
+	hashFile.Source = source
+	r.files[id] = hashFile
+	return nil
+}
+
+func (r *memoryHashFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.files, id)
+	return nil
+}
+
+// memoryHashFileOperationRepository keeps hash file operations in memory
+type memoryHashFileOperationRepository struct {
+	mu         sync.Mutex
+	operations map[uuid.UUID]domain.HashFileOperation
+}
+
+func (r *memoryHashFileOperationRepository) Create(ctx context.Context, operation *domain.HashFileOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	operation.ID = uuid.New()
+	r.operations[operation.ID] = *operation
+	return nil
+}
+
+func (r *memoryHashFileOperationRepository) Update(ctx context.Context, operation *domain.HashFileOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := *operation
+	saved.ResultIDs = append([]uuid.UUID(nil), operation.ResultIDs...)
+	r.operations[operation.ID] = saved
+	return nil
+}
+
+func (r *memoryHashFileOperationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.HashFileOperation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	operation, ok := r.operations[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "hash file operation"}
+	}
+	return &operation, nil
+}
+
+func (r *memoryHashFileOperationRepository) GetRecent(ctx context.Context, limit int) ([]domain.HashFileOperation, error) {
+	return nil, nil
+}
+
+func (r *memoryHashFileOperationRepository) FailUnfinished(ctx context.Context, message string) (int, error) {
+	return 0, nil
+}
+
+// hashFileOperationFixture uploads hash lists and runs operations on them
+type hashFileOperationFixture struct {
+	t          *testing.T
+	hashFiles  usecase.HashFileUsecase
+	fileRepo   *memoryHashFileRepository
+	operations usecase.HashFileOperationUsecase
+}
+
+func newHashFileOperationFixture(t *testing.T) *hashFileOperationFixture {
+	fileRepo := &memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}
+	hashFiles := usecase.NewHashFileUsecase(fileRepo, t.TempDir())
+	operations := usecase.NewHashFileOperationUsecase(&memoryHashFileOperationRepository{operations: map[uuid.UUID]domain.HashFileOperation{}}, hashFiles, 1)
+	t.Cleanup(operations.Stop)
+	return &hashFileOperationFixture{t: t, hashFiles: hashFiles, fileRepo: fileRepo, operations: operations}
+}
+
+func (f *hashFileOperationFixture) upload(name, content string) *domain.HashFile {
+	hashFile, err := f.hashFiles.UploadHashFile(context.Background(), name, strings.NewReader(content), int64(len(content)), nil)
+	require.NoError(f.t, err)
+	return hashFile
+}
+
+// wait returns the operation once it has finished
+func (f *hashFileOperationFixture) wait(operation *domain.HashFileOperation) *domain.HashFileOperation {
+	var finished *domain.HashFileOperation
+	require.Eventually(f.t, func() bool {
+		got, err := f.operations.GetOperation(context.Background(), operation.ID)
+		require.NoError(f.t, err)
+		finished = got
+		return got.Status == domain.HashFileOperationCompleted || got.Status == domain.HashFileOperationFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return finished
+}
+
+// result returns a result hash file and its content
+func (f *hashFileOperationFixture) result(id uuid.UUID) (*domain.HashFile, string) {
+	hashFile, err := f.fileRepo.GetByID(context.Background(), id)
+	require.NoError(f.t, err)
+	content, err := os.ReadFile(hashFile.Path)
+	require.NoError(f.t, err)
+	return hashFile, string(content)
+}
+
+func TestHashFileOperationUsecase_Merge(t *testing.T) {
+	f := newHashFileOperationFixture(t)
+	january := f.upload("january.txt", "aaa\nbbb\r\n\nccc\n")
+	february := f.upload("february.txt", "ccc\nddd\naaa")
+
+	operation, err := f.operations.MergeHashFiles(context.Background(), &domain.MergeHashFilesRequest{
+		HashFileIDs: []uuid.UUID{january.ID, february.ID},
+		Name:        "q1.txt",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.HashFileOperationPending, operation.Status)
+
+	operation = f.wait(operation)
+	require.Equal(t, domain.HashFileOperationCompleted, operation.Status, operation.Error)
+	assert.Equal(t, float64(100), operation.Progress)
+	assert.Equal(t, map[string]int64{"lines_read": 6, "lines_written": 4, "duplicates": 2}, operation.Counts)
+	require.Len(t, operation.ResultIDs, 1)
+
+	merged, content := f.result(operation.ResultIDs[0])
+	assert.Equal(t, "q1.txt", merged.OrigName)
+	assert.Equal(t, "hash", merged.Type)
+	assert.Equal(t, "aaa\nbbb\nccc\nddd\n", content)
+}
+
+func TestHashFileOperationUsecase_Split(t *testing.T) {
+	f := newHashFileOperationFixture(t)
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, uuid.NewString())
+	}
+	dump := f.upload("dump.hash", strings.Join(lines, "\n")+"\n")
+
+	operation, err := f.operations.SplitHashFile(context.Background(), dump.ID, &domain.SplitHashFileRequest{Parts: 3})
+	require.NoError(t, err)
+
+	operation = f.wait(operation)
+	require.Equal(t, domain.HashFileOperationCompleted, operation.Status, operation.Error)
+	assert.Equal(t, map[string]int64{"lines": 10, "parts": 3}, operation.Counts)
+	require.Len(t, operation.ResultIDs, 3)
+
+	var joined []string
+	for i, id := range operation.ResultIDs {
+		part, content := f.result(id)
+		assert.Equal(t, []string{"dump.part1of3.hash", "dump.part2of3.hash", "dump.part3of3.hash"}[i], part.OrigName)
+		partLines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		assert.InDelta(t, 10/3, len(partLines), 1)
+		joined = append(joined, partLines...)
+	}
+	assert.Equal(t, lines, joined, "the parts hold every line in order")
+}
+
+func TestHashFileOperationUsecase_SplitFewerLinesThanParts(t *testing.T) {
+	f := newHashFileOperationFixture(t)
+	small := f.upload("small.txt", "aaa\nbbb\n")
+
+	operation, err := f.operations.SplitHashFile(context.Background(), small.ID, &domain.SplitHashFileRequest{Parts: 3})
+	require.NoError(t, err)
+
+	operation = f.wait(operation)
+	assert.Equal(t, domain.HashFileOperationFailed, operation.Status)
+	assert.Contains(t, operation.Error, "fewer than the 3 parts")
+	assert.Empty(t, operation.ResultIDs)
+}
+
+func TestHashFileOperationUsecase_Diff(t *testing.T) {
+	f := newHashFileOperationFixture(t)
+	lastMonth := f.upload("ad-september.txt", "alice:hash1\nbob:hash2\ncarol:hash3\n")
+	thisMonth := f.upload("ad-october.txt", "alice:hash1\ncarol:hash4\ndave:hash5\ndave:hash5\n")
+
+	operation, err := f.operations.DiffHashFiles(context.Background(), &domain.DiffHashFilesRequest{BaseID: lastMonth.ID, CompareID: thisMonth.ID})
+	require.NoError(t, err)
+
+	operation = f.wait(operation)
+	require.Equal(t, domain.HashFileOperationCompleted, operation.Status, operation.Error)
+	assert.Equal(t, map[string]int64{"added": 2, "removed": 2, "unchanged": 1}, operation.Counts)
+	require.Len(t, operation.ResultIDs, 2)
+
+	added, content := f.result(operation.ResultIDs[0])
+	assert.Equal(t, "ad-october.added.txt", added.OrigName)
+	assert.Equal(t, "carol:hash4\ndave:hash5\n", content)
+
+	removed, content := f.result(operation.ResultIDs[1])
+	assert.Equal(t, "ad-september.removed.txt", removed.OrigName)
+	assert.Equal(t, "bob:hash2\ncarol:hash3\n", content)
+
+	// Nothing added makes no "added" file
+	subset := f.upload("subset.txt", "alice:hash1\ncarol:hash4\n")
+	operation, err = f.operations.DiffHashFiles(context.Background(), &domain.DiffHashFilesRequest{BaseID: thisMonth.ID, CompareID: subset.ID})
+	require.NoError(t, err)
+	operation = f.wait(operation)
+	require.Equal(t, domain.HashFileOperationCompleted, operation.Status, operation.Error)
+	require.Len(t, operation.ResultIDs, 1)
+	removed, content = f.result(operation.ResultIDs[0])
+	assert.Equal(t, "ad-october.removed.txt", removed.OrigName)
+	assert.Equal(t, "dave:hash5\n", content)
+}
+
+func TestHashFileOperationUsecase_Validation(t *testing.T) {
+	f := newHashFileOperationFixture(t)
+	list := f.upload("list.txt", "aaa\n")
+	capture := f.upload("wifi.hccapx", "HCPX")
+	ctx := context.Background()
+
+	_, err := f.operations.MergeHashFiles(ctx, &domain.MergeHashFilesRequest{HashFileIDs: []uuid.UUID{list.ID}})
+	assert.True(t, domain.IsValidationError(err), "a merge needs two files")
+
+	_, err = f.operations.MergeHashFiles(ctx, &domain.MergeHashFilesRequest{HashFileIDs: []uuid.UUID{list.ID, list.ID}})
+	assert.True(t, domain.IsValidationError(err), "files are merged once")
+
+	_, err = f.operations.MergeHashFiles(ctx, &domain.MergeHashFilesRequest{HashFileIDs: []uuid.UUID{list.ID, capture.ID}})
+	assert.True(t, domain.IsValidationError(err), "captures aren't lists")
+
+	_, err = f.operations.MergeHashFiles(ctx, &domain.MergeHashFilesRequest{HashFileIDs: []uuid.UUID{list.ID, uuid.New()}})
+	assert.True(t, domain.IsNotFoundError(err))
+
+	_, err = f.operations.SplitHashFile(ctx, list.ID, &domain.SplitHashFileRequest{Parts: domain.MaxSplitParts + 1})
+	assert.True(t, domain.IsValidationError(err))
+
+	_, err = f.operations.DiffHashFiles(ctx, &domain.DiffHashFilesRequest{BaseID: list.ID, CompareID: list.ID})
+	assert.True(t, domain.IsValidationError(err), "a file isn't diffed with itself")
+}
@@ -3,15 +3,19 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
 	"strings"
 	"testing"
 
 	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/infrastructure"
 	"go-distributed-hashcat/internal/usecase"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHashFileUsecase_UploadHashFile(t *testing.T) {
@@ -27,6 +31,7 @@ func TestHashFileUsecase_UploadHashFile(t *testing.T) {
 			filename:    "test.hash",
 			fileContent: "5d41402abc4b2a76b9719d911017c592\n8b1a9953c4611296a827abf8c47804d7",
 			mockSetup: func(repo *MockHashFileRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("hash file not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.HashFile")).Return(nil)
 			},
 			expectedError: false,
@@ -36,6 +41,7 @@ func TestHashFileUsecase_UploadHashFile(t *testing.T) {
 			filename:    "test.hash",
 			fileContent: "5d41402abc4b2a76b9719d911017c592",
 			mockSetup: func(repo *MockHashFileRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("hash file not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.HashFile")).Return(errors.New("database error"))
 			},
 			expectedError: true,
@@ -45,6 +51,7 @@ func TestHashFileUsecase_UploadHashFile(t *testing.T) {
 			filename:    "empty.hash",
 			fileContent: "",
 			mockSetup: func(repo *MockHashFileRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("hash file not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.HashFile")).Return(nil)
 			},
 			expectedError: false,
@@ -59,7 +66,7 @@ func TestHashFileUsecase_UploadHashFile(t *testing.T) {
 			usecase := usecase.NewHashFileUsecase(mockRepo, "/tmp/uploads")
 			ctx := context.Background()
 
-			hashFile, err := usecase.UploadHashFile(ctx, tt.filename, strings.NewReader(tt.fileContent), int64(len(tt.fileContent)))
+			hashFile, err := usecase.UploadHashFile(ctx, tt.filename, strings.NewReader(tt.fileContent), int64(len(tt.fileContent)), nil)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -76,6 +83,130 @@ func TestHashFileUsecase_UploadHashFile(t *testing.T) {
 	}
 }
 
+func TestHashFileUsecase_UploadHashFile_DuplicateInOtherProject(t *testing.T) {
+	dir := t.TempDir()
+	existingPath := dir + "/existing.hash"
+	require.NoError(t, os.WriteFile(existingPath, []byte("hash"), 0644))
+	projectID := uuid.New()
+
+	repo := new(MockHashFileRepository)
+	repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(&domain.HashFile{ID: uuid.New(), Path: existingPath}, nil)
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(h *domain.HashFile) bool {
+		return h.ProjectID != nil && *h.ProjectID == projectID
+	})).Return(nil)
+
+	// The same content in another project gets its own record
+	hashFile, err := usecase.NewHashFileUsecase(repo, dir).UploadHashFile(context.Background(), "copy.hash", strings.NewReader("hash"), 4, &projectID)
+	require.NoError(t, err)
+	assert.False(t, hashFile.Duplicate)
+	assert.NotEqual(t, existingPath, hashFile.Path)
+	repo.AssertExpectations(t)
+}
+
+func TestHashFileUsecase_EncryptionAtRest(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cipher, err := infrastructure.NewFileCipher("storage-secret")
+	require.NoError(t, err)
+
+	// Larger than one chunk, so the file is sealed in several
+	content := strings.Repeat("5d41402abc4b2a76b9719d911017c592:admin@example.com\n", 3000)
+	var stored *domain.HashFile
+	repo := new(MockHashFileRepository)
+	repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("hash file not found"))
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.HashFile")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.HashFile)
+	}).Return(nil)
+
+	hashFiles := usecase.NewHashFileUsecase(repo, dir)
+	hashFiles.SetFileEncryptor(cipher)
+	hashFile, err := hashFiles.UploadHashFile(ctx, "clients.hash", strings.NewReader(content), int64(len(content)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), hashFile.Size, "the size is of the plaintext")
+
+	onDisk, err := os.ReadFile(hashFile.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(onDisk), "admin@example.com")
+
+	repo.On("GetByID", mock.Anything, hashFile.ID).Return(stored, nil)
+	_, reader, err := hashFiles.OpenHashFile(ctx, hashFile.ID)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(reader)
+	require.NoError(t, reader.Close())
+	require.NoError(t, err)
+	assert.Equal(t, content, string(decrypted))
+
+	// A truncated file doesn't pass for a shorter one
+	require.NoError(t, os.WriteFile(hashFile.Path, onDisk[:len(onDisk)-100], 0644))
+	_, reader, err = hashFiles.OpenHashFile(ctx, hashFile.ID)
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	reader.Close()
+	assert.Error(t, err)
+
+	// Nor does the wrong key read it
+	require.NoError(t, os.WriteFile(hashFile.Path, onDisk, 0644))
+	wrong, err := infrastructure.NewFileCipher("another-secret")
+	require.NoError(t, err)
+	hashFiles.SetFileEncryptor(wrong)
+	_, reader, err = hashFiles.OpenHashFile(ctx, hashFile.ID)
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	reader.Close()
+	assert.Error(t, err)
+}
+
+func TestHashFileUsecase_EncryptStoredHashFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	plainPath := dir + "/plain.hash"
+	require.NoError(t, os.WriteFile(plainPath, []byte("8b1a9953c4611296a827abf8c47804d7\n"), 0600))
+	plain := domain.HashFile{ID: uuid.New(), Path: plainPath}
+	missing := domain.HashFile{ID: uuid.New(), Path: dir + "/gone.hash"}
+
+	repo := new(MockHashFileRepository)
+	repo.On("GetAll", mock.Anything).Return([]domain.HashFile{plain, missing}, nil)
+	repo.On("GetByID", mock.Anything, plain.ID).Return(&plain, nil)
+
+	hashFiles := usecase.NewHashFileUsecase(repo, dir)
+	_, err := hashFiles.EncryptStoredHashFiles(ctx)
+	assert.Error(t, err, "nothing to encrypt with")
+
+	cipher, err := infrastructure.NewFileCipher("storage-secret")
+	require.NoError(t, err)
+	hashFiles.SetFileEncryptor(cipher)
+
+	// Files stored before encryption was turned on are still served
+	_, reader, err := hashFiles.OpenHashFile(ctx, plain.ID)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "8b1a9953c4611296a827abf8c47804d7\n", string(content))
+
+	encrypted, err := hashFiles.EncryptStoredHashFiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, encrypted)
+	onDisk, err := os.ReadFile(plainPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(onDisk), "8b1a9953")
+	info, err := os.Stat(plainPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Running it again leaves encrypted files alone
+	encrypted, err = hashFiles.EncryptStoredHashFiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, encrypted)
+
+	_, reader, err = hashFiles.OpenHashFile(ctx, plain.ID)
+	require.NoError(t, err)
+	content, err = io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "8b1a9953c4611296a827abf8c47804d7\n", string(content))
+}
+
 func TestHashFileUsecase_GetHashFile(t *testing.T) {
 	hashFileID := uuid.New()
 	expectedHashFile := &domain.HashFile{
@@ -0,0 +1,176 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHashtopolisAttack(t *testing.T) {
+	attack, err := domain.ParseHashtopolisAttack("#HL# -a 0 -w 3 -O rockyou.txt -r best64.rule --kernel-accel 64")
+	require.NoError(t, err)
+	assert.Equal(t, domain.AttackModeStraight, attack.AttackMode)
+	assert.Equal(t, []string{"rockyou.txt"}, attack.Wordlists)
+	assert.Equal(t, []string{"best64.rule"}, attack.RuleFiles)
+	assert.Equal(t, []string{"-O", "--kernel-accel=64"}, attack.ExtraArgs)
+	assert.Equal(t, []string{"-w 3"}, attack.Ignored)
+
+	attack, err = domain.ParseHashtopolisAttack("#HL# -a3 -1 ?l?d ?1?1?1?1?d?d --increment")
+	require.NoError(t, err)
+	assert.Equal(t, domain.AttackModeBruteForce, attack.AttackMode)
+	assert.Equal(t, "?1?1?1?1?d?d", attack.Mask)
+	assert.Equal(t, []string{"?l?d"}, attack.CustomCharsets)
+	assert.Equal(t, []string{"--increment"}, attack.Ignored)
+
+	attack, err = domain.ParseHashtopolisAttack("#HL# --attack-mode=1 left.txt right.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"left.txt", "right.txt"}, attack.Wordlists)
+
+	_, err = domain.ParseHashtopolisAttack("#HL# -a 6 rockyou.txt ?d?d")
+	assert.Error(t, err)
+	_, err = domain.ParseHashtopolisAttack("#HL# -a 0")
+	assert.Error(t, err)
+}
+
+func TestHashtopolisExport_Flags(t *testing.T) {
+	var export domain.HashtopolisExport
+	require.NoError(t, json.Unmarshal([]byte(`{"hashes": [{"isCracked": 1}, {"isCracked": false}, {"isCracked": "1"}]}`), &export))
+	assert.True(t, bool(export.Hashes[0].IsCracked))
+	assert.False(t, bool(export.Hashes[1].IsCracked))
+	assert.True(t, bool(export.Hashes[2].IsCracked))
+	assert.Error(t, json.Unmarshal([]byte(`{"hashes": [{"isCracked": 2}]}`), &export))
+}
+
+func TestHashtopolisUsecase_Import(t *testing.T) {
+	ctx := context.Background()
+	uploadDir := t.TempDir()
+
+	rockyou := domain.Wordlist{ID: uuid.New(), Name: "rockyou.txt", OrigName: "rockyou.txt"}
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetAll", ctx).Return([]domain.Wordlist{rockyou}, nil)
+	projectRepo := new(MockProjectRepository)
+	projectRepo.On("GetByName", ctx, "Migrated").Return(nil, &domain.NotFoundError{Entity: "project"})
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByName", ctx, "rig-1").Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("GetByName", ctx, "rig-2").Return(&domain.Agent{Name: "rig-2"}, nil)
+	agentRepo.On("GetByAgentKey", ctx, "Xd8hLk2pQz").Return(nil, domain.ErrAgentNotFound)
+	var agent *domain.Agent
+	agentRepo.On("Create", ctx, mock.AnythingOfType("*domain.Agent")).Run(func(args mock.Arguments) {
+		agent = args.Get(1).(*domain.Agent)
+	}).Return(nil)
+	agentRepo.On("GetByID", ctx, mock.Anything).Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("UpdateAgent", ctx, mock.AnythingOfType("*domain.Agent")).Return(nil)
+	archiveRepo := &memoryArchiveRepository{}
+	hashFiles := usecase.NewHashFileUsecase(&memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}, uploadDir)
+
+	hashtopolis := usecase.NewHashtopolisUsecase(projectRepo, archiveRepo, hashFiles, usecase.NewWordlistUsecase(wordlistRepo, uploadDir),
+		usecase.NewAgentUsecase(agentRepo), nil)
+	result, err := hashtopolis.Import(ctx, "Migrated", &domain.HashtopolisExport{
+		Hashlists: []domain.HashtopolisHashlist{
+			{HashlistID: 1, HashlistName: "corp/ntlm", Format: domain.HashtopolisFormatText, HashTypeID: 1000},
+			{HashlistID: 2, HashlistName: "salted", Format: domain.HashtopolisFormatText, HashTypeID: 10, IsSalted: true, SaltSeparator: ":"},
+			{HashlistID: 3, HashlistName: "wifi", Format: domain.HashtopolisFormatWPA, HashTypeID: 22000},
+		},
+		Hashes: []domain.HashtopolisHash{
+			{HashlistID: 1, Hash: "8846f7eaee8fb117ad06bdd830b7586c", Plaintext: "password", IsCracked: true, TimeCracked: 1760000000},
+			{HashlistID: 1, Hash: "32ed87bdb5fdc5e9cba88547376818d4"},
+			{HashlistID: 2, Hash: "3d3c9d3e4a1ba3c2b8ffb3e1c1cbd3a0", Salt: "pepper"},
+		},
+		Tasks: []domain.HashtopolisTask{
+			{TaskID: 1, TaskName: "rockyou", AttackCmd: "#HL# -a 0 rockyou.txt", HashlistID: 1, Keyspace: 100, KeyspaceProgress: 100},
+			{TaskID: 2, TaskName: "digits", AttackCmd: "#HL# -a 3 ?d?d?d?d?d?d", HashlistID: 1, Keyspace: 1000000, KeyspaceProgress: 250000},
+			{TaskID: 3, TaskName: "corp rules", AttackCmd: "#HL# rockyou.txt -r corp.rule", HashlistID: 2, Keyspace: 100},
+			{TaskID: 4, TaskName: "hybrid", AttackCmd: "#HL# -a 6 rockyou.txt ?d", HashlistID: 1, Keyspace: 100},
+			{TaskID: 5, TaskName: "wpa", AttackCmd: "#HL# -a 0 rockyou.txt", HashlistID: 3},
+		},
+		Agents: []domain.HashtopolisAgent{
+			{AgentID: 1, AgentName: "rig-1", Devices: "NVIDIA RTX 4090\nNVIDIA RTX 4090\n", Token: "Xd8hLk2pQz", LastTime: 1760000000},
+			{AgentID: 2, AgentName: "rig-2", Token: "Qw3rTy"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Migrated", result.Project.Name)
+	assert.Equal(t, 2, result.HashFiles)
+	assert.Equal(t, 1, result.Credentials)
+	assert.Equal(t, 4, result.Jobs)
+	assert.Equal(t, 1, result.PausedJobs)
+	assert.Equal(t, 2, result.CancelledJobs)
+	assert.Equal(t, 1, result.Agents)
+	assert.Equal(t, []string{"corp.rule"}, result.MissingFiles)
+	assert.Len(t, result.Skipped, 3) // wifi hashlist, its task and rig-2
+
+	imported := archiveRepo.imported
+	require.NotNil(t, imported)
+	statuses := map[string]string{}
+	for _, job := range imported.Jobs {
+		statuses[job.Name] = job.Status
+		assert.Equal(t, []string{"hashtopolis"}, job.Tags)
+		assert.Equal(t, result.Project.ID, *job.ProjectID)
+	}
+	assert.Equal(t, map[string]string{
+		"rockyou":    domain.JobStatusCompleted,
+		"digits":     domain.JobStatusPaused,
+		"corp rules": domain.JobStatusCancelled,
+		"hybrid":     domain.JobStatusCancelled,
+	}, statuses)
+	assert.Equal(t, rockyou.ID, *imported.Jobs[0].WordlistID)
+	assert.Equal(t, "?d?d?d?d?d?d", imported.Jobs[1].Wordlist)
+	assert.InDelta(t, 25, imported.Jobs[1].Progress, 0.01)
+	assert.Len(t, imported.JobComments, 4)
+
+	// Salted hashes are written the way hashcat reads them
+	credential := imported.Credentials[0]
+	assert.Equal(t, domain.CredentialSourceHashtopolis, credential.Source)
+	assert.Equal(t, int64(1760000000), credential.CrackedAt.Unix())
+	_, content, err := hashFiles.OpenHashFile(ctx, *imported.Jobs[2].HashFileID)
+	require.NoError(t, err)
+	lines, err := io.ReadAll(content)
+	content.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "3d3c9d3e4a1ba3c2b8ffb3e1c1cbd3a0:pepper\n", string(lines))
+
+	// The agent logs in with its Hashtopolis token
+	require.NotNil(t, agent)
+	assert.Equal(t, "Xd8hLk2pQz", agent.AgentKey)
+	assert.Equal(t, "offline", agent.Status)
+	assert.Equal(t, "NVIDIA RTX 4090; NVIDIA RTX 4090", agent.Capabilities)
+
+	t.Run("refuses a taken project name", func(t *testing.T) {
+		projectRepo.On("GetByName", ctx, "Taken").Return(&domain.Project{Name: "Taken"}, nil)
+		_, err := hashtopolis.Import(ctx, "Taken", &domain.HashtopolisExport{})
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
+
+func TestHashtopolisUsecase_AgentLogin(t *testing.T) {
+	ctx := context.Background()
+	agent := &domain.Agent{ID: uuid.New(), Name: "rig-1", AgentKey: "Xd8hLk2pQz", Status: "offline"}
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByAgentKey", ctx, "Xd8hLk2pQz").Return(agent, nil)
+	agentRepo.On("GetByAgentKey", ctx, "unknown").Return(nil, domain.ErrAgentNotFound)
+	agentRepo.On("UpdateLastSeen", ctx, agent.ID).Return(nil)
+	agentRepo.On("GetByID", ctx, agent.ID).Return(agent, nil)
+	agentRepo.On("UpdateAgent", ctx, agent).Return(nil)
+
+	hashtopolis := usecase.NewHashtopolisUsecase(nil, nil, nil, nil, usecase.NewAgentUsecase(agentRepo), nil)
+
+	got, err := hashtopolis.LoginAgent(ctx, "Xd8hLk2pQz")
+	require.NoError(t, err)
+	assert.Equal(t, agent.ID, got.ID)
+	_, err = hashtopolis.LoginAgent(ctx, "unknown")
+	assert.True(t, domain.IsNotFoundError(err))
+
+	require.NoError(t, hashtopolis.UpdateAgentDevices(ctx, "Xd8hLk2pQz", []string{"NVIDIA RTX 4090", ""}))
+	assert.Equal(t, "NVIDIA RTX 4090", agent.Capabilities)
+	assert.Equal(t, "offline", agent.Status)
+}
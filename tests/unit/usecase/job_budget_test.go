@@ -0,0 +1,150 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const budgetPlaybook = `
+version: v1
+name: acme-budget
+budget_gpu_hours: 10
+defaults:
+  hash_file: acme-ntlm.txt
+  hash_type: 1000
+attacks:
+  - name: rockyou
+    wordlist: rockyou.txt
+  - name: eight-chars
+    mask: "?a?a?a?a?a?a?a?a"
+    budget_gpu_hours: 6
+  - name: six-digits
+    mask: "?d?d?d?d?d?d"
+pipeline: [six-digits, rockyou, eight-chars]
+`
+
+func TestPipelineBudget(t *testing.T) {
+	playbook, err := domain.ParseJobPlaybook([]byte(budgetPlaybook))
+	require.NoError(t, err)
+
+	budget := playbook.PlanBudget()
+	require.NotNil(t, budget)
+	assert.Equal(t, 10.0, budget.GPUHours)
+	assert.Equal(t, []domain.BudgetStage{
+		{Name: "six-digits", GPUHours: 2},
+		{Name: "rockyou", GPUHours: 2},
+		{Name: "eight-chars", GPUHours: 6},
+	}, budget.Stages, "pipeline order, the rest split equally")
+
+	t.Run("report", func(t *testing.T) {
+		jobs := []domain.Job{
+			// Finished with half its slice left over
+			{Status: domain.JobStatusCompleted, BudgetStage: "six-digits", DeviceSeconds: 3600},
+			// Split across two agents
+			{Status: domain.JobStatusRunning, BudgetStage: "rockyou", DeviceSeconds: 3600},
+			{Status: domain.JobStatusRunning, BudgetStage: "rockyou", DeviceSeconds: 5400},
+			{Status: domain.JobStatusPending, BudgetStage: "eight-chars"},
+		}
+		report := budget.Report(jobs)
+		assert.InDelta(t, 3.5, report.UsedGPUHours, 0.001)
+		assert.InDelta(t, 6.5, report.RemainingGPUHours, 0.001)
+		require.Len(t, report.Stages, 3)
+
+		assert.True(t, report.Stages[0].Finished)
+		assert.False(t, report.Stages[0].Exhausted)
+
+		rockyou := report.Stages[1]
+		assert.Equal(t, 2, rockyou.Jobs)
+		assert.InDelta(t, 3, rockyou.AllowedGPUHours, 0.001, "its slice plus what six-digits left")
+		assert.InDelta(t, 2.5, rockyou.UsedGPUHours, 0.001)
+		assert.False(t, rockyou.Exhausted)
+
+		jobs[2].DeviceSeconds = 7200
+		report = budget.Report(jobs)
+		assert.True(t, report.Stages[1].Exhausted)
+		assert.False(t, report.Stages[2].Exhausted)
+		assert.InDelta(t, 6, report.Stages[2].AllowedGPUHours, 0.001, "later stages keep their slice")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := domain.ParseJobPlaybook([]byte(`
+version: v1
+name: broken
+defaults:
+  hash_file: h
+  hash_type: 0
+  budget_gpu_hours: 1
+attacks:
+  - name: a
+    wordlist: w
+    budget_gpu_hours: 2
+`))
+		var pErr *domain.PlaybookError
+		require.ErrorAs(t, err, &pErr)
+		fields := make([]string, 0, len(pErr.Problems))
+		for _, problem := range pErr.Problems {
+			fields = append(fields, problem.Field)
+		}
+		assert.ElementsMatch(t, []string{"defaults.budget_gpu_hours", "attacks[0].budget_gpu_hours"}, fields)
+
+		_, err = domain.ParseJobPlaybook([]byte(`
+version: v1
+name: overcommitted
+budget_gpu_hours: 4
+defaults:
+  hash_file: h
+  hash_type: 0
+attacks:
+  - name: a
+    wordlist: w
+    budget_gpu_hours: 4
+  - name: b
+    mask: "?d?d?d?d"
+`))
+		require.ErrorAs(t, err, &pErr)
+		assert.Equal(t, "budget_gpu_hours", pErr.Problems[0].Field)
+		assert.Contains(t, pErr.Problems[0].Message, "nothing is left")
+	})
+}
+
+func TestJobUsecase_ExpireJobsOverBudget(t *testing.T) {
+	agentID := uuid.New()
+	group := domain.JobGroup{ID: uuid.New(), Name: "acme-budget", Budget: &domain.PipelineBudget{
+		GPUHours: 4,
+		Stages:   []domain.BudgetStage{{Name: "mask", GPUHours: 2}, {Name: "rockyou", GPUHours: 2}},
+	}}
+	overrun := domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, BudgetStage: "mask", AgentID: &agentID,
+		DeviceSeconds: 2.5 * 3600, Progress: 30}
+	queued := domain.Job{ID: uuid.New(), Status: domain.JobStatusPending, BudgetStage: "rockyou"}
+
+	jobRepo := new(MockJobRepository)
+	agentRepo := new(MockAgentRepository)
+	jobRepo.On("GetBudgetedGroups", mock.Anything).Return([]domain.JobGroup{group}, nil)
+	jobRepo.On("GetByGroupID", mock.Anything, group.ID).Return([]domain.Job{overrun, queued}, nil)
+	jobRepo.On("GetCracks", mock.Anything, overrun.ID).Return([]domain.JobCrack{{Hash: "a"}}, nil)
+	var expired *domain.Job
+	jobRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		expired = args.Get(1).(*domain.Job)
+	}).Return(nil).Once()
+	agentRepo.On("UpdateStatus", mock.Anything, agentID, "online").Return(nil).Once()
+
+	uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	count, err := uc.ExpireJobsOverBudget(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "the next stage keeps its slice")
+
+	require.NotNil(t, expired)
+	assert.Equal(t, overrun.ID, expired.ID)
+	assert.Equal(t, domain.JobStatusExpired, expired.Status)
+	assert.Equal(t, "Budget exhausted: 30.0% of the keyspace searched, 1 cracked", expired.Result)
+	jobRepo.AssertExpectations(t)
+	agentRepo.AssertExpectations(t)
+}
@@ -0,0 +1,154 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreFile builds a hashcat 6 restore file: a 296 byte header followed
+// by the command line, one argument per line
+func restoreFile(cwd string, wordsCur uint64, argv ...string) []byte {
+	data := make([]byte, 296)
+	binary.LittleEndian.PutUint32(data[0:], 620)
+	copy(data[4:260], cwd)
+	binary.LittleEndian.PutUint64(data[272:], wordsCur)
+	binary.LittleEndian.PutUint32(data[280:], uint32(len(argv)))
+	for _, arg := range argv {
+		data = append(data, arg+"\n"...)
+	}
+	return data
+}
+
+func TestHashcatRestore(t *testing.T) {
+	data := restoreFile("/home/old", 123456, "/usr/bin/hashcat", "-m", "0", "/home/old/uploads/hash.txt", "--restore-file-path", "/home/old/uploads/temp/hashcat-1.restore")
+
+	restore, err := domain.ParseHashcatRestore(data)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), restore.RestorePoint())
+	assert.Equal(t, "/home/old/uploads/hash.txt", restore.Argv[3])
+
+	rewritten, err := restore.Rewrite("/srv/agent", []string{"hashcat", "-m", "0", "/srv/agent/uploads/hash.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/agent\x00", string(rewritten[4:15]))
+	assert.Equal(t, data[:4], rewritten[:4], "the version is kept")
+
+	restore, err = domain.ParseHashcatRestore(rewritten)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), restore.RestorePoint())
+	assert.Equal(t, []string{"hashcat", "-m", "0", "/srv/agent/uploads/hash.txt"}, restore.Argv)
+
+	_, err = restore.Rewrite("/srv/agent", []string{"hashcat", "line\nbreak"})
+	assert.Error(t, err)
+
+	_, err = domain.ParseHashcatRestore([]byte("not a restore file"))
+	assert.True(t, domain.IsValidationError(err))
+	_, err = domain.ParseHashcatRestore(restoreFile("/home/old", 0))
+	assert.True(t, domain.IsValidationError(err), "no command line")
+}
+
+func TestJobCheckpoint_CheckResume(t *testing.T) {
+	checkpoint := &domain.JobCheckpoint{HashcatVersion: "v6.2.6"}
+	assert.NoError(t, checkpoint.CheckResume("v6.2.6"))
+	assert.NoError(t, checkpoint.CheckResume("v6.3.0"))
+	assert.NoError(t, checkpoint.CheckResume(""), "unknown versions get the benefit of the doubt")
+	assert.Error(t, checkpoint.CheckResume("v6.2.5"))
+	assert.Error(t, checkpoint.CheckResume("v7.0.0"))
+
+	assert.NoError(t, (&domain.JobCheckpoint{}).CheckResume("v5.1.0"))
+}
+
+func TestJobUsecase_InterruptJob(t *testing.T) {
+	agentID := uuid.New()
+	jobID := uuid.New()
+	data := restoreFile("/home/agent", 5000, "hashcat", "-m", "0")
+
+	newUsecase := func(status string) (usecase.JobUsecase, *MockJobRepository) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: status, AgentID: &agentID}, nil)
+		return usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository)), jobRepo
+	}
+
+	t.Run("stores the checkpoint and requeues the job", func(t *testing.T) {
+		uc, jobRepo := newUsecase(domain.JobStatusRunning)
+		jobRepo.On("SaveCheckpoint", mock.Anything, mock.MatchedBy(func(c *domain.JobCheckpoint) bool {
+			return c.JobID == jobID && *c.AgentID == agentID && c.HashcatVersion == "v6.2.6" && c.RestorePoint == 5000
+		})).Return(nil)
+		jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+			return job.Status == domain.JobStatusInterrupted && job.AgentID == nil
+		})).Return(nil)
+		// Reassignment finds nothing to hand out in this test
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+		err := uc.InterruptJob(context.Background(), jobID, &domain.InterruptJobRequest{Checkpoint: data, HashcatVersion: "v6.2.6"})
+		require.NoError(t, err)
+		jobRepo.AssertExpectations(t)
+		require.Len(t, jobRepo.events, 1)
+		assert.Equal(t, "agent shut down", jobRepo.events[0].Reason)
+	})
+
+	t.Run("without a checkpoint the job starts over", func(t *testing.T) {
+		uc, jobRepo := newUsecase(domain.JobStatusRunning)
+		jobRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		jobRepo.On("GetByStatus", mock.Anything, mock.Anything).Return([]domain.Job{}, nil)
+
+		require.NoError(t, uc.InterruptJob(context.Background(), jobID, &domain.InterruptJobRequest{Reason: "agent gpu-1 shut down"}))
+		jobRepo.AssertNotCalled(t, "SaveCheckpoint", mock.Anything, mock.Anything)
+		assert.Equal(t, "agent gpu-1 shut down", jobRepo.events[0].Reason)
+	})
+
+	t.Run("only running jobs", func(t *testing.T) {
+		uc, _ := newUsecase(domain.JobStatusCompleted)
+		err := uc.InterruptJob(context.Background(), jobID, &domain.InterruptJobRequest{Checkpoint: data})
+		assert.True(t, domain.IsJobTransitionError(err))
+	})
+
+	t.Run("invalid checkpoint", func(t *testing.T) {
+		uc, _ := newUsecase(domain.JobStatusRunning)
+		err := uc.InterruptJob(context.Background(), jobID, &domain.InterruptJobRequest{Checkpoint: []byte("garbage")})
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
+
+func TestJobUsecase_AssignInterruptedJob(t *testing.T) {
+	interruptedID, pendingID := uuid.New(), uuid.New()
+	oldAgent, newAgent := uuid.New(), uuid.New()
+
+	jobRepo := new(MockJobRepository)
+	agentRepo := new(MockAgentRepository)
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{{ID: pendingID, Status: domain.JobStatusPending}}, nil)
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{{ID: interruptedID, Status: domain.JobStatusInterrupted}}, nil)
+	jobRepo.On("GetCheckpoint", mock.Anything, interruptedID).Return(&domain.JobCheckpoint{JobID: interruptedID, HashcatVersion: "v6.2.6"}, nil)
+	expectIdleCluster(jobRepo)
+
+	// The faster agent's hashcat is too old to read the checkpoint
+	agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+		{ID: oldAgent, Name: "old", Status: "online", Speed: 9000, HashcatVersion: "v6.1.1"},
+		{ID: newAgent, Name: "new", Status: "online", Speed: 1000, HashcatVersion: "v6.2.6"},
+	}, nil)
+	agentRepo.On("GetHashcatArgs", mock.Anything, mock.Anything).Return([]string{}, nil)
+	agentRepo.On("GetSettings", mock.Anything, mock.Anything).Return(&domain.AgentSettings{}, nil)
+	agentRepo.On("UpdateStatus", mock.Anything, mock.Anything, "busy").Return(nil)
+
+	assigned := map[uuid.UUID]uuid.UUID{}
+	jobRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		job := args.Get(1).(*domain.Job)
+		assigned[job.ID] = *job.AgentID
+	}).Return(nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	require.NoError(t, uc.AssignJobsToAgents(context.Background()))
+
+	// The interrupted job goes first, to the agent that can resume it
+	assert.Equal(t, newAgent, assigned[interruptedID])
+	assert.Equal(t, oldAgent, assigned[pendingID])
+}
@@ -0,0 +1,86 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_CompareJobs(t *testing.T) {
+	hashFileID := uuid.New()
+	started := time.Now().Add(-3 * time.Hour)
+	oneHour, twoHours := started.Add(time.Hour), started.Add(2*time.Hour)
+
+	rockyou := &domain.Job{
+		ID: uuid.New(), Name: "rockyou", Status: domain.JobStatusCompleted, HashFileID: &hashFileID,
+		Wordlist: "rockyou.txt", TotalWords: 1000, ProcessedWords: 1000, Progress: 100,
+		CreatedAt: started, StartedAt: &started, CompletedAt: &twoHours, DeviceSeconds: 7200,
+	}
+	bestRules := &domain.Job{
+		ID: uuid.New(), Name: "rockyou + best64", Status: domain.JobStatusCompleted, HashFileID: &hashFileID,
+		Wordlist: "rockyou.txt", Rules: "best64.rule", Progress: 100,
+		CreatedAt: started, StartedAt: &started, CompletedAt: &oneHour, DeviceSeconds: 3600,
+		Stats: &domain.JobRuntimeStats{ProgressDone: 77000, ProgressTotal: 77000},
+	}
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetByID", mock.Anything, rockyou.ID).Return(rockyou, nil)
+	jobRepo.On("GetByID", mock.Anything, bestRules.ID).Return(bestRules, nil)
+	jobRepo.On("GetCracks", mock.Anything, rockyou.ID).Return([]domain.JobCrack{
+		{Hash: "a", CrackedAt: started.Add(time.Minute)},
+		{Hash: "b", CrackedAt: started.Add(time.Hour)},
+	}, nil)
+	jobRepo.On("GetCracks", mock.Anything, bestRules.ID).Return([]domain.JobCrack{
+		{Hash: "a", CrackedAt: started.Add(time.Minute)},
+		{Hash: "b", CrackedAt: started.Add(2 * time.Minute)},
+		{Hash: "c", CrackedAt: started.Add(3 * time.Minute)},
+	}, nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.SetCostRates(domain.CostRates{Currency: "EUR", DeviceHour: 1.5})
+
+	comparison, err := uc.CompareJobs(context.Background(), []uuid.UUID{rockyou.ID, bestRules.ID, rockyou.ID})
+	require.NoError(t, err)
+	require.Len(t, comparison.Jobs, 2, "repeated IDs count once")
+	assert.True(t, comparison.SameHashFile)
+	assert.Equal(t, "EUR", comparison.Currency)
+
+	plain, rules := comparison.Jobs[0], comparison.Jobs[1]
+	assert.Equal(t, rockyou.ID, plain.ID)
+	assert.InDelta(t, 7200, plain.DurationSeconds, 0.001)
+	assert.Equal(t, int64(1000), plain.KeyspaceTotal)
+	assert.Equal(t, 2, plain.Cracks)
+	assert.InDelta(t, 1, plain.CracksPerHour, 0.001)
+	assert.InDelta(t, 3, plain.Cost, 0.001)
+	require.NotNil(t, plain.CostPerCrack)
+	assert.InDelta(t, 1.5, *plain.CostPerCrack, 0.001)
+	require.NotNil(t, plain.TimeToFirstCrack)
+	assert.InDelta(t, 60, *plain.TimeToFirstCrack, 0.001)
+
+	assert.Equal(t, int64(77000), rules.KeyspaceTotal, "hashcat's candidates when it reported them")
+	assert.InDelta(t, 100, rules.KeyspaceCovered, 0.001)
+	assert.Equal(t, 3, rules.Cracks)
+	assert.Equal(t, &bestRules.ID, comparison.MostCracks)
+	assert.Equal(t, &bestRules.ID, comparison.BestCrackRate)
+	assert.Equal(t, &bestRules.ID, comparison.LowestCrackPrice)
+
+	t.Run("validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		_, err := uc.CompareJobs(context.Background(), []uuid.UUID{rockyou.ID, rockyou.ID})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "ids", validationErr.Field)
+
+		missing := uuid.New()
+		jobRepo.On("GetByID", mock.Anything, missing).Return(nil, assert.AnError)
+		_, err = uc.CompareJobs(context.Background(), []uuid.UUID{rockyou.ID, missing})
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
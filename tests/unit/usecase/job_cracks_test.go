@@ -0,0 +1,68 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_RecordJobCracks(t *testing.T) {
+	jobID, agentID := uuid.New(), uuid.New()
+	seenAt := time.Now().Add(-time.Minute)
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusRunning}, nil)
+	var stored []domain.JobCrack
+	jobRepo.On("CreateCracks", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]domain.JobCrack)
+	}).Return([]domain.JobCrack{{JobID: jobID, Hash: "5f4dcc3b5aa765d61d8327deb882cf99", Plaintext: "password"}}, nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	created, err := uc.RecordJobCracks(context.Background(), jobID, agentID, []domain.JobCrackReport{
+		{Hash: "5f4dcc3b5aa765d61d8327deb882cf99", Plaintext: "password", CrackedAt: seenAt},
+		// A clock ahead of the server's counts from now
+		{Hash: "0d107d09f5bbe40cade3de5c71e9e9b7", Plaintext: "letmein", CrackedAt: time.Now().Add(time.Hour)},
+	})
+	require.NoError(t, err)
+	assert.Len(t, created, 1)
+
+	require.Len(t, stored, 2)
+	assert.Equal(t, &agentID, stored[0].AgentID)
+	assert.WithinDuration(t, seenAt, stored[0].CrackedAt, time.Millisecond)
+	assert.WithinDuration(t, time.Now(), stored[1].CrackedAt, time.Second)
+
+	var validationErr *domain.ValidationError
+	_, err = uc.RecordJobCracks(context.Background(), jobID, agentID, nil)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "cracks", validationErr.Field)
+}
+
+func TestJobUsecase_GetJobCracks(t *testing.T) {
+	jobID := uuid.New()
+	createdAt := time.Now().Add(-time.Hour)
+	first := createdAt.Add(90 * time.Second)
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, CreatedAt: createdAt}, nil)
+	jobRepo.On("GetCracks", mock.Anything, jobID).Return([]domain.JobCrack{
+		{JobID: jobID, Hash: "a", CrackedAt: first},
+		{JobID: jobID, Hash: "b", CrackedAt: first.Add(time.Minute)},
+	}, nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	cracks, err := uc.GetJobCracks(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Len(t, cracks.Cracks, 2)
+	require.NotNil(t, cracks.FirstCrackAt)
+	assert.True(t, first.Equal(*cracks.FirstCrackAt))
+	require.NotNil(t, cracks.TimeToFirstCrack)
+	assert.InDelta(t, 90, *cracks.TimeToFirstCrack, 0.001)
+}
@@ -0,0 +1,90 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_ExpireJobsPastDeadline(t *testing.T) {
+	agentID := uuid.New()
+	justPassed := time.Now().Add(-10 * time.Second)
+	longPassed := time.Now().Add(-5 * time.Minute)
+	queued := domain.Job{ID: uuid.New(), Status: domain.JobStatusPending, Deadline: &justPassed}
+	// Its agent stops it at the deadline and gets a lease period to report
+	reporting := domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, AgentID: &agentID, Deadline: &justPassed}
+	silent := domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, AgentID: &agentID, Deadline: &longPassed,
+		Progress: 80, NormalizedProgress: 42.5}
+
+	jobRepo := new(MockJobRepository)
+	agentRepo := new(MockAgentRepository)
+	jobRepo.On("GetPastDeadline", mock.Anything, mock.Anything).Return([]domain.Job{queued, reporting, silent}, nil)
+	jobRepo.On("GetCracks", mock.Anything, queued.ID).Return([]domain.JobCrack{}, nil)
+	jobRepo.On("GetCracks", mock.Anything, silent.ID).Return([]domain.JobCrack{{Hash: "a"}, {Hash: "b"}}, nil)
+	expired := map[uuid.UUID]*domain.Job{}
+	jobRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		job := args.Get(1).(*domain.Job)
+		expired[job.ID] = job
+	}).Return(nil)
+	agentRepo.On("UpdateStatus", mock.Anything, agentID, "online").Return(nil).Once()
+
+	uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.SetJobLease(time.Minute)
+	count, err := uc.ExpireJobsPastDeadline(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.Contains(t, expired, queued.ID)
+	assert.Equal(t, domain.JobStatusExpired, expired[queued.ID].Status)
+	assert.NotContains(t, expired, reporting.ID)
+	require.Contains(t, expired, silent.ID)
+	assert.Equal(t, "Deadline reached: 42.5% of the keyspace searched, 2 cracked", expired[silent.ID].Result)
+	assert.Equal(t, 80.0, expired[silent.ID].Progress, "expired jobs keep their progress")
+	require.NotNil(t, expired[silent.ID].CompletedAt)
+	jobRepo.AssertExpectations(t)
+	agentRepo.AssertExpectations(t)
+}
+
+func TestJobUsecase_FailJobAtDeadline(t *testing.T) {
+	deadline := time.Now()
+	job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, Deadline: &deadline, Progress: 12}
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetByID", mock.Anything, job.ID).Return(job, nil)
+	jobRepo.On("GetCracks", mock.Anything, job.ID).Return([]domain.JobCrack{{Hash: "a"}}, nil)
+	jobRepo.On("Update", mock.Anything, job).Return(nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	require.NoError(t, uc.FailJob(context.Background(), job.ID, domain.JobDeadlineReachedReason))
+	assert.Equal(t, domain.JobStatusExpired, job.Status)
+	assert.Equal(t, "Deadline reached: 12.0% of the keyspace searched, 1 cracked", job.Result)
+}
+
+func TestJobDeadline(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(30*time.Second), now.Add(time.Hour)
+
+	var validationErr *domain.ValidationError
+	require.ErrorAs(t, domain.ValidateJobDeadline(&soon, now), &validationErr)
+	assert.Equal(t, "deadline", validationErr.Field)
+	assert.NoError(t, domain.ValidateJobDeadline(&later, now))
+	assert.NoError(t, domain.ValidateJobDeadline(nil, now))
+
+	job := &domain.Job{}
+	assert.True(t, job.CanStart(now), "no deadline")
+	job.Deadline = &soon
+	assert.False(t, job.CanStart(now), "too close to the deadline to get anything done")
+	job.Deadline = &later
+	assert.True(t, job.CanStart(now))
+	left, ok := job.TimeLeft(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, left)
+}
@@ -0,0 +1,143 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_EstimateJob(t *testing.T) {
+	hashFileID, wordlistID := uuid.New(), uuid.New()
+	fast, slow, fresh, offline := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	agents := []domain.Agent{
+		{ID: fast, Name: "fast", Status: "online", Speed: 1000},
+		{ID: slow, Name: "slow", Status: "online", Speed: 500},
+		{ID: fresh, Name: "fresh", Status: "online"},
+		{ID: offline, Name: "offline", Status: "offline", Speed: 9000},
+	}
+	words := int64(1_000_000)
+
+	newUsecase := func() (usecase.JobUsecase, *MockAgentRepository) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		hashFileRepo := new(MockHashFileRepository)
+		wordlistRepo := new(MockWordlistRepository)
+		hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID}, nil)
+		wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, WordCount: &words}, nil)
+		agentRepo.On("GetAll", mock.Anything).Return(agents, nil)
+		// The fast agent's history on the hash mode beats its benchmark
+		jobRepo.On("GetAgentSpeedsByHashType", mock.Anything, 1000).Return(map[uuid.UUID]int64{fast: 4000}, nil)
+		return usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo), agentRepo
+	}
+
+	t.Run("runs on the fastest online agent", func(t *testing.T) {
+		uc, _ := newUsecase()
+		estimate, err := uc.EstimateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", HashType: 1000, HashFileID: hashFileID.String(), Wordlist: wordlistID.String(), WordlistID: wordlistID.String(),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, words, estimate.Keyspace)
+		assert.False(t, estimate.Distributed)
+		assert.Equal(t, int64(4000), estimate.Speed)
+		assert.Equal(t, float64(250), estimate.DurationSeconds)
+		require.NotNil(t, estimate.EstimatedEnd)
+		assert.WithinDuration(t, time.Now().Add(250*time.Second), *estimate.EstimatedEnd, 5*time.Second)
+
+		require.Len(t, estimate.Agents, 2)
+		assert.Equal(t, domain.AgentEstimate{AgentID: fast, Name: "fast", Speed: 4000, SpeedSource: domain.SpeedSourceHashMode, DurationSeconds: 250}, estimate.Agents[0])
+		assert.Equal(t, domain.SpeedSourceBenchmark, estimate.Agents[1].SpeedSource)
+		assert.Contains(t, estimate.Note, "agents without speed data are left out (1)")
+	})
+
+	t.Run("benchmarks of the hash mode", func(t *testing.T) {
+		uc, _ := newUsecase()
+		// The fresh agent has no history, only a benchmark of the mode
+		benchmarks := new(MockAgentBenchmarkRepository)
+		benchmarks.On("GetSpeedsByHashType", mock.Anything, 1000).Return(map[uuid.UUID]int64{fast: 9000, fresh: 2000}, nil)
+		uc.SetBenchmarks(benchmarks)
+
+		estimate, err := uc.EstimateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", HashType: 1000, HashFileID: hashFileID.String(), Wordlist: wordlistID.String(), WordlistID: wordlistID.String(),
+		})
+		require.NoError(t, err)
+
+		require.Len(t, estimate.Agents, 3)
+		assert.Equal(t, domain.AgentEstimate{AgentID: fast, Name: "fast", Speed: 4000, SpeedSource: domain.SpeedSourceHashMode, DurationSeconds: 250}, estimate.Agents[0])
+		assert.Equal(t, domain.AgentEstimate{AgentID: fresh, Name: "fresh", Speed: 2000, SpeedSource: domain.SpeedSourceHashModeBenchmark, DurationSeconds: 500}, estimate.Agents[1])
+		assert.NotContains(t, estimate.Note, "agents without speed data")
+	})
+
+	t.Run("split across the chosen agents", func(t *testing.T) {
+		uc, agentRepo := newUsecase()
+		for _, agent := range agents {
+			agentRepo.On("GetByID", mock.Anything, agent.ID).Return(&agent, nil)
+		}
+		estimate, err := uc.EstimateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", HashType: 1000, AttackMode: domain.AttackModeBruteForce, HashFileID: hashFileID.String(), Wordlist: "?d?d?d?d?d?d",
+			AgentIDs: []string{fast.String(), slow.String(), offline.String()},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1_000_000), estimate.Keyspace)
+		assert.True(t, estimate.Distributed)
+		assert.Equal(t, int64(4500), estimate.Speed)
+		assert.InDelta(t, 222.2, estimate.DurationSeconds, 0.1)
+		assert.Contains(t, estimate.Note, "1 of the chosen agents can't take the job now")
+	})
+
+	t.Run("unknown keyspace", func(t *testing.T) {
+		uc, _ := newUsecase()
+		estimate, err := uc.EstimateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", HashType: 1000, HashFileID: hashFileID.String(), Wordlist: "rockyou.txt",
+		})
+		require.NoError(t, err)
+		assert.Zero(t, estimate.DurationSeconds)
+		assert.Nil(t, estimate.EstimatedEnd)
+		assert.Contains(t, estimate.Note, "keyspace of the attack is unknown")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		uc, _ := newUsecase()
+		_, err := uc.EstimateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", AttackMode: 9, HashFileID: hashFileID.String(), Wordlist: "rockyou.txt",
+		})
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
+
+func TestJobUsecase_EstimatePendingJob(t *testing.T) {
+	agentID := uuid.New()
+	total := int64(600_000)
+
+	t.Run("assigned job runs on its agent", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Name: "gpu", Status: "busy", Speed: 1000}, nil)
+		jobRepo.On("GetAgentSpeedsByHashType", mock.Anything, 0).Return(map[uuid.UUID]int64{}, nil)
+
+		uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+		estimate, err := uc.EstimatePendingJob(context.Background(), &domain.Job{
+			Status: domain.JobStatusAssigned, AgentID: &agentID, TotalWords: total, Rules: uuid.New().String(),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, estimate)
+		assert.Equal(t, float64(600), estimate.DurationSeconds)
+		assert.Contains(t, estimate.Note, "rules multiply the keyspace")
+	})
+
+	t.Run("started jobs have no estimate", func(t *testing.T) {
+		uc := usecase.NewJobUsecase(new(MockJobRepository), new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+		estimate, err := uc.EstimatePendingJob(context.Background(), &domain.Job{Status: domain.JobStatusRunning, TotalWords: total})
+		require.NoError(t, err)
+		assert.Nil(t, estimate)
+	})
+}
@@ -0,0 +1,76 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_ExpireJobLeases(t *testing.T) {
+	agentID := uuid.New()
+	runningID, assignedID, renewedID := uuid.New(), uuid.New(), uuid.New()
+
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("GetExpiredLeases", mock.Anything, mock.Anything).Return([]domain.Job{
+		{ID: runningID, Status: domain.JobStatusRunning, AgentID: &agentID},
+		{ID: assignedID, Status: domain.JobStatusAssigned, AgentID: &agentID},
+		{ID: renewedID, Status: domain.JobStatusRunning, AgentID: &agentID},
+	}, nil)
+	// Running jobs resume elsewhere, assigned ones go back to the queue
+	jobRepo.On("ExpireLease", mock.Anything, runningID, domain.JobStatusRunning, domain.JobStatusInterrupted, mock.Anything).Return(true, nil)
+	jobRepo.On("ExpireLease", mock.Anything, assignedID, domain.JobStatusAssigned, domain.JobStatusPending, mock.Anything).Return(true, nil)
+	// A heartbeat renewed this lease after the jobs were read
+	jobRepo.On("ExpireLease", mock.Anything, renewedID, domain.JobStatusRunning, domain.JobStatusInterrupted, mock.Anything).Return(false, nil)
+	// Reassignment finds nothing to hand out in this test
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusPending).Return([]domain.Job{}, nil)
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	expired, err := uc.ExpireJobLeases(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	require.Len(t, jobRepo.events, 2)
+	assert.Equal(t, runningID, jobRepo.events[0].JobID)
+	assert.Equal(t, domain.JobStatusInterrupted, jobRepo.events[0].ToStatus)
+	assert.Equal(t, "lease expired", jobRepo.events[0].Reason)
+	assert.Equal(t, assignedID, jobRepo.events[1].JobID)
+	jobRepo.AssertExpectations(t)
+	jobRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// recordingLeaser remembers the agents whose leases were renewed
+type recordingLeaser struct {
+	agents []uuid.UUID
+}
+
+func (l *recordingLeaser) RenewJobLeases(ctx context.Context, agentIDs []uuid.UUID) error {
+	l.agents = append(l.agents, agentIDs...)
+	return nil
+}
+
+func TestAgentUsecase_FlushRenewsJobLeases(t *testing.T) {
+	agentID := uuid.New()
+	mockRepo := new(MockAgentRepository)
+	leaser := &recordingLeaser{}
+	uc := usecase.NewAgentUsecase(mockRepo)
+	uc.SetJobLeaser(leaser)
+	ctx := context.Background()
+
+	mockRepo.On("UpdateLastSeenBatch", mock.Anything, mock.Anything).Return(nil)
+
+	// Nothing to renew without heartbeats
+	require.NoError(t, uc.FlushAgentHeartbeats(ctx))
+	assert.Empty(t, leaser.agents)
+
+	uc.AcceptAgentHeartbeat(ctx, agentID, 0, nil)
+	require.NoError(t, uc.FlushAgentHeartbeats(ctx))
+	assert.Equal(t, []uuid.UUID{agentID}, leaser.agents)
+}
@@ -0,0 +1,193 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const acmePlaybook = `
+version: v1
+name: acme-q3
+defaults:
+  hash_file: acme-ntlm.txt
+  hash_type: 1000
+  tags: [acme]
+attacks:
+  - name: rockyou-best64
+    wordlist: rockyou.txt
+    rules: best64.rule
+  - name: six-digits
+    mask: "?d?d?d?d?d?d"
+    agents:
+      group: gpu
+pipeline: [six-digits, rockyou-best64]
+`
+
+func TestParseJobPlaybook(t *testing.T) {
+	playbook, err := domain.ParseJobPlaybook([]byte(acmePlaybook))
+	require.NoError(t, err)
+
+	attacks := playbook.Ordered()
+	require.Len(t, attacks, 2)
+	assert.Equal(t, "six-digits", attacks[0].Name, "pipeline order")
+	assert.Equal(t, domain.AttackModeBruteForce, attacks[0].Mode())
+	assert.Empty(t, attacks[0].Wordlist, "a mask attack doesn't take the default wordlist")
+	assert.Equal(t, "acme-ntlm.txt", attacks[1].HashFile)
+	assert.Equal(t, 1000, *attacks[1].HashType)
+	assert.Equal(t, []string{"acme"}, attacks[1].Tags)
+	assert.Equal(t, domain.AttackModeStraight, attacks[1].Mode())
+
+	// JSON is read the same way
+	playbook, err = domain.ParseJobPlaybook([]byte(`{"version":"v1","name":"json","attacks":[{"name":"a","hash_file":"h","hash_type":0,"wordlist":"w","wordlist2":"w2"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.AttackModeCombinator, playbook.Ordered()[0].Mode())
+
+	_, err = domain.ParseJobPlaybook([]byte("version: v1\nname: typo\nattacks:\n  - name: a\n    wordlsit: rockyou.txt\n"))
+	assert.True(t, domain.IsValidationError(err))
+	assert.Contains(t, err.Error(), "wordlsit")
+
+	_, err = domain.ParseJobPlaybook([]byte(`
+version: v2
+attacks:
+  - name: a
+    hash_file: h
+  - name: a
+    hash_file: h
+    hash_type: 0
+    wordlist: w
+    mask: "?d"
+pipeline: [a, b]
+`))
+	var pErr *domain.PlaybookError
+	require.True(t, errors.As(err, &pErr))
+	assert.True(t, domain.IsValidationError(err))
+	fields := make([]string, len(pErr.Problems))
+	for i, problem := range pErr.Problems {
+		fields[i] = problem.Field
+	}
+	assert.Equal(t, []string{"version", "name", "attacks[0].hash_type", "attacks[0]", "attacks[1].name", "attacks[1].mask", "pipeline[1]"}, fields)
+}
+
+func TestJobUsecase_ApplyJobPlaybook(t *testing.T) {
+	hashFileID := uuid.New()
+	wordlistID := uuid.New()
+	gpuID := uuid.New()
+	project, otherProject := uuid.New(), uuid.New()
+
+	newUsecase := func() (usecase.JobUsecase, *MockJobRepository) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		hashFileRepo := new(MockHashFileRepository)
+		wordlistRepo := new(MockWordlistRepository)
+
+		hashFile := domain.HashFile{ID: hashFileID, Name: "3f2a.txt", OrigName: "acme-ntlm.txt", Path: "/uploads/3f2a.txt"}
+		hashFileRepo.On("GetAll", mock.Anything).Return([]domain.HashFile{
+			hashFile,
+			{ID: uuid.New(), OrigName: "acme-ntlm.txt", ProjectID: &otherProject},
+		}, nil)
+		hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&hashFile, nil)
+		wordlist := domain.Wordlist{ID: wordlistID, Name: "9c1d.txt", OrigName: "rockyou.txt"}
+		wordlistRepo.On("GetAll", mock.Anything).Return([]domain.Wordlist{wordlist}, nil)
+		wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&wordlist, nil)
+		agentRepo.On("GetAllGroups", mock.Anything).Return([]domain.AgentGroup{{ID: gpuID, Name: "gpu"}}, nil)
+		return usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo), jobRepo
+	}
+	ctx := context.Background()
+
+	t.Run("dry run resolves names without queuing", func(t *testing.T) {
+		uc, jobRepo := newUsecase()
+		playbook, err := domain.ParseJobPlaybook([]byte(acmePlaybook))
+		require.NoError(t, err)
+
+		result, err := uc.ApplyJobPlaybook(ctx, playbook, project.String(), true)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Nil(t, result.GroupID)
+		require.Len(t, result.Attacks, 2)
+
+		mask := result.Attacks[0]
+		assert.Equal(t, "six-digits", mask.Attack)
+		assert.Nil(t, mask.Job)
+		assert.Equal(t, "?d?d?d?d?d?d", mask.Request.Wordlist)
+		assert.Equal(t, gpuID.String(), mask.Request.AgentGroupID)
+		assert.Equal(t, hashFileID.String(), mask.Request.HashFileID, "the other project's file of the same name is left out")
+
+		straight := result.Attacks[1].Request
+		assert.Equal(t, wordlistID.String(), straight.WordlistID)
+		assert.Equal(t, "rockyou.txt", straight.Wordlist)
+		assert.Equal(t, "best64.rule", straight.Rules)
+		jobRepo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown references are listed together", func(t *testing.T) {
+		uc, _ := newUsecase()
+		playbook, err := domain.ParseJobPlaybook([]byte(`
+version: v1
+name: broken
+attacks:
+  - name: missing-wordlist
+    hash_file: acme-ntlm.txt
+    hash_type: 1000
+    wordlist: hashes.org.txt
+  - name: bad-mask
+    hash_file: acme-ntlm.txt
+    hash_type: 1000
+    mask: "-O"
+`))
+		require.NoError(t, err)
+
+		_, err = uc.ApplyJobPlaybook(ctx, playbook, project.String(), true)
+		var pErr *domain.PlaybookError
+		require.True(t, errors.As(err, &pErr))
+		require.Len(t, pErr.Problems, 2)
+		assert.Equal(t, "attacks.missing-wordlist.wordlist", pErr.Problems[0].Field)
+		assert.Contains(t, pErr.Problems[0].Message, "hashes.org.txt")
+		assert.Equal(t, "attacks.bad-mask.mask", pErr.Problems[1].Field)
+	})
+
+	t.Run("queues the jobs into one group in pipeline order", func(t *testing.T) {
+		uc, jobRepo := newUsecase()
+		var group *domain.JobGroup
+		jobRepo.On("CreateGroup", mock.Anything, mock.AnythingOfType("*domain.JobGroup")).
+			Run(func(args mock.Arguments) { group = args.Get(1).(*domain.JobGroup) }).Return(nil)
+		jobRepo.On("GetGroupByID", mock.Anything, mock.Anything).Return(&domain.JobGroup{}, nil)
+		var created []string
+		jobRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Job")).
+			Run(func(args mock.Arguments) { created = append(created, args.Get(1).(*domain.Job).Name) }).Return(nil)
+
+		playbook, err := domain.ParseJobPlaybook([]byte(`
+version: v1
+name: acme-q3
+defaults:
+  hash_file: acme-ntlm.txt
+  hash_type: 1000
+attacks:
+  - name: rockyou
+    wordlist: rockyou.txt
+  - name: six-digits
+    mask: "?d?d?d?d?d?d"
+pipeline: [six-digits, rockyou]
+`))
+		require.NoError(t, err)
+
+		result, err := uc.ApplyJobPlaybook(ctx, playbook, project.String(), false)
+		require.NoError(t, err)
+		require.NotNil(t, group)
+		assert.Equal(t, "acme-q3", group.Name)
+		assert.Equal(t, group.ID, *result.GroupID)
+		assert.Equal(t, []string{"six-digits", "rockyou"}, created)
+		for _, attack := range result.Attacks {
+			require.NotNil(t, attack.Job)
+			assert.Equal(t, group.ID, *attack.Job.GroupID)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_RecordJobProgress(t *testing.T) {
+	ctx := context.Background()
+	runningID, finishedID := uuid.New(), uuid.New()
+
+	jobRepo := new(MockJobRepository)
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+
+	for _, progress := range []float64{10, 20, 30} {
+		uc.RecordJobProgress(ctx, &domain.Job{ID: runningID, Progress: progress, Speed: 500, DeviceSeconds: progress})
+	}
+	uc.RecordJobProgress(ctx, &domain.Job{ID: finishedID, Progress: 90})
+
+	// Readers see the buffered progress of running jobs before it is written
+	jobRepo.On("GetByID", mock.Anything, runningID).Return(&domain.Job{ID: runningID, Status: domain.JobStatusRunning, Progress: 5}, nil).Once()
+	jobRepo.On("GetAll", mock.Anything).Return([]domain.Job{
+		{ID: runningID, Status: domain.JobStatusRunning, Progress: 5},
+		{ID: finishedID, Status: domain.JobStatusCompleted, Progress: 100},
+	}, nil).Once()
+
+	job, err := uc.GetJob(ctx, runningID)
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, job.Progress)
+	assert.Equal(t, 30.0, job.DeviceSeconds)
+
+	jobs, err := uc.GetAllJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, jobs[0].Progress)
+	assert.Equal(t, 100.0, jobs[1].Progress, "a finished job keeps its final progress")
+
+	// One batch holds the latest report of each job; nothing is written twice
+	jobRepo.On("UpdateProgressBatch", mock.Anything, mock.MatchedBy(func(progress map[uuid.UUID]domain.JobProgress) bool {
+		return len(progress) == 2 && progress[runningID].Progress == 30 && progress[runningID].Speed == 500
+	})).Return(nil).Once()
+	require.NoError(t, uc.FlushJobProgress(ctx))
+	require.NoError(t, uc.FlushJobProgress(ctx))
+
+	jobRepo.AssertExpectations(t)
+	jobRepo.AssertNumberOfCalls(t, "UpdateProgressBatch", 1)
+}
+
+func TestJobUsecase_FlushJobProgressRetries(t *testing.T) {
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	jobRepo := new(MockJobRepository)
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.RecordJobProgress(ctx, &domain.Job{ID: jobID, Progress: 10})
+
+	// A failed batch is kept for the next flush, unless a newer report came
+	jobRepo.On("UpdateProgressBatch", mock.Anything, mock.Anything).Return(errors.New("database is locked")).Once()
+	assert.Error(t, uc.FlushJobProgress(ctx))
+
+	uc.RecordJobProgress(ctx, &domain.Job{ID: jobID, Progress: 20})
+	jobRepo.On("UpdateProgressBatch", mock.Anything, mock.MatchedBy(func(progress map[uuid.UUID]domain.JobProgress) bool {
+		return progress[jobID].Progress == 20
+	})).Return(nil).Once()
+	assert.NoError(t, uc.FlushJobProgress(ctx))
+	jobRepo.AssertExpectations(t)
+}
+
+func TestJobUsecase_RunProgressFlusher(t *testing.T) {
+	jobRepo := new(MockJobRepository)
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.SetProgressFlush(time.Hour, 2)
+
+	written := make(chan int, 2)
+	jobRepo.On("UpdateProgressBatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written <- len(args.Get(1).(map[uuid.UUID]domain.JobProgress))
+	}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		uc.RunProgressFlusher(ctx)
+		close(done)
+	}()
+
+	// Reaching the threshold writes the batch long before the interval is up
+	uc.RecordJobProgress(ctx, &domain.Job{ID: uuid.New(), Progress: 10})
+	uc.RecordJobProgress(ctx, &domain.Job{ID: uuid.New(), Progress: 10})
+	select {
+	case n := <-written:
+		assert.Equal(t, 2, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the full buffer was not written")
+	}
+
+	// What is left is written on shutdown
+	uc.RecordJobProgress(ctx, &domain.Job{ID: uuid.New(), Progress: 10})
+	cancel()
+	<-done
+	assert.Equal(t, 1, <-written)
+}
@@ -0,0 +1,100 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_ReconcileAgentJobs(t *testing.T) {
+	agentID, otherAgent := uuid.New(), uuid.New()
+	jobID, lostID := uuid.New(), uuid.New()
+
+	newUsecase := func(agentJobs []domain.Job) (usecase.JobUsecase, *MockJobRepository) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("GetByAgentID", mock.Anything, agentID).Return(agentJobs, nil)
+		return usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository)), jobRepo
+	}
+	snapshot := &domain.AgentHeartbeat{CurrentJobID: &jobID, JobProgress: 40, JobSpeed: 1000}
+
+	t.Run("running jobs the agent lost are requeued", func(t *testing.T) {
+		uc, jobRepo := newUsecase([]domain.Job{
+			{ID: lostID, Status: domain.JobStatusRunning, AgentID: &agentID},
+			{ID: uuid.New(), Status: domain.JobStatusCompleted, AgentID: &agentID},
+		})
+		jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+			return job.ID == lostID && job.Status == domain.JobStatusInterrupted && job.AgentID == nil
+		})).Return(nil)
+		jobRepo.On("GetByStatus", mock.Anything, mock.Anything).Return([]domain.Job{}, nil)
+
+		result, err := uc.ReconcileAgentJobs(context.Background(), agentID, &domain.AgentHeartbeat{})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{lostID}, result.RequeuedJobs)
+		assert.False(t, result.KeepJob)
+		jobRepo.AssertExpectations(t)
+		assert.Equal(t, "agent reconnected without the job", jobRepo.events[0].Reason)
+	})
+
+	t.Run("the agent keeps its running job", func(t *testing.T) {
+		uc, jobRepo := newUsecase([]domain.Job{{ID: jobID, Status: domain.JobStatusRunning, AgentID: &agentID}})
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusRunning, AgentID: &agentID, Progress: 30}, nil)
+		jobRepo.On("UpdateProgressBatch", mock.Anything, mock.MatchedBy(func(progress map[uuid.UUID]domain.JobProgress) bool {
+			return progress[jobID].Progress == 40 && progress[jobID].Speed == 1000
+		})).Return(nil)
+
+		result, err := uc.ReconcileAgentJobs(context.Background(), agentID, snapshot)
+		require.NoError(t, err)
+		assert.True(t, result.KeepJob)
+		assert.Empty(t, result.RequeuedJobs)
+		require.NoError(t, uc.FlushJobProgress(context.Background()))
+		jobRepo.AssertExpectations(t)
+		jobRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("an interrupted job not handed out yet is taken back", func(t *testing.T) {
+		uc, jobRepo := newUsecase([]domain.Job{})
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusInterrupted, Progress: 30}, nil)
+		var job *domain.Job
+		jobRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			job = args.Get(1).(*domain.Job)
+		}).Return(nil)
+		jobRepo.On("UpdateProgressBatch", mock.Anything, mock.MatchedBy(func(progress map[uuid.UUID]domain.JobProgress) bool {
+			return progress[jobID].Progress == 40 && progress[jobID].Speed == 1000
+		})).Return(nil)
+
+		result, err := uc.ReconcileAgentJobs(context.Background(), agentID, snapshot)
+		require.NoError(t, err)
+		assert.True(t, result.KeepJob)
+		assert.Equal(t, domain.JobStatusRunning, job.Status)
+		assert.Equal(t, agentID, *job.AgentID)
+		require.NoError(t, uc.FlushJobProgress(context.Background()))
+		jobRepo.AssertExpectations(t)
+	})
+
+	t.Run("the agent stops jobs given away meanwhile", func(t *testing.T) {
+		uc, jobRepo := newUsecase([]domain.Job{})
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusRunning, AgentID: &otherAgent}, nil)
+
+		result, err := uc.ReconcileAgentJobs(context.Background(), agentID, snapshot)
+		require.NoError(t, err)
+		assert.False(t, result.KeepJob)
+		assert.Equal(t, "the job was given to another agent", result.Reason)
+	})
+
+	t.Run("the agent stops finished jobs", func(t *testing.T) {
+		uc, jobRepo := newUsecase([]domain.Job{})
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusCancelled, AgentID: &agentID}, nil)
+
+		result, err := uc.ReconcileAgentJobs(context.Background(), agentID, snapshot)
+		require.NoError(t, err)
+		assert.False(t, result.KeepJob)
+		assert.Equal(t, "the job is cancelled", result.Reason)
+	})
+}
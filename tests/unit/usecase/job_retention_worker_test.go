@@ -0,0 +1,41 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJobRetentionWorker_RunOnce(t *testing.T) {
+	t.Run("archives and purges past the cutoffs", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("Archive", mock.Anything, mock.MatchedBy(func(cutoff time.Time) bool {
+			return time.Since(cutoff) >= 30*24*time.Hour
+		})).Return(int64(3), nil)
+		jobRepo.On("Purge", mock.Anything, mock.MatchedBy(func(cutoff time.Time) bool {
+			return time.Since(cutoff) >= 90*24*time.Hour
+		})).Return(int64(1), nil)
+
+		worker := usecase.NewJobRetentionWorker(jobRepo, usecase.RetentionConfig{
+			ArchiveAfter: 30 * 24 * time.Hour,
+			PurgeAfter:   90 * 24 * time.Hour,
+		})
+		worker.RunOnce(context.Background())
+
+		jobRepo.AssertExpectations(t)
+	})
+
+	t.Run("zero durations disable the policy", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+
+		worker := usecase.NewJobRetentionWorker(jobRepo, usecase.RetentionConfig{})
+		worker.RunOnce(context.Background())
+
+		jobRepo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+		jobRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	})
+}
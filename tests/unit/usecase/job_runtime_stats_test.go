@@ -0,0 +1,51 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJobUsecase_ApplyRuntimeStats(t *testing.T) {
+	wordlistID := uuid.New()
+	words, skip := int64(1000), int64(200)
+
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, WordCount: &words}, nil)
+	uc := usecase.NewJobUsecase(new(MockJobRepository), new(MockAgentRepository), new(MockHashFileRepository), wordlistRepo)
+
+	// hashcat counts candidates, so with many rules it lags far behind the
+	// words that are done
+	job := &domain.Job{AttackMode: domain.AttackModeStraight, Rules: "dive.rule", WordlistID: &wordlistID, Skip: &skip, Progress: 12}
+	stats := &domain.JobRuntimeStats{RestorePoint: 600, Rejected: 5}
+	uc.ApplyRuntimeStats(context.Background(), job, stats)
+	assert.Equal(t, stats, job.Stats)
+	assert.InDelta(t, 50, job.NormalizedProgress, 0.001) // 400 of the 800 words after the skip
+	assert.Equal(t, 12.0, job.Progress)
+
+	// A report without a status keeps the previous one
+	uc.ApplyRuntimeStats(context.Background(), job, nil)
+	assert.Equal(t, stats, job.Stats)
+	assert.InDelta(t, 50, job.NormalizedProgress, 0.001)
+}
+
+func TestNormalizeProgress(t *testing.T) {
+	stats := &domain.JobRuntimeStats{RestorePoint: 250}
+	ruleJob := &domain.Job{AttackMode: domain.AttackModeStraight, Rules: "best64.rule", Progress: 3}
+
+	assert.InDelta(t, 25, domain.NormalizeProgress(ruleJob, stats, 1000), 0.001)
+	assert.Equal(t, 100.0, domain.NormalizeProgress(ruleJob, &domain.JobRuntimeStats{RestorePoint: 2000}, 1000))
+
+	// Without a status or a word count, and for other attacks, hashcat's
+	// progress stands
+	assert.Equal(t, 3.0, domain.NormalizeProgress(ruleJob, nil, 1000))
+	assert.Equal(t, 3.0, domain.NormalizeProgress(ruleJob, stats, 0))
+	maskJob := &domain.Job{AttackMode: domain.AttackModeBruteForce, Progress: 40}
+	assert.Equal(t, 40.0, domain.NormalizeProgress(maskJob, stats, 1000))
+}
@@ -3,7 +3,10 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"go-distributed-hashcat/internal/domain"
 	"go-distributed-hashcat/internal/usecase"
@@ -11,11 +14,116 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockJobRepository is a mock implementation of domain.JobRepository
 type MockJobRepository struct {
 	mock.Mock
+	events []domain.JobEvent
+}
+
+// CreateEvent records events without going through expectations, since
+// nearly every status change writes one
+func (m *MockJobRepository) CreateEvent(ctx context.Context, event *domain.JobEvent) error {
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *MockJobRepository) GetEvents(ctx context.Context, jobID uuid.UUID) ([]domain.JobEvent, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).([]domain.JobEvent), args.Error(1)
+}
+
+func (m *MockJobRepository) UpdateTags(ctx context.Context, jobID uuid.UUID, tags []string) error {
+	args := m.Called(ctx, jobID, tags)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) CreateComment(ctx context.Context, comment *domain.JobComment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetComments(ctx context.Context, jobID uuid.UUID) ([]domain.JobComment, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).([]domain.JobComment), args.Error(1)
+}
+
+func (m *MockJobRepository) DeleteComment(ctx context.Context, jobID, commentID uuid.UUID) error {
+	args := m.Called(ctx, jobID, commentID)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) SaveOutput(ctx context.Context, output *domain.JobOutput) error {
+	args := m.Called(ctx, output)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetOutput(ctx context.Context, jobID uuid.UUID) (*domain.JobOutput, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobOutput), args.Error(1)
+}
+
+func (m *MockJobRepository) SaveCheckpoint(ctx context.Context, checkpoint *domain.JobCheckpoint) error {
+	args := m.Called(ctx, checkpoint)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetCheckpoint(ctx context.Context, jobID uuid.UUID) (*domain.JobCheckpoint, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobCheckpoint), args.Error(1)
+}
+
+func (m *MockJobRepository) CreateCracks(ctx context.Context, cracks []domain.JobCrack) ([]domain.JobCrack, error) {
+	args := m.Called(ctx, cracks)
+	return args.Get(0).([]domain.JobCrack), args.Error(1)
+}
+
+func (m *MockJobRepository) GetCracks(ctx context.Context, jobID uuid.UUID) ([]domain.JobCrack, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).([]domain.JobCrack), args.Error(1)
+}
+
+func (m *MockJobRepository) AcquireLease(ctx context.Context, jobID, agentID uuid.UUID, until time.Time) (bool, error) {
+	args := m.Called(ctx, jobID, agentID, until)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockJobRepository) RenewLeases(ctx context.Context, agentIDs []uuid.UUID, until time.Time) error {
+	args := m.Called(ctx, agentIDs, until)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetExpiredLeases(ctx context.Context, before time.Time) ([]domain.Job, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) ExpireLease(ctx context.Context, jobID uuid.UUID, from, to string, before time.Time) (bool, error) {
+	args := m.Called(ctx, jobID, from, to, before)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockJobRepository) GetPastDeadline(ctx context.Context, before time.Time) ([]domain.Job, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) GetBudgetedGroups(ctx context.Context) ([]domain.JobGroup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.JobGroup), args.Error(1)
+}
+
+func (m *MockJobRepository) GetAgentSpeedsByHashType(ctx context.Context, hashType int) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, hashType)
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
 }
 
 func (m *MockJobRepository) Create(ctx context.Context, job *domain.Job) error {
@@ -23,6 +131,11 @@ func (m *MockJobRepository) Create(ctx context.Context, job *domain.Job) error {
 	return args.Error(0)
 }
 
+func (m *MockJobRepository) CreateBatch(ctx context.Context, group *domain.JobGroup, jobs []*domain.Job) error {
+	args := m.Called(ctx, group, jobs)
+	return args.Error(0)
+}
+
 func (m *MockJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Job, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -74,6 +187,54 @@ func (m *MockJobRepository) UpdateProgress(ctx context.Context, id uuid.UUID, pr
 	return args.Error(0)
 }
 
+func (m *MockJobRepository) UpdateProgressBatch(ctx context.Context, progress map[uuid.UUID]domain.JobProgress) error {
+	args := m.Called(ctx, progress)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetByGroupID(ctx context.Context, groupID uuid.UUID) ([]domain.Job, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) CreateGroup(ctx context.Context, group *domain.JobGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.JobGroup, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobGroup), args.Error(1)
+}
+
+func (m *MockJobRepository) List(ctx context.Context, filter domain.JobFilter) ([]domain.Job, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Job), args.Int(1), args.Error(2)
+}
+
+func (m *MockJobRepository) GetArchived(ctx context.Context) ([]domain.Job, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) Archive(ctx context.Context, completedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, completedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockJobRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // MockAgentRepository is defined in agent_usecase_test.go
 // We only need to add the missing UpdateSpeed method here
 
@@ -95,11 +256,29 @@ func (m *MockHashFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileRepository) GetBySHA256(ctx context.Context, sum string) (*domain.HashFile, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HashFile), args.Error(1)
+}
+
 func (m *MockHashFileRepository) GetAll(ctx context.Context) ([]domain.HashFile, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.HashFile), args.Error(1)
 }
 
+func (m *MockHashFileRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.HashFile, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.HashFile), args.Error(1)
+}
+
+func (m *MockHashFileRepository) SetSource(ctx context.Context, id uuid.UUID, source string) error {
+	args := m.Called(ctx, id, source)
+	return args.Error(0)
+}
+
 func (m *MockHashFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -110,6 +289,8 @@ func (m *MockHashFileRepository) Delete(ctx context.Context, id uuid.UUID) error
 func TestJobUsecase_CreateJob(t *testing.T) {
 	hashFileID := uuid.New()
 	agentID := uuid.New()
+	leftID, rightID := uuid.New(), uuid.New()
+	leftWords, rightWords := int64(1000), int64(50)
 
 	tests := []struct {
 		name          string
@@ -183,54 +364,396 @@ func TestJobUsecase_CreateJob(t *testing.T) {
 			expectedError: false,
 		},
 		{
-			name: "hash file not found",
+			name: "hash file not found",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(nil, errors.New("hash file not found"))
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid agent assignment",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				AgentID:    agentID.String(),
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				// Mock hash file exists
+				hashFile := &domain.HashFile{
+					ID:   hashFileID,
+					Name: "test.hash",
+					Path: "/uploads/test.hash",
+				}
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(hashFile, nil)
+
+				// Mock agent not found
+				agentRepo.On("GetByID", mock.Anything, agentID).Return(nil, errors.New("agent not found"))
+			},
+			expectedError: true,
+		},
+		{
+			name: "agent with a hashcat too old for the job rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   22100,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				AgentID:    agentID.String(),
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Name: "test.hash", Path: "/uploads/test.hash"}, nil)
+				agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Status: "online", HashcatVersion: "v6.0.0"}, nil)
+				agentRepo.On("GetHashcatArgs", mock.Anything, agentID).Return([]string{}, nil)
+				// No Create: the job is refused
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid hash file ID format",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 0,
+				HashFileID: "invalid-uuid",
+				Wordlist:   "rockyou.txt",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				// No mocks needed - should fail on UUID parsing
+			},
+			expectedError: true,
+		},
+		{
+			name: "wordlist path traversal rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "../../etc/shadow",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				// No mocks needed - should fail validation before any lookup
+			},
+			expectedError: true,
+		},
+		{
+			name: "rules given as path rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				Rules:      "/usr/share/hashcat/rules/best64.rule",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "unsupported attack mode rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 9,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "injected flag in mask rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   0,
+				AttackMode: 3,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "--outfile=/root/.ssh/authorized_keys",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "brute-force mask accepted",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   1000,
+				AttackMode: 3,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "?u?l?l?l?d?d",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFile := &domain.HashFile{
+					ID:   hashFileID,
+					Name: "test.hash",
+					Path: "/uploads/test.hash",
+				}
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(hashFile, nil)
+				jobRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "mask with custom charsets accepted",
+			request: &domain.CreateJobRequest{
+				Name:           "test-job",
+				HashType:       1000,
+				AttackMode:     3,
+				HashFileID:     hashFileID.String(),
+				Wordlist:       "?1?2?2?2?d?d",
+				CustomCharset1: "?u?d",
+				CustomCharset2: uuid.NewString(), // Charset file
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+				jobRepo.On("Create", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.CustomCharset1 == "?u?d" && job.CustomCharset2 != ""
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "mask using an undefined charset rejected",
+			request: &domain.CreateJobRequest{
+				Name:           "test-job",
+				HashType:       1000,
+				AttackMode:     3,
+				HashFileID:     hashFileID.String(),
+				Wordlist:       "?1?3",
+				CustomCharset1: "?l?d",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "charset given as path rejected",
+			request: &domain.CreateJobRequest{
+				Name:           "test-job",
+				HashType:       1000,
+				AttackMode:     3,
+				HashFileID:     hashFileID.String(),
+				Wordlist:       "?1?1",
+				CustomCharset1: "/etc/shadow",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "custom charsets on a straight attack rejected",
+			request: &domain.CreateJobRequest{
+				Name:           "test-job",
+				HashType:       0,
+				AttackMode:     0,
+				HashFileID:     hashFileID.String(),
+				Wordlist:       "rockyou.txt",
+				CustomCharset1: "?l?d",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "whitelisted extra args accepted",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   1000,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				ExtraArgs:  []string{"-O", "-n", "64", "--backend-devices=1,2"},
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+				jobRepo.On("Create", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return len(job.ExtraArgs) == 4 && job.ExtraArgs[0] == "-O"
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "john job with a format accepted",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				Engine:     domain.EngineJohn,
+				JohnFormat: "raw-md5",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+				jobRepo.On("Create", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.Engine == domain.EngineJohn && job.JohnFormat == "raw-md5"
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "john job without a format rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				Engine:     domain.EngineJohn,
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "john job with hashcat options rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				Engine:     domain.EngineJohn,
+				JohnFormat: "nt",
+				ExtraArgs:  []string{"-O"},
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "unknown engine rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				Engine:     "ophcrack",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "extra args outside the whitelist rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   1000,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				ExtraArgs:  []string{"--outfile", "/root/.ssh/authorized_keys"},
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "extra arg with a bad value rejected",
+			request: &domain.CreateJobRequest{
+				Name:       "test-job",
+				HashType:   1000,
+				AttackMode: 0,
+				HashFileID: hashFileID.String(),
+				Wordlist:   "rockyou.txt",
+				ExtraArgs:  []string{"-n", "/tmp/x"},
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "combinator keyspace is the product of both wordlists",
+			request: &domain.CreateJobRequest{
+				Name:        "test-job",
+				HashType:    1000,
+				AttackMode:  1,
+				HashFileID:  hashFileID.String(),
+				Wordlist:    "words.txt",
+				WordlistID:  leftID.String(),
+				Wordlist2ID: rightID.String(),
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, leftID).Return(&domain.Wordlist{ID: leftID, WordCount: &leftWords}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, rightID).Return(&domain.Wordlist{ID: rightID, WordCount: &rightWords}, nil)
+				jobRepo.On("Create", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.TotalWords == 50000 && job.Wordlist2ID != nil && *job.Wordlist2ID == rightID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "combinator with an unknown second wordlist rejected",
 			request: &domain.CreateJobRequest{
-				Name:       "test-job",
-				HashType:   0,
-				AttackMode: 0,
-				HashFileID: hashFileID.String(),
-				Wordlist:   "rockyou.txt",
+				Name:        "test-job",
+				HashType:    1000,
+				AttackMode:  1,
+				HashFileID:  hashFileID.String(),
+				Wordlist:    "words.txt",
+				WordlistID:  leftID.String(),
+				Wordlist2ID: rightID.String(),
 			},
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
-				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(nil, errors.New("hash file not found"))
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, leftID).Return(&domain.Wordlist{ID: leftID, WordCount: &leftWords}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, rightID).Return(nil, errors.New("wordlist not found"))
 			},
 			expectedError: true,
 		},
 		{
-			name: "invalid agent assignment",
+			name: "combinator without a second wordlist rejected",
 			request: &domain.CreateJobRequest{
 				Name:       "test-job",
-				HashType:   0,
-				AttackMode: 0,
+				HashType:   1000,
+				AttackMode: 1,
 				HashFileID: hashFileID.String(),
-				Wordlist:   "rockyou.txt",
-				AgentID:    agentID.String(),
+				Wordlist:   "words.txt",
+				WordlistID: leftID.String(),
 			},
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
-				// Mock hash file exists
-				hashFile := &domain.HashFile{
-					ID:   hashFileID,
-					Name: "test.hash",
-					Path: "/uploads/test.hash",
-				}
-				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(hashFile, nil)
-
-				// Mock agent not found
-				agentRepo.On("GetByID", mock.Anything, agentID).Return(nil, errors.New("agent not found"))
 			},
 			expectedError: true,
 		},
 		{
-			name: "invalid hash file ID format",
+			name: "combinator with rules rejected",
 			request: &domain.CreateJobRequest{
-				Name:       "test-job",
-				HashType:   0,
-				AttackMode: 0,
-				HashFileID: "invalid-uuid",
-				Wordlist:   "rockyou.txt",
+				Name:        "test-job",
+				HashType:    1000,
+				AttackMode:  1,
+				HashFileID:  hashFileID.String(),
+				Wordlist:    "words.txt",
+				WordlistID:  leftID.String(),
+				Wordlist2ID: rightID.String(),
+				Rules:       "best64.rule",
+			},
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+			},
+			expectedError: true,
+		},
+		{
+			name: "second wordlist on a straight attack rejected",
+			request: &domain.CreateJobRequest{
+				Name:        "test-job",
+				HashType:    0,
+				AttackMode:  0,
+				HashFileID:  hashFileID.String(),
+				Wordlist:    "words.txt",
+				WordlistID:  leftID.String(),
+				Wordlist2ID: rightID.String(),
 			},
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
-				// No mocks needed - should fail on UUID parsing
 			},
 			expectedError: true,
 		},
@@ -257,7 +780,12 @@ func TestJobUsecase_CreateJob(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, job)
 				assert.Equal(t, tt.request.Name, job.Name)
-				assert.Equal(t, "pending", job.Status)
+				// Jobs created for a specific agent start out assigned to it
+				expectedStatus := domain.JobStatusPending
+				if tt.request.AgentID != "" {
+					expectedStatus = domain.JobStatusAssigned
+				}
+				assert.Equal(t, expectedStatus, job.Status)
 				assert.NotEqual(t, uuid.Nil, job.ID)
 			}
 
@@ -268,6 +796,178 @@ func TestJobUsecase_CreateJob(t *testing.T) {
 	}
 }
 
+func TestJobUsecase_CreateJob_MultipleAgents(t *testing.T) {
+	hashFileID := uuid.New()
+	gpuID := uuid.New()
+	cpuID := uuid.New()
+
+	setup := func() (*MockJobRepository, *MockAgentRepository, *MockHashFileRepository) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		hashFileRepo := new(MockHashFileRepository)
+
+		hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+		agentRepo.On("GetByID", mock.Anything, gpuID).Return(&domain.Agent{ID: gpuID, Name: "gpu-1", Status: "online", Speed: 3000}, nil)
+		agentRepo.On("GetByID", mock.Anything, cpuID).Return(&domain.Agent{ID: cpuID, Name: "cpu-1", Status: "online", Speed: 1000}, nil)
+		return jobRepo, agentRepo, hashFileRepo
+	}
+
+	request := &domain.CreateJobRequest{
+		Name:       "distributed",
+		HashFileID: hashFileID.String(),
+		Wordlist:   "rockyou.txt",
+		AgentIDs:   []string{gpuID.String(), cpuID.String()},
+	}
+
+	t.Run("stores group and sub-jobs together before starting them", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup()
+
+		var group *domain.JobGroup
+		var subJobs []*domain.Job
+		jobRepo.On("CreateBatch", mock.Anything, mock.AnythingOfType("*domain.JobGroup"), mock.AnythingOfType("[]*domain.Job")).
+			Run(func(args mock.Arguments) {
+				group = args.Get(1).(*domain.JobGroup)
+				subJobs = args.Get(2).([]*domain.Job)
+			}).
+			Return(nil)
+		jobRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Job{Status: "pending", AgentID: &gpuID}, nil)
+		jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, new(MockWordlistRepository))
+		job, err := usecase.CreateJob(context.Background(), request)
+
+		require.NoError(t, err)
+		require.Len(t, subJobs, 2)
+		assert.Equal(t, subJobs[0].ID, job.ID)
+		for _, subJob := range subJobs {
+			assert.Equal(t, group.ID, *subJob.GroupID)
+			jobRepo.AssertCalled(t, "GetByID", mock.Anything, subJob.ID) // auto-started
+		}
+		jobRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("nothing is started when the batch fails", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup()
+		jobRepo.On("CreateBatch", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("disk I/O error"))
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, new(MockWordlistRepository))
+		job, err := usecase.CreateJob(context.Background(), request)
+
+		assert.Error(t, err)
+		assert.Nil(t, job)
+		jobRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		jobRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("combinator jobs are split on the larger wordlist", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup()
+		leftID, rightID := uuid.New(), uuid.New()
+		leftWords, rightWords := int64(100), int64(1000)
+		wordlistRepo := new(MockWordlistRepository)
+		wordlistRepo.On("GetByID", mock.Anything, leftID).Return(&domain.Wordlist{ID: leftID, WordCount: &leftWords}, nil)
+		wordlistRepo.On("GetByID", mock.Anything, rightID).Return(&domain.Wordlist{ID: rightID, WordCount: &rightWords}, nil)
+
+		var subJobs []*domain.Job
+		jobRepo.On("CreateBatch", mock.Anything, mock.Anything, mock.AnythingOfType("[]*domain.Job")).
+			Run(func(args mock.Arguments) { subJobs = args.Get(2).([]*domain.Job) }).
+			Return(nil)
+		jobRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Job{Status: "pending", AgentID: &gpuID}, nil)
+		jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+
+		combinator := *request
+		combinator.AttackMode = domain.AttackModeCombinator
+		combinator.WordlistID = leftID.String()
+		combinator.Wordlist2ID = rightID.String()
+		_, err := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo).CreateJob(context.Background(), &combinator)
+
+		require.NoError(t, err)
+		require.Len(t, subJobs, 2)
+		// --skip/--limit count the 1000 words of the right-hand wordlist, 3:1 by speed
+		assert.Equal(t, int64(0), *subJobs[0].Skip)
+		assert.Equal(t, int64(750), *subJobs[0].WordLimit)
+		assert.Equal(t, int64(750), *subJobs[1].Skip)
+		assert.Equal(t, int64(250), *subJobs[1].WordLimit)
+		assert.Equal(t, int64(75000), subJobs[0].TotalWords)
+		assert.Equal(t, int64(25000), subJobs[1].TotalWords)
+		for _, subJob := range subJobs {
+			assert.Equal(t, rightID, *subJob.Wordlist2ID)
+		}
+	})
+}
+
+func TestJobUsecase_CreateJob_AgentGroup(t *testing.T) {
+	hashFileID := uuid.New()
+	groupID := uuid.New()
+	gpuID := uuid.New()
+	cpuID := uuid.New()
+	offlineID := uuid.New()
+
+	setup := func(members []domain.Agent) (*MockJobRepository, *MockAgentRepository, *MockHashFileRepository) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		hashFileRepo := new(MockHashFileRepository)
+
+		hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/uploads/test.hash"}, nil)
+		agentRepo.On("GetGroupByID", mock.Anything, groupID).Return(&domain.AgentGroup{ID: groupID, Name: "red-team-lab"}, nil)
+		agentRepo.On("GetByGroupID", mock.Anything, groupID).Return(members, nil)
+		for i := range members {
+			agentRepo.On("GetByID", mock.Anything, members[i].ID).Return(&members[i], nil)
+		}
+		return jobRepo, agentRepo, hashFileRepo
+	}
+
+	request := &domain.CreateJobRequest{
+		Name:         "lab only",
+		HashFileID:   hashFileID.String(),
+		Wordlist:     "rockyou.txt",
+		AgentGroupID: groupID.String(),
+	}
+
+	t.Run("splits the job across the group's online agents", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup([]domain.Agent{
+			{ID: gpuID, Name: "gpu-1", Status: "online", Speed: 3000},
+			{ID: cpuID, Name: "cpu-1", Status: "online", Speed: 1000},
+			{ID: offlineID, Name: "old-1", Status: "offline"},
+		})
+
+		var subJobs []*domain.Job
+		jobRepo.On("CreateBatch", mock.Anything, mock.Anything, mock.AnythingOfType("[]*domain.Job")).
+			Run(func(args mock.Arguments) { subJobs = args.Get(2).([]*domain.Job) }).
+			Return(nil)
+		jobRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Job{Status: "pending", AgentID: &gpuID}, nil)
+		jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, new(MockWordlistRepository))
+		_, err := usecase.CreateJob(context.Background(), request)
+
+		require.NoError(t, err)
+		require.Len(t, subJobs, 2)
+		assert.ElementsMatch(t, []uuid.UUID{gpuID, cpuID}, []uuid.UUID{*subJobs[0].AgentID, *subJobs[1].AgentID})
+		assert.Empty(t, request.AgentIDs) // The caller's request is left alone
+	})
+
+	t.Run("group without online agents", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup([]domain.Agent{{ID: offlineID, Name: "old-1", Status: "offline"}})
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, new(MockWordlistRepository))
+		_, err := usecase.CreateJob(context.Background(), request)
+
+		assert.True(t, domain.IsValidationError(err))
+		jobRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("group combined with explicit agents", func(t *testing.T) {
+		jobRepo, agentRepo, hashFileRepo := setup(nil)
+		combined := *request
+		combined.AgentID = gpuID.String()
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, new(MockWordlistRepository))
+		_, err := usecase.CreateJob(context.Background(), &combined)
+
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
+
 func TestJobUsecase_StartJob(t *testing.T) {
 	jobID := uuid.New()
 	agentID := uuid.New()
@@ -356,6 +1056,7 @@ func TestJobUsecase_StartJob(t *testing.T) {
 
 func TestJobUsecase_UpdateJobProgress(t *testing.T) {
 	jobID := uuid.New()
+	agentID := uuid.New()
 
 	tests := []struct {
 		name          string
@@ -371,17 +1072,23 @@ func TestJobUsecase_UpdateJobProgress(t *testing.T) {
 			progress: 50.5,
 			speed:    1000000,
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
-				jobRepo.On("UpdateProgress", mock.Anything, jobID, 50.5, int64(1000000)).Return(nil)
+				jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, Status: domain.JobStatusRunning, AgentID: &agentID}, nil)
+				// Progress renews the agent's lease on the job
+				jobRepo.On("RenewLeases", mock.Anything, []uuid.UUID{agentID}, mock.Anything).Return(nil)
+				// and is written with the next batch
+				jobRepo.On("UpdateProgressBatch", mock.Anything, mock.MatchedBy(func(progress map[uuid.UUID]domain.JobProgress) bool {
+					return progress[jobID].Progress == 50.5 && progress[jobID].Speed == 1000000
+				})).Return(nil)
 			},
 			expectedError: false,
 		},
 		{
-			name:     "repository update error",
+			name:     "job not found",
 			jobID:    jobID,
 			progress: 75.0,
 			speed:    500000,
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
-				jobRepo.On("UpdateProgress", mock.Anything, jobID, 75.0, int64(500000)).Return(errors.New("database error"))
+				jobRepo.On("GetByID", mock.Anything, jobID).Return(nil, errors.New("job not found"))
 			},
 			expectedError: true,
 		},
@@ -405,6 +1112,7 @@ func TestJobUsecase_UpdateJobProgress(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.NoError(t, usecase.FlushJobProgress(ctx))
 			}
 
 			jobRepo.AssertExpectations(t)
@@ -458,9 +1166,35 @@ func TestJobUsecase_CompleteJob(t *testing.T) {
 					Status: "completed",
 				}
 				jobRepo.On("GetByID", mock.Anything, jobID).Return(job, nil)
-				jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
 			},
-			expectedError: false, // Implementation allows completing any job
+			expectedError: true, // Finished jobs can't change status again
+		},
+		{
+			name:   "password found cancels the rest of the job group",
+			jobID:  jobID,
+			result: "password123",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				groupID := uuid.New()
+				otherAgentID := uuid.New()
+				job := &domain.Job{
+					ID:      jobID,
+					Status:  "running",
+					AgentID: &agentID,
+					GroupID: &groupID,
+				}
+				jobRepo.On("GetByID", mock.Anything, jobID).Return(job, nil)
+				jobRepo.On("GetByGroupID", mock.Anything, groupID).Return([]domain.Job{
+					*job,
+					{ID: uuid.New(), Name: "sibling", Status: "running", AgentID: &otherAgentID, GroupID: &groupID},
+					{ID: uuid.New(), Name: "done", Status: "failed", GroupID: &groupID},
+				}, nil)
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(j *domain.Job) bool {
+					return j.Name == "sibling" && j.Status == "cancelled"
+				})).Return(nil).Once()
+				jobRepo.On("Update", mock.Anything, job).Return(nil).Once()
+				agentRepo.On("UpdateStatus", mock.Anything, otherAgentID, "online").Return(nil)
+			},
+			expectedError: false,
 		},
 	}
 
@@ -490,6 +1224,123 @@ func TestJobUsecase_CompleteJob(t *testing.T) {
 	}
 }
 
+func TestJobUsecase_CompleteJob_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{name: "password found", result: "Password found: secret", expected: domain.JobStatusCracked},
+		{name: "keyspace exhausted", result: domain.JobResultExhausted, expected: domain.JobStatusFailed},
+		{name: "no result", result: "", expected: domain.JobStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobRepo := new(MockJobRepository)
+			job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning}
+			jobRepo.On("GetByID", mock.Anything, job.ID).Return(job, nil)
+			jobRepo.On("Update", mock.Anything, job).Return(nil)
+
+			usecase := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+			err := usecase.CompleteJob(context.Background(), job.ID, tt.result, 1000)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, job.Status)
+			assert.Equal(t, tt.result, job.Result)
+			assert.Equal(t, float64(100), job.Progress)
+		})
+	}
+}
+
+// recordingSink remembers the passwords handed to it
+type recordingSink struct {
+	projectID *uuid.UUID
+	passwords []string
+}
+
+func (s *recordingSink) AddCrackedPasswords(ctx context.Context, projectID *uuid.UUID, passwords ...string) error {
+	s.projectID = projectID
+	s.passwords = append(s.passwords, passwords...)
+	return nil
+}
+
+func TestJobUsecase_CompleteJob_FeedsLoopback(t *testing.T) {
+	projectID := uuid.New()
+	tests := []struct {
+		name     string
+		result   string
+		expected []string
+	}{
+		{name: "cracked password is collected", result: "Password found: hunter2", expected: []string{"hunter2"}},
+		{name: "unreadable password is skipped", result: "Password found (extraction failed)"},
+		{name: "exhausted keyspace is skipped", result: domain.JobResultExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobRepo := new(MockJobRepository)
+			job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, ProjectID: &projectID}
+			jobRepo.On("GetByID", mock.Anything, job.ID).Return(job, nil)
+			jobRepo.On("Update", mock.Anything, job).Return(nil)
+
+			sink := &recordingSink{}
+			usecase := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+			usecase.SetCrackedPasswordSink(sink)
+
+			assert.NoError(t, usecase.CompleteJob(context.Background(), job.ID, tt.result, 1000))
+			assert.Equal(t, tt.expected, sink.passwords)
+			if tt.expected != nil {
+				assert.Equal(t, &projectID, sink.projectID)
+			}
+		})
+	}
+}
+
+func TestJobUsecase_AccountJobUsage(t *testing.T) {
+	uc := usecase.NewJobUsecase(new(MockJobRepository), new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.SetCostRates(domain.CostRates{DeviceWatts: 200})
+
+	t.Run("first report counts from the start", func(t *testing.T) {
+		startedAt := time.Now().Add(-30 * time.Second)
+		job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, StartedAt: &startedAt}
+
+		uc.AccountJobUsage(job, domain.UsageSample{Devices: 2, PowerWatts: 600})
+		assert.InDelta(t, 60, job.DeviceSeconds, 1)
+		assert.InDelta(t, 5, job.EnergyWh, 0.1) // 600 W for 30 s
+
+		// The next report only adds the time since this one
+		uc.AccountJobUsage(job, domain.UsageSample{Devices: 2})
+		assert.InDelta(t, 60, job.DeviceSeconds, 1)
+	})
+
+	t.Run("estimated draw without a reading", func(t *testing.T) {
+		startedAt := time.Now().Add(-36 * time.Second)
+		job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, StartedAt: &startedAt}
+
+		uc.AccountJobUsage(job, domain.UsageSample{})
+		assert.InDelta(t, 36, job.DeviceSeconds, 1)
+		assert.InDelta(t, 2, job.EnergyWh, 0.1) // One device at 200 W for 36 s
+	})
+
+	t.Run("gaps longer than a minute are not billed", func(t *testing.T) {
+		startedAt := time.Now().Add(-time.Hour)
+		job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, StartedAt: &startedAt}
+
+		uc.AccountJobUsage(job, domain.UsageSample{Devices: 1})
+		assert.Zero(t, job.DeviceSeconds)
+		assert.Zero(t, job.EnergyWh)
+	})
+}
+
+// expectIdleCluster mocks the scheduler's lookups for a cluster with no
+// other jobs on its agents and no speed history
+func expectIdleCluster(jobRepo *MockJobRepository) {
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusAssigned).Return([]domain.Job{}, nil)
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{}, nil)
+	jobRepo.On("GetAgentSpeedsByHashType", mock.Anything, mock.Anything).Return(map[uuid.UUID]int64{}, nil)
+}
+
 func TestJobUsecase_AssignJobsToAgents(t *testing.T) {
 	agentID := uuid.New()
 	jobID := uuid.New()
@@ -509,6 +1360,7 @@ func TestJobUsecase_AssignJobsToAgents(t *testing.T) {
 					AgentID: nil, // No agent assigned yet
 				}
 				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{*pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
 
 				// Mock available agents
 				agent := &domain.Agent{
@@ -517,16 +1369,228 @@ func TestJobUsecase_AssignJobsToAgents(t *testing.T) {
 				}
 				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{*agent}, nil)
 				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+				expectIdleCluster(jobRepo)
 
 				// Mock job update
 				jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
 			},
 			expectedError: false,
 		},
+		{
+			name: "prefers agent holding the wordlist locally",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				wordlistID := uuid.New()
+				pendingJob := domain.Job{
+					ID:         jobID,
+					Status:     "pending",
+					AttackMode: domain.AttackModeStraight,
+					Wordlist:   "rockyou.txt",
+					WordlistID: &wordlistID,
+				}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, Size: 1024}, nil)
+
+				otherID := uuid.New()
+				staleID := uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: otherID, Status: "online"},
+					{ID: staleID, Status: "online"},
+					{ID: agentID, Status: "online"},
+				}, nil)
+				agentRepo.On("GetFiles", mock.Anything, otherID).Return([]domain.AgentFile{}, nil)
+				// A copy of a different size is not the same wordlist
+				agentRepo.On("GetFiles", mock.Anything, staleID).Return([]domain.AgentFile{{Name: "rockyou.txt", Size: 512}}, nil)
+				agentRepo.On("GetFiles", mock.Anything, agentID).Return([]domain.AgentFile{{Name: "RockYou.txt", Size: 1024}}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+				expectIdleCluster(jobRepo)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "local wordlist outweighs a faster agent without it",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				hashFileID := uuid.New()
+				pendingJob := domain.Job{
+					ID:         jobID,
+					Status:     "pending",
+					HashType:   0,
+					AttackMode: domain.AttackModeStraight,
+					HashFile:   "/uploads/hash-files/hashes.txt",
+					HashFileID: &hashFileID,
+					Wordlist:   "rockyou.txt",
+				}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Size: 64}, nil)
+
+				// Twice as fast, but would have to download the wordlist first
+				fastID := uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: fastID, Status: "online", Speed: 2000},
+					{ID: agentID, Status: "online", Speed: 1000},
+				}, nil)
+				agentRepo.On("GetFiles", mock.Anything, fastID).Return([]domain.AgentFile{{Name: "hashes.txt", Size: 64}}, nil)
+				agentRepo.On("GetFiles", mock.Anything, agentID).Return([]domain.AgentFile{{Name: "rockyou.txt", Size: 4096}}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+				expectIdleCluster(jobRepo)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "hash mode speed history beats benchmark speed",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				pendingJob := domain.Job{ID: jobID, Status: "pending", HashType: 1000, AttackMode: domain.AttackModeBruteForce, Wordlist: "?a?a?a?a"}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusAssigned).Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{}, nil)
+
+				// The other agent benchmarks faster on WPA but is slower on NTLM
+				otherID := uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: otherID, Status: "online", Speed: 900000},
+					{ID: agentID, Status: "online", Speed: 300000},
+				}, nil)
+				jobRepo.On("GetAgentSpeedsByHashType", mock.Anything, 1000).Return(map[uuid.UUID]int64{
+					otherID: 5000000000,
+					agentID: 40000000000,
+				}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "loaded agents are passed over",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				loadedID := uuid.New()
+				pendingJob := domain.Job{ID: jobID, Status: "pending", AttackMode: domain.AttackModeBruteForce, Wordlist: "?d?d?d?d"}
+				// A job already waiting for the loaded agent
+				queuedJob := domain.Job{ID: uuid.New(), Status: "pending", AgentID: &loadedID}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob, queuedJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusAssigned).Return([]domain.Job{{ID: uuid.New(), AgentID: &loadedID}}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{}, nil)
+				jobRepo.On("GetAgentSpeedsByHashType", mock.Anything, 0).Return(map[uuid.UUID]int64{}, nil)
+
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: loadedID, Status: "online", Speed: 2000},
+					{ID: agentID, Status: "online", Speed: 1000},
+				}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "agents without hashcat or disk space are skipped",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				wordlistID := uuid.New()
+				pendingJob := domain.Job{ID: jobID, Status: "pending", AttackMode: domain.AttackModeStraight, Wordlist: "rockyou.txt", WordlistID: &wordlistID}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, Size: 10 << 30}, nil)
+				expectIdleCluster(jobRepo)
+
+				noHashcatID, fullDiskID := uuid.New(), uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: noHashcatID, Status: "online", Speed: 9000, Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: false, FreeDiskBytes: -1}},
+					{ID: fullDiskID, Status: "online", Speed: 9000, Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: true, FreeDiskBytes: 1 << 30}},
+					{ID: agentID, Status: "online", Speed: 1000, Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: true, FreeDiskBytes: 50 << 30}},
+				}, nil)
+				agentRepo.On("GetFiles", mock.Anything, fullDiskID).Return([]domain.AgentFile{}, nil)
+				agentRepo.On("GetFiles", mock.Anything, agentID).Return([]domain.AgentFile{}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "agents whose hashcat version can't run the job are skipped",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				pendingJob := domain.Job{ID: jobID, Status: "pending", HashType: 22100, AttackMode: domain.AttackModeBruteForce, Wordlist: "?d?d?d?d"}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				expectIdleCluster(jobRepo)
+
+				oldID, noOptionID := uuid.New(), uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: oldID, Status: "online", Speed: 9000, HashcatVersion: "v6.0.0"},
+					{ID: noOptionID, Status: "online", Speed: 9000, HashcatVersion: "v6.1.0"},
+					{ID: agentID, Status: "online", Speed: 1000, HashcatVersion: "v6.2.6"},
+				}, nil)
+				agentRepo.On("GetHashcatArgs", mock.Anything, oldID).Return([]string{}, nil)
+				agentRepo.On("GetHashcatArgs", mock.Anything, noOptionID).Return([]string{"--multiply-accel-disable"}, nil)
+				agentRepo.On("GetHashcatArgs", mock.Anything, agentID).Return([]string{"--multiply-accel-disable"}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "john jobs go to agents with john",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				pendingJob := domain.Job{ID: jobID, Status: "pending", AttackMode: domain.AttackModeBruteForce, Wordlist: "?d?d?d?d",
+					Engine: domain.EngineJohn, JohnFormat: "raw-md5"}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				expectIdleCluster(jobRepo)
+
+				hashcatID, unknownID := uuid.New(), uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: hashcatID, Status: "online", Speed: 9000, Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: true, Engines: []string{domain.EngineHashcat}, FreeDiskBytes: -1}},
+					{ID: unknownID, Status: "online", Speed: 9000},
+					{ID: agentID, Status: "online", Speed: 1000, Heartbeat: &domain.AgentHeartbeat{Engines: []string{domain.EngineJohn}, FreeDiskBytes: -1}},
+				}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, agentID, "busy").Return(nil)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == agentID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "job stays pending when no agent can run it",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				pendingJob := domain.Job{ID: jobID, Status: "pending", AttackMode: domain.AttackModeBruteForce, Wordlist: "?d?d?d?d"}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusAssigned).Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{}, nil)
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: agentID, Status: "online", Heartbeat: &domain.AgentHeartbeat{HashcatAvailable: false}},
+				}, nil)
+				// No Update or UpdateStatus: nothing is assigned
+			},
+			expectedError: false,
+		},
 		{
 			name: "no pending jobs",
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
 				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
 			},
 			expectedError: false,
 		},
@@ -539,12 +1603,36 @@ func TestJobUsecase_AssignJobsToAgents(t *testing.T) {
 					AgentID: nil,
 				}
 				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{*pendingJob}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
 
 				// Mock no available agents
 				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{}, nil)
 			},
 			expectedError: false, // Not an error, just no assignment happens
 		},
+		{
+			name: "skips agent at its max concurrent jobs",
+			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				// The faster agent already has a job queued and takes one at a time
+				queued := domain.Job{ID: uuid.New(), Status: "pending", AgentID: &agentID}
+				jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{{ID: jobID, Status: "pending"}, queued}, nil)
+				jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+
+				otherID := uuid.New()
+				agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+					{ID: agentID, Status: "online", Speed: 9000},
+					{ID: otherID, Status: "online", Speed: 1000},
+				}, nil)
+				agentRepo.On("GetSettings", mock.Anything, agentID).Return(&domain.AgentSettings{MaxConcurrentJobs: 1}, nil)
+				agentRepo.On("UpdateStatus", mock.Anything, otherID, "busy").Return(nil)
+				expectIdleCluster(jobRepo)
+
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+					return job.AgentID != nil && *job.AgentID == otherID
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
 		{
 			name: "repository error getting pending jobs",
 			mockSetup: func(jobRepo *MockJobRepository, agentRepo *MockAgentRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
@@ -562,6 +1650,7 @@ func TestJobUsecase_AssignJobsToAgents(t *testing.T) {
 			wordlistRepo := new(MockWordlistRepository)
 
 			tt.mockSetup(jobRepo, agentRepo, hashFileRepo, wordlistRepo)
+			agentRepo.On("GetSettings", mock.Anything, mock.Anything).Return(&domain.AgentSettings{}, nil).Maybe()
 
 			usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo)
 			ctx := context.Background()
@@ -645,3 +1734,399 @@ func TestJobUsecase_GetJobsByStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestJobUsecase_GetAvailableJobForAgent(t *testing.T) {
+	agentID := uuid.New()
+	hashFileID := uuid.New()
+	wordlistID := uuid.New()
+
+	tests := []struct {
+		name                string
+		mockSetup           func(*MockJobRepository, *MockHashFileRepository, *MockWordlistRepository)
+		expectedHashSHA     string
+		expectedWordlistSHA string
+		expectedError       bool
+		expectedSize        int64
+	}{
+		{
+			name: "attaches file checksums",
+			mockSetup: func(jobRepo *MockJobRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				jobRepo.On("GetAvailableJobForAgent", mock.Anything, agentID).Return(&domain.Job{
+					ID:         uuid.New(),
+					HashFileID: &hashFileID,
+					WordlistID: &wordlistID,
+					Wordlist:   "rockyou.txt",
+				}, nil)
+				jobRepo.On("AcquireLease", mock.Anything, mock.Anything, agentID, mock.Anything).Return(true, nil)
+				jobRepo.On("GetCheckpoint", mock.Anything, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "job checkpoint"})
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Size: 42, SHA256: "aaaa"}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(&domain.Wordlist{ID: wordlistID, Size: 1337, SHA256: "bbbb"}, nil)
+			},
+			expectedHashSHA:     "aaaa",
+			expectedWordlistSHA: "bbbb",
+			expectedSize:        1337,
+		},
+		{
+			name: "missing wordlist record leaves checksum empty",
+			mockSetup: func(jobRepo *MockJobRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				jobRepo.On("GetAvailableJobForAgent", mock.Anything, agentID).Return(&domain.Job{
+					ID:         uuid.New(),
+					HashFileID: &hashFileID,
+					WordlistID: &wordlistID,
+				}, nil)
+				jobRepo.On("AcquireLease", mock.Anything, mock.Anything, agentID, mock.Anything).Return(true, nil)
+				jobRepo.On("GetCheckpoint", mock.Anything, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "job checkpoint"})
+				hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Size: 42, SHA256: "aaaa"}, nil)
+				wordlistRepo.On("GetByID", mock.Anything, wordlistID).Return(nil, errors.New("wordlist not found"))
+			},
+			expectedHashSHA: "aaaa",
+		},
+		{
+			name: "job given to another agent meanwhile",
+			mockSetup: func(jobRepo *MockJobRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				jobRepo.On("GetAvailableJobForAgent", mock.Anything, agentID).Return(&domain.Job{ID: uuid.New()}, nil)
+				jobRepo.On("AcquireLease", mock.Anything, mock.Anything, agentID, mock.Anything).Return(false, nil)
+			},
+			expectedError: true,
+		},
+		{
+			name: "no job available",
+			mockSetup: func(jobRepo *MockJobRepository, hashFileRepo *MockHashFileRepository, wordlistRepo *MockWordlistRepository) {
+				jobRepo.On("GetAvailableJobForAgent", mock.Anything, agentID).Return(nil, errors.New("no available jobs for agent"))
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobRepo := new(MockJobRepository)
+			agentRepo := new(MockAgentRepository)
+			hashFileRepo := new(MockHashFileRepository)
+			wordlistRepo := new(MockWordlistRepository)
+
+			tt.mockSetup(jobRepo, hashFileRepo, wordlistRepo)
+			agentRepo.On("GetHashcatArgs", mock.Anything, agentID).Return([]string{"-O", "-n", "64"}, nil).Maybe()
+
+			usecase := usecase.NewJobUsecase(jobRepo, agentRepo, hashFileRepo, wordlistRepo)
+			job, err := usecase.GetAvailableJobForAgent(context.Background(), agentID)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, job)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedHashSHA, job.HashFileSHA256)
+				assert.Equal(t, tt.expectedWordlistSHA, job.WordlistSHA256)
+				assert.Equal(t, tt.expectedSize, job.WordlistSize)
+				assert.Equal(t, []string{"-O", "-n", "64"}, job.AgentArgs)
+			}
+
+			jobRepo.AssertExpectations(t)
+			hashFileRepo.AssertExpectations(t)
+			wordlistRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobUsecase_GetJobGroupStatus(t *testing.T) {
+	groupID := uuid.New()
+	fastAgent := uuid.New()
+	slowAgent := uuid.New()
+	fastLimit := int64(750)
+	slowLimit := int64(250)
+	fastETA := time.Now().Add(10 * time.Minute)
+	slowETA := time.Now().Add(time.Hour)
+
+	jobRepo := new(MockJobRepository)
+	agentRepo := new(MockAgentRepository)
+
+	jobRepo.On("GetGroupByID", mock.Anything, groupID).Return(&domain.JobGroup{ID: groupID, Name: "distributed"}, nil)
+	jobRepo.On("GetByGroupID", mock.Anything, groupID).Return([]domain.Job{
+		{ID: uuid.New(), Status: "running", AgentID: &fastAgent, Progress: 40, Speed: 3000, WordLimit: &fastLimit, ETA: &fastETA},
+		{ID: uuid.New(), Status: "running", AgentID: &slowAgent, Progress: 80, Speed: 1000, WordLimit: &slowLimit, ETA: &slowETA},
+	}, nil)
+	agentRepo.On("GetByID", mock.Anything, fastAgent).Return(&domain.Agent{ID: fastAgent, Name: "gpu-1"}, nil)
+	agentRepo.On("GetByID", mock.Anything, slowAgent).Return(&domain.Agent{ID: slowAgent, Name: "cpu-1"}, nil)
+
+	usecase := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	status, err := usecase.GetJobGroupStatus(context.Background(), groupID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "running", status.Status)
+	assert.InDelta(t, 50.0, status.Progress, 0.001) // 40*0.75 + 80*0.25
+	assert.Equal(t, int64(4000), status.Speed)
+	assert.Equal(t, slowETA, *status.ETA)
+	assert.Equal(t, 2, status.TotalJobs)
+	assert.Len(t, status.Agents, 2)
+	assert.Equal(t, "gpu-1", status.Agents[0].AgentName)
+	assert.Equal(t, int64(750), status.Agents[0].Keyspace)
+
+	t.Run("cracked group", func(t *testing.T) {
+		crackedID := uuid.New()
+		jobRepo.On("GetGroupByID", mock.Anything, crackedID).Return(&domain.JobGroup{ID: crackedID}, nil)
+		jobRepo.On("GetByGroupID", mock.Anything, crackedID).Return([]domain.Job{
+			{ID: uuid.New(), Status: domain.JobStatusCracked, Progress: 100},
+			{ID: uuid.New(), Status: domain.JobStatusCancelled, Progress: 100},
+		}, nil)
+
+		status, err := usecase.GetJobGroupStatus(context.Background(), crackedID)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.JobStatusCracked, status.Status)
+		assert.Equal(t, 1, status.CompletedJobs)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		missingID := uuid.New()
+		jobRepo.On("GetGroupByID", mock.Anything, missingID).Return(nil, &domain.NotFoundError{Entity: "job group"})
+
+		_, err := usecase.GetJobGroupStatus(context.Background(), missingID)
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
+
+func TestJobUsecase_RetryJob(t *testing.T) {
+	originalID := uuid.New()
+	hashFileID := uuid.New()
+	agentID := uuid.New()
+	skip := int64(500)
+	limit := int64(250)
+
+	original := func(status string) *domain.Job {
+		return &domain.Job{
+			ID:         originalID,
+			Name:       "office passwords",
+			Status:     status,
+			HashType:   2500,
+			AttackMode: 0,
+			HashFile:   "/uploads/office.hccapx",
+			HashFileID: &hashFileID,
+			Wordlist:   "rockyou.txt",
+			Rules:      "best64.rule",
+			Progress:   63.5,
+			Skip:       &skip,
+			WordLimit:  &limit,
+			Result:     "Exhausted",
+		}
+	}
+
+	t.Run("clones parameters and resets progress", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+
+		var created *domain.Job
+		jobRepo.On("GetByID", mock.Anything, originalID).Return(original("failed"), nil)
+		jobRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Job")).
+			Run(func(args mock.Arguments) { created = args.Get(1).(*domain.Job) }).
+			Return(nil)
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+		job, err := usecase.RetryJob(context.Background(), originalID, nil)
+
+		assert.NoError(t, err)
+		assert.Same(t, created, job)
+		assert.NotEqual(t, originalID, job.ID)
+		assert.Equal(t, originalID, *job.RetriedFrom)
+		assert.Equal(t, "pending", job.Status)
+		assert.Zero(t, job.Progress)
+		assert.Empty(t, job.Result)
+		assert.Equal(t, 2500, job.HashType)
+		assert.Equal(t, "rockyou.txt", job.Wordlist)
+		assert.Equal(t, "best64.rule", job.Rules)
+		assert.Equal(t, skip, *job.Skip)
+		assert.Equal(t, limit, *job.WordLimit)
+		jobRepo.AssertExpectations(t)
+	})
+
+	t.Run("moves the retry to another agent", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+
+		jobRepo.On("GetByID", mock.Anything, originalID).Return(original("cancelled"), nil)
+		jobRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Name: "gpu-2", Status: "online"}, nil)
+		// Auto-start of the new job
+		jobRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Job{Status: "pending", AgentID: &agentID}, nil)
+		jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil)
+
+		usecase := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+		job, err := usecase.RetryJob(context.Background(), originalID, &agentID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, agentID, *job.AgentID)
+		jobRepo.AssertExpectations(t)
+		agentRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects jobs that have not failed", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("GetByID", mock.Anything, originalID).Return(original("running"), nil)
+
+		usecase := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+		_, err := usecase.RetryJob(context.Background(), originalID, nil)
+
+		assert.True(t, domain.IsValidationError(err))
+		jobRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestJobUsecase_JobOutput(t *testing.T) {
+	jobID := uuid.New()
+	agentID := uuid.New()
+
+	t.Run("stores the output under the job's agent", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID, AgentID: &agentID}, nil)
+		var saved *domain.JobOutput
+		jobRepo.On("SaveOutput", mock.Anything, mock.AnythingOfType("*domain.JobOutput")).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*domain.JobOutput)
+		}).Return(nil)
+
+		uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+		exitCode := 255
+		err := uc.SaveJobOutput(context.Background(), jobID, &domain.JobOutput{
+			JobID:    uuid.New(), // Ignored, the URL names the job
+			ExitCode: &exitCode,
+			Stdout:   strings.Repeat("Dictionary cache hit\n", 20000),
+			Stderr:   "No hashes loaded.\n",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		assert.Equal(t, jobID, saved.JobID)
+		assert.Equal(t, agentID, *saved.AgentID)
+		assert.Equal(t, "No hashes loaded.\n", saved.Stderr)
+		assert.LessOrEqual(t, len(saved.Stdout), 256*1024)
+		assert.True(t, strings.HasPrefix(saved.Stdout, "Dictionary cache hit\n"), "cut at a line start")
+		assert.True(t, saved.Truncated)
+	})
+
+	t.Run("unknown jobs", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(nil, errors.New("job not found"))
+
+		uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+
+		assert.Error(t, uc.SaveJobOutput(context.Background(), jobID, &domain.JobOutput{Stderr: "boom"}))
+		_, err := uc.GetJobOutput(context.Background(), jobID)
+		assert.Error(t, err)
+		jobRepo.AssertNotCalled(t, "SaveOutput", mock.Anything, mock.Anything)
+	})
+}
+
+func TestJobUsecase_StateTransitions(t *testing.T) {
+	jobID := uuid.New()
+	agentID := uuid.New()
+
+	newUsecase := func(job *domain.Job) (usecase.JobUsecase, *MockJobRepository, *MockAgentRepository) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo.On("GetByID", mock.Anything, jobID).Return(job, nil)
+		jobRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Job")).Return(nil).Maybe()
+		agentRepo.On("UpdateStatus", mock.Anything, agentID, "online").Return(nil).Maybe()
+		return usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository)), jobRepo, agentRepo
+	}
+
+	t.Run("stopping a job cancels it and records who did it", func(t *testing.T) {
+		job := &domain.Job{ID: jobID, Status: domain.JobStatusRunning, AgentID: &agentID}
+		uc, jobRepo, agentRepo := newUsecase(job)
+
+		ctx := domain.WithActor(context.Background(), "user:admin")
+		require.NoError(t, uc.CancelJob(ctx, jobID, "Job stopped by user"))
+
+		assert.Equal(t, domain.JobStatusCancelled, job.Status)
+		require.Len(t, jobRepo.events, 1)
+		assert.Equal(t, domain.JobEvent{
+			JobID:      jobID,
+			FromStatus: domain.JobStatusRunning,
+			ToStatus:   domain.JobStatusCancelled,
+			Actor:      "user:admin",
+			Reason:     "Job stopped by user",
+		}, jobRepo.events[0])
+		agentRepo.AssertCalled(t, "UpdateStatus", mock.Anything, agentID, "online")
+	})
+
+	t.Run("only running jobs can be paused", func(t *testing.T) {
+		job := &domain.Job{ID: jobID, Status: domain.JobStatusPending}
+		uc, jobRepo, _ := newUsecase(job)
+
+		err := uc.PauseJob(context.Background(), jobID)
+
+		assert.True(t, domain.IsJobTransitionError(err))
+		jobRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		assert.Empty(t, jobRepo.events)
+	})
+
+	t.Run("resumed jobs go back to their agent", func(t *testing.T) {
+		job := &domain.Job{ID: jobID, Status: domain.JobStatusPaused, AgentID: &agentID}
+		uc, jobRepo, _ := newUsecase(job)
+
+		require.NoError(t, uc.ResumeJob(context.Background(), jobID))
+
+		assert.Equal(t, domain.JobStatusAssigned, job.Status)
+		require.Len(t, jobRepo.events, 1)
+		assert.Equal(t, domain.ActorSystem, jobRepo.events[0].Actor)
+	})
+
+	t.Run("finished jobs can't fail", func(t *testing.T) {
+		job := &domain.Job{ID: jobID, Status: domain.JobStatusCancelled, Result: "Password found by another agent - job cancelled"}
+		uc, _, _ := newUsecase(job)
+
+		err := uc.FailJob(context.Background(), jobID, "Hashcat execution failed: signal: killed")
+
+		assert.True(t, domain.IsJobTransitionError(err))
+		assert.Equal(t, "Password found by another agent - job cancelled", job.Result)
+	})
+
+	t.Run("event timeline", func(t *testing.T) {
+		uc, jobRepo, _ := newUsecase(&domain.Job{ID: jobID, Status: domain.JobStatusRunning})
+		events := []domain.JobEvent{
+			{JobID: jobID, ToStatus: domain.JobStatusPending, Actor: domain.ActorAPI, Reason: "created"},
+			{JobID: jobID, FromStatus: domain.JobStatusPending, ToStatus: domain.JobStatusRunning, Actor: domain.ActorAgent},
+		}
+		jobRepo.On("GetEvents", mock.Anything, jobID).Return(events, nil)
+
+		timeline, err := uc.GetJobEvents(context.Background(), jobID)
+
+		require.NoError(t, err)
+		assert.Equal(t, events, timeline)
+	})
+}
+
+func TestJobUsecase_TagsAndComments(t *testing.T) {
+	ctx := context.Background()
+	jobID := uuid.New()
+	jobRepo := new(MockJobRepository)
+	uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+
+	// Tags are trimmed and deduplicated ignoring case
+	jobRepo.On("UpdateTags", mock.Anything, jobID, []string{"Client-X", "retest"}).Return(nil).Once()
+	tags, err := uc.SetJobTags(ctx, jobID, []string{" Client-X ", "retest", "client-x", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Client-X", "retest"}, tags)
+
+	for _, bad := range [][]string{
+		{"a,b"},
+		{strings.Repeat("x", domain.MaxJobTagLength+1)},
+	} {
+		_, err := uc.SetJobTags(ctx, jobID, bad)
+		assert.True(t, domain.IsValidationError(err), "%v", bad)
+	}
+	tooMany := make([]string, domain.MaxJobTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+	_, err = uc.SetJobTags(ctx, jobID, tooMany)
+	assert.True(t, domain.IsValidationError(err))
+
+	jobRepo.On("GetByID", mock.Anything, jobID).Return(&domain.Job{ID: jobID}, nil)
+	jobRepo.On("CreateComment", mock.Anything, mock.MatchedBy(func(comment *domain.JobComment) bool {
+		return comment.JobID == jobID && comment.Body == "Scope confirmed"
+	})).Return(nil).Once()
+	require.NoError(t, uc.AddJobComment(ctx, jobID, &domain.JobComment{Author: "alice", Body: "  Scope confirmed\n"}))
+
+	err = uc.AddJobComment(ctx, jobID, &domain.JobComment{Author: "alice", Body: "   "})
+	assert.True(t, domain.IsValidationError(err))
+
+	jobRepo.AssertExpectations(t)
+}
@@ -0,0 +1,71 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobUsecase_ExpireStalledJobs(t *testing.T) {
+	agentID := uuid.New()
+	overdueID, freshID, unlimitedID := uuid.New(), uuid.New(), uuid.New()
+	startedAt := time.Now().Add(-10 * time.Minute)
+
+	jobRepo := new(MockJobRepository)
+	agentRepo := new(MockAgentRepository)
+	jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusRunning).Return([]domain.Job{
+		{ID: overdueID, Status: domain.JobStatusRunning, AgentID: &agentID, StartedAt: &startedAt, MaxRuntimeMinutes: 5},
+		// Seen for the first time, so its stall clock starts now
+		{ID: freshID, Status: domain.JobStatusRunning, AgentID: &agentID, StartedAt: &startedAt, StallTimeoutMinutes: 1},
+		{ID: unlimitedID, Status: domain.JobStatusRunning, AgentID: &agentID, StartedAt: &startedAt},
+	}, nil)
+	var failed *domain.Job
+	jobRepo.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		failed = args.Get(1).(*domain.Job)
+	}).Return(nil).Once()
+	agentRepo.On("UpdateStatus", mock.Anything, agentID, "online").Return(nil)
+
+	uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+	uc.SetJobLease(time.Minute)
+	stopped, err := uc.ExpireStalledJobs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stopped)
+
+	// Only the job over its max runtime, plus the agent's grace period, is stopped
+	require.NotNil(t, failed)
+	assert.Equal(t, overdueID, failed.ID)
+	assert.Equal(t, domain.JobStatusFailed, failed.Status)
+	assert.Equal(t, "Max runtime of 5 minutes exceeded", failed.Result)
+	require.Len(t, jobRepo.events, 1)
+	assert.Equal(t, failed.Result, jobRepo.events[0].Reason)
+	jobRepo.AssertExpectations(t)
+	agentRepo.AssertExpectations(t)
+}
+
+func TestJobTimeouts(t *testing.T) {
+	defaults := domain.JobTimeouts{MaxRuntimeMinutes: 600, StallTimeoutMinutes: 60}
+	zero, ten := 0, 10
+
+	maxRuntime, stallTimeout := defaults.Resolve(nil, nil)
+	assert.Equal(t, 600, maxRuntime)
+	assert.Equal(t, 60, stallTimeout)
+
+	// A job may set its own limits, or turn them off with 0
+	maxRuntime, stallTimeout = defaults.Resolve(&ten, &zero)
+	assert.Equal(t, 10, maxRuntime)
+	assert.Equal(t, 0, stallTimeout)
+
+	assert.NoError(t, domain.ValidateJobTimeouts(&zero, nil))
+	negative := -1
+	var validationErr *domain.ValidationError
+	require.ErrorAs(t, domain.ValidateJobTimeouts(nil, &negative), &validationErr)
+	assert.Equal(t, "stall_timeout_minutes", validationErr.Field)
+}
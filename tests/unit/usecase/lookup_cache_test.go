@@ -0,0 +1,92 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupCache_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	agentID := uuid.New()
+
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Name: "gpu-01"}, nil).Once()
+
+	lookups := usecase.NewLookupCache(agentRepo, new(MockWordlistRepository), new(MockHashFileRepository), usecase.DefaultLookupCacheTTL)
+	for i := 0; i < 3; i++ {
+		agent, err := lookups.GetAgent(ctx, agentID)
+		require.NoError(t, err)
+		assert.Equal(t, "gpu-01", agent.Name)
+	}
+
+	agentRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	stats := lookups.Stats()
+	assert.Equal(t, int64(2), stats["cacheHits"])
+	assert.Equal(t, int64(1), stats["cacheMisses"])
+	assert.Equal(t, 1, stats["agents"])
+}
+
+func TestLookupCache_InvalidatedOnAgentChange(t *testing.T) {
+	ctx := context.Background()
+	agentID := uuid.New()
+
+	agentRepo := new(MockAgentRepository)
+	agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, Name: "gpu-01"}, nil).Once()
+	agentRepo.On("Delete", mock.Anything, agentID).Return(nil).Once()
+
+	lookups := usecase.NewLookupCache(agentRepo, new(MockWordlistRepository), new(MockHashFileRepository), usecase.DefaultLookupCacheTTL)
+	agentUsecase := usecase.NewAgentUsecase(agentRepo)
+	agentUsecase.SetLookupCache(lookups)
+
+	_, err := lookups.GetAgent(ctx, agentID)
+	require.NoError(t, err)
+
+	// Deleting the agent drops it from the cache, the next lookup goes to the repository
+	require.NoError(t, agentUsecase.DeleteAgent(ctx, agentID))
+	agentRepo.On("GetByID", mock.Anything, agentID).Return(nil, domain.ErrAgentNotFound).Once()
+	_, err = lookups.GetAgent(ctx, agentID)
+	assert.Error(t, err)
+
+	agentRepo.AssertExpectations(t)
+	assert.Equal(t, int64(1), lookups.Stats()["invalidations"])
+}
+
+func TestLookupCache_Warm(t *testing.T) {
+	ctx := context.Background()
+	agentID, wordlistID, hashFileID := uuid.New(), uuid.New(), uuid.New()
+
+	agentRepo := new(MockAgentRepository)
+	wordlistRepo := new(MockWordlistRepository)
+	hashFileRepo := new(MockHashFileRepository)
+	agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{{ID: agentID, Name: "gpu-01"}}, nil).Once()
+	wordlistRepo.On("GetAll", mock.Anything).Return([]domain.Wordlist{{ID: wordlistID, Name: "stored.txt", OrigName: "rockyou.txt"}}, nil).Once()
+	hashFileRepo.On("GetAll", mock.Anything).Return([]domain.HashFile{{ID: hashFileID, Name: "stored.hccapx", OrigName: "office.hccapx"}}, nil).Once()
+
+	lookups := usecase.NewLookupCache(agentRepo, wordlistRepo, hashFileRepo, usecase.DefaultLookupCacheTTL)
+	require.NoError(t, lookups.Warm(ctx))
+	assert.NotNil(t, lookups.Stats()["warmedAt"])
+
+	// Enriching a job list after warm-up needs no repository lookups
+	enrichment := usecase.NewJobEnrichmentService(lookups)
+	enriched, err := enrichment.EnrichJobs(ctx, []domain.Job{
+		{ID: uuid.New(), AgentID: &agentID, Wordlist: wordlistID.String(), HashFileID: &hashFileID},
+	})
+	require.NoError(t, err)
+	require.Len(t, enriched, 1)
+	assert.Equal(t, "gpu-01", enriched[0].AgentName)
+	assert.Equal(t, "rockyou.txt", enriched[0].WordlistName)
+	assert.Equal(t, "office.hccapx", enriched[0].HashFileName)
+
+	agentRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	wordlistRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	hashFileRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	assert.Equal(t, int64(0), lookups.Stats()["cacheMisses"])
+}
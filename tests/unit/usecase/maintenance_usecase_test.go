@@ -0,0 +1,266 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMaintenanceRepository is a mock implementation of domain.MaintenanceRepository
+type MockMaintenanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceRepository) Create(ctx context.Context, window *domain.MaintenanceWindow) error {
+	args := m.Called(ctx, window)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MaintenanceWindow), args.Error(1)
+}
+
+func (m *MockMaintenanceRepository) GetAll(ctx context.Context) ([]domain.MaintenanceWindow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.MaintenanceWindow), args.Error(1)
+}
+
+func (m *MockMaintenanceRepository) Update(ctx context.Context, window *domain.MaintenanceWindow) error {
+	args := m.Called(ctx, window)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// fixedCalendar puts the same agents in maintenance at any time
+type fixedCalendar map[uuid.UUID]domain.AgentMaintenance
+
+func (c fixedCalendar) AgentsInMaintenance(ctx context.Context, at time.Time) (map[uuid.UUID]domain.AgentMaintenance, error) {
+	return c, nil
+}
+
+func TestMaintenanceUsecase_AgentsInMaintenance(t *testing.T) {
+	workstation, nightAgent, groupAgent := uuid.New(), uuid.New(), uuid.New()
+	groupID := uuid.New()
+	outageStart := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	outageEnd := outageStart.Add(4 * time.Hour)
+
+	windows := []domain.MaintenanceWindow{
+		// Office hours on a shared workstation, Berlin is UTC+2 in October
+		{ID: uuid.New(), Name: "office hours", AgentID: &workstation,
+			Days: []string{"mon", "tue", "wed", "thu", "fri"}, StartTime: "09:00", EndTime: "17:00", Timezone: "Europe/Berlin"},
+		// Nightly backups, past midnight
+		{ID: uuid.New(), Name: "backups", AgentID: &nightAgent, StartTime: "22:00", EndTime: "02:00"},
+		{ID: uuid.New(), Name: "rack move", AgentGroupID: &groupID, StartsAt: &outageStart, EndsAt: &outageEnd},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected map[uuid.UUID]time.Time // Agent -> end of its window
+	}{
+		{"wednesday morning in Berlin", time.Date(2026, 10, 14, 7, 0, 0, 0, time.UTC),
+			map[uuid.UUID]time.Time{workstation: time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)}},
+		{"before office hours start", time.Date(2026, 10, 14, 6, 59, 0, 0, time.UTC), map[uuid.UUID]time.Time{}},
+		{"office hours end", time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC), map[uuid.UUID]time.Time{}},
+		{"late evening", time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC),
+			map[uuid.UUID]time.Time{nightAgent: time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)}},
+		{"after midnight", time.Date(2026, 10, 15, 1, 30, 0, 0, time.UTC),
+			map[uuid.UUID]time.Time{nightAgent: time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)}},
+		{"saturday outage", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC),
+			map[uuid.UUID]time.Time{groupAgent: outageEnd}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maintenanceRepo := new(MockMaintenanceRepository)
+			agentRepo := new(MockAgentRepository)
+			maintenanceRepo.On("GetAll", mock.Anything).Return(windows, nil)
+			agentRepo.On("GetByGroupID", mock.Anything, groupID).Return([]domain.Agent{{ID: groupAgent}}, nil).Maybe()
+
+			uc := usecase.NewMaintenanceUsecase(maintenanceRepo, agentRepo)
+			inMaintenance, err := uc.AgentsInMaintenance(context.Background(), tt.at)
+			require.NoError(t, err)
+
+			until := make(map[uuid.UUID]time.Time, len(inMaintenance))
+			for agentID, maintenance := range inMaintenance {
+				until[agentID] = maintenance.Until.UTC()
+			}
+			assert.Equal(t, tt.expected, until)
+		})
+	}
+}
+
+func TestMaintenanceUsecase_ClusterWideWindow(t *testing.T) {
+	agentA, agentB := uuid.New(), uuid.New()
+	start := time.Now().Add(-time.Hour)
+	short, long := start.Add(2*time.Hour), start.Add(5*time.Hour)
+
+	maintenanceRepo := new(MockMaintenanceRepository)
+	agentRepo := new(MockAgentRepository)
+	maintenanceRepo.On("GetAll", mock.Anything).Return([]domain.MaintenanceWindow{
+		{ID: uuid.New(), Name: "upgrade", StartsAt: &start, EndsAt: &short},
+		{ID: uuid.New(), Name: "repair", AgentID: &agentB, StartsAt: &start, EndsAt: &long},
+	}, nil)
+	agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{{ID: agentA}, {ID: agentB}}, nil)
+
+	uc := usecase.NewMaintenanceUsecase(maintenanceRepo, agentRepo)
+	agents, err := uc.GetAgentsInMaintenance(context.Background())
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.Equal(t, agentA, agents[0].AgentID)
+	assert.Equal(t, "upgrade", agents[0].Window)
+	// Overlapping windows keep an agent out until the last one ends
+	assert.Equal(t, agentB, agents[1].AgentID)
+	assert.Equal(t, "repair", agents[1].Window)
+}
+
+func TestMaintenanceUsecase_CreateWindow(t *testing.T) {
+	agentID := uuid.New()
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		req           domain.MaintenanceWindowRequest
+		expectedField string
+	}{
+		{"weekly", domain.MaintenanceWindowRequest{Name: "office", AgentID: agentID.String(), Days: []string{"Mon", "fri"}, StartTime: "08:30", EndTime: "18:00", Timezone: "America/New_York"}, ""},
+		{"one-off", domain.MaintenanceWindowRequest{Name: "upgrade", StartsAt: &now, EndsAt: &later}, ""},
+		{"no schedule", domain.MaintenanceWindowRequest{Name: "empty"}, "starts_at"},
+		{"both schedules", domain.MaintenanceWindowRequest{Name: "mixed", StartsAt: &now, EndsAt: &later, StartTime: "09:00", EndTime: "17:00"}, "starts_at"},
+		{"ends before it starts", domain.MaintenanceWindowRequest{Name: "backwards", StartsAt: &later, EndsAt: &now}, "ends_at"},
+		{"bad time of day", domain.MaintenanceWindowRequest{Name: "office", StartTime: "9am", EndTime: "17:00"}, "start_time"},
+		{"unknown day", domain.MaintenanceWindowRequest{Name: "office", Days: []string{"someday"}, StartTime: "09:00", EndTime: "17:00"}, "days"},
+		{"unknown time zone", domain.MaintenanceWindowRequest{Name: "office", StartTime: "09:00", EndTime: "17:00", Timezone: "Mars/Olympus"}, "timezone"},
+		{"agent and group", domain.MaintenanceWindowRequest{Name: "office", AgentID: agentID.String(), AgentGroupID: uuid.New().String(), StartTime: "09:00", EndTime: "17:00"}, "agent_group_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maintenanceRepo := new(MockMaintenanceRepository)
+			agentRepo := new(MockAgentRepository)
+			agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID}, nil).Maybe()
+			maintenanceRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+			uc := usecase.NewMaintenanceUsecase(maintenanceRepo, agentRepo)
+			window, err := uc.CreateWindow(context.Background(), &tt.req)
+
+			if tt.expectedField == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.req.Name, window.Name)
+				maintenanceRepo.AssertCalled(t, "Create", mock.Anything, window)
+				return
+			}
+			var vErr *domain.ValidationError
+			require.ErrorAs(t, err, &vErr)
+			assert.Equal(t, tt.expectedField, vErr.Field)
+			maintenanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("unknown agent", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByID", mock.Anything, agentID).Return(nil, &domain.NotFoundError{Entity: "agent"})
+
+		uc := usecase.NewMaintenanceUsecase(new(MockMaintenanceRepository), agentRepo)
+		_, err := uc.CreateWindow(context.Background(), &domain.MaintenanceWindowRequest{Name: "office", AgentID: agentID.String(), StartTime: "09:00", EndTime: "17:00"})
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
+
+func TestJobUsecase_MaintenanceWindows(t *testing.T) {
+	busyID, freeID := uuid.New(), uuid.New()
+	calendar := fixedCalendar{busyID: {AgentID: busyID, Window: "office hours", Until: time.Now().Add(time.Hour)}}
+
+	t.Run("dispatcher passes over agents in maintenance", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		jobRepo.On("GetByStatus", mock.Anything, "pending").Return([]domain.Job{{ID: uuid.New(), Status: domain.JobStatusPending}}, nil)
+		jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusInterrupted).Return([]domain.Job{}, nil)
+		// The agent in maintenance is faster, but must not get the job
+		agentRepo.On("GetAll", mock.Anything).Return([]domain.Agent{
+			{ID: busyID, Status: "online", Speed: 9000},
+			{ID: freeID, Status: "online", Speed: 10},
+		}, nil)
+		agentRepo.On("GetSettings", mock.Anything, freeID).Return(&domain.AgentSettings{}, nil)
+		agentRepo.On("UpdateStatus", mock.Anything, freeID, "busy").Return(nil)
+		expectIdleCluster(jobRepo)
+		jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+			return job.AgentID != nil && *job.AgentID == freeID
+		})).Return(nil)
+
+		uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+		uc.SetMaintenanceCalendar(calendar)
+		require.NoError(t, uc.AssignJobsToAgents(context.Background()))
+		jobRepo.AssertExpectations(t)
+		agentRepo.AssertExpectations(t)
+	})
+
+	t.Run("agents in maintenance get no job to run", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		uc := usecase.NewJobUsecase(jobRepo, new(MockAgentRepository), new(MockHashFileRepository), new(MockWordlistRepository))
+		uc.SetMaintenanceCalendar(calendar)
+
+		job, err := uc.GetAvailableJobForAgent(context.Background(), busyID)
+		assert.Error(t, err)
+		assert.Nil(t, job)
+		jobRepo.AssertNotCalled(t, "GetAvailableJobForAgent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("jobs can't target an agent in maintenance", func(t *testing.T) {
+		hashFileID := uuid.New()
+		hashFileRepo := new(MockHashFileRepository)
+		agentRepo := new(MockAgentRepository)
+		hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Path: "/tmp/hashes.txt"}, nil)
+		agentRepo.On("GetByID", mock.Anything, busyID).Return(&domain.Agent{ID: busyID, Name: "workstation", Status: "online"}, nil)
+
+		uc := usecase.NewJobUsecase(new(MockJobRepository), agentRepo, hashFileRepo, new(MockWordlistRepository))
+		uc.SetMaintenanceCalendar(calendar)
+		_, err := uc.CreateJob(context.Background(), &domain.CreateJobRequest{
+			Name: "job", HashFileID: hashFileID.String(), AttackMode: domain.AttackModeBruteForce, Wordlist: "?d?d?d?d", AgentID: busyID.String(),
+		})
+		var vErr *domain.ValidationError
+		require.ErrorAs(t, err, &vErr)
+		assert.Contains(t, vErr.Message, "maintenance window office hours")
+	})
+
+	t.Run("draining hands queued jobs back and leaves running ones", func(t *testing.T) {
+		jobRepo := new(MockJobRepository)
+		agentRepo := new(MockAgentRepository)
+		queued := domain.Job{ID: uuid.New(), Status: domain.JobStatusAssigned, AgentID: &freeID}
+		jobRepo.On("GetByAgentID", mock.Anything, freeID).Return([]domain.Job{
+			queued,
+			{ID: uuid.New(), Status: domain.JobStatusCompleted, AgentID: &freeID},
+		}, nil)
+		jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job *domain.Job) bool {
+			return job.ID == queued.ID && job.Status == domain.JobStatusPending && job.AgentID == nil
+		})).Return(nil)
+		agentRepo.On("GetByID", mock.Anything, freeID).Return(&domain.Agent{ID: freeID, Status: "busy"}, nil)
+		agentRepo.On("UpdateStatus", mock.Anything, freeID, "online").Return(nil)
+
+		uc := usecase.NewJobUsecase(jobRepo, agentRepo, new(MockHashFileRepository), new(MockWordlistRepository))
+		drained, err := uc.DrainAgent(context.Background(), freeID, "agent entered maintenance window office hours")
+		require.NoError(t, err)
+		assert.Equal(t, 1, drained)
+		jobRepo.AssertExpectations(t)
+		agentRepo.AssertExpectations(t)
+		require.Len(t, jobRepo.events, 1)
+		assert.Equal(t, "agent entered maintenance window office hours", jobRepo.events[0].Reason)
+	})
+}
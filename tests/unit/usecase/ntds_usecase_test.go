@@ -0,0 +1,273 @@
+package usecase_test
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryNTDSRepository keeps NTDS imports and their accounts in memory
+type memoryNTDSRepository struct {
+	imports  map[uuid.UUID]domain.NTDSImport
+	accounts map[uuid.UUID][]domain.NTDSAccount
+}
+
+func newMemoryNTDSRepository() *memoryNTDSRepository {
+	return &memoryNTDSRepository{imports: map[uuid.UUID]domain.NTDSImport{}, accounts: map[uuid.UUID][]domain.NTDSAccount{}}
+}
+
+func (r *memoryNTDSRepository) Create(ctx context.Context, ntdsImport *domain.NTDSImport, accounts []domain.NTDSAccount) error {
+	ntdsImport.ID = uuid.New()
+	r.imports[ntdsImport.ID] = *ntdsImport
+	for i := range accounts {
+		accounts[i].ID = uuid.New()
+		accounts[i].ImportID = ntdsImport.ID
+	}
+	r.accounts[ntdsImport.ID] = accounts
+	return nil
+}
+
+func (r *memoryNTDSRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.NTDSImport, error) {
+	ntdsImport, ok := r.imports[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "NTDS import"}
+	}
+	return &ntdsImport, nil
+}
+
+func (r *memoryNTDSRepository) GetAll(ctx context.Context, projectID *uuid.UUID) ([]domain.NTDSImport, error) {
+	imports := []domain.NTDSImport{}
+	for _, ntdsImport := range r.imports {
+		imports = append(imports, ntdsImport)
+	}
+	return imports, nil
+}
+
+func (r *memoryNTDSRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.imports, id)
+	delete(r.accounts, id)
+	return nil
+}
+
+func (r *memoryNTDSRepository) GetAccounts(ctx context.Context, importID uuid.UUID, filter domain.NTDSAccountFilter) ([]domain.NTDSAccount, int, error) {
+	accounts := []domain.NTDSAccount{}
+	for _, account := range r.accounts[importID] {
+		if filter.Cracked != nil && account.Cracked != *filter.Cracked {
+			continue
+		}
+		if filter.Privileged != nil && account.Privileged != *filter.Privileged {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, len(accounts), nil
+}
+
+func (r *memoryNTDSRepository) MarkCracked(ctx context.Context, importID uuid.UUID, ntHashes []string, at time.Time) (int, error) {
+	marked := 0
+	for i, account := range r.accounts[importID] {
+		for _, hash := range ntHashes {
+			if !account.Cracked && account.NTHash == hash {
+				r.accounts[importID][i].Cracked = true
+				r.accounts[importID][i].CrackedAt = &at
+				marked++
+				break
+			}
+		}
+	}
+	return marked, nil
+}
+
+func (r *memoryNTDSRepository) SetPrivileged(ctx context.Context, importID uuid.UUID, usernames []string) (int, error) {
+	privileged := 0
+	for i, account := range r.accounts[importID] {
+		r.accounts[importID][i].Privileged = account.RID == domain.BuiltinAdministratorRID
+		for _, username := range usernames {
+			if strings.EqualFold(account.Username, username) {
+				r.accounts[importID][i].Privileged = true
+			}
+		}
+		if r.accounts[importID][i].Privileged {
+			privileged++
+		}
+	}
+	return privileged, nil
+}
+
+// ntdsDump is secretsdump output; alice's NT hash is that of "password",
+// carol and dave share one and guest has none
+const ntdsDump = `Impacket v0.12.0 - Copyright 2023 Fortra
+
+[*] Dumping Domain Credentials (domain\uid:rid:lmhash:nthash)
+[*] Using the DRSUAPI method to get NTDS.DIT secrets
+CORP.LOCAL\Administrator:500:aad3b435b51404eeaad3b435b51404ee:64f12cddaa88057e06a81b54e73b949b::: (status=Enabled)
+Guest:501:aad3b435b51404eeaad3b435b51404ee:31d6cfe0d16ae931b73c59d7e0c089c0::: (status=Disabled)
+krbtgt:502:aad3b435b51404eeaad3b435b51404ee:b21c99fc068e3ab2ca789bccbef67de4::: (status=Disabled)
+CORP.LOCAL\alice:1104:aad3b435b51404eeaad3b435b51404ee:8846F7EAEE8FB117AD06BDD830B7586C::: (status=Enabled)
+CORP.LOCAL\alice_history0:1104:aad3b435b51404eeaad3b435b51404ee:5835048ce94ad0564e29a924a03510ef:::
+CORP.LOCAL\carol:1105:aad3b435b51404eeaad3b435b51404ee:2d20d252a479f485cdf5e171d93985bf::: (status=Enabled)
+CORP.LOCAL\dave:1106:aad3b435b51404eeaad3b435b51404ee:2d20d252a479f485cdf5e171d93985bf::: (status=Enabled)
+WS01$:1107:aad3b435b51404eeaad3b435b51404ee:c5a237b7e9d8e708d8436b6148a25fa1:::
+[*] Kerberos keys grabbed
+CORP.LOCAL\alice:aes256-cts-hmac-sha1-96:0f2c0e2d4a6b8c1e3f5a7b9d1c3e5f7a9b1d3f5a7c9e1b3d5f7a9c1e3b5d7f9a
+[*] Cleaning up...
+`
+
+type ntdsFixture struct {
+	ntds      usecase.NTDSUsecase
+	ntdsRepo  *memoryNTDSRepository
+	fileRepo  *memoryHashFileRepository
+	jobRepo   *MockJobRepository
+	imported  *domain.NTDSImport
+	hashFiles usecase.HashFileUsecase
+}
+
+func newNTDSFixture(t *testing.T) *ntdsFixture {
+	f := &ntdsFixture{
+		ntdsRepo: newMemoryNTDSRepository(),
+		fileRepo: &memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}},
+		jobRepo:  new(MockJobRepository),
+	}
+	f.hashFiles = usecase.NewHashFileUsecase(f.fileRepo, t.TempDir())
+	f.ntds = usecase.NewNTDSUsecase(f.ntdsRepo, f.hashFiles, f.jobRepo)
+
+	imported, err := f.ntds.ImportNTDS(context.Background(), "corp.ntds", strings.NewReader(ntdsDump), &domain.ImportNTDSRequest{
+		Domain:     "CORP.LOCAL",
+		Privileged: []string{"CORP.LOCAL\\alice", " krbtgt "},
+	})
+	require.NoError(t, err)
+	f.imported = imported
+	return f
+}
+
+func (f *ntdsFixture) account(username string) domain.NTDSAccount {
+	accounts, _, _ := f.ntdsRepo.GetAccounts(context.Background(), f.imported.ID, domain.NTDSAccountFilter{})
+	for _, account := range accounts {
+		if account.Username == username {
+			return account
+		}
+	}
+	return domain.NTDSAccount{}
+}
+
+func TestNTDSUsecase_ImportNTDS(t *testing.T) {
+	f := newNTDSFixture(t)
+
+	assert.Equal(t, "corp.ntds", f.imported.Name)
+	assert.Equal(t, 6, f.imported.Accounts)
+	assert.Equal(t, 5, f.imported.UniqueHashes)
+	assert.Equal(t, 1, f.imported.MachineAccounts)
+	assert.Equal(t, 1, f.imported.HistoryEntries)
+	assert.Equal(t, 6, f.imported.SkippedLines, "banner, status and Kerberos key lines")
+
+	administrator := f.account("Administrator")
+	assert.Equal(t, "CORP.LOCAL", administrator.Domain)
+	assert.True(t, administrator.Privileged, "the built-in Administrator is always privileged")
+	require.NotNil(t, administrator.Enabled)
+	assert.True(t, *administrator.Enabled)
+
+	guest := f.account("Guest")
+	assert.Equal(t, "CORP.LOCAL", guest.Domain, "unqualified accounts get the import's domain")
+	require.NotNil(t, guest.Enabled)
+	assert.False(t, *guest.Enabled)
+	assert.True(t, guest.Cracked, "an empty password is known")
+
+	alice := f.account("alice")
+	assert.True(t, alice.Privileged)
+	assert.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c", alice.NTHash)
+	assert.False(t, alice.Cracked)
+	assert.True(t, f.account("krbtgt").Privileged)
+	assert.False(t, f.account("carol").Privileged)
+
+	// The hash file holds each NT hash once, to crack as mode 1000
+	require.NotNil(t, f.imported.HashFileID)
+	hashFile, err := f.fileRepo.GetByID(context.Background(), *f.imported.HashFileID)
+	require.NoError(t, err)
+	assert.Equal(t, "corp.nt.txt", hashFile.OrigName)
+	content, err := os.ReadFile(hashFile.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "64f12cddaa88057e06a81b54e73b949b\n31d6cfe0d16ae931b73c59d7e0c089c0\nb21c99fc068e3ab2ca789bccbef67de4\n"+
+		"8846f7eaee8fb117ad06bdd830b7586c\n2d20d252a479f485cdf5e171d93985bf\n", string(content))
+}
+
+func TestNTDSUsecase_ImportNTDSWithoutAccounts(t *testing.T) {
+	fileRepo := &memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}
+	ntds := usecase.NewNTDSUsecase(newMemoryNTDSRepository(), usecase.NewHashFileUsecase(fileRepo, t.TempDir()), new(MockJobRepository))
+
+	_, err := ntds.ImportNTDS(context.Background(), "hashes.txt", strings.NewReader("5d41402abc4b2a76b9719d911017c592\n"), &domain.ImportNTDSRequest{})
+	assert.True(t, domain.IsValidationError(err))
+	assert.Empty(t, fileRepo.files, "no hash file is created")
+}
+
+func TestNTDSUsecase_Report(t *testing.T) {
+	f := newNTDSFixture(t)
+	ctx := context.Background()
+
+	// A job on the import's hash file cracked alice, another job's result
+	// doesn't count
+	completed := time.Now().Add(-time.Hour)
+	f.jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusCracked).Return([]domain.Job{
+		{ID: uuid.New(), HashType: domain.NTLMHashType, HashFileID: f.imported.HashFileID, Result: "Password found: password", CompletedAt: &completed},
+		{ID: uuid.New(), HashType: domain.NTLMHashType, HashFileID: &uuid.Nil, Result: "Password found: Summer2026!"},
+	}, nil)
+
+	// carol's hash is in a potfile, and so is dave's password, the same
+	cracked, err := f.ntds.ImportCracked(ctx, f.imported.ID, strings.NewReader("2d20d252a479f485cdf5e171d93985bf:Summer2026!\nnot a potfile line\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, cracked)
+
+	report, err := f.ntds.GetReport(ctx, f.imported.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.NTDSCoverage{Accounts: 6, Cracked: 4, Percent: 66.7}, report.All)
+	assert.Equal(t, domain.NTDSCoverage{Accounts: 4, Cracked: 3, Percent: 75}, report.Enabled)
+	assert.Equal(t, domain.NTDSCoverage{Accounts: 3, Cracked: 1, Percent: 33.3}, report.Privileged)
+	assert.Equal(t, []string{"CORP.LOCAL\\alice"}, report.CrackedPrivileged)
+	assert.Equal(t, 5, report.UniqueHashes)
+	assert.Equal(t, 3, report.CrackedHashes)
+	assert.Equal(t, 2, report.SharedHashAccounts)
+	assert.Equal(t, 1, report.EmptyPasswords)
+
+	alice := f.account("alice")
+	assert.True(t, alice.Cracked)
+	require.NotNil(t, alice.CrackedAt)
+	assert.WithinDuration(t, completed, *alice.CrackedAt, time.Second, "cracked when the job completed")
+
+	_, err = f.ntds.ImportCracked(ctx, f.imported.ID, strings.NewReader("nothing here\n"))
+	assert.True(t, domain.IsValidationError(err))
+	_, err = f.ntds.GetReport(ctx, uuid.New())
+	assert.True(t, domain.IsNotFoundError(err))
+}
+
+func TestNTDSUsecase_SetPrivileged(t *testing.T) {
+	f := newNTDSFixture(t)
+	f.jobRepo.On("GetByStatus", mock.Anything, domain.JobStatusCracked).Return([]domain.Job{}, nil)
+
+	privileged, err := f.ntds.SetPrivileged(context.Background(), f.imported.ID, &domain.SetNTDSPrivilegedRequest{Usernames: []string{"CORP.LOCAL\\carol", "DAVE"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, privileged)
+
+	accounts, total, err := f.ntds.GetAccounts(context.Background(), f.imported.ID, domain.NTDSAccountFilter{Privileged: ptrBool(true)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	var usernames []string
+	for _, account := range accounts {
+		usernames = append(usernames, account.Username)
+	}
+	sort.Strings(usernames)
+	assert.Equal(t, []string{"Administrator", "carol", "dave"}, usernames)
+}
+
+func ptrBool(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,118 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryArchiveRepository exports a fixed archive and keeps what is imported
+type memoryArchiveRepository struct {
+	export   *domain.ProjectArchive
+	imported *domain.ProjectArchive
+}
+
+func (r *memoryArchiveRepository) Export(ctx context.Context, projectID uuid.UUID) (*domain.ProjectArchive, error) {
+	if r.export == nil || r.export.Project.ID != projectID {
+		return nil, &domain.NotFoundError{Entity: "project"}
+	}
+	return r.export, nil
+}
+
+func (r *memoryArchiveRepository) Import(ctx context.Context, archive *domain.ProjectArchive) (int, error) {
+	r.imported = archive
+	return len(archive.Credentials), nil
+}
+
+func TestProjectArchiveUsecase_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	project := domain.Project{ID: uuid.New(), Name: "acme", CreatedAt: time.Now()}
+
+	// The exporting server has the project's hash file; the shared one its
+	// second job used is gone
+	source := usecase.NewHashFileUsecase(&memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}, t.TempDir())
+	hashFile, err := source.UploadHashFile(ctx, "corp.nt.txt", strings.NewReader("8846f7eaee8fb117ad06bdd830b7586c\n"), 33, &project.ID)
+	require.NoError(t, err)
+	goneID, agentID := uuid.New(), uuid.New()
+	running := domain.Job{ID: uuid.New(), Name: "rockyou", Status: domain.JobStatusRunning, HashFileID: &hashFile.ID,
+		HashFile: hashFile.Path, AgentID: &agentID, ProjectID: &project.ID}
+	finished := domain.Job{ID: uuid.New(), Name: "old", Status: domain.JobStatusCracked, HashFileID: &goneID, ProjectID: &project.ID}
+	archiveRepo := &memoryArchiveRepository{export: &domain.ProjectArchive{
+		Version: domain.ProjectArchiveVersion,
+		Project: project,
+		HashFiles: []domain.ArchivedFile{
+			{ID: hashFile.ID, Name: hashFile.OrigName, Size: hashFile.Size, SHA256: hashFile.SHA256},
+			{ID: goneID, Name: "gone.txt", Shared: true},
+		},
+		Jobs:        []domain.Job{running, finished},
+		Credentials: []domain.Credential{{ID: uuid.New(), Hash: "8846f7eaee8fb117ad06bdd830b7586c", Plaintext: "password", HashFileID: &hashFile.ID, JobID: &running.ID}},
+	}}
+	exporter := usecase.NewProjectArchiveUsecase(archiveRepo, new(MockProjectRepository), source, usecase.NewWordlistUsecase(new(MockWordlistRepository), t.TempDir()))
+
+	var archive bytes.Buffer
+	require.NoError(t, exporter.ExportProject(ctx, project.ID, true, &archive))
+
+	// The importing server has neither
+	projectRepo := new(MockProjectRepository)
+	projectRepo.On("GetByID", mock.Anything, project.ID).Return(nil, &domain.NotFoundError{Entity: "project"})
+	projectRepo.On("GetByName", mock.Anything, "acme").Return(nil, &domain.NotFoundError{Entity: "project"})
+	targetDir := t.TempDir()
+	target := usecase.NewHashFileUsecase(&memoryHashFileRepository{files: map[uuid.UUID]domain.HashFile{}}, targetDir)
+	targetRepo := &memoryArchiveRepository{}
+	importer := usecase.NewProjectArchiveUsecase(targetRepo, projectRepo, target, usecase.NewWordlistUsecase(new(MockWordlistRepository), targetDir))
+
+	result, err := importer.ImportProject(ctx, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.HashFiles)
+	assert.Equal(t, []string{"gone.txt"}, result.MissingFiles)
+	assert.Equal(t, 2, result.Jobs)
+	assert.Equal(t, 1, result.PausedJobs)
+	assert.Equal(t, 1, result.Credentials)
+	assert.Equal(t, "acme", result.Project.Name)
+
+	imported := targetRepo.imported
+	require.NotNil(t, imported)
+	job := imported.Jobs[0]
+	assert.Equal(t, running.ID, job.ID, "jobs keep their IDs")
+	assert.Equal(t, domain.JobStatusPaused, job.Status, "unfinished jobs wait to be resumed")
+	assert.Nil(t, job.AgentID)
+	require.NotNil(t, job.HashFileID)
+	assert.NotEqual(t, hashFile.ID, *job.HashFileID, "files get IDs of this server")
+	stored, err := target.GetHashFile(ctx, *job.HashFileID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Path, job.HashFile)
+	content, err := os.ReadFile(stored.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c\n", string(content))
+	assert.Equal(t, &project.ID, stored.ProjectID)
+
+	assert.Nil(t, imported.Jobs[1].HashFileID, "the missing file is no longer referenced")
+	assert.Equal(t, domain.JobStatusCracked, imported.Jobs[1].Status)
+	assert.Equal(t, job.HashFileID, imported.Credentials[0].HashFileID)
+	require.Len(t, imported.JobEvents, 1)
+	assert.Equal(t, domain.JobStatusRunning, imported.JobEvents[0].FromStatus)
+
+	t.Run("an existing project is refused", func(t *testing.T) {
+		projectRepo := new(MockProjectRepository)
+		projectRepo.On("GetByID", mock.Anything, project.ID).Return(&project, nil)
+		importer := usecase.NewProjectArchiveUsecase(&memoryArchiveRepository{}, projectRepo, target, nil)
+		_, err := importer.ImportProject(ctx, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+		assert.True(t, domain.IsValidationError(err))
+	})
+
+	t.Run("other files are refused", func(t *testing.T) {
+		_, err := importer.ImportProject(ctx, strings.NewReader("not a zip"), 9)
+		assert.True(t, domain.IsValidationError(err))
+	})
+}
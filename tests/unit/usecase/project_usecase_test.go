@@ -0,0 +1,229 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockProjectRepository is a mock implementation of domain.ProjectRepository
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) GetByName(ctx context.Context, name string) (*domain.Project, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) GetAll(ctx context.Context) ([]domain.Project, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) GetByMember(ctx context.Context, userID uuid.UUID) ([]domain.Project, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) CountResources(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProjectRepository) AddMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	args := m.Called(ctx, projectID, userID)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	args := m.Called(ctx, projectID, userID)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.ProjectMember), args.Error(1)
+}
+
+func (m *MockProjectRepository) IsMember(ctx context.Context, projectID, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, projectID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockUserRepository is a mock implementation of domain.UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAll(ctx context.Context) ([]domain.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetTenant(ctx context.Context, id uuid.UUID, tenantID *uuid.UUID) error {
+	args := m.Called(ctx, id, tenantID)
+	return args.Error(0)
+}
+
+func TestProjectUsecase_CheckAccess(t *testing.T) {
+	project := &domain.Project{ID: uuid.New(), Name: "ACME"}
+	member := uuid.New()
+	stranger := uuid.New()
+
+	projectRepo := new(MockProjectRepository)
+	projectRepo.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	projectRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "project"})
+	projectRepo.On("IsMember", mock.Anything, project.ID, member).Return(true, nil)
+	projectRepo.On("IsMember", mock.Anything, project.ID, stranger).Return(false, nil)
+	projectUsecase := usecase.NewProjectUsecase(projectRepo, new(MockUserRepository))
+	ctx := context.Background()
+
+	assert.NoError(t, projectUsecase.CheckAccess(ctx, project.ID, member, "user"))
+	assert.NoError(t, projectUsecase.CheckAccess(ctx, project.ID, stranger, "admin"))
+
+	// Non-members get the same error as for a missing project
+	err := projectUsecase.CheckAccess(ctx, project.ID, stranger, "user")
+	assert.True(t, domain.IsNotFoundError(err))
+	assert.Equal(t, "project not found", err.Error())
+	assert.True(t, domain.IsNotFoundError(projectUsecase.CheckAccess(ctx, uuid.New(), member, "admin")))
+}
+
+func TestProjectUsecase_CreateProject(t *testing.T) {
+	t.Run("with members", func(t *testing.T) {
+		userID := uuid.New()
+		projectRepo := new(MockProjectRepository)
+		userRepo := new(MockUserRepository)
+		projectRepo.On("GetByName", mock.Anything, "ACME").Return(nil, &domain.NotFoundError{Entity: "project"})
+		userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID}, nil)
+		projectRepo.On("Create", mock.Anything, mock.MatchedBy(func(p *domain.Project) bool {
+			return p.Name == "ACME" && p.Description == "Q4"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Project).ID = uuid.New()
+		}).Return(nil)
+		projectRepo.On("AddMember", mock.Anything, mock.Anything, userID).Return(nil)
+
+		project, err := usecase.NewProjectUsecase(projectRepo, userRepo).CreateProject(context.Background(),
+			&domain.CreateProjectRequest{Name: " ACME ", Description: "Q4", MemberIDs: []string{userID.String()}})
+		require.NoError(t, err)
+		assert.Equal(t, "ACME", project.Name)
+		projectRepo.AssertExpectations(t)
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		projectRepo := new(MockProjectRepository)
+		projectRepo.On("GetByName", mock.Anything, "acme").Return(&domain.Project{ID: uuid.New(), Name: "ACME"}, nil)
+
+		_, err := usecase.NewProjectUsecase(projectRepo, new(MockUserRepository)).CreateProject(context.Background(),
+			&domain.CreateProjectRequest{Name: "acme"})
+		assert.True(t, domain.IsValidationError(err))
+		projectRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		userID := uuid.New()
+		projectRepo := new(MockProjectRepository)
+		userRepo := new(MockUserRepository)
+		projectRepo.On("GetByName", mock.Anything, "ACME").Return(nil, &domain.NotFoundError{Entity: "project"})
+		userRepo.On("GetByID", mock.Anything, userID).Return(nil, &domain.UserNotFoundError{Username: userID.String()})
+
+		_, err := usecase.NewProjectUsecase(projectRepo, userRepo).CreateProject(context.Background(),
+			&domain.CreateProjectRequest{Name: "ACME", MemberIDs: []string{userID.String()}})
+		assert.True(t, domain.IsNotFoundError(err))
+		projectRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProjectUsecase_DeleteProject(t *testing.T) {
+	project := &domain.Project{ID: uuid.New(), Name: "ACME"}
+	projectRepo := new(MockProjectRepository)
+	projectRepo.On("GetByID", mock.Anything, project.ID).Return(project, nil)
+	projectRepo.On("CountResources", mock.Anything, project.ID).Return(2, nil).Once()
+	projectUsecase := usecase.NewProjectUsecase(projectRepo, new(MockUserRepository))
+
+	err := projectUsecase.DeleteProject(context.Background(), project.ID)
+	assert.True(t, domain.IsValidationError(err))
+	projectRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	projectRepo.On("CountResources", mock.Anything, project.ID).Return(0, nil)
+	projectRepo.On("Delete", mock.Anything, project.ID).Return(nil)
+	assert.NoError(t, projectUsecase.DeleteProject(context.Background(), project.ID))
+	projectRepo.AssertExpectations(t)
+}
@@ -0,0 +1,172 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockQuotaRepository is a mock implementation of domain.QuotaRepository
+type MockQuotaRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuotaRepository) Get(ctx context.Context, scope string, subjectID uuid.UUID) (*domain.Quota, error) {
+	args := m.Called(ctx, scope, subjectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Quota), args.Error(1)
+}
+
+func (m *MockQuotaRepository) GetAll(ctx context.Context) ([]domain.Quota, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Quota), args.Error(1)
+}
+
+func (m *MockQuotaRepository) Upsert(ctx context.Context, quota *domain.Quota) error {
+	args := m.Called(ctx, quota)
+	return args.Error(0)
+}
+
+func (m *MockQuotaRepository) Delete(ctx context.Context, scope string, subjectID uuid.UUID) error {
+	args := m.Called(ctx, scope, subjectID)
+	return args.Error(0)
+}
+
+func (m *MockQuotaRepository) GetUsage(ctx context.Context, scope string, subjectID uuid.UUID, monthStart time.Time) (*domain.QuotaUsage, error) {
+	args := m.Called(ctx, scope, subjectID, monthStart)
+	return args.Get(0).(*domain.QuotaUsage), args.Error(1)
+}
+
+func TestQuotaUsecase_CheckJobQuota(t *testing.T) {
+	userID, projectID := uuid.New(), uuid.New()
+	ctx := domain.WithUserID(context.Background(), userID)
+
+	newUsecase := func(userQuota *domain.Quota, usage *domain.QuotaUsage) (usecase.QuotaUsecase, *MockQuotaRepository) {
+		repo := new(MockQuotaRepository)
+		if userQuota != nil {
+			repo.On("Get", mock.Anything, domain.QuotaScopeUser, userID).Return(userQuota, nil)
+		} else {
+			repo.On("Get", mock.Anything, domain.QuotaScopeUser, userID).Return(nil, nil)
+		}
+		repo.On("Get", mock.Anything, domain.QuotaScopeProject, projectID).Return(nil, nil)
+		repo.On("GetUsage", mock.Anything, domain.QuotaScopeUser, userID, domain.QuotaMonthStart(time.Now())).Return(usage, nil)
+		return usecase.NewQuotaUsecase(repo, new(MockUserRepository), new(MockProjectRepository), new(MockTenantRepository)), repo
+	}
+
+	t.Run("within the quota", func(t *testing.T) {
+		uc, _ := newUsecase(&domain.Quota{MaxRunningJobs: 3, MaxDeviceHoursPerMonth: 10}, &domain.QuotaUsage{RunningJobs: 1, DeviceHoursThisMonth: 9.5})
+		assert.NoError(t, uc.CheckJobQuota(ctx, &projectID, 2))
+	})
+
+	t.Run("too many running jobs", func(t *testing.T) {
+		uc, _ := newUsecase(&domain.Quota{MaxRunningJobs: 3}, &domain.QuotaUsage{RunningJobs: 2})
+
+		err := uc.CheckJobQuota(ctx, &projectID, 2)
+		var quotaErr *domain.QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, domain.QuotaScopeUser, quotaErr.Scope)
+		assert.Equal(t, domain.QuotaRunningJobs, quotaErr.Resource)
+	})
+
+	t.Run("device-hours used up", func(t *testing.T) {
+		uc, _ := newUsecase(&domain.Quota{MaxDeviceHoursPerMonth: 10}, &domain.QuotaUsage{DeviceHoursThisMonth: 10})
+
+		err := uc.CheckJobQuota(ctx, nil, 1)
+		var quotaErr *domain.QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, domain.QuotaDeviceHours, quotaErr.Resource)
+	})
+
+	t.Run("no quota", func(t *testing.T) {
+		uc, repo := newUsecase(nil, nil)
+		assert.NoError(t, uc.CheckJobQuota(ctx, &projectID, 100))
+		repo.AssertNotCalled(t, "GetUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("requests without a login only count against the project", func(t *testing.T) {
+		repo := new(MockQuotaRepository)
+		repo.On("Get", mock.Anything, domain.QuotaScopeProject, projectID).Return(&domain.Quota{MaxRunningJobs: 1}, nil)
+		repo.On("GetUsage", mock.Anything, domain.QuotaScopeProject, projectID, mock.Anything).Return(&domain.QuotaUsage{RunningJobs: 1}, nil)
+		uc := usecase.NewQuotaUsecase(repo, new(MockUserRepository), new(MockProjectRepository), new(MockTenantRepository))
+
+		err := uc.CheckJobQuota(context.Background(), &projectID, 1)
+		assert.True(t, domain.IsQuotaExceededError(err))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("users of a tenant count against the tenant", func(t *testing.T) {
+		tenantID := uuid.New()
+		repo := new(MockQuotaRepository)
+		repo.On("Get", mock.Anything, domain.QuotaScopeUser, userID).Return(nil, nil)
+		repo.On("Get", mock.Anything, domain.QuotaScopeTenant, tenantID).Return(&domain.Quota{MaxRunningJobs: 5}, nil)
+		repo.On("GetUsage", mock.Anything, domain.QuotaScopeTenant, tenantID, mock.Anything).Return(&domain.QuotaUsage{RunningJobs: 4}, nil)
+		uc := usecase.NewQuotaUsecase(repo, new(MockUserRepository), new(MockProjectRepository), new(MockTenantRepository))
+
+		err := uc.CheckJobQuota(domain.WithTenantID(ctx, tenantID), nil, 2)
+		var quotaErr *domain.QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, domain.QuotaScopeTenant, quotaErr.Scope)
+	})
+}
+
+func TestQuotaUsecase_CheckStorageQuota(t *testing.T) {
+	projectID := uuid.New()
+	repo := new(MockQuotaRepository)
+	repo.On("Get", mock.Anything, domain.QuotaScopeProject, projectID).Return(&domain.Quota{MaxStorageBytes: 1000}, nil)
+	repo.On("GetUsage", mock.Anything, domain.QuotaScopeProject, projectID, mock.Anything).Return(&domain.QuotaUsage{StorageBytes: 600}, nil)
+	uc := usecase.NewQuotaUsecase(repo, new(MockUserRepository), new(MockProjectRepository), new(MockTenantRepository))
+
+	assert.NoError(t, uc.CheckStorageQuota(context.Background(), &projectID, 400))
+
+	err := uc.CheckStorageQuota(context.Background(), &projectID, 401)
+	var quotaErr *domain.QuotaExceededError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, domain.QuotaStorage, quotaErr.Resource)
+	assert.Equal(t, float64(1001), quotaErr.Used)
+}
+
+func TestQuotaUsecase_SetQuota(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("saves the limits", func(t *testing.T) {
+		repo := new(MockQuotaRepository)
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID}, nil)
+		repo.On("Upsert", mock.Anything, mock.MatchedBy(func(q *domain.Quota) bool {
+			return q.Scope == domain.QuotaScopeUser && q.SubjectID == userID && q.MaxRunningJobs == 4 && q.MaxStorageBytes == 1<<30
+		})).Return(nil)
+		repo.On("GetUsage", mock.Anything, domain.QuotaScopeUser, userID, mock.Anything).Return(&domain.QuotaUsage{RunningJobs: 1}, nil)
+		uc := usecase.NewQuotaUsecase(repo, userRepo, new(MockProjectRepository), new(MockTenantRepository))
+
+		status, err := uc.SetQuota(context.Background(), domain.QuotaScopeUser, userID, &domain.SetQuotaRequest{MaxRunningJobs: 4, MaxStorageBytes: 1 << 30})
+		require.NoError(t, err)
+		assert.Equal(t, 4, status.MaxRunningJobs)
+		assert.Equal(t, 1, status.Usage.RunningJobs)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		uc := usecase.NewQuotaUsecase(new(MockQuotaRepository), new(MockUserRepository), new(MockProjectRepository), new(MockTenantRepository))
+		_, err := uc.SetQuota(context.Background(), "agent", userID, &domain.SetQuotaRequest{})
+		assert.True(t, domain.IsValidationError(err))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetByID", mock.Anything, userID).Return(nil, &domain.UserNotFoundError{Username: userID.String()})
+		uc := usecase.NewQuotaUsecase(new(MockQuotaRepository), userRepo, new(MockProjectRepository), new(MockTenantRepository))
+
+		_, err := uc.SetQuota(context.Background(), domain.QuotaScopeUser, userID, &domain.SetQuotaRequest{MaxRunningJobs: 1})
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
@@ -0,0 +1,69 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendationUsecase_RecommendWordlists(t *testing.T) {
+	rockyou, wifi, corporate := uuid.New(), uuid.New(), uuid.New()
+	outcomes := []domain.AttackOutcome{
+		// rockyou cracks the most overall, but mostly web-app hashes
+		{WordlistID: rockyou, Wordlist: "rockyou.txt", Source: "web-app", Attacks: 10, Cracked: 6, Cracks: 40},
+		{WordlistID: rockyou, Wordlist: "rockyou.txt", Source: "wifi", Attacks: 4, Cracked: 0},
+		{WordlistID: wifi, Wordlist: "wifi-common.txt", Source: "wifi", Attacks: 4, Cracked: 3, Cracks: 3},
+		// One lucky attack doesn't rank above a steady record
+		{WordlistID: corporate, Wordlist: "corporate.txt", Rules: "best64.rule", Source: "", Attacks: 1, Cracked: 1, Cracks: 1},
+	}
+	statsRepo := new(MockStatsRepository)
+	statsRepo.On("GetAttackOutcomes", mock.Anything, 22000).Return(outcomes, nil)
+	hashFileID := uuid.New()
+	hashFileRepo := new(MockHashFileRepository)
+	hashFileRepo.On("GetByID", mock.Anything, hashFileID).Return(&domain.HashFile{ID: hashFileID, Source: "wifi"}, nil)
+	uc := usecase.NewRecommendationUsecase(statsRepo, hashFileRepo)
+
+	t.Run("by the hash file's origin", func(t *testing.T) {
+		result, err := uc.RecommendWordlists(context.Background(), 22000, &hashFileID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "wifi", result.Source)
+		require.Len(t, result.Recommendations, 3)
+		top := result.Recommendations[0]
+		assert.Equal(t, wifi, top.WordlistID)
+		assert.Equal(t, 4, top.SourceAttacks)
+		assert.Equal(t, 3, top.SourceCracked)
+		assert.Equal(t, "wifi-common.txt cracked 3 of 4 attacks on wifi hash files", top.Reason)
+		assert.Equal(t, rockyou, result.Recommendations[2].WordlistID, "rockyou never cracked a wifi hash")
+	})
+
+	t.Run("by hash mode alone", func(t *testing.T) {
+		result, err := uc.RecommendWordlists(context.Background(), 22000, nil, "")
+		require.NoError(t, err)
+		top := result.Recommendations[0]
+		assert.Equal(t, wifi, top.WordlistID)
+		assert.Equal(t, 3, top.Cracked)
+		assert.Equal(t, "corporate.txt with best64.rule cracked 1 of 1 attacks on hash mode 22000", result.Recommendations[1].Reason)
+		assert.Equal(t, rockyou, result.Recommendations[2].WordlistID)
+		assert.Equal(t, 14, result.Recommendations[2].Attacks)
+		assert.InDelta(t, 6.0/14, result.Recommendations[2].CrackRate, 0.0001)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		_, err := uc.RecommendWordlists(context.Background(), 22000, nil, "wi fi")
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "source", validationErr.Field)
+
+		missing := uuid.New()
+		hashFileRepo.On("GetByID", mock.Anything, missing).Return(nil, assert.AnError)
+		_, err = uc.RecommendWordlists(context.Background(), 22000, &missing, "")
+		assert.True(t, domain.IsNotFoundError(err))
+	})
+}
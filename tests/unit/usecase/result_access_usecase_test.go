@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryResultAccessRepository keeps reveal grants and the reveal log in memory
+type memoryResultAccessRepository struct {
+	grants  map[uuid.UUID]domain.ResultRevealGrant
+	reveals []domain.ResultReveal
+}
+
+func (r *memoryResultAccessRepository) GrantReveal(ctx context.Context, grant *domain.ResultRevealGrant) error {
+	r.grants[grant.UserID] = *grant
+	return nil
+}
+
+func (r *memoryResultAccessRepository) RevokeReveal(ctx context.Context, userID uuid.UUID) error {
+	if _, ok := r.grants[userID]; !ok {
+		return &domain.NotFoundError{Entity: "reveal grant"}
+	}
+	delete(r.grants, userID)
+	return nil
+}
+
+func (r *memoryResultAccessRepository) HasRevealGrant(ctx context.Context, userID uuid.UUID) (bool, error) {
+	_, ok := r.grants[userID]
+	return ok, nil
+}
+
+func (r *memoryResultAccessRepository) GetRevealGrants(ctx context.Context) ([]domain.ResultRevealGrant, error) {
+	grants := []domain.ResultRevealGrant{}
+	for _, grant := range r.grants {
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+func (r *memoryResultAccessRepository) LogReveal(ctx context.Context, reveal *domain.ResultReveal) error {
+	r.reveals = append(r.reveals, *reveal)
+	return nil
+}
+
+func (r *memoryResultAccessRepository) GetReveals(ctx context.Context, jobID *uuid.UUID, limit int) ([]domain.ResultReveal, error) {
+	return r.reveals, nil
+}
+
+func TestRedactResult(t *testing.T) {
+	tests := map[string]string{
+		"Password found: hunter2":                             "Password found: ********",
+		"SUCCESS: Password found by agent - a - b":            "SUCCESS: Password found by agent - ********",
+		"SUCCESS: Password found by 2 agent(s) - Summer2026!": "SUCCESS: Password found by 2 agent(s) - ********",
+		"Password found (extraction failed)":                  "Password found (extraction failed)",
+		"Password found by another agent - job cancelled":     "Password found by another agent - job cancelled",
+		domain.JobResultExhausted:                             domain.JobResultExhausted,
+		"":                                                    "",
+	}
+	for result, want := range tests {
+		assert.Equal(t, want, domain.RedactResult(result), result)
+	}
+
+	password, ok := domain.ResultPassword("SUCCESS: Password found by agent - a - b")
+	assert.True(t, ok)
+	assert.Equal(t, "a - b", password)
+	_, ok = domain.ResultPassword("Password found (extraction failed)")
+	assert.False(t, ok)
+}
+
+func TestResultAccessUsecase_RevealResult(t *testing.T) {
+	ctx := context.Background()
+	jobRepo := new(MockJobRepository)
+	userRepo := new(MockUserRepository)
+	repo := &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{}}
+	access := usecase.NewResultAccessUsecase(repo, jobRepo, userRepo, true)
+	assert.True(t, access.RedactsResults())
+
+	job := &domain.Job{ID: uuid.New(), Status: domain.JobStatusCracked, Result: "Password found: hunter2"}
+	jobRepo.On("GetByID", ctx, job.ID).Return(job, nil)
+
+	analyst := domain.ResultViewer{UserID: uuid.New(), Username: "analyst", Role: "user", IPAddress: "10.0.0.5"}
+	_, err := access.RevealResult(ctx, job.ID, analyst)
+	assert.ErrorIs(t, err, domain.ErrRevealForbidden)
+	assert.Empty(t, repo.reveals, "refused reveals aren't logged")
+
+	// Admins can always reveal
+	admin := domain.ResultViewer{UserID: uuid.New(), Username: "admin", Role: "admin"}
+	revealed, err := access.RevealResult(ctx, job.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", revealed.Password)
+
+	userRepo.On("GetByID", ctx, analyst.UserID).Return(&domain.User{ID: analyst.UserID, Username: "analyst"}, nil)
+	grant, err := access.GrantReveal(ctx, analyst.UserID, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "analyst", grant.Username)
+
+	revealed, err = access.RevealResult(ctx, job.ID, analyst)
+	require.NoError(t, err)
+	assert.Equal(t, "Password found: hunter2", revealed.Result)
+
+	require.Len(t, repo.reveals, 2)
+	assert.Equal(t, "analyst", repo.reveals[1].Username)
+	assert.Equal(t, "10.0.0.5", repo.reveals[1].IPAddress)
+	assert.Equal(t, job.ID, repo.reveals[1].JobID)
+
+	require.NoError(t, access.RevokeReveal(ctx, analyst.UserID))
+	_, err = access.RevealResult(ctx, job.ID, analyst)
+	assert.ErrorIs(t, err, domain.ErrRevealForbidden)
+
+	missing := uuid.New()
+	userRepo.On("GetByID", ctx, missing).Return(nil, &domain.UserNotFoundError{Username: missing.String()})
+	_, err = access.GrantReveal(ctx, missing, "admin")
+	assert.True(t, domain.IsNotFoundError(err))
+}
+
+func TestResultAccessUsecase_RevealWordlist(t *testing.T) {
+	ctx := context.Background()
+	jobRepo := new(MockJobRepository)
+	repo := &memoryResultAccessRepository{grants: map[uuid.UUID]domain.ResultRevealGrant{}}
+	access := usecase.NewResultAccessUsecase(repo, jobRepo, new(MockUserRepository), true)
+	wordlistID := uuid.New()
+
+	analyst := domain.ResultViewer{UserID: uuid.New(), Username: "analyst", Role: "user"}
+	assert.ErrorIs(t, access.RevealWordlist(ctx, wordlistID, analyst), domain.ErrRevealForbidden)
+	require.NoError(t, access.RevealWordlist(ctx, wordlistID, domain.ResultViewer{UserID: uuid.New(), Username: "admin", Role: "admin"}))
+	require.Len(t, repo.reveals, 1)
+	assert.Equal(t, &wordlistID, repo.reveals[0].WordlistID)
+	assert.Equal(t, "admin", repo.reveals[0].Username)
+
+	// Agents only read the loopback wordlists of their unfinished jobs
+	agentID, otherID := uuid.New(), uuid.New()
+	running := domain.Job{ID: uuid.New(), Status: domain.JobStatusRunning, WordlistID: &wordlistID}
+	finished := domain.Job{ID: uuid.New(), Status: domain.JobStatusCompleted, WordlistID: &wordlistID}
+	jobRepo.On("GetByAgentID", ctx, agentID).Return([]domain.Job{running}, nil)
+	jobRepo.On("GetByAgentID", ctx, otherID).Return([]domain.Job{finished}, nil)
+
+	require.NoError(t, access.RevealWordlistToAgent(ctx, wordlistID, agentID, "10.0.0.9"))
+	require.Len(t, repo.reveals, 2)
+	assert.Equal(t, running.ID, repo.reveals[1].JobID)
+	assert.Equal(t, "10.0.0.9", repo.reveals[1].IPAddress)
+	assert.ErrorIs(t, access.RevealWordlistToAgent(ctx, wordlistID, otherID, ""), domain.ErrRevealForbidden)
+	assert.ErrorIs(t, access.RevealWordlistToAgent(ctx, uuid.New(), agentID, ""), domain.ErrRevealForbidden)
+	assert.Len(t, repo.reveals, 2)
+}
@@ -0,0 +1,60 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchRepository is a mock implementation of domain.SearchRepository
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) Search(ctx context.Context, query string, limit int, matchResults bool) ([]domain.SearchResult, error) {
+	args := m.Called(ctx, query, limit, matchResults)
+	return args.Get(0).([]domain.SearchResult), args.Error(1)
+}
+
+func TestSearchUsecase_Search(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		limit         int
+		expectedQuery string
+		expectedLimit int
+		expectedError bool
+	}{
+		{name: "default limit", query: "  wifi ", expectedQuery: "wifi", expectedLimit: 20},
+		{name: "limit is capped", query: "wifi", limit: 1000, expectedQuery: "wifi", expectedLimit: 100},
+		{name: "empty query", query: "   ", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searchRepo := new(MockSearchRepository)
+			if !tt.expectedError {
+				searchRepo.On("Search", mock.Anything, tt.expectedQuery, tt.expectedLimit, true).Return([]domain.SearchResult{
+					{Type: "job", ID: uuid.New(), Title: "Office WiFi"},
+				}, nil)
+			}
+
+			usecase := usecase.NewSearchUsecase(searchRepo)
+			results, err := usecase.Search(context.Background(), tt.query, tt.limit, true)
+
+			if tt.expectedError {
+				assert.True(t, domain.IsValidationError(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, results, 1)
+			}
+			searchRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSSOProvider is a mock implementation of domain.SSOProvider
+type MockSSOProvider struct {
+	mock.Mock
+}
+
+func (m *MockSSOProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	args := m.Called(ctx, state, nonce)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSSOProvider) Exchange(ctx context.Context, code, nonce string) (*domain.SSOLogin, error) {
+	args := m.Called(ctx, code, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOLogin), args.Error(1)
+}
+
+func (m *MockSSOProvider) Refresh(ctx context.Context, refreshToken string) (*domain.SSOLogin, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOLogin), args.Error(1)
+}
+
+// MockSSOIdentityRepository is a mock implementation of domain.SSOIdentityRepository
+type MockSSOIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockSSOIdentityRepository) GetBySubject(ctx context.Context, subject string) (*domain.SSOIdentity, error) {
+	args := m.Called(ctx, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOIdentity), args.Error(1)
+}
+
+func (m *MockSSOIdentityRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.SSOIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOIdentity), args.Error(1)
+}
+
+func (m *MockSSOIdentityRepository) Save(ctx context.Context, identity *domain.SSOIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+// sessionStarter starts sessions without storing them
+type sessionStarter struct{}
+
+func (sessionStarter) StartSession(ctx context.Context, user *domain.User, twoFactor bool) (*domain.LoginResponse, error) {
+	return &domain.LoginResponse{Token: "access-" + user.Username, RefreshToken: "refresh-" + user.Username, User: *user}, nil
+}
+
+var ssoConfig = usecase.SSOConfig{
+	RoleMapping: map[string]string{"hashcat-admins": "admin", "pentesters": "user"},
+	DefaultRole: "user",
+}
+
+func ssoClaims(roles ...string) *domain.SSOClaims {
+	return &domain.SSOClaims{Subject: "user-42", Email: "alice@example.com", EmailVerified: true, Username: "alice", Roles: roles}
+}
+
+func identityNotFound() error { return &domain.NotFoundError{Entity: "sso identity"} }
+
+func userNotFound() error { return &domain.UserNotFoundError{Username: "alice"} }
+
+func TestSSOUsecase_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("provisions a new user with the mapped role", func(t *testing.T) {
+		provider, identities, users := new(MockSSOProvider), new(MockSSOIdentityRepository), new(MockUserRepository)
+		provider.On("Exchange", mock.Anything, "code-1", "nonce-1").Return(&domain.SSOLogin{Claims: ssoClaims("hashcat-admins"), RefreshToken: "refresh-1"}, nil)
+		identities.On("GetBySubject", mock.Anything, "user-42").Return(nil, identityNotFound())
+		users.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, userNotFound())
+		users.On("GetByUsername", mock.Anything, "alice").Return(&domain.User{ID: uuid.New(), Username: "alice"}, nil)
+		users.On("GetByUsername", mock.Anything, "alice-2").Return(nil, userNotFound())
+		users.On("Create", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Username == "alice-2" && user.Role == "admin" && user.IsActive && user.Password != ""
+		})).Return(nil)
+		users.On("UpdateLastLogin", mock.Anything, mock.Anything).Return(nil)
+		identities.On("Save", mock.Anything, mock.MatchedBy(func(identity *domain.SSOIdentity) bool {
+			return identity.Subject == "user-42" && identity.RefreshToken == "refresh-1"
+		})).Return(nil)
+		uc := usecase.NewSSOUsecase(provider, identities, users, sessionStarter{}, ssoConfig)
+
+		response, err := uc.CompleteLogin(ctx, "code-1", "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "access-alice-2", response.Token)
+		assert.Equal(t, "refresh-alice-2", response.RefreshToken)
+		assert.Equal(t, "alice-2", response.User.Username)
+		assert.Equal(t, "admin", response.User.Role)
+		users.AssertExpectations(t)
+		identities.AssertExpectations(t)
+	})
+
+	t.Run("links a local user with the verified email", func(t *testing.T) {
+		existing := &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Role: "admin", IsActive: true}
+		provider, identities, users := new(MockSSOProvider), new(MockSSOIdentityRepository), new(MockUserRepository)
+		provider.On("Exchange", mock.Anything, "code-1", "nonce-1").Return(&domain.SSOLogin{Claims: ssoClaims("pentesters")}, nil)
+		identities.On("GetBySubject", mock.Anything, "user-42").Return(nil, identityNotFound())
+		identities.On("GetByUserID", mock.Anything, existing.ID).Return(nil, identityNotFound())
+		users.On("GetByEmail", mock.Anything, "alice@example.com").Return(existing, nil)
+		users.On("Update", mock.Anything, existing).Return(nil)
+		users.On("UpdateLastLogin", mock.Anything, existing.ID).Return(nil)
+		identities.On("Save", mock.Anything, mock.MatchedBy(func(identity *domain.SSOIdentity) bool { return identity.UserID == existing.ID })).Return(nil)
+		uc := usecase.NewSSOUsecase(provider, identities, users, sessionStarter{}, ssoConfig)
+
+		response, err := uc.CompleteLogin(ctx, "code-1", "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, response.User.ID)
+		assert.Equal(t, "user", response.User.Role, "the role follows the provider's claims")
+		users.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unmapped user without a default role", func(t *testing.T) {
+		provider := new(MockSSOProvider)
+		provider.On("Exchange", mock.Anything, "code-1", "nonce-1").Return(&domain.SSOLogin{Claims: ssoClaims("sales")}, nil)
+		config := ssoConfig
+		config.DefaultRole = ""
+		uc := usecase.NewSSOUsecase(provider, new(MockSSOIdentityRepository), new(MockUserRepository), sessionStarter{}, config)
+
+		_, err := uc.CompleteLogin(ctx, "code-1", "nonce-1")
+		var authErr *domain.AuthenticationError
+		assert.ErrorAs(t, err, &authErr)
+	})
+
+	t.Run("deactivated user", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Username: "alice", Role: "user", IsActive: false}
+		provider, identities, users := new(MockSSOProvider), new(MockSSOIdentityRepository), new(MockUserRepository)
+		provider.On("Exchange", mock.Anything, "code-1", "nonce-1").Return(&domain.SSOLogin{Claims: ssoClaims()}, nil)
+		identities.On("GetBySubject", mock.Anything, "user-42").Return(&domain.SSOIdentity{UserID: user.ID, Subject: "user-42"}, nil)
+		users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		uc := usecase.NewSSOUsecase(provider, identities, users, sessionStarter{}, ssoConfig)
+
+		_, err := uc.CompleteLogin(ctx, "code-1", "nonce-1")
+		assert.ErrorContains(t, err, "deactivated")
+		identities.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed exchange", func(t *testing.T) {
+		provider := new(MockSSOProvider)
+		provider.On("Exchange", mock.Anything, "code-1", "nonce-1").Return(nil, errors.New("oidc: invalid ID token"))
+		uc := usecase.NewSSOUsecase(provider, new(MockSSOIdentityRepository), new(MockUserRepository), sessionStarter{}, ssoConfig)
+
+		_, err := uc.CompleteLogin(ctx, "code-1", "nonce-1")
+		var authErr *domain.AuthenticationError
+		assert.ErrorAs(t, err, &authErr)
+	})
+}
+
+func TestSSOUsecase_RefreshSession(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Username: "alice", Role: "user", IsActive: true}
+
+	t.Run("local users are left alone", func(t *testing.T) {
+		identities := new(MockSSOIdentityRepository)
+		identities.On("GetByUserID", mock.Anything, user.ID).Return(nil, identityNotFound())
+		uc := usecase.NewSSOUsecase(new(MockSSOProvider), identities, new(MockUserRepository), sessionStarter{}, ssoConfig)
+
+		assert.NoError(t, uc.RefreshSession(ctx, user))
+	})
+
+	t.Run("ended provider session", func(t *testing.T) {
+		provider, identities := new(MockSSOProvider), new(MockSSOIdentityRepository)
+		identities.On("GetByUserID", mock.Anything, user.ID).Return(&domain.SSOIdentity{UserID: user.ID, Subject: "user-42", RefreshToken: "refresh-1"}, nil)
+		provider.On("Refresh", mock.Anything, "refresh-1").Return(nil, errors.New("invalid_grant"))
+		uc := usecase.NewSSOUsecase(provider, identities, new(MockUserRepository), sessionStarter{}, ssoConfig)
+
+		var authErr *domain.AuthenticationError
+		assert.ErrorAs(t, uc.RefreshSession(ctx, user), &authErr)
+	})
+
+	t.Run("keeps the new refresh token and role", func(t *testing.T) {
+		refreshed := *user
+		provider, identities, users := new(MockSSOProvider), new(MockSSOIdentityRepository), new(MockUserRepository)
+		identities.On("GetByUserID", mock.Anything, user.ID).Return(&domain.SSOIdentity{UserID: user.ID, Subject: "user-42", RefreshToken: "refresh-1"}, nil)
+		provider.On("Refresh", mock.Anything, "refresh-1").Return(&domain.SSOLogin{Claims: ssoClaims("hashcat-admins"), RefreshToken: "refresh-2"}, nil)
+		users.On("Update", mock.Anything, &refreshed).Return(nil)
+		identities.On("Save", mock.Anything, mock.MatchedBy(func(identity *domain.SSOIdentity) bool { return identity.RefreshToken == "refresh-2" })).Return(nil)
+		uc := usecase.NewSSOUsecase(provider, identities, users, sessionStarter{}, ssoConfig)
+
+		require.NoError(t, uc.RefreshSession(ctx, &refreshed))
+		assert.Equal(t, "admin", refreshed.Role)
+		identities.AssertExpectations(t)
+	})
+}
+
+func TestParseSSORoleMapping(t *testing.T) {
+	mapping, err := usecase.ParseSSORoleMapping(" hashcat-admins=admin, pentesters = user ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"hashcat-admins": "admin", "pentesters": "user"}, mapping)
+
+	_, err = usecase.ParseSSORoleMapping("hashcat-admins")
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStatsRepository is a mock implementation of domain.StatsRepository
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) GetAgentStats(ctx context.Context) (*domain.AgentStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgentStats), args.Error(1)
+}
+
+func (m *MockStatsRepository) CountJobsByStatus(ctx context.Context) (map[string]int, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockStatsRepository) CountCrackedSince(ctx context.Context, since time.Time) (int, error) {
+	args := m.Called(ctx, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetTopWordlists(ctx context.Context, limit int) ([]domain.WordlistUsage, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]domain.WordlistUsage), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetAverageTimeToCrack(ctx context.Context) ([]domain.HashModeCrackTime, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.HashModeCrackTime), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetKeyspacePerDay(ctx context.Context, since time.Time) ([]domain.DailyKeyspace, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]domain.DailyKeyspace), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetUsage(ctx context.Context, groupBy string, from, to *time.Time) ([]domain.UsageTotal, error) {
+	args := m.Called(ctx, groupBy, from, to)
+	return args.Get(0).([]domain.UsageTotal), args.Error(1)
+}
+
+func (m *MockStatsRepository) GetAttackOutcomes(ctx context.Context, hashType int) ([]domain.AttackOutcome, error) {
+	args := m.Called(ctx, hashType)
+	return args.Get(0).([]domain.AttackOutcome), args.Error(1)
+}
+
+func mockStatsQueries(repo *MockStatsRepository) {
+	repo.On("GetAgentStats", mock.Anything).Return(&domain.AgentStats{Total: 3, Active: 2, TotalSpeed: 1500}, nil)
+	repo.On("CountJobsByStatus", mock.Anything).Return(map[string]int{"running": 1}, nil)
+	repo.On("CountCrackedSince", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since).Round(time.Minute) == 24*time.Hour
+	})).Return(4, nil)
+	repo.On("GetTopWordlists", mock.Anything, 10).Return([]domain.WordlistUsage{{Name: "rockyou.txt", Jobs: 2}}, nil)
+	repo.On("GetAverageTimeToCrack", mock.Anything).Return([]domain.HashModeCrackTime{{HashType: 0, Cracked: 1, AvgSeconds: 30}}, nil)
+	repo.On("GetKeyspacePerDay", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+		// Midnight UTC, 29 days before today
+		return since.Equal(since.Truncate(24*time.Hour)) && time.Since(since) > 29*24*time.Hour && time.Since(since) <= 30*24*time.Hour
+	})).Return([]domain.DailyKeyspace{{Date: "2026-10-15", Processed: 100, Jobs: 1}}, nil)
+}
+
+func TestStatsUsecase_GetClusterStats(t *testing.T) {
+	t.Run("cached within the TTL", func(t *testing.T) {
+		repo := new(MockStatsRepository)
+		mockStatsQueries(repo)
+		statsUsecase := usecase.NewStatsUsecase(repo, time.Minute)
+
+		first, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, first.Agents.Active)
+		assert.Equal(t, 4, first.CracksLast24h)
+		assert.Equal(t, "rockyou.txt", first.TopWordlists[0].Name)
+		assert.Equal(t, int64(100), first.KeyspacePerDay[0].Processed)
+
+		second, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+		repo.AssertNumberOfCalls(t, "GetAgentStats", 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("recomputed after the TTL", func(t *testing.T) {
+		repo := new(MockStatsRepository)
+		mockStatsQueries(repo)
+		statsUsecase := usecase.NewStatsUsecase(repo, 0)
+
+		_, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		_, err = statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		repo.AssertNumberOfCalls(t, "GetAgentStats", 2)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		repo := new(MockStatsRepository)
+		repo.On("GetAgentStats", mock.Anything).Return(nil, errors.New("database is locked")).Once()
+		mockStatsQueries(repo)
+		statsUsecase := usecase.NewStatsUsecase(repo, time.Minute)
+
+		_, err := statsUsecase.GetClusterStats(context.Background())
+		assert.ErrorContains(t, err, "database is locked")
+
+		stats, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.Agents.Total)
+	})
+
+	t.Run("database stats are live", func(t *testing.T) {
+		repo := new(MockStatsRepository)
+		mockStatsQueries(repo)
+		statsUsecase := usecase.NewStatsUsecase(repo, time.Minute)
+		database := &fakeDatabaseStats{}
+		statsUsecase.SetDatabaseStats(database)
+
+		database.stats.BusyErrors = 1
+		first, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+		database.stats.BusyErrors = 3
+		second, err := statsUsecase.GetClusterStats(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), first.Database.BusyErrors)
+		assert.Equal(t, int64(3), second.Database.BusyErrors)
+		assert.Equal(t, first.GeneratedAt, second.GeneratedAt)
+		repo.AssertNumberOfCalls(t, "GetAgentStats", 1)
+	})
+}
+
+type fakeDatabaseStats struct {
+	stats domain.DatabaseStats
+}
+
+func (f *fakeDatabaseStats) DatabaseStats() domain.DatabaseStats {
+	return f.stats
+}
+
+func TestStatsUsecase_GetCostReport(t *testing.T) {
+	projectA, projectB := uuid.New(), uuid.New()
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockStatsRepository)
+	repo.On("GetUsage", mock.Anything, domain.CostGroupProject, &from, (*time.Time)(nil)).Return([]domain.UsageTotal{
+		{ID: &projectA, Name: "audit", Jobs: 2, DeviceSeconds: 7200, EnergyWh: 500},
+		{ID: &projectB, Name: "pentest", Jobs: 1, DeviceSeconds: 3600, EnergyWh: 4000},
+		{Name: "", Jobs: 1, DeviceSeconds: 1800},
+	}, nil)
+	statsUsecase := usecase.NewStatsUsecase(repo, time.Minute)
+	statsUsecase.SetCostRates(domain.CostRates{Currency: "EUR", DeviceHour: 1, KWh: 0.5})
+
+	report, err := statsUsecase.GetCostReport(context.Background(), domain.CostGroupProject, &from, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "EUR", report.Rates.Currency)
+	require.Len(t, report.Items, 3)
+	// pentest costs 1 + 4*0.5, audit 2 + 0.5*0.5
+	assert.Equal(t, &projectB, report.Items[0].ID)
+	assert.InDelta(t, 3.0, report.Items[0].Cost, 1e-9)
+	assert.Equal(t, &projectA, report.Items[1].ID)
+	assert.InDelta(t, 2.0, report.Items[1].DeviceHours, 1e-9)
+	assert.InDelta(t, 0.5, report.Items[1].EnergyKWh, 1e-9)
+	assert.InDelta(t, 2.25, report.Items[1].Cost, 1e-9)
+	assert.Nil(t, report.Items[2].ID)
+
+	assert.InDelta(t, 3.5, report.Total.DeviceHours, 1e-9)
+	assert.InDelta(t, 4.5, report.Total.EnergyKWh, 1e-9)
+	assert.InDelta(t, 5.75, report.Total.Cost, 1e-9)
+}
+
+func TestStatsUsecase_GetCostReport_Invalid(t *testing.T) {
+	statsUsecase := usecase.NewStatsUsecase(new(MockStatsRepository), time.Minute)
+
+	_, err := statsUsecase.GetCostReport(context.Background(), "hash_type", nil, nil)
+	assert.True(t, domain.IsValidationError(err))
+
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+	_, err = statsUsecase.GetCostReport(context.Background(), domain.CostGroupJob, &from, &to)
+	assert.True(t, domain.IsValidationError(err))
+}
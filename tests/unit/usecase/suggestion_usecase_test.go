@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordMask(t *testing.T) {
+	assert.Equal(t, "?u?l?l?l?d?d?s", domain.PasswordMask("Pass12!"))
+	assert.Equal(t, "?l?s?l", domain.PasswordMask("a b"))
+	assert.Equal(t, "?b?b?l", domain.PasswordMask("éa"))
+
+	assert.Equal(t, int64(26*26*10), domain.MaskKeyspace("?l?u?d"))
+	assert.Equal(t, int64(10), domain.MaskKeyspace("abc?d"))
+	assert.Equal(t, int64(0), domain.MaskKeyspace("?1?d"))
+	assert.Equal(t, int64(math.MaxInt64), domain.MaskKeyspace("?b?b?b?b?b?b?b?b?b"))
+}
+
+func TestSuggestionUsecase_GetProjectSuggestions(t *testing.T) {
+	projectID := uuid.New()
+	loopbackPath := filepath.Join(t.TempDir(), "loopback.txt")
+	require.NoError(t, os.WriteFile(loopbackPath, []byte("Summer24\nWinter23\nhunter2\nSpring22\n"), 0644))
+	loopback := &domain.Wordlist{ID: uuid.New(), OrigName: "loopback.txt", Path: loopbackPath, ProjectID: &projectID, Dynamic: true}
+
+	bestRules, weakRules := "best64.rule", "dive.rule"
+	groupID := uuid.New()
+	jobs := []domain.Job{
+		{ID: uuid.New(), Rules: bestRules, Status: domain.JobStatusCracked},
+		{ID: uuid.New(), Rules: bestRules, Status: domain.JobStatusFailed},
+		// Two sub-jobs of one distributed job, one of them cracked
+		{ID: uuid.New(), Rules: weakRules, Status: domain.JobStatusCancelled, GroupID: &groupID},
+		{ID: uuid.New(), Rules: weakRules, Status: domain.JobStatusCracked, GroupID: &groupID},
+		{ID: uuid.New(), Rules: weakRules, Status: domain.JobStatusFailed},
+		{ID: uuid.New(), Rules: weakRules, Status: domain.JobStatusFailed},
+		// Unfinished jobs and jobs without rules don't count
+		{ID: uuid.New(), Rules: weakRules, Status: domain.JobStatusRunning},
+		{ID: uuid.New(), Status: domain.JobStatusCracked},
+	}
+
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetLoopback", mock.Anything, &projectID).Return(loopback, nil)
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("List", mock.Anything, mock.MatchedBy(func(f domain.JobFilter) bool { return f.Offset == 0 })).Return(jobs[:5], len(jobs), nil)
+	jobRepo.On("List", mock.Anything, mock.MatchedBy(func(f domain.JobFilter) bool { return f.Offset == 5 })).Return(jobs[5:], len(jobs), nil)
+
+	uc := usecase.NewSuggestionUsecase(jobRepo, wordlistRepo)
+	suggestions, err := uc.GetProjectSuggestions(context.Background(), projectID)
+	require.NoError(t, err)
+
+	assert.Equal(t, projectID, suggestions.ProjectID)
+	assert.Equal(t, 4, suggestions.Cracked)
+
+	require.Len(t, suggestions.Masks, 2)
+	assert.Equal(t, domain.MaskSuggestion{Mask: "?u?l?l?l?l?l?d?d", Count: 3, Share: 0.75, Keyspace: 26 * 26 * 26 * 26 * 26 * 26 * 10 * 10}, suggestions.Masks[0])
+	assert.Equal(t, "?l?l?l?l?l?l?d", suggestions.Masks[1].Mask)
+
+	require.Len(t, suggestions.Rules, 2)
+	assert.Equal(t, domain.RuleSuggestion{Rules: bestRules, Jobs: 2, Cracked: 1, SuccessRate: 0.5}, suggestions.Rules[0])
+	assert.Equal(t, domain.RuleSuggestion{Rules: weakRules, Jobs: 3, Cracked: 1, SuccessRate: 1.0 / 3}, suggestions.Rules[1])
+
+	// The cracked passwords with the best rules come first, then the masks
+	require.Len(t, suggestions.Attacks, 3)
+	assert.Equal(t, domain.AttackModeStraight, suggestions.Attacks[0].AttackMode)
+	assert.Equal(t, &loopback.ID, suggestions.Attacks[0].WordlistID)
+	assert.Equal(t, bestRules, suggestions.Attacks[0].Rules)
+	assert.Equal(t, domain.AttackModeBruteForce, suggestions.Attacks[1].AttackMode)
+	assert.Equal(t, "?u?l?l?l?l?l?d?d", suggestions.Attacks[1].Wordlist)
+	for _, attack := range suggestions.Attacks {
+		assert.NoError(t, domain.ValidateHashcatParams(0, attack.AttackMode, attack.Wordlist, attack.Rules))
+	}
+}
+
+func TestSuggestionUsecase_GetProjectSuggestions_NothingCracked(t *testing.T) {
+	projectID := uuid.New()
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetLoopback", mock.Anything, &projectID).Return(nil, nil)
+	jobRepo := new(MockJobRepository)
+	jobRepo.On("List", mock.Anything, mock.Anything).Return([]domain.Job{}, 0, nil)
+
+	uc := usecase.NewSuggestionUsecase(jobRepo, wordlistRepo)
+	suggestions, err := uc.GetProjectSuggestions(context.Background(), projectID)
+	require.NoError(t, err)
+
+	assert.Zero(t, suggestions.Cracked)
+	assert.Empty(t, suggestions.Masks)
+	assert.Empty(t, suggestions.Rules)
+	assert.Empty(t, suggestions.Attacks)
+}
@@ -0,0 +1,174 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTenantRepository is a mock implementation of domain.TenantRepository
+type MockTenantRepository struct {
+	mock.Mock
+}
+
+func (m *MockTenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	args := m.Called(ctx, tenant)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tenant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) GetByName(ctx context.Context, name string) (*domain.Tenant, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) GetAll(ctx context.Context) ([]domain.Tenant, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) Update(ctx context.Context, tenant *domain.Tenant) error {
+	args := m.Called(ctx, tenant)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) CountResources(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTenantRepository) GetStats(ctx context.Context, id uuid.UUID) (*domain.TenantStats, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TenantStats), args.Error(1)
+}
+
+func TestTenantUsecase_CreateTenant(t *testing.T) {
+	t.Run("creates the tenant", func(t *testing.T) {
+		repo := new(MockTenantRepository)
+		repo.On("GetByName", mock.Anything, "Acme").Return(nil, &domain.NotFoundError{Entity: "tenant"})
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(tenant *domain.Tenant) bool { return tenant.Name == "Acme" })).Return(nil)
+		uc := usecase.NewTenantUsecase(repo, new(MockUserRepository), new(MockAgentRepository), new(MockQuotaRepository))
+
+		tenant, err := uc.CreateTenant(context.Background(), &domain.CreateTenantRequest{Name: "  Acme "})
+		require.NoError(t, err)
+		assert.Equal(t, "Acme", tenant.Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("name taken", func(t *testing.T) {
+		repo := new(MockTenantRepository)
+		repo.On("GetByName", mock.Anything, "Acme").Return(&domain.Tenant{ID: uuid.New(), Name: "acme"}, nil)
+		uc := usecase.NewTenantUsecase(repo, new(MockUserRepository), new(MockAgentRepository), new(MockQuotaRepository))
+
+		_, err := uc.CreateTenant(context.Background(), &domain.CreateTenantRequest{Name: "Acme"})
+		assert.True(t, domain.IsValidationError(err))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTenantUsecase_DeleteTenant(t *testing.T) {
+	tenantID := uuid.New()
+
+	t.Run("deletes an empty tenant", func(t *testing.T) {
+		repo := new(MockTenantRepository)
+		repo.On("GetByID", mock.Anything, tenantID).Return(&domain.Tenant{ID: tenantID, Name: "Acme"}, nil)
+		repo.On("CountResources", mock.Anything, tenantID).Return(0, nil)
+		repo.On("Delete", mock.Anything, tenantID).Return(nil)
+		uc := usecase.NewTenantUsecase(repo, new(MockUserRepository), new(MockAgentRepository), new(MockQuotaRepository))
+
+		require.NoError(t, uc.DeleteTenant(context.Background(), tenantID))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refuses a tenant with resources", func(t *testing.T) {
+		repo := new(MockTenantRepository)
+		repo.On("GetByID", mock.Anything, tenantID).Return(&domain.Tenant{ID: tenantID, Name: "Acme"}, nil)
+		repo.On("CountResources", mock.Anything, tenantID).Return(3, nil)
+		uc := usecase.NewTenantUsecase(repo, new(MockUserRepository), new(MockAgentRepository), new(MockQuotaRepository))
+
+		assert.True(t, domain.IsValidationError(uc.DeleteTenant(context.Background(), tenantID)))
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTenantUsecase_Members(t *testing.T) {
+	tenantID, otherID := uuid.New(), uuid.New()
+	userID, agentID := uuid.New(), uuid.New()
+
+	t.Run("adds a user", func(t *testing.T) {
+		repo, userRepo := new(MockTenantRepository), new(MockUserRepository)
+		repo.On("GetByID", mock.Anything, tenantID).Return(&domain.Tenant{ID: tenantID}, nil)
+		userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID}, nil)
+		userRepo.On("SetTenant", mock.Anything, userID, &tenantID).Return(nil)
+		uc := usecase.NewTenantUsecase(repo, userRepo, new(MockAgentRepository), new(MockQuotaRepository))
+
+		require.NoError(t, uc.AddUser(context.Background(), tenantID, userID))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		repo, userRepo := new(MockTenantRepository), new(MockUserRepository)
+		repo.On("GetByID", mock.Anything, tenantID).Return(&domain.Tenant{ID: tenantID}, nil)
+		userRepo.On("GetByID", mock.Anything, userID).Return(nil, &domain.UserNotFoundError{Username: userID.String()})
+		uc := usecase.NewTenantUsecase(repo, userRepo, new(MockAgentRepository), new(MockQuotaRepository))
+
+		assert.True(t, domain.IsNotFoundError(uc.AddUser(context.Background(), tenantID, userID)))
+	})
+
+	t.Run("only removes agents of the tenant", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, TenantID: &otherID}, nil)
+		uc := usecase.NewTenantUsecase(new(MockTenantRepository), new(MockUserRepository), agentRepo, new(MockQuotaRepository))
+
+		assert.True(t, domain.IsNotFoundError(uc.RemoveAgent(context.Background(), tenantID, agentID)))
+		agentRepo.AssertNotCalled(t, "SetTenant", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("shares a removed agent", func(t *testing.T) {
+		agentRepo := new(MockAgentRepository)
+		agentRepo.On("GetByID", mock.Anything, agentID).Return(&domain.Agent{ID: agentID, TenantID: &tenantID}, nil)
+		agentRepo.On("SetTenant", mock.Anything, agentID, (*uuid.UUID)(nil)).Return(nil)
+		uc := usecase.NewTenantUsecase(new(MockTenantRepository), new(MockUserRepository), agentRepo, new(MockQuotaRepository))
+
+		require.NoError(t, uc.RemoveAgent(context.Background(), tenantID, agentID))
+		agentRepo.AssertExpectations(t)
+	})
+}
+
+func TestAgent_ServesTenant(t *testing.T) {
+	tenantID, otherID := uuid.New(), uuid.New()
+
+	shared := &domain.Agent{}
+	assert.True(t, shared.ServesTenant(nil))
+	assert.True(t, shared.ServesTenant(&tenantID))
+
+	dedicated := &domain.Agent{TenantID: &tenantID}
+	assert.True(t, dedicated.ServesTenant(&tenantID))
+	assert.False(t, dedicated.ServesTenant(&otherID))
+	assert.False(t, dedicated.ServesTenant(nil))
+}
@@ -0,0 +1,220 @@
+package usecase_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go-distributed-hashcat/internal/domain"
+	"go-distributed-hashcat/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWordlistSourceRepository keeps sources in memory; fetches run in
+// the background, so it is safe for concurrent use
+type memoryWordlistSourceRepository struct {
+	mu      sync.Mutex
+	sources map[uuid.UUID]domain.WordlistSource
+}
+
+func (r *memoryWordlistSourceRepository) Create(ctx context.Context, source *domain.WordlistSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	source.ID = uuid.New()
+	r.sources[source.ID] = *source
+	return nil
+}
+
+func (r *memoryWordlistSourceRepository) Update(ctx context.Context, source *domain.WordlistSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sources[source.ID]; !ok {
+		return &domain.NotFoundError{Entity: "wordlist source"}
+	}
+	r.sources[source.ID] = *source
+	return nil
+}
+
+func (r *memoryWordlistSourceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.WordlistSource, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	source, ok := r.sources[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: "wordlist source"}
+	}
+	return &source, nil
+}
+
+func (r *memoryWordlistSourceRepository) GetAll(ctx context.Context) ([]domain.WordlistSource, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sources := []domain.WordlistSource{}
+	for _, source := range r.sources {
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func (r *memoryWordlistSourceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sources, id)
+	return nil
+}
+
+func (r *memoryWordlistSourceRepository) FailFetching(ctx context.Context, message string) (int, error) {
+	return 0, nil
+}
+
+// waitForFetch returns the source once its fetch has finished
+func waitForFetch(t *testing.T, sources usecase.WordlistSourceUsecase, id uuid.UUID) *domain.WordlistSource {
+	var fetched *domain.WordlistSource
+	require.Eventually(t, func() bool {
+		got, err := sources.GetSource(context.Background(), id)
+		require.NoError(t, err)
+		fetched = got
+		return got.Status == domain.WordlistSourceReady || got.Status == domain.WordlistSourceFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return fetched
+}
+
+func gzipped(t *testing.T, content string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestWordlistSourceUsecase_FetchSource(t *testing.T) {
+	download := gzipped(t, "123456\npassword\n\nletmein\n")
+	sum := sha256.Sum256(download)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(download)
+	}))
+	defer server.Close()
+
+	wordlistRepo := new(MockWordlistRepository)
+	wordlistRepo.On("GetBySHA256", mock.Anything, mock.Anything).Return(nil, &domain.NotFoundError{Entity: "wordlist"})
+	var stored *domain.Wordlist
+	wordlistRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.Wordlist)
+	}).Return(nil).Once()
+	wordlistRepo.On("GetByID", mock.Anything, mock.Anything).Return(&domain.Wordlist{}, nil)
+
+	sources := usecase.NewWordlistSourceUsecase(&memoryWordlistSourceRepository{sources: map[uuid.UUID]domain.WordlistSource{}},
+		usecase.NewWordlistUsecase(wordlistRepo, t.TempDir()), 0)
+	t.Cleanup(sources.Stop)
+
+	source, err := sources.CreateSource(context.Background(), &domain.CreateWordlistSourceRequest{
+		URL:    server.URL + "/lists/rockyou.txt.gz",
+		SHA256: hex.EncodeToString(sum[:]),
+		Fetch:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rockyou.txt", source.Name)
+	assert.Equal(t, domain.WordlistSourceFetching, source.Status)
+
+	// The download is decompressed and indexed like an upload
+	source = waitForFetch(t, sources, source.ID)
+	require.Equal(t, domain.WordlistSourceReady, source.Status, source.Error)
+	require.NotNil(t, stored)
+	assert.Equal(t, &stored.ID, source.WordlistID)
+	assert.Equal(t, hex.EncodeToString(sum[:]), source.FetchedSHA256)
+	assert.Equal(t, "rockyou.txt", stored.OrigName)
+	assert.Equal(t, int64(3), *stored.WordCount)
+	content, err := os.ReadFile(stored.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "123456\npassword\nletmein\n", string(content))
+
+	// An unchanged download keeps the wordlist it already has
+	_, err = sources.FetchSource(context.Background(), source.ID)
+	require.NoError(t, err)
+	source = waitForFetch(t, sources, source.ID)
+	require.Equal(t, domain.WordlistSourceReady, source.Status, source.Error)
+	assert.Equal(t, &stored.ID, source.WordlistID)
+	assert.Equal(t, 2, requests)
+	wordlistRepo.AssertExpectations(t)
+}
+
+func TestWordlistSourceUsecase_ChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered\n"))
+	}))
+	defer server.Close()
+
+	wordlistRepo := new(MockWordlistRepository)
+	sources := usecase.NewWordlistSourceUsecase(&memoryWordlistSourceRepository{sources: map[uuid.UUID]domain.WordlistSource{}},
+		usecase.NewWordlistUsecase(wordlistRepo, t.TempDir()), 0)
+	t.Cleanup(sources.Stop)
+
+	source, err := sources.CreateSource(context.Background(), &domain.CreateWordlistSourceRequest{
+		Name:   "common.txt",
+		URL:    server.URL + "/common.txt",
+		SHA256: "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855",
+		Fetch:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", source.SHA256)
+
+	// Nothing is stored from a download that doesn't match its checksum
+	source = waitForFetch(t, sources, source.ID)
+	assert.Equal(t, domain.WordlistSourceFailed, source.Status)
+	assert.Contains(t, source.Error, "checksum mismatch")
+	assert.Nil(t, source.WordlistID)
+	require.NotNil(t, source.FetchedAt)
+	wordlistRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestWordlistSourceUsecase_CreateSource(t *testing.T) {
+	sources := usecase.NewWordlistSourceUsecase(&memoryWordlistSourceRepository{sources: map[uuid.UUID]domain.WordlistSource{}},
+		usecase.NewWordlistUsecase(new(MockWordlistRepository), t.TempDir()), 0)
+	t.Cleanup(sources.Stop)
+
+	tests := []struct {
+		req   domain.CreateWordlistSourceRequest
+		field string
+	}{
+		{domain.CreateWordlistSourceRequest{URL: "ftp://mirror.example/rockyou.txt"}, "url"},
+		{domain.CreateWordlistSourceRequest{URL: "rockyou.txt"}, "url"},
+		{domain.CreateWordlistSourceRequest{URL: "https://mirror.example/rockyou.txt", SHA256: "abc"}, "sha256"},
+		{domain.CreateWordlistSourceRequest{URL: "https://mirror.example/rockyou.txt", RefreshHours: -1}, "refresh_hours"},
+	}
+	for _, tt := range tests {
+		_, err := sources.CreateSource(context.Background(), &tt.req)
+		var validationErr *domain.ValidationError
+		require.ErrorAs(t, err, &validationErr, tt.req.URL)
+		assert.Equal(t, tt.field, validationErr.Field)
+	}
+
+	source, err := sources.CreateSource(context.Background(), &domain.CreateWordlistSourceRequest{URL: "https://mirror.example/", RefreshHours: 24})
+	require.NoError(t, err)
+	assert.Equal(t, "wordlist.txt", source.Name)
+	assert.Equal(t, domain.WordlistSourcePending, source.Status)
+}
+
+func TestWordlistSource_Due(t *testing.T) {
+	now := time.Now()
+	fetchedAt := now.Add(-25 * time.Hour)
+
+	assert.False(t, (&domain.WordlistSource{}).Due(now), "on demand only")
+	assert.True(t, (&domain.WordlistSource{RefreshHours: 24}).Due(now), "never fetched")
+	assert.True(t, (&domain.WordlistSource{RefreshHours: 24, FetchedAt: &fetchedAt}).Due(now))
+	assert.False(t, (&domain.WordlistSource{RefreshHours: 48, FetchedAt: &fetchedAt}).Due(now))
+	assert.False(t, (&domain.WordlistSource{RefreshHours: 24, FetchedAt: &fetchedAt, Status: domain.WordlistSourceFetching}).Due(now))
+}
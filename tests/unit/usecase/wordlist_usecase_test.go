@@ -2,7 +2,11 @@ package usecase_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -32,16 +36,42 @@ func (m *MockWordlistRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistRepository) GetBySHA256(ctx context.Context, sum string) (*domain.Wordlist, error) {
+	args := m.Called(ctx, sum)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistRepository) GetAll(ctx context.Context) ([]domain.Wordlist, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.Wordlist), args.Error(1)
 }
 
+func (m *MockWordlistRepository) GetByProject(ctx context.Context, projectID uuid.UUID) ([]domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]domain.Wordlist), args.Error(1)
+}
+
 func (m *MockWordlistRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockWordlistRepository) GetLoopback(ctx context.Context, projectID *uuid.UUID) (*domain.Wordlist, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wordlist), args.Error(1)
+}
+
+func (m *MockWordlistRepository) UpdateContent(ctx context.Context, wordlist *domain.Wordlist) error {
+	args := m.Called(ctx, wordlist)
+	return args.Error(0)
+}
+
 func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -55,6 +85,7 @@ func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 			filename:    "rockyou.txt",
 			fileContent: "password\n123456\nadmin\ntest",
 			mockSetup: func(repo *MockWordlistRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("wordlist not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Wordlist")).Return(nil)
 			},
 			expectedError: false,
@@ -64,6 +95,7 @@ func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 			filename:    "test.txt",
 			fileContent: "password1\npassword2",
 			mockSetup: func(repo *MockWordlistRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("wordlist not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Wordlist")).Return(errors.New("database error"))
 			},
 			expectedError: true,
@@ -73,6 +105,7 @@ func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 			filename:    "empty.txt",
 			fileContent: "",
 			mockSetup: func(repo *MockWordlistRepository) {
+				repo.On("GetBySHA256", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("wordlist not found"))
 				repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Wordlist")).Return(nil)
 			},
 			expectedError: false,
@@ -87,7 +120,7 @@ func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 			usecase := usecase.NewWordlistUsecase(mockRepo, "/tmp/wordlists")
 			ctx := context.Background()
 
-			wordlist, err := usecase.UploadWordlist(ctx, tt.filename, strings.NewReader(tt.fileContent), int64(len(tt.fileContent)))
+			wordlist, err := usecase.UploadWordlist(ctx, tt.filename, strings.NewReader(tt.fileContent), int64(len(tt.fileContent)), nil)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -104,6 +137,40 @@ func TestWordlistUsecase_UploadWordlist(t *testing.T) {
 	}
 }
 
+func TestWordlistUsecase_UploadWordlist_Duplicate(t *testing.T) {
+	uploadDir := t.TempDir()
+	existingPath := filepath.Join(uploadDir, "existing.txt")
+	assert.NoError(t, os.WriteFile(existingPath, []byte("password\n123456\n"), 0644))
+
+	// SHA-256 of the normalized content "password\n123456\n"
+	sum := sha256.Sum256([]byte("password\n123456\n"))
+	existing := &domain.Wordlist{
+		ID:       uuid.New(),
+		Name:     "existing.txt",
+		OrigName: "rockyou.txt",
+		Path:     existingPath,
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+
+	mockRepo := new(MockWordlistRepository)
+	mockRepo.On("GetBySHA256", mock.Anything, existing.SHA256).Return(existing, nil)
+
+	uc := usecase.NewWordlistUsecase(mockRepo, uploadDir)
+	wordlist, err := uc.UploadWordlist(context.Background(), "copy.txt", strings.NewReader("  password\n\n123456"), 20, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, wordlist.ID)
+	assert.True(t, wordlist.Duplicate)
+
+	// The freshly written copy must not be left behind
+	entries, err := os.ReadDir(filepath.Join(uploadDir, "wordlists"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestWordlistUsecase_GetWordlist(t *testing.T) {
 	wordlistID := uuid.New()
 	expectedWordlist := &domain.Wordlist{
@@ -298,3 +365,58 @@ func TestWordlistUsecase_DeleteWordlist(t *testing.T) {
 		})
 	}
 }
+
+func TestWordlistUsecase_AddCrackedPasswords(t *testing.T) {
+	uploadDir := t.TempDir()
+	projectID := uuid.New()
+
+	var created *domain.Wordlist
+	mockRepo := new(MockWordlistRepository)
+	mockRepo.On("GetLoopback", mock.Anything, &projectID).Return(nil, nil).Once()
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Wordlist")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Wordlist)
+	}).Return(nil)
+
+	uc := usecase.NewWordlistUsecase(mockRepo, uploadDir)
+	ctx := context.Background()
+
+	// The first cracked password creates the project's loopback wordlist
+	assert.NoError(t, uc.AddCrackedPasswords(ctx, &projectID, "Summer2024!", "Summer2024!", "bad\nline", ""))
+	if assert.NotNil(t, created) {
+		assert.True(t, created.Dynamic)
+		assert.Equal(t, "loopback.txt", created.OrigName)
+		assert.Equal(t, &projectID, created.ProjectID)
+		assert.Equal(t, int64(1), *created.WordCount)
+	}
+	content, err := os.ReadFile(created.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Summer2024!\n", string(content))
+
+	// Later ones are appended once and the checksum follows the content
+	mockRepo.On("GetLoopback", mock.Anything, &projectID).Return(created, nil)
+	mockRepo.On("UpdateContent", mock.Anything, created).Return(nil).Once()
+	assert.NoError(t, uc.AddCrackedPasswords(ctx, &projectID, "hunter2", "Summer2024!"))
+
+	content, err = os.ReadFile(created.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Summer2024!\nhunter2\n", string(content))
+	sum := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), created.SHA256)
+	assert.Equal(t, int64(len(content)), created.Size)
+	assert.Equal(t, int64(2), *created.WordCount)
+
+	// Nothing new, nothing written
+	assert.NoError(t, uc.AddCrackedPasswords(ctx, &projectID, "hunter2"))
+	mockRepo.AssertNumberOfCalls(t, "UpdateContent", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWordlistUsecase_GetLoopbackWordlist_None(t *testing.T) {
+	mockRepo := new(MockWordlistRepository)
+	mockRepo.On("GetLoopback", mock.Anything, (*uuid.UUID)(nil)).Return(nil, nil)
+
+	uc := usecase.NewWordlistUsecase(mockRepo, t.TempDir())
+	_, err := uc.GetLoopbackWordlist(context.Background(), nil)
+
+	assert.True(t, domain.IsNotFoundError(err))
+}